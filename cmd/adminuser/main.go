@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/mongox"
+)
+
+// adminuser creates an admin user who logs in at POST /admin/login
+// The password is read from ADMIN_PASSWORD, or from the first line of standard input
+func main() {
+	username := flag.String("username", "", "login name of the admin user")
+	roleList := flag.String("roles", admin.RoleViewer, "comma-separated roles: viewer, editor, operator")
+	flag.Parse()
+
+	roles, err := admin.ParseRoles(*roleList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintf(os.Stderr, "Error reading password: %v\n", err)
+			os.Exit(1)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	user, err := admin.NewUser(*username, password, roles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	repo := admin.NewMongoRepository(mongox.MustConnect(cfg.MongoURI, "acai"))
+	if err := repo.CreateUser(context.Background(), user); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", user.Username, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Created admin user %s with roles %s\n", user.Username, strings.Join(user.Roles, ", "))
+}
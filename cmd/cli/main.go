@@ -91,6 +91,9 @@ func main() {
 				fmt.Println()
 
 				cid = out.GetConversationId()
+				if out.GetGreeting() != "" {
+					fmt.Printf("ASSISTANT:\n%s\n\n", out.GetGreeting())
+				}
 				fmt.Printf("ASSISTANT:\n%s\n\n", out.GetReply())
 				continue
 			}
@@ -105,6 +108,9 @@ func main() {
 				os.Exit(1)
 			}
 
+			if out.GetGreeting() != "" {
+				fmt.Printf("ASSISTANT:\n%s\n\n", out.GetGreeting())
+			}
 			fmt.Printf("ASSISTANT:\n%s\n\n", out.GetReply())
 		}
 
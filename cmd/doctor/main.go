@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/doctor"
+	"github.com/8adimka/Go_AI_Assistant/internal/weather"
+	"github.com/openai/openai-go/option"
+)
+
+// doctor checks the configuration and every external dependency of the server and prints a report
+// It exits with status 1 when the server cannot run as configured
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each check")
+	flag.Parse()
+
+	cfg := config.Load()
+	httpClient := &http.Client{Timeout: *timeout}
+	// The key is checked in the organization and project replies are billed to
+	var openAIOpts []option.RequestOption
+	if cfg.OpenAIOrganization != "" {
+		openAIOpts = append(openAIOpts, option.WithOrganization(cfg.OpenAIOrganization))
+	}
+	if cfg.OpenAIProject != "" {
+		openAIOpts = append(openAIOpts, option.WithProject(cfg.OpenAIProject))
+	}
+
+	checks := []doctor.Check{
+		doctor.ConfigCheck(cfg),
+		doctor.MongoCheck(cfg.MongoURI, "acai", "tech_challenge"),
+		doctor.RedisCheck(cfg.RedisAddr),
+		doctor.OpenAICheck(cfg.OpenAIApiKey, []string{
+			cfg.OpenAIModel, cfg.SummaryModel, cfg.SentimentModel, cfg.InjectionClassifierModel,
+		}, openAIOpts...),
+		doctor.WeatherCheck(cfg.WeatherApiKey, weather.WeatherAPIBaseURL, httpClient),
+		doctor.HolidaysCheck(cfg.HolidayCalendarLink, httpClient),
+	}
+
+	results := doctor.Run(context.Background(), checks, *timeout)
+	if err := doctor.WriteReport(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if doctor.Failed(results) {
+		os.Exit(1)
+	}
+}
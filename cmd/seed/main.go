@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/assistant"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/mongox"
+	"github.com/8adimka/Go_AI_Assistant/internal/seed"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// seed populates MongoDB with demo users, prompts, personas and conversations answered by the mock assistant,
+// so demo and load test environments have data without calling OpenAI
+func main() {
+	users := flag.Int("users", 20, "number of demo users")
+	conversations := flag.Int("conversations", 3, "conversations per user")
+	days := flag.Int("days", 30, "conversations start within the last days")
+	randomSeed := flag.Uint64("seed", 1, "random seed; the same seed generates the same data")
+	tenantID := flag.String("tenant", tenant.DefaultID, "tenant that owns the demo data")
+	reset := flag.Bool("reset", false, "delete previously seeded conversations first")
+	flag.Parse()
+
+	if !tenant.ValidID(*tenantID) {
+		fmt.Fprintf(os.Stderr, "Error: invalid tenant ID %q\n", *tenantID)
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	ctx := tenant.WithTenant(context.Background(), *tenantID)
+
+	db := mongox.MustConnect(cfg.MongoURI, "acai")
+	seeder := seed.NewSeeder(
+		model.New(db),
+		settings.NewMongoRepository(db),
+		seed.NewMongoPromptStore(mongox.MustConnect(cfg.MongoURI, "tech_challenge")),
+		assistant.NewMock(),
+		seed.Config{
+			Users:                *users,
+			ConversationsPerUser: *conversations,
+			Days:                 *days,
+			Seed:                 *randomSeed,
+		},
+	)
+
+	if *reset {
+		deleted, err := seeder.Reset(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resetting demo data: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted %d demo conversations\n", deleted)
+	}
+
+	summary, err := seeder.Seed(ctx, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error seeding demo data: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Seeded %d prompts, %d users and %d conversations with %d messages for tenant %s\n",
+		summary.Prompts, summary.Users, summary.Conversations, summary.Messages, *tenantID)
+}
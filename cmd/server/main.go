@@ -2,28 +2,76 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/abuse"
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/attachment"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/blocklist"
+	"github.com/8adimka/Go_AI_Assistant/internal/botguard"
+	"github.com/8adimka/Go_AI_Assistant/internal/budget"
+	"github.com/8adimka/Go_AI_Assistant/internal/bulk"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/assistant"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/injection"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/circuitbreaker"
+	"github.com/8adimka/Go_AI_Assistant/internal/coldstorage"
+	"github.com/8adimka/Go_AI_Assistant/internal/commands"
 	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/cron"
+	"github.com/8adimka/Go_AI_Assistant/internal/degradation"
+	"github.com/8adimka/Go_AI_Assistant/internal/delivery"
+	"github.com/8adimka/Go_AI_Assistant/internal/docs"
+	"github.com/8adimka/Go_AI_Assistant/internal/encryption"
+	"github.com/8adimka/Go_AI_Assistant/internal/experiment"
+	"github.com/8adimka/Go_AI_Assistant/internal/followup"
+	"github.com/8adimka/Go_AI_Assistant/internal/geoip"
+	"github.com/8adimka/Go_AI_Assistant/internal/graphql"
+	"github.com/8adimka/Go_AI_Assistant/internal/greeting"
 	"github.com/8adimka/Go_AI_Assistant/internal/health"
 	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/8adimka/Go_AI_Assistant/internal/identity"
+	"github.com/8adimka/Go_AI_Assistant/internal/inbox"
+	"github.com/8adimka/Go_AI_Assistant/internal/inflight"
+	"github.com/8adimka/Go_AI_Assistant/internal/jobs"
 	"github.com/8adimka/Go_AI_Assistant/internal/logging"
 	"github.com/8adimka/Go_AI_Assistant/internal/metrics"
+	"github.com/8adimka/Go_AI_Assistant/internal/migration"
 	"github.com/8adimka/Go_AI_Assistant/internal/mongox"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
 	"github.com/8adimka/Go_AI_Assistant/internal/otel"
 	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/postprocess"
+	"github.com/8adimka/Go_AI_Assistant/internal/quality"
 	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/8adimka/Go_AI_Assistant/internal/rest"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/rollout"
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"github.com/8adimka/Go_AI_Assistant/internal/sentiment"
 	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/stats"
+	"github.com/8adimka/Go_AI_Assistant/internal/takeout"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/internal/tlsx"
 	"github.com/8adimka/Go_AI_Assistant/internal/tokens"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
+	"github.com/8adimka/Go_AI_Assistant/internal/topics"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -59,6 +107,23 @@ func main() {
 	// Connect to Redis
 	redisClient := redisx.MustConnect(cfg.RedisAddr)
 
+	// Tenants with a storage profile keep their conversations, settings and sessions in that profile's region
+	mongoRouter := mongox.MustConnectRouter(cfg.ProfileMongoURIs(), "acai", cfg.TenantStorageProfiles)
+	redisRouter := redisx.MustConnectRouter(cfg.ProfileRedisAddrs(), cfg.TenantStorageProfiles)
+	cacheCodec, err := redisx.CodecByName(cfg.CacheCodec)
+	if err != nil {
+		secureLogger.Error("Invalid CACHE_CODEC", "error", err)
+		os.Exit(1)
+	}
+	if _, err := injection.NewDetector(cfg.InjectionMode, nil, nil); err != nil {
+		secureLogger.Error("Invalid INJECTION_MODE", "error", err)
+		os.Exit(1)
+	}
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		secureLogger.Error("CORS_ALLOW_CREDENTIALS cannot be combined with \"*\" in CORS_ALLOWED_ORIGINS")
+		os.Exit(1)
+	}
+
 	// Initialize metrics
 	meter := otel.GetMeter()
 	appMetrics, err := metrics.NewMetrics(meter)
@@ -66,6 +131,9 @@ func main() {
 		secureLogger.Error("Failed to initialize metrics", "error", err)
 		os.Exit(1)
 	}
+	redisx.SetRecorder(appMetrics)
+	// Caches skip Redis while it is failing; sessions are then recovered from MongoDB
+	redisx.SetAvailability(degradation.Shared().Health(degradation.Redis))
 
 	// Initialize global token counter for precise token counting
 	if err := tokens.InitGlobalTokenCounter(cfg.OpenAIModel); err != nil {
@@ -74,31 +142,441 @@ func main() {
 		secureLogger.Info("Global token counter initialized", "model", cfg.OpenAIModel)
 	}
 
-	repo := model.New(mongo)
-	assist := assistant.New(appMetrics)
+	// Object storage for billing exports, attachments and archived conversations
+	objectStore := mustObjectStore(cfg)
+
+	// Conversations archived to cold storage are restored when they are opened
+	coldArchive := coldstorage.NewArchive(objectstore.Prefixed(objectStore, "conversations/"))
+	repoOpts := []model.RepositoryOption{model.WithColdStorage(coldArchive), model.WithResidency(mongoRouter)}
+
+	// Tenants may encrypt their conversations with their own data keys, wrapped by the master key
+	auditLog := audit.NewMongoRepository(mongo)
+	var dataKeys *encryption.Manager
+	if cfg.TenantEncryptionMasterKey != "" {
+		kms, err := secrets.NewLocalKMS(cfg.TenantEncryptionMasterKey)
+		if err != nil {
+			secureLogger.Error("Invalid TENANT_ENCRYPTION_MASTER_KEY", "error", err)
+			os.Exit(1)
+		}
+		dataKeys = encryption.NewManager(encryption.NewMongoRepository(mongo), kms, auditLog,
+			time.Duration(cfg.TenantEncryptionCacheSeconds)*time.Second)
+		repoOpts = append(repoOpts, model.WithEncryption(dataKeys))
+	}
+	repo := model.New(mongo, repoOpts...)
+
+	// Tenants may bring their own OpenAI/Weather API keys, stored encrypted
+	var tenantKeys *tenant.KeyManager
+	usageRepo := billing.NewRepository(mongo)
+	assistOpts := []assistant.Option{assistant.WithUsageRecorder(usageRepo)}
+	if cfg.TenantCredentialsKey != "" {
+		cipher, err := secrets.NewCipherFromBase64(cfg.TenantCredentialsKey)
+		if err != nil {
+			secureLogger.Error("Invalid TENANT_CREDENTIALS_KEY", "error", err)
+			os.Exit(1)
+		}
+		tenantKeys = tenant.NewKeyManager(tenant.NewMongoCredentialRepository(mongo), cipher,
+			time.Duration(cfg.TenantCredentialsCacheSeconds)*time.Second)
+		assistOpts = append(assistOpts, assistant.WithCredentialResolver(tenantKeys))
+	}
+
+	// New system prompt versions are given to a growing share of new conversations and rolled back on breached thresholds
+	promptRollouts := rollout.NewService(rollout.NewMongoRepository(mongo),
+		rollout.ReplyMetrics{Variants: repo, Failures: usageRepo}, repo, auditLog, mustRolloutConfig(cfg))
+	assistOpts = append(assistOpts, assistant.WithPromptRollouts(promptRollouts))
+
+	// Replies can be recorded so bug reports can be reconstructed and re-run
+	var turnRepo *replay.MongoRepository
+	if cfg.ReplayRecordingEnabled {
+		turnRepo = replay.NewMongoRepository(mongo, time.Duration(cfg.ReplayRetentionDays)*24*time.Hour)
+		assistOpts = append(assistOpts, assistant.WithTurnRecorder(turnRepo))
+	}
+
+	// Tests can serve replies from recorded fixtures instead of OpenAI
+	if cfg.LLMFixturesMode != replay.FixtureModeOff {
+		fixtures, err := replay.LoadFixtures(replay.ScenarioPath(cfg.LLMFixturesDir, cfg.LLMFixturesScenario))
+		if err != nil {
+			secureLogger.Error("Failed to load LLM fixtures", "error", err)
+			os.Exit(1)
+		}
+		assistOpts = append(assistOpts, assistant.WithFixtures(fixtures, cfg.LLMFixturesMode))
+	}
+
+	assist := assistant.New(appMetrics, assistOpts...)
 
 	// Create Redis cache for session management with configurable TTL
 	sessionTTL := time.Duration(cfg.SessionTTLMinutes) * time.Minute
-	redisCache := redisx.NewCache(redisClient, sessionTTL)
+	redisCache := redisx.NewCache(redisClient, sessionTTL, redisx.WithCodec(cacheCodec), redisx.WithRouter(redisRouter))
 
 	// Create session manager
-	sessionManager := session.NewManager(redisCache, sessionTTL, repo)
+	sessionManager := session.NewManager(redisCache, sessionTTL, repo, session.WithContextClearer(assist))
+
+	// Optionally defer title generation to a background batching worker
+	var serverOpts []chat.ServerOption
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	// Replies reuse a connection to OpenAI opened ahead of them
+	go assist.KeepConnectionsWarm(workerCtx)
+	if cfg.CacheKeySampleIntervalSeconds > 0 {
+		keySampler := redisx.NewKeySampler(redisClient, appMetrics,
+			time.Duration(cfg.CacheKeySampleIntervalSeconds)*time.Second)
+		go keySampler.Run(workerCtx)
+	}
+	// Stored titles drop the cached ones, so the cache never outlives a title change
+	titleStore := assist.InvalidatingTitles(repo)
+	if cfg.TitleGenerationMode == "batch" {
+		titleBatcher := assistant.NewTitleBatcher(assist, titleStore, cfg.TitleBatchSize,
+			time.Duration(cfg.TitleBatchIntervalSeconds)*time.Second)
+		go titleBatcher.Run(workerCtx)
+		serverOpts = append(serverOpts, chat.WithTitleScheduler(titleBatcher))
+	}
+	if cfg.TitleGenerationMode == "concurrent" {
+		serverOpts = append(serverOpts, chat.WithConcurrentTitles(titleStore))
+	}
+
+	// Token usage exports per tenant, user and platform
+	billingPricing := mustBillingPricing(cfg)
+	billingExporter := billing.NewExporter(usageRepo, objectstore.Prefixed(objectStore, "billing/"), billingPricing)
+
+	// Messages pushed to users on external channels are sent in the background and tracked
+	deliveries := delivery.NewService(delivery.NewMongoRepository(mongo), delivery.Config{
+		Retry: retry.ConfigFromAppConfig(cfg),
+		Breaker: circuitbreaker.Config{
+			MaxFailures:    cfg.DeliveryBreakerMaxFailures,
+			CooldownPeriod: time.Duration(cfg.DeliveryBreakerCooldownSeconds) * time.Second,
+		},
+		QueueSize: cfg.DeliveryQueueSize,
+	})
+	if cfg.TelegramBotToken != "" {
+		deliveries.Register("telegram", delivery.NewTelegramSender(cfg.TelegramBotToken, "", nil))
+	}
+	go deliveries.Run(workerCtx)
+
+	// Recurring maintenance tasks run on the one instance holding the lease in Redis
+	instanceID := mustInstanceID()
+	cronLeader := redisx.NewElection(redisClient, "leader:cron", instanceID,
+		time.Duration(cfg.CronLeaderTTLSeconds)*time.Second)
+	scheduler := cron.NewScheduler(cronLeader, cron.NewRedisHistory(redisClient), appMetrics, cron.Config{
+		Instance:     instanceID,
+		HistoryLimit: cfg.CronHistoryLimit,
+	})
+	cronJitter := time.Duration(cfg.CronJitterSeconds) * time.Second
+
+	// Missing indexes are built in the background; features relying on them wait for their builds
+	var migrations *migration.Coordinator
+	if cfg.MigrationsEnabled {
+		migrationLeader := redisx.NewElection(redisClient, "leader:migrations", instanceID,
+			time.Duration(cfg.CronLeaderTTLSeconds)*time.Second)
+		builders := []migration.Builder{migration.NewMongoBuilder("main", mongo)}
+		for i, db := range mongoRouter.Databases() {
+			builders = append(builders, migration.NewMongoBuilder(mongoRouter.Profiles()[i], db))
+		}
+		migrations = migration.NewCoordinator(migration.NewMongoStore(mongo), migrationLeader, migration.Indexes, builders,
+			migration.Config{
+				Interval:    time.Duration(cfg.MigrationsIntervalSeconds) * time.Second,
+				MaxAttempts: cfg.MigrationsMaxAttempts,
+			})
+		go migrationLeader.Run(workerCtx)
+		go migrations.Run(workerCtx)
+	}
+	mustAddTask(scheduler, cron.Task{
+		Name:     "billing_export",
+		Schedule: cfg.CronBillingExport,
+		Enabled:  cfg.BillingExportSchedule,
+		Jitter:   cronJitter,
+		Run: func(ctx context.Context) error {
+			return billingExporter.ExportPrevious(ctx, time.Now())
+		},
+	})
+	if cfg.CronPromptWarmup != "" {
+		mustAddTask(scheduler, cron.Task{
+			Name:     "prompt_warmup",
+			Schedule: cfg.CronPromptWarmup,
+			Enabled:  true,
+			Jitter:   cronJitter,
+			Timeout:  time.Minute,
+			Run:      assist.WarmPrompts,
+		})
+	}
+	usageStats := stats.NewService(stats.NewMongoRepository(mongo), time.Duration(cfg.UsageStatsBackfillDays)*24*time.Hour)
+	if cfg.CronUsageStats != "" {
+		mustAddTask(scheduler, cron.Task{
+			Name:     "usage_stats",
+			Schedule: cfg.CronUsageStats,
+			Enabled:  true,
+			Jitter:   cronJitter,
+			Timeout:  10 * time.Minute,
+			Run: func(ctx context.Context) error {
+				return usageStats.Refresh(ctx, time.Now())
+			},
+		})
+	}
+	if cfg.CronPromptRollout != "" {
+		mustAddTask(scheduler, cron.Task{
+			Name:     "prompt_rollout",
+			Schedule: cfg.CronPromptRollout,
+			Enabled:  true,
+			Jitter:   cronJitter,
+			Timeout:  5 * time.Minute,
+			Run: func(ctx context.Context) error {
+				result, err := promptRollouts.Evaluate(ctx, time.Now())
+				if result != nil && result.Promoted > 0 {
+					// Cached prompts would keep serving the baseline version until they expire
+					err = errors.Join(err, assist.WarmPrompts(ctx))
+				}
+				return err
+			},
+		})
+	}
+	qualityScores := quality.NewService(repo, assistant.NewQualityScorer(assist, cfg.QualityModel),
+		quality.NewMongoRepository(mongo), cfg.QualitySampleSize)
+	mustAddTask(scheduler, cron.Task{
+		Name:     "quality_scores",
+		Schedule: cfg.CronQualityScores,
+		Enabled:  cfg.QualitySampleSize > 0,
+		Jitter:   cronJitter,
+		Timeout:  30 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := qualityScores.Run(ctx, time.Now())
+			return err
+		},
+	})
+	if cfg.CronSchemaUpgrade != "" {
+		mustAddTask(scheduler, cron.Task{
+			Name:     "schema_upgrade",
+			Schedule: cfg.CronSchemaUpgrade,
+			Enabled:  true,
+			Jitter:   cronJitter,
+			Timeout:  time.Hour,
+			Run: func(ctx context.Context) error {
+				_, err := repo.UpgradeConversations(ctx)
+				return err
+			},
+		})
+	}
+	if cfg.ColdStorageInactiveDays > 0 {
+		archiver := coldstorage.NewArchiver(repo, coldArchive, coldstorage.Config{
+			InactiveFor: time.Duration(cfg.ColdStorageInactiveDays) * 24 * time.Hour,
+			BatchSize:   cfg.ColdStorageBatchSize,
+		})
+		mustAddTask(scheduler, cron.Task{
+			Name:     "cold_storage",
+			Schedule: cfg.CronColdStorage,
+			Enabled:  true,
+			Jitter:   cronJitter,
+			Run: migrations.Gate(migration.FeatureColdStorage, func(ctx context.Context) error {
+				_, err := archiver.Run(ctx, time.Now())
+				return err
+			}),
+		})
+	}
+	if len(cfg.FollowUpPlatforms) > 0 {
+		followUps := followup.NewService(repo, assistant.NewFollowUpWriter(assist, cfg.FollowUpModel, cfg.FollowUpMaxTokens),
+			deliveries, followup.Config{
+				IdleFor:   time.Duration(cfg.FollowUpIdleHours) * time.Hour,
+				MaxIdle:   time.Duration(cfg.FollowUpMaxIdleHours) * time.Hour,
+				Platforms: mustFollowUpPlatforms(cfg),
+			})
+		mustAddTask(scheduler, cron.Task{
+			Name:     "follow_ups",
+			Schedule: cfg.CronFollowUps,
+			Enabled:  true,
+			Jitter:   cronJitter,
+			Timeout:  30 * time.Minute,
+			Run: migrations.Gate(migration.FeatureFollowUps, func(ctx context.Context) error {
+				_, err := followUps.Run(ctx, time.Now())
+				return err
+			}),
+		})
+	}
+	if cfg.CronEnabled {
+		go cronLeader.Run(workerCtx)
+		go scheduler.Run(workerCtx)
+	}
+
+	// Files uploaded to conversations are checked against the policy and kept in object storage
+	attachments := attachment.NewService(objectstore.Prefixed(objectStore, "attachments/"),
+		attachment.NewMongoRepository(mongo), attachment.Policy{
+			MaxBytes:     cfg.AttachmentMaxBytes,
+			AllowedTypes: cfg.AttachmentAllowedTypes,
+		})
+	serverOpts = append(serverOpts, chat.WithAttachments(attachments))
+
+	if turnRepo != nil {
+		serverOpts = append(serverOpts, chat.WithReplay(replay.NewService(turnRepo, assist)))
+	}
+
+	// Background jobs are queued in Redis and shared by all instances
+	jobQueue := jobs.NewQueue("default", jobs.NewRedisStore(redisClient, "default"), jobs.Config{
+		Workers:         cfg.JobWorkers,
+		MaxAttempts:     cfg.JobMaxAttempts,
+		BaseBackoff:     time.Duration(cfg.JobBackoffSeconds) * time.Second,
+		MaxBackoff:      time.Duration(cfg.JobMaxBackoffSeconds) * time.Second,
+		PollInterval:    time.Duration(cfg.JobPollIntervalMillis) * time.Millisecond,
+		Timeout:         time.Duration(cfg.JobTimeoutSeconds) * time.Second,
+		DeadLetterLimit: cfg.JobDeadLetterLimit,
+	}, appMetrics)
+	if cfg.JobWorkers > 0 {
+		go jobQueue.Run(workerCtx)
+	}
 
-	server := chat.NewServer(repo, assist, sessionManager)
+	// People using several platforms can link their identities, so per-user data follows them
+	var identities *identity.Service
+	if cfg.IdentityLinkingEnabled {
+		linkCodes := redisx.NewCache(redisClient, time.Duration(cfg.IdentityLinkCodeTTLMinutes)*time.Minute)
+		identities = identity.NewService(identity.NewMongoRepository(mongo), linkCodes)
+		serverOpts = append(serverOpts, chat.WithIdentityResolver(identities))
+	}
+
+	// Users can export their data; archives are generated in the background and linked with signed URLs
+	var takeoutService *takeout.Service
+	if cfg.TakeoutSigningKey != "" {
+		links, err := takeout.NewSigner(cfg.PublicBaseURL, cfg.TakeoutSigningKey)
+		if err != nil {
+			secureLogger.Error("Invalid data export configuration", "error", err)
+			os.Exit(1)
+		}
+
+		takeoutService = takeout.NewService(takeout.NewMongoRepository(mongo),
+			objectstore.Prefixed(objectStore, "takeout/"), links, takeout.ChannelNotifier{Deliveries: deliveries},
+			takeout.Config{LinkTTL: time.Duration(cfg.TakeoutLinkTTLDays) * 24 * time.Hour},
+			takeout.ConversationSource{Conversations: userConversations(repo, identities)})
+		go takeoutService.Run(workerCtx)
+		serverOpts = append(serverOpts, chat.WithTakeout(takeoutService))
+	}
+
+	// Tenants block keywords and topics in user messages and replies
+	blocklists := blocklist.NewService(blocklist.NewMongoRepository(mongo), redisCache, cfg.BlocklistCategories)
+	serverOpts = append(serverOpts, chat.WithContentPolicy(blocklists))
+
+	// Replies are filtered per platform before they are stored and returned
+	if len(cfg.ReplyFilters) > 0 {
+		serverOpts = append(serverOpts, chat.WithReplyProcessor(mustReplyPipeline(cfg,
+			assistant.NewReplyTranslator(assist, cfg.TranslationModel), blocklists)))
+	}
+
+	// New conversations and sessions open with the greeting configured for their platform
+	if greeter := mustGreeter(cfg); greeter != nil {
+		serverOpts = append(serverOpts, chat.WithGreeter(greeter))
+	}
+
+	// Bot platforms get slash commands answered without calling the model
+	if len(cfg.CommandPlatforms) > 0 {
+		commandRegistry := commands.NewRegistry()
+		commands.RegisterBuiltins(commandRegistry, cfg.CommandPlatforms, cfg.CommandPersonas)
+		if identities != nil {
+			commands.RegisterLink(commandRegistry, cfg.CommandPlatforms, identities)
+		}
+		serverOpts = append(serverOpts, chat.WithCommands(commands.NewRouter(commandRegistry, repo, sessionManager)))
+	}
+
+	// User preferences shape replies and tool output
+	userSettings := settings.NewService(settings.NewMongoRepository(mongo, settings.WithResidency(mongoRouter)), redisCache)
+	serverOpts = append(serverOpts, chat.WithUserSettings(userSettings))
+
+	// Reactions to replies feed the quality metrics
+	serverOpts = append(serverOpts, chat.WithReactionRecorder(appMetrics))
+	serverOpts = append(serverOpts, chat.WithExperimentRecorder(appMetrics))
+	serverOpts = append(serverOpts, chat.WithAuditLog(auditLog))
+
+	// Sampled user messages are classified to track how conversations feel
+	if cfg.SentimentEnabled {
+		sentimentTracker := sentiment.NewTracker(assistant.NewSentimentClassifier(assist, cfg.SentimentModel), repo, appMetrics,
+			sentiment.Config{
+				SampleRate:     cfg.SentimentSampleRate,
+				AlertThreshold: cfg.SentimentAlertThreshold,
+			})
+		go sentimentTracker.Run(workerCtx)
+		serverOpts = append(serverOpts, chat.WithSentimentTracker(sentimentTracker))
+	}
+
+	// Sampled user messages tag their conversations with coarse topics for analytics
+	if cfg.TopicsEnabled {
+		var classifier topics.Classifier = topics.NewKeywordClassifier(nil)
+		if cfg.TopicClassifier == "model" {
+			classifier = assistant.NewTopicClassifier(assist, cfg.TopicModel)
+		}
+		topicTagger := topics.NewTagger(classifier, repo, appMetrics, topics.Config{SampleRate: cfg.TopicSampleRate})
+		go topicTagger.Run(workerCtx)
+		serverOpts = append(serverOpts, chat.WithTopicTagger(topicTagger))
+	}
+
+	// Block platform users that abuse the service
+	abuseStore := abuse.NewRedisStore(redisClient)
+	abuseGuard := abuse.NewGuard(abuseStore, abuse.Config{
+		StrikeThreshold:   cfg.AbuseStrikeThreshold,
+		StrikeWindow:      time.Duration(cfg.AbuseStrikeWindowMinutes) * time.Minute,
+		RequestsPerMinute: cfg.AbuseRequestsPerMinute,
+		BaseBlock:         time.Duration(cfg.AbuseBaseBlockMinutes) * time.Minute,
+		MaxBlock:          time.Duration(cfg.AbuseMaxBlockHours) * time.Hour,
+	})
+	serverOpts = append(serverOpts, chat.WithAbuseGuard(abuseGuard))
+	// Stop runaway bots flooding a single conversation or chat
+	serverOpts = append(serverOpts, chat.WithMessageLimiter(abuse.NewMessageLimiter(abuseStore, appMetrics, abuse.MessageLimitConfig{
+		ConversationPerMinute: cfg.ConversationMessagesPerMinute,
+		SessionPerMinute:      cfg.SessionMessagesPerMinute,
+	})))
+	serverOpts = append(serverOpts, chat.WithMaxMessageTokens(cfg.MaxMessageTokens))
+	if cfg.LongInputChunking {
+		// Long messages are condensed chunk by chunk by the assistant, up to the chunk cap
+		serverOpts = append(serverOpts, chat.WithChunkedInput(cfg.LongInputChunkTokens*cfg.LongInputMaxChunks))
+	}
+
+	// Messages sent while a reply is being generated wait for it or are merged into one reply
+	serverOpts = append(serverOpts, chat.WithReplyGuard(inflight.NewGuard(mustConcurrencyPolicies(cfg))))
+
+	server := chat.NewServer(repo, assist, sessionManager, serverOpts...)
 
 	// Initialize rate limiter with configuration
-	rateLimiter := httpx.NewRateLimiter(cfg.APIRateLimitRPS, cfg.APIRateLimitBurst)
+	rateLimiter := httpx.NewRateLimiter(cfg.APIRateLimitRPS, cfg.APIRateLimitBurst,
+		httpx.WithMaxKeys(cfg.RateLimitMaxClients), httpx.WithKeyRecorder("ip", appMetrics))
+
+	// CORS for browser clients
+	cors := httpx.NewCORS(httpx.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		ExposedHeaders:   []string{"Retry-After"},
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAgeSeconds:    cfg.CORSMaxAgeSeconds,
+	})
+
+	// IP range and country rules, checked before anything else spends work on the request
+	accessControl := mustAccessControl(cfg, appMetrics)
+
+	// Resolve the tenant of each request; data, caches and metrics are scoped to it
+	tenantResolver := tenant.NewResolver(cfg.TenantIDs, cfg.TenantAccessKeys)
+
+	// Clients may bound how long they wait for a reply; part of each budget is kept back to respond
+	requestBudget := budget.Middleware(time.Duration(cfg.RequestBudgetMaxMs)*time.Millisecond,
+		time.Duration(cfg.RequestBudgetReserveMs)*time.Millisecond)
 
 	// Configure handler
 	handler := mux.NewRouter()
 	handler.Use(
-		rateLimiter.Middleware(), // Rate limiting first!
+		cors.Middleware(),           // Answer preflight requests before rate limiting and auth
+		accessControl.Middleware(),  // Blocked networks and countries never reach rate limiting
+		tenantResolver.Middleware(), // Tenant is needed by quotas and metrics labels
+		rateLimiter.Middleware(),    // Rate limiting before metrics and handlers
+		requestBudget,               // Deadlines of replies derive from the client's X-Request-Budget-Ms
 		appMetrics.HTTPMetricsMiddleware(),
 		httpx.OTelMiddleware(),
 		httpx.Logger(),
 		httpx.Recovery(),
 	)
 
+	// Tenant API keys are resolved once per request and used by the assistant and tools
+	if tenantKeys != nil {
+		handler.Use(tenantKeys.Middleware())
+	}
+
+	// Per-tenant request quota
+	if cfg.TenantRateLimitRPS > 0 {
+		tenantLimiter := httpx.NewRateLimiter(cfg.TenantRateLimitRPS, cfg.TenantRateLimitBurst,
+			httpx.WithMaxKeys(cfg.RateLimitMaxClients), httpx.WithKeyRecorder("tenant", appMetrics))
+		handler.Use(tenantLimiter.MiddlewareByKey(func(r *http.Request) string {
+			return tenant.FromContext(r.Context())
+		}))
+	}
+
 	// Health checks
 	healthChecker := health.NewHealthChecker(mongo.Client(), redisClient)
 	handler.HandleFunc("/health", healthChecker.HealthHandler)
@@ -109,9 +587,170 @@ func main() {
 	handler.Handle("/metrics", auth.Middleware()(promhttp.Handler()))
 
 	if cfg.APIKey == "" || cfg.APIKey == "changeme_in_production" {
-		secureLogger.Warn("API_KEY is not set or using default value - metrics endpoint is accessible but requires authentication")
+		secureLogger.Warn("API_KEY is not set or using default value - metrics and GraphQL endpoints are accessible but require authentication")
 	} else {
-		secureLogger.Info("Metrics endpoint protected with API key")
+		secureLogger.Info("Metrics and GraphQL endpoints protected with API key")
+	}
+
+	// Admin APIs check the roles of logged-in admin users, or accept the API key with every role
+	// Reads need any role; changes need the role given to each group and are written to the audit log
+	var adminTokens *admin.TokenIssuer
+	if cfg.AdminJWTSecret != "" {
+		adminTokens = admin.NewTokenIssuer(cfg.AdminJWTSecret, time.Duration(cfg.AdminTokenTTLMinutes)*time.Minute)
+	}
+	adminAuth := admin.NewAuthenticator(admin.NewMongoRepository(mongo), adminTokens, cfg.APIKey, auditLog)
+	handler.HandleFunc("/admin/login", adminAuth.LoginHandler).Methods(http.MethodPost)
+
+	// Admin API for blocking and unblocking users (operator role for changes)
+	abuseAdmin := abuse.NewAdminHandler(abuseGuard)
+	abuseRoutes := handler.PathPrefix("/admin/abuse").Subrouter()
+	abuseRoutes.Use(adminAuth.Require(admin.RoleOperator))
+	abuseRoutes.HandleFunc("/block", abuseAdmin.BlockHandler).Methods(http.MethodPost)
+	abuseRoutes.HandleFunc("/unblock", abuseAdmin.UnblockHandler).Methods(http.MethodPost)
+	abuseRoutes.HandleFunc("/status", abuseAdmin.StatusHandler).Methods(http.MethodGet)
+
+	// Admin API for billing exports (operator role for changes)
+	billingAdmin := billing.NewAdminHandler(billingExporter)
+	billingRoutes := handler.PathPrefix("/admin/billing").Subrouter()
+	billingRoutes.Use(adminAuth.Require(admin.RoleOperator))
+	billingRoutes.HandleFunc("/exports", billingAdmin.ExportHandler).Methods(http.MethodPost)
+	billingRoutes.HandleFunc("/exports/{name}", billingAdmin.DownloadHandler).Methods(http.MethodGet)
+
+	// Admin API for bulk conversation operations (operator role for changes)
+	bulkAdmin := bulk.NewAdminHandler(bulk.NewService(repo, bulk.NewMongoRepository(mongo), sessionManager,
+		cfg.BulkBatchSize, cfg.BulkMaxJobs))
+	bulkRoutes := handler.PathPrefix("/admin/conversations/bulk").Subrouter()
+	bulkRoutes.Use(adminAuth.Require(admin.RoleOperator))
+	bulkRoutes.HandleFunc("", bulkAdmin.StartHandler).Methods(http.MethodPost)
+	bulkRoutes.HandleFunc("/{job_id}", bulkAdmin.StatusHandler).Methods(http.MethodGet)
+
+	// Admin API for staged system prompt rollouts (operator role for changes)
+	rolloutAdmin := rollout.NewAdminHandler(promptRollouts)
+	rolloutRoutes := handler.PathPrefix("/admin/prompts/rollouts").Subrouter()
+	rolloutRoutes.Use(adminAuth.Require(admin.RoleOperator))
+	rolloutRoutes.HandleFunc("", rolloutAdmin.StartHandler).Methods(http.MethodPost)
+	rolloutRoutes.HandleFunc("", rolloutAdmin.ListHandler).Methods(http.MethodGet)
+	rolloutRoutes.HandleFunc("/{id}", rolloutAdmin.GetHandler).Methods(http.MethodGet)
+	rolloutRoutes.HandleFunc("/{id}/rollback", rolloutAdmin.RollbackHandler).Methods(http.MethodPost)
+
+	// Admin view of what the model saw on a conversation's latest turn against its stored messages
+	debugAdmin := chat.NewDebugHandler(repo, assist)
+	handler.Handle("/admin/conversations/{id}/context", adminAuth.Require()(http.HandlerFunc(debugAdmin.ContextDiffHandler))).Methods(http.MethodGet)
+
+	// Admin API adding operator notes the assistant reads with a conversation (operator role)
+	noteAdmin := chat.NewNoteHandler(server)
+	handler.Handle("/admin/conversations/{id}/notes", adminAuth.Require(admin.RoleOperator)(http.HandlerFunc(noteAdmin.InjectHandler))).Methods(http.MethodPost)
+
+	// Admin API replaying the recorded LLM exchanges of a conversation (operator role)
+	replayAdmin := chat.NewReplayHandler(server)
+	handler.Handle("/admin/conversations/{id}/replay", adminAuth.Require(admin.RoleOperator)(http.HandlerFunc(replayAdmin.TurnsHandler))).Methods(http.MethodPost)
+
+	// Admin view of the tools the model can call, with their schemas, rate limits and recent error rates
+	toolsAdmin := registry.NewAdminHandler(assist.Tools())
+	handler.Handle("/admin/tools", adminAuth.Require()(http.HandlerFunc(toolsAdmin.ListHandler))).Methods(http.MethodGet)
+
+	// Admin API for scheduled tasks and their run history (operator role for changes)
+	cronAdmin := cron.NewAdminHandler(scheduler)
+	cronRoutes := handler.PathPrefix("/admin/cron").Subrouter()
+	cronRoutes.Use(adminAuth.Require(admin.RoleOperator))
+	cronRoutes.HandleFunc("", cronAdmin.ListHandler).Methods(http.MethodGet)
+	cronRoutes.HandleFunc("/{task}/runs", cronAdmin.RunsHandler).Methods(http.MethodGet)
+	cronRoutes.HandleFunc("/{task}/run", cronAdmin.TriggerHandler).Methods(http.MethodPost)
+
+	// Admin API for background job queue state and dead letters (operator role for changes)
+	jobsAdmin := jobs.NewAdminHandler(jobQueue)
+	jobRoutes := handler.PathPrefix("/admin/jobs").Subrouter()
+	jobRoutes.Use(adminAuth.Require(admin.RoleOperator))
+	jobRoutes.HandleFunc("", jobsAdmin.StatsHandler).Methods(http.MethodGet)
+	jobRoutes.HandleFunc("/dead", jobsAdmin.DeadLettersHandler).Methods(http.MethodGet)
+	jobRoutes.HandleFunc("/dead/{job_id}/requeue", jobsAdmin.RequeueHandler).Methods(http.MethodPost)
+
+	// Admin API for outbound message delivery status (any admin role)
+	deliveryAdmin := delivery.NewAdminHandler(deliveries)
+	deliveryRoutes := handler.PathPrefix("/admin/deliveries").Subrouter()
+	deliveryRoutes.Use(adminAuth.Require())
+	deliveryRoutes.HandleFunc("", deliveryAdmin.ListHandler).Methods(http.MethodGet)
+	deliveryRoutes.HandleFunc("/{message_id}", deliveryAdmin.GetHandler).Methods(http.MethodGet)
+
+	// Admin API for conversation analytics (any admin role)
+	sentimentAdmin := sentiment.NewAdminHandler(repo, cfg.SentimentAlertThreshold)
+	analyticsRoutes := handler.PathPrefix("/admin/analytics").Subrouter()
+	analyticsRoutes.Use(adminAuth.Require())
+	analyticsRoutes.Handle("/sentiment", migrations.Require(migration.FeatureSentimentAnalytics)(
+		http.HandlerFunc(sentimentAdmin.SummaryHandler))).Methods(http.MethodGet)
+	analyticsRoutes.HandleFunc("/topics", topics.NewAdminHandler(repo).SummaryHandler).Methods(http.MethodGet)
+	analyticsRoutes.HandleFunc("/experiments", experiment.NewAdminHandler(repo, billingPricing).CompareHandler).Methods(http.MethodGet)
+	analyticsRoutes.HandleFunc("/usage", stats.NewAdminHandler(usageStats).SeriesHandler).Methods(http.MethodGet)
+	analyticsRoutes.HandleFunc("/quality", quality.NewAdminHandler(qualityScores).SeriesHandler).Methods(http.MethodGet)
+
+	// Admin API for identity linking (operator role for changes)
+	if identities != nil {
+		identityAdmin := identity.NewAdminHandler(identities)
+		identityRoutes := handler.PathPrefix("/admin/identities").Subrouter()
+		identityRoutes.Use(adminAuth.Require(admin.RoleOperator))
+		identityRoutes.HandleFunc("", identityAdmin.GetHandler).Methods(http.MethodGet)
+		identityRoutes.HandleFunc("/link", identityAdmin.LinkHandler).Methods(http.MethodPost)
+		identityRoutes.HandleFunc("/unlink", identityAdmin.UnlinkHandler).Methods(http.MethodPost)
+	}
+
+	// Admin API for index builds (operator role for retries)
+	if migrations != nil {
+		migrationAdmin := migration.NewAdminHandler(migrations)
+		migrationRoutes := handler.PathPrefix("/admin/migrations").Subrouter()
+		migrationRoutes.Use(adminAuth.Require(admin.RoleOperator))
+		migrationRoutes.HandleFunc("", migrationAdmin.ListHandler).Methods(http.MethodGet)
+		migrationRoutes.HandleFunc("/{id}/retry", migrationAdmin.RetryHandler).Methods(http.MethodPost)
+	}
+
+	// Admin API for the tamper-evident audit log (any admin role)
+	auditAdmin := audit.NewAdminHandler(auditLog)
+	auditRoutes := handler.PathPrefix("/admin/audit").Subrouter()
+	auditRoutes.Use(adminAuth.Require())
+	auditRoutes.HandleFunc("", auditAdmin.ListHandler).Methods(http.MethodGet)
+	auditRoutes.HandleFunc("/verify", auditAdmin.VerifyHandler).Methods(http.MethodGet)
+
+	// Admin API for tenant blocklists, API keys and data keys (operator role for changes)
+	tenants := handler.PathPrefix("/admin/tenants/{tenant_id}").Subrouter()
+	tenants.Use(adminAuth.Require(admin.RoleOperator))
+	blocklistAdmin := blocklist.NewAdminHandler(blocklists)
+	tenants.HandleFunc("/blocklist", blocklistAdmin.GetHandler).Methods(http.MethodGet)
+	tenants.HandleFunc("/blocklist", blocklistAdmin.PutHandler).Methods(http.MethodPut)
+	tenants.HandleFunc("/blocklist", blocklistAdmin.DeleteHandler).Methods(http.MethodDelete)
+	if tenantKeys != nil {
+		tenantAdmin := tenant.NewAdminHandler(tenantKeys)
+		tenants.HandleFunc("/credentials", tenantAdmin.GetCredentialsHandler).Methods(http.MethodGet)
+		tenants.HandleFunc("/credentials", tenantAdmin.PutCredentialsHandler).Methods(http.MethodPut)
+		tenants.HandleFunc("/credentials", tenantAdmin.DeleteCredentialsHandler).Methods(http.MethodDelete)
+	}
+	if dataKeys != nil {
+		encryptionAdmin := encryption.NewAdminHandler(dataKeys)
+		tenants.HandleFunc("/encryption", encryptionAdmin.GetHandler).Methods(http.MethodGet)
+		tenants.HandleFunc("/encryption/rotate", encryptionAdmin.RotateHandler).Methods(http.MethodPost)
+		tenants.HandleFunc("/encryption/revoke", encryptionAdmin.RevokeHandler).Methods(http.MethodPost)
+	}
+
+	// Bot webhooks queue messages in the inbox; workers answer them and send replies through the delivery channels
+	if cfg.InboxEnabled {
+		messageInbox := inbox.New(inbox.NewRedisStream(redisClient, "default", int64(cfg.InboxMaxLength)), server, deliveries,
+			inbox.Config{
+				Workers:       cfg.InboxWorkers,
+				MaxDeliveries: cfg.InboxMaxDeliveries,
+				ClaimIdle:     time.Duration(cfg.InboxClaimIdleSeconds) * time.Second,
+			})
+		go messageInbox.Run(workerCtx)
+
+		webhooks := inbox.NewWebhookHandler(messageInbox, cfg.TelegramWebhookSecret)
+		handler.HandleFunc("/webhooks/telegram", webhooks.TelegramHandler).Methods(http.MethodPost)
+		handler.Handle("/webhooks/inbox", auth.Middleware()(http.HandlerFunc(webhooks.EnqueueHandler))).Methods(http.MethodPost)
+		handler.Handle("/admin/inbox", adminAuth.Require()(http.HandlerFunc(webhooks.StatsHandler))).Methods(http.MethodGet)
+		if cfg.TelegramWebhookSecret == "" {
+			secureLogger.Warn("INBOX_ENABLED is set without TELEGRAM_WEBHOOK_SECRET - Telegram updates will be rejected")
+		}
+	}
+
+	// Data export downloads are authorized by their signed link, not the API key
+	if takeoutService != nil {
+		handler.HandleFunc("/takeout/{export_id}", takeoutService.DownloadHandler).Methods(http.MethodGet)
 	}
 
 	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -124,315 +763,48 @@ func main() {
 		fmt.Fprint(w, "<h1>Test Documentation</h1><p>This endpoint works!</p>")
 	})
 
-	handler.PathPrefix("/twirp/").Handler(pb.NewChatServiceServer(server, twirp.WithServerJSONSkipDefaults(true)))
+	// Service-to-service callers may sign requests with a shared HMAC key
+	signer := httpx.NewRequestSigner(httpx.SigningConfig{
+		Keys:     cfg.RequestSigningKeys,
+		Required: cfg.RequestSigningRequired,
+		MaxSkew:  time.Duration(cfg.RequestSigningMaxSkewSeconds) * time.Second,
+		Nonces:   redisx.NewNonceStore(redisClient),
+	})
+	if cfg.RequestSigningRequired && len(cfg.RequestSigningKeys) == 0 {
+		secureLogger.Warn("REQUEST_SIGNING_REQUIRED is set without REQUEST_SIGNING_KEYS - all API requests will be rejected")
+	}
 
-	// Serve swagger.json file for Swagger UI - always return full documentation
+	// Hooks record per-method metrics; failed calls carry the trace ID in their error meta
+	rpcHooks := httpx.TwirpHooks(appMetrics)
+	twirpHandler := pb.NewChatServiceServer(server,
+		twirp.WithServerJSONSkipDefaults(true),
+		twirp.WithServerHooks(rpcHooks),
+		twirp.WithServerInterceptors(httpx.TraceErrors()),
+	)
+	// Bodies are limited to the largest attachment, base64-encoded in JSON, plus room for other fields
+	maxBody := httpx.MaxBodySize(cfg.AttachmentMaxBytes*4/3 + 1<<20)
+	// Anonymous callers are checked for bots once signed service-to-service callers are known
+	botGuard := mustBotGuard(cfg, handler, appMetrics)
+	guardBots := botGuard.Middleware(startsConversation)
+	// Retried writes carrying an Idempotency-Key get the response of the first one instead of running again
+	idempotent := func(next http.Handler) http.Handler { return next }
+	if cfg.IdempotencyTTLHours > 0 {
+		idempotent = httpx.Idempotency(redisx.NewIdempotencyStore(redisClient), time.Duration(cfg.IdempotencyTTLHours)*time.Hour)
+	}
+	// Browser requests to the API must come from the same origin or an allowed one
+	handler.PathPrefix("/twirp/").Handler(maxBody(cors.OriginMiddleware()(signer.Middleware()(guardBots(idempotent(twirpHandler))))))
+	// The REST facade calls the same server behind the same checks, hooks and interceptors
+	restHandler := rest.NewHandler(server, rest.WithServerHooks(rpcHooks), rest.WithServerInterceptors(httpx.TraceErrors()))
+	handler.PathPrefix(rest.PathPrefix + "/").Handler(maxBody(cors.OriginMiddleware()(signer.Middleware()(guardBots(idempotent(restHandler))))))
+	// GraphQL reads the repository directly, behind the same checks; its queries change nothing
+	// Queries name any user, so like /metrics it is only served to callers holding the API key
+	handler.Handle(graphql.Path, maxBody(cors.OriginMiddleware()(signer.Middleware()(auth.Middleware()(graphql.NewHandler(repo, usageRepo, userSettings))))))
+
+	// Serve the generated OpenAPI document, kept in sync with the proto by tests/contract
 	handler.HandleFunc("/docs/doc.json", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		fmt.Fprint(w, `{
-			"swagger": "2.0",
-			"info": {
-				"title": "Go AI Assistant API",
-				"version": "1.0",
-				"description": "Production-ready AI assistant backend with modular tools, Redis caching, and comprehensive monitoring"
-			},
-			"host": "localhost:8080",
-			"basePath": "/",
-			"paths": {
-				"/": {
-					"get": {
-						"description": "Get basic service information",
-						"produces": ["text/plain"],
-						"tags": ["system"],
-						"summary": "Service information",
-						"responses": {
-							"200": {
-								"description": "Service information",
-								"schema": {"type": "string"}
-							}
-						}
-					}
-				},
-				"/health": {
-					"get": {
-						"description": "Check service health status including MongoDB and Redis connectivity",
-						"produces": ["application/json"],
-						"tags": ["system"],
-						"summary": "Health check",
-						"responses": {
-							"200": {
-								"description": "OK",
-								"schema": {"$ref": "#/definitions/HealthResponse"}
-							}
-						}
-					}
-				},
-				"/ready": {
-					"get": {
-						"description": "Check service readiness for traffic",
-						"produces": ["application/json"],
-						"tags": ["system"],
-						"summary": "Readiness check",
-						"responses": {
-							"200": {
-								"description": "OK",
-								"schema": {"$ref": "#/definitions/HealthResponse"}
-							}
-						}
-					}
-				},
-				"/metrics": {
-					"get": {
-						"security": [{"ApiKeyAuth": []}],
-						"description": "Get Prometheus metrics for monitoring (requires API key)",
-						"produces": ["text/plain"],
-						"tags": ["system"],
-						"summary": "Prometheus metrics",
-						"responses": {
-							"200": {
-								"description": "Prometheus metrics",
-								"schema": {"type": "string"}
-							},
-							"401": {
-								"description": "Unauthorized",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							}
-						}
-					}
-				},
-				"/twirp/chat.ChatService/StartConversation": {
-					"post": {
-						"description": "Create a new conversation with the AI assistant. The assistant can answer questions, provide weather information, date/time, and holiday information.",
-						"consumes": ["application/json"],
-						"produces": ["application/json"],
-						"tags": ["conversations"],
-						"summary": "Start a new conversation",
-						"parameters": [
-							{
-								"description": "Start conversation request",
-								"name": "request",
-								"in": "body",
-								"required": true,
-								"schema": {"$ref": "#/definitions/StartConversationRequest"}
-							}
-						],
-						"responses": {
-							"200": {
-								"description": "OK",
-								"schema": {"$ref": "#/definitions/StartConversationResponse"}
-							},
-							"400": {
-								"description": "Bad Request",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							},
-							"500": {
-								"description": "Internal Server Error",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							}
-						}
-					}
-				},
-				"/twirp/chat.ChatService/ContinueConversation": {
-					"post": {
-						"description": "Continue an existing conversation with the AI assistant. Supports both direct conversation_id and session-based conversations for stateless clients.",
-						"consumes": ["application/json"],
-						"produces": ["application/json"],
-						"tags": ["conversations"],
-						"summary": "Continue an existing conversation",
-						"parameters": [
-							{
-								"description": "Continue conversation request",
-								"name": "request",
-								"in": "body",
-								"required": true,
-								"schema": {"$ref": "#/definitions/ContinueConversationRequest"}
-							}
-						],
-						"responses": {
-							"200": {
-								"description": "OK",
-								"schema": {"$ref": "#/definitions/ContinueConversationResponse"}
-							},
-							"400": {
-								"description": "Bad Request",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							},
-							"404": {
-								"description": "Not Found",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							},
-							"500": {
-								"description": "Internal Server Error",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							}
-						}
-					}
-				},
-				"/twirp/chat.ChatService/ListConversations": {
-					"post": {
-						"description": "Get list of recent conversations. Messages are excluded from the response to avoid large payloads.",
-						"consumes": ["application/json"],
-						"produces": ["application/json"],
-						"tags": ["conversations"],
-						"summary": "List conversations",
-						"responses": {
-							"200": {
-								"description": "OK",
-								"schema": {"$ref": "#/definitions/ListConversationsResponse"}
-							},
-							"500": {
-								"description": "Internal Server Error",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							}
-						}
-					}
-				},
-				"/twirp/chat.ChatService/DescribeConversation": {
-					"post": {
-						"description": "Get detailed information about a specific conversation including all messages.",
-						"consumes": ["application/json"],
-						"produces": ["application/json"],
-						"tags": ["conversations"],
-						"summary": "Get conversation details",
-						"parameters": [
-							{
-								"description": "Describe conversation request",
-								"name": "request",
-								"in": "body",
-								"required": true,
-								"schema": {"$ref": "#/definitions/DescribeConversationRequest"}
-							}
-						],
-						"responses": {
-							"200": {
-								"description": "OK",
-								"schema": {"$ref": "#/definitions/DescribeConversationResponse"}
-							},
-							"400": {
-								"description": "Bad Request",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							},
-							"404": {
-								"description": "Not Found",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							},
-							"500": {
-								"description": "Internal Server Error",
-								"schema": {"$ref": "#/definitions/ErrorResponse"}
-							}
-						}
-					}
-				}
-			},
-			"definitions": {
-				"HealthResponse": {
-					"type": "object",
-					"properties": {
-						"status": {"type": "string", "example": "healthy"},
-						"checks": {
-							"type": "object",
-							"additionalProperties": {"type": "string"},
-							"example": {"mongodb": "ok", "redis": "ok"}
-						}
-					}
-				},
-				"ErrorResponse": {
-					"type": "object",
-					"properties": {
-						"code": {"type": "integer", "example": 400},
-						"message": {"type": "string", "example": "Bad Request"},
-						"details": {"type": "string", "example": "Missing required field: message"}
-					}
-				},
-				"StartConversationRequest": {
-					"type": "object",
-					"properties": {
-						"message": {"type": "string", "example": "What's the weather in Barcelona?"},
-						"session_metadata": {"$ref": "#/definitions/SessionMetadata"}
-					}
-				},
-				"StartConversationResponse": {
-					"type": "object",
-					"properties": {
-						"conversation_id": {"type": "string", "example": "507f1f77bcf86cd799439011"},
-						"title": {"type": "string", "example": "Weather in Barcelona"},
-						"reply": {"type": "string", "example": "The weather in Barcelona is sunny with 22°C..."}
-					}
-				},
-				"ContinueConversationRequest": {
-					"type": "object",
-					"properties": {
-						"conversation_id": {"type": "string", "example": "507f1f77bcf86cd799439011"},
-						"message": {"type": "string", "example": "What about tomorrow?"},
-						"session_metadata": {"$ref": "#/definitions/SessionMetadata"}
-					}
-				},
-				"ContinueConversationResponse": {
-					"type": "object",
-					"properties": {
-						"reply": {"type": "string", "example": "Tomorrow will be partly cloudy with 20°C..."}
-					}
-				},
-				"ListConversationsResponse": {
-					"type": "object",
-					"properties": {
-						"conversations": {
-							"type": "array",
-							"items": {"$ref": "#/definitions/Conversation"}
-						}
-					}
-				},
-				"DescribeConversationRequest": {
-					"type": "object",
-					"properties": {
-						"conversation_id": {"type": "string", "example": "507f1f77bcf86cd799439011"}
-					}
-				},
-				"DescribeConversationResponse": {
-					"type": "object",
-					"properties": {
-						"conversation": {"$ref": "#/definitions/Conversation"}
-					}
-				},
-				"Conversation": {
-					"type": "object",
-					"properties": {
-						"id": {"type": "string", "example": "507f1f77bcf86cd799439011"},
-						"title": {"type": "string", "example": "Weather discussion"},
-						"timestamp": {"type": "string", "example": "2025-11-07T20:15:00Z"},
-						"messages": {
-							"type": "array",
-							"items": {"$ref": "#/definitions/Message"}
-						}
-					}
-				},
-				"Message": {
-					"type": "object",
-					"properties": {
-						"id": {"type": "string", "example": "507f1f77bcf86cd799439012"},
-						"role": {"type": "string", "example": "user"},
-						"content": {"type": "string", "example": "What's the weather like?"},
-						"timestamp": {"type": "string", "example": "2025-11-07T20:15:00Z"}
-					}
-				},
-				"SessionMetadata": {
-					"type": "object",
-					"properties": {
-						"platform": {"type": "string", "example": "telegram"},
-						"user_id": {"type": "string", "example": "12345"},
-						"chat_id": {"type": "string", "example": "67890"}
-					}
-				}
-			},
-			"securityDefinitions": {
-				"ApiKeyAuth": {
-					"type": "apiKey",
-					"name": "X-API-Key",
-					"in": "header"
-				}
-			}
-		}`)
+		fmt.Fprint(w, docs.SwaggerInfo.ReadDoc())
 	})
 
 	// Swagger documentation
@@ -489,7 +861,7 @@ func main() {
 
         <div class="endpoint">
             <div class="method">POST</div>
-            <span class="path">/twirp/chat.ChatService/StartConversation</span>
+            <span class="path">/twirp/acai.chat.ChatService/StartConversation</span>
             <span class="tag">conversations</span>
             <div class="description">Start a new conversation with the AI assistant</div>
             <div class="example">
@@ -508,7 +880,7 @@ func main() {
 
         <div class="endpoint">
             <div class="method">POST</div>
-            <span class="path">/twirp/chat.ChatService/ContinueConversation</span>
+            <span class="path">/twirp/acai.chat.ChatService/ContinueConversation</span>
             <span class="tag">conversations</span>
             <div class="description">Continue an existing conversation. Supports both direct conversation_id and session-based conversations.</div>
             <div class="example">
@@ -535,7 +907,7 @@ func main() {
 
         <div class="endpoint">
             <div class="method">POST</div>
-            <span class="path">/twirp/chat.ChatService/ListConversations</span>
+            <span class="path">/twirp/acai.chat.ChatService/ListConversations</span>
             <span class="tag">conversations</span>
             <div class="description">Get list of recent conversations (messages excluded to avoid large payloads)</div>
             <div class="example">
@@ -556,7 +928,7 @@ func main() {
 
         <div class="endpoint">
             <div class="method">POST</div>
-            <span class="path">/twirp/chat.ChatService/DescribeConversation</span>
+            <span class="path">/twirp/acai.chat.ChatService/DescribeConversation</span>
             <span class="tag">conversations</span>
             <div class="description">Get detailed information about a specific conversation including all messages</div>
             <div class="example">
@@ -659,7 +1031,7 @@ func main() {
             # Start server<br>
             go run ./cmd/server<br><br>
             # Test API<br>
-            curl -X POST http://localhost:8080/twirp/chat.ChatService/StartConversation \<br>
+            curl -X POST http://localhost:8080/twirp/acai.chat.ChatService/StartConversation \<br>
             -H "Content-Type: application/json" \<br>
             -d '{"message": "Hello!"}'
         </div>
@@ -688,10 +1060,34 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Serve HTTPS, with mutual TLS when a client CA bundle is configured
+	tlsConfig := tlsx.ServerConfig{
+		CertFile:     cfg.TLSCertFile,
+		KeyFile:      cfg.TLSKeyFile,
+		ClientCAFile: cfg.TLSClientCAFile,
+		ClientAuth:   cfg.TLSClientAuth,
+	}
+	if tlsConfig.Enabled() {
+		srv.TLSConfig, err = tlsx.NewServerTLS(tlsConfig)
+		if err != nil {
+			secureLogger.Error("Failed to configure TLS", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Start server in a goroutine
 	go func() {
-		secureLogger.Info("Starting the server...", "port", "8080")
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		secureLogger.Info("Starting the server...", "port", "8080",
+			"tls", tlsConfig.Enabled(), "mtls", tlsConfig.Enabled() && tlsConfig.ClientCAFile != "")
+
+		var err error
+		if tlsConfig.Enabled() {
+			// Certificates are already loaded into srv.TLSConfig
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			secureLogger.Error("Server failed to start", "error", err)
 			os.Exit(1)
 		}
@@ -702,6 +1098,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	secureLogger.Info("Shutting down server...")
+	stopWorkers()
 
 	// Create a deadline for graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -713,3 +1110,225 @@ func main() {
 
 	secureLogger.Info("Server exited")
 }
+
+// mustObjectStore creates the object storage backend from configuration
+func mustObjectStore(cfg *config.Config) objectstore.Store {
+	if cfg.ObjectStoreBackend == "s3" {
+		store, err := objectstore.NewS3Store(objectstore.S3Config{
+			Bucket:          cfg.ObjectStoreS3Bucket,
+			Region:          cfg.ObjectStoreS3Region,
+			Endpoint:        cfg.ObjectStoreS3Endpoint,
+			AccessKeyID:     cfg.ObjectStoreS3AccessKeyID,
+			SecretAccessKey: cfg.ObjectStoreS3SecretAccessKey,
+		}, nil)
+		if err != nil {
+			slog.Error("Invalid S3 object store configuration", "error", err)
+			os.Exit(1)
+		}
+		return store
+	}
+
+	store, err := objectstore.NewLocalStore(cfg.ObjectStoreDir)
+	if err != nil {
+		slog.Error("Invalid object store directory", "error", err)
+		os.Exit(1)
+	}
+	return store
+}
+
+// mustInstanceID identifies this instance in leader election and task history
+func mustInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		slog.Error("Failed to get hostname", "error", err)
+		os.Exit(1)
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// mustRolloutConfig returns the defaults of prompt rollouts, exiting on invalid stages
+func mustRolloutConfig(cfg *config.Config) rollout.Config {
+	stages, err := rollout.ParseStages(cfg.PromptRolloutStages)
+	if err != nil {
+		slog.Error("Invalid PROMPT_ROLLOUT_STAGES", "error", err)
+		os.Exit(1)
+	}
+	return rollout.Config{
+		Stages:       stages,
+		StageMinutes: cfg.PromptRolloutStageMinutes,
+		Thresholds: rollout.Thresholds{
+			MinReplies:         int64(cfg.PromptRolloutMinReplies),
+			MaxErrorRate:       cfg.PromptRolloutMaxErrorRate,
+			MaxNegativeRate:    cfg.PromptRolloutMaxNegativeRate,
+			MaxLatencyIncrease: cfg.PromptRolloutMaxLatencyIncrease,
+		},
+	}
+}
+
+// mustAddTask registers a scheduled task, exiting on an invalid schedule
+func mustAddTask(scheduler *cron.Scheduler, task cron.Task) {
+	if err := scheduler.Add(task); err != nil {
+		slog.Error("Invalid scheduled task", "task", task.Name, "error", err)
+		os.Exit(1)
+	}
+}
+
+// userConversations lists a user's conversations for data exports, across linked identities when linking is enabled
+func userConversations(repo *model.Repository, identities *identity.Service) takeout.ConversationLister {
+	if identities == nil {
+		return repo
+	}
+	return identity.LinkedConversations{Identities: identities, Conversations: repo}
+}
+
+// mustConcurrencyPolicies returns the reply concurrency policy per platform
+func mustConcurrencyPolicies(cfg *config.Config) map[string]inflight.Policy {
+	policies, err := inflight.ParsePolicies(cfg.ConversationConcurrency)
+	if err != nil {
+		slog.Error("Invalid CONVERSATION_CONCURRENCY", "error", err)
+		os.Exit(1)
+	}
+	return policies
+}
+
+// mustBotGuard returns the bot guard, mounting the honeypot paths and, for proof-of-work, the challenge endpoint
+func mustBotGuard(cfg *config.Config, handler *mux.Router, recorder botguard.Recorder) *botguard.Guard {
+	verifier, err := botguard.NewVerifier(cfg.BotChallenge, cfg.BotChallengeSecret, cfg.BotPowDifficulty)
+	if err != nil {
+		slog.Error("Invalid BOT_CHALLENGE", "error", err)
+		os.Exit(1)
+	}
+	guard := botguard.NewGuard(botguard.Config{
+		FingerprintRPS:   cfg.BotFingerprintRPS,
+		FingerprintBurst: cfg.BotFingerprintBurst,
+		MaxFingerprints:  cfg.RateLimitMaxClients,
+		HoneypotBan:      time.Duration(cfg.BotHoneypotBanMinutes) * time.Minute,
+	}, verifier, recorder)
+
+	if pow, ok := verifier.(*botguard.ProofOfWork); ok {
+		handler.HandleFunc("/widget/challenge", pow.ChallengeHandler).Methods(http.MethodGet)
+	}
+	for _, path := range cfg.BotHoneypotPaths {
+		handler.HandleFunc(path, guard.HoneypotHandler)
+	}
+	return guard
+}
+
+// startsConversation reports whether a request starts a conversation, which anonymous callers must pass a challenge for
+func startsConversation(r *http.Request) bool {
+	return r.Method == http.MethodPost &&
+		(r.URL.Path == pb.ChatServicePathPrefix+"StartConversation" || r.URL.Path == rest.PathPrefix+"/conversations")
+}
+
+// mustAccessControl returns the IP range and country rules, loading the GeoIP database when one is configured
+func mustAccessControl(cfg *config.Config, recorder httpx.AccessRecorder) *httpx.AccessControl {
+	var countries httpx.CountryResolver
+	if cfg.GeoIPDatabasePath != "" {
+		reader, err := geoip.Open(cfg.GeoIPDatabasePath)
+		if err != nil {
+			slog.Error("Failed to open GeoIP database", "path", cfg.GeoIPDatabasePath, "error", err)
+			os.Exit(1)
+		}
+		countries = reader
+	}
+
+	access, err := httpx.NewAccessControl(httpx.AccessConfig{
+		AllowCIDRs:     cfg.AccessAllowCIDRs,
+		DenyCIDRs:      cfg.AccessDenyCIDRs,
+		AllowCountries: cfg.AccessAllowCountries,
+		DenyCountries:  cfg.AccessDenyCountries,
+	}, countries, recorder)
+	if err != nil {
+		slog.Error("Invalid access rules", "error", err)
+		os.Exit(1)
+	}
+	return access
+}
+
+// mustBillingPricing returns model prices with configured overrides
+func mustBillingPricing(cfg *config.Config) billing.Pricing {
+	pricing, err := billing.ParsePricing(cfg.BillingModelPrices)
+	if err != nil {
+		slog.Error("Invalid BILLING_MODEL_PRICES", "error", err)
+		os.Exit(1)
+	}
+	return pricing
+}
+
+// mustFollowUpPlatforms returns the platforms that opted in to follow-ups with their minimum interval
+func mustFollowUpPlatforms(cfg *config.Config) map[string]time.Duration {
+	platforms, err := followup.ParsePlatforms(cfg.FollowUpPlatforms)
+	if err != nil {
+		slog.Error("Invalid FOLLOW_UP_PLATFORMS", "error", err)
+		os.Exit(1)
+	}
+	return platforms
+}
+
+// mustGreeter returns the greeter of new conversations, nil when no greeting is configured
+func mustGreeter(cfg *config.Config) *greeting.Greeter {
+	greetings, err := greeting.Parse(cfg.Greetings)
+	if err != nil {
+		slog.Error("Invalid GREETINGS", "error", err)
+		os.Exit(1)
+	}
+	greeter, err := greeting.NewGreeter(greetings)
+	if err != nil {
+		slog.Error("Invalid GREETINGS", "error", err)
+		os.Exit(1)
+	}
+	return greeter
+}
+
+func mustReplyPipeline(cfg *config.Config, translator postprocess.Translator, policy postprocess.ContentPolicy) *postprocess.Pipeline {
+	profanity := postprocess.NewProfanityFilter(cfg.ProfanityWords)
+	maxLength := func(platform string) int {
+		limit, ok := cfg.ReplyMaxLengths[platform]
+		if !ok {
+			limit = cfg.ReplyMaxLengths[postprocess.DefaultPlatform]
+		}
+		n, _ := strconv.Atoi(limit)
+		return n
+	}
+	decorations, err := postprocess.ParseDecorations(cfg.ReplyDecorations)
+	if err != nil {
+		slog.Error("Invalid REPLY_DECORATIONS", "error", err)
+		os.Exit(1)
+	}
+	registry := postprocess.Registry{
+		"markdown":  func(string) postprocess.Filter { return postprocess.MarkdownSanitizer{} },
+		"profanity": func(string) postprocess.Filter { return profanity },
+		"blocklist": func(string) postprocess.Filter { return postprocess.NewBlocklistFilter(policy) },
+		"links": func(platform string) postprocess.Filter {
+			params := make(map[string]string, len(cfg.LinkRewriteParams))
+			for k, v := range cfg.LinkRewriteParams {
+				params[k] = strings.ReplaceAll(v, "{platform}", platform)
+			}
+			return postprocess.NewLinkRewriter(params, cfg.LinkRewriteHosts)
+		},
+		"length": func(platform string) postprocess.Filter {
+			return postprocess.NewLengthLimiter(maxLength(platform))
+		},
+		"decorate": func(platform string) postprocess.Filter {
+			decorator, err := postprocess.NewDecorator(platform, decorations, maxLength(platform))
+			if err != nil {
+				slog.Error("Invalid REPLY_DECORATIONS", "error", err)
+				os.Exit(1)
+			}
+			return decorator
+		},
+		"language": func(string) postprocess.Filter { return postprocess.NewLanguageEnforcer(translator) },
+	}
+
+	chains := make(map[string][]string, len(cfg.ReplyFilters))
+	for platform, filters := range cfg.ReplyFilters {
+		chains[platform] = strings.Split(filters, "|")
+	}
+
+	pipeline, err := registry.Build(chains)
+	if err != nil {
+		slog.Error("Invalid REPLY_FILTERS", "error", err)
+		os.Exit(1)
+	}
+	return pipeline
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/doctor"
+	"github.com/8adimka/Go_AI_Assistant/internal/smoketest"
+)
+
+// smoketest runs a scripted conversation against a live deployment and prints a report
+// It exits with status 1 when a step fails, for post-deploy verification pipelines
+// The API key, tenant key and signing secret are read from API_KEY, SMOKETEST_TENANT_KEY and SMOKETEST_SIGNING_SECRET
+func main() {
+	baseURL := flag.String("url", envOr("API_URL", "http://localhost:8080"), "base URL of the deployment")
+	tenantID := flag.String("tenant", "", "tenant to run as; empty uses the default tenant")
+	signingKeyID := flag.String("signing-key-id", "", "sign requests with this key ID and SMOKETEST_SIGNING_SECRET")
+	replyLatency := flag.Duration("reply-latency", 30*time.Second, "latency budget of calls that wait for the model")
+	readLatency := flag.Duration("read-latency", 2*time.Second, "latency budget of calls that read stored data")
+	timeout := flag.Duration("timeout", 2*time.Minute, "timeout of each step, including waiting for the data export")
+	flag.Parse()
+
+	checks := smoketest.Checks(smoketest.Config{
+		BaseURL:       *baseURL,
+		APIKey:        os.Getenv("API_KEY"),
+		TenantID:      *tenantID,
+		TenantKey:     os.Getenv("SMOKETEST_TENANT_KEY"),
+		SigningKeyID:  *signingKeyID,
+		SigningSecret: os.Getenv("SMOKETEST_SIGNING_SECRET"),
+		ReplyLatency:  *replyLatency,
+		ReadLatency:   *readLatency,
+		HTTPClient:    &http.Client{Timeout: *timeout},
+	})
+
+	fmt.Printf("Smoke testing %s\n\n", *baseURL)
+	results := doctor.Run(context.Background(), checks, *timeout)
+	if err := doctor.WriteReport(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if doctor.Failed(results) {
+		os.Exit(1)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
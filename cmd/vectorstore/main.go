@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/mongox"
+	"github.com/8adimka/Go_AI_Assistant/internal/vectorstore"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// vectorstore copies every stored embedding from one vector store backend to another,
+// e.g. from the in-process HNSW snapshot to Qdrant before switching VECTOR_STORE
+func main() {
+	from := flag.String("from", "", "source backend: hnsw, atlas or qdrant")
+	to := flag.String("to", "", "destination backend: hnsw, atlas or qdrant")
+	fromPath := flag.String("from-hnsw", "", "snapshot file of an hnsw source (default VECTOR_HNSW_PATH)")
+	toPath := flag.String("to-hnsw", "", "snapshot file of an hnsw destination (default VECTOR_HNSW_PATH)")
+	batchSize := flag.Int("batch", 500, "records copied per batch")
+	flag.Parse()
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	if *from == *to && *fromPath == *toPath {
+		fmt.Fprintln(os.Stderr, "Error: source and destination are the same store")
+		os.Exit(1)
+	}
+
+	var db *mongo.Database
+	if *from == vectorstore.BackendAtlas || *to == vectorstore.BackendAtlas {
+		db = mongox.MustConnect(cfg.MongoURI, "acai")
+	}
+
+	src, err := vectorstore.New(ctx, storeConfig(cfg, *from, *fromPath), db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening source: %v\n", err)
+		os.Exit(1)
+	}
+	dst, err := vectorstore.New(ctx, storeConfig(cfg, *to, *toPath), db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening destination: %v\n", err)
+		os.Exit(1)
+	}
+
+	copied, err := vectorstore.Migrate(ctx, src, dst, *batchSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error after copying %d records: %v\n", copied, err)
+		os.Exit(1)
+	}
+
+	if index, ok := dst.(*vectorstore.HNSW); ok {
+		path := storeConfig(cfg, *to, *toPath).HNSWPath
+		if path == "" {
+			fmt.Fprintln(os.Stderr, "Error: an hnsw destination needs -to-hnsw or VECTOR_HNSW_PATH")
+			os.Exit(1)
+		}
+		if err := index.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving snapshot: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("Copied %d records from %s to %s\n", copied, *from, *to)
+}
+
+// storeConfig builds the configuration of a backend from the environment, with an optional snapshot override
+func storeConfig(cfg *config.Config, backend, hnswPath string) vectorstore.Config {
+	if hnswPath == "" {
+		hnswPath = cfg.VectorHNSWPath
+	}
+	return vectorstore.Config{
+		Backend:            backend,
+		Dimensions:         cfg.VectorDimensions,
+		AtlasCollection:    cfg.VectorAtlasCollection,
+		AtlasIndex:         cfg.VectorAtlasIndex,
+		QdrantURL:          cfg.QdrantURL,
+		QdrantAPIKey:       cfg.QdrantAPIKey,
+		QdrantCollection:   cfg.QdrantCollection,
+		HNSWPath:           hnswPath,
+		HNSWM:              cfg.VectorHNSWM,
+		HNSWEfConstruction: cfg.VectorHNSWEfConstruction,
+		HNSWEfSearch:       cfg.VectorHNSWEfSearch,
+	}
+}
@@ -0,0 +1,112 @@
+package abuse
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// AdminHandler exposes block management over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	guard *Guard
+}
+
+// NewAdminHandler creates a new abuse admin handler
+func NewAdminHandler(guard *Guard) *AdminHandler {
+	return &AdminHandler{guard: guard}
+}
+
+// BlockRequest is the body of block and unblock requests
+type BlockRequest struct {
+	Platform        string `json:"platform"`
+	UserID          string `json:"user_id"`
+	DurationSeconds int    `json:"duration_seconds,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// StatusResponse reports whether a user is blocked
+type StatusResponse struct {
+	Blocked bool   `json:"blocked"`
+	Block   *Block `json:"block,omitempty"`
+}
+
+// BlockHandler handles POST /admin/abuse/block
+func (h *AdminHandler) BlockHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+
+	block, err := h.guard.Block(r.Context(), req.Platform, req.UserID,
+		time.Duration(req.DurationSeconds)*time.Second, req.Reason)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to block user", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to block user"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StatusResponse{Blocked: true, Block: block})
+}
+
+// UnblockHandler handles POST /admin/abuse/unblock
+func (h *AdminHandler) UnblockHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.guard.Unblock(r.Context(), req.Platform, req.UserID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to unblock user", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to unblock user"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StatusResponse{Blocked: false})
+}
+
+// StatusHandler handles GET /admin/abuse/status?platform=...&user_id=...
+func (h *AdminHandler) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	platform := r.URL.Query().Get("platform")
+	userID := r.URL.Query().Get("user_id")
+	if platform == "" || userID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "platform and user_id are required"})
+		return
+	}
+
+	block, err := h.guard.Status(r.Context(), platform, userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get block status", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to get block status"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StatusResponse{Blocked: block != nil, Block: block})
+}
+
+func (h *AdminHandler) decode(w http.ResponseWriter, r *http.Request) (*BlockRequest, bool) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return nil, false
+	}
+
+	var req BlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return nil, false
+	}
+
+	if req.Platform == "" || req.UserID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "platform and user_id are required"})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
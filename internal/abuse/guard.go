@@ -0,0 +1,253 @@
+package abuse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// Reasons recorded with strikes and blocks
+const (
+	ReasonModeration = "moderation"
+	ReasonRateLimit  = "rate_limit"
+	ReasonAdmin      = "admin"
+)
+
+// ErrUserBlocked is returned when a blocked user sends a request
+var ErrUserBlocked = errors.New("user blocked")
+
+// Block describes an active block on a user
+type Block struct {
+	Platform  string    `json:"platform"`
+	UserID    string    `json:"user_id"`
+	Reason    string    `json:"reason"`
+	Level     int       `json:"level"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BlockedError carries the block that rejected a request
+type BlockedError struct {
+	Block *Block
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("user blocked until %s", e.Block.ExpiresAt.Format(time.RFC3339))
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrUserBlocked
+}
+
+// Store persists counters and blocks, typically in Redis
+type Store interface {
+	// Incr increments a counter, starting its expiry window when the counter is created
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Delete removes counters or blocks
+	Delete(ctx context.Context, keys ...string) error
+	// SetBlock stores a block that expires after ttl
+	SetBlock(ctx context.Context, key string, block *Block, ttl time.Duration) error
+	// GetBlock returns the stored block, or nil when there is none
+	GetBlock(ctx context.Context, key string) (*Block, error)
+}
+
+// Config controls when users are blocked and for how long
+type Config struct {
+	StrikeThreshold   int           // Strikes within StrikeWindow that trigger a block
+	StrikeWindow      time.Duration // Window strikes are counted in
+	RequestsPerMinute int           // Per-user request rate above which a strike is recorded (0 disables)
+	BaseBlock         time.Duration // Duration of the first block
+	MaxBlock          time.Duration // Upper bound for escalated blocks
+	LevelTTL          time.Duration // How long previous blocks count towards escalation
+}
+
+// Guard tracks strikes per platform user and blocks repeat offenders
+type Guard struct {
+	store Store
+	cfg   Config
+}
+
+// NewGuard creates a new abuse guard
+func NewGuard(store Store, cfg Config) *Guard {
+	if cfg.StrikeThreshold <= 0 {
+		cfg.StrikeThreshold = 5
+	}
+	if cfg.StrikeWindow <= 0 {
+		cfg.StrikeWindow = time.Hour
+	}
+	if cfg.BaseBlock <= 0 {
+		cfg.BaseBlock = 5 * time.Minute
+	}
+	if cfg.MaxBlock < cfg.BaseBlock {
+		cfg.MaxBlock = 24 * time.Hour
+	}
+	if cfg.LevelTTL <= 0 {
+		cfg.LevelTTL = 7 * 24 * time.Hour
+	}
+
+	return &Guard{
+		store: store,
+		cfg:   cfg,
+	}
+}
+
+// Check rejects blocked users and counts the request towards the per-user rate
+// Returns a *BlockedError when the user is blocked
+func (g *Guard) Check(ctx context.Context, platform, userID string) error {
+	block, err := g.store.GetBlock(ctx, blockKey(ctx, platform, userID))
+	if err != nil {
+		// Fail open: abuse control must not take the service down with Redis
+		slog.WarnContext(ctx, "Failed to check user block", "platform", platform, "error", err)
+		return nil
+	}
+	if block != nil {
+		return &BlockedError{Block: block}
+	}
+
+	if g.cfg.RequestsPerMinute <= 0 {
+		return nil
+	}
+
+	count, err := g.store.Incr(ctx, requestsKey(ctx, platform, userID), time.Minute)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to count user request", "platform", platform, "error", err)
+		return nil
+	}
+
+	// Record one strike per minute the user stays over the limit
+	if count == int64(g.cfg.RequestsPerMinute)+1 {
+		block, err := g.RecordStrike(ctx, platform, userID, ReasonRateLimit)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to record rate limit strike", "platform", platform, "error", err)
+			return nil
+		}
+		if block != nil {
+			return &BlockedError{Block: block}
+		}
+	}
+
+	return nil
+}
+
+// RecordStrike counts an offense, e.g. a moderation flag, and blocks the user once the threshold is reached
+// Returns the new block, or nil if the user was not blocked
+func (g *Guard) RecordStrike(ctx context.Context, platform, userID, reason string) (*Block, error) {
+	strikes, err := g.store.Incr(ctx, strikesKey(ctx, platform, userID), g.cfg.StrikeWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record strike: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Abuse strike recorded",
+		"platform", platform,
+		"reason", reason,
+		"strikes", strikes,
+		"threshold", g.cfg.StrikeThreshold)
+
+	if strikes < int64(g.cfg.StrikeThreshold) {
+		return nil, nil
+	}
+
+	level, err := g.store.Incr(ctx, levelKey(ctx, platform, userID), g.cfg.LevelTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to escalate block: %w", err)
+	}
+
+	block, err := g.block(ctx, platform, userID, reason, int(level), g.Duration(int(level)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Start counting from zero once the block expires
+	if err := g.store.Delete(ctx, strikesKey(ctx, platform, userID)); err != nil {
+		slog.WarnContext(ctx, "Failed to reset strikes", "platform", platform, "error", err)
+	}
+
+	return block, nil
+}
+
+// Block blocks a user for the given duration on behalf of an admin
+func (g *Guard) Block(ctx context.Context, platform, userID string, duration time.Duration, reason string) (*Block, error) {
+	if duration <= 0 {
+		duration = g.cfg.BaseBlock
+	}
+	if reason == "" {
+		reason = ReasonAdmin
+	}
+	return g.block(ctx, platform, userID, reason, 0, duration)
+}
+
+// Unblock lifts a block and clears the user's strikes and escalation level
+func (g *Guard) Unblock(ctx context.Context, platform, userID string) error {
+	if err := g.store.Delete(ctx,
+		blockKey(ctx, platform, userID),
+		strikesKey(ctx, platform, userID),
+		levelKey(ctx, platform, userID),
+	); err != nil {
+		return fmt.Errorf("failed to unblock user: %w", err)
+	}
+
+	slog.InfoContext(ctx, "User unblocked", "platform", platform)
+	return nil
+}
+
+// Status returns the active block for a user, or nil if the user is not blocked
+func (g *Guard) Status(ctx context.Context, platform, userID string) (*Block, error) {
+	return g.store.GetBlock(ctx, blockKey(ctx, platform, userID))
+}
+
+// Duration returns the block duration for an escalation level
+// Each repeated block doubles the duration, up to MaxBlock
+func (g *Guard) Duration(level int) time.Duration {
+	duration := g.cfg.BaseBlock
+	for i := 1; i < level; i++ {
+		duration *= 2
+		if duration >= g.cfg.MaxBlock {
+			return g.cfg.MaxBlock
+		}
+	}
+	return duration
+}
+
+func (g *Guard) block(ctx context.Context, platform, userID, reason string, level int, duration time.Duration) (*Block, error) {
+	now := time.Now()
+	block := &Block{
+		Platform:  platform,
+		UserID:    userID,
+		Reason:    reason,
+		Level:     level,
+		CreatedAt: now,
+		ExpiresAt: now.Add(duration),
+	}
+
+	if err := g.store.SetBlock(ctx, blockKey(ctx, platform, userID), block, duration); err != nil {
+		return nil, fmt.Errorf("failed to block user: %w", err)
+	}
+
+	slog.WarnContext(ctx, "User blocked",
+		"platform", platform,
+		"reason", reason,
+		"level", level,
+		"duration", duration.String())
+
+	return block, nil
+}
+
+func blockKey(ctx context.Context, platform, userID string) string {
+	return tenant.Key(ctx, fmt.Sprintf("abuse:block:%s:%s", platform, userID))
+}
+
+func strikesKey(ctx context.Context, platform, userID string) string {
+	return tenant.Key(ctx, fmt.Sprintf("abuse:strikes:%s:%s", platform, userID))
+}
+
+func levelKey(ctx context.Context, platform, userID string) string {
+	return tenant.Key(ctx, fmt.Sprintf("abuse:level:%s:%s", platform, userID))
+}
+
+func requestsKey(ctx context.Context, platform, userID string) string {
+	return tenant.Key(ctx, fmt.Sprintf("abuse:requests:%s:%s", platform, userID))
+}
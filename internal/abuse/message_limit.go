@@ -0,0 +1,101 @@
+package abuse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// Scopes of message rate limits
+const (
+	ScopeConversation = "conversation"
+	ScopeSession      = "session"
+)
+
+// ErrMessageRateLimited is returned when a conversation or session sends messages faster than allowed
+var ErrMessageRateLimited = errors.New("message rate limit exceeded")
+
+// MessageRateError carries the limit that rejected a message
+type MessageRateError struct {
+	Scope string
+	Limit int // Messages allowed per minute
+}
+
+func (e *MessageRateError) Error() string {
+	return fmt.Sprintf("%s sent more than %d messages per minute", e.Scope, e.Limit)
+}
+
+func (e *MessageRateError) Unwrap() error {
+	return ErrMessageRateLimited
+}
+
+// MessageRecorder exports rejected messages for dashboards, see metrics.Metrics
+type MessageRecorder interface {
+	RecordMessageRateLimited(ctx context.Context, scope, platform string)
+}
+
+// MessageLimitConfig caps messages per conversation and per session; zero disables a limit
+type MessageLimitConfig struct {
+	ConversationPerMinute int
+	SessionPerMinute      int
+}
+
+// MessageLimiter stops runaway bots that flood a single conversation or chat session
+// Unlike the per-user Guard it records no strikes: the limit lifts as soon as the minute is over
+type MessageLimiter struct {
+	store    Store
+	recorder MessageRecorder
+	cfg      MessageLimitConfig
+}
+
+// NewMessageLimiter creates a message rate limiter; recorder may be nil
+func NewMessageLimiter(store Store, recorder MessageRecorder, cfg MessageLimitConfig) *MessageLimiter {
+	return &MessageLimiter{
+		store:    store,
+		recorder: recorder,
+		cfg:      cfg,
+	}
+}
+
+// CheckSession counts a message towards the rate of a platform chat session
+// Returns a *MessageRateError when the session is over its limit
+func (l *MessageLimiter) CheckSession(ctx context.Context, platform, userID, chatID string) error {
+	key := tenant.Key(ctx, fmt.Sprintf("abuse:messages:session:%s:%s:%s", platform, userID, chatID))
+	return l.check(ctx, ScopeSession, platform, key, l.cfg.SessionPerMinute)
+}
+
+// CheckConversation counts a message towards the rate of a conversation
+// Returns a *MessageRateError when the conversation is over its limit
+func (l *MessageLimiter) CheckConversation(ctx context.Context, platform, conversationID string) error {
+	key := tenant.Key(ctx, "abuse:messages:conversation:"+conversationID)
+	return l.check(ctx, ScopeConversation, platform, key, l.cfg.ConversationPerMinute)
+}
+
+func (l *MessageLimiter) check(ctx context.Context, scope, platform, key string, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	count, err := l.store.Incr(ctx, key, time.Minute)
+	if err != nil {
+		// Fail open like the Guard: rate limiting must not take the service down with Redis
+		slog.WarnContext(ctx, "Failed to count message", "scope", scope, "platform", platform, "error", err)
+		return nil
+	}
+	if count <= int64(limit) {
+		return nil
+	}
+
+	if l.recorder != nil {
+		l.recorder.RecordMessageRateLimited(ctx, scope, platform)
+	}
+	// Log once per window, not for every rejected message of a flood
+	if count == int64(limit)+1 {
+		slog.WarnContext(ctx, "Message rate limit exceeded", "scope", scope, "platform", platform, "limit", limit)
+	}
+	return &MessageRateError{Scope: scope, Limit: limit}
+}
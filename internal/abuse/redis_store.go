@@ -0,0 +1,77 @@
+package abuse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps abuse counters and blocks in Redis so they are shared across instances
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a new Redis-backed abuse store
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Incr increments a counter and starts its expiry window when the counter is created
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	count, err := s.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %w", err)
+	}
+
+	if count == 1 {
+		if err := s.client.Expire(ctx, key, window).Err(); err != nil {
+			return count, fmt.Errorf("failed to set counter expiry: %w", err)
+		}
+	}
+
+	return count, nil
+}
+
+// Delete removes counters or blocks
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete keys: %w", err)
+	}
+	return nil
+}
+
+// SetBlock stores a block that expires after ttl
+func (s *RedisStore) SetBlock(ctx context.Context, key string, block *Block, ttl time.Duration) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("failed to marshal block: %w", err)
+	}
+
+	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store block: %w", err)
+	}
+
+	return nil
+}
+
+// GetBlock returns the stored block, or nil when there is none
+func (s *RedisStore) GetBlock(ctx context.Context, key string) (*Block, error) {
+	data, err := s.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	var block Block
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block: %w", err)
+	}
+
+	return &block, nil
+}
@@ -0,0 +1,239 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+)
+
+// apiKeyPrincipal is the name audit entries give requests authenticated with the shared API key
+const apiKeyPrincipal = "api-key"
+
+// UserStore finds admin users, see MongoRepository
+type UserStore interface {
+	FindUser(ctx context.Context, username string) (*User, error)
+}
+
+// Principal is the authenticated caller of an admin request
+type Principal struct {
+	Username string
+	Roles    []string
+}
+
+type principalKey struct{}
+
+// FromContext returns the caller of the admin request, or nil outside admin routes
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Authenticator checks the roles of admin callers and audits their changes
+// Callers log in for a JWT; the shared API key, when configured, keeps working for automation and grants every role
+type Authenticator struct {
+	users  UserStore
+	tokens *TokenIssuer
+	apiKey string
+	audit  audit.Recorder
+}
+
+// NewAuthenticator creates an admin authenticator
+// tokens nil disables logins, apiKey empty disables the shared key, recorder nil disables auditing
+func NewAuthenticator(users UserStore, tokens *TokenIssuer, apiKey string, recorder audit.Recorder) *Authenticator {
+	return &Authenticator{users: users, tokens: tokens, apiKey: apiKey, audit: recorder}
+}
+
+// Require returns a middleware checking the caller's roles per request: reads (GET and HEAD)
+// need any role, other methods one of writeRoles; changes are recorded in the audit log
+func (a *Authenticator) Require(writeRoles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Without a key and logins, admin routes stay open as they were before authentication
+			if a.apiKey == "" && a.tokens == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := a.authenticate(r)
+			if err != nil {
+				slog.WarnContext(r.Context(), "Admin authentication failed",
+					"ip", httpx.GetClientIP(r),
+					"method", r.Method,
+					"path", r.URL.Path,
+					"error", err,
+				)
+				writeError(w, http.StatusUnauthorized, "unauthorized", err.Error())
+				return
+			}
+
+			read := r.Method == http.MethodGet || r.Method == http.MethodHead
+			if !allowed(principal.Roles, read, writeRoles) {
+				slog.WarnContext(r.Context(), "Admin request forbidden",
+					"admin", principal.Username,
+					"roles", principal.Roles,
+					"method", r.Method,
+					"path", r.URL.Path,
+				)
+				writeError(w, http.StatusForbidden, "forbidden", "role "+strings.Join(writeRoles, " or ")+" required")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), principalKey{}, principal)
+			if read {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+			a.record(ctx, &audit.Entry{
+				Action: audit.ActionAdminRequest,
+				Actor:  audit.Actor("admin", principal.Username),
+				Target: r.Method + " " + r.URL.Path,
+				After:  strconv.Itoa(rec.status),
+			})
+		})
+	}
+}
+
+// LoginRequest is the body of login requests
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// LoginResponse carries the token to send as "Authorization: Bearer <token>"
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Roles     []string  `json:"roles"`
+}
+
+// dummyUser is checked for unknown usernames, so response times do not reveal which usernames exist
+var dummyUser = sync.OnceValue(func() *User {
+	user, _ := NewUser("dummy", "dummy-password-for-timing", []string{RoleViewer})
+	return user
+})
+
+// LoginHandler handles POST /admin/login
+func (a *Authenticator) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	if a.tokens == nil {
+		writeError(w, http.StatusNotFound, "not_found", "admin logins are not enabled")
+		return
+	}
+
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "username and password are required")
+		return
+	}
+
+	user, err := a.users.FindUser(r.Context(), req.Username)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		slog.ErrorContext(r.Context(), "Failed to load admin user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "login failed")
+		return
+	}
+	if user == nil {
+		dummyUser().CheckPassword(req.Password)
+	}
+	if user == nil || !user.CheckPassword(req.Password) || user.Disabled {
+		slog.WarnContext(r.Context(), "Admin login failed", "admin", req.Username, "ip", httpx.GetClientIP(r))
+		a.record(r.Context(), &audit.Entry{
+			Action: audit.ActionAdminLogin,
+			Actor:  audit.Actor("admin", req.Username),
+			Target: req.Username,
+			After:  "failed",
+		})
+		writeError(w, http.StatusUnauthorized, "unauthorized", "invalid username or password")
+		return
+	}
+
+	token, expires, err := a.tokens.Issue(user)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to issue admin token", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "login failed")
+		return
+	}
+	a.record(r.Context(), &audit.Entry{
+		Action: audit.ActionAdminLogin,
+		Actor:  audit.Actor("admin", user.Username),
+		Target: user.Username,
+		After:  "succeeded",
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LoginResponse{Token: token, ExpiresAt: expires, Roles: user.Roles})
+}
+
+// authenticate resolves the caller from a bearer token or the shared API key
+func (a *Authenticator) authenticate(r *http.Request) (*Principal, error) {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && a.tokens != nil {
+		claims, err := a.tokens.Verify(bearer)
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{Username: claims.Subject, Roles: claims.Roles}, nil
+	}
+
+	key := r.Header.Get("X-API-Key")
+	switch {
+	case a.apiKey != "" && key != "" && httpx.ConstantTimeCompare(key, a.apiKey):
+		return &Principal{Username: apiKeyPrincipal, Roles: roles}, nil
+	case key != "":
+		return nil, errors.New("invalid API key")
+	default:
+		return nil, errors.New("bearer token or API key required")
+	}
+}
+
+// record stores an audit entry; a failure is logged but never fails the request that already ran
+func (a *Authenticator) record(ctx context.Context, entry *audit.Entry) {
+	if a.audit == nil {
+		return
+	}
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := a.audit.Record(recordCtx, entry); err != nil {
+		slog.ErrorContext(recordCtx, "Failed to audit admin action",
+			"action", entry.Action, "actor", entry.Actor, "target", entry.Target, "error", err)
+	}
+}
+
+func allowed(granted []string, read bool, writeRoles []string) bool {
+	if read {
+		return HasRole(granted, RoleViewer)
+	}
+	for _, role := range writeRoles {
+		if HasRole(granted, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "message": message})
+}
@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const userCollection = "admin_users"
+
+// Roles of admin users
+const (
+	RoleViewer   = "viewer"   // Reads analytics, job and delivery state
+	RoleEditor   = "editor"   // Changes assistant content such as prompts and personas
+	RoleOperator = "operator" // Runs operations: bulk changes, blocking users, exports, jobs and keys
+)
+
+var roles = []string{RoleViewer, RoleEditor, RoleOperator}
+
+var (
+	// ErrUserExists is returned when creating a user whose username is taken
+	ErrUserExists = errors.New("admin user already exists")
+	// ErrUserNotFound is returned for unknown usernames
+	ErrUserNotFound = errors.New("admin user not found")
+)
+
+// User is an admin allowed to log in to the admin API
+type User struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	Username     string             `bson:"username" json:"username"`
+	PasswordHash string             `bson:"password_hash" json:"-"`
+	Roles        []string           `bson:"roles" json:"roles"`
+	Disabled     bool               `bson:"disabled,omitempty" json:"disabled,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// HasRole reports whether roles grant role; every role may read, so any role grants viewer
+func HasRole(granted []string, role string) bool {
+	if role == RoleViewer {
+		return len(granted) > 0
+	}
+	return slices.Contains(granted, role)
+}
+
+// ParseRoles parses a comma-separated list of roles
+func ParseRoles(s string) ([]string, error) {
+	var parsed []string
+	for _, role := range strings.Split(s, ",") {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if !slices.Contains(roles, role) {
+			return nil, fmt.Errorf("unknown role %q, expected one of %s", role, strings.Join(roles, ", "))
+		}
+		if !slices.Contains(parsed, role) {
+			parsed = append(parsed, role)
+		}
+	}
+	if len(parsed) == 0 {
+		return nil, errors.New("at least one role is required")
+	}
+	return parsed, nil
+}
+
+// NewUser creates a user with a bcrypt hash of the password
+func NewUser(username, password string, roles []string) (*User, error) {
+	if username == "" {
+		return nil, errors.New("username is required")
+	}
+	if len(password) < 12 {
+		return nil, errors.New("password must be at least 12 characters")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	return &User{
+		ID:           primitive.NewObjectID(),
+		Username:     username,
+		PasswordHash: string(hash),
+		Roles:        roles,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// CheckPassword reports whether password is the user's password
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// MongoRepository stores admin users in MongoDB
+// Admin users are global: they are not scoped to a tenant
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB admin user repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+// FindUser returns the user with the username
+func (r *MongoRepository) FindUser(ctx context.Context, username string) (*User, error) {
+	var user User
+	err := r.conn.Collection(userCollection).FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUser stores a new user; returns ErrUserExists when the username is taken
+func (r *MongoRepository) CreateUser(ctx context.Context, user *User) error {
+	res, err := r.conn.Collection(userCollection).UpdateOne(ctx,
+		bson.M{"username": user.Username},
+		bson.M{"$setOnInsert": user},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	if res.UpsertedCount == 0 {
+		return ErrUserExists
+	}
+	return nil
+}
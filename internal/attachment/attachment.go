@@ -0,0 +1,190 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const attachmentCollection = "attachments"
+
+// ErrNotFound is returned when an attachment does not exist in the conversation
+var ErrNotFound = errors.New("attachment not found")
+
+// Attachment is the metadata of a stored file; the content lives in object storage
+type Attachment struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	TenantID       string             `bson:"tenant_id"`
+	ConversationID string             `bson:"conversation_id"`
+	MessageID      string             `bson:"message_id,omitempty"`
+	Filename       string             `bson:"filename"`
+	ContentType    string             `bson:"content_type"`
+	Size           int64              `bson:"size"`
+	SHA256         string             `bson:"sha256"`
+	StorageKey     string             `bson:"storage_key"`
+	CreatedAt      time.Time          `bson:"created_at"`
+}
+
+func (a *Attachment) Proto() *pb.Attachment {
+	return &pb.Attachment{
+		Id:             a.ID.Hex(),
+		ConversationId: a.ConversationID,
+		MessageId:      a.MessageID,
+		Filename:       a.Filename,
+		ContentType:    a.ContentType,
+		Size:           a.Size,
+		Sha256:         a.SHA256,
+		Timestamp:      timestamppb.New(a.CreatedAt),
+	}
+}
+
+// Upload is a file to attach to a conversation
+type Upload struct {
+	ConversationID string
+	MessageID      string
+	Filename       string
+	ContentType    string
+	Content        []byte
+}
+
+// Repository persists attachment metadata
+type Repository interface {
+	CreateAttachment(ctx context.Context, a *Attachment) error
+	// GetAttachment returns the attachment of the context's tenant, or ErrNotFound
+	GetAttachment(ctx context.Context, conversationID, id string) (*Attachment, error)
+}
+
+// Service validates uploads and stores them in object storage
+type Service struct {
+	store  objectstore.Store
+	repo   Repository
+	policy Policy
+}
+
+// NewService creates a new attachment service
+func NewService(store objectstore.Store, repo Repository, policy Policy) *Service {
+	return &Service{
+		store:  store,
+		repo:   repo,
+		policy: policy,
+	}
+}
+
+// Upload checks the file against the policy, stores it and records its metadata
+// Returns an error wrapping ErrRejected when the policy refuses the file
+func (s *Service) Upload(ctx context.Context, upload *Upload) (*Attachment, error) {
+	contentType, err := s.policy.Check(upload.Filename, upload.ContentType, upload.Content)
+	if err != nil {
+		slog.WarnContext(ctx, "Attachment rejected",
+			"conversation_id", upload.ConversationID,
+			"content_type", upload.ContentType,
+			"size", len(upload.Content),
+			"error", err)
+		return nil, err
+	}
+
+	sum := sha256.Sum256(upload.Content)
+	a := &Attachment{
+		ID:             primitive.NewObjectID(),
+		TenantID:       tenant.FromContext(ctx),
+		ConversationID: upload.ConversationID,
+		MessageID:      upload.MessageID,
+		Filename:       upload.Filename,
+		ContentType:    contentType,
+		Size:           int64(len(upload.Content)),
+		SHA256:         hex.EncodeToString(sum[:]),
+		CreatedAt:      time.Now(),
+	}
+	// User-supplied filenames never reach the storage key
+	a.StorageKey = fmt.Sprintf("%s/%s/%s", a.TenantID, a.ConversationID, a.ID.Hex())
+
+	if err := s.store.Put(ctx, a.StorageKey, contentType, upload.Content); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if err := s.repo.CreateAttachment(ctx, a); err != nil {
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Attachment uploaded",
+		"attachment_id", a.ID.Hex(),
+		"conversation_id", a.ConversationID,
+		"content_type", a.ContentType,
+		"size", a.Size)
+
+	return a, nil
+}
+
+// Get returns an attachment of a conversation with its content
+func (s *Service) Get(ctx context.Context, conversationID, id string) (*Attachment, []byte, error) {
+	a, err := s.repo.GetAttachment(ctx, conversationID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := s.store.Get(ctx, a.StorageKey)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+
+	return a, buf.Bytes(), nil
+}
+
+// MongoRepository stores attachment metadata in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB attachment repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+func (r *MongoRepository) CreateAttachment(ctx context.Context, a *Attachment) error {
+	_, err := r.conn.Collection(attachmentCollection).InsertOne(ctx, a)
+	return err
+}
+
+func (r *MongoRepository) GetAttachment(ctx context.Context, conversationID, id string) (*Attachment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var a Attachment
+	err = r.conn.Collection(attachmentCollection).FindOne(ctx, bson.M{
+		"_id":             oid,
+		"conversation_id": conversationID,
+		"tenant_id":       tenant.FromContext(ctx),
+	}).Decode(&a)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
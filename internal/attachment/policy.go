@@ -0,0 +1,129 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrRejected is returned when an upload violates the attachment policy
+var ErrRejected = errors.New("attachment rejected")
+
+// RejectedError explains why an upload was rejected
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "attachment rejected: " + e.Reason
+}
+
+func (e *RejectedError) Unwrap() error {
+	return ErrRejected
+}
+
+func reject(format string, args ...any) error {
+	return &RejectedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// DefaultAllowedTypes are the content types accepted when none are configured
+var DefaultAllowedTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"application/pdf", "text/plain", "text/csv",
+}
+
+// blockedExtensions are executable or active content never accepted, whatever the declared type
+var blockedExtensions = map[string]bool{
+	".exe": true, ".dll": true, ".bat": true, ".cmd": true, ".com": true, ".scr": true, ".msi": true,
+	".ps1": true, ".sh": true, ".vbs": true, ".js": true, ".jar": true, ".apk": true,
+	".html": true, ".htm": true, ".svg": true, ".php": true,
+}
+
+// Policy limits the size and type of uploaded files
+type Policy struct {
+	MaxBytes     int64
+	AllowedTypes []string
+}
+
+// Check validates an upload and returns its normalized content type
+// The filename is screened for path tricks and executable extensions, and the declared
+// content type must be allowed and agree with both the extension and the sniffed content
+func (p Policy) Check(filename, contentType string, content []byte) (string, error) {
+	if err := checkFilename(filename); err != nil {
+		return "", err
+	}
+
+	if len(content) == 0 {
+		return "", reject("file is empty")
+	}
+	if p.MaxBytes > 0 && int64(len(content)) > p.MaxBytes {
+		return "", reject("file exceeds %d bytes", p.MaxBytes)
+	}
+
+	declared, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", reject("invalid content type %q", contentType)
+	}
+	if !p.allowed(declared) {
+		return "", reject("content type %s is not allowed", declared)
+	}
+
+	// The extension must not claim a different type, e.g. "report.pdf" sent as image/png
+	if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); byExt != "" {
+		if extType, _, err := mime.ParseMediaType(byExt); err == nil && extType != declared {
+			return "", reject("extension does not match content type %s", declared)
+		}
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	if sniffed != declared && !(strings.HasPrefix(declared, "text/") && sniffed == "text/plain") {
+		return "", reject("content looks like %s, not %s", sniffed, declared)
+	}
+
+	return declared, nil
+}
+
+func (p Policy) allowed(contentType string) bool {
+	allowed := p.AllowedTypes
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedTypes
+	}
+	for _, t := range allowed {
+		if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFilename(filename string) error {
+	if filename == "" || len(filename) > 255 {
+		return reject("filename must be 1-255 bytes")
+	}
+	if !utf8.ValidString(filename) {
+		return reject("filename is not valid UTF-8")
+	}
+	if strings.ContainsAny(filename, `/\:`) || strings.HasPrefix(filename, ".") {
+		return reject("filename must not contain paths")
+	}
+	for _, r := range filename {
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) {
+			return reject("filename contains control characters")
+		}
+	}
+
+	// Check every extension so "invoice.pdf.exe" and "invoice.exe.pdf" are both caught
+	parts := strings.Split(strings.ToLower(filename), ".")
+	for _, ext := range parts[1:] {
+		if blockedExtensions["."+strings.TrimSpace(ext)] {
+			return reject("file type .%s is not allowed", ext)
+		}
+	}
+
+	return nil
+}
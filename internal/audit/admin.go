@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// Store lists and verifies audit entries, see MongoRepository
+type Store interface {
+	List(ctx context.Context, f Filter) ([]*Entry, error)
+	Verify(ctx context.Context) (*Verification, error)
+}
+
+// AdminHandler exposes the audit log of the request's tenant over HTTP
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	store Store
+}
+
+// NewAdminHandler creates a new audit admin handler
+func NewAdminHandler(store Store) *AdminHandler {
+	return &AdminHandler{store: store}
+}
+
+// ListHandler handles GET /admin/audit?action=admin.request&actor=admin:alice&target=...&since=2024-05-01T00:00:00Z&until=...&limit=100
+func (h *AdminHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := Filter{
+		Action: query.Get("action"),
+		Actor:  query.Get("actor"),
+		Target: query.Get("target"),
+		Limit:  defaultListLimit,
+	}
+
+	for name, field := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": name + " must be an RFC 3339 timestamp"})
+			return
+		}
+		*field = t
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 || limit > maxListLimit {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be between 1 and " + strconv.Itoa(maxListLimit)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.store.List(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list audit entries", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list audit entries"})
+		return
+	}
+	if entries == nil {
+		entries = []*Entry{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+// VerifyHandler handles GET /admin/audit/verify
+// It answers 200 whether or not the chain is intact; "valid" tells which
+func (h *AdminHandler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	result, err := h.store.Verify(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to verify audit chain", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to verify audit chain"})
+		return
+	}
+	if !result.Valid {
+		slog.ErrorContext(r.Context(), "Audit chain verification failed",
+			"broken_at", result.BrokenAt, "problem", result.Problem, "head_seq", result.HeadSeq)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,326 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditCollection = "audit_log"
+	chainCollection = "audit_chain" // Head of each tenant's chain: its last sequence number and hash
+)
+
+// maxAppendAttempts bounds the retries when instances append to the same chain concurrently
+const maxAppendAttempts = 5
+
+// ErrChainConflict is returned when an entry could not be appended because other writers kept advancing the chain
+var ErrChainConflict = errors.New("audit chain kept changing, entry not recorded")
+
+// Actions recorded in the audit log
+const (
+	ActionConversationInstructionsSet     = "conversation.instructions.set"
+	ActionConversationInstructionsCleared = "conversation.instructions.cleared"
+	ActionConversationNoteInjected        = "conversation.note.injected"
+	ActionSessionClaimed                  = "session.claimed"
+	ActionAdminLogin                      = "admin.login"   // After is "succeeded" or "failed"
+	ActionAdminRequest                    = "admin.request" // Target is the method and path, After the response status
+	ActionDataExportRequested             = "data_export.requested"
+)
+
+// Entry is a recorded change; entries are only ever inserted
+type Entry struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	TenantID  string             `bson:"tenant_id" json:"tenant_id"`
+	Action    string             `bson:"action" json:"action"`
+	Actor     string             `bson:"actor,omitempty" json:"actor,omitempty"` // "<platform>:<user_id>" of the user making the change
+	Target    string             `bson:"target" json:"target"`                   // ID of the changed object
+	Before    string             `bson:"before,omitempty" json:"before,omitempty"`
+	After     string             `bson:"after,omitempty" json:"after,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+
+	// Entries of a tenant form a hash chain: each entry's hash covers its contents and the previous entry's hash,
+	// so editing, removing or reordering recorded entries breaks the chain. Entries from before chaining have no Seq
+	Seq      int64  `bson:"seq,omitempty" json:"seq,omitempty"`
+	PrevHash string `bson:"prev_hash,omitempty" json:"prev_hash,omitempty"`
+	Hash     string `bson:"hash,omitempty" json:"hash,omitempty"`
+}
+
+// ComputeHash returns the hex SHA-256 of the entry's contents, sequence number and previous hash
+func (e *Entry) ComputeHash() string {
+	// Fields are hashed in a fixed order; CreatedAt at millisecond precision, as stored by MongoDB
+	content, _ := json.Marshal([]any{
+		e.Seq, e.PrevHash, e.ID.Hex(), e.TenantID, e.Action, e.Actor, e.Target, e.Before, e.After, e.CreatedAt.UnixMilli(),
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verification is the outcome of checking a tenant's audit chain
+type Verification struct {
+	Valid   bool  `json:"valid"`
+	Entries int64 `json:"entries"`  // Chained entries checked
+	HeadSeq int64 `json:"head_seq"` // Sequence number of the last entry appended to the chain
+	// BrokenAt is the sequence number where the chain first fails to verify, with the reason
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Problem  string `json:"problem,omitempty"`
+}
+
+// chainVerifier checks entries in sequence order, one at a time
+type chainVerifier struct {
+	result   Verification
+	prevSeq  int64
+	prevHash string
+}
+
+func (v *chainVerifier) add(e *Entry) bool {
+	v.result.Entries++
+	switch {
+	case e.Seq != v.prevSeq+1:
+		v.fail(v.prevSeq+1, fmt.Sprintf("entry %d is missing", v.prevSeq+1))
+	case e.PrevHash != v.prevHash:
+		v.fail(e.Seq, "previous hash does not match the preceding entry")
+	case e.Hash != e.ComputeHash():
+		v.fail(e.Seq, "hash does not match the entry's contents")
+	default:
+		v.prevSeq, v.prevHash = e.Seq, e.Hash
+		return true
+	}
+	return false
+}
+
+func (v *chainVerifier) fail(seq int64, problem string) {
+	v.result.BrokenAt, v.result.Problem = seq, problem
+}
+
+// finish compares the verified entries with the chain head, which reveals entries removed from the end
+func (v *chainVerifier) finish(headSeq int64, headHash string) Verification {
+	v.result.HeadSeq = headSeq
+	if v.result.Problem == "" {
+		switch {
+		case v.prevSeq < headSeq:
+			v.fail(v.prevSeq+1, fmt.Sprintf("entries %d to %d are missing", v.prevSeq+1, headSeq))
+		case v.prevSeq > headSeq || v.prevHash != headHash:
+			v.fail(v.prevSeq, "last entry does not match the chain head")
+		}
+	}
+	v.result.Valid = v.result.Problem == ""
+	return v.result
+}
+
+// VerifyChain checks chained entries, sorted by sequence number, against the head of their chain
+func VerifyChain(entries []*Entry, headSeq int64, headHash string) Verification {
+	v := &chainVerifier{}
+	for _, e := range entries {
+		if !v.add(e) {
+			break
+		}
+	}
+	return v.finish(headSeq, headHash)
+}
+
+// Actor identifies a platform user in audit entries
+func Actor(platform, userID string) string {
+	if userID == "" {
+		return ""
+	}
+	if platform == "" {
+		return userID
+	}
+	return platform + ":" + userID
+}
+
+// Recorder stores audit entries
+type Recorder interface {
+	Record(ctx context.Context, entry *Entry) error
+}
+
+// chainHead is the last entry appended to a tenant's chain
+type chainHead struct {
+	TenantID string `bson:"_id"`
+	Seq      int64  `bson:"seq"`
+	Hash     string `bson:"hash"`
+}
+
+// MongoRepository stores audit entries in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+	mu   sync.Mutex // Serializes appends of this instance; other instances are caught by the head's compare-and-swap
+}
+
+// NewMongoRepository creates a new MongoDB audit repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+// Record appends an entry to the chain of the context's tenant
+// The chain head is advanced with a compare-and-swap before the entry is inserted, so concurrent
+// instances never reuse a sequence number; an entry lost between the two shows up as missing on verification
+func (r *MongoRepository) Record(ctx context.Context, entry *Entry) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entry.CreatedAt = entry.CreatedAt.Truncate(time.Millisecond)
+	entry.TenantID = tenant.FromContext(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for range maxAppendAttempts {
+		head, err := r.head(ctx, entry.TenantID)
+		if err != nil {
+			return err
+		}
+		entry.Seq, entry.PrevHash = head.Seq+1, head.Hash
+		entry.Hash = entry.ComputeHash()
+
+		advanced, err := r.advanceHead(ctx, head, entry)
+		if err != nil {
+			return err
+		}
+		if advanced {
+			_, err = r.conn.Collection(auditCollection).InsertOne(ctx, entry)
+			return err
+		}
+	}
+	return ErrChainConflict
+}
+
+// head returns the head of the tenant's chain, or an empty head for a new chain
+func (r *MongoRepository) head(ctx context.Context, tenantID string) (*chainHead, error) {
+	head := &chainHead{TenantID: tenantID}
+	err := r.conn.Collection(chainCollection).FindOne(ctx, bson.M{"_id": tenantID}).Decode(head)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return head, nil
+}
+
+// advanceHead moves the head to entry if it is still at head; false means another writer got there first
+func (r *MongoRepository) advanceHead(ctx context.Context, head *chainHead, entry *Entry) (bool, error) {
+	update := bson.M{"$set": bson.M{"seq": entry.Seq, "hash": entry.Hash}}
+	if head.Seq == 0 {
+		// The first entry creates the head; a concurrent creation fails on the duplicate _id
+		_, err := r.conn.Collection(chainCollection).UpdateOne(ctx,
+			bson.M{"_id": head.TenantID, "seq": bson.M{"$exists": false}}, update, options.Update().SetUpsert(true))
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	res, err := r.conn.Collection(chainCollection).UpdateOne(ctx, bson.M{"_id": head.TenantID, "seq": head.Seq}, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// Verify checks the chain of the context's tenant, streaming its entries in sequence order
+func (r *MongoRepository) Verify(ctx context.Context) (*Verification, error) {
+	tenantID := tenant.FromContext(ctx)
+	head, err := r.head(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.conn.Collection(auditCollection).Find(ctx,
+		bson.M{"tenant_id": tenantID, "seq": bson.M{"$gt": 0}},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	v := &chainVerifier{}
+	for cursor.Next(ctx) {
+		var entry Entry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		if !v.add(&entry) {
+			break
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	result := v.finish(head.Seq, head.Hash)
+	return &result, nil
+}
+
+// Filter selects audit entries; zero fields match everything
+type Filter struct {
+	Action string
+	Actor  string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int64
+}
+
+// List returns the entries of the context's tenant matching the filter, newest first
+func (r *MongoRepository) List(ctx context.Context, f Filter) ([]*Entry, error) {
+	query := bson.M{"tenant_id": tenant.FromContext(ctx)}
+	for field, value := range map[string]string{"action": f.Action, "actor": f.Actor, "target": f.Target} {
+		if value != "" {
+			query[field] = value
+		}
+	}
+	created := bson.M{}
+	if !f.Since.IsZero() {
+		created["$gte"] = f.Since
+	}
+	if !f.Until.IsZero() {
+		created["$lt"] = f.Until
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "seq", Value: -1}})
+	if f.Limit > 0 {
+		opts.SetLimit(f.Limit)
+	}
+	cursor, err := r.conn.Collection(auditCollection).Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListByTarget returns the entries of an object of the context's tenant, oldest first
+func (r *MongoRepository) ListByTarget(ctx context.Context, target string) ([]*Entry, error) {
+	cursor, err := r.conn.Collection(auditCollection).Find(ctx,
+		bson.M{"tenant_id": tenant.FromContext(ctx), "target": target},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
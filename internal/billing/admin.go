@@ -0,0 +1,86 @@
+package billing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler exposes billing exports over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	exporter *Exporter
+}
+
+// NewAdminHandler creates a new billing admin handler
+func NewAdminHandler(exporter *Exporter) *AdminHandler {
+	return &AdminHandler{exporter: exporter}
+}
+
+// ExportRequest is the body of export requests
+type ExportRequest struct {
+	Period string `json:"period"` // "daily" or "monthly"
+	Label  string `json:"label"`  // YYYY-MM-DD for daily, YYYY-MM for monthly exports
+}
+
+// ExportHandler handles POST /admin/billing/exports
+func (h *AdminHandler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	var req ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	period, err := ParsePeriod(req.Period, req.Label)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	result, err := h.exporter.Export(r.Context(), period)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Billing export failed", "period", req.Period, "label", req.Label, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "export failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// DownloadHandler handles GET /admin/billing/exports/{name}
+func (h *AdminHandler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	body, err := h.exporter.Open(r.Context(), name)
+	if errors.Is(err, ErrExportNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "export not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to open billing export", "name", name, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to open export"})
+		return
+	}
+	defer body.Close()
+
+	contentType := "application/json"
+	if strings.HasSuffix(name, ".csv") {
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if _, err := io.Copy(w, body); err != nil {
+		slog.WarnContext(r.Context(), "Failed to stream billing export", "name", name, "error", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
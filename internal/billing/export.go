@@ -0,0 +1,213 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+)
+
+// Export periods
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+)
+
+// ErrExportNotFound is returned when an export file does not exist
+var ErrExportNotFound = objectstore.ErrNotFound
+
+var exportNamePattern = regexp.MustCompile(`^usage-(daily|monthly)-[0-9]{4}-[0-9]{2}(-[0-9]{2})?\.(csv|json)$`)
+
+// Period is the UTC time range covered by an export
+type Period struct {
+	Kind  string    `json:"period"`
+	Label string    `json:"label"` // 2006-01-02 for daily, 2006-01 for monthly exports
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+}
+
+// DailyPeriod returns the UTC day containing t
+func DailyPeriod(t time.Time) Period {
+	t = t.UTC()
+	from := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return Period{Kind: PeriodDaily, Label: from.Format("2006-01-02"), From: from, To: from.AddDate(0, 0, 1)}
+}
+
+// MonthlyPeriod returns the UTC month containing t
+func MonthlyPeriod(t time.Time) Period {
+	t = t.UTC()
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Period{Kind: PeriodMonthly, Label: from.Format("2006-01"), From: from, To: from.AddDate(0, 1, 0)}
+}
+
+// ParsePeriod parses a period kind and label, e.g. ("daily", "2024-05-31") or ("monthly", "2024-05")
+func ParsePeriod(kind, label string) (Period, error) {
+	switch kind {
+	case PeriodDaily:
+		t, err := time.Parse("2006-01-02", label)
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid day %q, expected YYYY-MM-DD", label)
+		}
+		return DailyPeriod(t), nil
+	case PeriodMonthly:
+		t, err := time.Parse("2006-01", label)
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid month %q, expected YYYY-MM", label)
+		}
+		return MonthlyPeriod(t), nil
+	default:
+		return Period{}, fmt.Errorf("invalid period %q, expected %s or %s", kind, PeriodDaily, PeriodMonthly)
+	}
+}
+
+// UsageSource aggregates recorded usage
+type UsageSource interface {
+	AggregateUsage(ctx context.Context, from, to time.Time) ([]*UsageSummary, error)
+}
+
+// ExportResult describes a completed export
+type ExportResult struct {
+	Period           Period    `json:"period"`
+	Files            []string  `json:"files"`
+	Rows             int       `json:"rows"`
+	TotalTokens      int64     `json:"total_tokens"`
+	EstimatedCostUSD float64   `json:"estimated_cost_usd"`
+	GeneratedAt      time.Time `json:"generated_at"`
+}
+
+// Exporter writes per-tenant, per-user and per-platform usage with estimated costs as CSV and JSON
+type Exporter struct {
+	source  UsageSource
+	store   objectstore.Store
+	pricing Pricing
+}
+
+// NewExporter creates a new billing exporter writing files to store
+func NewExporter(source UsageSource, store objectstore.Store, pricing Pricing) *Exporter {
+	if pricing == nil {
+		pricing = DefaultPricing()
+	}
+	return &Exporter{
+		source:  source,
+		store:   store,
+		pricing: pricing,
+	}
+}
+
+// Export aggregates usage of the period and writes it to the object store
+// Exports are idempotent: re-running a period overwrites its files
+func (e *Exporter) Export(ctx context.Context, period Period) (*ExportResult, error) {
+	summaries, err := e.source.AggregateUsage(ctx, period.From, period.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate usage: %w", err)
+	}
+
+	result := &ExportResult{Period: period, Rows: len(summaries), GeneratedAt: time.Now().UTC()}
+	for _, s := range summaries {
+		cost, ok := e.pricing.EstimateCost(s.Model, s.PromptTokens, s.CompletionTokens)
+		if !ok {
+			slog.WarnContext(ctx, "No price configured for model, exporting zero cost", "model", s.Model)
+		}
+		s.EstimatedCostUSD = cost
+		result.TotalTokens += s.TotalTokens
+		result.EstimatedCostUSD += cost
+	}
+
+	csvData, err := encodeCSV(summaries)
+	if err != nil {
+		return nil, err
+	}
+	jsonData, err := json.MarshalIndent(struct {
+		*ExportResult
+		Usage []*UsageSummary `json:"usage"`
+	}{result, summaries}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON export: %w", err)
+	}
+
+	base := fmt.Sprintf("usage-%s-%s", period.Kind, period.Label)
+	for _, file := range []struct {
+		name        string
+		contentType string
+		data        []byte
+	}{
+		{base + ".csv", "text/csv", csvData},
+		{base + ".json", "application/json", jsonData},
+	} {
+		if err := e.store.Put(ctx, file.name, file.contentType, file.data); err != nil {
+			return nil, err
+		}
+		result.Files = append(result.Files, file.name)
+	}
+
+	slog.InfoContext(ctx, "Billing export written",
+		"period", period.Kind,
+		"label", period.Label,
+		"rows", result.Rows,
+		"total_tokens", result.TotalTokens,
+		"estimated_cost_usd", result.EstimatedCostUSD)
+
+	return result, nil
+}
+
+// Open returns the contents of an export file
+func (e *Exporter) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	if !ValidExportName(name) {
+		return nil, ErrExportNotFound
+	}
+	return e.store.Get(ctx, name)
+}
+
+// ValidExportName reports whether name is a file written by Export
+func ValidExportName(name string) bool {
+	return exportNamePattern.MatchString(name)
+}
+
+// ExportPrevious exports the day before now (UTC), and the month before on the first of a month
+// It is run shortly after midnight by the cron scheduler
+func (e *Exporter) ExportPrevious(ctx context.Context, now time.Time) error {
+	yesterday := DailyPeriod(now.UTC()).From.Add(-time.Hour)
+	if _, err := e.Export(ctx, DailyPeriod(yesterday)); err != nil {
+		return fmt.Errorf("daily billing export failed: %w", err)
+	}
+	if now.UTC().Day() == 1 {
+		if _, err := e.Export(ctx, MonthlyPeriod(yesterday)); err != nil {
+			return fmt.Errorf("monthly billing export failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func encodeCSV(summaries []*UsageSummary) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{
+		"tenant_id", "user_id", "platform", "model", "key_source",
+		"requests", "prompt_tokens", "completion_tokens", "total_tokens", "estimated_cost_usd",
+	})
+	for _, s := range summaries {
+		w.Write([]string{
+			s.TenantID, s.UserID, s.Platform, s.Model, s.KeySource,
+			strconv.FormatInt(s.Requests, 10),
+			strconv.FormatInt(s.PromptTokens, 10),
+			strconv.FormatInt(s.CompletionTokens, 10),
+			strconv.FormatInt(s.TotalTokens, 10),
+			strconv.FormatFloat(s.EstimatedCostUSD, 'f', 6, 64),
+		})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
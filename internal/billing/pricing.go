@@ -0,0 +1,60 @@
+package billing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ModelPrice is the price of a model in USD per 1K tokens
+type ModelPrice struct {
+	PromptPer1K     float64
+	CompletionPer1K float64
+}
+
+// Pricing maps model names to prices
+type Pricing map[string]ModelPrice
+
+// DefaultPricing returns list prices of the models the assistant uses
+// Estimates only: invoices from the provider remain the source of truth
+func DefaultPricing() Pricing {
+	return Pricing{
+		"gpt-4.1":     {PromptPer1K: 0.002, CompletionPer1K: 0.008},
+		"gpt-4-turbo": {PromptPer1K: 0.01, CompletionPer1K: 0.03},
+		"gpt-4o":      {PromptPer1K: 0.0025, CompletionPer1K: 0.01},
+		"gpt-4o-mini": {PromptPer1K: 0.00015, CompletionPer1K: 0.0006},
+	}
+}
+
+// ParsePricing overrides default prices with "model" -> "prompt/completion" entries
+// e.g. {"gpt-4.1": "0.002/0.008"}
+func ParsePricing(overrides map[string]string) (Pricing, error) {
+	pricing := DefaultPricing()
+	for model, value := range overrides {
+		promptStr, completionStr, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid price for %s: %q, expected prompt/completion", model, value)
+		}
+
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(promptStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt price for %s: %w", model, err)
+		}
+		completion, err := strconv.ParseFloat(strings.TrimSpace(completionStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completion price for %s: %w", model, err)
+		}
+
+		pricing[model] = ModelPrice{PromptPer1K: prompt, CompletionPer1K: completion}
+	}
+	return pricing, nil
+}
+
+// EstimateCost returns the estimated cost in USD, and false when the model has no price
+func (p Pricing) EstimateCost(model string, promptTokens, completionTokens int64) (float64, bool) {
+	price, ok := p[model]
+	if !ok {
+		return 0, false
+	}
+	return float64(promptTokens)/1000*price.PromptPer1K + float64(completionTokens)/1000*price.CompletionPer1K, true
+}
@@ -0,0 +1,216 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const usageCollection = "usage_records"
+
+// Key sources tell whether a request was paid with the tenant's own key or the platform key
+const (
+	KeySourcePlatform = "platform"
+	KeySourceTenant   = "tenant"
+)
+
+// UsageRecord is the token usage of a single OpenAI request, attributed to a tenant
+type UsageRecord struct {
+	TenantID         string    `bson:"tenant_id" json:"tenant_id"`
+	Operation        string    `bson:"operation" json:"operation"`
+	Model            string    `bson:"model" json:"model"`
+	KeySource        string    `bson:"key_source" json:"key_source"`
+	Platform         string    `bson:"platform,omitempty" json:"platform,omitempty"`
+	UserID           string    `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	ConversationID   string    `bson:"conversation_id,omitempty" json:"conversation_id,omitempty"`
+	PromptVersion    string    `bson:"prompt_version,omitempty" json:"prompt_version,omitempty"` // System prompt version of the conversation
+	PromptTokens     int64     `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int64     `bson:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int64     `bson:"total_tokens" json:"total_tokens"`
+	Failed           bool      `bson:"failed,omitempty" json:"failed,omitempty"` // A reply that failed; carries no tokens and is not billed
+	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
+}
+
+// UsageSummary is the aggregated usage of one tenant, user, platform, model and key source
+type UsageSummary struct {
+	TenantID         string  `json:"tenant_id"`
+	UserID           string  `json:"user_id"`
+	Platform         string  `json:"platform"`
+	Model            string  `json:"model"`
+	KeySource        string  `json:"key_source"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// ConversationUsage is the aggregated usage of one conversation
+type ConversationUsage struct {
+	ConversationID   string `json:"conversation_id"`
+	Requests         int64  `json:"requests"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	TotalTokens      int64  `json:"total_tokens"`
+}
+
+// Repository stores usage records in MongoDB
+type Repository struct {
+	conn *mongo.Database
+}
+
+// NewRepository creates a new usage repository
+func NewRepository(conn *mongo.Database) *Repository {
+	return &Repository{conn: conn}
+}
+
+// RecordUsage stores a usage record
+func (r *Repository) RecordUsage(ctx context.Context, record *UsageRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	_, err := r.conn.Collection(usageCollection).InsertOne(ctx, record)
+	return err
+}
+
+// AggregateUsage sums usage recorded in [from, to) per tenant, user, platform, model and key source
+// Failed replies are left out
+func (r *Repository) AggregateUsage(ctx context.Context, from, to time.Time) ([]*UsageSummary, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lt": to}, "failed": bson.M{"$ne": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"tenant_id":  "$tenant_id",
+				"user_id":    "$user_id",
+				"platform":   "$platform",
+				"model":      "$model",
+				"key_source": "$key_source",
+			},
+			"requests":          bson.M{"$sum": 1},
+			"prompt_tokens":     bson.M{"$sum": "$prompt_tokens"},
+			"completion_tokens": bson.M{"$sum": "$completion_tokens"},
+			"total_tokens":      bson.M{"$sum": "$total_tokens"},
+		}}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: "_id.tenant_id", Value: 1},
+			{Key: "_id.user_id", Value: 1},
+			{Key: "_id.platform", Value: 1},
+			{Key: "_id.model", Value: 1},
+		}}},
+	}
+
+	cursor, err := r.conn.Collection(usageCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var summaries []*UsageSummary
+	for cursor.Next(ctx) {
+		var row struct {
+			ID struct {
+				TenantID  string `bson:"tenant_id"`
+				UserID    string `bson:"user_id"`
+				Platform  string `bson:"platform"`
+				Model     string `bson:"model"`
+				KeySource string `bson:"key_source"`
+			} `bson:"_id"`
+			Requests         int64 `bson:"requests"`
+			PromptTokens     int64 `bson:"prompt_tokens"`
+			CompletionTokens int64 `bson:"completion_tokens"`
+			TotalTokens      int64 `bson:"total_tokens"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+
+		summaries = append(summaries, &UsageSummary{
+			TenantID:         row.ID.TenantID,
+			UserID:           row.ID.UserID,
+			Platform:         row.ID.Platform,
+			Model:            row.ID.Model,
+			KeySource:        row.ID.KeySource,
+			Requests:         row.Requests,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			TotalTokens:      row.TotalTokens,
+		})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// SumConversationUsage sums the usage recorded for each of the given conversations of the context's tenant
+// Failed replies are left out; conversations without usage are missing from the result
+func (r *Repository) SumConversationUsage(ctx context.Context, conversationIDs []string) (map[string]*ConversationUsage, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"tenant_id":       tenant.FromContext(ctx),
+			"conversation_id": bson.M{"$in": conversationIDs},
+			"failed":          bson.M{"$ne": true},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":               "$conversation_id",
+			"requests":          bson.M{"$sum": 1},
+			"prompt_tokens":     bson.M{"$sum": "$prompt_tokens"},
+			"completion_tokens": bson.M{"$sum": "$completion_tokens"},
+			"total_tokens":      bson.M{"$sum": "$total_tokens"},
+		}}},
+	}
+
+	cursor, err := r.conn.Collection(usageCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	usage := make(map[string]*ConversationUsage, len(conversationIDs))
+	for cursor.Next(ctx) {
+		var row struct {
+			ConversationID   string `bson:"_id"`
+			Requests         int64  `bson:"requests"`
+			PromptTokens     int64  `bson:"prompt_tokens"`
+			CompletionTokens int64  `bson:"completion_tokens"`
+			TotalTokens      int64  `bson:"total_tokens"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+
+		usage[row.ConversationID] = &ConversationUsage{
+			ConversationID:   row.ConversationID,
+			Requests:         row.Requests,
+			PromptTokens:     row.PromptTokens,
+			CompletionTokens: row.CompletionTokens,
+			TotalTokens:      row.TotalTokens,
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// CountFailedReplies counts the replies of the context's tenant that failed since a time with a system prompt version
+func (r *Repository) CountFailedReplies(ctx context.Context, promptVersion string, since time.Time) (int64, error) {
+	return r.conn.Collection(usageCollection).CountDocuments(ctx, bson.M{
+		"tenant_id":      tenant.FromContext(ctx),
+		"operation":      "reply",
+		"failed":         true,
+		"prompt_version": promptVersion,
+		"created_at":     bson.M{"$gte": since},
+	})
+}
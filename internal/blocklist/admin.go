@@ -0,0 +1,118 @@
+package blocklist
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler exposes tenant blocklist management over HTTP
+// It must be mounted behind admin.Authenticator with a {tenant_id} route variable; writes require RoleOperator
+type AdminHandler struct {
+	service *Service
+}
+
+// NewAdminHandler creates a new blocklist admin handler
+func NewAdminHandler(service *Service) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+// PolicyRequest is the body of blocklist updates; it replaces the whole policy
+type PolicyRequest struct {
+	Blocked List `json:"blocked"`
+	Allowed List `json:"allowed"`
+}
+
+// PolicyResponse is a tenant's policy with the categories it can name
+type PolicyResponse struct {
+	*Policy
+	DefaultCategories []string `json:"default_categories"` // Blocked for every tenant unless allowed
+	Categories        []string `json:"categories"`
+}
+
+// GetHandler handles GET /admin/tenants/{tenant_id}/blocklist
+func (h *AdminHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	policy, err := h.service.Get(tenant.WithTenant(r.Context(), tenantID))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load tenant blocklist", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load blocklist"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.response(policy))
+}
+
+// PutHandler handles PUT /admin/tenants/{tenant_id}/blocklist
+func (h *AdminHandler) PutHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	var req PolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	policy, err := h.service.Set(tenant.WithTenant(r.Context(), tenantID), &Policy{Blocked: req.Blocked, Allowed: req.Allowed})
+	if errors.Is(err, ErrInvalid) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to store tenant blocklist", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store blocklist"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.response(policy))
+}
+
+// DeleteHandler handles DELETE /admin/tenants/{tenant_id}/blocklist
+func (h *AdminHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(tenant.WithTenant(r.Context(), tenantID)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete tenant blocklist", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete blocklist"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.response(&Policy{TenantID: tenantID}))
+}
+
+func (h *AdminHandler) tenantID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := mux.Vars(r)["tenant_id"]
+	if !tenant.ValidID(id) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tenant ID"})
+		return "", false
+	}
+	return id, true
+}
+
+func (h *AdminHandler) response(p *Policy) PolicyResponse {
+	defaults := h.service.defaults
+	if defaults == nil {
+		defaults = []string{}
+	}
+	return PolicyResponse{Policy: p, DefaultCategories: defaults, Categories: Categories()}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,393 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// CategoryProfanity is masked in replies; other categories are forbidden topics that replace the reply
+const CategoryProfanity = "profanity"
+
+// Refusal replaces replies that touch a forbidden topic
+const Refusal = "Sorry, I can't help with that topic."
+
+// cacheKey is scoped to the tenant by the cache
+const cacheKey = "blocklist"
+
+// Limits of a tenant's lists, keeping the compiled patterns small
+const (
+	MaxKeywords      = 500
+	MaxKeywordLength = 100
+)
+
+// categories are the built-in term lists tenants can block or allow by name
+var categories = map[string][]string{
+	CategoryProfanity: {"fuck", "fucking", "motherfucker", "shit", "bullshit", "bitch", "asshole", "bastard", "cunt", "dick"},
+	"violence":        {"kill", "murder", "weapon", "weapons", "gun", "guns", "bomb", "explosive", "explosives", "shooting"},
+	"drugs":           {"cocaine", "heroin", "meth", "methamphetamine", "marijuana", "cannabis", "weed", "mdma", "ecstasy", "fentanyl"},
+	"gambling":        {"casino", "gambling", "betting", "poker", "roulette", "slot machine", "slot machines", "jackpot", "sportsbook"},
+	"politics":        {"election", "elections", "politics", "political", "democrat", "democrats", "republican", "republicans", "parliament", "senator"},
+	"adult":           {"porn", "pornography", "sex", "sexual", "nude", "nudes", "escort", "escorts", "xxx"},
+}
+
+var (
+	// ErrNotFound is returned by repositories when a tenant has no stored policy
+	ErrNotFound = errors.New("blocklist not found")
+	// ErrInvalid is wrapped by validation errors
+	ErrInvalid = errors.New("invalid blocklist")
+	// ErrBlocked is wrapped by errors rejecting messages that contain blocked terms
+	ErrBlocked = errors.New("message blocked")
+)
+
+// Categories returns the names of the built-in categories, sorted
+func Categories() []string {
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidCategory reports whether name is a built-in category
+func ValidCategory(name string) bool {
+	_, ok := categories[name]
+	return ok
+}
+
+// List names terms by keyword and by category
+type List struct {
+	Keywords   []string `json:"keywords,omitempty" bson:"keywords,omitempty"`
+	Categories []string `json:"categories,omitempty" bson:"categories,omitempty"`
+}
+
+// Policy is a tenant's blocklist and allowlist
+// The allowlist lifts categories blocked for every tenant and exempts terms of blocked categories
+type Policy struct {
+	TenantID  string    `json:"tenant_id" bson:"_id"`
+	Blocked   List      `json:"blocked" bson:"blocked"`
+	Allowed   List      `json:"allowed" bson:"allowed"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks that every category is known and keywords are within the limits
+func (p *Policy) Validate() error {
+	for _, list := range []struct {
+		name string
+		List
+	}{{"blocked", p.Blocked}, {"allowed", p.Allowed}} {
+		if len(list.Keywords) > MaxKeywords {
+			return fmt.Errorf("%w: %s lists more than %d keywords", ErrInvalid, list.name, MaxKeywords)
+		}
+		for _, keyword := range list.Keywords {
+			if len(keyword) > MaxKeywordLength {
+				return fmt.Errorf("%w: %s keyword %q is longer than %d characters", ErrInvalid, list.name, keyword, MaxKeywordLength)
+			}
+		}
+		for _, category := range list.Categories {
+			if !ValidCategory(category) {
+				return fmt.Errorf("%w: unknown %s category %q, want one of %s", ErrInvalid, list.name, category,
+					strings.Join(Categories(), ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// normalize lowercases and trims the terms, dropping empty and duplicate ones
+func (p *Policy) normalize() {
+	for _, list := range []*List{&p.Blocked, &p.Allowed} {
+		list.Keywords = normalizeTerms(list.Keywords)
+		list.Categories = normalizeTerms(list.Categories)
+	}
+}
+
+func normalizeTerms(terms []string) []string {
+	var out []string
+	for _, term := range terms {
+		term = strings.ToLower(strings.Join(strings.Fields(term), " "))
+		if term != "" && !slices.Contains(out, term) {
+			out = append(out, term)
+		}
+	}
+	return out
+}
+
+// Match is a blocked term found in a text
+type Match struct {
+	Term     string
+	Category string // Empty for keywords of the tenant
+}
+
+// BlockedError carries the term that rejected a message
+type BlockedError struct {
+	Match Match
+}
+
+func (e *BlockedError) Error() string {
+	if e.Match.Category != "" {
+		return fmt.Sprintf("message mentions %q, blocked as %s", e.Match.Term, e.Match.Category)
+	}
+	return fmt.Sprintf("message mentions blocked keyword %q", e.Match.Term)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// Matcher finds the blocked terms of a policy in texts; matching is case-insensitive on whole words
+type Matcher struct {
+	pattern *regexp.Regexp
+	terms   map[string]string // Term -> category, empty for keywords
+}
+
+// Compile builds the matcher of a policy, with defaults naming the categories blocked for every tenant
+func Compile(p *Policy, defaults []string) *Matcher {
+	if p == nil {
+		p = &Policy{}
+	}
+	blocked := make(map[string]bool)
+	for _, category := range append(slices.Clone(defaults), p.Blocked.Categories...) {
+		blocked[category] = true
+	}
+	for _, category := range p.Allowed.Categories {
+		// The tenant's own blocklist wins over its allowlist
+		if !slices.Contains(p.Blocked.Categories, category) {
+			delete(blocked, category)
+		}
+	}
+
+	terms := make(map[string]string)
+	for category := range blocked {
+		for _, term := range categories[category] {
+			terms[term] = category
+		}
+	}
+	for _, keyword := range p.Allowed.Keywords {
+		delete(terms, strings.ToLower(keyword))
+	}
+	for _, keyword := range p.Blocked.Keywords {
+		terms[strings.ToLower(keyword)] = ""
+	}
+	if len(terms) == 0 {
+		return &Matcher{}
+	}
+
+	quoted := make([]string, 0, len(terms))
+	for term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	// Longer terms first, so "slot machines" is matched whole rather than as "slot machine"
+	sort.Slice(quoted, func(i, j int) bool {
+		if len(quoted[i]) != len(quoted[j]) {
+			return len(quoted[i]) > len(quoted[j])
+		}
+		return quoted[i] < quoted[j]
+	})
+	return &Matcher{
+		pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`),
+		terms:   terms,
+	}
+}
+
+// Find returns the first blocked term in the text
+func (m *Matcher) Find(text string) (Match, bool) {
+	if m.pattern == nil {
+		return Match{}, false
+	}
+	found := m.pattern.FindString(text)
+	if found == "" {
+		return Match{}, false
+	}
+	term := strings.ToLower(found)
+	return Match{Term: term, Category: m.terms[term]}, true
+}
+
+// Redact masks profanity in the text, keeping the first letter of each word, and reports
+// whether the text also touches a forbidden topic
+func (m *Matcher) Redact(text string) (string, bool) {
+	if m.pattern == nil {
+		return text, false
+	}
+	forbidden := false
+	redacted := m.pattern.ReplaceAllStringFunc(text, func(found string) string {
+		if m.terms[strings.ToLower(found)] != CategoryProfanity {
+			forbidden = true
+			return found
+		}
+		runes := []rune(found)
+		return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	})
+	return redacted, forbidden
+}
+
+// Repository persists tenant policies
+type Repository interface {
+	// GetPolicy returns ErrNotFound when the context's tenant has no stored policy
+	GetPolicy(ctx context.Context) (*Policy, error)
+	SavePolicy(ctx context.Context, p *Policy) error
+	DeletePolicy(ctx context.Context) error
+}
+
+// Cache keeps policies close to the request path, see redisx.Cache
+type Cache interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	Set(ctx context.Context, key string, value interface{}) error
+}
+
+type compiledMatcher struct {
+	matcher   *Matcher
+	updatedAt time.Time
+}
+
+// Service reads and updates tenant policies through a cache and enforces them
+// on user messages and replies
+type Service struct {
+	repo     Repository
+	cache    Cache
+	defaults []string
+
+	mu       sync.Mutex
+	matchers map[string]compiledMatcher
+}
+
+// NewService creates a blocklist service; cache may be nil
+// defaults name the categories blocked for every tenant unless its allowlist lifts them
+func NewService(repo Repository, cache Cache, defaults []string) *Service {
+	return &Service{
+		repo:     repo,
+		cache:    cache,
+		defaults: defaults,
+		matchers: make(map[string]compiledMatcher),
+	}
+}
+
+// Get returns the policy of the context's tenant, or an empty policy if none is stored
+func (s *Service) Get(ctx context.Context) (*Policy, error) {
+	if s.cache != nil {
+		var cached Policy
+		err := s.cache.Get(ctx, cacheKey, &cached)
+		if err == nil {
+			return &cached, nil
+		}
+		if !errors.Is(err, redisx.ErrCacheMiss) {
+			slog.WarnContext(ctx, "Failed to read cached blocklist", "error", err)
+		}
+	}
+
+	stored, err := s.repo.GetPolicy(ctx)
+	if errors.Is(err, ErrNotFound) {
+		stored = &Policy{TenantID: tenant.FromContext(ctx)}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load blocklist: %w", err)
+	}
+
+	s.store(ctx, stored)
+	return stored, nil
+}
+
+// Set replaces the policy of the context's tenant
+// Returns an error wrapping ErrInvalid when a category is unknown or a list is too long
+func (s *Service) Set(ctx context.Context, p *Policy) (*Policy, error) {
+	updated := *p
+	updated.normalize()
+	if err := updated.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated.TenantID = tenant.FromContext(ctx)
+	updated.UpdatedAt = time.Now()
+	if err := s.repo.SavePolicy(ctx, &updated); err != nil {
+		return nil, fmt.Errorf("failed to save blocklist: %w", err)
+	}
+
+	s.store(ctx, &updated)
+	slog.InfoContext(ctx, "Tenant blocklist updated",
+		"blocked_keywords", len(updated.Blocked.Keywords),
+		"blocked_categories", updated.Blocked.Categories,
+		"allowed_keywords", len(updated.Allowed.Keywords),
+		"allowed_categories", updated.Allowed.Categories)
+	return &updated, nil
+}
+
+// Delete removes the policy of the context's tenant, leaving only the categories blocked for every tenant
+func (s *Service) Delete(ctx context.Context) error {
+	if err := s.repo.DeletePolicy(ctx); err != nil {
+		return fmt.Errorf("failed to delete blocklist: %w", err)
+	}
+
+	// The empty policy replaces the cached one; its timestamp makes instances recompile their matchers
+	s.store(ctx, &Policy{TenantID: tenant.FromContext(ctx), UpdatedAt: time.Now()})
+	slog.InfoContext(ctx, "Tenant blocklist deleted")
+	return nil
+}
+
+// CheckMessage returns a *BlockedError when a user message contains a blocked term
+// A policy that cannot be loaded is logged and the message passes
+func (s *Service) CheckMessage(ctx context.Context, message string) error {
+	matcher, err := s.matcher(ctx)
+	if err != nil {
+		// Fail open: moderation must not take the service down with its storage
+		slog.WarnContext(ctx, "Failed to load blocklist for message", "error", err)
+		return nil
+	}
+	if match, ok := matcher.Find(message); ok {
+		return &BlockedError{Match: match}
+	}
+	return nil
+}
+
+// FilterReply masks profanity in a reply and replaces a reply touching a forbidden topic with Refusal
+// A policy that cannot be loaded is logged and the reply passes unchanged
+func (s *Service) FilterReply(ctx context.Context, reply string) string {
+	matcher, err := s.matcher(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load blocklist for reply", "error", err)
+		return reply
+	}
+	redacted, forbidden := matcher.Redact(reply)
+	if forbidden {
+		match, _ := matcher.Find(reply)
+		slog.InfoContext(ctx, "Reply replaced for touching a blocked topic", "category", match.Category)
+		return Refusal
+	}
+	return redacted
+}
+
+// matcher returns the compiled policy of the context's tenant, compiling it again when it changed
+func (s *Service) matcher(ctx context.Context) (*Matcher, error) {
+	policy, err := s.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := tenant.FromContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if compiled, ok := s.matchers[id]; ok && compiled.updatedAt.Equal(policy.UpdatedAt) {
+		return compiled.matcher, nil
+	}
+	matcher := Compile(policy, s.defaults)
+	s.matchers[id] = compiledMatcher{matcher: matcher, updatedAt: policy.UpdatedAt}
+	return matcher, nil
+}
+
+func (s *Service) store(ctx context.Context, p *Policy) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Set(ctx, cacheKey, p); err != nil {
+		slog.WarnContext(ctx, "Failed to cache blocklist", "error", err)
+	}
+}
@@ -0,0 +1,46 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const blocklistCollection = "tenant_blocklists"
+
+// MongoRepository stores tenant policies in MongoDB, one document per tenant
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB blocklist repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+func (r *MongoRepository) GetPolicy(ctx context.Context) (*Policy, error) {
+	var p Policy
+	err := r.conn.Collection(blocklistCollection).FindOne(ctx, bson.M{"_id": tenant.FromContext(ctx)}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *MongoRepository) SavePolicy(ctx context.Context, p *Policy) error {
+	_, err := r.conn.Collection(blocklistCollection).ReplaceOne(ctx,
+		bson.M{"_id": p.TenantID}, p, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoRepository) DeletePolicy(ctx context.Context) error {
+	_, err := r.conn.Collection(blocklistCollection).DeleteOne(ctx, bson.M{"_id": tenant.FromContext(ctx)})
+	return err
+}
@@ -0,0 +1,306 @@
+package botguard
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"golang.org/x/time/rate"
+)
+
+// Challenge modes
+const (
+	ChallengeOff       = "off"
+	ChallengePoW       = "pow"
+	ChallengeTurnstile = "turnstile"
+	ChallengeHCaptcha  = "hcaptcha"
+)
+
+// Results of checked challenges
+const (
+	ResultPass    = "pass"
+	ResultFail    = "fail"
+	ResultMissing = "missing"
+	ResultError   = "error" // The provider could not be reached; the request is let through
+)
+
+// Reasons anonymous requests are refused
+const (
+	BlockHoneypot  = "honeypot"
+	BlockThrottled = "throttled"
+	BlockChallenge = "challenge"
+)
+
+var (
+	// ErrChallengeMissing is returned when a request carries no challenge solution
+	ErrChallengeMissing = errors.New("bot challenge solution missing")
+	// ErrChallengeFailed is returned for wrong, expired or reused solutions
+	ErrChallengeFailed = errors.New("bot challenge failed")
+)
+
+// Verifier checks the challenge solution a request carries
+// Errors other than ErrChallengeMissing and ErrChallengeFailed mean the check itself failed
+type Verifier interface {
+	Provider() string
+	Verify(ctx context.Context, r *http.Request) error
+}
+
+// Recorder counts challenges and refused requests, see metrics.Metrics
+type Recorder interface {
+	RecordBotChallenge(ctx context.Context, provider, result string)
+	RecordBotBlocked(ctx context.Context, reason string)
+}
+
+// NewVerifier returns the verifier of a challenge mode, nil for off
+// An empty secret is only accepted for proof-of-work, which then signs with a random per-process key
+func NewVerifier(mode, secret string, difficulty int) (Verifier, error) {
+	switch mode {
+	case "", ChallengeOff:
+		return nil, nil
+	case ChallengePoW:
+		return NewProofOfWork(secret, difficulty, 0), nil
+	case ChallengeTurnstile, ChallengeHCaptcha:
+		captcha, err := NewCaptcha(mode, secret)
+		if err != nil {
+			return nil, err
+		}
+		return captcha, nil
+	default:
+		return nil, errors.New("unknown bot challenge " + mode + ", expected off, pow, turnstile or hcaptcha")
+	}
+}
+
+// Config controls throttling and honeypots
+type Config struct {
+	FingerprintRPS   float64       // Anonymous requests per second per fingerprint, 0 disables throttling
+	FingerprintBurst int           // Burst size of the fingerprint throttle
+	MaxFingerprints  int           // Fingerprints throttled at once, beyond it the least recently seen is forgotten; 0 for the httpx default
+	HoneypotBan      time.Duration // How long clients that requested a honeypot path are refused
+}
+
+// Guard mitigates bots on the public API used by web widgets
+// Requests signed by service-to-service callers are trusted and never guarded, so it must
+// run after httpx.RequestSigner; every other request is anonymous
+// Clients choose the headers their fingerprint is made of, so throttled fingerprints are kept least
+// recently seen last and forgotten like the clients of httpx.RateLimiter
+type Guard struct {
+	cfg      Config
+	verifier Verifier
+	recorder Recorder
+	now      func() time.Time
+	idleTTL  time.Duration // Fingerprints idle this long have a full bucket again and are forgotten
+
+	mu       sync.Mutex
+	limiters map[string]*list.Element // Values are *limiterEntry
+	lru      *list.List
+	banned   map[string]time.Time // Client IP -> end of the honeypot ban
+}
+
+type limiterEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewGuard creates a bot guard; verifier nil disables challenges, recorder nil disables metrics
+func NewGuard(cfg Config, verifier Verifier, recorder Recorder) *Guard {
+	if cfg.HoneypotBan <= 0 {
+		cfg.HoneypotBan = time.Hour
+	}
+	if cfg.MaxFingerprints <= 0 {
+		cfg.MaxFingerprints = httpx.DefaultRateLimiterMaxKeys
+	}
+	idleTTL := time.Minute
+	if cfg.FingerprintRPS > 0 {
+		idleTTL = max(idleTTL, time.Duration(float64(max(cfg.FingerprintBurst, 1))/cfg.FingerprintRPS*float64(time.Second)))
+	}
+	return &Guard{
+		cfg:      cfg,
+		verifier: verifier,
+		recorder: recorder,
+		now:      time.Now,
+		idleTTL:  idleTTL,
+		limiters: make(map[string]*list.Element),
+		lru:      list.New(),
+		banned:   make(map[string]time.Time),
+	}
+}
+
+// Middleware returns an HTTP middleware refusing honeypot visitors, throttling anonymous requests
+// per fingerprint and verifying the challenge on requests for which challenged returns true
+func (g *Guard) Middleware(challenged func(r *http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if httpx.SigningKeyID(ctx) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if g.isBanned(clientIP(r)) {
+				g.block(w, r, BlockHoneypot, http.StatusForbidden, "forbidden", "access denied")
+				return
+			}
+			if !g.allow(fingerprint(r)) {
+				w.Header().Set("Retry-After", "1")
+				g.block(w, r, BlockThrottled, http.StatusTooManyRequests, "rate limit exceeded", "too many requests, please try again later")
+				return
+			}
+
+			if g.verifier != nil && challenged != nil && challenged(r) {
+				err := g.verifier.Verify(ctx, r)
+				result := ResultPass
+				switch {
+				case errors.Is(err, ErrChallengeMissing):
+					result = ResultMissing
+				case errors.Is(err, ErrChallengeFailed):
+					result = ResultFail
+				case err != nil:
+					// A provider outage is not the client's fault; let the request through
+					result = ResultError
+					slog.ErrorContext(ctx, "Bot challenge verification failed", "provider", g.verifier.Provider(), "error", err)
+				}
+				if g.recorder != nil {
+					g.recorder.RecordBotChallenge(ctx, g.verifier.Provider(), result)
+				}
+				switch result {
+				case ResultMissing:
+					g.block(w, r, BlockChallenge, http.StatusForbidden, "challenge_required", err.Error())
+					return
+				case ResultFail:
+					g.block(w, r, BlockChallenge, http.StatusForbidden, "challenge_failed", err.Error())
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HoneypotHandler answers requests for decoy paths no real client requests, and refuses
+// anonymous API access to the client for the configured ban
+func (g *Guard) HoneypotHandler(w http.ResponseWriter, r *http.Request) {
+	ip := clientIP(r)
+	now := g.now()
+
+	g.mu.Lock()
+	for addr, until := range g.banned {
+		if !now.Before(until) {
+			delete(g.banned, addr)
+		}
+	}
+	g.banned[ip] = now.Add(g.cfg.HoneypotBan)
+	g.mu.Unlock()
+
+	slog.WarnContext(r.Context(), "Honeypot path requested",
+		"ip", ip,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"user_agent", r.UserAgent(),
+	)
+	if g.recorder != nil {
+		g.recorder.RecordBotBlocked(r.Context(), BlockHoneypot)
+	}
+	http.NotFound(w, r)
+}
+
+func (g *Guard) isBanned(ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	until, ok := g.banned[ip]
+	return ok && g.now().Before(until)
+}
+
+func (g *Guard) allow(key string) bool {
+	if g.cfg.FingerprintRPS <= 0 {
+		return true
+	}
+	now := g.now()
+	g.mu.Lock()
+	var limiter *rate.Limiter
+	if elem, ok := g.limiters[key]; ok {
+		entry := elem.Value.(*limiterEntry)
+		entry.lastSeen = now
+		g.lru.MoveToFront(elem)
+		limiter = entry.limiter
+	} else {
+		g.evict(now)
+		limiter = rate.NewLimiter(rate.Limit(g.cfg.FingerprintRPS), max(g.cfg.FingerprintBurst, 1))
+		g.limiters[key] = g.lru.PushFront(&limiterEntry{key: key, limiter: limiter, lastSeen: now})
+	}
+	g.mu.Unlock()
+	return limiter.AllowN(now, 1)
+}
+
+// Fingerprints returns the number of fingerprints the guard throttles
+func (g *Guard) Fingerprints() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.limiters)
+}
+
+// evict forgets idle fingerprints, and the least recently seen ones while the guard is full; g.mu must be held
+func (g *Guard) evict(now time.Time) {
+	for elem := g.lru.Back(); elem != nil; elem = g.lru.Back() {
+		entry := elem.Value.(*limiterEntry)
+		if len(g.limiters) < g.cfg.MaxFingerprints && now.Sub(entry.lastSeen) < g.idleTTL {
+			return
+		}
+		g.lru.Remove(elem)
+		delete(g.limiters, entry.key)
+	}
+}
+
+func (g *Guard) block(w http.ResponseWriter, r *http.Request, reason string, status int, code, message string) {
+	slog.WarnContext(r.Context(), "Request refused as bot traffic",
+		"reason", reason,
+		"ip", clientIP(r),
+		"method", r.Method,
+		"path", r.URL.Path,
+		"user_agent", r.UserAgent(),
+	)
+	// Failed challenges are already counted by RecordBotChallenge
+	if g.recorder != nil && reason != BlockChallenge {
+		g.recorder.RecordBotBlocked(r.Context(), reason)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "message": message})
+}
+
+// clientIP returns the client address without the port RemoteAddr carries, see httpx.GetClientIP
+func clientIP(r *http.Request) string {
+	ip := strings.TrimSpace(httpx.GetClientIP(r))
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		return host
+	}
+	return ip
+}
+
+// fingerprint identifies a client by its address and the headers its browser sends
+// Clients behind one NAT with different browsers are throttled separately
+func fingerprint(r *http.Request) string {
+	h := sha256.New()
+	for _, part := range []string{
+		clientIP(r),
+		r.UserAgent(),
+		r.Header.Get("Accept-Language"),
+		r.Header.Get("Accept-Encoding"),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,177 @@
+package botguard
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SolutionHeader carries "<challenge>:<nonce>" for proof-of-work challenges
+const SolutionHeader = "X-Bot-Solution"
+
+// Challenge is a proof-of-work puzzle: find a nonce such that SHA-256 of "<challenge>:<nonce>"
+// starts with Difficulty zero bits
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// ProofOfWork makes clients spend CPU time before starting conversations
+// Challenges are signed, so any instance sharing the secret verifies them; each solution is
+// accepted once per instance
+type ProofOfWork struct {
+	secret     []byte
+	difficulty int
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu   sync.Mutex
+	used map[string]time.Time // Solved challenges until they expire
+}
+
+// NewProofOfWork creates a proof-of-work verifier; challenges expire after ttl
+// An empty secret signs with a random key, so only the issuing instance verifies its challenges
+func NewProofOfWork(secret string, difficulty int, ttl time.Duration) *ProofOfWork {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	if difficulty <= 0 {
+		difficulty = 18
+	}
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &ProofOfWork{
+		secret:     key,
+		difficulty: min(difficulty, 32),
+		ttl:        ttl,
+		now:        time.Now,
+		used:       make(map[string]time.Time),
+	}
+}
+
+// Provider returns the name challenges are recorded under
+func (p *ProofOfWork) Provider() string {
+	return ChallengePoW
+}
+
+// Issue returns a new challenge
+func (p *ProofOfWork) Issue() (*Challenge, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	expires := p.now().Add(p.ttl).Truncate(time.Second)
+	unsigned := fmt.Sprintf("%d.%d.%s", expires.Unix(), p.difficulty, hex.EncodeToString(nonce))
+	return &Challenge{
+		Challenge:  unsigned + "." + p.sign(unsigned),
+		Difficulty: p.difficulty,
+		ExpiresAt:  expires,
+	}, nil
+}
+
+// Verify checks the solution in the X-Bot-Solution header
+func (p *ProofOfWork) Verify(_ context.Context, r *http.Request) error {
+	solution := r.Header.Get(SolutionHeader)
+	if solution == "" {
+		return ErrChallengeMissing
+	}
+	challenge, nonce, ok := strings.Cut(solution, ":")
+	if !ok || nonce == "" {
+		return fmt.Errorf("%w: malformed solution", ErrChallengeFailed)
+	}
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return fmt.Errorf("%w: malformed challenge", ErrChallengeFailed)
+	}
+	unsigned := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(p.sign(unsigned))) {
+		return fmt.Errorf("%w: invalid signature", ErrChallengeFailed)
+	}
+	expiresUnix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed challenge", ErrChallengeFailed)
+	}
+	expires := time.Unix(expiresUnix, 0)
+	now := p.now()
+	if !now.Before(expires) {
+		return fmt.Errorf("%w: challenge expired", ErrChallengeFailed)
+	}
+	// The difficulty is signed into the challenge; challenges issued before it was raised still count
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("%w: malformed challenge", ErrChallengeFailed)
+	}
+	if leadingZeroBits(challenge, nonce) < difficulty {
+		return fmt.Errorf("%w: insufficient work", ErrChallengeFailed)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, reused := p.used[challenge]; reused {
+		return fmt.Errorf("%w: challenge already used", ErrChallengeFailed)
+	}
+	for used, until := range p.used {
+		if !now.Before(until) {
+			delete(p.used, used)
+		}
+	}
+	p.used[challenge] = expires
+	return nil
+}
+
+// ChallengeHandler handles GET /widget/challenge
+func (p *ProofOfWork) ChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	challenge, err := p.Issue()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to issue bot challenge", "error", err)
+		http.Error(w, "failed to issue challenge", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// Solve finds a nonce for a challenge, returning the X-Bot-Solution header value
+func Solve(challenge *Challenge) string {
+	for nonce := 0; ; nonce++ {
+		n := strconv.Itoa(nonce)
+		if leadingZeroBits(challenge.Challenge, n) >= challenge.Difficulty {
+			return challenge.Challenge + ":" + n
+		}
+	}
+}
+
+func (p *ProofOfWork) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(unsigned))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func leadingZeroBits(challenge, nonce string) int {
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	n := 0
+	for _, b := range sum {
+		n += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return n
+}
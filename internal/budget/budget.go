@@ -0,0 +1,121 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Header carries the time in milliseconds a client is willing to wait for the response
+const Header = "X-Request-Budget-Ms"
+
+// Notice is the reply of requests whose budget ran out before any answer was generated
+const Notice = "I could not finish answering within the requested time. Please try again or allow more time."
+
+// ErrExceeded is the cause of contexts whose request budget ran out
+var ErrExceeded = errors.New("request budget exceeded")
+
+// ExceededError is returned by work stopped by the request budget, with what was answered until then
+type ExceededError struct {
+	Partial string // Answer generated before the budget ran out, "" when there is none
+}
+
+func (e *ExceededError) Error() string {
+	return ErrExceeded.Error()
+}
+
+// Is makes errors.Is(err, ErrExceeded) match
+func (e *ExceededError) Is(target error) bool {
+	return target == ErrExceeded
+}
+
+// PartialReply returns the reply of a request stopped by its budget: the partial answer, or Notice
+func PartialReply(err error) (string, bool) {
+	var exceeded *ExceededError
+	if errors.As(err, &exceeded) && exceeded.Partial != "" {
+		return exceeded.Partial, true
+	}
+	if errors.Is(err, ErrExceeded) {
+		return Notice, true
+	}
+	return "", false
+}
+
+type contextKey struct{}
+
+// budget is the deadline of a request and the time kept back to store and send the response
+type budget struct {
+	deadline time.Time
+	reserve  time.Duration
+}
+
+// WithBudget returns a context whose work must be finished within d, of which reserve is kept back
+// for storing and sending the response
+func WithBudget(ctx context.Context, d, reserve time.Duration) context.Context {
+	return context.WithValue(ctx, contextKey{}, budget{deadline: time.Now().Add(d), reserve: reserve})
+}
+
+// Deadline returns the deadline work of the request must finish by, false when the request has no budget
+func Deadline(ctx context.Context) (time.Time, bool) {
+	b, ok := ctx.Value(contextKey{}).(budget)
+	if !ok {
+		return time.Time{}, false
+	}
+	return b.deadline.Add(-b.reserve), true
+}
+
+// Context returns a context cancelled with ErrExceeded at the work deadline of the request's budget;
+// without a budget the context is only cancelled by cancel
+func Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := Deadline(ctx)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadlineCause(ctx, deadline, ErrExceeded)
+}
+
+// ToolContext returns a context for a tool call that leaves half of the remaining budget to the model
+// call answering with the tool's result; a tool that runs out of time fails and the model answers without it
+func ToolContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := Deadline(ctx)
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadlineCause(ctx, time.Now().Add(time.Until(deadline)/2), ErrExceeded)
+}
+
+// Exceeded reports whether the budget of the context ran out
+func Exceeded(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrExceeded)
+}
+
+// Middleware reads the budget of requests from Header; budgets over max are lowered to it
+// Requests without the header have no budget, malformed ones are rejected
+func Middleware(max, reserve time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(Header)
+			if value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || ms <= 0 {
+				slog.WarnContext(r.Context(), "Malformed request budget", "budget", value, "path", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_request_budget","message":"` + Header + ` must be a positive number of milliseconds"}`))
+				return
+			}
+			d := time.Duration(ms) * time.Millisecond
+			if max > 0 && d > max {
+				d = max
+			}
+			next.ServeHTTP(w, r.WithContext(WithBudget(r.Context(), d, reserve)))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler exposes bulk conversation operations over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	service *Service
+}
+
+// NewAdminHandler creates a new bulk operations admin handler
+func NewAdminHandler(service *Service) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+// JobResponse reports a job and its progress
+type JobResponse struct {
+	*Job
+	Progress float64 `json:"progress"`
+}
+
+// StartHandler handles POST /admin/conversations/bulk
+func (h *AdminHandler) StartHandler(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	job, err := h.service.Start(r.Context(), req)
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, ErrBusy):
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to start bulk job", "operation", req.Operation, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to start job"})
+		return
+	}
+
+	status := http.StatusAccepted
+	if req.DryRun {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, JobResponse{Job: job, Progress: job.Progress()})
+}
+
+// StatusHandler handles GET /admin/conversations/bulk/{job_id}
+func (h *AdminHandler) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.Get(r.Context(), mux.Vars(r)["job_id"])
+	if errors.Is(err, ErrJobNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get bulk job", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to get job"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, JobResponse{Job: job, Progress: job.Progress()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,319 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operations that can be applied to conversations in bulk
+const (
+	OperationArchive = "archive"
+	OperationDelete  = "delete"
+	OperationRetag   = "retag"
+	OperationPersona = "persona"
+	OperationPrompt  = "prompt_version" // Migrates conversations to another system prompt version
+)
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// sampleSize is the number of matching conversation IDs returned by dry runs
+const sampleSize = 20
+
+var (
+	// ErrInvalidRequest is wrapped by validation errors
+	ErrInvalidRequest = errors.New("invalid bulk request")
+	// ErrJobNotFound is returned when a job does not exist in the context's tenant
+	ErrJobNotFound = errors.New("job not found")
+	// ErrBusy is returned when too many jobs are already running
+	ErrBusy = errors.New("too many bulk jobs running")
+)
+
+// Request describes a bulk operation on the conversations matching a filter
+type Request struct {
+	Filter        model.ConversationFilter `json:"filter" bson:"filter"`
+	Operation     string                   `json:"operation" bson:"operation"`
+	AddTags       []string                 `json:"add_tags,omitempty" bson:"add_tags,omitempty"`             // retag
+	RemoveTags    []string                 `json:"remove_tags,omitempty" bson:"remove_tags,omitempty"`       // retag
+	Persona       string                   `json:"persona,omitempty" bson:"persona,omitempty"`               // persona; empty clears it
+	PromptVersion string                   `json:"prompt_version,omitempty" bson:"prompt_version,omitempty"` // prompt_version; empty unpins
+	DryRun        bool                     `json:"dry_run,omitempty" bson:"dry_run,omitempty"`               // Only count matching conversations
+}
+
+// Validate checks that the operation is known and has the arguments it needs
+func (r *Request) Validate() error {
+	switch r.Operation {
+	case OperationArchive, OperationDelete, OperationPersona, OperationPrompt:
+	case OperationRetag:
+		if len(r.AddTags) == 0 && len(r.RemoveTags) == 0 {
+			return fmt.Errorf("%w: retag needs add_tags or remove_tags", ErrInvalidRequest)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidRequest, r.Operation)
+	}
+
+	if !r.Filter.CreatedAfter.IsZero() && !r.Filter.CreatedBefore.IsZero() && !r.Filter.CreatedAfter.Before(r.Filter.CreatedBefore) {
+		return fmt.Errorf("%w: created_after must be before created_before", ErrInvalidRequest)
+	}
+	for _, tag := range append(r.AddTags, r.RemoveTags...) {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("%w: tags must not be empty", ErrInvalidRequest)
+		}
+	}
+
+	return nil
+}
+
+// Job tracks the progress of a bulk operation
+type Job struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	TenantID    string             `json:"tenant_id" bson:"tenant_id"`
+	Request     Request            `json:"request" bson:"request"`
+	Status      string             `json:"status" bson:"status"`
+	Matched     int64              `json:"matched" bson:"matched"`     // Conversations matching the filter when the job started
+	Processed   int64              `json:"processed" bson:"processed"` // Conversations the operation was applied to so far
+	Modified    int64              `json:"modified" bson:"modified"`   // Conversations actually changed
+	SampleIDs   []string           `json:"sample_ids,omitempty" bson:"sample_ids,omitempty"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	CompletedAt time.Time          `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// Progress returns the completed share of the job between 0 and 1
+func (j *Job) Progress() float64 {
+	if j.Status == StatusCompleted || j.Matched == 0 {
+		return 1
+	}
+	return min(float64(j.Processed)/float64(j.Matched), 1)
+}
+
+// ConversationStore selects and modifies conversations within the context's tenant
+type ConversationStore interface {
+	CountConversations(ctx context.Context, f model.ConversationFilter) (int64, error)
+	FindConversationRefs(ctx context.Context, f model.ConversationFilter, after primitive.ObjectID, limit int) ([]*model.Conversation, error)
+	ArchiveConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error)
+	DeleteConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error)
+	TagConversations(ctx context.Context, ids []primitive.ObjectID, add, remove []string) (int64, error)
+	SetConversationPersona(ctx context.Context, ids []primitive.ObjectID, persona string) (int64, error)
+	SetConversationPromptVersion(ctx context.Context, ids []primitive.ObjectID, version string) (int64, error)
+}
+
+// SessionCloser drops the active session of a chat so new messages start a new conversation
+type SessionCloser interface {
+	DeleteSession(ctx context.Context, platform, chatID string) error
+}
+
+// JobRepository stores bulk jobs
+type JobRepository interface {
+	SaveJob(ctx context.Context, job *Job) error
+	// GetJob returns ErrJobNotFound unless the job belongs to the context's tenant
+	GetJob(ctx context.Context, id string) (*Job, error)
+}
+
+// Service runs bulk operations on conversations as background jobs
+type Service struct {
+	store     ConversationStore
+	jobs      JobRepository
+	sessions  SessionCloser
+	batchSize int
+	slots     chan struct{}
+}
+
+// NewService creates a bulk operation service running at most maxJobs jobs at a time
+// sessions may be nil when archived or deleted conversations need no session cleanup
+func NewService(store ConversationStore, jobs JobRepository, sessions SessionCloser, batchSize, maxJobs int) *Service {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if maxJobs <= 0 {
+		maxJobs = 2
+	}
+
+	return &Service{
+		store:     store,
+		jobs:      jobs,
+		sessions:  sessions,
+		batchSize: batchSize,
+		slots:     make(chan struct{}, maxJobs),
+	}
+}
+
+// Start validates the request and starts a job for it
+// Dry runs complete immediately with the number of matching conversations and a sample of their IDs
+func (s *Service) Start(ctx context.Context, req Request) (*Job, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	matched, err := s.store.CountConversations(ctx, req.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count conversations: %w", err)
+	}
+
+	job := &Job{
+		ID:        primitive.NewObjectID(),
+		TenantID:  tenant.FromContext(ctx),
+		Request:   req,
+		Status:    StatusRunning,
+		Matched:   matched,
+		CreatedAt: time.Now(),
+	}
+
+	if req.DryRun {
+		sample, err := s.store.FindConversationRefs(ctx, req.Filter, primitive.NilObjectID, sampleSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
+		for _, conv := range sample {
+			job.SampleIDs = append(job.SampleIDs, conv.ID.Hex())
+		}
+		job.Status = StatusCompleted
+		job.CompletedAt = time.Now()
+		if err := s.jobs.SaveJob(ctx, job); err != nil {
+			return nil, fmt.Errorf("failed to save job: %w", err)
+		}
+		return job, nil
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		return nil, ErrBusy
+	}
+
+	if err := s.jobs.SaveJob(ctx, job); err != nil {
+		<-s.slots
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Bulk job started",
+		"job_id", job.ID.Hex(),
+		"operation", req.Operation,
+		"matched", matched)
+
+	// The job outlives the admin request that started it
+	snapshot := *job
+	go func() {
+		defer func() { <-s.slots }()
+		s.run(tenant.WithTenant(context.WithoutCancel(ctx), job.TenantID), &snapshot)
+	}()
+
+	return job, nil
+}
+
+// Get returns a job of the context's tenant
+func (s *Service) Get(ctx context.Context, id string) (*Job, error) {
+	return s.jobs.GetJob(ctx, id)
+}
+
+// run applies the operation batch by batch, saving progress after each one
+func (s *Service) run(ctx context.Context, job *Job) {
+	start := time.Now()
+	after := primitive.NilObjectID
+
+	for {
+		batch, err := s.store.FindConversationRefs(ctx, job.Request.Filter, after, s.batchSize)
+		if err != nil {
+			s.fail(ctx, job, fmt.Errorf("failed to list conversations: %w", err))
+			return
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		modified, err := s.apply(ctx, job.Request, batch)
+		if err != nil {
+			s.fail(ctx, job, err)
+			return
+		}
+
+		after = batch[len(batch)-1].ID
+		job.Processed += int64(len(batch))
+		job.Modified += modified
+		if err := s.jobs.SaveJob(ctx, job); err != nil {
+			slog.WarnContext(ctx, "Failed to save bulk job progress", "job_id", job.ID.Hex(), "error", err)
+		}
+	}
+
+	job.Status = StatusCompleted
+	job.CompletedAt = time.Now()
+	if err := s.jobs.SaveJob(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "Failed to save completed bulk job", "job_id", job.ID.Hex(), "error", err)
+	}
+
+	slog.InfoContext(ctx, "Bulk job completed",
+		"job_id", job.ID.Hex(),
+		"operation", job.Request.Operation,
+		"processed", job.Processed,
+		"modified", job.Modified,
+		"duration_ms", time.Since(start).Milliseconds())
+}
+
+func (s *Service) apply(ctx context.Context, req Request, batch []*model.Conversation) (int64, error) {
+	ids := make([]primitive.ObjectID, len(batch))
+	for i, conv := range batch {
+		ids[i] = conv.ID
+	}
+
+	var modified int64
+	var err error
+	switch req.Operation {
+	case OperationArchive:
+		modified, err = s.store.ArchiveConversations(ctx, ids)
+	case OperationDelete:
+		modified, err = s.store.DeleteConversations(ctx, ids)
+	case OperationRetag:
+		modified, err = s.store.TagConversations(ctx, ids, req.AddTags, req.RemoveTags)
+	case OperationPersona:
+		modified, err = s.store.SetConversationPersona(ctx, ids, req.Persona)
+	case OperationPrompt:
+		modified, err = s.store.SetConversationPromptVersion(ctx, ids, req.PromptVersion)
+	}
+	if err != nil {
+		return modified, fmt.Errorf("failed to %s conversations: %w", req.Operation, err)
+	}
+
+	if req.Operation == OperationArchive || req.Operation == OperationDelete {
+		s.closeSessions(ctx, batch)
+	}
+
+	return modified, nil
+}
+
+// closeSessions stops chats from continuing in conversations that were archived or deleted
+func (s *Service) closeSessions(ctx context.Context, batch []*model.Conversation) {
+	if s.sessions == nil {
+		return
+	}
+	for _, conv := range batch {
+		if conv.Platform == "" || conv.ChatID == "" {
+			continue
+		}
+		if err := s.sessions.DeleteSession(ctx, conv.Platform, conv.ChatID); err != nil {
+			slog.WarnContext(ctx, "Failed to close session", "conversation_id", conv.ID.Hex(), "error", err)
+		}
+	}
+}
+
+func (s *Service) fail(ctx context.Context, job *Job, err error) {
+	slog.ErrorContext(ctx, "Bulk job failed", "job_id", job.ID.Hex(), "processed", job.Processed, "error", err)
+
+	job.Status = StatusFailed
+	job.Error = err.Error()
+	job.CompletedAt = time.Now()
+	if err := s.jobs.SaveJob(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "Failed to save failed bulk job", "job_id", job.ID.Hex(), "error", err)
+	}
+}
@@ -0,0 +1,50 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const jobCollection = "bulk_jobs"
+
+// MongoRepository stores bulk jobs in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB bulk job repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+func (r *MongoRepository) SaveJob(ctx context.Context, job *Job) error {
+	_, err := r.conn.Collection(jobCollection).ReplaceOne(ctx,
+		bson.M{"_id": job.ID}, job, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoRepository) GetJob(ctx context.Context, id string) (*Job, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	var job Job
+	err = r.conn.Collection(jobCollection).FindOne(ctx, bson.M{
+		"_id":       oid,
+		"tenant_id": tenant.FromContext(ctx),
+	}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -6,43 +6,104 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/budget"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/grounding"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/injection"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/smalltalk"
 	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/costcap"
+	"github.com/8adimka/Go_AI_Assistant/internal/degradation"
+	"github.com/8adimka/Go_AI_Assistant/internal/errorsx"
+	"github.com/8adimka/Go_AI_Assistant/internal/experiment"
 	"github.com/8adimka/Go_AI_Assistant/internal/metrics"
+	"github.com/8adimka/Go_AI_Assistant/internal/prewarm"
 	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
 	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/style"
 	"github.com/8adimka/Go_AI_Assistant/internal/tokens"
 	"github.com/8adimka/Go_AI_Assistant/internal/tools/factory"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/pinfact"
 	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
 	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
 )
 
 // UnifiedAssistant provides comprehensive context management with AI summarization
 type UnifiedAssistant struct {
 	cli            openai.Client
+	warmer         *prewarm.Warmer // Keeps connections to OpenAI open, nil when pre-warming is disabled
 	cache          *redisx.Cache
 	toolRegistry   *registry.ToolRegistry
 	retryConfig    retry.RetryConfig
 	metrics        *metrics.Metrics
 	promptManager  *PromptManager
 	contextManager chat.ContextManagerInterface
+	summarizer     *StreamingSummarizer
 	cfg            *config.Config
-	fallbackMode   bool // Graceful degradation mode
+	grounding      *grounding.Policy
+	smalltalk      *smalltalk.Classifier
+	historyWindows *chat.HistoryWindows // Context limits per platform and persona
+	injection      *injection.Detector
+	usage          UsageRecorder
+	credentials    CredentialResolver
+	rollouts       PromptRollouts
+	turns          TurnRecorder
+	calibrator     *tokens.Calibrator   // Corrects pre-flight token estimates, nil when calibration is disabled
+	degradation    *degradation.Manager // Switches to the fallback model while OpenAI is failing
+	costCaps       *costcap.Policy      // Downgrades or refuses conversations over their spend cap, nil when uncapped
+	fallbackMode   bool                 // Graceful degradation mode
+}
+
+// Option configures optional assistant behaviour
+type Option func(*UnifiedAssistant)
+
+// WithUsageRecorder attributes OpenAI token usage to tenants for billing
+func WithUsageRecorder(recorder UsageRecorder) Option {
+	return func(ua *UnifiedAssistant) {
+		ua.usage = recorder
+	}
+}
+
+// WithCredentialResolver resolves tenant API keys for work running outside a request, e.g. batched titles
+func WithCredentialResolver(resolver CredentialResolver) Option {
+	return func(ua *UnifiedAssistant) {
+		ua.credentials = resolver
+	}
+}
+
+// PromptRollouts picks the system prompt version of new conversations while a version is rolled out, see rollout.Service
+type PromptRollouts interface {
+	Select(ctx context.Context, conversationID string) (string, bool)
+}
+
+// WithPromptRollouts gives new conversations the candidate system prompt version of running rollouts
+func WithPromptRollouts(rollouts PromptRollouts) Option {
+	return func(ua *UnifiedAssistant) {
+		ua.rollouts = rollouts
+	}
 }
 
 // New creates a new unified assistant with enhanced context management
-func New(appMetrics *metrics.Metrics) *UnifiedAssistant {
+func New(appMetrics *metrics.Metrics, opts ...Option) *UnifiedAssistant {
 	// Load configuration
 	cfg := config.Load()
 	redisClient := redisx.MustConnect(cfg.RedisAddr)
+	// Tenants with a storage profile keep their cached replies and context in the Redis of that profile
+	residency := redisx.WithRouter(redisx.MustConnectRouter(cfg.ProfileRedisAddrs(), cfg.TenantStorageProfiles))
 
 	// Use configurable cache TTL from config
 	cacheTTL := time.Duration(cfg.CacheTTLHours) * time.Hour
-	cache := redisx.NewCache(redisClient, cacheTTL)
+	cacheCodec := redisx.WithCodec(redisx.MustCodec(cfg.CacheCodec))
+	cache := redisx.NewCache(redisClient, cacheTTL, cacheCodec, residency)
 
 	// Create tool registry with all available tools
 	toolFactory := factory.NewFactory(cfg)
@@ -56,14 +117,38 @@ func New(appMetrics *metrics.Metrics) *UnifiedAssistant {
 	if cfg.MaxContextTokens > 0 {
 		maxTokens = cfg.MaxContextTokens
 	}
-	maxHistory := 50 // Maximum number of messages to keep
+	maxHistory := 50 // Maximum number of messages to keep, unless HISTORY_WINDOWS sets another for the platform
 
-	// Create Redis cache for context management with configurable TTL
-	contextTTL := time.Duration(cfg.CacheTTLHours) * time.Hour
-	contextCache := redisx.NewCache(redisClient, contextTTL)
+	// Context has its own TTL, refreshed on every reply, so history does not expire with the short-lived caches
+	contextTTL := time.Duration(cfg.ContextTTLHours) * time.Hour
+	if contextTTL <= 0 {
+		contextTTL = time.Duration(cfg.CacheTTLHours) * time.Hour
+	}
+	contextCache, err := chat.NewContextStore(chat.StorageConfig{
+		Strategy:      chat.StorageStrategy(cfg.ContextStorage),
+		TTL:           contextTTL,
+		LocalTTL:      time.Duration(cfg.ContextMemoryTTLSeconds) * time.Second,
+		MemoryEntries: cfg.ContextMemoryEntries,
+	}, redisx.NewCache(redisClient, contextTTL, cacheCodec, residency))
+	if err != nil {
+		panic(err)
+	}
 
 	// Use the actual OpenAI client for summarization
-	openAIClient := openai.NewClient()
+	// All requests share the process-wide limiter so rate limit headers slow down every caller
+	// Spend is attributed to the configured organization and project, see requestOptions for tenant keys
+	httpClient, warmer := openAIHTTPClient(cfg, appMetrics)
+	clientOpts := []option.RequestOption{
+		option.WithHTTPClient(httpClient),
+		option.WithMiddleware(retry.SharedLimiter().Middleware),
+	}
+	if cfg.OpenAIOrganization != "" {
+		clientOpts = append(clientOpts, option.WithOrganization(cfg.OpenAIOrganization))
+	}
+	if cfg.OpenAIProject != "" {
+		clientOpts = append(clientOpts, option.WithProject(cfg.OpenAIProject))
+	}
+	openAIClient := openai.NewClient(clientOpts...)
 
 	// Create token counter for precise token counting
 	tokenCounter, err := tokens.NewTokenCounter(cfg.OpenAIModel)
@@ -72,24 +157,119 @@ func New(appMetrics *metrics.Metrics) *UnifiedAssistant {
 		tokenCounter = nil
 	}
 
-	// Use context manager with Redis storage and token counter
-	contextManager := chat.NewContextManager(
+	ua := &UnifiedAssistant{
+		cli:           openAIClient,
+		warmer:        warmer,
+		cache:         cache,
+		toolRegistry:  toolRegistry,
+		retryConfig:   retry.ConfigFromAppConfig(cfg),
+		metrics:       appMetrics,
+		promptManager: promptManager,
+		cfg:           cfg,
+		grounding:     grounding.NewPolicy(cfg.StrictFactsPlatforms),
+		smalltalk:     smalltalk.NewClassifier(cfg.WarmPathMaxChars),
+		degradation:   degradation.Shared(),
+	}
+	if ua.historyWindows, err = chat.ParseHistoryWindows(cfg.HistoryWindows, cfg.PersonaHistoryWindows); err != nil {
+		panic(err)
+	}
+	pricing, err := billing.ParsePricing(cfg.BillingModelPrices)
+	if err != nil {
+		panic(err)
+	}
+	ua.costCaps, err = costcap.NewPolicy(costcap.Config{
+		Default:  costcap.Cap{Tokens: int64(cfg.ConversationTokenCap), CostUSD: cfg.ConversationCostCapUSD},
+		Personas: cfg.PersonaCostCaps,
+		Action:   cfg.CostCapAction,
+		Model:    cfg.CostCapModel,
+		Pricing:  pricing,
+	})
+	if err != nil {
+		panic(err)
+	}
+	if cfg.TokenCalibrationWeight > 0 {
+		ua.calibrator = tokens.NewCalibrator(cfg.TokenCalibrationWeight, cfg.TokenCalibrationWarmup)
+	}
+
+	// Use context manager with the configured storage and token counter
+	// Messages dropped to fit the model are replaced by rolling segment summaries from a cheaper model
+	summarizer := NewStreamingSummarizer(ua, cfg.SummaryModel,
+		time.Duration(cfg.SummaryTimeoutSeconds)*time.Second, tokenCounter)
+	ua.summarizer = summarizer
+	// Sampled summaries are scored by a cheap model; unfaithful ones fall back to basic reduction
+	contextOpts := []chat.ContextManagerOption{
+		chat.WithSummarizer(summarizer, cfg.SummaryMaxTokens, cfg.SummarySegmentMessages),
+	}
+	if appMetrics != nil {
+		contextOpts = append(contextOpts, chat.WithSummaryRecorder(appMetrics))
+	}
+	if cfg.SummaryJudgeSampleRate > 0 {
+		contextOpts = append(contextOpts, chat.WithSummaryJudge(NewSummaryJudge(ua, cfg.SummaryJudgeModel),
+			cfg.SummaryJudgeSampleRate, cfg.SummaryJudgeMinScore))
+	}
+	ua.contextManager = chat.NewContextManager(
 		contextCache,
 		maxTokens,
 		maxHistory,
 		tokenCounter,
+		contextOpts...,
 	)
+	ua.injection = ua.newInjectionDetector()
+	// Facts the model pins are kept by the context manager through summarization and truncation
+	toolRegistry.Register(pinfact.New(ua.contextManager))
+	for _, opt := range opts {
+		opt(ua)
+	}
+
+	return ua
+}
+
+// titleCacheKey returns the cache key of a conversation's title
+// Titles are cached per conversation, so users asking the same first question never share one
+func (ua *UnifiedAssistant) titleCacheKey(conversationID string) string {
+	return ua.cache.GenerateKey("title", conversationID)
+}
+
+// InvalidateTitle drops the cached title of a conversation, so the next Title call generates a new one
+func (ua *UnifiedAssistant) InvalidateTitle(ctx context.Context, conversationID string) error {
+	return ua.cache.Delete(ctx, ua.titleCacheKey(conversationID))
+}
 
-	return &UnifiedAssistant{
-		cli:            openAIClient,
-		cache:          cache,
-		toolRegistry:   toolRegistry,
-		retryConfig:    retry.ConfigFromAppConfig(cfg),
-		metrics:        appMetrics,
-		promptManager:  promptManager,
-		contextManager: contextManager,
-		cfg:            cfg,
+// RegenerateTitle generates a new title for a conversation instead of returning the cached one
+func (ua *UnifiedAssistant) RegenerateTitle(ctx context.Context, conv *model.Conversation) (string, error) {
+	if err := ua.InvalidateTitle(ctx, conv.ID.Hex()); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate cached title", "conversation_id", conv.ID.Hex(), "error", err)
 	}
+	return ua.Title(ctx, conv)
+}
+
+// InvalidatingTitles wraps a title store so every stored title drops the conversation's cached one
+// Once a title is stored the conversation's subject is the source of truth; a cached title outliving it
+// would be returned instead of a regenerated one after the title changed
+func (ua *UnifiedAssistant) InvalidatingTitles(store TitleStore) TitleStore {
+	return &invalidatingTitleStore{TitleStore: store, assistant: ua}
+}
+
+type invalidatingTitleStore struct {
+	TitleStore
+	assistant *UnifiedAssistant
+}
+
+func (s *invalidatingTitleStore) UpdateConversationTitle(ctx context.Context, id string, title string) error {
+	if err := s.TitleStore.UpdateConversationTitle(ctx, id, title); err != nil {
+		return err
+	}
+	if err := s.assistant.InvalidateTitle(ctx, id); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate cached title", "conversation_id", id, "error", err)
+	}
+	return nil
+}
+
+// WarmPrompts refreshes the cached prompts, see PromptManager.Warm
+func (ua *UnifiedAssistant) WarmPrompts(ctx context.Context) error {
+	warmed, err := ua.promptManager.Warm(ctx)
+	slog.InfoContext(ctx, "Prompt cache warmed", "prompts", warmed)
+	return err
 }
 
 // Title generates a conversation title with enhanced logging
@@ -97,6 +277,7 @@ func (ua *UnifiedAssistant) Title(ctx context.Context, conv *model.Conversation)
 	if len(conv.Messages) == 0 {
 		return "An empty conversation", nil
 	}
+	ctx = ua.withSpendTag(ctx, conv)
 
 	slog.InfoContext(ctx, "Generating title for conversation",
 		"conversation_id", conv.ID.Hex(),
@@ -106,7 +287,7 @@ func (ua *UnifiedAssistant) Title(ctx context.Context, conv *model.Conversation)
 
 	// Try to get from cache first
 	userMessage := conv.Messages[0].Content
-	cacheKey := ua.cache.GenerateKey("title", userMessage)
+	cacheKey := ua.titleCacheKey(conv.ID.Hex())
 
 	var cachedTitle string
 	if err := ua.cache.Get(ctx, cacheKey, &cachedTitle); err == nil {
@@ -120,31 +301,31 @@ func (ua *UnifiedAssistant) Title(ctx context.Context, conv *model.Conversation)
 	}
 
 	// Get title generation prompt from prompt manager
-	titlePrompt, err := ua.promptManager.GetPromptWithPlatform(ctx, model.PromptNameTitleGeneration, conv.Platform, conv.UserID)
+	titlePrompt, err := ua.resolvePrompt(ctx, model.PromptNameTitleGeneration, conv.Platform, conv.UserID)
 	if err != nil {
-		slog.WarnContext(ctx, "Failed to get title prompt, using fallback", "error", err)
-		// Use fallback prompt from manager
-		titlePrompt, err = ua.promptManager.GetFallbackPrompt(model.PromptNameTitleGeneration)
-		if err != nil {
-			return "", fmt.Errorf("failed to get fallback title prompt: %w", err)
-		}
+		return "", fmt.Errorf("failed to get fallback title prompt: %w", err)
 	}
 
 	msgs := []openai.ChatCompletionMessageParamUnion{
-		openai.SystemMessage(titlePrompt),
+		openai.SystemMessage(titlePrompt.Content),
 		openai.UserMessage(userMessage),
 	}
+	titleModel, degraded := ua.degradation.ChatModel(openai.ChatModelGPT4Turbo) // Faster model for titles
+	params := openai.ChatCompletionNewParams{
+		Model:     titleModel,
+		Messages:  msgs,
+		MaxTokens: openai.Int(30), // Limit tokens for brevity
+	}
+	titlePrompt.Apply(&params)
+	ua.withPromptCacheKey(&params, titlePrompt.Content)
 
 	// Use retry logic for OpenAI API call with timing
 	start := time.Now()
 	resp, err := retry.RetryWithResult(ctx, ua.retryConfig, func() (*openai.ChatCompletion, error) {
-		return ua.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-			Model:     openai.ChatModelGPT4Turbo, // Faster model for titles
-			Messages:  msgs,
-			MaxTokens: openai.Int(30), // Limit tokens for brevity
-		})
+		return ua.cli.Chat.Completions.New(ctx, params, ua.requestOptions(ctx)...)
 	})
 	duration := time.Since(start)
+	ua.reportOpenAI(degraded, err)
 
 	if err != nil {
 		return "", err
@@ -156,15 +337,17 @@ func (ua *UnifiedAssistant) Title(ctx context.Context, conv *model.Conversation)
 
 	// Record OpenAI metrics with token usage
 	if ua.metrics != nil {
-		ua.metrics.RecordOpenAIRequestWithTokens(ctx, "title", string(openai.ChatModelGPT4Turbo),
+		ua.metrics.RecordOpenAIRequestWithTokens(ctx, "title", titleModel,
 			conv.UserID, conv.Platform, duration,
 			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
 	}
+	ua.recordUsage(ctx, "title", titleModel, conv, resp.Usage)
+	ua.recordPromptCache(ctx, "title", titleModel, resp.Usage)
 
 	// Log OpenAI API call with token usage
 	slog.InfoContext(ctx, "OpenAI API call completed",
 		"operation", "title",
-		"model", openai.ChatModelGPT4Turbo,
+		"model", titleModel,
 		"conversation_id", conv.ID.Hex(),
 		"user_id", conv.UserID,
 		"platform", conv.Platform,
@@ -186,7 +369,20 @@ func (ua *UnifiedAssistant) Title(ctx context.Context, conv *model.Conversation)
 }
 
 // Reply generates a reply with intelligent context management and AI summarization
+// With a turn recorder configured, the LLM exchanges and tool calls of the reply are recorded for replay
 func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	ctx = ua.withSpendTag(ctx, conv)
+	ctx, rec := ua.startTurn(ctx, conv)
+	reply, err := ua.reply(ctx, conv)
+	ua.saveTurn(ctx, rec, reply, err)
+	// Replies abandoned by the client or superseded by a newer message did not fail
+	if err != nil && ctx.Err() == nil {
+		ua.recordFailure(ctx, "reply", conv)
+	}
+	return reply, err
+}
+
+func (ua *UnifiedAssistant) reply(ctx context.Context, conv *model.Conversation) (string, error) {
 	if len(conv.Messages) == 0 {
 		return "", errors.New("conversation has no messages")
 	}
@@ -196,34 +392,104 @@ func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation)
 		"user_id", conv.UserID,
 		"platform", conv.Platform,
 		"messages_count", len(conv.Messages),
+		"spend_tag", spendTag(ctx),
 	)
 
 	// Get system prompt from prompt manager
-	systemPrompt, err := ua.promptManager.GetPromptWithPlatform(ctx, model.PromptNameSystemPrompt, conv.Platform, conv.UserID)
+	// A persona assigned to the conversation takes the place of the user segment
+	segment := conv.UserID
+	if conv.Persona != "" {
+		segment = conv.Persona
+	}
+	prompt, err := ua.conversationPrompt(ctx, conv, segment)
 	if err != nil {
-		slog.WarnContext(ctx, "Failed to get system prompt, using fallback", "error", err)
-		// Use fallback prompt from manager
-		systemPrompt, err = ua.promptManager.GetFallbackPrompt(model.PromptNameSystemPrompt)
-		if err != nil {
-			return "", fmt.Errorf("failed to get fallback system prompt: %w", err)
+		return "", fmt.Errorf("failed to get fallback system prompt: %w", err)
+	}
+	// While OpenAI is failing, the whole reply uses the fallback model
+	replyModel, degraded := ua.degradation.ChatModel(openai.ChatModelGPT4_1)
+	// Conversations over their spend cap reply with a cheaper model, or not at all
+	if decision := ua.costCaps.Check(conv); decision.Action != "" {
+		ua.recordCostCap(ctx, conv, decision)
+		if decision.Action == costcap.ActionRefuse {
+			return costcap.Refusal, nil
 		}
+		replyModel = decision.Model
+	}
+	generation := experiment.FromContext(ctx)
+	if generation != nil {
+		generation.Variant = model.Variant{Persona: conv.Persona, PromptVersion: prompt.Version, Model: replyModel}
+	}
+	// A language chosen for the conversation wins over the user's preferred language, and both over
+	// the language the user writes in, which summaries of older messages would otherwise lose
+	trackLanguage(conv)
+	prefs := settings.FromContext(ctx)
+	var preferred string
+	if prefs != nil {
+		preferred = prefs.Language
+	}
+	language := conv.ReplyLanguage(preferred)
+	// The system prompt is the same for every conversation with its version and language, so OpenAI reads it
+	// from the prompt cache; instructions of the user, conversation and reply follow it in a message of their own
+	systemPrompt, statesLanguage := renderSystemPrompt(ctx, prompt, language)
+	var instructions string
+	if language != "" && !statesLanguage {
+		instructions += fmt.Sprintf("\n\nAlways reply in the language with code %q, whatever language the user writes in.", language)
+	}
+	if own := prefs.Instructions(); own != "" {
+		instructions += "\n\n" + own
+	}
+	// Instructions set for the conversation come last so they take precedence over the user's preferences
+	if conv.Instructions != "" {
+		instructions += "\n\nCustom instructions for this conversation:\n" + conv.Instructions
 	}
+	// The style requested for this reply is the most specific of all
+	if requested := style.FromContext(ctx).Instructions(); requested != "" {
+		instructions += "\n\n" + requested
+	}
+	// Older messages are summarized with the prompt variant for the platform and reply language
+	ctx = withSummaryScope(ctx, conv.Platform, language)
 
 	// Use context manager to manage conversation context with token limits
 	conversationID := conv.ID.Hex()
+	ctx = pinfact.WithConversation(ctx, conversationID)
+	window := ua.historyWindows.Resolve(conv.Platform, conv.Persona)
+	ctx = chat.WithHistoryWindow(ctx, window)
 
-	// Add all existing messages to context manager
-	for _, msg := range conv.Messages {
-		contextMsg := chat.ConvertModelMessage(msg)
-		if err := ua.contextManager.AddMessage(ctx, conversationID, contextMsg); err != nil {
-			slog.WarnContext(ctx, "Failed to add message to context manager",
-				"conversation_id", conversationID, "error", err)
-		}
+	// The context and pinned facts are read together to save Redis round trips on every reply
+	managedContext, pinnedFacts, err := ua.contextManager.LoadContext(ctx, conversationID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load context from context manager",
+			"conversation_id", conversationID, "error", err)
 	}
 
-	// Get managed context from context manager
-	managedContext := ua.contextManager.GetContext(conversationID)
-	currentTokenCount := ua.contextManager.GetTokenCount(conversationID)
+	// Seed the context with the whole conversation the first time, afterwards only the messages added since
+	// the last reply are missing: replies and tool turns are added once the reply succeeds
+	newMessages := conv.Messages
+	if len(managedContext) > 0 {
+		newMessages = pendingMessages(conv, managedContext)
+	} else if len(conv.Messages) > 1 {
+		slog.InfoContext(ctx, "Context expired or missing, reseeding it from the stored conversation",
+			"conversation_id", conversationID,
+			"messages_count", len(conv.Messages))
+	}
+	contextMsgs := make([]chat.Message, 0, len(newMessages))
+	for _, msg := range newMessages {
+		contextMsgs = append(contextMsgs, chat.ConvertModelMessage(msg))
+	}
+	// An oversized new message is answered from the summaries of its chunks
+	if last := len(contextMsgs) - 1; last >= 0 {
+		if contextMsgs[last], err = ua.condenseLongInput(ctx, contextMsgs[last]); err != nil {
+			return "", err
+		}
+	}
+	if updated, err := ua.contextManager.AddMessages(ctx, conversationID, contextMsgs); err != nil {
+		slog.WarnContext(ctx, "Failed to add message to context manager",
+			"conversation_id", conversationID, "error", err)
+		managedContext = append(managedContext, contextMsgs...)
+	} else {
+		managedContext = updated
+	}
+	currentTokenCount := ua.contextManager.TokenCount(managedContext)
 
 	slog.InfoContext(ctx, "Context manager state",
 		"conversation_id", conversationID,
@@ -232,80 +498,97 @@ func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation)
 	)
 
 	// Build messages for OpenAI API using managed context
-	msgs := []openai.ChatCompletionMessageParamUnion{
-		openai.SystemMessage(systemPrompt),
-	}
-
-	for _, msg := range managedContext {
-		switch msg.Role {
-		case "user":
-			msgs = append(msgs, openai.UserMessage(msg.Content))
-		case "assistant":
-			msgs = append(msgs, openai.AssistantMessage(msg.Content))
-		}
-	}
+	msgs := contextMessages(systemPrompt, instructions, pinnedFacts, managedContext)
 
 	// Convert registered tools to OpenAI tool format
-	tools := ua.convertToolsToOpenAIFormat()
+	// Small talk like "thanks" is sent without them: tool schemas cost prompt tokens on every request
+	tools := ua.toolsFor(ctx, conv)
 
 	// Calculate estimated token count for the current context
-	estimatedTokens := ua.estimateTokenCount(msgs, tools)
+	estimatedTokens := ua.calibrate(ua.estimateTokenCount(msgs, tools))
 
-	// Check if context exceeds safe limits for the model
-	maxModelTokens := ua.getMaxTokensForModel(openai.ChatModelGPT4_1)
-	if estimatedTokens > maxModelTokens {
+	// Check if context exceeds safe limits for the model, or the shorter window of the platform
+	maxModelTokens := ua.getMaxTokensForModel(replyModel)
+	contextBudget := maxModelTokens
+	if window.MaxTokens > 0 {
+		contextBudget = min(contextBudget, window.MaxTokens)
+	}
+	if estimatedTokens > contextBudget {
 		slog.WarnContext(ctx, "Context exceeds model limits, performing proactive reduction",
 			"conversation_id", conversationID,
 			"estimated_tokens", estimatedTokens,
 			"model_max_tokens", maxModelTokens,
-			"model", openai.ChatModelGPT4_1)
+			"context_budget", contextBudget,
+			"model", replyModel)
 
 		// Use context manager to ensure context fits within model limits
-		// Use 90% of model limit to be safe
-		safeLimit := int(float64(maxModelTokens) * 0.9)
+		// Use 90% of the budget to be safe
+		safeLimit := int(float64(contextBudget) * 0.9)
 		if err := ua.contextManager.EnsureContextFits(ctx, conversationID, safeLimit); err != nil {
 			return "", fmt.Errorf("failed to reduce context size: %w", err)
 		}
 
 		// Rebuild messages with reduced context
 		managedContext = ua.contextManager.GetContext(conversationID)
-		msgs = []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-		}
-		for _, msg := range managedContext {
-			switch msg.Role {
-			case "user":
-				msgs = append(msgs, openai.UserMessage(msg.Content))
-			case "assistant":
-				msgs = append(msgs, openai.AssistantMessage(msg.Content))
-			}
-		}
+		msgs = contextMessages(systemPrompt, instructions, pinnedFacts, managedContext)
 
 		// Recalculate token count
-		estimatedTokens = ua.estimateTokenCount(msgs, tools)
+		estimatedTokens = ua.calibrate(ua.estimateTokenCount(msgs, tools))
 		slog.InfoContext(ctx, "Context reduced after proactive reduction",
 			"conversation_id", conversationID,
 			"new_estimated_tokens", estimatedTokens,
 			"model_max_tokens", maxModelTokens)
 	}
 
+	// In strict facts mode, answers about weather, dates, or holidays must come from tool calls
+	var requiredTools []string
+	if ua.grounding.Enabled(conv.Platform, conv.Persona) {
+		requiredTools = grounding.RequiredTools(conv.Messages[len(conv.Messages)-1].Content)
+	}
+	calledTools := make(map[string]bool)
+	var turn []chat.Message // Tool calls and results of this reply, kept for later turns
+	reprompts := 0
+	var toolChoice openai.ChatCompletionToolChoiceOptionUnionParam
+	var partial string // Latest text of the model, returned when the request budget runs out before the answer
+
+	ua.saveSnapshot(ctx, conv, replyModel, systemPrompt+instructions, pinnedFacts, managedContext, estimatedTokens)
+
 	// Enhanced retry mechanism with intelligent context reduction
 	// Reduced from 15 to 5 iterations for better performance
+	contextExceeded := false
 	for i := 0; i < 5; i++ {
+		// Tool calls and results grow the prompt on every iteration
+		rawEstimate := ua.estimateTokenCount(msgs, tools)
+		estimatedTokens = ua.calibrate(rawEstimate)
+
 		// Use retry logic for OpenAI API call with timing
 		start := time.Now()
+		params := openai.ChatCompletionNewParams{
+			Model:      replyModel,
+			Messages:   msgs,
+			Tools:      tools,
+			ToolChoice: toolChoice,
+		}
+		prompt.Apply(&params)
+		ua.withPromptCacheKey(&params, systemPrompt)
 		resp, err := retry.RetryWithResult(ctx, ua.retryConfig, func() (*openai.ChatCompletion, error) {
-			return ua.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-				Model:    openai.ChatModelGPT4_1,
-				Messages: msgs,
-				Tools:    tools,
-			})
+			return ua.cli.Chat.Completions.New(ctx, params, ua.requestOptions(ctx)...)
 		})
 		duration := time.Since(start)
+		ua.reportOpenAI(degraded, err)
+
+		if err != nil && budget.Exceeded(ctx) {
+			slog.WarnContext(ctx, "Request budget exceeded, returning partial answer",
+				"conversation_id", conversationID,
+				"iteration", i+1,
+				"has_partial", partial != "")
+			return "", &budget.ExceededError{Partial: partial}
+		}
 
+		// Check if error is due to context length exceeded
+		contextExceeded = err != nil && ua.isContextLengthExceededError(err)
 		if err != nil {
-			// Check if error is due to context length exceeded
-			if ua.isContextLengthExceededError(err) {
+			if contextExceeded {
 				slog.WarnContext(ctx, "Context length exceeded, performing emergency reduction",
 					"conversation_id", conversationID,
 					"iteration", i+1)
@@ -318,25 +601,20 @@ func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation)
 				}
 
 				// Rebuild messages with reduced context
-				managedContext = ua.contextManager.GetContext(conversationID)
-				msgs = []openai.ChatCompletionMessageParamUnion{
-					openai.SystemMessage(systemPrompt),
-				}
-				for _, msg := range managedContext {
-					switch msg.Role {
-					case "user":
-						msgs = append(msgs, openai.UserMessage(msg.Content))
-					case "assistant":
-						msgs = append(msgs, openai.AssistantMessage(msg.Content))
-					}
+				// Facts may have been pinned by tool calls of this reply
+				managedContext, pinnedFacts, err = ua.contextManager.LoadContext(ctx, conversationID)
+				if err != nil {
+					return "", fmt.Errorf("failed to reload context: %w", err)
 				}
+				msgs = contextMessages(systemPrompt, instructions, pinnedFacts, managedContext)
 
 				// Recalculate token count
-				estimatedTokens = ua.estimateTokenCount(msgs, tools)
+				estimatedTokens = ua.calibrate(ua.estimateTokenCount(msgs, tools))
 				slog.InfoContext(ctx, "Context reduced after length exceeded error",
 					"conversation_id", conversationID,
 					"new_estimated_tokens", estimatedTokens,
 					"safe_limit", safeLimit)
+				ua.saveSnapshot(ctx, conv, replyModel, systemPrompt+instructions, pinnedFacts, managedContext, estimatedTokens)
 
 				// Continue to next iteration to retry
 				continue
@@ -347,24 +625,35 @@ func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation)
 		if len(resp.Choices) == 0 {
 			return "", errors.New("no choices returned by OpenAI")
 		}
+		if resp.Choices[0].FinishReason == "content_filter" {
+			ua.recordUsage(ctx, "reply", replyModel, conv, resp.Usage)
+			return "", fmt.Errorf("reply cut off: %w", errorsx.ErrContentFiltered)
+		}
 
 		// Record OpenAI metrics with token usage
 		if ua.metrics != nil {
-			ua.metrics.RecordOpenAIRequestWithTokens(ctx, "reply", string(openai.ChatModelGPT4_1),
+			ua.metrics.RecordOpenAIRequestWithTokens(ctx, "reply", replyModel,
 				conv.UserID, conv.Platform, duration,
 				int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
 
 			// Record context token count
 			ua.metrics.RecordContextTokenCount(ctx, conversationID, conv.Platform, int64(currentTokenCount))
 
-			// Record token estimation error
+			// Record the error of the estimate used for pre-flight checks, and of the uncorrected one
 			ua.metrics.RecordTokenEstimationError(ctx, "reply", estimatedTokens, int(resp.Usage.PromptTokens))
+			ua.metrics.RecordTokenEstimationError(ctx, "reply_uncalibrated", rawEstimate, int(resp.Usage.PromptTokens))
 		}
+		if ua.calibrator != nil {
+			ua.calibrator.Observe(rawEstimate, int(resp.Usage.PromptTokens))
+		}
+		ua.recordUsage(ctx, "reply", replyModel, conv, resp.Usage)
+		ua.recordPromptCache(ctx, "reply", replyModel, resp.Usage)
+		generation.AddUsage(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
 		// Log OpenAI API call with token usage
 		slog.InfoContext(ctx, "OpenAI API call completed",
 			"operation", "reply",
-			"model", openai.ChatModelGPT4_1,
+			"model", replyModel,
 			"conversation_id", conv.ID.Hex(),
 			"user_id", conv.UserID,
 			"platform", conv.Platform,
@@ -372,15 +661,33 @@ func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation)
 			"prompt_tokens", resp.Usage.PromptTokens,
 			"completion_tokens", resp.Usage.CompletionTokens,
 			"total_tokens", resp.Usage.TotalTokens,
+			"cached_tokens", resp.Usage.PromptTokensDetails.CachedTokens,
 			"duration_ms", duration.Milliseconds(),
 			"has_tool_calls", len(resp.Choices[0].Message.ToolCalls) > 0,
 			"context_tokens", currentTokenCount,
 		)
 
+		if content := resp.Choices[0].Message.Content; content != "" {
+			partial = content
+		}
+
 		if message := resp.Choices[0].Message; len(message.ToolCalls) > 0 {
 			msgs = append(msgs, message.ToParam())
+			toolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{}
 
+			callMsg := chat.Message{Role: "assistant", Content: message.Content}
 			for _, call := range message.ToolCalls {
+				callMsg.ToolCalls = append(callMsg.ToolCalls, chat.ToolCall{
+					ID:        call.ID,
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+				})
+			}
+			turn = append(turn, callMsg)
+
+			for _, call := range message.ToolCalls {
+				calledTools[call.Function.Name] = true
+
 				slog.InfoContext(ctx, "Tool call received",
 					"conversation_id", conv.ID.Hex(),
 					"tool_name", call.Function.Name,
@@ -395,21 +702,61 @@ func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation)
 						"tool_name", call.Function.Name,
 						"error", err,
 					)
-					msgs = append(msgs, openai.ToolMessage("tool execution failed: "+err.Error(), call.ID))
+					result = "tool execution failed: " + err.Error()
 				} else {
-					msgs = append(msgs, openai.ToolMessage(result, call.ID))
+					// Tool outputs carry external content, which must not be able to instruct the model
+					result = ua.injection.Scan(ctx, call.Function.Name, result).Content
 				}
+				msgs = append(msgs, openai.ToolMessage(result, call.ID))
+				turn = append(turn, chat.Message{
+					Role:       "tool",
+					Content:    result,
+					Name:       call.Function.Name,
+					ToolCallID: call.ID,
+				})
 			}
 
 			continue
 		}
 
-		// Add assistant's response to context manager
+		// Re-prompt the model when it answered a factual question without verifying it with tools
+		if missing := grounding.Missing(requiredTools, calledTools); len(missing) > 0 {
+			if reprompts < grounding.MaxReprompts {
+				reprompts++
+				slog.WarnContext(ctx, "Answer not grounded in tool calls, re-prompting",
+					"conversation_id", conversationID,
+					"platform", conv.Platform,
+					"missing_tools", missing,
+					"reprompt", reprompts,
+				)
+				if ua.metrics != nil {
+					ua.metrics.RecordGroundingReprompt(ctx, conv.Platform, missing)
+				}
+
+				msgs = append(msgs,
+					openai.AssistantMessage(resp.Choices[0].Message.Content),
+					openai.SystemMessage(grounding.RepromptInstruction(missing)),
+				)
+				// The API rejects a required tool choice without tools, e.g. when a tool filter left none
+				if len(tools) > 0 {
+					toolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("required")}
+				}
+				continue
+			}
+
+			slog.WarnContext(ctx, "Answer still not grounded after re-prompts, returning unverified answer",
+				"conversation_id", conversationID,
+				"platform", conv.Platform,
+				"missing_tools", missing,
+			)
+		}
+
+		// Add the tool turns and the assistant's response to context manager
 		assistantMsg := chat.ConvertModelMessage(&model.Message{
 			Role:    model.RoleAssistant,
 			Content: resp.Choices[0].Message.Content,
 		})
-		if err := ua.contextManager.AddMessage(ctx, conversationID, assistantMsg); err != nil {
+		if _, err := ua.contextManager.AddMessages(ctx, conversationID, append(turn, assistantMsg)); err != nil {
 			slog.WarnContext(ctx, "Failed to add assistant message to context manager",
 				"conversation_id", conversationID, "error", err)
 		}
@@ -417,9 +764,85 @@ func (ua *UnifiedAssistant) Reply(ctx context.Context, conv *model.Conversation)
 		return resp.Choices[0].Message.Content, nil
 	}
 
+	if contextExceeded {
+		return "", fmt.Errorf("context still too long after reduction: %w", errorsx.ErrContextTooLong)
+	}
 	return "", errors.New("too many tool calls, unable to generate reply")
 }
 
+// pendingMessages returns the messages added since the last reply that are not in the context yet: the new
+// user message, messages merged into the same reply and system notes
+// Messages of a reply that failed or was superseded were added to the context already
+func pendingMessages(conv *model.Conversation, managedContext []chat.Message) []*model.Message {
+	start := len(conv.Messages)
+	for start > 0 && conv.Messages[start-1].Role != model.RoleAssistant {
+		start--
+	}
+	added := len(managedContext)
+	for added > 0 && managedContext[added-1].Role != "assistant" {
+		added--
+	}
+	sent := managedContext[added:]
+
+	var pending []*model.Message
+	for _, msg := range conv.Messages[start:] {
+		converted := chat.ConvertModelMessage(msg)
+		if i := slices.IndexFunc(sent, func(m chat.Message) bool {
+			return m.Role == converted.Role && m.Content == converted.Content
+		}); i >= 0 {
+			sent = sent[i+1:]
+			continue
+		}
+		pending = append(pending, msg)
+	}
+	return pending
+}
+
+// contextMessages builds the OpenAI messages for a system prompt, instructions, pinned facts and managed context
+// System messages in the context carry summaries of older messages; tool turns are replayed
+// with their calls and results so the model keeps what the tools returned
+func contextMessages(systemPrompt, instructions string, pinnedFacts []string, managedContext []chat.Message) []openai.ChatCompletionMessageParamUnion {
+	msgs := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage(systemPrompt),
+	}
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		msgs = append(msgs, openai.SystemMessage(instructions))
+	}
+	if pinned, ok := chat.PinnedFactsMessage(pinnedFacts); ok {
+		msgs = append(msgs, openai.SystemMessage(pinned.Content))
+	}
+	for _, msg := range chat.CompleteToolTurns(managedContext) {
+		switch msg.Role {
+		case "system":
+			msgs = append(msgs, openai.SystemMessage(msg.Content))
+		case "user":
+			msgs = append(msgs, openai.UserMessage(msg.Content))
+		case "assistant":
+			if len(msg.ToolCalls) == 0 {
+				msgs = append(msgs, openai.AssistantMessage(msg.Content))
+				continue
+			}
+			assistant := openai.ChatCompletionAssistantMessageParam{}
+			if msg.Content != "" {
+				assistant.Content.OfString = openai.String(msg.Content)
+			}
+			for _, call := range msg.ToolCalls {
+				assistant.ToolCalls = append(assistant.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+					ID: call.ID,
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{
+						Name:      call.Name,
+						Arguments: call.Arguments,
+					},
+				})
+			}
+			msgs = append(msgs, openai.ChatCompletionMessageParamUnion{OfAssistant: &assistant})
+		case "tool":
+			msgs = append(msgs, openai.ToolMessage(msg.Content, msg.ToolCallID))
+		}
+	}
+	return msgs
+}
+
 // formatTitle formats and validates the title
 func (ua *UnifiedAssistant) formatTitle(title string) string {
 	// Remove extra spaces and newlines
@@ -464,10 +887,13 @@ func (ua *UnifiedAssistant) toTitleCase(s string) string {
 }
 
 // convertToolsToOpenAIFormat converts registered tools to OpenAI tool format
+// Tools are sorted by name: the schemas start every prompt, and any change of order would miss the prompt cache
 func (ua *UnifiedAssistant) convertToolsToOpenAIFormat() []openai.ChatCompletionToolParam {
 	var tools []openai.ChatCompletionToolParam
 
-	for _, tool := range ua.toolRegistry.GetAll() {
+	registered := ua.toolRegistry.GetAll()
+	slices.SortFunc(registered, func(a, b registry.Tool) int { return strings.Compare(a.Name(), b.Name()) })
+	for _, tool := range registered {
 		tools = append(tools, openai.ChatCompletionToolParam{
 			Type: "function",
 			Function: openai.FunctionDefinitionParam{
@@ -481,6 +907,32 @@ func (ua *UnifiedAssistant) convertToolsToOpenAIFormat() []openai.ChatCompletion
 	return tools
 }
 
+// toolsFor returns the tools offered for the reply to the last message of a conversation,
+// none when the message is small talk
+func (ua *UnifiedAssistant) toolsFor(ctx context.Context, conv *model.Conversation) []openai.ChatCompletionToolParam {
+	message := conv.Messages[len(conv.Messages)-1]
+	var previousReply string
+	for i := len(conv.Messages) - 2; i >= 0; i-- {
+		if conv.Messages[i].Role == model.RoleAssistant {
+			previousReply = conv.Messages[i].Content
+			break
+		}
+	}
+
+	decision := "tools"
+	var tools []openai.ChatCompletionToolParam
+	if message.Role == model.RoleUser && ua.smalltalk.Simple(message.Content, previousReply) {
+		decision = "skipped"
+		slog.DebugContext(ctx, "Small talk, replying without tools", "conversation_id", conv.ID.Hex())
+	} else {
+		tools = ua.convertToolsToOpenAIFormat()
+	}
+	if ua.metrics != nil {
+		ua.metrics.RecordToolDecision(ctx, conv.Platform, decision)
+	}
+	return tools
+}
+
 // executeTool executes a tool by name with the provided arguments
 func (ua *UnifiedAssistant) executeTool(ctx context.Context, toolName string, arguments string) (string, error) {
 	tool := ua.toolRegistry.Get(toolName)
@@ -494,8 +946,15 @@ func (ua *UnifiedAssistant) executeTool(ctx context.Context, toolName string, ar
 		return "", errors.New("failed to parse tool arguments: " + err.Error())
 	}
 
-	// Execute the tool
-	return tool.Execute(ctx, args)
+	// Execute the tool, leaving time within the request budget to answer with its result
+	toolCtx, cancel := budget.ToolContext(ctx)
+	defer cancel()
+	result, err := tool.Execute(toolCtx, args)
+	ua.toolRegistry.RecordCall(toolName, err)
+	if rec := replay.FromContext(ctx); rec != nil {
+		rec.RecordTool(toolName, arguments, result, err)
+	}
+	return result, err
 }
 
 // estimateTokenCount estimates the total token count for messages and tools
@@ -517,11 +976,110 @@ func (ua *UnifiedAssistant) estimateTokenCount(msgs []openai.ChatCompletionMessa
 	return totalTokens
 }
 
+// calibrate corrects an estimate with the token counts OpenAI reported for earlier replies
+func (ua *UnifiedAssistant) calibrate(estimated int) int {
+	if ua.calibrator == nil {
+		return estimated
+	}
+	return ua.calibrator.Adjust(estimated)
+}
+
+// resolvePrompt returns the prompt with its generation parameters, or the built-in fallback without parameters
+func (ua *UnifiedAssistant) resolvePrompt(ctx context.Context, name, platform, userSegment string) (*Prompt, error) {
+	prompt, err := ua.promptManager.ResolvePrompt(ctx, name, platform, userSegment, "")
+	if err == nil {
+		return prompt, nil
+	}
+	slog.WarnContext(ctx, "Failed to get prompt, using fallback", "name", name, "error", err)
+	content, err := ua.promptManager.GetFallbackPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Prompt{Content: content}, nil
+}
+
+// conversationPrompt returns the system prompt version the conversation is pinned to, pinning the active
+// version, or the candidate of a running rollout, on the first reply, so prompt updates only reach
+// conversations started or migrated after them
+// A pinned version that no longer exists falls back to the active version without changing the pin
+func (ua *UnifiedAssistant) conversationPrompt(ctx context.Context, conv *model.Conversation, segment string) (*Prompt, error) {
+	if conv.PromptVersion != "" {
+		prompt, err := ua.promptManager.ResolvePromptVersion(ctx, model.PromptNameSystemPrompt, conv.Platform, segment, "", conv.PromptVersion)
+		if err == nil {
+			return prompt, nil
+		}
+		slog.WarnContext(ctx, "Pinned prompt version unavailable, using the active version",
+			"conversation_id", conv.ID.Hex(),
+			"prompt_version", conv.PromptVersion,
+			"error", err,
+		)
+		return ua.resolvePrompt(ctx, model.PromptNameSystemPrompt, conv.Platform, segment)
+	}
+
+	// A conversation given the candidate of a rollout is pinned to it like to the active version
+	if ua.rollouts != nil {
+		if version, ok := ua.rollouts.Select(ctx, conv.ID.Hex()); ok {
+			prompt, err := ua.promptManager.ResolvePromptVersion(ctx, model.PromptNameSystemPrompt, conv.Platform, segment, "", version)
+			if err == nil {
+				conv.PromptVersion = prompt.Version
+				return prompt, nil
+			}
+			slog.WarnContext(ctx, "Rolled out prompt version unavailable, using the active version",
+				"conversation_id", conv.ID.Hex(),
+				"prompt_version", version,
+				"error", err,
+			)
+		}
+	}
+
+	prompt, err := ua.resolvePrompt(ctx, model.PromptNameSystemPrompt, conv.Platform, segment)
+	if err != nil {
+		return nil, err
+	}
+	// Built-in fallback prompts have no version; the conversation is pinned once a stored prompt answers
+	conv.PromptVersion = prompt.Version
+	return prompt, nil
+}
+
+// recordCostCap counts a reply of a conversation over its cap, marking when the conversation first exceeded it
+func (ua *UnifiedAssistant) recordCostCap(ctx context.Context, conv *model.Conversation, decision costcap.Decision) {
+	if ua.metrics != nil {
+		ua.metrics.RecordCostCap(ctx, conv.Platform, conv.Persona, decision.Action)
+	}
+	if !conv.CostCappedAt.IsZero() {
+		return
+	}
+	conv.CostCappedAt = time.Now()
+	slog.InfoContext(ctx, "Conversation reached its cost cap",
+		"conversation_id", conv.ID.Hex(),
+		"persona", conv.Persona,
+		"action", decision.Action,
+		"tokens", decision.Spend.Tokens,
+		"cost_usd", decision.Spend.CostUSD,
+		"cap_tokens", decision.Cap.Tokens,
+		"cap_usd", decision.Cap.CostUSD,
+	)
+}
+
+// reportOpenAI reports the outcome of a call to the primary model to the degradation manager
+// Only outages count: rate limits, server and network errors; calls to the fallback model are not reported
+func (ua *UnifiedAssistant) reportOpenAI(fallback bool, err error) {
+	if fallback || errors.Is(err, context.Canceled) {
+		return
+	}
+	if !retry.IsRetryable(err) {
+		err = nil
+	}
+	ua.degradation.Health(degradation.OpenAI).Report(err)
+}
+
 // getMaxTokensForModel returns the maximum context tokens for a given model
 func (ua *UnifiedAssistant) getMaxTokensForModel(model openai.ChatModel) int {
 	// Model-specific token limits (conservative estimates)
 	modelLimits := map[openai.ChatModel]int{
 		openai.ChatModelGPT4_1:      128000, // GPT-4-128K
+		openai.ChatModelGPT4o:       128000, // GPT-4o
+		openai.ChatModelGPT4oMini:   128000, // GPT-4o mini, the default fallback model
 		openai.ChatModelGPT4Turbo:   128000, // GPT-4 Turbo
 		openai.ChatModelGPT4:        8192,   // GPT-4
 		openai.ChatModelGPT4_0613:   8192,   // GPT-4 (June 2023)
@@ -550,6 +1108,39 @@ func (ua *UnifiedAssistant) isContextLengthExceededError(err error) bool {
 		strings.Contains(errStr, "context window")
 }
 
+// Tools returns the tools the assistant can call, with their recent call statistics
+func (ua *UnifiedAssistant) Tools() *registry.ToolRegistry {
+	return ua.toolRegistry
+}
+
+// ClearContext drops the model context cached for a conversation, e.g. when its session is reset
+func (ua *UnifiedAssistant) ClearContext(conversationID string) {
+	ua.contextManager.ClearContext(conversationID)
+}
+
+// ContextSnapshot returns what was sent to the model on the conversation's latest turn, nil when it expired
+func (ua *UnifiedAssistant) ContextSnapshot(ctx context.Context, conversationID string) (*chat.ContextSnapshot, error) {
+	return ua.contextManager.LoadSnapshot(ctx, conversationID)
+}
+
+// saveSnapshot keeps the managed context of a turn for the admin context diff
+// Tool calls and results added during the turn are left out; they are stored with the reply
+func (ua *UnifiedAssistant) saveSnapshot(ctx context.Context, conv *model.Conversation, replyModel, systemPrompt string, pinnedFacts []string, managedContext []chat.Message, tokens int) {
+	snapshot := &chat.ContextSnapshot{
+		ConversationID: conv.ID.Hex(),
+		MessageIndex:   len(conv.Messages),
+		Model:          replyModel,
+		SystemPrompt:   systemPrompt,
+		PinnedFacts:    pinnedFacts,
+		Messages:       chat.CompleteToolTurns(managedContext),
+		Tokens:         tokens,
+		CreatedAt:      time.Now(),
+	}
+	if err := ua.contextManager.SaveSnapshot(ctx, snapshot); err != nil {
+		slog.WarnContext(ctx, "Failed to save context snapshot", "conversation_id", snapshot.ConversationID, "error", err)
+	}
+}
+
 // EnableFallbackMode enables graceful degradation mode
 func (ua *UnifiedAssistant) EnableFallbackMode() {
 	ua.fallbackMode = true
@@ -610,6 +1201,7 @@ func (ua *UnifiedAssistant) executeToolWithFallback(ctx context.Context, toolNam
 	defer cancel()
 
 	result, err := tool.Execute(ctx, args)
+	ua.toolRegistry.RecordCall(toolName, err)
 	if err != nil {
 		slog.WarnContext(ctx, "Tool execution failed, using fallback",
 			"tool_name", toolName,
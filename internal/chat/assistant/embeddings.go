@@ -0,0 +1,74 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/embeddings"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/openai/openai-go"
+)
+
+// CreateEmbeddings embeds texts with a single OpenAI request using the tenant's credentials
+// Callers should go through embeddings.Service, which caches and batches requests
+func (ua *UnifiedAssistant) CreateEmbeddings(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	ctx = ua.withCredentials(ctx)
+	if model == "" {
+		model = openai.EmbeddingModelTextEmbedding3Small
+	}
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, ua.retryConfig, func() (*openai.CreateEmbeddingResponse, error) {
+		return ua.cli.Embeddings.New(ctx, openai.EmbeddingNewParams{
+			Model: model,
+			Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		}, ua.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ua.metrics != nil {
+		ua.metrics.RecordOpenAIRequestWithTokens(ctx, "embedding", model,
+			"", "", duration,
+			resp.Usage.PromptTokens, 0, resp.Usage.TotalTokens)
+	}
+	ua.recordUsage(ctx, "embedding", model, nil, openai.CompletionUsage{
+		PromptTokens: resp.Usage.PromptTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+	})
+
+	slog.DebugContext(ctx, "OpenAI API call completed",
+		"operation", "embedding",
+		"model", model,
+		"batch_size", len(texts),
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	// Embeddings carry their input index; the API does not promise to keep the order
+	vectors := make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index < 0 || int(item.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		vector := make([]float32, len(item.Embedding))
+		for i, x := range item.Embedding {
+			vector[i] = float32(x)
+		}
+		vectors[item.Index] = vector
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// Ensure UnifiedAssistant implements embeddings.Provider interface
+var _ embeddings.Provider = (*UnifiedAssistant)(nil)
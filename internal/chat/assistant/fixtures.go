@@ -0,0 +1,87 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/pinfact"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// liveTools only change in-process state, so they run for real even when replaying fixtures
+var liveTools = []string{pinfact.ToolName}
+
+// WithFixtures serves OpenAI responses and tool outputs from recorded fixtures, or records them,
+// so end-to-end tests of the full server path are deterministic and never reach the network
+// In replay mode a request without a fixture fails with replay.ErrNoFixture
+func WithFixtures(fixtures *replay.Fixtures, mode string) Option {
+	return func(ua *UnifiedAssistant) {
+		switch mode {
+		case replay.FixtureModeReplay:
+			ua.cli = openai.NewClient(
+				option.WithAPIKey("replay"),
+				option.WithMaxRetries(0),
+				option.WithMiddleware(fixtures.ReplayMiddleware),
+			)
+		case replay.FixtureModeRecord:
+			ua.cli = openai.NewClient(
+				option.WithMiddleware(retry.SharedLimiter().Middleware),
+				option.WithMiddleware(fixtures.RecordMiddleware),
+			)
+		default:
+			return
+		}
+		ua.warmer = nil // The recording client does not share the warmed connections
+		ua.toolRegistry = fixtureTools(ua.toolRegistry, fixtures, mode)
+		slog.Warn("LLM fixtures enabled, assistant replies come from test fixtures", "mode", mode)
+	}
+}
+
+// fixtureTools wraps the registered tools so their outputs are recorded or replayed
+func fixtureTools(tools *registry.ToolRegistry, fixtures *replay.Fixtures, mode string) *registry.ToolRegistry {
+	wrapped := registry.NewToolRegistry()
+	for _, tool := range tools.GetAll() {
+		if slices.Contains(liveTools, tool.Name()) {
+			wrapped.Register(tool)
+			continue
+		}
+		wrapped.Register(&fixtureTool{Tool: tool, fixtures: fixtures, replay: mode == replay.FixtureModeReplay})
+	}
+	return wrapped
+}
+
+type fixtureTool struct {
+	registry.Tool
+	fixtures *replay.Fixtures
+	replay   bool
+}
+
+func (t *fixtureTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	arguments, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+
+	if t.replay {
+		execution, ok := t.fixtures.Tool(t.Name(), string(arguments))
+		if !ok {
+			return "", fmt.Errorf("%w: tool %s with arguments %s", replay.ErrNoFixture, t.Name(), arguments)
+		}
+		if execution.Error != "" {
+			return "", errors.New(execution.Error)
+		}
+		return execution.Output, nil
+	}
+
+	output, err := t.Tool.Execute(ctx, args)
+	t.fixtures.RecordTool(t.Name(), string(arguments), output, err)
+	return output, err
+}
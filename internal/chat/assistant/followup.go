@@ -0,0 +1,104 @@
+package assistant
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/quality"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/openai/openai-go"
+)
+
+const followUpPrompt = "The user of the conversation below has not written for a while. Write a short, friendly " +
+	"follow-up from the assistant that picks up what they were doing and offers further help, in at most two sentences. " +
+	"Do not repeat the last reply, do not invent facts, and do not ask for personal information. " +
+	"If the conversation came to a natural end and needs no follow-up, respond with NONE. Respond with the message only."
+
+// followUpNone is the answer of the model to conversations not worth following up on
+const followUpNone = "NONE"
+
+// FollowUpWriter writes re-engagement messages for idle conversations with a cheap model
+type FollowUpWriter struct {
+	assistant *UnifiedAssistant
+	model     string
+	maxTokens int
+}
+
+// NewFollowUpWriter creates a writer using the assistant's OpenAI client and tenant credentials;
+// follow-ups are cut off at maxTokens
+func NewFollowUpWriter(ua *UnifiedAssistant, model string, maxTokens int) *FollowUpWriter {
+	if model == "" {
+		model = openai.ChatModelGPT4oMini
+	}
+	if maxTokens <= 0 {
+		maxTokens = 120
+	}
+	return &FollowUpWriter{
+		assistant: ua,
+		model:     model,
+		maxTokens: maxTokens,
+	}
+}
+
+// Write returns the follow-up of a conversation, "" when it needs none, see followup.Writer
+func (w *FollowUpWriter) Write(ctx context.Context, conv *model.Conversation) (string, error) {
+	transcript := quality.Transcript(conv)
+	if transcript == "" {
+		return "", nil
+	}
+	ctx = w.assistant.withCredentials(ctx)
+	ctx = w.assistant.withSpendTag(ctx, conv)
+
+	prompt := followUpPrompt
+	if language := conv.ReplyLanguage(""); language != "" {
+		prompt += " Write in the language with code " + language + "."
+	}
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, w.assistant.retryConfig, func() (*openai.ChatCompletion, error) {
+		return w.assistant.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Model: w.model,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage(prompt),
+				openai.UserMessage(transcript),
+			},
+			MaxTokens: openai.Int(int64(w.maxTokens)),
+		}, w.assistant.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("empty response from OpenAI for follow-up")
+	}
+
+	if w.assistant.metrics != nil {
+		w.assistant.metrics.RecordOpenAIRequestWithTokens(ctx, "follow_up", w.model,
+			"", "", duration,
+			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
+	}
+	w.assistant.recordUsage(ctx, "follow_up", w.model, conv, resp.Usage)
+
+	slog.DebugContext(ctx, "OpenAI API call completed",
+		"operation", "follow_up",
+		"model", w.model,
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	// A follow-up cut off at the token cap would end mid-sentence
+	if resp.Choices[0].FinishReason == "length" {
+		return "", errors.New("follow-up exceeded its token budget")
+	}
+	text := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if strings.EqualFold(strings.Trim(text, ". "), followUpNone) {
+		return "", nil
+	}
+	return text, nil
+}
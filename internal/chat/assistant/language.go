@@ -0,0 +1,120 @@
+package assistant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/language"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/openai/openai-go"
+)
+
+const translationPrompt = "Translate the assistant reply you are given into %s. Keep its meaning, tone and formatting: " +
+	"leave Markdown, code, links, names and numbers unchanged. Respond with the translation only."
+
+// PromptData is available to system prompts written as text/templates, e.g. "Answer in {{.LanguageName}}."
+type PromptData struct {
+	Language     string // Code of the language replies must use, e.g. "es"; empty when unknown
+	LanguageName string // English name of the language, e.g. "Spanish"
+}
+
+// trackLanguage records the language of the latest user message on the conversation
+// Short or ambiguous messages, e.g. "ok", keep the language detected earlier
+func trackLanguage(conv *model.Conversation) {
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		msg := conv.Messages[i]
+		if msg.Role != model.RoleUser {
+			continue
+		}
+		if detected := language.Detect(msg.Content); detected != "" {
+			conv.DetectedLanguage = detected
+		}
+		return
+	}
+}
+
+// renderSystemPrompt fills in a system prompt written as a template; it reports whether the prompt
+// states the reply language itself, so the default instruction is not added twice
+// Prompts that fail to render are used as they are
+func renderSystemPrompt(ctx context.Context, prompt *Prompt, code string) (string, bool) {
+	if !strings.Contains(prompt.Content, "{{") {
+		return prompt.Content, false
+	}
+
+	tmpl, err := template.New(prompt.Version).Parse(prompt.Content)
+	if err == nil {
+		var out strings.Builder
+		data := PromptData{Language: code}
+		if code != "" {
+			data.LanguageName = language.Name(code)
+		}
+		if err = tmpl.Execute(&out, data); err == nil {
+			return out.String(), strings.Contains(prompt.Content, ".Language")
+		}
+	}
+	slog.WarnContext(ctx, "Failed to render system prompt template", "prompt_version", prompt.Version, "error", err)
+	return prompt.Content, false
+}
+
+// ReplyTranslator translates replies written in the wrong language with a cheap model
+type ReplyTranslator struct {
+	assistant *UnifiedAssistant
+	model     string
+}
+
+// NewReplyTranslator creates a translator using the assistant's OpenAI client and tenant credentials
+func NewReplyTranslator(ua *UnifiedAssistant, model string) *ReplyTranslator {
+	if model == "" {
+		model = openai.ChatModelGPT4oMini
+	}
+	return &ReplyTranslator{
+		assistant: ua,
+		model:     model,
+	}
+}
+
+// Translate returns a reply translated into the language with the code
+func (t *ReplyTranslator) Translate(ctx context.Context, text, code string) (string, error) {
+	ctx = t.assistant.withCredentials(ctx)
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, t.assistant.retryConfig, func() (*openai.ChatCompletion, error) {
+		return t.assistant.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Model: t.model,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage(fmt.Sprintf(translationPrompt, language.Name(code))),
+				openai.UserMessage(text),
+			},
+		}, t.assistant.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", errors.New("empty response from OpenAI for reply translation")
+	}
+
+	if t.assistant.metrics != nil {
+		t.assistant.metrics.RecordOpenAIRequestWithTokens(ctx, "translation", t.model,
+			"", "", duration,
+			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
+	}
+	t.assistant.recordUsage(ctx, "translation", t.model, nil, resp.Usage)
+
+	slog.DebugContext(ctx, "OpenAI API call completed",
+		"operation", "translation",
+		"model", t.model,
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,67 @@
+package assistant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/metrics"
+	"github.com/8adimka/Go_AI_Assistant/internal/prewarm"
+	"github.com/openai/openai-go"
+)
+
+// openAIHTTPClient returns the HTTP client of OpenAI requests, which keeps connections open between
+// requests, and the warmer opening them ahead of the first one when pre-warming is enabled
+func openAIHTTPClient(cfg *config.Config, appMetrics *metrics.Metrics) (*http.Client, *prewarm.Warmer) {
+	transport := prewarm.NewTransport(cfg.OpenAIMaxIdleConns)
+	var warmer *prewarm.Warmer
+	if cfg.OpenAIPrewarm {
+		warmer = prewarm.NewWarmer(transport, cfg.OpenAIBaseURL, time.Duration(cfg.OpenAIKeepAliveSeconds)*time.Second)
+	}
+	if appMetrics == nil {
+		return &http.Client{Transport: transport}, warmer
+	}
+	return &http.Client{Transport: prewarm.Track(transport, "openai", appMetrics)}, warmer
+}
+
+// KeepConnectionsWarm opens a connection to OpenAI and keeps it open until the context is cancelled,
+// so replies do not wait for DNS, TCP and TLS handshakes before their first token
+// It returns at once when pre-warming is disabled or replies come from fixtures
+func (ua *UnifiedAssistant) KeepConnectionsWarm(ctx context.Context) {
+	if ua.warmer == nil {
+		return
+	}
+	ua.warmer.Run(ctx)
+}
+
+// withPromptCacheKey routes a request to the prompt cache of the requests sharing its system prompt and tools
+// OpenAI caches prompts by prefix, tool schemas first then messages, so every conversation with the same
+// prompt version, language and tools reads them from the cache instead of having them processed and billed
+// in full; what varies between conversations is sent after them, see reply
+func (ua *UnifiedAssistant) withPromptCacheKey(params *openai.ChatCompletionNewParams, systemPrompt string) {
+	if !ua.cfg.OpenAIPromptCacheKey {
+		return
+	}
+	params.PromptCacheKey = openai.String(promptCacheKey(systemPrompt, params.Tools))
+}
+
+func promptCacheKey(systemPrompt string, tools []openai.ChatCompletionToolParam) string {
+	h := sha256.New()
+	for _, tool := range tools {
+		h.Write([]byte(tool.Function.Name))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(systemPrompt))
+	return "prefix_" + hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// recordPromptCache records how much of the prompt of a request OpenAI read from its prompt cache
+func (ua *UnifiedAssistant) recordPromptCache(ctx context.Context, operation, modelName string, usage openai.CompletionUsage) {
+	if ua.metrics == nil {
+		return
+	}
+	ua.metrics.RecordPromptCache(ctx, operation, modelName, usage.PromptTokens, usage.PromptTokensDetails.CachedTokens)
+}
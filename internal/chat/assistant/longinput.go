@@ -0,0 +1,66 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+)
+
+const longInputPrompt = "You condense one part of a long message a user sent to an assistant. " +
+	"Keep every question, request, instruction, name, number, date and other detail an answer may depend on; " +
+	"drop repetition and filler. Write in the language of the message and do not answer it."
+
+// minChunkSummaryTokens keeps chunk summaries useful when a message is split into many chunks
+const minChunkSummaryTokens = 50
+
+// condenseLongInput replaces a user message too long for the context by the summaries of its chunks,
+// so the reply is answered from the combined summaries instead of churning through reductions
+// Messages within the limit, and all messages when chunking is disabled, are returned unchanged
+func (ua *UnifiedAssistant) condenseLongInput(ctx context.Context, msg chat.Message) (chat.Message, error) {
+	if !ua.cfg.LongInputChunking || ua.summarizer == nil || msg.Role != "user" {
+		return msg, nil
+	}
+
+	// The condensed message must fit where the original did not
+	budget := ua.cfg.MaxMessageTokens
+	if budget <= 0 {
+		budget = ua.cfg.LongInputChunkTokens
+	}
+	inputTokens := ua.summarizer.countTokens(msg.Content)
+	if inputTokens <= budget {
+		return msg, nil
+	}
+
+	chunks := chat.SplitChunks(msg.Content, ua.cfg.LongInputChunkTokens, ua.summarizer.countTokens)
+	if maxChunks := ua.cfg.LongInputMaxChunks; maxChunks > 0 && len(chunks) > maxChunks {
+		slog.WarnContext(ctx, "Long message has more chunks than allowed, dropping the rest",
+			"chunks", len(chunks),
+			"max_chunks", maxChunks)
+		chunks = chunks[:maxChunks]
+	}
+	summaryTokens := max(budget/len(chunks), minChunkSummaryTokens)
+
+	slog.InfoContext(ctx, "Condensing long message",
+		"input_tokens", inputTokens,
+		"chunks", len(chunks),
+		"summary_tokens_per_chunk", summaryTokens)
+	chat.ReportProgress(ctx, chat.Progress{Stage: "long_input", Total: len(chunks)})
+
+	var condensed strings.Builder
+	fmt.Fprintf(&condensed, chat.LongInputPrefix, len(chunks))
+	for i, chunk := range chunks {
+		prompt := fmt.Sprintf("%s\nThis is part %d of %d.", longInputPrompt, i+1, len(chunks))
+		summary, err := ua.summarizer.stream(ctx, "long_input", prompt, chunk, summaryTokens)
+		if err != nil {
+			return msg, fmt.Errorf("failed to condense part %d of %d of a long message: %w", i+1, len(chunks), err)
+		}
+		fmt.Fprintf(&condensed, "\n\nPart %d: %s", i+1, summary)
+		chat.ReportProgress(ctx, chat.Progress{Stage: "long_input", Done: i + 1, Total: len(chunks)})
+	}
+
+	msg.Content = condensed.String()
+	return msg, nil
+}
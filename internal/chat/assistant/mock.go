@@ -0,0 +1,122 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+// mockReplies are canned answers picked by keywords of the last user message
+var mockReplies = []struct {
+	keywords []string
+	replies  []string
+}{
+	{
+		keywords: []string{"weather", "rain", "temperature", "forecast", "sunny"},
+		replies: []string{
+			"It's currently 21°C and partly cloudy, with a light breeze from the west. No rain is expected today.",
+			"The forecast shows sunshine for the next three days, with highs around 24°C and cooler evenings.",
+			"Expect showers this afternoon and about 16°C. Taking an umbrella would be a good idea.",
+		},
+	},
+	{
+		keywords: []string{"holiday", "day off", "vacation", "festival"},
+		replies: []string{
+			"The next public holiday is in two weeks. Most shops and offices will be closed that day.",
+			"There are three public holidays left this year. The next one falls on a Monday, so it's a long weekend.",
+		},
+	},
+	{
+		keywords: []string{"time", "date", "today", "tomorrow", "week"},
+		replies: []string{
+			"Today is a Wednesday. Let me know if you need the time in another timezone.",
+			"Tomorrow is the 15th, and there are no holidays scheduled for this week.",
+		},
+	},
+	{
+		keywords: []string{"recipe", "cook", "dinner", "eat"},
+		replies: []string{
+			"A quick option is pasta with garlic, olive oil and chili. It takes about 15 minutes and needs only pantry staples.",
+			"Try a vegetable stir-fry: sauté whatever vegetables you have, add soy sauce and ginger, and serve over rice.",
+		},
+	},
+	{
+		keywords: []string{"trip", "travel", "visit", "flight", "hotel"},
+		replies: []string{
+			"For a weekend visit, I'd start with the old town in the morning, then a museum, and finish with dinner by the harbour.",
+			"Spring and early autumn are the best times to travel there: mild weather and fewer crowds than in summer.",
+		},
+	},
+}
+
+var mockDefaultReplies = []string{
+	"That's a good question. In short, it depends on your goals, but starting small and iterating usually works best.",
+	"Here's a summary: focus on the essentials first, then refine the details once the basics work.",
+	"I can help with that. Could you share a bit more detail so I can give you a more specific answer?",
+}
+
+// Mock is an assistant that answers with canned replies without calling OpenAI
+// It is used to generate demo data and in load tests, so replies are deterministic for a conversation
+type Mock struct{}
+
+// NewMock creates a mock assistant
+func NewMock() *Mock {
+	return &Mock{}
+}
+
+// Title returns the first words of the first user message in title case
+func (m *Mock) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	message := firstUserMessage(conv)
+	if message == "" {
+		return model.DefaultConversationTitle, nil
+	}
+
+	words := strings.Fields(strings.Trim(message, "?!. "))
+	if len(words) > 5 {
+		words = words[:5]
+	}
+	return (&UnifiedAssistant{}).formatTitle(strings.Join(words, " ")), nil
+}
+
+// Reply answers the last user message with a canned reply matching its topic
+func (m *Mock) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	if len(conv.Messages) == 0 {
+		return "", fmt.Errorf("conversation %s has no messages", conv.ID.Hex())
+	}
+	message := strings.ToLower(conv.Messages[len(conv.Messages)-1].Content)
+
+	replies := mockDefaultReplies
+	for _, topic := range mockReplies {
+		if containsAny(message, topic.keywords) {
+			replies = topic.replies
+			break
+		}
+	}
+
+	// The same message in the same conversation always gets the same reply
+	h := fnv.New32a()
+	h.Write([]byte(conv.ID.Hex()))
+	h.Write([]byte(message))
+	return replies[h.Sum32()%uint32(len(replies))], nil
+}
+
+func firstUserMessage(conv *model.Conversation) string {
+	for _, m := range conv.Messages {
+		if m.Role == model.RoleUser {
+			return m.Content
+		}
+	}
+	return ""
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
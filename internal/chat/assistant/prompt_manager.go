@@ -11,11 +11,39 @@ import (
 	"github.com/8adimka/Go_AI_Assistant/internal/config"
 	"github.com/8adimka/Go_AI_Assistant/internal/mongox"
 	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/openai/openai-go"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Prompt is the content of a prompt with the generation parameters stored alongside it
+type Prompt struct {
+	Content string
+	Version string // Empty for built-in fallback prompts
+	Params  *model.GenerationParams
+}
+
+// Apply sets the generation parameters of the prompt on a completion request
+func (p *Prompt) Apply(params *openai.ChatCompletionNewParams) {
+	if p == nil || p.Params == nil {
+		return
+	}
+	if p.Params.Temperature != nil {
+		params.Temperature = openai.Float(*p.Params.Temperature)
+	}
+	if p.Params.PresencePenalty != nil {
+		params.PresencePenalty = openai.Float(*p.Params.PresencePenalty)
+	}
+	if p.Params.FrequencyPenalty != nil {
+		params.FrequencyPenalty = openai.Float(*p.Params.FrequencyPenalty)
+	}
+	if p.Params.MaxTokens != nil {
+		params.MaxTokens = openai.Int(*p.Params.MaxTokens)
+	}
+}
+
 // PromptManager manages prompt configurations with caching and fallback
 type PromptManager struct {
 	cache    *redisx.Cache
@@ -32,7 +60,7 @@ func NewPromptManager(cfg *config.Config) *PromptManager {
 	// Connect to Redis
 	redisClient := redisx.MustConnect(cfg.RedisAddr)
 	cacheTTL := time.Duration(cfg.CacheTTLHours) * time.Hour
-	cache := redisx.NewCache(redisClient, cacheTTL)
+	cache := redisx.NewCache(redisClient, cacheTTL, redisx.WithCodec(redisx.MustCodec(cfg.CacheCodec)))
 
 	// Create fallback prompts from default configs
 	fallback := make(map[string]string)
@@ -56,18 +84,33 @@ func (pm *PromptManager) GetPrompt(ctx context.Context, name string) (string, er
 
 // GetPromptWithPlatform retrieves a prompt by name, platform, and user segment
 func (pm *PromptManager) GetPromptWithPlatform(ctx context.Context, name, platform, userSegment string) (string, error) {
+	return pm.GetPromptWithLocale(ctx, name, platform, userSegment, "")
+}
+
+// GetPromptWithLocale retrieves a prompt by name, platform, user segment and conversation language
+// Prompts written for the locale take precedence over prompts for every language
+func (pm *PromptManager) GetPromptWithLocale(ctx context.Context, name, platform, userSegment, locale string) (string, error) {
+	prompt, err := pm.ResolvePrompt(ctx, name, platform, userSegment, locale)
+	if err != nil {
+		return "", err
+	}
+	return prompt.Content, nil
+}
+
+// ResolvePrompt retrieves a prompt like GetPromptWithLocale, together with its generation parameters
+func (pm *PromptManager) ResolvePrompt(ctx context.Context, name, platform, userSegment, locale string) (*Prompt, error) {
 	// Generate cache key
-	cacheKey := pm.generateCacheKey(name, platform, userSegment)
+	cacheKey := pm.generateCacheKey(name, platform, userSegment, locale)
 
 	// Try to get from Redis cache first
-	var cachedPrompt string
+	var cachedPrompt Prompt
 	if err := pm.cache.Get(ctx, cacheKey, &cachedPrompt); err == nil {
 		slog.DebugContext(ctx, "Prompt retrieved from cache",
 			"name", name,
 			"platform", platform,
 			"user_segment", userSegment,
 		)
-		return cachedPrompt, nil
+		return &cachedPrompt, nil
 	} else if !errors.Is(err, redisx.ErrCacheMiss) {
 		slog.WarnContext(ctx, "Cache error, proceeding without cache",
 			"error", err,
@@ -76,7 +119,7 @@ func (pm *PromptManager) GetPromptWithPlatform(ctx context.Context, name, platfo
 	}
 
 	// Try to get from MongoDB
-	prompt, err := pm.getPromptFromMongo(ctx, name, platform, userSegment)
+	prompt, err := pm.getPromptFromMongo(ctx, name, platform, userSegment, locale, "")
 	if err == nil {
 		// Cache the result
 		if cacheErr := pm.cache.Set(ctx, cacheKey, prompt); cacheErr != nil {
@@ -95,20 +138,45 @@ func (pm *PromptManager) GetPromptWithPlatform(ctx context.Context, name, platfo
 	)
 
 	if fallbackPrompt, exists := pm.fallback[name]; exists {
-		return fallbackPrompt, nil
+		return &Prompt{Content: fallbackPrompt}, nil
 	}
 
-	return "", fmt.Errorf("prompt not found: %s (no fallback available)", name)
+	return nil, fmt.Errorf("prompt not found: %s (no fallback available)", name)
+}
+
+// ResolvePromptVersion retrieves a version of a prompt, active or not, for conversations pinned to it
+// There is no fallback: callers decide what a conversation pinned to a deleted version gets
+func (pm *PromptManager) ResolvePromptVersion(ctx context.Context, name, platform, userSegment, locale, version string) (*Prompt, error) {
+	if version == "" {
+		return pm.ResolvePrompt(ctx, name, platform, userSegment, locale)
+	}
+
+	cacheKey := pm.generateCacheKey(name, platform, userSegment, locale) + "@" + version
+	var cachedPrompt Prompt
+	if err := pm.cache.Get(ctx, cacheKey, &cachedPrompt); err == nil {
+		return &cachedPrompt, nil
+	} else if !errors.Is(err, redisx.ErrCacheMiss) {
+		slog.WarnContext(ctx, "Cache error, proceeding without cache", "error", err, "name", name)
+	}
+
+	prompt, err := pm.getPromptFromMongo(ctx, name, platform, userSegment, locale, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := pm.cache.Set(ctx, cacheKey, prompt); err != nil {
+		slog.WarnContext(ctx, "Failed to cache prompt", "error", err, "name", name)
+	}
+	return prompt, nil
 }
 
 // getPromptFromMongo retrieves a prompt from MongoDB
-func (pm *PromptManager) getPromptFromMongo(ctx context.Context, name, platform, userSegment string) (string, error) {
+// An empty version selects the active prompt; a version selects that version even when it is no longer active
+func (pm *PromptManager) getPromptFromMongo(ctx context.Context, name, platform, userSegment, locale, version string) (*Prompt, error) {
 	collection := pm.mongoDB.Collection("prompt_configs")
 
 	// Build query to find active prompt with matching criteria
 	filter := bson.M{
-		"name":      name,
-		"is_active": true,
+		"name": name,
 		"$or": []bson.M{
 			{"platform": platform},
 			{"platform": model.DefaultPlatform},
@@ -120,13 +188,35 @@ func (pm *PromptManager) getPromptFromMongo(ctx context.Context, name, platform,
 					{"user_segment": model.DefaultUserSegment},
 				},
 			},
+			{
+				// Tenant overrides take precedence over shared prompts
+				"$or": []bson.M{
+					{"tenant_id": tenant.FromContext(ctx)},
+					{"tenant_id": bson.M{"$exists": false}},
+				},
+			},
+			{
+				// Prompts without a locale serve every language
+				"$or": []bson.M{
+					{"locale": locale},
+					{"locale": bson.M{"$exists": false}},
+				},
+			},
 		},
 	}
 
-	// Sort by platform and user segment specificity (more specific first)
+	if version == "" {
+		filter["is_active"] = true
+	} else {
+		filter["version"] = version
+	}
+
+	// Sort by tenant, platform, user segment and locale specificity (more specific first)
 	sort := bson.D{
+		{Key: "tenant_id", Value: -1},    // Tenant override first
 		{Key: "platform", Value: -1},     // Specific platform first
 		{Key: "user_segment", Value: -1}, // Specific user segment first
+		{Key: "locale", Value: -1},       // Specific locale first
 		{Key: "updated_at", Value: -1},   // Most recent first
 	}
 
@@ -134,28 +224,63 @@ func (pm *PromptManager) getPromptFromMongo(ctx context.Context, name, platform,
 	err := collection.FindOne(ctx, filter, options.FindOne().SetSort(sort)).Decode(&promptConfig)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return "", fmt.Errorf("no active prompt found for name: %s, platform: %s, user_segment: %s", name, platform, userSegment)
+			return nil, fmt.Errorf("no prompt found for name: %s, platform: %s, user_segment: %s, locale: %q, version: %q", name, platform, userSegment, locale, version)
 		}
-		return "", fmt.Errorf("failed to query MongoDB for prompt: %w", err)
+		return nil, fmt.Errorf("failed to query MongoDB for prompt: %w", err)
 	}
 
 	if promptConfig.Content == "" {
-		return "", fmt.Errorf("prompt content is empty for name: %s", name)
+		return nil, fmt.Errorf("prompt content is empty for name: %s", name)
+	}
+	// Invalid parameters would fail every completion made with the prompt, so the content is used without them
+	if err := promptConfig.Params.Validate(); err != nil {
+		slog.WarnContext(ctx, "Ignoring invalid generation parameters of prompt",
+			"name", name,
+			"version", promptConfig.Version,
+			"error", err,
+		)
+		promptConfig.Params = nil
 	}
 
 	slog.DebugContext(ctx, "Prompt retrieved from MongoDB",
 		"name", name,
 		"platform", platform,
 		"user_segment", userSegment,
+		"locale", locale,
+		"tenant_id", tenant.FromContext(ctx),
 		"version", promptConfig.Version,
 	)
 
-	return promptConfig.Content, nil
+	return &Prompt{Content: promptConfig.Content, Version: promptConfig.Version, Params: promptConfig.Params}, nil
 }
 
 // generateCacheKey generates a cache key for prompt
-func (pm *PromptManager) generateCacheKey(name, platform, userSegment string) string {
-	return fmt.Sprintf("prompt:%s:%s:%s", name, platform, userSegment)
+// Keys are versioned since entries became prompts with parameters instead of plain content
+func (pm *PromptManager) generateCacheKey(name, platform, userSegment, locale string) string {
+	if locale == "" {
+		return fmt.Sprintf("prompt:v2:%s:%s:%s", name, platform, userSegment)
+	}
+	return fmt.Sprintf("prompt:v2:%s:%s:%s:%s", name, platform, userSegment, locale)
+}
+
+// Warm loads the active prompts of the default configurations from MongoDB into the cache,
+// refreshing cached copies, so replies after a deploy or cache flush do not wait on MongoDB
+func (pm *PromptManager) Warm(ctx context.Context) (int, error) {
+	warmed := 0
+	var errs []error
+	for _, config := range model.GetDefaultPromptConfigs() {
+		prompt, err := pm.getPromptFromMongo(ctx, config.Name, config.Platform, config.UserSegment, "", "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("prompt %s: %w", config.Name, err))
+			continue
+		}
+		if err := pm.cache.Set(ctx, pm.generateCacheKey(config.Name, config.Platform, config.UserSegment, ""), prompt); err != nil {
+			errs = append(errs, fmt.Errorf("prompt %s: %w", config.Name, err))
+			continue
+		}
+		warmed++
+	}
+	return warmed, errors.Join(errs...)
 }
 
 // GetFallbackPrompt returns a fallback prompt by name
@@ -0,0 +1,85 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/quality"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+const qualityPrompt = "You review conversations between a user and an AI assistant. Rate the assistant's replies " +
+	"from 1 (very poor) to 5 (excellent) on helpfulness (did it address what the user needed), correctness " +
+	"(are its statements accurate and consistent) and tone (is it polite, clear and appropriate). " +
+	`Respond with a JSON object {"helpfulness": number, "correctness": number, "tone": number}.`
+
+// QualityScorer rates conversations on the quality rubric with a cheap model
+type QualityScorer struct {
+	assistant *UnifiedAssistant
+	model     string
+}
+
+// NewQualityScorer creates a scorer using the assistant's OpenAI client and tenant credentials
+func NewQualityScorer(ua *UnifiedAssistant, model string) *QualityScorer {
+	if model == "" {
+		model = openai.ChatModelGPT4oMini
+	}
+	return &QualityScorer{
+		assistant: ua,
+		model:     model,
+	}
+}
+
+// Score rates a conversation transcript, see quality.Transcript
+func (s *QualityScorer) Score(ctx context.Context, transcript string) (quality.Scores, error) {
+	ctx = s.assistant.withCredentials(ctx)
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, s.assistant.retryConfig, func() (*openai.ChatCompletion, error) {
+		return s.assistant.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Model: s.model,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage(qualityPrompt),
+				openai.UserMessage(transcript),
+			},
+			MaxTokens: openai.Int(50),
+			ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+			},
+		}, s.assistant.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return quality.Scores{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return quality.Scores{}, errors.New("empty response from OpenAI for quality scoring")
+	}
+
+	if s.assistant.metrics != nil {
+		s.assistant.metrics.RecordOpenAIRequestWithTokens(ctx, "quality", s.model,
+			"", "", duration,
+			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
+	}
+	s.assistant.recordUsage(ctx, "quality", s.model, nil, resp.Usage)
+
+	slog.DebugContext(ctx, "OpenAI API call completed",
+		"operation", "quality",
+		"model", s.model,
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	var scores quality.Scores
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &scores); err != nil {
+		return quality.Scores{}, fmt.Errorf("failed to parse quality scores: %w", err)
+	}
+	return scores, nil
+}
@@ -0,0 +1,169 @@
+package assistant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TurnRecorder stores the LLM exchanges and tool calls of replies for debugging replays
+type TurnRecorder interface {
+	SaveTurn(ctx context.Context, turn *replay.Turn) error
+}
+
+// WithTurnRecorder records every reply so it can be reconstructed and re-run later
+func WithTurnRecorder(recorder TurnRecorder) Option {
+	return func(ua *UnifiedAssistant) {
+		ua.turns = recorder
+	}
+}
+
+// startTurn attaches a recording to the context when turns are recorded
+func (ua *UnifiedAssistant) startTurn(ctx context.Context, conv *model.Conversation) (context.Context, *replay.Recording) {
+	if ua.turns == nil {
+		return ctx, nil
+	}
+	return replay.Start(ctx, &replay.Turn{
+		TenantID:       tenant.FromContext(ctx),
+		ConversationID: conv.ID.Hex(),
+		Platform:       conv.Platform,
+		UserID:         conv.UserID,
+		MessageIndex:   len(conv.Messages),
+		CreatedAt:      time.Now(),
+	})
+}
+
+// saveTurn stores a finished turn in the background so recording never slows down replies
+func (ua *UnifiedAssistant) saveTurn(ctx context.Context, rec *replay.Recording, reply string, err error) {
+	if rec == nil {
+		return
+	}
+	turn := rec.Finish(reply, err)
+
+	go func() {
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := ua.turns.SaveTurn(saveCtx, turn); err != nil {
+			slog.WarnContext(saveCtx, "Failed to record conversation turn",
+				"conversation_id", turn.ConversationID, "error", err)
+		}
+	}()
+}
+
+// Rerun re-runs a recorded turn with the recorded LLM responses and tool outputs
+// The current prompts, context assembly and tool loop run for real, so the first request that
+// differs from the recording shows where behaviour changed; nothing is billed or persisted
+func (ua *UnifiedAssistant) Rerun(ctx context.Context, conv *model.Conversation, turn *replay.Turn) (*replay.Rerun, error) {
+	if turn.MessageIndex <= 0 || turn.MessageIndex > len(conv.Messages) {
+		return nil, fmt.Errorf("turn replied to %d messages, conversation has %d", turn.MessageIndex, len(conv.Messages))
+	}
+
+	player := replay.NewPlayer(turn.Exchanges)
+
+	clone := *ua
+	clone.cli = openai.NewClient(
+		option.WithAPIKey("replay"),
+		option.WithMaxRetries(0),
+		option.WithMiddleware(player.Middleware),
+	)
+	clone.retryConfig.MaxAttempts = 1
+	clone.metrics = nil
+	clone.usage = nil
+	clone.turns = nil
+	clone.toolRegistry = replayTools(ua.toolRegistry, turn.ToolCalls)
+	clone.injection = clone.newInjectionDetector()
+	clone.summarizer = NewStreamingSummarizer(&clone, ua.cfg.SummaryModel, 0, nil)
+	clone.contextManager = chat.NewContextManager(ua.cache, ua.cfg.MaxContextTokens, 50, nil,
+		chat.WithSummarizer(clone.summarizer, ua.cfg.SummaryMaxTokens, ua.cfg.SummarySegmentMessages))
+
+	// A throwaway conversation keeps the replay out of the real conversation's context
+	replayConv := &model.Conversation{
+		ID:       primitive.NewObjectID(),
+		TenantID: conv.TenantID,
+		Platform: conv.Platform,
+		UserID:   conv.UserID,
+		Messages: conv.Messages[:turn.MessageIndex],
+	}
+	defer clone.contextManager.ClearContext(replayConv.ID.Hex())
+
+	reply, err := clone.reply(ctx, replayConv)
+	requests := player.Requests()
+
+	result := &replay.Rerun{
+		Reply:      reply,
+		Requests:   len(requests),
+		DivergedAt: replay.DivergedAt(turn.Exchanges, requests),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Matches = err == nil && turn.Error == "" && reply == turn.Reply && result.DivergedAt == -1
+
+	slog.InfoContext(ctx, "Conversation turn re-run",
+		"conversation_id", turn.ConversationID,
+		"message_index", turn.MessageIndex,
+		"requests", result.Requests,
+		"diverged_at", result.DivergedAt,
+		"matches", result.Matches)
+
+	return result, nil
+}
+
+// replayTools wraps the registered tools so they return the recorded outputs instead of running
+func replayTools(tools *registry.ToolRegistry, executions []replay.ToolExecution) *registry.ToolRegistry {
+	recorded := &recordedOutputs{byName: make(map[string][]replay.ToolExecution)}
+	for _, e := range executions {
+		recorded.byName[e.Name] = append(recorded.byName[e.Name], e)
+	}
+
+	replayRegistry := registry.NewToolRegistry()
+	for _, tool := range tools.GetAll() {
+		replayRegistry.Register(&replayTool{Tool: tool, recorded: recorded})
+	}
+	return replayRegistry
+}
+
+// recordedOutputs hands out recorded tool outputs per tool, in call order
+type recordedOutputs struct {
+	mu     sync.Mutex
+	byName map[string][]replay.ToolExecution
+}
+
+func (r *recordedOutputs) next(name string) (replay.ToolExecution, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queue := r.byName[name]
+	if len(queue) == 0 {
+		return replay.ToolExecution{}, false
+	}
+	r.byName[name] = queue[1:]
+	return queue[0], true
+}
+
+type replayTool struct {
+	registry.Tool
+	recorded *recordedOutputs
+}
+
+func (t *replayTool) Execute(_ context.Context, _ map[string]interface{}) (string, error) {
+	execution, ok := t.recorded.next(t.Name())
+	if !ok {
+		return "", fmt.Errorf("no recorded output for tool %s", t.Name())
+	}
+	if execution.Error != "" {
+		return "", errors.New(execution.Error)
+	}
+	return execution.Output, nil
+}
@@ -0,0 +1,99 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/sentiment"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+const sentimentPrompt = "Classify the sentiment the user expresses towards the assistant or their situation. " +
+	`Respond with a JSON object {"sentiment": "positive" | "negative" | "neutral", "score": number} ` +
+	"where score ranges from -1 (very negative) to 1 (very positive)."
+
+// maxSentimentInputChars caps how much of a message is sent for classification
+const maxSentimentInputChars = 1000
+
+// SentimentClassifier labels user messages with a cheap model
+type SentimentClassifier struct {
+	assistant *UnifiedAssistant
+	model     string
+}
+
+// NewSentimentClassifier creates a classifier using the assistant's OpenAI client and tenant credentials
+func NewSentimentClassifier(ua *UnifiedAssistant, model string) *SentimentClassifier {
+	if model == "" {
+		model = openai.ChatModelGPT4oMini
+	}
+	return &SentimentClassifier{
+		assistant: ua,
+		model:     model,
+	}
+}
+
+// Classify returns the sentiment of a user message
+func (c *SentimentClassifier) Classify(ctx context.Context, text string) (sentiment.Result, error) {
+	ctx = c.assistant.withCredentials(ctx)
+	if len(text) > maxSentimentInputChars {
+		text = text[:maxSentimentInputChars]
+	}
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, c.assistant.retryConfig, func() (*openai.ChatCompletion, error) {
+		return c.assistant.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Model: c.model,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage(sentimentPrompt),
+				openai.UserMessage(text),
+			},
+			MaxTokens: openai.Int(30),
+			ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+			},
+		}, c.assistant.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return sentiment.Result{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return sentiment.Result{}, errors.New("empty response from OpenAI for sentiment classification")
+	}
+
+	if c.assistant.metrics != nil {
+		c.assistant.metrics.RecordOpenAIRequestWithTokens(ctx, "sentiment", c.model,
+			"", "", duration,
+			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
+	}
+	c.assistant.recordUsage(ctx, "sentiment", c.model, nil, resp.Usage)
+
+	slog.DebugContext(ctx, "OpenAI API call completed",
+		"operation", "sentiment",
+		"model", c.model,
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	var result sentiment.Result
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return sentiment.Result{}, fmt.Errorf("failed to parse sentiment: %w", err)
+	}
+
+	switch result.Sentiment {
+	case model.SentimentPositive, model.SentimentNegative, model.SentimentNeutral:
+	default:
+		return sentiment.Result{}, fmt.Errorf("unknown sentiment %q", result.Sentiment)
+	}
+	result.Score = max(-1, min(1, result.Score))
+
+	return result, nil
+}
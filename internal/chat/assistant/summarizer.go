@@ -0,0 +1,202 @@
+package assistant
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tokens"
+	"github.com/openai/openai-go"
+)
+
+// maxSummaryInputChars caps how much of a single message is sent for summarization
+const maxSummaryInputChars = 2000
+
+type summaryScopeKey struct{}
+
+// summaryScope selects the summarization prompt variant for the conversation being summarized
+type summaryScope struct {
+	platform string
+	language string
+}
+
+// withSummaryScope returns a context selecting the summarization prompt for a platform and language
+func withSummaryScope(ctx context.Context, platform, language string) context.Context {
+	return context.WithValue(ctx, summaryScopeKey{}, summaryScope{platform: platform, language: language})
+}
+
+// StreamingSummarizer summarizes older messages with a cheaper model
+// The completion is streamed and cut off at the token cap or the deadline, so an emergency
+// context reduction does not double the latency of the reply it is part of
+type StreamingSummarizer struct {
+	assistant    *UnifiedAssistant
+	model        string
+	timeout      time.Duration
+	tokenCounter *tokens.TokenCounter
+}
+
+// NewStreamingSummarizer creates a summarizer using the assistant's OpenAI client and tenant credentials
+func NewStreamingSummarizer(ua *UnifiedAssistant, model string, timeout time.Duration, tokenCounter *tokens.TokenCounter) *StreamingSummarizer {
+	if model == "" {
+		model = openai.ChatModelGPT4oMini
+	}
+	if timeout <= 0 {
+		timeout = 8 * time.Second
+	}
+	return &StreamingSummarizer{
+		assistant:    ua,
+		model:        model,
+		timeout:      timeout,
+		tokenCounter: tokenCounter,
+	}
+}
+
+// Summarize streams a summary of the messages, returning the partial text if the cap or deadline is hit
+func (s *StreamingSummarizer) Summarize(ctx context.Context, messages []chat.Message, maxTokens int) (string, error) {
+	var input strings.Builder
+	for _, msg := range messages {
+		content := msg.Content
+		if len(content) > maxSummaryInputChars {
+			content = content[:maxSummaryInputChars] + "..."
+		}
+		input.WriteString(msg.Role)
+		if msg.Name != "" {
+			input.WriteString(" " + msg.Name)
+		}
+		input.WriteString(": ")
+		input.WriteString(content)
+		input.WriteString("\n")
+	}
+
+	prompt, err := s.prompt(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return s.stream(ctx, "summary", prompt, input.String(), maxTokens)
+}
+
+// stream runs a completion of the cheaper model, cut off at maxTokens or the deadline
+func (s *StreamingSummarizer) stream(ctx context.Context, operation, prompt, input string, maxTokens int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	stream := s.assistant.cli.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Model: s.model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(prompt),
+			openai.UserMessage(input),
+		},
+		MaxTokens: openai.Int(int64(maxTokens)),
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		},
+	}, s.assistant.requestOptions(ctx)...)
+	defer stream.Close()
+
+	var summary strings.Builder
+	var usage openai.CompletionUsage
+	var firstToken time.Time
+	truncated := false
+	for stream.Next() {
+		chunk := stream.Current()
+		if chunk.Usage.TotalTokens > 0 {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if firstToken.IsZero() && chunk.Choices[0].Delta.Content != "" {
+			firstToken = time.Now()
+		}
+		summary.WriteString(chunk.Choices[0].Delta.Content)
+
+		// Stop reading as soon as the cap is reached instead of waiting for the model to finish
+		if s.countTokens(summary.String()) >= maxTokens {
+			truncated = true
+			break
+		}
+	}
+
+	text := summary.String()
+	if err := stream.Err(); err != nil && !truncated {
+		// A deadline with partial output still leaves a usable summary
+		if text == "" || !errors.Is(err, context.DeadlineExceeded) {
+			return "", err
+		}
+		truncated = true
+	}
+	if truncated {
+		text = trimToSentence(text)
+	}
+	duration := time.Since(start)
+
+	if usage.TotalTokens == 0 {
+		// Usage only arrives with the final chunk, so estimate it when the stream was cut off
+		usage.CompletionTokens = int64(s.countTokens(text))
+		usage.PromptTokens = int64(s.countTokens(input))
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	if s.assistant.metrics != nil {
+		s.assistant.metrics.RecordOpenAIRequestWithTokens(ctx, operation, s.model,
+			"", "", duration,
+			usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+		if !firstToken.IsZero() {
+			timeToFirstToken := firstToken.Sub(start)
+			s.assistant.metrics.RecordOpenAIStream(ctx, operation, s.model,
+				timeToFirstToken, duration-timeToFirstToken, usage.CompletionTokens)
+		}
+	}
+	s.assistant.recordUsage(ctx, operation, s.model, nil, usage)
+
+	slog.InfoContext(ctx, "OpenAI API call completed",
+		"operation", operation,
+		"model", s.model,
+		"prompt_tokens", usage.PromptTokens,
+		"completion_tokens", usage.CompletionTokens,
+		"truncated", truncated,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	return text, nil
+}
+
+// prompt returns the summarization prompt for the platform and language of the conversation
+func (s *StreamingSummarizer) prompt(ctx context.Context) (string, error) {
+	scope, ok := ctx.Value(summaryScopeKey{}).(summaryScope)
+	if !ok {
+		scope.platform = model.DefaultPlatform
+	}
+
+	prompt, err := s.assistant.promptManager.GetPromptWithLocale(ctx, model.PromptNameSummarization,
+		scope.platform, model.DefaultUserSegment, scope.language)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get summarization prompt, using fallback", "error", err)
+		return s.assistant.promptManager.GetFallbackPrompt(model.PromptNameSummarization)
+	}
+	return prompt, nil
+}
+
+func (s *StreamingSummarizer) countTokens(text string) int {
+	if s.tokenCounter != nil {
+		return s.tokenCounter.Count(text)
+	}
+	return len(text)/3 + 1
+}
+
+// trimToSentence drops a trailing partial sentence left by an early stop
+func trimToSentence(text string) string {
+	if i := strings.LastIndexAny(text, ".!?"); i > len(text)/2 {
+		return text[:i+1]
+	}
+	return strings.TrimSpace(text)
+}
+
+// Ensure StreamingSummarizer implements chat.Summarizer interface
+var _ chat.Summarizer = (*StreamingSummarizer)(nil)
@@ -0,0 +1,105 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+const summaryJudgePrompt = "You check summaries of conversation excerpts. Rate how faithfully the summary keeps " +
+	"the facts, user requests and decisions of the excerpt: 1 when nothing important is missing or wrong, " +
+	"0 when it is unrelated or contradicts the excerpt. " +
+	`Respond with a JSON object {"score": number} where score ranges from 0 to 1.`
+
+// SummaryJudge scores context summaries with a cheap model
+type SummaryJudge struct {
+	assistant *UnifiedAssistant
+	model     string
+}
+
+// NewSummaryJudge creates a judge using the assistant's OpenAI client and tenant credentials
+func NewSummaryJudge(ua *UnifiedAssistant, model string) *SummaryJudge {
+	if model == "" {
+		model = openai.ChatModelGPT4oMini
+	}
+	return &SummaryJudge{
+		assistant: ua,
+		model:     model,
+	}
+}
+
+// ScoreSummary returns how faithfully summary keeps the content of messages, from 0 to 1
+func (j *SummaryJudge) ScoreSummary(ctx context.Context, messages []chat.Message, summary string) (float64, error) {
+	ctx = j.assistant.withCredentials(ctx)
+
+	var input strings.Builder
+	input.WriteString("Excerpt:\n")
+	for _, msg := range messages {
+		content := msg.Content
+		if len(content) > maxSummaryInputChars {
+			content = content[:maxSummaryInputChars] + "..."
+		}
+		input.WriteString(msg.Role + ": " + content + "\n")
+	}
+	input.WriteString("\nSummary:\n" + summary)
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, j.assistant.retryConfig, func() (*openai.ChatCompletion, error) {
+		return j.assistant.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Model: j.model,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage(summaryJudgePrompt),
+				openai.UserMessage(input.String()),
+			},
+			MaxTokens: openai.Int(20),
+			ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+			},
+		}, j.assistant.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Choices) == 0 {
+		return 0, errors.New("empty response from OpenAI for summary scoring")
+	}
+
+	if j.assistant.metrics != nil {
+		j.assistant.metrics.RecordOpenAIRequestWithTokens(ctx, "summary_judge", j.model,
+			"", "", duration,
+			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
+	}
+	j.assistant.recordUsage(ctx, "summary_judge", j.model, nil, resp.Usage)
+
+	slog.DebugContext(ctx, "OpenAI API call completed",
+		"operation", "summary_judge",
+		"model", j.model,
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	var result struct {
+		Score *float64 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return 0, fmt.Errorf("failed to parse summary score: %w", err)
+	}
+	if result.Score == nil {
+		return 0, errors.New("summary score missing from response")
+	}
+	return max(0, min(1, *result.Score)), nil
+}
+
+// Ensure SummaryJudge implements chat.SummaryJudge interface
+var _ chat.SummaryJudge = (*SummaryJudge)(nil)
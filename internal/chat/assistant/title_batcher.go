@@ -0,0 +1,296 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+// TitleStore persists generated titles and finds conversations that still need one
+type TitleStore interface {
+	UpdateConversationTitle(ctx context.Context, id string, title string) error
+	ListUntitledConversations(ctx context.Context, createdBefore time.Time, limit int) ([]*model.Conversation, error)
+}
+
+// TitleBatcher generates conversation titles in the background, grouping several
+// conversations into a single OpenAI request
+type TitleBatcher struct {
+	assistant *UnifiedAssistant
+	store     TitleStore
+	queue     chan *model.Conversation
+	batchSize int
+	interval  time.Duration
+}
+
+// NewTitleBatcher creates a background title batching worker
+func NewTitleBatcher(ua *UnifiedAssistant, store TitleStore, batchSize int, interval time.Duration) *TitleBatcher {
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	return &TitleBatcher{
+		assistant: ua,
+		store:     store,
+		queue:     make(chan *model.Conversation, batchSize*10),
+		batchSize: batchSize,
+		interval:  interval,
+	}
+}
+
+// ScheduleTitle queues a conversation for background title generation
+// Returns false when the queue is full and the caller should generate the title itself
+func (b *TitleBatcher) ScheduleTitle(conv *model.Conversation) bool {
+	// Queue a snapshot so the caller can keep appending messages without racing the worker
+	snapshot := &model.Conversation{
+		ID:       conv.ID,
+		TenantID: conv.TenantID,
+		Platform: conv.Platform,
+		UserID:   conv.UserID,
+		Messages: conv.Messages[:min(len(conv.Messages), 1)],
+	}
+
+	select {
+	case b.queue <- snapshot:
+		return true
+	default:
+		slog.Warn("Title batch queue is full, falling back to synchronous generation",
+			"conversation_id", conv.ID.Hex())
+		return false
+	}
+}
+
+// Run processes queued conversations until the context is cancelled
+func (b *TitleBatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	slog.InfoContext(ctx, "Title batcher started", "batch_size", b.batchSize, "interval", b.interval)
+
+	pending := make([]*model.Conversation, 0, b.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Title batcher stopped", "pending", len(pending))
+			return
+		case conv := <-b.queue:
+			pending = append(pending, conv)
+			if len(pending) >= b.batchSize {
+				b.flush(ctx, pending)
+				pending = pending[:0]
+			}
+		case <-ticker.C:
+			if len(pending) == 0 {
+				pending = b.sweep(ctx)
+			}
+			if len(pending) > 0 {
+				b.flush(ctx, pending)
+				pending = pending[:0]
+			}
+		}
+	}
+}
+
+// sweep picks up untitled conversations that were never queued, e.g. created by the session manager
+func (b *TitleBatcher) sweep(ctx context.Context) []*model.Conversation {
+	// Skip recent conversations that may still be waiting in the queue
+	createdBefore := time.Now().Add(-2 * b.interval)
+
+	conversations, err := b.store.ListUntitledConversations(ctx, createdBefore, b.batchSize)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list untitled conversations", "error", err)
+		return nil
+	}
+
+	var pending []*model.Conversation
+	for _, conv := range conversations {
+		if len(conv.Messages) > 0 {
+			pending = append(pending, conv)
+		}
+	}
+	return pending
+}
+
+// flush generates and stores titles for a batch of conversations
+// Conversations are grouped by tenant so prompts, caches and API keys stay tenant-scoped
+func (b *TitleBatcher) flush(ctx context.Context, batch []*model.Conversation) {
+	byTenant := make(map[string][]*model.Conversation)
+	for _, conv := range batch {
+		byTenant[conv.TenantID] = append(byTenant[conv.TenantID], conv)
+	}
+
+	for tenantID, conversations := range byTenant {
+		tenantCtx := b.assistant.withCredentials(tenant.WithTenant(ctx, tenantID))
+		b.flushTenant(tenantCtx, conversations)
+	}
+}
+
+// flushTenant generates and stores titles for conversations of a single tenant
+func (b *TitleBatcher) flushTenant(ctx context.Context, batch []*model.Conversation) {
+	titles, err := b.assistant.GenerateTitles(ctx, batch)
+	if err != nil {
+		slog.WarnContext(ctx, "Batched title generation failed, generating titles individually",
+			"batch_size", len(batch), "error", err)
+
+		titles = make([]string, len(batch))
+		for i, conv := range batch {
+			title, err := b.assistant.Title(ctx, conv)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to generate conversation title",
+					"conversation_id", conv.ID.Hex(), "error", err)
+				continue
+			}
+			titles[i] = title
+		}
+	}
+
+	for i, conv := range batch {
+		if titles[i] == "" {
+			continue
+		}
+		if err := b.store.UpdateConversationTitle(ctx, conv.ID.Hex(), titles[i]); err != nil {
+			slog.ErrorContext(ctx, "Failed to store conversation title",
+				"conversation_id", conv.ID.Hex(), "error", err)
+		}
+	}
+}
+
+// GenerateTitles generates titles for several conversations with a single OpenAI request
+// Cached titles are reused; the returned slice is aligned with the input conversations
+func (ua *UnifiedAssistant) GenerateTitles(ctx context.Context, conversations []*model.Conversation) ([]string, error) {
+	titles := make([]string, len(conversations))
+	cacheKeys := make([]string, len(conversations))
+
+	// Cached titles of the whole batch are read with a single round trip
+	var lookup []int
+	var keys []string
+	var dests []interface{}
+	for i, conv := range conversations {
+		if len(conv.Messages) == 0 {
+			titles[i] = "An empty conversation"
+			continue
+		}
+		cacheKeys[i] = ua.titleCacheKey(conv.ID.Hex())
+		lookup = append(lookup, i)
+		keys = append(keys, cacheKeys[i])
+		dests = append(dests, &titles[i])
+	}
+
+	found, err := ua.cache.MGet(ctx, keys, dests)
+	if err != nil {
+		slog.WarnContext(ctx, "Cache error, proceeding without cache", "error", err)
+		found = make([]bool, len(keys))
+	}
+	var missing []int
+	for n, idx := range lookup {
+		if !found[n] {
+			titles[idx] = ""
+			missing = append(missing, idx)
+		}
+	}
+
+	if len(missing) == 0 {
+		return titles, nil
+	}
+
+	titlePrompt, err := ua.resolvePrompt(ctx, model.PromptNameTitleGeneration, model.DefaultPlatform, model.DefaultUserSegment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fallback title prompt: %w", err)
+	}
+
+	var input strings.Builder
+	for n, idx := range missing {
+		fmt.Fprintf(&input, "%d. %s\n", n+1, conversations[idx].Messages[0].Content)
+	}
+
+	systemPrompt := titlePrompt.Content + fmt.Sprintf("\n\nYou will receive %d numbered messages. "+
+		`Respond with a JSON object {"titles": [...]} containing exactly one title per message, in the same order.`, len(missing))
+
+	titleModel, degraded := ua.degradation.ChatModel(openai.ChatModelGPT4Turbo)
+	params := openai.ChatCompletionNewParams{
+		Model: titleModel,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.UserMessage(input.String()),
+		},
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		},
+	}
+	titlePrompt.Apply(&params)
+	// The token budget of the prompt is for one title
+	maxTokens := int64(30)
+	if titlePrompt.Params != nil && titlePrompt.Params.MaxTokens != nil {
+		maxTokens = *titlePrompt.Params.MaxTokens
+	}
+	params.MaxTokens = openai.Int(maxTokens * int64(len(missing)))
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, ua.retryConfig, func() (*openai.ChatCompletion, error) {
+		return ua.cli.Chat.Completions.New(ctx, params, ua.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+	ua.reportOpenAI(degraded, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("empty response from OpenAI for batched title generation")
+	}
+
+	if ua.metrics != nil {
+		ua.metrics.RecordOpenAIRequestWithTokens(ctx, "title_batch", titleModel,
+			"", "", duration,
+			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
+	}
+	ua.recordUsage(ctx, "title_batch", titleModel, nil, resp.Usage)
+
+	slog.InfoContext(ctx, "OpenAI API call completed",
+		"operation", "title_batch",
+		"model", titleModel,
+		"batch_size", len(missing),
+		"prompt_tokens", resp.Usage.PromptTokens,
+		"completion_tokens", resp.Usage.CompletionTokens,
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	var parsed struct {
+		Titles []string `json:"titles"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse batched titles: %w", err)
+	}
+	if len(parsed.Titles) != len(missing) {
+		return nil, fmt.Errorf("expected %d titles, got %d", len(missing), len(parsed.Titles))
+	}
+
+	generated := make(map[string]interface{}, len(missing))
+	for n, idx := range missing {
+		title := ua.formatTitle(parsed.Titles[n])
+		if title == "" {
+			title = ua.generateFallbackTitle(conversations[idx].Messages[0].Content)
+		}
+		titles[idx] = title
+		generated[cacheKeys[idx]] = title
+	}
+	if err := ua.cache.MSet(ctx, generated); err != nil {
+		slog.WarnContext(ctx, "Failed to cache titles", "error", err)
+	}
+
+	return titles, nil
+}
@@ -0,0 +1,102 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/topics"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/shared"
+)
+
+var topicPrompt = "Classify which of these topics the user message is about: " + strings.Join(topics.All, ", ") + ". " +
+	"smalltalk covers greetings, thanks and chatter; support covers problems with the service or an account; " +
+	"scheduling covers dates, meetings, reminders and holidays. " +
+	`Respond with a JSON object {"topics": [string]} listing every matching topic, or an empty list if none match.`
+
+// TopicClassifier tags user messages with coarse topics using a cheap model
+type TopicClassifier struct {
+	assistant *UnifiedAssistant
+	model     string
+}
+
+// NewTopicClassifier creates a classifier using the assistant's OpenAI client and tenant credentials
+func NewTopicClassifier(ua *UnifiedAssistant, model string) *TopicClassifier {
+	if model == "" {
+		model = openai.ChatModelGPT4oMini
+	}
+	return &TopicClassifier{
+		assistant: ua,
+		model:     model,
+	}
+}
+
+// Classify returns the topics of a user message; unknown topics in the response are dropped
+func (c *TopicClassifier) Classify(ctx context.Context, text string) ([]string, error) {
+	ctx = c.assistant.withCredentials(ctx)
+	if len(text) > maxSentimentInputChars {
+		text = text[:maxSentimentInputChars]
+	}
+
+	start := time.Now()
+	resp, err := retry.RetryWithResult(ctx, c.assistant.retryConfig, func() (*openai.ChatCompletion, error) {
+		return c.assistant.cli.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+			Model: c.model,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage(topicPrompt),
+				openai.UserMessage(text),
+			},
+			MaxTokens: openai.Int(40),
+			ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+				OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+			},
+		}, c.assistant.requestOptions(ctx)...)
+	})
+	duration := time.Since(start)
+
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("empty response from OpenAI for topic classification")
+	}
+
+	if c.assistant.metrics != nil {
+		c.assistant.metrics.RecordOpenAIRequestWithTokens(ctx, "topics", c.model,
+			"", "", duration,
+			int64(resp.Usage.PromptTokens), int64(resp.Usage.CompletionTokens), int64(resp.Usage.TotalTokens))
+	}
+	c.assistant.recordUsage(ctx, "topics", c.model, nil, resp.Usage)
+
+	slog.DebugContext(ctx, "OpenAI API call completed",
+		"operation", "topics",
+		"model", c.model,
+		"total_tokens", resp.Usage.TotalTokens,
+		"duration_ms", duration.Milliseconds(),
+	)
+
+	var result struct {
+		Topics []string `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse topics: %w", err)
+	}
+
+	var found []string
+	for _, topic := range result.Topics {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topics.IsKnown(topic) {
+			found = append(found, topic)
+		}
+	}
+	return found, nil
+}
+
+// Ensure TopicClassifier implements topics.Classifier interface
+var _ topics.Classifier = (*TopicClassifier)(nil)
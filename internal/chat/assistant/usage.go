@@ -0,0 +1,136 @@
+package assistant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// UsageRecorder stores per-tenant token usage for billing exports
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, record *billing.UsageRecord) error
+}
+
+// CredentialResolver attaches the API keys of the context's tenant
+type CredentialResolver interface {
+	Attach(ctx context.Context) context.Context
+}
+
+// requestOptions switches OpenAI requests to the tenant's own key when it has one, tags them with the
+// conversation they are made for and records them when the context belongs to a recorded turn
+func (ua *UnifiedAssistant) requestOptions(ctx context.Context) []option.RequestOption {
+	var opts []option.RequestOption
+	if creds := tenant.CredentialsFromContext(ctx); creds != nil && creds.OpenAIAPIKey != "" {
+		// The platform's organization and project do not own tenant keys
+		opts = append(opts, option.WithAPIKey(creds.OpenAIAPIKey),
+			option.WithHeaderDel("OpenAI-Organization"), option.WithHeaderDel("OpenAI-Project"))
+	}
+	if tag := spendTag(ctx); tag != "" {
+		opts = append(opts, option.WithJSONSet("user", tag))
+	}
+	if rec := replay.FromContext(ctx); rec != nil {
+		opts = append(opts, option.WithMiddleware(rec.Middleware))
+	}
+	return opts
+}
+
+type spendTagKey struct{}
+
+// withSpendTag tags the OpenAI requests made with the context with the conversation's SpendTag
+func (ua *UnifiedAssistant) withSpendTag(ctx context.Context, conv *model.Conversation) context.Context {
+	if !ua.cfg.OpenAIUserTags || conv.ID.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, spendTagKey{}, SpendTag(tenant.FromContext(ctx), conv.ID.Hex()))
+}
+
+// spendTag returns the tag of the context's OpenAI requests, "" when they are not made for a conversation
+func spendTag(ctx context.Context) string {
+	tag, _ := ctx.Value(spendTagKey{}).(string)
+	return tag
+}
+
+// SpendTag returns the user OpenAI requests of a conversation are attributed to in its usage dashboard
+// The conversation is hashed so OpenAI never sees its ID; operators trace flagged requests by the
+// spend_tag of reply logs, or by hashing a suspect conversation
+func SpendTag(tenantID, conversationID string) string {
+	sum := sha256.Sum256([]byte(tenantID + "/" + conversationID))
+	return "conv_" + hex.EncodeToString(sum[:12])
+}
+
+// withCredentials resolves tenant API keys for contexts that did not come through the HTTP middleware
+func (ua *UnifiedAssistant) withCredentials(ctx context.Context) context.Context {
+	if ua.credentials == nil || tenant.CredentialsFromContext(ctx) != nil {
+		return ctx
+	}
+	return ua.credentials.Attach(ctx)
+}
+
+// recordUsage attributes the token usage of an OpenAI request to the context's tenant
+// Records are written in the background so billing storage never slows down replies
+func (ua *UnifiedAssistant) recordUsage(ctx context.Context, operation, modelName string, conv *model.Conversation, usage openai.CompletionUsage) {
+	if ua.usage == nil {
+		return
+	}
+
+	record := usageRecord(ctx, operation, modelName, conv)
+	record.PromptTokens = usage.PromptTokens
+	record.CompletionTokens = usage.CompletionTokens
+	record.TotalTokens = usage.TotalTokens
+	ua.saveUsage(ctx, record)
+}
+
+// recordFailure records a reply that failed, so usage statistics count errors next to requests
+func (ua *UnifiedAssistant) recordFailure(ctx context.Context, operation string, conv *model.Conversation) {
+	if ua.usage == nil {
+		return
+	}
+
+	record := usageRecord(ctx, operation, "", conv)
+	record.Failed = true
+	ua.saveUsage(ctx, record)
+}
+
+func usageRecord(ctx context.Context, operation, modelName string, conv *model.Conversation) *billing.UsageRecord {
+	record := &billing.UsageRecord{
+		TenantID:  tenant.FromContext(ctx),
+		Operation: operation,
+		Model:     modelName,
+		KeySource: billing.KeySourcePlatform,
+		CreatedAt: time.Now(),
+	}
+	if creds := tenant.CredentialsFromContext(ctx); creds != nil && creds.OpenAIAPIKey != "" {
+		record.KeySource = billing.KeySourceTenant
+	}
+	if conv != nil {
+		record.Platform = conv.Platform
+		record.UserID = conv.UserID
+		record.ConversationID = conv.ID.Hex()
+		record.PromptVersion = conv.PromptVersion
+	}
+	return record
+}
+
+func (ua *UnifiedAssistant) saveUsage(ctx context.Context, record *billing.UsageRecord) {
+	go func() {
+		recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := ua.usage.RecordUsage(recordCtx, record); err != nil {
+			slog.WarnContext(recordCtx, "Failed to record token usage",
+				"tenant_id", record.TenantID,
+				"operation", record.Operation,
+				"total_tokens", record.TotalTokens,
+				"error", err)
+		}
+	}()
+}
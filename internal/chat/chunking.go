@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"context"
+	"strings"
+)
+
+// LongInputPrefix marks a user message that was condensed from the summaries of its chunks
+const LongInputPrefix = "[Long message condensed from %d parts]"
+
+// SplitChunks splits text into chunks of up to maxTokens, breaking between lines where possible
+// and between words otherwise; a single word longer than maxTokens becomes a chunk of its own
+func SplitChunks(text string, maxTokens int, countTokens func(string) int) []string {
+	if maxTokens <= 0 || countTokens(text) <= maxTokens {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+		currentTokens = 0
+	}
+	add := func(piece, sep string) {
+		tokens := countTokens(piece)
+		if currentTokens > 0 && currentTokens+tokens > maxTokens {
+			flush()
+		}
+		current.WriteString(piece)
+		current.WriteString(sep)
+		currentTokens += tokens
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if countTokens(line) <= maxTokens {
+			add(line, "")
+			continue
+		}
+		// A line too long for a chunk, such as a pasted log without line breaks, is split between words
+		for _, word := range strings.Fields(line) {
+			add(word, " ")
+		}
+		current.WriteString("\n")
+	}
+	flush()
+
+	return chunks
+}
+
+// Progress reports how far a long running step of a reply has got
+type Progress struct {
+	Stage string // e.g. "long_input"
+	Done  int
+	Total int
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context whose long running reply steps report their progress to fn
+// Transports that stream replies use it to keep the user informed while a long input is processed
+func WithProgress(ctx context.Context, fn func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports progress to the context's listener, if any
+func ReportProgress(ctx context.Context, p Progress) {
+	if fn, ok := ctx.Value(progressKey{}).(func(Progress)); ok && fn != nil {
+		fn(p)
+	}
+}
@@ -0,0 +1,211 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/gorilla/mux"
+	"github.com/twitchtv/twirp"
+)
+
+// ContextSnapshot is what was sent to the model on a conversation's latest turn,
+// after summarization and truncation; kept to diagnose replies that miss earlier messages
+type ContextSnapshot struct {
+	ConversationID string    `json:"conversation_id"`
+	MessageIndex   int       `json:"message_index"` // Stored messages the turn replied to
+	Model          string    `json:"model"`
+	SystemPrompt   string    `json:"system_prompt"`
+	PinnedFacts    []string  `json:"pinned_facts,omitempty"`
+	Messages       []Message `json:"messages"`
+	Tokens         int       `json:"tokens"` // Estimated tokens of the request
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Statuses of stored messages in a ContextDiff
+const (
+	StatusSent       = "sent"       // Sent to the model as stored
+	StatusCondensed  = "condensed"  // The turn's new message, sent as summaries of its chunks
+	StatusSummarized = "summarized" // Not sent itself; older than the messages sent, which a summary stands in for
+	StatusDropped    = "dropped"    // Not sent and not summarized: the model did not see it on the latest turn
+	StatusLater      = "later"      // Stored after the latest turn, such as its reply
+)
+
+// Kinds of entries in the context sent to the model
+const (
+	KindSystemPrompt = "system_prompt"
+	KindPinnedFacts  = "pinned_facts"
+	KindSummary      = "summary"
+	KindMessage      = "message"
+	KindTool         = "tool"
+)
+
+// ContextDiff compares the stored messages of a conversation with the context sent on its latest turn
+type ContextDiff struct {
+	ConversationID string         `json:"conversation_id"`
+	MessageIndex   int            `json:"message_index"`
+	Model          string         `json:"model"`
+	Tokens         int            `json:"tokens"`
+	CapturedAt     time.Time      `json:"captured_at"`
+	Stored         []StoredEntry  `json:"stored"`
+	Context        []ContextEntry `json:"context"`
+	Counts         map[string]int `json:"counts"` // Stored messages per status
+}
+
+// StoredEntry is a stored message and what became of it on the latest turn
+type StoredEntry struct {
+	Index   int    `json:"index"`
+	ID      string `json:"id"`
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+// ContextEntry is a message sent to the model
+type ContextEntry struct {
+	Kind        string `json:"kind"`
+	Role        string `json:"role"`
+	Content     string `json:"content"`
+	StoredIndex int    `json:"stored_index"` // Index of the stored message it is, -1 for everything else
+}
+
+// DiffContext matches the stored messages of a conversation against a snapshot of its latest turn
+// Stored messages are matched in order by role and content; the context is usually a suffix of the conversation
+func DiffContext(conv *model.Conversation, snapshot *ContextSnapshot) *ContextDiff {
+	diff := &ContextDiff{
+		ConversationID: snapshot.ConversationID,
+		MessageIndex:   snapshot.MessageIndex,
+		Model:          snapshot.Model,
+		Tokens:         snapshot.Tokens,
+		CapturedAt:     snapshot.CreatedAt,
+		Counts:         make(map[string]int),
+	}
+
+	diff.Context = append(diff.Context, ContextEntry{Kind: KindSystemPrompt, Role: "system", Content: snapshot.SystemPrompt, StoredIndex: -1})
+	if pinned, ok := PinnedFactsMessage(snapshot.PinnedFacts); ok {
+		diff.Context = append(diff.Context, ContextEntry{Kind: KindPinnedFacts, Role: pinned.Role, Content: pinned.Content, StoredIndex: -1})
+	}
+	first := len(diff.Context)
+	summarized := false
+	for _, msg := range snapshot.Messages {
+		entry := ContextEntry{Kind: KindMessage, Role: msg.Role, Content: msg.Content, StoredIndex: -1}
+		switch {
+		case msg.Role == "system" && strings.HasPrefix(msg.Content, SummaryPrefix):
+			entry.Kind = KindSummary
+			summarized = true
+		case msg.Role == "tool" || len(msg.ToolCalls) > 0:
+			entry.Kind = KindTool
+		}
+		diff.Context = append(diff.Context, entry)
+	}
+
+	next := first
+	firstSent := -1
+	for i, msg := range conv.Messages {
+		stored := StoredEntry{Index: i, ID: msg.ID.Hex(), Role: string(msg.Role), Content: msg.Content, Status: StatusDropped}
+		if i >= snapshot.MessageIndex {
+			stored.Status = StatusLater
+		} else {
+			// System notes are sent with a prefix
+			sent := ConvertModelMessage(msg)
+			for j := next; j < len(diff.Context); j++ {
+				entry := &diff.Context[j]
+				if entry.Kind == KindMessage && entry.Role == sent.Role && entry.Content == sent.Content {
+					entry.StoredIndex = i
+					stored.Status = StatusSent
+					next = j + 1
+					if firstSent < 0 {
+						firstSent = i
+					}
+					break
+				}
+			}
+		}
+		diff.Stored = append(diff.Stored, stored)
+	}
+
+	for i := range diff.Stored {
+		stored := &diff.Stored[i]
+		switch {
+		case stored.Status != StatusDropped:
+		case i == snapshot.MessageIndex-1 && stored.Role == string(model.RoleUser) && condensedLast(diff.Context):
+			stored.Status = StatusCondensed
+		case summarized && (firstSent < 0 || i < firstSent):
+			stored.Status = StatusSummarized
+		}
+		diff.Counts[stored.Status]++
+	}
+	return diff
+}
+
+// condensedLast reports whether the last message sent is an unmatched user message, the condensed new message
+func condensedLast(entries []ContextEntry) bool {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Kind == KindMessage {
+			return entries[i].Role == string(model.RoleUser) && entries[i].StoredIndex < 0
+		}
+	}
+	return false
+}
+
+// ConversationReader loads stored conversations, see model.Repository
+type ConversationReader interface {
+	DescribeConversation(ctx context.Context, id string) (*model.Conversation, error)
+}
+
+// SnapshotReader returns the context snapshot of a conversation's latest turn, see assistant.UnifiedAssistant
+type SnapshotReader interface {
+	ContextSnapshot(ctx context.Context, conversationID string) (*ContextSnapshot, error)
+}
+
+// DebugHandler serves debugging views of conversations to admins
+type DebugHandler struct {
+	conversations ConversationReader
+	snapshots     SnapshotReader
+}
+
+// NewDebugHandler creates a conversation debugging handler
+func NewDebugHandler(conversations ConversationReader, snapshots SnapshotReader) *DebugHandler {
+	return &DebugHandler{conversations: conversations, snapshots: snapshots}
+}
+
+// ContextDiffHandler handles GET /admin/conversations/{id}/context
+// Snapshots expire with the conversation context, so only recently active conversations have one
+func (h *DebugHandler) ContextDiffHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	conv, err := h.conversations.DescribeConversation(r.Context(), id)
+	var twerr twirp.Error
+	if errors.As(err, &twerr) && twerr.Code() == twirp.NotFound {
+		writeDebugJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load conversation", "conversation_id", id, "error", err)
+		writeDebugJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load conversation"})
+		return
+	}
+
+	snapshot, err := h.snapshots.ContextSnapshot(r.Context(), conv.ID.Hex())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load context snapshot", "conversation_id", id, "error", err)
+		writeDebugJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load context snapshot"})
+		return
+	}
+	if snapshot == nil {
+		writeDebugJSON(w, http.StatusNotFound, map[string]string{"error": "no context snapshot, the conversation has not been answered since its context expired"})
+		return
+	}
+
+	writeDebugJSON(w, http.StatusOK, DiffContext(conv, snapshot))
+}
+
+func writeDebugJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
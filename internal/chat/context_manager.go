@@ -2,19 +2,35 @@ package chat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"strings"
 	"sync"
 
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/experiment"
 	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
 	"github.com/8adimka/Go_AI_Assistant/internal/tokens"
 )
 
 // Message represents a conversation message
+// Tool results carry the tool name and the ID of the call they answer; assistant messages that
+// requested tools carry the calls so the pair can be replayed to the model
 type Message struct {
-	Role    string
-	Content string
+	Role       string
+	Content    string
+	Name       string     `json:",omitempty"`
+	ToolCallID string     `json:",omitempty"`
+	ToolCalls  []ToolCall `json:",omitempty"`
+}
+
+// ToolCall is a tool invocation requested by the assistant
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
 }
 
 // ContextManagerInterface defines the interface for context management
@@ -22,40 +38,145 @@ type ContextManagerInterface interface {
 	// AddMessage adds a message to the conversation context
 	AddMessage(ctx context.Context, conversationID string, message Message) error
 
+	// AddMessages adds messages to the conversation context at once and returns the updated context
+	AddMessages(ctx context.Context, conversationID string, messages []Message) ([]Message, error)
+
+	// LoadContext returns the conversation context and its pinned facts with a single read
+	LoadContext(ctx context.Context, conversationID string) ([]Message, []string, error)
+
 	// GetContext returns the conversation context
 	GetContext(conversationID string) []Message
 
 	// GetTokenCount returns the current token count for a conversation
 	GetTokenCount(conversationID string) int
 
+	// TokenCount returns the token count of already loaded context messages
+	TokenCount(messages []Message) int
+
 	// ClearContext clears the conversation context
 	ClearContext(conversationID string)
 
 	// EnsureContextFits guarantees that the context fits within the specified token limit
 	EnsureContextFits(ctx context.Context, conversationID string, targetTokens int) error
+
+	// PinFact keeps a fact in the conversation context regardless of summarization and truncation
+	PinFact(ctx context.Context, conversationID, fact string) error
+
+	// GetPinnedFacts returns the facts pinned to a conversation in the order they were pinned
+	GetPinnedFacts(conversationID string) []string
+
+	// SaveSnapshot keeps what was sent to the model on the conversation's latest turn
+	SaveSnapshot(ctx context.Context, snapshot *ContextSnapshot) error
+
+	// LoadSnapshot returns the snapshot of the conversation's latest turn, or nil when there is none
+	LoadSnapshot(ctx context.Context, conversationID string) (*ContextSnapshot, error)
 }
 
-// ContextManager provides persistent context management with Redis storage
+// SummaryPrefix marks a message that stands in for a summarized segment of older messages
+const SummaryPrefix = "Summary of earlier messages: "
+
+// SystemNotePrefix introduces an operator note to the model, so it is not taken for an instruction of the system prompt
+const SystemNotePrefix = "Note from an operator about this conversation, not visible to the user: "
+
+// PinnedFactsPrefix introduces the pinned facts of a conversation to the model
+const PinnedFactsPrefix = "Pinned facts of this conversation, always take them into account:"
+
+// MaxPinnedFacts caps the facts pinned to a single conversation
+const MaxPinnedFacts = 20
+
+// ErrTooManyPinnedFacts is returned when a conversation already has MaxPinnedFacts pinned
+var ErrTooManyPinnedFacts = errors.New("too many pinned facts")
+
+// Summarizer condenses older messages into a short summary
+// Implementations should return whatever they produced within maxTokens rather than fail on the cap
+type Summarizer interface {
+	Summarize(ctx context.Context, messages []Message, maxTokens int) (string, error)
+}
+
+// SummaryJudge scores how faithfully a summary keeps the facts, requests and decisions of the messages
+// it replaces, from 0 (unrelated or contradicting) to 1 (complete)
+type SummaryJudge interface {
+	ScoreSummary(ctx context.Context, messages []Message, summary string) (float64, error)
+}
+
+// SummaryRecorder receives summary quality measurements, see metrics.Metrics
+type SummaryRecorder interface {
+	RecordSummaryTokens(ctx context.Context, before, after int)
+	RecordSummaryFaithfulness(ctx context.Context, score float64, accepted bool)
+}
+
+// ErrUnfaithfulSummary is returned for summaries scored below the minimum by the SummaryJudge
+var ErrUnfaithfulSummary = errors.New("summary scored below the faithfulness minimum")
+
+// ContextManager provides persistent context management, see NewContextStore for its storage
 type ContextManager struct {
-	mu           sync.RWMutex
-	cache        *redisx.Cache
-	maxTokens    int
-	maxHistory   int
-	tokenCounter *tokens.TokenCounter
+	mu            sync.RWMutex
+	cache         ContextStore
+	maxTokens     int
+	maxHistory    int
+	tokenCounter  *tokens.TokenCounter
+	summarizer    Summarizer
+	summaryTokens int
+	segmentSize   int
+
+	summaryRecorder SummaryRecorder
+	judge           SummaryJudge
+	judgeRate       float64
+	minFaithfulness float64
+}
+
+// ContextManagerOption configures optional context manager behaviour
+type ContextManagerOption func(*ContextManager)
+
+// WithSummarizer replaces dropped messages with rolling segment summaries when reducing context
+// Each segment of segmentSize messages is summarized once into at most maxTokens
+func WithSummarizer(summarizer Summarizer, maxTokens, segmentSize int) ContextManagerOption {
+	return func(cm *ContextManager) {
+		cm.summarizer = summarizer
+		cm.summaryTokens = maxTokens
+		if cm.summaryTokens <= 0 {
+			cm.summaryTokens = 300
+		}
+		cm.segmentSize = segmentSize
+		if cm.segmentSize <= 0 {
+			cm.segmentSize = 10
+		}
+	}
+}
+
+// WithSummaryRecorder reports the token counts of new summaries and the messages they replace
+func WithSummaryRecorder(recorder SummaryRecorder) ContextManagerOption {
+	return func(cm *ContextManager) {
+		cm.summaryRecorder = recorder
+	}
+}
+
+// WithSummaryJudge scores a sampleRate share of new summaries with judge; summaries scored below minScore
+// are discarded and the context falls back to basic reduction, losing old messages rather than their meaning
+func WithSummaryJudge(judge SummaryJudge, sampleRate, minScore float64) ContextManagerOption {
+	return func(cm *ContextManager) {
+		cm.judge = judge
+		cm.judgeRate = sampleRate
+		cm.minFaithfulness = minScore
+	}
 }
 
 // NewContextManager creates a new persistent context manager
-func NewContextManager(cache *redisx.Cache, maxTokens, maxHistory int, tokenCounter *tokens.TokenCounter) *ContextManager {
-	return &ContextManager{
+func NewContextManager(cache ContextStore, maxTokens, maxHistory int, tokenCounter *tokens.TokenCounter, opts ...ContextManagerOption) *ContextManager {
+	cm := &ContextManager{
 		cache:        cache,
 		maxTokens:    maxTokens,
 		maxHistory:   maxHistory,
 		tokenCounter: tokenCounter,
 	}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	return cm
 }
 
 // NewContextManagerWithDefault creates a manager with default token counter
-func NewContextManagerWithDefault(cache *redisx.Cache, maxTokens, maxHistory int) *ContextManager {
+func NewContextManagerWithDefault(cache ContextStore, maxTokens, maxHistory int) *ContextManager {
 	var tokenCounter *tokens.TokenCounter
 
 	// Try to use global counter if available
@@ -73,27 +194,72 @@ func NewContextManagerWithDefault(cache *redisx.Cache, maxTokens, maxHistory int
 
 // AddMessage adds a message to the conversation context with persistence
 func (cm *ContextManager) AddMessage(ctx context.Context, conversationID string, message Message) error {
+	_, err := cm.AddMessages(ctx, conversationID, []Message{message})
+	return err
+}
+
+// AddMessages adds messages to the conversation context with one load and one save
+// Returns the updated context so callers do not read it back
+func (cm *ContextManager) AddMessages(ctx context.Context, conversationID string, messages []Message) ([]Message, error) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
 	// Load existing context
 	existingContext, err := cm.loadContext(ctx, conversationID)
 	if err != nil {
-		return fmt.Errorf("failed to load context: %w", err)
+		return nil, fmt.Errorf("failed to load context: %w", err)
 	}
 
-	// Add new message
-	existingContext = append(existingContext, message)
+	// Add new messages
+	existingContext = append(existingContext, messages...)
 
-	// Enforce max history limit
-	if len(existingContext) > cm.maxHistory {
+	// Enforce max history limit, which the conversation's platform or persona may override
+	maxHistory := cm.maxHistory
+	if window := HistoryWindowFromContext(ctx); window.MaxMessages > 0 {
+		maxHistory = window.MaxMessages
+	}
+	if len(existingContext) > maxHistory {
 		// Remove oldest messages to stay within limit
-		excess := len(existingContext) - cm.maxHistory
+		excess := len(existingContext) - maxHistory
 		existingContext = existingContext[excess:]
 	}
 
-	// Save updated context
-	return cm.saveContext(ctx, conversationID, existingContext)
+	// Save updated context; pinned facts expire with the context they belong to
+	if err := cm.saveContext(ctx, conversationID, existingContext); err != nil {
+		return nil, err
+	}
+	cm.touch(ctx, conversationID, cm.generatePinnedKey(conversationID))
+	return existingContext, nil
+}
+
+// LoadContext returns the conversation context and its pinned facts with a single read
+func (cm *ContextManager) LoadContext(ctx context.Context, conversationID string) ([]Message, []string, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	var messages []Message
+	var facts []string
+	_, err := cm.cache.MGet(ctx,
+		[]string{cm.generateContextKey(conversationID), cm.generatePinnedKey(conversationID)},
+		[]interface{}{&messages, &facts})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load context from cache: %w", err)
+	}
+	if messages == nil {
+		messages = []Message{}
+	}
+
+	// Reading the context for a reply is activity: it stays alive as long as the conversation does
+	cm.touch(ctx, conversationID, cm.generateContextKey(conversationID), cm.generatePinnedKey(conversationID))
+	return messages, facts, nil
+}
+
+// touch refreshes the TTL of a conversation's keys; a failure only shortens their life
+func (cm *ContextManager) touch(ctx context.Context, conversationID string, keys ...string) {
+	if err := cm.cache.Touch(ctx, keys...); err != nil {
+		slog.WarnContext(ctx, "Failed to refresh context expiry",
+			"conversation_id", conversationID, "error", err)
+	}
 }
 
 // GetContext returns the conversation context from persistent storage
@@ -114,8 +280,11 @@ func (cm *ContextManager) GetContext(conversationID string) []Message {
 
 // GetTokenCount returns the current token count for a conversation
 func (cm *ContextManager) GetTokenCount(conversationID string) int {
-	messages := cm.GetContext(conversationID)
+	return cm.TokenCount(cm.GetContext(conversationID))
+}
 
+// TokenCount returns the token count of already loaded context messages
+func (cm *ContextManager) TokenCount(messages []Message) int {
 	if cm.tokenCounter != nil {
 		// Convert messages to tokens.Message format
 		tokenMessages := make([]tokens.Message, len(messages))
@@ -129,11 +298,7 @@ func (cm *ContextManager) GetTokenCount(conversationID string) int {
 	}
 
 	// Fallback to existing logic
-	totalTokens := 0
-	for _, msg := range messages {
-		totalTokens += cm.estimateTokens(msg.Content)
-	}
-	return totalTokens
+	return CountTokens(messages, cm.estimateTokens)
 }
 
 // ClearContext clears the conversation context from persistent storage
@@ -142,29 +307,83 @@ func (cm *ContextManager) ClearContext(conversationID string) {
 	defer cm.mu.Unlock()
 
 	ctx := context.Background()
-	key := cm.generateContextKey(conversationID)
-	if err := cm.cache.Delete(ctx, key); err != nil {
-		slog.WarnContext(ctx, "Failed to clear context from persistent storage",
+	for _, key := range []string{cm.generateContextKey(conversationID), cm.generatePinnedKey(conversationID), cm.generateSnapshotKey(conversationID)} {
+		if err := cm.cache.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Failed to clear context from persistent storage",
+				"conversation_id", conversationID, "error", err)
+		}
+	}
+}
+
+// PinFact keeps a fact in the conversation context regardless of summarization and truncation
+// Pinning a fact twice is a no-op; returns ErrTooManyPinnedFacts once the conversation has MaxPinnedFacts
+func (cm *ContextManager) PinFact(ctx context.Context, conversationID, fact string) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	facts, err := cm.loadPinnedFacts(ctx, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to load pinned facts: %w", err)
+	}
+	for _, pinned := range facts {
+		if strings.EqualFold(pinned, fact) {
+			return nil
+		}
+	}
+	if len(facts) >= MaxPinnedFacts {
+		return ErrTooManyPinnedFacts
+	}
+
+	slog.InfoContext(ctx, "Fact pinned to conversation", "conversation_id", conversationID, "pinned_facts", len(facts)+1)
+	return cm.cache.Set(ctx, cm.generatePinnedKey(conversationID), append(facts, fact))
+}
+
+// GetPinnedFacts returns the facts pinned to a conversation in the order they were pinned
+func (cm *ContextManager) GetPinnedFacts(conversationID string) []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	ctx := context.Background()
+	facts, err := cm.loadPinnedFacts(ctx, conversationID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load pinned facts from persistent storage",
 			"conversation_id", conversationID, "error", err)
+		return nil
+	}
+	return facts
+}
+
+// PinnedFactsMessage renders pinned facts as a system message, or returns false if there are none
+func PinnedFactsMessage(facts []string) (Message, bool) {
+	if len(facts) == 0 {
+		return Message{}, false
+	}
+	var content strings.Builder
+	content.WriteString(PinnedFactsPrefix)
+	for _, fact := range facts {
+		content.WriteString("\n- ")
+		content.WriteString(fact)
 	}
+	return Message{Role: "system", Content: content.String()}, true
 }
 
 // EnsureContextFits guarantees that the context fits within the specified token limit
+// With a summarizer configured, dropped messages are replaced by a summary; the lock is not
+// held while the summary is generated so other conversations are not stalled
 func (cm *ContextManager) EnsureContextFits(ctx context.Context, conversationID string, targetTokens int) error {
 	cm.mu.Lock()
-	defer cm.mu.Unlock()
-
-	// Load current context
 	messages, err := cm.loadContext(ctx, conversationID)
+	cm.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to load context: %w", err)
 	}
 
-	currentTokens := 0
-	for _, msg := range messages {
-		currentTokens += cm.estimateTokens(msg.Content)
+	// Pinned facts are never reduced, the rest of the context makes room for them
+	if pinned, ok := PinnedFactsMessage(cm.GetPinnedFacts(conversationID)); ok {
+		targetTokens -= cm.estimateTokens(pinned.Content)
 	}
 
+	currentTokens := CountTokens(messages, cm.estimateTokens)
 	if currentTokens <= targetTokens {
 		return nil
 	}
@@ -174,8 +393,135 @@ func (cm *ContextManager) EnsureContextFits(ctx context.Context, conversationID
 		"current_tokens", currentTokens,
 		"target_tokens", targetTokens)
 
+	if cm.summarizer != nil {
+		reduced, err := cm.performSummaryReduction(ctx, conversationID, messages, targetTokens)
+		if err == nil {
+			cm.mu.Lock()
+			defer cm.mu.Unlock()
+			return cm.saveContext(ctx, conversationID, reduced)
+		}
+		slog.WarnContext(ctx, "Summarization failed, falling back to basic reduction",
+			"conversation_id", conversationID, "error", err)
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
 	// Use basic reduction
-	return cm.performBasicReduction(ctx, conversationID, messages, targetTokens)
+	return cm.saveContext(ctx, conversationID, TrimOldest(messages, targetTokens, cm.estimateTokens))
+}
+
+// performSummaryReduction replaces the oldest raw messages with rolling segment summaries
+// Segments are fixed windows of messages that are summarized once and cached by the messages they
+// cover, so earlier summaries are reused as they are instead of being summarized again
+func (cm *ContextManager) performSummaryReduction(ctx context.Context, conversationID string, messages []Message, targetTokens int) ([]Message, error) {
+	summaries, raw := SplitSummaries(messages)
+	windows := SummaryWindows(raw, targetTokens-CountTokens(summaries, cm.estimateTokens),
+		cm.segmentSize, cm.summaryTokens, cm.estimateTokens)
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no messages to summarize")
+	}
+
+	summarized, reused := 0, 0
+	for _, window := range windows {
+		summary, cached, err := cm.segmentSummary(ctx, conversationID, window)
+		if err != nil {
+			return nil, err
+		}
+		if cached {
+			reused++
+		}
+		summarized += len(window)
+		summaries = append(summaries, Message{Role: "system", Content: SummaryPrefix + summary})
+	}
+	raw = raw[summarized:]
+	// Summaries generated for a reply are stored with it, see model.Conversation.StatsProto
+	experiment.FromContext(ctx).AddSummaries(len(windows) - reused)
+
+	// Very long conversations can outgrow even their summaries; the oldest segments go first
+	kept := FitSummaries(summaries, targetTokens-CountTokens(raw, cm.estimateTokens), cm.estimateTokens)
+	dropped := len(summaries) - len(kept)
+	summaries = kept
+
+	slog.InfoContext(ctx, "Older messages replaced with segment summaries",
+		"conversation_id", conversationID,
+		"summarized_messages", summarized,
+		"new_segments", len(windows)-reused,
+		"reused_segments", reused,
+		"dropped_segments", dropped,
+		"kept_messages", len(raw))
+
+	return append(summaries, raw...), nil
+}
+
+// segmentSummary returns the summary of a window of messages, generating it only once
+func (cm *ContextManager) segmentSummary(ctx context.Context, conversationID string, window []Message) (string, bool, error) {
+	var transcript strings.Builder
+	for _, msg := range window {
+		transcript.WriteString(msg.Role)
+		transcript.WriteString(": ")
+		transcript.WriteString(msg.Content)
+		transcript.WriteString("\n")
+	}
+	cacheKey := cm.cache.GenerateKey(fmt.Sprintf("summary:%s:%d", conversationID, len(window)), transcript.String())
+
+	var summary string
+	err := cm.cache.Get(ctx, cacheKey, &summary)
+	if err == nil {
+		return summary, true, nil
+	}
+	if !errors.Is(err, redisx.ErrCacheMiss) {
+		slog.WarnContext(ctx, "Summary cache error, proceeding without cache", "error", err)
+	}
+
+	summary, err = cm.summarizer.Summarize(ctx, window, cm.summaryTokens)
+	if err != nil {
+		return "", false, err
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", false, fmt.Errorf("empty summary")
+	}
+	if err := cm.checkSummary(ctx, conversationID, window, summary); err != nil {
+		return "", false, err
+	}
+
+	if err := cm.cache.Set(ctx, cacheKey, summary); err != nil {
+		slog.WarnContext(ctx, "Failed to cache summary", "error", err)
+	}
+
+	return summary, false, nil
+}
+
+// checkSummary records the compression of a new summary and, for sampled summaries, its faithfulness
+// Only a low score rejects the summary; a failing judge does not block reduction
+func (cm *ContextManager) checkSummary(ctx context.Context, conversationID string, window []Message, summary string) error {
+	if cm.summaryRecorder != nil {
+		cm.summaryRecorder.RecordSummaryTokens(ctx, CountTokens(window, cm.estimateTokens), cm.estimateTokens(summary))
+	}
+	if cm.judge == nil || rand.Float64() >= cm.judgeRate {
+		return nil
+	}
+
+	score, err := cm.judge.ScoreSummary(ctx, window, summary)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to score summary, keeping it",
+			"conversation_id", conversationID, "error", err)
+		return nil
+	}
+	accepted := score >= cm.minFaithfulness
+	if cm.summaryRecorder != nil {
+		cm.summaryRecorder.RecordSummaryFaithfulness(ctx, score, accepted)
+	}
+	if !accepted {
+		slog.WarnContext(ctx, "Summary rejected as unfaithful",
+			"conversation_id", conversationID,
+			"score", score,
+			"min_score", cm.minFaithfulness,
+			"summarized_messages", len(window))
+		return ErrUnfaithfulSummary
+	}
+	return nil
 }
 
 // loadContext loads context from persistent storage
@@ -205,23 +551,44 @@ func (cm *ContextManager) generateContextKey(conversationID string) string {
 	return fmt.Sprintf("context:%s", conversationID)
 }
 
-// performBasicReduction performs basic context reduction without AI
-func (cm *ContextManager) performBasicReduction(ctx context.Context, conversationID string, messages []Message, targetTokens int) error {
-	currentTokens := 0
-	for _, msg := range messages {
-		currentTokens += cm.estimateTokens(msg.Content)
+// loadPinnedFacts loads pinned facts from persistent storage
+func (cm *ContextManager) loadPinnedFacts(ctx context.Context, conversationID string) ([]string, error) {
+	var facts []string
+	if err := cm.cache.Get(ctx, cm.generatePinnedKey(conversationID), &facts); err != nil {
+		if errors.Is(err, redisx.ErrCacheMiss) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return facts, nil
+}
+
+// generatePinnedKey generates a Redis key for pinned facts storage
+func (cm *ContextManager) generatePinnedKey(conversationID string) string {
+	return fmt.Sprintf("pinned:%s", conversationID)
+}
+
+// SaveSnapshot keeps what was sent to the model on the conversation's latest turn, replacing the previous one
+// It expires like the context it was taken from
+func (cm *ContextManager) SaveSnapshot(ctx context.Context, snapshot *ContextSnapshot) error {
+	return cm.cache.Set(ctx, cm.generateSnapshotKey(snapshot.ConversationID), snapshot)
+}
 
-	// Keep reducing until we fit within target
-	for currentTokens > targetTokens && len(messages) > 1 {
-		// Remove oldest message
-		oldest := messages[0]
-		messages = messages[1:]
-		currentTokens -= cm.estimateTokens(oldest.Content)
+// LoadSnapshot returns the snapshot of the conversation's latest turn, or nil when there is none
+func (cm *ContextManager) LoadSnapshot(ctx context.Context, conversationID string) (*ContextSnapshot, error) {
+	var snapshot ContextSnapshot
+	if err := cm.cache.Get(ctx, cm.generateSnapshotKey(conversationID), &snapshot); err != nil {
+		if errors.Is(err, redisx.ErrCacheMiss) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return &snapshot, nil
+}
 
-	// Save reduced context
-	return cm.saveContext(ctx, conversationID, messages)
+// generateSnapshotKey generates a Redis key for the snapshot of the latest turn
+func (cm *ContextManager) generateSnapshotKey(conversationID string) string {
+	return fmt.Sprintf("context_snapshot:%s", conversationID)
 }
 
 // estimateTokens provides improved token estimation
@@ -233,12 +600,60 @@ func (cm *ContextManager) estimateTokens(text string) int {
 	return len(text)/3 + 1
 }
 
+// CompleteToolTurns drops tool messages the model would reject
+// Context trimming and summarization can cut a tool turn in half, leaving results whose call is gone or
+// calls without all of their results; the assistant's text of an incomplete turn is kept
+func CompleteToolTurns(messages []Message) []Message {
+	result := make([]Message, 0, len(messages))
+	for i := 0; i < len(messages); i++ {
+		msg := messages[i]
+		switch {
+		case msg.Role == "tool":
+			// Orphaned result, its call was trimmed away
+			continue
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			end := i + 1
+			for end < len(messages) && messages[end].Role == "tool" {
+				end++
+			}
+			results := messages[i+1 : end]
+
+			answered := make(map[string]bool, len(results))
+			for _, r := range results {
+				answered[r.ToolCallID] = true
+			}
+			complete := true
+			for _, call := range msg.ToolCalls {
+				if !answered[call.ID] {
+					complete = false
+					break
+				}
+			}
+
+			if complete {
+				result = append(result, msg)
+				result = append(result, results...)
+			} else if strings.TrimSpace(msg.Content) != "" {
+				result = append(result, Message{Role: msg.Role, Content: msg.Content})
+			}
+			i = end - 1
+		default:
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
 // ConvertModelMessage converts chat model message to context message
 func ConvertModelMessage(modelMsg *model.Message) Message {
-	return Message{
+	msg := Message{
 		Role:    string(modelMsg.Role),
 		Content: modelMsg.Content,
 	}
+	if modelMsg.Role == model.RoleSystem {
+		msg.Content = SystemNotePrefix + modelMsg.Content
+	}
+	return msg
 }
 
 // ConvertContextMessages converts context messages to model messages
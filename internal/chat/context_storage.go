@@ -0,0 +1,64 @@
+package chat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+)
+
+// ContextStore stores conversation contexts and pinned facts
+// Implemented by redisx.Cache, redisx.MemoryCache and redisx.TieredCache
+type ContextStore interface {
+	redisx.Store
+	GenerateKey(prefix string, content string) string
+}
+
+// StorageStrategy selects where the context manager keeps conversation contexts
+type StorageStrategy string
+
+const (
+	// StorageRedis shares contexts between instances through Redis
+	StorageRedis StorageStrategy = "redis"
+	// StorageMemory keeps contexts in the memory of each instance, for single-instance deployments and tests
+	StorageMemory StorageStrategy = "memory"
+	// StorageHybrid keeps local copies over Redis and keeps working from memory while Redis is unavailable
+	StorageHybrid StorageStrategy = "hybrid"
+)
+
+// StorageConfig configures the context storage
+type StorageConfig struct {
+	Strategy      StorageStrategy
+	TTL           time.Duration // Inactivity after which a context expires in memory storage
+	LocalTTL      time.Duration // How long hybrid storage trusts a local copy before reading Redis again
+	MemoryEntries int           // Contexts and pinned fact lists kept in memory per instance
+}
+
+// ParseStorageStrategy validates a strategy name
+func ParseStorageStrategy(name string) (StorageStrategy, error) {
+	switch strategy := StorageStrategy(name); strategy {
+	case StorageRedis, StorageMemory, StorageHybrid:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown context storage %q, want redis, memory or hybrid", name)
+	}
+}
+
+// NewContextStore returns the storage of the configured strategy; redis backs the redis and hybrid strategies
+func NewContextStore(cfg StorageConfig, redis *redisx.Cache) (ContextStore, error) {
+	switch cfg.Strategy {
+	case StorageRedis, "":
+		return redis, nil
+	case StorageMemory:
+		return redisx.NewMemoryCache(cfg.TTL, cfg.MemoryEntries), nil
+	case StorageHybrid:
+		localTTL := cfg.LocalTTL
+		if localTTL <= 0 {
+			localTTL = time.Minute
+		}
+		return redisx.NewTieredCache(redisx.NewMemoryCache(localTTL, cfg.MemoryEntries), redis), nil
+	default:
+		_, err := ParseStorageStrategy(string(cfg.Strategy))
+		return nil, err
+	}
+}
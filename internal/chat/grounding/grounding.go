@@ -0,0 +1,103 @@
+package grounding
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tool names that can ground factual answers
+const (
+	ToolWeather  = "get_weather"
+	ToolDate     = "get_today_date"
+	ToolHolidays = "get_holidays"
+)
+
+// MaxReprompts limits how many times the model is asked to verify its answer with tools
+const MaxReprompts = 2
+
+// allPlatforms matches every platform in policy keys
+const allPlatforms = "all"
+
+// topicPatterns maps a grounding tool to the user message patterns that require it
+var topicPatterns = []struct {
+	tool    string
+	pattern *regexp.Regexp
+}{
+	{
+		tool:    ToolWeather,
+		pattern: regexp.MustCompile(`\b(weather|forecast|temperature|raining|rain|snow|snowing|sunny|humidity|wind|windy|degrees)\b`),
+	},
+	{
+		tool:    ToolDate,
+		pattern: regexp.MustCompile(`\b(date|what day|day of the week|what time|current time|time is it)\b`),
+	},
+	{
+		tool:    ToolHolidays,
+		pattern: regexp.MustCompile(`\b(holiday|holidays|bank holiday|public holiday|day off|days off)\b`),
+	},
+}
+
+// Policy decides on which platforms and personas answers must be derived from tool calls
+type Policy struct {
+	keys map[string]bool
+}
+
+// NewPolicy creates a strict facts policy for the given keys
+// Keys are "<platform>", "<platform>/<persona>", or "all" and "all/<persona>" to match every platform
+func NewPolicy(keys []string) *Policy {
+	p := &Policy{keys: make(map[string]bool)}
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key != "" {
+			p.keys[key] = true
+		}
+	}
+	return p
+}
+
+// Enabled reports whether strict facts mode applies to the platform and persona
+// The persona is empty for conversations without one
+func (p *Policy) Enabled(platform, persona string) bool {
+	if p == nil || len(p.keys) == 0 {
+		return false
+	}
+	platform, persona = strings.ToLower(platform), strings.ToLower(persona)
+	if p.keys[allPlatforms] || p.keys[platform] {
+		return true
+	}
+	return persona != "" && (p.keys[allPlatforms+"/"+persona] || p.keys[platform+"/"+persona])
+}
+
+// RequiredTools returns the tools whose output must ground an answer to the message
+func RequiredTools(message string) []string {
+	text := strings.ToLower(message)
+
+	var tools []string
+	for _, topic := range topicPatterns {
+		if topic.pattern.MatchString(text) {
+			tools = append(tools, topic.tool)
+		}
+	}
+	return tools
+}
+
+// Missing returns the required tools that were not called during the turn
+func Missing(required []string, called map[string]bool) []string {
+	var missing []string
+	for _, tool := range required {
+		if !called[tool] {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
+
+// RepromptInstruction builds the corrective instruction sent when the model answered without verification
+func RepromptInstruction(missing []string) string {
+	return fmt.Sprintf(
+		"Your previous answer was not verified. Before answering, you MUST call the following tools "+
+			"and base your answer only on their results: %s. Do not guess weather, dates, or holidays.",
+		strings.Join(missing, ", "),
+	)
+}
@@ -0,0 +1,91 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HistoryWindow bounds the context kept for a conversation; zero fields keep the defaults
+type HistoryWindow struct {
+	MaxMessages int // Messages kept in the context, older ones are dropped
+	MaxTokens   int // Request tokens before older messages are summarized; 0 leaves only the model's limit
+}
+
+// HistoryWindows holds the history windows configured per platform and per persona
+// Telegram bots may keep a short memory while web assistants keep a long one
+type HistoryWindows struct {
+	platforms map[string]HistoryWindow
+	personas  map[string]HistoryWindow
+}
+
+// ParseHistoryWindows parses platform and persona -> "<messages>/<tokens>" entries, e.g. "20/2000"
+// A zero keeps the default, so "20/0" only shortens the message history
+func ParseHistoryWindows(platforms, personas map[string]string) (*HistoryWindows, error) {
+	w := &HistoryWindows{
+		platforms: make(map[string]HistoryWindow, len(platforms)),
+		personas:  make(map[string]HistoryWindow, len(personas)),
+	}
+	for platform, spec := range platforms {
+		window, err := parseHistoryWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("platform %s: %w", platform, err)
+		}
+		w.platforms[platform] = window
+	}
+	for persona, spec := range personas {
+		window, err := parseHistoryWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("persona %s: %w", persona, err)
+		}
+		w.personas[persona] = window
+	}
+	return w, nil
+}
+
+func parseHistoryWindow(spec string) (HistoryWindow, error) {
+	messages, tokens, ok := strings.Cut(spec, "/")
+	if !ok {
+		return HistoryWindow{}, fmt.Errorf("history window %q is not <messages>/<tokens>", spec)
+	}
+	var window HistoryWindow
+	var err error
+	if window.MaxMessages, err = strconv.Atoi(strings.TrimSpace(messages)); err != nil || window.MaxMessages < 0 {
+		return HistoryWindow{}, fmt.Errorf("history window %q: messages must be a non-negative integer", spec)
+	}
+	if window.MaxTokens, err = strconv.Atoi(strings.TrimSpace(tokens)); err != nil || window.MaxTokens < 0 {
+		return HistoryWindow{}, fmt.Errorf("history window %q: tokens must be a non-negative integer", spec)
+	}
+	return window, nil
+}
+
+// Resolve returns the window of a conversation: the persona's fields take precedence over the platform's
+func (w *HistoryWindows) Resolve(platform, persona string) HistoryWindow {
+	if w == nil {
+		return HistoryWindow{}
+	}
+	window := w.platforms[platform]
+	if override, ok := w.personas[persona]; ok && persona != "" {
+		if override.MaxMessages > 0 {
+			window.MaxMessages = override.MaxMessages
+		}
+		if override.MaxTokens > 0 {
+			window.MaxTokens = override.MaxTokens
+		}
+	}
+	return window
+}
+
+type historyWindowKey struct{}
+
+// WithHistoryWindow applies a conversation's history window to the context manager calls made with ctx
+func WithHistoryWindow(ctx context.Context, window HistoryWindow) context.Context {
+	return context.WithValue(ctx, historyWindowKey{}, window)
+}
+
+// HistoryWindowFromContext returns the history window set by WithHistoryWindow, zero when there is none
+func HistoryWindowFromContext(ctx context.Context) HistoryWindow {
+	window, _ := ctx.Value(historyWindowKey{}).(HistoryWindow)
+	return window
+}
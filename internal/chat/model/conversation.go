@@ -8,6 +8,15 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// DefaultConversationTitle is the placeholder title used until a real title is generated
+const DefaultConversationTitle = "Untitled conversation"
+
+// MaxInstructionsChars caps the length of a conversation's custom instructions
+const MaxInstructionsChars = 1500
+
+// MaxSystemNoteChars caps the length of an operator note added to a conversation
+const MaxSystemNoteChars = 1000
+
 type Conversation struct {
 	ID        primitive.ObjectID `bson:"_id"`
 	Title     string             `bson:"subject"`
@@ -15,6 +24,12 @@ type Conversation struct {
 	UpdatedAt time.Time          `bson:"updated_at"`
 	Messages  []*Message         `bson:"messages"`
 
+	// TitlePending marks a provisional title that a title generated in the background will replace
+	TitlePending bool `bson:"title_pending,omitempty"`
+
+	// TenantID isolates conversations of different customers; empty for data created before multi-tenancy
+	TenantID string `bson:"tenant_id,omitempty"`
+
 	// New fields for conversation management
 	Platform     string    `bson:"platform,omitempty"` // telegram, web, api
 	UserID       string    `bson:"user_id,omitempty"`
@@ -22,13 +37,66 @@ type Conversation struct {
 	IsActive     bool      `bson:"is_active"` // default: true
 	Summary      string    `bson:"summary,omitempty"`
 	LastActivity time.Time `bson:"last_activity"` // default: time.Now()
+
+	// Tags group conversations for support teams, e.g. for bulk operations
+	Tags []string `bson:"tags,omitempty"`
+	// Persona selects the prompt variant (prompt user segment) used to reply; empty uses the user's segment
+	Persona string `bson:"persona,omitempty"`
+	// PromptVersion is the system prompt version replies use, pinned on the first reply so prompt
+	// updates do not change the behavior of running conversations; migrated with a bulk operation
+	PromptVersion string `bson:"prompt_version,omitempty"`
+	// Language is the language code replies must use, e.g. "es"; empty follows the user's language
+	Language string `bson:"language,omitempty"`
+	// DetectedLanguage is the language code detected in the latest user messages; it keeps replies
+	// in the user's language once older messages are summarized
+	DetectedLanguage string `bson:"detected_language,omitempty"`
+	// Instructions are custom instructions appended to the system prompt of every reply
+	Instructions string `bson:"instructions,omitempty"`
+
+	// SentimentScore is a moving average of classified user message sentiment, from -1 (negative) to 1 (positive)
+	SentimentScore float64 `bson:"sentiment_score,omitempty"`
+	// SentimentSamples counts the user messages that were classified
+	SentimentSamples int `bson:"sentiment_samples,omitempty"`
+
+	// Topics are the coarse subjects classified from user messages, e.g. "weather"
+	Topics []string `bson:"topics,omitempty"`
+
+	// CostCappedAt is when the replies of the conversation first exceeded its token or cost cap, see costcap.Policy
+	CostCappedAt time.Time `bson:"cost_capped_at,omitempty"`
+
+	// SchemaVersion is the layout version of the stored document, see CurrentSchemaVersion
+	SchemaVersion int `bson:"schema_version,omitempty"`
+
+	// ColdStorageKey marks a stub whose messages were moved to object storage after a long inactivity;
+	// DescribeConversation restores them
+	ColdStorageKey string `bson:"cold_storage_key,omitempty"`
+
+	// FollowUpAt is when the assistant last followed up on the conversation after the user went idle, see followup.Service
+	FollowUpAt time.Time `bson:"follow_up_at,omitempty"`
+}
+
+// ReplyLanguage returns the language code replies must use: the conversation's language, else the user's
+// preferred language, else the language detected in the conversation; empty when none is known
+func (c *Conversation) ReplyLanguage(preferred string) string {
+	switch {
+	case c.Language != "":
+		return c.Language
+	case preferred != "":
+		return preferred
+	}
+	return c.DetectedLanguage
 }
 
 func (c *Conversation) Proto() *pb.Conversation {
 	proto := &pb.Conversation{
-		Id:        c.ID.Hex(),
-		Title:     c.Title,
-		Timestamp: timestamppb.New(c.UpdatedAt),
+		Id:           c.ID.Hex(),
+		Title:        c.Title,
+		Timestamp:    timestamppb.New(c.UpdatedAt),
+		Instructions: c.Instructions,
+	}
+	if c.SentimentSamples > 0 {
+		score := c.SentimentScore
+		proto.SentimentScore = &score
 	}
 
 	for _, m := range c.Messages {
@@ -37,3 +105,27 @@ func (c *Conversation) Proto() *pb.Conversation {
 
 	return proto
 }
+
+// StatsProto computes the figures about the conversation shown in client info panes
+func (c *Conversation) StatsProto() *pb.ConversationStats {
+	stats := &pb.ConversationStats{
+		CreatedAt:      timestamppb.New(c.CreatedAt),
+		LastActivityAt: timestamppb.New(c.LastActivity),
+		Persona:        c.Persona,
+		Language:       c.ReplyLanguage(""),
+	}
+	if c.LastActivity.IsZero() {
+		stats.LastActivityAt = timestamppb.New(c.UpdatedAt)
+	}
+	for _, m := range c.Messages {
+		if m.Role == RoleSystem {
+			continue
+		}
+		stats.MessageCount++
+		if g := m.Generation; g != nil {
+			stats.TotalTokens += g.PromptTokens + g.CompletionTokens
+			stats.Summarizations += int32(g.Summaries)
+		}
+	}
+	return stats
+}
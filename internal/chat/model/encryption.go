@@ -0,0 +1,130 @@
+package model
+
+import (
+	"context"
+	"errors"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/twitchtv/twirp"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FieldCipher encrypts conversation content at rest with the keys of its tenant, see encryption.Manager
+type FieldCipher interface {
+	Encrypt(ctx context.Context, tenantID, plaintext string) (string, error)
+	Decrypt(ctx context.Context, tenantID, value string) (string, error)
+	// RevokedTenants returns the tenants whose data can no longer be decrypted
+	RevokedTenants(ctx context.Context) ([]string, error)
+}
+
+// WithEncryption stores message contents, summaries and instructions encrypted with the keys of their tenant
+// Titles, tags and other metadata stay in clear, so conversations can still be queried by them
+func WithEncryption(cipher FieldCipher) RepositoryOption {
+	return func(r *Repository) {
+		r.cipher = cipher
+	}
+}
+
+// tenantOf returns the tenant of a conversation, or that of the context when it is not set yet
+func tenantOf(ctx context.Context, c *Conversation) string {
+	if c.TenantID == "" {
+		return tenant.FromContext(ctx)
+	}
+	return c.TenantID
+}
+
+// seal returns a copy of a conversation to store, with its content encrypted
+func (r *Repository) seal(ctx context.Context, c *Conversation) (*Conversation, error) {
+	if r.cipher == nil {
+		return c, nil
+	}
+	sealed := *c
+	id := tenantOf(ctx, c)
+
+	var err error
+	if sealed.Summary, err = r.encrypt(ctx, id, c.Summary); err != nil {
+		return nil, err
+	}
+	if sealed.Instructions, err = r.encrypt(ctx, id, c.Instructions); err != nil {
+		return nil, err
+	}
+	if c.Messages != nil {
+		sealed.Messages = make([]*Message, len(c.Messages))
+		for i, m := range c.Messages {
+			if sealed.Messages[i], err = r.sealMessage(ctx, id, m); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &sealed, nil
+}
+
+// sealMessage returns a copy of a message to store, with its content encrypted
+func (r *Repository) sealMessage(ctx context.Context, tenantID string, m *Message) (*Message, error) {
+	if r.cipher == nil {
+		return m, nil
+	}
+	sealed := *m
+	var err error
+	if sealed.Content, err = r.encrypt(ctx, tenantID, m.Content); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// open decrypts the content of stored conversations in place
+func (r *Repository) open(ctx context.Context, conversations ...*Conversation) error {
+	if r.cipher == nil {
+		return nil
+	}
+	for _, c := range conversations {
+		id := tenantOf(ctx, c)
+		var err error
+		if c.Summary, err = r.decrypt(ctx, id, c.Summary); err != nil {
+			return err
+		}
+		if c.Instructions, err = r.decrypt(ctx, id, c.Instructions); err != nil {
+			return err
+		}
+		for _, m := range c.Messages {
+			if m.Content, err = r.decrypt(ctx, id, m.Content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readable excludes the conversations of tenants whose keys were revoked from a filter across all tenants
+func (r *Repository) readable(ctx context.Context, filter bson.M) (bson.M, error) {
+	if r.cipher == nil {
+		return filter, nil
+	}
+	revoked, err := r.cipher.RevokedTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(revoked) > 0 {
+		filter["tenant_id"] = bson.M{"$nin": revoked}
+	}
+	return filter, nil
+}
+
+func (r *Repository) encrypt(ctx context.Context, tenantID, value string) (string, error) {
+	sealed, err := r.cipher.Encrypt(ctx, tenantID, value)
+	return sealed, revokedError(err)
+}
+
+func (r *Repository) decrypt(ctx context.Context, tenantID, value string) (string, error) {
+	plaintext, err := r.cipher.Decrypt(ctx, tenantID, value)
+	return plaintext, revokedError(err)
+}
+
+// revokedError reports data of a tenant whose keys were revoked as inaccessible to clients
+func revokedError(err error) error {
+	if errors.Is(err, secrets.ErrKeyRevoked) {
+		return twirp.NewError(twirp.PermissionDenied, "conversation data of this tenant is no longer accessible")
+	}
+	return err
+}
@@ -14,13 +14,70 @@ type Message struct {
 	Content   string             `bson:"content"`
 	CreatedAt time.Time          `bson:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at"`
+
+	// AttachmentIDs link files uploaded with UploadAttachment to this message
+	AttachmentIDs []string `bson:"attachment_ids,omitempty"`
+
+	// Reactions are users' emoji reactions, used to measure reply quality
+	Reactions []Reaction `bson:"reactions,omitempty"`
+
+	// Sentiment is the classified sentiment of a user message; empty when it was not sampled
+	Sentiment string `bson:"sentiment,omitempty"`
+
+	// Generation records how an assistant reply was generated, for comparing experiment variants
+	Generation *Generation `bson:"generation,omitempty"`
+
+	// Author is the operator who added a system note
+	Author string `bson:"author,omitempty"`
+}
+
+// Variant identifies the configuration an assistant reply was generated with
+type Variant struct {
+	Persona       string `bson:"persona,omitempty" json:"persona,omitempty"`               // Empty for the user's segment
+	PromptVersion string `bson:"prompt_version,omitempty" json:"prompt_version,omitempty"` // Empty for the built-in fallback prompt
+	Model         string `bson:"model" json:"model"`
+}
+
+// Generation is the variant, latency and token spend of an assistant reply
+type Generation struct {
+	Variant          `bson:",inline"`
+	LatencyMs        int64 `bson:"latency_ms" json:"latency_ms"`
+	PromptTokens     int64 `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int64 `bson:"completion_tokens" json:"completion_tokens"`
+	Summaries        int   `bson:"summaries,omitempty" json:"summaries,omitempty"` // Segments of older messages summarized for the reply
+}
+
+// AddUsage adds the tokens of one completion; replies with tool calls take several
+func (g *Generation) AddUsage(promptTokens, completionTokens int64) {
+	if g == nil {
+		return
+	}
+	g.PromptTokens += promptTokens
+	g.CompletionTokens += completionTokens
+}
+
+// AddSummaries counts segments of older messages summarized to fit the reply's context
+func (g *Generation) AddSummaries(n int) {
+	if g == nil {
+		return
+	}
+	g.Summaries += n
 }
 
 func (m *Message) Proto() *pb.Conversation_Message {
-	return &pb.Conversation_Message{
-		Id:        m.ID.Hex(),
-		Role:      m.Role.Proto(),
-		Content:   m.Content,
-		Timestamp: timestamppb.New(m.CreatedAt),
+	proto := &pb.Conversation_Message{
+		Id:            m.ID.Hex(),
+		Role:          m.Role.Proto(),
+		Content:       m.Content,
+		Timestamp:     timestamppb.New(m.CreatedAt),
+		AttachmentIds: m.AttachmentIDs,
+		Sentiment:     m.Sentiment,
+		Author:        m.Author,
+	}
+
+	for _, r := range m.Reactions {
+		proto.Reactions = append(proto.Reactions, r.Proto())
 	}
+
+	return proto
 }
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -9,15 +11,49 @@ import (
 // PromptConfig represents a prompt configuration stored in MongoDB
 type PromptConfig struct {
 	ID              primitive.ObjectID `bson:"_id"`
-	Name            string             `bson:"name"`         // "title_generation", "system_prompt", "user_instruction"
-	Version         string             `bson:"version"`      // "v1", "v2"
-	Content         string             `bson:"content"`      // The actual prompt content
-	IsActive        bool               `bson:"is_active"`    // Whether this prompt version is active
-	Platform        string             `bson:"platform"`     // "all", "telegram", "web"
-	UserSegment     string             `bson:"user_segment"` // "all", "premium", "trial"
+	Name            string             `bson:"name"`                // "title_generation", "system_prompt", "user_instruction"
+	Version         string             `bson:"version"`             // "v1", "v2"
+	Content         string             `bson:"content"`             // The actual prompt content
+	IsActive        bool               `bson:"is_active"`           // Whether this prompt version is active
+	Platform        string             `bson:"platform"`            // "all", "telegram", "web"
+	UserSegment     string             `bson:"user_segment"`        // "all", "premium", "trial"
+	Locale          string             `bson:"locale,omitempty"`    // Language code of the conversations it is for, e.g. "es"; empty for all
+	TenantID        string             `bson:"tenant_id,omitempty"` // Tenant override; empty for prompts shared by all tenants
 	CreatedAt       time.Time          `bson:"created_at"`
 	UpdatedAt       time.Time          `bson:"updated_at"`
 	FallbackContent string             `bson:"fallback_content,omitempty"` // Fallback content if main content fails
+	// Params tune the completions made with the prompt; nil uses the model defaults
+	Params *GenerationParams `bson:"params,omitempty"`
+}
+
+// GenerationParams are sampling parameters stored with a prompt, so prompt engineers can tune
+// replies without a deploy; unset fields keep the defaults of the model or the caller
+type GenerationParams struct {
+	Temperature      *float64 `bson:"temperature,omitempty" json:"temperature,omitempty"`             // 0 to 2
+	PresencePenalty  *float64 `bson:"presence_penalty,omitempty" json:"presence_penalty,omitempty"`   // -2 to 2
+	FrequencyPenalty *float64 `bson:"frequency_penalty,omitempty" json:"frequency_penalty,omitempty"` // -2 to 2
+	MaxTokens        *int64   `bson:"max_tokens,omitempty" json:"max_tokens,omitempty"`               // Completion tokens
+}
+
+// Validate reports parameters outside the ranges accepted by the OpenAI API
+func (p *GenerationParams) Validate() error {
+	if p == nil {
+		return nil
+	}
+	var errs []error
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		errs = append(errs, fmt.Errorf("temperature %v is not between 0 and 2", *p.Temperature))
+	}
+	if p.PresencePenalty != nil && (*p.PresencePenalty < -2 || *p.PresencePenalty > 2) {
+		errs = append(errs, fmt.Errorf("presence_penalty %v is not between -2 and 2", *p.PresencePenalty))
+	}
+	if p.FrequencyPenalty != nil && (*p.FrequencyPenalty < -2 || *p.FrequencyPenalty > 2) {
+		errs = append(errs, fmt.Errorf("frequency_penalty %v is not between -2 and 2", *p.FrequencyPenalty))
+	}
+	if p.MaxTokens != nil && *p.MaxTokens <= 0 {
+		errs = append(errs, fmt.Errorf("max_tokens %d is not positive", *p.MaxTokens))
+	}
+	return errors.Join(errs...)
 }
 
 // PromptNames defines the available prompt types
@@ -25,6 +61,7 @@ const (
 	PromptNameTitleGeneration = "title_generation"
 	PromptNameSystemPrompt    = "system_prompt"
 	PromptNameUserInstruction = "user_instruction"
+	PromptNameSummarization   = "summarization"
 )
 
 // DefaultPlatform defines the default platform value
@@ -97,5 +134,18 @@ USER QUESTION:`,
 			CreatedAt:   now,
 			UpdatedAt:   now,
 		},
+		{
+			ID:      primitive.NewObjectID(),
+			Name:    PromptNameSummarization,
+			Version: "v1",
+			Content: "Summarize the conversation below for your own future reference. " +
+				"Keep names, facts, decisions, open questions and user preferences; drop small talk. " +
+				"Write compact plain sentences, most important first.",
+			IsActive:    true,
+			Platform:    DefaultPlatform,
+			UserSegment: DefaultUserSegment,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
 	}
 }
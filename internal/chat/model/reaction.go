@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Sentiments of reactions and user messages, used to measure reply quality
+const (
+	SentimentPositive = "positive"
+	SentimentNegative = "negative"
+	SentimentNeutral  = "neutral"
+)
+
+// maxReactionRunes bounds reactions to a single emoji, including skin tone and ZWJ sequences
+const maxReactionRunes = 8
+
+var reactionSentiments = map[string]string{
+	"👍": SentimentPositive, "❤️": SentimentPositive, "🔥": SentimentPositive, "🎉": SentimentPositive,
+	"😊": SentimentPositive, "🙏": SentimentPositive, "👏": SentimentPositive, "💯": SentimentPositive,
+	"👎": SentimentNegative, "😡": SentimentNegative, "😞": SentimentNegative, "🤬": SentimentNegative,
+	"💩": SentimentNegative, "🤦": SentimentNegative, "😕": SentimentNegative,
+}
+
+// Reaction is a user's emoji reaction to a message; each user has at most one per message
+type Reaction struct {
+	UserID    string    `bson:"user_id"`
+	Emoji     string    `bson:"emoji"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (r *Reaction) Proto() *pb.Conversation_Reaction {
+	return &pb.Conversation_Reaction{
+		UserId:    r.UserID,
+		Emoji:     r.Emoji,
+		Timestamp: timestamppb.New(r.CreatedAt),
+	}
+}
+
+// Sentiment classifies the reaction for quality measurement
+func (r *Reaction) Sentiment() string {
+	return ReactionSentiment(r.Emoji)
+}
+
+// ReactionSentiment classifies an emoji as positive, negative or neutral
+func ReactionSentiment(emoji string) string {
+	if sentiment, ok := reactionSentiments[emoji]; ok {
+		return sentiment
+	}
+	// Skin tone modifiers do not change the meaning
+	for base, sentiment := range reactionSentiments {
+		if len(emoji) > len(base) && emoji[:len(base)] == base {
+			return sentiment
+		}
+	}
+	return SentimentNeutral
+}
+
+// ValidReaction reports whether s is a single short emoji-like reaction
+func ValidReaction(s string) bool {
+	if s == "" || !utf8.ValidString(s) || utf8.RuneCountInString(s) > maxReactionRunes {
+		return false
+	}
+	for _, r := range s {
+		// Plain text is not a reaction; emoji are outside ASCII
+		if r < 0x80 {
+			return false
+		}
+	}
+	return true
+}
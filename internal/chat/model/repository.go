@@ -1,9 +1,15 @@
 package model
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/mongox"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
 	"github.com/twitchtv/twirp"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,17 +22,87 @@ const (
 )
 
 type Repository struct {
-	conn *mongo.Database
+	conn   *mongo.Database
+	router *mongox.Router
+	cold   ColdStorage
+	cipher FieldCipher
 }
 
-func New(conn *mongo.Database) *Repository {
-	return &Repository{
+// ColdStorage loads the messages of conversations archived to object storage, see coldstorage.Archive
+type ColdStorage interface {
+	RestoreMessages(ctx context.Context, key string) ([]*Message, error)
+}
+
+// RepositoryOption configures a Repository
+type RepositoryOption func(*Repository)
+
+// WithColdStorage restores archived conversations when they are described
+func WithColdStorage(cold ColdStorage) RepositoryOption {
+	return func(r *Repository) {
+		r.cold = cold
+	}
+}
+
+// WithResidency stores the conversations of tenants with a storage profile in the database of that profile
+func WithResidency(router *mongox.Router) RepositoryOption {
+	return func(r *Repository) {
+		r.router = router
+	}
+}
+
+func New(conn *mongo.Database, opts ...RepositoryOption) *Repository {
+	r := &Repository{
 		conn: conn,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// collection returns the conversations of the context's tenant
+func (r *Repository) collection(ctx context.Context) *mongo.Collection {
+	return r.tenantCollection(tenant.FromContext(ctx))
+}
+
+// tenantCollection returns the conversations of a tenant
+func (r *Repository) tenantCollection(id string) *mongo.Collection {
+	if db, ok := r.router.TenantDatabase(id); ok {
+		return db.Collection(conversationCollection)
+	}
+	return r.conn.Collection(conversationCollection)
+}
+
+// collections returns the conversations of every storage profile, for work across all tenants
+func (r *Repository) collections() []*mongo.Collection {
+	colls := []*mongo.Collection{r.conn.Collection(conversationCollection)}
+	for _, db := range r.router.Databases() {
+		colls = append(colls, db.Collection(conversationCollection))
+	}
+	return colls
+}
+
+// scoped restricts a query filter to the tenant of the context
+// The default tenant also owns conversations stored before multi-tenancy
+func scoped(ctx context.Context, filter bson.M) bson.M {
+	if tenant.IsDefault(ctx) {
+		filter["tenant_id"] = bson.M{"$in": bson.A{nil, tenant.DefaultID}}
+	} else {
+		filter["tenant_id"] = tenant.FromContext(ctx)
+	}
+	return filter
 }
 
 func (r *Repository) CreateConversation(ctx context.Context, c *Conversation) error {
-	_, err := r.conn.Collection(conversationCollection).InsertOne(ctx, c)
+	if c.TenantID == "" {
+		c.TenantID = tenant.FromContext(ctx)
+	}
+	c.SchemaVersion = CurrentSchemaVersion
+	sealed, err := r.seal(ctx, c)
+	if err != nil {
+		return err
+	}
+	_, err = r.tenantCollection(c.TenantID).InsertOne(ctx, sealed)
 	return err
 }
 
@@ -38,7 +114,7 @@ func (r *Repository) DescribeConversation(ctx context.Context, id string) (*Conv
 		return nil, twirp.NotFoundError("invalid conversation ID")
 	}
 
-	err = r.conn.Collection(conversationCollection).FindOne(ctx, map[string]any{"_id": oid}).Decode(&c)
+	err = r.collection(ctx).FindOne(ctx, scoped(ctx, bson.M{"_id": oid})).Decode(&c)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, twirp.NotFoundError("conversation not found")
 	}
@@ -47,15 +123,52 @@ func (r *Repository) DescribeConversation(ctx context.Context, id string) (*Conv
 		return nil, err
 	}
 
+	if c.ColdStorageKey != "" {
+		if err := r.rehydrate(ctx, &c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.open(ctx, &c); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
 
+// rehydrate moves the messages of a cold-storage stub back into Mongo, still encrypted as they were archived
+// The archive object is kept, so a failed write leaves the stub intact for the next access
+func (r *Repository) rehydrate(ctx context.Context, c *Conversation) error {
+	if r.cold == nil {
+		return fmt.Errorf("conversation %s is in cold storage, which is not configured", c.ID.Hex())
+	}
+	messages, err := r.cold.RestoreMessages(ctx, c.ColdStorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to restore conversation %s from cold storage: %w", c.ID.Hex(), err)
+	}
+	if messages == nil {
+		messages = []*Message{}
+	}
+
+	// Concurrent restores of the same stub write the same messages; only the first matches
+	_, err = r.collection(ctx).UpdateOne(ctx,
+		bson.M{"_id": c.ID, "cold_storage_key": c.ColdStorageKey},
+		bson.M{"$set": bson.M{"messages": messages}, "$unset": bson.M{"cold_storage_key": ""}})
+	if err != nil {
+		return err
+	}
+
+	c.Messages = messages
+	c.ColdStorageKey = ""
+	return nil
+}
+
 func (r *Repository) ListConversations(ctx context.Context) ([]*Conversation, error) {
 	opts := options.Find().
 		SetSort(bson.D{{Key: "created_at", Value: -1}})
 
-	cursor, err := r.conn.Collection(conversationCollection).
-		Find(ctx, map[string]any{}, opts)
+	cursor, err := r.collection(ctx).
+		Find(ctx, scoped(ctx, bson.M{}), opts)
 
 	if err != nil {
 		return nil, err
@@ -81,13 +194,21 @@ func (r *Repository) ListConversations(ctx context.Context) ([]*Conversation, er
 		return nil, err
 	}
 
+	if err := r.open(ctx, items...); err != nil {
+		return nil, err
+	}
+
 	return items, nil
 }
 
 func (r *Repository) UpdateConversation(ctx context.Context, c *Conversation) error {
-	_, err := r.conn.Collection(conversationCollection).UpdateOne(ctx,
-		map[string]any{"_id": c.ID},
-		map[string]any{"$set": c})
+	update, err := r.conversationUpdate(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.collection(ctx).UpdateOne(ctx,
+		scoped(ctx, bson.M{"_id": c.ID}), update)
 
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return twirp.NotFoundError("conversation not found")
@@ -96,8 +217,70 @@ func (r *Repository) UpdateConversation(ctx context.Context, c *Conversation) er
 	return err
 }
 
+// AppendReply stores the new messages of a reply with the conversation's other fields
+// The stored messages are not rewritten, so notes and reactions added while the reply was generated are
+// kept; retries are safe, the messages are not pushed again once the first of them is stored
+func (r *Repository) AppendReply(ctx context.Context, c *Conversation, messages []*Message) error {
+	withoutMessages := *c
+	withoutMessages.Messages = nil
+	update, err := r.conversationUpdate(ctx, &withoutMessages)
+	if err != nil {
+		return err
+	}
+	delete(update["$set"].(bson.M), "messages")
+
+	filter := bson.M{"_id": c.ID}
+	if len(messages) > 0 {
+		sealed := make([]*Message, len(messages))
+		for i, m := range messages {
+			if sealed[i], err = r.sealMessage(ctx, tenantOf(ctx, c), m); err != nil {
+				return err
+			}
+		}
+		update["$push"] = bson.M{"messages": bson.M{"$each": sealed}}
+		filter["messages._id"] = bson.M{"$ne": messages[0].ID}
+	}
+
+	_, err = r.collection(ctx).UpdateOne(ctx, scoped(ctx, filter), update)
+	return err
+}
+
+// conversationUpdate returns the update storing a conversation, leaving alone the fields other
+// writers maintain
+func (r *Repository) conversationUpdate(ctx context.Context, c *Conversation) (bson.M, error) {
+	sealed, err := r.seal(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	data, err := bson.Marshal(sealed)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	// The sentiment average is maintained by RecordMessageSentiment; never overwrite it with a stale copy
+	delete(fields, "sentiment_score")
+	delete(fields, "sentiment_samples")
+	// Topics are added by AddConversationTopics
+	delete(fields, "topics")
+	// Instructions are set by SetConversationInstructions, possibly while a reply is generated
+	delete(fields, "instructions")
+
+	update := bson.M{"$set": fields}
+	if c.TitlePending {
+		// A provisional title never overwrites the title generated in the background meanwhile
+		delete(fields, "subject")
+		delete(fields, "title_pending")
+	} else {
+		update["$unset"] = bson.M{"title_pending": ""}
+	}
+	return update, nil
+}
+
 func (r *Repository) DeleteConversation(ctx context.Context, id string) error {
-	_, err := r.conn.Collection(conversationCollection).DeleteOne(ctx, map[string]any{"_id": id})
+	_, err := r.collection(ctx).DeleteOne(ctx, scoped(ctx, bson.M{"_id": id}))
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return twirp.NotFoundError("conversation not found")
 	}
@@ -105,6 +288,274 @@ func (r *Repository) DeleteConversation(ctx context.Context, id string) error {
 	return err
 }
 
+// UpdateConversationTitle sets the title of a conversation without touching its messages
+// Not tenant-scoped: it is only used by background workers with IDs they read themselves
+func (r *Repository) UpdateConversationTitle(ctx context.Context, id string, title string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return twirp.NotFoundError("invalid conversation ID")
+	}
+
+	res, err := r.collection(ctx).UpdateOne(ctx,
+		bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"subject": title}, "$unset": bson.M{"title_pending": ""}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return twirp.NotFoundError("conversation not found")
+	}
+
+	return nil
+}
+
+// ListUntitledConversations returns conversations still carrying the default or a provisional title
+// Used by the background title batcher to pick up conversations it was never handed, across all tenants
+func (r *Repository) ListUntitledConversations(ctx context.Context, createdBefore time.Time, limit int) ([]*Conversation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"messages": bson.M{"$slice": 1}})
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"subject": DefaultConversationTitle},
+			bson.M{"title_pending": true},
+		},
+		"created_at": bson.M{"$lt": createdBefore},
+	}
+	filter, err := r.readable(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each storage profile holds its own tenants; the oldest conversations across them come first
+	var conversations []*Conversation
+	for _, coll := range r.collections() {
+		found, err := findConversations(ctx, coll, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, found...)
+	}
+	slices.SortStableFunc(conversations, func(a, b *Conversation) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	if len(conversations) > limit {
+		conversations = conversations[:limit]
+	}
+
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// FindInactiveConversations returns up to limit conversations, messages included, with no activity since before
+// and IDs greater than after, skipping cold-storage stubs
+// Used by the cold-storage archiver across all tenants; pages are ordered by ID
+// Contents are returned as stored, so encrypted conversations are archived encrypted
+func (r *Repository) FindInactiveConversations(ctx context.Context, before time.Time, after primitive.ObjectID, limit int) ([]*Conversation, error) {
+	filter, err := r.readable(ctx, bson.M{
+		"last_activity":    bson.M{"$lt": before},
+		"cold_storage_key": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	// Pages merge the storage profiles by ID, so the next page continues after the last ID of any profile
+	var conversations []*Conversation
+	for _, coll := range r.collections() {
+		found, err := findConversations(ctx, coll, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, found...)
+	}
+	slices.SortFunc(conversations, func(a, b *Conversation) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	if len(conversations) > limit {
+		conversations = conversations[:limit]
+	}
+
+	return conversations, nil
+}
+
+// FindFollowUpCandidates returns up to limit conversations of a platform, messages included, that the assistant
+// replied in, with a chat to send to and last activity in [idleFrom, idleBefore); conversations followed up since
+// their last activity or since followedUpBefore are skipped
+// Used by the follow-up job across all tenants; pages are ordered by ID
+func (r *Repository) FindFollowUpCandidates(ctx context.Context, platform string, idleFrom, idleBefore, followedUpBefore time.Time, after primitive.ObjectID, limit int) ([]*Conversation, error) {
+	filter, err := r.readable(ctx, activeReplied(idleFrom, idleBefore))
+	if err != nil {
+		return nil, err
+	}
+	filter["platform"] = platform
+	filter["is_active"] = true
+	filter["chat_id"] = bson.M{"$nin": bson.A{nil, ""}}
+	filter["follow_up_at"] = bson.M{"$not": bson.M{"$gte": followedUpBefore}}
+	// A missing follow_up_at sorts before any activity
+	filter["$expr"] = bson.M{"$lt": bson.A{"$follow_up_at", "$last_activity"}}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	var conversations []*Conversation
+	for _, coll := range r.collections() {
+		found, err := findConversations(ctx, coll, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, found...)
+	}
+	slices.SortFunc(conversations, func(a, b *Conversation) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	if len(conversations) > limit {
+		conversations = conversations[:limit]
+	}
+
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// AddFollowUp appends a follow-up message to a conversation and records when it was sent; the conversation's
+// last activity is kept, since it tracks the user
+// Returns false when the conversation had activity since it was read
+// Not tenant-scoped: it is only used by the follow-up job with conversations it read itself
+func (r *Repository) AddFollowUp(ctx context.Context, c *Conversation, msg *Message) (bool, error) {
+	sealed, err := r.sealMessage(ctx, tenantOf(ctx, c), msg)
+	if err != nil {
+		return false, err
+	}
+	res, err := r.tenantCollection(c.TenantID).UpdateOne(ctx,
+		bson.M{"_id": c.ID, "last_activity": c.LastActivity},
+		bson.M{
+			"$push": bson.M{"messages": sealed},
+			"$set":  bson.M{"follow_up_at": msg.CreatedAt, "updated_at": msg.CreatedAt},
+		})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// findConversations decodes every conversation of coll matching filter
+func findConversations(ctx context.Context, coll *mongo.Collection, filter bson.M, opts *options.FindOptions) ([]*Conversation, error) {
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var conversations []*Conversation
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// activeReplied matches the conversations active in [from, to) that the assistant replied in, skipping cold-storage stubs
+func activeReplied(from, to time.Time) bson.M {
+	return bson.M{
+		"last_activity":    bson.M{"$gte": from, "$lt": to},
+		"messages.role":    RoleAssistant,
+		"cold_storage_key": bson.M{"$exists": false},
+	}
+}
+
+// ActiveTenants returns the tenants with conversations active in [from, to) that the assistant replied in
+// Conversations created before multi-tenancy belong to the default tenant
+func (r *Repository) ActiveTenants(ctx context.Context, from, to time.Time) ([]string, error) {
+	filter, err := r.readable(ctx, activeReplied(from, to))
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for _, coll := range r.collections() {
+		found, err := coll.Distinct(ctx, "tenant_id", filter)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, found...)
+	}
+	seen := make(map[string]bool, len(values))
+	var tenants []string
+	for _, v := range values {
+		id, _ := v.(string)
+		if id == "" {
+			id = tenant.DefaultID
+		}
+		if !seen[id] {
+			seen[id] = true
+			tenants = append(tenants, id)
+		}
+	}
+	return tenants, nil
+}
+
+// SampleActiveConversations returns up to size random conversations of the context's tenant, messages included,
+// active in [from, to) and replied in by the assistant
+func (r *Repository) SampleActiveConversations(ctx context.Context, from, to time.Time, size int) ([]*Conversation, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: scoped(ctx, activeReplied(from, to))}},
+		{{Key: "$sample", Value: bson.M{"size": size}}},
+	}
+	cursor, err := r.collection(ctx).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var conversations []*Conversation
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// StubConversation drops the messages of a conversation archived under key, leaving a stub
+// Returns false when the conversation had activity since it was read, so the archive is stale
+// Not tenant-scoped: it is only used by the archiver with conversations it read itself
+func (r *Repository) StubConversation(ctx context.Context, c *Conversation, key string) (bool, error) {
+	res, err := r.tenantCollection(c.TenantID).UpdateOne(ctx,
+		bson.M{
+			"_id":              c.ID,
+			"last_activity":    c.LastActivity,
+			"cold_storage_key": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"messages": bson.A{}, "cold_storage_key": key}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
 // FindConversationsByPlatformAndChatID finds conversations by platform and chat ID
 // Used for session recovery when Redis is unavailable
 func (r *Repository) FindConversationsByPlatformAndChatID(ctx context.Context, platform, chatID string) ([]*Conversation, error) {
@@ -112,13 +563,13 @@ func (r *Repository) FindConversationsByPlatformAndChatID(ctx context.Context, p
 		SetSort(bson.D{{Key: "last_activity", Value: -1}}).
 		SetLimit(1) // Only need the most recent active conversation
 
-	filter := bson.M{
+	filter := scoped(ctx, bson.M{
 		"platform":  platform,
 		"chat_id":   chatID,
 		"is_active": true,
-	}
+	})
 
-	cursor, err := r.conn.Collection(conversationCollection).Find(ctx, filter, opts)
+	cursor, err := r.collection(ctx).Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -139,5 +590,696 @@ func (r *Repository) FindConversationsByPlatformAndChatID(ctx context.Context, p
 		return nil, err
 	}
 
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// ListUserConversations returns all conversations of a platform user within the context's tenant, oldest first
+// Used by data exports, so messages are included in full
+func (r *Repository) ListUserConversations(ctx context.Context, platform, userID string) ([]*Conversation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	filter := scoped(ctx, bson.M{
+		"platform": platform,
+		"user_id":  userID,
+	})
+
+	cursor, err := r.collection(ctx).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var conversations []*Conversation
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// RecentUserConversations returns up to limit conversations of a platform user within the context's tenant,
+// most recently active first; archived conversations are restored as by DescribeConversation
+func (r *Repository) RecentUserConversations(ctx context.Context, platform, userID string, limit int) ([]*Conversation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_activity", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+
+	filter := scoped(ctx, bson.M{
+		"platform": platform,
+		"user_id":  userID,
+	})
+
+	cursor, err := r.collection(ctx).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var conversations []*Conversation
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+
+	if err := r.restore(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// FindConversations returns the conversations with the given IDs within the context's tenant, in no particular order
+// IDs that are malformed or not found are left out; archived conversations are restored as by DescribeConversation
+func (r *Repository) FindConversations(ctx context.Context, ids []string) ([]*Conversation, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.collection(ctx).Find(ctx, scoped(ctx, bson.M{"_id": bson.M{"$in": oids}}))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var conversations []*Conversation
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+
+	if err := r.restore(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// restore rehydrates the cold storage stubs among conversations, so they are returned with their messages
+func (r *Repository) restore(ctx context.Context, conversations ...*Conversation) error {
+	for _, c := range conversations {
+		if c.ColdStorageKey != "" {
+			if err := r.rehydrate(ctx, c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ConversationFilter selects conversations for bulk operations
+// Zero fields do not restrict the selection
+type ConversationFilter struct {
+	Platform      string    `json:"platform,omitempty"`
+	Tag           string    `json:"tag,omitempty"`
+	Topic         string    `json:"topic,omitempty"`
+	PromptVersion string    `json:"prompt_version,omitempty"` // Conversations pinned to this system prompt version
+	CreatedAfter  time.Time `json:"created_after,omitempty"`
+	CreatedBefore time.Time `json:"created_before,omitempty"`
+}
+
+func (f ConversationFilter) query(ctx context.Context) bson.M {
+	filter := bson.M{}
+	if f.Platform != "" {
+		filter["platform"] = f.Platform
+	}
+	if f.Tag != "" {
+		filter["tags"] = f.Tag
+	}
+	if f.Topic != "" {
+		filter["topics"] = f.Topic
+	}
+	if f.PromptVersion != "" {
+		filter["prompt_version"] = f.PromptVersion
+	}
+
+	created := bson.M{}
+	if !f.CreatedAfter.IsZero() {
+		created["$gte"] = f.CreatedAfter
+	}
+	if !f.CreatedBefore.IsZero() {
+		created["$lt"] = f.CreatedBefore
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	return scoped(ctx, filter)
+}
+
+// CountConversations counts the conversations matching the filter within the context's tenant
+func (r *Repository) CountConversations(ctx context.Context, f ConversationFilter) (int64, error) {
+	return r.collection(ctx).CountDocuments(ctx, f.query(ctx))
+}
+
+// FindConversationRefs returns up to limit conversations matching the filter with IDs greater than after
+// Messages are not loaded; pages are ordered by ID so bulk operations can resume after the last one
+func (r *Repository) FindConversationRefs(ctx context.Context, f ConversationFilter, after primitive.ObjectID, limit int) ([]*Conversation, error) {
+	filter := f.query(ctx)
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"messages": 0})
+
+	cursor, err := r.collection(ctx).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var conversations []*Conversation
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, err
+	}
+
+	if err := r.open(ctx, conversations...); err != nil {
+		return nil, err
+	}
+
 	return conversations, nil
 }
+
+// ReassignConversations transfers the conversations of a platform user to another user of the same platform
+// Conversations of fromChatID move to toChatID, so session recovery finds them in the new chat
+// Returns the IDs of the transferred conversations
+func (r *Repository) ReassignConversations(ctx context.Context, platform, fromUserID, fromChatID, toUserID, toChatID string) ([]primitive.ObjectID, error) {
+	coll := r.collection(ctx)
+	filter := scoped(ctx, bson.M{"platform": platform, "user_id": fromUserID})
+
+	cursor, err := coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var refs []*Conversation
+	if err := cursor.All(ctx, &refs); err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(refs))
+	for _, c := range refs {
+		ids = append(ids, c.ID)
+	}
+
+	if fromChatID != "" && toChatID != "" {
+		chat := scoped(ctx, bson.M{"_id": bson.M{"$in": ids}, "chat_id": fromChatID})
+		if _, err := coll.UpdateMany(ctx, chat, bson.M{"$set": bson.M{"chat_id": toChatID}}); err != nil {
+			return nil, err
+		}
+	}
+	byID := scoped(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if _, err := coll.UpdateMany(ctx, byID, bson.M{"$set": bson.M{"user_id": toUserID}}); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ArchiveConversations closes conversations so sessions are no longer recovered into them
+func (r *Repository) ArchiveConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	res, err := r.collection(ctx).UpdateMany(ctx,
+		scoped(ctx, bson.M{"_id": bson.M{"$in": ids}}),
+		bson.M{"$set": bson.M{"is_active": false, "updated_at": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// DeleteConversations permanently removes conversations
+func (r *Repository) DeleteConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	res, err := r.collection(ctx).DeleteMany(ctx,
+		scoped(ctx, bson.M{"_id": bson.M{"$in": ids}}))
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// TagConversations adds and removes tags on conversations
+func (r *Repository) TagConversations(ctx context.Context, ids []primitive.ObjectID, add, remove []string) (int64, error) {
+	var modified int64
+	filter := scoped(ctx, bson.M{"_id": bson.M{"$in": ids}})
+
+	// MongoDB rejects $addToSet and $pull on the same field in one update
+	if len(add) > 0 {
+		res, err := r.collection(ctx).UpdateMany(ctx, filter,
+			bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": add}}})
+		if err != nil {
+			return modified, err
+		}
+		modified = res.ModifiedCount
+	}
+	if len(remove) > 0 {
+		res, err := r.collection(ctx).UpdateMany(ctx, filter,
+			bson.M{"$pull": bson.M{"tags": bson.M{"$in": remove}}})
+		if err != nil {
+			return modified, err
+		}
+		modified = max(modified, res.ModifiedCount)
+	}
+
+	return modified, nil
+}
+
+// SetConversationPersona changes the persona conversations are answered with; empty clears it
+// The pinned prompt version belongs to the previous persona's prompt, so the next reply pins the new one's
+func (r *Repository) SetConversationPersona(ctx context.Context, ids []primitive.ObjectID, persona string) (int64, error) {
+	update := bson.M{"$set": bson.M{"persona": persona}, "$unset": bson.M{"prompt_version": ""}}
+	if persona == "" {
+		update = bson.M{"$unset": bson.M{"persona": "", "prompt_version": ""}}
+	}
+
+	res, err := r.collection(ctx).UpdateMany(ctx,
+		scoped(ctx, bson.M{"_id": bson.M{"$in": ids}}), update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// SetConversationPromptVersion pins conversations to a system prompt version; empty unpins them,
+// so their next reply pins the version active then
+func (r *Repository) SetConversationPromptVersion(ctx context.Context, ids []primitive.ObjectID, version string) (int64, error) {
+	update := bson.M{"$set": bson.M{"prompt_version": version}}
+	if version == "" {
+		update = bson.M{"$unset": bson.M{"prompt_version": ""}}
+	}
+
+	res, err := r.collection(ctx).UpdateMany(ctx,
+		scoped(ctx, bson.M{"_id": bson.M{"$in": ids}}), update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// RepinPromptVersion moves the conversations created since a time from one system prompt version to another,
+// e.g. those given a prompt version that was rolled back
+func (r *Repository) RepinPromptVersion(ctx context.Context, from, to string, since time.Time) (int64, error) {
+	res, err := r.collection(ctx).UpdateMany(ctx,
+		scoped(ctx, bson.M{"prompt_version": from, "created_at": bson.M{"$gte": since}}),
+		bson.M{"$set": bson.M{"prompt_version": to}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// SetMessageReaction stores a user's reaction to a message, replacing the user's previous reaction to it
+func (r *Repository) SetMessageReaction(ctx context.Context, conversationID, messageID primitive.ObjectID, reaction Reaction) error {
+	filter := scoped(ctx, bson.M{"_id": conversationID, "messages._id": messageID})
+	onMessage := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []any{bson.M{"m._id": messageID}},
+	})
+
+	res, err := r.collection(ctx).UpdateOne(ctx, filter,
+		bson.M{"$pull": bson.M{"messages.$[m].reactions": bson.M{"user_id": reaction.UserID}}}, onMessage)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return twirp.NotFoundError("message not found")
+	}
+
+	_, err = r.collection(ctx).UpdateOne(ctx, filter,
+		bson.M{"$push": bson.M{"messages.$[m].reactions": reaction}}, onMessage)
+	return err
+}
+
+// AddMessageAttachment links an attachment to a message without rewriting the conversation's other messages
+func (r *Repository) AddMessageAttachment(ctx context.Context, conversationID, messageID primitive.ObjectID, attachmentID string) error {
+	res, err := r.collection(ctx).UpdateOne(ctx,
+		scoped(ctx, bson.M{"_id": conversationID, "messages._id": messageID}),
+		bson.M{"$push": bson.M{"messages.$.attachment_ids": attachmentID}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return twirp.NotFoundError("message not found")
+	}
+	return nil
+}
+
+// AddConversationMessage appends a message to a conversation without rewriting its other messages
+func (r *Repository) AddConversationMessage(ctx context.Context, id primitive.ObjectID, msg *Message) error {
+	sealed, err := r.sealMessage(ctx, tenant.FromContext(ctx), msg)
+	if err != nil {
+		return err
+	}
+	res, err := r.collection(ctx).UpdateOne(ctx, scoped(ctx, bson.M{"_id": id}),
+		bson.M{"$push": bson.M{"messages": sealed}, "$set": bson.M{"updated_at": msg.CreatedAt}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return twirp.NotFoundError("conversation not found")
+	}
+	return nil
+}
+
+// SetConversationLanguage changes the language conversations are answered in; empty clears it
+func (r *Repository) SetConversationLanguage(ctx context.Context, ids []primitive.ObjectID, language string) (int64, error) {
+	update := bson.M{"$set": bson.M{"language": language}}
+	if language == "" {
+		update = bson.M{"$unset": bson.M{"language": ""}}
+	}
+
+	res, err := r.collection(ctx).UpdateMany(ctx,
+		scoped(ctx, bson.M{"_id": bson.M{"$in": ids}}), update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// SetConversationInstructions changes the custom instructions of a conversation; empty clears them
+func (r *Repository) SetConversationInstructions(ctx context.Context, id primitive.ObjectID, instructions string) error {
+	update := bson.M{"$unset": bson.M{"instructions": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	if instructions != "" {
+		if r.cipher != nil {
+			var err error
+			if instructions, err = r.encrypt(ctx, tenant.FromContext(ctx), instructions); err != nil {
+				return err
+			}
+		}
+		update = bson.M{"$set": bson.M{"instructions": instructions, "updated_at": time.Now()}}
+	}
+
+	res, err := r.collection(ctx).UpdateOne(ctx, scoped(ctx, bson.M{"_id": id}), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return twirp.NotFoundError("conversation not found")
+	}
+	return nil
+}
+
+// RecordMessageSentiment stores the sentiment of a user message and blends its score into the
+// conversation's moving average with weight alpha
+// Returns the conversation score before and after the update
+func (r *Repository) RecordMessageSentiment(ctx context.Context, conversationID, messageID primitive.ObjectID, sentiment string, score, alpha float64) (float64, float64, error) {
+	coll := r.collection(ctx)
+
+	_, err := coll.UpdateOne(ctx,
+		scoped(ctx, bson.M{"_id": conversationID, "messages._id": messageID}),
+		bson.M{"$set": bson.M{"messages.$.sentiment": sentiment}})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	samples := bson.M{"$ifNull": bson.A{"$sentiment_samples", 0}}
+	update := bson.A{bson.M{"$set": bson.M{
+		"sentiment_score": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{samples, 0}},
+			bson.M{"$add": bson.A{alpha * score, bson.M{"$multiply": bson.A{1 - alpha, "$sentiment_score"}}}},
+			score,
+		}},
+		"sentiment_samples": bson.M{"$add": bson.A{samples, 1}},
+	}}}
+
+	var before Conversation
+	err = coll.FindOneAndUpdate(ctx, scoped(ctx, bson.M{"_id": conversationID}), update,
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.Before).
+			SetProjection(bson.M{"sentiment_score": 1, "sentiment_samples": 1}),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, 0, twirp.NotFoundError("conversation not found")
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return before.SentimentScore, BlendSentiment(before.SentimentScore, before.SentimentSamples, score, alpha), nil
+}
+
+// BlendSentiment adds a message score to a conversation's moving average
+// The first classified message sets the average
+func BlendSentiment(average float64, samples int, score, alpha float64) float64 {
+	if samples == 0 {
+		return score
+	}
+	return alpha*score + (1-alpha)*average
+}
+
+// SentimentSummary aggregates the sentiment of classified conversations
+type SentimentSummary struct {
+	Conversations int64           `json:"conversations" bson:"conversations"`
+	Negative      int64           `json:"negative" bson:"negative"` // Conversations scoring below the threshold
+	AverageScore  float64         `json:"average_score" bson:"average_score"`
+	MostNegative  []*Conversation `json:"-" bson:"-"`
+}
+
+// SummarizeSentiment aggregates the sentiment of conversations matching the filter
+// MostNegative holds up to limit conversations below the threshold, most negative first, without messages
+func (r *Repository) SummarizeSentiment(ctx context.Context, f ConversationFilter, threshold float64, limit int) (*SentimentSummary, error) {
+	coll := r.collection(ctx)
+	match := f.query(ctx)
+	match["sentiment_samples"] = bson.M{"$gt": 0}
+
+	cursor, err := coll.Aggregate(ctx, bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":           nil,
+			"conversations": bson.M{"$sum": 1},
+			"negative":      bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{"$sentiment_score", threshold}}, 1, 0}}},
+			"average_score": bson.M{"$avg": "$sentiment_score"},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups []SentimentSummary
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	summary := &SentimentSummary{}
+	if len(groups) > 0 {
+		*summary = groups[0]
+	}
+	if summary.Negative == 0 || limit <= 0 {
+		return summary, nil
+	}
+
+	match["sentiment_score"] = bson.M{"$lt": threshold}
+	cursor, err = coll.Find(ctx, match, options.Find().
+		SetSort(bson.D{{Key: "sentiment_score", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"messages": 0}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &summary.MostNegative); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// AddConversationTopics tags a conversation with topics
+// Returns the topics the conversation did not have before
+func (r *Repository) AddConversationTopics(ctx context.Context, conversationID primitive.ObjectID, topics []string) ([]string, error) {
+	var before Conversation
+	err := r.collection(ctx).FindOneAndUpdate(ctx,
+		scoped(ctx, bson.M{"_id": conversationID}),
+		bson.M{"$addToSet": bson.M{"topics": bson.M{"$each": topics}}},
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.Before).
+			SetProjection(bson.M{"topics": 1}),
+	).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, twirp.NotFoundError("conversation not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var added []string
+	for _, topic := range topics {
+		if !slices.Contains(before.Topics, topic) && !slices.Contains(added, topic) {
+			added = append(added, topic)
+		}
+	}
+	return added, nil
+}
+
+// TopicCount is the number of conversations tagged with a topic
+type TopicCount struct {
+	Topic         string `json:"topic" bson:"_id"`
+	Conversations int64  `json:"conversations" bson:"conversations"`
+}
+
+// TopicSummary aggregates the topics of conversations
+type TopicSummary struct {
+	Conversations int64         `json:"conversations"` // Conversations matching the filter, tagged or not
+	Untagged      int64         `json:"untagged"`      // Conversations without any topic
+	Topics        []*TopicCount `json:"topics"`        // Most frequent first
+}
+
+// SummarizeTopics counts the conversations matching the filter per topic
+// A conversation with several topics counts towards each of them
+func (r *Repository) SummarizeTopics(ctx context.Context, f ConversationFilter) (*TopicSummary, error) {
+	coll := r.collection(ctx)
+	match := f.query(ctx)
+
+	total, err := coll.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := coll.Aggregate(ctx, bson.A{
+		bson.M{"$match": match},
+		bson.M{"$project": bson.M{"topics": 1}},
+		bson.M{"$unwind": "$topics"},
+		bson.M{"$group": bson.M{"_id": "$topics", "conversations": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.D{{Key: "conversations", Value: -1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	summary := &TopicSummary{Conversations: total, Topics: []*TopicCount{}}
+	if err := cursor.All(ctx, &summary.Topics); err != nil {
+		return nil, err
+	}
+
+	match["topics.0"] = bson.M{"$exists": true}
+	tagged, err := coll.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+	summary.Untagged = total - tagged
+
+	return summary, nil
+}
+
+// VariantStats compares the assistant replies generated with one variant
+type VariantStats struct {
+	Variant          `bson:"_id"`
+	Replies          int64   `json:"replies" bson:"replies"`
+	Reacted          int64   `json:"reacted" bson:"reacted"` // Replies with at least one reaction
+	AvgLatencyMs     float64 `json:"avg_latency_ms" bson:"avg_latency_ms"`
+	PromptTokens     int64   `json:"prompt_tokens" bson:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens" bson:"completion_tokens"`
+
+	// Reactions to the replies by sentiment
+	Positive int64 `json:"positive" bson:"-"`
+	Negative int64 `json:"negative" bson:"-"`
+	Neutral  int64 `json:"neutral" bson:"-"`
+}
+
+// CompareVariants aggregates latency, token spend and reactions of the assistant replies in
+// conversations matching the filter per variant; replies stored without a generation are skipped
+func (r *Repository) CompareVariants(ctx context.Context, f ConversationFilter) ([]*VariantStats, error) {
+	coll := r.collection(ctx)
+	replies := bson.A{
+		bson.M{"$match": f.query(ctx)},
+		bson.M{"$project": bson.M{"messages": 1}},
+		bson.M{"$unwind": "$messages"},
+		bson.M{"$match": bson.M{"messages.generation": bson.M{"$exists": true}}},
+	}
+	variant := bson.M{
+		"persona":        "$messages.generation.persona",
+		"prompt_version": "$messages.generation.prompt_version",
+		"model":          "$messages.generation.model",
+	}
+
+	cursor, err := coll.Aggregate(ctx, append(replies,
+		bson.M{"$group": bson.M{
+			"_id":     variant,
+			"replies": bson.M{"$sum": 1},
+			"reacted": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$messages.reactions", bson.A{}}}}, 0}}, 1, 0,
+			}}},
+			"avg_latency_ms":    bson.M{"$avg": "$messages.generation.latency_ms"},
+			"prompt_tokens":     bson.M{"$sum": "$messages.generation.prompt_tokens"},
+			"completion_tokens": bson.M{"$sum": "$messages.generation.completion_tokens"},
+		}},
+		bson.M{"$sort": bson.D{{Key: "replies", Value: -1}}},
+	))
+	if err != nil {
+		return nil, err
+	}
+	stats := []*VariantStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+
+	// Sentiments are classified from emojis here, so reactions are counted per emoji
+	cursor, err = coll.Aggregate(ctx, append(replies,
+		bson.M{"$unwind": "$messages.reactions"},
+		bson.M{"$group": bson.M{
+			"_id":       bson.M{"variant": variant, "emoji": "$messages.reactions.emoji"},
+			"reactions": bson.M{"$sum": 1},
+		}},
+	))
+	if err != nil {
+		return nil, err
+	}
+	var counts []struct {
+		ID struct {
+			Variant Variant `bson:"variant"`
+			Emoji   string  `bson:"emoji"`
+		} `bson:"_id"`
+		Reactions int64 `bson:"reactions"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+
+	byVariant := make(map[Variant]*VariantStats, len(stats))
+	for _, s := range stats {
+		byVariant[s.Variant] = s
+	}
+	for _, count := range counts {
+		s, ok := byVariant[count.ID.Variant]
+		if !ok {
+			continue
+		}
+		switch ReactionSentiment(count.ID.Emoji) {
+		case SentimentPositive:
+			s.Positive += count.Reactions
+		case SentimentNegative:
+			s.Negative += count.Reactions
+		default:
+			s.Neutral += count.Reactions
+		}
+	}
+
+	return stats, nil
+}
@@ -7,6 +7,7 @@ type Role string
 const (
 	RoleUser      Role = "user"
 	RoleAssistant Role = "assistant"
+	RoleSystem    Role = "system" // Operator notes, see Message.Author
 )
 
 func (r Role) Proto() pb.Conversation_Role {
@@ -15,6 +16,8 @@ func (r Role) Proto() pb.Conversation_Role {
 		return pb.Conversation_USER
 	case RoleAssistant:
 		return pb.Conversation_ASSISTANT
+	case RoleSystem:
+		return pb.Conversation_SYSTEM
 	default:
 		return 0
 	}
@@ -0,0 +1,231 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CurrentSchemaVersion is the schema version of the conversation documents this code writes
+// Documents of older versions are upgraded when they are read, and stored upgraded by UpgradeConversations
+const CurrentSchemaVersion = 1
+
+// upgradeBatchSize is the number of documents UpgradeConversations loads per page
+const upgradeBatchSize = 500
+
+// upgrades[v] upgrades a document of schema version v to v+1
+// A change that leaves older documents missing data bumps CurrentSchemaVersion and appends a step here
+var upgrades = []func(doc bson.D) bson.D{
+	upgradeToV1,
+}
+
+// upgradeToV1 fills the fields of documents stored before schema versioning: those from before
+// conversation management lack the activity fields, those from before multi-tenancy the tenant
+func upgradeToV1(doc bson.D) bson.D {
+	created, _ := lookup(doc, "created_at")
+	doc = setDefault(doc, "updated_at", created)
+	updated, _ := lookup(doc, "updated_at")
+	doc = setDefault(doc, "last_activity", updated)
+	doc = setDefault(doc, "is_active", true)
+	doc = setDefault(doc, "tenant_id", tenant.DefaultID)
+
+	messages, _ := lookup(doc, "messages")
+	list, _ := messages.(bson.A)
+	upgraded := make(bson.A, 0, len(list))
+	for _, m := range list {
+		if msg, ok := m.(bson.D); ok {
+			created, _ := lookup(msg, "created_at")
+			m = setDefault(msg, "updated_at", created)
+		}
+		upgraded = append(upgraded, m)
+	}
+	return set(doc, "messages", upgraded)
+}
+
+// UpgradeDocument upgrades a stored conversation document to CurrentSchemaVersion
+// Documents of a newer version, written by a newer release, are returned unchanged
+func UpgradeDocument(doc bson.D) bson.D {
+	version := documentVersion(doc)
+	if version >= CurrentSchemaVersion {
+		return doc
+	}
+	for v := version; v < CurrentSchemaVersion; v++ {
+		doc = upgrades[v](doc)
+	}
+	return set(doc, "schema_version", int32(CurrentSchemaVersion))
+}
+
+// UnmarshalBSON decodes a stored conversation, upgrading documents of older schema versions
+func (c *Conversation) UnmarshalBSON(data []byte) error {
+	type stored Conversation // Without this method, so decoding does not recurse
+
+	if version, ok := bson.Raw(data).Lookup("schema_version").AsInt64OK(); ok && version >= CurrentSchemaVersion {
+		return bson.Unmarshal(data, (*stored)(c))
+	}
+
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	upgraded, err := bson.Marshal(UpgradeDocument(doc))
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(upgraded, (*stored)(c))
+}
+
+// UpgradeResult counts the conversations of a schema upgrade run
+type UpgradeResult struct {
+	Upgraded int `json:"upgraded"`
+	Skipped  int `json:"skipped"` // Changed while they were upgraded; upgraded on their next read or run
+}
+
+// UpgradeConversations stores every conversation of an older schema version upgraded, in all storage profiles
+// Conversations are read upgraded either way; storing them keeps queries on the new fields complete
+func (r *Repository) UpgradeConversations(ctx context.Context) (*UpgradeResult, error) {
+	result := &UpgradeResult{}
+	for _, coll := range r.collections() {
+		if err := upgradeCollection(ctx, coll, result); err != nil {
+			return result, err
+		}
+	}
+
+	slog.InfoContext(ctx, "Upgraded stored conversations",
+		"schema_version", CurrentSchemaVersion,
+		"upgraded", result.Upgraded,
+		"skipped", result.Skipped)
+	return result, nil
+}
+
+func upgradeCollection(ctx context.Context, coll *mongo.Collection, result *UpgradeResult) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(upgradeBatchSize)
+
+	var after primitive.ObjectID
+	for {
+		filter := bson.M{"schema_version": bson.M{"$not": bson.M{"$gte": CurrentSchemaVersion}}}
+		if !after.IsZero() {
+			filter["_id"] = bson.M{"$gt": after}
+		}
+		cursor, err := coll.Find(ctx, filter, opts)
+		if err != nil {
+			return fmt.Errorf("failed to find outdated conversations: %w", err)
+		}
+		var page []bson.Raw
+		if err := cursor.All(ctx, &page); err != nil {
+			return fmt.Errorf("failed to read outdated conversations: %w", err)
+		}
+
+		for _, raw := range page {
+			id, err := upgradeOne(ctx, coll, raw, result)
+			if err != nil {
+				return err
+			}
+			after = id
+		}
+		if len(page) < upgradeBatchSize {
+			return nil
+		}
+	}
+}
+
+// upgradeOne stores a document upgraded, unless it changed since it was read
+func upgradeOne(ctx context.Context, coll *mongo.Collection, raw bson.Raw, result *UpgradeResult) (primitive.ObjectID, error) {
+	var original, upgraded bson.D
+	if err := bson.Unmarshal(raw, &original); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := bson.Unmarshal(raw, &upgraded); err != nil {
+		return primitive.NilObjectID, err
+	}
+	id, _ := lookup(original, "_id")
+	oid, _ := id.(primitive.ObjectID)
+
+	filter, changes := upgradeUpdate(original, UpgradeDocument(upgraded))
+	res, err := coll.UpdateOne(ctx, filter, bson.M{"$set": changes})
+	if err != nil {
+		return oid, fmt.Errorf("failed to upgrade conversation %s: %w", oid.Hex(), err)
+	}
+	if res.MatchedCount == 0 {
+		result.Skipped++
+	} else {
+		result.Upgraded++
+	}
+	return oid, nil
+}
+
+// upgradeUpdate returns the fields an upgrade changed, and a filter matching the document only while
+// those fields keep their original values, so concurrent writes are never overwritten
+func upgradeUpdate(original, upgraded bson.D) (filter, changes bson.D) {
+	id, _ := lookup(original, "_id")
+	filter = bson.D{{Key: "_id", Value: id}}
+	for _, e := range upgraded {
+		value, ok := field(original, e.Key)
+		if ok && reflect.DeepEqual(value, e.Value) {
+			continue
+		}
+		changes = append(changes, e)
+		if ok {
+			filter = append(filter, bson.E{Key: e.Key, Value: value})
+		} else {
+			filter = append(filter, bson.E{Key: e.Key, Value: bson.M{"$exists": false}})
+		}
+	}
+	return filter, changes
+}
+
+// documentVersion returns the schema version of a document; 0 for documents stored before versioning
+func documentVersion(doc bson.D) int {
+	switch v, _ := lookup(doc, "schema_version"); v := v.(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// field returns the value of a top-level field, null included
+func field(doc bson.D, key string) (interface{}, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// lookup returns the value of a top-level field, or false when it is missing or null
+func lookup(doc bson.D, key string) (interface{}, bool) {
+	value, ok := field(doc, key)
+	return value, ok && value != nil
+}
+
+// set sets a top-level field, keeping its position when it exists
+func set(doc bson.D, key string, value interface{}) bson.D {
+	for i := range doc {
+		if doc[i].Key == key {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: key, Value: value})
+}
+
+// setDefault sets a top-level field that is missing or null
+func setDefault(doc bson.D, key string, value interface{}) bson.D {
+	if _, ok := lookup(doc, key); ok || value == nil {
+		return doc
+	}
+	return set(doc, key, value)
+}
@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/gorilla/mux"
+	"github.com/twitchtv/twirp"
+)
+
+// NoteHandler lets admins add operator notes to conversations, see Server.InjectSystemNote
+// It must be mounted behind admin authentication with the operator role: the model reads notes as
+// instructions about the conversation, so nobody else may write them
+type NoteHandler struct {
+	server *Server
+}
+
+// NewNoteHandler creates an operator notes handler
+func NewNoteHandler(server *Server) *NoteHandler {
+	return &NoteHandler{server: server}
+}
+
+// NoteRequest is the body of an operator note
+type NoteRequest struct {
+	Content string `json:"content"` // At most model.MaxSystemNoteChars characters
+}
+
+// NoteResponse is a stored operator note
+type NoteResponse struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	Content        string    `json:"content"`
+	Author         string    `json:"author"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// InjectHandler handles POST /admin/conversations/{id}/notes
+// The note is recorded as added by the admin making the request
+func (h *NoteHandler) InjectHandler(w http.ResponseWriter, r *http.Request) {
+	var req NoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDebugJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	note, err := h.server.InjectSystemNote(r.Context(), id, req.Content, noteAuthor(r))
+	var twerr twirp.Error
+	switch {
+	case errors.As(err, &twerr) && twerr.Code() == twirp.NotFound:
+		writeDebugJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	case errors.As(err, &twerr) && twerr.Code() == twirp.InvalidArgument:
+		writeDebugJSON(w, http.StatusBadRequest, map[string]string{"error": twerr.Msg()})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to add system note", "conversation_id", id, "error", err)
+		writeDebugJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to add note"})
+		return
+	}
+
+	writeDebugJSON(w, http.StatusCreated, NoteResponse{
+		ID:             note.ID.Hex(),
+		ConversationID: id,
+		Content:        note.Content,
+		Author:         note.Author,
+		CreatedAt:      note.CreatedAt,
+	})
+}
+
+// noteAuthor identifies the admin adding a note; admin routes without authentication configured have no caller
+func noteAuthor(r *http.Request) string {
+	if p := admin.FromContext(r.Context()); p != nil {
+		return audit.Actor("admin", p.Username)
+	}
+	return "admin"
+}
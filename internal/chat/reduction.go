@@ -0,0 +1,66 @@
+package chat
+
+import "strings"
+
+// CountTokens returns the tokens of the messages' content
+func CountTokens(messages []Message, countTokens func(string) int) int {
+	total := 0
+	for _, msg := range messages {
+		total += countTokens(msg.Content)
+	}
+	return total
+}
+
+// IsSummary reports whether a context message is a segment summary
+func IsSummary(msg Message) bool {
+	return msg.Role == "system" && strings.HasPrefix(msg.Content, SummaryPrefix)
+}
+
+// SplitSummaries separates the segment summaries leading a context from the raw messages that follow
+func SplitSummaries(messages []Message) (summaries, raw []Message) {
+	i := 0
+	for i < len(messages) && IsSummary(messages[i]) {
+		i++
+	}
+	return messages[:i:i], messages[i:]
+}
+
+// SummaryWindows splits the oldest messages into windows of up to windowSize messages to summarize
+// Windows are taken until the remaining messages plus summaryTokens per window fit targetTokens;
+// the latest message is never summarized
+func SummaryWindows(messages []Message, targetTokens, windowSize, summaryTokens int, countTokens func(string) int) [][]Message {
+	total := CountTokens(messages, countTokens)
+
+	var windows [][]Message
+	start := 0
+	for total > targetTokens && start < len(messages)-1 {
+		end := min(start+windowSize, len(messages)-1)
+		total -= CountTokens(messages[start:end], countTokens)
+		total += summaryTokens
+		windows = append(windows, messages[start:end])
+		start = end
+	}
+
+	return windows
+}
+
+// FitSummaries drops the oldest summaries until the rest fit targetTokens
+func FitSummaries(summaries []Message, targetTokens int, countTokens func(string) int) []Message {
+	total := CountTokens(summaries, countTokens)
+	for len(summaries) > 0 && total > targetTokens {
+		total -= countTokens(summaries[0].Content)
+		summaries = summaries[1:]
+	}
+	return summaries
+}
+
+// TrimOldest drops the oldest messages until the rest fit targetTokens
+// The latest message is always kept, even when it alone exceeds the target
+func TrimOldest(messages []Message, targetTokens int, countTokens func(string) int) []Message {
+	total := CountTokens(messages, countTokens)
+	for total > targetTokens && len(messages) > 1 {
+		total -= countTokens(messages[0].Content)
+		messages = messages[1:]
+	}
+	return messages
+}
@@ -0,0 +1,71 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/gorilla/mux"
+	"github.com/twitchtv/twirp"
+)
+
+// ReplayHandler lets admins replay the recorded turns of conversations, see Server.ReplayConversation
+// It must be mounted behind admin authentication with the operator role: turns hold the raw LLM
+// requests and responses, including the system prompt, operator notes and tool outputs
+type ReplayHandler struct {
+	server *Server
+}
+
+// NewReplayHandler creates a conversation replay handler
+func NewReplayHandler(server *Server) *ReplayHandler {
+	return &ReplayHandler{server: server}
+}
+
+// ReplayTurn is a recorded turn with the outcome of its re-run, when one was asked for
+type ReplayTurn struct {
+	*replay.Turn
+	Rerun *replay.Rerun `json:"rerun,omitempty"`
+}
+
+// ReplayResponse is the recorded turns of a conversation
+type ReplayResponse struct {
+	ConversationID string       `json:"conversation_id"`
+	Turns          []ReplayTurn `json:"turns"`
+}
+
+// ReplayRequest is the body of a replay; it may be empty
+type ReplayRequest struct {
+	Rerun bool `json:"rerun"` // Re-run each turn against its recorded LLM responses and tool outputs
+}
+
+// TurnsHandler handles POST /admin/conversations/{id}/replay
+// It is a POST although nothing is stored: admin reads are open to every role, and replays are
+// for operators only and recorded in the audit log
+func (h *ReplayHandler) TurnsHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeDebugJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	turns, err := h.server.ReplayConversation(r.Context(), id, req.Rerun)
+	var twerr twirp.Error
+	switch {
+	case errors.As(err, &twerr) && twerr.Code() == twirp.NotFound:
+		writeDebugJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	case errors.As(err, &twerr) && twerr.Code() == twirp.Unimplemented:
+		writeDebugJSON(w, http.StatusNotImplemented, map[string]string{"error": twerr.Msg()})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to replay conversation", "conversation_id", id, "error", err)
+		writeDebugJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to replay conversation"})
+		return
+	}
+
+	writeDebugJSON(w, http.StatusOK, ReplayResponse{ConversationID: id, Turns: turns})
+}
@@ -2,13 +2,34 @@ package chat
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/abuse"
+	"github.com/8adimka/Go_AI_Assistant/internal/attachment"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/blocklist"
+	"github.com/8adimka/Go_AI_Assistant/internal/budget"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/commands"
+	"github.com/8adimka/Go_AI_Assistant/internal/errorsx"
+	"github.com/8adimka/Go_AI_Assistant/internal/experiment"
+	"github.com/8adimka/Go_AI_Assistant/internal/identity"
+	"github.com/8adimka/Go_AI_Assistant/internal/inflight"
 	"github.com/8adimka/Go_AI_Assistant/internal/pb"
-	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"github.com/8adimka/Go_AI_Assistant/internal/postprocess"
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/style"
+	"github.com/8adimka/Go_AI_Assistant/internal/takeout"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/internal/tokens"
 	"github.com/twitchtv/twirp"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -20,24 +41,385 @@ type Assistant interface {
 	Reply(ctx context.Context, conv *model.Conversation) (string, error)
 }
 
+// TitleScheduler defers title generation to a background worker
+type TitleScheduler interface {
+	// ScheduleTitle queues the conversation and reports whether it was accepted
+	ScheduleTitle(conv *model.Conversation) bool
+}
+
+// TitleUpdater stores titles generated after a conversation was created, see model.Repository
+type TitleUpdater interface {
+	UpdateConversationTitle(ctx context.Context, id string, title string) error
+}
+
+// AbuseGuard rejects requests from blocked users
+type AbuseGuard interface {
+	// Check returns an error wrapping abuse.ErrUserBlocked when the user may not send requests
+	Check(ctx context.Context, platform, userID string) error
+	// RecordStrike counts an offense and returns the block it triggered, if any
+	RecordStrike(ctx context.Context, platform, userID, reason string) (*abuse.Block, error)
+}
+
+// ContentPolicy rejects user messages containing terms the tenant blocks, see blocklist.Service
+type ContentPolicy interface {
+	// CheckMessage returns an error wrapping blocklist.ErrBlocked when the message contains a blocked term
+	CheckMessage(ctx context.Context, message string) error
+}
+
+// MessageLimiter caps the message rate of single conversations and chat sessions, see abuse.MessageLimiter
+type MessageLimiter interface {
+	// CheckSession and CheckConversation return an error wrapping abuse.ErrMessageRateLimited when over the limit
+	CheckSession(ctx context.Context, platform, userID, chatID string) error
+	CheckConversation(ctx context.Context, platform, conversationID string) error
+}
+
+// AttachmentService stores files uploaded to conversations
+type AttachmentService interface {
+	// Upload returns an error wrapping attachment.ErrRejected when the file violates the upload policy
+	Upload(ctx context.Context, upload *attachment.Upload) (*attachment.Attachment, error)
+	// Get returns attachment.ErrNotFound when the attachment does not belong to the conversation
+	Get(ctx context.Context, conversationID, attachmentID string) (*attachment.Attachment, []byte, error)
+}
+
+// ReplayService reconstructs recorded turns of a conversation for debugging
+type ReplayService interface {
+	ListTurns(ctx context.Context, conversationID string) ([]*replay.Turn, error)
+	Rerun(ctx context.Context, conv *model.Conversation, turn *replay.Turn) (*replay.Rerun, error)
+}
+
+// TakeoutService exports everything stored for a user
+type TakeoutService interface {
+	// Request returns takeout.ErrBusy when too many exports are waiting to be generated
+	Request(ctx context.Context, platform, userID, chatID string) (*takeout.Export, error)
+	// Get returns takeout.ErrNotFound unless the export belongs to the user, with a download link once it is ready
+	Get(ctx context.Context, id, platform, userID string) (*takeout.Export, string, error)
+}
+
+// Greeter picks the greeting sent before the first reply of a conversation, see greeting.Greeter
+type Greeter interface {
+	// Greeting returns "" when the platform and persona have no greeting
+	Greeting(ctx context.Context, platform, persona string) string
+}
+
+// ReplyProcessor post-processes assistant replies before they are stored and returned
+type ReplyProcessor interface {
+	Process(ctx context.Context, platform, reply string) string
+}
+
+// ExperimentRecorder exports the latency, token spend and feedback of replies per variant, see metrics.Metrics
+type ExperimentRecorder interface {
+	RecordVariantReply(ctx context.Context, variant model.Variant, latency time.Duration, tokens int64)
+	RecordVariantReaction(ctx context.Context, variant model.Variant, sentiment string)
+}
+
+// ReactionRecorder receives reactions to assistant replies for quality measurement
+type ReactionRecorder interface {
+	RecordReaction(ctx context.Context, platform, sentiment string)
+}
+
+// CommandHandler answers slash commands sent from bot platforms without calling the model
+type CommandHandler interface {
+	// Handle reports handled=false when the message is not a command for the request's platform
+	Handle(ctx context.Context, req commands.Request) (reply string, handled bool, err error)
+}
+
+// SettingsService stores the preferences of platform users
+type SettingsService interface {
+	Get(ctx context.Context, platform, userID string) (*settings.Settings, error)
+	// Set returns an error wrapping settings.ErrInvalid when a preference has an unknown value
+	Set(ctx context.Context, s *settings.Settings) (*settings.Settings, error)
+}
+
+// SentimentTracker classifies user messages in the background
+type SentimentTracker interface {
+	// Observe samples the message and never blocks
+	Observe(conv *model.Conversation, msg *model.Message) bool
+}
+
+// TopicTagger tags conversations with the topics of their user messages in the background
+type TopicTagger interface {
+	// Observe samples the message and never blocks
+	Observe(conv *model.Conversation, msg *model.Message) bool
+}
+
+// IdentityResolver maps platform identities to the canonical user they are linked to, see identity.Service
+type IdentityResolver interface {
+	// Resolve returns the identity itself when it is not linked
+	Resolve(ctx context.Context, id identity.Identity) (identity.Identity, error)
+}
+
+// ConversationRepository is the conversation storage used by the server
+type ConversationRepository interface {
+	CreateConversation(ctx context.Context, c *model.Conversation) error
+	DescribeConversation(ctx context.Context, id string) (*model.Conversation, error)
+	ListConversations(ctx context.Context) ([]*model.Conversation, error)
+	UpdateConversation(ctx context.Context, c *model.Conversation) error
+	// AppendReply stores the new messages of a reply without rewriting the messages already stored
+	AppendReply(ctx context.Context, c *model.Conversation, messages []*model.Message) error
+	SetMessageReaction(ctx context.Context, conversationID, messageID primitive.ObjectID, reaction model.Reaction) error
+	AddMessageAttachment(ctx context.Context, conversationID, messageID primitive.ObjectID, attachmentID string) error
+	SetConversationInstructions(ctx context.Context, id primitive.ObjectID, instructions string) error
+	AddConversationMessage(ctx context.Context, id primitive.ObjectID, msg *model.Message) error
+}
+
+// SessionStore maps platform chats to conversations, see session.Manager
+type SessionStore interface {
+	// GetOrCreateSession returns the chat's conversation, starting an empty one when it has none
+	GetOrCreateSession(ctx context.Context, platform, userID, chatID string) (string, error)
+	// ResetSession archives the chat's conversation, clears its context and returns its ID, or "" if it had none
+	ResetSession(ctx context.Context, platform, chatID string) (string, error)
+	// ClaimSession transfers an anonymous user's conversations and chat session to an authenticated user
+	// Returns the transferred conversation IDs and the conversation now in session in the authenticated chat
+	ClaimSession(ctx context.Context, platform, anonymousUserID, anonymousChatID, userID, chatID string) ([]string, string, error)
+}
+
+// ReplyGuard keeps one reply in flight per conversation, see inflight.Guard
+type ReplyGuard interface {
+	Begin(ctx context.Context, platform, conversationID, message string) (*inflight.Ticket, error)
+	// Commit reports whether the ticket's reply may be stored
+	Commit(t *inflight.Ticket) bool
+	Release(t *inflight.Ticket)
+}
+
+// Provisional titles and the budget of titles generated alongside replies
+const (
+	maxProvisionalTitleWords = 6
+	maxProvisionalTitleRunes = 60
+	titleTimeout             = 30 * time.Second
+)
+
+// Persistence retry settings for saving replies that were already paid for
+const (
+	persistMaxAttempts = 3
+	persistBaseDelay   = 100 * time.Millisecond
+)
+
 type Server struct {
-	repo           *model.Repository
+	repo           ConversationRepository
 	assist         Assistant
-	sessionManager *session.Manager
+	sessionManager SessionStore
+	titleScheduler TitleScheduler
+	titleUpdater   TitleUpdater
+	abuseGuard     AbuseGuard
+	contentPolicy  ContentPolicy
+	messageLimiter MessageLimiter
+	attachments    AttachmentService
+	replay         ReplayService
+	takeout        TakeoutService
+	replyProcessor ReplyProcessor
+	reactions      ReactionRecorder
+	experiments    ExperimentRecorder
+	commands       CommandHandler
+	settings       SettingsService
+	sentiment      SentimentTracker
+	topics         TopicTagger
+	identities     IdentityResolver
+	audit          audit.Recorder
+	replyGuard     ReplyGuard
+	greeter        Greeter
+
+	maxMessageTokens   int
+	chunkedInputTokens int
+}
+
+// ServerOption configures optional Server behavior
+type ServerOption func(*Server)
+
+// WithTitleScheduler makes StartConversation defer title generation instead of blocking on it
+func WithTitleScheduler(scheduler TitleScheduler) ServerOption {
+	return func(s *Server) {
+		s.titleScheduler = scheduler
+	}
+}
+
+// WithConcurrentTitles makes StartConversation generate the title while the reply is generated
+// The reply returns with the generated title when it is ready in time, otherwise with a provisional
+// title taken from the message; the generated title is stored as soon as it arrives
+func WithConcurrentTitles(updater TitleUpdater) ServerOption {
+	return func(s *Server) {
+		s.titleUpdater = updater
+	}
+}
+
+// WithAbuseGuard rejects requests from users blocked for abuse
+func WithAbuseGuard(guard AbuseGuard) ServerOption {
+	return func(s *Server) {
+		s.abuseGuard = guard
+	}
+}
+
+// WithContentPolicy rejects user messages containing terms the tenant blocks, counting a moderation
+// strike against their sender when WithAbuseGuard is set
+func WithContentPolicy(policy ContentPolicy) ServerOption {
+	return func(s *Server) {
+		s.contentPolicy = policy
+	}
+}
+
+// WithMessageLimiter rejects messages to conversations and chat sessions that exceed their rate,
+// stopping a runaway bot before it burns tokens, independently of the per-IP and per-user limits
+func WithMessageLimiter(limiter MessageLimiter) ServerOption {
+	return func(s *Server) {
+		s.messageLimiter = limiter
+	}
+}
+
+// WithIdentityResolver keys user settings and abuse quotas by canonical user,
+// so they follow people who linked their identities on several platforms
+func WithIdentityResolver(resolver IdentityResolver) ServerOption {
+	return func(s *Server) {
+		s.identities = resolver
+	}
 }
 
-func NewServer(repo *model.Repository, assist Assistant, sessionManager *session.Manager) *Server {
-	return &Server{
+// WithAttachments enables the UploadAttachment and GetAttachment endpoints
+func WithAttachments(attachments AttachmentService) ServerOption {
+	return func(s *Server) {
+		s.attachments = attachments
+	}
+}
+
+// WithReplay enables conversation replay, served to admins by ReplayHandler
+func WithReplay(service ReplayService) ServerOption {
+	return func(s *Server) {
+		s.replay = service
+	}
+}
+
+// WithTakeout enables the RequestDataExport and GetDataExport endpoints
+func WithTakeout(service TakeoutService) ServerOption {
+	return func(s *Server) {
+		s.takeout = service
+	}
+}
+
+// WithReplyProcessor filters replies, e.g. sanitizing markdown or enforcing platform length limits
+func WithReplyProcessor(processor ReplyProcessor) ServerOption {
+	return func(s *Server) {
+		s.replyProcessor = processor
+	}
+}
+
+// WithExperimentRecorder reports replies and reactions per persona, prompt version and model
+func WithExperimentRecorder(recorder ExperimentRecorder) ServerOption {
+	return func(s *Server) {
+		s.experiments = recorder
+	}
+}
+
+// WithReactionRecorder reports reactions to assistant replies, e.g. as quality metrics
+func WithReactionRecorder(recorder ReactionRecorder) ServerOption {
+	return func(s *Server) {
+		s.reactions = recorder
+	}
+}
+
+// WithCommands intercepts slash commands such as /reset or /language in ContinueConversation
+func WithCommands(handler CommandHandler) ServerOption {
+	return func(s *Server) {
+		s.commands = handler
+	}
+}
+
+// WithUserSettings enables the settings endpoints and applies user preferences to replies and tools
+func WithUserSettings(service SettingsService) ServerOption {
+	return func(s *Server) {
+		s.settings = service
+	}
+}
+
+// WithAuditLog records changes made through the API, such as conversation instructions
+func WithAuditLog(recorder audit.Recorder) ServerOption {
+	return func(s *Server) {
+		s.audit = recorder
+	}
+}
+
+// WithMaxMessageTokens rejects user messages longer than limit tokens before any work is done on them
+// A message that alone does not fit the context cannot be answered however much history is reduced
+func WithMaxMessageTokens(limit int) ServerOption {
+	return func(s *Server) {
+		s.maxMessageTokens = limit
+	}
+}
+
+// WithChunkedInput accepts messages over the WithMaxMessageTokens limit up to maxTokens,
+// for assistants that answer long messages from summaries of their chunks
+func WithChunkedInput(maxTokens int) ServerOption {
+	return func(s *Server) {
+		s.chunkedInputTokens = maxTokens
+	}
+}
+
+// WithSentimentTracker tracks the sentiment of stored user messages
+func WithSentimentTracker(tracker SentimentTracker) ServerOption {
+	return func(s *Server) {
+		s.sentiment = tracker
+	}
+}
+
+// WithTopicTagger tags conversations with the topics of stored user messages
+func WithTopicTagger(tagger TopicTagger) ServerOption {
+	return func(s *Server) {
+		s.topics = tagger
+	}
+}
+
+// WithReplyGuard controls messages sent while a reply in the same conversation is being generated,
+// e.g. queueing them or merging them into one reply
+func WithReplyGuard(guard ReplyGuard) ServerOption {
+	return func(s *Server) {
+		s.replyGuard = guard
+	}
+}
+
+// WithGreeter sends a configured greeting before the first reply of new conversations and sessions
+func WithGreeter(greeter Greeter) ServerOption {
+	return func(s *Server) {
+		s.greeter = greeter
+	}
+}
+
+func NewServer(repo ConversationRepository, assist Assistant, sessionManager SessionStore, opts ...ServerOption) *Server {
+	s := &Server{
 		repo:           repo,
 		assist:         assist,
 		sessionManager: sessionManager,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *Server) StartConversation(ctx context.Context, req *pb.StartConversationRequest) (*pb.StartConversationResponse, error) {
+	if strings.TrimSpace(req.GetMessage()) == "" {
+		return nil, twirp.RequiredArgumentError("message")
+	}
+
+	if err := s.checkMessageSize(req.GetMessage()); err != nil {
+		return nil, err
+	}
+
+	ctx, err := withReplyStyle(ctx, req.GetStyle())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAbuse(ctx, req.GetSessionMetadata()); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkContent(ctx, req.GetSessionMetadata(), req.GetMessage()); err != nil {
+		return nil, err
+	}
+
 	conversation := &model.Conversation{
 		ID:           primitive.NewObjectID(),
-		Title:        "Untitled conversation",
+		Title:        model.DefaultConversationTitle,
+		TenantID:     tenant.FromContext(ctx),
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 		Platform:     "api", // default for direct API calls
@@ -52,40 +434,91 @@ func (s *Server) StartConversation(ctx context.Context, req *pb.StartConversatio
 		}},
 	}
 
-	if strings.TrimSpace(req.GetMessage()) == "" {
-		return nil, twirp.RequiredArgumentError("message")
+	// choose a title: deferred to the background batcher, generated alongside the reply, or generated first
+	var titles <-chan string
+	switch {
+	case s.titleScheduler != nil && s.titleScheduler.ScheduleTitle(conversation):
+		conversation.Title, conversation.TitlePending = provisionalTitle(req.GetMessage()), true
+	case s.titleUpdater != nil:
+		conversation.Title, conversation.TitlePending = provisionalTitle(req.GetMessage()), true
+		titles = s.generateTitle(ctx, conversation)
+	default:
+		title, err := s.assist.Title(ctx, conversation)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to generate conversation title", "error", err)
+		} else {
+			conversation.Title = title
+		}
 	}
 
-	// choose a title
-	title, err := s.assist.Title(ctx, conversation)
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to generate conversation title", "error", err)
-	} else {
-		conversation.Title = title
+	// persist the conversation before paying for a reply, so a storage failure costs no tokens
+	if err := s.repo.CreateConversation(ctx, conversation); err != nil {
+		return nil, twirp.InternalErrorWith(err)
 	}
+	stored := len(conversation.Messages)
 
 	// generate a reply
-	reply, err := s.assist.Reply(ctx, conversation)
+	ctx = s.withUserSettings(ctx, conversation)
+	reply, generation, err := s.reply(ctx, conversation)
 	if err != nil {
-		return nil, err
+		if titles != nil {
+			go s.storeTitle(ctx, conversation.ID.Hex(), conversation.Title, titles)
+		}
+		// Clients with a request budget get what was answered in time instead of an error
+		if partial, ok := budget.PartialReply(err); ok {
+			return &pb.StartConversationResponse{
+				ConversationId: conversation.ID.Hex(),
+				Title:          conversation.Title,
+				Reply:          s.processReply(ctx, conversation, partial),
+				BudgetExceeded: true,
+			}, nil
+		}
+		return nil, errorsx.UpstreamTwirpError(err)
+	}
+	reply = s.processReply(ctx, conversation, reply)
+	greeting := s.greet(ctx, conversation)
+
+	// a title generated while replying is returned and stored with the reply
+	if titles != nil {
+		select {
+		case title, ok := <-titles:
+			if ok {
+				conversation.Title = title
+			}
+			conversation.TitlePending = false
+			titles = nil
+		default:
+		}
 	}
 
 	conversation.Messages = append(conversation.Messages, &model.Message{
-		ID:        primitive.NewObjectID(),
-		Role:      model.RoleAssistant,
-		Content:   reply,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:         primitive.NewObjectID(),
+		Role:       model.RoleAssistant,
+		Content:    reply,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		Generation: generation,
 	})
+	conversation.UpdatedAt = time.Now()
+	conversation.LastActivity = time.Now()
 
-	if err := s.repo.CreateConversation(ctx, conversation); err != nil {
-		return nil, err
+	// the reply is already paid for: return it even if it could not be stored
+	if err := s.persistReply(ctx, conversation, conversation.Messages[stored:]); err != nil {
+		slog.ErrorContext(ctx, "Reply generated but not persisted, returning it unsaved",
+			"conversation_id", conversation.ID.Hex(),
+			"reply_length", len(reply),
+			"error", err)
+	}
+	s.observeUserMessage(conversation, conversation.Messages[0])
+	if titles != nil {
+		go s.storeTitle(ctx, conversation.ID.Hex(), conversation.Title, titles)
 	}
 
 	return &pb.StartConversationResponse{
 		ConversationId: conversation.ID.Hex(),
 		Title:          conversation.Title,
 		Reply:          reply,
+		Greeting:       greeting,
 	}, nil
 }
 
@@ -94,9 +527,46 @@ func (s *Server) ContinueConversation(ctx context.Context, req *pb.ContinueConve
 		return nil, twirp.RequiredArgumentError("message")
 	}
 
+	if err := s.checkMessageSize(req.GetMessage()); err != nil {
+		return nil, err
+	}
+
+	ctx, err := withReplyStyle(ctx, req.GetStyle())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAbuse(ctx, req.GetSessionMetadata()); err != nil {
+		return nil, err
+	}
+
+	// Commands are answered directly and never stored in the conversation
+	if s.commands != nil && req.GetSessionMetadata() != nil {
+		metadata := req.GetSessionMetadata()
+		reply, handled, err := s.commands.Handle(ctx, commands.Request{
+			Platform:       metadata.GetPlatform(),
+			UserID:         metadata.GetUserId(),
+			ChatID:         metadata.GetChatId(),
+			ConversationID: req.GetConversationId(),
+			Message:        req.GetMessage(),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to handle command",
+				"platform", metadata.GetPlatform(), "error", err)
+			return nil, twirp.InternalErrorWith(err)
+		}
+		if handled {
+			return &pb.ContinueConversationResponse{Reply: reply}, nil
+		}
+	}
+
+	if err := s.checkContent(ctx, req.GetSessionMetadata(), req.GetMessage()); err != nil {
+		return nil, err
+	}
+
 	// OPTION 1: Direct conversation_id (existing flow)
 	if req.GetConversationId() != "" {
-		return s.continueExistingConversation(ctx, req.GetConversationId(), req.GetMessage())
+		return s.continueExistingConversation(ctx, req.GetSessionMetadata().GetPlatform(), req.GetConversationId(), req.GetMessage())
 	}
 
 	// OPTION 2: Session-based (new flow) - use session_metadata
@@ -108,8 +578,14 @@ func (s *Server) ContinueConversation(ctx context.Context, req *pb.ContinueConve
 		chatID := sessionMetadata.GetChatId()
 
 		if platform != "" && userID != "" && chatID != "" {
+			if s.messageLimiter != nil {
+				if err := s.messageLimiter.CheckSession(ctx, platform, userID, chatID); err != nil {
+					return nil, messageRateError(err)
+				}
+			}
+
 			// Use Session Manager to find or create conversation
-			conversationID, err := s.sessionManager.GetOrCreateSession(ctx, platform, userID, chatID, req.GetMessage())
+			conversationID, err := s.sessionManager.GetOrCreateSession(ctx, platform, userID, chatID)
 			if err != nil {
 				slog.ErrorContext(ctx, "Failed to get or create session",
 					"platform", platform, "user_id", userID, "chat_id", chatID, "error", err)
@@ -117,7 +593,7 @@ func (s *Server) ContinueConversation(ctx context.Context, req *pb.ContinueConve
 			}
 
 			// Continue with the found/created conversation
-			return s.continueExistingConversation(ctx, conversationID, req.GetMessage())
+			return s.continueExistingConversation(ctx, platform, conversationID, req.GetMessage())
 		}
 	}
 
@@ -126,13 +602,33 @@ func (s *Server) ContinueConversation(ctx context.Context, req *pb.ContinueConve
 }
 
 // continueExistingConversation handles the actual conversation continuation logic
-func (s *Server) continueExistingConversation(ctx context.Context, conversationID, message string) (*pb.ContinueConversationResponse, error) {
+func (s *Server) continueExistingConversation(ctx context.Context, platform, conversationID, message string) (*pb.ContinueConversationResponse, error) {
 	if conversationID == "" {
 		// If no conversation ID provided, we need to handle this case
 		// For now, we'll return an error, but in production this would create a new conversation
 		return nil, twirp.RequiredArgumentError("conversation_id")
 	}
 
+	if s.messageLimiter != nil {
+		if err := s.messageLimiter.CheckConversation(ctx, platform, conversationID); err != nil {
+			return nil, messageRateError(err)
+		}
+	}
+
+	// Wait for replies in flight, so the conversation is read with their messages
+	messages := []string{message}
+	var ticket *inflight.Ticket
+	if s.replyGuard != nil {
+		var err error
+		ticket, err = s.replyGuard.Begin(ctx, platform, conversationID, message)
+		if err != nil {
+			return nil, guardError(err)
+		}
+		defer s.replyGuard.Release(ticket)
+		ctx = ticket.Context()
+		messages = ticket.Messages()
+	}
+
 	conversation, err := s.repo.DescribeConversation(ctx, conversationID)
 	if err != nil {
 		return nil, err
@@ -148,32 +644,55 @@ func (s *Server) continueExistingConversation(ctx context.Context, conversationI
 		"conversation_id", conversation.ID.Hex(),
 		"message_count", len(conversation.Messages))
 
-	conversation.Messages = append(conversation.Messages, &model.Message{
-		ID:        primitive.NewObjectID(),
-		Role:      model.RoleUser,
-		Content:   message,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	})
+	stored := len(conversation.Messages)
+	for _, message := range messages {
+		conversation.Messages = append(conversation.Messages, &model.Message{
+			ID:        primitive.NewObjectID(),
+			Role:      model.RoleUser,
+			Content:   message,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
 
-	reply, err := s.assist.Reply(ctx, conversation)
+	ctx = s.withUserSettings(ctx, conversation)
+	reply, generation, err := s.reply(ctx, conversation)
 	if err != nil {
-		return nil, twirp.InternalErrorWith(err)
+		if errors.Is(context.Cause(ctx), inflight.ErrSuperseded) {
+			return nil, guardError(inflight.ErrSuperseded)
+		}
+		if partial, ok := budget.PartialReply(err); ok {
+			return &pb.ContinueConversationResponse{Reply: s.processReply(ctx, conversation, partial), BudgetExceeded: true}, nil
+		}
+		return nil, errorsx.UpstreamTwirpError(err)
 	}
+	// A newer message took over this one while the reply was generated; its reply answers both
+	if ticket != nil && !s.replyGuard.Commit(ticket) {
+		return nil, guardError(inflight.ErrSuperseded)
+	}
+	reply = s.processReply(ctx, conversation, reply)
+	userMessage := conversation.Messages[len(conversation.Messages)-1]
+	greeting := s.greet(ctx, conversation)
 
 	conversation.Messages = append(conversation.Messages, &model.Message{
-		ID:        primitive.NewObjectID(),
-		Role:      model.RoleAssistant,
-		Content:   reply,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:         primitive.NewObjectID(),
+		Role:       model.RoleAssistant,
+		Content:    reply,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		Generation: generation,
 	})
 
-	if err := s.repo.UpdateConversation(ctx, conversation); err != nil {
+	if err := s.persistReply(ctx, conversation, conversation.Messages[stored:]); err != nil {
+		slog.ErrorContext(ctx, "Reply generated but not persisted",
+			"conversation_id", conversation.ID.Hex(),
+			"reply_length", len(reply),
+			"error", err)
 		return nil, twirp.InternalErrorWith(err)
 	}
+	s.observeUserMessage(conversation, userMessage)
 
-	return &pb.ContinueConversationResponse{Reply: reply}, nil
+	return &pb.ContinueConversationResponse{Reply: reply, Greeting: greeting}, nil
 }
 
 func (s *Server) ListConversations(ctx context.Context, req *pb.ListConversationsRequest) (*pb.ListConversationsResponse, error) {
@@ -205,7 +724,824 @@ func (s *Server) DescribeConversation(ctx context.Context, req *pb.DescribeConve
 		return nil, twirp.NotFoundError("conversation not found")
 	}
 
-	return &pb.DescribeConversationResponse{Conversation: conversation.Proto()}, nil
+	return &pb.DescribeConversationResponse{Conversation: conversation.Proto(), Stats: conversation.StatsProto()}, nil
+}
+
+func (s *Server) UploadAttachment(ctx context.Context, req *pb.UploadAttachmentRequest) (*pb.UploadAttachmentResponse, error) {
+	if s.attachments == nil {
+		return nil, twirp.NewError(twirp.Unimplemented, "attachments are not enabled")
+	}
+	if req.GetConversationId() == "" {
+		return nil, twirp.RequiredArgumentError("conversation_id")
+	}
+	if req.GetFilename() == "" {
+		return nil, twirp.RequiredArgumentError("filename")
+	}
+	if len(req.GetContent()) == 0 {
+		return nil, twirp.RequiredArgumentError("content")
+	}
+
+	conversation, err := s.repo.DescribeConversation(ctx, req.GetConversationId())
+	if err != nil {
+		return nil, err
+	}
+
+	var message *model.Message
+	if req.GetMessageId() != "" {
+		for _, m := range conversation.Messages {
+			if m.ID.Hex() == req.GetMessageId() {
+				message = m
+				break
+			}
+		}
+		if message == nil {
+			return nil, twirp.InvalidArgumentError("message_id", "message not found in conversation")
+		}
+	}
+
+	a, err := s.attachments.Upload(ctx, &attachment.Upload{
+		ConversationID: conversation.ID.Hex(),
+		MessageID:      req.GetMessageId(),
+		Filename:       req.GetFilename(),
+		ContentType:    req.GetContentType(),
+		Content:        req.GetContent(),
+	})
+	if errors.Is(err, attachment.ErrRejected) {
+		return nil, twirp.NewError(twirp.InvalidArgument, err.Error()).WithMeta("error_code", "attachment_rejected")
+	}
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	// Only the message's attachments are written, so replies and notes stored meanwhile are kept
+	if message != nil {
+		if err := s.repo.AddMessageAttachment(ctx, conversation.ID, message.ID, a.ID.Hex()); err != nil {
+			return nil, twirp.InternalErrorWith(err)
+		}
+	}
+
+	return &pb.UploadAttachmentResponse{Attachment: a.Proto()}, nil
+}
+
+func (s *Server) GetAttachment(ctx context.Context, req *pb.GetAttachmentRequest) (*pb.GetAttachmentResponse, error) {
+	if s.attachments == nil {
+		return nil, twirp.NewError(twirp.Unimplemented, "attachments are not enabled")
+	}
+	if req.GetConversationId() == "" {
+		return nil, twirp.RequiredArgumentError("conversation_id")
+	}
+	if req.GetAttachmentId() == "" {
+		return nil, twirp.RequiredArgumentError("attachment_id")
+	}
+
+	// Resolve the conversation first so attachments are only served within the caller's tenant
+	conversation, err := s.repo.DescribeConversation(ctx, req.GetConversationId())
+	if err != nil {
+		return nil, err
+	}
+
+	a, content, err := s.attachments.Get(ctx, conversation.ID.Hex(), req.GetAttachmentId())
+	if errors.Is(err, attachment.ErrNotFound) {
+		return nil, twirp.NotFoundError("attachment not found")
+	}
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	return &pb.GetAttachmentResponse{Attachment: a.Proto(), Content: content}, nil
+}
+
+// ReplayConversation reconstructs the recorded turns of a conversation, re-running each against its
+// recorded LLM responses when rerun is set
+// It is not part of the chat API: turns hold the raw LLM exchanges, so admins read them through ReplayHandler
+func (s *Server) ReplayConversation(ctx context.Context, conversationID string, rerun bool) ([]ReplayTurn, error) {
+	if s.replay == nil {
+		return nil, twirp.NewError(twirp.Unimplemented, "conversation replay is not enabled")
+	}
+	if conversationID == "" {
+		return nil, twirp.RequiredArgumentError("conversation_id")
+	}
+
+	// Resolve the conversation first so turns are only served within the caller's tenant
+	conversation, err := s.repo.DescribeConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	turns, err := s.replay.ListTurns(ctx, conversation.ID.Hex())
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	replayed := make([]ReplayTurn, 0, len(turns))
+	for _, turn := range turns {
+		entry := ReplayTurn{Turn: turn}
+		if rerun {
+			entry.Rerun, err = s.replay.Rerun(ctx, conversation, turn)
+			if err != nil {
+				entry.Rerun = &replay.Rerun{DivergedAt: -1, Error: err.Error()}
+			}
+		}
+		replayed = append(replayed, entry)
+	}
+
+	return replayed, nil
+}
+
+func (s *Server) AddReaction(ctx context.Context, req *pb.AddReactionRequest) (*pb.AddReactionResponse, error) {
+	if req.GetConversationId() == "" {
+		return nil, twirp.RequiredArgumentError("conversation_id")
+	}
+	if req.GetMessageId() == "" {
+		return nil, twirp.RequiredArgumentError("message_id")
+	}
+	if !model.ValidReaction(req.GetEmoji()) {
+		return nil, twirp.InvalidArgumentError("emoji", "must be a single emoji")
+	}
+
+	if err := s.checkAbuse(ctx, req.GetSessionMetadata()); err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.repo.DescribeConversation(ctx, req.GetConversationId())
+	if err != nil {
+		return nil, err
+	}
+
+	var message *model.Message
+	for _, m := range conversation.Messages {
+		if m.ID.Hex() == req.GetMessageId() {
+			message = m
+			break
+		}
+	}
+	if message == nil {
+		return nil, twirp.NotFoundError("message not found")
+	}
+	if message.Role != model.RoleAssistant {
+		return nil, twirp.InvalidArgumentError("message_id", "only assistant messages can be reacted to")
+	}
+
+	userID := req.GetSessionMetadata().GetUserId()
+	if userID == "" {
+		userID = conversation.UserID
+	}
+
+	reaction := model.Reaction{
+		UserID:    userID,
+		Emoji:     req.GetEmoji(),
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.SetMessageReaction(ctx, conversation.ID, message.ID, reaction); err != nil {
+		return nil, err
+	}
+
+	message.Reactions = slices.DeleteFunc(message.Reactions, func(r model.Reaction) bool {
+		return r.UserID == userID
+	})
+	message.Reactions = append(message.Reactions, reaction)
+
+	slog.InfoContext(ctx, "Message reaction recorded",
+		"conversation_id", conversation.ID.Hex(),
+		"message_id", message.ID.Hex(),
+		"platform", conversation.Platform,
+		"sentiment", reaction.Sentiment())
+
+	if s.reactions != nil {
+		s.reactions.RecordReaction(ctx, conversation.Platform, reaction.Sentiment())
+	}
+	if s.experiments != nil && message.Generation != nil {
+		s.experiments.RecordVariantReaction(ctx, message.Generation.Variant, reaction.Sentiment())
+	}
+
+	return &pb.AddReactionResponse{Message: message.Proto()}, nil
+}
+
+func (s *Server) RequestDataExport(ctx context.Context, req *pb.RequestDataExportRequest) (*pb.RequestDataExportResponse, error) {
+	if s.takeout == nil {
+		return nil, twirp.NewError(twirp.Unimplemented, "data export is not enabled")
+	}
+
+	metadata := req.GetSessionMetadata()
+	if metadata.GetPlatform() == "" || metadata.GetUserId() == "" {
+		return nil, twirp.RequiredArgumentError("session_metadata")
+	}
+
+	if err := s.checkAbuse(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	export, err := s.takeout.Request(ctx, metadata.GetPlatform(), metadata.GetUserId(), metadata.GetChatId())
+	if errors.Is(err, takeout.ErrBusy) {
+		return nil, twirp.NewError(twirp.ResourceExhausted, "too many exports in progress, try again later")
+	}
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	if s.audit != nil {
+		// The export is already queued, so a failed audit write is reported but does not fail the request
+		err := s.audit.Record(ctx, &audit.Entry{
+			Action: audit.ActionDataExportRequested,
+			Actor:  audit.Actor(metadata.GetPlatform(), metadata.GetUserId()),
+			Target: export.ID.Hex(),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to audit data export", "export_id", export.ID.Hex(), "error", err)
+		}
+	}
+
+	return &pb.RequestDataExportResponse{Export: export.Proto("")}, nil
+}
+
+func (s *Server) GetDataExport(ctx context.Context, req *pb.GetDataExportRequest) (*pb.GetDataExportResponse, error) {
+	if s.takeout == nil {
+		return nil, twirp.NewError(twirp.Unimplemented, "data export is not enabled")
+	}
+	if req.GetExportId() == "" {
+		return nil, twirp.RequiredArgumentError("export_id")
+	}
+
+	metadata := req.GetSessionMetadata()
+	if metadata.GetPlatform() == "" || metadata.GetUserId() == "" {
+		return nil, twirp.RequiredArgumentError("session_metadata")
+	}
+
+	export, downloadURL, err := s.takeout.Get(ctx, req.GetExportId(), metadata.GetPlatform(), metadata.GetUserId())
+	if errors.Is(err, takeout.ErrNotFound) {
+		return nil, twirp.NotFoundError("export not found")
+	}
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	return &pb.GetDataExportResponse{Export: export.Proto(downloadURL)}, nil
+}
+
+func (s *Server) ResetSession(ctx context.Context, req *pb.ResetSessionRequest) (*pb.ResetSessionResponse, error) {
+	metadata := req.GetSessionMetadata()
+	if metadata.GetPlatform() == "" || metadata.GetChatId() == "" {
+		return nil, twirp.RequiredArgumentError("session_metadata")
+	}
+
+	conversationID, err := s.sessionManager.ResetSession(ctx, metadata.GetPlatform(), metadata.GetChatId())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reset session",
+			"platform", metadata.GetPlatform(), "chat_id", metadata.GetChatId(), "error", err)
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	return &pb.ResetSessionResponse{ArchivedConversationId: conversationID}, nil
+}
+
+func (s *Server) GetUserSettings(ctx context.Context, req *pb.GetUserSettingsRequest) (*pb.GetUserSettingsResponse, error) {
+	if s.settings == nil {
+		return nil, twirp.NewError(twirp.Unimplemented, "user settings are not enabled")
+	}
+
+	metadata := req.GetSessionMetadata()
+	if metadata.GetPlatform() == "" || metadata.GetUserId() == "" {
+		return nil, twirp.RequiredArgumentError("session_metadata")
+	}
+
+	platform, userID := s.canonicalUser(ctx, metadata.GetPlatform(), metadata.GetUserId())
+	prefs, err := s.settings.Get(ctx, platform, userID)
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	return &pb.GetUserSettingsResponse{Settings: prefs.Proto()}, nil
+}
+
+func (s *Server) SetUserSettings(ctx context.Context, req *pb.SetUserSettingsRequest) (*pb.SetUserSettingsResponse, error) {
+	if s.settings == nil {
+		return nil, twirp.NewError(twirp.Unimplemented, "user settings are not enabled")
+	}
+
+	metadata := req.GetSessionMetadata()
+	if metadata.GetPlatform() == "" || metadata.GetUserId() == "" {
+		return nil, twirp.RequiredArgumentError("session_metadata")
+	}
+	if req.GetSettings() == nil {
+		return nil, twirp.RequiredArgumentError("settings")
+	}
+
+	platform, userID := s.canonicalUser(ctx, metadata.GetPlatform(), metadata.GetUserId())
+	prefs, err := s.settings.Set(ctx, &settings.Settings{
+		Platform:  platform,
+		UserID:    userID,
+		Language:  req.GetSettings().GetLanguage(),
+		Units:     req.GetSettings().GetUnits(),
+		Timezone:  req.GetSettings().GetTimezone(),
+		Verbosity: req.GetSettings().GetVerbosity(),
+	})
+	if errors.Is(err, settings.ErrInvalid) {
+		return nil, twirp.InvalidArgumentError("settings", err.Error())
+	}
+	if err != nil {
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	return &pb.SetUserSettingsResponse{Settings: prefs.Proto()}, nil
+}
+
+func (s *Server) SetConversationInstructions(ctx context.Context, req *pb.SetConversationInstructionsRequest) (*pb.SetConversationInstructionsResponse, error) {
+	if req.GetConversationId() == "" {
+		return nil, twirp.RequiredArgumentError("conversation_id")
+	}
+	instructions := strings.TrimSpace(req.GetInstructions())
+	if utf8.RuneCountInString(instructions) > model.MaxInstructionsChars {
+		return nil, twirp.InvalidArgumentError("instructions",
+			fmt.Sprintf("must be at most %d characters", model.MaxInstructionsChars))
+	}
+
+	if err := s.checkAbuse(ctx, req.GetSessionMetadata()); err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.repo.DescribeConversation(ctx, req.GetConversationId())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetConversationInstructions(ctx, conversation.ID, instructions); err != nil {
+		return nil, err
+	}
+
+	platform, userID := req.GetSessionMetadata().GetPlatform(), req.GetSessionMetadata().GetUserId()
+	if userID == "" {
+		platform, userID = conversation.Platform, conversation.UserID
+	}
+	slog.InfoContext(ctx, "Conversation instructions changed",
+		"conversation_id", conversation.ID.Hex(),
+		"platform", platform,
+		"user_id", userID,
+		"length", len(instructions))
+
+	if s.audit != nil && instructions != conversation.Instructions {
+		entry := &audit.Entry{
+			Action: audit.ActionConversationInstructionsSet,
+			Actor:  audit.Actor(platform, userID),
+			Target: conversation.ID.Hex(),
+			Before: conversation.Instructions,
+			After:  instructions,
+		}
+		if instructions == "" {
+			entry.Action = audit.ActionConversationInstructionsCleared
+		}
+		// The change is already stored, so a failed audit write is reported but does not fail the request
+		if err := s.audit.Record(ctx, entry); err != nil {
+			slog.ErrorContext(ctx, "Failed to audit conversation instructions change",
+				"conversation_id", conversation.ID.Hex(), "error", err)
+		}
+	}
+
+	return &pb.SetConversationInstructionsResponse{Instructions: instructions}, nil
+}
+
+func (s *Server) ClaimSession(ctx context.Context, req *pb.ClaimSessionRequest) (*pb.ClaimSessionResponse, error) {
+	anonymous, metadata := req.GetAnonymous(), req.GetSessionMetadata()
+	if anonymous.GetPlatform() == "" || anonymous.GetUserId() == "" {
+		return nil, twirp.RequiredArgumentError("anonymous")
+	}
+	if metadata.GetPlatform() == "" || metadata.GetUserId() == "" {
+		return nil, twirp.RequiredArgumentError("session_metadata")
+	}
+	if anonymous.GetPlatform() != metadata.GetPlatform() {
+		return nil, twirp.InvalidArgumentError("session_metadata", "must be on the platform of the anonymous user")
+	}
+	if anonymous.GetUserId() == metadata.GetUserId() {
+		return nil, twirp.InvalidArgumentError("session_metadata", "must be a different user than the anonymous user")
+	}
+
+	if err := s.checkAbuse(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	platform := metadata.GetPlatform()
+	conversationIDs, conversationID, err := s.sessionManager.ClaimSession(ctx, platform,
+		anonymous.GetUserId(), anonymous.GetChatId(), metadata.GetUserId(), metadata.GetChatId())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim session",
+			"platform", platform, "user_id", metadata.GetUserId(), "error", err)
+		return nil, twirp.InternalErrorWith(err)
+	}
+
+	resp := &pb.ClaimSessionResponse{ConversationIds: conversationIDs, ConversationId: conversationID}
+
+	if s.audit != nil {
+		// The transfer is already stored, so failed audit writes are reported but do not fail the request
+		for _, id := range conversationIDs {
+			err := s.audit.Record(ctx, &audit.Entry{
+				Action: audit.ActionSessionClaimed,
+				Actor:  audit.Actor(platform, metadata.GetUserId()),
+				Target: id,
+				Before: audit.Actor(platform, anonymous.GetUserId()),
+				After:  audit.Actor(platform, metadata.GetUserId()),
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to audit session claim", "conversation_id", id, "error", err)
+			}
+		}
+	}
+
+	if s.settings != nil {
+		prefs, err := s.mergeClaimedSettings(ctx, platform, anonymous.GetUserId(), metadata.GetUserId())
+		if err != nil {
+			// Conversations already moved; the user can set their preferences again
+			slog.WarnContext(ctx, "Failed to merge settings of claimed session",
+				"platform", platform, "user_id", metadata.GetUserId(), "error", err)
+		} else {
+			resp.Settings = prefs.Proto()
+		}
+	}
+
+	return resp, nil
+}
+
+// InjectSystemNote stores an operator note in a conversation; the assistant reads it with the messages
+// of its next reply, see SystemNotePrefix
+// It is not part of the chat API: admins add notes through NoteHandler
+func (s *Server) InjectSystemNote(ctx context.Context, conversationID, content, author string) (*model.Message, error) {
+	if conversationID == "" {
+		return nil, twirp.RequiredArgumentError("conversation_id")
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, twirp.RequiredArgumentError("content")
+	}
+	if utf8.RuneCountInString(content) > model.MaxSystemNoteChars {
+		return nil, twirp.InvalidArgumentError("content",
+			fmt.Sprintf("must be at most %d characters", model.MaxSystemNoteChars))
+	}
+	if author == "" {
+		return nil, twirp.RequiredArgumentError("author")
+	}
+
+	conversation, err := s.repo.DescribeConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	note := &model.Message{
+		ID:        primitive.NewObjectID(),
+		Role:      model.RoleSystem,
+		Content:   content,
+		Author:    author,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.AddConversationMessage(ctx, conversation.ID, note); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "System note added to conversation",
+		"conversation_id", conversation.ID.Hex(),
+		"author", author,
+		"length", len(content))
+
+	if s.audit != nil {
+		// The note is already stored, so a failed audit write is reported but does not fail the request
+		if err := s.audit.Record(ctx, &audit.Entry{
+			Action: audit.ActionConversationNoteInjected,
+			Actor:  author,
+			Target: conversation.ID.Hex(),
+			After:  content,
+		}); err != nil {
+			slog.ErrorContext(ctx, "Failed to audit system note",
+				"conversation_id", conversation.ID.Hex(), "error", err)
+		}
+	}
+
+	return note, nil
+}
+
+// mergeClaimedSettings fills the unset preferences of an authenticated user with those chosen while anonymous
+func (s *Server) mergeClaimedSettings(ctx context.Context, platform, anonymousUserID, userID string) (*settings.Settings, error) {
+	previous, err := s.settings.Get(ctx, platform, anonymousUserID)
+	if err != nil {
+		return nil, err
+	}
+	canonicalPlatform, canonicalUserID := s.canonicalUser(ctx, platform, userID)
+	prefs, err := s.settings.Get(ctx, canonicalPlatform, canonicalUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !prefs.FillFrom(previous) {
+		return prefs, nil
+	}
+	return s.settings.Set(ctx, prefs)
+}
+
+// checkAbuse rejects requests from blocked users identified by session metadata
+func (s *Server) checkAbuse(ctx context.Context, metadata *pb.SessionMetadata) error {
+	if s.abuseGuard == nil || metadata.GetPlatform() == "" || metadata.GetUserId() == "" {
+		return nil
+	}
+
+	// Blocks and quotas apply to the platform identity and, if linked, to the person behind it
+	err := s.abuseGuard.Check(ctx, metadata.GetPlatform(), metadata.GetUserId())
+	if err == nil {
+		platform, userID := s.canonicalUser(ctx, metadata.GetPlatform(), metadata.GetUserId())
+		if platform != metadata.GetPlatform() || userID != metadata.GetUserId() {
+			err = s.abuseGuard.Check(ctx, platform, userID)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, abuse.ErrUserBlocked) {
+		return twirp.InternalErrorWith(err)
+	}
+
+	twerr := twirp.NewError(twirp.PermissionDenied, "user is temporarily blocked").
+		WithMeta("error_code", "user_blocked")
+
+	var blocked *abuse.BlockedError
+	if errors.As(err, &blocked) {
+		twerr = twerr.WithMeta("blocked_until", blocked.Block.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	return twerr
+}
+
+// checkContent rejects a message containing a term the tenant blocks and counts a moderation strike
+// against the sender identified by session metadata
+func (s *Server) checkContent(ctx context.Context, metadata *pb.SessionMetadata, message string) error {
+	if s.contentPolicy == nil {
+		return nil
+	}
+
+	err := s.contentPolicy.CheckMessage(ctx, message)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, blocklist.ErrBlocked) {
+		return twirp.InternalErrorWith(err)
+	}
+
+	if s.abuseGuard != nil && metadata.GetPlatform() != "" && metadata.GetUserId() != "" {
+		platform, userID := s.canonicalUser(ctx, metadata.GetPlatform(), metadata.GetUserId())
+		if _, err := s.abuseGuard.RecordStrike(ctx, platform, userID, abuse.ReasonModeration); err != nil {
+			slog.WarnContext(ctx, "Failed to record moderation strike", "platform", platform, "error", err)
+		}
+	}
+
+	twerr := twirp.NewError(twirp.InvalidArgument, "message contains content that is not allowed here").
+		WithMeta("argument", "message").
+		WithMeta("error_code", "message_blocked")
+
+	var blocked *blocklist.BlockedError
+	if errors.As(err, &blocked) && blocked.Match.Category != "" {
+		twerr = twerr.WithMeta("category", blocked.Match.Category)
+	}
+
+	return twerr
+}
+
+// messageRateError converts message rate limit errors to API errors
+func messageRateError(err error) error {
+	var limited *abuse.MessageRateError
+	if !errors.As(err, &limited) {
+		return twirp.InternalErrorWith(err)
+	}
+	return twirp.NewError(twirp.ResourceExhausted, "too many messages in this "+limited.Scope+", slow down").
+		WithMeta("error_code", limited.Scope+"_rate_limited").
+		WithMeta("limit_per_minute", strconv.Itoa(limited.Limit))
+}
+
+// guardError converts reply guard errors to API errors
+func guardError(err error) error {
+	switch {
+	case errors.Is(err, inflight.ErrBusy), errors.Is(err, inflight.ErrSuperseded):
+		return twirp.NewError(twirp.Aborted, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return twirp.NewError(twirp.DeadlineExceeded, "timed out waiting for an earlier reply")
+	case errors.Is(err, context.Canceled):
+		return twirp.NewError(twirp.Canceled, "request cancelled while waiting for an earlier reply")
+	default:
+		return twirp.InternalErrorWith(err)
+	}
+}
+
+// checkMessageSize rejects a message that exceeds the token limit, reporting its measured size
+func (s *Server) checkMessageSize(message string) error {
+	limit := s.maxMessageTokens
+	if limit <= 0 {
+		return nil
+	}
+	limit = max(limit, s.chunkedInputTokens)
+
+	count := tokens.CountWithGlobal(message)
+	if count <= limit {
+		return nil
+	}
+
+	return twirp.NewError(twirp.InvalidArgument,
+		fmt.Sprintf("message is too long: %d tokens, the limit is %d; shorten it or split it into several messages",
+			count, limit)).
+		WithMeta("argument", "message").
+		WithMeta("error_code", "message_too_long").
+		WithMeta("token_count", strconv.Itoa(count)).
+		WithMeta("max_tokens", strconv.Itoa(limit))
+}
+
+// withUserSettings returns a context carrying the preferences of the conversation's user
+func (s *Server) withUserSettings(ctx context.Context, conversation *model.Conversation) context.Context {
+	if s.settings == nil || conversation.UserID == "" {
+		return ctx
+	}
+
+	platform, userID := s.canonicalUser(ctx, conversation.Platform, conversation.UserID)
+	prefs, err := s.settings.Get(ctx, platform, userID)
+	if err != nil {
+		// Preferences are cosmetic; answer with the defaults rather than fail
+		slog.WarnContext(ctx, "Failed to load user settings",
+			"conversation_id", conversation.ID.Hex(), "error", err)
+		return ctx
+	}
+	return settings.WithSettings(ctx, prefs)
+}
+
+// canonicalUser returns the canonical user of a platform identity, or the identity itself when it
+// is not linked or cannot be resolved
+func (s *Server) canonicalUser(ctx context.Context, platform, userID string) (string, string) {
+	if s.identities == nil {
+		return platform, userID
+	}
+	canonical, err := s.identities.Resolve(ctx, identity.Identity{Platform: platform, UserID: userID})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to resolve linked identity",
+			"platform", platform, "user_id", userID, "error", err)
+		return platform, userID
+	}
+	return canonical.Platform, canonical.UserID
+}
+
+// withReplyStyle validates the style requested for a reply and returns a context carrying it
+func withReplyStyle(ctx context.Context, requested *pb.ReplyStyle) (context.Context, error) {
+	replyStyle := style.FromProto(requested)
+	if err := replyStyle.Validate(); err != nil {
+		return ctx, twirp.InvalidArgumentError("style", err.Error())
+	}
+	return style.WithStyle(ctx, replyStyle), nil
+}
+
+// processReply runs a reply through the post-processing filters of the conversation's platform
+// A maximum length requested for the reply is enforced last, as the model may not keep to it
+func (s *Server) processReply(ctx context.Context, conversation *model.Conversation, reply string) string {
+	if s.replyProcessor != nil {
+		// Replies must be in the conversation's language, which the user's preferences may choose
+		var preferred string
+		if prefs := settings.FromContext(ctx); prefs != nil {
+			preferred = prefs.Language
+		}
+		filterCtx := postprocess.WithLanguage(postprocess.WithPersona(ctx, conversation.Persona), conversation.ReplyLanguage(preferred))
+		reply = s.replyProcessor.Process(filterCtx, conversation.Platform, reply)
+	}
+	if replyStyle := style.FromContext(ctx); replyStyle != nil && replyStyle.MaxLength > 0 {
+		reply = postprocess.NewLengthLimiter(replyStyle.MaxLength).Apply(ctx, reply)
+	}
+	return reply
+}
+
+// greet adds the configured greeting to a conversation about to get its first reply, so the stored
+// conversation reads as the user saw it; it returns the greeting, "" when there is none
+func (s *Server) greet(ctx context.Context, conversation *model.Conversation) string {
+	if s.greeter == nil || slices.ContainsFunc(conversation.Messages, func(m *model.Message) bool {
+		return m.Role == model.RoleAssistant
+	}) {
+		return ""
+	}
+	greeting := s.greeter.Greeting(ctx, conversation.Platform, conversation.Persona)
+	if greeting != "" {
+		conversation.Messages = append(conversation.Messages, &model.Message{
+			ID:        primitive.NewObjectID(),
+			Role:      model.RoleAssistant,
+			Content:   greeting,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
+	return greeting
+}
+
+// observeUserMessage hands a stored user message to the sentiment tracker and topic tagger, if configured
+func (s *Server) observeUserMessage(conversation *model.Conversation, msg *model.Message) {
+	if s.sentiment != nil {
+		s.sentiment.Observe(conversation, msg)
+	}
+	if s.topics != nil {
+		s.topics.Observe(conversation, msg)
+	}
+}
+
+// reply generates the assistant's reply and tracks the variant, latency and tokens it took
+// The context carries the user's preferences, see withUserSettings
+// The generation is nil when the assistant did not report a variant
+// Replies that run out of the request budget fail with budget.ErrExceeded
+func (s *Server) reply(ctx context.Context, conv *model.Conversation) (string, *model.Generation, error) {
+	replyCtx, generation := experiment.Track(ctx)
+	replyCtx, cancel := budget.Context(replyCtx)
+	defer cancel()
+	start := time.Now()
+	reply, err := s.assist.Reply(replyCtx, conv)
+	if err != nil && budget.Exceeded(replyCtx) && !errors.Is(err, budget.ErrExceeded) {
+		err = &budget.ExceededError{}
+	}
+	if err != nil || generation.Model == "" {
+		return reply, nil, err
+	}
+
+	latency := time.Since(start)
+	generation.LatencyMs = latency.Milliseconds()
+	if s.experiments != nil {
+		s.experiments.RecordVariantReply(ctx, generation.Variant, latency, generation.PromptTokens+generation.CompletionTokens)
+	}
+	return reply, generation, nil
+}
+
+// provisionalTitle is shown until the generated title is ready: the first words of the message
+func provisionalTitle(message string) string {
+	words := strings.Fields(message)
+	title := strings.Join(words, " ")
+	if len(words) > maxProvisionalTitleWords {
+		title = strings.Join(words[:maxProvisionalTitleWords], " ") + "…"
+	}
+	if runes := []rune(title); len(runes) > maxProvisionalTitleRunes {
+		title = string(runes[:maxProvisionalTitleRunes-1]) + "…"
+	}
+	return title
+}
+
+// generateTitle generates the conversation's title without holding up the caller
+// The channel receives the title, or is closed without one if generation fails
+func (s *Server) generateTitle(ctx context.Context, conv *model.Conversation) <-chan string {
+	snapshot := &model.Conversation{
+		ID:       conv.ID,
+		TenantID: conv.TenantID,
+		Platform: conv.Platform,
+		UserID:   conv.UserID,
+		Messages: conv.Messages[:1],
+	}
+
+	titles := make(chan string, 1)
+	go func() {
+		defer close(titles)
+		// The title outlives the request when the reply is faster
+		titleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), titleTimeout)
+		defer cancel()
+
+		title, err := s.assist.Title(titleCtx, snapshot)
+		if err != nil {
+			slog.ErrorContext(titleCtx, "Failed to generate conversation title",
+				"conversation_id", conv.ID.Hex(), "error", err)
+			return
+		}
+		titles <- title
+	}()
+	return titles
+}
+
+// storeTitle waits for a title generated in the background and replaces the provisional title with it
+// If generation failed the provisional title is kept as the conversation's title
+func (s *Server) storeTitle(ctx context.Context, conversationID, provisional string, titles <-chan string) {
+	title, ok := <-titles
+	if !ok {
+		title = provisional
+	}
+
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.titleUpdater.UpdateConversationTitle(storeCtx, conversationID, title); err != nil {
+		slog.ErrorContext(storeCtx, "Failed to store conversation title",
+			"conversation_id", conversationID, "error", err)
+	}
+}
+
+// persistReply saves the new messages of a conversation after a paid completion, retrying transient storage failures
+// Only the new messages are written, so notes added while the reply was generated are not overwritten
+// It keeps retrying even if the client went away, so the reply is not lost with the request
+func (s *Server) persistReply(ctx context.Context, conversation *model.Conversation, messages []*model.Message) error {
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	for attempt := 0; attempt < persistMaxAttempts; attempt++ {
+		if err = s.repo.AppendReply(ctx, conversation, messages); err == nil {
+			return nil
+		}
+
+		slog.WarnContext(ctx, "Failed to persist reply, retrying",
+			"conversation_id", conversation.ID.Hex(),
+			"attempt", attempt+1,
+			"max_attempts", persistMaxAttempts,
+			"error", err)
+
+		if attempt < persistMaxAttempts-1 {
+			time.Sleep(persistBaseDelay * time.Duration(1<<attempt))
+		}
+	}
+
+	return err
 }
 
 // summarizeConversation is deprecated - context management is now handled by the assistant
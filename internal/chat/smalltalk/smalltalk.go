@@ -0,0 +1,74 @@
+package smalltalk
+
+import (
+	"strings"
+	"unicode"
+)
+
+// vocabulary holds the words small talk is made of; a message with any other word may need a tool
+var vocabulary = toSet(
+	// Thanks and acknowledgements
+	"thanks", "thank", "thx", "ty", "you", "so", "much", "very", "a", "lot", "many",
+	"ok", "okay", "k", "kk", "alright", "fine", "sure", "yep", "yeah", "yes", "no", "nope",
+	"got", "it", "understood", "i", "see", "makes", "sense", "noted", "np", "problem",
+	"great", "cool", "nice", "awesome", "perfect", "excellent", "good", "amazing", "wonderful", "lol", "haha",
+	"that's", "thats", "that", "is", "was", "helpful", "helps", "appreciate", "appreciated",
+	// Greetings and goodbyes
+	"hi", "hello", "hey", "yo", "morning", "afternoon", "evening", "night",
+	"bye", "goodbye", "cya", "later", "ya", "take", "care", "have", "day", "cheers",
+	// Common in other languages
+	"merci", "danke", "gracias", "grazie", "obrigado", "obrigada", "спасибо", "привет", "пока",
+	"hola", "bonjour", "ciao", "hallo", "adios", "tschüss",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// Classifier decides which user messages are small talk the model can answer without tools
+// A nil *Classifier treats every message as needing tools
+type Classifier struct {
+	maxChars int
+}
+
+// NewClassifier creates a classifier for messages up to maxChars long; it returns nil when maxChars is not positive
+func NewClassifier(maxChars int) *Classifier {
+	if maxChars <= 0 {
+		return nil
+	}
+	return &Classifier{maxChars: maxChars}
+}
+
+// Simple reports whether a message like "thanks" or "bye" can be answered without tools
+// A message answering a question of the previous reply is never simple: "yes" to
+// "Shall I check the forecast?" needs the weather tool
+func (c *Classifier) Simple(message, previousReply string) bool {
+	if c == nil {
+		return false
+	}
+	message = strings.TrimSpace(message)
+	if message == "" || len([]rune(message)) > c.maxChars || strings.Contains(message, "?") {
+		return false
+	}
+	if strings.HasSuffix(strings.TrimSpace(previousReply), "?") {
+		return false
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(message), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) == 0 {
+		// Only emoji or punctuation, e.g. "👍"
+		return !strings.ContainsFunc(message, unicode.IsDigit)
+	}
+	for _, word := range words {
+		if !vocabulary[word] {
+			return false
+		}
+	}
+	return true
+}
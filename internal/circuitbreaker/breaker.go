@@ -83,6 +83,21 @@ func (cb *CircuitBreaker) Execute(fn func() error) error {
 	return nil
 }
 
+// Allow reports whether a call may be attempted, for callers that make the call themselves and report
+// its outcome with Record; an open circuit turns half-open once its cooldown has passed
+func (cb *CircuitBreaker) Allow() bool {
+	return cb.canAttempt()
+}
+
+// Record records the outcome of a call attempted after Allow; nil is a success
+func (cb *CircuitBreaker) Record(err error) {
+	if err != nil {
+		cb.recordFailure()
+		return
+	}
+	cb.recordSuccess()
+}
+
 // canAttempt checks if a request can be attempted
 func (cb *CircuitBreaker) canAttempt() bool {
 	cb.mu.Lock()
@@ -0,0 +1,91 @@
+package coldstorage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Archive keeps whole conversations in object storage as gzipped extended JSON
+// Documents keep their Mongo field names, so an archive can be inspected or re-imported with mongoimport
+type Archive struct {
+	store objectstore.Store
+}
+
+// NewArchive creates an archive writing to store, e.g. objectstore.Prefixed(store, "conversations/")
+func NewArchive(store objectstore.Store) *Archive {
+	return &Archive{store: store}
+}
+
+// Key returns the object key a conversation is archived under
+func Key(c *model.Conversation) string {
+	tenantID := c.TenantID
+	if tenantID == "" {
+		tenantID = tenant.DefaultID
+	}
+	return tenantID + "/" + c.ID.Hex() + ".json.gz"
+}
+
+// Save writes a conversation to the archive, returning its key
+// Saving a conversation again replaces the previous copy
+func (a *Archive) Save(ctx context.Context, c *model.Conversation) (string, error) {
+	doc, err := bson.MarshalExtJSON(c, false, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode conversation: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(doc); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	key := Key(c)
+	if err := a.store.Put(ctx, key, "application/gzip", buf.Bytes()); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Load reads an archived conversation
+func (a *Archive) Load(ctx context.Context, key string) (*model.Conversation, error) {
+	obj, err := a.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	zr, err := gzip.NewReader(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived conversation: %w", err)
+	}
+	doc, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived conversation: %w", err)
+	}
+
+	var c model.Conversation
+	if err := bson.UnmarshalExtJSON(doc, false, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode archived conversation: %w", err)
+	}
+	return &c, nil
+}
+
+// RestoreMessages returns the messages of an archived conversation, see model.WithColdStorage
+func (a *Archive) RestoreMessages(ctx context.Context, key string) ([]*model.Message, error) {
+	c, err := a.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return c.Messages, nil
+}
@@ -0,0 +1,105 @@
+package coldstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConversationStore finds inactive conversations and replaces them with stubs, see model.Repository
+type ConversationStore interface {
+	FindInactiveConversations(ctx context.Context, before time.Time, after primitive.ObjectID, limit int) ([]*model.Conversation, error)
+	StubConversation(ctx context.Context, c *model.Conversation, key string) (bool, error)
+}
+
+// Config controls which conversations are archived
+type Config struct {
+	InactiveFor time.Duration // Conversations without activity for this long are archived
+	BatchSize   int           // Conversations loaded per page
+}
+
+// Result counts the conversations of an archiving run
+type Result struct {
+	Archived int `json:"archived"`
+	Skipped  int `json:"skipped"` // Active again while they were archived; kept in Mongo
+	Failed   int `json:"failed"`
+}
+
+// Archiver moves long-inactive conversations to cold storage, leaving stubs in Mongo
+type Archiver struct {
+	conversations ConversationStore
+	archive       *Archive
+	cfg           Config
+}
+
+// NewArchiver creates an archiver
+func NewArchiver(conversations ConversationStore, archive *Archive, cfg Config) *Archiver {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Archiver{conversations: conversations, archive: archive, cfg: cfg}
+}
+
+// Run archives every conversation inactive since cfg.InactiveFor before now
+// A conversation that fails is left in Mongo and retried on the next run
+func (a *Archiver) Run(ctx context.Context, now time.Time) (*Result, error) {
+	if a.cfg.InactiveFor <= 0 {
+		return nil, errors.New("cold storage needs an inactivity period")
+	}
+	before := now.Add(-a.cfg.InactiveFor)
+	result := &Result{}
+
+	var after primitive.ObjectID
+	for {
+		page, err := a.conversations.FindInactiveConversations(ctx, before, after, a.cfg.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to find inactive conversations: %w", err)
+		}
+		for _, c := range page {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			a.archiveOne(ctx, c, result)
+			after = c.ID
+		}
+		if len(page) < a.cfg.BatchSize {
+			break
+		}
+	}
+
+	slog.InfoContext(ctx, "Archived inactive conversations to cold storage",
+		"inactive_since", before,
+		"archived", result.Archived,
+		"skipped", result.Skipped,
+		"failed", result.Failed)
+	if result.Failed > 0 {
+		return result, fmt.Errorf("%d conversations failed to archive", result.Failed)
+	}
+	return result, nil
+}
+
+func (a *Archiver) archiveOne(ctx context.Context, c *model.Conversation, result *Result) {
+	key, err := a.archive.Save(ctx, c)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to archive conversation", "conversation_id", c.ID.Hex(), "error", err)
+		result.Failed++
+		return
+	}
+
+	// The archive is written before the messages are dropped, so a crash in between loses nothing
+	stubbed, err := a.conversations.StubConversation(ctx, c, key)
+	switch {
+	case err != nil:
+		slog.ErrorContext(ctx, "Failed to stub archived conversation", "conversation_id", c.ID.Hex(), "error", err)
+		result.Failed++
+	case !stubbed:
+		result.Skipped++
+	default:
+		result.Archived++
+	}
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const resetReply = "Conversation reset. Your next message starts a new conversation."
+
+// languagePattern accepts ISO 639 codes with an optional region, e.g. "es" or "pt-BR"
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// RegisterBuiltins registers /reset, /help, /language and /persona on the given platforms
+// A non-empty personas list restricts /persona to those names
+func RegisterBuiltins(registry *Registry, platforms []string, personas []string) {
+	registry.Register(platforms, &Command{
+		Name:        "reset",
+		Usage:       "/reset",
+		Description: "Start a new conversation",
+		Run:         runReset,
+	})
+	registry.Register(platforms, &Command{
+		Name:        "help",
+		Usage:       "/help",
+		Description: "List the available commands",
+		Run:         runHelp,
+	})
+	registry.Register(platforms, &Command{
+		Name:        "language",
+		Usage:       "/language <code|auto>",
+		Description: "Choose the language replies are written in",
+		Run:         runLanguage,
+	})
+	registry.Register(platforms, &Command{
+		Name:        "persona",
+		Usage:       "/persona <name|default>",
+		Description: "Choose how the assistant talks to you",
+		Run: func(ctx context.Context, inv *Invocation) (string, error) {
+			return runPersona(ctx, inv, personas)
+		},
+	})
+}
+
+func runReset(ctx context.Context, inv *Invocation) (string, error) {
+	if inv.ChatID != "" {
+		if _, err := inv.router.sessions.ResetSession(ctx, inv.Platform, inv.ChatID); err != nil {
+			return "", fmt.Errorf("failed to reset session: %w", err)
+		}
+		return resetReply, nil
+	}
+
+	// Without a chat there is no session; archive the conversation the client addressed
+	conv, err := inv.Conversation(ctx)
+	if err != nil {
+		return "", err
+	}
+	if conv != nil {
+		if _, err := inv.router.store.ArchiveConversations(ctx, []primitive.ObjectID{conv.ID}); err != nil {
+			return "", fmt.Errorf("failed to archive conversation: %w", err)
+		}
+	}
+	return resetReply, nil
+}
+
+func runHelp(_ context.Context, inv *Invocation) (string, error) {
+	var b strings.Builder
+	b.WriteString("Available commands:")
+	for _, cmd := range inv.router.registry.Commands(inv.Platform) {
+		fmt.Fprintf(&b, "\n%s - %s", cmd.Usage, cmd.Description)
+	}
+	return b.String(), nil
+}
+
+func runLanguage(ctx context.Context, inv *Invocation) (string, error) {
+	if len(inv.Args) != 1 {
+		return "", ErrUsage
+	}
+
+	language := inv.Args[0]
+	if strings.EqualFold(language, "auto") {
+		language = ""
+	} else if !languagePattern.MatchString(language) {
+		return "", ErrUsage
+	}
+
+	conv, err := inv.EnsureConversation(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, err := inv.router.store.SetConversationLanguage(ctx, []primitive.ObjectID{conv.ID}, language); err != nil {
+		return "", fmt.Errorf("failed to set language: %w", err)
+	}
+
+	if language == "" {
+		return "I will reply in the language you write in.", nil
+	}
+	return fmt.Sprintf("I will reply in %q from now on.", language), nil
+}
+
+func runPersona(ctx context.Context, inv *Invocation, personas []string) (string, error) {
+	if len(inv.Args) != 1 {
+		return "", ErrUsage
+	}
+
+	persona := strings.ToLower(inv.Args[0])
+	if persona == "default" {
+		persona = ""
+	} else if len(personas) > 0 && !slices.Contains(personas, persona) {
+		return fmt.Sprintf("Unknown persona %q. Available personas: %s, default.", persona, strings.Join(personas, ", ")), nil
+	}
+
+	conv, err := inv.EnsureConversation(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, err := inv.router.store.SetConversationPersona(ctx, []primitive.ObjectID{conv.ID}, persona); err != nil {
+		return "", fmt.Errorf("failed to set persona: %w", err)
+	}
+
+	if persona == "" {
+		return "Persona reset to default.", nil
+	}
+	return fmt.Sprintf("Persona set to %q.", persona), nil
+}
@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prefix marks a message as a command
+const Prefix = "/"
+
+// ErrUsage is returned by commands called with invalid arguments; the command's usage is sent back to the user
+var ErrUsage = errors.New("invalid command arguments")
+
+// ConversationStore reads and changes the conversation settings commands act on
+type ConversationStore interface {
+	DescribeConversation(ctx context.Context, id string) (*model.Conversation, error)
+	ArchiveConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error)
+	SetConversationPersona(ctx context.Context, ids []primitive.ObjectID, persona string) (int64, error)
+	SetConversationLanguage(ctx context.Context, ids []primitive.ObjectID, language string) (int64, error)
+}
+
+// SessionStore maps platform chats to conversations, see session.Manager
+type SessionStore interface {
+	GetSession(ctx context.Context, platform, chatID string) (*session.Session, error)
+	StartSession(ctx context.Context, platform, userID, chatID string) (string, error)
+	// ResetSession archives the chat's conversation, clears its context and returns its ID, or "" if it had none
+	ResetSession(ctx context.Context, platform, chatID string) (string, error)
+}
+
+// Request is a message that may contain a command
+type Request struct {
+	Platform       string
+	UserID         string
+	ChatID         string
+	ConversationID string // Set when the client addresses a conversation directly
+	Message        string
+}
+
+// Invocation is a parsed command together with the chat it was sent from
+type Invocation struct {
+	Request
+	Name string
+	Args []string
+
+	router       *Router
+	conversation *model.Conversation
+}
+
+// Conversation returns the chat's current conversation, or nil if it has none
+func (inv *Invocation) Conversation(ctx context.Context) (*model.Conversation, error) {
+	if inv.conversation != nil {
+		return inv.conversation, nil
+	}
+
+	id := inv.ConversationID
+	if id == "" {
+		if inv.ChatID == "" {
+			return nil, nil
+		}
+		sess, err := inv.router.sessions.GetSession(ctx, inv.Platform, inv.ChatID)
+		if err != nil {
+			// GetSession reports a chat without conversations as an error
+			return nil, nil
+		}
+		id = sess.ConversationID
+	}
+
+	conv, err := inv.router.store.DescribeConversation(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	inv.conversation = conv
+	return conv, nil
+}
+
+// EnsureConversation returns the chat's current conversation, starting a new one if it has none
+// Lets settings such as /language be chosen before the first message
+func (inv *Invocation) EnsureConversation(ctx context.Context) (*model.Conversation, error) {
+	conv, err := inv.Conversation(ctx)
+	if err != nil || conv != nil {
+		return conv, err
+	}
+	if inv.ChatID == "" || inv.UserID == "" {
+		return nil, errors.New("no conversation to configure")
+	}
+
+	id, err := inv.router.sessions.StartSession(ctx, inv.Platform, inv.UserID, inv.ChatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start conversation: %w", err)
+	}
+	inv.ConversationID = id
+	return inv.Conversation(ctx)
+}
+
+// Command is a slash command handled without calling the model
+type Command struct {
+	Name        string // Without the leading slash
+	Usage       string // e.g. "/language <code|auto>"
+	Description string
+	Run         func(ctx context.Context, inv *Invocation) (string, error)
+}
+
+// Registry holds the commands available on each platform
+type Registry struct {
+	platforms map[string]map[string]*Command
+}
+
+// NewRegistry creates an empty command registry
+func NewRegistry() *Registry {
+	return &Registry{platforms: make(map[string]map[string]*Command)}
+}
+
+// Register makes a command available on the given platforms, replacing a command with the same name
+func (r *Registry) Register(platforms []string, cmd *Command) {
+	for _, platform := range platforms {
+		if r.platforms[platform] == nil {
+			r.platforms[platform] = make(map[string]*Command)
+		}
+		r.platforms[platform][strings.ToLower(cmd.Name)] = cmd
+	}
+}
+
+// Lookup returns the named command if it is registered for the platform
+func (r *Registry) Lookup(platform, name string) (*Command, bool) {
+	cmd, ok := r.platforms[platform][strings.ToLower(name)]
+	return cmd, ok
+}
+
+// Commands returns the commands registered for a platform, sorted by name
+func (r *Registry) Commands(platform string) []*Command {
+	cmds := make([]*Command, 0, len(r.platforms[platform]))
+	for _, cmd := range r.platforms[platform] {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// Enabled reports whether any command is registered for the platform
+func (r *Registry) Enabled(platform string) bool {
+	return len(r.platforms[platform]) > 0
+}
+
+// Parse splits a command message into its name and arguments
+// Bot platforms may address a command to a bot in group chats, e.g. "/help@my_bot"
+func Parse(message string) (name string, args []string, ok bool) {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, Prefix) {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(message, Prefix))
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+
+	name, _, _ = strings.Cut(fields[0], "@")
+	if name == "" || strings.Contains(name, Prefix) {
+		// "/usr/bin" and similar paths are not commands
+		return "", nil, false
+	}
+	return strings.ToLower(name), fields[1:], true
+}
+
+// Router intercepts command messages before they reach the model
+type Router struct {
+	registry *Registry
+	store    ConversationStore
+	sessions SessionStore
+}
+
+// NewRouter creates a command router
+func NewRouter(registry *Registry, store ConversationStore, sessions SessionStore) *Router {
+	return &Router{
+		registry: registry,
+		store:    store,
+		sessions: sessions,
+	}
+}
+
+// Handle runs the command in the message, if any
+// Returns handled=false when the message should be answered by the model instead
+func (r *Router) Handle(ctx context.Context, req Request) (string, bool, error) {
+	if !r.registry.Enabled(req.Platform) {
+		return "", false, nil
+	}
+
+	name, args, ok := Parse(req.Message)
+	if !ok {
+		return "", false, nil
+	}
+
+	cmd, ok := r.registry.Lookup(req.Platform, name)
+	if !ok {
+		return fmt.Sprintf("Unknown command %s%s. Send %shelp to see the available commands.", Prefix, name, Prefix), true, nil
+	}
+
+	inv := &Invocation{
+		Request: req,
+		Name:    name,
+		Args:    args,
+		router:  r,
+	}
+
+	reply, err := cmd.Run(ctx, inv)
+	if errors.Is(err, ErrUsage) {
+		return "Usage: " + cmd.Usage, true, nil
+	}
+	if err != nil {
+		return "", true, fmt.Errorf("command %s failed: %w", name, err)
+	}
+
+	slog.InfoContext(ctx, "Command handled",
+		"platform", req.Platform,
+		"command", name)
+
+	return reply, true, nil
+}
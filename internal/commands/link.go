@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/identity"
+)
+
+// Linker issues and redeems identity link codes, see identity.Service
+type Linker interface {
+	CreateLinkCode(ctx context.Context, issuer identity.Identity) (string, error)
+	RedeemLinkCode(ctx context.Context, code string, id identity.Identity) (identity.Identity, error)
+}
+
+// RegisterLink registers /link on the given platforms, so users can connect their accounts on different platforms
+func RegisterLink(registry *Registry, platforms []string, linker Linker) {
+	registry.Register(platforms, &Command{
+		Name:        "link",
+		Usage:       "/link [code]",
+		Description: "Connect your accounts on other platforms",
+		Run: func(ctx context.Context, inv *Invocation) (string, error) {
+			return runLink(ctx, inv, linker)
+		},
+	})
+}
+
+func runLink(ctx context.Context, inv *Invocation, linker Linker) (string, error) {
+	if len(inv.Args) > 1 {
+		return "", ErrUsage
+	}
+	if inv.UserID == "" {
+		return "", errors.New("linking needs a user ID")
+	}
+	id := identity.Identity{Platform: inv.Platform, UserID: inv.UserID}
+
+	if len(inv.Args) == 0 {
+		code, err := linker.CreateLinkCode(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Send /link %s from your other account within a few minutes to connect it to this one.", code), nil
+	}
+
+	_, err := linker.RedeemLinkCode(ctx, inv.Args[0], id)
+	switch {
+	case errors.Is(err, identity.ErrInvalidCode):
+		return "This link code is invalid or has expired. Send /link from your other account to get a new one.", nil
+	case errors.Is(err, identity.ErrAlreadyLinked):
+		return "This account is already connected to another one.", nil
+	case err != nil:
+		return "", err
+	}
+	return "Accounts connected. Your preferences and history now follow you on both.", nil
+}
@@ -26,20 +26,335 @@ type Config struct {
 	// API Security
 	APIKey string // API key for protecting sensitive endpoints
 
+	// Admin Authentication
+	AdminJWTSecret       string // Signs admin login tokens; empty disables logins and leaves only API_KEY
+	AdminTokenTTLMinutes int    // Lifetime of admin login tokens
+
+	// Request Signing (service-to-service callers)
+	RequestSigningKeys           map[string]string // Key ID -> HMAC secret; list several keys to rotate
+	RequestSigningRequired       bool              // Reject unsigned API requests
+	RequestSigningMaxSkewSeconds int               // Accepted clock skew for signed requests
+
+	// TLS (server)
+	TLSCertFile     string // Server certificate; enables HTTPS when set with TLSKeyFile
+	TLSKeyFile      string // Server private key
+	TLSClientCAFile string // CA bundle for client certificates; enables mutual TLS
+	TLSClientAuth   string // "require" or "verify_if_given"
+
+	// Outbound mTLS (internal tool services)
+	OutboundTLSCertFile string // Client certificate presented to internal services
+	OutboundTLSKeyFile  string // Client private key
+	OutboundTLSCAFile   string // CA bundle used to verify internal services
+
+	// Outbound Request Policy (SSRF protection for tool HTTP clients)
+	OutboundAllowedSchemes  []string // URL schemes tools may request
+	OutboundAllowedHosts    []string // If set, only these hosts and their subdomains may be reached
+	OutboundDeniedHosts     []string // Hosts and their subdomains that are never reached
+	OutboundPrivateNetworks []string // CIDRs of internal services that may be reached despite being non-public
+	OutboundMaxRedirects    int      // Redirects followed before a request fails
+
+	// OpenAI spend attribution (shown in the usage dashboard of the OpenAI account)
+	OpenAIOrganization string // Organization requests are billed to; empty uses the key's default
+	OpenAIProject      string // Project requests are billed to; empty uses the key's default
+	OpenAIUserTags     bool   // Send a hash of the conversation as the user of reply and title requests
+
+	// OpenAI first-token latency
+	OpenAIBaseURL          string // API endpoint, as read by the OpenAI client; connections to it are pre-warmed
+	OpenAIPrewarm          bool   // Open connections to the API at startup and keep them open between requests
+	OpenAIKeepAliveSeconds int    // How often warm connections are refreshed, below the API's idle timeout
+	OpenAIMaxIdleConns     int    // Connections to the API kept open between requests
+	OpenAIPromptCacheKey   bool   // Send a hash of the static prompt prefix so requests sharing it hit the same prompt cache
+
 	// Rate Limiting
-	APIRateLimitRPS   float64 // Requests per second
-	APIRateLimitBurst int     // Burst size
+	APIRateLimitRPS     float64 // Requests per second
+	APIRateLimitBurst   int     // Burst size
+	RateLimitMaxClients int     // Clients each rate limiter tracks at once; the least recently seen are forgotten beyond it
+
+	// Request Budgets (X-Request-Budget-Ms, see budget.Middleware)
+	RequestBudgetMaxMs     int // Budgets over this are lowered to it; 0 accepts any budget
+	RequestBudgetReserveMs int // Part of each budget kept back to store and send the response
+
+	// Idempotency (Idempotency-Key, see httpx.Idempotency)
+	IdempotencyTTLHours int // How long responses are replayed to requests repeating a key; 0 turns keys off
+
+	// Access rules (applied before rate limiting; clients are identified as in rate limiting, by X-Forwarded-For first)
+	AccessAllowCIDRs     []string // When set, only clients in these IP ranges are served
+	AccessDenyCIDRs      []string // Clients in these IP ranges are refused
+	AccessAllowCountries []string // ISO 3166 country codes; when set, only clients located there are served
+	AccessDenyCountries  []string // ISO 3166 country codes whose clients are refused
+	GeoIPDatabasePath    string   // MaxMind country or city database, required by country rules
+
+	// Bot mitigation (unsigned requests to the public API, such as those of web widgets)
+	BotChallenge          string   // Verification when conversations are started: off, pow, turnstile or hcaptcha
+	BotChallengeSecret    string   // Turnstile or hCaptcha secret key; signs proof-of-work challenges
+	BotPowDifficulty      int      // Leading zero bits proof-of-work solutions need
+	BotFingerprintRPS     float64  // Requests per second per client fingerprint, 0 disables
+	BotFingerprintBurst   int      // Burst size of the fingerprint throttle
+	BotHoneypotPaths      []string // Decoy paths; clients requesting them are refused API access
+	BotHoneypotBanMinutes int      // How long a honeypot visitor is refused
+
+	// CORS (browser clients)
+	CORSAllowedOrigins   []string // Origins allowed to call the API from a browser
+	CORSAllowedHeaders   []string // Request headers browsers may send
+	CORSAllowCredentials bool     // Whether browsers may send cookies and auth headers
+	CORSMaxAgeSeconds    int      // How long browsers may cache preflight responses
+
+	// Multi-tenancy
+	TenantIDs            []string          // Tenants served on the X-Tenant-ID header alone, for gateways that authenticate callers
+	TenantAccessKeys     map[string]string // Tenant ID -> key callers must send in X-Tenant-Key to act as the tenant
+	TenantRateLimitRPS   float64           // Requests per second per tenant (0 disables)
+	TenantRateLimitBurst int               // Burst size per tenant
+
+	// Tenant Credentials (tenants bringing their own API keys)
+	TenantCredentialsKey          string // Base64 32-byte master key encrypting stored tenant keys (empty disables)
+	TenantCredentialsCacheSeconds int    // How long decrypted tenant keys are cached in memory
+
+	// Tenant Encryption (conversation data keys per tenant, wrapped by a master key)
+	TenantEncryptionMasterKey    string // Base64 32-byte master key wrapping tenant data keys (empty disables)
+	TenantEncryptionCacheSeconds int    // How long unwrapped data keys and revocations are cached in memory
+
+	// Object Storage (billing exports, attachments)
+	ObjectStoreBackend           string // "local" or "s3"
+	ObjectStoreDir               string // Directory for the local backend
+	ObjectStoreS3Bucket          string
+	ObjectStoreS3Region          string
+	ObjectStoreS3Endpoint        string // Optional S3-compatible endpoint, e.g. MinIO
+	ObjectStoreS3AccessKeyID     string
+	ObjectStoreS3SecretAccessKey string
+
+	// Attachments
+	AttachmentMaxBytes     int64    // Largest accepted upload
+	AttachmentAllowedTypes []string // Accepted content types
+
+	// Billing Exports
+	BillingExportSchedule bool              // Export the previous day/month automatically
+	BillingModelPrices    map[string]string // Model -> "prompt/completion" USD per 1K tokens, overriding defaults
+
+	// Reply Post-processing
+	ReplyFilters      map[string]string // Platform -> "|"-separated filters in order: markdown, profanity, blocklist, links, length, decorate, language
+	ReplyMaxLengths   map[string]string // Platform -> maximum reply length in characters for the length filter
+	ProfanityWords    []string          // Words masked by the profanity filter
+	LinkRewriteParams map[string]string // Query parameters added to links; "{platform}" is replaced with the platform
+	LinkRewriteHosts  []string          // Hosts whose links are rewritten; empty rewrites all links
+	ReplyDecorations  string            // JSON of disclaimers and stripped claims per platform or platform/persona
+	TranslationModel  string            // Cheap model the language filter translates replies in the wrong language with
+
+	// Greetings
+	Greetings string // JSON of greeting templates per platform or platform/persona, sent before a conversation's first reply
+
+	// Slash Commands
+	CommandPlatforms []string // Platforms where messages starting with "/" are handled as commands
+	CommandPersonas  []string // Personas users may pick with /persona; empty allows any
+
+	// Identity Linking
+	IdentityLinkingEnabled     bool // Key settings, quotas and exports by the person behind linked platform identities
+	IdentityLinkCodeTTLMinutes int  // How long a /link code can be redeemed
+
+	// Conversation Concurrency
+	ConversationConcurrency map[string]string // Platform -> what a message does while a reply is in flight: queue, merge, reject or off
+
+	// Outbound Message Delivery
+	DeliveryQueueSize              int // Messages waiting to be sent before new ones are rejected
+	DeliveryBreakerMaxFailures     int // Consecutive send failures that pause a channel
+	DeliveryBreakerCooldownSeconds int // How long a paused channel waits before trying again
+
+	// Async Inbox
+	InboxEnabled          bool   // Serve bot webhooks that queue messages in a Redis Stream answered by background workers
+	InboxWorkers          int    // Messages answered at the same time by each instance
+	InboxMaxLength        int    // Messages kept in the stream; older answered ones are trimmed
+	InboxMaxDeliveries    int    // Attempts before a message is moved to the dead letters
+	InboxClaimIdleSeconds int    // How long a message may stay unanswered before another worker takes it over
+	TelegramWebhookSecret string // secret_token set with Telegram's setWebhook; required by POST /webhooks/telegram
+
+	// Background Jobs
+	JobWorkers            int // Jobs run at the same time by each instance; 0 disables the workers
+	JobMaxAttempts        int // Attempts before a failed job is moved to the dead letters
+	JobBackoffSeconds     int // Delay before the first retry, doubled on each further retry
+	JobMaxBackoffSeconds  int // Upper bound for retry delays
+	JobTimeoutSeconds     int // How long a job may run
+	JobDeadLetterLimit    int // Failed jobs kept for inspection
+	JobPollIntervalMillis int // How often idle workers look for due jobs
+
+	// Scheduled Maintenance Tasks
+	CronEnabled          bool   // Run recurring tasks on the instance holding the Redis lease
+	CronJitterSeconds    int    // Random delay added to each scheduled run
+	CronLeaderTTLSeconds int    // How long a lost leader keeps the lease before another instance takes over
+	CronHistoryLimit     int    // Runs kept per task for the admin API
+	CronBillingExport    string // Schedule of the billing export, enabled by BillingExportSchedule
+	CronPromptWarmup     string // Schedule of the prompt cache warm-up; empty disables it
+	CronColdStorage      string // Schedule of the cold-storage archive, enabled by ColdStorageInactiveDays
+	CronUsageStats       string // Schedule of the usage statistics refresh; empty disables it
+	CronPromptRollout    string // Schedule of the prompt rollout evaluation; empty stops rollouts advancing and rolling back
+	CronQualityScores    string // Schedule of the conversation quality scoring, enabled by QualitySampleSize
+	CronSchemaUpgrade    string // Schedule of storing conversations of older schema versions upgraded; empty disables it
+	CronFollowUps        string // Schedule of the follow-ups of idle conversations, enabled by FollowUpPlatforms
+
+	// Index Migrations (built in the background by the instance holding the Redis lease)
+	MigrationsEnabled         bool // Build missing indexes and keep the features relying on them off until they are built
+	MigrationsIntervalSeconds int  // How often build states and progress are refreshed
+	MigrationsMaxAttempts     int  // Failed builds are retried this many times before an operator must retry them
+
+	// Cold Storage
+	ColdStorageInactiveDays int // Conversations inactive this long move to object storage, leaving stubs; 0 disables archiving
+	ColdStorageBatchSize    int // Conversations archived per page
+
+	// Follow-ups (re-engaging users of idle conversations on their platform)
+	FollowUpPlatforms    map[string]string // Platforms that opted in -> minimum hours between follow-ups of a conversation; empty disables follow-ups
+	FollowUpIdleHours    int               // Conversations idle this long get a follow-up
+	FollowUpMaxIdleHours int               // Conversations idle longer are left alone
+	FollowUpModel        string            // Cheap model that writes follow-ups
+	FollowUpMaxTokens    int               // Token budget of each follow-up
+
+	// Usage Statistics
+	UsageStatsBackfillDays int // History materialized by the first usage statistics refresh
+
+	// Bulk Conversation Operations
+	BulkBatchSize int // Conversations modified per batch; progress is saved after each batch
+	BulkMaxJobs   int // Bulk jobs allowed to run at the same time
+
+	// Conversation Replay (debugging)
+	ReplayRecordingEnabled bool // Record the LLM exchanges and tool calls of every reply; stores full prompts
+	ReplayRetentionDays    int  // How long recorded turns are kept
+
+	// LLM Fixtures (deterministic tests)
+	LLMFixturesMode     string // "off", "record" (capture live responses) or "replay" (serve captured responses only)
+	LLMFixturesDir      string // Directory holding one fixture file per scenario
+	LLMFixturesScenario string // Scenario whose fixture file is recorded or replayed
+
+	// User Data Exports
+	PublicBaseURL      string // Public address of the service, used in download links sent to users
+	TakeoutSigningKey  string // HMAC key for download links; exports are disabled when empty
+	TakeoutLinkTTLDays int    // How long download links stay valid
 
 	// Cache TTL
-	CacheTTLHours     int // Redis cache TTL in hours
-	SessionTTLMinutes int // Session TTL in minutes
+	CacheTTLHours                 int    // Redis cache TTL in hours
+	SessionTTLMinutes             int    // Session TTL in minutes
+	CacheKeySampleIntervalSeconds int    // How often Redis keys are counted per prefix for metrics; 0 disables
+	CacheCodec                    string // Encoding of new cache entries: "json", "gob" or "proto"
 
-	// Circuit Breaker
-	CircuitBreakerMaxFailures     int // Max failures before opening circuit
-	CircuitBreakerCooldownSeconds int // Cooldown period in seconds
+	// Circuit Breaker (degradation of OpenAI, WeatherAPI and Redis, see degradation.Manager)
+	CircuitBreakerMaxFailures     int    // Max failures before opening circuit
+	CircuitBreakerCooldownSeconds int    // Cooldown period in seconds
+	OpenAIFallbackModel           string // Model replies and titles switch to while OpenAI is failing; empty disables switching
 
 	// Context Management
-	MaxContextTokens int // Maximum tokens for conversation context
+	MaxContextTokens        int     // Maximum tokens for conversation context
+	MaxMessageTokens        int     // Longest user message accepted, in tokens; 0 disables the check
+	LongInputChunking       bool    // Answer longer messages from summaries of their chunks instead of rejecting them
+	LongInputChunkTokens    int     // Tokens per chunk of a long message
+	LongInputMaxChunks      int     // Most chunks summarized per message; longer messages are still rejected
+	ContextTTLHours         int     // Inactivity after which a conversation's context expires, independent of CacheTTLHours
+	ContextStorage          string  // Where contexts are kept: "redis", "memory" or "hybrid" (memory copies over Redis)
+	ContextMemoryEntries    int     // Contexts kept in memory per instance by the memory and hybrid storage
+	ContextMemoryTTLSeconds int     // How long the hybrid storage serves a local copy before reading Redis again
+	SummaryModel            string  // Cheaper model that summarizes messages dropped from the context
+	SummaryMaxTokens        int     // Token cap at which each streamed segment summary is cut off
+	SummaryTimeoutSeconds   int     // Deadline after which the partial summary is used
+	SummarySegmentMessages  int     // Messages per rolling summary segment
+	SummaryJudgeModel       string  // Cheap model that scores the faithfulness of sampled summaries
+	SummaryJudgeSampleRate  float64 // Share of new summaries scored, from 0 to 1; 0 disables scoring
+	SummaryJudgeMinScore    float64 // Score below which a summary is discarded for basic reduction
+	TokenCalibrationWeight  float64 // Weight of each response in the rolling estimate correction; 0 disables calibration
+	TokenCalibrationWarmup  int     // Responses observed before estimates are corrected
+
+	// History Windows (context limits per platform and persona, see chat.HistoryWindows)
+	HistoryWindows        map[string]string // Platform -> "<messages>/<tokens>", e.g. telegram:20/2000
+	PersonaHistoryWindows map[string]string // Persona -> "<messages>/<tokens>", taking precedence over the platform's
+
+	// Prompt Rollouts (defaults of rollouts started without their own, see rollout.Service)
+	PromptRolloutStages             []string // Percentages of new conversations given the candidate per stage, ending at 100
+	PromptRolloutStageMinutes       int      // How long a healthy stage runs before the next one
+	PromptRolloutMinReplies         int      // Candidate replies needed before the thresholds are checked
+	PromptRolloutMaxErrorRate       float64  // Share of failed candidate replies that rolls back; 0 disables the check
+	PromptRolloutMaxNegativeRate    float64  // Share of negative reactions that rolls back; 0 disables the check
+	PromptRolloutMaxLatencyIncrease float64  // Latency over the baseline's that rolls back, 0.5 for 50%; 0 disables the check
+
+	// Sentiment Tracking
+	SentimentEnabled        bool    // Classify sampled user messages in the background
+	SentimentModel          string  // Cheap model that classifies message sentiment
+	SentimentSampleRate     float64 // Share of user messages classified, from 0 to 1
+	SentimentAlertThreshold float64 // Moving average below which a conversation counts as negative
+
+	// Conversation Quality Scoring
+	QualitySampleSize int    // Conversations of each tenant scored per day; 0 disables scoring
+	QualityModel      string // Model that scores sampled conversations on the rubric
+
+	// Topic Classification
+	TopicsEnabled   bool    // Tag conversations with coarse topics in the background
+	TopicClassifier string  // "keywords" matches keyword rules, "model" asks TopicModel
+	TopicModel      string  // Cheap model that classifies topics in "model" mode
+	TopicSampleRate float64 // Share of user messages classified, from 0 to 1
+
+	// Vector Store
+	VectorStore              string // "hnsw" (in-process), "atlas" (MongoDB Atlas Vector Search) or "qdrant"
+	VectorDimensions         int    // Length of every stored embedding
+	VectorAtlasCollection    string // Collection of the atlas backend
+	VectorAtlasIndex         string // Atlas Vector Search index on the collection's vector field
+	QdrantURL                string // Base URL of the Qdrant REST API, e.g. http://qdrant:6333
+	QdrantAPIKey             string
+	QdrantCollection         string
+	VectorHNSWPath           string // Snapshot file of the hnsw backend; empty keeps the index in memory only
+	VectorHNSWM              int    // Neighbors per node of the hnsw backend; more improves recall and costs memory
+	VectorHNSWEfSearch       int    // Candidates considered per hnsw search; more improves recall and costs latency
+	VectorHNSWEfConstruction int    // Candidates considered per hnsw insert
+
+	// Embeddings
+	EmbeddingModel         string // Produces vectors of VectorDimensions length
+	EmbeddingBatchSize     int    // Maximum texts per OpenAI embedding request
+	EmbeddingCacheTTLHours int    // How long embeddings stay cached in Redis, keyed by content hash and model
+
+	// Title Generation
+	TitleGenerationMode       string // "sync" generates titles inline, "batch" defers them to a background worker, "concurrent" generates them alongside the reply
+	TitleBatchSize            int    // Maximum conversations per batched title request
+	TitleBatchIntervalSeconds int    // Maximum time a conversation waits before its batch is flushed
+
+	// URL Fetching (fetch_url tool)
+	FetchURLEnabled      bool     // Let the assistant read pages users link to
+	FetchURLAllowedHosts []string // If set, only these hosts and their subdomains may be fetched
+	FetchURLDeniedHosts  []string // Hosts and their subdomains that are never fetched
+	FetchURLMaxBytes     int      // Maximum page size downloaded
+	FetchURLMaxChars     int      // Maximum characters of page text passed to the model
+
+	// Answer Grounding
+	StrictFactsPlatforms []string // Platforms or platform/persona pairs where weather/date/holiday answers must come from tool calls ("all" for every platform)
+
+	// Warm Path (small talk answered without tool schemas, see smalltalk.Classifier)
+	WarmPathMaxChars int // Longest message that can count as small talk; 0 sends tools with every message
+
+	// Prompt Injection Detection
+	InjectionMode            string // "flag" (default), "strip" or "off" for instructions found in tool outputs
+	InjectionClassifierModel string // Model that screens content the heuristics pass; empty uses heuristics only
+
+	// Abuse Control
+	AbuseStrikeThreshold     int // Strikes within the window that block a user
+	AbuseStrikeWindowMinutes int // Window strikes are counted in
+	AbuseRequestsPerMinute   int // Per-user request rate that records a strike (0 disables)
+	AbuseBaseBlockMinutes    int // Duration of a first block, doubled on each repeat
+	AbuseMaxBlockHours       int // Upper bound for escalated blocks
+
+	// Tenant Blocklists (per-tenant lists are edited through the admin API, see blocklist.Service)
+	BlocklistCategories []string // Categories blocked for every tenant unless its allowlist lifts them
+
+	// Message Rate Limits (runaway bots; independent of the per-IP and per-user limits)
+	ConversationMessagesPerMinute int // Messages one conversation accepts per minute (0 disables)
+	SessionMessagesPerMinute      int // Messages one platform chat session accepts per minute (0 disables)
+
+	// Conversation Cost Caps (cumulative reply spend per conversation, see costcap.Policy)
+	ConversationTokenCap   int               // Reply tokens a conversation may use; 0 is unlimited
+	ConversationCostCapUSD float64           // Estimated reply cost in USD a conversation may reach; 0 is unlimited
+	PersonaCostCaps        map[string]string // Persona -> "<tokens>/<usd>", taking precedence over the defaults
+	CostCapAction          string            // "downgrade" to CostCapModel or "refuse" with a polite message
+	CostCapModel           string            // Cheap model replies of capped conversations use
+
+	// Data Residency (tenants whose conversations, settings and cached context must stay in a region)
+	StorageProfiles       map[string]StorageProfile // Named Mongo/Redis connections, from STORAGE_PROFILES
+	TenantStorageProfiles map[string]string         // Tenant -> storage profile; other tenants use MONGO_URI and REDIS_ADDR
+}
+
+// StorageProfile is a named pair of Mongo and Redis connections, e.g. in the EU region
+type StorageProfile struct {
+	MongoURI  string
+	RedisAddr string
 }
 
 // Load loads configuration from environment variables and .env file
@@ -65,20 +380,329 @@ func Load() *Config {
 		// API Security
 		APIKey: getEnv("API_KEY", ""),
 
+		// Admin Authentication
+		AdminJWTSecret:       getEnv("ADMIN_JWT_SECRET", ""),
+		AdminTokenTTLMinutes: getEnvInt("ADMIN_TOKEN_TTL_MINUTES", 60),
+
+		// Request Signing (service-to-service callers)
+		RequestSigningKeys:           getEnvMap("REQUEST_SIGNING_KEYS"),
+		RequestSigningRequired:       getEnvBool("REQUEST_SIGNING_REQUIRED", false),
+		RequestSigningMaxSkewSeconds: getEnvInt("REQUEST_SIGNING_MAX_SKEW_SECONDS", 300),
+
+		// TLS (server)
+		TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile: getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:   getEnv("TLS_CLIENT_AUTH", "require"),
+
+		// Outbound mTLS (internal tool services)
+		OutboundTLSCertFile: getEnv("OUTBOUND_TLS_CERT_FILE", ""),
+		OutboundTLSKeyFile:  getEnv("OUTBOUND_TLS_KEY_FILE", ""),
+		OutboundTLSCAFile:   getEnv("OUTBOUND_TLS_CA_FILE", ""),
+
+		// Outbound Request Policy (SSRF protection for tool HTTP clients)
+		OutboundAllowedSchemes:  getEnvList("OUTBOUND_ALLOWED_SCHEMES", []string{"http", "https"}),
+		OutboundAllowedHosts:    getEnvList("OUTBOUND_ALLOWED_HOSTS", nil),
+		OutboundDeniedHosts:     getEnvList("OUTBOUND_DENIED_HOSTS", nil),
+		OutboundPrivateNetworks: getEnvList("OUTBOUND_PRIVATE_NETWORKS", nil),
+		OutboundMaxRedirects:    getEnvInt("OUTBOUND_MAX_REDIRECTS", 5),
+
+		// OpenAI spend attribution
+		OpenAIOrganization: getEnv("OPENAI_ORG_ID", ""),
+		OpenAIProject:      getEnv("OPENAI_PROJECT_ID", ""),
+		OpenAIUserTags:     getEnvBool("OPENAI_USER_TAGS", true),
+
+		// OpenAI first-token latency
+		OpenAIBaseURL:          getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1/"),
+		OpenAIPrewarm:          getEnvBool("OPENAI_PREWARM", true),
+		OpenAIKeepAliveSeconds: getEnvInt("OPENAI_KEEPALIVE_SECONDS", 30),
+		OpenAIMaxIdleConns:     getEnvInt("OPENAI_MAX_IDLE_CONNS", 16),
+		OpenAIPromptCacheKey:   getEnvBool("OPENAI_PROMPT_CACHE_KEY", true),
+
 		// Rate Limiting
-		APIRateLimitRPS:   getEnvFloat("API_RATE_LIMIT_RPS", 10.0),
-		APIRateLimitBurst: getEnvInt("API_RATE_LIMIT_BURST", 20),
+		APIRateLimitRPS:     getEnvFloat("API_RATE_LIMIT_RPS", 10.0),
+		APIRateLimitBurst:   getEnvInt("API_RATE_LIMIT_BURST", 20),
+		RateLimitMaxClients: getEnvInt("RATE_LIMIT_MAX_CLIENTS", 100000),
+
+		// Request Budgets
+		RequestBudgetMaxMs:     getEnvInt("REQUEST_BUDGET_MAX_MS", 120000),
+		RequestBudgetReserveMs: getEnvInt("REQUEST_BUDGET_RESERVE_MS", 300),
+
+		// Idempotency
+		IdempotencyTTLHours: getEnvInt("IDEMPOTENCY_TTL_HOURS", 24),
+
+		// Access rules
+		AccessAllowCIDRs:     getEnvList("ACCESS_ALLOW_CIDRS", nil),
+		AccessDenyCIDRs:      getEnvList("ACCESS_DENY_CIDRS", nil),
+		AccessAllowCountries: getEnvList("ACCESS_ALLOW_COUNTRIES", nil),
+		AccessDenyCountries:  getEnvList("ACCESS_DENY_COUNTRIES", nil),
+		GeoIPDatabasePath:    getEnv("GEOIP_DATABASE_PATH", ""),
+
+		// Bot mitigation
+		BotChallenge:          getEnv("BOT_CHALLENGE", "off"),
+		BotChallengeSecret:    getEnv("BOT_CHALLENGE_SECRET", ""),
+		BotPowDifficulty:      getEnvInt("BOT_POW_DIFFICULTY", 18),
+		BotFingerprintRPS:     getEnvFloat("BOT_FINGERPRINT_RPS", 0),
+		BotFingerprintBurst:   getEnvInt("BOT_FINGERPRINT_BURST", 10),
+		BotHoneypotPaths:      getEnvList("BOT_HONEYPOT_PATHS", nil),
+		BotHoneypotBanMinutes: getEnvInt("BOT_HONEYPOT_BAN_MINUTES", 60),
+
+		// CORS (browser clients)
+		CORSAllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS", nil),
+		CORSAllowedHeaders:   getEnvList("CORS_ALLOWED_HEADERS", []string{"Content-Type", "X-API-Key", "X-Tenant-ID", "X-Tenant-Key", "X-Bot-Solution", "X-Captcha-Token", "X-Request-Budget-Ms", "Idempotency-Key"}),
+		CORSAllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAgeSeconds:    getEnvInt("CORS_MAX_AGE_SECONDS", 600),
+
+		// Multi-tenancy
+		TenantIDs:            getEnvList("TENANT_IDS", nil),
+		TenantAccessKeys:     getEnvMap("TENANT_ACCESS_KEYS"),
+		TenantRateLimitRPS:   getEnvFloat("TENANT_RATE_LIMIT_RPS", 0),
+		TenantRateLimitBurst: getEnvInt("TENANT_RATE_LIMIT_BURST", 100),
+
+		// Tenant Credentials (tenants bringing their own API keys)
+		TenantCredentialsKey:          getEnv("TENANT_CREDENTIALS_KEY", ""),
+		TenantCredentialsCacheSeconds: getEnvInt("TENANT_CREDENTIALS_CACHE_SECONDS", 60),
+
+		// Tenant Encryption (conversation data keys per tenant)
+		TenantEncryptionMasterKey:    getEnv("TENANT_ENCRYPTION_MASTER_KEY", ""),
+		TenantEncryptionCacheSeconds: getEnvInt("TENANT_ENCRYPTION_CACHE_SECONDS", 60),
+
+		// Object Storage (billing exports, attachments)
+		ObjectStoreBackend:           getEnv("OBJECT_STORE_BACKEND", "local"),
+		ObjectStoreDir:               getEnv("OBJECT_STORE_DIR", "./data"),
+		ObjectStoreS3Bucket:          getEnv("OBJECT_STORE_S3_BUCKET", ""),
+		ObjectStoreS3Region:          getEnv("OBJECT_STORE_S3_REGION", "us-east-1"),
+		ObjectStoreS3Endpoint:        getEnv("OBJECT_STORE_S3_ENDPOINT", ""),
+		ObjectStoreS3AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+		ObjectStoreS3SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+
+		// Attachments
+		AttachmentMaxBytes:     int64(getEnvInt("ATTACHMENT_MAX_BYTES", 10<<20)),
+		AttachmentAllowedTypes: getEnvList("ATTACHMENT_ALLOWED_TYPES", nil),
+
+		// Billing Exports
+		BillingExportSchedule: getEnvBool("BILLING_EXPORT_SCHEDULE", false),
+		BillingModelPrices:    getEnvMap("BILLING_MODEL_PRICES"),
+
+		// Reply Post-processing
+		ReplyFilters:      getEnvMap("REPLY_FILTERS"),
+		ReplyMaxLengths:   getEnvMap("REPLY_MAX_LENGTHS"),
+		ProfanityWords:    getEnvList("PROFANITY_WORDS", nil),
+		LinkRewriteParams: getEnvMap("LINK_REWRITE_PARAMS"),
+		LinkRewriteHosts:  getEnvList("LINK_REWRITE_HOSTS", nil),
+		ReplyDecorations:  getEnv("REPLY_DECORATIONS", ""),
+		TranslationModel:  getEnv("TRANSLATION_MODEL", "gpt-4o-mini"),
+
+		// Greetings
+		Greetings: getEnv("GREETINGS", ""),
+
+		// Slash Commands
+		CommandPlatforms: getEnvList("COMMAND_PLATFORMS", []string{"telegram"}),
+		CommandPersonas:  getEnvList("COMMAND_PERSONAS", nil),
+
+		// Identity Linking
+		IdentityLinkingEnabled:     getEnvBool("IDENTITY_LINKING_ENABLED", true),
+		IdentityLinkCodeTTLMinutes: getEnvInt("IDENTITY_LINK_CODE_TTL_MINUTES", 10),
+
+		// Conversation Concurrency
+		ConversationConcurrency: getEnvMap("CONVERSATION_CONCURRENCY"),
+
+		// Outbound Message Delivery
+		DeliveryQueueSize:              getEnvInt("DELIVERY_QUEUE_SIZE", 1000),
+		DeliveryBreakerMaxFailures:     getEnvInt("DELIVERY_BREAKER_MAX_FAILURES", 5),
+		DeliveryBreakerCooldownSeconds: getEnvInt("DELIVERY_BREAKER_COOLDOWN_SECONDS", 30),
+
+		// Async Inbox
+		InboxEnabled:          getEnvBool("INBOX_ENABLED", false),
+		InboxWorkers:          getEnvInt("INBOX_WORKERS", 4),
+		InboxMaxLength:        getEnvInt("INBOX_MAX_LENGTH", 100000),
+		InboxMaxDeliveries:    getEnvInt("INBOX_MAX_DELIVERIES", 5),
+		InboxClaimIdleSeconds: getEnvInt("INBOX_CLAIM_IDLE_SECONDS", 120),
+		TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+
+		// Background Jobs
+		JobWorkers:            getEnvInt("JOB_WORKERS", 4),
+		JobMaxAttempts:        getEnvInt("JOB_MAX_ATTEMPTS", 5),
+		JobBackoffSeconds:     getEnvInt("JOB_BACKOFF_SECONDS", 10),
+		JobMaxBackoffSeconds:  getEnvInt("JOB_MAX_BACKOFF_SECONDS", 3600),
+		JobTimeoutSeconds:     getEnvInt("JOB_TIMEOUT_SECONDS", 300),
+		JobDeadLetterLimit:    getEnvInt("JOB_DEAD_LETTER_LIMIT", 1000),
+		JobPollIntervalMillis: getEnvInt("JOB_POLL_INTERVAL_MILLIS", 1000),
+
+		// Scheduled Maintenance Tasks
+		CronEnabled:          getEnvBool("CRON_ENABLED", true),
+		CronJitterSeconds:    getEnvInt("CRON_JITTER_SECONDS", 60),
+		CronLeaderTTLSeconds: getEnvInt("CRON_LEADER_TTL_SECONDS", 30),
+		CronHistoryLimit:     getEnvInt("CRON_HISTORY_LIMIT", 50),
+		CronBillingExport:    getEnv("CRON_BILLING_EXPORT", "5 0 * * *"),
+		CronPromptWarmup:     getEnv("CRON_PROMPT_WARMUP", "@every 1h"),
+		CronColdStorage:      getEnv("CRON_COLD_STORAGE", "30 3 * * *"),
+		CronUsageStats:       getEnv("CRON_USAGE_STATS", "@every 10m"),
+		CronPromptRollout:    getEnv("CRON_PROMPT_ROLLOUT", "@every 5m"),
+		CronQualityScores:    getEnv("CRON_QUALITY_SCORES", "0 2 * * *"),
+		CronSchemaUpgrade:    getEnv("CRON_SCHEMA_UPGRADE", "0 4 * * *"),
+		CronFollowUps:        getEnv("CRON_FOLLOW_UPS", "15 * * * *"),
+
+		// Index Migrations
+		MigrationsEnabled:         getEnvBool("MIGRATIONS_ENABLED", true),
+		MigrationsIntervalSeconds: getEnvInt("MIGRATIONS_INTERVAL_SECONDS", 10),
+		MigrationsMaxAttempts:     getEnvInt("MIGRATIONS_MAX_ATTEMPTS", 3),
+
+		// Cold Storage
+		ColdStorageInactiveDays: getEnvInt("COLD_STORAGE_INACTIVE_DAYS", 0),
+		ColdStorageBatchSize:    getEnvInt("COLD_STORAGE_BATCH_SIZE", 100),
+
+		// Follow-ups
+		FollowUpPlatforms:    getEnvMap("FOLLOW_UP_PLATFORMS"),
+		FollowUpIdleHours:    getEnvInt("FOLLOW_UP_IDLE_HOURS", 24),
+		FollowUpMaxIdleHours: getEnvInt("FOLLOW_UP_MAX_IDLE_HOURS", 72),
+		FollowUpModel:        getEnv("FOLLOW_UP_MODEL", "gpt-4o-mini"),
+		FollowUpMaxTokens:    getEnvInt("FOLLOW_UP_MAX_TOKENS", 120),
+
+		// Usage Statistics
+		UsageStatsBackfillDays: getEnvInt("USAGE_STATS_BACKFILL_DAYS", 30),
+
+		// Bulk Conversation Operations
+		BulkBatchSize: getEnvInt("BULK_BATCH_SIZE", 100),
+		BulkMaxJobs:   getEnvInt("BULK_MAX_JOBS", 2),
+
+		// Conversation Replay (debugging)
+		ReplayRecordingEnabled: getEnvBool("REPLAY_RECORDING_ENABLED", false),
+		ReplayRetentionDays:    getEnvInt("REPLAY_RETENTION_DAYS", 7),
+
+		// LLM Fixtures (deterministic tests)
+		LLMFixturesMode:     getEnv("LLM_FIXTURES_MODE", "off"),
+		LLMFixturesDir:      getEnv("LLM_FIXTURES_DIR", "tests/fixtures/llm"),
+		LLMFixturesScenario: getEnv("LLM_FIXTURES_SCENARIO", "default"),
+
+		// User Data Exports
+		PublicBaseURL:      getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+		TakeoutSigningKey:  getEnv("TAKEOUT_SIGNING_KEY", ""),
+		TakeoutLinkTTLDays: getEnvInt("TAKEOUT_LINK_TTL_DAYS", 7),
 
 		// Cache TTL
-		CacheTTLHours:     getEnvInt("CACHE_TTL_HOURS", 24),
-		SessionTTLMinutes: getEnvInt("SESSION_TTL_MINUTES", 30),
+		CacheTTLHours:                 getEnvInt("CACHE_TTL_HOURS", 24),
+		SessionTTLMinutes:             getEnvInt("SESSION_TTL_MINUTES", 30),
+		CacheKeySampleIntervalSeconds: getEnvInt("CACHE_KEY_SAMPLE_INTERVAL_SECONDS", 300),
+		CacheCodec:                    getEnv("CACHE_CODEC", "json"),
 
 		// Circuit Breaker
 		CircuitBreakerMaxFailures:     getEnvInt("CIRCUIT_BREAKER_MAX_FAILURES", 3),
 		CircuitBreakerCooldownSeconds: getEnvInt("CIRCUIT_BREAKER_COOLDOWN_SECONDS", 30),
+		OpenAIFallbackModel:           getEnv("OPENAI_FALLBACK_MODEL", "gpt-4o-mini"),
 
 		// Context Management
-		MaxContextTokens: getEnvInt("MAX_CONTEXT_TOKENS", 4000),
+		MaxContextTokens:        getEnvInt("MAX_CONTEXT_TOKENS", 4000),
+		MaxMessageTokens:        getEnvInt("MAX_MESSAGE_TOKENS", 3000),
+		LongInputChunking:       getEnvBool("LONG_INPUT_CHUNKING", false),
+		LongInputChunkTokens:    getEnvInt("LONG_INPUT_CHUNK_TOKENS", 2000),
+		LongInputMaxChunks:      getEnvInt("LONG_INPUT_MAX_CHUNKS", 10),
+		ContextTTLHours:         getEnvInt("CONTEXT_TTL_HOURS", 168),
+		ContextStorage:          getEnv("CONTEXT_STORAGE", "redis"),
+		ContextMemoryEntries:    getEnvInt("CONTEXT_MEMORY_MAX_ENTRIES", 10000),
+		ContextMemoryTTLSeconds: getEnvInt("CONTEXT_MEMORY_TTL_SECONDS", 60),
+		SummaryModel:            getEnv("SUMMARY_MODEL", "gpt-4o-mini"),
+		SummaryMaxTokens:        getEnvInt("SUMMARY_MAX_TOKENS", 300),
+		SummaryTimeoutSeconds:   getEnvInt("SUMMARY_TIMEOUT_SECONDS", 8),
+		SummarySegmentMessages:  getEnvInt("SUMMARY_SEGMENT_MESSAGES", 10),
+		SummaryJudgeModel:       getEnv("SUMMARY_JUDGE_MODEL", "gpt-4o-mini"),
+		SummaryJudgeSampleRate:  getEnvFloat("SUMMARY_JUDGE_SAMPLE_RATE", 0.1),
+		SummaryJudgeMinScore:    getEnvFloat("SUMMARY_JUDGE_MIN_SCORE", 0.5),
+		TokenCalibrationWeight:  getEnvFloat("TOKEN_CALIBRATION_WEIGHT", 0.1),
+		TokenCalibrationWarmup:  getEnvInt("TOKEN_CALIBRATION_WARMUP", 5),
+
+		// History Windows
+		HistoryWindows:        getEnvMap("HISTORY_WINDOWS"),
+		PersonaHistoryWindows: getEnvMap("PERSONA_HISTORY_WINDOWS"),
+
+		// Prompt Rollouts
+		PromptRolloutStages:             getEnvList("PROMPT_ROLLOUT_STAGES", []string{"5", "25", "50", "100"}),
+		PromptRolloutStageMinutes:       getEnvInt("PROMPT_ROLLOUT_STAGE_MINUTES", 60),
+		PromptRolloutMinReplies:         getEnvInt("PROMPT_ROLLOUT_MIN_REPLIES", 50),
+		PromptRolloutMaxErrorRate:       getEnvFloat("PROMPT_ROLLOUT_MAX_ERROR_RATE", 0.05),
+		PromptRolloutMaxNegativeRate:    getEnvFloat("PROMPT_ROLLOUT_MAX_NEGATIVE_RATE", 0.3),
+		PromptRolloutMaxLatencyIncrease: getEnvFloat("PROMPT_ROLLOUT_MAX_LATENCY_INCREASE", 0.5),
+
+		// Sentiment Tracking
+		SentimentEnabled:        getEnvBool("SENTIMENT_ENABLED", true),
+		SentimentModel:          getEnv("SENTIMENT_MODEL", "gpt-4o-mini"),
+		SentimentSampleRate:     getEnvFloat("SENTIMENT_SAMPLE_RATE", 0.2),
+		SentimentAlertThreshold: getEnvFloat("SENTIMENT_ALERT_THRESHOLD", -0.4),
+
+		// Conversation Quality Scoring
+		QualitySampleSize: getEnvInt("QUALITY_SAMPLE_SIZE", 50),
+		QualityModel:      getEnv("QUALITY_MODEL", "gpt-4o-mini"),
+
+		// Topic Classification
+		TopicsEnabled:   getEnvBool("TOPICS_ENABLED", true),
+		TopicClassifier: getEnv("TOPIC_CLASSIFIER", "keywords"),
+		TopicModel:      getEnv("TOPIC_MODEL", "gpt-4o-mini"),
+		TopicSampleRate: getEnvFloat("TOPIC_SAMPLE_RATE", 1),
+
+		// Vector Store
+		VectorStore:              getEnv("VECTOR_STORE", "hnsw"),
+		VectorDimensions:         getEnvInt("VECTOR_DIMENSIONS", 1536),
+		VectorAtlasCollection:    getEnv("VECTOR_ATLAS_COLLECTION", "vectors"),
+		VectorAtlasIndex:         getEnv("VECTOR_ATLAS_INDEX", "vector_index"),
+		QdrantURL:                getEnv("QDRANT_URL", ""),
+		QdrantAPIKey:             getEnv("QDRANT_API_KEY", ""),
+		QdrantCollection:         getEnv("QDRANT_COLLECTION", "acai_vectors"),
+		VectorHNSWPath:           getEnv("VECTOR_HNSW_PATH", ""),
+		VectorHNSWM:              getEnvInt("VECTOR_HNSW_M", 16),
+		VectorHNSWEfSearch:       getEnvInt("VECTOR_HNSW_EF_SEARCH", 64),
+		VectorHNSWEfConstruction: getEnvInt("VECTOR_HNSW_EF_CONSTRUCTION", 200),
+
+		// Embeddings
+		EmbeddingModel:         getEnv("EMBEDDING_MODEL", "text-embedding-3-small"),
+		EmbeddingBatchSize:     getEnvInt("EMBEDDING_BATCH_SIZE", 100),
+		EmbeddingCacheTTLHours: getEnvInt("EMBEDDING_CACHE_TTL_HOURS", 720),
+
+		// Title Generation
+		TitleGenerationMode:       getEnv("TITLE_GENERATION_MODE", "sync"),
+		TitleBatchSize:            getEnvInt("TITLE_BATCH_SIZE", 10),
+		TitleBatchIntervalSeconds: getEnvInt("TITLE_BATCH_INTERVAL_SECONDS", 5),
+
+		// URL Fetching (fetch_url tool)
+		FetchURLEnabled:      getEnvBool("FETCH_URL_ENABLED", true),
+		FetchURLAllowedHosts: getEnvList("FETCH_URL_ALLOWED_HOSTS", nil),
+		FetchURLDeniedHosts:  getEnvList("FETCH_URL_DENIED_HOSTS", nil),
+		FetchURLMaxBytes:     getEnvInt("FETCH_URL_MAX_BYTES", 2<<20),
+		FetchURLMaxChars:     getEnvInt("FETCH_URL_MAX_CHARS", 8000),
+
+		// Answer Grounding
+		StrictFactsPlatforms: getEnvList("STRICT_FACTS_PLATFORMS", nil),
+
+		// Warm Path
+		WarmPathMaxChars: getEnvInt("WARM_PATH_MAX_CHARS", 40),
+
+		// Prompt Injection Detection
+		InjectionMode:            getEnv("INJECTION_MODE", "flag"),
+		InjectionClassifierModel: getEnv("INJECTION_CLASSIFIER_MODEL", ""),
+
+		// Abuse Control
+		AbuseStrikeThreshold:     getEnvInt("ABUSE_STRIKE_THRESHOLD", 5),
+		AbuseStrikeWindowMinutes: getEnvInt("ABUSE_STRIKE_WINDOW_MINUTES", 60),
+		AbuseRequestsPerMinute:   getEnvInt("ABUSE_REQUESTS_PER_MINUTE", 30),
+		AbuseBaseBlockMinutes:    getEnvInt("ABUSE_BASE_BLOCK_MINUTES", 5),
+		AbuseMaxBlockHours:       getEnvInt("ABUSE_MAX_BLOCK_HOURS", 24),
+
+		// Tenant Blocklists
+		BlocklistCategories: getEnvList("BLOCKLIST_CATEGORIES", nil),
+
+		// Message Rate Limits
+		ConversationMessagesPerMinute: getEnvInt("CONVERSATION_MESSAGES_PER_MINUTE", 20),
+		SessionMessagesPerMinute:      getEnvInt("SESSION_MESSAGES_PER_MINUTE", 30),
+
+		// Conversation Cost Caps
+		ConversationTokenCap:   getEnvInt("CONVERSATION_TOKEN_CAP", 0),
+		ConversationCostCapUSD: getEnvFloat("CONVERSATION_COST_CAP_USD", 0),
+		PersonaCostCaps:        getEnvMap("PERSONA_COST_CAPS"),
+		CostCapAction:          getEnv("COST_CAP_ACTION", "downgrade"),
+		CostCapModel:           getEnv("COST_CAP_MODEL", "gpt-4o-mini"),
+
+		// Data Residency
+		StorageProfiles:       getStorageProfiles("STORAGE_PROFILES"),
+		TenantStorageProfiles: getEnvMap("TENANT_STORAGE_PROFILES"),
 	}
 
 	// Validate required configuration
@@ -122,6 +746,82 @@ func getEnvFloat(key string, fallback float64) float64 {
 	return fallback
 }
 
+// getEnvBool gets environment variable as boolean with fallback
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "1", "true", "yes", "on":
+			return true
+		case "0", "false", "no", "off":
+			return false
+		}
+		log.Printf("Warning: invalid boolean value for %s: %s, using default: %t", key, value, fallback)
+	}
+	return fallback
+}
+
+// getEnvList gets a comma-separated environment variable as a list with fallback
+func getEnvList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvMap gets a comma-separated list of key:value pairs as a map
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range getEnvList(key, nil) {
+		k, v, ok := strings.Cut(item, ":")
+		if !ok || strings.TrimSpace(k) == "" || strings.TrimSpace(v) == "" {
+			log.Printf("Warning: invalid key:value entry in %s, skipping", key)
+			continue
+		}
+		result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return result
+}
+
+// getStorageProfiles gets the profiles named in a comma-separated list, each from STORAGE_PROFILE_<NAME>_MONGO_URI
+// and STORAGE_PROFILE_<NAME>_REDIS_ADDR
+func getStorageProfiles(key string) map[string]StorageProfile {
+	profiles := make(map[string]StorageProfile)
+	for _, name := range getEnvList(key, nil) {
+		prefix := "STORAGE_PROFILE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		profiles[name] = StorageProfile{
+			MongoURI:  getEnv(prefix+"_MONGO_URI", ""),
+			RedisAddr: getEnv(prefix+"_REDIS_ADDR", ""),
+		}
+	}
+	return profiles
+}
+
+// ProfileMongoURIs returns the Mongo URI of every storage profile
+func (c *Config) ProfileMongoURIs() map[string]string {
+	uris := make(map[string]string, len(c.StorageProfiles))
+	for name, profile := range c.StorageProfiles {
+		uris[name] = profile.MongoURI
+	}
+	return uris
+}
+
+// ProfileRedisAddrs returns the Redis address of every storage profile
+func (c *Config) ProfileRedisAddrs() map[string]string {
+	addrs := make(map[string]string, len(c.StorageProfiles))
+	for name, profile := range c.StorageProfiles {
+		addrs[name] = profile.RedisAddr
+	}
+	return addrs
+}
+
 // SafeString returns a safe representation of the config for logging
 func (c *Config) SafeString() string {
 	return fmt.Sprintf(
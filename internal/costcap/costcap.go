@@ -0,0 +1,165 @@
+package costcap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+// Actions taken on the replies of a conversation over its cap
+const (
+	ActionDowngrade = "downgrade" // Reply with the cheap model
+	ActionRefuse    = "refuse"    // Reply with Refusal without calling the model
+)
+
+// Refusal is the reply of conversations over their cap when the action is ActionRefuse
+const Refusal = "This conversation has reached its usage limit. Please start a new conversation to continue."
+
+// Cap bounds the cumulative reply spend of a conversation; zero fields are unlimited
+type Cap struct {
+	Tokens  int64
+	CostUSD float64
+}
+
+// Exceeded reports whether a spend reached the cap
+func (c Cap) Exceeded(s Spend) bool {
+	return (c.Tokens > 0 && s.Tokens >= c.Tokens) || (c.CostUSD > 0 && s.CostUSD >= c.CostUSD)
+}
+
+// ParseCap parses a "<tokens>/<usd>" cap, e.g. "200000/1.5"; a zero leaves that limit off
+func ParseCap(spec string) (Cap, error) {
+	tokens, cost, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Cap{}, fmt.Errorf("cost cap %q is not <tokens>/<usd>", spec)
+	}
+	var c Cap
+	var err error
+	if c.Tokens, err = strconv.ParseInt(strings.TrimSpace(tokens), 10, 64); err != nil || c.Tokens < 0 {
+		return Cap{}, fmt.Errorf("cost cap %q: tokens must be a non-negative integer", spec)
+	}
+	if c.CostUSD, err = strconv.ParseFloat(strings.TrimSpace(cost), 64); err != nil || c.CostUSD < 0 {
+		return Cap{}, fmt.Errorf("cost cap %q: USD must be a non-negative number", spec)
+	}
+	return c, nil
+}
+
+// Spend is what the replies of a conversation took so far
+type Spend struct {
+	Tokens  int64
+	CostUSD float64
+}
+
+// Measure sums the tokens and estimated cost of a conversation's assistant replies
+// Replies by models without a price count their tokens but no cost
+func Measure(conv *model.Conversation, pricing billing.Pricing) Spend {
+	var s Spend
+	for _, m := range conv.Messages {
+		g := m.Generation
+		if m.Role != model.RoleAssistant || g == nil {
+			continue
+		}
+		s.Tokens += g.PromptTokens + g.CompletionTokens
+		if cost, ok := pricing.EstimateCost(g.Model, g.PromptTokens, g.CompletionTokens); ok {
+			s.CostUSD += cost
+		}
+	}
+	return s
+}
+
+// Config configures a Policy
+type Config struct {
+	Default  Cap               // Cap of every conversation
+	Personas map[string]string // Persona -> "<tokens>/<usd>", whose non-zero fields take precedence over Default
+	Action   string            // ActionDowngrade or ActionRefuse
+	Model    string            // Cheap model of downgraded conversations
+	Pricing  billing.Pricing   // Prices the spend of replies is estimated with
+}
+
+// Policy decides how conversations over their cap are answered
+// A nil *Policy caps nothing
+type Policy struct {
+	defaults Cap
+	personas map[string]Cap
+	action   string
+	model    string
+	pricing  billing.Pricing
+}
+
+// NewPolicy creates a policy; it returns nil when no cap is configured
+func NewPolicy(cfg Config) (*Policy, error) {
+	switch cfg.Action {
+	case ActionDowngrade:
+		if cfg.Model == "" {
+			return nil, fmt.Errorf("the %s action needs a model", ActionDowngrade)
+		}
+	case ActionRefuse:
+	default:
+		return nil, fmt.Errorf("unknown cost cap action %q, want %s or %s", cfg.Action, ActionDowngrade, ActionRefuse)
+	}
+
+	p := &Policy{
+		defaults: cfg.Default,
+		personas: make(map[string]Cap, len(cfg.Personas)),
+		action:   cfg.Action,
+		model:    cfg.Model,
+		pricing:  cfg.Pricing,
+	}
+	for persona, spec := range cfg.Personas {
+		c, err := ParseCap(spec)
+		if err != nil {
+			return nil, fmt.Errorf("persona %s: %w", persona, err)
+		}
+		p.personas[persona] = c
+	}
+	if p.defaults == (Cap{}) && len(p.personas) == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// Resolve returns the cap of a persona: its non-zero fields take precedence over the default cap
+func (p *Policy) Resolve(persona string) Cap {
+	if p == nil {
+		return Cap{}
+	}
+	c := p.defaults
+	if override, ok := p.personas[persona]; ok && persona != "" {
+		if override.Tokens > 0 {
+			c.Tokens = override.Tokens
+		}
+		if override.CostUSD > 0 {
+			c.CostUSD = override.CostUSD
+		}
+	}
+	return c
+}
+
+// Decision is how the next reply of a conversation over its cap is generated
+type Decision struct {
+	Action string // Empty while the conversation is within its cap
+	Model  string // Model of downgraded replies
+	Spend  Spend
+	Cap    Cap
+}
+
+// Check returns the decision for the next reply of a conversation
+func (p *Policy) Check(conv *model.Conversation) Decision {
+	if p == nil {
+		return Decision{}
+	}
+	c := p.Resolve(conv.Persona)
+	if c == (Cap{}) {
+		return Decision{}
+	}
+	d := Decision{Spend: Measure(conv, p.pricing), Cap: c}
+	if c.Exceeded(d.Spend) {
+		d.Action = p.action
+		if d.Action == ActionDowngrade {
+			d.Model = p.model
+		}
+	}
+	return d
+}
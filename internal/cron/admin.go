@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler exposes scheduled tasks and their run history over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	scheduler *Scheduler
+}
+
+// NewAdminHandler creates a new cron admin handler
+func NewAdminHandler(scheduler *Scheduler) *AdminHandler {
+	return &AdminHandler{scheduler: scheduler}
+}
+
+// ListHandler handles GET /admin/cron
+func (h *AdminHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"leader": h.scheduler.IsLeader(),
+		"tasks":  h.scheduler.Tasks(),
+	})
+}
+
+// RunsHandler handles GET /admin/cron/{task}/runs?limit=
+func (h *AdminHandler) RunsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	task := mux.Vars(r)["task"]
+	runs, err := h.scheduler.History(r.Context(), task, limit)
+	if errors.Is(err, ErrUnknownTask) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list task runs", "task", task, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list task runs"})
+		return
+	}
+	if runs == nil {
+		runs = []*Run{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"runs": runs})
+}
+
+// TriggerHandler handles POST /admin/cron/{task}/run, running the task on this instance and returning the run
+func (h *AdminHandler) TriggerHandler(w http.ResponseWriter, r *http.Request) {
+	task := mux.Vars(r)["task"]
+	run, err := h.scheduler.Trigger(r.Context(), task)
+	switch {
+	case errors.Is(err, ErrUnknownTask):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	case errors.Is(err, ErrRunning):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task is already running"})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to run task", "task", task, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to run task"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,306 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Run statuses
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	ErrUnknownTask = errors.New("unknown task")
+	ErrRunning     = errors.New("task is already running")
+)
+
+// Task is a recurring maintenance task
+type Task struct {
+	Name     string
+	Schedule string        // See ParseSchedule
+	Enabled  bool          // Disabled tasks are listed but only run when triggered manually
+	Jitter   time.Duration // Random delay added to each scheduled run so tasks due together do not start at once
+	Timeout  time.Duration // How long a run may take; defaults to an hour
+	Run      func(ctx context.Context) error
+}
+
+// Run is the record of a task run
+type Run struct {
+	Task       string    `json:"task"`
+	Trigger    string    `json:"trigger"`
+	Instance   string    `json:"instance"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// TaskStatus describes a registered task
+type TaskStatus struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Enabled  bool      `json:"enabled"`
+	Running  bool      `json:"running"`
+	NextRun  time.Time `json:"next_run,omitzero"`
+}
+
+// Leader tells whether this instance should run scheduled tasks, see redisx.Election
+type Leader interface {
+	IsLeader() bool
+}
+
+// History stores task runs, see RedisHistory
+type History interface {
+	// Add stores a run, keeping the latest limit runs of its task
+	Add(ctx context.Context, run *Run, limit int) error
+	// List returns the latest runs of a task, newest first
+	List(ctx context.Context, task string, limit int) ([]*Run, error)
+}
+
+// Recorder records task runs, see metrics.Metrics
+type Recorder interface {
+	RecordCronRun(ctx context.Context, task, status string, duration time.Duration)
+}
+
+// Config identifies the instance in run history and bounds the history
+type Config struct {
+	Instance     string
+	HistoryLimit int // Runs kept per task
+}
+
+type entry struct {
+	task     Task
+	schedule Schedule
+
+	mu      sync.Mutex
+	next    time.Time
+	running bool
+}
+
+// Scheduler runs recurring tasks on the leader instance and records every run
+type Scheduler struct {
+	leader   Leader
+	history  History
+	recorder Recorder
+	cfg      Config
+
+	mu    sync.RWMutex
+	tasks map[string]*entry
+}
+
+// NewScheduler creates a scheduler; tasks are added with Add before Run
+// A nil leader runs tasks on every instance; history and recorder may be nil
+func NewScheduler(leader Leader, history History, recorder Recorder, cfg Config) *Scheduler {
+	if cfg.HistoryLimit <= 0 {
+		cfg.HistoryLimit = 50
+	}
+	return &Scheduler{
+		leader:   leader,
+		history:  history,
+		recorder: recorder,
+		cfg:      cfg,
+		tasks:    make(map[string]*entry),
+	}
+}
+
+// Add registers a task, failing on an invalid schedule or a duplicate name
+func (s *Scheduler) Add(task Task) error {
+	schedule, err := ParseSchedule(task.Schedule)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", task.Name, err)
+	}
+	if task.Timeout <= 0 {
+		task.Timeout = time.Hour
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[task.Name]; ok {
+		return fmt.Errorf("task %s is already registered", task.Name)
+	}
+	s.tasks[task.Name] = &entry{task: task, schedule: schedule}
+	return nil
+}
+
+func (s *Scheduler) entry(name string) *entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tasks[name]
+}
+
+// Tasks lists the registered tasks by name
+func (s *Scheduler) Tasks() []TaskStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]TaskStatus, 0, len(s.tasks))
+	for _, e := range s.tasks {
+		e.mu.Lock()
+		statuses = append(statuses, TaskStatus{
+			Name:     e.task.Name,
+			Schedule: e.task.Schedule,
+			Enabled:  e.task.Enabled,
+			Running:  e.running,
+			NextRun:  e.next,
+		})
+		e.mu.Unlock()
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// IsLeader reports whether this instance runs the scheduled tasks
+func (s *Scheduler) IsLeader() bool {
+	return s.leader == nil || s.leader.IsLeader()
+}
+
+// History returns the latest runs of a task, newest first
+func (s *Scheduler) History(ctx context.Context, name string, limit int) ([]*Run, error) {
+	if s.entry(name) == nil {
+		return nil, ErrUnknownTask
+	}
+	if s.history == nil {
+		return nil, nil
+	}
+	if limit <= 0 || limit > s.cfg.HistoryLimit {
+		limit = s.cfg.HistoryLimit
+	}
+	return s.history.List(ctx, name, limit)
+}
+
+// Run schedules the enabled tasks until the context is cancelled
+// Runs that fall due while this instance is not the leader are skipped
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.RLock()
+	var wg sync.WaitGroup
+	for _, e := range s.tasks {
+		if !e.task.Enabled {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}()
+	}
+	count := len(s.tasks)
+	s.mu.RUnlock()
+
+	slog.InfoContext(ctx, "Cron scheduler started", "tasks", count, "instance", s.cfg.Instance)
+	wg.Wait()
+	slog.InfoContext(ctx, "Cron scheduler stopped")
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	for {
+		next := e.schedule.Next(time.Now())
+		if e.task.Jitter > 0 {
+			next = next.Add(rand.N(e.task.Jitter))
+		}
+		e.mu.Lock()
+		e.next = next
+		e.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !s.IsLeader() {
+			slog.DebugContext(ctx, "Not the leader, skipping scheduled task", "task", e.task.Name)
+			continue
+		}
+		if _, err := s.execute(ctx, e, TriggerSchedule); errors.Is(err, ErrRunning) {
+			slog.WarnContext(ctx, "Scheduled task is still running, skipping this run", "task", e.task.Name)
+		}
+	}
+}
+
+// Trigger runs a task now on this instance, whether or not it is enabled or the leader
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*Run, error) {
+	e := s.entry(name)
+	if e == nil {
+		return nil, ErrUnknownTask
+	}
+	return s.execute(ctx, e, TriggerManual)
+}
+
+// execute runs a task unless it is already running, and records the run
+func (s *Scheduler) execute(ctx context.Context, e *entry, trigger string) (*Run, error) {
+	e.mu.Lock()
+	if e.running {
+		e.mu.Unlock()
+		return nil, ErrRunning
+	}
+	e.running = true
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.running = false
+		e.mu.Unlock()
+	}()
+
+	run := &Run{
+		Task:      e.task.Name,
+		Trigger:   trigger,
+		Instance:  s.cfg.Instance,
+		StartedAt: time.Now(),
+	}
+	slog.InfoContext(ctx, "Running task", "task", run.Task, "trigger", trigger)
+
+	err := s.call(ctx, e.task)
+	run.FinishedAt = time.Now()
+	duration := run.FinishedAt.Sub(run.StartedAt)
+	run.DurationMS = duration.Milliseconds()
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+		slog.ErrorContext(ctx, "Task failed", "task", run.Task, "duration", duration, "error", err)
+	} else {
+		run.Status = StatusSucceeded
+		slog.InfoContext(ctx, "Task finished", "task", run.Task, "duration", duration)
+	}
+
+	// The run is recorded even when the scheduler is stopping
+	recordCtx := context.WithoutCancel(ctx)
+	if s.history != nil {
+		if err := s.history.Add(recordCtx, run, s.cfg.HistoryLimit); err != nil {
+			slog.WarnContext(ctx, "Failed to record task run", "task", run.Task, "error", err)
+		}
+	}
+	if s.recorder != nil {
+		s.recorder.RecordCronRun(recordCtx, run.Task, run.Status, duration)
+	}
+	return run, nil
+}
+
+// call runs a task with its timeout, turning a panic into an error
+func (s *Scheduler) call(ctx context.Context, task Task) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, task.Timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return task.Run(ctx)
+}
@@ -0,0 +1,57 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHistory keeps the latest runs of each task in a Redis list
+type RedisHistory struct {
+	client *redis.Client
+}
+
+// NewRedisHistory creates a new Redis-backed run history
+func NewRedisHistory(client *redis.Client) *RedisHistory {
+	return &RedisHistory{client: client}
+}
+
+func historyKey(task string) string {
+	return "cron:runs:" + task
+}
+
+// Add stores a run, keeping the latest limit runs of its task
+func (h *RedisHistory) Add(ctx context.Context, run *Run, limit int) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal run: %w", err)
+	}
+
+	pipe := h.client.TxPipeline()
+	pipe.LPush(ctx, historyKey(run.Task), data)
+	pipe.LTrim(ctx, historyKey(run.Task), 0, int64(limit)-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store run: %w", err)
+	}
+	return nil
+}
+
+// List returns the latest runs of a task, newest first
+func (h *RedisHistory) List(ctx context.Context, task string, limit int) ([]*Run, error) {
+	values, err := h.client.LRange(ctx, historyKey(task), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	runs := make([]*Run, 0, len(values))
+	for _, value := range values {
+		var run Run
+		if err := json.Unmarshal([]byte(value), &run); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal run: %w", err)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, nil
+}
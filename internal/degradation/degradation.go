@@ -0,0 +1,132 @@
+package degradation
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/circuitbreaker"
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+)
+
+// Dependency names an external service whose health changes how features behave
+type Dependency string
+
+// Dependencies tracked by the manager and the rule applied while each is failing
+const (
+	Weather Dependency = "weather" // Weather tools serve the last known data, flagged as stale
+	OpenAI  Dependency = "openai"  // Replies and titles switch to the fallback model
+	Redis   Dependency = "redis"   // Caches are bypassed and sessions are recovered from MongoDB
+)
+
+// Config configures the degradation manager
+type Config struct {
+	MaxFailures   int           // Consecutive failures that mark a dependency as degraded
+	Cooldown      time.Duration // How long a degraded dependency is avoided before it is tried again
+	FallbackModel string        // Model used while OpenAI is degraded; empty keeps the requested model
+}
+
+// ConfigFromAppConfig returns the degradation settings of the application configuration
+func ConfigFromAppConfig(cfg *config.Config) Config {
+	return Config{
+		MaxFailures:   cfg.CircuitBreakerMaxFailures,
+		Cooldown:      time.Duration(cfg.CircuitBreakerCooldownSeconds) * time.Second,
+		FallbackModel: cfg.OpenAIFallbackModel,
+	}
+}
+
+// Health is the circuit breaker of one dependency
+// Callers ask Available before calling the dependency and Report the outcome of calls they made;
+// a nil Health is always available, so features work unchanged without a manager
+type Health struct {
+	dependency Dependency
+	breaker    *circuitbreaker.CircuitBreaker
+
+	mu       sync.Mutex
+	degraded bool // Last state logged, so transitions are logged once
+}
+
+// Available reports whether the dependency should be called; a degraded dependency is tried again after the cooldown
+func (h *Health) Available() bool {
+	if h == nil {
+		return true
+	}
+	return h.breaker.Allow()
+}
+
+// Report records the outcome of a call; err should only be non-nil for failures of the dependency itself,
+// not for requests it rejected
+func (h *Health) Report(err error) {
+	if h == nil {
+		return
+	}
+	h.breaker.Record(err)
+
+	degraded := h.breaker.GetState() != circuitbreaker.StateClosed
+	h.mu.Lock()
+	changed := degraded != h.degraded
+	h.degraded = degraded
+	h.mu.Unlock()
+
+	switch {
+	case changed && degraded:
+		slog.Warn("Dependency degraded, switching to fallback behaviour", "dependency", h.dependency, "error", err)
+	case changed:
+		slog.Info("Dependency recovered, resuming normal behaviour", "dependency", h.dependency)
+	}
+}
+
+// Degraded reports whether the dependency is failing and its fallback behaviour applies
+func (h *Health) Degraded() bool {
+	return h != nil && h.breaker.GetState() != circuitbreaker.StateClosed
+}
+
+// Manager centralizes the health of dependencies and the rules deciding how features degrade
+type Manager struct {
+	cfg          Config
+	dependencies map[Dependency]*Health
+}
+
+// NewManager creates a degradation manager with every dependency healthy
+func NewManager(cfg Config) *Manager {
+	m := &Manager{cfg: cfg, dependencies: make(map[Dependency]*Health)}
+	for _, dep := range []Dependency{Weather, OpenAI, Redis} {
+		m.dependencies[dep] = &Health{
+			dependency: dep,
+			breaker: circuitbreaker.NewCircuitBreaker(circuitbreaker.Config{
+				MaxFailures:    cfg.MaxFailures,
+				CooldownPeriod: cfg.Cooldown,
+			}),
+		}
+	}
+	return m
+}
+
+var shared = sync.OnceValue(func() *Manager {
+	return NewManager(ConfigFromAppConfig(config.Load()))
+})
+
+// Shared returns the process-wide manager, so the assistant, tools and caches see the same dependency health
+func Shared() *Manager {
+	return shared()
+}
+
+// Health returns the health of a dependency; nil for a nil manager
+func (m *Manager) Health(dep Dependency) *Health {
+	if m == nil {
+		return nil
+	}
+	return m.dependencies[dep]
+}
+
+// ChatModel returns the model to call instead of primary, and whether it is the fallback
+// The fallback is used while OpenAI is degraded; once the cooldown has passed the primary model is tried again
+func (m *Manager) ChatModel(primary string) (string, bool) {
+	if m == nil || m.cfg.FallbackModel == "" || m.cfg.FallbackModel == primary {
+		return primary, false
+	}
+	if m.Health(OpenAI).Available() {
+		return primary, false
+	}
+	return m.cfg.FallbackModel, true
+}
@@ -0,0 +1,74 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler exposes delivery status over HTTP
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	service *Service
+}
+
+// NewAdminHandler creates a new delivery admin handler
+func NewAdminHandler(service *Service) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+// ListHandler handles GET /admin/deliveries?channel=&status=&recipient=&limit=
+func (h *AdminHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := Query{
+		Channel:   query.Get("channel"),
+		Status:    query.Get("status"),
+		Recipient: query.Get("recipient"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		q.Limit = n
+	}
+
+	messages, err := h.service.List(r.Context(), q)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list deliveries", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list deliveries"})
+		return
+	}
+	if messages == nil {
+		messages = []*Message{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"messages": messages})
+}
+
+// GetHandler handles GET /admin/deliveries/{message_id}
+func (h *AdminHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	m, err := h.service.Get(r.Context(), mux.Vars(r)["message_id"])
+	if errors.Is(err, ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "message not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get delivery", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to get delivery"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, m)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
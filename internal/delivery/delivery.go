@@ -0,0 +1,259 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/circuitbreaker"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delivery statuses
+// Channels without receipts stop at sent; delivered is only set by receipts reported through MarkDelivered
+const (
+	StatusQueued    = "queued"
+	StatusSent      = "sent"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+var (
+	// ErrUnknownChannel is returned when no sender is registered for a channel
+	ErrUnknownChannel = errors.New("unknown delivery channel")
+	// ErrNotFound is returned when a message does not exist in the context's tenant
+	ErrNotFound = errors.New("message not found")
+	// ErrBusy is returned when the outbound queue is full
+	ErrBusy = errors.New("delivery queue is full")
+)
+
+// Message is an outbound message pushed to a user on an external channel
+type Message struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	TenantID    string             `json:"tenant_id" bson:"tenant_id"`
+	Channel     string             `json:"channel" bson:"channel"`     // telegram, slack, whatsapp
+	Recipient   string             `json:"recipient" bson:"recipient"` // Chat or user ID on the channel
+	Text        string             `json:"text" bson:"text"`
+	Status      string             `json:"status" bson:"status"`
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	ExternalID  string             `json:"external_id,omitempty" bson:"external_id,omitempty"` // Message ID assigned by the channel
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	SentAt      time.Time          `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+	DeliveredAt time.Time          `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+}
+
+// Sender pushes messages to one external channel
+type Sender interface {
+	// Send returns the channel's ID for the sent message
+	// Errors carrying a StatusCode() of 5xx or 429, and network errors, are retried
+	Send(ctx context.Context, recipient, text string) (string, error)
+}
+
+// StatusError reports an unsuccessful HTTP response from a channel API
+type StatusError struct {
+	Channel string
+	Code    int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.Channel, e.Code)
+}
+
+// StatusCode makes channel errors classifiable by the retry package
+func (e *StatusError) StatusCode() int {
+	return e.Code
+}
+
+// Query selects messages for the admin API
+type Query struct {
+	Channel   string
+	Status    string
+	Recipient string
+	Limit     int
+}
+
+// Repository stores outbound messages and their status
+type Repository interface {
+	SaveMessage(ctx context.Context, m *Message) error
+	// GetMessage returns ErrNotFound unless the message belongs to the context's tenant
+	GetMessage(ctx context.Context, id string) (*Message, error)
+	// ListMessages returns the newest messages of the context's tenant matching the query
+	ListMessages(ctx context.Context, q Query) ([]*Message, error)
+	// MarkDelivered returns ErrNotFound when no sent message has the channel's ID
+	MarkDelivered(ctx context.Context, channel, externalID string, at time.Time) error
+}
+
+// Config controls retries and the outbound queue
+type Config struct {
+	Retry     retry.RetryConfig
+	Breaker   circuitbreaker.Config // Per channel, so one failing channel does not hold up the others
+	QueueSize int
+}
+
+type channel struct {
+	sender  Sender
+	breaker *circuitbreaker.CircuitBreaker
+}
+
+// Service queues outbound messages, sends them in the background and tracks their status
+type Service struct {
+	repo     Repository
+	cfg      Config
+	queue    chan *Message
+	mu       sync.RWMutex
+	channels map[string]*channel
+}
+
+// NewService creates a delivery service; senders are added with Register
+func NewService(repo Repository, cfg Config) *Service {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+
+	return &Service{
+		repo:     repo,
+		cfg:      cfg,
+		queue:    make(chan *Message, cfg.QueueSize),
+		channels: make(map[string]*channel),
+	}
+}
+
+// Register adds the sender for a channel
+func (s *Service) Register(name string, sender Sender) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels[name] = &channel{
+		sender:  sender,
+		breaker: circuitbreaker.NewCircuitBreaker(s.cfg.Breaker),
+	}
+}
+
+// Supports reports whether messages can be sent on the channel
+func (s *Service) Supports(name string) bool {
+	return s.channel(name) != nil
+}
+
+func (s *Service) channel(name string) *channel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.channels[name]
+}
+
+// Send queues a message for a recipient on a channel and returns its tracking record
+func (s *Service) Send(ctx context.Context, channel, recipient, text string) (*Message, error) {
+	if !s.Supports(channel) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	now := time.Now()
+	m := &Message{
+		ID:        primitive.NewObjectID(),
+		TenantID:  tenant.FromContext(ctx),
+		Channel:   channel,
+		Recipient: recipient,
+		Text:      text,
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.repo.SaveMessage(ctx, m); err != nil {
+		return nil, fmt.Errorf("failed to save message: %w", err)
+	}
+
+	// The worker gets its own copy; the caller keeps reading the returned record
+	queued := *m
+	select {
+	case s.queue <- &queued:
+	default:
+		s.finish(ctx, m, "", ErrBusy)
+		return nil, ErrBusy
+	}
+
+	return m, nil
+}
+
+// Get returns a message of the context's tenant
+func (s *Service) Get(ctx context.Context, id string) (*Message, error) {
+	return s.repo.GetMessage(ctx, id)
+}
+
+// List returns messages of the context's tenant, newest first
+func (s *Service) List(ctx context.Context, q Query) ([]*Message, error) {
+	if q.Limit <= 0 || q.Limit > 500 {
+		q.Limit = 100
+	}
+	return s.repo.ListMessages(ctx, q)
+}
+
+// MarkDelivered records a delivery receipt reported by a channel, e.g. from a status webhook
+func (s *Service) MarkDelivered(ctx context.Context, channel, externalID string) error {
+	return s.repo.MarkDelivered(ctx, channel, externalID, time.Now())
+}
+
+// Run sends queued messages until the context is cancelled
+func (s *Service) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Delivery worker started")
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Delivery worker stopped", "queued", len(s.queue))
+			return
+		case m := <-s.queue:
+			s.deliver(tenant.WithTenant(ctx, m.TenantID), m)
+		}
+	}
+}
+
+func (s *Service) deliver(ctx context.Context, m *Message) {
+	ch := s.channel(m.Channel)
+	if ch == nil {
+		s.finish(ctx, m, "", fmt.Errorf("%w: %s", ErrUnknownChannel, m.Channel))
+		return
+	}
+
+	externalID, err := retry.RetryWithResult(ctx, s.cfg.Retry, func() (string, error) {
+		m.Attempts++
+		var id string
+		err := ch.breaker.Execute(func() error {
+			var err error
+			id, err = ch.sender.Send(ctx, m.Recipient, m.Text)
+			return err
+		})
+		return id, err
+	})
+
+	s.finish(ctx, m, externalID, err)
+}
+
+func (s *Service) finish(ctx context.Context, m *Message, externalID string, err error) {
+	m.UpdatedAt = time.Now()
+	if err != nil {
+		m.Status = StatusFailed
+		m.Error = err.Error()
+		slog.WarnContext(ctx, "Message delivery failed",
+			"message_id", m.ID.Hex(),
+			"channel", m.Channel,
+			"attempts", m.Attempts,
+			"error", err)
+	} else {
+		m.Status = StatusSent
+		m.ExternalID = externalID
+		m.SentAt = m.UpdatedAt
+		slog.InfoContext(ctx, "Message sent",
+			"message_id", m.ID.Hex(),
+			"channel", m.Channel,
+			"attempts", m.Attempts)
+	}
+
+	if err := s.repo.SaveMessage(ctx, m); err != nil {
+		slog.ErrorContext(ctx, "Failed to save delivery status", "message_id", m.ID.Hex(), "error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const messageCollection = "deliveries"
+
+// MongoRepository stores outbound messages in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB delivery repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+func (r *MongoRepository) SaveMessage(ctx context.Context, m *Message) error {
+	_, err := r.conn.Collection(messageCollection).ReplaceOne(ctx,
+		bson.M{"_id": m.ID}, m, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoRepository) GetMessage(ctx context.Context, id string) (*Message, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var m Message
+	err = r.conn.Collection(messageCollection).FindOne(ctx, bson.M{
+		"_id":       oid,
+		"tenant_id": tenant.FromContext(ctx),
+	}).Decode(&m)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *MongoRepository) ListMessages(ctx context.Context, q Query) ([]*Message, error) {
+	filter := bson.M{"tenant_id": tenant.FromContext(ctx)}
+	if q.Channel != "" {
+		filter["channel"] = q.Channel
+	}
+	if q.Status != "" {
+		filter["status"] = q.Status
+	}
+	if q.Recipient != "" {
+		filter["recipient"] = q.Recipient
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(q.Limit))
+
+	cursor, err := r.conn.Collection(messageCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var messages []*Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkDelivered is not tenant-scoped: receipts arrive from channel webhooks keyed by the channel's message ID
+func (r *MongoRepository) MarkDelivered(ctx context.Context, channel, externalID string, at time.Time) error {
+	res, err := r.conn.Collection(messageCollection).UpdateOne(ctx,
+		bson.M{"channel": channel, "external_id": externalID, "status": StatusSent},
+		bson.M{"$set": bson.M{"status": StatusDelivered, "delivered_at": at, "updated_at": at}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
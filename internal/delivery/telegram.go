@@ -0,0 +1,88 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const telegramAPI = "https://api.telegram.org"
+
+// TelegramSender sends messages through a Telegram bot
+type TelegramSender struct {
+	token  string
+	apiURL string
+	client *http.Client
+}
+
+// NewTelegramSender creates a sender for the given bot token
+// An empty apiURL uses the public Telegram Bot API
+func NewTelegramSender(token, apiURL string, client *http.Client) *TelegramSender {
+	if apiURL == "" {
+		apiURL = telegramAPI
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &TelegramSender{
+		token:  token,
+		apiURL: apiURL,
+		client: client,
+	}
+}
+
+// Send posts a message to a Telegram chat
+// Telegram has no read receipts for bots, so messages stay at sent once the API accepts them
+func (t *TelegramSender) Send(ctx context.Context, chatID, text string) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.token), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The URL carries the bot token, keep it out of errors and logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return "", fmt.Errorf("telegram request failed: %w", urlErr.Err)
+		}
+		return "", errors.New("telegram request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{Channel: "telegram", Code: resp.StatusCode}
+	}
+
+	var result struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			MessageID int64 `json:"message_id"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("invalid telegram response: %w", err)
+	}
+	if !result.OK {
+		return "", errors.New("telegram rejected the message")
+	}
+
+	return strconv.FormatInt(result.Result.MessageID, 10), nil
+}
@@ -9,7 +9,13 @@ const docTemplate = `{
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {},
+        "contact": {
+            "name": "API Support",
+            "url": "https://github.com/8adimka/Go_AI_Assistant"
+        },
+        "license": {
+            "name": "MIT"
+        },
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
@@ -49,7 +55,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.HealthResponse"
+                            "$ref": "#/definitions/docs.HealthResponse"
                         }
                     }
                 }
@@ -80,7 +86,7 @@ const docTemplate = `{
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.ErrorResponse"
                         }
                     }
                 }
@@ -100,13 +106,111 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.HealthResponse"
+                            "$ref": "#/definitions/docs.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/twirp/acai.chat.ChatService/AddReaction": {
+            "post": {
+                "description": "React to an assistant message with an emoji; replaces the user's previous reaction to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "React to a message",
+                "parameters": [
+                    {
+                        "description": "Add reaction request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.AddReactionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.AddReactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/twirp/acai.chat.ChatService/ClaimSession": {
+            "post": {
+                "description": "Transfer the conversations of an anonymous user to the user they logged in as; the anonymous chat's session continues in the authenticated chat and the anonymous user's settings fill unset preferences.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Claim an anonymous session",
+                "parameters": [
+                    {
+                        "description": "Claim session request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.ClaimSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ClaimSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     }
                 }
             }
         },
-        "/twirp/chat.ChatService/ContinueConversation": {
+        "/twirp/acai.chat.ChatService/ContinueConversation": {
             "post": {
                 "description": "Continue an existing conversation with the AI assistant. Supports both direct conversation_id and session-based conversations for stateless clients.",
                 "consumes": [
@@ -126,7 +230,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.ContinueConversationRequest"
+                            "$ref": "#/definitions/docs.ContinueConversationRequest"
                         }
                     }
                 ],
@@ -134,33 +238,69 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.ContinueConversationResponse"
+                            "$ref": "#/definitions/docs.ContinueConversationResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "403": {
+                        "description": "The user is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "408": {
+                        "description": "Timed out waiting for an earlier reply of the conversation",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "409": {
+                        "description": "An earlier reply of the conversation is still running or was superseded",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "412": {
+                        "description": "The conversation no longer fits the model's context",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "503": {
+                        "description": "The LLM provider is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     }
                 }
             }
         },
-        "/twirp/chat.ChatService/DescribeConversation": {
+        "/twirp/acai.chat.ChatService/DescribeConversation": {
             "post": {
-                "description": "Get detailed information about a specific conversation including all messages.",
+                "description": "Get detailed information about a specific conversation including all messages and its statistics.",
                 "consumes": [
                     "application/json"
                 ],
@@ -178,7 +318,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.DescribeConversationRequest"
+                            "$ref": "#/definitions/docs.DescribeConversationRequest"
                         }
                     }
                 ],
@@ -186,33 +326,33 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.DescribeConversationResponse"
+                            "$ref": "#/definitions/docs.DescribeConversationResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     }
                 }
             }
         },
-        "/twirp/chat.ChatService/ListConversations": {
+        "/twirp/acai.chat.ChatService/GetAttachment": {
             "post": {
-                "description": "Get list of recent conversations. Messages are excluded from the response to avoid large payloads.",
+                "description": "Download an attachment of a conversation with its content.",
                 "consumes": [
                     "application/json"
                 ],
@@ -220,28 +360,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "conversations"
+                    "attachments"
+                ],
+                "summary": "Download an attachment",
+                "parameters": [
+                    {
+                        "description": "Get attachment request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.GetAttachmentRequest"
+                        }
+                    }
                 ],
-                "summary": "List conversations",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.ListConversationsResponse"
+                            "$ref": "#/definitions/docs.GetAttachmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     }
                 }
             }
         },
-        "/twirp/chat.ChatService/StartConversation": {
+        "/twirp/acai.chat.ChatService/GetDataExport": {
             "post": {
-                "description": "Create a new conversation with the AI assistant. The assistant can answer questions, provide weather information, date/time, and holiday information.",
+                "description": "Check the status of a data export and get its download link.",
                 "consumes": [
                     "application/json"
                 ],
@@ -249,17 +418,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "conversations"
+                    "users"
                 ],
-                "summary": "Start a new conversation",
+                "summary": "Check a data export",
                 "parameters": [
                     {
-                        "description": "Start conversation request",
+                        "description": "Get data export request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.StartConversationRequest"
+                            "$ref": "#/definitions/docs.GetDataExportRequest"
                         }
                     }
                 ],
@@ -267,109 +436,797 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.StartConversationResponse"
+                            "$ref": "#/definitions/docs.GetDataExportResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/main.ErrorResponse"
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "main.ContinueConversationRequest": {
-            "type": "object",
-            "properties": {
-                "conversation_id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
-                },
-                "message": {
-                    "type": "string",
-                    "example": "What about tomorrow?"
-                },
-                "session_metadata": {
-                    "$ref": "#/definitions/main.SessionMetadata"
-                }
-            }
-        },
-        "main.ContinueConversationResponse": {
-            "type": "object",
-            "properties": {
-                "reply": {
-                    "type": "string",
-                    "example": "Tomorrow will be partly cloudy with 20°C..."
-                }
-            }
         },
-        "main.Conversation": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
-                },
-                "messages": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/main.Message"
+        "/twirp/acai.chat.ChatService/GetUserSettings": {
+            "post": {
+                "description": "Get the preferences of a platform user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user settings",
+                "parameters": [
+                    {
+                        "description": "Get user settings request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.GetUserSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.GetUserSettingsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
                     }
-                },
-                "timestamp": {
-                    "type": "string",
-                    "example": "2025-11-07T20:15:00Z"
-                },
-                "title": {
-                    "type": "string",
-                    "example": "Weather discussion"
                 }
             }
         },
-        "main.DescribeConversationRequest": {
-            "type": "object",
-            "properties": {
-                "conversation_id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
+        "/twirp/acai.chat.ChatService/ListConversations": {
+            "post": {
+                "description": "Get list of recent conversations. Messages are excluded from the response to avoid large payloads.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "List conversations",
+                "parameters": [
+                    {
+                        "description": "List conversations request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.ListConversationsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ListConversationsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
                 }
             }
         },
-        "main.DescribeConversationResponse": {
-            "type": "object",
-            "properties": {
-                "conversation": {
-                    "$ref": "#/definitions/main.Conversation"
-                }
-            }
+        "/twirp/acai.chat.ChatService/RequestDataExport": {
+            "post": {
+                "description": "Request an export of everything stored for a user; a download link is sent once it is ready.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Request a data export",
+                "parameters": [
+                    {
+                        "description": "Request data export request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.RequestDataExportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.RequestDataExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/twirp/acai.chat.ChatService/ResetSession": {
+            "post": {
+                "description": "End the current session of a chat: its conversation is archived and the next message starts a new one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Reset a chat session",
+                "parameters": [
+                    {
+                        "description": "Reset session request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.ResetSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.ResetSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/twirp/acai.chat.ChatService/SetConversationInstructions": {
+            "post": {
+                "description": "Set custom instructions the assistant follows in every reply of a conversation; empty clears them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Set conversation instructions",
+                "parameters": [
+                    {
+                        "description": "Set conversation instructions request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.SetConversationInstructionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.SetConversationInstructionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/twirp/acai.chat.ChatService/SetUserSettings": {
+            "post": {
+                "description": "Replace the preferences of a platform user; empty fields use the defaults.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set user settings",
+                "parameters": [
+                    {
+                        "description": "Set user settings request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.SetUserSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.SetUserSettingsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/twirp/acai.chat.ChatService/StartConversation": {
+            "post": {
+                "description": "Create a new conversation with the AI assistant. The assistant can answer questions, provide weather information, date/time, and holiday information.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conversations"
+                ],
+                "summary": "Start a new conversation",
+                "parameters": [
+                    {
+                        "description": "Start conversation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.StartConversationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.StartConversationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "403": {
+                        "description": "The user is blocked",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "412": {
+                        "description": "The conversation no longer fits the model's context",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "503": {
+                        "description": "The LLM provider is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
+                }
+            }
+        },
+        "/twirp/acai.chat.ChatService/UploadAttachment": {
+            "post": {
+                "description": "Upload a file to a conversation, optionally linking it to one of its messages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Upload an attachment",
+                "parameters": [
+                    {
+                        "description": "Upload attachment request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/docs.UploadAttachmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/docs.UploadAttachmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/docs.TwirpError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "docs.AddReactionRequest": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "emoji": {
+                    "type": "string",
+                    "example": "👍"
+                },
+                "message_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                },
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                }
+            }
+        },
+        "docs.AddReactionResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "$ref": "#/definitions/docs.Message"
+                }
+            }
+        },
+        "docs.Attachment": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "itinerary.pdf"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439013"
+                },
+                "message_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "size": {
+                    "type": "string",
+                    "format": "int64",
+                    "example": "48213"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-07T20:15:00Z"
+                }
+            }
+        },
+        "docs.ClaimSessionRequest": {
+            "type": "object",
+            "properties": {
+                "anonymous": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                },
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                }
+            }
+        },
+        "docs.ClaimSessionResponse": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "conversation_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "settings": {
+                    "$ref": "#/definitions/docs.UserSettings"
+                }
+            }
+        },
+        "docs.ContinueConversationRequest": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "What about tomorrow?"
+                },
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                },
+                "style": {
+                    "$ref": "#/definitions/docs.ReplyStyle"
+                }
+            }
+        },
+        "docs.ContinueConversationResponse": {
+            "type": "object",
+            "properties": {
+                "budget_exceeded": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "greeting": {
+                    "type": "string",
+                    "example": "Welcome back!"
+                },
+                "reply": {
+                    "type": "string",
+                    "example": "Tomorrow will be partly cloudy with 20°C..."
+                }
+            }
+        },
+        "docs.Conversation": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "instructions": {
+                    "type": "string",
+                    "example": "Answer in metric units"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/docs.Message"
+                    }
+                },
+                "sentiment_score": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "timestamp": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-07T20:15:00Z"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Weather discussion"
+                }
+            }
         },
-        "main.ErrorResponse": {
+        "docs.ConversationStats": {
             "type": "object",
             "properties": {
-                "code": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-07T20:15:00Z"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "es"
+                },
+                "last_activity_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-07T20:41:00Z"
+                },
+                "message_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "persona": {
+                    "type": "string",
+                    "example": "friendly"
+                },
+                "summarizations": {
                     "type": "integer",
-                    "example": 400
+                    "example": 1
+                },
+                "total_tokens": {
+                    "type": "string",
+                    "format": "int64",
+                    "example": "4821"
+                }
+            }
+        },
+        "docs.DataExport": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-07T20:15:00Z"
+                },
+                "download_url": {
+                    "type": "string",
+                    "example": "https://assistant.example.com/takeout/507f1f77bcf86cd799439014?sig=..."
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-14T20:15:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439014"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "ready",
+                        "failed"
+                    ],
+                    "example": "ready"
+                }
+            }
+        },
+        "docs.DescribeConversationRequest": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                }
+            }
+        },
+        "docs.DescribeConversationResponse": {
+            "type": "object",
+            "properties": {
+                "conversation": {
+                    "$ref": "#/definitions/docs.Conversation"
                 },
-                "details": {
+                "stats": {
+                    "$ref": "#/definitions/docs.ConversationStats"
+                }
+            }
+        },
+        "docs.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string",
-                    "example": "Missing required field: message"
+                    "example": "unauthorized"
                 },
                 "message": {
                     "type": "string",
-                    "example": "Bad Request"
+                    "example": "API key required"
+                }
+            }
+        },
+        "docs.GetAttachmentRequest": {
+            "type": "object",
+            "properties": {
+                "attachment_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439013"
+                },
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                }
+            }
+        },
+        "docs.GetAttachmentResponse": {
+            "type": "object",
+            "properties": {
+                "attachment": {
+                    "$ref": "#/definitions/docs.Attachment"
+                },
+                "content": {
+                    "type": "string",
+                    "format": "byte",
+                    "example": "cmVtZW1iZXIgdGhlIG1pbGs="
+                }
+            }
+        },
+        "docs.GetDataExportRequest": {
+            "type": "object",
+            "properties": {
+                "export_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439014"
+                },
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                }
+            }
+        },
+        "docs.GetDataExportResponse": {
+            "type": "object",
+            "properties": {
+                "export": {
+                    "$ref": "#/definitions/docs.DataExport"
                 }
             }
         },
-        "main.HealthResponse": {
+        "docs.GetUserSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                }
+            }
+        },
+        "docs.GetUserSettingsResponse": {
+            "type": "object",
+            "properties": {
+                "settings": {
+                    "$ref": "#/definitions/docs.UserSettings"
+                }
+            }
+        },
+        "docs.HealthResponse": {
             "type": "object",
             "properties": {
                 "checks": {
@@ -388,20 +1245,33 @@ const docTemplate = `{
                 }
             }
         },
-        "main.ListConversationsResponse": {
+        "docs.ListConversationsRequest": {
+            "type": "object"
+        },
+        "docs.ListConversationsResponse": {
             "type": "object",
             "properties": {
                 "conversations": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/main.Conversation"
+                        "$ref": "#/definitions/docs.Conversation"
                     }
                 }
             }
         },
-        "main.Message": {
+        "docs.Message": {
             "type": "object",
             "properties": {
+                "attachment_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "author": {
+                    "type": "string",
+                    "example": "support:alice"
+                },
                 "content": {
                     "type": "string",
                     "example": "What's the weather like?"
@@ -410,17 +1280,116 @@ const docTemplate = `{
                     "type": "string",
                     "example": "507f1f77bcf86cd799439012"
                 },
+                "reactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/docs.Reaction"
+                    }
+                },
                 "role": {
                     "type": "string",
-                    "example": "user"
+                    "enum": [
+                        "UNKNOWN",
+                        "USER",
+                        "ASSISTANT",
+                        "SYSTEM"
+                    ],
+                    "example": "USER"
+                },
+                "sentiment": {
+                    "type": "string",
+                    "enum": [
+                        "positive",
+                        "negative",
+                        "neutral"
+                    ],
+                    "example": "positive"
                 },
                 "timestamp": {
                     "type": "string",
+                    "format": "date-time",
                     "example": "2025-11-07T20:15:00Z"
                 }
             }
         },
-        "main.SessionMetadata": {
+        "docs.Reaction": {
+            "type": "object",
+            "properties": {
+                "emoji": {
+                    "type": "string",
+                    "example": "👍"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-07T20:16:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "12345"
+                }
+            }
+        },
+        "docs.ReplyStyle": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "bullets",
+                        "prose"
+                    ],
+                    "example": "prose"
+                },
+                "max_length": {
+                    "type": "integer",
+                    "example": 160
+                },
+                "reading_level": {
+                    "type": "string",
+                    "enum": [
+                        "simple",
+                        "standard",
+                        "expert"
+                    ],
+                    "example": "simple"
+                }
+            }
+        },
+        "docs.RequestDataExportRequest": {
+            "type": "object",
+            "properties": {
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                }
+            }
+        },
+        "docs.RequestDataExportResponse": {
+            "type": "object",
+            "properties": {
+                "export": {
+                    "$ref": "#/definitions/docs.DataExport"
+                }
+            }
+        },
+        "docs.ResetSessionRequest": {
+            "type": "object",
+            "properties": {
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                }
+            }
+        },
+        "docs.ResetSessionResponse": {
+            "type": "object",
+            "properties": {
+                "archived_conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                }
+            }
+        },
+        "docs.SessionMetadata": {
             "type": "object",
             "properties": {
                 "chat_id": {
@@ -437,7 +1406,51 @@ const docTemplate = `{
                 }
             }
         },
-        "main.StartConversationRequest": {
+        "docs.SetConversationInstructionsRequest": {
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "instructions": {
+                    "type": "string",
+                    "example": "Answer in metric units"
+                },
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                }
+            }
+        },
+        "docs.SetConversationInstructionsResponse": {
+            "type": "object",
+            "properties": {
+                "instructions": {
+                    "type": "string",
+                    "example": "Answer in metric units"
+                }
+            }
+        },
+        "docs.SetUserSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "session_metadata": {
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                },
+                "settings": {
+                    "$ref": "#/definitions/docs.UserSettings"
+                }
+            }
+        },
+        "docs.SetUserSettingsResponse": {
+            "type": "object",
+            "properties": {
+                "settings": {
+                    "$ref": "#/definitions/docs.UserSettings"
+                }
+            }
+        },
+        "docs.StartConversationRequest": {
             "type": "object",
             "properties": {
                 "message": {
@@ -445,17 +1458,28 @@ const docTemplate = `{
                     "example": "What's the weather in Barcelona?"
                 },
                 "session_metadata": {
-                    "$ref": "#/definitions/main.SessionMetadata"
+                    "$ref": "#/definitions/docs.SessionMetadata"
+                },
+                "style": {
+                    "$ref": "#/definitions/docs.ReplyStyle"
                 }
             }
         },
-        "main.StartConversationResponse": {
+        "docs.StartConversationResponse": {
             "type": "object",
             "properties": {
+                "budget_exceeded": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "conversation_id": {
                     "type": "string",
                     "example": "507f1f77bcf86cd799439011"
                 },
+                "greeting": {
+                    "type": "string",
+                    "example": "Hi! I can help with weather, dates and holidays."
+                },
                 "reply": {
                     "type": "string",
                     "example": "The weather in Barcelona is sunny with 22°C..."
@@ -465,18 +1489,113 @@ const docTemplate = `{
                     "example": "Weather in Barcelona"
                 }
             }
+        },
+        "docs.TwirpError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "invalid_argument"
+                },
+                "meta": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "msg": {
+                    "type": "string",
+                    "example": "message is required"
+                }
+            }
+        },
+        "docs.UploadAttachmentRequest": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "format": "byte",
+                    "example": "cmVtZW1iZXIgdGhlIG1pbGs="
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "text/plain"
+                },
+                "conversation_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "notes.txt"
+                },
+                "message_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                }
+            }
+        },
+        "docs.UploadAttachmentResponse": {
+            "type": "object",
+            "properties": {
+                "attachment": {
+                    "$ref": "#/definitions/docs.Attachment"
+                }
+            }
+        },
+        "docs.UserSettings": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "es"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Madrid"
+                },
+                "units": {
+                    "type": "string",
+                    "enum": [
+                        "metric",
+                        "imperial"
+                    ],
+                    "example": "metric"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-11-07T20:15:00Z"
+                },
+                "verbosity": {
+                    "type": "string",
+                    "enum": [
+                        "concise",
+                        "normal",
+                        "detailed"
+                    ],
+                    "example": "concise"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
-	Host:             "",
-	BasePath:         "",
+	Version:          "1.0",
+	Host:             "localhost:8080",
+	BasePath:         "/",
 	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Title:            "Go AI Assistant API",
+	Description:      "Production-ready AI assistant backend with modular tools, Redis caching, and comprehensive monitoring",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -13,10 +13,16 @@ package docs
 // @in header
 // @name X-API-Key
 
+// The types below describe the JSON of rpc/chat.proto as the Twirp server writes it: proto field names,
+// empty fields omitted, 64-bit integers as strings, bytes as base64 and enums by name
+// tests/contract checks that they match the proto messages and the server's responses; run `make docs`
+// after changing them
+
 // StartConversationRequest represents request to start a new conversation
 type StartConversationRequest struct {
 	Message         string           `json:"message" example:"What's the weather in Barcelona?"`
 	SessionMetadata *SessionMetadata `json:"session_metadata,omitempty"`
+	Style           *ReplyStyle      `json:"style,omitempty"`
 }
 
 // StartConversationResponse represents response from starting a conversation
@@ -24,6 +30,8 @@ type StartConversationResponse struct {
 	ConversationID string `json:"conversation_id" example:"507f1f77bcf86cd799439011"`
 	Title          string `json:"title" example:"Weather in Barcelona"`
 	Reply          string `json:"reply" example:"The weather in Barcelona is sunny with 22°C..."`
+	BudgetExceeded bool   `json:"budget_exceeded,omitempty" example:"false"`
+	Greeting       string `json:"greeting,omitempty" example:"Hi! I can help with weather, dates and holidays."`
 }
 
 // ContinueConversationRequest represents request to continue a conversation
@@ -31,13 +39,19 @@ type ContinueConversationRequest struct {
 	ConversationID  string           `json:"conversation_id,omitempty" example:"507f1f77bcf86cd799439011"`
 	Message         string           `json:"message" example:"What about tomorrow?"`
 	SessionMetadata *SessionMetadata `json:"session_metadata,omitempty"`
+	Style           *ReplyStyle      `json:"style,omitempty"`
 }
 
 // ContinueConversationResponse represents response from continuing a conversation
 type ContinueConversationResponse struct {
-	Reply string `json:"reply" example:"Tomorrow will be partly cloudy with 20°C..."`
+	Reply          string `json:"reply" example:"Tomorrow will be partly cloudy with 20°C..."`
+	BudgetExceeded bool   `json:"budget_exceeded,omitempty" example:"false"`
+	Greeting       string `json:"greeting,omitempty" example:"Welcome back!"`
 }
 
+// ListConversationsRequest represents request to list conversations
+type ListConversationsRequest struct{}
+
 // ListConversationsResponse represents response from listing conversations
 type ListConversationsResponse struct {
 	Conversations []Conversation `json:"conversations"`
@@ -50,7 +64,19 @@ type DescribeConversationRequest struct {
 
 // DescribeConversationResponse represents response from describing a conversation
 type DescribeConversationResponse struct {
-	Conversation Conversation `json:"conversation"`
+	Conversation Conversation       `json:"conversation"`
+	Stats        *ConversationStats `json:"stats,omitempty"`
+}
+
+// ConversationStats represents figures about a conversation for info panes
+type ConversationStats struct {
+	MessageCount   int32  `json:"message_count" example:"12"`
+	TotalTokens    string `json:"total_tokens" format:"int64" example:"4821"`
+	CreatedAt      string `json:"created_at" format:"date-time" example:"2025-11-07T20:15:00Z"`
+	LastActivityAt string `json:"last_activity_at" format:"date-time" example:"2025-11-07T20:41:00Z"`
+	Persona        string `json:"persona,omitempty" example:"friendly"`
+	Language       string `json:"language,omitempty" example:"es"`
+	Summarizations int32  `json:"summarizations,omitempty" example:"1"`
 }
 
 // SessionMetadata represents session information for stateless clients
@@ -60,20 +86,187 @@ type SessionMetadata struct {
 	ChatID   string `json:"chat_id" example:"67890"`
 }
 
+// ReplyStyle represents the shape of a single reply; unset fields leave the assistant's defaults
+type ReplyStyle struct {
+	MaxLength    int32  `json:"max_length,omitempty" example:"160"`
+	Format       string `json:"format,omitempty" enums:"bullets,prose" example:"prose"`
+	ReadingLevel string `json:"reading_level,omitempty" enums:"simple,standard,expert" example:"simple"`
+}
+
 // Conversation represents a conversation with the AI assistant
 type Conversation struct {
-	ID        string    `json:"id" example:"507f1f77bcf86cd799439011"`
-	Title     string    `json:"title" example:"Weather discussion"`
-	Timestamp string    `json:"timestamp" example:"2025-11-07T20:15:00Z"`
-	Messages  []Message `json:"messages,omitempty"`
+	ID             string    `json:"id" example:"507f1f77bcf86cd799439011"`
+	Title          string    `json:"title" example:"Weather discussion"`
+	Timestamp      string    `json:"timestamp" format:"date-time" example:"2025-11-07T20:15:00Z"`
+	Messages       []Message `json:"messages,omitempty"`
+	SentimentScore *float64  `json:"sentiment_score,omitempty" example:"0.4"`
+	Instructions   string    `json:"instructions,omitempty" example:"Answer in metric units"`
 }
 
 // Message represents a single message in a conversation
 type Message struct {
-	ID        string `json:"id" example:"507f1f77bcf86cd799439012"`
-	Role      string `json:"role" example:"user"`
-	Content   string `json:"content" example:"What's the weather like?"`
-	Timestamp string `json:"timestamp" example:"2025-11-07T20:15:00Z"`
+	ID            string     `json:"id" example:"507f1f77bcf86cd799439012"`
+	Role          string     `json:"role" enums:"UNKNOWN,USER,ASSISTANT,SYSTEM" example:"USER"`
+	Content       string     `json:"content" example:"What's the weather like?"`
+	Timestamp     string     `json:"timestamp" format:"date-time" example:"2025-11-07T20:15:00Z"`
+	AttachmentIDs []string   `json:"attachment_ids,omitempty"`
+	Reactions     []Reaction `json:"reactions,omitempty"`
+	Sentiment     string     `json:"sentiment,omitempty" enums:"positive,negative,neutral" example:"positive"`
+	Author        string     `json:"author,omitempty" example:"support:alice"`
+}
+
+// Reaction represents a user's emoji reaction to an assistant message
+type Reaction struct {
+	UserID    string `json:"user_id" example:"12345"`
+	Emoji     string `json:"emoji" example:"👍"`
+	Timestamp string `json:"timestamp" format:"date-time" example:"2025-11-07T20:16:00Z"`
+}
+
+// Attachment represents a file uploaded to a conversation
+type Attachment struct {
+	ID             string `json:"id" example:"507f1f77bcf86cd799439013"`
+	ConversationID string `json:"conversation_id" example:"507f1f77bcf86cd799439011"`
+	MessageID      string `json:"message_id,omitempty" example:"507f1f77bcf86cd799439012"`
+	Filename       string `json:"filename" example:"itinerary.pdf"`
+	ContentType    string `json:"content_type" example:"application/pdf"`
+	Size           string `json:"size" format:"int64" example:"48213"`
+	SHA256         string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Timestamp      string `json:"timestamp" format:"date-time" example:"2025-11-07T20:15:00Z"`
+}
+
+// UploadAttachmentRequest represents request to upload a file to a conversation
+type UploadAttachmentRequest struct {
+	ConversationID string `json:"conversation_id" example:"507f1f77bcf86cd799439011"`
+	MessageID      string `json:"message_id,omitempty" example:"507f1f77bcf86cd799439012"`
+	Filename       string `json:"filename" example:"notes.txt"`
+	ContentType    string `json:"content_type" example:"text/plain"`
+	Content        string `json:"content" format:"byte" example:"cmVtZW1iZXIgdGhlIG1pbGs="`
+}
+
+// UploadAttachmentResponse represents response from uploading a file
+type UploadAttachmentResponse struct {
+	Attachment Attachment `json:"attachment"`
+}
+
+// GetAttachmentRequest represents request to download an attachment
+type GetAttachmentRequest struct {
+	ConversationID string `json:"conversation_id" example:"507f1f77bcf86cd799439011"`
+	AttachmentID   string `json:"attachment_id" example:"507f1f77bcf86cd799439013"`
+}
+
+// GetAttachmentResponse represents response with an attachment and its content
+type GetAttachmentResponse struct {
+	Attachment Attachment `json:"attachment"`
+	Content    string     `json:"content" format:"byte" example:"cmVtZW1iZXIgdGhlIG1pbGs="`
+}
+
+// AddReactionRequest represents request to react to an assistant message
+type AddReactionRequest struct {
+	ConversationID  string           `json:"conversation_id" example:"507f1f77bcf86cd799439011"`
+	MessageID       string           `json:"message_id" example:"507f1f77bcf86cd799439012"`
+	Emoji           string           `json:"emoji" example:"👍"`
+	SessionMetadata *SessionMetadata `json:"session_metadata,omitempty"`
+}
+
+// AddReactionResponse represents the reacted message
+type AddReactionResponse struct {
+	Message Message `json:"message"`
+}
+
+// DataExport represents an export of everything stored for a user
+type DataExport struct {
+	ID          string `json:"id" example:"507f1f77bcf86cd799439014"`
+	Status      string `json:"status" enums:"pending,ready,failed" example:"ready"`
+	DownloadURL string `json:"download_url,omitempty" example:"https://assistant.example.com/takeout/507f1f77bcf86cd799439014?sig=..."`
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"created_at" format:"date-time" example:"2025-11-07T20:15:00Z"`
+	ExpiresAt   string `json:"expires_at,omitempty" format:"date-time" example:"2025-11-14T20:15:00Z"`
+}
+
+// RequestDataExportRequest represents request to export a user's data
+type RequestDataExportRequest struct {
+	SessionMetadata SessionMetadata `json:"session_metadata"`
+}
+
+// RequestDataExportResponse represents the queued export
+type RequestDataExportResponse struct {
+	Export DataExport `json:"export"`
+}
+
+// GetDataExportRequest represents request to check a data export
+type GetDataExportRequest struct {
+	ExportID        string          `json:"export_id" example:"507f1f77bcf86cd799439014"`
+	SessionMetadata SessionMetadata `json:"session_metadata"`
+}
+
+// GetDataExportResponse represents the export and its download link once ready
+type GetDataExportResponse struct {
+	Export DataExport `json:"export"`
+}
+
+// ResetSessionRequest represents request to end the current session of a chat
+type ResetSessionRequest struct {
+	SessionMetadata SessionMetadata `json:"session_metadata"`
+}
+
+// ResetSessionResponse represents the conversation archived by a reset
+type ResetSessionResponse struct {
+	ArchivedConversationID string `json:"archived_conversation_id,omitempty" example:"507f1f77bcf86cd799439011"`
+}
+
+// UserSettings represents the preferences of a platform user
+type UserSettings struct {
+	Language  string `json:"language,omitempty" example:"es"`
+	Units     string `json:"units,omitempty" enums:"metric,imperial" example:"metric"`
+	Timezone  string `json:"timezone,omitempty" example:"Europe/Madrid"`
+	Verbosity string `json:"verbosity,omitempty" enums:"concise,normal,detailed" example:"concise"`
+	UpdatedAt string `json:"updated_at,omitempty" format:"date-time" example:"2025-11-07T20:15:00Z"`
+}
+
+// GetUserSettingsRequest represents request to get a user's preferences
+type GetUserSettingsRequest struct {
+	SessionMetadata SessionMetadata `json:"session_metadata"`
+}
+
+// GetUserSettingsResponse represents a user's preferences
+type GetUserSettingsResponse struct {
+	Settings UserSettings `json:"settings"`
+}
+
+// SetUserSettingsRequest represents request to replace a user's preferences
+type SetUserSettingsRequest struct {
+	SessionMetadata SessionMetadata `json:"session_metadata"`
+	Settings        UserSettings    `json:"settings"`
+}
+
+// SetUserSettingsResponse represents the stored preferences
+type SetUserSettingsResponse struct {
+	Settings UserSettings `json:"settings"`
+}
+
+// SetConversationInstructionsRequest represents request to set custom instructions of a conversation
+type SetConversationInstructionsRequest struct {
+	ConversationID  string           `json:"conversation_id" example:"507f1f77bcf86cd799439011"`
+	Instructions    string           `json:"instructions" example:"Answer in metric units"`
+	SessionMetadata *SessionMetadata `json:"session_metadata,omitempty"`
+}
+
+// SetConversationInstructionsResponse represents the stored instructions
+type SetConversationInstructionsResponse struct {
+	Instructions string `json:"instructions,omitempty" example:"Answer in metric units"`
+}
+
+// ClaimSessionRequest represents request to transfer an anonymous user's conversations after login
+type ClaimSessionRequest struct {
+	Anonymous       SessionMetadata `json:"anonymous"`
+	SessionMetadata SessionMetadata `json:"session_metadata"`
+}
+
+// ClaimSessionResponse represents the transferred conversations and merged settings
+type ClaimSessionResponse struct {
+	ConversationIDs []string      `json:"conversation_ids,omitempty"`
+	ConversationID  string        `json:"conversation_id,omitempty" example:"507f1f77bcf86cd799439011"`
+	Settings        *UserSettings `json:"settings,omitempty"`
 }
 
 // @Summary Start a new conversation
@@ -83,9 +276,13 @@ type Message struct {
 // @Produce json
 // @Param request body StartConversationRequest true "Start conversation request"
 // @Success 200 {object} StartConversationResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
-// @Router /twirp/chat.ChatService/StartConversation [post]
+// @Failure 400 {object} TwirpError
+// @Failure 403 {object} TwirpError "The user is blocked"
+// @Failure 412 {object} TwirpError "The conversation no longer fits the model's context"
+// @Failure 429 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 503 {object} TwirpError "The LLM provider is unavailable"
+// @Router /twirp/acai.chat.ChatService/StartConversation [post]
 func _startConversation() {}
 
 // @Summary Continue an existing conversation
@@ -95,10 +292,16 @@ func _startConversation() {}
 // @Produce json
 // @Param request body ContinueConversationRequest true "Continue conversation request"
 // @Success 200 {object} ContinueConversationResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
-// @Router /twirp/chat.ChatService/ContinueConversation [post]
+// @Failure 400 {object} TwirpError
+// @Failure 403 {object} TwirpError "The user is blocked"
+// @Failure 404 {object} TwirpError
+// @Failure 408 {object} TwirpError "Timed out waiting for an earlier reply of the conversation"
+// @Failure 409 {object} TwirpError "An earlier reply of the conversation is still running or was superseded"
+// @Failure 412 {object} TwirpError "The conversation no longer fits the model's context"
+// @Failure 429 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 503 {object} TwirpError "The LLM provider is unavailable"
+// @Router /twirp/acai.chat.ChatService/ContinueConversation [post]
 func _continueConversation() {}
 
 // @Summary List conversations
@@ -106,24 +309,157 @@ func _continueConversation() {}
 // @Tags conversations
 // @Accept json
 // @Produce json
+// @Param request body ListConversationsRequest true "List conversations request"
 // @Success 200 {object} ListConversationsResponse
-// @Failure 500 {object} ErrorResponse
-// @Router /twirp/chat.ChatService/ListConversations [post]
+// @Failure 500 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/ListConversations [post]
 func _listConversations() {}
 
 // @Summary Get conversation details
-// @Description Get detailed information about a specific conversation including all messages.
+// @Description Get detailed information about a specific conversation including all messages and its statistics.
 // @Tags conversations
 // @Accept json
 // @Produce json
 // @Param request body DescribeConversationRequest true "Describe conversation request"
 // @Success 200 {object} DescribeConversationResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
-// @Router /twirp/chat.ChatService/DescribeConversation [post]
+// @Failure 400 {object} TwirpError
+// @Failure 404 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/DescribeConversation [post]
 func _describeConversation() {}
 
+// @Summary Upload an attachment
+// @Description Upload a file to a conversation, optionally linking it to one of its messages.
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param request body UploadAttachmentRequest true "Upload attachment request"
+// @Success 200 {object} UploadAttachmentResponse
+// @Failure 400 {object} TwirpError
+// @Failure 404 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 501 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/UploadAttachment [post]
+func _uploadAttachment() {}
+
+// @Summary Download an attachment
+// @Description Download an attachment of a conversation with its content.
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param request body GetAttachmentRequest true "Get attachment request"
+// @Success 200 {object} GetAttachmentResponse
+// @Failure 400 {object} TwirpError
+// @Failure 404 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 501 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/GetAttachment [post]
+func _getAttachment() {}
+
+// @Summary React to a message
+// @Description React to an assistant message with an emoji; replaces the user's previous reaction to it.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param request body AddReactionRequest true "Add reaction request"
+// @Success 200 {object} AddReactionResponse
+// @Failure 400 {object} TwirpError
+// @Failure 404 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/AddReaction [post]
+func _addReaction() {}
+
+// @Summary Request a data export
+// @Description Request an export of everything stored for a user; a download link is sent once it is ready.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body RequestDataExportRequest true "Request data export request"
+// @Success 200 {object} RequestDataExportResponse
+// @Failure 400 {object} TwirpError
+// @Failure 429 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 501 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/RequestDataExport [post]
+func _requestDataExport() {}
+
+// @Summary Check a data export
+// @Description Check the status of a data export and get its download link.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body GetDataExportRequest true "Get data export request"
+// @Success 200 {object} GetDataExportResponse
+// @Failure 400 {object} TwirpError
+// @Failure 404 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 501 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/GetDataExport [post]
+func _getDataExport() {}
+
+// @Summary Reset a chat session
+// @Description End the current session of a chat: its conversation is archived and the next message starts a new one.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param request body ResetSessionRequest true "Reset session request"
+// @Success 200 {object} ResetSessionResponse
+// @Failure 400 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/ResetSession [post]
+func _resetSession() {}
+
+// @Summary Get user settings
+// @Description Get the preferences of a platform user.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body GetUserSettingsRequest true "Get user settings request"
+// @Success 200 {object} GetUserSettingsResponse
+// @Failure 400 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 501 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/GetUserSettings [post]
+func _getUserSettings() {}
+
+// @Summary Set user settings
+// @Description Replace the preferences of a platform user; empty fields use the defaults.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body SetUserSettingsRequest true "Set user settings request"
+// @Success 200 {object} SetUserSettingsResponse
+// @Failure 400 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Failure 501 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/SetUserSettings [post]
+func _setUserSettings() {}
+
+// @Summary Set conversation instructions
+// @Description Set custom instructions the assistant follows in every reply of a conversation; empty clears them.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param request body SetConversationInstructionsRequest true "Set conversation instructions request"
+// @Success 200 {object} SetConversationInstructionsResponse
+// @Failure 400 {object} TwirpError
+// @Failure 404 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/SetConversationInstructions [post]
+func _setConversationInstructions() {}
+
+// @Summary Claim an anonymous session
+// @Description Transfer the conversations of an anonymous user to the user they logged in as; the anonymous chat's session continues in the authenticated chat and the anonymous user's settings fill unset preferences.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param request body ClaimSessionRequest true "Claim session request"
+// @Success 200 {object} ClaimSessionResponse
+// @Failure 400 {object} TwirpError
+// @Failure 500 {object} TwirpError
+// @Router /twirp/acai.chat.ChatService/ClaimSession [post]
+func _claimSession() {}
+
 // @Summary Health check
 // @Description Check service health status including MongoDB and Redis connectivity
 // @Tags system
@@ -158,11 +494,17 @@ func _metrics() {}
 // @Router / [get]
 func _root() {}
 
-// ErrorResponse represents a standard error response
+// TwirpError represents an error returned by an RPC, see https://twitchtv.github.io/twirp/docs/errors.html
+type TwirpError struct {
+	Code string            `json:"code" example:"invalid_argument"`
+	Msg  string            `json:"msg" example:"message is required"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// ErrorResponse represents an error returned by the HTTP middleware, e.g. for a missing API key
 type ErrorResponse struct {
-	Code    int    `json:"code" example:"400"`
-	Message string `json:"message" example:"Bad Request"`
-	Details string `json:"details,omitempty" example:"Missing required field: message"`
+	Error   string `json:"error" example:"unauthorized"`
+	Message string `json:"message" example:"API key required"`
 }
 
 // HealthResponse represents health check response
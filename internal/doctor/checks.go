@@ -0,0 +1,430 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/blocklist"
+	"github.com/8adimka/Go_AI_Assistant/internal/botguard"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/injection"
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/costcap"
+	"github.com/8adimka/Go_AI_Assistant/internal/cron"
+	"github.com/8adimka/Go_AI_Assistant/internal/followup"
+	"github.com/8adimka/Go_AI_Assistant/internal/greeting"
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/8adimka/Go_AI_Assistant/internal/inflight"
+	"github.com/8adimka/Go_AI_Assistant/internal/postprocess"
+	"github.com/8adimka/Go_AI_Assistant/internal/prewarm"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/8adimka/Go_AI_Assistant/internal/rollout"
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"github.com/8adimka/Go_AI_Assistant/internal/takeout"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/internal/vectorstore"
+	ics "github.com/arran4/golang-ical"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// embeddingDimensions lists the vector length of OpenAI embedding models at their default size
+var embeddingDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// ValidateConfig returns the configuration problems that stop the server from starting,
+// and the settings that leave a feature disabled
+func ValidateConfig(cfg *config.Config) (problems, warnings []string) {
+	if cfg.OpenAIApiKey == "" {
+		problems = append(problems, "OPENAI_API_KEY is not set")
+	}
+	if _, err := redisx.CodecByName(cfg.CacheCodec); err != nil {
+		problems = append(problems, "CACHE_CODEC: "+err.Error())
+	}
+	if strategy, err := chat.ParseStorageStrategy(cfg.ContextStorage); err != nil {
+		problems = append(problems, "CONTEXT_STORAGE: "+err.Error())
+	} else if strategy == chat.StorageMemory {
+		warnings = append(warnings, "CONTEXT_STORAGE is memory, conversation contexts are lost on restart and not shared between instances")
+	}
+	if _, err := chat.ParseHistoryWindows(cfg.HistoryWindows, cfg.PersonaHistoryWindows); err != nil {
+		problems = append(problems, "HISTORY_WINDOWS: "+err.Error())
+	}
+	if _, err := rollout.ParseStages(cfg.PromptRolloutStages); err != nil {
+		problems = append(problems, "PROMPT_ROLLOUT_STAGES: "+err.Error())
+	}
+	if cfg.PromptRolloutStageMinutes < 0 || cfg.PromptRolloutMinReplies < 0 || cfg.PromptRolloutMaxErrorRate < 0 ||
+		cfg.PromptRolloutMaxNegativeRate < 0 || cfg.PromptRolloutMaxLatencyIncrease < 0 {
+		problems = append(problems, "PROMPT_ROLLOUT_*: stage minutes and thresholds must not be negative")
+	}
+	if _, err := inflight.ParsePolicies(cfg.ConversationConcurrency); err != nil {
+		problems = append(problems, "CONVERSATION_CONCURRENCY: "+err.Error())
+	}
+	if _, err := injection.NewDetector(cfg.InjectionMode, nil, nil); err != nil {
+		problems = append(problems, "INJECTION_MODE: "+err.Error())
+	}
+	if _, err := billing.ParsePricing(cfg.BillingModelPrices); err != nil {
+		problems = append(problems, "BILLING_MODEL_PRICES: "+err.Error())
+	}
+	if cfg.ConversationTokenCap < 0 || cfg.ConversationCostCapUSD < 0 {
+		problems = append(problems, "CONVERSATION_TOKEN_CAP, CONVERSATION_COST_CAP_USD: caps must not be negative")
+	}
+	if _, err := costcap.NewPolicy(costcap.Config{Personas: cfg.PersonaCostCaps, Action: cfg.CostCapAction, Model: cfg.CostCapModel}); err != nil {
+		problems = append(problems, "PERSONA_COST_CAPS, COST_CAP_ACTION: "+err.Error())
+	}
+	if cfg.RequestBudgetMaxMs < 0 || cfg.RequestBudgetReserveMs < 0 {
+		problems = append(problems, "REQUEST_BUDGET_MAX_MS, REQUEST_BUDGET_RESERVE_MS: must not be negative")
+	} else if cfg.RequestBudgetMaxMs > 0 && cfg.RequestBudgetReserveMs >= cfg.RequestBudgetMaxMs {
+		problems = append(problems, "REQUEST_BUDGET_RESERVE_MS: must be shorter than REQUEST_BUDGET_MAX_MS")
+	}
+	if cfg.IdempotencyTTLHours < 0 {
+		problems = append(problems, "IDEMPOTENCY_TTL_HOURS: must not be negative")
+	}
+	if _, err := postprocess.ParseDecorations(cfg.ReplyDecorations); err != nil {
+		problems = append(problems, "REPLY_DECORATIONS: "+err.Error())
+	}
+	if greetings, err := greeting.Parse(cfg.Greetings); err != nil {
+		problems = append(problems, "GREETINGS: "+err.Error())
+	} else if _, err := greeting.NewGreeter(greetings); err != nil {
+		problems = append(problems, "GREETINGS: "+err.Error())
+	}
+	switch cfg.TitleGenerationMode {
+	case "sync", "batch", "concurrent":
+	default:
+		problems = append(problems, fmt.Sprintf("TITLE_GENERATION_MODE: %q is not \"sync\", \"batch\" or \"concurrent\"", cfg.TitleGenerationMode))
+	}
+	if cfg.TopicClassifier != "keywords" && cfg.TopicClassifier != "model" {
+		problems = append(problems, fmt.Sprintf("TOPIC_CLASSIFIER: %q is neither \"keywords\" nor \"model\"", cfg.TopicClassifier))
+	}
+	switch cfg.LLMFixturesMode {
+	case replay.FixtureModeOff:
+	case replay.FixtureModeRecord, replay.FixtureModeReplay:
+		warnings = append(warnings, fmt.Sprintf("LLM_FIXTURES_MODE is %s, replies come from test fixtures", cfg.LLMFixturesMode))
+	default:
+		problems = append(problems, fmt.Sprintf("LLM_FIXTURES_MODE: %q is not \"off\", \"record\" or \"replay\"", cfg.LLMFixturesMode))
+	}
+	if _, err := vectorstore.ParseBackend(cfg.VectorStore); err != nil {
+		problems = append(problems, "VECTOR_STORE: "+err.Error())
+	} else if cfg.VectorStore == vectorstore.BackendQdrant && cfg.QdrantURL == "" {
+		problems = append(problems, "QDRANT_URL is required for the qdrant vector store")
+	}
+	if cfg.VectorDimensions <= 0 {
+		problems = append(problems, fmt.Sprintf("VECTOR_DIMENSIONS: %d is not positive", cfg.VectorDimensions))
+	} else if dims, ok := embeddingDimensions[cfg.EmbeddingModel]; ok && dims != cfg.VectorDimensions {
+		problems = append(problems, fmt.Sprintf("VECTOR_DIMENSIONS: %d does not match the %d dimensions of %s",
+			cfg.VectorDimensions, dims, cfg.EmbeddingModel))
+	}
+	if cfg.EmbeddingBatchSize <= 0 || cfg.EmbeddingBatchSize > 2048 {
+		problems = append(problems, fmt.Sprintf("EMBEDDING_BATCH_SIZE: %d is not between 1 and 2048", cfg.EmbeddingBatchSize))
+	}
+	switch cfg.ObjectStoreBackend {
+	case "local":
+	case "s3":
+		if cfg.ObjectStoreS3Bucket == "" {
+			problems = append(problems, "OBJECT_STORE_S3_BUCKET is required for the s3 backend")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("OBJECT_STORE_BACKEND: %q is neither \"local\" nor \"s3\"", cfg.ObjectStoreBackend))
+	}
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		problems = append(problems, "CORS_ALLOW_CREDENTIALS cannot be combined with \"*\" in CORS_ALLOWED_ORIGINS, list the origins instead")
+	}
+	if cfg.ColdStorageInactiveDays < 0 {
+		problems = append(problems, fmt.Sprintf("COLD_STORAGE_INACTIVE_DAYS: %d is negative", cfg.ColdStorageInactiveDays))
+	} else if cfg.ColdStorageInactiveDays > 0 && cfg.ObjectStoreBackend == "local" {
+		warnings = append(warnings, "COLD_STORAGE_INACTIVE_DAYS archives conversations to the local object store, which is not shared between instances")
+	}
+	for _, category := range cfg.BlocklistCategories {
+		if !blocklist.ValidCategory(category) {
+			problems = append(problems, fmt.Sprintf("BLOCKLIST_CATEGORIES: unknown category %q, want one of %s",
+				category, strings.Join(blocklist.Categories(), ", ")))
+		}
+	}
+	if _, err := followup.ParsePlatforms(cfg.FollowUpPlatforms); err != nil {
+		problems = append(problems, "FOLLOW_UP_PLATFORMS: "+err.Error())
+	} else if len(cfg.FollowUpPlatforms) > 0 && (cfg.FollowUpIdleHours <= 0 || cfg.FollowUpMaxIdleHours <= cfg.FollowUpIdleHours) {
+		problems = append(problems, fmt.Sprintf("FOLLOW_UP_IDLE_HOURS: %d must be positive and below FOLLOW_UP_MAX_IDLE_HOURS (%d)",
+			cfg.FollowUpIdleHours, cfg.FollowUpMaxIdleHours))
+	}
+	if cfg.QualitySampleSize < 0 {
+		problems = append(problems, fmt.Sprintf("QUALITY_SAMPLE_SIZE: %d is negative", cfg.QualitySampleSize))
+	}
+	if !cfg.MigrationsEnabled {
+		warnings = append(warnings, "MIGRATIONS_ENABLED is off, indexes must be created with make migrate-up")
+	} else if cfg.MigrationsIntervalSeconds <= 0 {
+		problems = append(problems, fmt.Sprintf("MIGRATIONS_INTERVAL_SECONDS: %d is not positive", cfg.MigrationsIntervalSeconds))
+	}
+	if u, err := url.Parse(cfg.OpenAIBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("OPENAI_BASE_URL: %q is not an absolute URL", cfg.OpenAIBaseURL))
+	}
+	if cfg.OpenAIMaxIdleConns <= 0 {
+		problems = append(problems, fmt.Sprintf("OPENAI_MAX_IDLE_CONNS: %d is not positive", cfg.OpenAIMaxIdleConns))
+	}
+	if cfg.OpenAIPrewarm && (cfg.OpenAIKeepAliveSeconds <= 0 || time.Duration(cfg.OpenAIKeepAliveSeconds)*time.Second >= prewarm.IdleTimeout) {
+		problems = append(problems, fmt.Sprintf("OPENAI_KEEPALIVE_SECONDS: %d must be positive and below %s, when idle connections are closed",
+			cfg.OpenAIKeepAliveSeconds, prewarm.IdleTimeout))
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.StorageProfiles)) {
+		profile := cfg.StorageProfiles[name]
+		if profile.MongoURI == "" || profile.RedisAddr == "" {
+			problems = append(problems, fmt.Sprintf("STORAGE_PROFILES: profile %q needs both a Mongo URI and a Redis address", name))
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(cfg.TenantStorageProfiles)) {
+		if _, ok := cfg.StorageProfiles[cfg.TenantStorageProfiles[id]]; !ok {
+			problems = append(problems, fmt.Sprintf("TENANT_STORAGE_PROFILES: tenant %s uses unknown storage profile %q",
+				id, cfg.TenantStorageProfiles[id]))
+		}
+		if _, keyed := cfg.TenantAccessKeys[id]; !keyed && id != tenant.DefaultID && !slices.Contains(cfg.TenantIDs, id) {
+			problems = append(problems, fmt.Sprintf("TENANT_STORAGE_PROFILES: tenant %s is in neither TENANT_ACCESS_KEYS nor TENANT_IDS, so it is never served", id))
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(cfg.TenantAccessKeys)) {
+		if !tenant.ValidID(id) {
+			problems = append(problems, fmt.Sprintf("TENANT_ACCESS_KEYS: %q is not a valid tenant ID", id))
+		}
+	}
+	var trusted []string
+	for _, id := range cfg.TenantIDs {
+		if !tenant.ValidID(id) {
+			problems = append(problems, fmt.Sprintf("TENANT_IDS: %q is not a valid tenant ID", id))
+		} else if _, keyed := cfg.TenantAccessKeys[id]; !keyed && id != tenant.DefaultID {
+			trusted = append(trusted, id)
+		}
+	}
+	if len(trusted) > 0 {
+		warnings = append(warnings, fmt.Sprintf("TENANT_IDS: %s are served on the X-Tenant-ID header alone, "+
+			"give them TENANT_ACCESS_KEYS unless a gateway authenticates callers", strings.Join(trusted, ", ")))
+	}
+	if cfg.TenantCredentialsKey != "" {
+		if _, err := secrets.NewCipherFromBase64(cfg.TenantCredentialsKey); err != nil {
+			problems = append(problems, "TENANT_CREDENTIALS_KEY: "+err.Error())
+		}
+	}
+	if cfg.TenantEncryptionMasterKey != "" {
+		if _, err := secrets.NewLocalKMS(cfg.TenantEncryptionMasterKey); err != nil {
+			problems = append(problems, "TENANT_ENCRYPTION_MASTER_KEY: "+err.Error())
+		}
+	}
+	if cfg.TakeoutSigningKey != "" {
+		if _, err := takeout.NewSigner(cfg.PublicBaseURL, cfg.TakeoutSigningKey); err != nil {
+			problems = append(problems, "TAKEOUT_SIGNING_KEY: "+err.Error())
+		}
+	} else {
+		warnings = append(warnings, "TAKEOUT_SIGNING_KEY is not set, users cannot export their data")
+	}
+	for name, schedule := range map[string]string{
+		"CRON_BILLING_EXPORT": cfg.CronBillingExport,
+		"CRON_PROMPT_WARMUP":  cfg.CronPromptWarmup,
+		"CRON_COLD_STORAGE":   cfg.CronColdStorage,
+		"CRON_USAGE_STATS":    cfg.CronUsageStats,
+		"CRON_PROMPT_ROLLOUT": cfg.CronPromptRollout,
+		"CRON_QUALITY_SCORES": cfg.CronQualityScores,
+		"CRON_SCHEMA_UPGRADE": cfg.CronSchemaUpgrade,
+		"CRON_FOLLOW_UPS":     cfg.CronFollowUps,
+	} {
+		if schedule == "" {
+			continue
+		}
+		if _, err := cron.ParseSchedule(schedule); err != nil {
+			problems = append(problems, name+": "+err.Error())
+		}
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		problems = append(problems, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.WeatherApiKey == "" {
+		warnings = append(warnings, "WEATHER_API_KEY is not set, weather answers are unavailable")
+	}
+	if cfg.AdminJWTSecret != "" && len(cfg.AdminJWTSecret) < 32 {
+		problems = append(problems, "ADMIN_JWT_SECRET must be at least 32 bytes")
+	}
+	if cfg.AdminTokenTTLMinutes <= 0 {
+		problems = append(problems, "ADMIN_TOKEN_TTL_MINUTES must be positive")
+	}
+	if _, err := httpx.NewAccessControl(httpx.AccessConfig{AllowCIDRs: cfg.AccessAllowCIDRs, DenyCIDRs: cfg.AccessDenyCIDRs}, nil, nil); err != nil {
+		problems = append(problems, "ACCESS_ALLOW_CIDRS/ACCESS_DENY_CIDRS: "+err.Error())
+	}
+	if _, err := botguard.NewVerifier(cfg.BotChallenge, cfg.BotChallengeSecret, cfg.BotPowDifficulty); err != nil {
+		problems = append(problems, "BOT_CHALLENGE: "+err.Error())
+	} else if cfg.BotChallenge == botguard.ChallengePoW && cfg.BotChallengeSecret == "" {
+		warnings = append(warnings, "BOT_CHALLENGE_SECRET is not set, proof-of-work challenges only verify on the instance that issued them")
+	}
+	if (len(cfg.AccessAllowCountries) > 0 || len(cfg.AccessDenyCountries) > 0) && cfg.GeoIPDatabasePath == "" {
+		problems = append(problems, "ACCESS_ALLOW_COUNTRIES/ACCESS_DENY_COUNTRIES need GEOIP_DATABASE_PATH")
+	}
+
+	slices.Sort(problems)
+	return problems, warnings
+}
+
+// ConfigCheck reports the configuration problems found by ValidateConfig
+func ConfigCheck(cfg *config.Config) Check {
+	return Check{Name: "config", Run: func(ctx context.Context) (string, string) {
+		problems, warnings := ValidateConfig(cfg)
+		switch {
+		case len(problems) > 0:
+			return StatusFail, strings.Join(append(problems, warnings...), "\n")
+		case len(warnings) > 0:
+			return StatusWarn, strings.Join(warnings, "\n")
+		}
+		return StatusOK, "valid"
+	}}
+}
+
+// MongoCheck connects to MongoDB and writes and deletes a document in each database,
+// which needs the read-write role the server runs with
+func MongoCheck(uri string, databases ...string) Check {
+	return Check{Name: "mongodb", Run: func(ctx context.Context) (string, string) {
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetServerSelectionTimeout(5*time.Second))
+		if err != nil {
+			return StatusFail, "invalid MONGO_URI: " + err.Error()
+		}
+		defer client.Disconnect(context.WithoutCancel(ctx))
+
+		if err := client.Ping(ctx, nil); err != nil {
+			return StatusFail, "unreachable: " + err.Error()
+		}
+		for _, database := range databases {
+			collection := client.Database(database).Collection("doctor_checks")
+			id := primitive.NewObjectID()
+			if _, err := collection.InsertOne(ctx, bson.M{"_id": id, "created_at": time.Now()}); err != nil {
+				return StatusFail, fmt.Sprintf("cannot write to %s: %v", database, err)
+			}
+			if _, err := collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+				return StatusFail, fmt.Sprintf("cannot delete from %s: %v", database, err)
+			}
+		}
+		return StatusOK, "connected, read-write access to " + strings.Join(databases, ", ")
+	}}
+}
+
+// RedisCheck connects to Redis and writes, reads and deletes a key with a script,
+// as the job queue and leader election rely on scripts
+func RedisCheck(addr string) Check {
+	return Check{Name: "redis", Run: func(ctx context.Context) (string, string) {
+		client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+		defer client.Close()
+
+		if err := client.Ping(ctx).Err(); err != nil {
+			return StatusFail, "unreachable: " + err.Error()
+		}
+		key := "doctor:" + primitive.NewObjectID().Hex()
+		if err := client.Set(ctx, key, "ok", time.Minute).Err(); err != nil {
+			return StatusFail, "cannot write: " + err.Error()
+		}
+		if err := client.Get(ctx, key).Err(); err != nil {
+			return StatusFail, "cannot read: " + err.Error()
+		}
+		if err := client.Eval(ctx, "return redis.call('DEL', KEYS[1])", []string{key}).Err(); err != nil {
+			return StatusFail, "cannot run scripts: " + err.Error()
+		}
+		return StatusOK, "connected, read-write access and scripting"
+	}}
+}
+
+// OpenAICheck lists the models available to the API key and warns about configured models missing from the list
+func OpenAICheck(apiKey string, models []string, opts ...option.RequestOption) Check {
+	return Check{Name: "openai", Run: func(ctx context.Context) (string, string) {
+		if apiKey == "" {
+			return StatusFail, "OPENAI_API_KEY is not set"
+		}
+
+		client := openai.NewClient(append([]option.RequestOption{
+			option.WithAPIKey(apiKey),
+			option.WithMaxRetries(0),
+		}, opts...)...)
+		page, err := client.Models.List(ctx)
+		if err != nil {
+			var apiErr *openai.Error
+			if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+				return StatusFail, fmt.Sprintf("API key rejected (HTTP %d)", apiErr.StatusCode)
+			}
+			return StatusFail, "cannot list models: " + err.Error()
+		}
+
+		available := make(map[string]bool, len(page.Data))
+		for _, m := range page.Data {
+			available[m.ID] = true
+		}
+		var missing []string
+		for _, m := range models {
+			if m != "" && !available[m] && !slices.Contains(missing, m) {
+				missing = append(missing, m)
+			}
+		}
+		if len(missing) > 0 {
+			return StatusWarn, "key valid, but these configured models are not available: " + strings.Join(missing, ", ")
+		}
+		return StatusOK, fmt.Sprintf("key valid, %d models available", len(page.Data))
+	}}
+}
+
+// WeatherCheck requests the current weather of a city to verify the WeatherAPI key
+func WeatherCheck(apiKey, baseURL string, httpClient *http.Client) Check {
+	return Check{Name: "weatherapi", Run: func(ctx context.Context) (string, string) {
+		if apiKey == "" {
+			return StatusWarn, "WEATHER_API_KEY is not set"
+		}
+
+		query := url.Values{"key": {apiKey}, "q": {"London"}, "aqi": {"no"}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/current.json?"+query.Encode(), nil)
+		if err != nil {
+			return StatusFail, "invalid base URL: " + err.Error()
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			// The request URL carries the key, so only the cause is reported
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return StatusFail, "unreachable: " + err.Error()
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return StatusOK, "key valid"
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return StatusFail, fmt.Sprintf("API key rejected (HTTP %d)", resp.StatusCode)
+		default:
+			return StatusFail, "unexpected response: " + resp.Status
+		}
+	}}
+}
+
+// HolidaysCheck downloads and parses the holiday calendar
+func HolidaysCheck(calendarURL string, httpClient *http.Client) Check {
+	return Check{Name: "holidays", Run: func(ctx context.Context) (string, string) {
+		if calendarURL == "" {
+			return StatusSkip, "HOLIDAY_CALENDAR_LINK is not set"
+		}
+
+		cal, err := ics.ParseCalendarFromUrl(calendarURL, ctx, httpClient)
+		if err != nil {
+			return StatusFail, "cannot load calendar: " + err.Error()
+		}
+		events := len(cal.Events())
+		if events == 0 {
+			return StatusWarn, "calendar has no events"
+		}
+		return StatusOK, fmt.Sprintf("calendar loaded, %d events", events)
+	}}
+}
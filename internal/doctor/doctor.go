@@ -0,0 +1,89 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Check outcomes
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn" // The service runs, but a feature is degraded or disabled
+	StatusFail = "fail" // The service cannot run as configured
+	StatusSkip = "skip" // The dependency is not configured
+)
+
+// Result is the outcome of a check
+type Result struct {
+	Name     string
+	Status   string
+	Detail   string
+	Duration time.Duration
+}
+
+// Check verifies one dependency or part of the configuration
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (status, detail string)
+}
+
+// Run runs the checks one after another, each with its own timeout
+func Run(ctx context.Context, checks []Check, timeout time.Duration) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		status, detail := check.Run(checkCtx)
+		cancel()
+		results = append(results, Result{
+			Name:     check.Name,
+			Status:   status,
+			Detail:   detail,
+			Duration: time.Since(start),
+		})
+	}
+	return results
+}
+
+// Failed reports whether any check failed
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteReport prints the results as a table followed by a summary line
+func WriteReport(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tTIME\tDETAIL")
+
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+		// Multi-line details, such as a list of configuration problems, are indented under the check
+		lines := strings.Split(r.Detail, "\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, strings.ToUpper(r.Status),
+			r.Duration.Round(time.Millisecond), lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(tw, "\t\t\t%s\n", line)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	verdict := "ready"
+	if Failed(results) {
+		verdict = "not ready"
+	}
+	_, err := fmt.Fprintf(w, "\n%d ok, %d warnings, %d failed, %d skipped: %s\n",
+		counts[StatusOK], counts[StatusWarn], counts[StatusFail], counts[StatusSkip], verdict)
+	return err
+}
@@ -0,0 +1,161 @@
+package embeddings
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+)
+
+// DefaultBatchSize is the number of texts sent per embedding request when none is configured
+const DefaultBatchSize = 100
+
+// Provider turns texts into embeddings with one upstream request, see assistant.UnifiedAssistant.CreateEmbeddings
+// The returned vectors are aligned with texts
+type Provider interface {
+	CreateEmbeddings(ctx context.Context, model string, texts []string) ([][]float32, error)
+}
+
+// Cache keeps embeddings between requests, see redisx.Cache
+type Cache interface {
+	MGet(ctx context.Context, keys []string, dests []interface{}) ([]bool, error)
+	MSet(ctx context.Context, values map[string]interface{}) error
+}
+
+// Recorder exports batching for dashboards, see metrics.Metrics
+// Cache hits are recorded by the cache itself under the "embedding" cache name
+type Recorder interface {
+	RecordEmbeddingBatch(ctx context.Context, model string, texts int)
+}
+
+// Config controls the embedding model and batching
+type Config struct {
+	Model     string // Embedding model, part of every cache key
+	BatchSize int    // Maximum texts per upstream request
+}
+
+// Service embeds texts, reusing cached embeddings and batching the rest into as few requests as possible
+type Service struct {
+	provider  Provider
+	cache     Cache
+	recorder  Recorder
+	model     string
+	batchSize int
+}
+
+// NewService creates an embedding service; cache and recorder may be nil
+func NewService(provider Provider, cache Cache, recorder Recorder, cfg Config) *Service {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	return &Service{
+		provider:  provider,
+		cache:     cache,
+		recorder:  recorder,
+		model:     cfg.Model,
+		batchSize: cfg.BatchSize,
+	}
+}
+
+// Model returns the embedding model, which decides the dimensions of the vectors
+func (s *Service) Model() string {
+	return s.model
+}
+
+// Embed returns the embeddings of texts, aligned with texts
+// Repeated texts are embedded once; cache errors fall back to embedding every text
+func (s *Service) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	if len(texts) == 0 {
+		return vectors, nil
+	}
+
+	// Identical texts share a key, so each distinct text is looked up and embedded once
+	positions := make(map[string][]int)
+	var keys []string
+	var unique []string
+	for i, text := range texts {
+		key := CacheKey(s.model, text)
+		if _, seen := positions[key]; !seen {
+			keys = append(keys, key)
+			unique = append(unique, text)
+		}
+		positions[key] = append(positions[key], i)
+	}
+
+	cached := make([][]float32, len(keys))
+	found := make([]bool, len(keys))
+	if s.cache != nil {
+		dests := make([]interface{}, len(keys))
+		for i := range cached {
+			dests[i] = &cached[i]
+		}
+		hits, err := s.cache.MGet(ctx, keys, dests)
+		if err != nil {
+			slog.WarnContext(ctx, "Embedding cache error, proceeding without cache", "error", err)
+		} else {
+			found = hits
+		}
+	}
+
+	var missing []int
+	for i, key := range keys {
+		if found[i] {
+			for _, pos := range positions[key] {
+				vectors[pos] = cached[i]
+			}
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	for start := 0; start < len(missing); start += s.batchSize {
+		batch := missing[start:min(start+s.batchSize, len(missing))]
+		inputs := make([]string, len(batch))
+		for n, i := range batch {
+			inputs[n] = unique[i]
+		}
+
+		embedded, err := s.provider.CreateEmbeddings(ctx, s.model, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %d texts: %w", len(inputs), err)
+		}
+		if len(embedded) != len(inputs) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(embedded))
+		}
+		if s.recorder != nil {
+			s.recorder.RecordEmbeddingBatch(ctx, s.model, len(inputs))
+		}
+
+		generated := make(map[string]interface{}, len(batch))
+		for n, i := range batch {
+			for _, pos := range positions[keys[i]] {
+				vectors[pos] = embedded[n]
+			}
+			generated[keys[i]] = embedded[n]
+		}
+		if s.cache != nil {
+			if err := s.cache.MSet(ctx, generated); err != nil {
+				slog.WarnContext(ctx, "Failed to cache embeddings", "error", err)
+			}
+		}
+	}
+
+	return vectors, nil
+}
+
+// EmbedOne returns the embedding of a single text
+func (s *Service) EmbedOne(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := s.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// CacheKey identifies the embedding of text by model; the content is hashed so texts never appear in Redis keys
+func CacheKey(model, text string) string {
+	hash := sha256.Sum256([]byte(model + "\x00" + text))
+	return "embedding:" + hex.EncodeToString(hash[:])
+}
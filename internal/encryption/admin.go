@@ -0,0 +1,147 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler manages tenant data keys over HTTP
+// It must be mounted behind admin.Authenticator with a {tenant_id} route variable; writes require RoleOperator
+type AdminHandler struct {
+	keys *Manager
+}
+
+// NewAdminHandler creates a new tenant data key admin handler
+func NewAdminHandler(keys *Manager) *AdminHandler {
+	return &AdminHandler{keys: keys}
+}
+
+// RotateRequest is the optional body of key rotations
+// Key is write-only base64 key material supplied by the tenant; a key is generated when it is empty
+type RotateRequest struct {
+	Key string `json:"key,omitempty"`
+}
+
+// RevokeRequest is the body of key revocations, which must repeat the tenant ID
+type RevokeRequest struct {
+	Confirm string `json:"confirm"`
+}
+
+// GetHandler handles GET /admin/tenants/{tenant_id}/encryption
+func (h *AdminHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.keys.Status(r.Context(), tenantID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load tenant data keys", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load keys"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// RotateHandler handles POST /admin/tenants/{tenant_id}/encryption/rotate
+func (h *AdminHandler) RotateHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	var req RotateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	var material []byte
+	if req.Key != "" {
+		var err error
+		if material, err = base64.StdEncoding.DecodeString(req.Key); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "key is not valid base64"})
+			return
+		}
+	}
+
+	status, err := h.keys.Rotate(r.Context(), tenantID, material, actor(r))
+	switch {
+	case errors.Is(err, ErrInvalidKey):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, secrets.ErrKeyRevoked), errors.Is(err, ErrConcurrentChange):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to rotate tenant data key", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to rotate key"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// RevokeHandler handles POST /admin/tenants/{tenant_id}/encryption/revoke
+func (h *AdminHandler) RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	var req RevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if req.Confirm != tenantID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "confirm must repeat the tenant ID"})
+		return
+	}
+
+	status, err := h.keys.Revoke(r.Context(), tenantID, actor(r))
+	switch {
+	case errors.Is(err, ErrConcurrentChange):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to revoke tenant data keys", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to revoke keys"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *AdminHandler) tenantID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := mux.Vars(r)["tenant_id"]
+	if !tenant.ValidID(id) || id == tenant.DefaultID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tenant ID"})
+		return "", false
+	}
+	return id, true
+}
+
+// actor identifies the admin making a change in the audit log
+func actor(r *http.Request) string {
+	if p := admin.FromContext(r.Context()); p != nil {
+		return audit.Actor("admin", p.Username)
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,416 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const dataKeysCollection = "tenant_data_keys"
+
+// sealedPrefix marks values sealed with a tenant data key: "enc:v<version>:<ciphertext>"
+const sealedPrefix = "enc:v"
+
+// Sources of data keys
+const (
+	SourceGenerated = "generated"
+	SourceImported  = "imported" // Key material supplied by the tenant
+)
+
+// Actions recorded in the audit log of the tenant
+const (
+	ActionKeyRotated = "data_key.rotated" // After is the new active version and its source
+	ActionKeyRevoked = "data_key.revoked" // Before is the number of destroyed versions
+)
+
+var (
+	// ErrInvalidKey is returned when imported key material is not a 32-byte key
+	ErrInvalidKey = fmt.Errorf("key material must be %d bytes", secrets.KeySize)
+	// ErrConcurrentChange is returned when the keys of a tenant changed while they were updated
+	ErrConcurrentChange = errors.New("tenant keys changed concurrently")
+)
+
+// DataKey is a version of a tenant's data key, stored wrapped by the master key
+type DataKey struct {
+	Version     int       `bson:"version" json:"version"`
+	WrappedKey  string    `bson:"wrapped_key" json:"-"`
+	MasterKeyID string    `bson:"master_key_id" json:"master_key_id"`
+	Source      string    `bson:"source" json:"source"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+// TenantKeys are the data keys of a tenant
+type TenantKeys struct {
+	TenantID string `bson:"_id"`
+	// Active is the version new data is sealed with; older versions open data sealed before a rotation
+	Active int       `bson:"active"`
+	Keys   []DataKey `bson:"keys"`
+	// RevokedAt is when the keys were destroyed on offboarding; data sealed with them can no longer be read
+	RevokedAt time.Time `bson:"revoked_at,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// Revoked reports whether the keys were destroyed
+func (k *TenantKeys) Revoked() bool {
+	return k != nil && !k.RevokedAt.IsZero()
+}
+
+// Repository persists wrapped tenant data keys
+type Repository interface {
+	// GetKeys returns the keys of a tenant, or nil when it has none
+	GetKeys(ctx context.Context, tenantID string) (*TenantKeys, error)
+	// SaveKeys stores the keys of a tenant, unless they were revoked or their active version is no longer previous
+	SaveKeys(ctx context.Context, keys *TenantKeys, previous int) error
+	// RevokedTenants returns the tenants whose keys were revoked
+	RevokedTenants(ctx context.Context) ([]string, error)
+}
+
+// Status describes the data keys of a tenant; key material is never returned
+type Status struct {
+	TenantID      string     `json:"tenant_id"`
+	Enabled       bool       `json:"enabled"`
+	ActiveVersion int        `json:"active_version,omitempty"`
+	Keys          []DataKey  `json:"keys"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+type cachedKeys struct {
+	ciphers   map[int]*secrets.Cipher // Unwrapped data keys by version; nil when the tenant has none
+	active    int
+	revoked   bool
+	expiresAt time.Time
+}
+
+type cachedRevoked struct {
+	tenants   []string
+	expiresAt time.Time
+}
+
+// Manager seals conversation data with per-tenant data keys, wrapped by a master key held in a KMS
+// Tenants without data keys are stored in clear. Unwrapped keys are cached in memory, so rotations and
+// revocations made on another instance apply there after the cache TTL
+type Manager struct {
+	repo     Repository
+	kms      secrets.KMS
+	audit    audit.Recorder
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	cache   map[string]cachedKeys
+	revoked cachedRevoked
+}
+
+// NewManager creates a new tenant data key manager; recorder may be nil
+func NewManager(repo Repository, kms secrets.KMS, recorder audit.Recorder, cacheTTL time.Duration) *Manager {
+	if cacheTTL <= 0 {
+		cacheTTL = time.Minute
+	}
+	return &Manager{
+		repo:     repo,
+		kms:      kms,
+		audit:    recorder,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedKeys),
+	}
+}
+
+// Status returns the data keys of a tenant
+func (m *Manager) Status(ctx context.Context, tenantID string) (*Status, error) {
+	keys, err := m.repo.GetKeys(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant keys: %w", err)
+	}
+	return status(tenantID, keys), nil
+}
+
+// Rotate adds a data key version and seals new data with it; the first rotation enables encryption
+// The key is generated unless the tenant supplies its own material. Earlier versions are kept, so data
+// sealed with them stays readable until it is rewritten
+func (m *Manager) Rotate(ctx context.Context, tenantID string, material []byte, actor string) (*Status, error) {
+	source := SourceImported
+	if material == nil {
+		source = SourceGenerated
+		var err error
+		if material, err = secrets.NewKey(); err != nil {
+			return nil, err
+		}
+	} else if len(material) != secrets.KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	keys, err := m.repo.GetKeys(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant keys: %w", err)
+	}
+	if keys.Revoked() {
+		return nil, secrets.ErrKeyRevoked
+	}
+	if keys == nil {
+		keys = &TenantKeys{TenantID: tenantID}
+	}
+
+	wrapped, err := m.kms.Wrap(ctx, material)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	previous := keys.Active
+	now := time.Now()
+	keys.Active = previous + 1
+	keys.Keys = append(keys.Keys, DataKey{
+		Version:     keys.Active,
+		WrappedKey:  wrapped,
+		MasterKeyID: m.kms.KeyID(),
+		Source:      source,
+		CreatedAt:   now,
+	})
+	keys.UpdatedAt = now
+	if err := m.repo.SaveKeys(ctx, keys, previous); err != nil {
+		return nil, err
+	}
+
+	m.invalidate(tenantID)
+	m.record(ctx, tenantID, &audit.Entry{
+		Action: ActionKeyRotated,
+		Actor:  actor,
+		Target: tenantID,
+		After:  fmt.Sprintf("v%d (%s)", keys.Active, source),
+	})
+	slog.InfoContext(ctx, "Tenant data key rotated", "tenant_id", tenantID, "version", keys.Active, "source", source)
+	return status(tenantID, keys), nil
+}
+
+// Revoke destroys the data keys of an offboarded tenant
+// Its data can no longer be read nor written; this cannot be undone
+func (m *Manager) Revoke(ctx context.Context, tenantID, actor string) (*Status, error) {
+	keys, err := m.repo.GetKeys(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant keys: %w", err)
+	}
+	if keys.Revoked() {
+		return status(tenantID, keys), nil
+	}
+	if keys == nil {
+		keys = &TenantKeys{TenantID: tenantID}
+	}
+
+	previous, destroyed := keys.Active, len(keys.Keys)
+	now := time.Now()
+	keys.Active, keys.Keys = 0, nil
+	keys.RevokedAt, keys.UpdatedAt = now, now
+	if err := m.repo.SaveKeys(ctx, keys, previous); err != nil {
+		return nil, err
+	}
+
+	m.invalidate(tenantID)
+	m.mu.Lock()
+	m.revoked = cachedRevoked{}
+	m.mu.Unlock()
+	m.record(ctx, tenantID, &audit.Entry{
+		Action: ActionKeyRevoked,
+		Actor:  actor,
+		Target: tenantID,
+		Before: strconv.Itoa(destroyed) + " versions",
+	})
+	slog.WarnContext(ctx, "Tenant data keys revoked", "tenant_id", tenantID, "destroyed_versions", destroyed)
+	return status(tenantID, keys), nil
+}
+
+// Encrypt seals a value with the active data key of a tenant
+// Values of tenants without data keys are returned unchanged
+func (m *Manager) Encrypt(ctx context.Context, tenantID, plaintext string) (string, error) {
+	if plaintext == "" || tenantID == "" || tenantID == tenant.DefaultID {
+		return plaintext, nil
+	}
+	keys, err := m.keys(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	if keys.revoked {
+		return "", secrets.ErrKeyRevoked
+	}
+	if keys.ciphers == nil {
+		return plaintext, nil
+	}
+
+	sealed, err := keys.ciphers[keys.active].Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + strconv.Itoa(keys.active) + ":" + sealed, nil
+}
+
+// Decrypt opens a value sealed by Encrypt with any version of the tenant's data key
+// Values stored in clear, before the tenant had data keys, are returned unchanged
+func (m *Manager) Decrypt(ctx context.Context, tenantID, value string) (string, error) {
+	if value == "" || tenantID == "" || tenantID == tenant.DefaultID {
+		return value, nil
+	}
+	keys, err := m.keys(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	if keys.revoked {
+		return "", secrets.ErrKeyRevoked
+	}
+
+	rest, sealed := strings.CutPrefix(value, sealedPrefix)
+	if !sealed || keys.ciphers == nil {
+		return value, nil
+	}
+	version, ciphertext, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", secrets.ErrInvalidCiphertext
+	}
+	n, err := strconv.Atoi(version)
+	if err != nil || keys.ciphers[n] == nil {
+		return "", fmt.Errorf("unknown data key version %q of tenant %s: %w", version, tenantID, secrets.ErrInvalidCiphertext)
+	}
+	return keys.ciphers[n].Decrypt(ciphertext)
+}
+
+// RevokedTenants returns the tenants whose keys were revoked, so work across tenants can skip their data
+func (m *Manager) RevokedTenants(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	cached := m.revoked
+	m.mu.Unlock()
+	if time.Now().Before(cached.expiresAt) {
+		return cached.tenants, nil
+	}
+
+	tenants, err := m.repo.RevokedTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revoked tenants: %w", err)
+	}
+
+	m.mu.Lock()
+	m.revoked = cachedRevoked{tenants: tenants, expiresAt: time.Now().Add(m.cacheTTL)}
+	m.mu.Unlock()
+	return tenants, nil
+}
+
+// keys returns the unwrapped data keys of a tenant
+func (m *Manager) keys(ctx context.Context, tenantID string) (cachedKeys, error) {
+	m.mu.Lock()
+	cached, ok := m.cache[tenantID]
+	m.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	stored, err := m.repo.GetKeys(ctx, tenantID)
+	if err != nil {
+		return cachedKeys{}, fmt.Errorf("failed to load tenant keys: %w", err)
+	}
+
+	cached = cachedKeys{revoked: stored.Revoked(), expiresAt: time.Now().Add(m.cacheTTL)}
+	if stored != nil && !cached.revoked && len(stored.Keys) > 0 {
+		cached.active = stored.Active
+		cached.ciphers = make(map[int]*secrets.Cipher, len(stored.Keys))
+		for _, k := range stored.Keys {
+			material, err := m.kms.Unwrap(ctx, k.WrappedKey)
+			if err != nil {
+				return cachedKeys{}, fmt.Errorf("failed to unwrap data key v%d of tenant %s with master key %s: %w",
+					k.Version, tenantID, k.MasterKeyID, err)
+			}
+			if cached.ciphers[k.Version], err = secrets.NewCipher(material); err != nil {
+				return cachedKeys{}, err
+			}
+		}
+	}
+
+	m.mu.Lock()
+	m.cache[tenantID] = cached
+	m.mu.Unlock()
+	return cached, nil
+}
+
+func (m *Manager) invalidate(tenantID string) {
+	m.mu.Lock()
+	delete(m.cache, tenantID)
+	m.mu.Unlock()
+}
+
+// record adds an entry to the audit log of the tenant
+func (m *Manager) record(ctx context.Context, tenantID string, entry *audit.Entry) {
+	if m.audit == nil {
+		return
+	}
+	if err := m.audit.Record(tenant.WithTenant(ctx, tenantID), entry); err != nil {
+		slog.ErrorContext(ctx, "Failed to audit tenant data key change",
+			"action", entry.Action, "tenant_id", tenantID, "error", err)
+	}
+}
+
+func status(tenantID string, keys *TenantKeys) *Status {
+	s := &Status{TenantID: tenantID, Keys: []DataKey{}}
+	if keys == nil {
+		return s
+	}
+	if keys.Revoked() {
+		revokedAt := keys.RevokedAt
+		s.RevokedAt = &revokedAt
+		return s
+	}
+	s.Enabled = len(keys.Keys) > 0
+	s.ActiveVersion = keys.Active
+	s.Keys = append(s.Keys, keys.Keys...)
+	return s
+}
+
+// MongoRepository stores wrapped tenant data keys in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB data key repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+func (r *MongoRepository) GetKeys(ctx context.Context, tenantID string) (*TenantKeys, error) {
+	var keys TenantKeys
+	err := r.conn.Collection(dataKeysCollection).FindOne(ctx, bson.M{"_id": tenantID}).Decode(&keys)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
+
+func (r *MongoRepository) SaveKeys(ctx context.Context, keys *TenantKeys, previous int) error {
+	// A document changed meanwhile does not match, and the upsert then collides with its ID
+	filter := bson.M{"_id": keys.TenantID, "active": previous, "revoked_at": bson.M{"$exists": false}}
+	_, err := r.conn.Collection(dataKeysCollection).ReplaceOne(ctx, filter, keys, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConcurrentChange
+	}
+	return err
+}
+
+func (r *MongoRepository) RevokedTenants(ctx context.Context) ([]string, error) {
+	ids, err := r.conn.Collection(dataKeysCollection).Distinct(ctx, "_id", bson.M{"revoked_at": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if s, ok := id.(string); ok {
+			tenants = append(tenants, s)
+		}
+	}
+	return tenants, nil
+}
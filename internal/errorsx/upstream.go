@@ -0,0 +1,89 @@
+package errorsx
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/openai/openai-go"
+	"github.com/twitchtv/twirp"
+)
+
+// Model provider failures clients can show a fitting fallback for, see UpstreamTwirpError
+var (
+	ErrProviderRateLimited = errors.New("model provider rate limit reached")
+	ErrContentFiltered     = errors.New("blocked by the model provider's content filter")
+	ErrContextTooLong      = errors.New("conversation exceeds the model's context window")
+	ErrProviderUnavailable = errors.New("model provider unavailable")
+)
+
+// ClassifyUpstream returns which model provider failure err is, or nil when it is none of them
+func ClassifyUpstream(err error) error {
+	for _, known := range []error{ErrProviderRateLimited, ErrContentFiltered, ErrContextTooLong, ErrProviderUnavailable} {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == "content_filter" || apiErr.Code == "content_policy_violation":
+			return ErrContentFiltered
+		case apiErr.Code == "context_length_exceeded":
+			return ErrContextTooLong
+		case apiErr.Code == "insufficient_quota":
+			// The account ran out of credit; waiting a minute does not help
+			return ErrProviderUnavailable
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return ErrProviderRateLimited
+		case apiErr.StatusCode >= http.StatusInternalServerError:
+			return ErrProviderUnavailable
+		}
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrProviderUnavailable
+	}
+	return nil
+}
+
+// UpstreamTwirpError converts a failed reply to a Twirp error with a message that is safe to show users and
+// an error_code clients can choose a fallback by; errors that are not model provider failures become Internal
+func UpstreamTwirpError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(twirp.Error); ok {
+		return err
+	}
+
+	switch ClassifyUpstream(err) {
+	case ErrProviderRateLimited:
+		twerr := twirp.NewError(twirp.ResourceExhausted, "The assistant is busy right now, please try again in a minute").
+			WithMeta("error_code", "provider_rate_limited")
+		var apiErr *openai.Error
+		if errors.As(err, &apiErr) && apiErr.Response != nil {
+			if delay, ok := retry.ParseRetryAfter(apiErr.Response.Header); ok {
+				twerr = twerr.WithMeta("retry_after_seconds", strconv.Itoa(int(delay.Seconds()+0.5)))
+			}
+		}
+		return twerr
+	case ErrContentFiltered:
+		return twirp.NewError(twirp.InvalidArgument, "The request was declined by the content filter, please rephrase it").
+			WithMeta("argument", "message").
+			WithMeta("error_code", "content_filtered")
+	case ErrContextTooLong:
+		return twirp.NewError(twirp.FailedPrecondition, "This conversation is too long to continue, please start a new one").
+			WithMeta("error_code", "context_too_long")
+	case ErrProviderUnavailable:
+		return twirp.NewError(twirp.Unavailable, "The assistant is temporarily unavailable, please try again later").
+			WithMeta("error_code", "provider_unavailable")
+	default:
+		return twirp.InternalErrorWith(err)
+	}
+}
@@ -0,0 +1,93 @@
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+// Store aggregates assistant replies per variant, see model.Repository
+type Store interface {
+	CompareVariants(ctx context.Context, f model.ConversationFilter) ([]*model.VariantStats, error)
+}
+
+// Comparison is the outcome of one variant, with its cost and feedback rates
+type Comparison struct {
+	*model.VariantStats
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+	CostPerReplyUSD  float64 `json:"cost_per_reply_usd"`
+	// PositiveRate is the share of positive among positive and negative reactions; 0 without any
+	PositiveRate float64 `json:"positive_rate"`
+	// ReactionRate is the share of replies that received a reaction
+	ReactionRate float64 `json:"reaction_rate"`
+}
+
+// AdminHandler compares experiment variants over HTTP, without exporting raw conversations
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	store   Store
+	pricing billing.Pricing
+}
+
+// NewAdminHandler creates a new experiment admin handler; costs are estimated with pricing
+func NewAdminHandler(store Store, pricing billing.Pricing) *AdminHandler {
+	return &AdminHandler{store: store, pricing: pricing}
+}
+
+// CompareHandler handles GET /admin/analytics/experiments?platform=telegram&since=2024-05-01T00:00:00Z&until=2024-06-01T00:00:00Z
+func (h *AdminHandler) CompareHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.ConversationFilter{Platform: query.Get("platform")}
+
+	for name, field := range map[string]*time.Time{"since": &filter.CreatedAfter, "until": &filter.CreatedBefore} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": name + " must be an RFC 3339 timestamp"})
+			return
+		}
+		*field = t
+	}
+
+	stats, err := h.store.CompareVariants(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to compare experiment variants", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to compare variants"})
+		return
+	}
+
+	variants := make([]*Comparison, 0, len(stats))
+	for _, s := range stats {
+		variants = append(variants, h.compare(s))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"variants": variants})
+}
+
+func (h *AdminHandler) compare(s *model.VariantStats) *Comparison {
+	c := &Comparison{VariantStats: s}
+	if cost, ok := h.pricing.EstimateCost(s.Model, s.PromptTokens, s.CompletionTokens); ok {
+		c.EstimatedCostUSD = cost
+	}
+	if s.Replies > 0 {
+		c.CostPerReplyUSD = c.EstimatedCostUSD / float64(s.Replies)
+		c.ReactionRate = float64(s.Reacted) / float64(s.Replies)
+	}
+	if rated := s.Positive + s.Negative; rated > 0 {
+		c.PositiveRate = float64(s.Positive) / float64(rated)
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
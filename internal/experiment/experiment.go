@@ -0,0 +1,21 @@
+package experiment
+
+import (
+	"context"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+type generationKey struct{}
+
+// Track returns a context collecting how a reply is generated; the assistant fills it in
+func Track(ctx context.Context) (context.Context, *model.Generation) {
+	g := &model.Generation{}
+	return context.WithValue(ctx, generationKey{}, g), g
+}
+
+// FromContext returns the generation tracked for the context's reply, or nil
+func FromContext(ctx context.Context) *model.Generation {
+	g, _ := ctx.Value(generationKey{}).(*model.Generation)
+	return g
+}
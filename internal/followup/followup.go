@@ -0,0 +1,173 @@
+package followup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/delivery"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConversationStore finds conversations due a follow-up and records the ones sent, see model.Repository
+type ConversationStore interface {
+	// FindFollowUpCandidates returns up to limit conversations of a platform, messages included, whose last
+	// activity is in [idleFrom, idleBefore), that were not followed up since that activity nor since
+	// followedUpBefore, with IDs greater than after
+	FindFollowUpCandidates(ctx context.Context, platform string, idleFrom, idleBefore, followedUpBefore time.Time,
+		after primitive.ObjectID, limit int) ([]*model.Conversation, error)
+	// AddFollowUp appends the follow-up to a conversation; false when it had activity since it was read
+	AddFollowUp(ctx context.Context, c *model.Conversation, msg *model.Message) (bool, error)
+}
+
+// Writer generates the follow-up of a conversation, "" when there is nothing worth following up on,
+// see assistant.FollowUpWriter
+type Writer interface {
+	Write(ctx context.Context, conv *model.Conversation) (string, error)
+}
+
+// Sender pushes messages to users on external channels, see delivery.Service
+type Sender interface {
+	Supports(channel string) bool
+	Send(ctx context.Context, channel, recipient, text string) (*delivery.Message, error)
+}
+
+// Config controls which conversations are followed up
+type Config struct {
+	IdleFor   time.Duration            // Conversations idle this long get a follow-up
+	MaxIdle   time.Duration            // Conversations idle longer are left alone
+	Platforms map[string]time.Duration // Platforms that opted in -> minimum time between follow-ups of a conversation
+	BatchSize int                      // Conversations loaded per page
+}
+
+// ParsePlatforms parses platform -> minimum hours between follow-ups of a conversation, e.g. telegram:72
+// With 0 hours a conversation still gets one follow-up per idle period at most
+func ParsePlatforms(raw map[string]string) (map[string]time.Duration, error) {
+	platforms := make(map[string]time.Duration, len(raw))
+	for platform, value := range raw {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 0 {
+			return nil, fmt.Errorf("invalid follow-up interval %q for %s, want hours", value, platform)
+		}
+		platforms[platform] = time.Duration(hours) * time.Hour
+	}
+	return platforms, nil
+}
+
+// Result counts the conversations of a follow-up run
+type Result struct {
+	Sent    int `json:"sent"`
+	Skipped int `json:"skipped"` // Nothing to follow up on, or active again while the follow-up was written
+	Failed  int `json:"failed"`
+}
+
+// Service re-engages users of idle conversations with a short follow-up generated from the conversation,
+// pushed on the platform they talked on
+type Service struct {
+	conversations ConversationStore
+	writer        Writer
+	sender        Sender
+	cfg           Config
+}
+
+// NewService creates a follow-up service
+func NewService(conversations ConversationStore, writer Writer, sender Sender, cfg Config) *Service {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Service{conversations: conversations, writer: writer, sender: sender, cfg: cfg}
+}
+
+// Run follows up on every conversation of an opted-in platform idle between cfg.IdleFor and cfg.MaxIdle before now
+// A conversation whose follow-up fails to generate is retried on the next run; one stored but not
+// queued for delivery is not, so users never get the same follow-up twice
+func (s *Service) Run(ctx context.Context, now time.Time) (*Result, error) {
+	if s.cfg.IdleFor <= 0 || s.cfg.MaxIdle <= s.cfg.IdleFor {
+		return nil, errors.New("follow-ups need an idle period shorter than the maximum idle period")
+	}
+	idleFrom, idleBefore := now.Add(-s.cfg.MaxIdle), now.Add(-s.cfg.IdleFor)
+	result := &Result{}
+
+	for _, platform := range slices.Sorted(maps.Keys(s.cfg.Platforms)) {
+		if !s.sender.Supports(platform) {
+			slog.WarnContext(ctx, "Skipping follow-ups of a platform without a delivery channel", "platform", platform)
+			continue
+		}
+		followedUpBefore := now.Add(-s.cfg.Platforms[platform])
+
+		var after primitive.ObjectID
+		for {
+			page, err := s.conversations.FindFollowUpCandidates(ctx, platform, idleFrom, idleBefore, followedUpBefore,
+				after, s.cfg.BatchSize)
+			if err != nil {
+				return result, fmt.Errorf("failed to find idle %s conversations: %w", platform, err)
+			}
+			for _, c := range page {
+				if err := ctx.Err(); err != nil {
+					return result, err
+				}
+				s.followUp(tenant.WithTenant(ctx, c.TenantID), c, now, result)
+				after = c.ID
+			}
+			if len(page) < s.cfg.BatchSize {
+				break
+			}
+		}
+	}
+
+	slog.InfoContext(ctx, "Followed up on idle conversations",
+		"idle_since", idleBefore,
+		"sent", result.Sent,
+		"skipped", result.Skipped,
+		"failed", result.Failed)
+	if result.Failed > 0 {
+		return result, fmt.Errorf("%d follow-ups failed", result.Failed)
+	}
+	return result, nil
+}
+
+func (s *Service) followUp(ctx context.Context, c *model.Conversation, now time.Time, result *Result) {
+	text, err := s.writer.Write(ctx, c)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write follow-up", "conversation_id", c.ID.Hex(), "error", err)
+		result.Failed++
+		return
+	}
+	if text == "" {
+		result.Skipped++
+		return
+	}
+
+	// The follow-up is stored before it is sent, so a crash in between never sends it twice
+	msg := &model.Message{
+		ID:        primitive.NewObjectID(),
+		Role:      model.RoleAssistant,
+		Content:   text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	added, err := s.conversations.AddFollowUp(ctx, c, msg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to store follow-up", "conversation_id", c.ID.Hex(), "error", err)
+		result.Failed++
+		return
+	}
+	if !added {
+		result.Skipped++
+		return
+	}
+
+	if _, err := s.sender.Send(ctx, c.Platform, c.ChatID, text); err != nil {
+		slog.ErrorContext(ctx, "Failed to send follow-up", "conversation_id", c.ID.Hex(), "platform", c.Platform, "error", err)
+		result.Failed++
+		return
+	}
+	result.Sent++
+}
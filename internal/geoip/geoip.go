@@ -0,0 +1,344 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+)
+
+// metadataMarker precedes the metadata section at the end of a MaxMind DB file
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// ErrInvalidDatabase is returned for files that are not valid MaxMind DB files
+var ErrInvalidDatabase = errors.New("invalid MaxMind database")
+
+// Reader looks up countries in a MaxMind DB file, such as GeoLite2-Country or GeoIP2-City
+// The whole file is kept in memory; lookups are safe for concurrent use
+type Reader struct {
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	dataStart  uint // Offset of the data section, after the search tree and its 16-byte separator
+	ipv4Start  uint // Node IPv4 lookups start from in an IPv6 database
+}
+
+// Open reads a MaxMind DB file
+func Open(path string) (*Reader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(data)
+}
+
+// New parses a MaxMind DB from its contents
+func New(data []byte) (*Reader, error) {
+	at := bytes.LastIndex(data, metadataMarker)
+	if at < 0 {
+		return nil, fmt.Errorf("%w: metadata not found", ErrInvalidDatabase)
+	}
+	metaStart := uint(at + len(metadataMarker))
+	meta := decoder{data: data[metaStart:]}
+	value, _, err := meta.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: metadata: %v", ErrInvalidDatabase, err)
+	}
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", ErrInvalidDatabase)
+	}
+
+	r := &Reader{
+		data:       data,
+		nodeCount:  metaUint(fields, "node_count"),
+		recordSize: metaUint(fields, "record_size"),
+		ipVersion:  metaUint(fields, "ip_version"),
+	}
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %d", ErrInvalidDatabase, r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported IP version %d", ErrInvalidDatabase, r.ipVersion)
+	}
+	treeSize := r.recordSize * 2 / 8 * r.nodeCount
+	r.dataStart = treeSize + 16
+	if r.dataStart > metaStart {
+		return nil, fmt.Errorf("%w: search tree exceeds the file", ErrInvalidDatabase)
+	}
+
+	// IPv4 addresses live under ::/96 in IPv6 databases
+	if r.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.nodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// Country returns the ISO 3166 code of the country of ip, or "" when the database has none
+// The country the address is located in is preferred over the country its network is registered in
+func (r *Reader) Country(ip netip.Addr) (string, error) {
+	record, err := r.lookup(ip)
+	if err != nil || record == nil {
+		return "", err
+	}
+	for _, key := range []string{"country", "registered_country"} {
+		if country, ok := record[key].(map[string]any); ok {
+			if code, ok := country["iso_code"].(string); ok && code != "" {
+				return code, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// lookup returns the record of the network containing ip, or nil when there is none
+func (r *Reader) lookup(ip netip.Addr) (map[string]any, error) {
+	ip = ip.Unmap()
+	node, bits := uint(0), 128
+	switch {
+	case ip.Is4() && r.ipVersion == 6:
+		node, bits = r.ipv4Start, 32
+	case ip.Is4():
+		bits = 32
+	case r.ipVersion == 4:
+		return nil, nil // IPv6 addresses are not in IPv4 databases
+	}
+
+	addr := ip.AsSlice()
+	for i := 0; i < bits && node < r.nodeCount; i++ {
+		bit := uint(addr[i/8]>>(7-uint(i%8))) & 1
+		node = r.record(node, bit)
+	}
+	if node <= r.nodeCount {
+		return nil, nil // Not found: the tree ended on the empty record
+	}
+
+	offset := node - r.nodeCount - 16
+	d := decoder{data: r.data[r.dataStart:]}
+	value, _, err := d.decode(offset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDatabase, err)
+	}
+	record, _ := value.(map[string]any)
+	return record, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a search tree node
+func (r *Reader) record(node, bit uint) uint {
+	b := r.data[node*r.recordSize/4:]
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+func metaUint(fields map[string]any, key string) uint {
+	v, _ := fields[key].(uint64)
+	return uint(v)
+}
+
+// Data section field types
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// decoder decodes values of a MaxMind DB data section; pointers are offsets within data
+type decoder struct {
+	data []byte
+}
+
+// decode returns the value at offset and the offset following it
+func (d *decoder) decode(offset uint) (any, uint, error) {
+	return d.decodeDepth(offset, 0)
+}
+
+func (d *decoder) decodeDepth(offset uint, depth int) (any, uint, error) {
+	if depth > 32 {
+		return nil, 0, errors.New("data nested too deeply")
+	}
+	kind, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch kind {
+	case typePointer:
+		target, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decodeDepth(target, depth+1)
+		return value, next, err
+	case typeMap:
+		m := make(map[string]any, size)
+		for range size {
+			var key, value any
+			if key, offset, err = d.decodeDepth(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			if value, offset, err = d.decodeDepth(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			m[name] = value
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]any, 0, size)
+		for range size {
+			var value any
+			if value, offset, err = d.decodeDepth(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	next := offset + size
+	switch kind {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes, typeUint128:
+		return bytes.Clone(b), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double size")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float size")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, errors.New("invalid integer size")
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, errors.New("invalid integer size")
+		}
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int64(int32(v)), next, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type %d", kind)
+	}
+}
+
+// control reads the control byte of the field at offset: its type, its size, and where its payload starts
+// For pointers, size holds the pointer's size bits and the control byte's value bits
+func (d *decoder) control(offset uint) (kind, size, next uint, err error) {
+	b, err := d.bytes(offset, 1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	ctrl := uint(b[0])
+	offset++
+
+	kind = ctrl >> 5
+	if kind == typePointer {
+		return kind, ctrl & 0x1f, offset, nil
+	}
+	if kind == typeExtended {
+		ext, err := d.bytes(offset, 1)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		kind = 7 + uint(ext[0])
+		offset++
+	}
+
+	size = ctrl & 0x1f
+	if size >= 29 {
+		extra := size - 28 // 1, 2 or 3 more bytes
+		b, err := d.bytes(offset, extra)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		var v uint
+		for _, c := range b {
+			v = v<<8 | uint(c)
+		}
+		size = [...]uint{29, 285, 65821}[extra-1] + v
+		offset += extra
+	}
+	return kind, size, offset, nil
+}
+
+// pointer decodes a pointer whose control byte carried bits, returning its target and the offset following it
+func (d *decoder) pointer(bits, offset uint) (uint, uint, error) {
+	n := bits>>3 + 1
+	b, err := d.bytes(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	var v uint
+	for _, c := range b {
+		v = v<<8 | uint(c)
+	}
+	switch n {
+	case 1:
+		v |= (bits & 0x7) << 8
+	case 2:
+		v = (v | (bits&0x7)<<16) + 2048
+	case 3:
+		v = (v | (bits&0x7)<<24) + 526336
+	}
+	return v, offset + n, nil
+}
+
+func (d *decoder) bytes(offset, n uint) ([]byte, error) {
+	if offset+n > uint(len(d.data)) || offset+n < offset {
+		return nil, errors.New("unexpected end of data")
+	}
+	return d.data[offset : offset+n], nil
+}
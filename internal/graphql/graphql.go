@@ -0,0 +1,102 @@
+// Package graphql serves read-only GraphQL queries over conversations, their messages and usage, and user
+// settings, so clients can fetch what a screen needs in one request, e.g. conversations with their last
+// message and unread count
+// Queries are executed by github.com/graph-gophers/graphql-go; reads are batched per request with
+// github.com/graph-gophers/dataloader. Introspection is disabled so depth limits stay tight; GET /graphql
+// returns the schema for client code generators instead
+// Queries name the user whose data they read, so the handler must be mounted behind API key authentication
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	gql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+)
+
+// Path is where the GraphQL API is mounted
+const Path = "/graphql"
+
+// Limits of a query; the schema is three fields deep and list fields are bounded, see maxConversations
+const (
+	maxDepth       = 5
+	maxQueryLength = 8 << 10
+	maxParallelism = 20
+)
+
+// Request is a GraphQL request as POSTed in JSON
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Handler serves GraphQL queries over HTTP
+// Queries are POSTed as JSON; each request batches its reads with its own loaders
+type Handler struct {
+	schema        *gql.Schema
+	conversations Conversations
+	usage         Usage
+	settings      Settings
+}
+
+// NewHandler creates a GraphQL handler reading from the given sources
+func NewHandler(conversations Conversations, usage Usage, settings Settings) *Handler {
+	return &Handler{
+		schema: gql.MustParseSchema(schema, &queryResolver{conversations: conversations},
+			gql.UseStringDescriptions(),
+			gql.DisableIntrospection(),
+			gql.MaxDepth(maxDepth),
+			gql.MaxQueryLength(maxQueryLength),
+			gql.MaxParallelism(maxParallelism),
+		),
+		conversations: conversations,
+		usage:         usage,
+		settings:      settings,
+	}
+}
+
+// SDL returns the schema in the GraphQL schema definition language
+func (h *Handler) SDL() string {
+	return strings.TrimSpace(schema) + "\n"
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(h.SDL()))
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, &gql.Response{Errors: []*errors.QueryError{
+			errors.Errorf("%s is not allowed, POST queries as JSON", r.Method)}})
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &gql.Response{Errors: []*errors.QueryError{
+			errors.Errorf("the body must be a JSON object with a query")}})
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(h.conversations, h.usage, h.settings))
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	// Requests that could not be executed at all, e.g. invalid queries, have no data
+	status := http.StatusOK
+	if resp.Data == nil && len(resp.Errors) > 0 {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, resp *gql.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
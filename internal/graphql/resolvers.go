@@ -0,0 +1,282 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/graph-gophers/dataloader/v7"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+// Conversations reads conversations, see model.Repository
+type Conversations interface {
+	RecentUserConversations(ctx context.Context, platform, userID string, limit int) ([]*model.Conversation, error)
+	FindConversations(ctx context.Context, ids []string) ([]*model.Conversation, error)
+}
+
+// Usage sums the token usage of conversations, see billing.Repository
+type Usage interface {
+	SumConversationUsage(ctx context.Context, conversationIDs []string) (map[string]*billing.ConversationUsage, error)
+}
+
+// Settings reads user settings, see settings.Service
+type Settings interface {
+	Get(ctx context.Context, platform, userID string) (*settings.Settings, error)
+}
+
+const (
+	// maxConversations limits how many conversations one conversations field returns
+	maxConversations = 100
+	// maxConversationsPerRequest limits the conversations of all fields of a request, which aliases would multiply
+	maxConversationsPerRequest = 500
+	// batchWait is how long loaders collect keys before reading them
+	batchWait = 2 * time.Millisecond
+)
+
+// errInternal replaces the errors of failed reads in responses; the details are logged
+var errInternal = errors.New("internal error")
+
+func internalError(ctx context.Context, msg string, err error) error {
+	slog.ErrorContext(ctx, msg, "error", err)
+	return errInternal
+}
+
+// loaders batch and cache the reads of one request
+type loaders struct {
+	conversations *dataloader.Loader[string, *model.Conversation]
+	usage         *dataloader.Loader[string, *billing.ConversationUsage]
+	settings      *dataloader.Loader[userKey, *settings.Settings]
+
+	mu       sync.Mutex
+	selected int // Conversations returned so far, see maxConversationsPerRequest
+}
+
+type userKey struct{ platform, userID string }
+
+type loadersKey struct{}
+
+func newLoaders(conversations Conversations, usage Usage, userSettings Settings) *loaders {
+	return &loaders{
+		conversations: dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*model.Conversation] {
+			found, err := conversations.FindConversations(ctx, ids)
+			byID := make(map[string]*model.Conversation, len(found))
+			for _, conv := range found {
+				byID[conv.ID.Hex()] = conv
+			}
+			return results(ids, byID, err)
+		}, dataloader.WithWait[string, *model.Conversation](batchWait)),
+		usage: dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*billing.ConversationUsage] {
+			byID, err := usage.SumConversationUsage(ctx, ids)
+			return results(ids, byID, err)
+		}, dataloader.WithWait[string, *billing.ConversationUsage](batchWait)),
+		// Settings are read one user at a time, from the cache in front of MongoDB; the loader
+		// reads each user once however many of their conversations the query selects
+		settings: dataloader.NewBatchedLoader(func(ctx context.Context, users []userKey) []*dataloader.Result[*settings.Settings] {
+			out := make([]*dataloader.Result[*settings.Settings], len(users))
+			for i, user := range users {
+				s, err := userSettings.Get(ctx, user.platform, user.userID)
+				out[i] = &dataloader.Result[*settings.Settings]{Data: s, Error: err}
+			}
+			return out
+		}, dataloader.WithWait[userKey, *settings.Settings](batchWait)),
+	}
+}
+
+// results orders the values read for a batch like its keys; keys missing from values load nil
+func results[K comparable, V any](keys []K, values map[K]V, err error) []*dataloader.Result[V] {
+	out := make([]*dataloader.Result[V], len(keys))
+	for i, key := range keys {
+		out[i] = &dataloader.Result[V]{Data: values[key], Error: err}
+	}
+	return out
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// spend counts conversations against the budget of the request
+func (l *loaders) spend(n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.selected+n > maxConversationsPerRequest {
+		return fmt.Errorf("the query selects more than %d conversations", maxConversationsPerRequest)
+	}
+	l.selected += n
+	return nil
+}
+
+type queryResolver struct {
+	conversations Conversations
+}
+
+func (q *queryResolver) Conversations(ctx context.Context, args struct {
+	Platform string
+	UserID   string
+	First    int32 // Defaults to 20 in the schema
+}) ([]*conversationResolver, error) {
+	first := int(args.First)
+	if first < 1 || first > maxConversations {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxConversations)
+	}
+	if err := loadersFrom(ctx).spend(first); err != nil {
+		return nil, err
+	}
+
+	found, err := q.conversations.RecentUserConversations(ctx, args.Platform, args.UserID, first)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to list conversations", err)
+	}
+	out := make([]*conversationResolver, len(found))
+	for i, c := range found {
+		out[i] = &conversationResolver{c: c}
+	}
+	return out, nil
+}
+
+func (q *queryResolver) Conversation(ctx context.Context, args struct{ ID gql.ID }) (*conversationResolver, error) {
+	l := loadersFrom(ctx)
+	if err := l.spend(1); err != nil {
+		return nil, err
+	}
+	c, err := l.conversations.Load(ctx, string(args.ID))()
+	if err != nil {
+		return nil, internalError(ctx, "Failed to load conversation", err)
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return &conversationResolver{c: c}, nil
+}
+
+func (q *queryResolver) Settings(ctx context.Context, args struct{ Platform, UserID string }) (*settingsResolver, error) {
+	return loadSettings(ctx, args.Platform, args.UserID)
+}
+
+func loadSettings(ctx context.Context, platform, userID string) (*settingsResolver, error) {
+	s, err := loadersFrom(ctx).settings.Load(ctx, userKey{platform: platform, userID: userID})()
+	if err != nil {
+		return nil, internalError(ctx, "Failed to load user settings", err)
+	}
+	return &settingsResolver{s: s}, nil
+}
+
+type conversationResolver struct {
+	c *model.Conversation
+}
+
+func (r *conversationResolver) ID() gql.ID       { return gql.ID(r.c.ID.Hex()) }
+func (r *conversationResolver) Title() *string   { return optional(r.c.Title) }
+func (r *conversationResolver) Platform() string { return r.c.Platform }
+func (r *conversationResolver) UserID() string   { return r.c.UserID }
+
+func (r *conversationResolver) CreatedAt() gql.Time { return gql.Time{Time: r.c.CreatedAt.UTC()} }
+
+func (r *conversationResolver) LastActivity() gql.Time {
+	// Conversations created before activity was tracked only have an update time
+	if r.c.LastActivity.IsZero() {
+		return gql.Time{Time: r.c.UpdatedAt.UTC()}
+	}
+	return gql.Time{Time: r.c.LastActivity.UTC()}
+}
+
+func (r *conversationResolver) MessageCount() int32 { return int32(len(r.c.Messages)) }
+
+func (r *conversationResolver) LastMessage() *messageResolver {
+	if len(r.c.Messages) == 0 {
+		return nil
+	}
+	return &messageResolver{m: r.c.Messages[len(r.c.Messages)-1]}
+}
+
+// UnreadCount counts the assistant replies after the user's last message
+func (r *conversationResolver) UnreadCount() int32 {
+	var unread int32
+	for i := len(r.c.Messages) - 1; i >= 0 && r.c.Messages[i].Role != model.RoleUser; i-- {
+		if r.c.Messages[i].Role == model.RoleAssistant {
+			unread++
+		}
+	}
+	return unread
+}
+
+func (r *conversationResolver) Messages(args struct{ Last *int32 }) ([]*messageResolver, error) {
+	messages := r.c.Messages
+	if args.Last != nil {
+		if *args.Last < 0 {
+			return nil, errors.New("last must not be negative")
+		}
+		messages = messages[max(len(messages)-int(*args.Last), 0):]
+	}
+	out := make([]*messageResolver, len(messages))
+	for i, m := range messages {
+		out[i] = &messageResolver{m: m}
+	}
+	return out, nil
+}
+
+func (r *conversationResolver) Usage(ctx context.Context) (*usageResolver, error) {
+	id := r.c.ID.Hex()
+	u, err := loadersFrom(ctx).usage.Load(ctx, id)()
+	if err != nil {
+		return nil, internalError(ctx, "Failed to load conversation usage", err)
+	}
+	if u == nil {
+		u = &billing.ConversationUsage{ConversationID: id}
+	}
+	return &usageResolver{u: u}, nil
+}
+
+func (r *conversationResolver) Settings(ctx context.Context) (*settingsResolver, error) {
+	return loadSettings(ctx, r.c.Platform, r.c.UserID)
+}
+
+type messageResolver struct {
+	m *model.Message
+}
+
+func (r *messageResolver) ID() gql.ID          { return gql.ID(r.m.ID.Hex()) }
+func (r *messageResolver) Role() string        { return strings.ToUpper(string(r.m.Role)) }
+func (r *messageResolver) Content() string     { return r.m.Content }
+func (r *messageResolver) Author() *string     { return optional(r.m.Author) }
+func (r *messageResolver) CreatedAt() gql.Time { return gql.Time{Time: r.m.CreatedAt.UTC()} }
+
+type usageResolver struct {
+	u *billing.ConversationUsage
+}
+
+func (r *usageResolver) Requests() int32         { return toInt(r.u.Requests) }
+func (r *usageResolver) PromptTokens() int32     { return toInt(r.u.PromptTokens) }
+func (r *usageResolver) CompletionTokens() int32 { return toInt(r.u.CompletionTokens) }
+func (r *usageResolver) TotalTokens() int32      { return toInt(r.u.TotalTokens) }
+
+type settingsResolver struct {
+	s *settings.Settings
+}
+
+func (r *settingsResolver) Language() *string  { return optional(r.s.Language) }
+func (r *settingsResolver) Units() *string     { return optional(r.s.Units) }
+func (r *settingsResolver) Timezone() *string  { return optional(r.s.Timezone) }
+func (r *settingsResolver) Verbosity() *string { return optional(r.s.Verbosity) }
+
+// optional returns null for empty strings
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// toInt converts a count to a GraphQL Int, which is 32-bit
+func toInt(n int64) int32 {
+	return int32(min(n, math.MaxInt32))
+}
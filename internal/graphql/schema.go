@@ -0,0 +1,76 @@
+package graphql
+
+// schema is the GraphQL schema, resolved by queryResolver
+const schema = `
+schema {
+  query: Query
+}
+
+"""
+A time in RFC 3339 format, e.g. "2024-05-01T12:00:00Z"
+"""
+scalar Time
+
+type Query {
+  "Conversations of a user, most recently active first"
+  conversations(
+    platform: String!
+    userId: String!
+    "How many conversations to return, at most 100"
+    first: Int = 20
+  ): [Conversation!]!
+  conversation(id: ID!): Conversation
+  "Settings of a user"
+  settings(platform: String!, userId: String!): UserSettings!
+}
+
+type Conversation {
+  id: ID!
+  title: String
+  platform: String!
+  userId: String!
+  createdAt: Time!
+  lastActivity: Time!
+  messageCount: Int!
+  lastMessage: Message
+  "Replies after the user's last message; reads are not tracked, so the user's last message marks what they have seen"
+  unreadCount: Int!
+  "Messages oldest first"
+  messages("Only return the last messages" last: Int): [Message!]!
+  usage: Usage!
+  "Settings of the conversation's user"
+  settings: UserSettings!
+}
+
+"The author of a message"
+enum Role {
+  USER
+  ASSISTANT
+  SYSTEM
+}
+
+type Message {
+  id: ID!
+  role: Role!
+  content: String!
+  "The operator who added a SYSTEM note"
+  author: String
+  createdAt: Time!
+}
+
+"Tokens used by the replies of a conversation; failed replies are not counted"
+type Usage {
+  requests: Int!
+  promptTokens: Int!
+  completionTokens: Int!
+  totalTokens: Int!
+}
+
+"Preferences of a user; null fields use the defaults"
+type UserSettings {
+  language: String
+  units: String
+  timezone: String
+  verbosity: String
+}
+`
@@ -0,0 +1,92 @@
+package greeting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+)
+
+// DefaultPlatform keys the greeting of platforms without their own
+const DefaultPlatform = "default"
+
+// Data is available to greeting templates
+type Data struct {
+	Platform string
+	Persona  string // Empty until a persona is chosen for the conversation
+}
+
+// Parse parses a JSON object of greetings keyed by "<platform>", "<platform>/<persona>",
+// "default" or "default/<persona>"; values are text/templates executed with Data
+func Parse(data string) (map[string]string, error) {
+	greetings := make(map[string]string)
+	if strings.TrimSpace(data) == "" {
+		return greetings, nil
+	}
+	if err := json.Unmarshal([]byte(data), &greetings); err != nil {
+		return nil, fmt.Errorf("invalid greetings: %w", err)
+	}
+	return greetings, nil
+}
+
+// Greeter picks the greeting a conversation opens with
+// The most specific greeting applies: platform and persona, platform, default and persona, then default
+type Greeter struct {
+	templates map[string]*template.Template
+}
+
+// NewGreeter creates a greeter from parsed greetings; it returns nil when none is configured
+func NewGreeter(greetings map[string]string) (*Greeter, error) {
+	if len(greetings) == 0 {
+		return nil, nil
+	}
+	g := &Greeter{templates: make(map[string]*template.Template, len(greetings))}
+	for key, text := range greetings {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid greeting for %s: %w", key, err)
+		}
+		g.templates[key] = tmpl
+	}
+	return g, nil
+}
+
+// Greeting returns the greeting for a platform and persona, "" when none is configured
+// An empty greeting configured for a platform turns off the default one there
+func (g *Greeter) Greeting(ctx context.Context, platform, persona string) string {
+	if g == nil {
+		return ""
+	}
+	tmpl, ok := g.lookup(platform, persona)
+	if !ok {
+		return ""
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, Data{Platform: platform, Persona: persona}); err != nil {
+		slog.WarnContext(ctx, "Failed to render greeting", "platform", platform, "persona", persona, "error", err)
+		return ""
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func (g *Greeter) lookup(platform, persona string) (*template.Template, bool) {
+	var keys []string
+	if persona != "" {
+		keys = append(keys, platform+"/"+persona)
+	}
+	keys = append(keys, platform)
+	if persona != "" {
+		keys = append(keys, DefaultPlatform+"/"+persona)
+	}
+	keys = append(keys, DefaultPlatform)
+
+	for _, key := range keys {
+		if tmpl, ok := g.templates[key]; ok {
+			return tmpl, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,205 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Reasons a request is blocked by AccessControl
+const (
+	BlockIPDenied          = "ip_denied"
+	BlockIPNotAllowed      = "ip_not_allowed"
+	BlockCountryDenied     = "country_denied"
+	BlockCountryNotAllowed = "country_not_allowed"
+)
+
+// AccessConfig restricts which clients may reach the API by IP range and country
+// Empty lists impose nothing; deny rules win over allow rules
+type AccessConfig struct {
+	AllowCIDRs     []string // When set, only clients in these ranges are served
+	DenyCIDRs      []string // Clients in these ranges are refused
+	AllowCountries []string // ISO 3166 codes; when set, only clients located in these countries are served
+	DenyCountries  []string // ISO 3166 codes of countries whose clients are refused
+}
+
+// CountryResolver returns the ISO 3166 code of the country of an address, "" when unknown; see geoip.Reader
+type CountryResolver interface {
+	Country(ip netip.Addr) (string, error)
+}
+
+// AccessRecorder counts blocked requests, see metrics.Metrics
+type AccessRecorder interface {
+	RecordAccessBlocked(ctx context.Context, reason, country string)
+}
+
+// AccessControl refuses requests from denied IP ranges and countries
+// Private and loopback addresses have no country, so country rules never apply to them
+type AccessControl struct {
+	allow          []netip.Prefix
+	deny           []netip.Prefix
+	allowCountries []string
+	denyCountries  []string
+	countries      CountryResolver
+	recorder       AccessRecorder
+}
+
+// NewAccessControl creates an access control middleware
+// countries may be nil when no country rules are configured; recorder nil disables metrics
+func NewAccessControl(cfg AccessConfig, countries CountryResolver, recorder AccessRecorder) (*AccessControl, error) {
+	allow, err := parsePrefixes(cfg.AllowCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed range: %w", err)
+	}
+	deny, err := parsePrefixes(cfg.DenyCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denied range: %w", err)
+	}
+	a := &AccessControl{
+		allow:          allow,
+		deny:           deny,
+		allowCountries: upper(cfg.AllowCountries),
+		denyCountries:  upper(cfg.DenyCountries),
+		countries:      countries,
+		recorder:       recorder,
+	}
+	if countries == nil && (len(a.allowCountries) > 0 || len(a.denyCountries) > 0) {
+		return nil, errors.New("country rules need a GeoIP database")
+	}
+	return a, nil
+}
+
+// Enabled reports whether any rule is configured
+func (a *AccessControl) Enabled() bool {
+	return len(a.allow)+len(a.deny)+len(a.allowCountries)+len(a.denyCountries) > 0
+}
+
+// Middleware returns an HTTP middleware answering 403 to blocked clients
+func (a *AccessControl) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !a.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reason, country := a.Check(r.Context(), clientAddr(r))
+			if reason == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			slog.WarnContext(r.Context(), "Request blocked by access rules",
+				"reason", reason,
+				"country", country,
+				"ip", GetClientIP(r),
+				"method", r.Method,
+				"path", r.URL.Path,
+			)
+			if a.recorder != nil {
+				a.recorder.RecordAccessBlocked(r.Context(), reason, country)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error":   "forbidden",
+				"reason":  reason,
+				"message": "access from your network or location is not allowed",
+			})
+		})
+	}
+}
+
+// Check returns why a client address is blocked, "" when it is allowed, and its country when it was looked up
+// An address that cannot be parsed is only allowed when no allow rules are configured
+func (a *AccessControl) Check(ctx context.Context, ip netip.Addr) (reason, country string) {
+	if !ip.IsValid() {
+		if len(a.allow) > 0 {
+			return BlockIPNotAllowed, ""
+		}
+		if len(a.allowCountries) > 0 {
+			return BlockCountryNotAllowed, ""
+		}
+		return "", ""
+	}
+	ip = ip.Unmap()
+
+	if containsAddr(a.deny, ip) {
+		return BlockIPDenied, ""
+	}
+	if len(a.allow) > 0 && !containsAddr(a.allow, ip) {
+		return BlockIPNotAllowed, ""
+	}
+
+	if (len(a.allowCountries) == 0 && len(a.denyCountries) == 0) || ip.IsPrivate() || ip.IsLoopback() {
+		return "", ""
+	}
+	country, err := a.countries.Country(ip)
+	if err != nil {
+		slog.WarnContext(ctx, "GeoIP lookup failed", "error", err)
+	}
+	if slices.Contains(a.denyCountries, country) {
+		return BlockCountryDenied, country
+	}
+	if len(a.allowCountries) > 0 && !slices.Contains(a.allowCountries, country) {
+		return BlockCountryNotAllowed, country
+	}
+	return "", country
+}
+
+// clientAddr returns the address of the client, see GetClientIP
+func clientAddr(r *http.Request) netip.Addr {
+	ip := strings.TrimSpace(GetClientIP(r))
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	addr, _ := netip.ParseAddr(ip)
+	return addr
+}
+
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		// Single addresses are accepted as /32 or /128 ranges
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func upper(codes []string) []string {
+	out := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			out = append(out, code)
+		}
+	}
+	return out
+}
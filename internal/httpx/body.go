@@ -0,0 +1,21 @@
+package httpx
+
+import "net/http"
+
+// MaxBodySize returns an HTTP middleware that limits request bodies to limit bytes
+// Handlers reading past the limit get an error and the connection is closed after the response
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				w.Write([]byte(`{"error":"request_too_large","message":"request body too large"}`))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
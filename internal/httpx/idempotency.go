@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/twitchtv/twirp"
+)
+
+// Idempotency headers: clients name a request with IdempotencyKeyHeader, and replayed responses carry IdempotentReplayedHeader
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds the keys clients may choose, e.g. UUIDs or hashes
+const maxIdempotencyKeyLength = 255
+
+// idempotencyPendingTTL is how long a request holds its key while it runs; a crashed instance frees it afterwards
+const idempotencyPendingTTL = 5 * time.Minute
+
+// StoredResponse is a response kept for requests repeated with the same idempotency key
+type StoredResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// IdempotencyStore keeps the responses of requests sent with an idempotency key, see redisx.IdempotencyStore
+type IdempotencyStore interface {
+	// Claim holds the key for a request for ttl; when the key is taken it returns false, with the stored
+	// response if the request that took it completed
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, *StoredResponse, error)
+	// Save stores the response of the request holding the key for ttl
+	Save(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) error
+	// Release frees the key of a request that failed, so it can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency answers POST requests repeated with the same Idempotency-Key with the stored response of the
+// first one, so clients can retry writes such as starting a conversation without doing them twice
+// Only successful responses are stored; keys are scoped to the tenant and path
+func Idempotency(store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				twirp.WriteError(w, twirp.InvalidArgumentError(IdempotencyKeyHeader, "must be at most 255 characters"))
+				return
+			}
+
+			ctx := r.Context()
+			storeKey := tenant.Key(ctx, "idempotency:"+r.URL.Path+":"+key)
+			claimed, stored, err := store.Claim(ctx, storeKey, idempotencyPendingTTL)
+			if err != nil {
+				// Serving the request without the guarantee beats failing it
+				slog.WarnContext(ctx, "Failed to claim idempotency key, serving the request without it",
+					"path", r.URL.Path, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if stored != nil {
+				w.Header().Set("Content-Type", stored.ContentType)
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+			if !claimed {
+				twirp.WriteError(w, twirp.NewError(twirp.Aborted, "a request with this idempotency key is in progress, retry later"))
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// Failed and panicking requests free the key for a retry
+				saveCtx := context.WithoutCancel(ctx)
+				if completed && rec.status < 300 {
+					err = store.Save(saveCtx, storeKey, &StoredResponse{
+						Status:      rec.status,
+						ContentType: rec.Header().Get("Content-Type"),
+						Body:        rec.body.Bytes(),
+					}, ttl)
+				} else {
+					err = store.Release(saveCtx, storeKey)
+				}
+				if err != nil {
+					slog.WarnContext(saveCtx, "Failed to store idempotent response", "path", r.URL.Path, "error", err)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+			completed = true
+		})
+	}
+}
+
+// recordingWriter copies the response it writes, for storing it
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
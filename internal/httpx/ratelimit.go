@@ -1,57 +1,157 @@
 package httpx
 
 import (
+	"container/list"
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
 )
 
+// DefaultRateLimiterMaxKeys bounds the clients a rate limiter tracks unless WithMaxKeys sets another bound
+const DefaultRateLimiterMaxKeys = 100_000
+
+// RateLimiterKeyRecorder records the number of clients a rate limiter tracks, see metrics.Metrics
+type RateLimiterKeyRecorder interface {
+	RecordRateLimiterKeys(ctx context.Context, limiter string, count int64)
+}
+
+// RateLimiterOption configures a RateLimiter
+type RateLimiterOption func(*RateLimiter)
+
+// WithMaxKeys bounds the clients tracked at once; beyond it the least recently seen client is forgotten
+func WithMaxKeys(n int) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		if n > 0 {
+			rl.maxKeys = n
+		}
+	}
+}
+
+// WithIdleTTL forgets clients idle for d; shorter than the time a bucket takes to refill, it lets
+// clients back in early
+func WithIdleTTL(d time.Duration) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		if d > 0 {
+			rl.idleTTL = d
+		}
+	}
+}
+
+// WithKeyRecorder records the number of tracked clients under the limiter's name, e.g. "ip" or "tenant"
+func WithKeyRecorder(name string, recorder RateLimiterKeyRecorder) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.name = name
+		rl.recorder = recorder
+	}
+}
+
 // RateLimiter provides per-IP rate limiting
+// Clients are kept least recently seen last, so idle ones are forgotten in constant time and the
+// memory of the limiter stays bounded however many addresses it sees
 type RateLimiter struct {
-	mu       sync.RWMutex
-	limiters map[string]*rate.Limiter
+	mu       sync.Mutex
+	limiters map[string]*list.Element // Values are *limiterEntry
+	lru      *list.List
 	rps      rate.Limit
 	burst    int
+	idleTTL  time.Duration
+	maxKeys  int
+	name     string
+	recorder RateLimiterKeyRecorder
+}
+
+type limiterEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
 }
 
 // NewRateLimiter creates a new rate limiter with the given requests per second and burst
-func NewRateLimiter(rps float64, burst int) *RateLimiter {
-	return &RateLimiter{
-		limiters: make(map[string]*rate.Limiter),
+// Clients idle long enough for their bucket to refill are forgotten, since a new bucket treats them
+// the same; the idle time is at least a minute
+func NewRateLimiter(rps float64, burst int, opts ...RateLimiterOption) *RateLimiter {
+	idleTTL := time.Minute
+	if rps > 0 {
+		idleTTL = max(idleTTL, time.Duration(float64(burst)/rps*float64(time.Second)))
+	}
+	rl := &RateLimiter{
+		limiters: make(map[string]*list.Element),
+		lru:      list.New(),
 		rps:      rate.Limit(rps),
 		burst:    burst,
+		idleTTL:  idleTTL,
+		maxKeys:  DefaultRateLimiterMaxKeys,
+	}
+	for _, opt := range opts {
+		opt(rl)
 	}
+	return rl
+}
+
+// Len returns the number of clients the limiter tracks
+func (rl *RateLimiter) Len() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return len(rl.limiters)
 }
 
-// getLimiter returns the rate limiter for a given IP address
-func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
+// getLimiter returns the rate limiter for a given key, usually an IP address
+func (rl *RateLimiter) getLimiter(ctx context.Context, key string) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	limiter, exists := rl.limiters[ip]
-	if !exists {
-		limiter = rate.NewLimiter(rl.rps, rl.burst)
-		rl.limiters[ip] = limiter
+	now := time.Now()
+	if elem, exists := rl.limiters[key]; exists {
+		entry := elem.Value.(*limiterEntry)
+		entry.lastSeen = now
+		rl.lru.MoveToFront(elem)
+		return entry.limiter
 	}
 
-	return limiter
+	rl.evict(now)
+	entry := &limiterEntry{key: key, limiter: rate.NewLimiter(rl.rps, rl.burst), lastSeen: now}
+	rl.limiters[key] = rl.lru.PushFront(entry)
+	if rl.recorder != nil {
+		rl.recorder.RecordRateLimiterKeys(ctx, rl.name, int64(len(rl.limiters)))
+	}
+	return entry.limiter
+}
+
+// evict forgets idle clients, and the least recently seen ones while the limiter is full
+func (rl *RateLimiter) evict(now time.Time) {
+	for elem := rl.lru.Back(); elem != nil; elem = rl.lru.Back() {
+		entry := elem.Value.(*limiterEntry)
+		if len(rl.limiters) < rl.maxKeys && now.Sub(entry.lastSeen) < rl.idleTTL {
+			return
+		}
+		rl.lru.Remove(elem)
+		delete(rl.limiters, entry.key)
+	}
 }
 
 // Middleware returns an HTTP middleware that enforces rate limiting per IP
 func (rl *RateLimiter) Middleware() func(http.Handler) http.Handler {
+	// Get client IP (handle X-Forwarded-For and X-Real-IP)
+	return rl.MiddlewareByKey(GetClientIP)
+}
+
+// MiddlewareByKey returns an HTTP middleware that enforces rate limiting per key, e.g. per tenant
+func (rl *RateLimiter) MiddlewareByKey(keyFunc func(r *http.Request) string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get client IP (handle X-Forwarded-For and X-Real-IP)
-			ip := GetClientIP(r)
+			key := keyFunc(r)
 
-			limiter := rl.getLimiter(ip)
+			limiter := rl.getLimiter(r.Context(), key)
 
 			if !limiter.Allow() {
 				slog.WarnContext(r.Context(), "Rate limit exceeded",
-					"ip", ip,
+					"key", key,
+					"ip", GetClientIP(r),
 					"method", r.Method,
 					"path", r.URL.Path,
 					"user_agent", r.UserAgent(),
@@ -0,0 +1,113 @@
+package httpx
+
+import (
+	"context"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/twitchtv/twirp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDMeta is the error meta key that carries the trace ID of a failed RPC
+const TraceIDMeta = "trace_id"
+
+// TwirpRecorder receives per-RPC measurements, see metrics.Metrics
+type TwirpRecorder interface {
+	// RecordTwirpRequest counts a request; status is "success" or the Twirp error code
+	RecordTwirpRequest(ctx context.Context, method string, status string)
+	RecordTwirpLatency(ctx context.Context, method string, status string, duration time.Duration)
+}
+
+// Caller identifies who made an RPC
+type Caller struct {
+	TenantID     string
+	SigningKeyID string // Key that signed the request, "" when it was not signed
+}
+
+type twirpContextKey int
+
+const (
+	callerKey twirpContextKey = iota
+	startKey
+	errorCodeKey
+)
+
+// CallerFromContext returns the caller attached by TwirpHooks
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey).(Caller)
+	return caller, ok
+}
+
+// TwirpHooks records the count, latency and error code of every RPC, including requests rejected before
+// they reach a method, and attaches the Caller to the context
+// The tenant and signing key are read from the context, so the hooks run behind the tenant Resolver and RequestSigner
+func TwirpHooks(recorder TwirpRecorder) *twirp.ServerHooks {
+	return &twirp.ServerHooks{
+		RequestReceived: func(ctx context.Context) (context.Context, error) {
+			ctx = context.WithValue(ctx, startKey, time.Now())
+			return context.WithValue(ctx, callerKey, Caller{
+				TenantID:     tenant.FromContext(ctx),
+				SigningKeyID: SigningKeyID(ctx),
+			}), nil
+		},
+		Error: func(ctx context.Context, err twirp.Error) context.Context {
+			return context.WithValue(ctx, errorCodeKey, string(err.Code()))
+		},
+		ResponseSent: func(ctx context.Context) {
+			if recorder == nil {
+				return
+			}
+			status := "success"
+			if code, ok := ctx.Value(errorCodeKey).(string); ok {
+				status = code
+			}
+			method := rpcMethod(ctx)
+			recorder.RecordTwirpRequest(ctx, method, status)
+			if start, ok := ctx.Value(startKey).(time.Time); ok {
+				recorder.RecordTwirpLatency(ctx, method, status, time.Since(start))
+			}
+		},
+	}
+}
+
+// TraceErrors adds the trace ID of the request to the meta of errors returned by RPC methods,
+// so clients can quote it when reporting a failure
+func TraceErrors() twirp.Interceptor {
+	return func(next twirp.Method) twirp.Method {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			resp, err := next(ctx, req)
+			if err == nil {
+				return resp, nil
+			}
+			id := traceID(ctx)
+			if id == "" {
+				return resp, err
+			}
+
+			twerr, ok := err.(twirp.Error)
+			if !ok {
+				// Twirp reports other errors as internal errors anyway
+				twerr = twirp.InternalErrorWith(err)
+			}
+			return resp, twerr.WithMeta(TraceIDMeta, id)
+		}
+	}
+}
+
+// rpcMethod returns the name of the called method, "unknown" for requests that matched no route
+func rpcMethod(ctx context.Context) string {
+	if method, ok := twirp.MethodName(ctx); ok && method != "" {
+		return method
+	}
+	return "unknown"
+}
+
+// traceID returns the trace ID of the request, or "" when it is not traced
+func traceID(ctx context.Context) string {
+	spanContext := trace.SpanFromContext(ctx).SpanContext()
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,111 @@
+package identity
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// AdminHandler exposes identity linking over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	service *Service
+}
+
+// NewAdminHandler creates a new identity admin handler
+func NewAdminHandler(service *Service) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+// LinkRequest is the body of link requests
+type LinkRequest struct {
+	Owner    Identity `json:"owner"`    // Any identity of the user to link to
+	Identity Identity `json:"identity"` // The identity to link
+}
+
+// IdentitiesResponse lists the identities of a canonical user
+type IdentitiesResponse struct {
+	Canonical  Identity   `json:"canonical"`
+	Identities []Identity `json:"identities"`
+}
+
+// GetHandler handles GET /admin/identities?platform=...&user_id=...
+func (h *AdminHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	id := Identity{Platform: r.URL.Query().Get("platform"), UserID: r.URL.Query().Get("user_id")}
+	if id.IsZero() {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "platform and user_id are required"})
+		return
+	}
+
+	canonical, identities, err := h.service.Identities(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list identities", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list identities"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, IdentitiesResponse{Canonical: canonical, Identities: identities})
+}
+
+// LinkHandler handles POST /admin/identities/link
+func (h *AdminHandler) LinkHandler(w http.ResponseWriter, r *http.Request) {
+	var req LinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if req.Owner.IsZero() || req.Identity.IsZero() {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "owner and identity need a platform and user_id"})
+		return
+	}
+
+	if _, err := h.service.Link(r.Context(), req.Owner, req.Identity); err != nil {
+		if errors.Is(err, ErrAlreadyLinked) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to link identity", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to link identity"})
+		return
+	}
+
+	canonical, identities, err := h.service.Identities(r.Context(), req.Owner)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list identities", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list identities"})
+		return
+	}
+	writeJSON(w, http.StatusOK, IdentitiesResponse{Canonical: canonical, Identities: identities})
+}
+
+// UnlinkHandler handles POST /admin/identities/unlink with an Identity body
+func (h *AdminHandler) UnlinkHandler(w http.ResponseWriter, r *http.Request) {
+	var id Identity
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if id.IsZero() {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "platform and user_id are required"})
+		return
+	}
+
+	if err := h.service.Unlink(r.Context(), id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to unlink identity", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to unlink identity"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,42 @@
+package identity
+
+import (
+	"context"
+	"sort"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+// ConversationLister lists a platform user's conversations within the context's tenant, see model.Repository
+type ConversationLister interface {
+	ListUserConversations(ctx context.Context, platform, userID string) ([]*model.Conversation, error)
+}
+
+// LinkedConversations lists the conversations of every identity linked with the requested one,
+// so data exports cover the person's history on all platforms
+type LinkedConversations struct {
+	Identities    *Service
+	Conversations ConversationLister
+}
+
+// ListUserConversations returns the conversations of all linked identities, oldest first
+func (l LinkedConversations) ListUserConversations(ctx context.Context, platform, userID string) ([]*model.Conversation, error) {
+	_, identities, err := l.Identities.Identities(ctx, Identity{Platform: platform, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	var conversations []*model.Conversation
+	for _, id := range identities {
+		found, err := l.Conversations.ListUserConversations(ctx, id.Platform, id.UserID)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, found...)
+	}
+
+	sort.SliceStable(conversations, func(i, j int) bool {
+		return conversations[i].CreatedAt.Before(conversations[j].CreatedAt)
+	})
+	return conversations, nil
+}
@@ -0,0 +1,220 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+var (
+	// ErrNotFound is returned by stores when an identity is not linked to another
+	ErrNotFound = errors.New("identity not linked")
+	// ErrAlreadyLinked is returned when an identity already belongs to another user, or has identities of its own
+	ErrAlreadyLinked = errors.New("identity already linked to another user")
+	// ErrInvalidCode is returned for unknown, expired or already used link codes
+	ErrInvalidCode = errors.New("invalid or expired link code")
+)
+
+// linkCodeAlphabet leaves out characters that are easily confused, such as 0 and O
+const linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const linkCodeLength = 8
+
+// Identity is a user as seen by one platform, e.g. telegram:123
+type Identity struct {
+	Platform string `json:"platform" bson:"platform"`
+	UserID   string `json:"user_id" bson:"user_id"`
+}
+
+func (i Identity) String() string {
+	return i.Platform + ":" + i.UserID
+}
+
+// IsZero reports whether the identity is incomplete
+func (i Identity) IsZero() bool {
+	return i.Platform == "" || i.UserID == ""
+}
+
+// Link attaches a platform identity to a canonical user
+// The canonical user is its primary identity, the first one seen, so data stored before linking keeps its key
+type Link struct {
+	TenantID  string    `json:"-" bson:"tenant_id"`
+	Identity  Identity  `json:"identity" bson:"identity"`
+	Canonical Identity  `json:"canonical" bson:"canonical"`
+	LinkedAt  time.Time `json:"linked_at" bson:"linked_at"`
+}
+
+// Store persists links within the context's tenant, see MongoRepository
+type Store interface {
+	// FindLink returns ErrNotFound when the identity is not linked
+	FindLink(ctx context.Context, id Identity) (*Link, error)
+	// ListLinks returns the identities linked to a canonical user, oldest first
+	ListLinks(ctx context.Context, canonical Identity) ([]*Link, error)
+	SaveLink(ctx context.Context, link *Link) error
+	// DeleteLink returns ErrNotFound when the identity is not linked
+	DeleteLink(ctx context.Context, id Identity) error
+}
+
+// CodeStore keeps link codes until they expire, see redisx.Store
+type CodeStore interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	Set(ctx context.Context, key string, value interface{}) error
+	Delete(ctx context.Context, key string) error
+}
+
+// linkCode is the identity that issued a link code
+type linkCode struct {
+	TenantID string   `json:"tenant_id"`
+	Issuer   Identity `json:"issuer"`
+}
+
+// Service links platform identities of the same person under one canonical user
+// Per-user data such as settings and quotas is keyed by the canonical user, so it follows the person across platforms
+type Service struct {
+	store Store
+	codes CodeStore
+}
+
+// NewService creates an identity service; codes may be nil to disable link codes
+func NewService(store Store, codes CodeStore) *Service {
+	return &Service{store: store, codes: codes}
+}
+
+// Resolve returns the canonical user of a platform identity; unlinked identities are their own canonical user
+func (s *Service) Resolve(ctx context.Context, id Identity) (Identity, error) {
+	link, err := s.store.FindLink(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return id, nil
+	}
+	if err != nil {
+		return id, err
+	}
+	return link.Canonical, nil
+}
+
+// Identities returns the canonical user of id followed by every identity linked to it
+func (s *Service) Identities(ctx context.Context, id Identity) (Identity, []Identity, error) {
+	canonical, err := s.Resolve(ctx, id)
+	if err != nil {
+		return id, nil, err
+	}
+	links, err := s.store.ListLinks(ctx, canonical)
+	if err != nil {
+		return canonical, nil, err
+	}
+
+	identities := []Identity{canonical}
+	for _, link := range links {
+		identities = append(identities, link.Identity)
+	}
+	return canonical, identities, nil
+}
+
+// Link attaches id to the canonical user of owner and returns that canonical user
+// Linking an identity that is already linked to the same user is a no-op; identities of other users,
+// or that other identities are linked to, return ErrAlreadyLinked and must be unlinked first
+func (s *Service) Link(ctx context.Context, owner, id Identity) (Identity, error) {
+	if owner.IsZero() || id.IsZero() {
+		return Identity{}, errors.New("platform and user ID are required")
+	}
+
+	canonical, err := s.Resolve(ctx, owner)
+	if err != nil {
+		return Identity{}, err
+	}
+	if canonical == id {
+		return canonical, nil
+	}
+
+	existing, err := s.store.FindLink(ctx, id)
+	switch {
+	case err == nil && existing.Canonical == canonical:
+		return canonical, nil
+	case err == nil:
+		return Identity{}, ErrAlreadyLinked
+	case !errors.Is(err, ErrNotFound):
+		return Identity{}, err
+	}
+
+	// Merging two users would have to choose whose data wins; make the caller unlink instead
+	linked, err := s.store.ListLinks(ctx, id)
+	if err != nil {
+		return Identity{}, err
+	}
+	if len(linked) > 0 {
+		return Identity{}, ErrAlreadyLinked
+	}
+
+	err = s.store.SaveLink(ctx, &Link{
+		TenantID:  tenant.FromContext(ctx),
+		Identity:  id,
+		Canonical: canonical,
+		LinkedAt:  time.Now(),
+	})
+	if err != nil {
+		return Identity{}, err
+	}
+
+	slog.InfoContext(ctx, "Identity linked", "identity", id.String(), "canonical", canonical.String())
+	return canonical, nil
+}
+
+// Unlink detaches id from its canonical user; its data stays with the canonical user
+func (s *Service) Unlink(ctx context.Context, id Identity) error {
+	if err := s.store.DeleteLink(ctx, id); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Identity unlinked", "identity", id.String())
+	return nil
+}
+
+// CreateLinkCode issues a one-time code that links the identity redeeming it to issuer
+// The code expires with the code store's TTL
+func (s *Service) CreateLinkCode(ctx context.Context, issuer Identity) (string, error) {
+	if s.codes == nil {
+		return "", errors.New("link codes are not enabled")
+	}
+
+	buf := make([]byte, linkCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate link code: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = linkCodeAlphabet[int(b)%len(linkCodeAlphabet)]
+	}
+	code := string(buf)
+
+	if err := s.codes.Set(ctx, codeKey(code), linkCode{TenantID: tenant.FromContext(ctx), Issuer: issuer}); err != nil {
+		return "", fmt.Errorf("failed to store link code: %w", err)
+	}
+	return code, nil
+}
+
+// RedeemLinkCode links id to the issuer of code and returns their canonical user
+func (s *Service) RedeemLinkCode(ctx context.Context, code string, id Identity) (Identity, error) {
+	if s.codes == nil {
+		return Identity{}, ErrInvalidCode
+	}
+
+	key := codeKey(strings.ToUpper(strings.TrimSpace(code)))
+	var issued linkCode
+	if err := s.codes.Get(ctx, key, &issued); err != nil || issued.TenantID != tenant.FromContext(ctx) {
+		return Identity{}, ErrInvalidCode
+	}
+	// Codes are single use, even if linking fails
+	if err := s.codes.Delete(ctx, key); err != nil {
+		return Identity{}, fmt.Errorf("failed to delete link code: %w", err)
+	}
+
+	return s.Link(ctx, issued.Issuer, id)
+}
+
+func codeKey(code string) string {
+	return "identity:code:" + code
+}
@@ -0,0 +1,77 @@
+package identity
+
+import (
+	"context"
+	"errors"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const linkCollection = "identity_links"
+
+// MongoRepository stores identity links in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB identity link repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+func (r *MongoRepository) FindLink(ctx context.Context, id Identity) (*Link, error) {
+	var link Link
+	err := r.conn.Collection(linkCollection).FindOne(ctx, filter(ctx, id)).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *MongoRepository) ListLinks(ctx context.Context, canonical Identity) ([]*Link, error) {
+	cursor, err := r.conn.Collection(linkCollection).Find(ctx, bson.M{
+		"tenant_id":          tenant.FromContext(ctx),
+		"canonical.platform": canonical.Platform,
+		"canonical.user_id":  canonical.UserID,
+	}, options.Find().SetSort(bson.D{{Key: "linked_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*Link
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *MongoRepository) SaveLink(ctx context.Context, link *Link) error {
+	_, err := r.conn.Collection(linkCollection).ReplaceOne(ctx,
+		filter(ctx, link.Identity), link, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoRepository) DeleteLink(ctx context.Context, id Identity) error {
+	res, err := r.conn.Collection(linkCollection).DeleteOne(ctx, filter(ctx, id))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func filter(ctx context.Context, id Identity) bson.M {
+	return bson.M{
+		"tenant_id":         tenant.FromContext(ctx),
+		"identity.platform": id.Platform,
+		"identity.user_id":  id.UserID,
+	}
+}
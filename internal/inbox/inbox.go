@@ -0,0 +1,280 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/delivery"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/twitchtv/twirp"
+)
+
+// Message is an inbound chat message waiting to be answered
+type Message struct {
+	ID         string    `json:"-"` // Stream entry ID, set by Add and Read
+	TenantID   string    `json:"tenant_id,omitempty"`
+	Platform   string    `json:"platform"`
+	UserID     string    `json:"user_id"`
+	ChatID     string    `json:"chat_id"`
+	Text       string    `json:"text"`
+	ReceivedAt time.Time `json:"received_at"`
+	Deliveries int       `json:"-"` // Times the message was handed to a worker, set by Read and Claim
+}
+
+// Stream buffers inbound messages until a worker acknowledges them, see RedisStream
+type Stream interface {
+	// Add appends a message and returns its ID
+	Add(ctx context.Context, m *Message) (string, error)
+	// Read returns up to count messages no worker has read yet, waiting up to block when there are none
+	Read(ctx context.Context, consumer string, count int, block time.Duration) ([]*Message, error)
+	// Claim hands the consumer up to count messages read more than minIdle ago and never acknowledged,
+	// e.g. by a worker that died
+	Claim(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]*Message, error)
+	// Ack removes finished messages from the pending list
+	Ack(ctx context.Context, ids ...string) error
+	// Bury acknowledges a message and keeps it with the reason in the dead letters
+	Bury(ctx context.Context, m *Message, reason string) error
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// Stats counts the messages of an inbox
+type Stats struct {
+	Length  int64 `json:"length"`  // Messages kept in the stream, including answered ones until it is trimmed
+	Pending int64 `json:"pending"` // Messages read by a worker and not finished yet
+	Dead    int64 `json:"dead"`
+}
+
+// Processor answers a message in the chat's session conversation, see chat.Server
+type Processor interface {
+	ContinueConversation(ctx context.Context, req *pb.ContinueConversationRequest) (*pb.ContinueConversationResponse, error)
+}
+
+// Replier sends replies to users on their platform, see delivery.Service
+type Replier interface {
+	Supports(channel string) bool
+	Send(ctx context.Context, channel, recipient, text string) (*delivery.Message, error)
+}
+
+// Config controls the workers
+type Config struct {
+	Consumer      string        // Name of this instance in the consumer group; defaults to the host name and process ID
+	Workers       int           // Messages answered at the same time; messages of one chat are answered in order
+	BatchSize     int           // Messages read from the stream at once
+	Block         time.Duration // How long an idle reader waits for new messages
+	ClaimIdle     time.Duration // How long a message may stay unfinished before another worker takes it over
+	MaxDeliveries int           // Attempts before a message is moved to the dead letters
+	Timeout       time.Duration // How long answering one message may take
+}
+
+// Inbox buffers messages from bot platforms in a stream and answers them in the background,
+// so load spikes queue up instead of failing
+// Messages are answered at least once: a worker that dies leaves its messages to be claimed by another
+type Inbox struct {
+	stream    Stream
+	processor Processor
+	replier   Replier
+	cfg       Config
+}
+
+// New creates an inbox; replies are sent with replier
+func New(stream Stream, processor Processor, replier Replier, cfg Config) *Inbox {
+	if cfg.Consumer == "" {
+		host, _ := os.Hostname()
+		cfg.Consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10 * cfg.Workers
+	}
+	if cfg.Block <= 0 {
+		cfg.Block = 5 * time.Second
+	}
+	if cfg.ClaimIdle <= 0 {
+		cfg.ClaimIdle = 2 * time.Minute
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = 5
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Minute
+	}
+	return &Inbox{stream: stream, processor: processor, replier: replier, cfg: cfg}
+}
+
+// Enqueue adds a message of the context's tenant to the inbox
+func (i *Inbox) Enqueue(ctx context.Context, platform, userID, chatID, text string) (*Message, error) {
+	m := &Message{
+		TenantID:   tenant.FromContext(ctx),
+		Platform:   platform,
+		UserID:     userID,
+		ChatID:     chatID,
+		Text:       text,
+		ReceivedAt: time.Now(),
+	}
+	id, err := i.stream.Add(ctx, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue message: %w", err)
+	}
+	m.ID = id
+	return m, nil
+}
+
+// Stats counts the messages of the inbox
+func (i *Inbox) Stats(ctx context.Context) (Stats, error) {
+	return i.stream.Stats(ctx)
+}
+
+// Run answers messages until the context is cancelled
+// Messages are spread over the workers by chat, so each chat's messages are answered in order
+func (i *Inbox) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Inbox workers started", "consumer", i.cfg.Consumer, "workers", i.cfg.Workers)
+
+	shards := make([]chan *Message, i.cfg.Workers)
+	var wg sync.WaitGroup
+	for n := range shards {
+		shards[n] = make(chan *Message, i.cfg.BatchSize)
+		wg.Add(1)
+		go func(messages <-chan *Message) {
+			defer wg.Done()
+			for m := range messages {
+				i.process(ctx, m)
+			}
+		}(shards[n])
+	}
+
+	for ctx.Err() == nil {
+		messages, err := i.fetch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "Failed to read inbox", "error", err)
+				sleep(ctx, time.Second)
+			}
+			continue
+		}
+		for _, m := range messages {
+			shards[shard(m, len(shards))] <- m
+		}
+	}
+
+	for _, messages := range shards {
+		close(messages)
+	}
+	wg.Wait()
+	slog.InfoContext(ctx, "Inbox workers stopped", "consumer", i.cfg.Consumer)
+}
+
+// ProcessBatch answers the messages of one read, one after another, and returns how many it handled
+// Used by tests and tools; servers use Run
+func (i *Inbox) ProcessBatch(ctx context.Context) (int, error) {
+	messages, err := i.fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range messages {
+		i.process(ctx, m)
+	}
+	return len(messages), nil
+}
+
+// fetch takes over abandoned messages first, then reads new ones
+func (i *Inbox) fetch(ctx context.Context) ([]*Message, error) {
+	claimed, err := i.stream.Claim(ctx, i.cfg.Consumer, i.cfg.ClaimIdle, i.cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(claimed) > 0 {
+		return claimed, nil
+	}
+	return i.stream.Read(ctx, i.cfg.Consumer, i.cfg.BatchSize, i.cfg.Block)
+}
+
+func (i *Inbox) process(ctx context.Context, m *Message) {
+	ctx, cancel := context.WithTimeout(tenant.WithTenant(ctx, m.TenantID), i.cfg.Timeout)
+	defer cancel()
+
+	if m.Deliveries > i.cfg.MaxDeliveries {
+		i.bury(ctx, m, fmt.Sprintf("not answered after %d attempts", m.Deliveries-1))
+		return
+	}
+
+	resp, err := i.processor.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		Message:         m.Text,
+		SessionMetadata: &pb.SessionMetadata{Platform: m.Platform, UserId: m.UserID, ChatId: m.ChatID},
+	})
+	if err != nil {
+		if permanent(err) {
+			i.bury(ctx, m, err.Error())
+			return
+		}
+		// The message stays pending and is retried once another worker claims it
+		slog.WarnContext(ctx, "Failed to answer inbox message, will retry",
+			"message_id", m.ID,
+			"platform", m.Platform,
+			"attempt", m.Deliveries,
+			"error", err)
+		return
+	}
+
+	// The reply is stored in the conversation, so answering again would repeat it: the message is done
+	// even when the reply cannot be sent, and failed sends are tracked by the delivery service
+	if err := i.stream.Ack(ctx, m.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to acknowledge inbox message", "message_id", m.ID, "error", err)
+	}
+	if !i.replier.Supports(m.Platform) {
+		slog.WarnContext(ctx, "No channel to send inbox reply on", "message_id", m.ID, "platform", m.Platform)
+		return
+	}
+	// A new session opens with its greeting
+	if greeting := resp.GetGreeting(); greeting != "" {
+		if _, err := i.replier.Send(ctx, m.Platform, m.ChatID, greeting); err != nil {
+			slog.ErrorContext(ctx, "Failed to queue inbox greeting", "message_id", m.ID, "platform", m.Platform, "error", err)
+		}
+	}
+	if _, err := i.replier.Send(ctx, m.Platform, m.ChatID, resp.GetReply()); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue inbox reply", "message_id", m.ID, "platform", m.Platform, "error", err)
+	}
+}
+
+func (i *Inbox) bury(ctx context.Context, m *Message, reason string) {
+	slog.WarnContext(ctx, "Inbox message moved to dead letters",
+		"message_id", m.ID,
+		"platform", m.Platform,
+		"reason", reason)
+	if err := i.stream.Bury(ctx, m, reason); err != nil {
+		slog.ErrorContext(ctx, "Failed to bury inbox message", "message_id", m.ID, "error", err)
+	}
+}
+
+// permanent reports errors that answering the message again cannot fix, e.g. an invalid or blocked request
+func permanent(err error) bool {
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) {
+		return false
+	}
+	status := twirp.ServerHTTPStatusFromErrorCode(twerr.Code())
+	return status >= 400 && status < 500 && twerr.Code() != twirp.ResourceExhausted && twerr.Code() != twirp.Canceled
+}
+
+// shard maps a chat to a worker
+func shard(m *Message, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join([]string{m.TenantID, m.Platform, m.ChatID}, "\x00")))
+	return int(h.Sum32() % uint32(n))
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,241 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	group        = "workers"
+	messageField = "message"
+	// maxDead bounds the dead letters, dropping the oldest
+	maxDead = 10000
+)
+
+// RedisStream keeps an inbox in a Redis Stream read by the "workers" consumer group, so messages survive
+// restarts and are shared across instances
+// Unacknowledged messages stay in the group's pending list until a worker claims them again
+type RedisStream struct {
+	client *redis.Client
+	stream string
+	dead   string
+	maxLen int64
+
+	mu    sync.Mutex
+	ready bool // Whether the consumer group is known to exist
+}
+
+// NewRedisStream creates the stream of the named inbox; maxLen bounds the stream, trimming the oldest
+// answered messages, and zero keeps them all
+func NewRedisStream(client *redis.Client, name string, maxLen int64) *RedisStream {
+	// The hash tag keeps an inbox's keys in one cluster slot
+	prefix := "inbox:{" + name + "}:"
+	return &RedisStream{
+		client: client,
+		stream: prefix + "messages",
+		dead:   prefix + "dead",
+		maxLen: maxLen,
+	}
+}
+
+// Add appends a message and returns its ID
+func (s *RedisStream) Add(ctx context.Context, m *Message) (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+	id, err := s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: s.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{messageField: data},
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to add message: %w", err)
+	}
+	return id, nil
+}
+
+// Read returns up to count new messages, waiting up to block when there are none
+func (s *RedisStream) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]*Message, error) {
+	if err := s.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{s.stream, ">"},
+		Count:    int64(count),
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		s.checkGroup(err)
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	var messages []*Message
+	for _, stream := range streams {
+		for _, entry := range stream.Messages {
+			if m := s.decode(ctx, entry); m != nil {
+				m.Deliveries = 1
+				messages = append(messages, m)
+			}
+		}
+	}
+	return messages, nil
+}
+
+// Claim hands the consumer up to count messages read more than minIdle ago and never acknowledged
+func (s *RedisStream) Claim(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]*Message, error) {
+	if err := s.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+	entries, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   s.stream,
+		Group:    group,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+		Consumer: consumer,
+	}).Result()
+	if err != nil {
+		s.checkGroup(err)
+		return nil, fmt.Errorf("failed to claim messages: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	// Claiming counts as a delivery; the counts tell when to give up on a message
+	pending, err := s.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   s.stream,
+		Group:    group,
+		Start:    entries[0].ID,
+		End:      entries[len(entries)-1].ID,
+		Count:    int64(len(entries)),
+		Consumer: consumer,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delivery counts: %w", err)
+	}
+	deliveries := make(map[string]int64, len(pending))
+	for _, p := range pending {
+		deliveries[p.ID] = p.RetryCount
+	}
+
+	var messages []*Message
+	for _, entry := range entries {
+		if m := s.decode(ctx, entry); m != nil {
+			m.Deliveries = int(deliveries[entry.ID])
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+// Ack removes finished messages from the pending list
+func (s *RedisStream) Ack(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.client.XAck(ctx, s.stream, group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to acknowledge messages: %w", err)
+	}
+	return nil
+}
+
+// Bury acknowledges a message and adds it with the reason to the dead letter stream
+func (s *RedisStream) Bury(ctx context.Context, m *Message, reason string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.dead,
+		MaxLen: maxDead,
+		Approx: true,
+		Values: map[string]interface{}{
+			messageField: data,
+			"id":         m.ID,
+			"reason":     reason,
+			"buried_at":  time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	pipe.XAck(ctx, s.stream, group, m.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to bury message: %w", err)
+	}
+	return nil
+}
+
+// Stats counts the messages of the inbox
+func (s *RedisStream) Stats(ctx context.Context) (Stats, error) {
+	pipe := s.client.Pipeline()
+	length := pipe.XLen(ctx, s.stream)
+	pending := pipe.XPending(ctx, s.stream, group)
+	dead := pipe.XLen(ctx, s.dead)
+	// A missing group only means no worker has started yet
+	if _, err := pipe.Exec(ctx); err != nil && !isNoGroup(err) {
+		return Stats{}, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	stats := Stats{Length: length.Val(), Dead: dead.Val()}
+	if p, err := pending.Result(); err == nil {
+		stats.Pending = p.Count
+	}
+	return stats, nil
+}
+
+// ensureGroup creates the stream and its consumer group on first use
+func (s *RedisStream) ensureGroup(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+
+	err := s.client.XGroupCreateMkStream(ctx, s.stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	s.ready = true
+	return nil
+}
+
+// checkGroup forgets the consumer group when Redis lost it, e.g. after a flush, so it is created again
+func (s *RedisStream) checkGroup(err error) {
+	if isNoGroup(err) {
+		s.mu.Lock()
+		s.ready = false
+		s.mu.Unlock()
+	}
+}
+
+// decode returns the message of a stream entry; entries that cannot be decoded are acknowledged and dropped
+func (s *RedisStream) decode(ctx context.Context, entry redis.XMessage) *Message {
+	data, _ := entry.Values[messageField].(string)
+	var m Message
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		// Entries trimmed from the stream are claimed without values
+		s.client.XAck(ctx, s.stream, group, entry.ID)
+		return nil
+	}
+	m.ID = entry.ID
+	return &m
+}
+
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
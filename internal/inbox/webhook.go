@@ -0,0 +1,118 @@
+package inbox
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TelegramSecretHeader carries the secret_token given to Telegram's setWebhook
+const TelegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookHandler accepts inbound messages over HTTP and queues them in the inbox
+// Webhooks answer as soon as the message is queued; replies are sent through the delivery channels
+type WebhookHandler struct {
+	inbox          *Inbox
+	telegramSecret string
+}
+
+// NewWebhookHandler creates a webhook handler; an empty telegramSecret rejects every Telegram update
+func NewWebhookHandler(inbox *Inbox, telegramSecret string) *WebhookHandler {
+	return &WebhookHandler{inbox: inbox, telegramSecret: telegramSecret}
+}
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		From *struct {
+			ID int64 `json:"id"`
+		} `json:"from"`
+	} `json:"message"`
+}
+
+// TelegramHandler handles POST /webhooks/telegram
+// Updates without text are acknowledged and dropped, so Telegram does not send them again
+func (h *WebhookHandler) TelegramHandler(w http.ResponseWriter, r *http.Request) {
+	secret := r.Header.Get(TelegramSecretHeader)
+	if h.telegramSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.telegramSecret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid secret token"})
+		return
+	}
+
+	var update telegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid update"})
+		return
+	}
+	if update.Message == nil || strings.TrimSpace(update.Message.Text) == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	chatID := strconv.FormatInt(update.Message.Chat.ID, 10)
+	userID := chatID
+	if update.Message.From != nil {
+		userID = strconv.FormatInt(update.Message.From.ID, 10)
+	}
+	if _, err := h.inbox.Enqueue(r.Context(), "telegram", userID, chatID, update.Message.Text); err != nil {
+		// Telegram retries failed updates, so the message is not lost
+		slog.ErrorContext(r.Context(), "Failed to enqueue Telegram update", "update_id", update.UpdateID, "error", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "inbox unavailable"})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// EnqueueRequest is the body of POST /webhooks/inbox
+type EnqueueRequest struct {
+	Platform string `json:"platform"`
+	UserID   string `json:"user_id"`
+	ChatID   string `json:"chat_id"`
+	Text     string `json:"text"`
+}
+
+// EnqueueHandler handles POST /webhooks/inbox for bot platforms without a dedicated webhook
+// It must be mounted behind API key authentication
+func (h *WebhookHandler) EnqueueHandler(w http.ResponseWriter, r *http.Request) {
+	var req EnqueueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return
+	}
+	if req.Platform == "" || req.UserID == "" || req.ChatID == "" || strings.TrimSpace(req.Text) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "platform, user_id, chat_id and text are required"})
+		return
+	}
+
+	m, err := h.inbox.Enqueue(r.Context(), req.Platform, req.UserID, req.ChatID, req.Text)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to enqueue message", "platform", req.Platform, "error", err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "inbox unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"message_id": m.ID})
+}
+
+// StatsHandler handles GET /admin/inbox
+func (h *WebhookHandler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.inbox.Stats(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to count inbox messages", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count inbox messages"})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,253 @@
+// Package inflight keeps one reply at a time in flight per conversation, so quick successive messages
+// do not generate interleaved replies from the same history
+// The guard is per process; deployments that spread a chat over instances route it with sticky sessions
+// or through the inbox, which answers each chat's messages in order
+package inflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// DefaultPlatform holds the policy for platforms without their own, and for requests without a platform
+const DefaultPlatform = "default"
+
+// Policy decides what a message does while a reply to an earlier one is being generated
+type Policy string
+
+const (
+	// PolicyQueue answers the message after the earlier reply, seeing it in the history
+	PolicyQueue Policy = "queue"
+	// PolicyMerge cancels the earlier generation and answers both messages with one reply
+	// An earlier reply that is already being stored is kept, and the message waits for it as with PolicyQueue
+	PolicyMerge Policy = "merge"
+	// PolicyReject fails the message with ErrBusy
+	PolicyReject Policy = "reject"
+	// PolicyOff lets replies run concurrently
+	PolicyOff Policy = "off"
+)
+
+var (
+	// ErrBusy is returned by PolicyReject while a reply is in flight
+	ErrBusy = errors.New("a reply to an earlier message is still being generated")
+	// ErrSuperseded is returned to, and is the cancellation cause of, requests whose message was merged into a newer one
+	ErrSuperseded = errors.New("message was merged into a newer one")
+)
+
+// ParsePolicy validates a policy name
+func ParsePolicy(name string) (Policy, error) {
+	switch policy := Policy(name); policy {
+	case PolicyQueue, PolicyMerge, PolicyReject, PolicyOff:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown concurrency policy %q, want queue, merge, reject or off", name)
+	}
+}
+
+// ParsePolicies parses platform -> policy names; DefaultPlatform falls back to PolicyQueue
+func ParsePolicies(names map[string]string) (map[string]Policy, error) {
+	policies := map[string]Policy{DefaultPlatform: PolicyQueue}
+	for platform, name := range names {
+		policy, err := ParsePolicy(name)
+		if err != nil {
+			return nil, fmt.Errorf("platform %s: %w", platform, err)
+		}
+		policies[platform] = policy
+	}
+	return policies, nil
+}
+
+// Ticket is a request's turn to generate a reply
+type Ticket struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	key      string
+	messages []string
+	ready    chan struct{} // Closed when the ticket runs or is superseded while waiting
+
+	// Guarded by Guard.mu
+	superseded bool
+	committed  bool
+}
+
+// Context is cancelled with ErrSuperseded when a newer message takes over the ticket's messages
+func (t *Ticket) Context() context.Context {
+	return t.ctx
+}
+
+// Messages returns the user messages to answer, oldest first: the request's own message,
+// preceded by the messages of earlier requests merged into it
+func (t *Ticket) Messages() []string {
+	return t.messages
+}
+
+type slot struct {
+	running *Ticket
+	waiting []*Ticket // In arrival order
+}
+
+// Guard tracks the replies in flight per conversation
+type Guard struct {
+	policies map[string]Policy
+
+	mu    sync.Mutex
+	slots map[string]*slot
+}
+
+// NewGuard creates a guard with platform -> policy; platforms not listed use the DefaultPlatform policy
+func NewGuard(policies map[string]Policy) *Guard {
+	return &Guard{policies: policies, slots: make(map[string]*slot)}
+}
+
+// Policy returns the policy of a platform
+func (g *Guard) Policy(platform string) Policy {
+	if policy, ok := g.policies[platform]; ok {
+		return policy
+	}
+	if policy, ok := g.policies[DefaultPlatform]; ok {
+		return policy
+	}
+	return PolicyQueue
+}
+
+// Begin waits for the conversation's turn to answer message and returns the ticket to answer it with
+// Callers call Commit before storing the reply and Release when finished
+// It fails with ErrBusy under PolicyReject, with ErrSuperseded when a newer message took over this one
+// while it waited, and with the context's error when the request is cancelled while waiting
+func (g *Guard) Begin(ctx context.Context, platform, conversationID, message string) (*Ticket, error) {
+	ticketCtx, cancel := context.WithCancelCause(ctx)
+	t := &Ticket{
+		ctx:      ticketCtx,
+		cancel:   cancel,
+		key:      tenant.Key(ctx, conversationID),
+		messages: []string{message},
+		ready:    make(chan struct{}),
+	}
+	policy := g.Policy(platform)
+	if policy == PolicyOff {
+		close(t.ready)
+		return t, nil
+	}
+
+	g.mu.Lock()
+	s, ok := g.slots[t.key]
+	if !ok {
+		s = &slot{}
+		g.slots[t.key] = s
+	}
+	if s.running == nil && len(s.waiting) == 0 {
+		s.running = t
+		g.mu.Unlock()
+		return t, nil
+	}
+
+	switch policy {
+	case PolicyReject:
+		g.mu.Unlock()
+		cancel(ErrBusy)
+		return nil, ErrBusy
+	case PolicyMerge:
+		// Take over the messages of everything not answered yet
+		var merged []string
+		if r := s.running; r != nil && !r.committed && !r.superseded {
+			r.superseded = true
+			merged = append(merged, r.messages...)
+			r.cancel(ErrSuperseded)
+		}
+		for _, w := range s.waiting {
+			w.superseded = true
+			merged = append(merged, w.messages...)
+			close(w.ready)
+		}
+		s.waiting = nil
+		t.messages = append(merged, message)
+	}
+	s.waiting = append(s.waiting, t)
+	g.mu.Unlock()
+
+	select {
+	case <-t.ready:
+	case <-ctx.Done():
+		g.mu.Lock()
+		waiting := g.remove(s, t)
+		g.mu.Unlock()
+		if !waiting {
+			// The turn arrived together with the cancellation, pass it on
+			g.Release(t)
+		}
+		cancel(context.Cause(ctx))
+		return nil, ctx.Err()
+	}
+
+	g.mu.Lock()
+	superseded := t.superseded
+	g.mu.Unlock()
+	if superseded {
+		cancel(ErrSuperseded)
+		return nil, ErrSuperseded
+	}
+	return t, nil
+}
+
+// remove drops a waiting ticket, reporting whether it was still waiting
+// Callers hold the lock
+func (g *Guard) remove(s *slot, t *Ticket) bool {
+	for i, w := range s.waiting {
+		if w == t {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			g.cleanup(t.key, s)
+			return true
+		}
+	}
+	return false
+}
+
+// Commit marks the reply as final before it is stored, so newer messages no longer merge into it
+// It returns false when the ticket was superseded and the reply must be discarded
+func (g *Guard) Commit(t *Ticket) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if t.superseded {
+		return false
+	}
+	t.committed = true
+	return true
+}
+
+// Release ends the ticket's turn and starts the next waiting request
+func (g *Guard) Release(t *Ticket) {
+	t.cancel(nil)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.slots[t.key]
+	if !ok || s.running != t {
+		return
+	}
+	s.running = nil
+	if len(s.waiting) > 0 {
+		s.running = s.waiting[0]
+		s.waiting = s.waiting[1:]
+		close(s.running.ready)
+	}
+	g.cleanup(t.key, s)
+}
+
+// cleanup forgets idle conversations
+// Callers hold the lock
+func (g *Guard) cleanup(key string, s *slot) {
+	if s.running == nil && len(s.waiting) == 0 {
+		delete(g.slots, key)
+	}
+}
+
+// InFlight returns the number of conversations with a reply running or waiting
+func (g *Guard) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.slots)
+}
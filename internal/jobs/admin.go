@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler exposes queue state and dead letters over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	queue *Queue
+}
+
+// NewAdminHandler creates a new job queue admin handler
+func NewAdminHandler(queue *Queue) *AdminHandler {
+	return &AdminHandler{queue: queue}
+}
+
+// StatsHandler handles GET /admin/jobs
+func (h *AdminHandler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.queue.Stats(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to count jobs", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count jobs"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// DeadLettersHandler handles GET /admin/jobs/dead?limit=
+func (h *AdminHandler) DeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := h.queue.DeadLetters(r.Context(), limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list dead letters", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list dead letters"})
+		return
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"jobs": jobs})
+}
+
+// RequeueHandler handles POST /admin/jobs/dead/{job_id}/requeue
+func (h *AdminHandler) RequeueHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["job_id"]
+	err := h.queue.Requeue(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to requeue job", "job_id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to requeue job"})
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Dead job requeued", "job_id", id)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "requeued"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,397 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/otel"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Job results recorded in metrics
+const (
+	ResultSucceeded = "succeeded"
+	ResultRetried   = "retried"
+	ResultDead      = "dead"
+)
+
+var (
+	// ErrUnknownType is the error of jobs no handler is registered for; they go straight to the dead letters
+	ErrUnknownType = errors.New("no handler for job type")
+	ErrNotFound    = errors.New("job not found")
+)
+
+// Job is a unit of background work; handlers must tolerate running a job more than once,
+// as a job whose worker dies before finishing it is run again
+type Job struct {
+	ID          string            `json:"id"`
+	Type        string            `json:"type"`
+	TenantID    string            `json:"tenant_id,omitempty"`
+	Payload     json.RawMessage   `json:"payload,omitempty"`
+	Attempts    int               `json:"attempts"`
+	MaxAttempts int               `json:"max_attempts"`
+	RunAt       time.Time         `json:"run_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+	LastError   string            `json:"last_error,omitempty"`
+	Trace       map[string]string `json:"trace,omitempty"` // Trace context of the request that enqueued the job
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid %s payload: %w", j.Type, err))
+	}
+	return nil
+}
+
+// Handler runs a job; the context carries the job's tenant and is cancelled after Config.Timeout
+type Handler func(ctx context.Context, job *Job) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error that retrying cannot fix; the job goes to the dead letters at once
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Store persists queued jobs, see RedisStore
+type Store interface {
+	// Push stores a job to run at job.RunAt
+	Push(ctx context.Context, job *Job) error
+	// Pop leases the job that has been due longest until now+lease, or returns nil when none is due
+	// Jobs whose lease has run out are due again
+	Pop(ctx context.Context, now time.Time, lease time.Duration) (*Job, error)
+	// Ack removes a finished job
+	Ack(ctx context.Context, job *Job) error
+	// Retry stores a failed job to run again at job.RunAt
+	Retry(ctx context.Context, job *Job) error
+	// Bury moves a failed job to the dead letters, keeping at most limit of them
+	Bury(ctx context.Context, job *Job, limit int) error
+	// DeadLetters returns the most recently buried jobs
+	DeadLetters(ctx context.Context, limit int) ([]*Job, error)
+	// Requeue moves a buried job back to the queue to run now with all its attempts;
+	// returns ErrNotFound for jobs that are not buried
+	Requeue(ctx context.Context, id string, now time.Time) error
+	// Stats counts the queued, running and buried jobs
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// Stats counts the jobs of a queue
+type Stats struct {
+	Scheduled int64 `json:"scheduled"` // Due or delayed jobs, including those waiting for a retry
+	Running   int64 `json:"running"`
+	Dead      int64 `json:"dead"`
+}
+
+// Recorder records processed jobs, see metrics.Metrics
+type Recorder interface {
+	RecordJob(ctx context.Context, queue, jobType, result string, duration time.Duration)
+}
+
+// Config controls the worker pool and retries
+type Config struct {
+	Workers         int           // Jobs run at the same time by this instance
+	MaxAttempts     int           // Attempts before a job is buried, unless set when enqueued
+	BaseBackoff     time.Duration // Delay before the first retry, doubled on each further retry
+	MaxBackoff      time.Duration // Upper bound for retry delays
+	PollInterval    time.Duration // How often idle workers look for due jobs
+	Timeout         time.Duration // How long a job may run
+	DeadLetterLimit int           // Buried jobs kept for inspection; older ones are dropped
+}
+
+// leaseMargin keeps a job leased a little beyond its timeout so a slow worker does not race a retry
+const leaseMargin = 30 * time.Second
+
+// Queue runs background jobs with retries and dead letters, shared by all instances using the same store
+type Queue struct {
+	name     string
+	store    Store
+	cfg      Config
+	recorder Recorder
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewQueue creates a queue; handlers are added with Handle before Run; recorder may be nil
+func NewQueue(name string, store Store, cfg Config, recorder Recorder) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 10 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = max(time.Hour, cfg.BaseBackoff)
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	if cfg.DeadLetterLimit <= 0 {
+		cfg.DeadLetterLimit = 1000
+	}
+
+	return &Queue{
+		name:     name,
+		store:    store,
+		cfg:      cfg,
+		recorder: recorder,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Handle registers the handler of a job type
+func (q *Queue) Handle(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+func (q *Queue) handler(jobType string) Handler {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[jobType]
+}
+
+// EnqueueOption customizes a job when it is enqueued
+type EnqueueOption func(*Job)
+
+// Delay runs the job no earlier than d from now
+func Delay(d time.Duration) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = j.RunAt.Add(d)
+	}
+}
+
+// At runs the job no earlier than t
+func At(t time.Time) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = t
+	}
+}
+
+// MaxAttempts overrides the queue's attempts before the job is buried
+func MaxAttempts(n int) EnqueueOption {
+	return func(j *Job) {
+		if n > 0 {
+			j.MaxAttempts = n
+		}
+	}
+}
+
+// Enqueue stores a job of the context's tenant; payload is marshalled to JSON
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any, opts ...EnqueueOption) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", jobType, err)
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          primitive.NewObjectID().Hex(),
+		Type:        jobType,
+		TenantID:    tenant.FromContext(ctx),
+		Payload:     data,
+		MaxAttempts: q.cfg.MaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		Trace:       make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	propagation.TraceContext{}.Inject(ctx, propagation.MapCarrier(job.Trace))
+
+	if err := q.store.Push(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+
+	slog.DebugContext(ctx, "Job enqueued", "queue", q.name, "job_id", job.ID, "type", jobType, "run_at", job.RunAt)
+	return job, nil
+}
+
+// DeadLetters returns the most recently buried jobs of all tenants
+func (q *Queue) DeadLetters(ctx context.Context, limit int) ([]*Job, error) {
+	if limit <= 0 || limit > q.cfg.DeadLetterLimit {
+		limit = min(100, q.cfg.DeadLetterLimit)
+	}
+	return q.store.DeadLetters(ctx, limit)
+}
+
+// Requeue runs a buried job again
+func (q *Queue) Requeue(ctx context.Context, id string) error {
+	return q.store.Requeue(ctx, id, time.Now())
+}
+
+// Stats counts the queue's jobs
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	return q.store.Stats(ctx)
+}
+
+// Run processes due jobs with the configured number of workers until the context is cancelled
+// Running jobs are allowed to finish before Run returns
+func (q *Queue) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Job workers started", "queue", q.name, "workers", q.cfg.Workers)
+
+	var wg sync.WaitGroup
+	for range q.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	slog.InfoContext(ctx, "Job workers stopped", "queue", q.name)
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := q.ProcessNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Failed to fetch job", "queue", q.name, "error", err)
+		}
+		if processed {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(q.cfg.PollInterval):
+		}
+	}
+}
+
+// ProcessNext runs the job that has been due longest and reports whether there was one
+func (q *Queue) ProcessNext(ctx context.Context) (bool, error) {
+	job, err := q.store.Pop(ctx, time.Now(), q.cfg.Timeout+leaseMargin)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	// A started job is finished even when the workers are stopped
+	ctx = context.WithoutCancel(ctx)
+	q.process(ctx, job)
+	return true, nil
+}
+
+func (q *Queue) process(ctx context.Context, job *Job) {
+	ctx = tenant.WithTenant(ctx, job.TenantID)
+	ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier(job.Trace))
+	ctx, span := otel.GetTracer().Start(ctx, "job "+job.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.queue", q.name),
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", job.Type),
+			attribute.Int("job.attempt", job.Attempts+1),
+		))
+	defer span.End()
+
+	job.Attempts++
+	start := time.Now()
+	err := q.run(ctx, job)
+	duration := time.Since(start)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	result := q.finish(ctx, job, err)
+	if q.recorder != nil {
+		q.recorder.RecordJob(ctx, q.name, job.Type, result, duration)
+	}
+}
+
+// run calls the job's handler, turning a panic into an error
+func (q *Queue) run(ctx context.Context, job *Job) (err error) {
+	handler := q.handler(job.Type)
+	if handler == nil {
+		return Permanent(fmt.Errorf("%w: %s", ErrUnknownType, job.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.Timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// finish acknowledges, retries or buries a job after an attempt and returns the result
+func (q *Queue) finish(ctx context.Context, job *Job, err error) string {
+	if err == nil {
+		if err := q.store.Ack(ctx, job); err != nil {
+			slog.ErrorContext(ctx, "Failed to acknowledge job", "queue", q.name, "job_id", job.ID, "error", err)
+		}
+		slog.DebugContext(ctx, "Job succeeded", "queue", q.name, "job_id", job.ID, "type", job.Type, "attempts", job.Attempts)
+		return ResultSucceeded
+	}
+
+	job.LastError = err.Error()
+	var permanent *permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		slog.ErrorContext(ctx, "Job failed, moving it to the dead letters",
+			"queue", q.name,
+			"job_id", job.ID,
+			"type", job.Type,
+			"attempts", job.Attempts,
+			"error", err)
+		if err := q.store.Bury(ctx, job, q.cfg.DeadLetterLimit); err != nil {
+			slog.ErrorContext(ctx, "Failed to bury job", "queue", q.name, "job_id", job.ID, "error", err)
+		}
+		return ResultDead
+	}
+
+	delay := Backoff(q.cfg.BaseBackoff, q.cfg.MaxBackoff, job.Attempts)
+	job.RunAt = time.Now().Add(delay)
+	slog.WarnContext(ctx, "Job failed, will retry",
+		"queue", q.name,
+		"job_id", job.ID,
+		"type", job.Type,
+		"attempts", job.Attempts,
+		"max_attempts", job.MaxAttempts,
+		"delay", delay,
+		"error", err)
+	if err := q.store.Retry(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "Failed to reschedule job", "queue", q.name, "job_id", job.ID, "error", err)
+	}
+	return ResultRetried
+}
+
+// Backoff returns the delay before retrying a job that has failed attempts times: base doubled
+// for each attempt after the first, capped at maxDelay
+func Backoff(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
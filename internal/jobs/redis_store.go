@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// popScript returns leases that ran out to the schedule, then leases the job that has been due longest
+// KEYS: scheduled, leased, data; ARGV: now, lease deadline (unix ms)
+var popScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+redis.call('ZREM', KEYS[1], ids[1])
+local data = redis.call('HGET', KEYS[3], ids[1])
+if not data then
+	return false
+end
+redis.call('ZADD', KEYS[2], ARGV[2], ids[1])
+return data
+`)
+
+// buryScript moves a job to the dead letters and drops the oldest beyond the limit
+// KEYS: leased, data, dead; ARGV: id, job, now (unix ms), limit
+var buryScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+local excess = redis.call('ZCARD', KEYS[3]) - tonumber(ARGV[4])
+if excess > 0 then
+	for _, id in ipairs(redis.call('ZRANGE', KEYS[3], 0, excess - 1)) do
+		redis.call('ZREM', KEYS[3], id)
+		redis.call('HDEL', KEYS[2], id)
+	end
+end
+return excess
+`)
+
+// requeueScript moves a buried job back to the schedule
+// KEYS: dead, scheduled, data; ARGV: id, job, now (unix ms)
+var requeueScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`)
+
+// RedisStore keeps a queue's jobs in Redis so they survive restarts and are shared across instances
+// Jobs are stored by ID in a hash; sorted sets order them by due time, lease expiry and burial time
+type RedisStore struct {
+	client    *redis.Client
+	scheduled string
+	leased    string
+	data      string
+	dead      string
+}
+
+// NewRedisStore creates a store for the named queue
+func NewRedisStore(client *redis.Client, queue string) *RedisStore {
+	// The hash tag keeps a queue's keys in one cluster slot, as the scripts touch several of them
+	prefix := "jobs:{" + queue + "}:"
+	return &RedisStore{
+		client:    client,
+		scheduled: prefix + "scheduled",
+		leased:    prefix + "leased",
+		data:      prefix + "data",
+		dead:      prefix + "dead",
+	}
+}
+
+// Push stores a job to run at job.RunAt
+func (s *RedisStore) Push(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, s.data, job.ID, data)
+	pipe.ZAdd(ctx, s.scheduled, redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store job: %w", err)
+	}
+	return nil
+}
+
+// Pop leases the job that has been due longest, or returns nil when none is due
+func (s *RedisStore) Pop(ctx context.Context, now time.Time, lease time.Duration) (*Job, error) {
+	data, err := popScript.Run(ctx, s.client, []string{s.scheduled, s.leased, s.data},
+		now.UnixMilli(), now.Add(lease).UnixMilli()).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pop job: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	return &job, nil
+}
+
+// Ack removes a finished job
+func (s *RedisStore) Ack(ctx context.Context, job *Job) error {
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, s.leased, job.ID)
+	pipe.HDel(ctx, s.data, job.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove job: %w", err)
+	}
+	return nil
+}
+
+// Retry stores a failed job to run again at job.RunAt
+func (s *RedisStore) Retry(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, s.leased, job.ID)
+	pipe.HSet(ctx, s.data, job.ID, data)
+	pipe.ZAdd(ctx, s.scheduled, redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
+	}
+	return nil
+}
+
+// Bury moves a failed job to the dead letters, keeping at most limit of them
+func (s *RedisStore) Bury(ctx context.Context, job *Job, limit int) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	err = buryScript.Run(ctx, s.client, []string{s.leased, s.data, s.dead},
+		job.ID, data, time.Now().UnixMilli(), limit).Err()
+	if err != nil {
+		return fmt.Errorf("failed to bury job: %w", err)
+	}
+	return nil
+}
+
+// DeadLetters returns the most recently buried jobs
+func (s *RedisStore) DeadLetters(ctx context.Context, limit int) ([]*Job, error) {
+	ids, err := s.client.ZRevRange(ctx, s.dead, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.client.HMGet(ctx, s.data, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letters: %w", err)
+	}
+
+	jobs := make([]*Job, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+// Requeue moves a buried job back to the queue to run now with all its attempts
+func (s *RedisStore) Requeue(ctx context.Context, id string, now time.Time) error {
+	stored, err := s.client.HGet(ctx, s.data, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal(stored, &job); err != nil {
+		return fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	job.Attempts = 0
+	job.RunAt = now
+	data, err := json.Marshal(&job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	moved, err := requeueScript.Run(ctx, s.client, []string{s.dead, s.scheduled, s.data},
+		id, data, now.UnixMilli()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	if moved == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Stats counts the queued, running and buried jobs
+func (s *RedisStore) Stats(ctx context.Context) (Stats, error) {
+	pipe := s.client.Pipeline()
+	scheduled := pipe.ZCard(ctx, s.scheduled)
+	leased := pipe.ZCard(ctx, s.leased)
+	dead := pipe.ZCard(ctx, s.dead)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Stats{}, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	return Stats{Scheduled: scheduled.Val(), Running: leased.Val(), Dead: dead.Val()}, nil
+}
@@ -0,0 +1,201 @@
+package language
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minLetters is the fewest letters detection is attempted on; shorter texts such as "ok" or "thanks" are ambiguous
+const minLetters = 12
+
+// minStopwords is the fewest common words needed to tell languages written in the Latin script apart
+const minStopwords = 2
+
+var names = map[string]string{
+	"ar": "Arabic",
+	"ca": "Catalan",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"th": "Thai",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// Name returns the English name of a language code for prompts, e.g. "Spanish" for "es" or "es-MX";
+// codes without a known name are returned as they are
+func Name(code string) string {
+	if name, ok := names[Base(code)]; ok {
+		return name
+	}
+	return code
+}
+
+// Base returns the primary language subtag of a code in lower case, e.g. "pt" for "pt-BR"
+func Base(code string) string {
+	base, _, _ := strings.Cut(code, "-")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// Same reports whether two language codes name the same language, ignoring regions
+func Same(a, b string) bool {
+	return Base(a) == Base(b)
+}
+
+// Common words of the languages written in the Latin script; words shared by several languages count for each
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "that", "this", "with", "for", "have", "what", "not", "it", "of",
+		"to", "be", "was", "can", "will", "your", "how", "my", "do", "would", "there", "please", "thanks"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "es", "en", "un", "una", "por", "para", "con", "no", "se",
+		"su", "lo", "como", "más", "pero", "está", "qué", "muy", "tiene", "hola", "gracias", "puedes", "del"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "que", "qui", "pour", "dans", "pas", "ne",
+		"vous", "je", "il", "sur", "avec", "ce", "au", "du", "mais", "très", "bonjour", "merci", "c'est"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "es", "ein", "eine", "zu", "den", "mit", "von",
+		"auf", "für", "auch", "wie", "sind", "dem", "wir", "kann", "haben", "danke", "bitte"},
+	"it": {"il", "lo", "la", "di", "che", "e", "è", "un", "una", "per", "non", "sono", "con", "si", "mi", "come",
+		"ma", "più", "questo", "della", "anche", "ciao", "grazie", "perché", "gli"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "é", "um", "uma", "para", "com", "não", "em", "do", "da", "se",
+		"no", "na", "mais", "você", "obrigado", "olá", "muito", "está", "isso"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "ik", "je", "op", "te", "met", "voor", "zijn",
+		"maar", "ook", "wat", "hoe", "bedankt", "alsjeblieft"},
+	"ca": {"el", "la", "els", "les", "de", "que", "i", "és", "un", "una", "per", "amb", "no", "es", "en", "del",
+		"molt", "però", "també", "això", "gràcies", "com", "està", "hola"},
+}
+
+var stopwordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for code, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], code)
+		}
+	}
+	return index
+}()
+
+// Code, links and inline code are left out of detection, as they are mostly written in English
+var (
+	codeBlockPattern  = regexp.MustCompile("(?s)```.*?```")
+	inlineCodePattern = regexp.MustCompile("`[^`]*`")
+	linkPattern       = regexp.MustCompile(`https?://\S+`)
+)
+
+// Detect returns the code of the language a text is written in, or "" when it is too short or ambiguous
+// Scripts identify most languages; those written in the Latin script are told apart by their common words
+func Detect(text string) string {
+	text = codeBlockPattern.ReplaceAllString(text, " ")
+	text = inlineCodePattern.ReplaceAllString(text, " ")
+	text = linkPattern.ReplaceAllString(text, " ")
+
+	scripts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		scripts[script(r)]++
+	}
+	if letters < minLetters {
+		return ""
+	}
+
+	// Japanese mixes kana with Chinese characters, so any share of kana makes it Japanese
+	if kana := scripts["kana"]; kana > 0 && kana*10 >= letters {
+		return "ja"
+	}
+	for _, s := range []string{"han", "hangul", "cyrillic", "greek", "arabic", "hebrew", "thai", "devanagari"} {
+		if scripts[s]*2 < letters {
+			continue
+		}
+		switch s {
+		case "han":
+			return "zh"
+		case "hangul":
+			return "ko"
+		case "cyrillic":
+			if strings.ContainsAny(strings.ToLower(text), "іїєґ") {
+				return "uk"
+			}
+			return "ru"
+		case "greek":
+			return "el"
+		case "arabic":
+			return "ar"
+		case "hebrew":
+			return "he"
+		case "thai":
+			return "th"
+		case "devanagari":
+			return "hi"
+		}
+	}
+	if scripts["latin"]*2 < letters {
+		return ""
+	}
+	return detectLatin(text)
+}
+
+func script(r rune) string {
+	switch {
+	case unicode.Is(unicode.Latin, r):
+		return "latin"
+	case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+		return "kana"
+	case unicode.Is(unicode.Han, r):
+		return "han"
+	case unicode.Is(unicode.Hangul, r):
+		return "hangul"
+	case unicode.Is(unicode.Cyrillic, r):
+		return "cyrillic"
+	case unicode.Is(unicode.Greek, r):
+		return "greek"
+	case unicode.Is(unicode.Arabic, r):
+		return "arabic"
+	case unicode.Is(unicode.Hebrew, r):
+		return "hebrew"
+	case unicode.Is(unicode.Thai, r):
+		return "thai"
+	case unicode.Is(unicode.Devanagari, r):
+		return "devanagari"
+	}
+	return "other"
+}
+
+// detectLatin scores the common words of each language; the best must clearly beat the runner-up
+func detectLatin(text string) string {
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, code := range stopwordLanguages[strings.Trim(word, "'")] {
+			scores[code]++
+		}
+	}
+
+	best, bestScore, runnerUp := "", 0, 0
+	for code, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = code, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	// A tie, or a lead of a single shared word on short texts, leaves the language open
+	if bestScore < minStopwords || bestScore*4 < runnerUp*5 || bestScore == runnerUp {
+		return ""
+	}
+	return best
+}
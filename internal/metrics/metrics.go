@@ -6,25 +6,89 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 )
 
+// PromptCacheMinTokens is the length under which OpenAI never reads prompts from its prompt cache
+const PromptCacheMinTokens = 1024
+
 // Metrics holds all application metrics
 type Metrics struct {
 	httpRequestsTotal   metric.Int64Counter
 	httpRequestDuration metric.Float64Histogram
 	twirpRequestsTotal  metric.Int64Counter
+	twirpRequestLatency metric.Float64Histogram
 
 	// Simplified OpenAI metrics
 	openaiRequestsTotal   metric.Int64Counter
 	openaiRequestDuration metric.Float64Histogram
 
+	// Streaming metrics: perceived responsiveness, which the request duration hides
+	openaiTimeToFirstToken metric.Float64Histogram
+	openaiTokensPerSecond  metric.Float64Histogram
+
+	// Prompt caching metrics: prompt prefixes OpenAI served from its cache, and connections reused for requests
+	openaiPromptCacheTokens   metric.Int64Counter
+	openaiPromptCacheRequests metric.Int64Counter
+	upstreamConnectionsTotal  metric.Int64Counter
+
 	// Token usage metrics
 	tokenUsageTotal      metric.Int64Counter
 	tokenUsageByModel    metric.Int64Counter
 	contextTokenCount    metric.Int64Histogram
 	tokenEstimationError metric.Float64Histogram
+
+	// Context summary metrics
+	summaryTokens       metric.Int64Histogram
+	summaryFaithfulness metric.Float64Histogram
+
+	// Embedding metrics
+	embeddingBatchSize metric.Int64Histogram
+
+	// Answer grounding metrics
+	groundingRepromptsTotal metric.Int64Counter
+	toolDecisionsTotal      metric.Int64Counter
+
+	// Reply quality metrics
+	messageReactionsTotal metric.Int64Counter
+
+	// Experiment metrics per persona, prompt version and model
+	variantReplyDuration  metric.Float64Histogram
+	variantTokensTotal    metric.Int64Counter
+	variantReactionsTotal metric.Int64Counter
+
+	// Safety metrics
+	injectionDetectionsTotal metric.Int64Counter
+	messagesRateLimitedTotal metric.Int64Counter
+	accessBlockedTotal       metric.Int64Counter
+	botChallengesTotal       metric.Int64Counter
+	botBlockedTotal          metric.Int64Counter
+
+	// Conversation sentiment metrics
+	messageSentimentTotal      metric.Int64Counter
+	negativeConversationsTotal metric.Int64Counter
+	conversationTopicsTotal    metric.Int64Counter
+
+	// Conversation cost cap metrics
+	costCapsTotal metric.Int64Counter
+
+	// Cache metrics
+	cacheRequestsTotal metric.Int64Counter
+	cacheKeys          metric.Int64Gauge
+
+	// Rate limiter metrics
+	rateLimiterKeys metric.Int64Gauge
+
+	// Background job metrics
+	jobsProcessedTotal metric.Int64Counter
+	jobDuration        metric.Float64Histogram
+
+	// Scheduled task metrics
+	cronRunsTotal   metric.Int64Counter
+	cronRunDuration metric.Float64Histogram
 }
 
 // NewMetrics creates and initializes all metrics
@@ -56,6 +120,15 @@ func NewMetrics(meter metric.Meter) (*Metrics, error) {
 		return nil, err
 	}
 
+	twirpRequestLatency, err := meter.Float64Histogram(
+		"twirp_request_latency_ms",
+		metric.WithDescription("Twirp request latency in milliseconds"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// Simplified OpenAI metrics
 	openaiRequestsTotal, err := meter.Int64Counter(
 		"openai_requests_total",
@@ -75,6 +148,53 @@ func NewMetrics(meter metric.Meter) (*Metrics, error) {
 		return nil, err
 	}
 
+	openaiTimeToFirstToken, err := meter.Float64Histogram(
+		"openai_time_to_first_token_ms",
+		metric.WithDescription("Time from a streamed OpenAI request to its first content token in milliseconds"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(100, 250, 500, 750, 1000, 1500, 2000, 3000, 5000, 10000),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	openaiTokensPerSecond, err := meter.Float64Histogram(
+		"openai_generation_tokens_per_second",
+		metric.WithDescription("Completion tokens per second of streamed OpenAI requests, after the first token"),
+		metric.WithUnit("1/s"),
+		metric.WithExplicitBucketBoundaries(5, 10, 20, 30, 50, 75, 100, 150, 200, 300),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	openaiPromptCacheTokens, err := meter.Int64Counter(
+		"openai_prompt_cache_tokens_total",
+		metric.WithDescription("Prompt tokens of OpenAI requests by whether they were read from the prompt cache"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	openaiPromptCacheRequests, err := meter.Int64Counter(
+		"openai_prompt_cache_requests_total",
+		metric.WithDescription("OpenAI requests by whether part of their prompt was read from the prompt cache"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamConnectionsTotal, err := meter.Int64Counter(
+		"upstream_connections_total",
+		metric.WithDescription("Requests to upstream APIs by whether they reused an open connection"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// Token usage metrics
 	tokenUsageTotal, err := meter.Int64Counter(
 		"token_usage_total",
@@ -114,16 +234,287 @@ func NewMetrics(meter metric.Meter) (*Metrics, error) {
 		return nil, err
 	}
 
+	summaryTokens, err := meter.Int64Histogram(
+		"context_summary_tokens",
+		metric.WithDescription("Tokens of messages replaced by a context summary (stage=before) and of the summary (stage=after)"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summaryFaithfulness, err := meter.Float64Histogram(
+		"context_summary_faithfulness",
+		metric.WithDescription("Sampled faithfulness scores of context summaries, from 0 to 1"),
+		metric.WithUnit("1"),
+		metric.WithExplicitBucketBoundaries(0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddingBatchSize, err := meter.Int64Histogram(
+		"embedding_batch_size",
+		metric.WithDescription("Texts sent per embedding request after cache hits and duplicates are removed"),
+		metric.WithUnit("1"),
+		metric.WithExplicitBucketBoundaries(1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2048),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	groundingRepromptsTotal, err := meter.Int64Counter(
+		"grounding_reprompts_total",
+		metric.WithDescription("Total re-prompts issued because an answer was not verified with tools"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	toolDecisionsTotal, err := meter.Int64Counter(
+		"reply_tool_decisions_total",
+		metric.WithDescription("Total replies by whether tool schemas were sent or skipped for small talk"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	messageReactionsTotal, err := meter.Int64Counter(
+		"message_reactions_total",
+		metric.WithDescription("Total user reactions to assistant replies by sentiment"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	variantReplyDuration, err := meter.Float64Histogram(
+		"experiment_reply_duration_seconds",
+		metric.WithDescription("Reply generation time per persona, prompt version and model"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	variantTokensTotal, err := meter.Int64Counter(
+		"experiment_tokens_total",
+		metric.WithDescription("Total tokens spent on replies per persona, prompt version and model"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	variantReactionsTotal, err := meter.Int64Counter(
+		"experiment_reactions_total",
+		metric.WithDescription("Total user reactions by sentiment per persona, prompt version and model"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	injectionDetectionsTotal, err := meter.Int64Counter(
+		"prompt_injection_detections_total",
+		metric.WithDescription("Total external contents found to contain instructions, by source, method and action"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	messagesRateLimitedTotal, err := meter.Int64Counter(
+		"messages_rate_limited_total",
+		metric.WithDescription("Total messages rejected because their conversation or chat session exceeded its rate"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accessBlockedTotal, err := meter.Int64Counter(
+		"access_blocked_total",
+		metric.WithDescription("Total requests refused by the IP range and country access rules"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	botChallengesTotal, err := meter.Int64Counter(
+		"bot_challenges_total",
+		metric.WithDescription("Total bot challenges checked on anonymous requests by provider and result"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	botBlockedTotal, err := meter.Int64Counter(
+		"bot_blocked_total",
+		metric.WithDescription("Total anonymous requests refused as bot traffic by reason"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	messageSentimentTotal, err := meter.Int64Counter(
+		"message_sentiment_total",
+		metric.WithDescription("Total classified user messages by sentiment"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	negativeConversationsTotal, err := meter.Int64Counter(
+		"conversations_turned_negative_total",
+		metric.WithDescription("Total conversations whose sentiment fell below the alert threshold"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	conversationTopicsTotal, err := meter.Int64Counter(
+		"conversation_topics_total",
+		metric.WithDescription("Total conversations tagged with a topic, by topic"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	costCapsTotal, err := meter.Int64Counter(
+		"conversation_cost_caps_total",
+		metric.WithDescription("Total replies of conversations over their token or cost cap, by action"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheRequestsTotal, err := meter.Int64Counter(
+		"cache_requests_total",
+		metric.WithDescription("Total Redis cache lookups by cache and result"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKeys, err := meter.Int64Gauge(
+		"cache_keys",
+		metric.WithDescription("Number of Redis keys per prefix, sampled periodically"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimiterKeys, err := meter.Int64Gauge(
+		"rate_limiter_keys",
+		metric.WithDescription("Number of clients tracked by an in-memory rate limiter"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	jobsProcessedTotal, err := meter.Int64Counter(
+		"jobs_processed_total",
+		metric.WithDescription("Total background job attempts by queue, type and result"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	jobDuration, err := meter.Float64Histogram(
+		"job_duration_ms",
+		metric.WithDescription("Background job attempt duration in milliseconds"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cronRunsTotal, err := meter.Int64Counter(
+		"cron_runs_total",
+		metric.WithDescription("Total scheduled task runs by task and status"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cronRunDuration, err := meter.Float64Histogram(
+		"cron_run_duration_ms",
+		metric.WithDescription("Scheduled task run duration in milliseconds"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Metrics{
 		httpRequestsTotal:     httpRequestsTotal,
 		httpRequestDuration:   httpRequestDuration,
 		twirpRequestsTotal:    twirpRequestsTotal,
+		twirpRequestLatency:   twirpRequestLatency,
 		openaiRequestsTotal:   openaiRequestsTotal,
 		openaiRequestDuration: openaiRequestDuration,
 		tokenUsageTotal:       tokenUsageTotal,
 		tokenUsageByModel:     tokenUsageByModel,
 		contextTokenCount:     contextTokenCount,
 		tokenEstimationError:  tokenEstimationError,
+
+		openaiTimeToFirstToken: openaiTimeToFirstToken,
+		openaiTokensPerSecond:  openaiTokensPerSecond,
+
+		openaiPromptCacheTokens:   openaiPromptCacheTokens,
+		openaiPromptCacheRequests: openaiPromptCacheRequests,
+		upstreamConnectionsTotal:  upstreamConnectionsTotal,
+
+		summaryTokens:       summaryTokens,
+		summaryFaithfulness: summaryFaithfulness,
+
+		embeddingBatchSize: embeddingBatchSize,
+
+		groundingRepromptsTotal: groundingRepromptsTotal,
+		toolDecisionsTotal:      toolDecisionsTotal,
+		messageReactionsTotal:   messageReactionsTotal,
+
+		variantReplyDuration:  variantReplyDuration,
+		variantTokensTotal:    variantTokensTotal,
+		variantReactionsTotal: variantReactionsTotal,
+
+		injectionDetectionsTotal: injectionDetectionsTotal,
+		messagesRateLimitedTotal: messagesRateLimitedTotal,
+		accessBlockedTotal:       accessBlockedTotal,
+		botChallengesTotal:       botChallengesTotal,
+		botBlockedTotal:          botBlockedTotal,
+
+		messageSentimentTotal:      messageSentimentTotal,
+		negativeConversationsTotal: negativeConversationsTotal,
+		conversationTopicsTotal:    conversationTopicsTotal,
+
+		costCapsTotal: costCapsTotal,
+
+		cacheRequestsTotal: cacheRequestsTotal,
+		cacheKeys:          cacheKeys,
+
+		rateLimiterKeys: rateLimiterKeys,
+
+		jobsProcessedTotal: jobsProcessedTotal,
+		jobDuration:        jobDuration,
+
+		cronRunsTotal:   cronRunsTotal,
+		cronRunDuration: cronRunDuration,
 	}, nil
 }
 
@@ -148,6 +539,7 @@ func (m *Metrics) HTTPMetricsMiddleware() func(http.Handler) http.Handler {
 					attribute.String("method", r.Method),
 					attribute.String("path", r.URL.Path),
 					attribute.String("status_code", statusCode),
+					tenantAttr(r.Context()),
 				),
 			)
 
@@ -156,6 +548,7 @@ func (m *Metrics) HTTPMetricsMiddleware() func(http.Handler) http.Handler {
 					attribute.String("method", r.Method),
 					attribute.String("path", r.URL.Path),
 					attribute.String("status_code", statusCode),
+					tenantAttr(r.Context()),
 				),
 			)
 		})
@@ -168,6 +561,18 @@ func (m *Metrics) RecordTwirpRequest(ctx context.Context, method string, status
 		metric.WithAttributes(
 			attribute.String("method", method),
 			attribute.String("status", status),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordTwirpLatency records how long a Twirp request took; status is "success" or the Twirp error code
+func (m *Metrics) RecordTwirpLatency(ctx context.Context, method string, status string, duration time.Duration) {
+	m.twirpRequestLatency.Record(ctx, float64(duration.Nanoseconds())/1e6,
+		metric.WithAttributes(
+			attribute.String("method", method),
+			attribute.String("status", status),
+			tenantAttr(ctx),
 		),
 	)
 }
@@ -179,17 +584,64 @@ func (m *Metrics) RecordOpenAIRequest(ctx context.Context, operation, model, use
 		attribute.String("model", model),
 		attribute.String("user_id", userID),
 		attribute.String("platform", platform),
+		tenantAttr(ctx),
 	}
 
 	m.openaiRequestsTotal.Add(ctx, 1, metric.WithAttributes(attrs...))
 	m.openaiRequestDuration.Record(ctx, float64(duration.Milliseconds()), metric.WithAttributes(attrs...))
 }
 
+// RecordOpenAIStream records the time to the first content token of a streamed request and the generation
+// throughput after it; streams that produced no content or tokens record nothing
+func (m *Metrics) RecordOpenAIStream(ctx context.Context, operation, model string, timeToFirstToken, generation time.Duration, completionTokens int64) {
+	if timeToFirstToken <= 0 {
+		return
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("operation", operation),
+		attribute.String("model", model),
+		tenantAttr(ctx),
+	)
+	m.openaiTimeToFirstToken.Record(ctx, float64(timeToFirstToken.Milliseconds()), attrs)
+	if generation > 0 && completionTokens > 0 {
+		m.openaiTokensPerSecond.Record(ctx, float64(completionTokens)/generation.Seconds(), attrs)
+	}
+}
+
+// RecordPromptCache records how much of the prompt of an OpenAI request was read from the prompt cache
+// OpenAI only caches prompts of at least PromptCacheMinTokens; shorter ones are recorded as "ineligible"
+func (m *Metrics) RecordPromptCache(ctx context.Context, operation, model string, promptTokens, cachedTokens int64) {
+	result := "miss"
+	switch {
+	case cachedTokens > 0:
+		result = "hit"
+	case promptTokens < PromptCacheMinTokens:
+		result = "ineligible"
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("operation", operation),
+		attribute.String("model", model),
+		tenantAttr(ctx),
+	}
+	m.openaiPromptCacheRequests.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("result", result))...))
+	m.openaiPromptCacheTokens.Add(ctx, cachedTokens, metric.WithAttributes(append(attrs, attribute.String("cache", "cached"))...))
+	m.openaiPromptCacheTokens.Add(ctx, promptTokens-cachedTokens, metric.WithAttributes(append(attrs, attribute.String("cache", "uncached"))...))
+}
+
+// RecordUpstreamConnection records whether a request to an upstream API got an open connection
+func (m *Metrics) RecordUpstreamConnection(ctx context.Context, upstream string, reused bool) {
+	m.upstreamConnectionsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("upstream", upstream),
+		attribute.Bool("reused", reused),
+	))
+}
+
 // RecordTokenUsage records token usage metrics
 func (m *Metrics) RecordTokenUsage(ctx context.Context, operation, model string, promptTokens, completionTokens, totalTokens int64) {
 	attrs := []attribute.KeyValue{
 		attribute.String("operation", operation),
 		attribute.String("model", model),
+		tenantAttr(ctx),
 	}
 
 	// Record total tokens
@@ -238,6 +690,28 @@ func (m *Metrics) RecordTokenEstimationError(ctx context.Context, operation stri
 	m.tokenEstimationError.Record(ctx, errorPercent, metric.WithAttributes(attrs...))
 }
 
+// RecordSummaryTokens records the size of messages replaced by a context summary and of the summary
+func (m *Metrics) RecordSummaryTokens(ctx context.Context, before, after int) {
+	m.summaryTokens.Record(ctx, int64(before), metric.WithAttributes(attribute.String("stage", "before"), tenantAttr(ctx)))
+	m.summaryTokens.Record(ctx, int64(after), metric.WithAttributes(attribute.String("stage", "after"), tenantAttr(ctx)))
+}
+
+// RecordSummaryFaithfulness records the score of a checked summary and whether it was kept
+func (m *Metrics) RecordSummaryFaithfulness(ctx context.Context, score float64, accepted bool) {
+	outcome := "accepted"
+	if !accepted {
+		outcome = "rejected"
+	}
+	m.summaryFaithfulness.Record(ctx, score,
+		metric.WithAttributes(attribute.String("outcome", outcome), tenantAttr(ctx)))
+}
+
+// RecordEmbeddingBatch records the number of texts sent in one embedding request
+func (m *Metrics) RecordEmbeddingBatch(ctx context.Context, model string, texts int) {
+	m.embeddingBatchSize.Record(ctx, int64(texts),
+		metric.WithAttributes(attribute.String("model", model), tenantAttr(ctx)))
+}
+
 // RecordOpenAIRequestWithTokens records OpenAI request with detailed token metrics
 func (m *Metrics) RecordOpenAIRequestWithTokens(ctx context.Context, operation, model, userID, platform string, duration time.Duration, promptTokens, completionTokens, totalTokens int64) {
 	// Record basic OpenAI metrics
@@ -247,6 +721,153 @@ func (m *Metrics) RecordOpenAIRequestWithTokens(ctx context.Context, operation,
 	m.RecordTokenUsage(ctx, operation, model, promptTokens, completionTokens, totalTokens)
 }
 
+// RecordGroundingReprompt records a re-prompt issued in strict facts mode
+func (m *Metrics) RecordGroundingReprompt(ctx context.Context, platform string, missingTools []string) {
+	for _, tool := range missingTools {
+		m.groundingRepromptsTotal.Add(ctx, 1,
+			metric.WithAttributes(
+				attribute.String("platform", platform),
+				attribute.String("tool", tool),
+			),
+		)
+	}
+}
+
+// RecordToolDecision records whether a reply was requested with tool schemas ("tools") or without them ("skipped")
+func (m *Metrics) RecordToolDecision(ctx context.Context, platform, decision string) {
+	m.toolDecisionsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("platform", platform),
+			attribute.String("decision", decision),
+		),
+	)
+}
+
+// RecordReaction records a user's reaction to an assistant reply
+func (m *Metrics) RecordReaction(ctx context.Context, platform, sentiment string) {
+	m.messageReactionsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("platform", platform),
+			attribute.String("sentiment", sentiment),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordVariantReply records the generation time and token spend of a reply per experiment variant
+func (m *Metrics) RecordVariantReply(ctx context.Context, variant model.Variant, latency time.Duration, tokens int64) {
+	attrs := metric.WithAttributes(variantAttrs(ctx, variant)...)
+	m.variantReplyDuration.Record(ctx, latency.Seconds(), attrs)
+	m.variantTokensTotal.Add(ctx, tokens, attrs)
+}
+
+// RecordVariantReaction records a user's reaction to a reply per experiment variant
+func (m *Metrics) RecordVariantReaction(ctx context.Context, variant model.Variant, sentiment string) {
+	m.variantReactionsTotal.Add(ctx, 1,
+		metric.WithAttributes(append(variantAttrs(ctx, variant), attribute.String("sentiment", sentiment))...))
+}
+
+func variantAttrs(ctx context.Context, variant model.Variant) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("persona", variant.Persona),
+		attribute.String("prompt_version", variant.PromptVersion),
+		attribute.String("model", variant.Model),
+		tenantAttr(ctx),
+	}
+}
+
+// RecordMessageSentiment records the classified sentiment of a user message
+func (m *Metrics) RecordMessageSentiment(ctx context.Context, platform, sentiment string) {
+	m.messageSentimentTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("platform", platform),
+			attribute.String("sentiment", sentiment),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordInjectionDetection records external content that contained instructions
+func (m *Metrics) RecordInjectionDetection(ctx context.Context, source, method, mode string) {
+	m.injectionDetectionsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("source", source),
+			attribute.String("method", method),
+			attribute.String("mode", mode),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordMessageRateLimited records a message rejected by a per-conversation or per-session rate limit
+func (m *Metrics) RecordMessageRateLimited(ctx context.Context, scope, platform string) {
+	m.messagesRateLimitedTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("scope", scope),
+			attribute.String("platform", platform),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordAccessBlocked records a request refused by the access rules; country is "" unless it was looked up
+// Requests are blocked before their tenant is resolved, so the count has no tenant
+func (m *Metrics) RecordAccessBlocked(ctx context.Context, reason, country string) {
+	m.accessBlockedTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("reason", reason),
+			attribute.String("country", country),
+		),
+	)
+}
+
+// RecordBotChallenge records a checked bot challenge; result is pass, fail, missing or error
+func (m *Metrics) RecordBotChallenge(ctx context.Context, provider, result string) {
+	m.botChallengesTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("provider", provider),
+			attribute.String("result", result),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordBotBlocked records an anonymous request refused as bot traffic, or a honeypot hit
+func (m *Metrics) RecordBotBlocked(ctx context.Context, reason string) {
+	m.botBlockedTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("reason", reason),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordNegativeConversation records a conversation whose sentiment fell below the alert threshold
+func (m *Metrics) RecordNegativeConversation(ctx context.Context, platform string) {
+	m.negativeConversationsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("platform", platform),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordConversationTopic records a conversation newly tagged with a topic
+func (m *Metrics) RecordConversationTopic(ctx context.Context, platform, topic string) {
+	m.conversationTopicsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("platform", platform),
+			attribute.String("topic", topic),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// tenantAttr labels a metric with the tenant of the context
+func tenantAttr(ctx context.Context) attribute.KeyValue {
+	return attribute.String("tenant_id", tenant.FromContext(ctx))
+}
+
 // Helper function for absolute value
 func abs(x int) int {
 	if x < 0 {
@@ -265,3 +886,65 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// RecordCostCap records a reply of a conversation over its cap; action is downgrade or refuse
+func (m *Metrics) RecordCostCap(ctx context.Context, platform, persona, action string) {
+	m.costCapsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("platform", platform),
+			attribute.String("persona", persona),
+			attribute.String("action", action),
+			tenantAttr(ctx),
+		),
+	)
+}
+
+// RecordCacheRequest records a Redis cache lookup; result is "hit", "miss" or "error"
+func (m *Metrics) RecordCacheRequest(ctx context.Context, cache, result string) {
+	m.cacheRequestsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("cache", cache),
+			attribute.String("result", result),
+		),
+	)
+}
+
+// RecordCacheKeys records the sampled number of Redis keys with a prefix
+func (m *Metrics) RecordCacheKeys(ctx context.Context, prefix string, count int64) {
+	m.cacheKeys.Record(ctx, count,
+		metric.WithAttributes(
+			attribute.String("prefix", prefix),
+		),
+	)
+}
+
+// RecordRateLimiterKeys records the number of clients a rate limiter tracks, e.g. the "ip" or "tenant" limiter
+func (m *Metrics) RecordRateLimiterKeys(ctx context.Context, limiter string, count int64) {
+	m.rateLimiterKeys.Record(ctx, count,
+		metric.WithAttributes(
+			attribute.String("limiter", limiter),
+		),
+	)
+}
+
+// RecordJob records a background job attempt; result is "succeeded", "retried" or "dead"
+func (m *Metrics) RecordJob(ctx context.Context, queue, jobType, result string, duration time.Duration) {
+	attrs := metric.WithAttributes(
+		attribute.String("queue", queue),
+		attribute.String("type", jobType),
+		attribute.String("result", result),
+		tenantAttr(ctx),
+	)
+	m.jobsProcessedTotal.Add(ctx, 1, attrs)
+	m.jobDuration.Record(ctx, float64(duration.Nanoseconds())/1e6, attrs)
+}
+
+// RecordCronRun records a scheduled task run; status is "succeeded" or "failed"
+func (m *Metrics) RecordCronRun(ctx context.Context, task, status string, duration time.Duration) {
+	attrs := metric.WithAttributes(
+		attribute.String("task", task),
+		attribute.String("status", status),
+	)
+	m.cronRunsTotal.Add(ctx, 1, attrs)
+	m.cronRunDuration.Record(ctx, float64(duration.Nanoseconds())/1e6, attrs)
+}
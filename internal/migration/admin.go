@@ -0,0 +1,44 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler reports index builds and retries failed ones over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	coordinator *Coordinator
+}
+
+// NewAdminHandler creates a new migration admin handler
+func NewAdminHandler(coordinator *Coordinator) *AdminHandler {
+	return &AdminHandler{coordinator: coordinator}
+}
+
+// ListHandler handles GET /admin/migrations
+func (h *AdminHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"migrations": h.coordinator.States(),
+		"features":   h.coordinator.Features(),
+	})
+}
+
+// RetryHandler handles POST /admin/migrations/{id}/retry
+func (h *AdminHandler) RetryHandler(w http.ResponseWriter, r *http.Request) {
+	state, err := h.coordinator.Retry(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, ErrUnknownMigration) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "migration not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
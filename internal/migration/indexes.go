@@ -0,0 +1,51 @@
+package migration
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Features gated on their indexes
+const (
+	FeatureFollowUps          = "follow_ups"          // Follow-up job scanning idle conversations of a platform
+	FeatureColdStorage        = "cold_storage"        // Archiver scanning inactive conversations
+	FeatureSentimentAnalytics = "sentiment_analytics" // Sentiment summary of the admin analytics API
+)
+
+// Indexes are the indexes the application needs, built in this order
+// Adding one here builds it on the next start; indexes are never dropped automatically
+var Indexes = []Index{
+	{Collection: "conversations", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}, Residency: true},
+	{Collection: "conversations", Keys: bson.D{{Key: "platform", Value: 1}, {Key: "chat_id", Value: 1}}, Residency: true},
+	{Collection: "conversations", Keys: bson.D{{Key: "is_active", Value: 1}, {Key: "last_activity", Value: -1}}, Residency: true},
+	{Collection: "conversations", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "created_at", Value: -1}}, Residency: true},
+	{Collection: "conversations", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "tags", Value: 1}}, Residency: true},
+	{Collection: "conversations", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "platform", Value: 1}, {Key: "user_id", Value: 1}, {Key: "last_activity", Value: -1}},
+		Residency: true},
+	{
+		Collection: "conversations",
+		Keys:       bson.D{{Key: "tenant_id", Value: 1}, {Key: "sentiment_score", Value: 1}},
+		Residency:  true,
+		Features:   []string{FeatureSentimentAnalytics},
+	},
+	{
+		Collection: "conversations",
+		Keys:       bson.D{{Key: "platform", Value: 1}, {Key: "is_active", Value: 1}, {Key: "last_activity", Value: 1}},
+		Residency:  true,
+		Features:   []string{FeatureFollowUps},
+	},
+	{
+		Collection: "conversations",
+		Keys:       bson.D{{Key: "last_activity", Value: 1}, {Key: "_id", Value: 1}},
+		Residency:  true,
+		Features:   []string{FeatureColdStorage},
+	},
+	{Collection: "user_settings", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "platform", Value: 1}, {Key: "user_id", Value: 1}},
+		Unique: true, Residency: true},
+	{Collection: "usage_records", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "usage_records", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "conversation_id", Value: 1}}},
+	{Collection: "attachments", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "conversation_id", Value: 1}}},
+	{Collection: "conversation_turns", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "conversation_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	{Collection: "conversation_turns", Keys: bson.D{{Key: "expires_at", Value: 1}}, Expires: true},
+	{Collection: "data_exports", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "platform", Value: 1}, {Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "deliveries", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{Collection: "deliveries", Keys: bson.D{{Key: "channel", Value: 1}, {Key: "external_id", Value: 1}}},
+	{Collection: "audit_log", Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "target", Value: 1}, {Key: "created_at", Value: 1}}},
+}
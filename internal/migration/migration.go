@@ -0,0 +1,377 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Migration statuses
+const (
+	StatusPending  = "pending"
+	StatusBuilding = "building"
+	StatusReady    = "ready"
+	StatusFailed   = "failed" // Retried until the attempts run out, then left for an operator to retry
+)
+
+// ErrUnknownMigration is returned when retrying a migration that is not registered
+var ErrUnknownMigration = errors.New("unknown migration")
+
+// Index is a MongoDB index the application relies on
+type Index struct {
+	Collection string
+	Keys       bson.D
+	Unique     bool
+	Expires    bool // Documents are deleted once the time in the key has passed
+	// Residency builds the index in the database of every storage profile too, for collections routed by tenant
+	Residency bool
+	// Features are disabled until the index is built in every database
+	Features []string
+}
+
+// Name returns the name MongoDB gives the index by default, e.g. "tenant_id_1_created_at_-1",
+// so indexes created by hand or by older releases are recognized
+func (i Index) Name() string {
+	parts := make([]string, 0, len(i.Keys))
+	for _, k := range i.Keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", k.Key, k.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// Progress is how far an index build went, in documents scanned
+type Progress struct {
+	Done  int64 `bson:"done" json:"done"`
+	Total int64 `bson:"total" json:"total"`
+}
+
+// State is the build of an index in one database; states are stored so builds resume after restarts
+type State struct {
+	ID         string    `bson:"_id" json:"id"` // "<database>.<collection>.<index>"
+	Database   string    `bson:"database" json:"database"`
+	Collection string    `bson:"collection" json:"collection"`
+	Index      string    `bson:"index" json:"index"`
+	Features   []string  `bson:"features,omitempty" json:"features,omitempty"`
+	Status     string    `bson:"status" json:"status"`
+	Progress   *Progress `bson:"progress,omitempty" json:"progress,omitempty"`
+	Attempts   int       `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt  time.Time `bson:"started_at,omitempty" json:"started_at,omitzero"`
+	FinishedAt time.Time `bson:"finished_at,omitempty" json:"finished_at,omitzero"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Store persists migration states, shared by every instance
+type Store interface {
+	ListStates(ctx context.Context) ([]*State, error)
+	SaveState(ctx context.Context, state *State) error
+}
+
+// Builder builds indexes in one database, see MongoBuilder
+type Builder interface {
+	Database() string
+	// Exists reports whether the index was built
+	Exists(ctx context.Context, collection, name string) (bool, error)
+	// Build creates the index and returns once it is built; a build of the same index already running is joined
+	Build(ctx context.Context, index Index) error
+	// Progress returns the progress of a running build of the index, or nil when none runs
+	Progress(ctx context.Context, collection, name string) (*Progress, error)
+}
+
+// Leader tells whether this instance should start index builds, see redisx.Election
+type Leader interface {
+	IsLeader() bool
+}
+
+// Config configures the Coordinator
+type Config struct {
+	Interval    time.Duration // How often states are refreshed and the next build is started
+	MaxAttempts int           // Failed builds are retried this many times in total
+}
+
+// target is an index in the database of one builder
+type target struct {
+	index   Index
+	builder Builder
+	id      string
+}
+
+// Coordinator builds the indexes the application needs in the background, one at a time, and keeps the
+// features that rely on them disabled until they are built
+// Every instance tracks the states; only the leader starts builds. MongoDB keeps building an index when the
+// instance that started it stops, and the next leader joins the running build
+type Coordinator struct {
+	targets []target
+	store   Store
+	leader  Leader
+	cfg     Config
+
+	mu       sync.RWMutex
+	states   map[string]*State
+	building map[string]bool // Builds started by this instance
+}
+
+// NewCoordinator creates a coordinator for indexes built in the database of each builder
+// Builders after the first are storage profile databases, which only get indexes with Residency
+func NewCoordinator(store Store, leader Leader, indexes []Index, builders []Builder, cfg Config) *Coordinator {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+
+	c := &Coordinator{store: store, leader: leader, cfg: cfg, states: map[string]*State{}, building: map[string]bool{}}
+	for i, b := range builders {
+		for _, index := range indexes {
+			if i > 0 && !index.Residency {
+				continue
+			}
+			id := b.Database() + "." + index.Collection + "." + index.Name()
+			c.targets = append(c.targets, target{index: index, builder: b, id: id})
+			c.states[id] = c.newState(id, index, b)
+		}
+	}
+	return c
+}
+
+func (c *Coordinator) newState(id string, index Index, b Builder) *State {
+	return &State{
+		ID:         id,
+		Database:   b.Database(),
+		Collection: index.Collection,
+		Index:      index.Name(),
+		Features:   index.Features,
+		Status:     StatusPending,
+	}
+}
+
+// Run coordinates the builds until the context is cancelled
+func (c *Coordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Step(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to coordinate index builds", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Step refreshes the states, records the progress of running builds and, on the leader, starts the next build
+func (c *Coordinator) Step(ctx context.Context) error {
+	stored, err := c.store.ListStates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load migration states: %w", err)
+	}
+	c.mu.Lock()
+	for _, s := range stored {
+		if _, known := c.states[s.ID]; known && !c.building[s.ID] {
+			c.states[s.ID] = s
+		}
+	}
+	c.mu.Unlock()
+
+	leads := c.leader == nil || c.leader.IsLeader()
+	running := false
+	for _, t := range c.targets {
+		state := c.State(t.id)
+		if state.Status == StatusReady {
+			continue
+		}
+
+		// Built by hand, by an older release, or by a build that finished while no instance watched it
+		exists, err := t.builder.Exists(ctx, t.index.Collection, t.index.Name())
+		if err != nil {
+			return fmt.Errorf("failed to list indexes of %s: %w", t.id, err)
+		}
+		if exists {
+			if !c.isBuilding(t.id) {
+				c.finish(ctx, t, state, nil)
+			}
+			continue
+		}
+
+		progress, err := t.builder.Progress(ctx, t.index.Collection, t.index.Name())
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read index build progress", "migration", t.id, "error", err)
+		}
+		if progress != nil || c.isBuilding(t.id) {
+			running = true
+			if progress != nil && leads {
+				state.Progress = progress
+				c.save(ctx, state)
+			}
+			continue
+		}
+
+		if !leads || running || (state.Status == StatusFailed && state.Attempts >= c.cfg.MaxAttempts) {
+			continue
+		}
+		running = true
+		c.start(ctx, t, state)
+	}
+	return nil
+}
+
+// start builds an index in the background
+func (c *Coordinator) start(ctx context.Context, t target, state *State) {
+	state.Status = StatusBuilding
+	state.Attempts++
+	state.Error = ""
+	state.Progress = nil
+	state.StartedAt = time.Now()
+	c.mu.Lock()
+	c.building[t.id] = true
+	c.mu.Unlock()
+	c.save(ctx, state)
+	slog.InfoContext(ctx, "Building index", "migration", t.id, "attempt", state.Attempts)
+
+	// MongoDB finishes a build the instance stops waiting for; its outcome is recorded even during shutdown
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		err := t.builder.Build(ctx, t.index)
+		c.mu.Lock()
+		delete(c.building, t.id)
+		c.mu.Unlock()
+		c.finish(ctx, t, c.State(t.id), err)
+	}()
+}
+
+// finish records the outcome of a build
+func (c *Coordinator) finish(ctx context.Context, t target, state *State, err error) {
+	state.FinishedAt = time.Now()
+	if err != nil {
+		state.Status = StatusFailed
+		state.Error = err.Error()
+		slog.ErrorContext(ctx, "Index build failed", "migration", t.id, "attempt", state.Attempts, "error", err)
+	} else {
+		state.Status = StatusReady
+		state.Error = ""
+		if state.Progress != nil {
+			state.Progress.Done = state.Progress.Total
+		}
+		slog.InfoContext(ctx, "Index ready", "migration", t.id, "features", t.index.Features)
+	}
+	c.save(ctx, state)
+}
+
+func (c *Coordinator) save(ctx context.Context, state *State) {
+	state.UpdatedAt = time.Now()
+	c.mu.Lock()
+	copied := *state
+	c.states[state.ID] = &copied
+	c.mu.Unlock()
+	if err := c.store.SaveState(ctx, &copied); err != nil {
+		slog.ErrorContext(ctx, "Failed to save migration state", "migration", state.ID, "error", err)
+	}
+}
+
+func (c *Coordinator) isBuilding(id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.building[id]
+}
+
+// State returns a copy of the state of a migration
+func (c *Coordinator) State(id string) *State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	copied := *c.states[id]
+	return &copied
+}
+
+// States returns the state of every migration, in build order
+func (c *Coordinator) States() []*State {
+	states := make([]*State, 0, len(c.targets))
+	for _, t := range c.targets {
+		states = append(states, c.State(t.id))
+	}
+	return states
+}
+
+// Retry lets a failed migration be built again, with a fresh count of attempts
+func (c *Coordinator) Retry(ctx context.Context, id string) (*State, error) {
+	c.mu.RLock()
+	_, known := c.states[id]
+	c.mu.RUnlock()
+	if !known {
+		return nil, ErrUnknownMigration
+	}
+
+	state := c.State(id)
+	if state.Status == StatusFailed {
+		state.Status = StatusPending
+		state.Attempts = 0
+		c.save(ctx, state)
+	}
+	return state, nil
+}
+
+// Ready reports whether every index a feature relies on is built; features no index lists are always ready
+// A nil *Coordinator gates nothing
+func (c *Coordinator) Ready(feature string) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, s := range c.states {
+		if s.Status != StatusReady && slices.Contains(s.Features, feature) {
+			return false
+		}
+	}
+	return true
+}
+
+// Features reports, for every feature an index lists, whether it is ready
+func (c *Coordinator) Features() map[string]bool {
+	features := map[string]bool{}
+	for _, t := range c.targets {
+		for _, f := range t.index.Features {
+			features[f] = c.Ready(f)
+		}
+	}
+	return features
+}
+
+// Gate wraps a task so that it is skipped while the indexes of a feature are being built
+func (c *Coordinator) Gate(feature string, run func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if !c.Ready(feature) {
+			slog.InfoContext(ctx, "Skipping task until its indexes are built", "feature", feature)
+			return nil
+		}
+		return run(ctx)
+	}
+}
+
+// Require returns an HTTP middleware answering 503 while the indexes of a feature are being built
+func (c *Coordinator) Require(feature string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.Ready(feature) {
+				w.Header().Set("Retry-After", "60")
+				writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+					"error":   "feature_unavailable",
+					"message": "indexes of " + feature + " are still being built",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const stateCollection = "migrations"
+
+// MongoStore stores migration states in MongoDB
+type MongoStore struct {
+	conn *mongo.Database
+}
+
+// NewMongoStore creates a new MongoDB migration state store
+func NewMongoStore(conn *mongo.Database) *MongoStore {
+	return &MongoStore{conn: conn}
+}
+
+func (s *MongoStore) ListStates(ctx context.Context) ([]*State, error) {
+	cursor, err := s.conn.Collection(stateCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var states []*State
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+func (s *MongoStore) SaveState(ctx context.Context, state *State) error {
+	_, err := s.conn.Collection(stateCollection).ReplaceOne(ctx,
+		bson.M{"_id": state.ID}, state, options.Replace().SetUpsert(true))
+	return err
+}
+
+// MongoBuilder builds indexes in a MongoDB database
+// Since MongoDB 4.2 index builds only lock the collection briefly when they start and finish
+type MongoBuilder struct {
+	name string
+	db   *mongo.Database
+}
+
+// NewMongoBuilder creates a builder for db; name identifies the database in migration states, e.g. its
+// storage profile, since profiles may use the same database name on different clusters
+func NewMongoBuilder(name string, db *mongo.Database) *MongoBuilder {
+	return &MongoBuilder{name: name, db: db}
+}
+
+func (b *MongoBuilder) Database() string {
+	return b.name
+}
+
+func (b *MongoBuilder) Exists(ctx context.Context, collection, name string) (bool, error) {
+	cursor, err := b.db.Collection(collection).Indexes().List(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	for cursor.Next(ctx) {
+		var spec struct {
+			Name      string   `bson:"name"`
+			BuildUUID bson.Raw `bson:"buildUUID"` // Set on builds still in progress
+		}
+		if err := cursor.Decode(&spec); err != nil {
+			return false, err
+		}
+		if spec.Name == name && spec.BuildUUID == nil {
+			return true, nil
+		}
+	}
+	return false, cursor.Err()
+}
+
+func (b *MongoBuilder) Build(ctx context.Context, index Index) error {
+	opts := options.Index().SetName(index.Name())
+	if index.Unique {
+		opts.SetUnique(true)
+	}
+	if index.Expires {
+		opts.SetExpireAfterSeconds(0)
+	}
+	_, err := b.db.Collection(index.Collection).Indexes().CreateOne(ctx, mongo.IndexModel{Keys: index.Keys, Options: opts})
+	return err
+}
+
+// Progress reads the build from $currentOp, which needs the inprog privilege; without it progress is not reported
+func (b *MongoBuilder) Progress(ctx context.Context, collection, name string) (*Progress, error) {
+	cursor, err := b.db.Client().Database("admin").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$currentOp", Value: bson.M{"allUsers": true}}},
+		{{Key: "$match", Value: bson.M{
+			"command.$db":           b.db.Name(),
+			"command.createIndexes": collection,
+			"command.indexes.name":  name,
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	if !cursor.Next(ctx) {
+		return nil, cursor.Err()
+	}
+	var op struct {
+		Progress *Progress `bson:"progress"`
+	}
+	if err := cursor.Decode(&op); err != nil {
+		return nil, err
+	}
+	if op.Progress == nil {
+		// Builds report no progress while they wait for locks or commit
+		return &Progress{}, nil
+	}
+	return op.Progress, nil
+}
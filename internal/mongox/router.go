@@ -0,0 +1,91 @@
+package mongox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Router sends the data of tenants with a storage profile to the database of that profile,
+// e.g. to keep EU tenants in an EU cluster; other tenants stay in the repository's own database
+// A nil *Router routes nothing
+type Router struct {
+	databases map[string]*mongo.Database // Profile -> database
+	tenants   map[string]string          // Tenant -> profile
+}
+
+// NewRouter creates a router from profile -> database and tenant -> profile
+func NewRouter(databases map[string]*mongo.Database, tenants map[string]string) (*Router, error) {
+	for id, profile := range tenants {
+		if _, ok := databases[profile]; !ok {
+			return nil, fmt.Errorf("tenant %s uses unknown storage profile %q", id, profile)
+		}
+	}
+	return &Router{databases: databases, tenants: tenants}, nil
+}
+
+// MustConnectRouter connects to the database of every profile in uris (profile -> URI)
+// Returns nil when no tenant is routed, and panics on an unknown profile or a failed connection
+func MustConnectRouter(uris map[string]string, dbname string, tenants map[string]string) *Router {
+	if len(tenants) == 0 {
+		return nil
+	}
+	databases := make(map[string]*mongo.Database, len(uris))
+	for profile, uri := range uris {
+		databases[profile] = MustConnect(uri, dbname)
+	}
+	r, err := NewRouter(databases, tenants)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Database returns the database of the context's tenant, or false when the tenant has no storage profile
+func (r *Router) Database(ctx context.Context) (*mongo.Database, bool) {
+	return r.TenantDatabase(tenant.FromContext(ctx))
+}
+
+// TenantDatabase returns the database of a tenant, or false when the tenant has no storage profile
+func (r *Router) TenantDatabase(id string) (*mongo.Database, bool) {
+	if r == nil {
+		return nil, false
+	}
+	if id == "" {
+		id = tenant.DefaultID
+	}
+	profile, ok := r.tenants[id]
+	if !ok {
+		return nil, false
+	}
+	return r.databases[profile], true
+}
+
+// Profiles returns the name of every profile in the order of Databases
+func (r *Router) Profiles() []string {
+	if r == nil {
+		return nil
+	}
+	profiles := make([]string, 0, len(r.databases))
+	for profile := range r.databases {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// Databases returns the database of every profile ordered by profile name, for work across all tenants
+func (r *Router) Databases() []*mongo.Database {
+	if r == nil {
+		return nil
+	}
+	profiles := r.Profiles()
+	databases := make([]*mongo.Database, 0, len(profiles))
+	for _, profile := range profiles {
+		databases = append(databases, r.databases[profile])
+	}
+	return databases
+}
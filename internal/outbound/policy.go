@@ -0,0 +1,247 @@
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked is returned when a URL or the address it resolves to is not allowed
+var ErrBlocked = errors.New("outbound request blocked")
+
+// DefaultMaxRedirects is used when Config.MaxRedirects is not set
+const DefaultMaxRedirects = 5
+
+// Config describes which destinations outbound requests may reach
+type Config struct {
+	AllowedSchemes  []string // Defaults to http and https
+	AllowedHosts    []string // If set, only these hosts and their subdomains may be reached
+	DeniedHosts     []string // Hosts and their subdomains that are never reached
+	PrivateNetworks []string // CIDRs of non-public ranges that may be reached, e.g. internal tool services
+	MaxRedirects    int      // Redirects followed before a request fails
+}
+
+// Policy guards outbound requests against SSRF
+// URLs are checked before every request and redirect, and every resolved address is checked
+// again right before connecting, so DNS rebinding cannot smuggle in a private address
+type Policy struct {
+	schemes      map[string]bool
+	allowed      []string
+	denied       []string
+	networks     []netip.Prefix
+	maxRedirects int
+}
+
+// NewPolicy creates an outbound request policy
+func NewPolicy(cfg Config) (*Policy, error) {
+	if len(cfg.AllowedSchemes) == 0 {
+		cfg.AllowedSchemes = []string{"http", "https"}
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = DefaultMaxRedirects
+	}
+
+	p := &Policy{
+		schemes:      make(map[string]bool, len(cfg.AllowedSchemes)),
+		allowed:      normalizeHosts(cfg.AllowedHosts),
+		denied:       normalizeHosts(cfg.DeniedHosts),
+		maxRedirects: cfg.MaxRedirects,
+	}
+	for _, scheme := range cfg.AllowedSchemes {
+		p.schemes[strings.ToLower(strings.TrimSpace(scheme))] = true
+	}
+	for _, cidr := range cfg.PrivateNetworks {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private network %q: %w", cidr, err)
+		}
+		p.networks = append(p.networks, prefix.Masked())
+	}
+
+	return p, nil
+}
+
+// MustPolicy creates an outbound request policy or panics on invalid configuration
+func MustPolicy(cfg Config) *Policy {
+	p, err := NewPolicy(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("failed to configure outbound policy: %v", err))
+	}
+	return p
+}
+
+// CheckURL enforces the scheme and the host allow and deny lists
+// Literal IP hosts are checked like resolved addresses
+func (p *Policy) CheckURL(u *url.URL) error {
+	if !p.schemes[strings.ToLower(u.Scheme)] {
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrBlocked, u.Scheme)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: URLs with credentials are not supported", ErrBlocked)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlocked)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if err := p.CheckAddr(addr); err != nil {
+			return err
+		}
+	} else if len(p.networks) == 0 && isInternalName(host) {
+		// Without private networks there is nothing an internal name could legitimately reach
+		return fmt.Errorf("%w: %s is not a public host", ErrBlocked, host)
+	}
+
+	if matchesHost(host, p.denied) {
+		return fmt.Errorf("%w: %s is denied", ErrBlocked, host)
+	}
+	if len(p.allowed) > 0 && !matchesHost(host, p.allowed) {
+		return fmt.Errorf("%w: %s is not allowed", ErrBlocked, host)
+	}
+
+	return nil
+}
+
+// CheckAddr allows public addresses and addresses inside the configured private networks
+func (p *Policy) CheckAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if IsPublicAddr(addr) {
+		return nil
+	}
+	for _, prefix := range p.networks {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not a public address", ErrBlocked, addr)
+}
+
+// Client returns a copy of base that enforces the policy
+// The transport is cloned with proxies disabled, since a proxy would resolve hosts itself
+func (p *Policy) Client(base *http.Client) (*http.Client, error) {
+	if base == nil {
+		base = &http.Client{}
+	}
+
+	var transport *http.Transport
+	switch t := base.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("unsupported transport %T", t)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   p.control,
+	}
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	client := *base
+	client.Transport = &guardedTransport{policy: p, next: transport}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= p.maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrBlocked, p.maxRedirects)
+		}
+		return p.CheckURL(req.URL)
+	}
+
+	return &client, nil
+}
+
+// MustClient returns a copy of base that enforces the policy or panics on an unsupported transport
+func (p *Policy) MustClient(base *http.Client) *http.Client {
+	client, err := p.Client(base)
+	if err != nil {
+		panic(fmt.Sprintf("failed to apply outbound policy: %v", err))
+	}
+	return client
+}
+
+// control runs after DNS resolution, right before each connection attempt
+func (p *Policy) control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %s", ErrBlocked, address)
+	}
+	return p.CheckAddr(addrPort.Addr())
+}
+
+// guardedTransport checks every request URL, including ones made without the policy's client
+type guardedTransport struct {
+	policy *Policy
+	next   http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.CheckURL(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+func isInternalName(host string) bool {
+	return host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal")
+}
+
+func normalizeHosts(hosts []string) []string {
+	var normalized []string
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "*."))
+		if h != "" {
+			normalized = append(normalized, h)
+		}
+	}
+	return normalized
+}
+
+// matchesHost reports whether host equals one of the patterns or is a subdomain of one
+func matchesHost(host string, patterns []string) bool {
+	for _, p := range patterns {
+		if host == p || strings.HasSuffix(host, "."+p) {
+			return true
+		}
+	}
+	return false
+}
+
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, may map to private IPv4
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // Documentation
+	netip.MustParsePrefix("fec0::/10"),      // Deprecated site-local
+}
+
+// IsPublicAddr reports whether addr is a globally routable unicast address
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
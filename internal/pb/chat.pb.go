@@ -28,6 +28,7 @@ const (
 	Conversation_UNKNOWN   Conversation_Role = 0
 	Conversation_USER      Conversation_Role = 1
 	Conversation_ASSISTANT Conversation_Role = 2
+	Conversation_SYSTEM    Conversation_Role = 3 // Operator notes added by admins, see Message.author
 )
 
 // Enum value maps for Conversation_Role.
@@ -36,11 +37,13 @@ var (
 		0: "UNKNOWN",
 		1: "USER",
 		2: "ASSISTANT",
+		3: "SYSTEM",
 	}
 	Conversation_Role_value = map[string]int32{
 		"UNKNOWN":   0,
 		"USER":      1,
 		"ASSISTANT": 2,
+		"SYSTEM":    3,
 	}
 )
 
@@ -72,13 +75,15 @@ func (Conversation_Role) EnumDescriptor() ([]byte, []int) {
 }
 
 type Conversation struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	Id            string                  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title         string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Timestamp     *timestamppb.Timestamp  `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Messages      []*Conversation_Message `protobuf:"bytes,4,rep,name=messages,proto3" json:"messages,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState  `protogen:"open.v1"`
+	Id             string                  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title          string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Timestamp      *timestamppb.Timestamp  `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Messages       []*Conversation_Message `protobuf:"bytes,4,rep,name=messages,proto3" json:"messages,omitempty"`
+	SentimentScore *float64                `protobuf:"fixed64,5,opt,name=sentiment_score,json=sentimentScore,proto3,oneof" json:"sentiment_score,omitempty"` // Moving average from -1 to 1; unset until a message is classified
+	Instructions   string                  `protobuf:"bytes,6,opt,name=instructions,proto3" json:"instructions,omitempty"`                                   // Custom instructions set with SetConversationInstructions
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *Conversation) Reset() {
@@ -139,10 +144,25 @@ func (x *Conversation) GetMessages() []*Conversation_Message {
 	return nil
 }
 
+func (x *Conversation) GetSentimentScore() float64 {
+	if x != nil && x.SentimentScore != nil {
+		return *x.SentimentScore
+	}
+	return 0
+}
+
+func (x *Conversation) GetInstructions() string {
+	if x != nil {
+		return x.Instructions
+	}
+	return ""
+}
+
 type StartConversationRequest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	Message         string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	SessionMetadata *SessionMetadata       `protobuf:"bytes,2,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"` // NEW optional field
+	Style           *ReplyStyle            `protobuf:"bytes,3,opt,name=style,proto3" json:"style,omitempty"`                                            // Optional shape of the reply, e.g. for constrained UIs such as SMS
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -191,11 +211,20 @@ func (x *StartConversationRequest) GetSessionMetadata() *SessionMetadata {
 	return nil
 }
 
+func (x *StartConversationRequest) GetStyle() *ReplyStyle {
+	if x != nil {
+		return x.Style
+	}
+	return nil
+}
+
 type StartConversationResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
 	Title          string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
 	Reply          string                 `protobuf:"bytes,3,opt,name=reply,proto3" json:"reply,omitempty"`
+	BudgetExceeded bool                   `protobuf:"varint,4,opt,name=budget_exceeded,json=budgetExceeded,proto3" json:"budget_exceeded,omitempty"` // The X-Request-Budget-Ms budget ran out: reply is a partial answer or a notice, and is not stored
+	Greeting       string                 `protobuf:"bytes,5,opt,name=greeting,proto3" json:"greeting,omitempty"`                                    // Configured welcome to send before the reply, see GREETINGS
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -251,11 +280,26 @@ func (x *StartConversationResponse) GetReply() string {
 	return ""
 }
 
+func (x *StartConversationResponse) GetBudgetExceeded() bool {
+	if x != nil {
+		return x.BudgetExceeded
+	}
+	return false
+}
+
+func (x *StartConversationResponse) GetGreeting() string {
+	if x != nil {
+		return x.Greeting
+	}
+	return ""
+}
+
 type ContinueConversationRequest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	ConversationId  string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`    // EXISTING field
 	Message         string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`                                        // EXISTING field
 	SessionMetadata *SessionMetadata       `protobuf:"bytes,3,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"` // NEW optional field
+	Style           *ReplyStyle            `protobuf:"bytes,4,opt,name=style,proto3" json:"style,omitempty"`                                            // Optional shape of the reply, e.g. for constrained UIs such as SMS
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -311,6 +355,13 @@ func (x *ContinueConversationRequest) GetSessionMetadata() *SessionMetadata {
 	return nil
 }
 
+func (x *ContinueConversationRequest) GetStyle() *ReplyStyle {
+	if x != nil {
+		return x.Style
+	}
+	return nil
+}
+
 type SessionMetadata struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Platform      string                 `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"` // "telegram", "web", "api"
@@ -371,16 +422,79 @@ func (x *SessionMetadata) GetChatId() string {
 	return ""
 }
 
-type ContinueConversationResponse struct {
+// ReplyStyle shapes a single reply; unset fields leave the assistant's defaults
+type ReplyStyle struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Reply         string                 `protobuf:"bytes,1,opt,name=reply,proto3" json:"reply,omitempty"`
+	MaxLength     int32                  `protobuf:"varint,1,opt,name=max_length,json=maxLength,proto3" json:"max_length,omitempty"`         // Longest reply in characters, 0 for no limit; longer replies are shortened
+	Format        string                 `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`                                 // "bullets" or "prose"
+	ReadingLevel  string                 `protobuf:"bytes,3,opt,name=reading_level,json=readingLevel,proto3" json:"reading_level,omitempty"` // "simple", "standard" or "expert"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *ReplyStyle) Reset() {
+	*x = ReplyStyle{}
+	mi := &file_rpc_chat_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplyStyle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplyStyle) ProtoMessage() {}
+
+func (x *ReplyStyle) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplyStyle.ProtoReflect.Descriptor instead.
+func (*ReplyStyle) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReplyStyle) GetMaxLength() int32 {
+	if x != nil {
+		return x.MaxLength
+	}
+	return 0
+}
+
+func (x *ReplyStyle) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ReplyStyle) GetReadingLevel() string {
+	if x != nil {
+		return x.ReadingLevel
+	}
+	return ""
+}
+
+type ContinueConversationResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Reply          string                 `protobuf:"bytes,1,opt,name=reply,proto3" json:"reply,omitempty"`
+	BudgetExceeded bool                   `protobuf:"varint,2,opt,name=budget_exceeded,json=budgetExceeded,proto3" json:"budget_exceeded,omitempty"` // The X-Request-Budget-Ms budget ran out: reply is a partial answer or a notice, and is not stored
+	Greeting       string                 `protobuf:"bytes,3,opt,name=greeting,proto3" json:"greeting,omitempty"`                                    // Configured welcome to send before the first reply of a session, see GREETINGS
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
 func (x *ContinueConversationResponse) Reset() {
 	*x = ContinueConversationResponse{}
-	mi := &file_rpc_chat_proto_msgTypes[5]
+	mi := &file_rpc_chat_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -392,7 +506,7 @@ func (x *ContinueConversationResponse) String() string {
 func (*ContinueConversationResponse) ProtoMessage() {}
 
 func (x *ContinueConversationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_chat_proto_msgTypes[5]
+	mi := &file_rpc_chat_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -405,7 +519,7 @@ func (x *ContinueConversationResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ContinueConversationResponse.ProtoReflect.Descriptor instead.
 func (*ContinueConversationResponse) Descriptor() ([]byte, []int) {
-	return file_rpc_chat_proto_rawDescGZIP(), []int{5}
+	return file_rpc_chat_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ContinueConversationResponse) GetReply() string {
@@ -415,6 +529,20 @@ func (x *ContinueConversationResponse) GetReply() string {
 	return ""
 }
 
+func (x *ContinueConversationResponse) GetBudgetExceeded() bool {
+	if x != nil {
+		return x.BudgetExceeded
+	}
+	return false
+}
+
+func (x *ContinueConversationResponse) GetGreeting() string {
+	if x != nil {
+		return x.Greeting
+	}
+	return ""
+}
+
 type ListConversationsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -423,7 +551,7 @@ type ListConversationsRequest struct {
 
 func (x *ListConversationsRequest) Reset() {
 	*x = ListConversationsRequest{}
-	mi := &file_rpc_chat_proto_msgTypes[6]
+	mi := &file_rpc_chat_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -435,7 +563,7 @@ func (x *ListConversationsRequest) String() string {
 func (*ListConversationsRequest) ProtoMessage() {}
 
 func (x *ListConversationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_chat_proto_msgTypes[6]
+	mi := &file_rpc_chat_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -448,7 +576,7 @@ func (x *ListConversationsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListConversationsRequest.ProtoReflect.Descriptor instead.
 func (*ListConversationsRequest) Descriptor() ([]byte, []int) {
-	return file_rpc_chat_proto_rawDescGZIP(), []int{6}
+	return file_rpc_chat_proto_rawDescGZIP(), []int{7}
 }
 
 type ListConversationsResponse struct {
@@ -460,7 +588,7 @@ type ListConversationsResponse struct {
 
 func (x *ListConversationsResponse) Reset() {
 	*x = ListConversationsResponse{}
-	mi := &file_rpc_chat_proto_msgTypes[7]
+	mi := &file_rpc_chat_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -472,7 +600,7 @@ func (x *ListConversationsResponse) String() string {
 func (*ListConversationsResponse) ProtoMessage() {}
 
 func (x *ListConversationsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_chat_proto_msgTypes[7]
+	mi := &file_rpc_chat_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -485,7 +613,7 @@ func (x *ListConversationsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListConversationsResponse.ProtoReflect.Descriptor instead.
 func (*ListConversationsResponse) Descriptor() ([]byte, []int) {
-	return file_rpc_chat_proto_rawDescGZIP(), []int{7}
+	return file_rpc_chat_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ListConversationsResponse) GetConversations() []*Conversation {
@@ -504,7 +632,7 @@ type DescribeConversationRequest struct {
 
 func (x *DescribeConversationRequest) Reset() {
 	*x = DescribeConversationRequest{}
-	mi := &file_rpc_chat_proto_msgTypes[8]
+	mi := &file_rpc_chat_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -516,7 +644,7 @@ func (x *DescribeConversationRequest) String() string {
 func (*DescribeConversationRequest) ProtoMessage() {}
 
 func (x *DescribeConversationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_chat_proto_msgTypes[8]
+	mi := &file_rpc_chat_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -529,7 +657,7 @@ func (x *DescribeConversationRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DescribeConversationRequest.ProtoReflect.Descriptor instead.
 func (*DescribeConversationRequest) Descriptor() ([]byte, []int) {
-	return file_rpc_chat_proto_rawDescGZIP(), []int{8}
+	return file_rpc_chat_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *DescribeConversationRequest) GetConversationId() string {
@@ -542,13 +670,14 @@ func (x *DescribeConversationRequest) GetConversationId() string {
 type DescribeConversationResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Conversation  *Conversation          `protobuf:"bytes,1,opt,name=conversation,proto3" json:"conversation,omitempty"`
+	Stats         *ConversationStats     `protobuf:"bytes,2,opt,name=stats,proto3" json:"stats,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DescribeConversationResponse) Reset() {
 	*x = DescribeConversationResponse{}
-	mi := &file_rpc_chat_proto_msgTypes[9]
+	mi := &file_rpc_chat_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -560,7 +689,7 @@ func (x *DescribeConversationResponse) String() string {
 func (*DescribeConversationResponse) ProtoMessage() {}
 
 func (x *DescribeConversationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_chat_proto_msgTypes[9]
+	mi := &file_rpc_chat_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -573,7 +702,7 @@ func (x *DescribeConversationResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DescribeConversationResponse.ProtoReflect.Descriptor instead.
 func (*DescribeConversationResponse) Descriptor() ([]byte, []int) {
-	return file_rpc_chat_proto_rawDescGZIP(), []int{9}
+	return file_rpc_chat_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *DescribeConversationResponse) GetConversation() *Conversation {
@@ -583,31 +712,42 @@ func (x *DescribeConversationResponse) GetConversation() *Conversation {
 	return nil
 }
 
-type Conversation_Message struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Role          Conversation_Role      `protobuf:"varint,2,opt,name=role,proto3,enum=acai.chat.Conversation_Role" json:"role,omitempty"`
-	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *DescribeConversationResponse) GetStats() *ConversationStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
 }
 
-func (x *Conversation_Message) Reset() {
-	*x = Conversation_Message{}
-	mi := &file_rpc_chat_proto_msgTypes[10]
+// Figures about a conversation for info panes, computed when it is described
+type ConversationStats struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MessageCount   int32                  `protobuf:"varint,1,opt,name=message_count,json=messageCount,proto3" json:"message_count,omitempty"` // User and assistant messages, without operator notes
+	TotalTokens    int64                  `protobuf:"varint,2,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`    // Tokens spent on replies, including their tool calls
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LastActivityAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_activity_at,json=lastActivityAt,proto3" json:"last_activity_at,omitempty"`
+	Persona        string                 `protobuf:"bytes,5,opt,name=persona,proto3" json:"persona,omitempty"`                // Empty when replies use the user's prompt segment
+	Language       string                 `protobuf:"bytes,6,opt,name=language,proto3" json:"language,omitempty"`              // Language code replies use, empty until one is known
+	Summarizations int32                  `protobuf:"varint,7,opt,name=summarizations,proto3" json:"summarizations,omitempty"` // Segments of older messages summarized to keep the context small
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ConversationStats) Reset() {
+	*x = ConversationStats{}
+	mi := &file_rpc_chat_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Conversation_Message) String() string {
+func (x *ConversationStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Conversation_Message) ProtoMessage() {}
+func (*ConversationStats) ProtoMessage() {}
 
-func (x *Conversation_Message) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_chat_proto_msgTypes[10]
+func (x *ConversationStats) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -618,87 +758,1672 @@ func (x *Conversation_Message) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Conversation_Message.ProtoReflect.Descriptor instead.
-func (*Conversation_Message) Descriptor() ([]byte, []int) {
-	return file_rpc_chat_proto_rawDescGZIP(), []int{0, 0}
+// Deprecated: Use ConversationStats.ProtoReflect.Descriptor instead.
+func (*ConversationStats) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *Conversation_Message) GetId() string {
+func (x *ConversationStats) GetMessageCount() int32 {
+	if x != nil {
+		return x.MessageCount
+	}
+	return 0
+}
+
+func (x *ConversationStats) GetTotalTokens() int64 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *ConversationStats) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ConversationStats) GetLastActivityAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastActivityAt
+	}
+	return nil
+}
+
+func (x *ConversationStats) GetPersona() string {
+	if x != nil {
+		return x.Persona
+	}
+	return ""
+}
+
+func (x *ConversationStats) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ConversationStats) GetSummarizations() int32 {
+	if x != nil {
+		return x.Summarizations
+	}
+	return 0
+}
+
+type Attachment struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	MessageId      string                 `protobuf:"bytes,3,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // Empty when not linked to a message
+	Filename       string                 `protobuf:"bytes,4,opt,name=filename,proto3" json:"filename,omitempty"`
+	ContentType    string                 `protobuf:"bytes,5,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size           int64                  `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256         string                 `protobuf:"bytes,7,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Timestamp      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_rpc_chat_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Attachment) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *Conversation_Message) GetRole() Conversation_Role {
+func (x *Attachment) GetConversationId() string {
 	if x != nil {
-		return x.Role
+		return x.ConversationId
 	}
-	return Conversation_UNKNOWN
+	return ""
 }
 
-func (x *Conversation_Message) GetContent() string {
+func (x *Attachment) GetMessageId() string {
 	if x != nil {
-		return x.Content
+		return x.MessageId
 	}
 	return ""
 }
 
-func (x *Conversation_Message) GetTimestamp() *timestamppb.Timestamp {
+func (x *Attachment) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Attachment) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Attachment) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Attachment) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *Attachment) GetTimestamp() *timestamppb.Timestamp {
 	if x != nil {
 		return x.Timestamp
 	}
 	return nil
 }
 
-var File_rpc_chat_proto protoreflect.FileDescriptor
+type UploadAttachmentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	MessageId      string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // Optional
+	Filename       string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
+	ContentType    string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Content        []byte                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
 
-const file_rpc_chat_proto_rawDesc = "" +
-	"\n" +
-	"\x0erpc/chat.proto\x12\tacai.chat\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfb\x02\n" +
-	"\fConversation\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
-	"\x05title\x18\x02 \x01(\tR\x05title\x128\n" +
-	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12;\n" +
-	"\bmessages\x18\x04 \x03(\v2\x1f.acai.chat.Conversation.MessageR\bmessages\x1a\x9f\x01\n" +
-	"\aMessage\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
-	"\x04role\x18\x02 \x01(\x0e2\x1c.acai.chat.Conversation.RoleR\x04role\x12\x18\n" +
-	"\acontent\x18\x03 \x01(\tR\acontent\x128\n" +
-	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\",\n" +
-	"\x04Role\x12\v\n" +
-	"\aUNKNOWN\x10\x00\x12\b\n" +
-	"\x04USER\x10\x01\x12\r\n" +
-	"\tASSISTANT\x10\x02\"{\n" +
-	"\x18StartConversationRequest\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\x12E\n" +
-	"\x10session_metadata\x18\x02 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"p\n" +
-	"\x19StartConversationResponse\x12'\n" +
-	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x14\n" +
-	"\x05title\x18\x02 \x01(\tR\x05title\x12\x14\n" +
-	"\x05reply\x18\x03 \x01(\tR\x05reply\"\xa7\x01\n" +
-	"\x1bContinueConversationRequest\x12'\n" +
-	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12E\n" +
-	"\x10session_metadata\x18\x03 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"_\n" +
-	"\x0fSessionMetadata\x12\x1a\n" +
-	"\bplatform\x18\x01 \x01(\tR\bplatform\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
-	"\achat_id\x18\x03 \x01(\tR\x06chatId\"4\n" +
-	"\x1cContinueConversationResponse\x12\x14\n" +
-	"\x05reply\x18\x01 \x01(\tR\x05reply\"\x1a\n" +
-	"\x18ListConversationsRequest\"Z\n" +
-	"\x19ListConversationsResponse\x12=\n" +
-	"\rconversations\x18\x01 \x03(\v2\x17.acai.chat.ConversationR\rconversations\"F\n" +
-	"\x1bDescribeConversationRequest\x12'\n" +
-	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\"[\n" +
+func (x *UploadAttachmentRequest) Reset() {
+	*x = UploadAttachmentRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadAttachmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadAttachmentRequest) ProtoMessage() {}
+
+func (x *UploadAttachmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadAttachmentRequest.ProtoReflect.Descriptor instead.
+func (*UploadAttachmentRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UploadAttachmentRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *UploadAttachmentRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *UploadAttachmentRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *UploadAttachmentRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *UploadAttachmentRequest) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type UploadAttachmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attachment    *Attachment            `protobuf:"bytes,1,opt,name=attachment,proto3" json:"attachment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadAttachmentResponse) Reset() {
+	*x = UploadAttachmentResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadAttachmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadAttachmentResponse) ProtoMessage() {}
+
+func (x *UploadAttachmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadAttachmentResponse.ProtoReflect.Descriptor instead.
+func (*UploadAttachmentResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UploadAttachmentResponse) GetAttachment() *Attachment {
+	if x != nil {
+		return x.Attachment
+	}
+	return nil
+}
+
+type GetAttachmentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	AttachmentId   string                 `protobuf:"bytes,2,opt,name=attachment_id,json=attachmentId,proto3" json:"attachment_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetAttachmentRequest) Reset() {
+	*x = GetAttachmentRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAttachmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAttachmentRequest) ProtoMessage() {}
+
+func (x *GetAttachmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAttachmentRequest.ProtoReflect.Descriptor instead.
+func (*GetAttachmentRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetAttachmentRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *GetAttachmentRequest) GetAttachmentId() string {
+	if x != nil {
+		return x.AttachmentId
+	}
+	return ""
+}
+
+type GetAttachmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attachment    *Attachment            `protobuf:"bytes,1,opt,name=attachment,proto3" json:"attachment,omitempty"`
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAttachmentResponse) Reset() {
+	*x = GetAttachmentResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAttachmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAttachmentResponse) ProtoMessage() {}
+
+func (x *GetAttachmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAttachmentResponse.ProtoReflect.Descriptor instead.
+func (*GetAttachmentResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetAttachmentResponse) GetAttachment() *Attachment {
+	if x != nil {
+		return x.Attachment
+	}
+	return nil
+}
+
+func (x *GetAttachmentResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type AddReactionRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId  string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	MessageId       string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Emoji           string                 `protobuf:"bytes,3,opt,name=emoji,proto3" json:"emoji,omitempty"`                                            // A single emoji, e.g. "👍" or "👎"
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,4,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"` // Identifies the reacting user; defaults to the conversation's user
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AddReactionRequest) Reset() {
+	*x = AddReactionRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddReactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddReactionRequest) ProtoMessage() {}
+
+func (x *AddReactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddReactionRequest.ProtoReflect.Descriptor instead.
+func (*AddReactionRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AddReactionRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *AddReactionRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *AddReactionRequest) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+func (x *AddReactionRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+type AddReactionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       *Conversation_Message  `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddReactionResponse) Reset() {
+	*x = AddReactionResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddReactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddReactionResponse) ProtoMessage() {}
+
+func (x *AddReactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddReactionResponse.ProtoReflect.Descriptor instead.
+func (*AddReactionResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AddReactionResponse) GetMessage() *Conversation_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+type RequestDataExportRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,1,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RequestDataExportRequest) Reset() {
+	*x = RequestDataExportRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestDataExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestDataExportRequest) ProtoMessage() {}
+
+func (x *RequestDataExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestDataExportRequest.ProtoReflect.Descriptor instead.
+func (*RequestDataExportRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RequestDataExportRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+type RequestDataExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Export        *DataExport            `protobuf:"bytes,1,opt,name=export,proto3" json:"export,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestDataExportResponse) Reset() {
+	*x = RequestDataExportResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestDataExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestDataExportResponse) ProtoMessage() {}
+
+func (x *RequestDataExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestDataExportResponse.ProtoReflect.Descriptor instead.
+func (*RequestDataExportResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RequestDataExportResponse) GetExport() *DataExport {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+type GetDataExportRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ExportId        string                 `protobuf:"bytes,1,opt,name=export_id,json=exportId,proto3" json:"export_id,omitempty"`
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,2,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"` // Must name the user who requested the export
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetDataExportRequest) Reset() {
+	*x = GetDataExportRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDataExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDataExportRequest) ProtoMessage() {}
+
+func (x *GetDataExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDataExportRequest.ProtoReflect.Descriptor instead.
+func (*GetDataExportRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetDataExportRequest) GetExportId() string {
+	if x != nil {
+		return x.ExportId
+	}
+	return ""
+}
+
+func (x *GetDataExportRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+type GetDataExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Export        *DataExport            `protobuf:"bytes,1,opt,name=export,proto3" json:"export,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDataExportResponse) Reset() {
+	*x = GetDataExportResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDataExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDataExportResponse) ProtoMessage() {}
+
+func (x *GetDataExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDataExportResponse.ProtoReflect.Descriptor instead.
+func (*GetDataExportResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetDataExportResponse) GetExport() *DataExport {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+type DataExport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                              // "pending", "ready", "failed"
+	DownloadUrl   string                 `protobuf:"bytes,3,opt,name=download_url,json=downloadUrl,proto3" json:"download_url,omitempty"` // Signed, time-limited link; set once the export is ready
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataExport) Reset() {
+	*x = DataExport{}
+	mi := &file_rpc_chat_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataExport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataExport) ProtoMessage() {}
+
+func (x *DataExport) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataExport.ProtoReflect.Descriptor instead.
+func (*DataExport) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DataExport) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DataExport) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *DataExport) GetDownloadUrl() string {
+	if x != nil {
+		return x.DownloadUrl
+	}
+	return ""
+}
+
+func (x *DataExport) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *DataExport) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *DataExport) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type ResetSessionRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,1,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ResetSessionRequest) Reset() {
+	*x = ResetSessionRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetSessionRequest) ProtoMessage() {}
+
+func (x *ResetSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetSessionRequest.ProtoReflect.Descriptor instead.
+func (*ResetSessionRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ResetSessionRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+type ResetSessionResponse struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	ArchivedConversationId string                 `protobuf:"bytes,1,opt,name=archived_conversation_id,json=archivedConversationId,proto3" json:"archived_conversation_id,omitempty"` // Empty if the chat had no active conversation
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ResetSessionResponse) Reset() {
+	*x = ResetSessionResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetSessionResponse) ProtoMessage() {}
+
+func (x *ResetSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetSessionResponse.ProtoReflect.Descriptor instead.
+func (*ResetSessionResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ResetSessionResponse) GetArchivedConversationId() string {
+	if x != nil {
+		return x.ArchivedConversationId
+	}
+	return ""
+}
+
+type GetUserSettingsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,1,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetUserSettingsRequest) Reset() {
+	*x = GetUserSettingsRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserSettingsRequest) ProtoMessage() {}
+
+func (x *GetUserSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserSettingsRequest.ProtoReflect.Descriptor instead.
+func (*GetUserSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetUserSettingsRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+type GetUserSettingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *UserSettings          `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserSettingsResponse) Reset() {
+	*x = GetUserSettingsResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserSettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserSettingsResponse) ProtoMessage() {}
+
+func (x *GetUserSettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserSettingsResponse.ProtoReflect.Descriptor instead.
+func (*GetUserSettingsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetUserSettingsResponse) GetSettings() *UserSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type SetUserSettingsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,1,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"`
+	Settings        *UserSettings          `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SetUserSettingsRequest) Reset() {
+	*x = SetUserSettingsRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserSettingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserSettingsRequest) ProtoMessage() {}
+
+func (x *SetUserSettingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserSettingsRequest.ProtoReflect.Descriptor instead.
+func (*SetUserSettingsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SetUserSettingsRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+func (x *SetUserSettingsRequest) GetSettings() *UserSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type SetUserSettingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *UserSettings          `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetUserSettingsResponse) Reset() {
+	*x = SetUserSettingsResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserSettingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserSettingsResponse) ProtoMessage() {}
+
+func (x *SetUserSettingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserSettingsResponse.ProtoReflect.Descriptor instead.
+func (*SetUserSettingsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SetUserSettingsResponse) GetSettings() *UserSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type SetConversationInstructionsRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId  string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Instructions    string                 `protobuf:"bytes,2,opt,name=instructions,proto3" json:"instructions,omitempty"`                              // At most 1500 characters; empty clears the instructions
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,3,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"` // Identifies the user making the change; defaults to the conversation's user
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SetConversationInstructionsRequest) Reset() {
+	*x = SetConversationInstructionsRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConversationInstructionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConversationInstructionsRequest) ProtoMessage() {}
+
+func (x *SetConversationInstructionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConversationInstructionsRequest.ProtoReflect.Descriptor instead.
+func (*SetConversationInstructionsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *SetConversationInstructionsRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *SetConversationInstructionsRequest) GetInstructions() string {
+	if x != nil {
+		return x.Instructions
+	}
+	return ""
+}
+
+func (x *SetConversationInstructionsRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+type SetConversationInstructionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instructions  string                 `protobuf:"bytes,1,opt,name=instructions,proto3" json:"instructions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetConversationInstructionsResponse) Reset() {
+	*x = SetConversationInstructionsResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConversationInstructionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConversationInstructionsResponse) ProtoMessage() {}
+
+func (x *SetConversationInstructionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConversationInstructionsResponse.ProtoReflect.Descriptor instead.
+func (*SetConversationInstructionsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *SetConversationInstructionsResponse) GetInstructions() string {
+	if x != nil {
+		return x.Instructions
+	}
+	return ""
+}
+
+type ClaimSessionRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Anonymous       *SessionMetadata       `protobuf:"bytes,1,opt,name=anonymous,proto3" json:"anonymous,omitempty"`                                    // The anonymous user and chat, e.g. of a web widget before login
+	SessionMetadata *SessionMetadata       `protobuf:"bytes,2,opt,name=session_metadata,json=sessionMetadata,proto3" json:"session_metadata,omitempty"` // The authenticated user and chat on the same platform
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ClaimSessionRequest) Reset() {
+	*x = ClaimSessionRequest{}
+	mi := &file_rpc_chat_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimSessionRequest) ProtoMessage() {}
+
+func (x *ClaimSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimSessionRequest.ProtoReflect.Descriptor instead.
+func (*ClaimSessionRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ClaimSessionRequest) GetAnonymous() *SessionMetadata {
+	if x != nil {
+		return x.Anonymous
+	}
+	return nil
+}
+
+func (x *ClaimSessionRequest) GetSessionMetadata() *SessionMetadata {
+	if x != nil {
+		return x.SessionMetadata
+	}
+	return nil
+}
+
+type ClaimSessionResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ConversationIds []string               `protobuf:"bytes,1,rep,name=conversation_ids,json=conversationIds,proto3" json:"conversation_ids,omitempty"` // Conversations transferred to the authenticated user
+	ConversationId  string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`    // The conversation now in session in the authenticated chat; empty if none moved
+	Settings        *UserSettings          `protobuf:"bytes,3,opt,name=settings,proto3" json:"settings,omitempty"`                                      // The authenticated user's settings after merging; unset if settings are disabled
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ClaimSessionResponse) Reset() {
+	*x = ClaimSessionResponse{}
+	mi := &file_rpc_chat_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimSessionResponse) ProtoMessage() {}
+
+func (x *ClaimSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimSessionResponse.ProtoReflect.Descriptor instead.
+func (*ClaimSessionResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ClaimSessionResponse) GetConversationIds() []string {
+	if x != nil {
+		return x.ConversationIds
+	}
+	return nil
+}
+
+func (x *ClaimSessionResponse) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ClaimSessionResponse) GetSettings() *UserSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type UserSettings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Language      string                 `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`   // Reply language code, e.g. "es"; empty follows the user's language
+	Units         string                 `protobuf:"bytes,2,opt,name=units,proto3" json:"units,omitempty"`         // "metric" (default) or "imperial"
+	Timezone      string                 `protobuf:"bytes,3,opt,name=timezone,proto3" json:"timezone,omitempty"`   // IANA name, e.g. "Europe/Madrid"; defaults to the server's timezone
+	Verbosity     string                 `protobuf:"bytes,4,opt,name=verbosity,proto3" json:"verbosity,omitempty"` // "concise", "normal" (default) or "detailed"
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserSettings) Reset() {
+	*x = UserSettings{}
+	mi := &file_rpc_chat_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserSettings) ProtoMessage() {}
+
+func (x *UserSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserSettings.ProtoReflect.Descriptor instead.
+func (*UserSettings) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *UserSettings) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *UserSettings) GetUnits() string {
+	if x != nil {
+		return x.Units
+	}
+	return ""
+}
+
+func (x *UserSettings) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *UserSettings) GetVerbosity() string {
+	if x != nil {
+		return x.Verbosity
+	}
+	return ""
+}
+
+func (x *UserSettings) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Conversation_Message struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Id            string                   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Role          Conversation_Role        `protobuf:"varint,2,opt,name=role,proto3,enum=acai.chat.Conversation_Role" json:"role,omitempty"`
+	Content       string                   `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Timestamp     *timestamppb.Timestamp   `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	AttachmentIds []string                 `protobuf:"bytes,5,rep,name=attachment_ids,json=attachmentIds,proto3" json:"attachment_ids,omitempty"`
+	Reactions     []*Conversation_Reaction `protobuf:"bytes,6,rep,name=reactions,proto3" json:"reactions,omitempty"`
+	Sentiment     string                   `protobuf:"bytes,7,opt,name=sentiment,proto3" json:"sentiment,omitempty"` // "positive", "negative" or "neutral" for classified user messages
+	Author        string                   `protobuf:"bytes,8,opt,name=author,proto3" json:"author,omitempty"`       // Operator who added a SYSTEM note
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Conversation_Message) Reset() {
+	*x = Conversation_Message{}
+	mi := &file_rpc_chat_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Conversation_Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conversation_Message) ProtoMessage() {}
+
+func (x *Conversation_Message) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conversation_Message.ProtoReflect.Descriptor instead.
+func (*Conversation_Message) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *Conversation_Message) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Conversation_Message) GetRole() Conversation_Role {
+	if x != nil {
+		return x.Role
+	}
+	return Conversation_UNKNOWN
+}
+
+func (x *Conversation_Message) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Conversation_Message) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Conversation_Message) GetAttachmentIds() []string {
+	if x != nil {
+		return x.AttachmentIds
+	}
+	return nil
+}
+
+func (x *Conversation_Message) GetReactions() []*Conversation_Reaction {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+func (x *Conversation_Message) GetSentiment() string {
+	if x != nil {
+		return x.Sentiment
+	}
+	return ""
+}
+
+func (x *Conversation_Message) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+type Conversation_Reaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Emoji         string                 `protobuf:"bytes,2,opt,name=emoji,proto3" json:"emoji,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Conversation_Reaction) Reset() {
+	*x = Conversation_Reaction{}
+	mi := &file_rpc_chat_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Conversation_Reaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conversation_Reaction) ProtoMessage() {}
+
+func (x *Conversation_Reaction) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_chat_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conversation_Reaction.ProtoReflect.Descriptor instead.
+func (*Conversation_Reaction) Descriptor() ([]byte, []int) {
+	return file_rpc_chat_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *Conversation_Reaction) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Conversation_Reaction) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+func (x *Conversation_Reaction) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+var File_rpc_chat_proto protoreflect.FileDescriptor
+
+const file_rpc_chat_proto_rawDesc = "" +
+	"\n" +
+	"\x0erpc/chat.proto\x12\tacai.chat\x1a\x1fgoogle/protobuf/timestamp.proto\"\xff\x05\n" +
+	"\fConversation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12;\n" +
+	"\bmessages\x18\x04 \x03(\v2\x1f.acai.chat.Conversation.MessageR\bmessages\x12,\n" +
+	"\x0fsentiment_score\x18\x05 \x01(\x01H\x00R\x0esentimentScore\x88\x01\x01\x12\"\n" +
+	"\finstructions\x18\x06 \x01(\tR\finstructions\x1a\xbc\x02\n" +
+	"\aMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
+	"\x04role\x18\x02 \x01(\x0e2\x1c.acai.chat.Conversation.RoleR\x04role\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12%\n" +
+	"\x0eattachment_ids\x18\x05 \x03(\tR\rattachmentIds\x12>\n" +
+	"\treactions\x18\x06 \x03(\v2 .acai.chat.Conversation.ReactionR\treactions\x12\x1c\n" +
+	"\tsentiment\x18\a \x01(\tR\tsentiment\x12\x16\n" +
+	"\x06author\x18\b \x01(\tR\x06author\x1as\n" +
+	"\bReaction\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05emoji\x18\x02 \x01(\tR\x05emoji\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"8\n" +
+	"\x04Role\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\b\n" +
+	"\x04USER\x10\x01\x12\r\n" +
+	"\tASSISTANT\x10\x02\x12\n" +
+	"\n" +
+	"\x06SYSTEM\x10\x03B\x12\n" +
+	"\x10_sentiment_score\"\xa8\x01\n" +
+	"\x18StartConversationRequest\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12E\n" +
+	"\x10session_metadata\x18\x02 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\x12+\n" +
+	"\x05style\x18\x03 \x01(\v2\x15.acai.chat.ReplyStyleR\x05style\"\xb5\x01\n" +
+	"\x19StartConversationResponse\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x14\n" +
+	"\x05reply\x18\x03 \x01(\tR\x05reply\x12'\n" +
+	"\x0fbudget_exceeded\x18\x04 \x01(\bR\x0ebudgetExceeded\x12\x1a\n" +
+	"\bgreeting\x18\x05 \x01(\tR\bgreeting\"\xd4\x01\n" +
+	"\x1bContinueConversationRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12E\n" +
+	"\x10session_metadata\x18\x03 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\x12+\n" +
+	"\x05style\x18\x04 \x01(\v2\x15.acai.chat.ReplyStyleR\x05style\"_\n" +
+	"\x0fSessionMetadata\x12\x1a\n" +
+	"\bplatform\x18\x01 \x01(\tR\bplatform\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
+	"\achat_id\x18\x03 \x01(\tR\x06chatId\"h\n" +
+	"\n" +
+	"ReplyStyle\x12\x1d\n" +
+	"\n" +
+	"max_length\x18\x01 \x01(\x05R\tmaxLength\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\x12#\n" +
+	"\rreading_level\x18\x03 \x01(\tR\freadingLevel\"y\n" +
+	"\x1cContinueConversationResponse\x12\x14\n" +
+	"\x05reply\x18\x01 \x01(\tR\x05reply\x12'\n" +
+	"\x0fbudget_exceeded\x18\x02 \x01(\bR\x0ebudgetExceeded\x12\x1a\n" +
+	"\bgreeting\x18\x03 \x01(\tR\bgreeting\"\x1a\n" +
+	"\x18ListConversationsRequest\"Z\n" +
+	"\x19ListConversationsResponse\x12=\n" +
+	"\rconversations\x18\x01 \x03(\v2\x17.acai.chat.ConversationR\rconversations\"F\n" +
+	"\x1bDescribeConversationRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\"\x8f\x01\n" +
 	"\x1cDescribeConversationResponse\x12;\n" +
-	"\fconversation\x18\x01 \x01(\v2\x17.acai.chat.ConversationR\fconversation2\x9f\x03\n" +
+	"\fconversation\x18\x01 \x01(\v2\x17.acai.chat.ConversationR\fconversation\x122\n" +
+	"\x05stats\x18\x02 \x01(\v2\x1c.acai.chat.ConversationStatsR\x05stats\"\xba\x02\n" +
+	"\x11ConversationStats\x12#\n" +
+	"\rmessage_count\x18\x01 \x01(\x05R\fmessageCount\x12!\n" +
+	"\ftotal_tokens\x18\x02 \x01(\x03R\vtotalTokens\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12D\n" +
+	"\x10last_activity_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x0elastActivityAt\x12\x18\n" +
+	"\apersona\x18\x05 \x01(\tR\apersona\x12\x1a\n" +
+	"\blanguage\x18\x06 \x01(\tR\blanguage\x12&\n" +
+	"\x0esummarizations\x18\a \x01(\x05R\x0esummarizations\"\x89\x02\n" +
+	"\n" +
+	"Attachment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x03 \x01(\tR\tmessageId\x12\x1a\n" +
+	"\bfilename\x18\x04 \x01(\tR\bfilename\x12!\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04size\x18\x06 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\a \x01(\tR\x06sha256\x128\n" +
+	"\ttimestamp\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\xba\x01\n" +
+	"\x17UploadAttachmentRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\x12\x1a\n" +
+	"\bfilename\x18\x03 \x01(\tR\bfilename\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\fR\acontent\"Q\n" +
+	"\x18UploadAttachmentResponse\x125\n" +
+	"\n" +
+	"attachment\x18\x01 \x01(\v2\x15.acai.chat.AttachmentR\n" +
+	"attachment\"d\n" +
+	"\x14GetAttachmentRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12#\n" +
+	"\rattachment_id\x18\x02 \x01(\tR\fattachmentId\"h\n" +
+	"\x15GetAttachmentResponse\x125\n" +
+	"\n" +
+	"attachment\x18\x01 \x01(\v2\x15.acai.chat.AttachmentR\n" +
+	"attachment\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"\xb9\x01\n" +
+	"\x12AddReactionRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\x12\x14\n" +
+	"\x05emoji\x18\x03 \x01(\tR\x05emoji\x12E\n" +
+	"\x10session_metadata\x18\x04 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"P\n" +
+	"\x13AddReactionResponse\x129\n" +
+	"\amessage\x18\x01 \x01(\v2\x1f.acai.chat.Conversation.MessageR\amessage\"a\n" +
+	"\x18RequestDataExportRequest\x12E\n" +
+	"\x10session_metadata\x18\x01 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"J\n" +
+	"\x19RequestDataExportResponse\x12-\n" +
+	"\x06export\x18\x01 \x01(\v2\x15.acai.chat.DataExportR\x06export\"z\n" +
+	"\x14GetDataExportRequest\x12\x1b\n" +
+	"\texport_id\x18\x01 \x01(\tR\bexportId\x12E\n" +
+	"\x10session_metadata\x18\x02 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"F\n" +
+	"\x15GetDataExportResponse\x12-\n" +
+	"\x06export\x18\x01 \x01(\v2\x15.acai.chat.DataExportR\x06export\"\xe3\x01\n" +
+	"\n" +
+	"DataExport\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12!\n" +
+	"\fdownload_url\x18\x03 \x01(\tR\vdownloadUrl\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\\\n" +
+	"\x13ResetSessionRequest\x12E\n" +
+	"\x10session_metadata\x18\x01 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"P\n" +
+	"\x14ResetSessionResponse\x128\n" +
+	"\x18archived_conversation_id\x18\x01 \x01(\tR\x16archivedConversationId\"_\n" +
+	"\x16GetUserSettingsRequest\x12E\n" +
+	"\x10session_metadata\x18\x01 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"N\n" +
+	"\x17GetUserSettingsResponse\x123\n" +
+	"\bsettings\x18\x01 \x01(\v2\x17.acai.chat.UserSettingsR\bsettings\"\x94\x01\n" +
+	"\x16SetUserSettingsRequest\x12E\n" +
+	"\x10session_metadata\x18\x01 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\x123\n" +
+	"\bsettings\x18\x02 \x01(\v2\x17.acai.chat.UserSettingsR\bsettings\"N\n" +
+	"\x17SetUserSettingsResponse\x123\n" +
+	"\bsettings\x18\x01 \x01(\v2\x17.acai.chat.UserSettingsR\bsettings\"\xb8\x01\n" +
+	"\"SetConversationInstructionsRequest\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\"\n" +
+	"\finstructions\x18\x02 \x01(\tR\finstructions\x12E\n" +
+	"\x10session_metadata\x18\x03 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"I\n" +
+	"#SetConversationInstructionsResponse\x12\"\n" +
+	"\finstructions\x18\x01 \x01(\tR\finstructions\"\x96\x01\n" +
+	"\x13ClaimSessionRequest\x128\n" +
+	"\tanonymous\x18\x01 \x01(\v2\x1a.acai.chat.SessionMetadataR\tanonymous\x12E\n" +
+	"\x10session_metadata\x18\x02 \x01(\v2\x1a.acai.chat.SessionMetadataR\x0fsessionMetadata\"\x9f\x01\n" +
+	"\x14ClaimSessionResponse\x12)\n" +
+	"\x10conversation_ids\x18\x01 \x03(\tR\x0fconversationIds\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\x123\n" +
+	"\bsettings\x18\x03 \x01(\v2\x17.acai.chat.UserSettingsR\bsettings\"\xb5\x01\n" +
+	"\fUserSettings\x12\x1a\n" +
+	"\blanguage\x18\x01 \x01(\tR\blanguage\x12\x14\n" +
+	"\x05units\x18\x02 \x01(\tR\x05units\x12\x1a\n" +
+	"\btimezone\x18\x03 \x01(\tR\btimezone\x12\x1c\n" +
+	"\tverbosity\x18\x04 \x01(\tR\tverbosity\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt2\xa6\n" +
+	"\n" +
 	"\vChatService\x12^\n" +
 	"\x11StartConversation\x12#.acai.chat.StartConversationRequest\x1a$.acai.chat.StartConversationResponse\x12g\n" +
 	"\x14ContinueConversation\x12&.acai.chat.ContinueConversationRequest\x1a'.acai.chat.ContinueConversationResponse\x12^\n" +
 	"\x11ListConversations\x12#.acai.chat.ListConversationsRequest\x1a$.acai.chat.ListConversationsResponse\x12g\n" +
-	"\x14DescribeConversation\x12&.acai.chat.DescribeConversationRequest\x1a'.acai.chat.DescribeConversationResponseB\rZ\vinternal/pbb\x06proto3"
+	"\x14DescribeConversation\x12&.acai.chat.DescribeConversationRequest\x1a'.acai.chat.DescribeConversationResponse\x12[\n" +
+	"\x10UploadAttachment\x12\".acai.chat.UploadAttachmentRequest\x1a#.acai.chat.UploadAttachmentResponse\x12R\n" +
+	"\rGetAttachment\x12\x1f.acai.chat.GetAttachmentRequest\x1a .acai.chat.GetAttachmentResponse\x12L\n" +
+	"\vAddReaction\x12\x1d.acai.chat.AddReactionRequest\x1a\x1e.acai.chat.AddReactionResponse\x12^\n" +
+	"\x11RequestDataExport\x12#.acai.chat.RequestDataExportRequest\x1a$.acai.chat.RequestDataExportResponse\x12R\n" +
+	"\rGetDataExport\x12\x1f.acai.chat.GetDataExportRequest\x1a .acai.chat.GetDataExportResponse\x12O\n" +
+	"\fResetSession\x12\x1e.acai.chat.ResetSessionRequest\x1a\x1f.acai.chat.ResetSessionResponse\x12X\n" +
+	"\x0fGetUserSettings\x12!.acai.chat.GetUserSettingsRequest\x1a\".acai.chat.GetUserSettingsResponse\x12X\n" +
+	"\x0fSetUserSettings\x12!.acai.chat.SetUserSettingsRequest\x1a\".acai.chat.SetUserSettingsResponse\x12|\n" +
+	"\x1bSetConversationInstructions\x12-.acai.chat.SetConversationInstructionsRequest\x1a..acai.chat.SetConversationInstructionsResponse\x12O\n" +
+	"\fClaimSession\x12\x1e.acai.chat.ClaimSessionRequest\x1a\x1f.acai.chat.ClaimSessionResponseB\rZ\vinternal/pbb\x06proto3"
 
 var (
 	file_rpc_chat_proto_rawDescOnce sync.Once
@@ -713,44 +2438,119 @@ func file_rpc_chat_proto_rawDescGZIP() []byte {
 }
 
 var file_rpc_chat_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_rpc_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_rpc_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_rpc_chat_proto_goTypes = []any{
-	(Conversation_Role)(0),               // 0: acai.chat.Conversation.Role
-	(*Conversation)(nil),                 // 1: acai.chat.Conversation
-	(*StartConversationRequest)(nil),     // 2: acai.chat.StartConversationRequest
-	(*StartConversationResponse)(nil),    // 3: acai.chat.StartConversationResponse
-	(*ContinueConversationRequest)(nil),  // 4: acai.chat.ContinueConversationRequest
-	(*SessionMetadata)(nil),              // 5: acai.chat.SessionMetadata
-	(*ContinueConversationResponse)(nil), // 6: acai.chat.ContinueConversationResponse
-	(*ListConversationsRequest)(nil),     // 7: acai.chat.ListConversationsRequest
-	(*ListConversationsResponse)(nil),    // 8: acai.chat.ListConversationsResponse
-	(*DescribeConversationRequest)(nil),  // 9: acai.chat.DescribeConversationRequest
-	(*DescribeConversationResponse)(nil), // 10: acai.chat.DescribeConversationResponse
-	(*Conversation_Message)(nil),         // 11: acai.chat.Conversation.Message
-	(*timestamppb.Timestamp)(nil),        // 12: google.protobuf.Timestamp
+	(Conversation_Role)(0),                      // 0: acai.chat.Conversation.Role
+	(*Conversation)(nil),                        // 1: acai.chat.Conversation
+	(*StartConversationRequest)(nil),            // 2: acai.chat.StartConversationRequest
+	(*StartConversationResponse)(nil),           // 3: acai.chat.StartConversationResponse
+	(*ContinueConversationRequest)(nil),         // 4: acai.chat.ContinueConversationRequest
+	(*SessionMetadata)(nil),                     // 5: acai.chat.SessionMetadata
+	(*ReplyStyle)(nil),                          // 6: acai.chat.ReplyStyle
+	(*ContinueConversationResponse)(nil),        // 7: acai.chat.ContinueConversationResponse
+	(*ListConversationsRequest)(nil),            // 8: acai.chat.ListConversationsRequest
+	(*ListConversationsResponse)(nil),           // 9: acai.chat.ListConversationsResponse
+	(*DescribeConversationRequest)(nil),         // 10: acai.chat.DescribeConversationRequest
+	(*DescribeConversationResponse)(nil),        // 11: acai.chat.DescribeConversationResponse
+	(*ConversationStats)(nil),                   // 12: acai.chat.ConversationStats
+	(*Attachment)(nil),                          // 13: acai.chat.Attachment
+	(*UploadAttachmentRequest)(nil),             // 14: acai.chat.UploadAttachmentRequest
+	(*UploadAttachmentResponse)(nil),            // 15: acai.chat.UploadAttachmentResponse
+	(*GetAttachmentRequest)(nil),                // 16: acai.chat.GetAttachmentRequest
+	(*GetAttachmentResponse)(nil),               // 17: acai.chat.GetAttachmentResponse
+	(*AddReactionRequest)(nil),                  // 18: acai.chat.AddReactionRequest
+	(*AddReactionResponse)(nil),                 // 19: acai.chat.AddReactionResponse
+	(*RequestDataExportRequest)(nil),            // 20: acai.chat.RequestDataExportRequest
+	(*RequestDataExportResponse)(nil),           // 21: acai.chat.RequestDataExportResponse
+	(*GetDataExportRequest)(nil),                // 22: acai.chat.GetDataExportRequest
+	(*GetDataExportResponse)(nil),               // 23: acai.chat.GetDataExportResponse
+	(*DataExport)(nil),                          // 24: acai.chat.DataExport
+	(*ResetSessionRequest)(nil),                 // 25: acai.chat.ResetSessionRequest
+	(*ResetSessionResponse)(nil),                // 26: acai.chat.ResetSessionResponse
+	(*GetUserSettingsRequest)(nil),              // 27: acai.chat.GetUserSettingsRequest
+	(*GetUserSettingsResponse)(nil),             // 28: acai.chat.GetUserSettingsResponse
+	(*SetUserSettingsRequest)(nil),              // 29: acai.chat.SetUserSettingsRequest
+	(*SetUserSettingsResponse)(nil),             // 30: acai.chat.SetUserSettingsResponse
+	(*SetConversationInstructionsRequest)(nil),  // 31: acai.chat.SetConversationInstructionsRequest
+	(*SetConversationInstructionsResponse)(nil), // 32: acai.chat.SetConversationInstructionsResponse
+	(*ClaimSessionRequest)(nil),                 // 33: acai.chat.ClaimSessionRequest
+	(*ClaimSessionResponse)(nil),                // 34: acai.chat.ClaimSessionResponse
+	(*UserSettings)(nil),                        // 35: acai.chat.UserSettings
+	(*Conversation_Message)(nil),                // 36: acai.chat.Conversation.Message
+	(*Conversation_Reaction)(nil),               // 37: acai.chat.Conversation.Reaction
+	(*timestamppb.Timestamp)(nil),               // 38: google.protobuf.Timestamp
 }
 var file_rpc_chat_proto_depIdxs = []int32{
-	12, // 0: acai.chat.Conversation.timestamp:type_name -> google.protobuf.Timestamp
-	11, // 1: acai.chat.Conversation.messages:type_name -> acai.chat.Conversation.Message
+	38, // 0: acai.chat.Conversation.timestamp:type_name -> google.protobuf.Timestamp
+	36, // 1: acai.chat.Conversation.messages:type_name -> acai.chat.Conversation.Message
 	5,  // 2: acai.chat.StartConversationRequest.session_metadata:type_name -> acai.chat.SessionMetadata
-	5,  // 3: acai.chat.ContinueConversationRequest.session_metadata:type_name -> acai.chat.SessionMetadata
-	1,  // 4: acai.chat.ListConversationsResponse.conversations:type_name -> acai.chat.Conversation
-	1,  // 5: acai.chat.DescribeConversationResponse.conversation:type_name -> acai.chat.Conversation
-	0,  // 6: acai.chat.Conversation.Message.role:type_name -> acai.chat.Conversation.Role
-	12, // 7: acai.chat.Conversation.Message.timestamp:type_name -> google.protobuf.Timestamp
-	2,  // 8: acai.chat.ChatService.StartConversation:input_type -> acai.chat.StartConversationRequest
-	4,  // 9: acai.chat.ChatService.ContinueConversation:input_type -> acai.chat.ContinueConversationRequest
-	7,  // 10: acai.chat.ChatService.ListConversations:input_type -> acai.chat.ListConversationsRequest
-	9,  // 11: acai.chat.ChatService.DescribeConversation:input_type -> acai.chat.DescribeConversationRequest
-	3,  // 12: acai.chat.ChatService.StartConversation:output_type -> acai.chat.StartConversationResponse
-	6,  // 13: acai.chat.ChatService.ContinueConversation:output_type -> acai.chat.ContinueConversationResponse
-	8,  // 14: acai.chat.ChatService.ListConversations:output_type -> acai.chat.ListConversationsResponse
-	10, // 15: acai.chat.ChatService.DescribeConversation:output_type -> acai.chat.DescribeConversationResponse
-	12, // [12:16] is the sub-list for method output_type
-	8,  // [8:12] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	6,  // 3: acai.chat.StartConversationRequest.style:type_name -> acai.chat.ReplyStyle
+	5,  // 4: acai.chat.ContinueConversationRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	6,  // 5: acai.chat.ContinueConversationRequest.style:type_name -> acai.chat.ReplyStyle
+	1,  // 6: acai.chat.ListConversationsResponse.conversations:type_name -> acai.chat.Conversation
+	1,  // 7: acai.chat.DescribeConversationResponse.conversation:type_name -> acai.chat.Conversation
+	12, // 8: acai.chat.DescribeConversationResponse.stats:type_name -> acai.chat.ConversationStats
+	38, // 9: acai.chat.ConversationStats.created_at:type_name -> google.protobuf.Timestamp
+	38, // 10: acai.chat.ConversationStats.last_activity_at:type_name -> google.protobuf.Timestamp
+	38, // 11: acai.chat.Attachment.timestamp:type_name -> google.protobuf.Timestamp
+	13, // 12: acai.chat.UploadAttachmentResponse.attachment:type_name -> acai.chat.Attachment
+	13, // 13: acai.chat.GetAttachmentResponse.attachment:type_name -> acai.chat.Attachment
+	5,  // 14: acai.chat.AddReactionRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	36, // 15: acai.chat.AddReactionResponse.message:type_name -> acai.chat.Conversation.Message
+	5,  // 16: acai.chat.RequestDataExportRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	24, // 17: acai.chat.RequestDataExportResponse.export:type_name -> acai.chat.DataExport
+	5,  // 18: acai.chat.GetDataExportRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	24, // 19: acai.chat.GetDataExportResponse.export:type_name -> acai.chat.DataExport
+	38, // 20: acai.chat.DataExport.created_at:type_name -> google.protobuf.Timestamp
+	38, // 21: acai.chat.DataExport.expires_at:type_name -> google.protobuf.Timestamp
+	5,  // 22: acai.chat.ResetSessionRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	5,  // 23: acai.chat.GetUserSettingsRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	35, // 24: acai.chat.GetUserSettingsResponse.settings:type_name -> acai.chat.UserSettings
+	5,  // 25: acai.chat.SetUserSettingsRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	35, // 26: acai.chat.SetUserSettingsRequest.settings:type_name -> acai.chat.UserSettings
+	35, // 27: acai.chat.SetUserSettingsResponse.settings:type_name -> acai.chat.UserSettings
+	5,  // 28: acai.chat.SetConversationInstructionsRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	5,  // 29: acai.chat.ClaimSessionRequest.anonymous:type_name -> acai.chat.SessionMetadata
+	5,  // 30: acai.chat.ClaimSessionRequest.session_metadata:type_name -> acai.chat.SessionMetadata
+	35, // 31: acai.chat.ClaimSessionResponse.settings:type_name -> acai.chat.UserSettings
+	38, // 32: acai.chat.UserSettings.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 33: acai.chat.Conversation.Message.role:type_name -> acai.chat.Conversation.Role
+	38, // 34: acai.chat.Conversation.Message.timestamp:type_name -> google.protobuf.Timestamp
+	37, // 35: acai.chat.Conversation.Message.reactions:type_name -> acai.chat.Conversation.Reaction
+	38, // 36: acai.chat.Conversation.Reaction.timestamp:type_name -> google.protobuf.Timestamp
+	2,  // 37: acai.chat.ChatService.StartConversation:input_type -> acai.chat.StartConversationRequest
+	4,  // 38: acai.chat.ChatService.ContinueConversation:input_type -> acai.chat.ContinueConversationRequest
+	8,  // 39: acai.chat.ChatService.ListConversations:input_type -> acai.chat.ListConversationsRequest
+	10, // 40: acai.chat.ChatService.DescribeConversation:input_type -> acai.chat.DescribeConversationRequest
+	14, // 41: acai.chat.ChatService.UploadAttachment:input_type -> acai.chat.UploadAttachmentRequest
+	16, // 42: acai.chat.ChatService.GetAttachment:input_type -> acai.chat.GetAttachmentRequest
+	18, // 43: acai.chat.ChatService.AddReaction:input_type -> acai.chat.AddReactionRequest
+	20, // 44: acai.chat.ChatService.RequestDataExport:input_type -> acai.chat.RequestDataExportRequest
+	22, // 45: acai.chat.ChatService.GetDataExport:input_type -> acai.chat.GetDataExportRequest
+	25, // 46: acai.chat.ChatService.ResetSession:input_type -> acai.chat.ResetSessionRequest
+	27, // 47: acai.chat.ChatService.GetUserSettings:input_type -> acai.chat.GetUserSettingsRequest
+	29, // 48: acai.chat.ChatService.SetUserSettings:input_type -> acai.chat.SetUserSettingsRequest
+	31, // 49: acai.chat.ChatService.SetConversationInstructions:input_type -> acai.chat.SetConversationInstructionsRequest
+	33, // 50: acai.chat.ChatService.ClaimSession:input_type -> acai.chat.ClaimSessionRequest
+	3,  // 51: acai.chat.ChatService.StartConversation:output_type -> acai.chat.StartConversationResponse
+	7,  // 52: acai.chat.ChatService.ContinueConversation:output_type -> acai.chat.ContinueConversationResponse
+	9,  // 53: acai.chat.ChatService.ListConversations:output_type -> acai.chat.ListConversationsResponse
+	11, // 54: acai.chat.ChatService.DescribeConversation:output_type -> acai.chat.DescribeConversationResponse
+	15, // 55: acai.chat.ChatService.UploadAttachment:output_type -> acai.chat.UploadAttachmentResponse
+	17, // 56: acai.chat.ChatService.GetAttachment:output_type -> acai.chat.GetAttachmentResponse
+	19, // 57: acai.chat.ChatService.AddReaction:output_type -> acai.chat.AddReactionResponse
+	21, // 58: acai.chat.ChatService.RequestDataExport:output_type -> acai.chat.RequestDataExportResponse
+	23, // 59: acai.chat.ChatService.GetDataExport:output_type -> acai.chat.GetDataExportResponse
+	26, // 60: acai.chat.ChatService.ResetSession:output_type -> acai.chat.ResetSessionResponse
+	28, // 61: acai.chat.ChatService.GetUserSettings:output_type -> acai.chat.GetUserSettingsResponse
+	30, // 62: acai.chat.ChatService.SetUserSettings:output_type -> acai.chat.SetUserSettingsResponse
+	32, // 63: acai.chat.ChatService.SetConversationInstructions:output_type -> acai.chat.SetConversationInstructionsResponse
+	34, // 64: acai.chat.ChatService.ClaimSession:output_type -> acai.chat.ClaimSessionResponse
+	51, // [51:65] is the sub-list for method output_type
+	37, // [37:51] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
 }
 
 func init() { file_rpc_chat_proto_init() }
@@ -758,13 +2558,14 @@ func file_rpc_chat_proto_init() {
 	if File_rpc_chat_proto != nil {
 		return
 	}
+	file_rpc_chat_proto_msgTypes[0].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rpc_chat_proto_rawDesc), len(file_rpc_chat_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   11,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,31 @@
+package postprocess
+
+import "context"
+
+// ContentPolicy enforces the blocked terms of the context's tenant on replies, see blocklist.Service
+type ContentPolicy interface {
+	// FilterReply masks profanity and replaces replies touching a forbidden topic
+	FilterReply(ctx context.Context, reply string) string
+}
+
+// BlocklistFilter applies the tenant's blocklist to replies, as the model may bring up
+// topics the user did not mention
+type BlocklistFilter struct {
+	policy ContentPolicy
+}
+
+// NewBlocklistFilter creates a filter enforcing the policy
+func NewBlocklistFilter(policy ContentPolicy) *BlocklistFilter {
+	return &BlocklistFilter{policy: policy}
+}
+
+func (f *BlocklistFilter) Name() string {
+	return "blocklist"
+}
+
+func (f *BlocklistFilter) Apply(ctx context.Context, reply string) string {
+	if f.policy == nil {
+		return reply
+	}
+	return f.policy.FilterReply(ctx, reply)
+}
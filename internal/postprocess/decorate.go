@@ -0,0 +1,173 @@
+package postprocess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// Decoration is the output policy of a platform or persona
+type Decoration struct {
+	// Disclaimer is a text/template appended to every reply, e.g. "\n\n_AI-generated on {{.Platform}}_"
+	Disclaimer string `json:"disclaimer"`
+	// Strip lists regular expressions whose matches are removed from replies, e.g. claims the deployment must not make
+	Strip []string `json:"strip"`
+}
+
+// DisclaimerData is available to disclaimer templates
+type DisclaimerData struct {
+	Platform string
+	Persona  string
+	Date     string // Current date, YYYY-MM-DD
+}
+
+// ParseDecorations parses a JSON object of decorations keyed by "<platform>", "<platform>/<persona>",
+// "default" or "default/<persona>"
+func ParseDecorations(data string) (map[string]Decoration, error) {
+	decorations := make(map[string]Decoration)
+	if strings.TrimSpace(data) == "" {
+		return decorations, nil
+	}
+	if err := json.Unmarshal([]byte(data), &decorations); err != nil {
+		return nil, fmt.Errorf("invalid reply decorations: %w", err)
+	}
+	return decorations, nil
+}
+
+type personaKey struct{}
+
+// WithPersona returns a context selecting the persona's decorations for the reply
+func WithPersona(ctx context.Context, persona string) context.Context {
+	return context.WithValue(ctx, personaKey{}, persona)
+}
+
+func personaFromContext(ctx context.Context) string {
+	persona, _ := ctx.Value(personaKey{}).(string)
+	return persona
+}
+
+type compiledDecoration struct {
+	disclaimer *template.Template
+	strip      []*regexp.Regexp
+}
+
+// Decorator strips configured claims from replies and appends a disclaimer
+// The most specific decoration applies: platform and persona, platform, default and persona, then default
+type Decorator struct {
+	platform    string
+	decorations map[string]compiledDecoration
+	maxRunes    int
+}
+
+// NewDecorator creates a decorator for a platform's replies
+// When maxRunes is set, replies are shortened so the decorated reply still fits the platform's message limit
+func NewDecorator(platform string, decorations map[string]Decoration, maxRunes int) (*Decorator, error) {
+	d := &Decorator{
+		platform:    platform,
+		decorations: make(map[string]compiledDecoration, len(decorations)),
+		maxRunes:    maxRunes,
+	}
+	for key, decoration := range decorations {
+		var compiled compiledDecoration
+		if decoration.Disclaimer != "" {
+			tmpl, err := template.New(key).Option("missingkey=error").Parse(decoration.Disclaimer)
+			if err != nil {
+				return nil, fmt.Errorf("invalid disclaimer for %s: %w", key, err)
+			}
+			compiled.disclaimer = tmpl
+		}
+		for _, expr := range decoration.Strip {
+			pattern, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid strip pattern for %s: %w", key, err)
+			}
+			compiled.strip = append(compiled.strip, pattern)
+		}
+		d.decorations[key] = compiled
+	}
+	return d, nil
+}
+
+func (d *Decorator) Name() string {
+	return "decorate"
+}
+
+// Apply strips claims and appends the disclaimer; a reply that already ends with the disclaimer,
+// e.g. one assembled from streamed chunks that were decorated on the way, is not decorated twice
+func (d *Decorator) Apply(ctx context.Context, reply string) string {
+	decoration, ok := d.lookup(personaFromContext(ctx))
+	if !ok {
+		return reply
+	}
+
+	for _, pattern := range decoration.strip {
+		reply = pattern.ReplaceAllString(reply, "")
+	}
+
+	disclaimer := d.render(ctx, decoration)
+	if disclaimer == "" || strings.HasSuffix(reply, disclaimer) {
+		return reply
+	}
+
+	if d.maxRunes > 0 {
+		room := d.maxRunes - utf8.RuneCountInString(disclaimer)
+		if room <= 0 {
+			return reply
+		}
+		reply = NewLengthLimiter(room).Apply(ctx, reply)
+	}
+	return reply + disclaimer
+}
+
+// Suffix returns the disclaimer for the context's reply, for transports that stream replies
+// and send it after the last chunk
+func (d *Decorator) Suffix(ctx context.Context) string {
+	decoration, ok := d.lookup(personaFromContext(ctx))
+	if !ok {
+		return ""
+	}
+	return d.render(ctx, decoration)
+}
+
+func (d *Decorator) lookup(persona string) (compiledDecoration, bool) {
+	var keys []string
+	if persona != "" {
+		keys = append(keys, d.platform+"/"+persona)
+	}
+	keys = append(keys, d.platform)
+	if persona != "" {
+		keys = append(keys, DefaultPlatform+"/"+persona)
+	}
+	keys = append(keys, DefaultPlatform)
+
+	for _, key := range keys {
+		if decoration, ok := d.decorations[key]; ok {
+			return decoration, true
+		}
+	}
+	return compiledDecoration{}, false
+}
+
+func (d *Decorator) render(ctx context.Context, decoration compiledDecoration) string {
+	if decoration.disclaimer == nil {
+		return ""
+	}
+
+	var out strings.Builder
+	err := decoration.disclaimer.Execute(&out, DisclaimerData{
+		Platform: d.platform,
+		Persona:  personaFromContext(ctx),
+		Date:     time.Now().Format(time.DateOnly),
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to render reply disclaimer", "platform", d.platform, "error", err)
+		return ""
+	}
+	return out.String()
+}
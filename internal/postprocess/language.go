@@ -0,0 +1,63 @@
+package postprocess
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/language"
+)
+
+// Translator translates a reply into the language with the code, see assistant.ReplyTranslator
+type Translator interface {
+	Translate(ctx context.Context, text, code string) (string, error)
+}
+
+type languageKey struct{}
+
+// WithLanguage returns a context with the language code the reply must be written in
+func WithLanguage(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, languageKey{}, code)
+}
+
+func languageFromContext(ctx context.Context) string {
+	code, _ := ctx.Value(languageKey{}).(string)
+	return code
+}
+
+// LanguageEnforcer translates replies written in another language than the conversation's
+// Replies whose language cannot be detected, e.g. short or mostly code, are left as they are
+type LanguageEnforcer struct {
+	translator Translator
+}
+
+// NewLanguageEnforcer creates a filter translating replies with the translator
+func NewLanguageEnforcer(translator Translator) *LanguageEnforcer {
+	return &LanguageEnforcer{translator: translator}
+}
+
+func (e *LanguageEnforcer) Name() string {
+	return "language"
+}
+
+// Apply translates the reply when it is detected to be in another language than the context's;
+// a failed translation keeps the reply, as an answer in the wrong language beats no answer
+func (e *LanguageEnforcer) Apply(ctx context.Context, reply string) string {
+	expected := languageFromContext(ctx)
+	if expected == "" || e.translator == nil {
+		return reply
+	}
+	detected := language.Detect(reply)
+	if detected == "" || language.Same(detected, expected) {
+		return reply
+	}
+
+	translated, err := e.translator.Translate(ctx, reply, expected)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to translate reply into the conversation language",
+			"expected", expected, "detected", detected, "error", err)
+		return reply
+	}
+	slog.InfoContext(ctx, "Translated reply into the conversation language",
+		"expected", expected, "detected", detected)
+	return translated
+}
@@ -0,0 +1,44 @@
+package postprocess
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+const ellipsis = "…"
+
+// LengthLimiter truncates replies to a platform's message size limit
+type LengthLimiter struct {
+	maxRunes int
+}
+
+// NewLengthLimiter creates a limiter for replies of at most maxRunes characters
+func NewLengthLimiter(maxRunes int) *LengthLimiter {
+	return &LengthLimiter{maxRunes: maxRunes}
+}
+
+func (l *LengthLimiter) Name() string {
+	return "length"
+}
+
+// Apply cuts at the last paragraph, sentence or word break that fits, in that order of preference
+func (l *LengthLimiter) Apply(ctx context.Context, reply string) string {
+	if l.maxRunes <= 0 || utf8.RuneCountInString(reply) <= l.maxRunes {
+		return reply
+	}
+
+	runes := []rune(reply)
+	cut := string(runes[:l.maxRunes-utf8.RuneCountInString(ellipsis)])
+
+	// Only back off to a break in the last fifth, so little text is lost
+	minKeep := len(cut) * 4 / 5
+	for _, sep := range []string{"\n\n", ". ", "\n", " "} {
+		if i := strings.LastIndex(cut, sep); i >= minKeep {
+			cut = cut[:i+len(strings.TrimRight(sep, " \n"))]
+			break
+		}
+	}
+
+	return strings.TrimRight(cut, " \n") + ellipsis
+}
@@ -0,0 +1,72 @@
+package postprocess
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var bareURL = regexp.MustCompile(`https?://[^\s<>()\[\]"']+`)
+
+// LinkRewriter adds query parameters, e.g. UTM tags, to links in replies
+type LinkRewriter struct {
+	params url.Values
+	hosts  []string
+}
+
+// NewLinkRewriter creates a rewriter adding params to links
+// Only links to hosts (and their subdomains) are rewritten; an empty list rewrites every link
+func NewLinkRewriter(params map[string]string, hosts []string) *LinkRewriter {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return &LinkRewriter{params: values, hosts: hosts}
+}
+
+func (r *LinkRewriter) Name() string {
+	return "links"
+}
+
+func (r *LinkRewriter) Apply(ctx context.Context, reply string) string {
+	if len(r.params) == 0 {
+		return reply
+	}
+	return bareURL.ReplaceAllStringFunc(reply, r.rewrite)
+}
+
+func (r *LinkRewriter) rewrite(raw string) string {
+	// Keep sentence punctuation out of the link
+	trimmed := strings.TrimRight(raw, ".,;:!?")
+	suffix := raw[len(trimmed):]
+
+	u, err := url.Parse(trimmed)
+	if err != nil || !r.matches(u.Hostname()) {
+		return raw
+	}
+
+	query := u.Query()
+	for k, v := range r.params {
+		if !query.Has(k) {
+			query[k] = v
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String() + suffix
+}
+
+func (r *LinkRewriter) matches(host string) bool {
+	if len(r.hosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, h := range r.hosts {
+		h = strings.ToLower(h)
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package postprocess
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTag = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	// Link targets may contain one level of balanced parentheses and an optional quoted title
+	markdownLink = regexp.MustCompile(`(!?)\[([^\]]*)\]\(((?:[^()\s]|\([^()\s]*\))*)(?:\s+"[^"]*")?\)`)
+	safeSchemes  = []string{"http://", "https://", "mailto:", "tg://"}
+)
+
+// MarkdownSanitizer makes model output safe to render as markdown in chat clients
+// It drops raw HTML, keeps only links with safe schemes, turns images into links and closes open code fences
+type MarkdownSanitizer struct{}
+
+func (MarkdownSanitizer) Name() string {
+	return "markdown"
+}
+
+func (MarkdownSanitizer) Apply(ctx context.Context, reply string) string {
+	reply = htmlTag.ReplaceAllString(reply, "")
+
+	reply = markdownLink.ReplaceAllStringFunc(reply, func(link string) string {
+		m := markdownLink.FindStringSubmatch(link)
+		text, target := m[2], m[3]
+		if !safeLink(target) {
+			return text
+		}
+		if m[1] == "!" {
+			// Chat clients do not render inline images
+			if text == "" {
+				text = target
+			}
+			return "[" + text + "](" + target + ")"
+		}
+		return link
+	})
+
+	if strings.Count(reply, "```")%2 == 1 {
+		reply = strings.TrimRight(reply, "\n") + "\n```"
+	}
+
+	return reply
+}
+
+func safeLink(target string) bool {
+	lower := strings.ToLower(target)
+	for _, scheme := range safeSchemes {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// DefaultPlatform holds the filter chain for platforms without their own
+const DefaultPlatform = "default"
+
+// Filter transforms an assistant reply before it is stored and returned
+type Filter interface {
+	Name() string
+	Apply(ctx context.Context, reply string) string
+}
+
+// Pipeline applies an ordered list of filters per platform
+type Pipeline struct {
+	chains map[string][]Filter
+}
+
+// NewPipeline creates a pipeline from platform -> ordered filters
+// Platforms missing from chains use the DefaultPlatform chain, if any
+func NewPipeline(chains map[string][]Filter) *Pipeline {
+	return &Pipeline{chains: chains}
+}
+
+// Process runs the reply through the platform's filters in order
+func (p *Pipeline) Process(ctx context.Context, platform, reply string) string {
+	chain, ok := p.chains[platform]
+	if !ok {
+		chain = p.chains[DefaultPlatform]
+	}
+
+	for _, filter := range chain {
+		filtered := filter.Apply(ctx, reply)
+		if filtered != reply {
+			slog.DebugContext(ctx, "Reply changed by filter",
+				"filter", filter.Name(),
+				"platform", platform,
+				"length_before", len(reply),
+				"length_after", len(filtered))
+		}
+		reply = filtered
+	}
+
+	return reply
+}
+
+// Registry builds filters by name, so chains can be configured as lists of names
+// Constructors receive the platform so filters can use per-platform settings, e.g. message size limits
+type Registry map[string]func(platform string) Filter
+
+// Build resolves platform -> filter names into a pipeline
+func (r Registry) Build(chains map[string][]string) (*Pipeline, error) {
+	resolved := make(map[string][]Filter, len(chains))
+	for platform, names := range chains {
+		for _, name := range names {
+			build, ok := r[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown reply filter %q for platform %s", name, platform)
+			}
+			resolved[platform] = append(resolved[platform], build(platform))
+		}
+	}
+	return NewPipeline(resolved), nil
+}
@@ -0,0 +1,43 @@
+package postprocess
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// ProfanityFilter masks listed words, keeping their first letter
+type ProfanityFilter struct {
+	pattern *regexp.Regexp
+}
+
+// NewProfanityFilter creates a filter for the given words; matching is case-insensitive on whole words
+func NewProfanityFilter(words []string) *ProfanityFilter {
+	var quoted []string
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return &ProfanityFilter{}
+	}
+
+	return &ProfanityFilter{
+		pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`),
+	}
+}
+
+func (f *ProfanityFilter) Name() string {
+	return "profanity"
+}
+
+func (f *ProfanityFilter) Apply(ctx context.Context, reply string) string {
+	if f.pattern == nil {
+		return reply
+	}
+	return f.pattern.ReplaceAllStringFunc(reply, func(word string) string {
+		runes := []rune(word)
+		return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	})
+}
@@ -0,0 +1,104 @@
+package prewarm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// IdleTimeout is how long NewTransport keeps unused connections open; warmers must refresh them sooner
+const IdleTimeout = 90 * time.Second
+
+// NewTransport returns a transport keeping up to maxIdle connections per host open between requests,
+// for an API that most requests of the process go to
+func NewTransport(maxIdle int) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = max(transport.MaxIdleConns, maxIdle)
+	transport.MaxIdleConnsPerHost = maxIdle
+	transport.IdleConnTimeout = IdleTimeout
+	return transport
+}
+
+// ConnRecorder records whether requests got a pooled connection, see metrics.Metrics
+type ConnRecorder interface {
+	RecordUpstreamConnection(ctx context.Context, upstream string, reused bool)
+}
+
+// Track wraps a transport to record, for every request, whether it reused an open connection or paid
+// for a new one
+func Track(next http.RoundTripper, upstream string, recorder ConnRecorder) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				recorder.RecordUpstreamConnection(req.Context(), upstream, info.Reused)
+			},
+		}
+		return next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Warmer opens a connection to an API ahead of the first request and keeps it from idling out,
+// so requests do not wait for DNS, TCP and TLS handshakes
+// It sends unauthenticated HEAD requests: whatever the API answers, the connection is left in the pool
+type Warmer struct {
+	client   *http.Client
+	url      string
+	interval time.Duration
+}
+
+// NewWarmer creates a warmer for the API at url, sending its requests through the transport the
+// API client uses; interval must be shorter than the idle timeouts of the transport and of the API
+func NewWarmer(transport http.RoundTripper, url string, interval time.Duration) *Warmer {
+	return &Warmer{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   10 * time.Second,
+			// Redirects would warm connections to other hosts
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		url:      url,
+		interval: interval,
+	}
+}
+
+// Warm opens a connection, or reuses and refreshes an open one
+func (w *Warmer) Warm(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, w.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create warm-up request: %w", err)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", req.URL.Host, err)
+	}
+	// The connection only returns to the pool once the body is read and closed
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
+
+// Run warms the connection right away, then keeps it warm until the context is cancelled
+func (w *Warmer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Warm(ctx); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Failed to warm upstream connection", "url", w.url, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
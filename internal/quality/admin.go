@@ -0,0 +1,75 @@
+package quality
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Longest range served, two years of days
+const maxDays = 731
+
+// AdminHandler exposes the daily quality scores over HTTP for dashboards
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	service *Service
+	now     func() time.Time
+}
+
+// NewAdminHandler creates a new quality scores admin handler
+func NewAdminHandler(service *Service) *AdminHandler {
+	return &AdminHandler{service: service, now: time.Now}
+}
+
+// SeriesHandler handles GET /admin/analytics/quality?from=2024-05-01T00:00:00Z&to=2024-06-01T00:00:00Z
+// Without from and to it returns the last 30 days, one point per day, persona and prompt version
+// Days are scored by the quality_scores scheduled task, so the current day is never included
+func (h *AdminHandler) SeriesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	to := h.now()
+	if v := query.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to must be an RFC 3339 timestamp"})
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -30)
+	if v := query.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from must be an RFC 3339 timestamp"})
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from must be before to"})
+		return
+	}
+	if to.Sub(from) > maxDays*24*time.Hour {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "range too long", "max_days": maxDays})
+		return
+	}
+
+	points, err := h.service.Series(r.Context(), from, to)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load quality scores", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load quality scores"})
+		return
+	}
+	if points == nil {
+		points = []*Aggregate{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"points": points})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,252 @@
+package quality
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// Bounds of rubric scores
+const (
+	MinScore = 1
+	MaxScore = 5
+)
+
+// Transcripts sent for scoring keep the latest messages, each cut to a length, so long conversations stay cheap
+const (
+	maxTranscriptMessages = 20
+	maxMessageChars       = 1000
+)
+
+// Scores rate a conversation on the rubric, each from MinScore to MaxScore
+type Scores struct {
+	Helpfulness float64 `json:"helpfulness"`
+	Correctness float64 `json:"correctness"`
+	Tone        float64 `json:"tone"`
+}
+
+// Overall is the mean of the rubric scores
+func (s Scores) Overall() float64 {
+	return (s.Helpfulness + s.Correctness + s.Tone) / 3
+}
+
+// Validate checks that every score is within the rubric's bounds
+func (s Scores) Validate() error {
+	for name, score := range map[string]float64{
+		"helpfulness": s.Helpfulness,
+		"correctness": s.Correctness,
+		"tone":        s.Tone,
+	} {
+		if score < MinScore || score > MaxScore {
+			return fmt.Errorf("%s score %v is outside %d-%d", name, score, MinScore, MaxScore)
+		}
+	}
+	return nil
+}
+
+// Aggregate is the mean quality of a day's sampled conversations of a tenant that replied with a persona
+// and system prompt version
+type Aggregate struct {
+	TenantID      string    `bson:"tenant_id" json:"-"`
+	Day           time.Time `bson:"day" json:"day"`
+	Persona       string    `bson:"persona" json:"persona,omitempty"`               // Empty for the user's segment
+	PromptVersion string    `bson:"prompt_version" json:"prompt_version,omitempty"` // Empty for the built-in fallback prompt
+	Samples       int       `bson:"samples" json:"samples"`
+	Helpfulness   float64   `bson:"helpfulness" json:"helpfulness"`
+	Correctness   float64   `bson:"correctness" json:"correctness"`
+	Tone          float64   `bson:"tone" json:"tone"`
+	Overall       float64   `bson:"overall" json:"overall"`
+}
+
+// Sampler picks conversations to score, see model.Repository
+type Sampler interface {
+	// ActiveTenants returns the tenants with conversations active in [from, to)
+	ActiveTenants(ctx context.Context, from, to time.Time) ([]string, error)
+	// SampleActiveConversations returns up to size random conversations of the context's tenant active in [from, to)
+	SampleActiveConversations(ctx context.Context, from, to time.Time, size int) ([]*model.Conversation, error)
+}
+
+// Scorer rates a conversation transcript on the rubric, see assistant.QualityScorer
+type Scorer interface {
+	Score(ctx context.Context, transcript string) (Scores, error)
+}
+
+// Store keeps the daily aggregates, see MongoRepository
+type Store interface {
+	// SaveAggregates replaces the aggregates of a tenant's day
+	SaveAggregates(ctx context.Context, tenantID string, day time.Time, aggregates []*Aggregate) error
+	// Aggregates returns the aggregates of a tenant for the days in [from, to), oldest first
+	Aggregates(ctx context.Context, tenantID string, from, to time.Time) ([]*Aggregate, error)
+}
+
+// Result counts the conversations of a scoring run
+type Result struct {
+	Tenants int `json:"tenants"`
+	Sampled int `json:"sampled"`
+	Scored  int `json:"scored"`
+	Failed  int `json:"failed"`
+}
+
+// Service scores a daily sample of conversations and serves the aggregates as time series
+type Service struct {
+	sampler    Sampler
+	scorer     Scorer
+	store      Store
+	sampleSize int
+}
+
+// NewService creates a quality scoring service sampling up to sampleSize conversations per tenant and day
+func NewService(sampler Sampler, scorer Scorer, store Store, sampleSize int) *Service {
+	return &Service{
+		sampler:    sampler,
+		scorer:     scorer,
+		store:      store,
+		sampleSize: sampleSize,
+	}
+}
+
+// Run scores a sample of the conversations active on the UTC day before now and stores the aggregates
+// Conversations that cannot be scored are counted as failed and left out of the aggregates;
+// running again for the same day replaces its aggregates
+func (s *Service) Run(ctx context.Context, now time.Time) (*Result, error) {
+	to := Day(now)
+	from := to.AddDate(0, 0, -1)
+
+	tenants, err := s.sampler.ActiveTenants(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Tenants: len(tenants)}
+	var errs []error
+	for _, tenantID := range tenants {
+		if err := s.scoreTenant(tenant.WithTenant(ctx, tenantID), tenantID, from, to, result); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+		}
+	}
+
+	slog.InfoContext(ctx, "Conversation quality scored",
+		"day", from.Format(time.DateOnly),
+		"tenants", result.Tenants,
+		"sampled", result.Sampled,
+		"scored", result.Scored,
+		"failed", result.Failed)
+	return result, errors.Join(errs...)
+}
+
+func (s *Service) scoreTenant(ctx context.Context, tenantID string, from, to time.Time, result *Result) error {
+	conversations, err := s.sampler.SampleActiveConversations(ctx, from, to, s.sampleSize)
+	if err != nil {
+		return err
+	}
+	result.Sampled += len(conversations)
+
+	groups := make(map[model.Variant]*Aggregate)
+	for _, conv := range conversations {
+		transcript := Transcript(conv)
+		if transcript == "" {
+			continue
+		}
+		scores, err := s.scorer.Score(ctx, transcript)
+		if err == nil {
+			err = scores.Validate()
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			result.Failed++
+			slog.WarnContext(ctx, "Failed to score conversation quality",
+				"conversation_id", conv.ID.Hex(), "error", err)
+			continue
+		}
+		result.Scored++
+
+		variant := replyVariant(conv)
+		agg, ok := groups[variant]
+		if !ok {
+			agg = &Aggregate{TenantID: tenantID, Day: from, Persona: variant.Persona, PromptVersion: variant.PromptVersion}
+			groups[variant] = agg
+		}
+		agg.Samples++
+		agg.Helpfulness += scores.Helpfulness
+		agg.Correctness += scores.Correctness
+		agg.Tone += scores.Tone
+	}
+
+	aggregates := make([]*Aggregate, 0, len(groups))
+	for _, agg := range groups {
+		n := float64(agg.Samples)
+		agg.Helpfulness /= n
+		agg.Correctness /= n
+		agg.Tone /= n
+		agg.Overall = Scores{Helpfulness: agg.Helpfulness, Correctness: agg.Correctness, Tone: agg.Tone}.Overall()
+		aggregates = append(aggregates, agg)
+	}
+	return s.store.SaveAggregates(ctx, tenantID, from, aggregates)
+}
+
+// Series returns the daily aggregates of the context's tenant for the days in [from, to)
+func (s *Service) Series(ctx context.Context, from, to time.Time) ([]*Aggregate, error) {
+	return s.store.Aggregates(ctx, tenant.FromContext(ctx), Day(from), to)
+}
+
+// replyVariant is the persona and prompt version of the conversation's latest reply; the model is
+// left out so replies degraded to the fallback model are judged with their variant
+func replyVariant(conv *model.Conversation) model.Variant {
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		if msg := conv.Messages[i]; msg.Role == model.RoleAssistant && msg.Generation != nil {
+			return model.Variant{Persona: msg.Generation.Persona, PromptVersion: msg.Generation.PromptVersion}
+		}
+	}
+	return model.Variant{Persona: conv.Persona, PromptVersion: conv.PromptVersion}
+}
+
+// Transcript renders the latest user and assistant messages of a conversation for scoring;
+// empty when the assistant never replied
+func Transcript(conv *model.Conversation) string {
+	var lines []string
+	replied := false
+	for i := len(conv.Messages) - 1; i >= 0 && len(lines) < maxTranscriptMessages; i-- {
+		msg := conv.Messages[i]
+		var speaker string
+		switch msg.Role {
+		case model.RoleUser:
+			speaker = "User"
+		case model.RoleAssistant:
+			speaker = "Assistant"
+			replied = true
+		default:
+			continue
+		}
+		content := strings.TrimSpace(msg.Content)
+		if utf8.RuneCountInString(content) > maxMessageChars {
+			content = string([]rune(content)[:maxMessageChars]) + "…"
+		}
+		lines = append(lines, speaker+": "+content)
+	}
+	if !replied {
+		return ""
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// Day returns the start of the UTC day holding t
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,61 @@
+package quality
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const scoreCollection = "quality_scores"
+
+// MongoRepository stores daily quality aggregates in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB quality repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+// SaveAggregates replaces the aggregates of a tenant's day
+func (r *MongoRepository) SaveAggregates(ctx context.Context, tenantID string, day time.Time, aggregates []*Aggregate) error {
+	coll := r.conn.Collection(scoreCollection)
+	if _, err := coll.DeleteMany(ctx, bson.M{"tenant_id": tenantID, "day": day}); err != nil {
+		return err
+	}
+	if len(aggregates) == 0 {
+		return nil
+	}
+	docs := make([]any, len(aggregates))
+	for i, agg := range aggregates {
+		docs[i] = agg
+	}
+	_, err := coll.InsertMany(ctx, docs)
+	return err
+}
+
+// Aggregates returns the aggregates of a tenant for the days in [from, to), oldest first
+func (r *MongoRepository) Aggregates(ctx context.Context, tenantID string, from, to time.Time) ([]*Aggregate, error) {
+	cursor, err := r.conn.Collection(scoreCollection).Find(ctx,
+		bson.M{
+			"tenant_id": tenantID,
+			"day":       bson.M{"$gte": from, "$lt": to},
+		},
+		options.Find().SetSort(bson.D{{Key: "day", Value: 1}, {Key: "persona", Value: 1}, {Key: "prompt_version", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	aggregates := []*Aggregate{}
+	if err := cursor.All(ctx, &aggregates); err != nil {
+		return nil, err
+	}
+	return aggregates, nil
+}
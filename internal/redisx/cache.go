@@ -4,12 +4,13 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -19,14 +20,39 @@ var (
 
 type Cache struct {
 	client *redis.Client
+	router *Router
 	ttl    time.Duration
+	codec  Codec
 }
 
-func NewCache(client *redis.Client, ttl time.Duration) *Cache {
-	return &Cache{
+// CacheOption configures optional cache behaviour
+type CacheOption func(*Cache)
+
+// WithCodec encodes new entries with the codec instead of JSON
+// Entries already stored with another codec remain readable
+func WithCodec(codec Codec) CacheOption {
+	return func(c *Cache) {
+		c.codec = codec
+	}
+}
+
+// WithRouter stores the keys of tenants with a storage profile in the Redis of that profile
+func WithRouter(router *Router) CacheOption {
+	return func(c *Cache) {
+		c.router = router
+	}
+}
+
+func NewCache(client *redis.Client, ttl time.Duration, opts ...CacheOption) *Cache {
+	c := &Cache{
 		client: client,
 		ttl:    ttl,
+		codec:  JSONCodec{},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // MustConnect creates a Redis connection or panics on error
@@ -49,40 +75,201 @@ func MustConnect(addr string) *redis.Client {
 	return client
 }
 
+// Availability tracks the health of Redis, see degradation.Health
+type Availability interface {
+	Available() bool
+	Report(err error)
+}
+
+var (
+	availabilityMu sync.RWMutex
+	availability   Availability
+)
+
+// SetAvailability makes every Cache skip Redis while it is unavailable and report the outcome of its calls
+// Meanwhile reads are misses and writes are dropped, so callers fall back to their source of truth
+func SetAvailability(a Availability) {
+	availabilityMu.Lock()
+	defer availabilityMu.Unlock()
+	availability = a
+}
+
+// redisAvailable reports whether Redis calls should be made
+func redisAvailable() bool {
+	availabilityMu.RLock()
+	a := availability
+	availabilityMu.RUnlock()
+	return a == nil || a.Available()
+}
+
+// reportRedis reports the outcome of a Redis call; a missing key is a success, a cancelled call neither
+func reportRedis(err error) {
+	availabilityMu.RLock()
+	a := availability
+	availabilityMu.RUnlock()
+	if a == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if errors.Is(err, redis.Nil) {
+		err = nil
+	}
+	a.Report(err)
+}
+
+// clientFor returns the client holding the keys of the context's tenant
+func (c *Cache) clientFor(ctx context.Context) *redis.Client {
+	if client, ok := c.router.Client(ctx); ok {
+		return client
+	}
+	return c.client
+}
+
 // Get retrieves a value from cache
+// Keys are scoped to the tenant of the context
 func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
-	data, err := c.client.Get(ctx, key).Result()
+	if !redisAvailable() {
+		recordRequest(ctx, key, ResultMiss)
+		return ErrCacheMiss
+	}
+
+	data, err := c.clientFor(ctx).Get(ctx, tenant.Key(ctx, key)).Result()
+	reportRedis(err)
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
+			recordRequest(ctx, key, ResultMiss)
 			return ErrCacheMiss
 		}
+		recordRequest(ctx, key, ResultError)
 		return fmt.Errorf("failed to get from cache: %w", err)
 	}
 
-	if err := json.Unmarshal([]byte(data), dest); err != nil {
+	if err := Decode([]byte(data), dest); err != nil {
+		recordRequest(ctx, key, ResultError)
 		return fmt.Errorf("failed to unmarshal cached data: %w", err)
 	}
 
+	recordRequest(ctx, key, ResultHit)
 	return nil
 }
 
 // Set stores a value in cache
 func (c *Cache) Set(ctx context.Context, key string, value interface{}) error {
-	data, err := json.Marshal(value)
+	data, err := c.codec.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data for cache: %w", err)
 	}
 
-	if err := c.client.Set(ctx, key, data, c.ttl).Err(); err != nil {
+	if !redisAvailable() {
+		return nil
+	}
+	err = c.clientFor(ctx).Set(ctx, tenant.Key(ctx, key), data, c.ttl).Err()
+	reportRedis(err)
+	if err != nil {
+		return fmt.Errorf("failed to set cache: %w", err)
+	}
+
+	return nil
+}
+
+// MGet retrieves several values with a single round trip
+// dests must be aligned with keys; the returned flags report which keys were found and decoded
+func (c *Cache) MGet(ctx context.Context, keys []string, dests []interface{}) ([]bool, error) {
+	if len(keys) != len(dests) {
+		return nil, fmt.Errorf("got %d keys but %d destinations", len(keys), len(dests))
+	}
+	found := make([]bool, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+	if !redisAvailable() {
+		for _, key := range keys {
+			recordRequest(ctx, key, ResultMiss)
+		}
+		return found, nil
+	}
+
+	scoped := make([]string, len(keys))
+	for i, key := range keys {
+		scoped[i] = tenant.Key(ctx, key)
+	}
+
+	values, err := c.clientFor(ctx).MGet(ctx, scoped...).Result()
+	reportRedis(err)
+	if err != nil {
+		for _, key := range keys {
+			recordRequest(ctx, key, ResultError)
+		}
+		return nil, fmt.Errorf("failed to get from cache: %w", err)
+	}
+
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			recordRequest(ctx, keys[i], ResultMiss)
+			continue
+		}
+		if err := Decode([]byte(data), dests[i]); err != nil {
+			recordRequest(ctx, keys[i], ResultError)
+			return nil, fmt.Errorf("failed to unmarshal cached data for %s: %w", keys[i], err)
+		}
+		recordRequest(ctx, keys[i], ResultHit)
+		found[i] = true
+	}
+
+	return found, nil
+}
+
+// MSet stores several values with a single pipelined round trip
+func (c *Cache) MSet(ctx context.Context, values map[string]interface{}) error {
+	if len(values) == 0 || !redisAvailable() {
+		return nil
+	}
+
+	pipe := c.clientFor(ctx).Pipeline()
+	for key, value := range values {
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal data for cache: %w", err)
+		}
+		pipe.Set(ctx, tenant.Key(ctx, key), data, c.ttl)
+	}
+
+	_, err := pipe.Exec(ctx)
+	reportRedis(err)
+	if err != nil {
 		return fmt.Errorf("failed to set cache: %w", err)
 	}
+	return nil
+}
 
+// Touch resets the TTL of existing keys with a single pipelined round trip
+func (c *Cache) Touch(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 || !redisAvailable() {
+		return nil
+	}
+
+	pipe := c.clientFor(ctx).Pipeline()
+	for _, key := range keys {
+		pipe.Expire(ctx, tenant.Key(ctx, key), c.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	reportRedis(err)
+	if err != nil {
+		return fmt.Errorf("failed to refresh cache ttl: %w", err)
+	}
 	return nil
 }
 
 // Delete removes a value from cache
+// While Redis is unavailable the deletion is skipped; the key expires with its TTL
 func (c *Cache) Delete(ctx context.Context, key string) error {
-	if err := c.client.Del(ctx, key).Err(); err != nil {
+	if !redisAvailable() {
+		slog.WarnContext(ctx, "Redis unavailable, cache entry not deleted", "key", KeyPrefix(key))
+		return nil
+	}
+	err := c.clientFor(ctx).Del(ctx, tenant.Key(ctx, key)).Err()
+	reportRedis(err)
+	if err != nil {
 		return fmt.Errorf("failed to delete from cache: %w", err)
 	}
 	return nil
@@ -91,6 +278,10 @@ func (c *Cache) Delete(ctx context.Context, key string) error {
 // GenerateKey generates a secure cache key using SHA256 hash
 // This prevents sensitive content from appearing in Redis keys
 func (c *Cache) GenerateKey(prefix string, content string) string {
+	return hashKey(prefix, content)
+}
+
+func hashKey(prefix string, content string) string {
 	hash := sha256.Sum256([]byte(content))
 	return fmt.Sprintf("%s:%s", prefix, hex.EncodeToString(hash[:]))
 }
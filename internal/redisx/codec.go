@@ -0,0 +1,117 @@
+package redisx
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec names accepted by CodecByName
+const (
+	CodecJSON  = "json"
+	CodecGob   = "gob"
+	CodecProto = "proto"
+)
+
+// Codec encodes cache entries
+// Entries of non-JSON codecs start with the codec's header, so entries written with any codec stay
+// readable after the configured codec changes; data without a known header is decoded as JSON
+type Codec interface {
+	// Header marks entries written with the codec; empty for JSON
+	Header() []byte
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes entries as JSON, the format of all entries written before codecs were configurable
+type JSONCodec struct{}
+
+func (JSONCodec) Header() []byte                             { return nil }
+func (JSONCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// GobCodec encodes entries with encoding/gob, a compact binary format for large contexts and sessions
+type GobCodec struct{}
+
+var gobHeader = []byte("\x00gob\x00")
+
+func (GobCodec) Header() []byte { return gobHeader }
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := bytes.NewBuffer(append([]byte{}, gobHeader...))
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, gobHeader))).Decode(v)
+}
+
+// ProtoCodec encodes protobuf messages in their binary wire format
+// Values that are not protobuf messages are written as JSON
+type ProtoCodec struct{}
+
+var protoHeader = []byte("\x00pb\x00")
+
+func (ProtoCodec) Header() []byte { return protoHeader }
+
+func (ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return json.Marshal(v)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, protoHeader...), data...), nil
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("cannot decode a protobuf entry into %T", v)
+	}
+	return proto.Unmarshal(bytes.TrimPrefix(data, protoHeader), msg)
+}
+
+// codecs are tried in order when decoding; JSON is the fallback for data without a header
+var codecs = []Codec{GobCodec{}, ProtoCodec{}}
+
+// CodecByName returns the codec with the given name
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", CodecJSON:
+		return JSONCodec{}, nil
+	case CodecGob:
+		return GobCodec{}, nil
+	case CodecProto:
+		return ProtoCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache codec %q, want %q, %q or %q", name, CodecJSON, CodecGob, CodecProto)
+	}
+}
+
+// MustCodec returns the codec with the given name or panics
+func MustCodec(name string) Codec {
+	codec, err := CodecByName(name)
+	if err != nil {
+		panic(err)
+	}
+	return codec
+}
+
+// Decode decodes an entry with the codec it was written with, JSON when it has no known header
+func Decode(data []byte, v interface{}) error {
+	for _, codec := range codecs {
+		if bytes.HasPrefix(data, codec.Header()) {
+			return codec.Unmarshal(data, v)
+		}
+	}
+	return JSONCodec{}.Unmarshal(data, v)
+}
@@ -0,0 +1,68 @@
+package redisx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyStore keeps idempotent responses in Redis, so retries reaching any instance get them
+// A key holds an empty value while its request runs and the encoded response once it completed
+type IdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewIdempotencyStore creates a Redis-backed idempotency store
+func NewIdempotencyStore(client *redis.Client) *IdempotencyStore {
+	return &IdempotencyStore{client: client}
+}
+
+// Claim holds the key for ttl, or returns the stored response of the request that holds it
+func (s *IdempotencyStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, *httpx.StoredResponse, error) {
+	claimed, err := s.client.SetNX(ctx, key, "", ttl).Result()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed {
+		return true, nil, nil
+	}
+
+	data, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) || len(data) == 0 {
+		// Still running, or released since
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to read idempotent response: %w", err)
+	}
+	var resp httpx.StoredResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return false, nil, fmt.Errorf("failed to decode idempotent response: %w", err)
+	}
+	return false, &resp, nil
+}
+
+// Save stores the response of the request holding the key
+func (s *IdempotencyStore) Save(ctx context.Context, key string, resp *httpx.StoredResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release frees the key
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
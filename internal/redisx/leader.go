@@ -0,0 +1,178 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// renewScript extends the lease if the instance still holds it
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// resignScript deletes the lease if the instance still holds it
+var resignScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Election elects one instance among the replicas by holding a lease key in Redis
+// A leader that stops renewing, e.g. because it crashed, loses the lease after ttl
+type Election struct {
+	client *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+
+	leader    atomic.Bool
+	mu        sync.Mutex
+	cancel    context.CancelFunc // Cancels the context passed to OnElected callbacks
+	onElected []func(ctx context.Context)
+	onDeposed []func()
+}
+
+// ElectionOption configures an Election
+type ElectionOption func(*Election)
+
+// OnElected runs fn in its own goroutine whenever the instance becomes the leader
+// Its context is cancelled when leadership is lost, so fn may run work that must happen on one replica only
+func OnElected(fn func(ctx context.Context)) ElectionOption {
+	return func(e *Election) {
+		e.onElected = append(e.onElected, fn)
+	}
+}
+
+// OnDeposed calls fn whenever the instance stops being the leader
+func OnDeposed(fn func()) ElectionOption {
+	return func(e *Election) {
+		e.onDeposed = append(e.onDeposed, fn)
+	}
+}
+
+// NewElection creates an election for the lease key; id identifies this instance
+func NewElection(client *redis.Client, key, id string, ttl time.Duration, opts ...ElectionOption) *Election {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	e := &Election{client: client, key: key, id: id, ttl: ttl}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// IsLeader reports whether the instance held the lease at the last acquisition or renewal
+func (e *Election) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Acquire takes the lease if it is free, or renews it if the instance already holds it,
+// and reports whether the instance leads
+func (e *Election) Acquire(ctx context.Context) (bool, error) {
+	acquired, err := e.client.SetNX(ctx, e.key, e.id, e.ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", e.key, err)
+	}
+	if acquired {
+		return true, nil
+	}
+	return e.Renew(ctx)
+}
+
+// Renew extends the lease and reports whether the instance still holds it
+func (e *Election) Renew(ctx context.Context) (bool, error) {
+	renewed, err := renewScript.Run(ctx, e.client, []string{e.key}, e.id, e.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease %s: %w", e.key, err)
+	}
+	return renewed == 1, nil
+}
+
+// Resign gives up the lease so another instance can take over without waiting for it to expire
+func (e *Election) Resign(ctx context.Context) error {
+	defer e.setLeader(ctx, false)
+	if err := resignScript.Run(ctx, e.client, []string{e.key}, e.id).Err(); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", e.key, err)
+	}
+	return nil
+}
+
+// Leader returns the ID of the instance holding the lease, or "" when no instance leads
+func (e *Election) Leader(ctx context.Context) (string, error) {
+	id, err := e.client.Get(ctx, e.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get lease %s: %w", e.key, err)
+	}
+	return id, nil
+}
+
+// Run campaigns for the lease and renews it every third of its ttl until the context is cancelled,
+// then resigns
+func (e *Election) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		leads, err := e.Acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			// Without Redis no instance can tell whether another one leads, so this one steps down
+			slog.WarnContext(ctx, "Leader election failed", "key", e.key, "error", err)
+		}
+		e.setLeader(ctx, leads && err == nil)
+
+		select {
+		case <-ctx.Done():
+			if e.IsLeader() {
+				resignCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+				if err := e.Resign(resignCtx); err != nil {
+					slog.WarnContext(ctx, "Failed to resign leadership", "key", e.key, "error", err)
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setLeader records the outcome of a campaign and notifies the callbacks when leadership changes
+func (e *Election) setLeader(ctx context.Context, leads bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.leader.Swap(leads) == leads {
+		return
+	}
+	slog.InfoContext(ctx, "Leadership changed", "key", e.key, "instance", e.id, "leader", leads)
+
+	if leads {
+		leaderCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		e.cancel = cancel
+		for _, fn := range e.onElected {
+			go fn(leaderCtx)
+		}
+		return
+	}
+
+	if e.cancel != nil {
+		e.cancel()
+		e.cancel = nil
+	}
+	for _, fn := range e.onDeposed {
+		fn()
+	}
+}
@@ -0,0 +1,186 @@
+package redisx
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// Store is the key-value storage shared by Cache, MemoryCache and TieredCache
+type Store interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	Set(ctx context.Context, key string, value interface{}) error
+	MGet(ctx context.Context, keys []string, dests []interface{}) ([]bool, error)
+	Touch(ctx context.Context, keys ...string) error
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+// MemoryCache stores values in process memory with the same semantics as Cache
+// Values are encoded on Set, so callers never share them; the least recently used entries
+// are evicted beyond maxEntries
+type MemoryCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Most recently used first
+}
+
+// NewMemoryCache creates a memory cache; a maxEntries of zero or less keeps 10000 entries
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &MemoryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// lookup returns the live entry of a scoped key, dropping it when it expired
+// Callers hold the lock
+func (c *MemoryCache) lookup(key string, now time.Time) *memoryEntry {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if now.After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return entry
+}
+
+// Get retrieves a value; keys are scoped to the tenant of the context
+func (c *MemoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	c.mu.Lock()
+	var data []byte
+	if entry := c.lookup(tenant.Key(ctx, key), time.Now()); entry != nil {
+		data = entry.data
+	}
+	c.mu.Unlock()
+	if data == nil {
+		return ErrCacheMiss
+	}
+
+	if err := Decode(data, dest); err != nil {
+		return fmt.Errorf("failed to unmarshal cached data: %w", err)
+	}
+	return nil
+}
+
+// Set stores a value
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}) error {
+	data, err := JSONCodec{}.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data for cache: %w", err)
+	}
+	c.set(tenant.Key(ctx, key), data)
+	return nil
+}
+
+func (c *MemoryCache) set(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.data, entry.expires = data, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, data: data, expires: expires})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// MGet retrieves several values; the returned flags report which keys were found and decoded
+func (c *MemoryCache) MGet(ctx context.Context, keys []string, dests []interface{}) ([]bool, error) {
+	if len(keys) != len(dests) {
+		return nil, fmt.Errorf("got %d keys but %d destinations", len(keys), len(dests))
+	}
+
+	found := make([]bool, len(keys))
+	for i, key := range keys {
+		err := c.Get(ctx, key, dests[i])
+		if errors.Is(err, ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cached data for %s: %w", key, err)
+		}
+		found[i] = true
+	}
+	return found, nil
+}
+
+// MSet stores several values
+func (c *MemoryCache) MSet(ctx context.Context, values map[string]interface{}) error {
+	for key, value := range values {
+		if err := c.Set(ctx, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Touch resets the TTL of existing keys
+func (c *MemoryCache) Touch(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range keys {
+		if entry := c.lookup(tenant.Key(ctx, key), now); entry != nil {
+			entry.expires = now.Add(c.ttl)
+		}
+	}
+	return nil
+}
+
+// Delete removes a value
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key = tenant.Key(ctx, key)
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of stored entries, including expired entries not yet dropped
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// GenerateKey generates a secure cache key using SHA256 hash, see Cache.GenerateKey
+func (c *MemoryCache) GenerateKey(prefix string, content string) string {
+	return hashKey(prefix, content)
+}
@@ -0,0 +1,128 @@
+package redisx
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache lookup results
+const (
+	ResultHit   = "hit"
+	ResultMiss  = "miss"
+	ResultError = "error"
+)
+
+// Recorder records cache lookups, see metrics.Metrics
+type Recorder interface {
+	RecordCacheRequest(ctx context.Context, cache, result string)
+}
+
+var (
+	recorderMu sync.RWMutex
+	recorder   Recorder
+)
+
+// SetRecorder makes every Cache record its lookups by cache name and result
+// Cache names are the key prefixes, e.g. "title", "prompt", "weather" or "context"
+func SetRecorder(r Recorder) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recorder = r
+}
+
+func recordRequest(ctx context.Context, key, result string) {
+	recorderMu.RLock()
+	r := recorder
+	recorderMu.RUnlock()
+	if r != nil {
+		r.RecordCacheRequest(ctx, KeyPrefix(key), result)
+	}
+}
+
+// KeyPrefix returns the cache name of a key: its first segment, without the tenant scope
+func KeyPrefix(key string) string {
+	// Tenant-scoped keys look like "t:<tenant>:<key>", see tenant.Key
+	if rest, ok := strings.CutPrefix(key, "t:"); ok {
+		if _, unscoped, ok := strings.Cut(rest, ":"); ok {
+			key = unscoped
+		}
+	}
+	prefix, _, _ := strings.Cut(key, ":")
+	return prefix
+}
+
+// KeyRecorder records the number of stored keys per prefix, see metrics.Metrics
+type KeyRecorder interface {
+	RecordCacheKeys(ctx context.Context, prefix string, count int64)
+}
+
+// KeySampler periodically counts the keys in Redis per prefix
+// Counting scans the whole keyspace, so the interval should be minutes rather than seconds
+type KeySampler struct {
+	client   *redis.Client
+	recorder KeyRecorder
+	interval time.Duration
+	seen     map[string]bool
+}
+
+// NewKeySampler creates a sampler counting keys every interval
+func NewKeySampler(client *redis.Client, recorder KeyRecorder, interval time.Duration) *KeySampler {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &KeySampler{
+		client:   client,
+		recorder: recorder,
+		interval: interval,
+		seen:     make(map[string]bool),
+	}
+}
+
+// Run samples key counts until the context is cancelled
+func (s *KeySampler) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Cache key sampler started", "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sample(ctx); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Failed to sample cache keys", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Cache key sampler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample counts the keys per prefix once and records the counts
+// Prefixes that no longer have keys are recorded as zero
+func (s *KeySampler) Sample(ctx context.Context) error {
+	counts := make(map[string]int64)
+	iter := s.client.Scan(ctx, 0, "*", 1000).Iterator()
+	for iter.Next(ctx) {
+		counts[KeyPrefix(iter.Val())]++
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	for prefix := range s.seen {
+		if _, ok := counts[prefix]; !ok {
+			s.recorder.RecordCacheKeys(ctx, prefix, 0)
+		}
+	}
+	for prefix, count := range counts {
+		s.seen[prefix] = true
+		s.recorder.RecordCacheKeys(ctx, prefix, count)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package redisx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceStore remembers nonces in Redis to detect replayed requests across instances
+type NonceStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewNonceStore creates a new Redis-backed nonce store
+func NewNonceStore(client *redis.Client) *NonceStore {
+	return &NonceStore{
+		client: client,
+		prefix: "nonce",
+	}
+}
+
+// Remember stores the nonce for ttl and reports whether it was seen for the first time
+func (s *NonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	first, err := s.client.SetNX(ctx, fmt.Sprintf("%s:%s", s.prefix, nonce), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to store nonce: %w", err)
+	}
+	return first, nil
+}
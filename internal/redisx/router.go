@@ -0,0 +1,56 @@
+package redisx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+// Router sends the keys of tenants with a storage profile to the Redis of that profile,
+// e.g. to keep EU tenants' sessions and context in an EU region; other tenants stay on the cache's own client
+// A nil *Router routes nothing
+type Router struct {
+	clients map[string]*redis.Client // Profile -> client
+	tenants map[string]string        // Tenant -> profile
+}
+
+// NewRouter creates a router from profile -> client and tenant -> profile
+func NewRouter(clients map[string]*redis.Client, tenants map[string]string) (*Router, error) {
+	for id, profile := range tenants {
+		if _, ok := clients[profile]; !ok {
+			return nil, fmt.Errorf("tenant %s uses unknown storage profile %q", id, profile)
+		}
+	}
+	return &Router{clients: clients, tenants: tenants}, nil
+}
+
+// MustConnectRouter connects to the Redis of every profile in addrs (profile -> address)
+// Returns nil when no tenant is routed, and panics on an unknown profile or a failed connection
+func MustConnectRouter(addrs map[string]string, tenants map[string]string) *Router {
+	if len(tenants) == 0 {
+		return nil
+	}
+	clients := make(map[string]*redis.Client, len(addrs))
+	for profile, addr := range addrs {
+		clients[profile] = MustConnect(addr)
+	}
+	r, err := NewRouter(clients, tenants)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Client returns the client of the context's tenant, or false when the tenant has no storage profile
+func (r *Router) Client(ctx context.Context) (*redis.Client, bool) {
+	if r == nil {
+		return nil, false
+	}
+	profile, ok := r.tenants[tenant.FromContext(ctx)]
+	if !ok {
+		return nil, false
+	}
+	return r.clients[profile], true
+}
@@ -0,0 +1,115 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// TieredCache keeps local copies of a shared store's values in memory
+// Reads are served locally while the copy is fresh, writes go to both, and when the shared store
+// fails the local copies keep the instance working: values written during the outage stay local
+// Local copies are not invalidated by other instances, so their TTL bounds how stale a read can be
+type TieredCache struct {
+	local  *MemoryCache
+	remote Store
+}
+
+// NewTieredCache creates a cache of local copies over the remote store, usually a Cache
+func NewTieredCache(local *MemoryCache, remote Store) *TieredCache {
+	return &TieredCache{local: local, remote: remote}
+}
+
+// Get returns the local copy, or reads the remote store and keeps a copy
+// When the remote store fails, the value is a miss
+func (c *TieredCache) Get(ctx context.Context, key string, dest interface{}) error {
+	if err := c.local.Get(ctx, key, dest); err == nil {
+		return nil
+	}
+
+	err := c.remote.Get(ctx, key, dest)
+	switch {
+	case err == nil:
+		c.keepLocal(ctx, key, dest)
+		return nil
+	case errors.Is(err, ErrCacheMiss):
+		return ErrCacheMiss
+	default:
+		slog.WarnContext(ctx, "Shared cache unavailable, serving from memory", "key", KeyPrefix(key), "error", err)
+		return ErrCacheMiss
+	}
+}
+
+// Set stores the value locally and in the remote store; a remote failure is logged, not returned
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}) error {
+	if err := c.local.Set(ctx, key, value); err != nil {
+		return err
+	}
+	if err := c.remote.Set(ctx, key, value); err != nil {
+		slog.WarnContext(ctx, "Shared cache unavailable, value kept in memory only", "key", KeyPrefix(key), "error", err)
+	}
+	return nil
+}
+
+// MGet reads the keys without a local copy from the remote store with a single call
+func (c *TieredCache) MGet(ctx context.Context, keys []string, dests []interface{}) ([]bool, error) {
+	found, err := c.local.MGet(ctx, keys, dests)
+	if err != nil {
+		return nil, err
+	}
+
+	var missingKeys []string
+	var missingDests []interface{}
+	var missing []int
+	for i, ok := range found {
+		if !ok {
+			missingKeys = append(missingKeys, keys[i])
+			missingDests = append(missingDests, dests[i])
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	remoteFound, err := c.remote.MGet(ctx, missingKeys, missingDests)
+	if err != nil {
+		slog.WarnContext(ctx, "Shared cache unavailable, serving from memory", "keys", len(missingKeys), "error", err)
+		return found, nil
+	}
+	for j, ok := range remoteFound {
+		if ok {
+			found[missing[j]] = true
+			c.keepLocal(ctx, missingKeys[j], missingDests[j])
+		}
+	}
+	return found, nil
+}
+
+// Touch resets the TTL of the keys in both stores; a remote failure is logged, not returned
+func (c *TieredCache) Touch(ctx context.Context, keys ...string) error {
+	_ = c.local.Touch(ctx, keys...)
+	if err := c.remote.Touch(ctx, keys...); err != nil {
+		slog.WarnContext(ctx, "Shared cache unavailable, expiry refreshed in memory only", "keys", len(keys), "error", err)
+	}
+	return nil
+}
+
+// Delete removes the value from both stores
+// A remote failure is returned, as the value would come back once the local copy expires
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	_ = c.local.Delete(ctx, key)
+	return c.remote.Delete(ctx, key)
+}
+
+// GenerateKey generates a secure cache key using SHA256 hash, see Cache.GenerateKey
+func (c *TieredCache) GenerateKey(prefix string, content string) string {
+	return hashKey(prefix, content)
+}
+
+// keepLocal stores a local copy of a value read from the remote store
+func (c *TieredCache) keepLocal(ctx context.Context, key string, value interface{}) {
+	if err := c.local.Set(ctx, key, value); err != nil {
+		slog.WarnContext(ctx, "Failed to keep a local copy", "key", KeyPrefix(key), "error", err)
+	}
+}
@@ -0,0 +1,214 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Fixture modes selectable with LLM_FIXTURES_MODE
+const (
+	FixtureModeOff    = "off"
+	FixtureModeRecord = "record" // Call OpenAI and tools for real and capture their responses
+	FixtureModeReplay = "replay" // Serve captured responses and never reach the network
+)
+
+// ErrNoFixture is returned in replay mode for a request that was never recorded
+var ErrNoFixture = errors.New("no fixture recorded for request")
+
+// Fixtures maps LLM requests and tool calls of a test scenario to recorded responses
+// Requests are keyed by a hash of their method, path and JSON body, so the same request
+// always gets the same response regardless of the order requests are made in
+type Fixtures struct {
+	mu        sync.Mutex
+	path      string
+	Exchanges map[string]Exchange      `json:"exchanges"`
+	Tools     map[string]ToolExecution `json:"tools"`
+}
+
+// ScenarioPath returns the fixture file of a scenario in dir
+func ScenarioPath(dir, scenario string) string {
+	return filepath.Join(dir, scenario+".json")
+}
+
+// LoadFixtures reads a fixture file; a missing file gives empty fixtures that Save creates
+func LoadFixtures(path string) (*Fixtures, error) {
+	f := &Fixtures{
+		path:      path,
+		Exchanges: make(map[string]Exchange),
+		Tools:     make(map[string]ToolExecution),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures %s: %w", path, err)
+	}
+	if f.Exchanges == nil {
+		f.Exchanges = make(map[string]Exchange)
+	}
+	if f.Tools == nil {
+		f.Tools = make(map[string]ToolExecution)
+	}
+	return f, nil
+}
+
+// Save writes the fixtures back to their file; keys are sorted so re-recording gives small diffs
+func (f *Fixtures) Save() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	return os.WriteFile(f.path, append(data, '\n'), 0o644)
+}
+
+// RequestHash identifies an LLM request by method, path and body, ignoring JSON key order and whitespace
+func RequestHash(method, path, body string) string {
+	return hashParts(method, path, canonicalJSON(body))
+}
+
+// ToolHash identifies a tool call by name and JSON arguments
+func ToolHash(name, arguments string) string {
+	return hashParts(name, canonicalJSON(arguments))
+}
+
+// ReplayMiddleware answers LLM requests from the fixtures without calling next
+// Its signature matches option.Middleware of the OpenAI client
+func (f *Fixtures) ReplayMiddleware(req *http.Request, _ func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	hash := RequestHash(req.Method, req.URL.Path, body)
+
+	f.mu.Lock()
+	recorded, ok := f.Exchanges[hash]
+	f.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s (hash %s)", ErrNoFixture, req.Method, req.URL.Path, hash)
+	}
+	return recordedResponse(req, recorded), nil
+}
+
+// RecordMiddleware calls next and stores successful responses in the fixtures, saving them as they arrive
+// Response bodies are captured as they are read, so streamed responses keep streaming
+func (f *Fixtures) RecordMiddleware(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	hash := RequestHash(req.Method, req.URL.Path, body)
+
+	start := time.Now()
+	resp, err := next(req)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		// Transient failures are retried and would make the recording flaky
+		return resp, err
+	}
+
+	capture := &captureBody{ReadCloser: resp.Body}
+	capture.onClose = func(data []byte) {
+		f.mu.Lock()
+		f.Exchanges[hash] = Exchange{
+			Request:    body,
+			Response:   string(data),
+			Status:     resp.StatusCode,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		f.mu.Unlock()
+		f.save()
+	}
+	resp.Body = capture
+	return resp, nil
+}
+
+// Tool returns the recorded outcome of a tool call
+func (f *Fixtures) Tool(name, arguments string) (ToolExecution, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	execution, ok := f.Tools[ToolHash(name, arguments)]
+	return execution, ok
+}
+
+// RecordTool stores the outcome of a tool call and saves the fixtures
+func (f *Fixtures) RecordTool(name, arguments, output string, err error) {
+	execution := ToolExecution{Name: name, Arguments: arguments, Output: output}
+	if err != nil {
+		execution.Error = err.Error()
+	}
+
+	f.mu.Lock()
+	f.Tools[ToolHash(name, arguments)] = execution
+	f.mu.Unlock()
+	f.save()
+}
+
+// save writes the fixtures after every recorded response, so a test run killed halfway keeps what it captured
+func (f *Fixtures) save() {
+	if err := f.Save(); err != nil {
+		slog.Warn("Failed to save LLM fixtures", "path", f.path, "error", err)
+	}
+}
+
+// recordedResponse rebuilds an HTTP response from a recorded exchange
+func recordedResponse(req *http.Request, recorded Exchange) *http.Response {
+	contentType := "application/json"
+	if bytes.HasPrefix(bytes.TrimSpace([]byte(recorded.Response)), []byte("data:")) {
+		contentType = "text/event-stream"
+	}
+	status := recorded.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(recorded.Response))),
+		Request:    req,
+	}
+}
+
+// canonicalJSON re-encodes JSON so key order and whitespace do not matter; other text is kept as is
+func canonicalJSON(s string) string {
+	var v any
+	if json.Unmarshal([]byte(s), &v) != nil {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return s
+	}
+	return string(data)
+}
+
+func hashParts(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
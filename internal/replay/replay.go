@@ -0,0 +1,227 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrExhausted is returned by a Player when a re-run makes more LLM requests than were recorded
+var ErrExhausted = errors.New("replay exhausted: no recorded response left")
+
+// Exchange is one recorded LLM request with its raw request and response bodies
+// Headers are not recorded, so API keys never reach the log
+type Exchange struct {
+	Request    string `bson:"request" json:"request"`
+	Response   string `bson:"response" json:"response"`
+	Status     int    `bson:"status" json:"status"`
+	DurationMs int64  `bson:"duration_ms" json:"duration_ms"`
+}
+
+// ToolExecution is one recorded tool call made while answering a turn
+type ToolExecution struct {
+	Name      string `bson:"name" json:"name"`
+	Arguments string `bson:"arguments" json:"arguments"`
+	Output    string `bson:"output,omitempty" json:"output,omitempty"`
+	Error     string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// Turn is everything that went into and came out of a single reply
+type Turn struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	TenantID       string             `bson:"tenant_id" json:"tenant_id"`
+	ConversationID string             `bson:"conversation_id" json:"conversation_id"`
+	Platform       string             `bson:"platform,omitempty" json:"platform,omitempty"`
+	UserID         string             `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	MessageIndex   int                `bson:"message_index" json:"message_index"` // Conversation messages the turn replied to
+	Exchanges      []Exchange         `bson:"exchanges" json:"exchanges"`
+	ToolCalls      []ToolExecution    `bson:"tool_calls,omitempty" json:"tool_calls,omitempty"`
+	Reply          string             `bson:"reply,omitempty" json:"reply,omitempty"`
+	Error          string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt      time.Time          `bson:"expires_at" json:"-"`
+}
+
+// Rerun is the outcome of re-running a recorded turn against its recorded LLM responses
+type Rerun struct {
+	Reply      string `json:"reply"`
+	Requests   int    `json:"requests"`
+	DivergedAt int    `json:"diverged_at"` // Index of the first LLM request that differs from the recording, -1 if none
+	Matches    bool   `json:"matches"`
+	Error      string `json:"error,omitempty"`
+}
+
+type contextKey struct{}
+
+// Recording collects the exchanges and tool calls of a turn in progress
+type Recording struct {
+	mu   sync.Mutex
+	turn *Turn
+}
+
+// Start attaches a new recording for the turn to the context
+func Start(ctx context.Context, turn *Turn) (context.Context, *Recording) {
+	rec := &Recording{turn: turn}
+	return context.WithValue(ctx, contextKey{}, rec), rec
+}
+
+// FromContext returns the recording of the context, or nil when the turn is not recorded
+func FromContext(ctx context.Context) *Recording {
+	rec, _ := ctx.Value(contextKey{}).(*Recording)
+	return rec
+}
+
+// Middleware records LLM requests; its signature matches option.Middleware of the OpenAI client
+// Response bodies are captured as they are read, so streamed responses keep streaming
+func (r *Recording) Middleware(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	index := len(r.turn.Exchanges)
+	r.turn.Exchanges = append(r.turn.Exchanges, Exchange{Request: body})
+	r.mu.Unlock()
+
+	start := time.Now()
+	resp, err := next(req)
+	if err != nil {
+		r.mu.Lock()
+		r.turn.Exchanges[index].DurationMs = time.Since(start).Milliseconds()
+		r.mu.Unlock()
+		return nil, err
+	}
+
+	capture := &captureBody{ReadCloser: resp.Body}
+	capture.onClose = func(data []byte) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.turn.Exchanges[index].Response = string(data)
+		r.turn.Exchanges[index].Status = resp.StatusCode
+		r.turn.Exchanges[index].DurationMs = time.Since(start).Milliseconds()
+	}
+	resp.Body = capture
+	return resp, nil
+}
+
+// RecordTool records the outcome of a tool call
+func (r *Recording) RecordTool(name, arguments, output string, err error) {
+	execution := ToolExecution{Name: name, Arguments: arguments, Output: output}
+	if err != nil {
+		execution.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.turn.ToolCalls = append(r.turn.ToolCalls, execution)
+}
+
+// Finish stores the reply or error of the turn and returns it
+func (r *Recording) Finish(reply string, err error) *Turn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.turn.Reply = reply
+	if err != nil {
+		r.turn.Error = err.Error()
+	}
+	return r.turn
+}
+
+// Player is a mock LLM that answers requests with the responses of a recorded turn, in order
+type Player struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	requests  []string
+}
+
+// NewPlayer creates a mock LLM for the recorded exchanges
+func NewPlayer(exchanges []Exchange) *Player {
+	return &Player{exchanges: exchanges}
+}
+
+// Middleware answers a request without calling next; its signature matches option.Middleware
+func (p *Player) Middleware(req *http.Request, _ func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	index := len(p.requests)
+	p.requests = append(p.requests, body)
+	p.mu.Unlock()
+
+	if index >= len(p.exchanges) {
+		return nil, ErrExhausted
+	}
+	return recordedResponse(req, p.exchanges[index]), nil
+}
+
+// Requests returns the raw bodies of the requests the player answered
+func (p *Player) Requests() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.requests...)
+}
+
+// DivergedAt returns the index of the first request that differs from the recorded one, or -1
+// Requests are compared as JSON, so key order and whitespace do not count as differences
+func DivergedAt(recorded []Exchange, requests []string) int {
+	for i := 0; i < max(len(recorded), len(requests)); i++ {
+		if i >= len(recorded) || i >= len(requests) || !sameJSON(recorded[i].Request, requests[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func sameJSON(a, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return a == b
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
+
+// readBody returns the request body and restores it for the next reader
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
+
+// captureBody copies a response body while it is read and reports the copy on Close
+type captureBody struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	once    sync.Once
+	onClose func([]byte)
+}
+
+func (c *captureBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.buf.Write(p[:n])
+	return n, err
+}
+
+func (c *captureBody) Close() error {
+	c.once.Do(func() { c.onClose(c.buf.Bytes()) })
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"context"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const turnCollection = "conversation_turns"
+
+// Repository stores recorded turns
+type Repository interface {
+	SaveTurn(ctx context.Context, turn *Turn) error
+	// ListTurns returns the turns of a conversation within the context's tenant, oldest first
+	ListTurns(ctx context.Context, conversationID string) ([]*Turn, error)
+}
+
+// Runner re-runs a recorded turn of a conversation against its recorded LLM responses
+type Runner interface {
+	Rerun(ctx context.Context, conv *model.Conversation, turn *Turn) (*Rerun, error)
+}
+
+// Service lists recorded turns and re-runs them for debugging
+type Service struct {
+	Repository
+	runner Runner
+}
+
+// NewService creates a replay service
+func NewService(repo Repository, runner Runner) *Service {
+	return &Service{Repository: repo, runner: runner}
+}
+
+// Rerun re-runs a recorded turn
+func (s *Service) Rerun(ctx context.Context, conv *model.Conversation, turn *Turn) (*Rerun, error) {
+	return s.runner.Rerun(ctx, conv, turn)
+}
+
+// MongoRepository stores recorded turns in MongoDB
+// Documents expire through a TTL index on expires_at
+type MongoRepository struct {
+	conn      *mongo.Database
+	retention time.Duration
+}
+
+// NewMongoRepository creates a new MongoDB turn repository keeping turns for retention
+func NewMongoRepository(conn *mongo.Database, retention time.Duration) *MongoRepository {
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+	return &MongoRepository{conn: conn, retention: retention}
+}
+
+func (r *MongoRepository) SaveTurn(ctx context.Context, turn *Turn) error {
+	if turn.ID.IsZero() {
+		turn.ID = primitive.NewObjectID()
+	}
+	if turn.TenantID == "" {
+		turn.TenantID = tenant.FromContext(ctx)
+	}
+	if turn.CreatedAt.IsZero() {
+		turn.CreatedAt = time.Now()
+	}
+	turn.ExpiresAt = turn.CreatedAt.Add(r.retention)
+
+	_, err := r.conn.Collection(turnCollection).InsertOne(ctx, turn)
+	return err
+}
+
+func (r *MongoRepository) ListTurns(ctx context.Context, conversationID string) ([]*Turn, error) {
+	cursor, err := r.conn.Collection(turnCollection).Find(ctx, bson.M{
+		"tenant_id":       tenant.FromContext(ctx),
+		"conversation_id": conversationID,
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var turns []*Turn
+	if err := cursor.All(ctx, &turns); err != nil {
+		return nil, err
+	}
+	return turns, nil
+}
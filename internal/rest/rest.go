@@ -0,0 +1,288 @@
+// Package rest serves the chat API as resource-oriented JSON over HTTP, for clients that cannot use Twirp
+// Every route maps onto a ChatService method, so requests are validated and answered exactly as over Twirp,
+// and errors use the Twirp JSON error format. Twirp server hooks and interceptors run around each route
+// as they do around RPCs, with the context carrying the name of the mapped method
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/gorilla/mux"
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/ctxsetters"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// PathPrefix is where the REST API is mounted
+const PathPrefix = "/v1"
+
+// Bodies and responses use the field names of the Twirp JSON API, and omit empty fields like the server does
+var (
+	unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+	marshalOptions   = protojson.MarshalOptions{UseProtoNames: true}
+)
+
+// Package and service of the mapped methods, as the Twirp server sets them in the context
+const (
+	packageName = "acai.chat"
+	serviceName = "ChatService"
+)
+
+// Handler serves the REST API on top of a ChatService
+type Handler struct {
+	router      *mux.Router
+	hooks       *twirp.ServerHooks
+	interceptor twirp.Interceptor
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithServerHooks runs the hooks around every request, like twirp.WithServerHooks
+func WithServerHooks(hooks *twirp.ServerHooks) Option {
+	return func(h *Handler) {
+		h.hooks = twirp.ChainHooks(h.hooks, hooks)
+	}
+}
+
+// WithServerInterceptors wraps every method call with the interceptors, like twirp.WithServerInterceptors
+func WithServerInterceptors(interceptors ...twirp.Interceptor) Option {
+	return func(h *Handler) {
+		h.interceptor = twirp.ChainInterceptors(append([]twirp.Interceptor{h.interceptor}, interceptors...)...)
+	}
+}
+
+// NewHandler creates a REST handler for chat
+//
+//	GET    /v1/conversations                                   ListConversations
+//	POST   /v1/conversations                                   StartConversation
+//	GET    /v1/conversations/{id}                              DescribeConversation
+//	POST   /v1/conversations/{id}/messages                     ContinueConversation
+//	POST   /v1/conversations/{id}/messages/{message_id}/reactions  AddReaction
+//	PUT    /v1/conversations/{id}/instructions                 SetConversationInstructions
+//	POST   /v1/sessions/messages                               ContinueConversation of the chat in session_metadata
+//	POST   /v1/sessions/reset                                  ResetSession
+//	POST   /v1/sessions/claim                                  ClaimSession
+//	GET    /v1/users/{platform}/{user_id}/settings             GetUserSettings
+//	PUT    /v1/users/{platform}/{user_id}/settings             SetUserSettings
+func NewHandler(chat pb.ChatService, opts ...Option) *Handler {
+	h := &Handler{router: mux.NewRouter()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	r := h.router.PathPrefix(PathPrefix).Subrouter()
+
+	r.Handle("/conversations", handle(h, http.StatusOK, noBody[*pb.ListConversationsRequest],
+		"ListConversations", chat.ListConversations)).Methods(http.MethodGet)
+	r.Handle("/conversations", handle(h, http.StatusCreated, body[*pb.StartConversationRequest],
+		"StartConversation", chat.StartConversation)).Methods(http.MethodPost)
+	r.Handle("/conversations/{id}", handle(h, http.StatusOK,
+		func(r *http.Request) (*pb.DescribeConversationRequest, error) {
+			return &pb.DescribeConversationRequest{ConversationId: mux.Vars(r)["id"]}, nil
+		}, "DescribeConversation", chat.DescribeConversation)).Methods(http.MethodGet)
+	r.Handle("/conversations/{id}/messages", handle(h, http.StatusOK,
+		func(r *http.Request) (*pb.ContinueConversationRequest, error) {
+			req, err := body[*pb.ContinueConversationRequest](r)
+			if err != nil {
+				return nil, err
+			}
+			// The conversation is addressed by the path, so sessions are ignored
+			req.ConversationId, req.SessionMetadata = mux.Vars(r)["id"], nil
+			return req, nil
+		}, "ContinueConversation", chat.ContinueConversation)).Methods(http.MethodPost)
+	r.Handle("/conversations/{id}/messages/{message_id}/reactions", handle(h, http.StatusOK,
+		func(r *http.Request) (*pb.AddReactionRequest, error) {
+			req, err := body[*pb.AddReactionRequest](r)
+			if err != nil {
+				return nil, err
+			}
+			req.ConversationId, req.MessageId = mux.Vars(r)["id"], mux.Vars(r)["message_id"]
+			return req, nil
+		}, "AddReaction", chat.AddReaction)).Methods(http.MethodPost)
+	r.Handle("/conversations/{id}/instructions", handle(h, http.StatusOK,
+		func(r *http.Request) (*pb.SetConversationInstructionsRequest, error) {
+			req, err := body[*pb.SetConversationInstructionsRequest](r)
+			if err != nil {
+				return nil, err
+			}
+			req.ConversationId = mux.Vars(r)["id"]
+			return req, nil
+		}, "SetConversationInstructions", chat.SetConversationInstructions)).Methods(http.MethodPut)
+
+	r.Handle("/sessions/messages", handle(h, http.StatusOK,
+		func(r *http.Request) (*pb.ContinueConversationRequest, error) {
+			req, err := body[*pb.ContinueConversationRequest](r)
+			if err != nil {
+				return nil, err
+			}
+			if req.GetSessionMetadata() == nil {
+				return nil, twirp.RequiredArgumentError("session_metadata")
+			}
+			req.ConversationId = ""
+			return req, nil
+		}, "ContinueConversation", chat.ContinueConversation)).Methods(http.MethodPost)
+	r.Handle("/sessions/reset", handle(h, http.StatusOK, body[*pb.ResetSessionRequest],
+		"ResetSession", chat.ResetSession)).Methods(http.MethodPost)
+	r.Handle("/sessions/claim", handle(h, http.StatusOK, body[*pb.ClaimSessionRequest],
+		"ClaimSession", chat.ClaimSession)).Methods(http.MethodPost)
+
+	r.Handle("/users/{platform}/{user_id}/settings", handle(h, http.StatusOK,
+		func(r *http.Request) (*pb.GetUserSettingsRequest, error) {
+			return &pb.GetUserSettingsRequest{SessionMetadata: userMetadata(r)}, nil
+		}, "GetUserSettings", chat.GetUserSettings)).Methods(http.MethodGet)
+	r.Handle("/users/{platform}/{user_id}/settings", handle(h, http.StatusOK,
+		func(r *http.Request) (*pb.SetUserSettingsRequest, error) {
+			settings := &pb.UserSettings{}
+			if err := decode(r, settings); err != nil {
+				return nil, err
+			}
+			return &pb.SetUserSettingsRequest{SessionMetadata: userMetadata(r), Settings: settings}, nil
+		}, "SetUserSettings", chat.SetUserSettings)).Methods(http.MethodPut)
+
+	h.router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.reject(w, r, twirp.NewError(twirp.BadRoute, "no route for "+r.Method+" "+r.URL.Path))
+	})
+	h.router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.reject(w, r, twirp.NewError(twirp.BadRoute, r.Method+" is not allowed on "+r.URL.Path))
+	})
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+// handle decodes the request with parse, calls the method and writes its response with the given status
+// The hooks and interceptors of h run as the Twirp server runs them for the method
+func handle[Req, Resp proto.Message](h *Handler, status int, parse func(*http.Request) (Req, error), name string, method func(context.Context, Req) (Resp, error)) http.Handler {
+	call := twirp.Method(func(ctx context.Context, req any) (any, error) {
+		return method(ctx, req.(Req))
+	})
+	if h.interceptor != nil {
+		call = h.interceptor(call)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := h.received(w, r, name)
+		if err != nil {
+			h.writeError(ctx, w, err)
+			return
+		}
+		if h.hooks != nil && h.hooks.RequestRouted != nil {
+			if ctx, err = h.hooks.RequestRouted(ctx); err != nil {
+				h.writeError(ctx, w, err)
+				return
+			}
+		}
+
+		req, err := parse(r.WithContext(ctx))
+		if err != nil {
+			h.writeError(ctx, w, err)
+			return
+		}
+
+		resp, err := call(ctx, req)
+		if err != nil {
+			h.writeError(ctx, w, err)
+			return
+		}
+
+		data, err := marshalOptions.Marshal(resp.(Resp))
+		if err != nil {
+			h.writeError(ctx, w, twirp.InternalErrorWith(err))
+			return
+		}
+		ctx = ctxsetters.WithStatusCode(ctx, status)
+		if h.hooks != nil && h.hooks.ResponsePrepared != nil {
+			ctx = h.hooks.ResponsePrepared(ctx)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(data)
+		if h.hooks != nil && h.hooks.ResponseSent != nil {
+			h.hooks.ResponseSent(ctx)
+		}
+	})
+}
+
+// received sets up the context of a request like the Twirp server does and runs the RequestReceived hook
+// name is the mapped method, "" for requests that matched no route
+func (h *Handler) received(w http.ResponseWriter, r *http.Request, name string) (context.Context, error) {
+	ctx := ctxsetters.WithPackageName(r.Context(), packageName)
+	ctx = ctxsetters.WithServiceName(ctx, serviceName)
+	ctx = ctxsetters.WithResponseWriter(ctx, w)
+	if name != "" {
+		ctx = ctxsetters.WithMethodName(ctx, name)
+	}
+	if h.hooks != nil && h.hooks.RequestReceived != nil {
+		return h.hooks.RequestReceived(ctx)
+	}
+	return ctx, nil
+}
+
+// reject answers requests that matched no route, running the hooks like the Twirp server does for bad routes
+func (h *Handler) reject(w http.ResponseWriter, r *http.Request, twerr twirp.Error) {
+	ctx, err := h.received(w, r, "")
+	if err != nil {
+		h.writeError(ctx, w, err)
+		return
+	}
+	h.writeError(ctx, w, twerr)
+}
+
+// writeError writes err in the Twirp JSON error format, running the Error and ResponseSent hooks
+func (h *Handler) writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	twerr, ok := err.(twirp.Error)
+	if !ok {
+		twerr = twirp.InternalErrorWith(err)
+	}
+	ctx = ctxsetters.WithStatusCode(ctx, twirp.ServerHTTPStatusFromErrorCode(twerr.Code()))
+	if h.hooks != nil && h.hooks.Error != nil {
+		ctx = h.hooks.Error(ctx, twerr)
+	}
+	twirp.WriteError(w, twerr)
+	if h.hooks != nil && h.hooks.ResponseSent != nil {
+		h.hooks.ResponseSent(ctx)
+	}
+}
+
+// body decodes the JSON body into a new request message
+func body[Req proto.Message](r *http.Request) (Req, error) {
+	req := newMessage[Req]()
+	return req, decode(r, req)
+}
+
+// noBody returns an empty request message for routes that take no body
+func noBody[Req proto.Message](*http.Request) (Req, error) {
+	return newMessage[Req](), nil
+}
+
+func newMessage[M proto.Message]() M {
+	var m M
+	return m.ProtoReflect().Type().New().Interface().(M)
+}
+
+// decode reads a JSON body into msg; an empty body leaves it empty
+func decode(r *http.Request, msg proto.Message) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return twirp.NewError(twirp.Malformed, "failed to read request body")
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err := unmarshalOptions.Unmarshal(data, msg); err != nil {
+		return twirp.NewError(twirp.Malformed, "the json request could not be decoded: "+err.Error())
+	}
+	return nil
+}
+
+// userMetadata identifies the user in the path of settings routes
+func userMetadata(r *http.Request) *pb.SessionMetadata {
+	vars := mux.Vars(r)
+	return &pb.SessionMetadata{Platform: vars["platform"], UserId: vars["user_id"]}
+}
@@ -0,0 +1,182 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// maxServerDelay caps how long a retry waits for the time a server asked for;
+// longer waits give up instead of holding the request
+const maxServerDelay = time.Minute
+
+// lowRemainingShare is the share of the rate limit below which requests are spread over the reset window
+const lowRemainingShare = 0.1
+
+// Limiter paces requests by the rate limit headers of their responses
+// It blocks all requests while the server asked to back off and spreads them out as the limit runs low
+type Limiter struct {
+	mu           sync.Mutex
+	blockedUntil time.Time     // No request starts before this time
+	next         time.Time     // Earliest start of the next request while pacing
+	interval     time.Duration // Spacing between requests while the limit is low
+}
+
+// NewLimiter creates a limiter that lets every request through until a response says otherwise
+func NewLimiter() *Limiter {
+	return &Limiter{}
+}
+
+var sharedLimiter = NewLimiter()
+
+// SharedLimiter returns the process-wide limiter for OpenAI requests
+func SharedLimiter() *Limiter {
+	return sharedLimiter
+}
+
+// Wait blocks until the limiter lets the next request start or the context is cancelled
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	start := now
+	if l.blockedUntil.After(start) {
+		start = l.blockedUntil
+	}
+	if l.interval > 0 {
+		if l.next.After(start) {
+			start = l.next
+		}
+		l.next = start.Add(l.interval)
+	}
+	l.mu.Unlock()
+
+	delay := start.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe updates the limiter from the headers of a response
+func (l *Limiter) Observe(status int, header http.Header) {
+	now := time.Now()
+	var block time.Duration
+	var interval time.Duration
+
+	if status == http.StatusTooManyRequests {
+		if d, ok := ParseRetryAfter(header); ok {
+			block = d
+		}
+	}
+
+	for _, kind := range []string{"requests", "tokens"} {
+		limit, hasLimit := parseInt(header.Get("x-ratelimit-limit-" + kind))
+		remaining, ok := parseInt(header.Get("x-ratelimit-remaining-" + kind))
+		if !ok {
+			continue
+		}
+		reset, ok := parseReset(header.Get("x-ratelimit-reset-" + kind))
+		if !ok {
+			continue
+		}
+
+		if remaining <= 0 {
+			block = max(block, reset)
+			continue
+		}
+		if kind == "requests" && hasLimit && float64(remaining) < float64(limit)*lowRemainingShare {
+			interval = max(interval, reset/time.Duration(remaining+1))
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if block > 0 && now.Add(block).After(l.blockedUntil) {
+		if !l.blockedUntil.After(now) {
+			slog.Warn("OpenAI rate limit reached, holding requests", "delay", block)
+		}
+		l.blockedUntil = now.Add(block)
+	}
+	if interval != l.interval {
+		if interval > 0 && l.interval == 0 {
+			slog.Info("OpenAI rate limit running low, pacing requests", "interval", interval)
+		}
+		l.interval = interval
+	}
+}
+
+// Middleware waits for the limiter before each request and feeds it the response headers
+// Use it with option.WithMiddleware
+func (l *Limiter) Middleware(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	if err := l.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := next(req)
+	if resp != nil {
+		l.Observe(resp.StatusCode, resp.Header)
+	}
+	return resp, err
+}
+
+// ParseRetryAfter returns the delay a response asks for in its retry-after-ms or Retry-After header
+func ParseRetryAfter(header http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(at)), true
+	}
+	return 0, false
+}
+
+// serverDelay returns the retry delay an OpenAI error response asks for
+func serverDelay(err error) (time.Duration, bool) {
+	var openaiErr *openai.Error
+	if !errors.As(err, &openaiErr) || openaiErr.Response == nil {
+		return 0, false
+	}
+	return ParseRetryAfter(openaiErr.Response.Header)
+}
+
+func parseInt(value string) (int64, bool) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n, err == nil
+}
+
+// parseReset parses the reset headers, which OpenAI sends as durations such as "1s", "6m0s" or "20ms"
+func parseReset(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, true
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
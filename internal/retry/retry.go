@@ -59,12 +59,26 @@ func RetryWithResult[T any](ctx context.Context, config RetryConfig, fn func() (
 			return zero, fmt.Errorf("max retry attempts (%d) reached, last error: %w", config.MaxAttempts+1, err)
 		}
 
-		// Calculate delay with exponential backoff
-		delay := calculateDelay(config, attempt)
+		// Retry when the server asked to, falling back to exponential backoff
+		delay, fromServer := serverDelay(err)
+		if !fromServer {
+			delay = calculateDelay(config, attempt)
+		}
+		if delay > maxServerDelay {
+			slog.WarnContext(ctx, "Server asked to retry too late, giving up",
+				"attempt", attempt+1,
+				"delay", delay,
+				"error", err)
+			return zero, fmt.Errorf("server asked to retry in %s: %w", delay, err)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return zero, fmt.Errorf("retry in %s would pass the deadline: %w", delay, err)
+		}
 		slog.WarnContext(ctx, "Retryable error encountered, will retry",
 			"attempt", attempt+1,
 			"max_attempts", config.MaxAttempts+1,
 			"delay", delay,
+			"server_delay", fromServer,
 			"error", err)
 
 		// Wait for the delay or context cancellation
@@ -79,6 +93,11 @@ func RetryWithResult[T any](ctx context.Context, config RetryConfig, fn func() (
 	return zero, lastErr
 }
 
+// IsRetryable reports whether err is a transient failure: a rate limit, server or network error
+func IsRetryable(err error) bool {
+	return isRetryableError(err)
+}
+
 // isRetryableError determines if an error should be retried
 func isRetryableError(err error) bool {
 	if err == nil {
@@ -89,6 +108,9 @@ func isRetryableError(err error) bool {
 	var openaiErr *openai.Error
 	if errors.As(err, &openaiErr) {
 		// Rate limits and server errors are retryable
+		if openaiErr.StatusCode == http.StatusTooManyRequests || openaiErr.StatusCode >= 500 {
+			return true
+		}
 		errorStr := openaiErr.Error()
 		return strings.Contains(errorStr, "rate limit") ||
 			strings.Contains(errorStr, "server") ||
@@ -0,0 +1,130 @@
+package rollout
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/gorilla/mux"
+)
+
+// defaultListLimit is the number of rollouts listed without a limit
+const defaultListLimit = 20
+
+// AdminHandler starts, lists and rolls back system prompt rollouts over HTTP
+// It must be mounted behind admin.Authenticator; writes require RoleOperator
+type AdminHandler struct {
+	service *Service
+}
+
+// NewAdminHandler creates a new prompt rollout admin handler
+func NewAdminHandler(service *Service) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+// RollbackRequest is the body of manual rollbacks
+type RollbackRequest struct {
+	Reason string `json:"reason"`
+}
+
+// StartHandler handles POST /admin/prompts/rollouts
+func (h *AdminHandler) StartHandler(w http.ResponseWriter, r *http.Request) {
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	rollout, err := h.service.Start(r.Context(), req, actor(r))
+	switch {
+	case errors.Is(err, ErrInvalidRollout):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, ErrRolloutRunning):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to start prompt rollout", "candidate", req.Candidate, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to start rollout"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, rollout)
+}
+
+// ListHandler handles GET /admin/prompts/rollouts?limit=20
+func (h *AdminHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	limit := int64(defaultListLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	rollouts, err := h.service.List(r.Context(), limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list prompt rollouts", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list rollouts"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rollouts": rollouts})
+}
+
+// GetHandler handles GET /admin/prompts/rollouts/{id}
+func (h *AdminHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	rollout, err := h.service.Get(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, ErrRolloutNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "rollout not found"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get prompt rollout", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to get rollout"})
+		return
+	}
+	writeJSON(w, http.StatusOK, rollout)
+}
+
+// RollbackHandler handles POST /admin/prompts/rollouts/{id}/rollback with an optional reason
+func (h *AdminHandler) RollbackHandler(w http.ResponseWriter, r *http.Request) {
+	var req RollbackRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+			return
+		}
+	}
+
+	rollout, err := h.service.Rollback(r.Context(), mux.Vars(r)["id"], req.Reason, actor(r))
+	if errors.Is(err, ErrRolloutNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no running rollout with this ID"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to roll back prompt rollout", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to roll back"})
+		return
+	}
+	writeJSON(w, http.StatusOK, rollout)
+}
+
+// actor identifies the admin making a change in the audit log
+func actor(r *http.Request) string {
+	if p := admin.FromContext(r.Context()); p != nil {
+		return audit.Actor("admin", p.Username)
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,54 @@
+package rollout
+
+import (
+	"context"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+// VariantStore aggregates assistant replies per variant, see model.Repository
+type VariantStore interface {
+	CompareVariants(ctx context.Context, f model.ConversationFilter) ([]*model.VariantStats, error)
+}
+
+// FailureCounter counts the replies that failed, see billing.Repository
+type FailureCounter interface {
+	CountFailedReplies(ctx context.Context, promptVersion string, since time.Time) (int64, error)
+}
+
+// ReplyMetrics measures a prompt version from the generations and reactions stored with replies
+// and the failed replies recorded with usage
+type ReplyMetrics struct {
+	Variants VariantStore
+	Failures FailureCounter
+}
+
+// VersionMetrics measures the replies in the context tenant's conversations pinned to a version, created since a time
+func (m ReplyMetrics) VersionMetrics(ctx context.Context, version string, since time.Time) (*Metrics, error) {
+	variants, err := m.Variants.CompareVariants(ctx, model.ConversationFilter{PromptVersion: version, CreatedAfter: since})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &Metrics{}
+	var latency float64
+	for _, v := range variants {
+		// Conversations pinned to the version may hold replies made with the fallback prompt
+		if v.PromptVersion != version {
+			continue
+		}
+		metrics.Replies += v.Replies
+		metrics.Positive += v.Positive
+		metrics.Negative += v.Negative
+		latency += v.AvgLatencyMs * float64(v.Replies)
+	}
+	if metrics.Replies > 0 {
+		metrics.AvgLatencyMs = latency / float64(metrics.Replies)
+	}
+
+	if metrics.Failed, err = m.Failures.CountFailedReplies(ctx, version, since); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
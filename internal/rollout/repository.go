@@ -0,0 +1,168 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	rolloutCollection = "prompt_rollouts"
+	promptCollection  = "prompt_configs" // Read by assistant.PromptManager
+)
+
+// MongoRepository stores rollouts in MongoDB and switches the active system prompt version of a tenant
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB rollout repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+// CreateRollout stores a new rollout; ErrRolloutRunning when the tenant already runs one
+func (r *MongoRepository) CreateRollout(ctx context.Context, rollout *Rollout) error {
+	running, err := r.RunningRollout(ctx)
+	if err != nil {
+		return err
+	}
+	if running != nil {
+		return ErrRolloutRunning
+	}
+	_, err = r.conn.Collection(rolloutCollection).InsertOne(ctx, rollout)
+	return err
+}
+
+// GetRollout returns a rollout of the context's tenant
+func (r *MongoRepository) GetRollout(ctx context.Context, id string) (*Rollout, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrRolloutNotFound
+	}
+	var rollout Rollout
+	err = r.conn.Collection(rolloutCollection).FindOne(ctx,
+		bson.M{"_id": oid, "tenant_id": tenant.FromContext(ctx)}).Decode(&rollout)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRolloutNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rollout, nil
+}
+
+// ListRollouts returns the latest rollouts of the context's tenant, newest first
+func (r *MongoRepository) ListRollouts(ctx context.Context, limit int64) ([]*Rollout, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	return r.find(ctx, bson.M{"tenant_id": tenant.FromContext(ctx)}, opts)
+}
+
+// RunningRollout returns the running rollout of the context's tenant, nil when there is none
+func (r *MongoRepository) RunningRollout(ctx context.Context) (*Rollout, error) {
+	var rollout Rollout
+	err := r.conn.Collection(rolloutCollection).FindOne(ctx,
+		bson.M{"tenant_id": tenant.FromContext(ctx), "status": StatusRunning}).Decode(&rollout)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rollout, nil
+}
+
+// PendingRollouts returns the running and unsettled rolled back rollouts of every tenant
+func (r *MongoRepository) PendingRollouts(ctx context.Context) ([]*Rollout, error) {
+	return r.find(ctx, bson.M{"$or": bson.A{
+		bson.M{"status": StatusRunning},
+		bson.M{"status": StatusRolledBack, "settled": bson.M{"$ne": true}},
+	}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+}
+
+// UpdateRollout replaces a rollout that still has the status; ErrRolloutNotFound when it changed meanwhile
+func (r *MongoRepository) UpdateRollout(ctx context.Context, rollout *Rollout, status string) error {
+	res, err := r.conn.Collection(rolloutCollection).ReplaceOne(ctx,
+		bson.M{"_id": rollout.ID, "tenant_id": rollout.TenantID, "status": status}, rollout)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrRolloutNotFound
+	}
+	return nil
+}
+
+func (r *MongoRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*Rollout, error) {
+	cursor, err := r.conn.Collection(rolloutCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	rollouts := []*Rollout{}
+	if err := cursor.All(ctx, &rollouts); err != nil {
+		return nil, err
+	}
+	return rollouts, nil
+}
+
+// promptScope selects the system prompts a tenant rolls out: its own overrides, or the shared prompts
+// for the default tenant, so a tenant's rollout never changes the prompts of other tenants
+func promptScope(ctx context.Context, filter bson.M) bson.M {
+	filter["name"] = model.PromptNameSystemPrompt
+	if tenant.IsDefault(ctx) {
+		filter["tenant_id"] = bson.M{"$exists": false}
+	} else {
+		filter["tenant_id"] = tenant.FromContext(ctx)
+	}
+	return filter
+}
+
+// ActivePromptVersion returns the active system prompt version for all platforms and segments, empty when there is none
+func (r *MongoRepository) ActivePromptVersion(ctx context.Context) (string, error) {
+	var prompt model.PromptConfig
+	err := r.conn.Collection(promptCollection).FindOne(ctx,
+		promptScope(ctx, bson.M{
+			"is_active":    true,
+			"platform":     model.DefaultPlatform,
+			"user_segment": model.DefaultUserSegment,
+		}),
+		options.FindOne().SetSort(bson.D{{Key: "updated_at", Value: -1}})).Decode(&prompt)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return prompt.Version, nil
+}
+
+// PromptVersionExists reports whether the tenant has a system prompt of the version
+func (r *MongoRepository) PromptVersionExists(ctx context.Context, version string) (bool, error) {
+	n, err := r.conn.Collection(promptCollection).CountDocuments(ctx,
+		promptScope(ctx, bson.M{"version": version}), options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// ActivatePromptVersion makes the prompts of a version active in place of those of another
+// The new version is activated first, so there is always an active prompt to serve
+func (r *MongoRepository) ActivatePromptVersion(ctx context.Context, from, to string) error {
+	coll := r.conn.Collection(promptCollection)
+	now := time.Now()
+	if _, err := coll.UpdateMany(ctx, promptScope(ctx, bson.M{"version": to}),
+		bson.M{"$set": bson.M{"is_active": true, "updated_at": now}}); err != nil {
+		return err
+	}
+	_, err := coll.UpdateMany(ctx, promptScope(ctx, bson.M{"version": from}),
+		bson.M{"$set": bson.M{"is_active": false, "updated_at": now}})
+	return err
+}
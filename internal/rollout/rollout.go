@@ -0,0 +1,176 @@
+package rollout
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rollout statuses
+const (
+	StatusRunning    = "running"
+	StatusPromoted   = "promoted"    // The candidate became the active version
+	StatusRolledBack = "rolled_back" // Conversations given the candidate were moved back to the baseline
+)
+
+var (
+	// ErrInvalidRollout is wrapped by validation errors
+	ErrInvalidRollout = errors.New("invalid rollout")
+	// ErrRolloutNotFound is returned when a rollout does not exist in the context's tenant or is no longer running
+	ErrRolloutNotFound = errors.New("rollout not found")
+	// ErrRolloutRunning is returned when the tenant already rolls out a system prompt version
+	ErrRolloutRunning = errors.New("a prompt rollout is already running")
+)
+
+// Thresholds are the limits the candidate's replies must stay within; zero fields are not checked
+type Thresholds struct {
+	MinReplies      int64   `json:"min_replies" bson:"min_replies"`             // Candidate replies needed before it is judged
+	MaxErrorRate    float64 `json:"max_error_rate" bson:"max_error_rate"`       // Share of candidate replies that failed
+	MaxNegativeRate float64 `json:"max_negative_rate" bson:"max_negative_rate"` // Share of negative among rated replies
+	// MaxLatencyIncrease is how much slower than the baseline's the candidate's average latency may be, 0.5 for 50%
+	MaxLatencyIncrease float64 `json:"max_latency_increase" bson:"max_latency_increase"`
+}
+
+// Metrics are the replies of one prompt version since a rollout started
+type Metrics struct {
+	Replies      int64   `json:"replies" bson:"replies"`
+	Failed       int64   `json:"failed" bson:"failed"`
+	Positive     int64   `json:"positive" bson:"positive"`
+	Negative     int64   `json:"negative" bson:"negative"`
+	AvgLatencyMs float64 `json:"avg_latency_ms" bson:"avg_latency_ms"`
+}
+
+// ErrorRate is the share of failed among all replies; 0 without any
+func (m Metrics) ErrorRate() float64 {
+	if total := m.Replies + m.Failed; total > 0 {
+		return float64(m.Failed) / float64(total)
+	}
+	return 0
+}
+
+// NegativeRate is the share of negative among positive and negative reactions; 0 without any
+func (m Metrics) NegativeRate() float64 {
+	if rated := m.Positive + m.Negative; rated > 0 {
+		return float64(m.Negative) / float64(rated)
+	}
+	return 0
+}
+
+// Rollout serves a candidate system prompt version to a growing share of new conversations,
+// watching its replies against the baseline version the other conversations keep
+type Rollout struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	TenantID       string             `json:"tenant_id" bson:"tenant_id"`
+	Baseline       string             `json:"baseline_version" bson:"baseline_version"`
+	Candidate      string             `json:"candidate_version" bson:"candidate_version"`
+	Stages         []int              `json:"stages" bson:"stages"` // Percentages of new conversations given the candidate
+	Stage          int                `json:"stage" bson:"stage"`   // Index of the current stage
+	StageMinutes   int                `json:"stage_minutes" bson:"stage_minutes"`
+	StageStartedAt time.Time          `json:"stage_started_at" bson:"stage_started_at"`
+	Thresholds     Thresholds         `json:"thresholds" bson:"thresholds"`
+	Status         string             `json:"status" bson:"status"`
+	Reason         string             `json:"reason,omitempty" bson:"reason,omitempty"` // Why the rollout was rolled back
+	Actor          string             `json:"actor,omitempty" bson:"actor,omitempty"`   // Admin who started it
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+	EndedAt        time.Time          `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+
+	// Settled is set on a rolled back rollout once no instance can still give the candidate to new
+	// conversations and every conversation given it was moved back to the baseline
+	Settled bool `json:"settled,omitempty" bson:"settled,omitempty"`
+
+	// Metrics of both versions at the last evaluation
+	CandidateMetrics *Metrics `json:"candidate_metrics,omitempty" bson:"candidate_metrics,omitempty"`
+	BaselineMetrics  *Metrics `json:"baseline_metrics,omitempty" bson:"baseline_metrics,omitempty"`
+}
+
+// Percent returns the share of new conversations given the candidate at the current stage
+func (r *Rollout) Percent() int {
+	if r.Stage < 0 || r.Stage >= len(r.Stages) {
+		return 0
+	}
+	return r.Stages[r.Stage]
+}
+
+// Serves reports whether a new conversation is given the candidate
+// Conversations are bucketed by a hash of the rollout and conversation IDs, so a conversation given
+// the candidate at one stage is also given it at the later, larger stages
+func (r *Rollout) Serves(conversationID string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(r.ID.Hex() + ":" + conversationID))
+	return int(h.Sum32()%100) < r.Percent()
+}
+
+// Verdict is the outcome of an evaluation
+type Verdict string
+
+const (
+	VerdictHold     Verdict = "hold"     // Not enough replies yet, or the stage has not run long enough
+	VerdictAdvance  Verdict = "advance"  // Move to the next stage
+	VerdictPromote  Verdict = "promote"  // The last stage passed
+	VerdictRollback Verdict = "rollback" // A threshold was breached
+)
+
+// Evaluate judges the candidate's replies against the thresholds and the baseline's replies
+// Thresholds are checked once the candidate has MinReplies replies; a healthy stage advances after StageMinutes
+func Evaluate(r *Rollout, candidate, baseline Metrics, now time.Time) (Verdict, string) {
+	t := r.Thresholds
+	if candidate.Replies+candidate.Failed < t.MinReplies {
+		return VerdictHold, ""
+	}
+	if t.MaxErrorRate > 0 && candidate.ErrorRate() > t.MaxErrorRate {
+		return VerdictRollback, fmt.Sprintf("error rate %.3f above %.3f", candidate.ErrorRate(), t.MaxErrorRate)
+	}
+	if t.MaxNegativeRate > 0 && candidate.NegativeRate() > t.MaxNegativeRate {
+		return VerdictRollback, fmt.Sprintf("negative feedback rate %.3f above %.3f", candidate.NegativeRate(), t.MaxNegativeRate)
+	}
+	if t.MaxLatencyIncrease > 0 && baseline.Replies > 0 && baseline.AvgLatencyMs > 0 {
+		if limit := baseline.AvgLatencyMs * (1 + t.MaxLatencyIncrease); candidate.AvgLatencyMs > limit {
+			return VerdictRollback, fmt.Sprintf("average latency %.0fms above %.0fms", candidate.AvgLatencyMs, limit)
+		}
+	}
+
+	if now.Sub(r.StageStartedAt) < time.Duration(r.StageMinutes)*time.Minute {
+		return VerdictHold, ""
+	}
+	if r.Stage >= len(r.Stages)-1 {
+		return VerdictPromote, ""
+	}
+	return VerdictAdvance, ""
+}
+
+// ParseStages parses the stage percentages of a rollout, e.g. "5", "25", "100"
+// Percentages must grow and end at 100, where the candidate is promoted
+func ParseStages(values []string) ([]int, error) {
+	stages := make([]int, 0, len(values))
+	for _, v := range values {
+		percent, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("stage %q is not a percentage", v)
+		}
+		stages = append(stages, percent)
+	}
+	return stages, validateStages(stages)
+}
+
+func validateStages(stages []int) error {
+	if len(stages) == 0 {
+		return errors.New("at least one stage is required")
+	}
+	prev := 0
+	for _, percent := range stages {
+		if percent <= prev || percent > 100 {
+			return fmt.Errorf("stages %v must grow between 1 and 100", stages)
+		}
+		prev = percent
+	}
+	if prev != 100 {
+		return fmt.Errorf("stages %v must end at 100", stages)
+	}
+	return nil
+}
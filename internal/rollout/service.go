@@ -0,0 +1,393 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the audit log, with the rollout ID as target
+const (
+	ActionStarted    = "prompt_rollout.started"     // After is the candidate version and first percentage
+	ActionAdvanced   = "prompt_rollout.advanced"    // Before and After are the percentages
+	ActionPromoted   = "prompt_rollout.promoted"    // Before is the baseline version, After the candidate
+	ActionRolledBack = "prompt_rollout.rolled_back" // Before is the percentage, After the reason
+)
+
+// selectionTTL is how long instances serve a rollout's state before looking it up again
+const selectionTTL = 30 * time.Second
+
+// Store keeps rollouts and switches the active system prompt version, see MongoRepository
+type Store interface {
+	CreateRollout(ctx context.Context, r *Rollout) error
+	// GetRollout returns ErrRolloutNotFound unless the rollout belongs to the context's tenant
+	GetRollout(ctx context.Context, id string) (*Rollout, error)
+	ListRollouts(ctx context.Context, limit int64) ([]*Rollout, error)
+	// RunningRollout returns the running rollout of the context's tenant, nil when there is none
+	RunningRollout(ctx context.Context) (*Rollout, error)
+	// PendingRollouts returns the running and unsettled rolled back rollouts of every tenant
+	PendingRollouts(ctx context.Context) ([]*Rollout, error)
+	// UpdateRollout returns ErrRolloutNotFound unless the stored rollout still has the status
+	UpdateRollout(ctx context.Context, r *Rollout, status string) error
+	ActivePromptVersion(ctx context.Context) (string, error)
+	PromptVersionExists(ctx context.Context, version string) (bool, error)
+	ActivatePromptVersion(ctx context.Context, from, to string) error
+}
+
+// MetricsSource measures the replies of a system prompt version in conversations created since a time, see ReplyMetrics
+type MetricsSource interface {
+	VersionMetrics(ctx context.Context, version string, since time.Time) (*Metrics, error)
+}
+
+// ConversationPinner moves conversations from one system prompt version to another, see model.Repository
+type ConversationPinner interface {
+	RepinPromptVersion(ctx context.Context, from, to string, since time.Time) (int64, error)
+}
+
+// Config holds the defaults of rollouts started without their own stages and thresholds
+type Config struct {
+	Stages       []int
+	StageMinutes int
+	Thresholds   Thresholds
+}
+
+// StartRequest starts the rollout of a candidate system prompt version; zero fields take the defaults
+type StartRequest struct {
+	Candidate    string      `json:"candidate_version"`
+	Baseline     string      `json:"baseline_version,omitempty"` // The active version when empty
+	Stages       []int       `json:"stages,omitempty"`
+	StageMinutes int         `json:"stage_minutes,omitempty"`
+	Thresholds   *Thresholds `json:"thresholds,omitempty"`
+}
+
+// Result counts what an evaluation run did
+type Result struct {
+	Evaluated  int `json:"evaluated"`
+	Advanced   int `json:"advanced"`
+	Promoted   int `json:"promoted"`
+	RolledBack int `json:"rolled_back"`
+	Settled    int `json:"settled"`
+	Failed     int `json:"failed"`
+}
+
+// Service stages system prompt rollouts and rolls them back when their replies breach the thresholds
+type Service struct {
+	store         Store
+	metrics       MetricsSource
+	conversations ConversationPinner
+	audit         audit.Recorder
+	cfg           Config
+	selections    *redisx.MemoryCache
+}
+
+// NewService creates a new prompt rollout service
+func NewService(store Store, metrics MetricsSource, conversations ConversationPinner, recorder audit.Recorder, cfg Config) *Service {
+	return &Service{
+		store:         store,
+		metrics:       metrics,
+		conversations: conversations,
+		audit:         recorder,
+		cfg:           cfg,
+		selections:    redisx.NewMemoryCache(selectionTTL, 0),
+	}
+}
+
+// Start starts rolling out a candidate version in the context's tenant; actor is recorded in the audit log
+func (s *Service) Start(ctx context.Context, req StartRequest, actor string) (*Rollout, error) {
+	now := time.Now()
+	r := &Rollout{
+		ID:           primitive.NewObjectID(),
+		TenantID:     tenant.FromContext(ctx),
+		Candidate:    req.Candidate,
+		Baseline:     req.Baseline,
+		Stages:       req.Stages,
+		StageMinutes: req.StageMinutes,
+		Thresholds:   s.cfg.Thresholds,
+		Status:       StatusRunning,
+		Actor:        actor,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	r.StageStartedAt = now
+	if len(r.Stages) == 0 {
+		r.Stages = s.cfg.Stages
+	}
+	if r.StageMinutes == 0 {
+		r.StageMinutes = s.cfg.StageMinutes
+	}
+	if req.Thresholds != nil {
+		r.Thresholds = *req.Thresholds
+	}
+	if err := s.validate(ctx, r); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.CreateRollout(ctx, r); err != nil {
+		return nil, err
+	}
+	s.record(ctx, &audit.Entry{
+		Action: ActionStarted,
+		Actor:  actor,
+		Target: r.ID.Hex(),
+		Before: r.Baseline,
+		After:  fmt.Sprintf("%s at %d%%", r.Candidate, r.Percent()),
+	})
+	slog.InfoContext(ctx, "Prompt rollout started",
+		"rollout_id", r.ID.Hex(), "baseline", r.Baseline, "candidate", r.Candidate, "percent", r.Percent())
+	return r, nil
+}
+
+func (s *Service) validate(ctx context.Context, r *Rollout) error {
+	if r.Candidate == "" {
+		return fmt.Errorf("%w: candidate_version is required", ErrInvalidRollout)
+	}
+	if err := validateStages(r.Stages); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRollout, err)
+	}
+	if r.StageMinutes < 0 {
+		return fmt.Errorf("%w: stage_minutes must not be negative", ErrInvalidRollout)
+	}
+	t := r.Thresholds
+	if t.MinReplies < 0 || t.MaxErrorRate < 0 || t.MaxNegativeRate < 0 || t.MaxLatencyIncrease < 0 {
+		return fmt.Errorf("%w: thresholds must not be negative", ErrInvalidRollout)
+	}
+
+	if r.Baseline == "" {
+		active, err := s.store.ActivePromptVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if active == "" {
+			return fmt.Errorf("%w: there is no active system prompt to roll out against", ErrInvalidRollout)
+		}
+		r.Baseline = active
+	}
+	if r.Baseline == r.Candidate {
+		return fmt.Errorf("%w: candidate_version is the baseline version", ErrInvalidRollout)
+	}
+	for _, version := range []string{r.Candidate, r.Baseline} {
+		exists, err := s.store.PromptVersionExists(ctx, version)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: system prompt version %q does not exist", ErrInvalidRollout, version)
+		}
+	}
+	return nil
+}
+
+// Get returns a rollout of the context's tenant
+func (s *Service) Get(ctx context.Context, id string) (*Rollout, error) {
+	return s.store.GetRollout(ctx, id)
+}
+
+// List returns the latest rollouts of the context's tenant, newest first
+func (s *Service) List(ctx context.Context, limit int64) ([]*Rollout, error) {
+	return s.store.ListRollouts(ctx, limit)
+}
+
+// Rollback stops a running rollout of the context's tenant by hand
+func (s *Service) Rollback(ctx context.Context, id, reason, actor string) (*Rollout, error) {
+	r, err := s.store.GetRollout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != StatusRunning {
+		return nil, ErrRolloutNotFound
+	}
+	if reason == "" {
+		reason = "rolled back by hand"
+	}
+	if err := s.rollback(ctx, r, reason, actor, time.Now()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Select returns the candidate version when a new conversation of the context's tenant is given it
+// Rollouts are looked up at most every selectionTTL per instance; lookup failures serve the active version
+func (s *Service) Select(ctx context.Context, conversationID string) (string, bool) {
+	var cached struct{ Rollout *Rollout }
+	if err := s.selections.Get(ctx, "running", &cached); err != nil {
+		r, err := s.store.RunningRollout(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to look up prompt rollout, serving the active version", "error", err)
+			return "", false
+		}
+		cached.Rollout = r
+		_ = s.selections.Set(ctx, "running", cached)
+	}
+	if cached.Rollout == nil || !cached.Rollout.Serves(conversationID) {
+		return "", false
+	}
+	return cached.Rollout.Candidate, true
+}
+
+// Evaluate judges every running rollout, advancing, promoting or rolling them back, and settles rollbacks
+// A rollout that cannot be evaluated is counted as failed and left for the next run
+func (s *Service) Evaluate(ctx context.Context, now time.Time) (*Result, error) {
+	rollouts, err := s.store.PendingRollouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var errs []error
+	for _, r := range rollouts {
+		rolloutCtx := tenant.WithTenant(ctx, r.TenantID)
+		if r.Status == StatusRolledBack {
+			settled, err := s.settle(rolloutCtx, r, now)
+			if err != nil {
+				result.Failed++
+				errs = append(errs, fmt.Errorf("rollout %s: %w", r.ID.Hex(), err))
+			} else if settled {
+				result.Settled++
+			}
+			continue
+		}
+
+		verdict, err := s.evaluate(rolloutCtx, r, now)
+		if err != nil {
+			result.Failed++
+			errs = append(errs, fmt.Errorf("rollout %s: %w", r.ID.Hex(), err))
+			continue
+		}
+		result.Evaluated++
+		switch verdict {
+		case VerdictAdvance:
+			result.Advanced++
+		case VerdictPromote:
+			result.Promoted++
+		case VerdictRollback:
+			result.RolledBack++
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+func (s *Service) evaluate(ctx context.Context, r *Rollout, now time.Time) (Verdict, error) {
+	candidate, err := s.metrics.VersionMetrics(ctx, r.Candidate, r.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+	baseline, err := s.metrics.VersionMetrics(ctx, r.Baseline, r.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+	r.CandidateMetrics, r.BaselineMetrics = candidate, baseline
+
+	verdict, reason := Evaluate(r, *candidate, *baseline, now)
+	switch verdict {
+	case VerdictRollback:
+		err = s.rollback(ctx, r, reason, "", now)
+	case VerdictPromote:
+		err = s.promote(ctx, r, now)
+	case VerdictAdvance:
+		err = s.advance(ctx, r, now)
+	default:
+		r.UpdatedAt = now
+		err = s.store.UpdateRollout(ctx, r, StatusRunning)
+	}
+	return verdict, err
+}
+
+func (s *Service) advance(ctx context.Context, r *Rollout, now time.Time) error {
+	from := r.Percent()
+	r.Stage++
+	r.StageStartedAt, r.UpdatedAt = now, now
+	if err := s.store.UpdateRollout(ctx, r, StatusRunning); err != nil {
+		return err
+	}
+	s.record(ctx, &audit.Entry{
+		Action: ActionAdvanced,
+		Target: r.ID.Hex(),
+		Before: strconv.Itoa(from) + "%",
+		After:  strconv.Itoa(r.Percent()) + "%",
+	})
+	slog.InfoContext(ctx, "Prompt rollout advanced", "rollout_id", r.ID.Hex(), "candidate", r.Candidate, "percent", r.Percent())
+	return nil
+}
+
+// promote makes the candidate the active version; conversations pinned to the baseline keep it
+func (s *Service) promote(ctx context.Context, r *Rollout, now time.Time) error {
+	if err := s.store.ActivatePromptVersion(ctx, r.Baseline, r.Candidate); err != nil {
+		return err
+	}
+	r.Status = StatusPromoted
+	r.UpdatedAt, r.EndedAt = now, now
+	if err := s.store.UpdateRollout(ctx, r, StatusRunning); err != nil {
+		return err
+	}
+	s.record(ctx, &audit.Entry{
+		Action: ActionPromoted,
+		Target: r.ID.Hex(),
+		Before: r.Baseline,
+		After:  r.Candidate,
+	})
+	slog.InfoContext(ctx, "Prompt rollout promoted", "rollout_id", r.ID.Hex(), "baseline", r.Baseline, "candidate", r.Candidate)
+	return nil
+}
+
+// rollback stops serving the candidate and moves the conversations given it back to the baseline
+// The rollout is marked first, so conversations are no longer given the candidate while they are moved;
+// instances that had not looked it up again yet are caught when the rollback is settled
+func (s *Service) rollback(ctx context.Context, r *Rollout, reason, actor string, now time.Time) error {
+	percent := r.Percent()
+	r.Status, r.Reason = StatusRolledBack, reason
+	r.UpdatedAt, r.EndedAt = now, now
+	if err := s.store.UpdateRollout(ctx, r, StatusRunning); err != nil {
+		return err
+	}
+	s.record(ctx, &audit.Entry{
+		Action: ActionRolledBack,
+		Actor:  actor,
+		Target: r.ID.Hex(),
+		Before: strconv.Itoa(percent) + "%",
+		After:  reason,
+	})
+	slog.WarnContext(ctx, "Prompt rollout rolled back",
+		"rollout_id", r.ID.Hex(), "candidate", r.Candidate, "percent", percent, "reason", reason)
+
+	return s.repin(ctx, r)
+}
+
+// settle moves the conversations given the candidate back once no instance can still be serving it
+func (s *Service) settle(ctx context.Context, r *Rollout, now time.Time) (bool, error) {
+	if now.Sub(r.EndedAt) < selectionTTL {
+		return false, nil
+	}
+	if err := s.repin(ctx, r); err != nil {
+		return false, err
+	}
+	r.Settled, r.UpdatedAt = true, now
+	return true, s.store.UpdateRollout(ctx, r, StatusRolledBack)
+}
+
+func (s *Service) repin(ctx context.Context, r *Rollout) error {
+	moved, err := s.conversations.RepinPromptVersion(ctx, r.Candidate, r.Baseline, r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("move conversations back to %s: %w", r.Baseline, err)
+	}
+	slog.InfoContext(ctx, "Conversations moved back to the baseline prompt", "rollout_id", r.ID.Hex(), "conversations", moved)
+	return nil
+}
+
+// record stores an audit entry; a failure is logged but never undoes the change already made
+func (s *Service) record(ctx context.Context, entry *audit.Entry) {
+	if s.audit == nil {
+		return
+	}
+	if err := s.audit.Record(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "Failed to audit prompt rollout",
+			"action", entry.Action, "target", entry.Target, "error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrKeyRevoked is returned when data is sealed with keys that were destroyed
+var ErrKeyRevoked = errors.New("encryption keys were revoked")
+
+// KMS wraps data keys with a master key that never leaves it
+type KMS interface {
+	// KeyID identifies the master key, so wrapped keys record which one can unwrap them
+	KeyID() string
+	Wrap(ctx context.Context, key []byte) (string, error)
+	Unwrap(ctx context.Context, wrapped string) ([]byte, error)
+}
+
+// LocalKMS wraps data keys with a master key held by the process
+type LocalKMS struct {
+	id     string
+	cipher *Cipher
+}
+
+// NewLocalKMS creates a KMS from a base64-encoded 32-byte master key
+// Its key ID is a fingerprint of the master key
+func NewLocalKMS(encodedKey string) (*LocalKMS, error) {
+	cipher, err := NewCipherFromBase64(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := base64.StdEncoding.DecodeString(encodedKey)
+	sum := sha256.Sum256(key)
+	return &LocalKMS{id: "local:" + hex.EncodeToString(sum[:4]), cipher: cipher}, nil
+}
+
+func (k *LocalKMS) KeyID() string {
+	return k.id
+}
+
+func (k *LocalKMS) Wrap(ctx context.Context, key []byte) (string, error) {
+	return k.cipher.Encrypt(base64.StdEncoding.EncodeToString(key))
+}
+
+func (k *LocalKMS) Unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	encoded, err := k.cipher.Decrypt(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return key, nil
+}
+
+// NewKey returns a random key of KeySize bytes
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
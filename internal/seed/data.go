@@ -0,0 +1,125 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+)
+
+// script is the user side of a demo conversation
+type script struct {
+	tag       string
+	questions []string
+}
+
+var scripts = []script{
+	{"weather", []string{
+		"What's the weather like in Barcelona today?",
+		"Will it rain tomorrow?",
+		"Should I bring a jacket in the evening?",
+	}},
+	{"weather", []string{
+		"What's the forecast for Madrid this weekend?",
+		"And the temperature on Sunday?",
+	}},
+	{"holidays", []string{
+		"When is the next public holiday in Catalonia?",
+		"Are shops open on that day?",
+	}},
+	{"holidays", []string{
+		"How many holidays are left this year?",
+		"Is there a long weekend coming up?",
+		"I want to plan a vacation around it, any tips?",
+	}},
+	{"datetime", []string{
+		"What day is it today?",
+		"What's the date next Friday?",
+	}},
+	{"cooking", []string{
+		"What can I cook for dinner with pasta and tomatoes?",
+		"Any vegetarian recipe that takes less than 20 minutes?",
+	}},
+	{"travel", []string{
+		"I'm planning a trip to Lisbon, what should I visit?",
+		"Which neighbourhood is best for a hotel?",
+		"What's the weather like there in October?",
+	}},
+	{"general", []string{
+		"How do I get started with learning Go?",
+		"What's a good first project?",
+	}},
+	{"general", []string{
+		"Can you help me write a polite email asking for a deadline extension?",
+	}},
+}
+
+// persona is a system prompt variant selected by setting the persona of a conversation
+type persona struct {
+	name    string
+	content string
+}
+
+var personas = []persona{
+	{"concise", `You are a helpful AI assistant that answers in as few words as possible.
+Prefer a single sentence or a short list. Never pad answers with pleasantries.
+
+USER QUESTION:`},
+	{"friendly", `You are a warm, friendly AI assistant. Answer clearly and accurately, with a cheerful tone,
+and suggest a helpful next step when it fits.
+
+USER QUESTION:`},
+	{"expert", `You are an expert AI assistant. Give precise, well-structured answers, state assumptions,
+and mention trade-offs when there are several options.
+
+USER QUESTION:`},
+}
+
+var (
+	platforms = []string{"telegram", "web", "api"}
+	languages = []string{"", "en", "es", "de", "ca"}
+	timezones = []string{"", "Europe/Madrid", "Europe/London", "America/New_York"}
+	units     = []string{"", settings.UnitsMetric, settings.UnitsImperial}
+	verbosity = []string{"", settings.VerbosityConcise, settings.VerbosityNormal, settings.VerbosityDetailed}
+)
+
+// firstTelegramID is the ID of the first demo Telegram user; Telegram user IDs are numeric
+const firstTelegramID = 100000001
+
+// newUser returns the settings of the i-th demo user
+func newUser(rng *rand.Rand, i int, now time.Time) *settings.Settings {
+	platform := platforms[i%len(platforms)]
+	userID := fmt.Sprintf("demo-user-%03d", i+1)
+	if platform == "telegram" {
+		userID = fmt.Sprint(firstTelegramID + i)
+	}
+	return &settings.Settings{
+		Platform:  platform,
+		UserID:    userID,
+		Language:  languages[rng.IntN(len(languages))],
+		Units:     units[rng.IntN(len(units))],
+		Timezone:  timezones[rng.IntN(len(timezones))],
+		Verbosity: verbosity[rng.IntN(len(verbosity))],
+		UpdatedAt: now,
+	}
+}
+
+// Prompts returns the default prompts and a system prompt for each demo persona
+func Prompts(now time.Time) []model.PromptConfig {
+	prompts := model.GetDefaultPromptConfigs()
+	for _, p := range personas {
+		prompts = append(prompts, model.PromptConfig{
+			Name:        model.PromptNameSystemPrompt,
+			Version:     "v1",
+			Content:     p.content,
+			IsActive:    true,
+			Platform:    model.DefaultPlatform,
+			UserSegment: p.name,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+	return prompts
+}
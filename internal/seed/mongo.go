@@ -0,0 +1,57 @@
+package seed
+
+import (
+	"context"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const promptCollection = "prompt_configs"
+
+// MongoPromptStore stores prompts in the collection read by assistant.PromptManager
+type MongoPromptStore struct {
+	conn *mongo.Database
+}
+
+// NewMongoPromptStore creates a new MongoDB prompt store
+func NewMongoPromptStore(conn *mongo.Database) *MongoPromptStore {
+	return &MongoPromptStore{conn: conn}
+}
+
+func (s *MongoPromptStore) UpsertPrompt(ctx context.Context, p *model.PromptConfig) error {
+	filter := bson.M{
+		"name":         p.Name,
+		"version":      p.Version,
+		"platform":     p.Platform,
+		"user_segment": p.UserSegment,
+		"locale":       bson.M{"$in": bson.A{nil, p.Locale}},
+		"tenant_id":    bson.M{"$in": bson.A{nil, p.TenantID}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"content":          p.Content,
+			"fallback_content": p.FallbackContent,
+			"is_active":        p.IsActive,
+			"updated_at":       p.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": p.CreatedAt,
+		},
+	}
+	if p.Locale != "" {
+		update["$set"].(bson.M)["locale"] = p.Locale
+	}
+	if p.TenantID != "" {
+		update["$set"].(bson.M)["tenant_id"] = p.TenantID
+	}
+	if p.Params != nil {
+		update["$set"].(bson.M)["params"] = p.Params
+	}
+	_, err := s.conn.Collection(promptCollection).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,205 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DemoTag marks seeded conversations so they can be removed with Reset
+const DemoTag = "demo"
+
+// Assistant answers the seeded conversations, see assistant.Mock
+type Assistant interface {
+	Title(ctx context.Context, conv *model.Conversation) (string, error)
+	Reply(ctx context.Context, conv *model.Conversation) (string, error)
+}
+
+// ConversationStore stores seeded conversations, see model.Repository
+type ConversationStore interface {
+	CreateConversation(ctx context.Context, c *model.Conversation) error
+	FindConversationRefs(ctx context.Context, f model.ConversationFilter, after primitive.ObjectID, limit int) ([]*model.Conversation, error)
+	DeleteConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error)
+}
+
+// SettingsStore stores the preferences of seeded users, see settings.MongoRepository
+type SettingsStore interface {
+	SaveSettings(ctx context.Context, s *settings.Settings) error
+}
+
+// PromptStore stores prompt configurations, see MongoPromptStore
+type PromptStore interface {
+	// UpsertPrompt creates the prompt or replaces the content of the prompt with the same
+	// name, version, platform, user segment and locale
+	UpsertPrompt(ctx context.Context, p *model.PromptConfig) error
+}
+
+// Config sizes the generated data
+type Config struct {
+	Users                int
+	ConversationsPerUser int
+	Days                 int    // Conversations start within the last Days days
+	Seed                 uint64 // Seeds the random generator, so the same seed generates the same data
+}
+
+// Summary counts the seeded records
+type Summary struct {
+	Prompts       int
+	Users         int
+	Conversations int
+	Messages      int
+}
+
+// Seeder populates the database with demo users, prompts, personas and conversations
+type Seeder struct {
+	conversations ConversationStore
+	settings      SettingsStore
+	prompts       PromptStore
+	assistant     Assistant
+	cfg           Config
+}
+
+// NewSeeder creates a seeder; the assistant writes titles and replies of the seeded conversations
+func NewSeeder(conversations ConversationStore, settings SettingsStore, prompts PromptStore, assistant Assistant, cfg Config) *Seeder {
+	if cfg.Users <= 0 {
+		cfg.Users = 20
+	}
+	if cfg.ConversationsPerUser <= 0 {
+		cfg.ConversationsPerUser = 3
+	}
+	if cfg.Days <= 0 {
+		cfg.Days = 30
+	}
+	return &Seeder{
+		conversations: conversations,
+		settings:      settings,
+		prompts:       prompts,
+		assistant:     assistant,
+		cfg:           cfg,
+	}
+}
+
+// Seed writes the prompts shared by all tenants, then users with their conversations in the tenant of the context
+// Prompts and user settings are upserted, so seeding twice only adds conversations
+func (s *Seeder) Seed(ctx context.Context, now time.Time) (*Summary, error) {
+	rng := rand.New(rand.NewPCG(s.cfg.Seed, s.cfg.Seed))
+	summary := &Summary{}
+
+	for _, p := range Prompts(now) {
+		if err := s.prompts.UpsertPrompt(ctx, &p); err != nil {
+			return summary, fmt.Errorf("failed to seed prompt %s/%s: %w", p.Name, p.UserSegment, err)
+		}
+		summary.Prompts++
+	}
+
+	for i := range s.cfg.Users {
+		user := newUser(rng, i, now)
+		user.TenantID = tenant.FromContext(ctx)
+		if err := s.settings.SaveSettings(ctx, user); err != nil {
+			return summary, fmt.Errorf("failed to seed user %s: %w", user.UserID, err)
+		}
+		summary.Users++
+
+		for range s.cfg.ConversationsPerUser {
+			conv, err := s.conversation(ctx, rng, user, now)
+			if err != nil {
+				return summary, err
+			}
+			if err := s.conversations.CreateConversation(ctx, conv); err != nil {
+				return summary, fmt.Errorf("failed to seed conversation of %s: %w", user.UserID, err)
+			}
+			summary.Conversations++
+			summary.Messages += len(conv.Messages)
+		}
+	}
+	return summary, nil
+}
+
+// conversation plays a random script against the assistant, spreading the messages over a few minutes
+func (s *Seeder) conversation(ctx context.Context, rng *rand.Rand, user *settings.Settings, now time.Time) (*model.Conversation, error) {
+	script := scripts[rng.IntN(len(scripts))]
+	at := now.Add(-time.Duration(rng.Int64N(int64(s.cfg.Days) * int64(24*time.Hour))))
+
+	conv := &model.Conversation{
+		ID:        primitive.NewObjectID(),
+		Title:     model.DefaultConversationTitle,
+		CreatedAt: at,
+		Platform:  user.Platform,
+		UserID:    user.UserID,
+		ChatID:    user.UserID,
+		IsActive:  true,
+		Tags:      []string{DemoTag, script.tag},
+		Language:  user.Language,
+	}
+	if rng.IntN(4) == 0 {
+		conv.Persona = personas[rng.IntN(len(personas))].name
+	}
+
+	for _, question := range script.questions {
+		conv.Messages = append(conv.Messages, message(model.RoleUser, question, at))
+		at = at.Add(time.Duration(5+rng.IntN(20)) * time.Second)
+
+		reply, err := s.assistant.Reply(ctx, conv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a reply: %w", err)
+		}
+		conv.Messages = append(conv.Messages, message(model.RoleAssistant, reply, at))
+		at = at.Add(time.Duration(30+rng.IntN(150)) * time.Second)
+	}
+
+	title, err := s.assistant.Title(ctx, conv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a title: %w", err)
+	}
+	conv.Title = title
+	last := conv.Messages[len(conv.Messages)-1].CreatedAt
+	conv.UpdatedAt = last
+	conv.LastActivity = last
+	return conv, nil
+}
+
+// Reset deletes the seeded conversations of the tenant of the context
+// Prompts and user settings are kept, as seeding again overwrites them
+func (s *Seeder) Reset(ctx context.Context) (int64, error) {
+	var deleted int64
+	filter := model.ConversationFilter{Tag: DemoTag}
+	for {
+		page, err := s.conversations.FindConversationRefs(ctx, filter, primitive.NilObjectID, 500)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to find demo conversations: %w", err)
+		}
+		if len(page) == 0 {
+			return deleted, nil
+		}
+
+		ids := make([]primitive.ObjectID, len(page))
+		for i, c := range page {
+			ids[i] = c.ID
+		}
+		n, err := s.conversations.DeleteConversations(ctx, ids)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete demo conversations: %w", err)
+		}
+		deleted += n
+		if n == 0 {
+			// Conversations that cannot be deleted would be found again
+			return deleted, nil
+		}
+	}
+}
+
+func message(role model.Role, content string, at time.Time) *model.Message {
+	return &model.Message{
+		ID:        primitive.NewObjectID(),
+		Role:      role,
+		Content:   content,
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+}
@@ -0,0 +1,110 @@
+package sentiment
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+// defaultSummaryLimit is the number of most negative conversations listed by default
+const defaultSummaryLimit = 20
+
+// SummaryStore aggregates conversation sentiment, see model.Repository
+type SummaryStore interface {
+	SummarizeSentiment(ctx context.Context, f model.ConversationFilter, threshold float64, limit int) (*model.SentimentSummary, error)
+}
+
+// AdminHandler exposes sentiment analytics over HTTP
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	store     SummaryStore
+	threshold float64
+}
+
+// NewAdminHandler creates a new sentiment analytics admin handler
+func NewAdminHandler(store SummaryStore, threshold float64) *AdminHandler {
+	return &AdminHandler{
+		store:     store,
+		threshold: threshold,
+	}
+}
+
+// ConversationSentiment is a negative conversation listed in the summary
+type ConversationSentiment struct {
+	ConversationID string    `json:"conversation_id"`
+	Title          string    `json:"title"`
+	Platform       string    `json:"platform,omitempty"`
+	UserID         string    `json:"user_id,omitempty"`
+	Score          float64   `json:"score"`
+	Samples        int       `json:"samples"`
+	LastActivity   time.Time `json:"last_activity"`
+}
+
+// SummaryResponse reports the sentiment of classified conversations
+type SummaryResponse struct {
+	*model.SentimentSummary
+	Threshold    float64                  `json:"threshold"`
+	MostNegative []*ConversationSentiment `json:"most_negative"`
+}
+
+// SummaryHandler handles GET /admin/analytics/sentiment?platform=telegram&topic=support&since=2024-05-01T00:00:00Z&limit=20
+func (h *AdminHandler) SummaryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.ConversationFilter{Platform: query.Get("platform"), Topic: query.Get("topic")}
+
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "since must be an RFC 3339 timestamp"})
+			return
+		}
+		filter.CreatedAfter = t
+	}
+
+	limit := defaultSummaryLimit
+	if l := query.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a non-negative number"})
+			return
+		}
+		limit = n
+	}
+
+	summary, err := h.store.SummarizeSentiment(r.Context(), filter, h.threshold, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to summarize sentiment", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to summarize sentiment"})
+		return
+	}
+
+	resp := SummaryResponse{
+		SentimentSummary: summary,
+		Threshold:        h.threshold,
+		MostNegative:     make([]*ConversationSentiment, 0, len(summary.MostNegative)),
+	}
+	for _, conv := range summary.MostNegative {
+		resp.MostNegative = append(resp.MostNegative, &ConversationSentiment{
+			ConversationID: conv.ID.Hex(),
+			Title:          conv.Title,
+			Platform:       conv.Platform,
+			UserID:         conv.UserID,
+			Score:          conv.SentimentScore,
+			Samples:        conv.SentimentSamples,
+			LastActivity:   conv.LastActivity,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
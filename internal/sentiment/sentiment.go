@@ -0,0 +1,158 @@
+package sentiment
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Result is the classified sentiment of a message
+type Result struct {
+	Sentiment string  `json:"sentiment"` // model.SentimentPositive, model.SentimentNegative or model.SentimentNeutral
+	Score     float64 `json:"score"`     // From -1 (negative) to 1 (positive)
+}
+
+// Classifier labels the sentiment of a message
+type Classifier interface {
+	Classify(ctx context.Context, text string) (Result, error)
+}
+
+// Store records message sentiment and maintains the conversation's moving average, see model.Repository
+type Store interface {
+	// RecordMessageSentiment returns the conversation score before and after the update
+	RecordMessageSentiment(ctx context.Context, conversationID, messageID primitive.ObjectID, sentiment string, score, alpha float64) (float64, float64, error)
+}
+
+// Recorder exports sentiment for dashboards and alerting, see metrics.Metrics
+type Recorder interface {
+	RecordMessageSentiment(ctx context.Context, platform, sentiment string)
+	RecordNegativeConversation(ctx context.Context, platform string)
+}
+
+// Config controls sampling and when a conversation counts as negative
+type Config struct {
+	SampleRate     float64 // Share of user messages classified, from 0 to 1
+	Alpha          float64 // Weight of the newest message in the conversation's moving average
+	AlertThreshold float64 // Conversations whose average falls below this turn negative
+	QueueSize      int     // Messages waiting to be classified before new ones are dropped
+}
+
+type observation struct {
+	tenantID       string
+	platform       string
+	conversationID primitive.ObjectID
+	messageID      primitive.ObjectID
+	text           string
+}
+
+// Tracker classifies sampled user messages in the background
+type Tracker struct {
+	classifier Classifier
+	store      Store
+	recorder   Recorder
+	cfg        Config
+	queue      chan observation
+}
+
+// NewTracker creates a sentiment tracker; recorder may be nil
+func NewTracker(classifier Classifier, store Store, recorder Recorder, cfg Config) *Tracker {
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+		cfg.Alpha = 0.3
+	}
+	if cfg.AlertThreshold == 0 {
+		cfg.AlertThreshold = -0.4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+
+	return &Tracker{
+		classifier: classifier,
+		store:      store,
+		recorder:   recorder,
+		cfg:        cfg,
+		queue:      make(chan observation, cfg.QueueSize),
+	}
+}
+
+// Threshold returns the score below which conversations count as negative
+func (t *Tracker) Threshold() float64 {
+	return t.cfg.AlertThreshold
+}
+
+// Observe samples a user message of the conversation for classification
+// Never blocks; returns whether the message was queued
+func (t *Tracker) Observe(conv *model.Conversation, msg *model.Message) bool {
+	if msg.Role != model.RoleUser || msg.Content == "" || rand.Float64() >= t.cfg.SampleRate {
+		return false
+	}
+
+	select {
+	case t.queue <- observation{
+		tenantID:       conv.TenantID,
+		platform:       conv.Platform,
+		conversationID: conv.ID,
+		messageID:      msg.ID,
+		text:           msg.Content,
+	}:
+		return true
+	default:
+		slog.Warn("Sentiment queue is full, skipping message", "conversation_id", conv.ID.Hex())
+		return false
+	}
+}
+
+// Run classifies queued messages until the context is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Sentiment tracker started", "sample_rate", t.cfg.SampleRate)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Sentiment tracker stopped", "pending", len(t.queue))
+			return
+		case obs := <-t.queue:
+			t.process(tenant.WithTenant(ctx, obs.tenantID), obs)
+		}
+	}
+}
+
+func (t *Tracker) process(ctx context.Context, obs observation) {
+	result, err := t.classifier.Classify(ctx, obs.text)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to classify message sentiment",
+			"conversation_id", obs.conversationID.Hex(), "error", err)
+		return
+	}
+
+	before, after, err := t.store.RecordMessageSentiment(ctx, obs.conversationID, obs.messageID,
+		result.Sentiment, result.Score, t.cfg.Alpha)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to store message sentiment",
+			"conversation_id", obs.conversationID.Hex(), "error", err)
+		return
+	}
+
+	if t.recorder != nil {
+		t.recorder.RecordMessageSentiment(ctx, obs.platform, result.Sentiment)
+	}
+
+	// Alert once when the conversation crosses the threshold, not on every further negative message
+	if after < t.cfg.AlertThreshold && before >= t.cfg.AlertThreshold {
+		slog.WarnContext(ctx, "Conversation turned negative",
+			"conversation_id", obs.conversationID.Hex(),
+			"platform", obs.platform,
+			"score", after,
+			"threshold", t.cfg.AlertThreshold)
+		if t.recorder != nil {
+			t.recorder.RecordNegativeConversation(ctx, obs.platform)
+		}
+	}
+}
@@ -21,20 +21,50 @@ type Session struct {
 	LastActivity   time.Time `json:"last_activity"`
 }
 
+// ContextClearer drops the model context cached for a conversation
+type ContextClearer interface {
+	ClearContext(conversationID string)
+}
+
+// Repository is the conversation storage sessions are created in and recovered from, see model.Repository
+type Repository interface {
+	CreateConversation(ctx context.Context, c *model.Conversation) error
+	// FindConversationsByPlatformAndChatID returns the chat's active conversations, most recent first
+	FindConversationsByPlatformAndChatID(ctx context.Context, platform, chatID string) ([]*model.Conversation, error)
+	ArchiveConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error)
+	// ReassignConversations returns the IDs of the conversations moved from one user and chat to another
+	ReassignConversations(ctx context.Context, platform, fromUserID, fromChatID, toUserID, toChatID string) ([]primitive.ObjectID, error)
+}
+
 // Manager handles session storage and recovery
 type Manager struct {
-	cache *redisx.Cache
-	ttl   time.Duration
-	repo  *model.Repository
+	cache   redisx.Store
+	ttl     time.Duration
+	repo    Repository
+	context ContextClearer
+}
+
+// ManagerOption configures optional session manager behaviour
+type ManagerOption func(*Manager)
+
+// WithContextClearer clears the cached model context of conversations ended by ResetSession
+func WithContextClearer(clearer ContextClearer) ManagerOption {
+	return func(m *Manager) {
+		m.context = clearer
+	}
 }
 
 // NewManager creates a new session manager
-func NewManager(cache *redisx.Cache, ttl time.Duration, repo *model.Repository) *Manager {
-	return &Manager{
+func NewManager(cache redisx.Store, ttl time.Duration, repo Repository, opts ...ManagerOption) *Manager {
+	m := &Manager{
 		cache: cache,
 		ttl:   ttl,
 		repo:  repo,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // GetSession retrieves a session from Redis or recovers from MongoDB
@@ -72,8 +102,88 @@ func (m *Manager) DeleteSession(ctx context.Context, platform, chatID string) er
 	return m.cache.Delete(ctx, key)
 }
 
-// GetOrCreateSession finds an existing session or creates a new one
-func (m *Manager) GetOrCreateSession(ctx context.Context, platform, userID, chatID, message string) (string, error) {
+// ResetSession ends the chat's current session so its next message starts a new conversation
+// The conversation is archived and its cached context cleared; returns its ID, or "" if the chat had none
+func (m *Manager) ResetSession(ctx context.Context, platform, chatID string) (string, error) {
+	session, err := m.GetSession(ctx, platform, chatID)
+	if err != nil {
+		// GetSession reports a chat without an active conversation as an error
+		slog.DebugContext(ctx, "No session to reset", "platform", platform, "chat_id", chatID)
+		return "", nil
+	}
+
+	conversationID, err := primitive.ObjectIDFromHex(session.ConversationID)
+	if err != nil {
+		return "", fmt.Errorf("invalid conversation ID in session: %w", err)
+	}
+
+	// Archived conversations are skipped by session recovery
+	if _, err := m.repo.ArchiveConversations(ctx, []primitive.ObjectID{conversationID}); err != nil {
+		return "", fmt.Errorf("failed to archive conversation: %w", err)
+	}
+	if err := m.DeleteSession(ctx, platform, chatID); err != nil {
+		return "", fmt.Errorf("failed to delete session: %w", err)
+	}
+	if m.context != nil {
+		m.context.ClearContext(session.ConversationID)
+	}
+
+	slog.InfoContext(ctx, "Session reset",
+		"platform", platform,
+		"chat_id", chatID,
+		"conversation_id", session.ConversationID)
+
+	return session.ConversationID, nil
+}
+
+// ClaimSession transfers the conversations of an anonymous user to an authenticated user of the same platform
+// The anonymous chat's session becomes the authenticated chat's session, so the conversation continues there
+// with its cached context; returns the transferred conversation IDs and the moved session's conversation ID
+func (m *Manager) ClaimSession(ctx context.Context, platform, anonymousUserID, anonymousChatID, userID, chatID string) ([]string, string, error) {
+	// Look the session up before its conversations leave the anonymous chat, or recovery would miss it
+	var claimed *Session
+	if anonymousChatID != "" {
+		if session, err := m.GetSession(ctx, platform, anonymousChatID); err == nil {
+			claimed = session
+		}
+	}
+
+	ids, err := m.repo.ReassignConversations(ctx, platform, anonymousUserID, anonymousChatID, userID, chatID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to reassign conversations: %w", err)
+	}
+	conversationIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		conversationIDs = append(conversationIDs, id.Hex())
+	}
+
+	if claimed == nil || chatID == "" {
+		return conversationIDs, "", nil
+	}
+
+	claimed.UserID = userID
+	claimed.ChatID = chatID
+	claimed.LastActivity = time.Now()
+	if err := m.SetSession(ctx, platform, chatID, claimed); err != nil {
+		return nil, "", fmt.Errorf("failed to store session: %w", err)
+	}
+	if err := m.DeleteSession(ctx, platform, anonymousChatID); err != nil {
+		slog.WarnContext(ctx, "Failed to delete claimed session",
+			"platform", platform, "chat_id", anonymousChatID, "error", err)
+	}
+
+	slog.InfoContext(ctx, "Session claimed",
+		"platform", platform,
+		"chat_id", chatID,
+		"conversation_id", claimed.ConversationID,
+		"conversations", len(conversationIDs))
+
+	return conversationIDs, claimed.ConversationID, nil
+}
+
+// GetOrCreateSession finds an existing session or starts a new, empty conversation
+// The caller appends the message that opened the session, so it is stored once
+func (m *Manager) GetOrCreateSession(ctx context.Context, platform, userID, chatID string) (string, error) {
 	// Try to get existing session
 	session, err := m.GetSession(ctx, platform, chatID)
 	if err == nil {
@@ -85,6 +195,17 @@ func (m *Manager) GetOrCreateSession(ctx context.Context, platform, userID, chat
 	}
 
 	// No session found - create a new conversation
+	return m.createSession(ctx, platform, userID, chatID, []*model.Message{})
+}
+
+// StartSession starts a new, empty conversation for a chat, replacing its current session
+// Used when chat settings are changed before the first message
+func (m *Manager) StartSession(ctx context.Context, platform, userID, chatID string) (string, error) {
+	return m.createSession(ctx, platform, userID, chatID, []*model.Message{})
+}
+
+// createSession creates a conversation with the given messages and makes it the chat's session
+func (m *Manager) createSession(ctx context.Context, platform, userID, chatID string, messages []*model.Message) (string, error) {
 	slog.InfoContext(ctx, "Creating new session",
 		"platform", platform,
 		"user_id", userID,
@@ -93,7 +214,7 @@ func (m *Manager) GetOrCreateSession(ctx context.Context, platform, userID, chat
 	// Create a new conversation
 	conversation := &model.Conversation{
 		ID:           primitive.NewObjectID(),
-		Title:        "Untitled conversation",
+		Title:        model.DefaultConversationTitle,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 		Platform:     platform,
@@ -101,13 +222,7 @@ func (m *Manager) GetOrCreateSession(ctx context.Context, platform, userID, chat
 		ChatID:       chatID,
 		IsActive:     true,
 		LastActivity: time.Now(),
-		Messages: []*model.Message{{
-			ID:        primitive.NewObjectID(),
-			Role:      model.RoleUser,
-			Content:   message,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}},
+		Messages:     messages,
 	}
 
 	// Generate title and reply would be handled by the assistant later
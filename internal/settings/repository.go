@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"context"
+	"errors"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/mongox"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const settingsCollection = "user_settings"
+
+// MongoRepository stores user settings in MongoDB
+type MongoRepository struct {
+	conn   *mongo.Database
+	router *mongox.Router
+}
+
+type RepositoryOption func(*MongoRepository)
+
+// WithResidency stores the settings of tenants with a storage profile in the database of that profile
+func WithResidency(router *mongox.Router) RepositoryOption {
+	return func(r *MongoRepository) {
+		r.router = router
+	}
+}
+
+// NewMongoRepository creates a new MongoDB settings repository
+func NewMongoRepository(conn *mongo.Database, opts ...RepositoryOption) *MongoRepository {
+	r := &MongoRepository{conn: conn}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// collection returns the settings of the context's tenant
+func (r *MongoRepository) collection(ctx context.Context) *mongo.Collection {
+	if db, ok := r.router.Database(ctx); ok {
+		return db.Collection(settingsCollection)
+	}
+	return r.conn.Collection(settingsCollection)
+}
+
+func (r *MongoRepository) GetSettings(ctx context.Context, platform, userID string) (*Settings, error) {
+	var s Settings
+	err := r.collection(ctx).FindOne(ctx, filter(ctx, platform, userID)).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *MongoRepository) SaveSettings(ctx context.Context, s *Settings) error {
+	_, err := r.collection(ctx).ReplaceOne(ctx,
+		filter(ctx, s.Platform, s.UserID), s, options.Replace().SetUpsert(true))
+	return err
+}
+
+func filter(ctx context.Context, platform, userID string) bson.M {
+	return bson.M{
+		"tenant_id": tenant.FromContext(ctx),
+		"platform":  platform,
+		"user_id":   userID,
+	}
+}
@@ -0,0 +1,257 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Unit systems
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// Verbosity levels
+const (
+	VerbosityConcise  = "concise"
+	VerbosityNormal   = "normal"
+	VerbosityDetailed = "detailed"
+)
+
+var (
+	// ErrNotFound is returned by repositories when a user has no stored settings
+	ErrNotFound = errors.New("settings not found")
+	// ErrInvalid is wrapped by validation errors
+	ErrInvalid = errors.New("invalid settings")
+)
+
+// languagePattern accepts ISO 639 codes with an optional region, e.g. "es" or "pt-BR"
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// Settings are the preferences of a platform user; empty fields use the defaults
+type Settings struct {
+	TenantID  string    `json:"-" bson:"tenant_id"`
+	Platform  string    `json:"platform" bson:"platform"`
+	UserID    string    `json:"user_id" bson:"user_id"`
+	Language  string    `json:"language,omitempty" bson:"language,omitempty"`   // Reply language code; empty follows the user's language
+	Units     string    `json:"units,omitempty" bson:"units,omitempty"`         // UnitsMetric or UnitsImperial
+	Timezone  string    `json:"timezone,omitempty" bson:"timezone,omitempty"`   // IANA name, e.g. "Europe/Madrid"
+	Verbosity string    `json:"verbosity,omitempty" bson:"verbosity,omitempty"` // VerbosityConcise, VerbosityNormal or VerbosityDetailed
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate checks that every set preference has a known value
+func (s *Settings) Validate() error {
+	if s.Language != "" && !languagePattern.MatchString(s.Language) {
+		return fmt.Errorf("%w: unknown language code %q", ErrInvalid, s.Language)
+	}
+	switch s.Units {
+	case "", UnitsMetric, UnitsImperial:
+	default:
+		return fmt.Errorf("%w: units must be %q or %q", ErrInvalid, UnitsMetric, UnitsImperial)
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalid, s.Timezone)
+		}
+	}
+	switch s.Verbosity {
+	case "", VerbosityConcise, VerbosityNormal, VerbosityDetailed:
+	default:
+		return fmt.Errorf("%w: verbosity must be %q, %q or %q", ErrInvalid, VerbosityConcise, VerbosityNormal, VerbosityDetailed)
+	}
+	return nil
+}
+
+// UnitSystem returns the preferred unit system, metric by default
+func (s *Settings) UnitSystem() string {
+	if s == nil || s.Units == "" {
+		return UnitsMetric
+	}
+	return s.Units
+}
+
+// Location returns the preferred timezone, the server's local timezone by default
+func (s *Settings) Location() *time.Location {
+	if s == nil || s.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Instructions describes the preferences for the system prompt
+// The reply language is left out; conversations may override it
+func (s *Settings) Instructions() string {
+	if s == nil {
+		return ""
+	}
+
+	var parts []string
+	if s.Units == UnitsImperial {
+		parts = append(parts, "Use imperial units (Fahrenheit, miles, inches, pounds).")
+	} else if s.Units == UnitsMetric {
+		parts = append(parts, "Use metric units (Celsius, kilometers, millimeters, kilograms).")
+	}
+	if s.Timezone != "" {
+		parts = append(parts, fmt.Sprintf("The user's timezone is %s; give dates and times in it.", s.Timezone))
+	}
+	switch s.Verbosity {
+	case VerbosityConcise:
+		parts = append(parts, "Keep replies short and to the point.")
+	case VerbosityDetailed:
+		parts = append(parts, "Give thorough, detailed replies.")
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return "User preferences: " + strings.Join(parts, " ")
+}
+
+// FillFrom sets the unset preferences of s to those of other and reports whether any changed
+// Used when an anonymous user logs in: choices made before keep applying unless the account overrides them
+func (s *Settings) FillFrom(other *Settings) bool {
+	if other == nil {
+		return false
+	}
+	changed := false
+	for _, field := range []struct{ dst, src *string }{
+		{&s.Language, &other.Language},
+		{&s.Units, &other.Units},
+		{&s.Timezone, &other.Timezone},
+		{&s.Verbosity, &other.Verbosity},
+	} {
+		if *field.dst == "" && *field.src != "" {
+			*field.dst = *field.src
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Proto converts the settings to their API representation
+func (s *Settings) Proto() *pb.UserSettings {
+	out := &pb.UserSettings{
+		Language:  s.Language,
+		Units:     s.Units,
+		Timezone:  s.Timezone,
+		Verbosity: s.Verbosity,
+	}
+	if !s.UpdatedAt.IsZero() {
+		out.UpdatedAt = timestamppb.New(s.UpdatedAt)
+	}
+	return out
+}
+
+type contextKey struct{}
+
+// WithSettings returns a context carrying the settings of the user being answered
+// Tools read them to format their results, e.g. weather units and the local time
+func WithSettings(ctx context.Context, s *Settings) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the settings of the context, or nil if none are set
+// The accessors of a nil *Settings return the defaults
+func FromContext(ctx context.Context) *Settings {
+	s, _ := ctx.Value(contextKey{}).(*Settings)
+	return s
+}
+
+// Repository persists user settings
+type Repository interface {
+	// GetSettings returns ErrNotFound when the user has no stored settings
+	GetSettings(ctx context.Context, platform, userID string) (*Settings, error)
+	SaveSettings(ctx context.Context, s *Settings) error
+}
+
+// Cache keeps recently used settings close to the request path, see redisx.Cache
+type Cache interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	Set(ctx context.Context, key string, value interface{}) error
+}
+
+// Service reads and updates user settings through a cache
+type Service struct {
+	repo  Repository
+	cache Cache
+}
+
+// NewService creates a user settings service; cache may be nil
+func NewService(repo Repository, cache Cache) *Service {
+	return &Service{
+		repo:  repo,
+		cache: cache,
+	}
+}
+
+// Get returns the user's settings, or empty settings with the defaults if none are stored
+func (s *Service) Get(ctx context.Context, platform, userID string) (*Settings, error) {
+	key := cacheKey(platform, userID)
+
+	if s.cache != nil {
+		var cached Settings
+		err := s.cache.Get(ctx, key, &cached)
+		if err == nil {
+			return &cached, nil
+		}
+		if !errors.Is(err, redisx.ErrCacheMiss) {
+			slog.WarnContext(ctx, "Failed to read cached settings", "platform", platform, "error", err)
+		}
+	}
+
+	stored, err := s.repo.GetSettings(ctx, platform, userID)
+	if errors.Is(err, ErrNotFound) {
+		stored = &Settings{TenantID: tenant.FromContext(ctx), Platform: platform, UserID: userID}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	s.store(ctx, key, stored)
+	return stored, nil
+}
+
+// Set replaces the user's settings
+// Returns an error wrapping ErrInvalid when a preference has an unknown value
+func (s *Service) Set(ctx context.Context, settings *Settings) (*Settings, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	updated := *settings
+	updated.TenantID = tenant.FromContext(ctx)
+	updated.UpdatedAt = time.Now()
+	if err := s.repo.SaveSettings(ctx, &updated); err != nil {
+		return nil, fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	s.store(ctx, cacheKey(updated.Platform, updated.UserID), &updated)
+	return &updated, nil
+}
+
+func (s *Service) store(ctx context.Context, key string, settings *Settings) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Set(ctx, key, settings); err != nil {
+		slog.WarnContext(ctx, "Failed to cache settings", "platform", settings.Platform, "error", err)
+	}
+}
+
+func cacheKey(platform, userID string) string {
+	return fmt.Sprintf("settings:%s:%s", platform, userID)
+}
@@ -0,0 +1,370 @@
+package smoketest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/doctor"
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Config points the smoke test at a deployment
+type Config struct {
+	BaseURL       string
+	APIKey        string // Sent as X-API-Key when set
+	TenantID      string // Sent as X-Tenant-ID when set
+	TenantKey     string // Sent as X-Tenant-Key when set
+	SigningKeyID  string // Requests are signed when set, see httpx.SignRequest
+	SigningSecret string
+	ReplyLatency  time.Duration // Latency budget of calls that wait for the model
+	ReadLatency   time.Duration // Latency budget of calls that only read stored data
+	PollInterval  time.Duration // How often a pending data export is checked
+	HTTPClient    *http.Client
+}
+
+// script is the scripted conversation; the follow-ups make the assistant call its tools
+var (
+	openingMessage   = "Hi! What's the weather like in Barcelona right now?"
+	followUpMessages = []string{
+		"When is the next public holiday?",
+		"And what's today's date?",
+	}
+)
+
+// run holds the state shared by the steps of one smoke test
+type run struct {
+	cfg      Config
+	client   pb.ChatService
+	metadata *pb.SessionMetadata
+
+	conversationID string
+	replies        int // Replies stored in the conversation
+}
+
+// Checks returns the steps of the smoke test in order, to be run with doctor.Run
+// Steps that depend on a failed step are skipped
+func Checks(cfg Config) []doctor.Check {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.ReplyLatency <= 0 {
+		cfg.ReplyLatency = 30 * time.Second
+	}
+	if cfg.ReadLatency <= 0 {
+		cfg.ReadLatency = 2 * time.Second
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+
+	httpClient := &http.Client{
+		Transport: &transport{cfg: cfg, next: cfg.HTTPClient.Transport},
+		Timeout:   cfg.HTTPClient.Timeout,
+	}
+	r := &run{
+		cfg:    cfg,
+		client: pb.NewChatServiceJSONClient(cfg.BaseURL, httpClient),
+		// Each run uses its own user, so its conversation and export do not mix with real data
+		metadata: &pb.SessionMetadata{
+			Platform: "api",
+			UserId:   "smoketest-" + primitive.NewObjectID().Hex(),
+		},
+	}
+	r.metadata.ChatId = r.metadata.UserId
+
+	checks := []doctor.Check{
+		{Name: "health", Run: func(ctx context.Context) (string, string) { return r.health(ctx, httpClient) }},
+		{Name: "start", Run: r.start},
+	}
+	for i, message := range followUpMessages {
+		checks = append(checks, doctor.Check{
+			Name: fmt.Sprintf("continue #%d", i+1),
+			Run:  func(ctx context.Context) (string, string) { return r.continueWith(ctx, message) },
+		})
+	}
+	return append(checks,
+		doctor.Check{Name: "list", Run: r.list},
+		doctor.Check{Name: "describe", Run: r.describe},
+		doctor.Check{Name: "export", Run: func(ctx context.Context) (string, string) { return r.export(ctx, httpClient) }},
+	)
+}
+
+// timed runs a call and fails it when it exceeds the latency budget
+func timed(budget time.Duration, call func() error) (string, time.Duration) {
+	start := time.Now()
+	err := call()
+	elapsed := time.Since(start)
+	if err != nil {
+		return err.Error(), elapsed
+	}
+	if elapsed > budget {
+		return fmt.Sprintf("took %s, over the %s budget", elapsed.Round(time.Millisecond), budget), elapsed
+	}
+	return "", elapsed
+}
+
+func (r *run) health(ctx context.Context, client *http.Client) (string, string) {
+	var health struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	failure, _ := timed(r.cfg.ReadLatency, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(r.cfg.BaseURL, "/")+"/health", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected response: %s", resp.Status)
+		}
+		return json.NewDecoder(resp.Body).Decode(&health)
+	})
+	if failure != "" {
+		return doctor.StatusFail, failure
+	}
+	if health.Status != "healthy" {
+		return doctor.StatusFail, fmt.Sprintf("status %q: %v", health.Status, health.Checks)
+	}
+	return doctor.StatusOK, "healthy"
+}
+
+func (r *run) start(ctx context.Context) (string, string) {
+	var resp *pb.StartConversationResponse
+	failure, elapsed := timed(r.cfg.ReplyLatency, func() (err error) {
+		resp, err = r.client.StartConversation(ctx, &pb.StartConversationRequest{
+			Message:         openingMessage,
+			SessionMetadata: r.metadata,
+		})
+		return err
+	})
+	if resp != nil {
+		// Later steps can check the conversation even when the reply was slow
+		r.conversationID = resp.GetConversationId()
+		if resp.GetReply() != "" {
+			r.replies++
+		}
+	}
+	if failure != "" {
+		return doctor.StatusFail, failure
+	}
+
+	if _, err := primitive.ObjectIDFromHex(resp.GetConversationId()); err != nil {
+		r.conversationID = ""
+		return doctor.StatusFail, fmt.Sprintf("invalid conversation ID %q", resp.GetConversationId())
+	}
+	if resp.GetReply() == "" {
+		return doctor.StatusFail, "empty reply"
+	}
+	return toolStatus(resp.GetReply(), fmt.Sprintf("conversation %s in %s", r.conversationID, elapsed.Round(time.Millisecond)))
+}
+
+func (r *run) continueWith(ctx context.Context, message string) (string, string) {
+	if r.conversationID == "" {
+		return doctor.StatusSkip, "no conversation"
+	}
+
+	var resp *pb.ContinueConversationResponse
+	failure, elapsed := timed(r.cfg.ReplyLatency, func() (err error) {
+		resp, err = r.client.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+			ConversationId:  r.conversationID,
+			Message:         message,
+			SessionMetadata: r.metadata,
+		})
+		return err
+	})
+	if resp != nil && resp.GetReply() != "" {
+		r.replies++
+	}
+	if failure != "" {
+		return doctor.StatusFail, failure
+	}
+	if resp.GetReply() == "" {
+		return doctor.StatusFail, "empty reply"
+	}
+	return toolStatus(resp.GetReply(), "replied in "+elapsed.Round(time.Millisecond).String())
+}
+
+// toolStatus warns when a reply to a question answered by a tool has no figures, e.g. a temperature or a date,
+// which suggests the tool failed and the assistant apologized instead
+func toolStatus(reply, detail string) (string, string) {
+	if !strings.ContainsAny(reply, "0123456789") {
+		return doctor.StatusWarn, detail + ", but the reply has no figures: " + truncate(reply, 80)
+	}
+	return doctor.StatusOK, detail
+}
+
+func (r *run) list(ctx context.Context) (string, string) {
+	if r.conversationID == "" {
+		return doctor.StatusSkip, "no conversation"
+	}
+
+	var resp *pb.ListConversationsResponse
+	failure, elapsed := timed(r.cfg.ReadLatency, func() (err error) {
+		resp, err = r.client.ListConversations(ctx, &pb.ListConversationsRequest{})
+		return err
+	})
+	if failure != "" {
+		return doctor.StatusFail, failure
+	}
+	if !slices.ContainsFunc(resp.GetConversations(), func(c *pb.Conversation) bool { return c.GetId() == r.conversationID }) {
+		return doctor.StatusFail, fmt.Sprintf("conversation %s is not among the %d listed", r.conversationID, len(resp.GetConversations()))
+	}
+	return doctor.StatusOK, fmt.Sprintf("%d conversations in %s", len(resp.GetConversations()), elapsed.Round(time.Millisecond))
+}
+
+func (r *run) describe(ctx context.Context) (string, string) {
+	if r.conversationID == "" {
+		return doctor.StatusSkip, "no conversation"
+	}
+
+	var resp *pb.DescribeConversationResponse
+	failure, elapsed := timed(r.cfg.ReadLatency, func() (err error) {
+		resp, err = r.client.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: r.conversationID})
+		return err
+	})
+	if failure != "" {
+		return doctor.StatusFail, failure
+	}
+
+	conv := resp.GetConversation()
+	if conv.GetId() != r.conversationID || conv.GetTitle() == "" {
+		return doctor.StatusFail, fmt.Sprintf("unexpected conversation %q titled %q", conv.GetId(), conv.GetTitle())
+	}
+	// Every reply follows the user message it answers
+	messages := conv.GetMessages()
+	if len(messages) != 2*r.replies {
+		return doctor.StatusFail, fmt.Sprintf("%d messages, want %d", len(messages), 2*r.replies)
+	}
+	for i, m := range messages {
+		want := pb.Conversation_USER
+		if i%2 == 1 {
+			want = pb.Conversation_ASSISTANT
+		}
+		if m.GetRole() != want || m.GetContent() == "" || m.GetId() == "" {
+			return doctor.StatusFail, fmt.Sprintf("message %d is not a non-empty %s message", i, want)
+		}
+	}
+	return doctor.StatusOK, fmt.Sprintf("%d messages in %s", len(messages), elapsed.Round(time.Millisecond))
+}
+
+// export requests a data export of the smoke test user, waits for it and downloads it
+func (r *run) export(ctx context.Context, client *http.Client) (string, string) {
+	if r.conversationID == "" {
+		return doctor.StatusSkip, "no conversation"
+	}
+
+	start := time.Now()
+	resp, err := r.client.RequestDataExport(ctx, &pb.RequestDataExportRequest{SessionMetadata: r.metadata})
+	if err != nil {
+		return doctor.StatusFail, "request failed: " + err.Error()
+	}
+	export := resp.GetExport()
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for export.GetStatus() == "pending" {
+		select {
+		case <-ctx.Done():
+			return doctor.StatusFail, fmt.Sprintf("export %s still pending after %s", export.GetId(), time.Since(start).Round(time.Second))
+		case <-ticker.C:
+		}
+		got, err := r.client.GetDataExport(ctx, &pb.GetDataExportRequest{ExportId: export.GetId(), SessionMetadata: r.metadata})
+		if err != nil {
+			return doctor.StatusFail, "status check failed: " + err.Error()
+		}
+		export = got.GetExport()
+	}
+	if export.GetStatus() != "ready" {
+		return doctor.StatusFail, fmt.Sprintf("export %s %s: %s", export.GetId(), export.GetStatus(), export.GetError())
+	}
+
+	if failure := r.download(ctx, client, export.GetDownloadUrl()); failure != "" {
+		return doctor.StatusFail, failure
+	}
+	return doctor.StatusOK, fmt.Sprintf("export %s downloaded in %s", export.GetId(), time.Since(start).Round(time.Millisecond))
+}
+
+func (r *run) download(ctx context.Context, client *http.Client, link string) string {
+	if link == "" {
+		return "ready export has no download link"
+	}
+	base, err := url.Parse(r.cfg.BaseURL)
+	if err != nil {
+		return "invalid base URL: " + err.Error()
+	}
+	target, err := base.Parse(link)
+	if err != nil {
+		return "invalid download link: " + err.Error()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The link carries a signature, so only the cause is reported
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "download failed: " + err.Error()
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "download failed: " + resp.Status
+	}
+	return ""
+}
+
+// transport authenticates and optionally signs every request to the deployment
+type transport struct {
+	cfg  Config
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.cfg.APIKey != "" {
+		req.Header.Set("X-API-Key", t.cfg.APIKey)
+	}
+	if t.cfg.TenantID != "" {
+		req.Header.Set(tenant.Header, t.cfg.TenantID)
+	}
+	if t.cfg.TenantKey != "" {
+		req.Header.Set(tenant.KeyHeader, t.cfg.TenantKey)
+	}
+	if t.cfg.SigningKeyID != "" {
+		if err := httpx.SignRequest(req, t.cfg.SigningKeyID, t.cfg.SigningSecret, primitive.NewObjectID().Hex(), time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Longest series served: a month of hours or two years of days
+const (
+	maxHourlyPoints = 31 * 24
+	maxDailyPoints  = 731
+)
+
+// AdminHandler exposes usage time series over HTTP for dashboards
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	service *Service
+	now     func() time.Time
+}
+
+// NewAdminHandler creates a new usage statistics admin handler
+func NewAdminHandler(service *Service) *AdminHandler {
+	return &AdminHandler{service: service, now: time.Now}
+}
+
+// SeriesHandler handles GET /admin/analytics/usage?granularity=hour&from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z
+// Without from and to it returns the last 24 hours, or the last 30 days for daily series
+// Buckets are refreshed by the usage_stats scheduled task, so the latest one may lag behind
+func (h *AdminHandler) SeriesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	granularity := query.Get("granularity")
+	if granularity == "" {
+		granularity = Hourly
+	}
+	limit := maxHourlyPoints
+	span := 24 * time.Hour
+	switch granularity {
+	case Hourly:
+	case Daily:
+		limit = maxDailyPoints
+		span = 30 * 24 * time.Hour
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": ErrInvalidGranularity.Error()})
+		return
+	}
+
+	to := h.now()
+	if v := query.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to must be an RFC 3339 timestamp"})
+			return
+		}
+		to = t
+	}
+	from := to.Add(-span)
+	if v := query.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from must be an RFC 3339 timestamp"})
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from must be before to"})
+		return
+	}
+	if Points(granularity, from, to) > limit {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "range too long", "max_points": limit})
+		return
+	}
+
+	series, err := h.service.Series(r.Context(), granularity, from, to)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load usage series", "granularity", granularity, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load usage series"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, series)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	usageCollection = "usage_records" // Written by billing.Repository
+	statsCollection = "usage_stats"
+)
+
+// MongoRepository materializes usage buckets from billing usage records into the usage_stats collection
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB usage statistics repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+// Materialize aggregates the usage records of [from, to) into buckets of a granularity
+// The aggregation runs in Mongo and replaces the stored buckets it covers; from should start a bucket
+func (r *MongoRepository) Materialize(ctx context.Context, granularity string, from, to time.Time) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"tenant_id": "$tenant_id",
+				"start":     bson.M{"$dateTrunc": bson.M{"date": "$created_at", "unit": granularity, "timezone": "UTC"}},
+			},
+			"requests":          bson.M{"$sum": bson.M{"$cond": bson.A{"$failed", 0, 1}}},
+			"errors":            bson.M{"$sum": bson.M{"$cond": bson.A{"$failed", 1, 0}}},
+			"prompt_tokens":     bson.M{"$sum": "$prompt_tokens"},
+			"completion_tokens": bson.M{"$sum": "$completion_tokens"},
+			"total_tokens":      bson.M{"$sum": "$total_tokens"},
+			"users":             bson.M{"$addToSet": "$user_id"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id": bson.M{
+				"granularity": bson.M{"$literal": granularity},
+				"tenant_id":   "$_id.tenant_id",
+				"start":       "$_id.start",
+			},
+			"granularity":       bson.M{"$literal": granularity},
+			"tenant_id":         "$_id.tenant_id",
+			"start":             "$_id.start",
+			"requests":          1,
+			"errors":            1,
+			"prompt_tokens":     1,
+			"completion_tokens": 1,
+			"total_tokens":      1,
+			// Usage without a user, e.g. background title generation, has no user ID
+			"active_users": bson.M{"$size": bson.M{"$setDifference": bson.A{"$users", bson.A{nil, ""}}}},
+			"updated_at":   "$$NOW",
+		}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           statsCollection,
+			"on":             "_id",
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}}},
+	}
+
+	cursor, err := r.conn.Collection(usageCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// LatestBucket returns the start of the latest stored bucket of a granularity, zero when there is none
+func (r *MongoRepository) LatestBucket(ctx context.Context, granularity string) (time.Time, error) {
+	var b Bucket
+	err := r.conn.Collection(statsCollection).FindOne(ctx,
+		bson.M{"granularity": granularity},
+		options.FindOne().SetSort(bson.D{{Key: "start", Value: -1}})).Decode(&b)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return b.Start, nil
+}
+
+// Buckets returns the stored buckets of a tenant starting in [from, to), oldest first
+func (r *MongoRepository) Buckets(ctx context.Context, granularity, tenantID string, from, to time.Time) ([]*Bucket, error) {
+	cursor, err := r.conn.Collection(statsCollection).Find(ctx,
+		bson.M{
+			"granularity": granularity,
+			"tenant_id":   tenantID,
+			"start":       bson.M{"$gte": from, "$lt": to},
+		},
+		options.Find().SetSort(bson.D{{Key: "start", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var buckets []*Bucket
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
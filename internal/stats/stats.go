@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// Granularities of usage buckets
+const (
+	Hourly = "hour"
+	Daily  = "day"
+)
+
+// ErrInvalidGranularity is returned for granularities other than hour and day
+var ErrInvalidGranularity = errors.New("granularity must be hour or day")
+
+// Bucket is the usage of one tenant over an hour or a day, materialized from usage records
+type Bucket struct {
+	Granularity      string    `bson:"granularity" json:"-"`
+	TenantID         string    `bson:"tenant_id" json:"-"`
+	Start            time.Time `bson:"start" json:"start"`
+	Requests         int64     `bson:"requests" json:"requests"` // Successful OpenAI requests of every operation
+	Errors           int64     `bson:"errors" json:"errors"`     // Replies that failed
+	PromptTokens     int64     `bson:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int64     `bson:"completion_tokens" json:"completion_tokens"`
+	TotalTokens      int64     `bson:"total_tokens" json:"total_tokens"`
+	ActiveUsers      int64     `bson:"active_users" json:"active_users"` // Distinct users with requests in the bucket
+}
+
+// Series is a gap-free run of buckets, one per hour or day
+type Series struct {
+	Granularity string    `json:"granularity"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Points      []*Bucket `json:"points"`
+}
+
+// Store materializes usage buckets and reads them back, see MongoRepository
+type Store interface {
+	Materialize(ctx context.Context, granularity string, from, to time.Time) error
+	LatestBucket(ctx context.Context, granularity string) (time.Time, error)
+	Buckets(ctx context.Context, granularity, tenantID string, from, to time.Time) ([]*Bucket, error)
+}
+
+// Service keeps usage buckets up to date and serves them as time series
+type Service struct {
+	store    Store
+	backfill time.Duration
+}
+
+// NewService creates a usage statistics service; the first refresh materializes backfill of history
+func NewService(store Store, backfill time.Duration) *Service {
+	if backfill <= 0 {
+		backfill = 30 * 24 * time.Hour
+	}
+	return &Service{store: store, backfill: backfill}
+}
+
+// Refresh materializes the buckets changed since the previous refresh, up to now
+// The latest bucket and the one before it are recomputed, since usage is recorded in the background
+// and may land after its bucket was first materialized
+func (s *Service) Refresh(ctx context.Context, now time.Time) error {
+	latest, err := s.store.LatestBucket(ctx, Hourly)
+	if err != nil {
+		return err
+	}
+	from := truncate(Hourly, latest).Add(-time.Hour)
+	if oldest := truncate(Hourly, now.Add(-s.backfill)); latest.IsZero() || from.Before(oldest) {
+		from = oldest
+	}
+
+	if err := s.store.Materialize(ctx, Hourly, from, now); err != nil {
+		return err
+	}
+	return s.store.Materialize(ctx, Daily, truncate(Daily, from), now)
+}
+
+// Series returns the usage of the context's tenant from the bucket holding from up to to
+// Buckets without usage are returned with zero counts, so every point of the range is present
+func (s *Service) Series(ctx context.Context, granularity string, from, to time.Time) (*Series, error) {
+	if granularity != Hourly && granularity != Daily {
+		return nil, ErrInvalidGranularity
+	}
+	from = truncate(granularity, from)
+
+	buckets, err := s.store.Buckets(ctx, granularity, tenant.FromContext(ctx), from, to)
+	if err != nil {
+		return nil, err
+	}
+	byStart := make(map[int64]*Bucket, len(buckets))
+	for _, b := range buckets {
+		byStart[b.Start.Unix()] = b
+	}
+
+	series := &Series{Granularity: granularity, From: from, To: to, Points: []*Bucket{}}
+	for start := from; start.Before(to); start = next(granularity, start) {
+		b, ok := byStart[start.Unix()]
+		if !ok {
+			b = &Bucket{Start: start}
+		}
+		series.Points = append(series.Points, b)
+	}
+	return series, nil
+}
+
+// Points returns the number of buckets of a granularity in [from, to)
+func Points(granularity string, from, to time.Time) int {
+	span := to.Sub(truncate(granularity, from))
+	if span <= 0 {
+		return 0
+	}
+	step := time.Hour
+	if granularity == Daily {
+		step = 24 * time.Hour // UTC days have no daylight saving changes
+	}
+	return int((span + step - 1) / step)
+}
+
+// truncate returns the start of the UTC bucket holding t
+func truncate(granularity string, t time.Time) time.Time {
+	t = t.UTC()
+	if granularity == Daily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+func next(granularity string, start time.Time) time.Time {
+	if granularity == Daily {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.Add(time.Hour)
+}
@@ -0,0 +1,115 @@
+package style
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+)
+
+// Reply formats
+const (
+	FormatBullets = "bullets"
+	FormatProse   = "prose"
+)
+
+// Reading levels
+const (
+	ReadingLevelSimple   = "simple"
+	ReadingLevelStandard = "standard"
+	ReadingLevelExpert   = "expert"
+)
+
+// Bounds of the maximum reply length; shorter limits leave no room for an answer
+const (
+	MinMaxLength = 50
+	MaxMaxLength = 10000
+)
+
+// ErrInvalid is wrapped by validation errors
+var ErrInvalid = errors.New("invalid reply style")
+
+// Style shapes a single reply, e.g. to fit it into an SMS; empty fields leave the assistant's defaults
+type Style struct {
+	MaxLength    int    // Longest reply in characters; 0 for no limit
+	Format       string // FormatBullets or FormatProse
+	ReadingLevel string // ReadingLevelSimple, ReadingLevelStandard or ReadingLevelExpert
+}
+
+// FromProto converts the style of a request; nil when the request has none
+func FromProto(s *pb.ReplyStyle) *Style {
+	if s == nil {
+		return nil
+	}
+	return &Style{
+		MaxLength:    int(s.GetMaxLength()),
+		Format:       s.GetFormat(),
+		ReadingLevel: s.GetReadingLevel(),
+	}
+}
+
+// Validate checks that every set field has a known value
+func (s *Style) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.MaxLength != 0 && (s.MaxLength < MinMaxLength || s.MaxLength > MaxMaxLength) {
+		return fmt.Errorf("%w: max_length must be between %d and %d", ErrInvalid, MinMaxLength, MaxMaxLength)
+	}
+	switch s.Format {
+	case "", FormatBullets, FormatProse:
+	default:
+		return fmt.Errorf("%w: format must be %q or %q", ErrInvalid, FormatBullets, FormatProse)
+	}
+	switch s.ReadingLevel {
+	case "", ReadingLevelSimple, ReadingLevelStandard, ReadingLevelExpert:
+	default:
+		return fmt.Errorf("%w: reading_level must be %q, %q or %q", ErrInvalid,
+			ReadingLevelSimple, ReadingLevelStandard, ReadingLevelExpert)
+	}
+	return nil
+}
+
+// Instructions describes the style for the system prompt
+func (s *Style) Instructions() string {
+	if s == nil {
+		return ""
+	}
+
+	var parts []string
+	if s.MaxLength > 0 {
+		parts = append(parts, fmt.Sprintf("The reply must be at most %d characters long; leave out anything that does not fit.", s.MaxLength))
+	}
+	switch s.Format {
+	case FormatBullets:
+		parts = append(parts, "Answer with a short list of bullet points.")
+	case FormatProse:
+		parts = append(parts, "Answer in plain prose paragraphs, without lists or headings.")
+	}
+	switch s.ReadingLevel {
+	case ReadingLevelSimple:
+		parts = append(parts, "Use simple words and short sentences anyone can follow, and explain any technical term.")
+	case ReadingLevelExpert:
+		parts = append(parts, "Write for an expert: use precise technical terms and skip the basics.")
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Reply style for this message: " + strings.Join(parts, " ")
+}
+
+type contextKey struct{}
+
+// WithStyle returns a context carrying the style of the reply being generated
+func WithStyle(ctx context.Context, s *Style) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the style of the context, or nil if none is set
+func FromContext(ctx context.Context) *Style {
+	s, _ := ctx.Value(contextKey{}).(*Style)
+	return s
+}
@@ -0,0 +1,27 @@
+package takeout
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/delivery"
+)
+
+// ChannelNotifier sends export links to users on the platform they requested the export from
+type ChannelNotifier struct {
+	Deliveries *delivery.Service
+}
+
+// Notify queues the link on the user's channel; delivery is tracked by the delivery service
+func (n ChannelNotifier) Notify(ctx context.Context, e *Export, downloadURL string) (bool, error) {
+	if e.ChatID == "" || !n.Deliveries.Supports(e.Platform) {
+		return false, nil
+	}
+
+	text := fmt.Sprintf("Your data export is ready. Download it before %s:\n%s",
+		e.ExpiresAt.UTC().Format("2006-01-02 15:04 MST"), downloadURL)
+	if _, err := n.Deliveries.Send(ctx, e.Platform, e.ChatID, text); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package takeout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const exportCollection = "data_exports"
+
+// MongoRepository stores export requests in MongoDB
+type MongoRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoRepository creates a new MongoDB export repository
+func NewMongoRepository(conn *mongo.Database) *MongoRepository {
+	return &MongoRepository{conn: conn}
+}
+
+func (r *MongoRepository) CreateExport(ctx context.Context, e *Export) error {
+	_, err := r.conn.Collection(exportCollection).InsertOne(ctx, e)
+	return err
+}
+
+func (r *MongoRepository) UpdateExport(ctx context.Context, e *Export) error {
+	_, err := r.conn.Collection(exportCollection).ReplaceOne(ctx, bson.M{"_id": e.ID}, e)
+	return err
+}
+
+func (r *MongoRepository) GetExport(ctx context.Context, id string) (*Export, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": oid, "tenant_id": tenant.FromContext(ctx)})
+}
+
+func (r *MongoRepository) GetExportByID(ctx context.Context, id string) (*Export, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *MongoRepository) PendingExport(ctx context.Context, platform, userID string, since time.Time) (*Export, error) {
+	export, err := r.findOne(ctx, bson.M{
+		"tenant_id":  tenant.FromContext(ctx),
+		"platform":   platform,
+		"user_id":    userID,
+		"status":     StatusPending,
+		"created_at": bson.M{"$gte": since},
+	}, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return export, err
+}
+
+func (r *MongoRepository) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*Export, error) {
+	var e Export
+	err := r.conn.Collection(exportCollection).FindOne(ctx, filter, opts...).Decode(&e)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
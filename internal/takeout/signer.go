@@ -0,0 +1,67 @@
+package takeout
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidLink is returned for download links with a bad signature or past their expiry
+var ErrInvalidLink = errors.New("invalid or expired download link")
+
+// Signer creates and verifies time-limited download links
+type Signer struct {
+	baseURL string
+	key     []byte
+}
+
+// NewSigner creates a link signer; baseURL is the public address the service is reachable at
+func NewSigner(baseURL, key string) (*Signer, error) {
+	if key == "" {
+		return nil, errors.New("signing key is required")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	return &Signer{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		key:     []byte(key),
+	}, nil
+}
+
+// URL returns a download link for the export that stops working at expires
+func (s *Signer) URL(id string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{
+		"expires":   {exp},
+		"signature": {s.sign(id, exp)},
+	}
+	return fmt.Sprintf("%s/takeout/%s?%s", s.baseURL, url.PathEscape(id), query.Encode())
+}
+
+// Verify checks the signature and expiry of a download link
+func (s *Signer) Verify(id, expires, signature string, now time.Time) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidLink
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(id, expires))) {
+		return ErrInvalidLink
+	}
+	if now.Unix() > exp {
+		return ErrInvalidLink
+	}
+	return nil
+}
+
+func (s *Signer) sign(id, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(id + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,388 @@
+package takeout
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Export statuses
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+var (
+	// ErrNotFound is returned when an export does not exist or belongs to someone else
+	ErrNotFound = errors.New("export not found")
+	// ErrBusy is returned when too many exports are waiting to be generated
+	ErrBusy = errors.New("too many exports in progress")
+)
+
+// Export is a user's request for a copy of their data
+type Export struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	TenantID    string             `bson:"tenant_id"`
+	Platform    string             `bson:"platform"`
+	UserID      string             `bson:"user_id"`
+	ChatID      string             `bson:"chat_id,omitempty"`
+	Status      string             `bson:"status"`
+	ObjectKey   string             `bson:"object_key,omitempty"`
+	Size        int64              `bson:"size,omitempty"`
+	Error       string             `bson:"error,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	CompletedAt time.Time          `bson:"completed_at,omitempty"`
+	ExpiresAt   time.Time          `bson:"expires_at,omitempty"` // Download links stop working after this
+}
+
+// Proto converts the export to the API representation
+func (e *Export) Proto(downloadURL string) *pb.DataExport {
+	export := &pb.DataExport{
+		Id:          e.ID.Hex(),
+		Status:      e.Status,
+		DownloadUrl: downloadURL,
+		Error:       e.Error,
+		CreatedAt:   timestamppb.New(e.CreatedAt),
+	}
+	if !e.ExpiresAt.IsZero() {
+		export.ExpiresAt = timestamppb.New(e.ExpiresAt)
+	}
+	return export
+}
+
+// Repository stores export requests
+type Repository interface {
+	CreateExport(ctx context.Context, e *Export) error
+	UpdateExport(ctx context.Context, e *Export) error
+	// GetExport returns ErrNotFound unless the export belongs to the context's tenant
+	GetExport(ctx context.Context, id string) (*Export, error)
+	// GetExportByID ignores tenants; signed download links are opened outside any tenant
+	GetExportByID(ctx context.Context, id string) (*Export, error)
+	// PendingExport returns the user's export still being generated, or nil
+	PendingExport(ctx context.Context, platform, userID string, since time.Time) (*Export, error)
+}
+
+// Source contributes one file of a user's data to the archive
+type Source interface {
+	Name() string
+	Collect(ctx context.Context, platform, userID string) (any, error)
+}
+
+// Notifier tells users on their channel that their export is ready
+type Notifier interface {
+	// Notify returns false when the user's platform has no channel to notify
+	Notify(ctx context.Context, e *Export, downloadURL string) (bool, error)
+}
+
+// Config controls export generation and download links
+type Config struct {
+	LinkTTL    time.Duration // How long download links stay valid
+	QueueSize  int           // Exports waiting to be generated before requests are rejected
+	MaxPending time.Duration // After this a pending export is considered lost and can be requested again
+}
+
+// Service generates user data exports in the background
+type Service struct {
+	repo     Repository
+	store    objectstore.Store
+	sources  []Source
+	links    *Signer
+	notifier Notifier
+	cfg      Config
+	queue    chan *Export
+}
+
+// NewService creates a takeout service; a nil notifier only exposes links through GetDataExport
+func NewService(repo Repository, store objectstore.Store, links *Signer, notifier Notifier, cfg Config, sources ...Source) *Service {
+	if cfg.LinkTTL <= 0 {
+		cfg.LinkTTL = 7 * 24 * time.Hour
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = time.Hour
+	}
+
+	return &Service{
+		repo:     repo,
+		store:    store,
+		sources:  sources,
+		links:    links,
+		notifier: notifier,
+		cfg:      cfg,
+		queue:    make(chan *Export, cfg.QueueSize),
+	}
+}
+
+// Request queues an export of everything stored for the user
+// A user with an export in progress gets that export back instead of a new one
+func (s *Service) Request(ctx context.Context, platform, userID, chatID string) (*Export, error) {
+	pending, err := s.repo.PendingExport(ctx, platform, userID, time.Now().Add(-s.cfg.MaxPending))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending exports: %w", err)
+	}
+	if pending != nil {
+		return pending, nil
+	}
+
+	export := &Export{
+		ID:        primitive.NewObjectID(),
+		TenantID:  tenant.FromContext(ctx),
+		Platform:  platform,
+		UserID:    userID,
+		ChatID:    chatID,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateExport(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	// The worker gets its own copy; the caller keeps reading the returned export
+	queued := *export
+	select {
+	case s.queue <- &queued:
+	default:
+		export.Status = StatusFailed
+		export.Error = "too many exports in progress"
+		export.CompletedAt = time.Now()
+		if err := s.repo.UpdateExport(ctx, export); err != nil {
+			slog.WarnContext(ctx, "Failed to mark rejected export", "export_id", export.ID.Hex(), "error", err)
+		}
+		return nil, ErrBusy
+	}
+
+	slog.InfoContext(ctx, "Data export requested", "export_id", export.ID.Hex(), "platform", platform)
+	return export, nil
+}
+
+// Get returns the user's export and, once it is ready, its signed download link
+func (s *Service) Get(ctx context.Context, id, platform, userID string) (*Export, string, error) {
+	export, err := s.repo.GetExport(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if export.Platform != platform || export.UserID != userID {
+		return nil, "", ErrNotFound
+	}
+
+	if export.Status != StatusReady || time.Now().After(export.ExpiresAt) {
+		return export, "", nil
+	}
+	return export, s.links.URL(export.ID.Hex(), export.ExpiresAt), nil
+}
+
+// Run generates queued exports until the context is cancelled
+func (s *Service) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Takeout worker started")
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Takeout worker stopped", "queued", len(s.queue))
+			return
+		case export := <-s.queue:
+			s.process(tenant.WithTenant(ctx, export.TenantID), export)
+		}
+	}
+}
+
+func (s *Service) process(ctx context.Context, export *Export) {
+	start := time.Now()
+	archive, err := s.build(ctx, export)
+	if err == nil {
+		export.ObjectKey = fmt.Sprintf("%s/%s.zip", export.TenantID, export.ID.Hex())
+		err = s.store.Put(ctx, export.ObjectKey, "application/zip", archive)
+	}
+
+	export.CompletedAt = time.Now()
+	if err != nil {
+		slog.ErrorContext(ctx, "Data export failed", "export_id", export.ID.Hex(), "error", err)
+		export.Status = StatusFailed
+		export.Error = "export could not be generated"
+	} else {
+		export.Status = StatusReady
+		export.Size = int64(len(archive))
+		export.ExpiresAt = export.CompletedAt.Add(s.cfg.LinkTTL)
+	}
+
+	if err := s.repo.UpdateExport(ctx, export); err != nil {
+		slog.ErrorContext(ctx, "Failed to update data export", "export_id", export.ID.Hex(), "error", err)
+		return
+	}
+	if export.Status != StatusReady {
+		return
+	}
+
+	slog.InfoContext(ctx, "Data export ready",
+		"export_id", export.ID.Hex(),
+		"bytes", export.Size,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	if s.notifier == nil {
+		return
+	}
+	notified, err := s.notifier.Notify(ctx, export, s.links.URL(export.ID.Hex(), export.ExpiresAt))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to notify user about data export", "export_id", export.ID.Hex(), "error", err)
+	} else if notified {
+		slog.InfoContext(ctx, "Data export notification queued", "export_id", export.ID.Hex(), "platform", export.Platform)
+	}
+}
+
+// build writes one JSON file per source into a zip archive
+func (s *Service) build(ctx context.Context, export *Export) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	manifest := map[string]any{
+		"export_id":    export.ID.Hex(),
+		"platform":     export.Platform,
+		"user_id":      export.UserID,
+		"generated_at": time.Now().UTC(),
+	}
+	files := []string{}
+
+	for _, source := range s.sources {
+		data, err := source.Collect(ctx, export.Platform, export.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect %s: %w", source.Name(), err)
+		}
+		if err := writeJSON(zw, source.Name(), data); err != nil {
+			return nil, err
+		}
+		files = append(files, source.Name())
+	}
+
+	manifest["files"] = files
+	if err := writeJSON(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(zw *zip.Writer, name string, data any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// DownloadHandler serves an archive to anyone holding a valid signed link
+// Links carry no API key, so the signature and expiry are the only authorization
+func (s *Service) DownloadHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["export_id"]
+	query := r.URL.Query()
+	if err := s.links.Verify(id, query.Get("expires"), query.Get("signature"), time.Now()); err != nil {
+		http.Error(w, "invalid or expired link", http.StatusForbidden)
+		return
+	}
+
+	export, err := s.repo.GetExportByID(r.Context(), id)
+	if err != nil || export.Status != StatusReady {
+		http.Error(w, "export not found", http.StatusNotFound)
+		return
+	}
+
+	body, err := s.store.Get(r.Context(), export.ObjectKey)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to open data export", "export_id", id, "error", err)
+		http.Error(w, "export not found", http.StatusNotFound)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="takeout-%s.zip"`, id))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := io.Copy(w, body); err != nil {
+		slog.WarnContext(r.Context(), "Failed to send data export", "export_id", id, "error", err)
+	}
+}
+
+// ConversationLister lists a platform user's conversations within the context's tenant
+type ConversationLister interface {
+	ListUserConversations(ctx context.Context, platform, userID string) ([]*model.Conversation, error)
+}
+
+// ConversationSource exports a user's conversations with all their messages
+type ConversationSource struct {
+	Conversations ConversationLister
+}
+
+type exportedMessage struct {
+	Role          string    `json:"role"`
+	Content       string    `json:"content"`
+	CreatedAt     time.Time `json:"created_at"`
+	AttachmentIDs []string  `json:"attachment_ids,omitempty"`
+}
+
+type exportedConversation struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Platform  string            `json:"platform,omitempty"`
+	ChatID    string            `json:"chat_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Messages  []exportedMessage `json:"messages"`
+}
+
+func (s ConversationSource) Name() string {
+	return "conversations.json"
+}
+
+func (s ConversationSource) Collect(ctx context.Context, platform, userID string) (any, error) {
+	conversations, err := s.Conversations.ListUserConversations(ctx, platform, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]exportedConversation, 0, len(conversations))
+	for _, c := range conversations {
+		conv := exportedConversation{
+			ID:        c.ID.Hex(),
+			Title:     c.Title,
+			Platform:  c.Platform,
+			ChatID:    c.ChatID,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+			Messages:  make([]exportedMessage, 0, len(c.Messages)),
+		}
+		for _, m := range c.Messages {
+			conv.Messages = append(conv.Messages, exportedMessage{
+				Role:          string(m.Role),
+				Content:       m.Content,
+				CreatedAt:     m.CreatedAt,
+				AttachmentIDs: m.AttachmentIDs,
+			})
+		}
+		exported = append(exported, conv)
+	}
+	return exported, nil
+}
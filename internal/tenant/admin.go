@@ -0,0 +1,118 @@
+package tenant
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler exposes tenant credential management over HTTP
+// It must be mounted behind admin.Authenticator with a {tenant_id} route variable; writes require RoleOperator
+type AdminHandler struct {
+	keys *KeyManager
+}
+
+// NewAdminHandler creates a new tenant admin handler
+func NewAdminHandler(keys *KeyManager) *AdminHandler {
+	return &AdminHandler{keys: keys}
+}
+
+// CredentialsRequest is the body of credential updates
+// Keys are write-only: they are never returned by the API
+type CredentialsRequest struct {
+	OpenAIAPIKey  string `json:"openai_api_key,omitempty"`
+	WeatherAPIKey string `json:"weather_api_key,omitempty"`
+}
+
+// CredentialsStatus reports which keys a tenant has configured
+type CredentialsStatus struct {
+	TenantID string `json:"tenant_id"`
+	OpenAI   bool   `json:"openai_api_key_configured"`
+	Weather  bool   `json:"weather_api_key_configured"`
+}
+
+// PutCredentialsHandler handles PUT /admin/tenants/{tenant_id}/credentials
+func (h *AdminHandler) PutCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if req.OpenAIAPIKey == "" && req.WeatherAPIKey == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at least one API key is required"})
+		return
+	}
+
+	creds := &Credentials{OpenAIAPIKey: req.OpenAIAPIKey, WeatherAPIKey: req.WeatherAPIKey}
+	if err := h.keys.Set(r.Context(), tenantID, creds); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to store tenant credentials", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store credentials"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, credentialsStatus(tenantID, creds))
+}
+
+// GetCredentialsHandler handles GET /admin/tenants/{tenant_id}/credentials
+func (h *AdminHandler) GetCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	creds, err := h.keys.Get(r.Context(), tenantID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to load tenant credentials", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load credentials"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, credentialsStatus(tenantID, creds))
+}
+
+// DeleteCredentialsHandler handles DELETE /admin/tenants/{tenant_id}/credentials
+func (h *AdminHandler) DeleteCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.tenantID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.keys.Delete(r.Context(), tenantID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete tenant credentials", "tenant_id", tenantID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete credentials"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, credentialsStatus(tenantID, nil))
+}
+
+func (h *AdminHandler) tenantID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := mux.Vars(r)["tenant_id"]
+	if !ValidID(id) || id == DefaultID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid tenant ID"})
+		return "", false
+	}
+	return id, true
+}
+
+func credentialsStatus(tenantID string, creds *Credentials) CredentialsStatus {
+	s := CredentialsStatus{TenantID: tenantID}
+	if creds != nil {
+		s.OpenAI = creds.OpenAIAPIKey != ""
+		s.Weather = creds.WeatherAPIKey != ""
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,229 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const credentialsCollection = "tenant_credentials"
+
+// Credentials holds upstream API keys a tenant brings instead of the platform keys
+type Credentials struct {
+	OpenAIAPIKey  string
+	WeatherAPIKey string
+}
+
+// EncryptedCredentials is the stored form of Credentials
+type EncryptedCredentials struct {
+	TenantID      string    `bson:"_id"`
+	OpenAIAPIKey  string    `bson:"openai_api_key,omitempty"`
+	WeatherAPIKey string    `bson:"weather_api_key,omitempty"`
+	UpdatedAt     time.Time `bson:"updated_at"`
+}
+
+// CredentialRepository persists encrypted tenant credentials
+type CredentialRepository interface {
+	// GetCredentials returns the stored credentials, or nil when the tenant has none
+	GetCredentials(ctx context.Context, tenantID string) (*EncryptedCredentials, error)
+	SaveCredentials(ctx context.Context, creds *EncryptedCredentials) error
+	DeleteCredentials(ctx context.Context, tenantID string) error
+}
+
+type credentialsKey struct{}
+
+// WithCredentials returns a context carrying the tenant's own API keys
+func WithCredentials(ctx context.Context, creds *Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, creds)
+}
+
+// CredentialsFromContext returns the tenant's own API keys, or nil when the platform keys apply
+func CredentialsFromContext(ctx context.Context) *Credentials {
+	creds, _ := ctx.Value(credentialsKey{}).(*Credentials)
+	return creds
+}
+
+type cachedCredentials struct {
+	creds     *Credentials
+	expiresAt time.Time
+}
+
+// KeyManager encrypts, stores and resolves per-tenant API keys
+// Decrypted keys are cached in memory for a short time to keep lookups off the request path
+type KeyManager struct {
+	repo     CredentialRepository
+	cipher   *secrets.Cipher
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedCredentials
+}
+
+// NewKeyManager creates a new tenant key manager
+func NewKeyManager(repo CredentialRepository, cipher *secrets.Cipher, cacheTTL time.Duration) *KeyManager {
+	if cacheTTL <= 0 {
+		cacheTTL = time.Minute
+	}
+	return &KeyManager{
+		repo:     repo,
+		cipher:   cipher,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedCredentials),
+	}
+}
+
+// Get returns the decrypted credentials of a tenant, or nil when it uses the platform keys
+func (m *KeyManager) Get(ctx context.Context, tenantID string) (*Credentials, error) {
+	m.mu.Lock()
+	cached, ok := m.cache[tenantID]
+	m.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.creds, nil
+	}
+
+	stored, err := m.repo.GetCredentials(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant credentials: %w", err)
+	}
+
+	var creds *Credentials
+	if stored != nil {
+		creds = &Credentials{}
+		if creds.OpenAIAPIKey, err = m.decrypt(stored.OpenAIAPIKey); err != nil {
+			return nil, fmt.Errorf("failed to decrypt OpenAI key of tenant %s: %w", tenantID, err)
+		}
+		if creds.WeatherAPIKey, err = m.decrypt(stored.WeatherAPIKey); err != nil {
+			return nil, fmt.Errorf("failed to decrypt weather key of tenant %s: %w", tenantID, err)
+		}
+	}
+
+	m.mu.Lock()
+	m.cache[tenantID] = cachedCredentials{creds: creds, expiresAt: time.Now().Add(m.cacheTTL)}
+	m.mu.Unlock()
+
+	return creds, nil
+}
+
+// Set encrypts and stores the credentials of a tenant, replacing previous ones
+func (m *KeyManager) Set(ctx context.Context, tenantID string, creds *Credentials) error {
+	stored := &EncryptedCredentials{TenantID: tenantID, UpdatedAt: time.Now()}
+
+	var err error
+	if stored.OpenAIAPIKey, err = m.encrypt(creds.OpenAIAPIKey); err != nil {
+		return err
+	}
+	if stored.WeatherAPIKey, err = m.encrypt(creds.WeatherAPIKey); err != nil {
+		return err
+	}
+
+	if err := m.repo.SaveCredentials(ctx, stored); err != nil {
+		return fmt.Errorf("failed to save tenant credentials: %w", err)
+	}
+
+	m.invalidate(tenantID)
+	slog.InfoContext(ctx, "Tenant credentials updated", "tenant_id", tenantID)
+	return nil
+}
+
+// Delete removes the credentials of a tenant so it falls back to the platform keys
+func (m *KeyManager) Delete(ctx context.Context, tenantID string) error {
+	if err := m.repo.DeleteCredentials(ctx, tenantID); err != nil {
+		return fmt.Errorf("failed to delete tenant credentials: %w", err)
+	}
+
+	m.invalidate(tenantID)
+	slog.InfoContext(ctx, "Tenant credentials deleted", "tenant_id", tenantID)
+	return nil
+}
+
+// Attach returns a context carrying the credentials of the context's tenant
+// Lookup failures are logged and the platform keys are used instead
+func (m *KeyManager) Attach(ctx context.Context) context.Context {
+	if IsDefault(ctx) {
+		return ctx
+	}
+
+	creds, err := m.Get(ctx, FromContext(ctx))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to resolve tenant credentials, using platform keys",
+			"tenant_id", FromContext(ctx), "error", err)
+		return ctx
+	}
+	if creds == nil {
+		return ctx
+	}
+
+	return WithCredentials(ctx, creds)
+}
+
+// Middleware returns an HTTP middleware that attaches tenant credentials to the request context
+// It must run after the tenant Resolver
+func (m *KeyManager) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(m.Attach(r.Context())))
+		})
+	}
+}
+
+func (m *KeyManager) encrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return m.cipher.Encrypt(value)
+}
+
+func (m *KeyManager) decrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return m.cipher.Decrypt(value)
+}
+
+func (m *KeyManager) invalidate(tenantID string) {
+	m.mu.Lock()
+	delete(m.cache, tenantID)
+	m.mu.Unlock()
+}
+
+// MongoCredentialRepository stores encrypted tenant credentials in MongoDB
+type MongoCredentialRepository struct {
+	conn *mongo.Database
+}
+
+// NewMongoCredentialRepository creates a new MongoDB credential repository
+func NewMongoCredentialRepository(conn *mongo.Database) *MongoCredentialRepository {
+	return &MongoCredentialRepository{conn: conn}
+}
+
+func (r *MongoCredentialRepository) GetCredentials(ctx context.Context, tenantID string) (*EncryptedCredentials, error) {
+	var creds EncryptedCredentials
+	err := r.conn.Collection(credentialsCollection).FindOne(ctx, bson.M{"_id": tenantID}).Decode(&creds)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+func (r *MongoCredentialRepository) SaveCredentials(ctx context.Context, creds *EncryptedCredentials) error {
+	_, err := r.conn.Collection(credentialsCollection).ReplaceOne(ctx,
+		bson.M{"_id": creds.TenantID}, creds, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *MongoCredentialRepository) DeleteCredentials(ctx context.Context, tenantID string) error {
+	_, err := r.conn.Collection(credentialsCollection).DeleteOne(ctx, bson.M{"_id": tenantID})
+	return err
+}
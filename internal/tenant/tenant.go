@@ -0,0 +1,118 @@
+package tenant
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+)
+
+// DefaultID is the tenant used when a request does not name one
+// Data written before multi-tenancy belongs to this tenant
+const DefaultID = "default"
+
+// Header carries the tenant ID on incoming requests
+const Header = "X-Tenant-ID"
+
+// KeyHeader carries the access key of the tenant named in Header
+const KeyHeader = "X-Tenant-Key"
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type contextKey struct{}
+
+// WithTenant returns a context scoped to the given tenant
+func WithTenant(ctx context.Context, id string) context.Context {
+	if id == "" {
+		id = DefaultID
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant of the context, or DefaultID if none is set
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok && id != "" {
+		return id
+	}
+	return DefaultID
+}
+
+// IsDefault reports whether the context belongs to the default tenant
+func IsDefault(ctx context.Context) bool {
+	return FromContext(ctx) == DefaultID
+}
+
+// Key scopes a storage key to the tenant of the context
+// Keys of the default tenant are left unchanged so existing data stays reachable
+func Key(ctx context.Context, key string) string {
+	id := FromContext(ctx)
+	if id == DefaultID {
+		return key
+	}
+	return fmt.Sprintf("t:%s:%s", id, key)
+}
+
+// ValidID reports whether id is a well-formed tenant ID
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Resolver identifies the tenant of incoming requests
+// The tenant header is chosen by the caller, so a tenant other than the default is only served to
+// requests carrying its access key, or when it is listed as trusted
+type Resolver struct {
+	trusted map[string]bool
+	keys    map[string]string // Tenant ID -> access key
+}
+
+// NewResolver creates a tenant resolver
+// Tenants with an access key in keys need it in KeyHeader; tenants in trusted are served on the header
+// alone, for deployments whose gateway authenticates callers and sets it. Any other tenant is refused
+func NewResolver(trusted []string, keys map[string]string) *Resolver {
+	r := &Resolver{trusted: make(map[string]bool, len(trusted)), keys: keys}
+	for _, id := range trusted {
+		r.trusted[id] = true
+	}
+	return r
+}
+
+// Allowed reports whether requests may act on behalf of the tenant, given the access key they carry
+func (r *Resolver) Allowed(id, key string) bool {
+	if !ValidID(id) {
+		return false
+	}
+	if want, ok := r.keys[id]; ok {
+		return subtle.ConstantTimeCompare([]byte(key), []byte(want)) == 1
+	}
+	return id == DefaultID || r.trusted[id]
+}
+
+// Middleware returns an HTTP middleware that stores the request tenant in the context
+// Requests without a tenant header are served as the default tenant
+func (r *Resolver) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(Header)
+			if id == "" {
+				id = DefaultID
+			}
+
+			if !r.Allowed(id, req.Header.Get(KeyHeader)) {
+				slog.WarnContext(req.Context(), "Request for unknown tenant",
+					"tenant_id", id,
+					"method", req.Method,
+					"path", req.URL.Path,
+				)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":"forbidden","message":"unknown tenant"}`))
+				return
+			}
+
+			next.ServeHTTP(w, req.WithContext(WithTenant(req.Context(), id)))
+		})
+	}
+}
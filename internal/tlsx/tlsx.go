@@ -0,0 +1,135 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ServerConfig holds TLS settings for the HTTP server
+type ServerConfig struct {
+	CertFile     string // Server certificate (PEM)
+	KeyFile      string // Server private key (PEM)
+	ClientCAFile string // CA bundle for client certificates; enables mutual TLS when set
+	ClientAuth   string // "require" (default) or "verify_if_given"
+}
+
+// ClientConfig holds TLS settings for outbound calls
+type ClientConfig struct {
+	CertFile string // Client certificate presented to internal services (PEM)
+	KeyFile  string // Client private key (PEM)
+	CAFile   string // CA bundle used to verify internal services; system roots when empty
+}
+
+// Enabled reports whether the server should serve TLS
+func (c ServerConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// Enabled reports whether outbound calls need a custom TLS configuration
+func (c ClientConfig) Enabled() bool {
+	return c.CertFile != "" || c.CAFile != ""
+}
+
+// NewServerTLS builds the server TLS configuration, requiring client certificates when a client CA is configured
+func NewServerTLS(cfg ServerConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if cfg.ClientCAFile == "" {
+		return tlsCfg, nil
+	}
+
+	pool, err := loadCertPool(cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg.ClientCAs = pool
+
+	switch cfg.ClientAuth {
+	case "", "require":
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	case "verify_if_given":
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unknown client auth mode %q", cfg.ClientAuth)
+	}
+
+	return tlsCfg, nil
+}
+
+// NewClientTLS builds the TLS configuration for outbound calls to internal services
+func NewClientTLS(cfg ClientConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return tlsCfg, nil
+}
+
+// NewHTTPClient returns an HTTP client for outbound calls, using mutual TLS when configured
+func NewHTTPClient(cfg ClientConfig, timeout time.Duration) (*http.Client, error) {
+	if !cfg.Enabled() {
+		return &http.Client{Timeout: timeout}, nil
+	}
+
+	tlsCfg, err := NewClientTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
+
+// MustHTTPClient returns an outbound HTTP client or panics on invalid TLS material
+func MustHTTPClient(cfg ClientConfig, timeout time.Duration) *http.Client {
+	client, err := NewHTTPClient(cfg, timeout)
+	if err != nil {
+		panic(fmt.Sprintf("failed to configure outbound TLS: %v", err))
+	}
+	return client
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", file)
+	}
+
+	return pool, nil
+}
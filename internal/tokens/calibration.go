@@ -0,0 +1,89 @@
+package tokens
+
+import (
+	"math"
+	"sync"
+)
+
+// Calibration defaults
+const (
+	// DefaultCalibrationWeight is the weight of each new observation in the rolling factor
+	DefaultCalibrationWeight = 0.1
+	// DefaultCalibrationWarmup is the number of observations before the factor is applied
+	DefaultCalibrationWarmup = 5
+
+	minCalibrationFactor = 0.5
+	maxCalibrationFactor = 3.0
+)
+
+// Calibrator corrects token estimates with the counts the API reports
+// It keeps an exponentially weighted average of actual/estimated tokens and scales estimates by it,
+// so pre-flight checks follow the real tokenizer and message overhead as prompts and tools change
+type Calibrator struct {
+	weight float64
+	warmup int
+
+	mu      sync.Mutex
+	factor  float64
+	samples int
+}
+
+// NewCalibrator creates a calibrator; a weight outside (0, 1] or a negative warmup uses the defaults
+func NewCalibrator(weight float64, warmup int) *Calibrator {
+	if weight <= 0 || weight > 1 {
+		weight = DefaultCalibrationWeight
+	}
+	if warmup < 0 {
+		warmup = DefaultCalibrationWarmup
+	}
+	return &Calibrator{weight: weight, warmup: warmup, factor: 1}
+}
+
+// Observe records the actual token count of a request whose estimate was estimated
+// Counts of zero or less carry no information and are ignored
+func (c *Calibrator) Observe(estimated, actual int) {
+	if estimated <= 0 || actual <= 0 {
+		return
+	}
+	ratio := clampFactor(float64(actual) / float64(estimated))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.samples == 0 {
+		c.factor = ratio
+	} else {
+		c.factor += c.weight * (ratio - c.factor)
+	}
+	c.samples++
+}
+
+// Adjust scales an estimate by the correction factor, rounding up; estimates pass unchanged during warmup
+func (c *Calibrator) Adjust(estimated int) int {
+	factor := c.Factor()
+	if factor == 1 {
+		return estimated
+	}
+	return int(math.Ceil(float64(estimated) * factor))
+}
+
+// Factor returns the correction factor applied by Adjust
+func (c *Calibrator) Factor() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.samples < c.warmup {
+		return 1
+	}
+	return c.factor
+}
+
+// Samples returns the number of observations
+func (c *Calibrator) Samples() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.samples
+}
+
+// clampFactor bounds a ratio, so one odd response cannot make estimates useless
+func clampFactor(ratio float64) float64 {
+	return math.Min(math.Max(ratio, minCalibrationFactor), maxCalibrationFactor)
+}
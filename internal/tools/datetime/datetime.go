@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
 	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
 )
 
@@ -33,9 +34,9 @@ func (d *DateTimeTool) Parameters() map[string]interface{} {
 	}
 }
 
-// Execute returns the current date and time
+// Execute returns the current date and time in the user's timezone
 func (d *DateTimeTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
-	return time.Now().Format(time.RFC3339), nil
+	return time.Now().In(settings.FromContext(ctx).Location()).Format(time.RFC3339), nil
 }
 
 // Ensure DateTimeTool implements registry.Tool interface
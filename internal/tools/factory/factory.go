@@ -2,11 +2,15 @@ package factory
 
 import (
 	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/outbound"
 	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tlsx"
 	"github.com/8adimka/Go_AI_Assistant/internal/tools/datetime"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/fetchurl"
 	"github.com/8adimka/Go_AI_Assistant/internal/tools/holidays"
 	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
 	"github.com/8adimka/Go_AI_Assistant/internal/weather"
@@ -33,15 +37,33 @@ func (f *Factory) CreateAllTools() *registry.ToolRegistry {
 	// Create Redis cache for weather service with configurable TTL
 	redisClient := redisx.MustConnect(f.config.RedisAddr)
 	cacheTTL := time.Duration(f.config.CacheTTLHours) * time.Hour
-	cache := redisx.NewCache(redisClient, cacheTTL)
+	cache := redisx.NewCache(redisClient, cacheTTL, redisx.WithCodec(redisx.MustCodec(f.config.CacheCodec)))
+
+	// Outbound HTTP client for tool services, presenting a client certificate when mTLS is configured
+	// and refusing destinations the outbound policy does not allow
+	policy := outbound.MustPolicy(outbound.Config{
+		AllowedSchemes:  f.config.OutboundAllowedSchemes,
+		AllowedHosts:    f.config.OutboundAllowedHosts,
+		DeniedHosts:     f.config.OutboundDeniedHosts,
+		PrivateNetworks: f.config.OutboundPrivateNetworks,
+		MaxRedirects:    f.config.OutboundMaxRedirects,
+	})
+	httpClient := policy.MustClient(tlsx.MustHTTPClient(tlsx.ClientConfig{
+		CertFile: f.config.OutboundTLSCertFile,
+		KeyFile:  f.config.OutboundTLSKeyFile,
+		CAFile:   f.config.OutboundTLSCAFile,
+	}, 10*time.Second))
 
 	// Create weather service with fallback
-	weatherService := weather.CreateWeatherService(f.config.WeatherApiKey, cache)
+	weatherService := weather.CreateWeatherService(f.config.WeatherApiKey, cache, httpClient)
 
 	// Register all tools
 	f.registerDateTimeTool()
 	f.registerWeatherTool(weatherService)
-	f.registerHolidaysTool()
+	f.registerHolidaysTool(httpClient)
+	if f.config.FetchURLEnabled {
+		f.registerFetchURLTool()
+	}
 
 	slog.Info("All tools registered successfully", "count", f.registry.Count())
 	return f.registry
@@ -60,13 +82,25 @@ func (f *Factory) registerWeatherTool(weatherService *weather.FallbackWeatherSer
 }
 
 // registerHolidaysTool registers the holidays tool
-func (f *Factory) registerHolidaysTool() {
-	// Use default calendar URL, can be overridden by environment variable
-	calendarURL := "https://www.officeholidays.com/ics/spain/catalonia"
-	holidaysTool := holidays.New(calendarURL)
+func (f *Factory) registerHolidaysTool(httpClient *http.Client) {
+	// The calendar URL comes from HOLIDAY_CALENDAR_LINK; the outbound policy guards where it points
+	holidaysTool := holidays.New(f.config.HolidayCalendarLink, httpClient)
 	f.registry.Register(holidaysTool)
 }
 
+// registerFetchURLTool registers the URL fetching tool
+// It uses its own HTTP client: arbitrary sites must never see the outbound mTLS certificate,
+// nor reach the private networks internal tool services live on
+func (f *Factory) registerFetchURLTool() {
+	fetchTool := fetchurl.New(fetchurl.Config{
+		AllowedHosts: f.config.FetchURLAllowedHosts,
+		DeniedHosts:  append(append([]string{}, f.config.OutboundDeniedHosts...), f.config.FetchURLDeniedHosts...),
+		MaxBytes:     int64(f.config.FetchURLMaxBytes),
+		MaxChars:     f.config.FetchURLMaxChars,
+	})
+	f.registry.Register(fetchTool)
+}
+
 // GetRegistry returns the tool registry
 func (f *Factory) GetRegistry() *registry.ToolRegistry {
 	return f.registry
@@ -0,0 +1,127 @@
+package fetchurl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Page is the readable content of a fetched document
+type Page struct {
+	Title       string
+	Description string
+	Text        string
+}
+
+// skippedElements never contain readable article text
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Nav: true, atom.Footer: true,
+	atom.Header: true, atom.Aside: true, atom.Form: true, atom.Button: true,
+}
+
+// blockElements end a line of text
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Article: true, atom.Section: true, atom.Blockquote: true, atom.Pre: true,
+}
+
+// ExtractHTML returns the title, description and visible text of an HTML document
+func ExtractHTML(r io.Reader) (*Page, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{}
+	var text strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if page.Title == "" && n.FirstChild != nil {
+					page.Title = collapseSpaces(n.FirstChild.Data)
+				}
+				return
+			case atom.Meta:
+				name := strings.ToLower(attr(n, "name") + attr(n, "property"))
+				if page.Description == "" && (name == "description" || name == "og:description") {
+					page.Description = collapseSpaces(attr(n, "content"))
+				}
+				return
+			}
+			if skippedElements[n.DataAtom] {
+				return
+			}
+		}
+
+		if n.Type == html.TextNode {
+			if s := collapseSpaces(n.Data); s != "" {
+				text.WriteString(s)
+				text.WriteString(" ")
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+			text.WriteString("\n")
+		}
+	}
+	walk(doc)
+
+	page.Text = normalizeLines(text.String())
+	return page, nil
+}
+
+// Format renders the page as a link preview followed by its text, truncated to maxChars
+func (p *Page) Format(url string, maxChars int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "URL: %s\n", url)
+	if p.Title != "" {
+		fmt.Fprintf(&b, "Title: %s\n", p.Title)
+	}
+	if p.Description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", p.Description)
+	}
+	b.WriteString("\n")
+
+	text := p.Text
+	if runes := []rune(text); len(runes) > maxChars {
+		text = string(runes[:maxChars]) + "\n[content truncated]"
+	}
+	b.WriteString(text)
+
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func normalizeLines(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
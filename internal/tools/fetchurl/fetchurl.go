@@ -0,0 +1,160 @@
+package fetchurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/outbound"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
+)
+
+// ErrBlocked is returned when a URL or the address it resolves to is not allowed
+var ErrBlocked = outbound.ErrBlocked
+
+// Config limits which URLs the tool may fetch and how much it reads
+type Config struct {
+	AllowedHosts []string // If set, only these hosts and their subdomains may be fetched
+	DeniedHosts  []string // Hosts and their subdomains that are never fetched
+	MaxBytes     int64    // Maximum response body size read
+	MaxChars     int      // Maximum characters of extracted text returned to the model
+	Timeout      time.Duration
+}
+
+// FetchURLTool downloads a web page and extracts its readable text
+type FetchURLTool struct {
+	cfg    Config
+	policy *outbound.Policy
+	client *http.Client
+}
+
+// New creates a new FetchURLTool instance
+// Requests never reach private, loopback or link-local addresses, even through redirects or DNS rebinding
+func New(cfg Config) *FetchURLTool {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 2 << 20
+	}
+	if cfg.MaxChars <= 0 {
+		cfg.MaxChars = 8000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	policy := outbound.MustPolicy(outbound.Config{
+		AllowedHosts: cfg.AllowedHosts,
+		DeniedHosts:  cfg.DeniedHosts,
+	})
+
+	return &FetchURLTool{
+		cfg:    cfg,
+		policy: policy,
+		client: policy.MustClient(&http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				TLSHandshakeTimeout:   cfg.Timeout,
+				ResponseHeaderTimeout: cfg.Timeout,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       30 * time.Second,
+			},
+		}),
+	}
+}
+
+// Name returns the tool name
+func (t *FetchURLTool) Name() string {
+	return "fetch_url"
+}
+
+// Description returns the tool description
+func (t *FetchURLTool) Description() string {
+	return "Downloads a public web page the user linked and returns its title, description and readable text, " +
+		"so it can be summarized or answered about. Only http and https URLs are supported."
+}
+
+// Parameters returns the JSON schema for parameters
+func (t *FetchURLTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "The full http or https URL provided by the user",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+// Execute fetches the URL and returns a link preview followed by the page text
+func (t *FetchURLTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	rawURL, ok := args["url"].(string)
+	if !ok || strings.TrimSpace(rawURL) == "" {
+		return "", errors.New("url parameter is required")
+	}
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	if err := t.policy.CheckURL(u); err != nil {
+		slog.WarnContext(ctx, "URL fetch blocked", "host", u.Hostname(), "error", err)
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Go-AI-Assistant/1.0 (+link preview)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9")
+
+	start := time.Now()
+	resp, err := t.client.Do(req)
+	if err != nil {
+		slog.WarnContext(ctx, "URL fetch failed", "host", u.Hostname(), "error", err)
+		return "", fmt.Errorf("failed to fetch url: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch url: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.cfg.MaxBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var page *Page
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		page, err = ExtractHTML(strings.NewReader(string(body)))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse page: %w", err)
+		}
+	case "text/plain", "":
+		page = &Page{Text: string(body)}
+	default:
+		return "", fmt.Errorf("unsupported content type %s", mediaType)
+	}
+
+	slog.InfoContext(ctx, "URL fetched",
+		"host", resp.Request.URL.Hostname(),
+		"content_type", mediaType,
+		"bytes", len(body),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return page.Format(resp.Request.URL.String(), t.cfg.MaxChars), nil
+}
+
+// Ensure FetchURLTool implements registry.Tool interface
+var _ registry.Tool = (*FetchURLTool)(nil)
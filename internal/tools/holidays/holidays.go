@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -15,12 +16,18 @@ import (
 // HolidaysTool provides holiday information from iCal calendar
 type HolidaysTool struct {
 	calendarURL string
+	httpClient  *http.Client
 }
 
 // New creates a new HolidaysTool instance
-func New(calendarURL string) *HolidaysTool {
+// A nil httpClient uses http.DefaultClient
+func New(calendarURL string, httpClient *http.Client) *HolidaysTool {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
 	return &HolidaysTool{
 		calendarURL: calendarURL,
+		httpClient:  httpClient,
 	}
 }
 
@@ -121,7 +128,7 @@ func (h *HolidaysTool) loadCalendar(ctx context.Context, url string) ([]*ics.VEv
 		calendarURL = envURL
 	}
 
-	cal, err := ics.ParseCalendarFromUrl(calendarURL)
+	cal, err := ics.ParseCalendarFromUrl(calendarURL, ctx, h.httpClient)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,93 @@
+package pinfact
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
+)
+
+// MaxFactChars caps the length of a single pinned fact
+const MaxFactChars = 300
+
+// ToolName is the name the model calls the tool by
+const ToolName = "pin_fact"
+
+// Pinner stores facts that must stay in a conversation's context, see chat.ContextManager
+type Pinner interface {
+	PinFact(ctx context.Context, conversationID, fact string) error
+}
+
+type contextKey struct{}
+
+// WithConversation returns a context carrying the ID of the conversation being answered
+func WithConversation(ctx context.Context, conversationID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, conversationID)
+}
+
+// ConversationFromContext returns the conversation ID of the context, or "" if none is set
+func ConversationFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// PinFactTool lets the model pin critical details so they survive context summarization and truncation
+type PinFactTool struct {
+	pinner Pinner
+}
+
+// New creates a new PinFactTool instance
+func New(pinner Pinner) *PinFactTool {
+	return &PinFactTool{pinner: pinner}
+}
+
+// Name returns the tool name
+func (t *PinFactTool) Name() string {
+	return ToolName
+}
+
+// Description returns the tool description
+func (t *PinFactTool) Description() string {
+	return "Pins a critical fact from the conversation, such as an order number, a name, an address or a date, " +
+		"so it is never forgotten as the conversation grows. Pin only details the user will rely on later."
+}
+
+// Parameters returns the JSON schema for parameters
+func (t *PinFactTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"fact": map[string]interface{}{
+				"type":        "string",
+				"description": "The fact as a short self-contained statement, e.g. \"Order number is 48213\"",
+			},
+		},
+		"required": []string{"fact"},
+	}
+}
+
+// Execute pins the fact to the conversation being answered
+func (t *PinFactTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	fact, ok := args["fact"].(string)
+	fact = strings.Join(strings.Fields(fact), " ")
+	if !ok || fact == "" {
+		return "", errors.New("fact parameter is required")
+	}
+	if len(fact) > MaxFactChars {
+		return "", errors.New("fact is too long, keep it to a short statement")
+	}
+
+	conversationID := ConversationFromContext(ctx)
+	if conversationID == "" {
+		return "", errors.New("no conversation to pin the fact to")
+	}
+
+	if err := t.pinner.PinFact(ctx, conversationID, fact); err != nil {
+		return "", err
+	}
+	return "Pinned: " + fact, nil
+}
+
+// Ensure PinFactTool implements registry.Tool interface
+var _ registry.Tool = (*PinFactTool)(nil)
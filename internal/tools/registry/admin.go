@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// ToolInfo describes a registered tool as the model sees it, with its limits and recent health
+type ToolInfo struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`           // JSON schema of the arguments
+	RateLimit   *RateLimit             `json:"rate_limit,omitempty"` // Unset when calls are not limited
+	Recent      Stats                  `json:"recent"`
+}
+
+// Describe returns every registered tool, sorted by name
+func (r *ToolRegistry) Describe() []ToolInfo {
+	infos := make([]ToolInfo, 0, len(r.tools))
+	for _, tool := range r.tools {
+		info := ToolInfo{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  tool.Parameters(),
+			Recent:      r.Stats(tool.Name()),
+		}
+		if limited, ok := tool.(RateLimited); ok {
+			info.RateLimit = limited.RateLimit()
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// AdminHandler lists the tools the assistant can call in this deployment over HTTP
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	tools *ToolRegistry
+}
+
+// NewAdminHandler creates a new tools admin handler
+func NewAdminHandler(tools *ToolRegistry) *AdminHandler {
+	return &AdminHandler{tools: tools}
+}
+
+// ListHandler handles GET /admin/tools
+// Recent call statistics are those of the instance serving the request
+func (h *AdminHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tools":                h.tools.Describe(),
+		"stats_window_seconds": int(StatsWindow.Seconds()),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
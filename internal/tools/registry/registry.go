@@ -3,6 +3,8 @@ package registry
 import (
 	"context"
 	"log/slog"
+	"sync"
+	"time"
 )
 
 // Tool defines the interface that all tools must implement
@@ -20,15 +22,78 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]interface{}) (string, error)
 }
 
+// RateLimit is the rate calls of a tool are limited to, e.g. by the API behind it
+type RateLimit struct {
+	PerMinute float64 `json:"per_minute"` // Sustained calls per minute
+	Burst     int     `json:"burst"`      // Calls allowed at once before the sustained rate applies
+}
+
+// RateLimited is implemented by tools whose calls are rate limited
+type RateLimited interface {
+	// RateLimit returns nil when calls are not limited
+	RateLimit() *RateLimit
+}
+
+// StatsWindow is how far back call statistics reach
+const StatsWindow = 15 * time.Minute
+
+// Stats counts the calls of a tool on this instance over the last StatsWindow
+type Stats struct {
+	Calls     int     `json:"calls"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"` // Errors per call, 0 without calls
+}
+
+// callBucket counts the calls of one minute
+type callBucket struct {
+	minute int64 // Unix minute
+	calls  int
+	errors int
+}
+
+// callWindow keeps a bucket per minute of StatsWindow, reused as minutes pass
+type callWindow [int(StatsWindow / time.Minute)]callBucket
+
+func (w *callWindow) record(now time.Time, failed bool) {
+	minute := now.Unix() / 60
+	b := &w[minute%int64(len(w))]
+	if b.minute != minute {
+		*b = callBucket{minute: minute}
+	}
+	b.calls++
+	if failed {
+		b.errors++
+	}
+}
+
+func (w *callWindow) stats(now time.Time) Stats {
+	minute := now.Unix() / 60
+	var s Stats
+	for _, b := range w {
+		if minute-b.minute < int64(len(w)) {
+			s.Calls += b.calls
+			s.Errors += b.errors
+		}
+	}
+	if s.Calls > 0 {
+		s.ErrorRate = float64(s.Errors) / float64(s.Calls)
+	}
+	return s
+}
+
 // ToolRegistry manages the registration and retrieval of tools
 type ToolRegistry struct {
 	tools map[string]Tool
+
+	mu    sync.Mutex
+	calls map[string]*callWindow
 }
 
 // NewToolRegistry creates a new empty tool registry
 func NewToolRegistry() *ToolRegistry {
 	return &ToolRegistry{
 		tools: make(map[string]Tool),
+		calls: make(map[string]*callWindow),
 	}
 }
 
@@ -75,3 +140,26 @@ func (r *ToolRegistry) HasTool(name string) bool {
 func (r *ToolRegistry) Count() int {
 	return len(r.tools)
 }
+
+// RecordCall counts a call of a registered tool and whether it failed
+func (r *ToolRegistry) RecordCall(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	window, ok := r.calls[name]
+	if !ok {
+		window = &callWindow{}
+		r.calls[name] = window
+	}
+	window.record(time.Now(), err != nil)
+}
+
+// Stats returns the calls of a tool recorded over the last StatsWindow
+func (r *ToolRegistry) Stats(name string) Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	window, ok := r.calls[name]
+	if !ok {
+		return Stats{}
+	}
+	return window.stats(time.Now())
+}
@@ -0,0 +1,57 @@
+package topics
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+// SummaryStore aggregates conversation topics, see model.Repository
+type SummaryStore interface {
+	SummarizeTopics(ctx context.Context, f model.ConversationFilter) (*model.TopicSummary, error)
+}
+
+// AdminHandler exposes topic analytics over HTTP
+// It must be mounted behind admin.Authenticator; it only reads, so any admin role may use it
+type AdminHandler struct {
+	store SummaryStore
+}
+
+// NewAdminHandler creates a new topic analytics admin handler
+func NewAdminHandler(store SummaryStore) *AdminHandler {
+	return &AdminHandler{store: store}
+}
+
+// SummaryHandler handles GET /admin/analytics/topics?platform=telegram&since=2024-05-01T00:00:00Z
+func (h *AdminHandler) SummaryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.ConversationFilter{Platform: query.Get("platform")}
+
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "since must be an RFC 3339 timestamp"})
+			return
+		}
+		filter.CreatedAfter = t
+	}
+
+	summary, err := h.store.SummarizeTopics(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to summarize topics", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to summarize topics"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
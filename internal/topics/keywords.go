@@ -0,0 +1,64 @@
+package topics
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// DefaultKeywords are the words and phrases that tag a message with each topic
+var DefaultKeywords = map[string][]string{
+	TopicWeather: {
+		"weather", "forecast", "temperature", "rain", "raining", "rainy", "snow", "snowing", "sunny",
+		"cloudy", "windy", "wind", "storm", "humidity", "umbrella", "degrees", "celsius", "fahrenheit",
+	},
+	TopicSmalltalk: {
+		"hi", "hello", "hey", "good morning", "good evening", "how are you", "thanks", "thank you",
+		"bye", "goodbye", "who are you", "what is your name", "joke", "nice to meet you",
+	},
+	TopicSupport: {
+		"help", "error", "bug", "broken", "not working", "doesn't work", "does not work", "issue",
+		"problem", "crash", "refund", "complaint", "support", "account", "password", "login", "cancel subscription",
+	},
+	TopicScheduling: {
+		"schedule", "meeting", "appointment", "calendar", "event", "remind", "reminder", "book",
+		"booking", "reschedule", "tomorrow", "next week", "what time", "holiday", "holidays",
+	},
+}
+
+// KeywordClassifier tags messages whose words match the topic keywords
+// It is free and instant, but only sees the words it knows
+type KeywordClassifier struct {
+	keywords map[string][]string
+}
+
+// NewKeywordClassifier creates a classifier from topic keywords; nil uses DefaultKeywords
+func NewKeywordClassifier(keywords map[string][]string) *KeywordClassifier {
+	if keywords == nil {
+		keywords = DefaultKeywords
+	}
+	return &KeywordClassifier{keywords: keywords}
+}
+
+// Classify returns the topics, in the order of All, with a keyword among the message's words
+func (c *KeywordClassifier) Classify(ctx context.Context, text string) ([]string, error) {
+	words := " " + normalize(text) + " "
+
+	var found []string
+	for _, topic := range All {
+		for _, kw := range c.keywords[topic] {
+			if strings.Contains(words, " "+kw+" ") {
+				found = append(found, topic)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// normalize lowercases text and collapses everything but letters, digits and apostrophes into single spaces
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}), " ")
+}
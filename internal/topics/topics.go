@@ -0,0 +1,146 @@
+package topics
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Coarse conversation topics
+const (
+	TopicWeather    = "weather"
+	TopicSmalltalk  = "smalltalk"
+	TopicSupport    = "support"
+	TopicScheduling = "scheduling"
+)
+
+// All lists the known topics in a stable order
+var All = []string{TopicWeather, TopicSmalltalk, TopicSupport, TopicScheduling}
+
+// IsKnown reports whether topic is one of All
+func IsKnown(topic string) bool {
+	return slices.Contains(All, topic)
+}
+
+// Classifier labels a user message with the topics it touches; no topics is a valid answer
+type Classifier interface {
+	Classify(ctx context.Context, text string) ([]string, error)
+}
+
+// Store tags conversations with topics, see model.Repository
+type Store interface {
+	// AddConversationTopics returns the topics the conversation did not have before
+	AddConversationTopics(ctx context.Context, conversationID primitive.ObjectID, topics []string) ([]string, error)
+}
+
+// Recorder exports topics for dashboards, see metrics.Metrics
+type Recorder interface {
+	RecordConversationTopic(ctx context.Context, platform, topic string)
+}
+
+// Config controls sampling of user messages
+type Config struct {
+	SampleRate float64 // Share of user messages classified, from 0 to 1
+	QueueSize  int     // Messages waiting to be classified before new ones are dropped
+}
+
+type observation struct {
+	tenantID       string
+	platform       string
+	conversationID primitive.ObjectID
+	text           string
+}
+
+// Tagger classifies sampled user messages in the background and tags their conversations
+type Tagger struct {
+	classifier Classifier
+	store      Store
+	recorder   Recorder
+	cfg        Config
+	queue      chan observation
+}
+
+// NewTagger creates a topic tagger; recorder may be nil
+func NewTagger(classifier Classifier, store Store, recorder Recorder, cfg Config) *Tagger {
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		cfg.SampleRate = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+
+	return &Tagger{
+		classifier: classifier,
+		store:      store,
+		recorder:   recorder,
+		cfg:        cfg,
+		queue:      make(chan observation, cfg.QueueSize),
+	}
+}
+
+// Observe samples a user message of the conversation for classification
+// Never blocks; returns whether the message was queued
+func (t *Tagger) Observe(conv *model.Conversation, msg *model.Message) bool {
+	if msg.Role != model.RoleUser || msg.Content == "" || rand.Float64() >= t.cfg.SampleRate {
+		return false
+	}
+
+	select {
+	case t.queue <- observation{
+		tenantID:       conv.TenantID,
+		platform:       conv.Platform,
+		conversationID: conv.ID,
+		text:           msg.Content,
+	}:
+		return true
+	default:
+		slog.Warn("Topic queue is full, skipping message", "conversation_id", conv.ID.Hex())
+		return false
+	}
+}
+
+// Run classifies queued messages until the context is cancelled
+func (t *Tagger) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Topic tagger started", "sample_rate", t.cfg.SampleRate)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Topic tagger stopped", "pending", len(t.queue))
+			return
+		case obs := <-t.queue:
+			t.process(tenant.WithTenant(ctx, obs.tenantID), obs)
+		}
+	}
+}
+
+func (t *Tagger) process(ctx context.Context, obs observation) {
+	found, err := t.classifier.Classify(ctx, obs.text)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to classify message topics",
+			"conversation_id", obs.conversationID.Hex(), "error", err)
+		return
+	}
+	if len(found) == 0 {
+		return
+	}
+
+	added, err := t.store.AddConversationTopics(ctx, obs.conversationID, found)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to store conversation topics",
+			"conversation_id", obs.conversationID.Hex(), "error", err)
+		return
+	}
+
+	// Count each conversation once per topic, not every message about it
+	if t.recorder != nil {
+		for _, topic := range added {
+			t.recorder.RecordConversationTopic(ctx, obs.platform, topic)
+		}
+	}
+}
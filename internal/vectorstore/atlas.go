@@ -0,0 +1,160 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultAtlasCollection and DefaultAtlasIndex name the collection and index of the Atlas backend
+const (
+	DefaultAtlasCollection = "vectors"
+	DefaultAtlasIndex      = "vector_index"
+)
+
+// Atlas stores vectors in MongoDB and searches them with Atlas Vector Search
+// The collection needs a vectorSearch index on "vector" (cosine similarity) with "tenant_id"
+// and every filtered "metadata.<key>" declared as filter fields
+type Atlas struct {
+	coll       *mongo.Collection
+	index      string
+	dimensions int
+}
+
+// NewAtlas creates an Atlas Vector Search store; empty names use the defaults
+func NewAtlas(db *mongo.Database, collection, index string, dimensions int) *Atlas {
+	if collection == "" {
+		collection = DefaultAtlasCollection
+	}
+	if index == "" {
+		index = DefaultAtlasIndex
+	}
+	return &Atlas{coll: db.Collection(collection), index: index, dimensions: dimensions}
+}
+
+func (a *Atlas) Upsert(ctx context.Context, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tenantID := tenant.FromContext(ctx)
+
+	models := make([]mongo.WriteModel, 0, len(records))
+	for _, rec := range records {
+		if rec.ID == "" {
+			return errors.New("record ID is required")
+		}
+		if err := checkDimensions(a.dimensions, rec.Vector); err != nil {
+			return err
+		}
+		rec.TenantID = tenantID
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": documentID(tenantID, rec.ID)}).
+			SetReplacement(rec).
+			SetUpsert(true))
+	}
+
+	_, err := a.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (a *Atlas) Search(ctx context.Context, vector []float32, limit int, filter map[string]string) ([]Match, error) {
+	if err := checkDimensions(a.dimensions, vector); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	preFilter := bson.M{"tenant_id": tenant.FromContext(ctx)}
+	for k, v := range filter {
+		preFilter["metadata."+k] = v
+	}
+
+	cursor, err := a.coll.Aggregate(ctx, bson.A{
+		bson.M{"$vectorSearch": bson.M{
+			"index":         a.index,
+			"path":          "vector",
+			"queryVector":   vector,
+			"numCandidates": limit * 10,
+			"limit":         limit,
+			"filter":        preFilter,
+		}},
+		bson.M{"$addFields": bson.M{"score": bson.M{"$meta": "vectorSearchScore"}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		Record `bson:",inline"`
+		Score  float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	found := make([]Match, 0, len(docs))
+	for _, doc := range docs {
+		// Atlas reports cosine similarity scaled to [0, 1]
+		found = append(found, Match{Record: doc.Record, Score: 2*doc.Score - 1})
+	}
+	return found, nil
+}
+
+func (a *Atlas) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tenantID := tenant.FromContext(ctx)
+	docIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		docIDs = append(docIDs, documentID(tenantID, id))
+	}
+	_, err := a.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": docIDs}})
+	return err
+}
+
+func (a *Atlas) Scan(ctx context.Context, batchSize int, fn func([]Record) error) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	cursor, err := a.coll.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(int32(batchSize)))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	batch := make([]Record, 0, batchSize)
+	for cursor.Next(ctx) {
+		var rec Record
+		if err := cursor.Decode(&rec); err != nil {
+			return err
+		}
+		batch = append(batch, rec)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]Record, 0, batchSize)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// documentID keeps record IDs of different tenants apart
+func documentID(tenantID, id string) string {
+	return tenantID + ":" + id
+}
+
+var _ VectorStore = (*Atlas)(nil)
@@ -0,0 +1,437 @@
+package vectorstore
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// HNSW defaults
+const (
+	DefaultHNSWM              = 16
+	DefaultHNSWEfConstruction = 200
+	DefaultHNSWEfSearch       = 64
+
+	// minRebuildDeleted is the number of deleted records below which the graph is never rebuilt
+	minRebuildDeleted = 64
+)
+
+type hnswNode struct {
+	record    Record    // As stored, for Scan and snapshots
+	vector    []float32 // Normalized
+	neighbors [][]int32 // Per layer, up to the node's level
+	deleted   bool
+}
+
+// hnswGraph is the index of one tenant
+type hnswGraph struct {
+	nodes    []*hnswNode
+	byID     map[string]int32
+	entry    int32 // -1 while empty
+	maxLevel int
+	deleted  int
+}
+
+func newGraph() *hnswGraph {
+	return &hnswGraph{byID: make(map[string]int32), entry: -1}
+}
+
+// HNSW is an in-process Hierarchical Navigable Small World index
+// It needs no infrastructure and suits development and small deployments; each instance has its own copy,
+// which lives in memory unless saved to a snapshot file
+type HNSW struct {
+	dimensions     int
+	m              int
+	mMax0          int
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+
+	mu     sync.RWMutex
+	graphs map[string]*hnswGraph
+	rng    *rand.Rand
+}
+
+// NewHNSW creates an empty index; zero parameters use the defaults
+func NewHNSW(dimensions, m, efConstruction, efSearch int) *HNSW {
+	if m <= 1 {
+		m = DefaultHNSWM
+	}
+	if efConstruction <= 0 {
+		efConstruction = DefaultHNSWEfConstruction
+	}
+	if efSearch <= 0 {
+		efSearch = DefaultHNSWEfSearch
+	}
+	return &HNSW{
+		dimensions:     dimensions,
+		m:              m,
+		mMax0:          2 * m,
+		efConstruction: efConstruction,
+		efSearch:       efSearch,
+		levelMult:      1 / math.Log(float64(m)),
+		graphs:         make(map[string]*hnswGraph),
+		rng:            rand.New(rand.NewPCG(1, 2)),
+	}
+}
+
+func (h *HNSW) Upsert(ctx context.Context, records []Record) error {
+	for _, rec := range records {
+		if rec.ID == "" {
+			return errors.New("record ID is required")
+		}
+		if err := checkDimensions(h.dimensions, rec.Vector); err != nil {
+			return err
+		}
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g, ok := h.graphs[tenantID]
+	if !ok {
+		g = newGraph()
+		h.graphs[tenantID] = g
+	}
+	for _, rec := range records {
+		rec.TenantID = tenantID
+		rec.Vector = slices.Clone(rec.Vector)
+		h.remove(g, rec.ID)
+		h.insert(g, rec)
+	}
+	h.maybeRebuild(tenantID, g)
+	return nil
+}
+
+func (h *HNSW) Search(ctx context.Context, vector []float32, limit int, filter map[string]string) ([]Match, error) {
+	if err := checkDimensions(h.dimensions, vector); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	g, ok := h.graphs[tenant.FromContext(ctx)]
+	if !ok || g.entry < 0 {
+		return nil, nil
+	}
+	q := normalize(vector)
+
+	ep := g.entry
+	for l := g.maxLevel; l > 0; l-- {
+		ep = g.greedy(q, ep, l)
+	}
+	found := g.searchLayer(q, ep, max(h.efSearch, limit), 0)
+
+	results := make([]Match, 0, limit)
+	for _, c := range found {
+		node := g.nodes[c.idx]
+		if node.deleted || !matches(node.record.Metadata, filter) {
+			continue
+		}
+		results = append(results, Match{Record: node.record, Score: 1 - c.dist})
+		if len(results) == limit {
+			return results, nil
+		}
+	}
+
+	// Deleted records and selective filters can leave the graph search short; fall back to an exact scan
+	if live := len(g.byID); len(results) < live && (len(filter) > 0 || g.deleted > 0) {
+		return g.exact(q, limit, filter), nil
+	}
+	return results, nil
+}
+
+func (h *HNSW) Delete(ctx context.Context, ids []string) error {
+	tenantID := tenant.FromContext(ctx)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g, ok := h.graphs[tenantID]
+	if !ok {
+		return nil
+	}
+	for _, id := range ids {
+		h.remove(g, id)
+	}
+	h.maybeRebuild(tenantID, g)
+	return nil
+}
+
+func (h *HNSW) Scan(ctx context.Context, batchSize int, fn func([]Record) error) error {
+	// Copy first, so fn may write to this index
+	records := h.records()
+	if batchSize <= 0 {
+		batchSize = len(records)
+	}
+	for start := 0; start < len(records); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(records[start:min(start+batchSize, len(records))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of stored records of every tenant
+func (h *HNSW) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	n := 0
+	for _, g := range h.graphs {
+		n += len(g.byID)
+	}
+	return n
+}
+
+// Save writes the records to a snapshot file; the graph is rebuilt on Load
+func (h *HNSW) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(h.records()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load adds the records of a snapshot file written by Save; a missing file leaves the index unchanged
+func (h *HNSW) Load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	if err := gob.NewDecoder(f).Decode(&records); err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	for tenantID, batch := range byTenant(records) {
+		if err := h.Upsert(tenant.WithTenant(context.Background(), tenantID), batch); err != nil {
+			return fmt.Errorf("failed to load snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// records returns the live records of every tenant, ordered by tenant and ID
+func (h *HNSW) records() []Record {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var records []Record
+	for _, g := range h.graphs {
+		for _, idx := range g.byID {
+			records = append(records, g.nodes[idx].record)
+		}
+	}
+	slices.SortFunc(records, func(a, b Record) int {
+		return cmp.Or(cmp.Compare(a.TenantID, b.TenantID), cmp.Compare(a.ID, b.ID))
+	})
+	return records
+}
+
+func (h *HNSW) insert(g *hnswGraph, rec Record) {
+	vec := normalize(rec.Vector)
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	node := &hnswNode{record: rec, vector: vec, neighbors: make([][]int32, level+1)}
+	idx := int32(len(g.nodes))
+	g.nodes = append(g.nodes, node)
+	g.byID[rec.ID] = idx
+
+	if g.entry < 0 {
+		g.entry, g.maxLevel = idx, level
+		return
+	}
+
+	ep := g.entry
+	for l := g.maxLevel; l > level; l-- {
+		ep = g.greedy(vec, ep, l)
+	}
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		candidates := g.searchLayer(vec, ep, h.efConstruction, l)
+		maxConn := h.m
+		if l == 0 {
+			maxConn = h.mMax0
+		}
+		for _, c := range candidates[:min(h.m, len(candidates))] {
+			node.neighbors[l] = append(node.neighbors[l], c.idx)
+			neighbor := g.nodes[c.idx]
+			neighbor.neighbors[l] = append(neighbor.neighbors[l], idx)
+			if len(neighbor.neighbors[l]) > maxConn {
+				g.prune(c.idx, l, maxConn)
+			}
+		}
+		ep = candidates[0].idx
+	}
+
+	if level > g.maxLevel {
+		g.entry, g.maxLevel = idx, level
+	}
+}
+
+// remove marks a record deleted; the node stays in the graph to keep it connected until the next rebuild
+func (h *HNSW) remove(g *hnswGraph, id string) {
+	idx, ok := g.byID[id]
+	if !ok {
+		return
+	}
+	g.nodes[idx].deleted = true
+	delete(g.byID, id)
+	g.deleted++
+}
+
+// maybeRebuild builds a fresh graph once most nodes are deleted, so searches stop walking through them
+func (h *HNSW) maybeRebuild(tenantID string, g *hnswGraph) {
+	if g.deleted < minRebuildDeleted || g.deleted < len(g.byID) {
+		return
+	}
+	if len(g.byID) == 0 {
+		delete(h.graphs, tenantID)
+		return
+	}
+
+	rebuilt := newGraph()
+	for _, node := range g.nodes {
+		if !node.deleted {
+			h.insert(rebuilt, node.record)
+		}
+	}
+	h.graphs[tenantID] = rebuilt
+}
+
+func (g *hnswGraph) distance(q []float32, idx int32) float64 {
+	return 1 - dot(q, g.nodes[idx].vector)
+}
+
+// greedy walks layer l from ep towards q and returns the closest node it reaches
+func (g *hnswGraph) greedy(q []float32, ep int32, l int) int32 {
+	best, bestDist := ep, g.distance(q, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range g.nodes[best].neighbors[l] {
+			if d := g.distance(q, n); d < bestDist {
+				best, bestDist, changed = n, d, true
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer returns up to ef nodes of layer l closest to q, closest first
+func (g *hnswGraph) searchLayer(q []float32, ep int32, ef, l int) []candidate {
+	visited := map[int32]bool{ep: true}
+	start := candidate{idx: ep, dist: g.distance(q, ep)}
+	frontier := &minQueue{start}
+	results := &maxQueue{start}
+
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(candidate)
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+		for _, n := range g.nodes[c.idx].neighbors[l] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			d := g.distance(q, n)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(frontier, candidate{idx: n, dist: d})
+				heap.Push(results, candidate{idx: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := []candidate(*results)
+	slices.SortFunc(out, func(a, b candidate) int { return cmp.Compare(a.dist, b.dist) })
+	return out
+}
+
+// prune keeps the maxConn neighbors of node idx on layer l closest to it
+func (g *hnswGraph) prune(idx int32, l, maxConn int) {
+	node := g.nodes[idx]
+	slices.SortFunc(node.neighbors[l], func(a, b int32) int {
+		return cmp.Compare(g.distance(node.vector, a), g.distance(node.vector, b))
+	})
+	node.neighbors[l] = node.neighbors[l][:maxConn]
+}
+
+// exact compares q with every live node
+func (g *hnswGraph) exact(q []float32, limit int, filter map[string]string) []Match {
+	var all []Match
+	for _, idx := range g.byID {
+		node := g.nodes[idx]
+		if matches(node.record.Metadata, filter) {
+			all = append(all, Match{Record: node.record, Score: 1 - g.distance(q, idx)})
+		}
+	}
+	slices.SortFunc(all, func(a, b Match) int { return cmp.Compare(b.Score, a.Score) })
+	return all[:min(limit, len(all))]
+}
+
+type candidate struct {
+	idx  int32
+	dist float64
+}
+
+// minQueue pops the closest candidate first
+type minQueue []candidate
+
+func (q minQueue) Len() int           { return len(q) }
+func (q minQueue) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q minQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *minQueue) Push(x any)        { *q = append(*q, x.(candidate)) }
+func (q *minQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// maxQueue pops the farthest candidate first
+type maxQueue []candidate
+
+func (q maxQueue) Len() int           { return len(q) }
+func (q maxQueue) Less(i, j int) bool { return q[i].dist > q[j].dist }
+func (q maxQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *maxQueue) Push(x any)        { *q = append(*q, x.(candidate)) }
+func (q *maxQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+var _ VectorStore = (*HNSW)(nil)
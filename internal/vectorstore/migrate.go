@@ -0,0 +1,37 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// Migrate copies every record of src into dst, keeping each record's tenant, and returns how many were copied
+// Records already in dst with the same ID are replaced, so an interrupted migration can simply be rerun
+func Migrate(ctx context.Context, src, dst VectorStore, batchSize int) (int, error) {
+	copied := 0
+	err := src.Scan(ctx, batchSize, func(records []Record) error {
+		for tenantID, batch := range byTenant(records) {
+			if err := dst.Upsert(tenant.WithTenant(ctx, tenantID), batch); err != nil {
+				return fmt.Errorf("failed to copy records of tenant %s: %w", tenantID, err)
+			}
+			copied += len(batch)
+		}
+		return nil
+	})
+	return copied, err
+}
+
+// byTenant groups records by their tenant
+func byTenant(records []Record) map[string][]Record {
+	groups := make(map[string][]Record)
+	for _, rec := range records {
+		tenantID := rec.TenantID
+		if tenantID == "" {
+			tenantID = tenant.DefaultID
+		}
+		groups[tenantID] = append(groups[tenantID], rec)
+	}
+	return groups
+}
@@ -0,0 +1,264 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// DefaultQdrantCollection names the collection of the Qdrant backend
+const DefaultQdrantCollection = "acai_vectors"
+
+// Qdrant stores vectors in a Qdrant collection through its REST API
+type Qdrant struct {
+	client     *http.Client
+	baseURL    string
+	apiKey     string
+	collection string
+	dimensions int
+}
+
+// NewQdrant creates a Qdrant store; a nil httpClient uses a plain client with a 10s timeout
+func NewQdrant(baseURL, apiKey, collection string, dimensions int, httpClient *http.Client) *Qdrant {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if collection == "" {
+		collection = DefaultQdrantCollection
+	}
+	return &Qdrant{
+		client:     httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		collection: collection,
+		dimensions: dimensions,
+	}
+}
+
+type qdrantPayload struct {
+	RecordID string            `json:"record_id"`
+	TenantID string            `json:"tenant_id"`
+	Text     string            `json:"text,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type qdrantPoint struct {
+	ID      string        `json:"id"`
+	Vector  []float32     `json:"vector"`
+	Payload qdrantPayload `json:"payload"`
+	Score   float64       `json:"score,omitempty"`
+}
+
+func (p qdrantPoint) record() Record {
+	return Record{
+		ID:       p.Payload.RecordID,
+		TenantID: p.Payload.TenantID,
+		Vector:   p.Vector,
+		Text:     p.Payload.Text,
+		Metadata: p.Payload.Metadata,
+	}
+}
+
+// EnsureCollection creates the collection with cosine distance if it does not exist
+func (q *Qdrant) EnsureCollection(ctx context.Context) error {
+	err := q.call(ctx, http.MethodGet, "", nil, nil)
+	if err == nil {
+		return nil
+	}
+	var apiErr *qdrantError
+	if !errors.As(err, &apiErr) || apiErr.status != http.StatusNotFound {
+		return err
+	}
+	if q.dimensions <= 0 {
+		return errors.New("vector dimensions are required to create a Qdrant collection")
+	}
+	return q.call(ctx, http.MethodPut, "", map[string]any{
+		"vectors": map[string]any{"size": q.dimensions, "distance": "Cosine"},
+	}, nil)
+}
+
+func (q *Qdrant) Upsert(ctx context.Context, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tenantID := tenant.FromContext(ctx)
+
+	points := make([]qdrantPoint, 0, len(records))
+	for _, rec := range records {
+		if rec.ID == "" {
+			return errors.New("record ID is required")
+		}
+		if err := checkDimensions(q.dimensions, rec.Vector); err != nil {
+			return err
+		}
+		points = append(points, qdrantPoint{
+			ID:     pointID(tenantID, rec.ID),
+			Vector: rec.Vector,
+			Payload: qdrantPayload{
+				RecordID: rec.ID,
+				TenantID: tenantID,
+				Text:     rec.Text,
+				Metadata: rec.Metadata,
+			},
+		})
+	}
+	return q.call(ctx, http.MethodPut, "/points?wait=true", map[string]any{"points": points}, nil)
+}
+
+func (q *Qdrant) Search(ctx context.Context, vector []float32, limit int, filter map[string]string) ([]Match, error) {
+	if err := checkDimensions(q.dimensions, vector); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	must := []map[string]any{{"key": "tenant_id", "match": map[string]any{"value": tenant.FromContext(ctx)}}}
+	for k, v := range filter {
+		must = append(must, map[string]any{"key": "metadata." + k, "match": map[string]any{"value": v}})
+	}
+
+	var resp struct {
+		Result []qdrantPoint `json:"result"`
+	}
+	err := q.call(ctx, http.MethodPost, "/points/search", map[string]any{
+		"vector":       vector,
+		"limit":        limit,
+		"filter":       map[string]any{"must": must},
+		"with_payload": true,
+		"with_vector":  true,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]Match, 0, len(resp.Result))
+	for _, p := range resp.Result {
+		found = append(found, Match{Record: p.record(), Score: p.Score})
+	}
+	return found, nil
+}
+
+func (q *Qdrant) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tenantID := tenant.FromContext(ctx)
+	points := make([]string, 0, len(ids))
+	for _, id := range ids {
+		points = append(points, pointID(tenantID, id))
+	}
+	return q.call(ctx, http.MethodPost, "/points/delete?wait=true", map[string]any{"points": points}, nil)
+}
+
+func (q *Qdrant) Scan(ctx context.Context, batchSize int, fn func([]Record) error) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var offset any
+	for {
+		req := map[string]any{"limit": batchSize, "with_payload": true, "with_vector": true}
+		if offset != nil {
+			req["offset"] = offset
+		}
+		var resp struct {
+			Result struct {
+				Points []qdrantPoint `json:"points"`
+				Next   any           `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		if err := q.call(ctx, http.MethodPost, "/points/scroll", req, &resp); err != nil {
+			return err
+		}
+
+		if len(resp.Result.Points) > 0 {
+			batch := make([]Record, 0, len(resp.Result.Points))
+			for _, p := range resp.Result.Points {
+				batch = append(batch, p.record())
+			}
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if resp.Result.Next == nil {
+			return nil
+		}
+		offset = resp.Result.Next
+	}
+}
+
+type qdrantError struct {
+	status  int
+	message string
+}
+
+func (e *qdrantError) Error() string {
+	return fmt.Sprintf("qdrant returned %d: %s", e.status, e.message)
+}
+
+// call sends a request to a path of the collection and decodes the response into out, if not nil
+func (q *Qdrant) call(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method,
+		q.baseURL+"/collections/"+url.PathEscape(q.collection)+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var failure struct {
+			Status struct {
+				Error string `json:"error"`
+			} `json:"status"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &failure) == nil && failure.Status.Error != "" {
+			message = failure.Status.Error
+		}
+		return &qdrantError{status: resp.StatusCode, message: message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// pointID derives a stable UUID from the tenant and record ID, since Qdrant only accepts UUIDs and integers
+func pointID(tenantID, id string) string {
+	sum := sha1.Sum([]byte(tenantID + ":" + id))
+	sum[6] = (sum[6] & 0x0f) | 0x50 // Version 5
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+var _ VectorStore = (*Qdrant)(nil)
@@ -0,0 +1,154 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Backends selectable with VECTOR_STORE
+const (
+	BackendHNSW   = "hnsw"
+	BackendAtlas  = "atlas"
+	BackendQdrant = "qdrant"
+)
+
+// ErrDimensions is returned for vectors whose length differs from the store's dimensions
+var ErrDimensions = errors.New("vector has the wrong number of dimensions")
+
+// Record is an embedded piece of text, e.g. a remembered fact or a document chunk
+type Record struct {
+	ID       string            `json:"id" bson:"record_id"`
+	TenantID string            `json:"tenant_id" bson:"tenant_id"` // Set by the store from the context on Upsert
+	Vector   []float32         `json:"vector" bson:"vector"`
+	Text     string            `json:"text,omitempty" bson:"text,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+}
+
+// Match is a record found by a search
+type Match struct {
+	Record
+	Score float64 `json:"score"` // Cosine similarity, higher is closer
+}
+
+// VectorStore keeps embeddings and finds the ones closest to a query
+// Records belong to the context's tenant; searches and deletes never cross tenants
+type VectorStore interface {
+	// Upsert stores records, replacing records with the same ID
+	Upsert(ctx context.Context, records []Record) error
+	// Search returns up to limit records closest to vector, closest first
+	// Only records whose metadata has every key and value of filter are returned
+	Search(ctx context.Context, vector []float32, limit int, filter map[string]string) ([]Match, error)
+	// Delete removes records; unknown IDs are ignored
+	Delete(ctx context.Context, ids []string) error
+	// Scan calls fn with batches of the records of every tenant, for migrations between backends
+	Scan(ctx context.Context, batchSize int, fn func([]Record) error) error
+}
+
+// Config selects and configures a backend
+type Config struct {
+	Backend    string
+	Dimensions int // Length of every vector, e.g. 1536 for text-embedding-3-small
+
+	AtlasCollection string // Collection of the Atlas backend
+	AtlasIndex      string // Name of the Atlas Vector Search index on the vector field
+
+	QdrantURL        string
+	QdrantAPIKey     string
+	QdrantCollection string
+
+	HNSWPath           string // Snapshot file the in-process index is loaded from and saved to; empty keeps it in memory only
+	HNSWM              int    // Neighbors per node
+	HNSWEfConstruction int    // Candidates considered while inserting
+	HNSWEfSearch       int    // Candidates considered while searching
+}
+
+// ParseBackend validates a backend name
+func ParseBackend(name string) (string, error) {
+	switch name {
+	case BackendHNSW, BackendAtlas, BackendQdrant:
+		return name, nil
+	default:
+		return "", fmt.Errorf("unknown vector store %q, want %q, %q or %q", name, BackendHNSW, BackendAtlas, BackendQdrant)
+	}
+}
+
+// New creates the store selected by cfg.Backend; db is only used by the Atlas backend
+// The HNSW backend loads cfg.HNSWPath if set; callers save it with (*HNSW).Save on shutdown
+func New(ctx context.Context, cfg Config, db *mongo.Database) (VectorStore, error) {
+	backend, err := ParseBackend(cfg.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	switch backend {
+	case BackendAtlas:
+		if db == nil {
+			return nil, errors.New("the atlas vector store needs a MongoDB database")
+		}
+		return NewAtlas(db, cfg.AtlasCollection, cfg.AtlasIndex, cfg.Dimensions), nil
+	case BackendQdrant:
+		if cfg.QdrantURL == "" {
+			return nil, errors.New("the qdrant vector store needs QDRANT_URL")
+		}
+		store := NewQdrant(cfg.QdrantURL, cfg.QdrantAPIKey, cfg.QdrantCollection, cfg.Dimensions, nil)
+		if err := store.EnsureCollection(ctx); err != nil {
+			return nil, fmt.Errorf("failed to prepare qdrant collection: %w", err)
+		}
+		return store, nil
+	default:
+		store := NewHNSW(cfg.Dimensions, cfg.HNSWM, cfg.HNSWEfConstruction, cfg.HNSWEfSearch)
+		if cfg.HNSWPath != "" {
+			if err := store.Load(cfg.HNSWPath); err != nil {
+				return nil, err
+			}
+		}
+		return store, nil
+	}
+}
+
+// matches reports whether metadata contains every key and value of filter
+func matches(metadata, filter map[string]string) bool {
+	for k, v := range filter {
+		if metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDimensions validates vectors against the store's dimensions; zero dimensions accept any length
+func checkDimensions(dimensions int, vector []float32) error {
+	if len(vector) == 0 || (dimensions > 0 && len(vector) != dimensions) {
+		return fmt.Errorf("%w: got %d, want %d", ErrDimensions, len(vector), dimensions)
+	}
+	return nil
+}
+
+// normalize returns a unit-length copy of v, so cosine similarity is a dot product
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	out := make([]float32, len(v))
+	if sum == 0 {
+		return out
+	}
+	norm := float32(1 / math.Sqrt(sum))
+	for i, x := range v {
+		out[i] = x * norm
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return float64(sum)
+}
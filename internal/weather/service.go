@@ -11,11 +11,18 @@ import (
 	"time"
 
 	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/degradation"
 	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
 	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
 	"golang.org/x/time/rate"
 )
 
+// ErrInvalidLocation is returned for locations the weather API does not know
+var ErrInvalidLocation = errors.New("invalid location")
+
 // WeatherData represents current weather information
 type WeatherData struct {
 	Location    string  `json:"location"`
@@ -29,12 +36,14 @@ type WeatherData struct {
 	Visibility  float64 `json:"vis_km"`
 	UVIndex     float64 `json:"uv"`
 	LastUpdated string  `json:"last_updated"`
+	Stale       bool    `json:"stale,omitempty"` // Last known data, served while the weather API is unavailable
 }
 
 // ForecastData represents weather forecast information
 type ForecastData struct {
 	Location string        `json:"location"`
 	Forecast []ForecastDay `json:"forecast"`
+	Stale    bool          `json:"stale,omitempty"` // Last known data, served while the weather API is unavailable
 }
 
 // ForecastDay represents daily forecast
@@ -56,6 +65,9 @@ type WeatherProvider interface {
 	GetForecast(ctx context.Context, location string, days int) (*ForecastData, error)
 }
 
+// WeatherAPIBaseURL is the WeatherAPI.com endpoint the client calls
+const WeatherAPIBaseURL = "http://api.weatherapi.com/v1"
+
 // WeatherAPIClient implements WeatherProvider using WeatherAPI.com
 type WeatherAPIClient struct {
 	client      *http.Client
@@ -66,17 +78,37 @@ type WeatherAPIClient struct {
 }
 
 // NewWeatherAPIClient creates a new WeatherAPI client with rate limiting
-func NewWeatherAPIClient(apiKey string) *WeatherAPIClient {
+// A nil httpClient uses a plain client with a 10s timeout
+func NewWeatherAPIClient(apiKey string, httpClient *http.Client) *WeatherAPIClient {
 	cfg := config.Load()
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
 	return &WeatherAPIClient{
-		client:      &http.Client{Timeout: 10 * time.Second},
+		client:      httpClient,
 		apiKey:      apiKey,
-		baseURL:     "http://api.weatherapi.com/v1",
-		rateLimiter: rate.NewLimiter(rate.Every(time.Minute), 10), // 10 requests per minute
+		baseURL:     WeatherAPIBaseURL,
+		rateLimiter: rate.NewLimiter(rate.Every(time.Minute), 10), // Bursts of 10 requests, refilled at one per minute
 		retryConfig: retry.ConfigFromAppConfig(cfg),
 	}
 }
 
+// RateLimit returns the rate requests to WeatherAPI are limited to
+func (w *WeatherAPIClient) RateLimit() *registry.RateLimit {
+	return &registry.RateLimit{
+		PerMinute: float64(w.rateLimiter.Limit()) * 60,
+		Burst:     w.rateLimiter.Burst(),
+	}
+}
+
+// apiKeyFor returns the tenant's own WeatherAPI key when it has one, otherwise the platform key
+func (w *WeatherAPIClient) apiKeyFor(ctx context.Context) string {
+	if creds := tenant.CredentialsFromContext(ctx); creds != nil && creds.WeatherAPIKey != "" {
+		return creds.WeatherAPIKey
+	}
+	return w.apiKey
+}
+
 // GetCurrent retrieves current weather for a location
 func (w *WeatherAPIClient) GetCurrent(ctx context.Context, location string) (*WeatherData, error) {
 	// Apply rate limiting
@@ -84,7 +116,7 @@ func (w *WeatherAPIClient) GetCurrent(ctx context.Context, location string) (*We
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/current.json?key=%s&q=%s&aqi=no", w.baseURL, w.apiKey, location)
+	url := fmt.Sprintf("%s/current.json?key=%s&q=%s&aqi=no", w.baseURL, w.apiKeyFor(ctx), location)
 
 	// Use retry logic for HTTP request
 	resp, err := retry.RetryWithResult(ctx, w.retryConfig, func() (*http.Response, error) {
@@ -114,7 +146,7 @@ func (w *WeatherAPIClient) GetCurrent(ctx context.Context, location string) (*We
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusBadRequest {
-			return nil, fmt.Errorf("invalid location: %s", location)
+			return nil, fmt.Errorf("%w: %s", ErrInvalidLocation, location)
 		}
 		return nil, fmt.Errorf("weather API error: %s", resp.Status)
 	}
@@ -173,7 +205,7 @@ func (w *WeatherAPIClient) GetForecast(ctx context.Context, location string, day
 		return nil, fmt.Errorf("rate limit exceeded: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/forecast.json?key=%s&q=%s&days=%d&aqi=no", w.baseURL, w.apiKey, location, days)
+	url := fmt.Sprintf("%s/forecast.json?key=%s&q=%s&days=%d&aqi=no", w.baseURL, w.apiKeyFor(ctx), location, days)
 
 	// Use retry logic for HTTP request
 	resp, err := retry.RetryWithResult(ctx, w.retryConfig, func() (*http.Response, error) {
@@ -203,7 +235,7 @@ func (w *WeatherAPIClient) GetForecast(ctx context.Context, location string, day
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusBadRequest {
-			return nil, fmt.Errorf("invalid location: %s", location)
+			return nil, fmt.Errorf("%w: %s", ErrInvalidLocation, location)
 		}
 		return nil, fmt.Errorf("weather API error: %s", resp.Status)
 	}
@@ -259,12 +291,47 @@ func (w *WeatherAPIClient) GetForecast(ctx context.Context, location string, day
 	return forecast, nil
 }
 
-// FormatWeather formats weather data for display
+// FormatWeather formats weather data for display in metric units
 func FormatWeather(weather *WeatherData) string {
+	return FormatWeatherIn(weather, settings.UnitsMetric)
+}
+
+// FormatWeatherIn formats weather data for display in the given unit system
+func FormatWeatherIn(weather *WeatherData, units string) string {
 	if weather == nil {
 		return "Weather data unavailable"
 	}
 
+	formatted := formatCurrent(weather, units)
+	if weather.Stale {
+		formatted += ". " + staleNote
+	}
+	return formatted
+}
+
+// staleNote tells the model, and through it the user, that the data may be out of date
+const staleNote = "Live weather data is currently unavailable: this is the last known report and may be out of date"
+
+func formatCurrent(weather *WeatherData, units string) string {
+	if units == settings.UnitsImperial {
+		return fmt.Sprintf(
+			"Current weather in %s: %s, %.1f°F (feels like %.1f°F). "+
+				"Humidity: %d%%, Wind: %.1f mph %s, Pressure: %.2f inHg, "+
+				"Visibility: %.1f mi, UV Index: %.1f. Last updated: %s",
+			weather.Location,
+			strings.ToLower(weather.Condition),
+			celsiusToFahrenheit(weather.Temperature),
+			celsiusToFahrenheit(weather.FeelsLike),
+			weather.Humidity,
+			weather.WindSpeed*kmToMiles,
+			weather.WindDir,
+			weather.Pressure*millibarToInHg,
+			weather.Visibility*kmToMiles,
+			weather.UVIndex,
+			weather.LastUpdated,
+		)
+	}
+
 	return fmt.Sprintf(
 		"Current weather in %s: %s, %.1f°C (feels like %.1f°C). "+
 			"Humidity: %d%%, Wind: %.1f km/h %s, Pressure: %.0f mb, "+
@@ -283,6 +350,16 @@ func FormatWeather(weather *WeatherData) string {
 	)
 }
 
+// Conversion factors for imperial units
+const (
+	kmToMiles      = 0.621371
+	millibarToInHg = 0.02953
+)
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
 // FormatForecast formats forecast data for display
 func FormatForecast(forecast *ForecastData, days int) string {
 	if forecast == nil || len(forecast.Forecast) == 0 {
@@ -310,6 +387,9 @@ func FormatForecast(forecast *ForecastData, days int) string {
 			day.UVIndex,
 		))
 	}
+	if forecast.Stale {
+		builder.WriteString(staleNote + "\n")
+	}
 
 	return builder.String()
 }
@@ -445,64 +525,187 @@ func (m *MockWeatherProvider) GetForecast(ctx context.Context, location string,
 	return forecast, nil
 }
 
+// Cache keeps the last data of the primary provider, see redisx.Cache
+type Cache interface {
+	Get(ctx context.Context, key string, dest interface{}) error
+	Set(ctx context.Context, key string, value interface{}) error
+	GenerateKey(prefix string, content string) string
+}
+
+// Health tracks whether the primary provider is failing, see degradation.Health
+type Health interface {
+	Available() bool
+	Report(err error)
+}
+
 // FallbackWeatherService provides weather data with fallback to mock data
+// While the primary provider is degraded it is not called: the last data it returned is served instead,
+// flagged as stale, and mock data only when there is none
 type FallbackWeatherService struct {
 	primaryProvider  WeatherProvider
 	fallbackProvider WeatherProvider
-	cache            *redisx.Cache
+	cache            Cache
+	health           Health
+}
+
+// FallbackOption configures optional fallback behaviour
+type FallbackOption func(*FallbackWeatherService)
+
+// WithHealth stops calling the primary provider while health reports it unavailable, and reports its failures
+func WithHealth(health Health) FallbackOption {
+	return func(f *FallbackWeatherService) {
+		f.health = health
+	}
 }
 
 // NewFallbackWeatherService creates a weather service with fallback
-func NewFallbackWeatherService(primary WeatherProvider, fallback WeatherProvider, cache *redisx.Cache) *FallbackWeatherService {
-	return &FallbackWeatherService{
+// cache keeps the last data of the primary provider; nil serves mock data whenever the primary fails
+func NewFallbackWeatherService(primary WeatherProvider, fallback WeatherProvider, cache Cache, opts ...FallbackOption) *FallbackWeatherService {
+	f := &FallbackWeatherService{
 		primaryProvider:  primary,
 		fallbackProvider: fallback,
 		cache:            cache,
 	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
-// GetCurrentWithFallback tries primary provider, falls back to mock data on error
+// GetCurrentWithFallback tries primary provider, falls back to the last known data, then to mock data
 func (f *FallbackWeatherService) GetCurrentWithFallback(ctx context.Context, location string) (*WeatherData, error) {
-	// Try primary provider first
-	weather, err := f.primaryProvider.GetCurrent(ctx, location)
-	if err == nil {
-		return weather, nil
+	if f.primaryAvailable(ctx, location) {
+		weather, err := f.primaryProvider.GetCurrent(ctx, location)
+		f.report(err)
+		if err == nil {
+			f.remember(ctx, "current", location, weather)
+			return weather, nil
+		}
+		slog.ErrorContext(ctx, "Primary weather provider failed, using fallback",
+			"location", location, "error", err)
 	}
 
-	slog.ErrorContext(ctx, "Primary weather provider failed, using fallback",
-		"location", location, "error", err)
+	var last WeatherData
+	if f.recall(ctx, "current", location, &last) {
+		last.Stale = true
+		return &last, nil
+	}
 
 	// Fall back to mock provider
 	return f.fallbackProvider.GetCurrent(ctx, location)
 }
 
-// GetForecastWithFallback tries primary provider, falls back to mock data on error
+// GetForecastWithFallback tries primary provider, falls back to the last known data, then to mock data
 func (f *FallbackWeatherService) GetForecastWithFallback(ctx context.Context, location string, days int) (*ForecastData, error) {
-	// Try primary provider first
-	forecast, err := f.primaryProvider.GetForecast(ctx, location, days)
-	if err == nil {
-		return forecast, nil
+	query := fmt.Sprintf("%s:%d", location, days)
+	if f.primaryAvailable(ctx, location) {
+		forecast, err := f.primaryProvider.GetForecast(ctx, location, days)
+		f.report(err)
+		if err == nil {
+			f.remember(ctx, "forecast", query, forecast)
+			return forecast, nil
+		}
+		slog.ErrorContext(ctx, "Primary forecast provider failed, using fallback",
+			"location", location, "days", days, "error", err)
 	}
 
-	slog.ErrorContext(ctx, "Primary forecast provider failed, using fallback",
-		"location", location, "days", days, "error", err)
+	var last ForecastData
+	if f.recall(ctx, "forecast", query, &last) {
+		last.Stale = true
+		return &last, nil
+	}
 
 	// Fall back to mock provider
 	return f.fallbackProvider.GetForecast(ctx, location, days)
 }
 
+// RateLimit returns the rate limit of the primary provider, nil when it has none
+func (f *FallbackWeatherService) RateLimit() *registry.RateLimit {
+	if limited, ok := f.primaryProvider.(registry.RateLimited); ok {
+		return limited.RateLimit()
+	}
+	return nil
+}
+
+func (f *FallbackWeatherService) primaryAvailable(ctx context.Context, location string) bool {
+	if f.health == nil || f.health.Available() {
+		return true
+	}
+	slog.WarnContext(ctx, "Weather API degraded, serving last known data", "location", location)
+	return false
+}
+
+// report reports failures of the weather API; unknown locations and cancelled requests are not its failures
+func (f *FallbackWeatherService) report(err error) {
+	if f.health == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	if errors.Is(err, ErrInvalidLocation) {
+		err = nil
+	}
+	f.health.Report(err)
+}
+
+// remember keeps data of the primary provider to serve while it is unavailable, for as long as the cache TTL
+func (f *FallbackWeatherService) remember(ctx context.Context, kind, query string, data any) {
+	if f.cache == nil {
+		return
+	}
+	if err := f.cache.Set(ctx, f.cache.GenerateKey("weather:last:"+kind, query), data); err != nil {
+		slog.WarnContext(ctx, "Failed to keep last known weather data", "error", err)
+	}
+}
+
+func (f *FallbackWeatherService) recall(ctx context.Context, kind, query string, dest any) bool {
+	return f.cache != nil && f.cache.Get(ctx, f.cache.GenerateKey("weather:last:"+kind, query), dest) == nil
+}
+
 // Helper function to create weather service with all features
-func CreateWeatherService(apiKey string, cache *redisx.Cache) *FallbackWeatherService {
+func CreateWeatherService(apiKey string, cache *redisx.Cache, httpClient *http.Client) *FallbackWeatherService {
 	var primaryProvider WeatherProvider
 
 	if apiKey != "" {
-		primaryProvider = NewWeatherAPIClient(apiKey)
+		primaryProvider = NewWeatherAPIClient(apiKey, httpClient)
 	} else {
-		slog.Warn("No WeatherAPI key provided, using mock provider as primary")
-		primaryProvider = NewMockWeatherProvider()
+		slog.Warn("No WeatherAPI key provided, using mock provider as primary for tenants without their own key")
+		primaryProvider = &tenantKeyProvider{
+			api:  NewWeatherAPIClient("", httpClient),
+			mock: NewMockWeatherProvider(),
+		}
 	}
 
 	fallbackProvider := NewMockWeatherProvider()
 
-	return NewFallbackWeatherService(primaryProvider, fallbackProvider, cache)
+	var lastKnown Cache
+	if cache != nil {
+		lastKnown = cache
+	}
+	return NewFallbackWeatherService(primaryProvider, fallbackProvider, lastKnown,
+		WithHealth(degradation.Shared().Health(degradation.Weather)))
+}
+
+// tenantKeyProvider calls WeatherAPI only for tenants that bring their own key
+// Used when no platform key is configured
+type tenantKeyProvider struct {
+	api  *WeatherAPIClient
+	mock *MockWeatherProvider
+}
+
+func (p *tenantKeyProvider) GetCurrent(ctx context.Context, location string) (*WeatherData, error) {
+	if p.api.apiKeyFor(ctx) == "" {
+		return p.mock.GetCurrent(ctx, location)
+	}
+	return p.api.GetCurrent(ctx, location)
+}
+
+func (p *tenantKeyProvider) GetForecast(ctx context.Context, location string, days int) (*ForecastData, error) {
+	if p.api.apiKeyFor(ctx) == "" {
+		return p.mock.GetForecast(ctx, location, days)
+	}
+	return p.api.GetForecast(ctx, location, days)
+}
+
+// RateLimit returns the rate limit of tenants' own WeatherAPI keys
+func (p *tenantKeyProvider) RateLimit() *registry.RateLimit {
+	return p.api.RateLimit()
 }
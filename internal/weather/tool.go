@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
 	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
 )
 
@@ -60,10 +61,16 @@ func (w *WeatherTool) Execute(ctx context.Context, args map[string]interface{})
 		return "weather data unavailable", err
 	}
 
-	// Format weather data for response
-	weatherMessage := FormatWeather(weatherData)
+	// Format weather data in the user's preferred units
+	weatherMessage := FormatWeatherIn(weatherData, settings.FromContext(ctx).UnitSystem())
 	return weatherMessage, nil
 }
 
+// RateLimit returns the rate limit of the weather API the tool calls
+func (w *WeatherTool) RateLimit() *registry.RateLimit {
+	return w.weatherService.RateLimit()
+}
+
 // Ensure WeatherTool implements registry.Tool interface
 var _ registry.Tool = (*WeatherTool)(nil)
+var _ registry.RateLimited = (*WeatherTool)(nil)
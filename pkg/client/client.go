@@ -0,0 +1,178 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+)
+
+// RetryPolicy controls how failed calls are retried
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts per call, including the first; 1 turns retries off
+	BaseDelay   time.Duration // Delay before the second attempt, doubled for each further one
+	MaxDelay    time.Duration // Longest delay between attempts; longer ones asked for by the service are not waited for
+}
+
+// DefaultRetryPolicy is the retry policy of clients created without WithRetry
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+type config struct {
+	apiKey        string
+	tenantID      string
+	tenantKey     string
+	signingKeyID  string
+	signingSecret string
+	httpClient    *http.Client
+	retry         RetryPolicy
+	json          bool
+}
+
+// Option configures a Client
+type Option func(*config)
+
+// WithAPIKey authenticates calls with the service's API key
+func WithAPIKey(key string) Option {
+	return func(c *config) { c.apiKey = key }
+}
+
+// WithTenant makes calls on behalf of a tenant, authenticated with its access key (TENANT_ACCESS_KEYS)
+// The key is empty for tenants the service trusts on their ID alone (TENANT_IDS)
+func WithTenant(tenantID, accessKey string) Option {
+	return func(c *config) {
+		c.tenantID = tenantID
+		c.tenantKey = accessKey
+	}
+}
+
+// WithSigningKey signs calls with an HMAC key shared with the service (REQUEST_SIGNING_KEYS)
+func WithSigningKey(keyID, secret string) Option {
+	return func(c *config) {
+		c.signingKeyID = keyID
+		c.signingSecret = secret
+	}
+}
+
+// WithHTTPClient sends calls with client instead of http.DefaultClient, e.g. for timeouts or mTLS
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) { c.httpClient = client }
+}
+
+// WithRetry replaces DefaultRetryPolicy
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = policy
+		c.retry.MaxAttempts = max(1, policy.MaxAttempts)
+	}
+}
+
+// WithJSON sends calls as JSON instead of Protobuf, e.g. to read them in proxy logs
+func WithJSON() Option {
+	return func(c *config) { c.json = true }
+}
+
+// Client calls the chat assistant; it is safe for concurrent use
+type Client struct {
+	chat pb.ChatService
+}
+
+// New creates a client of the service at baseURL, e.g. "https://assistant.internal"
+func New(baseURL string, opts ...Option) *Client {
+	cfg := config{httpClient: http.DefaultClient, retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	doer := &transport{cfg: cfg, next: cfg.httpClient}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if cfg.json {
+		return &Client{chat: pb.NewChatServiceJSONClient(baseURL, doer)}
+	}
+	return &Client{chat: pb.NewChatServiceProtobufClient(baseURL, doer)}
+}
+
+// StartConversation starts a conversation with its first message and returns the reply
+func (c *Client) StartConversation(ctx context.Context, req *StartConversationRequest) (*StartConversationResponse, error) {
+	return call(ctx, req, c.chat.StartConversation)
+}
+
+// ContinueConversation sends a message to a conversation and returns the reply
+func (c *Client) ContinueConversation(ctx context.Context, req *ContinueConversationRequest) (*ContinueConversationResponse, error) {
+	return call(ctx, req, c.chat.ContinueConversation)
+}
+
+// ListConversations lists the most recent conversations
+func (c *Client) ListConversations(ctx context.Context, req *ListConversationsRequest) (*ListConversationsResponse, error) {
+	return call(ctx, req, c.chat.ListConversations)
+}
+
+// DescribeConversation returns a conversation by its ID
+func (c *Client) DescribeConversation(ctx context.Context, req *DescribeConversationRequest) (*DescribeConversationResponse, error) {
+	return call(ctx, req, c.chat.DescribeConversation)
+}
+
+// UploadAttachment uploads a file to a conversation, optionally linking it to one of its messages
+func (c *Client) UploadAttachment(ctx context.Context, req *UploadAttachmentRequest) (*UploadAttachmentResponse, error) {
+	return call(ctx, req, c.chat.UploadAttachment)
+}
+
+// GetAttachment downloads an attachment of a conversation
+func (c *Client) GetAttachment(ctx context.Context, req *GetAttachmentRequest) (*GetAttachmentResponse, error) {
+	return call(ctx, req, c.chat.GetAttachment)
+}
+
+// AddReaction reacts to an assistant message with an emoji, replacing the user's previous reaction to it
+func (c *Client) AddReaction(ctx context.Context, req *AddReactionRequest) (*AddReactionResponse, error) {
+	return call(ctx, req, c.chat.AddReaction)
+}
+
+// RequestDataExport requests an export of everything stored for a user; GetDataExport reports when it is ready
+func (c *Client) RequestDataExport(ctx context.Context, req *RequestDataExportRequest) (*RequestDataExportResponse, error) {
+	return call(ctx, req, c.chat.RequestDataExport)
+}
+
+// GetDataExport returns the status of a data export and its download link
+func (c *Client) GetDataExport(ctx context.Context, req *GetDataExportRequest) (*GetDataExportResponse, error) {
+	return call(ctx, req, c.chat.GetDataExport)
+}
+
+// ResetSession ends the current session of a chat; the next message starts a new conversation
+func (c *Client) ResetSession(ctx context.Context, req *ResetSessionRequest) (*ResetSessionResponse, error) {
+	return call(ctx, req, c.chat.ResetSession)
+}
+
+// GetUserSettings returns the preferences of a platform user
+func (c *Client) GetUserSettings(ctx context.Context, req *GetUserSettingsRequest) (*GetUserSettingsResponse, error) {
+	return call(ctx, req, c.chat.GetUserSettings)
+}
+
+// SetUserSettings replaces the preferences of a platform user
+func (c *Client) SetUserSettings(ctx context.Context, req *SetUserSettingsRequest) (*SetUserSettingsResponse, error) {
+	return call(ctx, req, c.chat.SetUserSettings)
+}
+
+// SetConversationInstructions sets custom instructions the assistant follows in every reply of a conversation
+func (c *Client) SetConversationInstructions(ctx context.Context, req *SetConversationInstructionsRequest) (*SetConversationInstructionsResponse, error) {
+	return call(ctx, req, c.chat.SetConversationInstructions)
+}
+
+// ClaimSession transfers the conversations of an anonymous user to the user they logged in as
+func (c *Client) ClaimSession(ctx context.Context, req *ClaimSessionRequest) (*ClaimSessionResponse, error) {
+	return call(ctx, req, c.chat.ClaimSession)
+}
+
+// call makes a call and converts its error to *Error
+func call[Req, Resp any](ctx context.Context, req Req, fn func(context.Context, Req) (Resp, error)) (Resp, error) {
+	resp, err := fn(ctx, req)
+	if err != nil {
+		var zero Resp
+		return zero, toError(err)
+	}
+	return resp, nil
+}
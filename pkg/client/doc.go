@@ -0,0 +1,29 @@
+// Package client is the Go SDK of the chat assistant, for services that talk to it over Twirp
+//
+// Calls are retried on network errors, rate limiting and unavailable upstreams, waiting as long as the
+// service asks. Writes such as StartConversation carry an Idempotency-Key that stays the same across
+// retries, so a retried call never starts a second conversation or sends a message twice. Failed calls
+// return *Error, which matches the sentinel errors with errors.Is:
+//
+//	c := client.New("https://assistant.internal",
+//		client.WithAPIKey(os.Getenv("API_KEY")),
+//		client.WithTenant("acme", os.Getenv("TENANT_ACCESS_KEY")),
+//	)
+//
+//	start, err := c.StartConversation(ctx, &client.StartConversationRequest{Message: "Weather in Barcelona?"})
+//	if errors.Is(err, client.ErrProviderRateLimited) {
+//		// The model provider is busy even after retries
+//	}
+//
+//	next, err := c.ContinueConversation(ctx, &client.ContinueConversationRequest{
+//		ConversationId: start.GetConversationId(),
+//		Message:        "And tomorrow?",
+//	})
+//
+// Callers that retry on their own, e.g. from a job queue, pass their key so all attempts share it:
+//
+//	ctx = client.WithIdempotencyKey(ctx, job.ID)
+//
+// Replies are returned whole: the service does not stream replies yet. WithRequestBudget bounds how
+// long a reply may take; replies that run out of it come back partial with BudgetExceeded set.
+package client
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/twitchtv/twirp"
+)
+
+// Error is a call the service failed
+// Code is the Twirp error code; ErrorCode narrows it down where the service tells causes apart,
+// e.g. "provider_rate_limited" or "content_filtered"
+type Error struct {
+	Code       string
+	Message    string
+	ErrorCode  string
+	RetryAfter time.Duration // How long the service asked to wait before retrying, 0 when it did not say
+	TraceID    string        // Trace of the failed call, for support requests
+	Meta       map[string]string
+}
+
+func (e *Error) Error() string {
+	if e.ErrorCode != "" {
+		return "assistant: " + e.Code + " (" + e.ErrorCode + "): " + e.Message
+	}
+	return "assistant: " + e.Code + ": " + e.Message
+}
+
+// Is makes errors.Is match the sentinel errors: by ErrorCode when the sentinel has one, by Code otherwise
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	if t.ErrorCode != "" {
+		return e.ErrorCode == t.ErrorCode
+	}
+	return e.Code == t.Code
+}
+
+// Sentinel errors to match failed calls with errors.Is
+var (
+	ErrInvalidArgument  = &Error{Code: string(twirp.InvalidArgument)}
+	ErrNotFound         = &Error{Code: string(twirp.NotFound)}
+	ErrUnauthenticated  = &Error{Code: string(twirp.Unauthenticated)}
+	ErrPermissionDenied = &Error{Code: string(twirp.PermissionDenied)}
+	ErrRateLimited      = &Error{Code: string(twirp.ResourceExhausted)} // The service's own limits, retried before returning
+	ErrUnavailable      = &Error{Code: string(twirp.Unavailable)}
+
+	// Model provider failures, see errorsx.Upstream
+	ErrProviderRateLimited = &Error{ErrorCode: "provider_rate_limited"}
+	ErrContentFiltered     = &Error{ErrorCode: "content_filtered"}
+	ErrContextTooLong      = &Error{ErrorCode: "context_too_long"}
+	ErrProviderUnavailable = &Error{ErrorCode: "provider_unavailable"}
+)
+
+// intermediaryCodes gives errors of middleware answering before Twirp, e.g. a malformed request budget,
+// the code the service would have used where Twirp clients fall back to internal or unknown
+var intermediaryCodes = map[int]twirp.ErrorCode{
+	http.StatusBadRequest:            twirp.InvalidArgument,
+	http.StatusRequestEntityTooLarge: twirp.InvalidArgument,
+}
+
+// toError converts the errors of Twirp clients to *Error; other errors, e.g. network ones, are returned as they are
+func toError(err error) error {
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) {
+		return err
+	}
+
+	e := &Error{
+		Code:      string(twerr.Code()),
+		Message:   twerr.Msg(),
+		ErrorCode: twerr.Meta("error_code"),
+		TraceID:   twerr.Meta(httpx.TraceIDMeta),
+		Meta:      twerr.MetaMap(),
+	}
+	if seconds, err := strconv.Atoi(twerr.Meta("retry_after_seconds")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	if twerr.Meta("http_error_from_intermediary") == "true" {
+		status, _ := strconv.Atoi(twerr.Meta("status_code"))
+		if code, ok := intermediaryCodes[status]; ok {
+			e.Code = string(code)
+		}
+		// Middleware answers with {"error": "...", "message": "..."}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal([]byte(twerr.Meta("body")), &body) == nil && body.Message != "" {
+			e.Message = body.Message
+		}
+	}
+	return e
+}
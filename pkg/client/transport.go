@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/budget"
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reads are the methods that change nothing, so retrying them needs no idempotency key
+var reads = map[string]bool{
+	"ListConversations":    true,
+	"DescribeConversation": true,
+	"GetAttachment":        true,
+	"GetDataExport":        true,
+	"GetUserSettings":      true,
+}
+
+// retriedStatuses are the responses worth another attempt; conflicts are requests with the same
+// idempotency key still running, whose response is replayed once they finish
+var retriedStatuses = map[int]bool{
+	http.StatusConflict:           true,
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// transport authenticates, signs and retries the requests of the Twirp client
+type transport struct {
+	cfg  config
+	next *http.Client
+}
+
+func (t *transport) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	header := req.Header.Clone()
+	if t.cfg.apiKey != "" {
+		header.Set("X-API-Key", t.cfg.apiKey)
+	}
+	if t.cfg.tenantID != "" {
+		header.Set(tenant.Header, t.cfg.tenantID)
+	}
+	if t.cfg.tenantKey != "" {
+		header.Set(tenant.KeyHeader, t.cfg.tenantKey)
+	}
+	if ms, ok := ctx.Value(budgetKey{}).(int64); ok {
+		header.Set(budget.Header, strconv.FormatInt(ms, 10))
+	}
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	if key == "" && !reads[path.Base(req.URL.Path)] {
+		key = newIdempotencyKey()
+	}
+	if key != "" {
+		header.Set(httpx.IdempotencyKeyHeader, key)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.attempt(req, header)
+		last := attempt >= t.cfg.retry.MaxAttempts
+		if err != nil {
+			if last || ctx.Err() != nil {
+				return nil, err
+			}
+		} else if last || !retriedStatuses[resp.StatusCode] || (resp.StatusCode == http.StatusConflict && key == "") {
+			return resp, nil
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			serverDelay, body := retryDelay(resp)
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			if serverDelay > t.cfg.retry.MaxDelay {
+				// Waiting that long is the caller's call; the error carries the delay where the service gave it
+				return resp, nil
+			}
+			delay = max(delay, serverDelay)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			if resp != nil {
+				return resp, nil
+			}
+			return nil, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends the request once, with a fresh body and signature
+func (t *transport) attempt(req *http.Request, header http.Header) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Header = header.Clone()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	if t.cfg.signingKeyID != "" {
+		if err := httpx.SignRequest(out, t.cfg.signingKeyID, t.cfg.signingSecret, primitive.NewObjectID().Hex(), time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return t.next.Do(out)
+}
+
+// backoff returns the exponential delay before the next attempt, with jitter so clients retrying
+// together spread out
+func (t *transport) backoff(attempt int) time.Duration {
+	delay := t.cfg.retry.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > t.cfg.retry.MaxDelay {
+		delay = t.cfg.retry.MaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryDelay reads the delay a response asks for from its Retry-After header or the retry_after_seconds
+// meta of a Twirp error; it consumes the body and returns it, for responses that are passed on
+func retryDelay(resp *http.Response) (time.Duration, []byte) {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	if d, ok := retry.ParseRetryAfter(resp.Header); ok {
+		return d, body
+	}
+	var twerr struct {
+		Meta map[string]string `json:"meta"`
+	}
+	if json.Unmarshal(body, &twerr) == nil {
+		if seconds, err := strconv.Atoi(twerr.Meta["retry_after_seconds"]); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second, body
+		}
+	}
+	return 0, body
+}
+
+// newIdempotencyKey returns a random key identifying a call across its retries
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		return primitive.NewObjectID().Hex()
+	}
+	return hex.EncodeToString(b[:])
+}
+
+type idempotencyKey struct{}
+
+type budgetKey struct{}
+
+// WithIdempotencyKey sets the idempotency key of the calls made with the context, for callers that retry
+// calls on their own; without it every write call gets its own key, kept across the client's retries
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// WithRequestBudget bounds how long the service works on the replies of calls made with the context;
+// replies that run out of it are returned partial with BudgetExceeded set
+func WithRequestBudget(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, budgetKey{}, max(1, d.Milliseconds()))
+}
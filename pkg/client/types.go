@@ -0,0 +1,52 @@
+package client
+
+import "github.com/8adimka/Go_AI_Assistant/internal/pb"
+
+// The messages of the API, aliased so code outside this module can name them
+type (
+	Conversation         = pb.Conversation
+	ConversationMessage  = pb.Conversation_Message
+	ConversationReaction = pb.Conversation_Reaction
+	Role                 = pb.Conversation_Role
+	SessionMetadata      = pb.SessionMetadata
+	ReplyStyle           = pb.ReplyStyle
+	Attachment           = pb.Attachment
+	DataExport           = pb.DataExport
+	UserSettings         = pb.UserSettings
+
+	StartConversationRequest            = pb.StartConversationRequest
+	StartConversationResponse           = pb.StartConversationResponse
+	ContinueConversationRequest         = pb.ContinueConversationRequest
+	ContinueConversationResponse        = pb.ContinueConversationResponse
+	ListConversationsRequest            = pb.ListConversationsRequest
+	ListConversationsResponse           = pb.ListConversationsResponse
+	DescribeConversationRequest         = pb.DescribeConversationRequest
+	DescribeConversationResponse        = pb.DescribeConversationResponse
+	UploadAttachmentRequest             = pb.UploadAttachmentRequest
+	UploadAttachmentResponse            = pb.UploadAttachmentResponse
+	GetAttachmentRequest                = pb.GetAttachmentRequest
+	GetAttachmentResponse               = pb.GetAttachmentResponse
+	AddReactionRequest                  = pb.AddReactionRequest
+	AddReactionResponse                 = pb.AddReactionResponse
+	RequestDataExportRequest            = pb.RequestDataExportRequest
+	RequestDataExportResponse           = pb.RequestDataExportResponse
+	GetDataExportRequest                = pb.GetDataExportRequest
+	GetDataExportResponse               = pb.GetDataExportResponse
+	ResetSessionRequest                 = pb.ResetSessionRequest
+	ResetSessionResponse                = pb.ResetSessionResponse
+	GetUserSettingsRequest              = pb.GetUserSettingsRequest
+	GetUserSettingsResponse             = pb.GetUserSettingsResponse
+	SetUserSettingsRequest              = pb.SetUserSettingsRequest
+	SetUserSettingsResponse             = pb.SetUserSettingsResponse
+	SetConversationInstructionsRequest  = pb.SetConversationInstructionsRequest
+	SetConversationInstructionsResponse = pb.SetConversationInstructionsResponse
+	ClaimSessionRequest                 = pb.ClaimSessionRequest
+	ClaimSessionResponse                = pb.ClaimSessionResponse
+)
+
+// Roles of conversation messages
+const (
+	RoleUser      = pb.Conversation_USER
+	RoleAssistant = pb.Conversation_ASSISTANT
+	RoleSystem    = pb.Conversation_SYSTEM
+)
@@ -0,0 +1,236 @@
+package contract_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/attachment"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
+	"github.com/twitchtv/twirp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var chatService = pb.File_rpc_chat_proto.Services().ByName("ChatService")
+
+const twirpError = "#/definitions/docs.TwirpError"
+
+func rpcPath(name protoreflect.Name) string {
+	return "/twirp/" + string(chatService.FullName()) + "/" + string(name)
+}
+
+func TestSpec_DocumentsEveryRPC(t *testing.T) {
+	s := loadSpec(t)
+	methods := chatService.Methods()
+
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		t.Run(string(method.Name()), func(t *testing.T) {
+			op := s.Paths[rpcPath(method.Name())]["post"]
+			if op == nil {
+				t.Fatalf("POST %s is not documented", rpcPath(method.Name()))
+			}
+			if op.body() == nil {
+				t.Fatal("request body is not documented")
+			}
+			for _, diff := range s.compareMessage(method.Input(), op.body(), map[string]bool{}) {
+				t.Errorf("request: %s", diff)
+			}
+			ok, found := op.Responses["200"]
+			if !found {
+				t.Fatal("200 response is not documented")
+			}
+			for _, diff := range s.compareMessage(method.Output(), ok.Schema, map[string]bool{}) {
+				t.Errorf("response: %s", diff)
+			}
+			for status, resp := range op.Responses {
+				if status != "200" && (resp.Schema == nil || resp.Schema.Ref != twirpError) {
+					t.Errorf("%s response is not documented as a Twirp error", status)
+				}
+			}
+		})
+	}
+
+	// Documented RPCs the service no longer has would fail for every client that calls them
+	for path := range s.Paths {
+		name, isRPC := strings.CutPrefix(path, rpcPath(""))
+		if isRPC && methods.ByName(protoreflect.Name(name)) == nil {
+			t.Errorf("%s is documented but not served", path)
+		}
+		if !isRPC && strings.HasPrefix(path, "/twirp/") {
+			t.Errorf("%s is documented outside the %s service", path, chatService.FullName())
+		}
+	}
+}
+
+// newServer serves the chat server with every optional RPC enabled, or none
+func newServer(t *testing.T, full bool) *httptest.Server {
+	t.Helper()
+	repo := mocks.NewConversationRepository()
+	var opts []chat.ServerOption
+	if full {
+		store, err := objectstore.NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewLocalStore() error = %v", err)
+		}
+		attachments := attachment.NewService(store,
+			&memoryAttachments{attachments: map[string]*attachment.Attachment{}},
+			attachment.Policy{MaxBytes: 1024})
+		opts = append(opts,
+			chat.WithAttachments(attachments),
+			chat.WithTakeout(&stubTakeout{}),
+			chat.WithUserSettings(settings.NewService(&memorySettings{settings: map[string]*settings.Settings{}}, nil)),
+		)
+	}
+	srv := chat.NewServer(repo, stubAssistant{}, mocks.NewSessionStore(repo), opts...)
+
+	// Configured like cmd/server
+	ts := httptest.NewServer(pb.NewChatServiceServer(srv, twirp.WithServerJSONSkipDefaults(true)))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// client calls RPCs as JSON and checks requests and responses against the OpenAPI document
+type client struct {
+	t      *testing.T
+	spec   *spec
+	url    string
+	called map[protoreflect.Name]bool
+}
+
+func (c *client) post(name protoreflect.Name, body []byte) (int, []byte) {
+	c.t.Helper()
+	resp, err := http.Post(c.url+rpcPath(name), "application/json", bytes.NewReader(body))
+	if err != nil {
+		c.t.Fatalf("%s: %v", name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("%s: failed to read response: %v", name, err)
+	}
+	return resp.StatusCode, data
+}
+
+// call sends req, built from the generated types as any client would, and decodes the response into resp
+func (c *client) call(req, resp proto.Message) {
+	c.t.Helper()
+	name := req.ProtoReflect().Descriptor().Name()
+	name = protoreflect.Name(strings.TrimSuffix(string(name), "Request"))
+	op := c.spec.Paths[rpcPath(name)]["post"]
+	if op == nil {
+		c.t.Fatalf("%s is not documented", name)
+	}
+
+	body, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(req)
+	if err != nil {
+		c.t.Fatalf("%s: failed to encode request: %v", name, err)
+	}
+	for _, e := range c.spec.validate(body, op.body()) {
+		c.t.Errorf("%s request %s: %s", name, body, e)
+	}
+
+	status, data := c.post(name, body)
+	if status != http.StatusOK {
+		c.t.Fatalf("%s: status %d: %s", name, status, data)
+	}
+	for _, e := range c.spec.validate(data, op.Responses["200"].Schema) {
+		c.t.Errorf("%s response %s: %s", name, data, e)
+	}
+	if err := protojson.Unmarshal(data, resp); err != nil {
+		c.t.Fatalf("%s: failed to decode response: %v", name, err)
+	}
+	c.called[name] = true
+}
+
+func TestServer_ResponsesMatchSpec(t *testing.T) {
+	c := &client{t: t, spec: loadSpec(t), url: newServer(t, true).URL, called: map[protoreflect.Name]bool{}}
+	user := &pb.SessionMetadata{Platform: "web", UserId: "user-1", ChatId: "chat-1"}
+	style := &pb.ReplyStyle{MaxLength: 400, Format: "prose", ReadingLevel: "simple"}
+
+	var started pb.StartConversationResponse
+	c.call(&pb.StartConversationRequest{Message: "Weather in Barcelona?", SessionMetadata: user, Style: style}, &started)
+	id := started.ConversationId
+
+	var continued pb.ContinueConversationResponse
+	c.call(&pb.ContinueConversationRequest{ConversationId: id, Message: "And tomorrow?", SessionMetadata: user, Style: style}, &continued)
+
+	var described pb.DescribeConversationResponse
+	c.call(&pb.DescribeConversationRequest{ConversationId: id}, &described)
+	if len(described.Conversation.GetMessages()) < 2 {
+		t.Fatalf("expected the user message and reply, got %v", described.Conversation.GetMessages())
+	}
+	question, reply := described.Conversation.Messages[0], described.Conversation.Messages[1]
+
+	var uploaded pb.UploadAttachmentResponse
+	c.call(&pb.UploadAttachmentRequest{ConversationId: id, MessageId: question.Id, Filename: "notes.txt",
+		ContentType: "text/plain", Content: []byte("remember the umbrella")}, &uploaded)
+	c.call(&pb.GetAttachmentRequest{ConversationId: id, AttachmentId: uploaded.Attachment.GetId()}, &pb.GetAttachmentResponse{})
+
+	c.call(&pb.AddReactionRequest{ConversationId: id, MessageId: reply.Id, Emoji: "👍", SessionMetadata: user}, &pb.AddReactionResponse{})
+	c.call(&pb.SetConversationInstructionsRequest{ConversationId: id, Instructions: "Answer in metric units",
+		SessionMetadata: user}, &pb.SetConversationInstructionsResponse{})
+
+	// Described again with reactions, attachments and instructions
+	c.call(&pb.DescribeConversationRequest{ConversationId: id}, &described)
+	c.call(&pb.ListConversationsRequest{}, &pb.ListConversationsResponse{})
+
+	var export pb.RequestDataExportResponse
+	c.call(&pb.RequestDataExportRequest{SessionMetadata: user}, &export)
+	c.call(&pb.GetDataExportRequest{ExportId: export.Export.GetId(), SessionMetadata: user}, &pb.GetDataExportResponse{})
+
+	prefs := &pb.UserSettings{Language: "es", Units: "metric", Timezone: "Europe/Madrid", Verbosity: "concise"}
+	c.call(&pb.SetUserSettingsRequest{SessionMetadata: user, Settings: prefs}, &pb.SetUserSettingsResponse{})
+	c.call(&pb.GetUserSettingsRequest{SessionMetadata: user}, &pb.GetUserSettingsResponse{})
+
+	anonymous := &pb.SessionMetadata{Platform: "web", UserId: "anon-1", ChatId: "chat-anon"}
+	c.call(&pb.ContinueConversationRequest{Message: "Hi", SessionMetadata: anonymous}, &continued)
+	var claimed pb.ClaimSessionResponse
+	c.call(&pb.ClaimSessionRequest{Anonymous: anonymous, SessionMetadata: user}, &claimed)
+	c.call(&pb.ResetSessionRequest{SessionMetadata: user}, &pb.ResetSessionResponse{})
+
+	methods := chatService.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		if name := methods.Get(i).Name(); !c.called[name] {
+			t.Errorf("%s is not exercised, add a call to this test", name)
+		}
+	}
+}
+
+func TestServer_ErrorsMatchSpec(t *testing.T) {
+	s := loadSpec(t)
+	methods := chatService.Methods()
+
+	for _, full := range []bool{true, false} {
+		c := &client{t: t, spec: s, url: newServer(t, full).URL}
+		for i := 0; i < methods.Len(); i++ {
+			name := methods.Get(i).Name()
+			op := s.Paths[rpcPath(name)]["post"]
+			if op == nil {
+				t.Errorf("%s is not documented", name)
+				continue
+			}
+
+			// Empty requests fail validation, or the RPC is disabled
+			status, data := c.post(name, []byte(`{}`))
+			resp, documented := op.Responses[strconv.Itoa(status)]
+			if !documented {
+				t.Errorf("%s (all services %v): status %d is not documented: %s", name, full, status, data)
+				continue
+			}
+			for _, e := range s.validate(data, resp.Schema) {
+				t.Errorf("%s (all services %v) %d response %s: %s", name, full, status, data, e)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package contract_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/attachment"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/takeout"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type stubAssistant struct{}
+
+func (stubAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	return "Weather in Barcelona", nil
+}
+
+func (stubAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	return "Sunny, 22°C.", nil
+}
+
+type memoryAttachments struct {
+	mu          sync.Mutex
+	attachments map[string]*attachment.Attachment
+}
+
+func (r *memoryAttachments) CreateAttachment(ctx context.Context, a *attachment.Attachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attachments[a.ID.Hex()] = a
+	return nil
+}
+
+func (r *memoryAttachments) GetAttachment(ctx context.Context, conversationID, id string) (*attachment.Attachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attachments[id]
+	if !ok || a.ConversationID != conversationID {
+		return nil, attachment.ErrNotFound
+	}
+	return a, nil
+}
+
+type stubTakeout struct {
+	mu     sync.Mutex
+	export *takeout.Export
+}
+
+func (s *stubTakeout) Request(ctx context.Context, platform, userID, chatID string) (*takeout.Export, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.export = &takeout.Export{
+		ID:        primitive.NewObjectID(),
+		Platform:  platform,
+		UserID:    userID,
+		ChatID:    chatID,
+		Status:    takeout.StatusReady,
+		CreatedAt: now,
+		ExpiresAt: now.Add(7 * 24 * time.Hour),
+	}
+	return s.export, nil
+}
+
+func (s *stubTakeout) Get(ctx context.Context, id, platform, userID string) (*takeout.Export, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.export == nil || s.export.ID.Hex() != id || s.export.UserID != userID {
+		return nil, "", takeout.ErrNotFound
+	}
+	return s.export, "https://assistant.example.com/takeout/" + id, nil
+}
+
+type memorySettings struct {
+	mu       sync.Mutex
+	settings map[string]*settings.Settings
+}
+
+func (r *memorySettings) GetSettings(ctx context.Context, platform, userID string) (*settings.Settings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.settings[platform+":"+userID]
+	if !ok {
+		return nil, settings.ErrNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (r *memorySettings) SaveSettings(ctx context.Context, s *settings.Settings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *s
+	r.settings[s.Platform+":"+s.UserID] = &copied
+	return nil
+}
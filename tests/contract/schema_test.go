@@ -0,0 +1,274 @@
+package contract_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/docs"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// spec is the part of the Swagger 2.0 document the contract is checked against
+type spec struct {
+	Paths       map[string]map[string]*operation `json:"paths"`
+	Definitions map[string]*schema               `json:"definitions"`
+}
+
+type operation struct {
+	Parameters []struct {
+		In     string  `json:"in"`
+		Schema *schema `json:"schema"`
+	} `json:"parameters"`
+	Responses map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"responses"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []string           `json:"enum"`
+	Properties           map[string]*schema `json:"properties"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+}
+
+func loadSpec(t *testing.T) *spec {
+	t.Helper()
+	var s spec
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &s); err != nil {
+		t.Fatalf("failed to parse the OpenAPI document: %v", err)
+	}
+	return &s
+}
+
+// resolve follows a $ref to its definition
+func (s *spec) resolve(sch *schema) (*schema, string, error) {
+	if sch == nil {
+		return nil, "", fmt.Errorf("missing schema")
+	}
+	if sch.Ref == "" {
+		return sch, "", nil
+	}
+	name := strings.TrimPrefix(sch.Ref, "#/definitions/")
+	def, ok := s.Definitions[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown definition %s", sch.Ref)
+	}
+	return def, name, nil
+}
+
+// body returns the schema of the operation's request body
+func (op *operation) body() *schema {
+	for _, p := range op.Parameters {
+		if p.In == "body" {
+			return p.Schema
+		}
+	}
+	return nil
+}
+
+var timestampName = (&timestamppb.Timestamp{}).ProtoReflect().Descriptor().FullName()
+
+// compareMessage reports every difference between a proto message and the schema documenting its JSON,
+// in both directions and through nested messages; checked holds the definitions already compared
+func (s *spec) compareMessage(md protoreflect.MessageDescriptor, sch *schema, checked map[string]bool) []string {
+	def, name, err := s.resolve(sch)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", md.FullName(), err)}
+	}
+	if name != "" {
+		if checked[name] {
+			return nil
+		}
+		checked[name] = true
+	}
+	if def.Type != "object" {
+		return []string{fmt.Sprintf("%s: documented as %q, want an object", md.FullName(), def.Type)}
+	}
+
+	var diffs []string
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		prop, ok := def.Properties[fd.TextName()]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s.%s: field is not documented", md.FullName(), fd.TextName()))
+			continue
+		}
+		diffs = append(diffs, s.compareField(fd, prop, checked)...)
+	}
+	for prop := range def.Properties {
+		if fields.ByTextName(prop) == nil {
+			diffs = append(diffs, fmt.Sprintf("%s.%s: documented property is not in the proto", md.FullName(), prop))
+		}
+	}
+	return diffs
+}
+
+func (s *spec) compareField(fd protoreflect.FieldDescriptor, prop *schema, checked map[string]bool) []string {
+	where := string(fd.FullName())
+	if fd.IsMap() {
+		return []string{where + ": map fields are not supported by the contract test"}
+	}
+	if fd.IsList() {
+		if prop.Type != "array" || prop.Items == nil {
+			return []string{fmt.Sprintf("%s: documented as %q, want an array", where, prop.Type)}
+		}
+		prop = prop.Items
+	}
+
+	if fd.Kind() == protoreflect.MessageKind {
+		if fd.Message().FullName() == timestampName {
+			return expectScalar(where, prop, "string", "date-time")
+		}
+		return s.compareMessage(fd.Message(), prop, checked)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return expectScalar(where, prop, "string", "")
+	case protoreflect.BoolKind:
+		return expectScalar(where, prop, "boolean", "")
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return expectScalar(where, prop, "integer", "")
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson writes 64-bit integers as strings
+		return expectScalar(where, prop, "string", "int64")
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return expectScalar(where, prop, "number", "")
+	case protoreflect.BytesKind:
+		return expectScalar(where, prop, "string", "byte")
+	case protoreflect.EnumKind:
+		diffs := expectScalar(where, prop, "string", "")
+		var names []string
+		values := fd.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		if !slices.Equal(slices.Sorted(slices.Values(prop.Enum)), slices.Sorted(slices.Values(names))) {
+			diffs = append(diffs, fmt.Sprintf("%s: documented values %v, want %v", where, prop.Enum, names))
+		}
+		return diffs
+	default:
+		return []string{fmt.Sprintf("%s: unsupported kind %s", where, fd.Kind())}
+	}
+}
+
+func expectScalar(where string, prop *schema, typ, format string) []string {
+	if prop.Ref != "" || prop.Type != typ || prop.Format != format {
+		return []string{fmt.Sprintf("%s: documented as %q %q %q, want %q %q", where, prop.Ref, prop.Type, prop.Format, typ, format)}
+	}
+	return nil
+}
+
+// validate reports every way the JSON document does not match the schema
+// Properties the schema does not list are errors, so undocumented response fields are caught
+func (s *spec) validate(data []byte, sch *schema) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	return s.validateValue("$", value, sch)
+}
+
+func (s *spec) validateValue(path string, value any, sch *schema) []string {
+	sch, _, err := s.resolve(sch)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", path, err)}
+	}
+
+	switch sch.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %T, want an object", path, value)}
+		}
+		var errs []string
+		for _, name := range sch.Required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s.%s: required property is missing", path, name))
+			}
+		}
+		for name, v := range obj {
+			prop, ok := sch.Properties[name]
+			if !ok {
+				prop = sch.AdditionalProperties
+			}
+			if prop == nil {
+				errs = append(errs, fmt.Sprintf("%s.%s: property is not documented", path, name))
+				continue
+			}
+			errs = append(errs, s.validateValue(path+"."+name, v, prop)...)
+		}
+		return errs
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %T, want an array", path, value)}
+		}
+		var errs []string
+		for i, v := range items {
+			errs = append(errs, s.validateValue(fmt.Sprintf("%s[%d]", path, i), v, sch.Items)...)
+		}
+		return errs
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %T, want a string", path, value)}
+		}
+		if len(sch.Enum) > 0 && !slices.Contains(sch.Enum, str) {
+			return []string{fmt.Sprintf("%s: %q is not one of %v", path, str, sch.Enum)}
+		}
+		return validateFormat(path, str, sch.Format)
+	case "integer":
+		n, ok := value.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			return []string{fmt.Sprintf("%s: %v is not an integer", path, value)}
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return []string{fmt.Sprintf("%s: got %T, want a number", path, value)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: got %T, want a boolean", path, value)}
+		}
+	default:
+		return []string{fmt.Sprintf("%s: unsupported schema type %q", path, sch.Type)}
+	}
+	return nil
+}
+
+func validateFormat(path, value, format string) []string {
+	var err error
+	switch format {
+	case "":
+	case "date-time":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "int64":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "byte":
+		_, err = base64.StdEncoding.DecodeString(value)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %q is not a valid %s: %v", path, value, format, err)}
+	}
+	return nil
+}
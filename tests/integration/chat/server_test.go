@@ -34,7 +34,7 @@ func (m *MockAssistant) Reply(ctx context.Context, conv *model.Conversation) (st
 // MockSessionManager is a mock implementation of the session.Manager interface for testing
 type MockSessionManager struct{}
 
-func (m *MockSessionManager) GetOrCreateSession(ctx context.Context, platform, userID, chatID, message string) (string, error) {
+func (m *MockSessionManager) GetOrCreateSession(ctx context.Context, platform, userID, chatID string) (string, error) {
 	// For testing, just return a fixed conversation ID
 	return "test-conversation-id", nil
 }
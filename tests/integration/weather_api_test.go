@@ -25,7 +25,7 @@ func TestWeatherAPIWithRetry(t *testing.T) {
 	// Create weather service
 	redisClient := redisx.MustConnect(cfg.RedisAddr)
 	cache := redisx.NewCache(redisClient, 24*time.Hour)
-	weatherService := weather.CreateWeatherService(cfg.WeatherApiKey, cache)
+	weatherService := weather.CreateWeatherService(cfg.WeatherApiKey, cache, nil)
 
 	tests := []struct {
 		name        string
@@ -98,7 +98,7 @@ func TestWeatherServiceFallback(t *testing.T) {
 	// Create weather service with invalid API key to trigger fallback
 	redisClient := redisx.MustConnect(cfg.RedisAddr)
 	cache := redisx.NewCache(redisClient, 24*time.Hour)
-	weatherService := weather.CreateWeatherService("invalid_key_12345", cache)
+	weatherService := weather.CreateWeatherService("invalid_key_12345", cache, nil)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -138,7 +138,7 @@ func TestWeatherServiceRateLimiting(t *testing.T) {
 
 	redisClient := redisx.MustConnect(cfg.RedisAddr)
 	cache := redisx.NewCache(redisClient, 24*time.Hour)
-	weatherService := weather.CreateWeatherService(cfg.WeatherApiKey, cache)
+	weatherService := weather.CreateWeatherService(cfg.WeatherApiKey, cache, nil)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -0,0 +1,228 @@
+package performance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// contextSizes are the conversation lengths benchmarked, from a short chat to a very long one
+var contextSizes = []int{10, 100, 1000}
+
+// contextBackend creates a context manager that keeps up to maxHistory messages
+// Backends that need an external service skip the benchmark when it is unavailable
+type contextBackend struct {
+	name string
+	new  func(b *testing.B, maxHistory int) chat.ContextManagerInterface
+}
+
+var contextBackends = []contextBackend{
+	{"redis", newRedisContextManager},
+	{"memory", newMemoryContextManager},
+	{"hybrid", newHybridContextManager},
+}
+
+// benchRedis connects once to REDIS_ADDR, or localhost:6379 when it is not set; nil when Redis is unavailable
+var benchRedis = sync.OnceValue(func() *redis.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil
+	}
+	return client
+})
+
+func newRedisContextManager(b *testing.B, maxHistory int) chat.ContextManagerInterface {
+	client := benchRedis()
+	if client == nil {
+		b.Skip("Redis not available, skipping benchmark")
+	}
+	return chat.NewContextManager(redisx.NewCache(client, time.Hour), 1_000_000, maxHistory, nil)
+}
+
+func newMemoryContextManager(b *testing.B, maxHistory int) chat.ContextManagerInterface {
+	return chat.NewContextManager(redisx.NewMemoryCache(time.Hour, 0), 1_000_000, maxHistory, nil)
+}
+
+func newHybridContextManager(b *testing.B, maxHistory int) chat.ContextManagerInterface {
+	client := benchRedis()
+	if client == nil {
+		b.Skip("Redis not available, skipping benchmark")
+	}
+	store := redisx.NewTieredCache(redisx.NewMemoryCache(time.Minute, 0), redisx.NewCache(client, time.Hour))
+	return chat.NewContextManager(store, 1_000_000, maxHistory, nil)
+}
+
+// conversationMessages returns n alternating user and assistant messages of realistic length,
+// with a weather tool call every tenth turn
+func conversationMessages(n int) []chat.Message {
+	userText := "Could you tell me what the weather will be like in Barcelona this weekend? " +
+		"I'm planning a trip to the beach and want to know whether to pack a jacket."
+	assistantText := strings.Repeat("The forecast for the weekend is mostly sunny with highs around 24°C "+
+		"and light winds from the sea, so a light jacket for the evenings should be enough. ", 3)
+
+	messages := make([]chat.Message, 0, n)
+	for i := 0; len(messages) < n; i++ {
+		if i%10 == 9 && n-len(messages) >= 3 {
+			callID := fmt.Sprintf("call_%d", i)
+			messages = append(messages,
+				chat.Message{Role: "assistant", ToolCalls: []chat.ToolCall{{ID: callID, Name: "get_weather", Arguments: `{"location":"Barcelona"}`}}},
+				chat.Message{Role: "tool", Name: "get_weather", ToolCallID: callID, Content: `{"temp_c":24,"condition":"Sunny","wind_kph":12}`},
+				chat.Message{Role: "assistant", Content: assistantText},
+			)
+			continue
+		}
+		if i%2 == 0 {
+			messages = append(messages, chat.Message{Role: "user", Content: userText})
+		} else {
+			messages = append(messages, chat.Message{Role: "assistant", Content: assistantText})
+		}
+	}
+	return messages
+}
+
+// seedContext stores a conversation of n messages and returns its ID
+func seedContext(b *testing.B, cm chat.ContextManagerInterface, n int) string {
+	b.Helper()
+	id := "bench-" + primitive.NewObjectID().Hex()
+	if _, err := cm.AddMessages(context.Background(), id, conversationMessages(n)); err != nil {
+		b.Fatalf("failed to seed context: %v", err)
+	}
+	b.Cleanup(func() { cm.ClearContext(id) })
+	return id
+}
+
+// BenchmarkContextManager_AddMessage measures appending a message to a full context window
+func BenchmarkContextManager_AddMessage(b *testing.B) {
+	message := chat.Message{Role: "user", Content: "And what about Sunday evening?"}
+	for _, backend := range contextBackends {
+		for _, size := range contextSizes {
+			b.Run(fmt.Sprintf("%s/%d", backend.name, size), func(b *testing.B) {
+				cm := backend.new(b, size)
+				id := seedContext(b, cm, size)
+				ctx := context.Background()
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := cm.AddMessage(ctx, id, message); err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkContextManager_GetContext measures reading a stored context
+func BenchmarkContextManager_GetContext(b *testing.B) {
+	for _, backend := range contextBackends {
+		for _, size := range contextSizes {
+			b.Run(fmt.Sprintf("%s/%d", backend.name, size), func(b *testing.B) {
+				cm := backend.new(b, size)
+				id := seedContext(b, cm, size)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if got := cm.GetContext(id); len(got) != size {
+						b.Fatalf("got %d messages, want %d", len(got), size)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkContextManager_EnsureContextFits measures the check on every reply, when the context fits,
+// and the reduction when it has to drop half of its tokens
+func BenchmarkContextManager_EnsureContextFits(b *testing.B) {
+	for _, backend := range contextBackends {
+		for _, size := range contextSizes {
+			b.Run(fmt.Sprintf("%s/%d/fits", backend.name, size), func(b *testing.B) {
+				cm := backend.new(b, size)
+				id := seedContext(b, cm, size)
+				ctx := context.Background()
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := cm.EnsureContextFits(ctx, id, 1_000_000); err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+
+			b.Run(fmt.Sprintf("%s/%d/trims", backend.name, size), func(b *testing.B) {
+				cm := backend.new(b, size)
+				messages := conversationMessages(size)
+				target := cm.TokenCount(messages) / 2
+				ctx := context.Background()
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					// Every iteration reduces a full context again
+					b.StopTimer()
+					id := seedContext(b, cm, size)
+					b.StartTimer()
+
+					if err := cm.EnsureContextFits(ctx, id, target); err != nil {
+						b.Fatalf("unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkContextReduction measures the in-memory work done on loaded context, independent of storage
+func BenchmarkContextReduction(b *testing.B) {
+	estimate := func(text string) int { return len(text) / 4 }
+
+	for _, size := range contextSizes {
+		messages := conversationMessages(size)
+		target := chat.CountTokens(messages, estimate) / 2
+
+		b.Run(fmt.Sprintf("CountTokens/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				chat.CountTokens(messages, estimate)
+			}
+		})
+		b.Run(fmt.Sprintf("TrimOldest/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				chat.TrimOldest(messages, target, estimate)
+			}
+		})
+		b.Run(fmt.Sprintf("CompleteToolTurns/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				chat.CompleteToolTurns(messages)
+			}
+		})
+		b.Run(fmt.Sprintf("SummaryWindows/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				chat.SummaryWindows(messages, target, 10, 300, estimate)
+			}
+		})
+	}
+}
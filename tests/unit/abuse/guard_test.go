@@ -0,0 +1,170 @@
+package abuse_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/abuse"
+)
+
+// memoryStore is an in-memory abuse.Store without expiry
+type memoryStore struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	blocks   map[string]*abuse.Block
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		counters: map[string]int64{},
+		blocks:   map[string]*abuse.Block{},
+	}
+}
+
+func (s *memoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[key]++
+	return s.counters[key], nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.counters, key)
+		delete(s.blocks, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) SetBlock(ctx context.Context, key string, block *abuse.Block, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[key] = block
+	return nil
+}
+
+func (s *memoryStore) GetBlock(ctx context.Context, key string) (*abuse.Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blocks[key], nil
+}
+
+func newGuard(store abuse.Store) *abuse.Guard {
+	return abuse.NewGuard(store, abuse.Config{
+		StrikeThreshold:   3,
+		StrikeWindow:      time.Hour,
+		RequestsPerMinute: 2,
+		BaseBlock:         time.Minute,
+		MaxBlock:          10 * time.Minute,
+	})
+}
+
+func TestGuard_BlocksAfterStrikeThreshold(t *testing.T) {
+	ctx := context.Background()
+	guard := newGuard(newMemoryStore())
+
+	for i := 0; i < 2; i++ {
+		block, err := guard.RecordStrike(ctx, "telegram", "42", abuse.ReasonModeration)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if block != nil {
+			t.Fatalf("strike %d: expected no block yet", i+1)
+		}
+	}
+
+	block, err := guard.RecordStrike(ctx, "telegram", "42", abuse.ReasonModeration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if block == nil || block.Reason != abuse.ReasonModeration || block.Level != 1 {
+		t.Fatalf("expected level 1 moderation block, got %+v", block)
+	}
+
+	err = guard.Check(ctx, "telegram", "42")
+	if !errors.Is(err, abuse.ErrUserBlocked) {
+		t.Fatalf("expected ErrUserBlocked, got %v", err)
+	}
+
+	var blocked *abuse.BlockedError
+	if !errors.As(err, &blocked) || blocked.Block.UserID != "42" {
+		t.Errorf("expected BlockedError for user 42, got %v", err)
+	}
+
+	if err := guard.Check(ctx, "telegram", "43"); err != nil {
+		t.Errorf("expected other users to pass, got %v", err)
+	}
+}
+
+func TestGuard_RequestRateRecordsStrikes(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	guard := abuse.NewGuard(store, abuse.Config{StrikeThreshold: 1, RequestsPerMinute: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := guard.Check(ctx, "web", "u1"); err != nil {
+			t.Fatalf("request %d: expected to pass, got %v", i+1, err)
+		}
+	}
+
+	if err := guard.Check(ctx, "web", "u1"); !errors.Is(err, abuse.ErrUserBlocked) {
+		t.Fatalf("expected block once over the request rate, got %v", err)
+	}
+
+	block, _ := guard.Status(ctx, "web", "u1")
+	if block == nil || block.Reason != abuse.ReasonRateLimit {
+		t.Errorf("expected rate limit block, got %+v", block)
+	}
+}
+
+func TestGuard_Duration(t *testing.T) {
+	guard := newGuard(newMemoryStore())
+
+	tests := []struct {
+		level int
+		want  time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{10, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := guard.Duration(tt.level); got != tt.want {
+			t.Errorf("Duration(%d) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestGuard_AdminBlockAndUnblock(t *testing.T) {
+	ctx := context.Background()
+	guard := newGuard(newMemoryStore())
+
+	block, err := guard.Block(ctx, "api", "u2", 30*time.Minute, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if block.Reason != abuse.ReasonAdmin {
+		t.Errorf("expected admin reason, got %q", block.Reason)
+	}
+
+	if err := guard.Check(ctx, "api", "u2"); !errors.Is(err, abuse.ErrUserBlocked) {
+		t.Fatalf("expected ErrUserBlocked, got %v", err)
+	}
+
+	if err := guard.Unblock(ctx, "api", "u2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := guard.Check(ctx, "api", "u2"); err != nil {
+		t.Errorf("expected unblocked user to pass, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package abuse_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/abuse"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+type rateRecorder struct {
+	scopes []string
+}
+
+func (r *rateRecorder) RecordMessageRateLimited(ctx context.Context, scope, platform string) {
+	r.scopes = append(r.scopes, scope)
+}
+
+func TestMessageLimiter(t *testing.T) {
+	ctx := context.Background()
+	recorder := &rateRecorder{}
+	limiter := abuse.NewMessageLimiter(newMemoryStore(), recorder, abuse.MessageLimitConfig{
+		ConversationPerMinute: 3,
+		SessionPerMinute:      2,
+	})
+
+	for i := range 3 {
+		if err := limiter.CheckConversation(ctx, "telegram", "conv-1"); err != nil {
+			t.Fatalf("message %d: CheckConversation() error = %v", i+1, err)
+		}
+	}
+	err := limiter.CheckConversation(ctx, "telegram", "conv-1")
+	var limited *abuse.MessageRateError
+	if !errors.As(err, &limited) || limited.Scope != abuse.ScopeConversation || !errors.Is(err, abuse.ErrMessageRateLimited) {
+		t.Fatalf("4th message: CheckConversation() error = %v, want a conversation rate error", err)
+	}
+
+	// Other conversations, sessions and tenants have their own counters
+	if err := limiter.CheckConversation(ctx, "telegram", "conv-2"); err != nil {
+		t.Errorf("other conversation: error = %v", err)
+	}
+	if err := limiter.CheckConversation(tenant.WithTenant(ctx, "acme"), "telegram", "conv-1"); err != nil {
+		t.Errorf("other tenant: error = %v", err)
+	}
+	limiter.CheckSession(ctx, "telegram", "42", "42")
+	limiter.CheckSession(ctx, "telegram", "42", "42")
+	if err := limiter.CheckSession(ctx, "telegram", "42", "42"); !errors.Is(err, abuse.ErrMessageRateLimited) {
+		t.Errorf("3rd session message: error = %v, want a session rate error", err)
+	}
+	if err := limiter.CheckSession(ctx, "telegram", "42", "other-chat"); err != nil {
+		t.Errorf("other chat: error = %v", err)
+	}
+
+	if len(recorder.scopes) != 2 || recorder.scopes[0] != abuse.ScopeConversation || recorder.scopes[1] != abuse.ScopeSession {
+		t.Errorf("recorded %v, want one conversation and one session rejection", recorder.scopes)
+	}
+}
+
+func TestMessageLimiter_Disabled(t *testing.T) {
+	limiter := abuse.NewMessageLimiter(newMemoryStore(), nil, abuse.MessageLimitConfig{})
+	for range 100 {
+		if err := limiter.CheckConversation(context.Background(), "api", "conv-1"); err != nil {
+			t.Fatalf("CheckConversation() error = %v, want no limit when disabled", err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+type memoryUsers map[string]*admin.User
+
+func (m memoryUsers) FindUser(ctx context.Context, username string) (*admin.User, error) {
+	if user, ok := m[username]; ok {
+		return user, nil
+	}
+	return nil, admin.ErrUserNotFound
+}
+
+type recordingAudit struct {
+	entries []*audit.Entry
+}
+
+func (r *recordingAudit) Record(ctx context.Context, entry *audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func newUser(t *testing.T, username string, roles ...string) *admin.User {
+	t.Helper()
+	user, err := admin.NewUser(username, "correct horse battery", roles)
+	if err != nil {
+		t.Fatalf("NewUser() error = %v", err)
+	}
+	return user
+}
+
+func TestTokenIssuer(t *testing.T) {
+	tokens := admin.NewTokenIssuer(secret, time.Hour)
+	token, expires, err := tokens.Issue(&admin.User{Username: "alice", Roles: []string{admin.RoleEditor}})
+	if err != nil || time.Until(expires) < 59*time.Minute {
+		t.Fatalf("Issue() = %v, %v", expires, err)
+	}
+
+	claims, err := tokens.Verify(token)
+	if err != nil || claims.Subject != "alice" || len(claims.Roles) != 1 || claims.Roles[0] != admin.RoleEditor {
+		t.Fatalf("Verify() = %+v, %v, want alice as editor", claims, err)
+	}
+
+	// A token signed with another secret, or with changed claims, is rejected
+	if _, err := admin.NewTokenIssuer(strings.Repeat("x", 32), time.Hour).Verify(token); !errors.Is(err, admin.ErrInvalidToken) {
+		t.Errorf("Verify() with another secret error = %v, want ErrInvalidToken", err)
+	}
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := tokens.Verify(forged); !errors.Is(err, admin.ErrInvalidToken) {
+		t.Errorf("Verify() of a forged token error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAuthenticator_Require(t *testing.T) {
+	tokens := admin.NewTokenIssuer(secret, time.Hour)
+	log := &recordingAudit{}
+	auth := admin.NewAuthenticator(memoryUsers{}, tokens, "api-secret", log)
+	handler := auth.Require(admin.RoleOperator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if admin.FromContext(r.Context()) == nil {
+			t.Error("handler ran without a principal")
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	viewer, _, _ := tokens.Issue(newUser(t, "vic", admin.RoleViewer))
+	operator, _, _ := tokens.Issue(newUser(t, "olga", admin.RoleOperator))
+	tests := []struct {
+		name   string
+		method string
+		header string
+		value  string
+		want   int
+	}{
+		{"no credentials", http.MethodGet, "", "", http.StatusUnauthorized},
+		{"invalid token", http.MethodGet, "Authorization", "Bearer nope", http.StatusUnauthorized},
+		{"viewer reads", http.MethodGet, "Authorization", "Bearer " + viewer, http.StatusAccepted},
+		{"viewer cannot change", http.MethodPost, "Authorization", "Bearer " + viewer, http.StatusForbidden},
+		{"operator changes", http.MethodPost, "Authorization", "Bearer " + operator, http.StatusAccepted},
+		{"API key has every role", http.MethodPost, "X-API-Key", "api-secret", http.StatusAccepted},
+		{"wrong API key", http.MethodPost, "X-API-Key", "guess", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/abuse/block", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	// Only the changes that got through are audited, with their caller and outcome
+	if len(log.entries) != 2 {
+		t.Fatalf("audited %d requests, want 2", len(log.entries))
+	}
+	if e := log.entries[0]; e.Action != audit.ActionAdminRequest || e.Actor != "admin:olga" ||
+		e.Target != "POST /admin/abuse/block" || e.After != "202" {
+		t.Errorf("audit entry = %+v, want olga's block request", e)
+	}
+}
+
+func TestAuthenticator_Login(t *testing.T) {
+	log := &recordingAudit{}
+	tokens := admin.NewTokenIssuer(secret, time.Hour)
+	users := memoryUsers{"alice": newUser(t, "alice", admin.RoleEditor)}
+	auth := admin.NewAuthenticator(users, tokens, "", log)
+
+	login := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		auth.LoginHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := login(`{"username":"alice","password":"correct horse battery"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login status = %d, body %s", rec.Code, rec.Body)
+	}
+	var resp admin.LoginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if claims, err := tokens.Verify(resp.Token); err != nil || claims.Subject != "alice" {
+		t.Errorf("issued token claims = %+v, %v", claims, err)
+	}
+
+	for _, body := range []string{
+		`{"username":"alice","password":"wrong password!"}`,
+		`{"username":"mallory","password":"correct horse battery"}`,
+	} {
+		if rec := login(body); rec.Code != http.StatusUnauthorized {
+			t.Errorf("login %s: status = %d, want 401", body, rec.Code)
+		}
+	}
+	if len(log.entries) != 3 || log.entries[0].After != "succeeded" || log.entries[2].After != "failed" {
+		t.Errorf("audited logins = %d, want a success and two failures", len(log.entries))
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	roles, err := admin.ParseRoles("viewer, operator,viewer")
+	if err != nil || len(roles) != 2 {
+		t.Errorf("ParseRoles() = %v, %v, want viewer and operator", roles, err)
+	}
+	if _, err := admin.ParseRoles("superuser"); err == nil {
+		t.Error("ParseRoles() accepted an unknown role")
+	}
+	if !admin.HasRole([]string{admin.RoleOperator}, admin.RoleViewer) || admin.HasRole([]string{admin.RoleViewer}, admin.RoleEditor) {
+		t.Error("HasRole() does not let every role read, or lets a viewer edit")
+	}
+}
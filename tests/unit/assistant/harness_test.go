@@ -0,0 +1,203 @@
+package assistant_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/assistant"
+)
+
+// fakeRedis answers the few commands the assistant's caches send, over RESP2
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func startRedis(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	r := &fakeRedis{data: map[string]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	return r, listener.Addr().String()
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, r.handle(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (r *fakeRedis) handle(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "CLIENT", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := r.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		r.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := r.data[key]; ok {
+				delete(r.data, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	default:
+		// HELLO included: the client falls back to RESP2
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+func (r *fakeRedis) keys(prefix string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []string
+	for key := range r.data {
+		if strings.Contains(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// chatRequest is the part of a chat completion request the tests look at
+type chatRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+	Tools      []json.RawMessage `json:"tools"`
+	ToolChoice string            `json:"tool_choice"`
+}
+
+// fakeOpenAI records chat completion requests and answers them with the reply of the test
+type fakeOpenAI struct {
+	mu       sync.Mutex
+	requests []chatRequest
+	reply    func(req chatRequest) (int, string)
+}
+
+func (f *fakeOpenAI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	reply := f.reply
+	f.mu.Unlock()
+
+	status, content := http.StatusOK, "Hello there"
+	if reply != nil {
+		status, content = reply(req)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if status != http.StatusOK {
+		fmt.Fprintf(w, `{"error":{"message":%q,"type":"invalid_request_error"}}`, content)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":      "chatcmpl-test",
+		"object":  "chat.completion",
+		"model":   req.Model,
+		"choices": []map[string]any{{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": content}}},
+		"usage":   map[string]any{"prompt_tokens": 10, "completion_tokens": 2, "total_tokens": 12},
+	})
+}
+
+func (f *fakeOpenAI) Requests() []chatRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]chatRequest(nil), f.requests...)
+}
+
+// newAssistant creates an assistant whose caches live in a fake Redis and whose OpenAI requests go to a fake API
+// MongoDB is unreachable, so prompts come from the built-in fallbacks
+func newAssistant(t *testing.T) (*assistant.UnifiedAssistant, *fakeOpenAI, *fakeRedis) {
+	t.Helper()
+	redis, addr := startRedis(t)
+	api := &fakeOpenAI{}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	t.Setenv("REDIS_ADDR", addr)
+	t.Setenv("MONGO_URI", "mongodb://127.0.0.1:1/?serverSelectionTimeoutMS=50&connectTimeoutMS=50")
+	t.Setenv("OPENAI_API_KEY", "test")
+	t.Setenv("OPENAI_BASE_URL", server.URL+"/v1/")
+	t.Setenv("OPENAI_PREWARM", "false")
+	t.Setenv("CONTEXT_STORAGE", "memory")
+	return assistant.New(nil), api, redis
+}
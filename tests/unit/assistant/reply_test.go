@@ -0,0 +1,100 @@
+package assistant_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func message(role model.Role, content string) *model.Message {
+	return &model.Message{ID: primitive.NewObjectID(), Role: role, Content: content, CreatedAt: time.Now()}
+}
+
+func TestReply_SendsMessagesMergedIntoOneReply(t *testing.T) {
+	ua, api, _ := newAssistant(t)
+	ctx := context.Background()
+	conv := &model.Conversation{
+		ID:       primitive.NewObjectID(),
+		Platform: "web",
+		UserID:   "user-1",
+		Messages: []*model.Message{message(model.RoleUser, "What's a good name for a cat?")},
+	}
+
+	if _, err := ua.Reply(ctx, conv); err != nil {
+		t.Fatalf("Reply() error = %v", err)
+	}
+	conv.Messages = append(conv.Messages, message(model.RoleAssistant, "Hello there"))
+
+	// The reply to A fails after A reached the context, e.g. because B superseded it
+	conv.Messages = append(conv.Messages, message(model.RoleUser, "A: it is black and white"))
+	api.reply = func(chatRequest) (int, string) { return http.StatusBadRequest, "cancelled" }
+	if _, err := ua.Reply(ctx, conv); err == nil {
+		t.Fatal("Reply() to A error = nil, want the API error")
+	}
+
+	// B was superseded before its reply started; C answers A, B and C at once
+	api.reply = nil
+	conv.Messages = append(conv.Messages,
+		message(model.RoleUser, "B: and very fluffy"),
+		message(model.RoleUser, "C: something short please"))
+	if _, err := ua.Reply(ctx, conv); err != nil {
+		t.Fatalf("Reply() to C error = %v", err)
+	}
+
+	requests := api.Requests()
+	var users []string
+	for _, m := range requests[len(requests)-1].Messages {
+		if m.Role == "user" {
+			users = append(users, m.Content)
+		}
+	}
+	want := []string{"What's a good name for a cat?", "A: it is black and white", "B: and very fluffy", "C: something short please"}
+	if !slices.Equal(users, want) {
+		t.Errorf("user messages of the merged reply = %q, want %q", users, want)
+	}
+}
+
+func TestReply_StrictFactsForPersona(t *testing.T) {
+	t.Setenv("STRICT_FACTS_PLATFORMS", "web/travel")
+	ua, api, _ := newAssistant(t)
+	ctx := context.Background()
+	conv := &model.Conversation{
+		ID:       primitive.NewObjectID(),
+		Platform: "web",
+		UserID:   "user-1",
+		Persona:  "travel",
+		Messages: []*model.Message{message(model.RoleUser, "What's the weather in Barcelona?")},
+	}
+
+	// The model answers without calling get_weather, so it is re-prompted with tool calls required
+	if _, err := ua.Reply(ctx, conv); err != nil {
+		t.Fatalf("Reply() error = %v", err)
+	}
+	requests := api.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("completion requests = %d, want the answer and 2 re-prompts", len(requests))
+	}
+	if len(requests[1].Tools) == 0 || requests[1].ToolChoice != "required" {
+		t.Errorf("re-prompt tool_choice = %q with %d tools, want required", requests[1].ToolChoice, len(requests[1].Tools))
+	}
+
+	// Other personas on the platform are not re-prompted
+	conv = &model.Conversation{
+		ID:       primitive.NewObjectID(),
+		Platform: "web",
+		UserID:   "user-1",
+		Persona:  "chef",
+		Messages: []*model.Message{message(model.RoleUser, "What's the weather in Barcelona?")},
+	}
+	if _, err := ua.Reply(ctx, conv); err != nil {
+		t.Fatalf("Reply() error = %v", err)
+	}
+	if got := len(api.Requests()) - len(requests); got != 1 {
+		t.Errorf("completion requests for another persona = %d, want 1", got)
+	}
+}
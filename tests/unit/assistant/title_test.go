@@ -0,0 +1,153 @@
+package assistant_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// numberedTitles answers every title request with a new title
+func numberedTitles(api *fakeOpenAI) {
+	n := 0
+	api.reply = func(chatRequest) (int, string) {
+		n++
+		return http.StatusOK, fmt.Sprintf("Title %d", n)
+	}
+}
+
+func conversation(first string) *model.Conversation {
+	return &model.Conversation{
+		ID:       primitive.NewObjectID(),
+		Platform: "web",
+		UserID:   "user-1",
+		Messages: []*model.Message{message(model.RoleUser, first)},
+	}
+}
+
+func title(t *testing.T, ctx context.Context, generate func(context.Context, *model.Conversation) (string, error), conv *model.Conversation) string {
+	t.Helper()
+	got, err := generate(ctx, conv)
+	if err != nil {
+		t.Fatalf("Title() error = %v", err)
+	}
+	return got
+}
+
+func TestTitle_CachedPerConversation(t *testing.T) {
+	ua, api, _ := newAssistant(t)
+	numberedTitles(api)
+	ctx := context.Background()
+
+	// Two users opening with the same question
+	first, second := conversation("What's the weather in Paris?"), conversation("What's the weather in Paris?")
+	a := title(t, ctx, ua.Title, first)
+	b := title(t, ctx, ua.Title, second)
+	if a == b {
+		t.Errorf("titles of two conversations = %q and %q, want each generated for its conversation", a, b)
+	}
+
+	if again := title(t, ctx, ua.Title, first); again != a {
+		t.Errorf("Title() again = %q, want the cached %q", again, a)
+	}
+	if requests := len(api.Requests()); requests != 2 {
+		t.Errorf("OpenAI requests = %d, want one per conversation", requests)
+	}
+}
+
+func TestTitle_InvalidateForcesRegeneration(t *testing.T) {
+	ua, api, _ := newAssistant(t)
+	numberedTitles(api)
+	ctx := context.Background()
+	conv := conversation("Plan a trip to Rome")
+
+	before := title(t, ctx, ua.Title, conv)
+	if err := ua.InvalidateTitle(ctx, conv.ID.Hex()); err != nil {
+		t.Fatalf("InvalidateTitle() error = %v", err)
+	}
+	after := title(t, ctx, ua.Title, conv)
+	if after == before {
+		t.Errorf("Title() after InvalidateTitle = %q, want a new title", after)
+	}
+	if requests := len(api.Requests()); requests != 2 {
+		t.Errorf("OpenAI requests = %d, want the title generated again", requests)
+	}
+
+	if regenerated := title(t, ctx, ua.RegenerateTitle, conv); regenerated == after {
+		t.Errorf("RegenerateTitle() = %q, want a new title", regenerated)
+	}
+}
+
+// memoryTitles stores titles by conversation ID
+type memoryTitles struct {
+	titles map[string]string
+}
+
+func (m *memoryTitles) UpdateConversationTitle(ctx context.Context, id string, title string) error {
+	m.titles[id] = title
+	return nil
+}
+
+func (m *memoryTitles) ListUntitledConversations(ctx context.Context, createdBefore time.Time, limit int) ([]*model.Conversation, error) {
+	return nil, nil
+}
+
+func TestTitle_StoringATitleDropsTheCachedOne(t *testing.T) {
+	ua, api, redis := newAssistant(t)
+	numberedTitles(api)
+	ctx := context.Background()
+	conv := conversation("Find me a recipe for paella")
+	store := &memoryTitles{titles: map[string]string{}}
+	titles := ua.InvalidatingTitles(store)
+
+	generated := title(t, ctx, ua.Title, conv)
+	if keys := redis.keys("title:"); len(keys) != 1 {
+		t.Fatalf("title keys = %q, want the generated title cached", keys)
+	}
+
+	// An edited title replaces the generated one; the cache must not bring the old one back
+	if err := titles.UpdateConversationTitle(ctx, conv.ID.Hex(), "Paella night"); err != nil {
+		t.Fatalf("UpdateConversationTitle() error = %v", err)
+	}
+	if store.titles[conv.ID.Hex()] != "Paella night" {
+		t.Errorf("stored title = %q, want the edited one", store.titles[conv.ID.Hex()])
+	}
+	if keys := redis.keys("title:"); len(keys) != 0 {
+		t.Errorf("title keys after storing a title = %q, want none", keys)
+	}
+	if again := title(t, ctx, ua.Title, conv); again == generated {
+		t.Errorf("Title() after a stored title = %q, want a regenerated title", again)
+	}
+}
+
+func TestTitle_KeyScopedToTenant(t *testing.T) {
+	ua, api, redis := newAssistant(t)
+	numberedTitles(api)
+	conv := conversation("Recommend a book")
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	a := title(t, acme, ua.Title, conv)
+	keys := redis.keys("title:")
+	if len(keys) != 1 || !strings.HasPrefix(keys[0], "t:acme:") {
+		t.Fatalf("title keys = %q, want one scoped to the tenant", keys)
+	}
+
+	// The same conversation ID in another tenant does not read the first tenant's title
+	if b := title(t, globex, ua.Title, conv); b == a {
+		t.Errorf("Title() in another tenant = %q, want its own title", b)
+	}
+
+	if err := ua.InvalidateTitle(globex, conv.ID.Hex()); err != nil {
+		t.Fatalf("InvalidateTitle() error = %v", err)
+	}
+	if keys := redis.keys("title:"); len(keys) != 1 || !strings.HasPrefix(keys[0], "t:acme:") {
+		t.Errorf("title keys after invalidating in another tenant = %q, want the first tenant's kept", keys)
+	}
+}
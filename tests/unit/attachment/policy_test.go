@@ -0,0 +1,68 @@
+package attachment_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/attachment"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestPolicy_Check(t *testing.T) {
+	policy := attachment.Policy{MaxBytes: 64}
+
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		content     []byte
+		wantType    string
+		wantErr     bool
+	}{
+		{"png image", "photo.png", "image/png", pngHeader, "image/png", false},
+		{"text with charset", "notes.txt", "text/plain; charset=utf-8", []byte("hello"), "text/plain", false},
+		{"csv sniffed as text", "data.csv", "text/csv", []byte("a,b\n1,2\n"), "text/csv", false},
+		{"empty file", "photo.png", "image/png", nil, "", true},
+		{"too large", "notes.txt", "text/plain", bytes.Repeat([]byte("a"), 65), "", true},
+		{"type not allowed", "archive.zip", "application/zip", []byte("PK\x03\x04"), "", true},
+		{"content does not match type", "photo.png", "image/png", []byte("<html><body>hi</body></html>"), "", true},
+		{"extension does not match type", "photo.pdf", "image/png", pngHeader, "", true},
+		{"double extension executable", "invoice.pdf.exe", "application/pdf", []byte("%PDF-1.4"), "", true},
+		{"executable hidden before extension", "invoice.exe.png", "image/png", pngHeader, "", true},
+		{"path traversal", "../etc/passwd", "text/plain", []byte("root"), "", true},
+		{"hidden file", ".htaccess", "text/plain", []byte("deny"), "", true},
+		{"control characters", "photo\x00.png", "image/png", pngHeader, "", true},
+		{"right-to-left override", "photo‮gnp.exe", "image/png", pngHeader, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Check(tt.filename, tt.contentType, tt.content)
+			if tt.wantErr {
+				if !errors.Is(err, attachment.ErrRejected) {
+					t.Errorf("expected ErrRejected, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.wantType {
+				t.Errorf("Check() = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestPolicy_CustomAllowedTypes(t *testing.T) {
+	policy := attachment.Policy{AllowedTypes: []string{"text/plain"}}
+
+	if _, err := policy.Check("photo.png", "image/png", pngHeader); !errors.Is(err, attachment.ErrRejected) {
+		t.Errorf("expected image/png to be rejected, got %v", err)
+	}
+	if _, err := policy.Check("notes.txt", "text/plain", []byte("hello")); err != nil {
+		t.Errorf("expected text/plain to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// chain builds n correctly chained entries
+func chain(n int) []*audit.Entry {
+	var entries []*audit.Entry
+	prev := ""
+	for i := 1; i <= n; i++ {
+		e := &audit.Entry{
+			ID:        primitive.NewObjectID(),
+			TenantID:  "default",
+			Action:    audit.ActionAdminRequest,
+			Actor:     "admin:alice",
+			Target:    "POST /admin/abuse/block",
+			After:     "200",
+			CreatedAt: time.Date(2024, 5, 1, 12, 0, i, 0, time.UTC),
+			Seq:       int64(i),
+			PrevHash:  prev,
+		}
+		e.Hash = e.ComputeHash()
+		prev = e.Hash
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(entries []*audit.Entry) []*audit.Entry
+		headSeq  int64
+		brokenAt int64
+	}{
+		{"intact", func(e []*audit.Entry) []*audit.Entry { return e }, 4, 0},
+		{"edited entry", func(e []*audit.Entry) []*audit.Entry { e[1].After = "403"; return e }, 4, 2},
+		{"rehashed edit", func(e []*audit.Entry) []*audit.Entry {
+			e[1].After = "403"
+			e[1].Hash = e[1].ComputeHash()
+			return e
+		}, 4, 3},
+		{"removed entry", func(e []*audit.Entry) []*audit.Entry { return append(e[:1], e[2:]...) }, 4, 2},
+		{"removed last entry", func(e []*audit.Entry) []*audit.Entry { return e[:3] }, 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := chain(4)
+			head := entries[3].Hash
+			got := audit.VerifyChain(tt.tamper(entries), tt.headSeq, head)
+			if got.Valid != (tt.brokenAt == 0) || got.BrokenAt != tt.brokenAt {
+				t.Errorf("VerifyChain() = %+v, want broken at %d", got, tt.brokenAt)
+			}
+		})
+	}
+}
+
+func TestEntry_ComputeHash_StoredPrecision(t *testing.T) {
+	e := chain(1)[0]
+	e.CreatedAt = e.CreatedAt.Add(123456 * time.Nanosecond)
+	hash := e.ComputeHash()
+
+	// MongoDB keeps milliseconds and returns local times; neither changes the hash
+	e.CreatedAt = e.CreatedAt.Truncate(time.Millisecond).Local()
+	if e.ComputeHash() != hash {
+		t.Error("hash changed after a storage round trip of CreatedAt")
+	}
+}
+
+type stubStore struct {
+	filter       audit.Filter
+	verification *audit.Verification
+}
+
+func (s *stubStore) List(ctx context.Context, f audit.Filter) ([]*audit.Entry, error) {
+	s.filter = f
+	return chain(2), nil
+}
+
+func (s *stubStore) Verify(ctx context.Context) (*audit.Verification, error) {
+	return s.verification, nil
+}
+
+func TestAdminHandler(t *testing.T) {
+	store := &stubStore{verification: &audit.Verification{Entries: 3, HeadSeq: 4, BrokenAt: 4, Problem: "entries 4 to 4 are missing"}}
+	h := audit.NewAdminHandler(store)
+
+	rec := httptest.NewRecorder()
+	h.ListHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/audit?action=admin.login&since=2024-05-01T00:00:00Z&limit=10", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body %s", rec.Code, rec.Body)
+	}
+	if store.filter.Action != audit.ActionAdminLogin || store.filter.Limit != 10 || store.filter.Since.IsZero() {
+		t.Errorf("filter = %+v, want admin logins since May, limited to 10", store.filter)
+	}
+
+	for _, target := range []string{"/admin/audit?limit=0", "/admin/audit?until=yesterday"} {
+		rec := httptest.NewRecorder()
+		h.ListHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.VerifyHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/audit/verify", nil))
+	var got audit.Verification
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if rec.Code != http.StatusOK || got.Valid || got.BrokenAt != 4 {
+		t.Errorf("verify = %d %+v, want the broken chain reported", rec.Code, got)
+	}
+}
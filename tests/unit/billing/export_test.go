@@ -0,0 +1,150 @@
+package billing_test
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+)
+
+type staticSource struct {
+	summaries []*billing.UsageSummary
+	from, to  time.Time
+}
+
+func (s *staticSource) AggregateUsage(ctx context.Context, from, to time.Time) ([]*billing.UsageSummary, error) {
+	s.from, s.to = from, to
+	return s.summaries, nil
+}
+
+func TestParsePeriod(t *testing.T) {
+	day, err := billing.ParsePeriod(billing.PeriodDaily, "2024-02-29")
+	if err != nil {
+		t.Fatalf("ParsePeriod() error = %v", err)
+	}
+	if !day.From.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) || !day.To.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected daily range %v - %v", day.From, day.To)
+	}
+
+	month, err := billing.ParsePeriod(billing.PeriodMonthly, "2024-12")
+	if err != nil {
+		t.Fatalf("ParsePeriod() error = %v", err)
+	}
+	if month.Label != "2024-12" || !month.To.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected monthly period %+v", month)
+	}
+
+	for _, tc := range [][2]string{{"weekly", "2024-12"}, {billing.PeriodDaily, "2024-12"}, {billing.PeriodMonthly, "December"}} {
+		if _, err := billing.ParsePeriod(tc[0], tc[1]); err == nil {
+			t.Errorf("expected an error for %v", tc)
+		}
+	}
+}
+
+func TestPricing_EstimateCost(t *testing.T) {
+	pricing, err := billing.ParsePricing(map[string]string{"custom-model": "0.5/1"})
+	if err != nil {
+		t.Fatalf("ParsePricing() error = %v", err)
+	}
+
+	cost, ok := pricing.EstimateCost("custom-model", 2000, 1000)
+	if !ok || math.Abs(cost-2.0) > 1e-9 {
+		t.Errorf("EstimateCost() = %v, %v, want 2.0, true", cost, ok)
+	}
+	if _, ok := pricing.EstimateCost("gpt-4.1", 1, 1); !ok {
+		t.Error("expected default prices to be kept")
+	}
+	if _, ok := pricing.EstimateCost("unknown", 1, 1); ok {
+		t.Error("expected no price for an unknown model")
+	}
+
+	if _, err := billing.ParsePricing(map[string]string{"gpt-4.1": "0.002"}); err == nil {
+		t.Error("expected an error for a price without completion part")
+	}
+}
+
+func TestExporter_WritesCSVAndJSON(t *testing.T) {
+	sink, err := objectstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	source := &staticSource{summaries: []*billing.UsageSummary{
+		{TenantID: "acme", UserID: "u1", Platform: "telegram", Model: "gpt-4.1", KeySource: billing.KeySourceTenant,
+			Requests: 2, PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500},
+		{TenantID: "default", UserID: "u2", Platform: "web", Model: "unknown", KeySource: billing.KeySourcePlatform,
+			Requests: 1, PromptTokens: 10, CompletionTokens: 10, TotalTokens: 20},
+	}}
+	exporter := billing.NewExporter(source, sink, billing.DefaultPricing())
+
+	period := billing.MonthlyPeriod(time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC))
+	result, err := exporter.Export(context.Background(), period)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	if !source.from.Equal(period.From) || !source.to.Equal(period.To) {
+		t.Errorf("aggregated %v - %v, want %v - %v", source.from, source.to, period.From, period.To)
+	}
+	if result.Rows != 2 || result.TotalTokens != 1520 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if math.Abs(result.EstimatedCostUSD-0.006) > 1e-9 {
+		t.Errorf("EstimatedCostUSD = %v, want 0.006", result.EstimatedCostUSD)
+	}
+	if len(result.Files) != 2 || result.Files[0] != "usage-monthly-2024-05.csv" || result.Files[1] != "usage-monthly-2024-05.json" {
+		t.Fatalf("unexpected files %v", result.Files)
+	}
+
+	f, err := exporter.Open(context.Background(), "usage-monthly-2024-05.csv")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[1][0] != "acme" || rows[1][9] != "0.006000" {
+		t.Errorf("unexpected CSV rows %v", rows)
+	}
+}
+
+func TestExporter_OpenRejectsUnknownNames(t *testing.T) {
+	sink, _ := objectstore.NewLocalStore(t.TempDir())
+	exporter := billing.NewExporter(&staticSource{}, sink, nil)
+
+	for _, name := range []string{"../../etc/passwd", "usage-daily-2024-05-01.txt", "usage-daily-2024-05-01.csv"} {
+		if _, err := exporter.Open(context.Background(), name); !errors.Is(err, billing.ErrExportNotFound) {
+			t.Errorf("Open(%q) error = %v, want ErrExportNotFound", name, err)
+		}
+	}
+}
+
+func TestExporter_ExportPreviousAddsMonthOnFirstDay(t *testing.T) {
+	sink, _ := objectstore.NewLocalStore(t.TempDir())
+	exporter := billing.NewExporter(&staticSource{}, sink, nil)
+
+	if err := exporter.ExportPrevious(context.Background(), time.Date(2024, 6, 1, 0, 5, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("ExportPrevious() error = %v", err)
+	}
+	for _, name := range []string{"usage-daily-2024-05-31.csv", "usage-monthly-2024-05.csv"} {
+		f, err := exporter.Open(context.Background(), name)
+		if err != nil {
+			t.Errorf("Open(%q) error = %v", name, err)
+			continue
+		}
+		f.Close()
+	}
+
+	if err := exporter.ExportPrevious(context.Background(), time.Date(2024, 6, 2, 0, 5, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("ExportPrevious() error = %v", err)
+	}
+	if _, err := exporter.Open(context.Background(), "usage-monthly-2024-06.csv"); err == nil {
+		t.Error("monthly export written before the month ended")
+	}
+}
@@ -0,0 +1,188 @@
+package blocklist_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/blocklist"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/gorilla/mux"
+)
+
+type memoryRepository struct {
+	policies map[string]*blocklist.Policy
+	reads    int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{policies: make(map[string]*blocklist.Policy)}
+}
+
+func (r *memoryRepository) GetPolicy(ctx context.Context) (*blocklist.Policy, error) {
+	r.reads++
+	p, ok := r.policies[tenant.FromContext(ctx)]
+	if !ok {
+		return nil, blocklist.ErrNotFound
+	}
+	return p, nil
+}
+
+func (r *memoryRepository) SavePolicy(ctx context.Context, p *blocklist.Policy) error {
+	r.policies[p.TenantID] = p
+	return nil
+}
+
+func (r *memoryRepository) DeletePolicy(ctx context.Context) error {
+	delete(r.policies, tenant.FromContext(ctx))
+	return nil
+}
+
+func TestMatcher(t *testing.T) {
+	matcher := blocklist.Compile(&blocklist.Policy{
+		Blocked: blocklist.List{Keywords: []string{"Acme Corp"}, Categories: []string{"gambling", "profanity"}},
+		Allowed: blocklist.List{Keywords: []string{"poker"}},
+	}, nil)
+
+	tests := []struct {
+		text     string
+		term     string
+		category string
+	}{
+		{"Tell me about ACME corp.", "acme corp", ""},
+		{"Where is the nearest casino?", "casino", "gambling"},
+		{"I love slot machines", "slot machines", "gambling"},
+		{"Let's play poker", "", ""},
+		{"Casinos", "", ""}, // Whole words only
+		{"The weather is nice", "", ""},
+	}
+	for _, tt := range tests {
+		match, ok := matcher.Find(tt.text)
+		if ok != (tt.term != "") || match.Term != tt.term || match.Category != tt.category {
+			t.Errorf("Find(%q) = %+v, %v, want %q in %q", tt.text, match, ok, tt.term, tt.category)
+		}
+	}
+
+	if got, forbidden := matcher.Redact("Oh SHIT, it rains"); got != "Oh S***, it rains" || forbidden {
+		t.Errorf("Redact() = %q, %v, want profanity masked", got, forbidden)
+	}
+	if _, forbidden := matcher.Redact("Try the casino"); !forbidden {
+		t.Error("Redact() did not report the forbidden topic")
+	}
+}
+
+func TestMatcher_Defaults(t *testing.T) {
+	defaults := []string{"politics", "drugs"}
+
+	matcher := blocklist.Compile(nil, defaults)
+	if _, ok := matcher.Find("Who won the election?"); !ok {
+		t.Error("default category not blocked for a tenant without a policy")
+	}
+
+	matcher = blocklist.Compile(&blocklist.Policy{Allowed: blocklist.List{Categories: []string{"politics"}}}, defaults)
+	if _, ok := matcher.Find("Who won the election?"); ok {
+		t.Error("allowed category still blocked")
+	}
+	if _, ok := matcher.Find("Is cannabis legal?"); !ok {
+		t.Error("default category not allowed by the tenant lifted")
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	valid := &blocklist.Policy{Blocked: blocklist.List{Keywords: []string{"acme"}, Categories: []string{"violence"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	invalid := []*blocklist.Policy{
+		{Blocked: blocklist.List{Categories: []string{"sports"}}},
+		{Allowed: blocklist.List{Keywords: []string{strings.Repeat("a", blocklist.MaxKeywordLength+1)}}},
+		{Blocked: blocklist.List{Keywords: make([]string, blocklist.MaxKeywords+1)}},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); !errors.Is(err, blocklist.ErrInvalid) {
+			t.Errorf("Validate(%+v) error = %v, want ErrInvalid", p, err)
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	repo := newMemoryRepository()
+	service := blocklist.NewService(repo, redisx.NewMemoryCache(time.Minute, 100), nil)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	if err := service.CheckMessage(acme, "Where is the casino?"); err != nil {
+		t.Errorf("CheckMessage() without policy error = %v", err)
+	}
+
+	saved, err := service.Set(acme, &blocklist.Policy{Blocked: blocklist.List{
+		Keywords:   []string{"  Globex ", "globex"},
+		Categories: []string{"Gambling"},
+	}})
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if saved.TenantID != "acme" || len(saved.Blocked.Keywords) != 1 || saved.Blocked.Categories[0] != "gambling" {
+		t.Errorf("Set() = %+v, want normalized terms of acme", saved)
+	}
+
+	err = service.CheckMessage(acme, "Where is the casino?")
+	var blocked *blocklist.BlockedError
+	if !errors.As(err, &blocked) || blocked.Match.Category != "gambling" {
+		t.Errorf("CheckMessage() error = %v, want blocked as gambling", err)
+	}
+	if err := service.CheckMessage(globex, "Where is the casino?"); err != nil {
+		t.Errorf("CheckMessage() of another tenant error = %v", err)
+	}
+
+	if got := service.FilterReply(acme, "Globex sells anvils"); got != blocklist.Refusal {
+		t.Errorf("FilterReply() = %q, want the refusal", got)
+	}
+
+	reads := repo.reads
+	service.CheckMessage(acme, "Hello")
+	if repo.reads != reads {
+		t.Error("CheckMessage() read the policy from the repository instead of the cache")
+	}
+
+	if err := service.Delete(acme); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := service.CheckMessage(acme, "Where is the casino?"); err != nil {
+		t.Errorf("CheckMessage() after Delete() error = %v", err)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	handler := blocklist.NewAdminHandler(blocklist.NewService(newMemoryRepository(), nil, []string{"adult"}))
+	router := mux.NewRouter()
+	router.HandleFunc("/admin/tenants/{tenant_id}/blocklist", handler.GetHandler).Methods(http.MethodGet)
+	router.HandleFunc("/admin/tenants/{tenant_id}/blocklist", handler.PutHandler).Methods(http.MethodPut)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{http.MethodPut, "/admin/tenants/acme/blocklist", `{"blocked":{"keywords":["globex"]}}`, http.StatusOK, `"keywords":["globex"]`},
+		{http.MethodGet, "/admin/tenants/acme/blocklist", "", http.StatusOK, `"default_categories":["adult"]`},
+		{http.MethodPut, "/admin/tenants/acme/blocklist", `{"blocked":{"categories":["sports"]}}`, http.StatusBadRequest, "unknown blocked category"},
+		{http.MethodPut, "/admin/tenants/acme/blocklist", `{`, http.StatusBadRequest, "invalid JSON body"},
+		{http.MethodGet, "/admin/tenants/Not%20Valid/blocklist", "", http.StatusBadRequest, "invalid tenant ID"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s %s = %d %s, want %d containing %s", tt.method, tt.path, rec.Code, rec.Body.String(), tt.status, tt.want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package botguard_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/botguard"
+)
+
+type botRecorder struct {
+	challenges []string
+	blocked    []string
+}
+
+func (r *botRecorder) RecordBotChallenge(_ context.Context, provider, result string) {
+	r.challenges = append(r.challenges, provider+":"+result)
+}
+
+func (r *botRecorder) RecordBotBlocked(_ context.Context, reason string) {
+	r.blocked = append(r.blocked, reason)
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func always(*http.Request) bool { return true }
+
+func request(ip string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/conversations", nil)
+	req.RemoteAddr = ip
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestProofOfWork_Verify(t *testing.T) {
+	pow := botguard.NewProofOfWork("secret", 8, time.Minute)
+	challenge, err := pow.Issue()
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	solution := botguard.Solve(challenge)
+
+	if err := pow.Verify(context.Background(), request("1.2.3.4", nil)); !errors.Is(err, botguard.ErrChallengeMissing) {
+		t.Errorf("no solution: error = %v, want ErrChallengeMissing", err)
+	}
+	if err := pow.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.SolutionHeader: solution})); err != nil {
+		t.Fatalf("valid solution: error = %v", err)
+	}
+	if err := pow.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.SolutionHeader: solution})); !errors.Is(err, botguard.ErrChallengeFailed) {
+		t.Errorf("reused solution: error = %v, want ErrChallengeFailed", err)
+	}
+
+	// Challenges signed with another secret are refused
+	other := botguard.NewProofOfWork("other", 8, time.Minute)
+	foreign, _ := other.Issue()
+	if err := pow.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.SolutionHeader: botguard.Solve(foreign)})); !errors.Is(err, botguard.ErrChallengeFailed) {
+		t.Errorf("foreign challenge: error = %v, want ErrChallengeFailed", err)
+	}
+
+	// Tampering with the difficulty breaks the signature
+	fresh, _ := pow.Issue()
+	fresh.Challenge = strings.Replace(fresh.Challenge, ".8.", ".0.", 1)
+	if err := pow.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.SolutionHeader: fresh.Challenge + ":0"})); !errors.Is(err, botguard.ErrChallengeFailed) {
+		t.Errorf("tampered challenge: error = %v, want ErrChallengeFailed", err)
+	}
+}
+
+func TestProofOfWork_Expired(t *testing.T) {
+	pow := botguard.NewProofOfWork("secret", 4, time.Nanosecond)
+	challenge, _ := pow.Issue()
+	time.Sleep(time.Until(challenge.ExpiresAt) + 10*time.Millisecond)
+	if err := pow.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.SolutionHeader: botguard.Solve(challenge)})); !errors.Is(err, botguard.ErrChallengeFailed) {
+		t.Errorf("expired challenge: error = %v, want ErrChallengeFailed", err)
+	}
+}
+
+func TestCaptcha_Verify(t *testing.T) {
+	var secret, remoteIP string
+	siteverify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		secret, remoteIP = r.PostForm.Get("secret"), r.PostForm.Get("remoteip")
+		if r.PostForm.Get("response") == "good" {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer siteverify.Close()
+
+	captcha, err := botguard.NewCaptcha(botguard.ChallengeTurnstile, "site-secret", botguard.WithVerifyURL(siteverify.URL))
+	if err != nil {
+		t.Fatalf("NewCaptcha() error = %v", err)
+	}
+
+	if err := captcha.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.TokenHeader: "good"})); err != nil {
+		t.Errorf("good token: error = %v", err)
+	}
+	if secret != "site-secret" || remoteIP != "1.2.3.4" {
+		t.Errorf("siteverify got secret %q remoteip %q", secret, remoteIP)
+	}
+	if err := captcha.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.TokenHeader: "bad"})); !errors.Is(err, botguard.ErrChallengeFailed) {
+		t.Errorf("bad token: error = %v, want ErrChallengeFailed", err)
+	}
+	if err := captcha.Verify(context.Background(), request("1.2.3.4", nil)); !errors.Is(err, botguard.ErrChallengeMissing) {
+		t.Errorf("no token: error = %v, want ErrChallengeMissing", err)
+	}
+
+	siteverify.Close()
+	if err := captcha.Verify(context.Background(), request("1.2.3.4", map[string]string{botguard.TokenHeader: "good"})); err == nil || errors.Is(err, botguard.ErrChallengeFailed) {
+		t.Errorf("provider down: error = %v, want a non-challenge error", err)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	if v, err := botguard.NewVerifier("off", "", 0); v != nil || err != nil {
+		t.Errorf("off: verifier = %v, error = %v", v, err)
+	}
+	if v, err := botguard.NewVerifier("pow", "", 10); err != nil || v.Provider() != botguard.ChallengePoW {
+		t.Errorf("pow: verifier = %v, error = %v", v, err)
+	}
+	if _, err := botguard.NewVerifier("hcaptcha", "", 0); err == nil {
+		t.Error("hcaptcha without a secret: expected an error")
+	}
+	if _, err := botguard.NewVerifier("recaptcha", "secret", 0); err == nil {
+		t.Error("unknown mode: expected an error")
+	}
+}
+
+func TestGuard_Challenge(t *testing.T) {
+	recorder := &botRecorder{}
+	pow := botguard.NewProofOfWork("secret", 6, time.Minute)
+	handler := botguard.NewGuard(botguard.Config{}, pow, recorder).Middleware(always)(okHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("1.2.3.4", nil))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "challenge_required") {
+		t.Errorf("no solution: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	challenge, _ := pow.Issue()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("1.2.3.4", map[string]string{botguard.SolutionHeader: botguard.Solve(challenge)}))
+	if rec.Code != http.StatusOK {
+		t.Errorf("solved: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	want := []string{"pow:missing", "pow:pass"}
+	if strings.Join(recorder.challenges, ",") != strings.Join(want, ",") {
+		t.Errorf("recorded challenges = %v, want %v", recorder.challenges, want)
+	}
+}
+
+func TestGuard_Throttle(t *testing.T) {
+	recorder := &botRecorder{}
+	handler := botguard.NewGuard(botguard.Config{FingerprintRPS: 0.001, FingerprintBurst: 2}, nil, recorder).Middleware(always)(okHandler())
+
+	browser := map[string]string{"User-Agent": "Mozilla/5.0", "Accept-Language": "en"}
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request("1.2.3.4", browser))
+		if rec.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+
+	// Another browser on the same address has its own budget
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("1.2.3.4", map[string]string{"User-Agent": "curl/8.0"}))
+	if rec.Code != http.StatusOK {
+		t.Errorf("other fingerprint: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if len(recorder.blocked) != 1 || recorder.blocked[0] != botguard.BlockThrottled {
+		t.Errorf("blocked = %v, want one %s", recorder.blocked, botguard.BlockThrottled)
+	}
+}
+
+func TestGuard_ThrottleBounded(t *testing.T) {
+	guard := botguard.NewGuard(botguard.Config{FingerprintRPS: 0.001, FingerprintBurst: 1, MaxFingerprints: 100}, nil, nil)
+	handler := guard.Middleware(always)(okHandler())
+
+	first := map[string]string{"User-Agent": "bot-0"}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("1.2.3.4", first))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// Rotating headers creates a fingerprint per request; the guard keeps only the most recent ones
+	for i := 1; i <= 1000; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), request("1.2.3.4", map[string]string{"User-Agent": fmt.Sprintf("bot-%d", i)}))
+	}
+	if n := guard.Fingerprints(); n > 100 {
+		t.Errorf("Fingerprints() = %d, want at most 100", n)
+	}
+
+	// The first fingerprint was forgotten, so it starts with a full bucket again
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("1.2.3.4", first))
+	if rec.Code != http.StatusOK {
+		t.Errorf("forgotten fingerprint: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestGuard_Honeypot(t *testing.T) {
+	guard := botguard.NewGuard(botguard.Config{HoneypotBan: time.Hour}, nil, nil)
+	handler := guard.Middleware(always)(okHandler())
+
+	rec := httptest.NewRecorder()
+	guard.HoneypotHandler(rec, httptest.NewRequest(http.MethodGet, "/wp-login.php", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("honeypot status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	// httptest requests come from 192.0.2.1; the ban holds whatever port the client connects from
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("192.0.2.1:5678", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("honeypot visitor: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, request("198.51.100.1:1234", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("other client: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,105 @@
+package budget_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/budget"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		status    int
+		hasBudget bool
+		maxLeft   time.Duration
+	}{
+		{"no header", "", http.StatusOK, false, 0},
+		{"budget", "2000", http.StatusOK, true, 2 * time.Second},
+		{"budget over the maximum", "600000", http.StatusOK, true, 10 * time.Second},
+		{"not a number", "soon", http.StatusBadRequest, false, 0},
+		{"not positive", "0", http.StatusBadRequest, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			var hasBudget bool
+			handler := budget.Middleware(10*time.Second, 100*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, hasBudget = budget.Deadline(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/twirp/acai.chat.ChatService/StartConversation", nil)
+			if tt.header != "" {
+				req.Header.Set(budget.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if hasBudget != tt.hasBudget {
+				t.Fatalf("has budget = %v, want %v", hasBudget, tt.hasBudget)
+			}
+			// The work deadline keeps the reserve back
+			if left := time.Until(deadline); hasBudget && (left > tt.maxLeft-100*time.Millisecond || left < tt.maxLeft-time.Second) {
+				t.Errorf("deadline in %s, want just under %s", left, tt.maxLeft-100*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx, cancel := budget.Context(budget.WithBudget(context.Background(), 30*time.Millisecond, 10*time.Millisecond))
+	defer cancel()
+
+	<-ctx.Done()
+	if !budget.Exceeded(ctx) {
+		t.Errorf("cause = %v, want %v", context.Cause(ctx), budget.ErrExceeded)
+	}
+
+	unbounded, cancel := budget.Context(context.Background())
+	defer cancel()
+	if _, ok := unbounded.Deadline(); ok {
+		t.Error("context without a budget has a deadline")
+	}
+}
+
+func TestToolContext(t *testing.T) {
+	ctx := budget.WithBudget(context.Background(), 2*time.Second, 0)
+	toolCtx, cancel := budget.ToolContext(ctx)
+	defer cancel()
+
+	deadline, ok := toolCtx.Deadline()
+	if left := time.Until(deadline); !ok || left > time.Second {
+		t.Errorf("tool deadline in %s, want at most half of the 2s budget", left)
+	}
+}
+
+func TestPartialReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		reply string
+		ok    bool
+	}{
+		{"partial answer", fmt.Errorf("reply: %w", &budget.ExceededError{Partial: "It is sunny"}), "It is sunny", true},
+		{"nothing answered", &budget.ExceededError{}, budget.Notice, true},
+		{"other error", errors.New("no choices returned by OpenAI"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, ok := budget.PartialReply(tt.err)
+			if reply != tt.reply || ok != tt.ok {
+				t.Errorf("PartialReply() = %q, %v, want %q, %v", reply, ok, tt.reply, tt.ok)
+			}
+		})
+	}
+}
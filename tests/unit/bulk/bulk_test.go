@@ -0,0 +1,299 @@
+package bulk_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/bulk"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryStore struct {
+	mu            sync.Mutex
+	conversations []*model.Conversation
+}
+
+func (s *memoryStore) matches(c *model.Conversation, f model.ConversationFilter) bool {
+	return (f.Platform == "" || c.Platform == f.Platform) &&
+		(f.Tag == "" || slices.Contains(c.Tags, f.Tag)) &&
+		(f.PromptVersion == "" || c.PromptVersion == f.PromptVersion) &&
+		(f.CreatedAfter.IsZero() || !c.CreatedAt.Before(f.CreatedAfter)) &&
+		(f.CreatedBefore.IsZero() || c.CreatedAt.Before(f.CreatedBefore))
+}
+
+func (s *memoryStore) CountConversations(ctx context.Context, f model.ConversationFilter) (int64, error) {
+	refs, _ := s.FindConversationRefs(ctx, f, primitive.NilObjectID, 1000)
+	return int64(len(refs)), nil
+}
+
+func (s *memoryStore) FindConversationRefs(ctx context.Context, f model.ConversationFilter, after primitive.ObjectID, limit int) ([]*model.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var refs []*model.Conversation
+	for _, c := range s.conversations {
+		if s.matches(c, f) && c.ID.Hex() > after.Hex() && len(refs) < limit {
+			refs = append(refs, c)
+		}
+	}
+	return refs, nil
+}
+
+func (s *memoryStore) update(ids []primitive.ObjectID, fn func(c *model.Conversation)) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, c := range s.conversations {
+		if slices.Contains(ids, c.ID) {
+			fn(c)
+			n++
+		}
+	}
+	return n
+}
+
+func (s *memoryStore) ArchiveConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	return s.update(ids, func(c *model.Conversation) { c.IsActive = false }), nil
+}
+
+func (s *memoryStore) DeleteConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := len(s.conversations)
+	s.conversations = slices.DeleteFunc(s.conversations, func(c *model.Conversation) bool {
+		return slices.Contains(ids, c.ID)
+	})
+	return int64(before - len(s.conversations)), nil
+}
+
+func (s *memoryStore) TagConversations(ctx context.Context, ids []primitive.ObjectID, add, remove []string) (int64, error) {
+	return s.update(ids, func(c *model.Conversation) {
+		c.Tags = slices.DeleteFunc(c.Tags, func(tag string) bool { return slices.Contains(remove, tag) })
+		for _, tag := range add {
+			if !slices.Contains(c.Tags, tag) {
+				c.Tags = append(c.Tags, tag)
+			}
+		}
+	}), nil
+}
+
+func (s *memoryStore) SetConversationPersona(ctx context.Context, ids []primitive.ObjectID, persona string) (int64, error) {
+	return s.update(ids, func(c *model.Conversation) { c.Persona, c.PromptVersion = persona, "" }), nil
+}
+
+func (s *memoryStore) SetConversationPromptVersion(ctx context.Context, ids []primitive.ObjectID, version string) (int64, error) {
+	return s.update(ids, func(c *model.Conversation) { c.PromptVersion = version }), nil
+}
+
+type memoryJobs struct {
+	mu   sync.Mutex
+	jobs map[string]bulk.Job
+}
+
+func (r *memoryJobs) SaveJob(ctx context.Context, job *bulk.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID.Hex()] = *job
+	return nil
+}
+
+func (r *memoryJobs) GetJob(ctx context.Context, id string) (*bulk.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.TenantID != tenant.FromContext(ctx) {
+		return nil, bulk.ErrJobNotFound
+	}
+	return &job, nil
+}
+
+type recordingSessions struct {
+	mu     sync.Mutex
+	closed []string
+}
+
+func (s *recordingSessions) DeleteSession(ctx context.Context, platform, chatID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = append(s.closed, platform+":"+chatID)
+	return nil
+}
+
+func newStore(n int) *memoryStore {
+	store := &memoryStore{}
+	for i := range n {
+		platform := "telegram"
+		if i%2 == 1 {
+			platform = "web"
+		}
+		store.conversations = append(store.conversations, &model.Conversation{
+			ID:        primitive.NewObjectID(),
+			Platform:  platform,
+			ChatID:    string(rune('a' + i)),
+			IsActive:  true,
+			CreatedAt: time.Now().Add(-time.Duration(i) * time.Hour),
+		})
+	}
+	return store
+}
+
+func waitForJob(t *testing.T, svc *bulk.Service, ctx context.Context, id string) *bulk.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := svc.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.Status != bulk.StatusRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("job did not finish")
+	return nil
+}
+
+func TestService_Archive(t *testing.T) {
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	store := newStore(7)
+	sessions := &recordingSessions{}
+	svc := bulk.NewService(store, &memoryJobs{jobs: map[string]bulk.Job{}}, sessions, 2, 1)
+
+	job, err := svc.Start(ctx, bulk.Request{
+		Filter:    model.ConversationFilter{Platform: "telegram"},
+		Operation: bulk.OperationArchive,
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if job.Matched != 4 {
+		t.Fatalf("expected 4 matching conversations, got %d", job.Matched)
+	}
+
+	job = waitForJob(t, svc, ctx, job.ID.Hex())
+	if job.Status != bulk.StatusCompleted || job.Processed != 4 || job.Modified != 4 || job.Progress() != 1 {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	for _, c := range store.conversations {
+		if c.IsActive == (c.Platform == "telegram") {
+			t.Errorf("conversation on %s has is_active=%v", c.Platform, c.IsActive)
+		}
+	}
+	if len(sessions.closed) != 4 {
+		t.Errorf("expected 4 closed sessions, got %v", sessions.closed)
+	}
+
+	if _, err := svc.Get(tenant.WithTenant(context.Background(), "other"), job.ID.Hex()); !errors.Is(err, bulk.ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound for another tenant, got %v", err)
+	}
+}
+
+func TestService_DryRunDoesNotModify(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(5)
+	svc := bulk.NewService(store, &memoryJobs{jobs: map[string]bulk.Job{}}, nil, 2, 1)
+
+	job, err := svc.Start(ctx, bulk.Request{Operation: bulk.OperationDelete, DryRun: true})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if job.Status != bulk.StatusCompleted || job.Matched != 5 || len(job.SampleIDs) != 5 {
+		t.Fatalf("unexpected dry run %+v", job)
+	}
+	if len(store.conversations) != 5 {
+		t.Errorf("dry run deleted conversations")
+	}
+}
+
+func TestService_Retag(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(3)
+	store.conversations[0].Tags = []string{"vip"}
+	svc := bulk.NewService(store, &memoryJobs{jobs: map[string]bulk.Job{}}, nil, 10, 1)
+
+	job, err := svc.Start(ctx, bulk.Request{
+		Filter:     model.ConversationFilter{Tag: "vip"},
+		Operation:  bulk.OperationRetag,
+		AddTags:    []string{"escalated"},
+		RemoveTags: []string{"vip"},
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitForJob(t, svc, ctx, job.ID.Hex())
+
+	if got := store.conversations[0].Tags; !slices.Equal(got, []string{"escalated"}) {
+		t.Errorf("tags = %v, want [escalated]", got)
+	}
+	if len(store.conversations[1].Tags) != 0 {
+		t.Errorf("unmatched conversation was retagged: %v", store.conversations[1].Tags)
+	}
+}
+
+func TestService_MigratePromptVersion(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(4)
+	store.conversations[0].PromptVersion = "v1"
+	store.conversations[1].PromptVersion = "v1"
+	store.conversations[2].PromptVersion = "v2"
+	svc := bulk.NewService(store, &memoryJobs{jobs: map[string]bulk.Job{}}, nil, 10, 1)
+
+	job, err := svc.Start(ctx, bulk.Request{
+		Filter:        model.ConversationFilter{PromptVersion: "v1"},
+		Operation:     bulk.OperationPrompt,
+		PromptVersion: "v3",
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	job = waitForJob(t, svc, ctx, job.ID.Hex())
+	if job.Modified != 2 {
+		t.Fatalf("modified = %d, want 2", job.Modified)
+	}
+
+	var versions []string
+	for _, c := range store.conversations {
+		versions = append(versions, c.PromptVersion)
+	}
+	if want := []string{"v3", "v3", "v2", ""}; !slices.Equal(versions, want) {
+		t.Errorf("prompt versions = %q, want %q", versions, want)
+	}
+}
+
+func TestRequest_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		req  bulk.Request
+		ok   bool
+	}{
+		{"archive", bulk.Request{Operation: bulk.OperationArchive}, true},
+		{"persona clears", bulk.Request{Operation: bulk.OperationPersona}, true},
+		{"prompt version unpins", bulk.Request{Operation: bulk.OperationPrompt}, true},
+		{"unknown operation", bulk.Request{Operation: "close"}, false},
+		{"retag without tags", bulk.Request{Operation: bulk.OperationRetag}, false},
+		{"empty tag", bulk.Request{Operation: bulk.OperationRetag, AddTags: []string{" "}}, false},
+		{"inverted range", bulk.Request{
+			Operation: bulk.OperationArchive,
+			Filter:    model.ConversationFilter{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.ok && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if !tt.ok && !errors.Is(err, bulk.ErrInvalidRequest) {
+				t.Errorf("Validate() = %v, want ErrInvalidRequest", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package chat_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+)
+
+// countWords counts one token per word
+func countWords(s string) int { return len(strings.Fields(s)) }
+
+func TestSplitChunks_ShortInput(t *testing.T) {
+	chunks := chat.SplitChunks("one two three", 5, countWords)
+	if len(chunks) != 1 || chunks[0] != "one two three" {
+		t.Errorf("SplitChunks() = %q, want the input unchanged", chunks)
+	}
+}
+
+func TestSplitChunks_BreaksBetweenLines(t *testing.T) {
+	text := "a b c\nd e\nf g h\ni"
+	chunks := chat.SplitChunks(text, 5, countWords)
+
+	want := []string{"a b c\nd e", "f g h\ni"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("SplitChunks() = %q, want %q", chunks, want)
+	}
+}
+
+func TestSplitChunks_BreaksLongLinesBetweenWords(t *testing.T) {
+	text := strings.TrimSpace(strings.Repeat("word ", 12))
+	chunks := chat.SplitChunks(text, 5, countWords)
+
+	if len(chunks) != 3 {
+		t.Fatalf("SplitChunks() = %q, want 3 chunks", chunks)
+	}
+	total := 0
+	for _, chunk := range chunks {
+		if n := countWords(chunk); n > 5 {
+			t.Errorf("chunk %q has %d tokens, want at most 5", chunk, n)
+		}
+		total += countWords(chunk)
+	}
+	if total != 12 {
+		t.Errorf("chunks hold %d words, want all 12", total)
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	// Without a listener reporting is a no-op
+	chat.ReportProgress(context.Background(), chat.Progress{Stage: "long_input", Done: 1, Total: 2})
+
+	var got []chat.Progress
+	ctx := chat.WithProgress(context.Background(), func(p chat.Progress) { got = append(got, p) })
+	chat.ReportProgress(ctx, chat.Progress{Stage: "long_input", Total: 2})
+	chat.ReportProgress(ctx, chat.Progress{Stage: "long_input", Done: 1, Total: 2})
+
+	if len(got) != 2 || got[1].Done != 1 || got[1].Total != 2 {
+		t.Errorf("reported progress = %+v", got)
+	}
+}
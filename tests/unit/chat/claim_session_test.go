@@ -0,0 +1,92 @@
+package chat_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
+	"github.com/twitchtv/twirp"
+)
+
+func TestServer_ClaimSession(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewConversationRepository()
+	sessions := session.NewManager(redisx.NewMemoryCache(time.Hour, 0), time.Hour, repo)
+	prefs := &memorySettings{stored: map[string]*settings.Settings{
+		"webwidget:anon-1": {Platform: "webwidget", UserID: "anon-1", Units: settings.UnitsImperial, Timezone: "Europe/Madrid"},
+		"webwidget:user-9": {Platform: "webwidget", UserID: "user-9", Timezone: "Europe/Paris"},
+	}}
+	auditLog := &recordingAudit{}
+	srv := chat.NewServer(repo, &MockAssistant{ReplyResponse: "Sunny"}, sessions,
+		chat.WithUserSettings(prefs), chat.WithAuditLog(auditLog))
+
+	anonymous := &pb.SessionMetadata{Platform: "webwidget", UserId: "anon-1", ChatId: "tab-anon"}
+	authenticated := &pb.SessionMetadata{Platform: "webwidget", UserId: "user-9", ChatId: "tab-user"}
+	if _, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		Message: "Weather in Paris?", SessionMetadata: anonymous,
+	}); err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+
+	resp, err := srv.ClaimSession(ctx, &pb.ClaimSessionRequest{Anonymous: anonymous, SessionMetadata: authenticated})
+	if err != nil {
+		t.Fatalf("ClaimSession() error = %v", err)
+	}
+
+	conv := onlyConversation(t, repo)
+	if len(resp.ConversationIds) != 1 || resp.ConversationId != conv.ID.Hex() {
+		t.Errorf("ClaimSession() = %v, want the anonymous conversation", resp)
+	}
+	if conv.UserID != "user-9" || conv.ChatID != "tab-user" {
+		t.Errorf("conversation belongs to %s in %s, want user-9 in tab-user", conv.UserID, conv.ChatID)
+	}
+
+	// The account's timezone wins; the units chosen while anonymous fill the gap
+	if resp.Settings.GetUnits() != settings.UnitsImperial || resp.Settings.GetTimezone() != "Europe/Paris" {
+		t.Errorf("settings = %v, want imperial units and the account's timezone", resp.Settings)
+	}
+
+	if len(auditLog.entries) != 1 || auditLog.entries[0].Action != audit.ActionSessionClaimed ||
+		auditLog.entries[0].Before != "webwidget:anon-1" || auditLog.entries[0].After != "webwidget:user-9" {
+		t.Errorf("audit entries = %+v, want one claim from anon-1 to user-9", auditLog.entries)
+	}
+
+	// The conversation continues in the authenticated chat
+	if _, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		Message: "And tomorrow?", SessionMetadata: authenticated,
+	}); err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+	if conv := onlyConversation(t, repo); len(conv.Messages) != 4 {
+		t.Errorf("got %d messages, want both exchanges in the claimed conversation", len(conv.Messages))
+	}
+}
+
+func TestServer_ClaimSession_Validation(t *testing.T) {
+	srv := chat.NewServer(mocks.NewConversationRepository(), &MockAssistant{}, mocks.NewSessionStore(nil))
+
+	tests := map[string]*pb.ClaimSessionRequest{
+		"missing anonymous user": {SessionMetadata: &pb.SessionMetadata{Platform: "webwidget", UserId: "user-9"}},
+		"other platform": {
+			Anonymous:       &pb.SessionMetadata{Platform: "webwidget", UserId: "anon-1"},
+			SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "user-9"},
+		},
+		"same user": {
+			Anonymous:       &pb.SessionMetadata{Platform: "webwidget", UserId: "user-9"},
+			SessionMetadata: &pb.SessionMetadata{Platform: "webwidget", UserId: "user-9"},
+		},
+	}
+	for name, req := range tests {
+		_, err := srv.ClaimSession(context.Background(), req)
+		if twerr, ok := err.(twirp.Error); !ok || (twerr.Code() != twirp.InvalidArgument && twerr.Code() != twirp.Malformed) {
+			t.Errorf("%s: error = %v, want an invalid argument", name, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package chat_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/inflight"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
+	"github.com/twitchtv/twirp"
+)
+
+// slowAssistant replies with the number of user messages it saw, after unblock is closed or the context ends
+type slowAssistant struct {
+	MockAssistant
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (a *slowAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	a.started <- struct{}{}
+	select {
+	case <-a.unblock:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	var users []string
+	for _, m := range conv.Messages {
+		if m.Role == model.RoleUser {
+			users = append(users, m.Content)
+		}
+	}
+	return "answered " + users[len(users)-1], nil
+}
+
+func TestServer_ContinueConversation_ReplyGuard(t *testing.T) {
+	run := func(policy inflight.Policy) (*mocks.ConversationRepository, []error) {
+		assist := &slowAssistant{started: make(chan struct{}, 2), unblock: make(chan struct{})}
+		repo := mocks.NewConversationRepository()
+		sessions := session.NewManager(redisx.NewMemoryCache(time.Hour, 0), time.Hour, repo)
+		guard := inflight.NewGuard(map[string]inflight.Policy{"telegram": policy})
+		srv := chat.NewServer(repo, assist, sessions, chat.WithReplyGuard(guard))
+
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		send := func(i int, message string) {
+			defer wg.Done()
+			_, errs[i] = srv.ContinueConversation(context.Background(), &pb.ContinueConversationRequest{
+				Message:         message,
+				SessionMetadata: telegramChat("chat-1"),
+			})
+		}
+		wg.Add(2)
+		go send(0, "first")
+		<-assist.started
+		go send(1, "second")
+		// Give the second message time to reach the guard while the first reply is generated
+		time.Sleep(50 * time.Millisecond)
+		close(assist.unblock)
+		wg.Wait()
+		return repo, errs
+	}
+
+	t.Run("queue answers messages one after another", func(t *testing.T) {
+		repo, errs := run(inflight.PolicyQueue)
+		if errs[0] != nil || errs[1] != nil {
+			t.Fatalf("errors = %v", errs)
+		}
+		var roles []model.Role
+		for _, m := range onlyConversation(t, repo).Messages {
+			roles = append(roles, m.Role)
+		}
+		want := []model.Role{model.RoleUser, model.RoleAssistant, model.RoleUser, model.RoleAssistant}
+		if len(roles) != len(want) {
+			t.Fatalf("roles = %v, want %v", roles, want)
+		}
+		for i := range want {
+			if roles[i] != want[i] {
+				t.Fatalf("roles = %v, want %v", roles, want)
+			}
+		}
+	})
+
+	t.Run("merge answers both messages with one reply", func(t *testing.T) {
+		repo, errs := run(inflight.PolicyMerge)
+		if twerr, ok := errs[0].(twirp.Error); !ok || twerr.Code() != twirp.Aborted {
+			t.Errorf("superseded request error = %v, want aborted", errs[0])
+		}
+		if errs[1] != nil {
+			t.Fatalf("error = %v", errs[1])
+		}
+		messages := onlyConversation(t, repo).Messages
+		if len(messages) != 3 || messages[0].Content != "first" || messages[1].Content != "second" ||
+			messages[2].Content != "answered second" {
+			t.Errorf("messages = %v, want both questions and one reply", messages)
+		}
+	})
+}
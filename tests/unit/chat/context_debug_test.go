@@ -0,0 +1,117 @@
+package chat_test
+
+import (
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func storedConversation(contents ...string) *model.Conversation {
+	conv := &model.Conversation{ID: primitive.NewObjectID()}
+	for i, content := range contents {
+		role := model.RoleUser
+		if i%2 == 1 {
+			role = model.RoleAssistant
+		}
+		conv.Messages = append(conv.Messages, &model.Message{ID: primitive.NewObjectID(), Role: role, Content: content})
+	}
+	return conv
+}
+
+func TestDiffContext(t *testing.T) {
+	conv := storedConversation("hi", "hello", "weather in Paris?", "sunny", "and in Rome?", "rainy", "a very long question", "an answer")
+	snapshot := &chat.ContextSnapshot{
+		ConversationID: conv.ID.Hex(),
+		MessageIndex:   7,
+		SystemPrompt:   "You are helpful",
+		PinnedFacts:    []string{"lives in Paris"},
+		Messages: []chat.Message{
+			{Role: "system", Content: chat.SummaryPrefix + "greetings"},
+			{Role: "user", Content: "weather in Paris?"},
+			{Role: "assistant", Content: "sunny"},
+			{Role: "assistant", Content: "rainy"},
+			{Role: "assistant", ToolCalls: []chat.ToolCall{{ID: "call_1", Name: "get_weather"}}},
+			{Role: "tool", Content: "12C", ToolCallID: "call_1"},
+			{Role: "user", Content: "a condensed question"},
+		},
+	}
+
+	diff := chat.DiffContext(conv, snapshot)
+
+	want := []string{
+		chat.StatusSummarized, chat.StatusSummarized,
+		chat.StatusSent, chat.StatusSent,
+		chat.StatusDropped, chat.StatusSent,
+		chat.StatusCondensed, chat.StatusLater,
+	}
+	for i, stored := range diff.Stored {
+		if stored.Status != want[i] {
+			t.Errorf("stored[%d] %q: status = %s, want %s", i, stored.Content, stored.Status, want[i])
+		}
+	}
+	if diff.Counts[chat.StatusSent] != 3 || diff.Counts[chat.StatusSummarized] != 2 {
+		t.Errorf("counts = %v", diff.Counts)
+	}
+
+	kinds := []string{
+		chat.KindSystemPrompt, chat.KindPinnedFacts, chat.KindSummary,
+		chat.KindMessage, chat.KindMessage, chat.KindMessage,
+		chat.KindTool, chat.KindTool, chat.KindMessage,
+	}
+	if len(diff.Context) != len(kinds) {
+		t.Fatalf("context has %d entries, want %d", len(diff.Context), len(kinds))
+	}
+	for i, entry := range diff.Context {
+		if entry.Kind != kinds[i] {
+			t.Errorf("context[%d]: kind = %s, want %s", i, entry.Kind, kinds[i])
+		}
+	}
+	if diff.Context[3].StoredIndex != 2 || diff.Context[5].StoredIndex != 5 || diff.Context[8].StoredIndex != -1 {
+		t.Errorf("stored indexes = %d, %d, %d, want 2, 5, -1",
+			diff.Context[3].StoredIndex, diff.Context[5].StoredIndex, diff.Context[8].StoredIndex)
+	}
+}
+
+func TestDiffContext_WithoutSummary(t *testing.T) {
+	// Messages missing from an unsummarized context were dropped, not summarized
+	conv := storedConversation("first", "reply", "second")
+	snapshot := &chat.ContextSnapshot{
+		ConversationID: conv.ID.Hex(),
+		MessageIndex:   3,
+		Messages:       []chat.Message{{Role: "user", Content: "second"}},
+	}
+
+	diff := chat.DiffContext(conv, snapshot)
+	if diff.Counts[chat.StatusDropped] != 2 || diff.Counts[chat.StatusSent] != 1 {
+		t.Errorf("counts = %v, want 2 dropped and 1 sent", diff.Counts)
+	}
+}
+
+func TestDiffContext_SystemNote(t *testing.T) {
+	conv := storedConversation("hi", "hello")
+	note := &model.Message{ID: primitive.NewObjectID(), Role: model.RoleSystem, Content: "The user upgraded their plan"}
+	conv.Messages = append(conv.Messages, note,
+		&model.Message{ID: primitive.NewObjectID(), Role: model.RoleUser, Content: "what changes for me?"})
+
+	sent := chat.ConvertModelMessage(note)
+	if sent.Role != "system" || sent.Content != chat.SystemNotePrefix+note.Content {
+		t.Fatalf("ConvertModelMessage() = %+v, want the note as a prefixed system message", sent)
+	}
+
+	snapshot := &chat.ContextSnapshot{
+		ConversationID: conv.ID.Hex(),
+		MessageIndex:   4,
+		Messages: []chat.Message{
+			{Role: "user", Content: "hi"},
+			{Role: "assistant", Content: "hello"},
+			sent,
+			{Role: "user", Content: "what changes for me?"},
+		},
+	}
+	diff := chat.DiffContext(conv, snapshot)
+	if diff.Counts[chat.StatusSent] != 4 {
+		t.Errorf("expected every stored message sent, including the note, got %v", diff.Counts)
+	}
+}
@@ -0,0 +1,157 @@
+package chat_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+)
+
+func TestSummaryWindows(t *testing.T) {
+	// One token per character keeps the arithmetic readable
+	count := func(s string) int { return len(s) }
+
+	messages := func(n, size int) []chat.Message {
+		var msgs []chat.Message
+		for i := 0; i < n; i++ {
+			msgs = append(msgs, chat.Message{Role: "user", Content: strings.Repeat("x", size)})
+		}
+		return msgs
+	}
+
+	tests := []struct {
+		name          string
+		messages      []chat.Message
+		targetTokens  int
+		windowSize    int
+		summaryTokens int
+		want          []int // Window lengths
+	}{
+		{
+			name:         "fits without summarizing",
+			messages:     messages(5, 10),
+			targetTokens: 50,
+			windowSize:   2,
+			want:         nil,
+		},
+		{
+			name:          "summarizes fixed windows until the rest fits",
+			messages:      messages(10, 10),
+			targetTokens:  60,
+			windowSize:    3,
+			summaryTokens: 5,
+			want:          []int{3, 3},
+		},
+		{
+			name:          "never summarizes the latest message",
+			messages:      messages(4, 100),
+			targetTokens:  10,
+			windowSize:    2,
+			summaryTokens: 5,
+			want:          []int{2, 1},
+		},
+		{
+			name:         "single message is never summarized",
+			messages:     messages(1, 100),
+			targetTokens: 10,
+			windowSize:   2,
+			want:         nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows := chat.SummaryWindows(tt.messages, tt.targetTokens, tt.windowSize, tt.summaryTokens, count)
+			if len(windows) != len(tt.want) {
+				t.Fatalf("got %d windows, want %d", len(windows), len(tt.want))
+			}
+			for i, w := range windows {
+				if len(w) != tt.want[i] {
+					t.Errorf("window %d has %d messages, want %d", i, len(w), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIsSummary(t *testing.T) {
+	if !chat.IsSummary(chat.Message{Role: "system", Content: chat.SummaryPrefix + "facts"}) {
+		t.Error("expected segment summary to be recognized")
+	}
+	if chat.IsSummary(chat.Message{Role: "user", Content: chat.SummaryPrefix + "facts"}) {
+		t.Error("user messages must never be treated as summaries")
+	}
+}
+
+func TestCompleteToolTurns(t *testing.T) {
+	call := func(ids ...string) chat.Message {
+		msg := chat.Message{Role: "assistant"}
+		for _, id := range ids {
+			msg.ToolCalls = append(msg.ToolCalls, chat.ToolCall{ID: id, Name: "get_weather", Arguments: "{}"})
+		}
+		return msg
+	}
+	result := func(id string) chat.Message {
+		return chat.Message{Role: "tool", Name: "get_weather", ToolCallID: id, Content: "sunny"}
+	}
+	user := chat.Message{Role: "user", Content: "weather?"}
+	reply := chat.Message{Role: "assistant", Content: "It is sunny"}
+
+	roles := func(msgs []chat.Message) string {
+		var r []string
+		for _, m := range msgs {
+			r = append(r, m.Role)
+		}
+		return strings.Join(r, ",")
+	}
+
+	tests := []struct {
+		name     string
+		messages []chat.Message
+		want     string
+	}{
+		{
+			name:     "keeps complete tool turns",
+			messages: []chat.Message{user, call("a", "b"), result("a"), result("b"), reply},
+			want:     "user,assistant,tool,tool,assistant",
+		},
+		{
+			name:     "drops orphaned results",
+			messages: []chat.Message{result("a"), result("b"), reply, user},
+			want:     "assistant,user",
+		},
+		{
+			name:     "drops calls missing a result",
+			messages: []chat.Message{user, call("a", "b"), result("a"), reply},
+			want:     "user,assistant",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roles(chat.CompleteToolTurns(tt.messages)); got != tt.want {
+				t.Errorf("CompleteToolTurns() roles = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinnedFactsMessage(t *testing.T) {
+	if _, ok := chat.PinnedFactsMessage(nil); ok {
+		t.Error("expected no message without pinned facts")
+	}
+
+	msg, ok := chat.PinnedFactsMessage([]string{"Order number is 48213", "Name is Ana"})
+	if !ok {
+		t.Fatal("expected a message for pinned facts")
+	}
+	if msg.Role != "system" || !strings.HasPrefix(msg.Content, chat.PinnedFactsPrefix) {
+		t.Errorf("message = %+v", msg)
+	}
+	if !strings.Contains(msg.Content, "\n- Order number is 48213\n- Name is Ana") {
+		t.Errorf("facts missing from %q", msg.Content)
+	}
+	if chat.IsSummary(msg) {
+		t.Error("pinned facts must not be taken for a segment summary")
+	}
+}
@@ -0,0 +1,95 @@
+package chat_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/redis/go-redis/v9"
+)
+
+// unreachableRedis returns a cache over a Redis server that refuses every connection
+func unreachableRedis(t *testing.T) *redisx.Cache {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { client.Close() })
+	return redisx.NewCache(client, time.Hour)
+}
+
+func TestNewContextStore(t *testing.T) {
+	redisCache := unreachableRedis(t)
+
+	tests := []struct {
+		strategy chat.StorageStrategy
+		wantErr  bool
+	}{
+		{chat.StorageRedis, false},
+		{"", false},
+		{chat.StorageMemory, false},
+		{chat.StorageHybrid, false},
+		{"memcached", true},
+	}
+	for _, tt := range tests {
+		store, err := chat.NewContextStore(chat.StorageConfig{Strategy: tt.strategy, TTL: time.Hour}, redisCache)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: got error %v, want error %v", tt.strategy, err, tt.wantErr)
+		}
+		if err == nil && store == nil {
+			t.Errorf("%q: got nil store", tt.strategy)
+		}
+	}
+
+	if _, err := chat.ParseStorageStrategy("hybrid"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := chat.ParseStorageStrategy("disk"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+// contextRoundTrip stores messages and a pinned fact and reads them back
+func contextRoundTrip(t *testing.T, cm chat.ContextManagerInterface) {
+	t.Helper()
+	ctx := context.Background()
+	id := "conv-storage"
+
+	if err := cm.AddMessage(ctx, id, chat.Message{Role: "user", Content: "Hi"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cm.AddMessage(ctx, id, chat.Message{Role: "assistant", Content: "Hello!"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cm.PinFact(ctx, id, "The user lives in Barcelona"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := cm.GetContext(id); len(got) != 2 || got[1].Content != "Hello!" {
+		t.Errorf("got context %+v", got)
+	}
+	if got := cm.GetPinnedFacts(id); len(got) != 1 {
+		t.Errorf("got pinned facts %v", got)
+	}
+
+	cm.ClearContext(id)
+	if got := cm.GetContext(id); len(got) != 0 {
+		t.Errorf("expected the context to be cleared, got %+v", got)
+	}
+}
+
+func TestContextManager_MemoryStorage(t *testing.T) {
+	store, err := chat.NewContextStore(chat.StorageConfig{Strategy: chat.StorageMemory, TTL: time.Hour}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	contextRoundTrip(t, chat.NewContextManagerWithDefault(store, 4000, 50))
+}
+
+func TestContextManager_HybridStorageFailover(t *testing.T) {
+	store, err := chat.NewContextStore(chat.StorageConfig{Strategy: chat.StorageHybrid, TTL: time.Hour, LocalTTL: time.Hour}, unreachableRedis(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Conversations keep their context from the local copies while Redis is down
+	contextRoundTrip(t, chat.NewContextManagerWithDefault(store, 4000, 50))
+}
@@ -0,0 +1,77 @@
+package chat_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+)
+
+func TestParseHistoryWindows(t *testing.T) {
+	windows, err := chat.ParseHistoryWindows(
+		map[string]string{"telegram": "20/2000", "web": "100/0"},
+		map[string]string{"support": "0/12000"},
+	)
+	if err != nil {
+		t.Fatalf("ParseHistoryWindows() error = %v", err)
+	}
+
+	tests := []struct {
+		platform, persona string
+		want              chat.HistoryWindow
+	}{
+		{"telegram", "", chat.HistoryWindow{MaxMessages: 20, MaxTokens: 2000}},
+		{"web", "", chat.HistoryWindow{MaxMessages: 100}},
+		{"api", "", chat.HistoryWindow{}},
+		{"telegram", "support", chat.HistoryWindow{MaxMessages: 20, MaxTokens: 12000}},
+		{"api", "support", chat.HistoryWindow{MaxTokens: 12000}},
+		{"telegram", "sales", chat.HistoryWindow{MaxMessages: 20, MaxTokens: 2000}},
+	}
+	for _, tt := range tests {
+		if got := windows.Resolve(tt.platform, tt.persona); got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %+v, want %+v", tt.platform, tt.persona, got, tt.want)
+		}
+	}
+
+	var none *chat.HistoryWindows
+	if got := none.Resolve("telegram", ""); got != (chat.HistoryWindow{}) {
+		t.Errorf("nil windows resolved to %+v", got)
+	}
+}
+
+func TestParseHistoryWindows_Invalid(t *testing.T) {
+	for _, spec := range []string{"20", "twenty/2000", "20/-1", "-5/100"} {
+		if _, err := chat.ParseHistoryWindows(map[string]string{"telegram": spec}, nil); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestContextManager_HistoryWindow(t *testing.T) {
+	cm := chat.NewContextManager(redisx.NewMemoryCache(time.Hour, 0), 4000, 50, nil)
+	var messages []chat.Message
+	for i := range 30 {
+		messages = append(messages, chat.Message{Role: "user", Content: fmt.Sprintf("message %d", i)})
+	}
+
+	// The platform's window is shorter than the default of 50 messages
+	ctx := chat.WithHistoryWindow(context.Background(), chat.HistoryWindow{MaxMessages: 20})
+	kept, err := cm.AddMessages(ctx, "short", messages)
+	if err != nil {
+		t.Fatalf("AddMessages() error = %v", err)
+	}
+	if len(kept) != 20 || kept[0].Content != "message 10" {
+		t.Errorf("kept %d messages starting with %q, want the last 20", len(kept), kept[0].Content)
+	}
+
+	kept, err = cm.AddMessages(context.Background(), "default", messages)
+	if err != nil {
+		t.Fatalf("AddMessages() error = %v", err)
+	}
+	if len(kept) != 30 {
+		t.Errorf("kept %d messages without a window, want all 30", len(kept))
+	}
+}
@@ -0,0 +1,139 @@
+package chat_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNoteHandler_Inject(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	auditLog := &recordingAudit{}
+	srv := chat.NewServer(repo, &MockAssistant{}, nil, chat.WithAuditLog(auditLog))
+
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", UserID: "42"}
+	repo.CreateConversation(ctx, conv)
+
+	// Mounted like cmd/server
+	tokens := admin.NewTokenIssuer("secret", time.Hour)
+	auth := admin.NewAuthenticator(nil, tokens, "", nil)
+	router := mux.NewRouter()
+	router.Handle("/admin/conversations/{id}/notes",
+		auth.Require(admin.RoleOperator)(http.HandlerFunc(chat.NewNoteHandler(srv).InjectHandler))).Methods(http.MethodPost)
+	token := func(roles ...string) string {
+		user, err := admin.NewUser("alice", "correct horse battery", roles)
+		if err != nil {
+			t.Fatalf("NewUser() error = %v", err)
+		}
+		signed, _, err := tokens.Issue(user)
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		return signed
+	}
+	post := func(id, body, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/conversations/"+id+"/notes", strings.NewReader(body))
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	operator := token(admin.RoleOperator)
+	for _, tc := range []struct {
+		name, id, body, bearer string
+		want                   int
+	}{
+		{"anonymous", conv.ID.Hex(), `{"content":"Upgraded"}`, "", http.StatusUnauthorized},
+		{"without the operator role", conv.ID.Hex(), `{"content":"Upgraded"}`, token(admin.RoleEditor), http.StatusForbidden},
+		{"empty note", conv.ID.Hex(), `{"content":"  "}`, operator, http.StatusBadRequest},
+		{"long note", conv.ID.Hex(), `{"content":"` + strings.Repeat("é", model.MaxSystemNoteChars+1) + `"}`, operator, http.StatusBadRequest},
+		{"invalid body", conv.ID.Hex(), `{`, operator, http.StatusBadRequest},
+		{"unknown conversation", primitive.NewObjectID().Hex(), `{"content":"Upgraded"}`, operator, http.StatusNotFound},
+	} {
+		if rec := post(tc.id, tc.body, tc.bearer); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d: %s", tc.name, rec.Code, tc.want, rec.Body)
+		}
+	}
+	if len(repo.conversations[conv.ID.Hex()].Messages) != 0 {
+		t.Fatalf("rejected notes were stored: %v", repo.conversations[conv.ID.Hex()].Messages)
+	}
+
+	rec := post(conv.ID.Hex(), `{"content":" The user upgraded their plan "}`, operator)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var note chat.NoteResponse
+	if err := json.NewDecoder(rec.Body).Decode(&note); err != nil {
+		t.Fatalf("failed to decode note: %v", err)
+	}
+	// The author is the authenticated admin, not something the caller chooses
+	if note.Author != "admin:alice" || note.Content != "The user upgraded their plan" || note.ConversationID != conv.ID.Hex() {
+		t.Errorf("unexpected note: %+v", note)
+	}
+
+	described, err := srv.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: conv.ID.Hex()})
+	if err != nil {
+		t.Fatalf("DescribeConversation() error = %v", err)
+	}
+	messages := described.GetConversation().GetMessages()
+	if len(messages) != 1 || messages[0].GetRole() != pb.Conversation_SYSTEM || messages[0].GetAuthor() != "admin:alice" {
+		t.Errorf("expected the note listed as a system message, got %v", messages)
+	}
+
+	if len(auditLog.entries) != 1 || auditLog.entries[0].Action != audit.ActionConversationNoteInjected ||
+		auditLog.entries[0].Actor != "admin:alice" || auditLog.entries[0].Target != conv.ID.Hex() {
+		t.Errorf("unexpected audit entries: %+v", auditLog.entries)
+	}
+}
+
+func TestServer_NoteAddedDuringReplyIsKept(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewConversationRepository()
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", UserID: "42"}
+	repo.CreateConversation(ctx, conv)
+
+	var srv *chat.Server
+	assist := &busyAssistant{MockAssistant: MockAssistant{ReplyResponse: "Hi"}}
+	assist.during = func(conv *model.Conversation) {
+		if _, err := srv.InjectSystemNote(ctx, conv.ID.Hex(), "The user upgraded their plan", "admin:alice"); err != nil {
+			t.Fatalf("InjectSystemNote() error = %v", err)
+		}
+	}
+	srv = chat.NewServer(repo, assist, nil)
+
+	if _, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{ConversationId: conv.ID.Hex(), Message: "Hello"}); err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+
+	stored, err := repo.DescribeConversation(ctx, conv.ID.Hex())
+	if err != nil {
+		t.Fatalf("DescribeConversation() error = %v", err)
+	}
+	var roles []model.Role
+	for _, m := range stored.Messages {
+		roles = append(roles, m.Role)
+	}
+	// The note is stored before the reply's messages, which are appended rather than overwriting it
+	want := []model.Role{model.RoleSystem, model.RoleUser, model.RoleAssistant}
+	if !slices.Equal(roles, want) {
+		t.Errorf("stored roles = %v, want %v", roles, want)
+	}
+}
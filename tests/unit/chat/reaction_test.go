@@ -0,0 +1,35 @@
+package chat_test
+
+import (
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+)
+
+func TestReactionSentiment(t *testing.T) {
+	tests := map[string]string{
+		"👍":  model.SentimentPositive,
+		"👍🏽": model.SentimentPositive,
+		"❤️": model.SentimentPositive,
+		"👎":  model.SentimentNegative,
+		"🤔":  model.SentimentNeutral,
+	}
+	for emoji, want := range tests {
+		if got := model.ReactionSentiment(emoji); got != want {
+			t.Errorf("ReactionSentiment(%q) = %s, want %s", emoji, got, want)
+		}
+	}
+}
+
+func TestValidReaction(t *testing.T) {
+	for _, valid := range []string{"👍", "👍🏽", "❤️", "👨‍👩‍👧"} {
+		if !model.ValidReaction(valid) {
+			t.Errorf("ValidReaction(%q) = false", valid)
+		}
+	}
+	for _, invalid := range []string{"", "+1", "good", "👍 ", "👍👍👍👍👍👍👍👍👍"} {
+		if model.ValidReaction(invalid) {
+			t.Errorf("ValidReaction(%q) = true", invalid)
+		}
+	}
+}
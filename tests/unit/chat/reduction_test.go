@@ -0,0 +1,128 @@
+package chat_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+)
+
+// countChars counts one token per character to keep the arithmetic readable
+func countChars(s string) int { return len(s) }
+
+func sized(sizes ...int) []chat.Message {
+	msgs := make([]chat.Message, 0, len(sizes))
+	for _, size := range sizes {
+		msgs = append(msgs, chat.Message{Role: "user", Content: strings.Repeat("x", size)})
+	}
+	return msgs
+}
+
+func TestReduction_EmptyContext(t *testing.T) {
+	if got := chat.CountTokens(nil, countChars); got != 0 {
+		t.Errorf("CountTokens(nil) = %d, want 0", got)
+	}
+	if summaries, raw := chat.SplitSummaries(nil); len(summaries) != 0 || len(raw) != 0 {
+		t.Errorf("SplitSummaries(nil) = %v, %v", summaries, raw)
+	}
+	if windows := chat.SummaryWindows(nil, 0, 3, 5, countChars); len(windows) != 0 {
+		t.Errorf("SummaryWindows(nil) = %v, want none", windows)
+	}
+	if got := chat.TrimOldest(nil, 0, countChars); len(got) != 0 {
+		t.Errorf("TrimOldest(nil) = %v, want none", got)
+	}
+	if got := chat.FitSummaries(nil, 0, countChars); len(got) != 0 {
+		t.Errorf("FitSummaries(nil) = %v, want none", got)
+	}
+}
+
+func TestTrimOldest(t *testing.T) {
+	tests := []struct {
+		name   string
+		sizes  []int
+		target int
+		want   int // Messages kept
+	}{
+		{"exactly at target keeps everything", []int{10, 10, 10}, 30, 3},
+		{"one token over drops the oldest", []int{10, 10, 10}, 29, 2},
+		{"drops until the rest fit", []int{50, 10, 10}, 25, 2},
+		{"latest message is kept even when too large", []int{10, 100}, 20, 1},
+		{"single message is kept", []int{100}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := sized(tt.sizes...)
+			got := chat.TrimOldest(messages, tt.target, countChars)
+			if len(got) != tt.want {
+				t.Fatalf("kept %d messages, want %d", len(got), tt.want)
+			}
+			if got[len(got)-1].Content != messages[len(messages)-1].Content {
+				t.Error("the latest message was dropped")
+			}
+		})
+	}
+}
+
+func TestSummaryWindows_Boundaries(t *testing.T) {
+	// Exactly at the target nothing is summarized
+	if windows := chat.SummaryWindows(sized(10, 10, 10), 30, 2, 5, countChars); len(windows) != 0 {
+		t.Errorf("at target: got %d windows, want none", len(windows))
+	}
+
+	// A window never reaches into the latest message, even when windowSize would allow it
+	windows := chat.SummaryWindows(sized(10, 10, 10), 0, 3, 1, countChars)
+	if len(windows) != 1 || len(windows[0]) != 2 {
+		t.Errorf("window sizes = %v, want a single window of 2", windowSizes(windows))
+	}
+
+	// The window that brings the context exactly to the target is the last one
+	windows = chat.SummaryWindows(sized(10, 10, 10, 10, 10), 35, 1, 5, countChars)
+	if got := windowSizes(windows); len(got) != 3 {
+		t.Errorf("window sizes = %v, want 3 windows of 1", got)
+	}
+}
+
+func TestSplitSummaries(t *testing.T) {
+	summary := chat.Message{Role: "system", Content: chat.SummaryPrefix + "earlier"}
+	messages := append([]chat.Message{summary, summary}, sized(5, 5)...)
+	// A summary after a raw message is history, not a leading segment summary
+	messages = append(messages, summary)
+
+	summaries, raw := chat.SplitSummaries(messages)
+	if len(summaries) != 2 || len(raw) != 3 {
+		t.Fatalf("split into %d summaries and %d raw messages, want 2 and 3", len(summaries), len(raw))
+	}
+
+	// Adding summaries must not overwrite the raw messages that follow
+	_ = append(summaries, chat.Message{Role: "system", Content: "new"})
+	if raw[0].Content != strings.Repeat("x", 5) {
+		t.Errorf("raw message overwritten: %q", raw[0].Content)
+	}
+
+	if summaries, raw := chat.SplitSummaries(sized(5)); len(summaries) != 0 || len(raw) != 1 {
+		t.Errorf("without summaries: %d summaries and %d raw messages", len(summaries), len(raw))
+	}
+}
+
+func TestFitSummaries(t *testing.T) {
+	summaries := sized(10, 20, 30)
+	if got := chat.FitSummaries(summaries, 60, countChars); len(got) != 3 {
+		t.Errorf("at target kept %d summaries, want 3", len(got))
+	}
+	got := chat.FitSummaries(summaries, 50, countChars)
+	if len(got) != 2 || got[0].Content != summaries[1].Content {
+		t.Errorf("kept %v, want the two newest summaries", got)
+	}
+	if got := chat.FitSummaries(summaries, -1, countChars); len(got) != 0 {
+		t.Errorf("negative target kept %d summaries, want none", len(got))
+	}
+}
+
+func windowSizes(windows [][]chat.Message) []int {
+	sizes := make([]int, len(windows))
+	for i, w := range windows {
+		sizes[i] = len(w)
+	}
+	return sizes
+}
@@ -0,0 +1,115 @@
+package chat_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type stubReplayService struct {
+	turns []*replay.Turn
+}
+
+func (s *stubReplayService) ListTurns(ctx context.Context, conversationID string) ([]*replay.Turn, error) {
+	return s.turns, nil
+}
+
+func (s *stubReplayService) Rerun(ctx context.Context, conv *model.Conversation, turn *replay.Turn) (*replay.Rerun, error) {
+	return &replay.Rerun{Reply: turn.Reply, Requests: len(turn.Exchanges), DivergedAt: -1, Matches: true}, nil
+}
+
+func TestReplayHandler_Turns(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", UserID: "42"}
+	repo.CreateConversation(ctx, conv)
+
+	replays := &stubReplayService{turns: []*replay.Turn{{
+		MessageIndex: 1,
+		Exchanges:    []replay.Exchange{{Request: `{"model":"gpt-4.1"}`, Response: `{}`, Status: 200}},
+		ToolCalls:    []replay.ToolExecution{{Name: "get_weather", Output: "sunny"}},
+		Reply:        "Hi",
+	}}}
+
+	// Mounted like cmd/server
+	tokens := admin.NewTokenIssuer("secret", time.Hour)
+	auth := admin.NewAuthenticator(nil, tokens, "", nil)
+	route := func(srv *chat.Server) *mux.Router {
+		router := mux.NewRouter()
+		router.Handle("/admin/conversations/{id}/replay",
+			auth.Require(admin.RoleOperator)(http.HandlerFunc(chat.NewReplayHandler(srv).TurnsHandler))).Methods(http.MethodPost)
+		return router
+	}
+	token := func(roles ...string) string {
+		user, err := admin.NewUser("alice", "correct horse battery", roles)
+		if err != nil {
+			t.Fatalf("NewUser() error = %v", err)
+		}
+		signed, _, err := tokens.Issue(user)
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		return signed
+	}
+	post := func(router *mux.Router, id, body, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/conversations/"+id+"/replay", strings.NewReader(body))
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	operator := token(admin.RoleOperator)
+	disabled := route(chat.NewServer(repo, &MockAssistant{}, nil))
+	if rec := post(disabled, conv.ID.Hex(), "", operator); rec.Code != http.StatusNotImplemented {
+		t.Errorf("without a replay service: status = %d, want 501: %s", rec.Code, rec.Body)
+	}
+
+	router := route(chat.NewServer(repo, &MockAssistant{}, nil, chat.WithReplay(replays)))
+	for _, tc := range []struct {
+		name, id, body, bearer string
+		want                   int
+	}{
+		{"anonymous", conv.ID.Hex(), "", "", http.StatusUnauthorized},
+		{"viewer", conv.ID.Hex(), "", token(admin.RoleViewer), http.StatusForbidden},
+		{"without the operator role", conv.ID.Hex(), "", token(admin.RoleEditor), http.StatusForbidden},
+		{"invalid body", conv.ID.Hex(), `{`, operator, http.StatusBadRequest},
+		{"unknown conversation", primitive.NewObjectID().Hex(), "", operator, http.StatusNotFound},
+	} {
+		if rec := post(router, tc.id, tc.body, tc.bearer); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d: %s", tc.name, rec.Code, tc.want, rec.Body)
+		}
+	}
+
+	rec := post(router, conv.ID.Hex(), `{"rerun":true}`, operator)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp chat.ReplayResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode replay: %v", err)
+	}
+	if resp.ConversationID != conv.ID.Hex() || len(resp.Turns) != 1 {
+		t.Fatalf("unexpected replay: %+v", resp)
+	}
+	turn := resp.Turns[0]
+	if len(turn.Exchanges) != 1 || len(turn.ToolCalls) != 1 || turn.Reply != "Hi" {
+		t.Errorf("unexpected turn: %+v", turn)
+	}
+	if turn.Rerun == nil || !turn.Rerun.Matches || turn.Rerun.DivergedAt != -1 {
+		t.Errorf("unexpected rerun: %+v", turn.Rerun)
+	}
+}
@@ -0,0 +1,131 @@
+package chat_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func decodeConversation(t *testing.T, doc bson.D) *model.Conversation {
+	t.Helper()
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var c model.Conversation
+	if err := bson.Unmarshal(data, &c); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return &c
+}
+
+func TestConversation_ReadsLegacyDocument(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	// Stored before conversation management, multi-tenancy and schema versioning
+	c := decodeConversation(t, bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "subject", Value: "Trip to Lisbon"},
+		{Key: "created_at", Value: created},
+		{Key: "updated_at", Value: updated},
+		{Key: "messages", Value: bson.A{
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "role", Value: "user"}, {Key: "content", Value: "Hi"}, {Key: "created_at", Value: created}},
+		}},
+	})
+
+	if !c.IsActive || !c.LastActivity.Equal(updated) {
+		t.Errorf("IsActive, LastActivity = %v, %v, want an active conversation last active at %v", c.IsActive, c.LastActivity, updated)
+	}
+	if c.TenantID != tenant.DefaultID {
+		t.Errorf("TenantID = %q, want the default tenant", c.TenantID)
+	}
+	if len(c.Messages) != 1 || !c.Messages[0].UpdatedAt.Equal(created) || c.Messages[0].Content != "Hi" {
+		t.Errorf("Messages = %+v, want the message updated at its creation", c.Messages)
+	}
+	if c.SchemaVersion != model.CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", c.SchemaVersion, model.CurrentSchemaVersion)
+	}
+}
+
+func TestConversation_ReadsUnversionedDocument(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	active := created.Add(2 * time.Hour)
+
+	// Stored with every field but the schema version; its values must survive the upgrade
+	c := decodeConversation(t, bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "subject", Value: "Archived"},
+		{Key: "created_at", Value: created},
+		{Key: "tenant_id", Value: "acme"},
+		{Key: "is_active", Value: false},
+		{Key: "last_activity", Value: active},
+		{Key: "messages", Value: nil},
+		{Key: "tags", Value: bson.A{"vip"}},
+	})
+
+	if c.IsActive || !c.LastActivity.Equal(active) || c.TenantID != "acme" || len(c.Tags) != 1 {
+		t.Errorf("Conversation = %+v, want stored values kept", c)
+	}
+	if !c.UpdatedAt.Equal(created) || c.Messages == nil {
+		t.Errorf("UpdatedAt, Messages = %v, %v, want the creation time and no messages", c.UpdatedAt, c.Messages)
+	}
+}
+
+func TestConversation_ReadsCurrentAndNewerDocuments(t *testing.T) {
+	for _, version := range []int32{model.CurrentSchemaVersion, model.CurrentSchemaVersion + 1} {
+		c := decodeConversation(t, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "subject", Value: "Current"},
+			{Key: "schema_version", Value: version},
+		})
+		// Nothing is filled in for documents that are not older than this code
+		if c.IsActive || c.TenantID != "" || int32(c.SchemaVersion) != version {
+			t.Errorf("version %d: Conversation = %+v, want it read as stored", version, c)
+		}
+	}
+}
+
+func TestConversation_RoundTrip(t *testing.T) {
+	want := &model.Conversation{
+		ID:            primitive.NewObjectID(),
+		Title:         "Round trip",
+		TenantID:      "acme",
+		IsActive:      true,
+		SchemaVersion: model.CurrentSchemaVersion,
+	}
+	data, err := bson.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got model.Conversation
+	if err := bson.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.ID != want.ID || got.Title != want.Title || got.TenantID != want.TenantID || !got.IsActive {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUpgradeDocument(t *testing.T) {
+	doc := model.UpgradeDocument(bson.D{{Key: "_id", Value: primitive.NewObjectID()}})
+
+	var version interface{}
+	for _, e := range doc {
+		if e.Key == "schema_version" {
+			version = e.Value
+		}
+	}
+	if version != int32(model.CurrentSchemaVersion) {
+		t.Errorf("schema_version = %v, want %d", version, model.CurrentSchemaVersion)
+	}
+
+	again := model.UpgradeDocument(doc)
+	if len(again) != len(doc) {
+		t.Errorf("UpgradeDocument() of an upgraded document = %v, want it unchanged", again)
+	}
+}
@@ -2,13 +2,35 @@ package chat_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"github.com/8adimka/Go_AI_Assistant/internal/abuse"
+	"github.com/8adimka/Go_AI_Assistant/internal/attachment"
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/blocklist"
+	"github.com/8adimka/Go_AI_Assistant/internal/budget"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/commands"
+	"github.com/8adimka/Go_AI_Assistant/internal/errorsx"
+	"github.com/8adimka/Go_AI_Assistant/internal/experiment"
+	"github.com/8adimka/Go_AI_Assistant/internal/greeting"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
 	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/takeout"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
 	"github.com/twitchtv/twirp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockAssistant is a mock implementation of the Assistant interface for testing
@@ -33,6 +55,120 @@ func (m *MockAssistant) Reply(ctx context.Context, conv *model.Conversation) (st
 	return m.ReplyResponse, nil
 }
 
+// busyAssistant runs during while it generates a reply, e.g. to change the conversation meanwhile
+type busyAssistant struct {
+	MockAssistant
+	during func(conv *model.Conversation)
+}
+
+func (a *busyAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	a.during(conv)
+	return a.MockAssistant.Reply(ctx, conv)
+}
+
+// memoryRepository is an in-memory ConversationRepository with injectable failures
+type memoryRepository struct {
+	mu            sync.Mutex
+	conversations map[string]*model.Conversation
+	createErr     error
+	updateErr     error
+	updateCalls   int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{conversations: map[string]*model.Conversation{}}
+}
+
+func (r *memoryRepository) CreateConversation(ctx context.Context, c *model.Conversation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	stored := *c
+	stored.Messages = append([]*model.Message(nil), c.Messages...)
+	r.conversations[c.ID.Hex()] = &stored
+	return nil
+}
+
+func (r *memoryRepository) DescribeConversation(ctx context.Context, id string) (*model.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conversations[id]
+	if !ok {
+		return nil, twirp.NotFoundError("conversation not found")
+	}
+	return c, nil
+}
+
+func (r *memoryRepository) ListConversations(ctx context.Context) ([]*model.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*model.Conversation, 0, len(r.conversations))
+	for _, c := range r.conversations {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) UpdateConversation(ctx context.Context, c *model.Conversation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updateCalls++
+	if r.updateErr != nil {
+		return r.updateErr
+	}
+	stored := *c
+	stored.Messages = append([]*model.Message(nil), c.Messages...)
+	r.conversations[c.ID.Hex()] = &stored
+	return nil
+}
+
+// AppendReply stores the whole conversation: DescribeConversation shares it with the server, so its
+// messages already are the stored ones
+func (r *memoryRepository) AppendReply(ctx context.Context, c *model.Conversation, messages []*model.Message) error {
+	return r.UpdateConversation(ctx, c)
+}
+
+func (r *memoryRepository) SetMessageReaction(ctx context.Context, conversationID, messageID primitive.ObjectID, reaction model.Reaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conversations[conversationID.Hex()]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	for i, m := range c.Messages {
+		if m.ID == messageID {
+			updated := *m
+			updated.Reactions = slices.DeleteFunc(slices.Clone(m.Reactions), func(existing model.Reaction) bool {
+				return existing.UserID == reaction.UserID
+			})
+			updated.Reactions = append(updated.Reactions, reaction)
+			c.Messages[i] = &updated
+			return nil
+		}
+	}
+	return twirp.NotFoundError("message not found")
+}
+
+func (r *memoryRepository) AddMessageAttachment(ctx context.Context, conversationID, messageID primitive.ObjectID, attachmentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conversations[conversationID.Hex()]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	for i, m := range c.Messages {
+		if m.ID == messageID {
+			updated := *m
+			updated.AttachmentIDs = append(slices.Clone(m.AttachmentIDs), attachmentID)
+			c.Messages[i] = &updated
+			return nil
+		}
+	}
+	return twirp.NotFoundError("message not found")
+}
+
 func TestServer_InputValidation(t *testing.T) {
 	ctx := context.Background()
 
@@ -85,13 +221,12 @@ func TestServer_InputValidation(t *testing.T) {
 	})
 
 	t.Run("returns error when reply generation fails", func(t *testing.T) {
-		// Use nil repository
 		// Create mock assistant that fails on reply generation
 		mockAssist := &MockAssistant{
 			TitleResponse: "Weather in Barcelona",
 			ReplyError:    twirp.InternalError("reply generation failed"),
 		}
-		srv := chat.NewServer(nil, mockAssist, nil)
+		srv := chat.NewServer(newMemoryRepository(), mockAssist, nil)
 
 		// Start conversation should fail if reply fails
 		_, err := srv.StartConversation(ctx, &pb.StartConversationRequest{
@@ -108,6 +243,72 @@ func TestServer_InputValidation(t *testing.T) {
 			t.Errorf("expected error to contain 'reply generation failed', got %v", err)
 		}
 	})
+
+	t.Run("surfaces model provider failures with an error code", func(t *testing.T) {
+		mockAssist := &MockAssistant{
+			TitleResponse: "Weather in Barcelona",
+			ReplyError:    fmt.Errorf("context still too long after reduction: %w", errorsx.ErrContextTooLong),
+		}
+		srv := chat.NewServer(newMemoryRepository(), mockAssist, nil)
+
+		_, err := srv.StartConversation(ctx, &pb.StartConversationRequest{
+			Message: "What is the weather like in Barcelona?",
+		})
+
+		te, ok := err.(twirp.Error)
+		if !ok || te.Code() != twirp.FailedPrecondition || te.Meta("error_code") != "context_too_long" {
+			t.Errorf("expected a context_too_long error, got %v", err)
+		}
+	})
+
+	t.Run("returns the partial answer when the request budget runs out", func(t *testing.T) {
+		mockAssist := &MockAssistant{
+			TitleResponse: "Weather in Barcelona",
+			ReplyError:    &budget.ExceededError{Partial: "It is sunny in Barcelona"},
+		}
+		srv := chat.NewServer(newMemoryRepository(), mockAssist, nil)
+
+		resp, err := srv.StartConversation(ctx, &pb.StartConversationRequest{
+			Message: "What is the weather like in Barcelona?",
+		})
+		if err != nil {
+			t.Fatalf("StartConversation() error = %v", err)
+		}
+		if !resp.GetBudgetExceeded() || resp.GetReply() != "It is sunny in Barcelona" {
+			t.Errorf("expected the partial answer flagged as over budget, got %+v", resp)
+		}
+	})
+
+	t.Run("stops the reply at the request budget", func(t *testing.T) {
+		srv := chat.NewServer(newMemoryRepository(), blockingAssistant{}, nil)
+		budgetCtx := budget.WithBudget(ctx, 50*time.Millisecond, 10*time.Millisecond)
+
+		start := time.Now()
+		resp, err := srv.StartConversation(budgetCtx, &pb.StartConversationRequest{
+			Message: "What is the weather like in Barcelona?",
+		})
+		if err != nil {
+			t.Fatalf("StartConversation() error = %v", err)
+		}
+		if !resp.GetBudgetExceeded() || resp.GetReply() != budget.Notice {
+			t.Errorf("expected the budget notice, got %+v", resp)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("reply took %s despite a 50ms budget", elapsed)
+		}
+	})
+}
+
+// blockingAssistant replies only once its context is done, like a model call that outlasts the deadline
+type blockingAssistant struct{}
+
+func (blockingAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	return "Title", nil
+}
+
+func (blockingAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
 }
 
 func TestServer_ContinueConversation_InputValidation(t *testing.T) {
@@ -183,3 +384,1102 @@ func TestServer_DescribeConversation_InputValidation(t *testing.T) {
 		}
 	})
 }
+
+func TestServer_ResetSession_InputValidation(t *testing.T) {
+	srv := chat.NewServer(nil, nil, nil)
+
+	for _, metadata := range []*pb.SessionMetadata{nil, {Platform: "telegram"}, {ChatId: "c1"}} {
+		_, err := srv.ResetSession(context.Background(), &pb.ResetSessionRequest{SessionMetadata: metadata})
+		if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.InvalidArgument {
+			t.Errorf("ResetSession(%v) error = %v, want twirp.InvalidArgument", metadata, err)
+		}
+	}
+}
+
+// recordingAssistant counts calls to Title and always fails to reply
+type recordingAssistant struct {
+	titleCalls int
+	replyCalls int
+}
+
+func (r *recordingAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	r.titleCalls++
+	return "Generated Title", nil
+}
+
+func (r *recordingAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	r.replyCalls++
+	return "", twirp.InternalError("reply generation failed")
+}
+
+// stubTitleScheduler records scheduled conversations
+type stubTitleScheduler struct {
+	accept    bool
+	scheduled []*model.Conversation
+}
+
+func (s *stubTitleScheduler) ScheduleTitle(conv *model.Conversation) bool {
+	s.scheduled = append(s.scheduled, conv)
+	return s.accept
+}
+
+func TestServer_StartConversation_TitleScheduling(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("defers title generation to scheduler", func(t *testing.T) {
+		assist := &recordingAssistant{}
+		scheduler := &stubTitleScheduler{accept: true}
+		srv := chat.NewServer(newMemoryRepository(), assist, nil, chat.WithTitleScheduler(scheduler))
+
+		_, _ = srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello there"})
+
+		if len(scheduler.scheduled) != 1 {
+			t.Fatalf("expected 1 scheduled conversation, got %d", len(scheduler.scheduled))
+		}
+		if assist.titleCalls != 0 {
+			t.Errorf("expected no synchronous title generation, got %d calls", assist.titleCalls)
+		}
+	})
+
+	t.Run("falls back to synchronous generation when scheduler is full", func(t *testing.T) {
+		assist := &recordingAssistant{}
+		scheduler := &stubTitleScheduler{accept: false}
+		srv := chat.NewServer(newMemoryRepository(), assist, nil, chat.WithTitleScheduler(scheduler))
+
+		_, _ = srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello there"})
+
+		if assist.titleCalls != 1 {
+			t.Errorf("expected 1 synchronous title generation, got %d calls", assist.titleCalls)
+		}
+	})
+}
+
+// slowTitleAssistant replies at once but generates titles only when release is closed
+type slowTitleAssistant struct {
+	release chan struct{}
+}
+
+func (a *slowTitleAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	<-a.release
+	return "Weather in Barcelona", nil
+}
+
+func (a *slowTitleAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	return "It is sunny", nil
+}
+
+// recordingTitles receives titles stored after StartConversation returned
+type recordingTitles chan string
+
+func (r recordingTitles) UpdateConversationTitle(ctx context.Context, id string, title string) error {
+	r <- title
+	return nil
+}
+
+func TestServer_StartConversation_ConcurrentTitle(t *testing.T) {
+	assist := &slowTitleAssistant{release: make(chan struct{})}
+	titles := make(recordingTitles, 1)
+	srv := chat.NewServer(newMemoryRepository(), assist, nil, chat.WithConcurrentTitles(titles))
+
+	resp, err := srv.StartConversation(context.Background(), &pb.StartConversationRequest{
+		Message: "What is the weather like in Barcelona this weekend?",
+	})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	// The reply does not wait for the title
+	if resp.GetTitle() != "What is the weather like in…" {
+		t.Errorf("title = %q, want the provisional title", resp.GetTitle())
+	}
+
+	close(assist.release)
+	select {
+	case title := <-titles:
+		if title != "Weather in Barcelona" {
+			t.Errorf("stored title = %q, want the generated title", title)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("generated title was never stored")
+	}
+}
+
+// variantAssistant reports the variant it replies with
+type variantAssistant struct{}
+
+func (variantAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	return "Title", nil
+}
+
+func (variantAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	generation := experiment.FromContext(ctx)
+	generation.Variant = model.Variant{Persona: "friendly", PromptVersion: "v2", Model: "gpt-4.1"}
+	generation.AddUsage(100, 20)
+	return "Hi!", nil
+}
+
+type recordingExperiments struct {
+	replies   []model.Variant
+	reactions []string
+}
+
+func (r *recordingExperiments) RecordVariantReply(ctx context.Context, variant model.Variant, latency time.Duration, tokens int64) {
+	r.replies = append(r.replies, variant)
+}
+
+func (r *recordingExperiments) RecordVariantReaction(ctx context.Context, variant model.Variant, sentiment string) {
+	r.reactions = append(r.reactions, variant.PromptVersion+":"+sentiment)
+}
+
+func TestServer_ExperimentVariants(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	recorder := &recordingExperiments{}
+	srv := chat.NewServer(repo, variantAssistant{}, nil, chat.WithExperimentRecorder(recorder))
+
+	resp, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello"})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	stored, _ := repo.DescribeConversation(ctx, resp.GetConversationId())
+	reply := stored.Messages[len(stored.Messages)-1]
+	if g := reply.Generation; g == nil || g.PromptVersion != "v2" || g.PromptTokens != 100 || g.CompletionTokens != 20 {
+		t.Fatalf("stored generation = %+v, want the reported variant and usage", g)
+	}
+	if len(recorder.replies) != 1 || recorder.replies[0].Persona != "friendly" {
+		t.Errorf("recorded replies = %v, want the friendly variant", recorder.replies)
+	}
+
+	if _, err := srv.AddReaction(ctx, &pb.AddReactionRequest{
+		ConversationId: resp.GetConversationId(),
+		MessageId:      reply.ID.Hex(),
+		Emoji:          "👍",
+	}); err != nil {
+		t.Fatalf("AddReaction() error = %v", err)
+	}
+	if !slices.Equal(recorder.reactions, []string{"v2:positive"}) {
+		t.Errorf("recorded reactions = %v, want a positive reaction to v2", recorder.reactions)
+	}
+}
+
+func TestServer_StartConversation_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps user message when reply generation fails", func(t *testing.T) {
+		repo := newMemoryRepository()
+		assist := &MockAssistant{TitleResponse: "Title", ReplyError: twirp.InternalError("reply generation failed")}
+		srv := chat.NewServer(repo, assist, nil)
+
+		if _, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello"}); err == nil {
+			t.Fatal("expected error for reply generation failure, got nil")
+		}
+
+		convs, _ := repo.ListConversations(ctx)
+		if len(convs) != 1 {
+			t.Fatalf("expected 1 persisted conversation, got %d", len(convs))
+		}
+		if len(convs[0].Messages) != 1 || convs[0].Messages[0].Role != model.RoleUser {
+			t.Errorf("expected only the user message to be persisted, got %+v", convs[0].Messages)
+		}
+	})
+
+	t.Run("does not generate reply when conversation cannot be created", func(t *testing.T) {
+		repo := newMemoryRepository()
+		repo.createErr = errors.New("mongo unavailable")
+		assist := &recordingAssistant{}
+		srv := chat.NewServer(repo, assist, nil)
+
+		_, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello"})
+		if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.Internal {
+			t.Fatalf("expected twirp.Internal error, got %v", err)
+		}
+		if assist.replyCalls != 0 {
+			t.Errorf("expected no reply generation, got %d calls", assist.replyCalls)
+		}
+	})
+
+	t.Run("returns reply even when saving it keeps failing", func(t *testing.T) {
+		repo := newMemoryRepository()
+		repo.updateErr = errors.New("write conflict")
+		assist := &MockAssistant{TitleResponse: "Title", ReplyResponse: "Hi!"}
+		srv := chat.NewServer(repo, assist, nil)
+
+		resp, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello"})
+		if err != nil {
+			t.Fatalf("expected reply despite persistence failure, got %v", err)
+		}
+		if resp.GetReply() != "Hi!" {
+			t.Errorf("expected reply 'Hi!', got %q", resp.GetReply())
+		}
+		if repo.updateCalls != 3 {
+			t.Errorf("expected 3 persistence attempts, got %d", repo.updateCalls)
+		}
+	})
+}
+
+// blockingGuard rejects every user it is asked about
+type blockingGuard struct{}
+
+func (blockingGuard) Check(ctx context.Context, platform, userID string) error {
+	return &abuse.BlockedError{Block: &abuse.Block{Platform: platform, UserID: userID, ExpiresAt: time.Now().Add(time.Minute)}}
+}
+
+func (blockingGuard) RecordStrike(ctx context.Context, platform, userID, reason string) (*abuse.Block, error) {
+	return nil, nil
+}
+
+// strikeGuard lets every user through and records the strikes counted against them
+type strikeGuard struct {
+	strikes []string
+}
+
+func (g *strikeGuard) Check(ctx context.Context, platform, userID string) error {
+	return nil
+}
+
+func (g *strikeGuard) RecordStrike(ctx context.Context, platform, userID, reason string) (*abuse.Block, error) {
+	g.strikes = append(g.strikes, platform+":"+userID+":"+reason)
+	return nil, nil
+}
+
+// keywordPolicy blocks messages mentioning "casino" as gambling
+type keywordPolicy struct{}
+
+func (keywordPolicy) CheckMessage(ctx context.Context, message string) error {
+	if strings.Contains(strings.ToLower(message), "casino") {
+		return &blocklist.BlockedError{Match: blocklist.Match{Term: "casino", Category: "gambling"}}
+	}
+	return nil
+}
+
+func (r *memoryRepository) SetConversationInstructions(ctx context.Context, id primitive.ObjectID, instructions string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conversations[id.Hex()]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	updated := *c
+	updated.Instructions = instructions
+	r.conversations[id.Hex()] = &updated
+	return nil
+}
+
+func (r *memoryRepository) AddConversationMessage(ctx context.Context, id primitive.ObjectID, msg *model.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conversations[id.Hex()]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	updated := *c
+	updated.Messages = append(slices.Clone(c.Messages), msg)
+	r.conversations[id.Hex()] = &updated
+	return nil
+}
+
+func TestServer_BlockedUser(t *testing.T) {
+	ctx := context.Background()
+	assist := &recordingAssistant{}
+	srv := chat.NewServer(newMemoryRepository(), assist, nil, chat.WithAbuseGuard(blockingGuard{}))
+
+	_, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		Message:         "Hello",
+		SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "42", ChatId: "42"},
+	})
+
+	te, ok := err.(twirp.Error)
+	if !ok || te.Code() != twirp.PermissionDenied {
+		t.Fatalf("expected twirp.PermissionDenied error, got %v", err)
+	}
+	if te.Meta("error_code") != "user_blocked" {
+		t.Errorf("expected error_code meta 'user_blocked', got %q", te.Meta("error_code"))
+	}
+	if te.Meta("blocked_until") == "" {
+		t.Error("expected blocked_until meta to be set")
+	}
+	if assist.replyCalls != 0 {
+		t.Errorf("expected no reply generation for blocked user, got %d calls", assist.replyCalls)
+	}
+}
+
+func TestServer_BlockedContent(t *testing.T) {
+	ctx := context.Background()
+	assist := &recordingAssistant{}
+	guard := &strikeGuard{}
+	srv := chat.NewServer(newMemoryRepository(), assist, nil,
+		chat.WithAbuseGuard(guard), chat.WithContentPolicy(keywordPolicy{}))
+
+	_, err := srv.StartConversation(ctx, &pb.StartConversationRequest{
+		Message:         "Which casino is open tonight?",
+		SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "42", ChatId: "42"},
+	})
+
+	te, ok := err.(twirp.Error)
+	if !ok || te.Code() != twirp.InvalidArgument {
+		t.Fatalf("expected twirp.InvalidArgument error, got %v", err)
+	}
+	if te.Meta("error_code") != "message_blocked" || te.Meta("category") != "gambling" {
+		t.Errorf("expected message_blocked in gambling, got %q in %q", te.Meta("error_code"), te.Meta("category"))
+	}
+	if len(guard.strikes) != 1 || guard.strikes[0] != "telegram:42:"+abuse.ReasonModeration {
+		t.Errorf("expected one moderation strike, got %v", guard.strikes)
+	}
+	if assist.replyCalls != 0 {
+		t.Errorf("expected no reply generation for blocked message, got %d calls", assist.replyCalls)
+	}
+
+	srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "What's the weather?"})
+	if assist.replyCalls != 1 {
+		t.Errorf("expected allowed message to reach the assistant, got %d reply calls", assist.replyCalls)
+	}
+}
+
+// floodedLimiter rejects messages of one scope as if it were over its rate
+type floodedLimiter struct {
+	scope string
+}
+
+func (l floodedLimiter) CheckSession(ctx context.Context, platform, userID, chatID string) error {
+	if l.scope == abuse.ScopeSession {
+		return &abuse.MessageRateError{Scope: abuse.ScopeSession, Limit: 30}
+	}
+	return nil
+}
+
+func (l floodedLimiter) CheckConversation(ctx context.Context, platform, conversationID string) error {
+	if l.scope == abuse.ScopeConversation {
+		return &abuse.MessageRateError{Scope: abuse.ScopeConversation, Limit: 20}
+	}
+	return nil
+}
+
+func TestServer_MessageRateLimited(t *testing.T) {
+	for _, scope := range []string{abuse.ScopeSession, abuse.ScopeConversation} {
+		assist := &recordingAssistant{}
+		sessions := mocks.NewSessionStore(nil)
+		sessions.SetSession("telegram", "42", primitive.NewObjectID().Hex())
+		srv := chat.NewServer(newMemoryRepository(), assist, sessions,
+			chat.WithMessageLimiter(floodedLimiter{scope: scope}))
+
+		_, err := srv.ContinueConversation(context.Background(), &pb.ContinueConversationRequest{
+			Message:         "Hello",
+			SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "42", ChatId: "42"},
+		})
+
+		te, ok := err.(twirp.Error)
+		if !ok || te.Code() != twirp.ResourceExhausted {
+			t.Fatalf("%s: expected twirp.ResourceExhausted error, got %v", scope, err)
+		}
+		if te.Meta("error_code") != scope+"_rate_limited" {
+			t.Errorf("%s: expected error_code meta %q, got %q", scope, scope+"_rate_limited", te.Meta("error_code"))
+		}
+		if assist.replyCalls != 0 {
+			t.Errorf("%s: expected no reply generation for a flooded %s, got %d calls", scope, scope, assist.replyCalls)
+		}
+	}
+}
+
+func TestServer_MessageTooLong(t *testing.T) {
+	ctx := context.Background()
+	assist := &recordingAssistant{}
+	srv := chat.NewServer(newMemoryRepository(), assist, nil, chat.WithMaxMessageTokens(100))
+	huge := strings.Repeat("paste ", 1000)
+
+	_, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: huge})
+	te, ok := err.(twirp.Error)
+	if !ok || te.Code() != twirp.InvalidArgument {
+		t.Fatalf("expected twirp.InvalidArgument error, got %v", err)
+	}
+	if te.Meta("error_code") != "message_too_long" || te.Meta("max_tokens") != "100" {
+		t.Errorf("unexpected error meta: %v", te.MetaMap())
+	}
+	if count, err := strconv.Atoi(te.Meta("token_count")); err != nil || count <= 100 {
+		t.Errorf("expected the measured token count above the limit, got %q", te.Meta("token_count"))
+	}
+	if !strings.Contains(te.Msg(), te.Meta("token_count")) {
+		t.Errorf("expected the message to report the token count, got %q", te.Msg())
+	}
+
+	_, err = srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		Message:         huge,
+		SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "42", ChatId: "42"},
+	})
+	if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.InvalidArgument {
+		t.Fatalf("expected twirp.InvalidArgument error, got %v", err)
+	}
+	if assist.replyCalls != 0 {
+		t.Errorf("expected no reply generation for oversized messages, got %d calls", assist.replyCalls)
+	}
+}
+
+func TestServer_ChunkedInput(t *testing.T) {
+	ctx := context.Background()
+	assist := &MockAssistant{TitleResponse: "Long paste", ReplyResponse: "Summary of your paste"}
+	srv := chat.NewServer(newMemoryRepository(), assist, nil,
+		chat.WithMaxMessageTokens(100), chat.WithChunkedInput(1000))
+
+	if _, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: strings.Repeat("paste ", 300)}); err != nil {
+		t.Fatalf("expected a message within the chunked limit to be accepted, got %v", err)
+	}
+
+	_, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: strings.Repeat("paste ", 3000)})
+	te, ok := err.(twirp.Error)
+	if !ok || te.Code() != twirp.InvalidArgument || te.Meta("max_tokens") != "1000" {
+		t.Fatalf("expected twirp.InvalidArgument error with the chunked limit, got %v", err)
+	}
+}
+
+type memoryAttachmentRepository struct {
+	attachments map[string]*attachment.Attachment
+}
+
+func (r *memoryAttachmentRepository) CreateAttachment(ctx context.Context, a *attachment.Attachment) error {
+	r.attachments[a.ID.Hex()] = a
+	return nil
+}
+
+func (r *memoryAttachmentRepository) GetAttachment(ctx context.Context, conversationID, id string) (*attachment.Attachment, error) {
+	a, ok := r.attachments[id]
+	if !ok || a.ConversationID != conversationID || a.TenantID != tenant.FromContext(ctx) {
+		return nil, attachment.ErrNotFound
+	}
+	return a, nil
+}
+
+func TestServer_Attachments(t *testing.T) {
+	ctx := context.Background()
+	store, err := objectstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	attachments := attachment.NewService(store,
+		&memoryAttachmentRepository{attachments: map[string]*attachment.Attachment{}},
+		attachment.Policy{MaxBytes: 1024})
+
+	repo := newMemoryRepository()
+	srv := chat.NewServer(repo, &MockAssistant{TitleResponse: "Notes", ReplyResponse: "Hi"}, nil, chat.WithAttachments(attachments))
+
+	started, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello"})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	conv, _ := repo.DescribeConversation(ctx, started.ConversationId)
+	messageID := conv.Messages[0].ID.Hex()
+
+	uploaded, err := srv.UploadAttachment(ctx, &pb.UploadAttachmentRequest{
+		ConversationId: started.ConversationId,
+		MessageId:      messageID,
+		Filename:       "notes.txt",
+		ContentType:    "text/plain",
+		Content:        []byte("remember the milk"),
+	})
+	if err != nil {
+		t.Fatalf("UploadAttachment() error = %v", err)
+	}
+
+	described, err := srv.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: started.ConversationId})
+	if err != nil {
+		t.Fatalf("DescribeConversation() error = %v", err)
+	}
+	if ids := described.Conversation.Messages[0].AttachmentIds; len(ids) != 1 || ids[0] != uploaded.Attachment.Id {
+		t.Errorf("expected attachment linked to message, got %v", ids)
+	}
+
+	got, err := srv.GetAttachment(ctx, &pb.GetAttachmentRequest{
+		ConversationId: started.ConversationId,
+		AttachmentId:   uploaded.Attachment.Id,
+	})
+	if err != nil {
+		t.Fatalf("GetAttachment() error = %v", err)
+	}
+	if string(got.Content) != "remember the milk" || got.Attachment.Filename != "notes.txt" {
+		t.Errorf("unexpected attachment %+v with content %q", got.Attachment, got.Content)
+	}
+
+	_, err = srv.UploadAttachment(ctx, &pb.UploadAttachmentRequest{
+		ConversationId: started.ConversationId,
+		Filename:       "setup.exe",
+		ContentType:    "text/plain",
+		Content:        []byte("MZ"),
+	})
+	if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.InvalidArgument || te.Meta("error_code") != "attachment_rejected" {
+		t.Errorf("expected attachment_rejected error, got %v", err)
+	}
+
+	_, err = srv.GetAttachment(tenant.WithTenant(ctx, "other"), &pb.GetAttachmentRequest{
+		ConversationId: started.ConversationId,
+		AttachmentId:   uploaded.Attachment.Id,
+	})
+	if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.NotFound {
+		t.Errorf("expected attachment of another tenant to be hidden, got %v", err)
+	}
+}
+
+// slowAttachmentRepository runs during while an upload is stored
+type slowAttachmentRepository struct {
+	memoryAttachmentRepository
+	during func()
+}
+
+func (r *slowAttachmentRepository) CreateAttachment(ctx context.Context, a *attachment.Attachment) error {
+	r.during()
+	return r.memoryAttachmentRepository.CreateAttachment(ctx, a)
+}
+
+func TestServer_ReplyStoredDuringUploadIsKept(t *testing.T) {
+	ctx := context.Background()
+	store, err := objectstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	repo := mocks.NewConversationRepository()
+	user := &model.Message{ID: primitive.NewObjectID(), Role: model.RoleUser, Content: "Here is my invoice", CreatedAt: time.Now()}
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", UserID: "42", Messages: []*model.Message{user}}
+	repo.CreateConversation(ctx, conv)
+
+	reply := &model.Message{ID: primitive.NewObjectID(), Role: model.RoleAssistant, Content: "Thanks, I got it", CreatedAt: time.Now()}
+	attachments := attachment.NewService(store, &slowAttachmentRepository{
+		memoryAttachmentRepository: memoryAttachmentRepository{attachments: map[string]*attachment.Attachment{}},
+		during: func() {
+			if err := repo.AddConversationMessage(ctx, conv.ID, reply); err != nil {
+				t.Fatalf("AddConversationMessage() error = %v", err)
+			}
+		},
+	}, attachment.Policy{MaxBytes: 1024})
+	srv := chat.NewServer(repo, &MockAssistant{}, nil, chat.WithAttachments(attachments))
+
+	uploaded, err := srv.UploadAttachment(ctx, &pb.UploadAttachmentRequest{
+		ConversationId: conv.ID.Hex(),
+		MessageId:      user.ID.Hex(),
+		Filename:       "invoice.txt",
+		ContentType:    "text/plain",
+		Content:        []byte("total: 42"),
+	})
+	if err != nil {
+		t.Fatalf("UploadAttachment() error = %v", err)
+	}
+
+	stored, _ := repo.DescribeConversation(ctx, conv.ID.Hex())
+	if len(stored.Messages) != 2 || stored.Messages[1].ID != reply.ID {
+		t.Fatalf("stored messages = %d, want the reply stored during the upload kept", len(stored.Messages))
+	}
+	if ids := stored.Messages[0].AttachmentIDs; len(ids) != 1 || ids[0] != uploaded.Attachment.Id {
+		t.Errorf("attachment IDs = %v, want the upload linked to the message", ids)
+	}
+}
+
+type stubTakeoutService struct {
+	export *takeout.Export
+}
+
+func (s *stubTakeoutService) Request(ctx context.Context, platform, userID, chatID string) (*takeout.Export, error) {
+	s.export = &takeout.Export{ID: primitive.NewObjectID(), Platform: platform, UserID: userID, Status: takeout.StatusPending, CreatedAt: time.Now()}
+	return s.export, nil
+}
+
+func (s *stubTakeoutService) Get(ctx context.Context, id, platform, userID string) (*takeout.Export, string, error) {
+	if s.export == nil || s.export.ID.Hex() != id || s.export.UserID != userID {
+		return nil, "", takeout.ErrNotFound
+	}
+	return s.export, "https://example.com/takeout/" + id, nil
+}
+
+func TestServer_DataExport(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	metadata := &pb.SessionMetadata{Platform: "telegram", UserId: "42", ChatId: "100"}
+
+	disabled := chat.NewServer(repo, &MockAssistant{}, nil)
+	_, err := disabled.RequestDataExport(ctx, &pb.RequestDataExportRequest{SessionMetadata: metadata})
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.Unimplemented {
+		t.Fatalf("expected Unimplemented without a takeout service, got %v", err)
+	}
+
+	srv := chat.NewServer(repo, &MockAssistant{}, nil, chat.WithTakeout(&stubTakeoutService{}))
+
+	_, err = srv.RequestDataExport(ctx, &pb.RequestDataExportRequest{})
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without session metadata, got %v", err)
+	}
+
+	requested, err := srv.RequestDataExport(ctx, &pb.RequestDataExportRequest{SessionMetadata: metadata})
+	if err != nil {
+		t.Fatalf("RequestDataExport() error = %v", err)
+	}
+	if requested.Export.Status != takeout.StatusPending || requested.Export.DownloadUrl != "" {
+		t.Errorf("unexpected export %v", requested.Export)
+	}
+
+	got, err := srv.GetDataExport(ctx, &pb.GetDataExportRequest{ExportId: requested.Export.Id, SessionMetadata: metadata})
+	if err != nil {
+		t.Fatalf("GetDataExport() error = %v", err)
+	}
+	if got.Export.DownloadUrl == "" {
+		t.Error("expected a download link")
+	}
+
+	_, err = srv.GetDataExport(ctx, &pb.GetDataExportRequest{
+		ExportId:        requested.Export.Id,
+		SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "43"},
+	})
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.NotFound {
+		t.Errorf("expected NotFound for another user, got %v", err)
+	}
+}
+
+type prefixProcessor struct{}
+
+func (prefixProcessor) Process(ctx context.Context, platform, reply string) string {
+	return platform + ": " + reply
+}
+
+func TestServer_ReplyProcessor(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	srv := chat.NewServer(repo, &MockAssistant{TitleResponse: "Hi", ReplyResponse: "Hello"}, nil,
+		chat.WithReplyProcessor(prefixProcessor{}))
+
+	resp, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hi"})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	if resp.Reply != "api: Hello" {
+		t.Errorf("reply = %q, want the processed reply", resp.Reply)
+	}
+
+	conv, err := repo.DescribeConversation(ctx, resp.ConversationId)
+	if err != nil {
+		t.Fatalf("DescribeConversation() error = %v", err)
+	}
+	if stored := conv.Messages[len(conv.Messages)-1].Content; stored != "api: Hello" {
+		t.Errorf("stored reply = %q, want the processed reply", stored)
+	}
+}
+
+func TestServer_Greeting(t *testing.T) {
+	ctx := context.Background()
+	greeter, err := greeting.NewGreeter(map[string]string{
+		"default":          "Welcome!",
+		"telegram/finance": "Welcome to the {{.Persona}} desk on {{.Platform}}!",
+	})
+	if err != nil {
+		t.Fatalf("NewGreeter() error = %v", err)
+	}
+	repo := newMemoryRepository()
+	srv := chat.NewServer(repo, &MockAssistant{TitleResponse: "Hi", ReplyResponse: "Hello"}, nil, chat.WithGreeter(greeter))
+
+	started, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hi"})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	if started.Greeting != "Welcome!" || started.Reply != "Hello" {
+		t.Errorf("response = %+v, want the greeting and the reply", started)
+	}
+	conv, _ := repo.DescribeConversation(ctx, started.ConversationId)
+	var stored []string
+	for _, m := range conv.Messages {
+		stored = append(stored, m.Content)
+	}
+	if !slices.Equal(stored, []string{"Hi", "Welcome!", "Hello"}) {
+		t.Errorf("stored messages = %q, want the greeting before the reply", stored)
+	}
+
+	continued, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{ConversationId: started.ConversationId, Message: "Thanks"})
+	if err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+	if continued.Greeting != "" {
+		t.Errorf("greeting = %q, want none after the first reply", continued.Greeting)
+	}
+
+	// A session whose persona was chosen before the first message is greeted on that message
+	session := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", Persona: "finance"}
+	repo.CreateConversation(ctx, session)
+	first, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{ConversationId: session.ID.Hex(), Message: "Hi"})
+	if err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+	if first.Greeting != "Welcome to the finance desk on telegram!" {
+		t.Errorf("greeting = %q, want the persona's greeting", first.Greeting)
+	}
+}
+
+func TestServer_ReplyStyle(t *testing.T) {
+	ctx := context.Background()
+	assist := &recordingAssistant{}
+	srv := chat.NewServer(newMemoryRepository(), assist, nil)
+
+	_, err := srv.StartConversation(ctx, &pb.StartConversationRequest{
+		Message: "Hi",
+		Style:   &pb.ReplyStyle{Format: "haiku"},
+	})
+	if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.InvalidArgument || te.Meta("argument") != "style" {
+		t.Fatalf("expected twirp.InvalidArgument error for the style, got %v", err)
+	}
+	if assist.replyCalls != 0 {
+		t.Errorf("expected no reply generation for an invalid style, got %d calls", assist.replyCalls)
+	}
+
+	long := strings.Repeat("A sentence that goes on. ", 20)
+	srv = chat.NewServer(newMemoryRepository(), &MockAssistant{TitleResponse: "Hi", ReplyResponse: long}, nil)
+	resp, err := srv.StartConversation(ctx, &pb.StartConversationRequest{
+		Message: "Hi",
+		Style:   &pb.ReplyStyle{MaxLength: 160, Format: "prose"},
+	})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	if n := utf8.RuneCountInString(resp.Reply); n > 160 {
+		t.Errorf("reply has %d characters, want at most 160", n)
+	}
+}
+
+type recordingReactions struct {
+	sentiments []string
+}
+
+func (r *recordingReactions) RecordReaction(ctx context.Context, platform, sentiment string) {
+	r.sentiments = append(r.sentiments, sentiment)
+}
+
+func TestServer_AddReaction(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	recorder := &recordingReactions{}
+	srv := chat.NewServer(repo, &MockAssistant{TitleResponse: "Hi", ReplyResponse: "Hello"}, nil,
+		chat.WithReactionRecorder(recorder))
+
+	started, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hi"})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	conv, _ := repo.DescribeConversation(ctx, started.ConversationId)
+	userMessage, reply := conv.Messages[0].ID.Hex(), conv.Messages[1].ID.Hex()
+	metadata := &pb.SessionMetadata{Platform: "telegram", UserId: "42"}
+
+	var twerr twirp.Error
+	_, err = srv.AddReaction(ctx, &pb.AddReactionRequest{ConversationId: started.ConversationId, MessageId: reply, Emoji: "nice"})
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a text reaction, got %v", err)
+	}
+	_, err = srv.AddReaction(ctx, &pb.AddReactionRequest{ConversationId: started.ConversationId, MessageId: userMessage, Emoji: "👍"})
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a user message, got %v", err)
+	}
+
+	for _, emoji := range []string{"👍", "👎"} {
+		resp, err := srv.AddReaction(ctx, &pb.AddReactionRequest{
+			ConversationId:  started.ConversationId,
+			MessageId:       reply,
+			Emoji:           emoji,
+			SessionMetadata: metadata,
+		})
+		if err != nil {
+			t.Fatalf("AddReaction(%s) error = %v", emoji, err)
+		}
+		if len(resp.Message.Reactions) != 1 || resp.Message.Reactions[0].Emoji != emoji {
+			t.Errorf("reactions after %s = %v, want only the latest", emoji, resp.Message.Reactions)
+		}
+	}
+
+	described, err := srv.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: started.ConversationId})
+	if err != nil {
+		t.Fatalf("DescribeConversation() error = %v", err)
+	}
+	if got := described.Conversation.Messages[1].Reactions; len(got) != 1 || got[0].Emoji != "👎" || got[0].UserId != "42" {
+		t.Errorf("DescribeConversation reactions = %v", got)
+	}
+
+	if !slices.Equal(recorder.sentiments, []string{model.SentimentPositive, model.SentimentNegative}) {
+		t.Errorf("recorded sentiments = %v", recorder.sentiments)
+	}
+}
+
+type stubCommands struct{}
+
+func (stubCommands) Handle(ctx context.Context, req commands.Request) (string, bool, error) {
+	if req.Message == "/ping" {
+		return "pong", true, nil
+	}
+	return "", false, nil
+}
+
+func TestServer_Commands(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	assist := &recordingAssistant{}
+	srv := chat.NewServer(repo, assist, nil, chat.WithCommands(stubCommands{}))
+
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram"}
+	if err := repo.CreateConversation(ctx, conv); err != nil {
+		t.Fatalf("CreateConversation() error = %v", err)
+	}
+
+	resp, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		ConversationId:  conv.ID.Hex(),
+		Message:         "/ping",
+		SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "u1", ChatId: "c1"},
+	})
+	if err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+	if resp.Reply != "pong" {
+		t.Errorf("reply = %q, want the command reply", resp.Reply)
+	}
+
+	stored, _ := repo.DescribeConversation(ctx, conv.ID.Hex())
+	if len(stored.Messages) != 0 || assist.replyCalls != 0 {
+		t.Errorf("command reached the conversation: %d messages, %d model calls", len(stored.Messages), assist.replyCalls)
+	}
+}
+
+type memorySettings struct {
+	stored map[string]*settings.Settings
+}
+
+func (m *memorySettings) Get(ctx context.Context, platform, userID string) (*settings.Settings, error) {
+	if s, ok := m.stored[platform+":"+userID]; ok {
+		return s, nil
+	}
+	return &settings.Settings{Platform: platform, UserID: userID}, nil
+}
+
+func (m *memorySettings) Set(ctx context.Context, s *settings.Settings) (*settings.Settings, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	m.stored[s.Platform+":"+s.UserID] = s
+	return s, nil
+}
+
+// settingsAssistant replies with the units preferred by the user it answers
+type settingsAssistant struct{}
+
+func (settingsAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	return "Title", nil
+}
+
+func (settingsAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	return settings.FromContext(ctx).UnitSystem(), nil
+}
+
+func TestServer_UserSettings(t *testing.T) {
+	ctx := context.Background()
+	metadata := &pb.SessionMetadata{Platform: "telegram", UserId: "u1", ChatId: "c1"}
+
+	_, err := chat.NewServer(nil, nil, nil).GetUserSettings(ctx, &pb.GetUserSettingsRequest{SessionMetadata: metadata})
+	var twerr twirp.Error
+	if !errors.As(err, &twerr) || twerr.Code() != twirp.Unimplemented {
+		t.Errorf("GetUserSettings() without a settings service error = %v, want unimplemented", err)
+	}
+
+	repo := newMemoryRepository()
+	srv := chat.NewServer(repo, settingsAssistant{}, nil,
+		chat.WithUserSettings(&memorySettings{stored: map[string]*settings.Settings{}}))
+
+	_, err = srv.SetUserSettings(ctx, &pb.SetUserSettingsRequest{
+		SessionMetadata: metadata,
+		Settings:        &pb.UserSettings{Units: "kelvin"},
+	})
+	if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.InvalidArgument {
+		t.Errorf("SetUserSettings() with invalid units error = %v, want invalid argument", err)
+	}
+
+	if _, err := srv.SetUserSettings(ctx, &pb.SetUserSettingsRequest{
+		SessionMetadata: metadata,
+		Settings:        &pb.UserSettings{Units: settings.UnitsImperial, Timezone: "Europe/Madrid"},
+	}); err != nil {
+		t.Fatalf("SetUserSettings() error = %v", err)
+	}
+
+	got, err := srv.GetUserSettings(ctx, &pb.GetUserSettingsRequest{SessionMetadata: metadata})
+	if err != nil {
+		t.Fatalf("GetUserSettings() error = %v", err)
+	}
+	if got.Settings.Units != settings.UnitsImperial || got.Settings.Timezone != "Europe/Madrid" {
+		t.Errorf("GetUserSettings() = %+v", got.Settings)
+	}
+
+	// Replies are generated with the preferences of the conversation's user
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", UserID: "u1"}
+	if err := repo.CreateConversation(ctx, conv); err != nil {
+		t.Fatalf("CreateConversation() error = %v", err)
+	}
+	resp, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{ConversationId: conv.ID.Hex(), Message: "Weather?"})
+	if err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+	if resp.Reply != settings.UnitsImperial {
+		t.Errorf("reply generated with %q units, want the user's imperial units", resp.Reply)
+	}
+}
+
+type recordingTracker struct {
+	observed []string
+}
+
+func (r *recordingTracker) Observe(conv *model.Conversation, msg *model.Message) bool {
+	r.observed = append(r.observed, msg.Content)
+	return true
+}
+
+func TestServer_SentimentTracker(t *testing.T) {
+	ctx := context.Background()
+	tracker := &recordingTracker{}
+	srv := chat.NewServer(newMemoryRepository(), &MockAssistant{TitleResponse: "Hi", ReplyResponse: "Hello"}, nil,
+		chat.WithSentimentTracker(tracker))
+
+	started, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hi"})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	if _, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		ConversationId: started.ConversationId,
+		Message:        "This is not what I asked",
+	}); err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+
+	if !slices.Equal(tracker.observed, []string{"Hi", "This is not what I asked"}) {
+		t.Errorf("observed messages = %v, want the user messages only", tracker.observed)
+	}
+}
+
+// recordingAudit keeps recorded audit entries in memory
+type recordingAudit struct {
+	entries []*audit.Entry
+}
+
+func (r *recordingAudit) Record(ctx context.Context, entry *audit.Entry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func TestServer_ConversationInstructions(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	auditLog := &recordingAudit{}
+	srv := chat.NewServer(repo, &MockAssistant{}, nil, chat.WithAuditLog(auditLog))
+
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", UserID: "42"}
+	repo.CreateConversation(ctx, conv)
+
+	if _, err := srv.SetConversationInstructions(ctx, &pb.SetConversationInstructionsRequest{}); err == nil {
+		t.Error("expected an error without conversation_id")
+	}
+
+	_, err := srv.SetConversationInstructions(ctx, &pb.SetConversationInstructionsRequest{
+		ConversationId: conv.ID.Hex(),
+		Instructions:   strings.Repeat("é", model.MaxInstructionsChars+1),
+	})
+	if te, ok := err.(twirp.Error); !ok || te.Code() != twirp.InvalidArgument {
+		t.Fatalf("expected twirp.InvalidArgument error for long instructions, got %v", err)
+	}
+
+	resp, err := srv.SetConversationInstructions(ctx, &pb.SetConversationInstructionsRequest{
+		ConversationId: conv.ID.Hex(),
+		Instructions:   "  Answer like a pirate  ",
+	})
+	if err != nil {
+		t.Fatalf("SetConversationInstructions() error = %v", err)
+	}
+	if resp.GetInstructions() != "Answer like a pirate" {
+		t.Errorf("expected trimmed instructions, got %q", resp.GetInstructions())
+	}
+
+	stored, _ := repo.DescribeConversation(ctx, conv.ID.Hex())
+	if stored.Instructions != "Answer like a pirate" || stored.Proto().GetInstructions() != "Answer like a pirate" {
+		t.Errorf("expected instructions to be stored, got %q", stored.Instructions)
+	}
+
+	// Clearing is audited with the previous instructions
+	if _, err := srv.SetConversationInstructions(ctx, &pb.SetConversationInstructionsRequest{
+		ConversationId:  conv.ID.Hex(),
+		SessionMetadata: &pb.SessionMetadata{Platform: "web", UserId: "7"},
+	}); err != nil {
+		t.Fatalf("SetConversationInstructions() error = %v", err)
+	}
+
+	if len(auditLog.entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(auditLog.entries))
+	}
+	set, cleared := auditLog.entries[0], auditLog.entries[1]
+	if set.Action != audit.ActionConversationInstructionsSet || set.Actor != "telegram:42" ||
+		set.Target != conv.ID.Hex() || set.After != "Answer like a pirate" {
+		t.Errorf("unexpected audit entry for setting instructions: %+v", set)
+	}
+	if cleared.Action != audit.ActionConversationInstructionsCleared || cleared.Actor != "web:7" ||
+		cleared.Before != "Answer like a pirate" || cleared.After != "" {
+		t.Errorf("unexpected audit entry for clearing instructions: %+v", cleared)
+	}
+}
+
+func TestServer_InstructionsSetDuringReplyAreKept(t *testing.T) {
+	ctx := context.Background()
+	repo := mocks.NewConversationRepository()
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram", UserID: "42"}
+	repo.CreateConversation(ctx, conv)
+
+	var srv *chat.Server
+	assist := &busyAssistant{MockAssistant: MockAssistant{ReplyResponse: "Hi"}}
+	assist.during = func(conv *model.Conversation) {
+		if _, err := srv.SetConversationInstructions(ctx, &pb.SetConversationInstructionsRequest{
+			ConversationId: conv.ID.Hex(),
+			Instructions:   "Answer like a pirate",
+		}); err != nil {
+			t.Fatalf("SetConversationInstructions() error = %v", err)
+		}
+	}
+	srv = chat.NewServer(repo, assist, nil)
+
+	if _, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{ConversationId: conv.ID.Hex(), Message: "Hello"}); err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+
+	// The reply was generated from a copy without instructions; storing it must not clear them
+	stored, _ := repo.DescribeConversation(ctx, conv.ID.Hex())
+	if stored.Instructions != "Answer like a pirate" {
+		t.Errorf("instructions = %q, want them kept", stored.Instructions)
+	}
+}
+
+// summarizingAssistant reports usage and a summarized segment for every reply
+type summarizingAssistant struct{ variantAssistant }
+
+func (a summarizingAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	experiment.FromContext(ctx).AddSummaries(1)
+	return a.variantAssistant.Reply(ctx, conv)
+}
+
+func TestServer_DescribeConversation_Stats(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryRepository()
+	srv := chat.NewServer(repo, summarizingAssistant{}, nil)
+
+	started, err := srv.StartConversation(ctx, &pb.StartConversationRequest{Message: "Hello"})
+	if err != nil {
+		t.Fatalf("StartConversation() error = %v", err)
+	}
+	if _, err := srv.ContinueConversation(ctx, &pb.ContinueConversationRequest{
+		ConversationId: started.ConversationId,
+		Message:        "And tomorrow?",
+	}); err != nil {
+		t.Fatalf("ContinueConversation() error = %v", err)
+	}
+	conv, _ := repo.DescribeConversation(ctx, started.ConversationId)
+	conv.Messages = append(conv.Messages, &model.Message{Role: model.RoleSystem, Content: "Operator note"})
+	conv.Language = "de"
+
+	described, err := srv.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: started.ConversationId})
+	if err != nil {
+		t.Fatalf("DescribeConversation() error = %v", err)
+	}
+	stats := described.GetStats()
+	if stats.GetMessageCount() != 4 || stats.GetTotalTokens() != 240 || stats.GetSummarizations() != 2 {
+		t.Errorf("stats = %v, want 4 messages, 240 tokens and 2 summarizations", stats)
+	}
+	if stats.GetLanguage() != "de" || stats.GetCreatedAt().AsTime().IsZero() || stats.GetLastActivityAt().AsTime().IsZero() {
+		t.Errorf("stats = %v, want the conversation's language and timestamps", stats)
+	}
+}
@@ -0,0 +1,172 @@
+package chat_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
+	"github.com/twitchtv/twirp"
+)
+
+func telegramChat(chatID string) *pb.SessionMetadata {
+	return &pb.SessionMetadata{Platform: "telegram", UserId: "user-1", ChatId: chatID}
+}
+
+// newSessionServer returns a server whose sessions are kept by a session.Manager over in-memory storage
+func newSessionServer(assist chat.Assistant) (*chat.Server, *mocks.ConversationRepository) {
+	repo := mocks.NewConversationRepository()
+	sessions := session.NewManager(redisx.NewMemoryCache(time.Hour, 0), time.Hour, repo)
+	return chat.NewServer(repo, assist, sessions), repo
+}
+
+func continueInChat(t *testing.T, srv *chat.Server, chatID, message string) {
+	t.Helper()
+	if _, err := srv.ContinueConversation(context.Background(), &pb.ContinueConversationRequest{
+		Message:         message,
+		SessionMetadata: telegramChat(chatID),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func onlyConversation(t *testing.T, repo *mocks.ConversationRepository) *model.Conversation {
+	t.Helper()
+	conversations, _ := repo.ListConversations(context.Background())
+	if len(conversations) != 1 {
+		t.Fatalf("got %d conversations, want 1", len(conversations))
+	}
+	return conversations[0]
+}
+
+func TestServer_ContinueConversation_Session(t *testing.T) {
+	t.Run("first message starts a conversation that stores it once", func(t *testing.T) {
+		srv, repo := newSessionServer(&MockAssistant{ReplyResponse: "Sunny, 24°C"})
+
+		continueInChat(t, srv, "chat-1", "Weather in Barcelona?")
+
+		conv := onlyConversation(t, repo)
+		if len(conv.Messages) != 2 {
+			t.Fatalf("got %d messages, want the question and the reply", len(conv.Messages))
+		}
+		if conv.Messages[0].Role != model.RoleUser || conv.Messages[0].Content != "Weather in Barcelona?" {
+			t.Errorf("got first message %+v", conv.Messages[0])
+		}
+		if conv.Messages[1].Role != model.RoleAssistant || conv.Messages[1].Content != "Sunny, 24°C" {
+			t.Errorf("got second message %+v", conv.Messages[1])
+		}
+		if conv.Platform != "telegram" || conv.ChatID != "chat-1" || conv.UserID != "user-1" {
+			t.Errorf("got conversation of %s/%s/%s", conv.Platform, conv.UserID, conv.ChatID)
+		}
+	})
+
+	t.Run("later messages continue the chat's conversation", func(t *testing.T) {
+		srv, repo := newSessionServer(&MockAssistant{ReplyResponse: "OK"})
+
+		continueInChat(t, srv, "chat-1", "Hi")
+		continueInChat(t, srv, "chat-1", "And tomorrow?")
+
+		if got := len(onlyConversation(t, repo).Messages); got != 4 {
+			t.Errorf("got %d messages, want 4", got)
+		}
+
+		continueInChat(t, srv, "chat-2", "Hi from another chat")
+		if repo.Len() != 2 {
+			t.Errorf("got %d conversations, want one per chat", repo.Len())
+		}
+	})
+
+	t.Run("reset starts a new conversation on the next message", func(t *testing.T) {
+		srv, repo := newSessionServer(&MockAssistant{ReplyResponse: "OK"})
+		ctx := context.Background()
+
+		continueInChat(t, srv, "chat-1", "Hi")
+		first := onlyConversation(t, repo)
+
+		resp, err := srv.ResetSession(ctx, &pb.ResetSessionRequest{SessionMetadata: telegramChat("chat-1")})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.GetArchivedConversationId() != first.ID.Hex() {
+			t.Errorf("got archived conversation %q, want %q", resp.GetArchivedConversationId(), first.ID.Hex())
+		}
+
+		continueInChat(t, srv, "chat-1", "Hi again")
+		if repo.Len() != 2 {
+			t.Fatalf("got %d conversations, want 2", repo.Len())
+		}
+		archived, _ := repo.DescribeConversation(ctx, first.ID.Hex())
+		if archived.IsActive || len(archived.Messages) != 2 {
+			t.Errorf("expected the first conversation to be archived unchanged, got active %v with %d messages",
+				archived.IsActive, len(archived.Messages))
+		}
+	})
+
+	t.Run("sessions are recovered from the repository", func(t *testing.T) {
+		repo := mocks.NewConversationRepository()
+		assist := &MockAssistant{ReplyResponse: "OK"}
+		srv := chat.NewServer(repo, assist, session.NewManager(redisx.NewMemoryCache(time.Hour, 0), time.Hour, repo))
+		continueInChat(t, srv, "chat-1", "Hi")
+
+		// A manager with empty session storage, as after a Redis restart
+		restarted := chat.NewServer(repo, assist, session.NewManager(redisx.NewMemoryCache(time.Hour, 0), time.Hour, repo))
+		continueInChat(t, restarted, "chat-1", "Still there?")
+
+		if got := len(onlyConversation(t, repo).Messages); got != 4 {
+			t.Errorf("got %d messages, want 4", got)
+		}
+	})
+
+	t.Run("session store failure is an internal error", func(t *testing.T) {
+		sessions := mocks.NewSessionStore(nil)
+		sessions.Err = errors.New("redis down")
+		srv := chat.NewServer(mocks.NewConversationRepository(), &MockAssistant{}, sessions)
+
+		_, err := srv.ContinueConversation(context.Background(), &pb.ContinueConversationRequest{
+			Message:         "Hi",
+			SessionMetadata: telegramChat("chat-1"),
+		})
+		var te twirp.Error
+		if !errors.As(err, &te) || te.Code() != twirp.Internal {
+			t.Errorf("got %v, want an internal error", err)
+		}
+	})
+
+	t.Run("incomplete metadata is rejected", func(t *testing.T) {
+		sessions := mocks.NewSessionStore(nil)
+		srv := chat.NewServer(mocks.NewConversationRepository(), &MockAssistant{}, sessions)
+
+		_, err := srv.ContinueConversation(context.Background(), &pb.ContinueConversationRequest{
+			Message:         "Hi",
+			SessionMetadata: &pb.SessionMetadata{Platform: "telegram", UserId: "user-1"},
+		})
+		var te twirp.Error
+		if !errors.As(err, &te) || te.Code() != twirp.InvalidArgument {
+			t.Errorf("got %v, want an invalid argument error", err)
+		}
+		if sessions.Session("telegram", "") != "" {
+			t.Error("expected no session to be created")
+		}
+	})
+
+	t.Run("fake session store continues the stored conversation", func(t *testing.T) {
+		repo := mocks.NewConversationRepository()
+		sessions := mocks.NewSessionStore(repo)
+		srv := chat.NewServer(repo, &MockAssistant{ReplyResponse: "OK"}, sessions)
+
+		continueInChat(t, srv, "chat-1", "Hi")
+		conv := onlyConversation(t, repo)
+		if sessions.Session("telegram", "chat-1") != conv.ID.Hex() {
+			t.Errorf("expected the chat's session to point at conversation %s", conv.ID.Hex())
+		}
+		if len(conv.Messages) != 2 {
+			t.Errorf("got %d messages, want 2", len(conv.Messages))
+		}
+	})
+}
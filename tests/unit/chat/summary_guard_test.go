@@ -0,0 +1,121 @@
+package chat_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+)
+
+type fixedSummarizer struct{ summary string }
+
+func (s fixedSummarizer) Summarize(ctx context.Context, messages []chat.Message, maxTokens int) (string, error) {
+	return s.summary, nil
+}
+
+type fixedJudge struct {
+	score float64
+	err   error
+	calls int
+}
+
+func (j *fixedJudge) ScoreSummary(ctx context.Context, messages []chat.Message, summary string) (float64, error) {
+	j.calls++
+	return j.score, j.err
+}
+
+type summaryRecorder struct {
+	before, after int
+	scores        []float64
+	accepted      []bool
+}
+
+func (r *summaryRecorder) RecordSummaryTokens(ctx context.Context, before, after int) {
+	r.before += before
+	r.after += after
+}
+
+func (r *summaryRecorder) RecordSummaryFaithfulness(ctx context.Context, score float64, accepted bool) {
+	r.scores = append(r.scores, score)
+	r.accepted = append(r.accepted, accepted)
+}
+
+// reduce fills a context with long messages and reduces it to a fraction of its size
+func reduce(t *testing.T, opts ...chat.ContextManagerOption) []chat.Message {
+	t.Helper()
+	opts = append([]chat.ContextManagerOption{chat.WithSummarizer(fixedSummarizer{"User asked about Paris weather."}, 100, 4)}, opts...)
+	cm := chat.NewContextManager(redisx.NewMemoryCache(time.Hour, 0), 10000, 50, nil, opts...)
+
+	ctx := context.Background()
+	var messages []chat.Message
+	for i := 0; i < 12; i++ {
+		messages = append(messages, chat.Message{Role: "user", Content: strings.Repeat("weather in Paris ", 20)})
+	}
+	if _, err := cm.AddMessages(ctx, "conv-1", messages); err != nil {
+		t.Fatalf("AddMessages() error = %v", err)
+	}
+	if err := cm.EnsureContextFits(ctx, "conv-1", 500); err != nil {
+		t.Fatalf("EnsureContextFits() error = %v", err)
+	}
+	return cm.GetContext("conv-1")
+}
+
+func hasSummary(messages []chat.Message) bool {
+	for _, m := range messages {
+		if chat.IsSummary(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestContextManager_SummaryGuard(t *testing.T) {
+	t.Run("faithful summaries are kept and measured", func(t *testing.T) {
+		judge, recorder := &fixedJudge{score: 0.9}, &summaryRecorder{}
+		reduced := reduce(t, chat.WithSummaryJudge(judge, 1, 0.5), chat.WithSummaryRecorder(recorder))
+
+		if !hasSummary(reduced) {
+			t.Error("context has no summary, want the faithful summary kept")
+		}
+		if judge.calls == 0 || len(recorder.scores) != judge.calls || !recorder.accepted[0] {
+			t.Errorf("recorded scores %v (accepted %v) for %d checks", recorder.scores, recorder.accepted, judge.calls)
+		}
+		if recorder.before <= recorder.after || recorder.after == 0 {
+			t.Errorf("recorded %d tokens before and %d after, want the summary smaller", recorder.before, recorder.after)
+		}
+	})
+
+	t.Run("unfaithful summaries fall back to basic reduction", func(t *testing.T) {
+		recorder := &summaryRecorder{}
+		reduced := reduce(t, chat.WithSummaryJudge(&fixedJudge{score: 0.2}, 1, 0.5), chat.WithSummaryRecorder(recorder))
+
+		if hasSummary(reduced) {
+			t.Error("context kept a summary scored below the minimum")
+		}
+		if len(reduced) == 0 || len(reduced) >= 12 {
+			t.Errorf("got %d messages, want the oldest dropped", len(reduced))
+		}
+		if len(recorder.accepted) == 0 || recorder.accepted[0] {
+			t.Errorf("recorded outcomes %v, want a rejection", recorder.accepted)
+		}
+	})
+
+	t.Run("judge failures keep the summary", func(t *testing.T) {
+		reduced := reduce(t, chat.WithSummaryJudge(&fixedJudge{err: errors.New("timeout")}, 1, 0.5))
+		if !hasSummary(reduced) {
+			t.Error("context has no summary, want it kept when scoring fails")
+		}
+	})
+
+	t.Run("unsampled summaries are not scored", func(t *testing.T) {
+		judge := &fixedJudge{score: 0}
+		reduced := reduce(t, chat.WithSummaryJudge(judge, 0, 0.5))
+		if judge.calls != 0 || !hasSummary(reduced) {
+			t.Errorf("judge called %d times, want none at a zero sample rate", judge.calls)
+		}
+	})
+}
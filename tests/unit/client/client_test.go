@@ -0,0 +1,297 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/pkg/client"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
+	"github.com/openai/openai-go"
+)
+
+// echoAssistant replies with the last message; "busy" fails as a rate limited model provider would
+type echoAssistant struct{}
+
+func (echoAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	return conv.Messages[0].Content, nil
+}
+
+func (echoAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	message := conv.Messages[len(conv.Messages)-1].Content
+	if message == "busy" {
+		req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil)
+		return "", &openai.Error{
+			StatusCode: http.StatusTooManyRequests,
+			Request:    req,
+			Response:   &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"30"}}},
+		}
+	}
+	return "You said: " + message, nil
+}
+
+// memoryIdempotency is an in-memory httpx.IdempotencyStore
+type memoryIdempotency struct {
+	mu        sync.Mutex
+	pending   map[string]bool
+	responses map[string]*httpx.StoredResponse
+}
+
+func (m *memoryIdempotency) Claim(ctx context.Context, key string, ttl time.Duration) (bool, *httpx.StoredResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if resp, ok := m.responses[key]; ok {
+		return false, resp, nil
+	}
+	if m.pending[key] {
+		return false, nil, nil
+	}
+	m.pending[key] = true
+	return true, nil, nil
+}
+
+func (m *memoryIdempotency) Save(ctx context.Context, key string, resp *httpx.StoredResponse, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, key)
+	m.responses[key] = resp
+	return nil
+}
+
+func (m *memoryIdempotency) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, key)
+	return nil
+}
+
+// service runs the chat server behind the idempotency middleware; lose makes the next n responses
+// fail with 502 after the server handled the request, as a proxy dropping them would
+type service struct {
+	*httptest.Server
+	repo *mocks.ConversationRepository
+
+	mu       sync.Mutex
+	lose     int
+	requests []*http.Request
+}
+
+func newService(t *testing.T) *service {
+	s := &service{repo: mocks.NewConversationRepository()}
+	sessions := session.NewManager(redisx.NewMemoryCache(time.Hour, 0), time.Hour, s.repo)
+	twirpHandler := pb.NewChatServiceServer(chat.NewServer(s.repo, echoAssistant{}, sessions))
+	idempotent := httpx.Idempotency(&memoryIdempotency{pending: map[string]bool{}, responses: map[string]*httpx.StoredResponse{}}, time.Hour)(twirpHandler)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		lose := s.lose > 0
+		if lose {
+			s.lose--
+		}
+		s.mu.Unlock()
+
+		if lose {
+			idempotent.ServeHTTP(httptest.NewRecorder(), r)
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		idempotent.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+var fastRetries = client.WithRetry(client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+func TestClient_Conversation(t *testing.T) {
+	svc := newService(t)
+	c := client.New(svc.URL+"/", client.WithAPIKey("key"), client.WithTenant("acme", "acme-key"))
+	ctx := context.Background()
+
+	start, err := c.StartConversation(ctx, &client.StartConversationRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("StartConversation failed: %v", err)
+	}
+	if start.GetReply() != "You said: hello" {
+		t.Errorf("unexpected reply %q", start.GetReply())
+	}
+
+	next, err := c.ContinueConversation(ctx, &client.ContinueConversationRequest{ConversationId: start.GetConversationId(), Message: "again"})
+	if err != nil {
+		t.Fatalf("ContinueConversation failed: %v", err)
+	}
+	if next.GetReply() != "You said: again" {
+		t.Errorf("unexpected reply %q", next.GetReply())
+	}
+
+	described, err := c.DescribeConversation(ctx, &client.DescribeConversationRequest{ConversationId: start.GetConversationId()})
+	if err != nil {
+		t.Fatalf("DescribeConversation failed: %v", err)
+	}
+	if n := len(described.GetConversation().GetMessages()); n != 4 {
+		t.Errorf("expected 4 messages, got %d", n)
+	}
+
+	for _, r := range svc.requests {
+		if r.Header.Get("X-API-Key") != "key" || r.Header.Get(tenant.Header) != "acme" || r.Header.Get(tenant.KeyHeader) != "acme-key" {
+			t.Errorf("expected %s to carry the API key and tenant, got %v", r.URL.Path, r.Header)
+		}
+	}
+	if svc.requests[0].Header.Get(httpx.IdempotencyKeyHeader) == "" {
+		t.Error("expected StartConversation to carry an idempotency key")
+	}
+	if svc.requests[2].Header.Get(httpx.IdempotencyKeyHeader) != "" {
+		t.Error("expected DescribeConversation to go without an idempotency key")
+	}
+}
+
+func TestClient_RetriesWithoutDuplicating(t *testing.T) {
+	svc := newService(t)
+	svc.lose = 2
+	c := client.New(svc.URL, fastRetries)
+
+	start, err := c.StartConversation(context.Background(), &client.StartConversationRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("StartConversation failed: %v", err)
+	}
+	if start.GetReply() != "You said: hello" {
+		t.Errorf("unexpected reply %q", start.GetReply())
+	}
+	if len(svc.requests) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(svc.requests))
+	}
+	key := svc.requests[0].Header.Get(httpx.IdempotencyKeyHeader)
+	for _, r := range svc.requests[1:] {
+		if r.Header.Get(httpx.IdempotencyKeyHeader) != key {
+			t.Error("expected retries to keep the idempotency key")
+		}
+	}
+	if svc.repo.Len() != 1 {
+		t.Errorf("expected one conversation, got %d", svc.repo.Len())
+	}
+}
+
+func TestClient_CallerIdempotencyKey(t *testing.T) {
+	svc := newService(t)
+	c := client.New(svc.URL)
+	ctx := client.WithIdempotencyKey(context.Background(), "job-42")
+
+	first, err := c.StartConversation(ctx, &client.StartConversationRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("StartConversation failed: %v", err)
+	}
+	second, err := c.StartConversation(ctx, &client.StartConversationRequest{Message: "hello"})
+	if err != nil {
+		t.Fatalf("repeated StartConversation failed: %v", err)
+	}
+	if first.GetConversationId() != second.GetConversationId() || svc.repo.Len() != 1 {
+		t.Errorf("expected the repeated call to return the first conversation, got %s and %s",
+			first.GetConversationId(), second.GetConversationId())
+	}
+}
+
+func TestClient_SignsEveryAttempt(t *testing.T) {
+	svc := newService(t)
+	svc.lose = 1
+	signer := httpx.NewRequestSigner(httpx.SigningConfig{
+		Keys:     map[string]string{"svc": "secret"},
+		Required: true,
+		MaxSkew:  time.Minute,
+		Nonces:   &memoryNonces{seen: map[string]bool{}},
+	})
+	signed := httptest.NewServer(signer.Middleware()(svc.Config.Handler))
+	t.Cleanup(signed.Close)
+
+	c := client.New(signed.URL, client.WithSigningKey("svc", "secret"), fastRetries)
+	if _, err := c.StartConversation(context.Background(), &client.StartConversationRequest{Message: "hello"}); err != nil {
+		t.Fatalf("expected the signed retry to succeed: %v", err)
+	}
+
+	unsigned := client.New(signed.URL, fastRetries)
+	_, err := unsigned.StartConversation(context.Background(), &client.StartConversationRequest{Message: "hello"})
+	if !errors.Is(err, client.ErrUnauthenticated) {
+		t.Errorf("expected ErrUnauthenticated, got %v", err)
+	}
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	svc := newService(t)
+	c := client.New(svc.URL, fastRetries)
+	ctx := context.Background()
+
+	_, err := c.DescribeConversation(ctx, &client.DescribeConversationRequest{ConversationId: "5f1d7b1c9d3e2a0001a1b2c3"})
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	_, err = c.DescribeConversation(ctx, &client.DescribeConversationRequest{})
+	if !errors.Is(err, client.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument, got %v", err)
+	}
+
+	// Waiting 30s is longer than the retry policy allows, so the error is returned with the delay
+	attempts := len(svc.requests)
+	_, err = c.StartConversation(ctx, &client.StartConversationRequest{Message: "busy"})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, client.ErrProviderRateLimited) || !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("expected a provider rate limit error, got %v", err)
+	}
+	if apiErr.RetryAfter != 30*time.Second {
+		t.Errorf("expected a 30s retry delay, got %s", apiErr.RetryAfter)
+	}
+	if len(svc.requests)-attempts != 1 {
+		t.Errorf("expected no retry, got %d attempts", len(svc.requests)-attempts)
+	}
+}
+
+func TestClient_RateLimitedByService(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"rate limit exceeded","message":"too many requests, please try again later"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	c := client.New(server.URL, fastRetries)
+	_, err := c.ListConversations(context.Background(), &client.ListConversationsRequest{})
+	if !errors.Is(err, client.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) && apiErr.Message != "too many requests, please try again later" {
+		t.Errorf("expected the middleware's message, got %q", apiErr.Message)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+// memoryNonces is an in-memory httpx.NonceStore
+type memoryNonces struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (m *memoryNonces) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[nonce] {
+		return false, nil
+	}
+	m.seen[nonce] = true
+	return true, nil
+}
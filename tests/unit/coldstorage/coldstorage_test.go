@@ -0,0 +1,143 @@
+package coldstorage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/coldstorage"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryConversations struct {
+	conversations []*model.Conversation
+	touched       map[primitive.ObjectID]bool // Active again after they were read
+	stubs         map[primitive.ObjectID]string
+}
+
+func (s *memoryConversations) FindInactiveConversations(_ context.Context, before time.Time, after primitive.ObjectID, limit int) ([]*model.Conversation, error) {
+	var page []*model.Conversation
+	for _, c := range s.conversations {
+		if c.LastActivity.Before(before) && s.stubs[c.ID] == "" && c.ID.Hex() > after.Hex() && len(page) < limit {
+			page = append(page, c)
+		}
+	}
+	return page, nil
+}
+
+func (s *memoryConversations) StubConversation(_ context.Context, c *model.Conversation, key string) (bool, error) {
+	if s.touched[c.ID] {
+		return false, nil
+	}
+	s.stubs[c.ID] = key
+	return true, nil
+}
+
+func conversation(lastActivity time.Time, contents ...string) *model.Conversation {
+	c := &model.Conversation{ID: primitive.NewObjectID(), Title: "Trip", LastActivity: lastActivity.Truncate(time.Millisecond)}
+	for _, content := range contents {
+		c.Messages = append(c.Messages, &model.Message{
+			ID:        primitive.NewObjectID(),
+			Role:      model.RoleUser,
+			Content:   content,
+			CreatedAt: lastActivity.Truncate(time.Millisecond),
+		})
+	}
+	return c
+}
+
+func newArchive(t *testing.T) *coldstorage.Archive {
+	store, err := objectstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	return coldstorage.NewArchive(store)
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	archive := newArchive(t)
+	conv := conversation(time.Now(), "weather in Paris?", "and in Rome?")
+	conv.TenantID = "acme"
+	conv.Messages[1].Generation = &model.Generation{Variant: model.Variant{Model: "gpt-4.1"}}
+
+	key, err := archive.Save(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if want := "acme/" + conv.ID.Hex() + ".json.gz"; key != want {
+		t.Errorf("key = %q, want %q", key, want)
+	}
+
+	loaded, err := archive.Load(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.ID != conv.ID || loaded.Title != conv.Title || loaded.TenantID != "acme" || !loaded.LastActivity.Equal(conv.LastActivity) {
+		t.Errorf("loaded conversation = %+v, want %+v", loaded, conv)
+	}
+
+	messages, err := archive.RestoreMessages(context.Background(), key)
+	if err != nil {
+		t.Fatalf("RestoreMessages() error = %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("restored %d messages, want 2", len(messages))
+	}
+	for i, msg := range messages {
+		want := conv.Messages[i]
+		if msg.ID != want.ID || msg.Content != want.Content || !msg.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("message %d = %+v, want %+v", i, msg, want)
+		}
+	}
+	if messages[1].Generation == nil || messages[1].Generation.Model != "gpt-4.1" {
+		t.Errorf("generation = %+v, want the archived one", messages[1].Generation)
+	}
+}
+
+func TestArchive_Missing(t *testing.T) {
+	if _, err := newArchive(t).Load(context.Background(), "default/missing.json.gz"); err != objectstore.ErrNotFound {
+		t.Errorf("Load() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestArchiver_Run(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-100 * 24 * time.Hour)
+	stale := conversation(old, "hello")
+	recent := conversation(now.Add(-time.Hour), "hi")
+	resumed := conversation(old, "are you there?")
+	another := conversation(old, "bye")
+
+	store := &memoryConversations{
+		conversations: []*model.Conversation{stale, recent, resumed, another},
+		touched:       map[primitive.ObjectID]bool{resumed.ID: true},
+		stubs:         make(map[primitive.ObjectID]string),
+	}
+	archive := newArchive(t)
+	archiver := coldstorage.NewArchiver(store, archive, coldstorage.Config{InactiveFor: 90 * 24 * time.Hour, BatchSize: 1})
+
+	result, err := archiver.Run(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Archived != 2 || result.Skipped != 1 || result.Failed != 0 {
+		t.Errorf("result = %+v, want 2 archived and 1 skipped", result)
+	}
+	if store.stubs[recent.ID] != "" || store.stubs[resumed.ID] != "" {
+		t.Errorf("stubs = %v, want only inactive conversations", store.stubs)
+	}
+
+	messages, err := archive.RestoreMessages(context.Background(), store.stubs[stale.ID])
+	if err != nil || len(messages) != 1 || messages[0].Content != "hello" {
+		t.Errorf("RestoreMessages() = %v, %v, want the archived message", messages, err)
+	}
+}
+
+func TestArchiver_RequiresInactivityPeriod(t *testing.T) {
+	archiver := coldstorage.NewArchiver(&memoryConversations{}, newArchive(t), coldstorage.Config{})
+	if _, err := archiver.Run(context.Background(), time.Now()); err == nil {
+		t.Error("Run() without an inactivity period: expected an error")
+	}
+}
@@ -0,0 +1,205 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/commands"
+	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		message string
+		name    string
+		args    []string
+		ok      bool
+	}{
+		{"/reset", "reset", nil, true},
+		{"  /Language   es ", "language", []string{"es"}, true},
+		{"/help@my_bot", "help", nil, true},
+		{"/persona formal extra", "persona", []string{"formal", "extra"}, true},
+		{"hello /reset", "", nil, false},
+		{"/", "", nil, false},
+		{"/usr/bin/env", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		name, args, ok := commands.Parse(tt.message)
+		if ok != tt.ok || name != tt.name || !slices.Equal(args, tt.args) {
+			t.Errorf("Parse(%q) = %q, %q, %v; want %q, %q, %v", tt.message, name, args, ok, tt.name, tt.args, tt.ok)
+		}
+	}
+}
+
+type memoryStore struct {
+	conversations map[string]*model.Conversation
+	archived      []primitive.ObjectID
+}
+
+func (s *memoryStore) DescribeConversation(ctx context.Context, id string) (*model.Conversation, error) {
+	conv, ok := s.conversations[id]
+	if !ok {
+		return nil, errors.New("conversation not found")
+	}
+	return conv, nil
+}
+
+func (s *memoryStore) ArchiveConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	s.archived = append(s.archived, ids...)
+	return int64(len(ids)), nil
+}
+
+func (s *memoryStore) SetConversationPersona(ctx context.Context, ids []primitive.ObjectID, persona string) (int64, error) {
+	for _, id := range ids {
+		s.conversations[id.Hex()].Persona = persona
+	}
+	return int64(len(ids)), nil
+}
+
+func (s *memoryStore) SetConversationLanguage(ctx context.Context, ids []primitive.ObjectID, language string) (int64, error) {
+	for _, id := range ids {
+		s.conversations[id.Hex()].Language = language
+	}
+	return int64(len(ids)), nil
+}
+
+type memorySessions struct {
+	store    *memoryStore
+	sessions map[string]string // chat ID -> conversation ID
+}
+
+func (m *memorySessions) GetSession(ctx context.Context, platform, chatID string) (*session.Session, error) {
+	id, ok := m.sessions[chatID]
+	if !ok {
+		return nil, errors.New("no session found")
+	}
+	return &session.Session{ConversationID: id, Platform: platform, ChatID: chatID}, nil
+}
+
+func (m *memorySessions) StartSession(ctx context.Context, platform, userID, chatID string) (string, error) {
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: platform, UserID: userID, ChatID: chatID}
+	m.store.conversations[conv.ID.Hex()] = conv
+	m.sessions[chatID] = conv.ID.Hex()
+	return conv.ID.Hex(), nil
+}
+
+func (m *memorySessions) ResetSession(ctx context.Context, platform, chatID string) (string, error) {
+	id, ok := m.sessions[chatID]
+	if !ok {
+		return "", nil
+	}
+	delete(m.sessions, chatID)
+	_, err := m.store.ArchiveConversations(ctx, []primitive.ObjectID{m.store.conversations[id].ID})
+	return id, err
+}
+
+func newRouter(personas []string) (*commands.Router, *memoryStore, *memorySessions) {
+	store := &memoryStore{conversations: map[string]*model.Conversation{}}
+	sessions := &memorySessions{store: store, sessions: map[string]string{}}
+
+	registry := commands.NewRegistry()
+	commands.RegisterBuiltins(registry, []string{"telegram"}, personas)
+	return commands.NewRouter(registry, store, sessions), store, sessions
+}
+
+func telegram(message string) commands.Request {
+	return commands.Request{Platform: "telegram", UserID: "u1", ChatID: "c1", Message: message}
+}
+
+func TestRouter_PlatformRegistration(t *testing.T) {
+	router, _, _ := newRouter(nil)
+	ctx := context.Background()
+
+	if _, handled, _ := router.Handle(ctx, commands.Request{Platform: "web", Message: "/help"}); handled {
+		t.Error("command handled on a platform without registered commands")
+	}
+	if _, handled, _ := router.Handle(ctx, telegram("hello")); handled {
+		t.Error("plain message handled as a command")
+	}
+
+	reply, handled, err := router.Handle(ctx, telegram("/help"))
+	if err != nil || !handled {
+		t.Fatalf("Handle(/help) = %v, %v", handled, err)
+	}
+	for _, name := range []string{"/reset", "/help", "/language", "/persona"} {
+		if !strings.Contains(reply, name) {
+			t.Errorf("help reply %q does not list %s", reply, name)
+		}
+	}
+
+	reply, handled, _ = router.Handle(ctx, telegram("/unknown"))
+	if !handled || !strings.Contains(reply, "Unknown command /unknown") {
+		t.Errorf("unknown command reply = %q, %v", reply, handled)
+	}
+}
+
+func TestRouter_LanguageStartsConversation(t *testing.T) {
+	router, store, sessions := newRouter(nil)
+	ctx := context.Background()
+
+	if _, _, err := router.Handle(ctx, telegram("/language es")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	conv := store.conversations[sessions.sessions["c1"]]
+	if conv == nil || conv.Language != "es" {
+		t.Fatalf("conversation = %+v, want a new conversation answered in es", conv)
+	}
+
+	if _, _, err := router.Handle(ctx, telegram("/language auto")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if conv.Language != "" {
+		t.Errorf("language = %q after /language auto, want it cleared", conv.Language)
+	}
+
+	reply, handled, err := router.Handle(ctx, telegram("/language not-a-language"))
+	if err != nil || !handled || !strings.HasPrefix(reply, "Usage: /language") {
+		t.Errorf("invalid language reply = %q, %v, %v; want usage", reply, handled, err)
+	}
+}
+
+func TestRouter_Persona(t *testing.T) {
+	router, store, sessions := newRouter([]string{"formal", "casual"})
+	ctx := context.Background()
+
+	if _, _, err := router.Handle(ctx, telegram("/persona Formal")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	conv := store.conversations[sessions.sessions["c1"]]
+	if conv.Persona != "formal" {
+		t.Errorf("persona = %q, want formal", conv.Persona)
+	}
+
+	reply, _, _ := router.Handle(ctx, telegram("/persona pirate"))
+	if !strings.Contains(reply, "Unknown persona") || conv.Persona != "formal" {
+		t.Errorf("persona outside the allowed list: reply %q, persona %q", reply, conv.Persona)
+	}
+}
+
+func TestRouter_Reset(t *testing.T) {
+	router, store, sessions := newRouter(nil)
+	ctx := context.Background()
+
+	id, _ := sessions.StartSession(ctx, "telegram", "u1", "c1")
+	if _, _, err := router.Handle(ctx, telegram("/reset")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(store.archived) != 1 || store.archived[0].Hex() != id {
+		t.Errorf("archived = %v, want the current conversation", store.archived)
+	}
+	if _, ok := sessions.sessions["c1"]; ok {
+		t.Error("session was not reset")
+	}
+
+	// Resetting a chat without a conversation still succeeds
+	if _, handled, err := router.Handle(ctx, telegram("/reset")); err != nil || !handled {
+		t.Errorf("second /reset = %v, %v", handled, err)
+	}
+}
@@ -0,0 +1,122 @@
+package costcap_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/costcap"
+)
+
+// conversation returns a conversation whose assistant replies each took the given prompt and completion tokens
+func conversation(persona, replyModel string, replies int, promptTokens, completionTokens int64) *model.Conversation {
+	conv := &model.Conversation{Persona: persona}
+	for i := 0; i < replies; i++ {
+		conv.Messages = append(conv.Messages,
+			&model.Message{Role: model.RoleUser, Content: "question"},
+			&model.Message{Role: model.RoleAssistant, Content: "answer", Generation: &model.Generation{
+				Variant:          model.Variant{Model: replyModel},
+				PromptTokens:     promptTokens,
+				CompletionTokens: completionTokens,
+			}},
+		)
+	}
+	return conv
+}
+
+func TestParseCap(t *testing.T) {
+	c, err := costcap.ParseCap("200000/1.5")
+	if err != nil || c.Tokens != 200000 || c.CostUSD != 1.5 {
+		t.Errorf("ParseCap() = %+v, %v, want 200000 tokens and 1.5 USD", c, err)
+	}
+	for _, invalid := range []string{"200000", "-1/0", "100/abc", "1.5/1"} {
+		if _, err := costcap.ParseCap(invalid); err == nil {
+			t.Errorf("ParseCap(%q) accepted an invalid cap", invalid)
+		}
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	conv := conversation("", "gpt-4o", 2, 1000, 500)
+	conv.Messages = append(conv.Messages, &model.Message{Role: model.RoleAssistant, Content: costcap.Refusal})
+
+	spend := costcap.Measure(conv, billing.DefaultPricing())
+	if spend.Tokens != 3000 {
+		t.Errorf("Tokens = %d, want 3000", spend.Tokens)
+	}
+	// 2 × (1K prompt tokens at 0.0025 + 0.5K completion tokens at 0.01)
+	if math.Abs(spend.CostUSD-0.015) > 1e-9 {
+		t.Errorf("CostUSD = %f, want 0.015", spend.CostUSD)
+	}
+
+	if spend := costcap.Measure(conversation("", "unpriced", 1, 1000, 0), billing.DefaultPricing()); spend.Tokens != 1000 || spend.CostUSD != 0 {
+		t.Errorf("Measure() of an unpriced model = %+v, want tokens without cost", spend)
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy, err := costcap.NewPolicy(costcap.Config{
+		Default:  costcap.Cap{Tokens: 5000},
+		Personas: map[string]string{"premium": "50000/0", "trial": "0/0.01"},
+		Action:   costcap.ActionDowngrade,
+		Model:    "gpt-4o-mini",
+		Pricing:  billing.DefaultPricing(),
+	})
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		conv   *model.Conversation
+		action string
+	}{
+		{"within the default cap", conversation("", "gpt-4o", 2, 1000, 500), ""},
+		{"over the default cap", conversation("", "gpt-4o", 4, 1000, 500), costcap.ActionDowngrade},
+		{"within a persona's larger cap", conversation("premium", "gpt-4o", 4, 1000, 500), ""},
+		{"over a persona's cost cap", conversation("trial", "gpt-4o", 2, 1000, 500), costcap.ActionDowngrade},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Check(tt.conv)
+			if decision.Action != tt.action {
+				t.Errorf("Check() = %+v, want action %q", decision, tt.action)
+			}
+			if tt.action == costcap.ActionDowngrade && decision.Model != "gpt-4o-mini" {
+				t.Errorf("Model = %q, want the cheap model", decision.Model)
+			}
+		})
+	}
+}
+
+func TestPolicy_Refuse(t *testing.T) {
+	policy, err := costcap.NewPolicy(costcap.Config{Default: costcap.Cap{Tokens: 100}, Action: costcap.ActionRefuse})
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	if decision := policy.Check(conversation("", "gpt-4o", 1, 100, 0)); decision.Action != costcap.ActionRefuse || decision.Model != "" {
+		t.Errorf("Check() = %+v, want a refusal", decision)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	policy, err := costcap.NewPolicy(costcap.Config{Action: costcap.ActionDowngrade, Model: "gpt-4o-mini"})
+	if err != nil || policy != nil {
+		t.Errorf("NewPolicy() without caps = %v, %v, want nil", policy, err)
+	}
+	if decision := policy.Check(conversation("", "gpt-4o", 100, 1000, 1000)); decision.Action != "" {
+		t.Errorf("nil policy capped a conversation: %+v", decision)
+	}
+
+	invalid := []costcap.Config{
+		{Default: costcap.Cap{Tokens: 100}, Action: "block"},
+		{Default: costcap.Cap{Tokens: 100}, Action: costcap.ActionDowngrade},
+		{Personas: map[string]string{"trial": "cheap"}, Action: costcap.ActionRefuse},
+	}
+	for _, cfg := range invalid {
+		if _, err := costcap.NewPolicy(cfg); err == nil {
+			t.Errorf("NewPolicy(%+v) accepted an invalid config", cfg)
+		}
+	}
+}
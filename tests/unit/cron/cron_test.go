@@ -0,0 +1,191 @@
+package cron_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/cron"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	from := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC) // A Friday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 5, 17, 10, 45, 0, 0, time.UTC)},
+		{"5 0 * * *", time.Date(2024, 5, 18, 0, 5, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 5, 17, 13, 0, 0, 0, time.UTC)},
+		{"0 3 * * 1", time.Date(2024, 5, 20, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2024, 5, 19, 3, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 5, 17, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := cron.ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q).Next() = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "0 0 30 2 *", "@every 1ms", "@yearly"} {
+		if _, err := cron.ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+type memoryHistory struct {
+	mu   sync.Mutex
+	runs []*cron.Run
+}
+
+func (h *memoryHistory) Add(ctx context.Context, run *cron.Run, limit int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runs = append([]*cron.Run{run}, h.runs...)
+	if len(h.runs) > limit {
+		h.runs = h.runs[:limit]
+	}
+	return nil
+}
+
+func (h *memoryHistory) List(ctx context.Context, task string, limit int) ([]*cron.Run, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var runs []*cron.Run
+	for _, run := range h.runs {
+		if run.Task == task && len(runs) < limit {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+type staticLeader bool
+
+func (l staticLeader) IsLeader() bool { return bool(l) }
+
+func TestScheduler_TriggerRecordsRuns(t *testing.T) {
+	history := &memoryHistory{}
+	scheduler := cron.NewScheduler(staticLeader(false), history, nil, cron.Config{Instance: "i1", HistoryLimit: 2})
+
+	fail := false
+	err := scheduler.Add(cron.Task{
+		Name:     "sweep",
+		Schedule: "@daily",
+		Run: func(ctx context.Context) error {
+			if fail {
+				return errors.New("mongo unavailable")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := scheduler.Add(cron.Task{Name: "sweep", Schedule: "@daily"}); err == nil {
+		t.Error("Add() accepted a duplicate task")
+	}
+
+	run, err := scheduler.Trigger(context.Background(), "sweep")
+	if err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if run.Status != cron.StatusSucceeded || run.Trigger != cron.TriggerManual || run.Instance != "i1" {
+		t.Errorf("run = %+v, want a succeeded manual run on i1", run)
+	}
+
+	fail = true
+	scheduler.Trigger(context.Background(), "sweep")
+	scheduler.Trigger(context.Background(), "sweep")
+
+	runs, err := scheduler.History(context.Background(), "sweep", 0)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].Status != cron.StatusFailed || runs[0].Error != "mongo unavailable" {
+		t.Errorf("runs = %+v, want the latest 2 failed runs", runs)
+	}
+
+	if _, err := scheduler.Trigger(context.Background(), "missing"); !errors.Is(err, cron.ErrUnknownTask) {
+		t.Errorf("Trigger(missing) error = %v, want ErrUnknownTask", err)
+	}
+}
+
+func TestScheduler_OverlappingRunsAndPanics(t *testing.T) {
+	scheduler := cron.NewScheduler(nil, nil, nil, cron.Config{})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	scheduler.Add(cron.Task{
+		Name:     "slow",
+		Schedule: "@hourly",
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		},
+	})
+	scheduler.Add(cron.Task{
+		Name:     "broken",
+		Schedule: "@hourly",
+		Run:      func(ctx context.Context) error { panic("nil map") },
+	})
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Trigger(context.Background(), "slow")
+		close(done)
+	}()
+	<-started
+	if _, err := scheduler.Trigger(context.Background(), "slow"); !errors.Is(err, cron.ErrRunning) {
+		t.Errorf("second Trigger() error = %v, want ErrRunning", err)
+	}
+	close(release)
+	<-done
+
+	run, err := scheduler.Trigger(context.Background(), "broken")
+	if err != nil || run.Status != cron.StatusFailed {
+		t.Errorf("Trigger(broken) = %+v, %v; want a failed run", run, err)
+	}
+}
+
+func TestScheduler_TasksListsDisabledTasks(t *testing.T) {
+	scheduler := cron.NewScheduler(nil, nil, nil, cron.Config{})
+	scheduler.Add(cron.Task{Name: "b", Schedule: "@daily", Enabled: true, Run: func(ctx context.Context) error { return nil }})
+	scheduler.Add(cron.Task{Name: "a", Schedule: "@daily", Run: func(ctx context.Context) error { return nil }})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(stopped)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for scheduler.Tasks()[1].NextRun.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	tasks := scheduler.Tasks()
+	if len(tasks) != 2 || tasks[0].Name != "a" || tasks[0].Enabled || !tasks[0].NextRun.IsZero() {
+		t.Errorf("tasks = %+v, want disabled task a without a next run first", tasks)
+	}
+	if !tasks[1].Enabled || tasks[1].NextRun.IsZero() {
+		t.Errorf("task b = %+v, want it scheduled", tasks[1])
+	}
+
+	cancel()
+	<-stopped
+}
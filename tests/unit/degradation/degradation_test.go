@@ -0,0 +1,57 @@
+package degradation_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/degradation"
+)
+
+func TestManager_ChatModel(t *testing.T) {
+	m := degradation.NewManager(degradation.Config{MaxFailures: 2, Cooldown: 50 * time.Millisecond, FallbackModel: "gpt-4o-mini"})
+	openAI := m.Health(degradation.OpenAI)
+
+	if model, fallback := m.ChatModel("gpt-4.1"); model != "gpt-4.1" || fallback {
+		t.Fatalf("ChatModel() = %q, %v while healthy, want the primary model", model, fallback)
+	}
+
+	openAI.Report(errors.New("503 Service Unavailable"))
+	openAI.Report(errors.New("503 Service Unavailable"))
+	if model, fallback := m.ChatModel("gpt-4.1"); model != "gpt-4o-mini" || !fallback || !openAI.Degraded() {
+		t.Fatalf("ChatModel() = %q, %v after failures, want the fallback model", model, fallback)
+	}
+	if !m.Health(degradation.Weather).Available() || !m.Health(degradation.Redis).Available() {
+		t.Error("an OpenAI outage degraded other dependencies")
+	}
+
+	// After the cooldown the primary model is tried again, and a success restores it
+	time.Sleep(60 * time.Millisecond)
+	if model, _ := m.ChatModel("gpt-4.1"); model != "gpt-4.1" {
+		t.Fatalf("ChatModel() = %q after the cooldown, want the primary model tried again", model)
+	}
+	openAI.Report(nil)
+	if openAI.Degraded() {
+		t.Error("OpenAI still degraded after a successful call")
+	}
+}
+
+func TestManager_WithoutFallbackModel(t *testing.T) {
+	m := degradation.NewManager(degradation.Config{MaxFailures: 1, Cooldown: time.Minute})
+	m.Health(degradation.OpenAI).Report(errors.New("timeout"))
+	if model, fallback := m.ChatModel("gpt-4.1"); model != "gpt-4.1" || fallback {
+		t.Errorf("ChatModel() = %q, %v, want the primary model without a fallback configured", model, fallback)
+	}
+}
+
+func TestManager_Nil(t *testing.T) {
+	var m *degradation.Manager
+	health := m.Health(degradation.Redis)
+	health.Report(errors.New("connection refused"))
+	if !health.Available() || health.Degraded() {
+		t.Error("a nil manager degraded a dependency")
+	}
+	if model, fallback := m.ChatModel("gpt-4.1"); model != "gpt-4.1" || fallback {
+		t.Errorf("ChatModel() = %q, %v, want the primary model", model, fallback)
+	}
+}
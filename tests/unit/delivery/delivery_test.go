@@ -0,0 +1,196 @@
+package delivery_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/circuitbreaker"
+	"github.com/8adimka/Go_AI_Assistant/internal/delivery"
+	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+type memoryRepository struct {
+	mu       sync.Mutex
+	messages map[string]delivery.Message
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{messages: map[string]delivery.Message{}}
+}
+
+func (r *memoryRepository) SaveMessage(ctx context.Context, m *delivery.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages[m.ID.Hex()] = *m
+	return nil
+}
+
+func (r *memoryRepository) GetMessage(ctx context.Context, id string) (*delivery.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.messages[id]
+	if !ok || m.TenantID != tenant.FromContext(ctx) {
+		return nil, delivery.ErrNotFound
+	}
+	return &m, nil
+}
+
+func (r *memoryRepository) ListMessages(ctx context.Context, q delivery.Query) ([]*delivery.Message, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) MarkDelivered(ctx context.Context, channel, externalID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, m := range r.messages {
+		if m.Channel == channel && m.ExternalID == externalID && m.Status == delivery.StatusSent {
+			m.Status = delivery.StatusDelivered
+			m.DeliveredAt = at
+			r.messages[id] = m
+			return nil
+		}
+	}
+	return delivery.ErrNotFound
+}
+
+type flakySender struct {
+	mu    sync.Mutex
+	errs  []error
+	calls int
+}
+
+func (s *flakySender) Send(ctx context.Context, recipient, text string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return "", err
+	}
+	return "ext-1", nil
+}
+
+func newService(repo delivery.Repository) *delivery.Service {
+	return delivery.NewService(repo, delivery.Config{
+		Retry:   retry.RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Breaker: circuitbreaker.Config{MaxFailures: 10, CooldownPeriod: time.Minute},
+	})
+}
+
+func waitForStatus(t *testing.T, svc *delivery.Service, ctx context.Context, id string) *delivery.Message {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		m, err := svc.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if m.Status != delivery.StatusQueued {
+			return m
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("message was not sent")
+	return nil
+}
+
+func TestService_RetriesTransientFailures(t *testing.T) {
+	repo := newMemoryRepository()
+	svc := newService(repo)
+	sender := &flakySender{errs: []error{&delivery.StatusError{Channel: "telegram", Code: http.StatusServiceUnavailable}}}
+	svc.Register("telegram", sender)
+
+	ctx, cancel := context.WithCancel(tenant.WithTenant(context.Background(), "acme"))
+	defer cancel()
+	go svc.Run(ctx)
+
+	m, err := svc.Send(ctx, "telegram", "100", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if m.Status != delivery.StatusQueued {
+		t.Fatalf("status = %s, want queued", m.Status)
+	}
+
+	m = waitForStatus(t, svc, ctx, m.ID.Hex())
+	if m.Status != delivery.StatusSent || m.Attempts != 2 || m.ExternalID != "ext-1" || m.SentAt.IsZero() {
+		t.Fatalf("unexpected message %+v", m)
+	}
+
+	if err := svc.MarkDelivered(ctx, "telegram", "ext-1"); err != nil {
+		t.Fatalf("MarkDelivered() error = %v", err)
+	}
+	if m, _ = svc.Get(ctx, m.ID.Hex()); m.Status != delivery.StatusDelivered {
+		t.Errorf("status = %s, want delivered", m.Status)
+	}
+
+	if _, err := svc.Get(tenant.WithTenant(context.Background(), "other"), m.ID.Hex()); !errors.Is(err, delivery.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another tenant, got %v", err)
+	}
+}
+
+func TestService_DoesNotRetryRejectedMessages(t *testing.T) {
+	svc := newService(newMemoryRepository())
+	sender := &flakySender{errs: []error{&delivery.StatusError{Channel: "telegram", Code: http.StatusBadRequest}}}
+	svc.Register("telegram", sender)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx)
+
+	m, err := svc.Send(ctx, "telegram", "100", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	m = waitForStatus(t, svc, ctx, m.ID.Hex())
+	if m.Status != delivery.StatusFailed || m.Attempts != 1 || m.Error == "" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+}
+
+func TestService_UnknownChannel(t *testing.T) {
+	svc := newService(newMemoryRepository())
+	if _, err := svc.Send(context.Background(), "whatsapp", "100", "hello"); !errors.Is(err, delivery.ErrUnknownChannel) {
+		t.Errorf("expected ErrUnknownChannel, got %v", err)
+	}
+}
+
+func TestTelegramSender(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botTOKEN/sendMessage" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"ok":true,"result":{"message_id":42}}`))
+	}))
+	defer server.Close()
+
+	sender := delivery.NewTelegramSender("TOKEN", server.URL, server.Client())
+	id, err := sender.Send(context.Background(), "100", "hello")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if id != "42" || got["chat_id"] != "100" || got["text"] != "hello" {
+		t.Errorf("id = %q, body = %v", id, got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+
+	_, err = delivery.NewTelegramSender("TOKEN", failing.URL, failing.Client()).Send(context.Background(), "100", "hello")
+	var statusErr *delivery.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode() != http.StatusTooManyRequests {
+		t.Errorf("expected a 429 StatusError, got %v", err)
+	}
+}
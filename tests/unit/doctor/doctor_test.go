@@ -0,0 +1,169 @@
+package doctor_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/8adimka/Go_AI_Assistant/internal/doctor"
+	"github.com/openai/openai-go/option"
+)
+
+func TestRun_ReportsEachCheck(t *testing.T) {
+	checks := []doctor.Check{
+		{Name: "fast", Run: func(ctx context.Context) (string, string) { return doctor.StatusOK, "fine" }},
+		{Name: "slow", Run: func(ctx context.Context) (string, string) {
+			<-ctx.Done()
+			return doctor.StatusFail, "timed out"
+		}},
+		{Name: "optional", Run: func(ctx context.Context) (string, string) { return doctor.StatusSkip, "not set" }},
+	}
+
+	results := doctor.Run(context.Background(), checks, 20*time.Millisecond)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if results[1].Status != doctor.StatusFail || results[1].Duration < 20*time.Millisecond {
+		t.Errorf("slow check = %+v, want a failure after the timeout", results[1])
+	}
+	if !doctor.Failed(results) {
+		t.Error("Failed() = false, want true")
+	}
+	if doctor.Failed(results[:1]) {
+		t.Error("Failed() = true for passing checks")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	results := []doctor.Result{
+		{Name: "config", Status: doctor.StatusWarn, Detail: "first\nsecond"},
+		{Name: "redis", Status: doctor.StatusOK, Detail: "connected"},
+	}
+
+	var buf bytes.Buffer
+	if err := doctor.WriteReport(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	report := buf.String()
+	for _, want := range []string{"WARN", "first", "second", "connected", "1 ok, 1 warnings, 0 failed, 0 skipped: ready"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("WEATHER_API_KEY", "weather")
+	t.Setenv("TAKEOUT_SIGNING_KEY", "signing-key")
+	cfg := config.Load()
+
+	if problems, warnings := doctor.ValidateConfig(cfg); len(problems) > 0 || len(warnings) > 0 {
+		t.Fatalf("ValidateConfig() = %v, %v, want no findings for the defaults", problems, warnings)
+	}
+
+	cfg.CacheCodec = "xml"
+	cfg.CronBillingExport = "0 0 30 2 *"
+	cfg.ObjectStoreBackend = "s3"
+	cfg.ObjectStoreS3Bucket = ""
+	cfg.StorageProfiles = map[string]config.StorageProfile{"eu": {MongoURI: "mongodb://mongo-eu:27017"}}
+	cfg.TenantStorageProfiles = map[string]string{"acme": "eu", "globex": "us"}
+	cfg.TenantIDs = []string{"acme"}
+	cfg.TenantAccessKeys = map[string]string{"globex": "secret"}
+	cfg.CORSAllowedOrigins = []string{"*"}
+	cfg.CORSAllowCredentials = true
+	problems, warnings := doctor.ValidateConfig(cfg)
+	if len(problems) != 6 {
+		t.Fatalf("ValidateConfig() problems = %v, want 6", problems)
+	}
+	// acme is trusted on its header alone
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "acme") {
+		t.Errorf("ValidateConfig() warnings = %v, want one about acme", warnings)
+	}
+
+	// A tenant with a storage profile that no request can act as
+	cfg.TenantIDs = nil
+	if problems, _ := doctor.ValidateConfig(cfg); len(problems) != 7 {
+		t.Errorf("ValidateConfig() problems = %v, want 7", problems)
+	}
+}
+
+func TestOpenAICheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"Incorrect API key","type":"invalid_request_error"}}`))
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o-mini","object":"model"}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		key    string
+		models []string
+		want   string
+	}{
+		{"valid", "good", []string{"gpt-4o-mini"}, doctor.StatusOK},
+		{"missing model", "good", []string{"gpt-4o-mini", "gpt-5"}, doctor.StatusWarn},
+		{"rejected key", "bad", nil, doctor.StatusFail},
+		{"no key", "", nil, doctor.StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := doctor.OpenAICheck(tt.key, tt.models, option.WithBaseURL(server.URL))
+			if status, detail := check.Run(context.Background()); status != tt.want {
+				t.Errorf("status = %s (%s), want %s", status, detail, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeatherCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "good" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	if status, detail := doctor.WeatherCheck("good", server.URL, server.Client()).Run(context.Background()); status != doctor.StatusOK {
+		t.Errorf("valid key: status = %s (%s)", status, detail)
+	}
+	if status, _ := doctor.WeatherCheck("bad", server.URL, server.Client()).Run(context.Background()); status != doctor.StatusFail {
+		t.Errorf("rejected key: status = %s, want fail", status)
+	}
+	if status, _ := doctor.WeatherCheck("", server.URL, server.Client()).Run(context.Background()); status != doctor.StatusWarn {
+		t.Errorf("no key: status = %s, want warn", status)
+	}
+
+	// Transport errors must not leak the key carried in the URL
+	status, detail := doctor.WeatherCheck("secret-key", "http://127.0.0.1:1", http.DefaultClient).Run(context.Background())
+	if status != doctor.StatusFail || strings.Contains(detail, "secret-key") {
+		t.Errorf("unreachable: status = %s, detail = %q", status, detail)
+	}
+}
+
+func TestHolidaysCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:1\r\nSUMMARY:New Year\r\n" +
+			"DTSTART;VALUE=DATE:20250101\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	}))
+	defer server.Close()
+
+	if status, detail := doctor.HolidaysCheck(server.URL, server.Client()).Run(context.Background()); status != doctor.StatusOK {
+		t.Errorf("status = %s (%s), want ok", status, detail)
+	}
+	if status, _ := doctor.HolidaysCheck("", server.Client()).Run(context.Background()); status != doctor.StatusSkip {
+		t.Errorf("no link: status = %s, want skip", status)
+	}
+}
@@ -0,0 +1,90 @@
+package embeddings_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/embeddings"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+)
+
+// fakeProvider embeds a text as its length and records every request
+type fakeProvider struct {
+	requests [][]string
+	err      error
+}
+
+func (p *fakeProvider) CreateEmbeddings(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.requests = append(p.requests, texts)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text)), 1}
+	}
+	return vectors, nil
+}
+
+type batchRecorder struct {
+	sizes []int
+}
+
+func (r *batchRecorder) RecordEmbeddingBatch(ctx context.Context, model string, texts int) {
+	r.sizes = append(r.sizes, texts)
+}
+
+func TestService_BatchesAndCaches(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	recorder := &batchRecorder{}
+	cache := redisx.NewMemoryCache(time.Hour, 0)
+	service := embeddings.NewService(provider, cache, recorder, embeddings.Config{Model: "small", BatchSize: 2})
+
+	texts := []string{"a", "bb", "a", "ccc", "dddd"}
+	vectors, err := service.Embed(ctx, texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	for i, text := range texts {
+		if vectors[i][0] != float32(len(text)) {
+			t.Errorf("vector %d = %v, want the embedding of %q", i, vectors[i], text)
+		}
+	}
+	// The repeated "a" is embedded once, and four distinct texts take two requests of two
+	if !slices.Equal(recorder.sizes, []int{2, 2}) {
+		t.Errorf("batch sizes = %v, want [2 2]", recorder.sizes)
+	}
+
+	// Cached texts are not embedded again; only the new one is sent
+	vectors, err = service.Embed(ctx, []string{"bb", "eeeee", "a"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if last := provider.requests[len(provider.requests)-1]; len(provider.requests) != 3 || !slices.Equal(last, []string{"eeeee"}) {
+		t.Errorf("requests = %v, want only eeeee embedded", provider.requests)
+	}
+	if vectors[0][0] != 2 || vectors[1][0] != 5 || vectors[2][0] != 1 {
+		t.Errorf("Embed() = %v, want cached and new embeddings in input order", vectors)
+	}
+
+	// Another model does not reuse the cache
+	other := embeddings.NewService(provider, cache, nil, embeddings.Config{Model: "large"})
+	if _, err := other.EmbedOne(ctx, "a"); err != nil || len(provider.requests) != 4 {
+		t.Errorf("EmbedOne() error = %v after %d requests, want a new request for another model", err, len(provider.requests))
+	}
+}
+
+func TestService_ProviderError(t *testing.T) {
+	provider := &fakeProvider{err: errors.New("rate limited")}
+	service := embeddings.NewService(provider, nil, nil, embeddings.Config{Model: "small"})
+	if _, err := service.Embed(context.Background(), []string{"a"}); err == nil {
+		t.Error("Embed() succeeded, want the provider error")
+	}
+	if vectors, err := service.Embed(context.Background(), nil); err != nil || len(vectors) != 0 {
+		t.Errorf("Embed(nil) = %v, %v, want no vectors", vectors, err)
+	}
+}
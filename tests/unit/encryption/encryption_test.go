@@ -0,0 +1,201 @@
+package encryption_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/encryption"
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/gorilla/mux"
+)
+
+type memoryKeys struct {
+	stored map[string]*encryption.TenantKeys
+}
+
+func (r *memoryKeys) GetKeys(ctx context.Context, tenantID string) (*encryption.TenantKeys, error) {
+	keys, ok := r.stored[tenantID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *keys
+	copied.Keys = append([]encryption.DataKey(nil), keys.Keys...)
+	return &copied, nil
+}
+
+func (r *memoryKeys) SaveKeys(ctx context.Context, keys *encryption.TenantKeys, previous int) error {
+	if current, ok := r.stored[keys.TenantID]; ok && (current.Active != previous || current.Revoked()) {
+		return encryption.ErrConcurrentChange
+	}
+	r.stored[keys.TenantID] = keys
+	return nil
+}
+
+func (r *memoryKeys) RevokedTenants(ctx context.Context) ([]string, error) {
+	var tenants []string
+	for id, keys := range r.stored {
+		if keys.Revoked() {
+			tenants = append(tenants, id)
+		}
+	}
+	return tenants, nil
+}
+
+type memoryAudit struct {
+	entries []*audit.Entry
+}
+
+func (a *memoryAudit) Record(ctx context.Context, entry *audit.Entry) error {
+	entry.TenantID = tenant.FromContext(ctx)
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func newManager(t *testing.T) (*encryption.Manager, *memoryKeys, *memoryAudit) {
+	t.Helper()
+	kms, err := secrets.NewLocalKMS(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, secrets.KeySize)))
+	if err != nil {
+		t.Fatalf("NewLocalKMS() error = %v", err)
+	}
+	repo := &memoryKeys{stored: map[string]*encryption.TenantKeys{}}
+	recorder := &memoryAudit{}
+	return encryption.NewManager(repo, kms, recorder, time.Minute), repo, recorder
+}
+
+func TestManager_SealsWithTheActiveKey(t *testing.T) {
+	keys, repo, _ := newManager(t)
+	ctx := context.Background()
+
+	clear, err := keys.Encrypt(ctx, "acme", "hello")
+	if err != nil || clear != "hello" {
+		t.Fatalf("Encrypt() without keys = %q, %v, want the plaintext", clear, err)
+	}
+
+	if _, err := keys.Rotate(ctx, "acme", nil, "admin:alice"); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if strings.Contains(repo.stored["acme"].Keys[0].WrappedKey, "hello") || repo.stored["acme"].Keys[0].MasterKeyID == "" {
+		t.Fatalf("stored key = %+v, want a wrapped key naming its master key", repo.stored["acme"].Keys[0])
+	}
+	v1, err := keys.Encrypt(ctx, "acme", "hello")
+	if err != nil || !strings.HasPrefix(v1, "enc:v1:") {
+		t.Fatalf("Encrypt() = %q, %v, want a value sealed with v1", v1, err)
+	}
+
+	// Rotating to a key the tenant brings keeps earlier data readable
+	own := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{9}, secrets.KeySize))
+	material, _ := base64.StdEncoding.DecodeString(own)
+	status, err := keys.Rotate(ctx, "acme", material, "admin:alice")
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if status.ActiveVersion != 2 || len(status.Keys) != 2 || status.Keys[1].Source != encryption.SourceImported {
+		t.Errorf("status = %+v, want v2 imported next to v1", status)
+	}
+	v2, _ := keys.Encrypt(ctx, "acme", "hello")
+	if !strings.HasPrefix(v2, "enc:v2:") {
+		t.Errorf("Encrypt() after rotation = %q, want v2", v2)
+	}
+	for _, sealed := range []string{v1, v2, "stored before encryption"} {
+		want := sealed
+		if sealed != "stored before encryption" {
+			want = "hello"
+		}
+		if got, err := keys.Decrypt(ctx, "acme", sealed); err != nil || got != want {
+			t.Errorf("Decrypt(%q) = %q, %v, want %q", sealed, got, err, want)
+		}
+	}
+
+	// Values of one tenant never open with the keys of another
+	if _, err := keys.Rotate(ctx, "globex", nil, ""); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := keys.Decrypt(ctx, "globex", v1); !errors.Is(err, secrets.ErrInvalidCiphertext) {
+		t.Errorf("Decrypt() with another tenant = %v, want ErrInvalidCiphertext", err)
+	}
+
+	if _, err := keys.Rotate(ctx, "acme", []byte("short"), ""); !errors.Is(err, encryption.ErrInvalidKey) {
+		t.Errorf("Rotate() with short material = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestManager_RevokeDeniesDecryption(t *testing.T) {
+	keys, repo, recorder := newManager(t)
+	ctx := context.Background()
+
+	keys.Rotate(ctx, "acme", nil, "admin:alice")
+	sealed, _ := keys.Encrypt(ctx, "acme", "hello")
+	if revoked, _ := keys.RevokedTenants(ctx); len(revoked) != 0 {
+		t.Fatalf("RevokedTenants() = %v, want none", revoked)
+	}
+
+	status, err := keys.Revoke(ctx, "acme", "admin:bob")
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if status.Enabled || status.RevokedAt == nil || len(repo.stored["acme"].Keys) != 0 {
+		t.Errorf("status = %+v, stored = %+v, want the keys destroyed", status, repo.stored["acme"])
+	}
+
+	if _, err := keys.Decrypt(ctx, "acme", sealed); !errors.Is(err, secrets.ErrKeyRevoked) {
+		t.Errorf("Decrypt() after revocation = %v, want ErrKeyRevoked", err)
+	}
+	if _, err := keys.Decrypt(ctx, "acme", "stored before encryption"); !errors.Is(err, secrets.ErrKeyRevoked) {
+		t.Errorf("Decrypt() of clear data after revocation = %v, want ErrKeyRevoked", err)
+	}
+	if _, err := keys.Encrypt(ctx, "acme", "hello"); !errors.Is(err, secrets.ErrKeyRevoked) {
+		t.Errorf("Encrypt() after revocation = %v, want ErrKeyRevoked", err)
+	}
+	if _, err := keys.Rotate(ctx, "acme", nil, ""); !errors.Is(err, secrets.ErrKeyRevoked) {
+		t.Errorf("Rotate() after revocation = %v, want ErrKeyRevoked", err)
+	}
+	if revoked, _ := keys.RevokedTenants(ctx); len(revoked) != 1 || revoked[0] != "acme" {
+		t.Errorf("RevokedTenants() = %v, want acme", revoked)
+	}
+
+	if len(recorder.entries) != 2 || recorder.entries[1].Action != encryption.ActionKeyRevoked ||
+		recorder.entries[1].TenantID != "acme" || recorder.entries[1].Actor != "admin:bob" {
+		t.Errorf("audit entries = %+v, want the rotation and revocation in the tenant's log", recorder.entries)
+	}
+}
+
+func TestAdminHandler_RevokeNeedsConfirmation(t *testing.T) {
+	keys, repo, _ := newManager(t)
+	keys.Rotate(context.Background(), "acme", nil, "")
+
+	router := mux.NewRouter()
+	handler := encryption.NewAdminHandler(keys)
+	router.HandleFunc("/admin/tenants/{tenant_id}/encryption/revoke", handler.RevokeHandler).Methods(http.MethodPost)
+	router.HandleFunc("/admin/tenants/{tenant_id}/encryption/rotate", handler.RotateHandler).Methods(http.MethodPost)
+
+	post := func(path, body string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := post("/admin/tenants/acme/encryption/revoke", `{"confirm":"globex"}`); code != http.StatusBadRequest {
+		t.Errorf("revoke with the wrong confirmation = %d, want 400", code)
+	}
+	if repo.stored["acme"].Revoked() {
+		t.Fatal("keys were revoked without confirmation")
+	}
+	if code := post("/admin/tenants/acme/encryption/rotate", ""); code != http.StatusOK {
+		t.Errorf("rotate without a body = %d, want 200", code)
+	}
+	if code := post("/admin/tenants/acme/encryption/revoke", `{"confirm":"acme"}`); code != http.StatusOK {
+		t.Errorf("confirmed revoke = %d, want 200", code)
+	}
+	if code := post("/admin/tenants/acme/encryption/rotate", `{}`); code != http.StatusConflict {
+		t.Errorf("rotate after revocation = %d, want 409", code)
+	}
+}
@@ -0,0 +1,80 @@
+package errorsx_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/errorsx"
+	"github.com/openai/openai-go"
+	"github.com/twitchtv/twirp"
+)
+
+// apiError builds an OpenAI API error as the client returns it; Error() needs the request and response
+func apiError(status int, code string, header http.Header) error {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil)
+	return fmt.Errorf("max retry attempts (3) reached, last error: %w", &openai.Error{
+		Code:       code,
+		StatusCode: status,
+		Request:    req,
+		Response:   &http.Response{StatusCode: status, Header: header},
+	})
+}
+
+func TestUpstreamTwirpError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      twirp.ErrorCode
+		errorCode string
+	}{
+		{"rate limited", apiError(http.StatusTooManyRequests, "rate_limit_exceeded", nil), twirp.ResourceExhausted, "provider_rate_limited"},
+		{"out of quota", apiError(http.StatusTooManyRequests, "insufficient_quota", nil), twirp.Unavailable, "provider_unavailable"},
+		{"content filtered", apiError(http.StatusBadRequest, "content_policy_violation", nil), twirp.InvalidArgument, "content_filtered"},
+		{"filtered reply", fmt.Errorf("reply cut off: %w", errorsx.ErrContentFiltered), twirp.InvalidArgument, "content_filtered"},
+		{"context too long", apiError(http.StatusBadRequest, "context_length_exceeded", nil), twirp.FailedPrecondition, "context_too_long"},
+		{"server error", apiError(http.StatusBadGateway, "", nil), twirp.Unavailable, "provider_unavailable"},
+		{"network error", &url.Error{Op: "Post", URL: "https://api.openai.com", Err: errors.New("connection refused")}, twirp.Unavailable, ""},
+		{"bad request", apiError(http.StatusBadRequest, "invalid_request_error", nil), twirp.Internal, ""},
+		{"other", errors.New("no choices returned by OpenAI"), twirp.Internal, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var te twirp.Error
+			if !errors.As(errorsx.UpstreamTwirpError(tt.err), &te) {
+				t.Fatal("UpstreamTwirpError() did not return a twirp error")
+			}
+			if te.Code() != tt.code {
+				t.Errorf("code = %s, want %s", te.Code(), tt.code)
+			}
+			if tt.errorCode != "" && te.Meta("error_code") != tt.errorCode {
+				t.Errorf("error_code = %q, want %q", te.Meta("error_code"), tt.errorCode)
+			}
+			if tt.code != twirp.Internal && strings.Contains(te.Msg(), "openai.com") {
+				t.Errorf("message %q leaks upstream details", te.Msg())
+			}
+		})
+	}
+}
+
+func TestUpstreamTwirpError_RetryAfter(t *testing.T) {
+	err := apiError(http.StatusTooManyRequests, "", http.Header{"Retry-After": []string{"20"}})
+
+	var te twirp.Error
+	if !errors.As(errorsx.UpstreamTwirpError(err), &te) || te.Meta("retry_after_seconds") != "20" {
+		t.Errorf("retry_after_seconds = %q, want 20", te.Meta("retry_after_seconds"))
+	}
+}
+
+func TestUpstreamTwirpError_KeepsTwirpErrors(t *testing.T) {
+	original := twirp.NewError(twirp.Aborted, "superseded")
+	if got := errorsx.UpstreamTwirpError(original); got != original {
+		t.Errorf("UpstreamTwirpError() = %v, want the original error", got)
+	}
+	if errorsx.UpstreamTwirpError(nil) != nil {
+		t.Error("UpstreamTwirpError(nil) != nil")
+	}
+}
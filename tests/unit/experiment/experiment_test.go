@@ -0,0 +1,104 @@
+package experiment_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/experiment"
+)
+
+type stubStore struct {
+	filter model.ConversationFilter
+	stats  []*model.VariantStats
+}
+
+func (s *stubStore) CompareVariants(ctx context.Context, f model.ConversationFilter) ([]*model.VariantStats, error) {
+	s.filter = f
+	return s.stats, nil
+}
+
+func TestAdminHandler_Compare(t *testing.T) {
+	store := &stubStore{stats: []*model.VariantStats{
+		{
+			Variant:          model.Variant{Persona: "friendly", PromptVersion: "v2", Model: "gpt-4.1"},
+			Replies:          4,
+			Reacted:          2,
+			AvgLatencyMs:     1200,
+			PromptTokens:     2000,
+			CompletionTokens: 1000,
+			Positive:         3,
+			Negative:         1,
+		},
+		{Variant: model.Variant{Model: "unpriced"}, Replies: 1},
+	}}
+	pricing := billing.Pricing{"gpt-4.1": {PromptPer1K: 0.002, CompletionPer1K: 0.008}}
+	handler := experiment.NewAdminHandler(store, pricing)
+
+	rec := httptest.NewRecorder()
+	handler.CompareHandler(rec, httptest.NewRequest(http.MethodGet,
+		"/admin/analytics/experiments?platform=web&since=2024-05-01T00:00:00Z", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if store.filter.Platform != "web" || store.filter.CreatedAfter.IsZero() || !store.filter.CreatedBefore.IsZero() {
+		t.Errorf("filter = %+v, want the platform and since of the query", store.filter)
+	}
+
+	var body struct {
+		Variants []struct {
+			Persona          string  `json:"persona"`
+			PromptVersion    string  `json:"prompt_version"`
+			Replies          int64   `json:"replies"`
+			EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+			CostPerReplyUSD  float64 `json:"cost_per_reply_usd"`
+			PositiveRate     float64 `json:"positive_rate"`
+			ReactionRate     float64 `json:"reaction_rate"`
+		} `json:"variants"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(body.Variants) != 2 {
+		t.Fatalf("got %d variants, want 2", len(body.Variants))
+	}
+	v := body.Variants[0]
+	if v.Persona != "friendly" || v.PromptVersion != "v2" || v.Replies != 4 {
+		t.Errorf("variant = %+v, want the stored variant", v)
+	}
+	if math.Abs(v.EstimatedCostUSD-0.012) > 1e-9 || math.Abs(v.CostPerReplyUSD-0.003) > 1e-9 {
+		t.Errorf("cost = %v (%v per reply), want 0.012 (0.003 per reply)", v.EstimatedCostUSD, v.CostPerReplyUSD)
+	}
+	if v.PositiveRate != 0.75 || v.ReactionRate != 0.5 {
+		t.Errorf("positive rate = %v, reaction rate = %v, want 0.75 and 0.5", v.PositiveRate, v.ReactionRate)
+	}
+	if body.Variants[1].EstimatedCostUSD != 0 || body.Variants[1].PositiveRate != 0 {
+		t.Errorf("unpriced variant without reactions = %+v, want zero cost and rate", body.Variants[1])
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CompareHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/analytics/experiments?until=yesterday", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid until: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	if experiment.FromContext(context.Background()) != nil {
+		t.Fatal("untracked context has a generation")
+	}
+	// Untracked replies add usage to nothing
+	experiment.FromContext(context.Background()).AddUsage(10, 5)
+
+	ctx, generation := experiment.Track(context.Background())
+	experiment.FromContext(ctx).AddUsage(10, 5)
+	experiment.FromContext(ctx).AddUsage(20, 5)
+	if generation.PromptTokens != 30 || generation.CompletionTokens != 10 {
+		t.Errorf("generation = %+v, want the usage of both completions", generation)
+	}
+}
@@ -0,0 +1,186 @@
+package followup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/delivery"
+	"github.com/8adimka/Go_AI_Assistant/internal/followup"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryConversations follows the filter of model.Repository.FindFollowUpCandidates
+type memoryConversations struct {
+	conversations []*model.Conversation
+	touched       map[primitive.ObjectID]bool // Active again after they were read
+}
+
+func (s *memoryConversations) FindFollowUpCandidates(_ context.Context, platform string, idleFrom, idleBefore, followedUpBefore time.Time, after primitive.ObjectID, limit int) ([]*model.Conversation, error) {
+	var page []*model.Conversation
+	for _, c := range s.conversations {
+		idle := !c.LastActivity.Before(idleFrom) && c.LastActivity.Before(idleBefore)
+		capped := !c.FollowUpAt.Before(followedUpBefore) || !c.FollowUpAt.Before(c.LastActivity)
+		if c.Platform == platform && idle && !capped && c.ID.Hex() > after.Hex() && len(page) < limit {
+			page = append(page, c)
+		}
+	}
+	return page, nil
+}
+
+func (s *memoryConversations) AddFollowUp(_ context.Context, c *model.Conversation, msg *model.Message) (bool, error) {
+	if s.touched[c.ID] {
+		return false, nil
+	}
+	c.Messages = append(c.Messages, msg)
+	c.FollowUpAt = msg.CreatedAt
+	return true, nil
+}
+
+type stubWriter struct {
+	texts map[primitive.ObjectID]string
+	err   error
+}
+
+func (w *stubWriter) Write(ctx context.Context, conv *model.Conversation) (string, error) {
+	if w.err != nil {
+		return "", w.err
+	}
+	if text, ok := w.texts[conv.ID]; ok {
+		return text, nil
+	}
+	return "Did you find a sunny day for the trip?", nil
+}
+
+type sent struct {
+	tenant, channel, recipient, text string
+}
+
+type recordingSender struct {
+	channels []string
+	sent     []sent
+}
+
+func (s *recordingSender) Supports(channel string) bool {
+	for _, c := range s.channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *recordingSender) Send(ctx context.Context, channel, recipient, text string) (*delivery.Message, error) {
+	s.sent = append(s.sent, sent{tenant: tenant.FromContext(ctx), channel: channel, recipient: recipient, text: text})
+	return &delivery.Message{Channel: channel, Recipient: recipient, Text: text}, nil
+}
+
+func conversation(platform, chatID string, lastActivity time.Time) *model.Conversation {
+	return &model.Conversation{
+		ID:           primitive.NewObjectID(),
+		TenantID:     "acme",
+		Platform:     platform,
+		ChatID:       chatID,
+		LastActivity: lastActivity,
+		Messages: []*model.Message{
+			{ID: primitive.NewObjectID(), Role: model.RoleUser, Content: "Weather in Rome on Saturday?"},
+			{ID: primitive.NewObjectID(), Role: model.RoleAssistant, Content: "Sunny, 24°C."},
+		},
+	}
+}
+
+var cfg = followup.Config{
+	IdleFor:   24 * time.Hour,
+	MaxIdle:   72 * time.Hour,
+	Platforms: map[string]time.Duration{"telegram": 72 * time.Hour},
+	BatchSize: 1,
+}
+
+func TestService_Run(t *testing.T) {
+	now := time.Now()
+	idle := conversation("telegram", "chat-1", now.Add(-30*time.Hour))
+	recent := conversation("telegram", "chat-2", now.Add(-time.Hour))
+	abandoned := conversation("telegram", "chat-3", now.Add(-100*time.Hour))
+	web := conversation("web", "chat-4", now.Add(-30*time.Hour))
+	finished := conversation("telegram", "chat-5", now.Add(-30*time.Hour))
+	returned := conversation("telegram", "chat-6", now.Add(-30*time.Hour))
+	store := &memoryConversations{
+		conversations: []*model.Conversation{idle, recent, abandoned, web, finished, returned},
+		touched:       map[primitive.ObjectID]bool{returned.ID: true},
+	}
+	sender := &recordingSender{channels: []string{"telegram", "web"}}
+	writer := &stubWriter{texts: map[primitive.ObjectID]string{finished.ID: ""}}
+
+	result, err := followup.NewService(store, writer, sender, cfg).Run(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Sent != 1 || result.Skipped != 2 || result.Failed != 0 {
+		t.Errorf("result = %+v, want 1 sent and 2 skipped", result)
+	}
+	if len(sender.sent) != 1 || sender.sent[0] != (sent{"acme", "telegram", "chat-1", "Did you find a sunny day for the trip?"}) {
+		t.Errorf("sent = %+v, want the follow-up of the idle telegram conversation", sender.sent)
+	}
+	last := idle.Messages[len(idle.Messages)-1]
+	if last.Role != model.RoleAssistant || last.Content != sender.sent[0].text || !idle.FollowUpAt.Equal(now) {
+		t.Errorf("stored follow-up = %+v at %v, want the sent message", last, idle.FollowUpAt)
+	}
+
+	// One follow-up per idle period, however often the job runs
+	result, err = followup.NewService(store, writer, sender, cfg).Run(context.Background(), now.Add(time.Hour))
+	if err != nil || result.Sent != 0 {
+		t.Errorf("second run = %+v, %v, want nothing sent", result, err)
+	}
+}
+
+func TestService_Run_FrequencyCap(t *testing.T) {
+	now := time.Now()
+	conv := conversation("telegram", "chat-1", now.Add(-30*time.Hour))
+	// The user answered the previous follow-up, then went idle again
+	conv.FollowUpAt = now.Add(-40 * time.Hour)
+	store := &memoryConversations{conversations: []*model.Conversation{conv}}
+	sender := &recordingSender{channels: []string{"telegram"}}
+
+	result, err := followup.NewService(store, &stubWriter{}, sender, cfg).Run(context.Background(), now)
+	if err != nil || result.Sent != 0 {
+		t.Errorf("Run() = %+v, %v, want the conversation capped", result, err)
+	}
+
+	uncapped := cfg
+	uncapped.Platforms = map[string]time.Duration{"telegram": 24 * time.Hour}
+	result, err = followup.NewService(store, &stubWriter{}, sender, uncapped).Run(context.Background(), now)
+	if err != nil || result.Sent != 1 {
+		t.Errorf("Run() = %+v, %v, want a follow-up once the cap passed", result, err)
+	}
+}
+
+func TestService_Run_WriterFailure(t *testing.T) {
+	now := time.Now()
+	conv := conversation("telegram", "chat-1", now.Add(-30*time.Hour))
+	store := &memoryConversations{conversations: []*model.Conversation{conv}}
+	sender := &recordingSender{channels: []string{"telegram"}}
+
+	result, err := followup.NewService(store, &stubWriter{err: errors.New("openai down")}, sender, cfg).Run(context.Background(), now)
+	if err == nil || result.Failed != 1 || len(sender.sent) != 0 {
+		t.Errorf("Run() = %+v, %v, want a failure and nothing sent", result, err)
+	}
+	if !conv.FollowUpAt.IsZero() {
+		t.Error("failed follow-up was recorded, it would not be retried")
+	}
+}
+
+func TestParsePlatforms(t *testing.T) {
+	platforms, err := followup.ParsePlatforms(map[string]string{"telegram": "72", "slack": "0"})
+	if err != nil {
+		t.Fatalf("ParsePlatforms() error = %v", err)
+	}
+	if platforms["telegram"] != 72*time.Hour || platforms["slack"] != 0 {
+		t.Errorf("platforms = %v", platforms)
+	}
+	if _, err := followup.ParsePlatforms(map[string]string{"telegram": "3d"}); err == nil {
+		t.Error("expected an error for an interval that is not hours")
+	}
+}
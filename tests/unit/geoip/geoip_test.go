@@ -0,0 +1,92 @@
+package geoip_test
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/geoip"
+)
+
+// mmdbString encodes a short string field of the MaxMind DB data format
+func mmdbString(s string) []byte {
+	return append([]byte{0x40 | byte(len(s))}, s...)
+}
+
+// mmdbMap encodes the header of a map with n entries
+func mmdbMap(n int) []byte {
+	return []byte{0xe0 | byte(n)}
+}
+
+// mmdbUint16 encodes a uint16 field
+func mmdbUint16(v uint16) []byte {
+	return []byte{0xa2, byte(v >> 8), byte(v)}
+}
+
+// testDatabase builds an IPv4 database with 24-bit records and a single node:
+// 0.0.0.0/1 resolves to DE, 128.0.0.0/1 has no data; metaNodes is the node count its metadata claims
+func testDatabase(metaNodes byte) []byte {
+	const nodeCount = 1
+	var db []byte
+
+	// Search tree: left record points to data offset 0, right record is the empty value
+	left := nodeCount + 16
+	db = append(db, byte(left>>16), byte(left>>8), byte(left))
+	db = append(db, 0, 0, nodeCount)
+	db = append(db, make([]byte, 16)...)
+
+	// Data section: {"country": {"iso_code": "DE"}}
+	db = append(db, mmdbMap(1)...)
+	db = append(db, mmdbString("country")...)
+	db = append(db, mmdbMap(1)...)
+	db = append(db, mmdbString("iso_code")...)
+	db = append(db, mmdbString("DE")...)
+
+	// Metadata
+	db = append(db, "\xab\xcd\xefMaxMind.com"...)
+	db = append(db, mmdbMap(3)...)
+	db = append(db, mmdbString("node_count")...)
+	db = append(db, 0xc1, metaNodes) // uint32
+	db = append(db, mmdbString("record_size")...)
+	db = append(db, mmdbUint16(24)...)
+	db = append(db, mmdbString("ip_version")...)
+	db = append(db, mmdbUint16(4)...)
+	return db
+}
+
+func TestReader_Country(t *testing.T) {
+	reader, err := geoip.New(testDatabase(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"81.2.69.142", "DE"},
+		{"::ffff:81.2.69.142", "DE"},
+		{"203.0.113.7", ""},
+		{"2001:db8::1", ""},
+	}
+	for _, tt := range tests {
+		got, err := reader.Country(netip.MustParseAddr(tt.ip))
+		if err != nil {
+			t.Errorf("Country(%s) error = %v", tt.ip, err)
+		}
+		if got != tt.want {
+			t.Errorf("Country(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestNew_InvalidDatabase(t *testing.T) {
+	if _, err := geoip.New([]byte("not a database")); !errors.Is(err, geoip.ErrInvalidDatabase) {
+		t.Errorf("New() error = %v, want ErrInvalidDatabase", err)
+	}
+
+	// Metadata claiming a search tree larger than the file
+	if _, err := geoip.New(testDatabase(200)); !errors.Is(err, geoip.ErrInvalidDatabase) {
+		t.Errorf("New() with oversized tree error = %v, want ErrInvalidDatabase", err)
+	}
+}
@@ -0,0 +1,338 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/billing"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/graphql"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeConversations struct {
+	mu            sync.Mutex
+	conversations []*model.Conversation
+	finds         [][]string
+}
+
+func (f *fakeConversations) RecentUserConversations(ctx context.Context, platform, userID string, limit int) ([]*model.Conversation, error) {
+	var found []*model.Conversation
+	for _, c := range f.conversations {
+		if c.Platform == platform && c.UserID == userID {
+			found = append(found, c)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].LastActivity.After(found[j].LastActivity) })
+	return found[:min(len(found), limit)], nil
+}
+
+func (f *fakeConversations) FindConversations(ctx context.Context, ids []string) ([]*model.Conversation, error) {
+	f.mu.Lock()
+	f.finds = append(f.finds, ids)
+	f.mu.Unlock()
+
+	var found []*model.Conversation
+	for _, c := range f.conversations {
+		for _, id := range ids {
+			if c.ID.Hex() == id {
+				found = append(found, c)
+			}
+		}
+	}
+	return found, nil
+}
+
+type fakeUsage struct {
+	mu    sync.Mutex
+	usage map[string]*billing.ConversationUsage
+	sums  [][]string
+}
+
+func (f *fakeUsage) SumConversationUsage(ctx context.Context, ids []string) (map[string]*billing.ConversationUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sums = append(f.sums, ids)
+	return f.usage, nil
+}
+
+type fakeSettings struct {
+	mu    sync.Mutex
+	reads int
+	err   error
+}
+
+func (f *fakeSettings) Get(ctx context.Context, platform, userID string) (*settings.Settings, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &settings.Settings{Platform: platform, UserID: userID, Language: "es"}, nil
+}
+
+func message(role model.Role, content string) *model.Message {
+	return &model.Message{ID: primitive.NewObjectID(), Role: role, Content: content, CreatedAt: time.Now()}
+}
+
+type fixture struct {
+	handler       *graphql.Handler
+	conversations *fakeConversations
+	usage         *fakeUsage
+	settings      *fakeSettings
+	older, newer  *model.Conversation
+}
+
+func newFixture() *fixture {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	older := &model.Conversation{ID: primitive.NewObjectID(), Title: "Weather", Platform: "web", UserID: "user-1",
+		CreatedAt: now.Add(-2 * time.Hour), LastActivity: now.Add(-time.Hour),
+		Messages: []*model.Message{message(model.RoleUser, "Weather in Paris?"), message(model.RoleAssistant, "Sunny")}}
+	newer := &model.Conversation{ID: primitive.NewObjectID(), Platform: "web", UserID: "user-1",
+		CreatedAt: now.Add(-time.Hour), LastActivity: now,
+		Messages: []*model.Message{
+			message(model.RoleUser, "Plan a trip"),
+			message(model.RoleAssistant, "Where to?"),
+			message(model.RoleUser, "Rome"),
+			message(model.RoleAssistant, "Day one: the Colosseum"),
+			message(model.RoleSystem, "Customer is a VIP"),
+			message(model.RoleAssistant, "Day two: the Vatican"),
+		}}
+
+	f := &fixture{
+		conversations: &fakeConversations{conversations: []*model.Conversation{older, newer}},
+		usage: &fakeUsage{usage: map[string]*billing.ConversationUsage{
+			newer.ID.Hex(): {ConversationID: newer.ID.Hex(), Requests: 2, PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150},
+		}},
+		settings: &fakeSettings{},
+		older:    older,
+		newer:    newer,
+	}
+	f.handler = graphql.NewHandler(f.conversations, f.usage, f.settings)
+	return f
+}
+
+type response struct {
+	Data   map[string]any   `json:"data"`
+	Errors []map[string]any `json:"errors"`
+}
+
+func (f *fixture) query(t *testing.T, query string, variables map[string]any) (int, response) {
+	t.Helper()
+	body, err := json.Marshal(graphql.Request{Query: query, Variables: variables})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, graphql.Path, strings.NewReader(string(body))))
+
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response %q", rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+func TestGraphQL_ConversationsInOneRequest(t *testing.T) {
+	f := newFixture()
+	status, resp := f.query(t, `
+		query Inbox($user: String!, $first: Int) {
+			inbox: conversations(platform: "web", userId: $user, first: $first) {
+				...summary
+				usage { totalTokens }
+				settings { language units }
+			}
+		}
+		fragment summary on Conversation {
+			id
+			title
+			lastActivity
+			unreadCount
+			lastMessage { role content }
+		}`, map[string]any{"user": "user-1", "first": 5})
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("got status %d, errors %v", status, resp.Errors)
+	}
+
+	inbox := resp.Data["inbox"].([]any)
+	if len(inbox) != 2 {
+		t.Fatalf("got %d conversations, want 2", len(inbox))
+	}
+	newer, older := inbox[0].(map[string]any), inbox[1].(map[string]any)
+	if newer["id"] != f.newer.ID.Hex() || newer["lastActivity"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("first conversation = %v, want the most recently active", newer)
+	}
+	if newer["title"] != nil || older["title"] != "Weather" {
+		t.Errorf("titles = %v and %v, want null without a title", newer["title"], older["title"])
+	}
+	// Replies after the user's last message; operator notes are not replies
+	if newer["unreadCount"] != 2.0 || older["unreadCount"] != 1.0 {
+		t.Errorf("unread counts = %v and %v, want 2 and 1", newer["unreadCount"], older["unreadCount"])
+	}
+	if last := newer["lastMessage"].(map[string]any); last["role"] != "ASSISTANT" || last["content"] != "Day two: the Vatican" {
+		t.Errorf("lastMessage = %v", last)
+	}
+	if newer["usage"].(map[string]any)["totalTokens"] != 150.0 || older["usage"].(map[string]any)["totalTokens"] != 0.0 {
+		t.Errorf("usage = %v and %v, want zero without recorded usage", newer["usage"], older["usage"])
+	}
+	if s := older["settings"].(map[string]any); s["language"] != "es" || s["units"] != nil {
+		t.Errorf("settings = %v, want unset preferences null", s)
+	}
+
+	if len(f.usage.sums) != 1 || len(f.usage.sums[0]) != 2 {
+		t.Errorf("usage sums = %v, want one for both conversations", f.usage.sums)
+	}
+	if f.settings.reads != 1 {
+		t.Errorf("settings reads = %d, want one for the user of both conversations", f.settings.reads)
+	}
+}
+
+func TestGraphQL_ConversationsByIDAreBatched(t *testing.T) {
+	f := newFixture()
+	status, resp := f.query(t, `query ($a: ID!, $b: ID!) {
+		a: conversation(id: $a) { id messages(last: 1) { content } }
+		b: conversation(id: $b) { id messageCount __typename }
+		missing: conversation(id: "000000000000000000000000") { id }
+	}`, map[string]any{"a": f.older.ID.Hex(), "b": f.newer.ID.Hex()})
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("got status %d, errors %v", status, resp.Errors)
+	}
+
+	a, b := resp.Data["a"].(map[string]any), resp.Data["b"].(map[string]any)
+	if a["id"] != f.older.ID.Hex() || b["id"] != f.newer.ID.Hex() || resp.Data["missing"] != nil {
+		t.Errorf("data = %v", resp.Data)
+	}
+	if messages := a["messages"].([]any); len(messages) != 1 || messages[0].(map[string]any)["content"] != "Sunny" {
+		t.Errorf("last messages = %v", messages)
+	}
+	if b["messageCount"] != 6.0 || b["__typename"] != "Conversation" {
+		t.Errorf("conversation b = %v", b)
+	}
+	if len(f.conversations.finds) != 1 || len(f.conversations.finds[0]) != 3 {
+		t.Errorf("finds = %v, want one for all three IDs", f.conversations.finds)
+	}
+}
+
+func TestGraphQL_RejectedQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"invalid", `{ conversation(id: "1") { secret } }`, `Cannot query field "secret" on type "Conversation"`},
+		{"mutation", `mutation { conversation(id: "1") { id } }`, "no mutations are offered"},
+		{"too long", `{ conversation(id: "` + strings.Repeat("1", 9000) + `") { id } }`, "exceeds the maximum allowed query length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			status, resp := f.query(t, tt.query, nil)
+			if status != http.StatusBadRequest || resp.Data != nil || len(resp.Errors) == 0 {
+				t.Fatalf("got status %d, response %v, want 400 without data", status, resp)
+			}
+			if msg, _ := resp.Errors[0]["message"].(string); !strings.Contains(msg, tt.want) {
+				t.Errorf("error = %q, want it to contain %q", msg, tt.want)
+			}
+			if len(f.conversations.finds) > 0 || f.settings.reads > 0 {
+				t.Error("rejected query was executed")
+			}
+		})
+	}
+}
+
+func TestGraphQL_IntrospectionDisabled(t *testing.T) {
+	f := newFixture()
+	_, resp := f.query(t, `{ __schema { types { name } } }`, nil)
+	if resp.Data["__schema"] != nil {
+		t.Errorf("data = %v, want no schema; GET returns it", resp.Data)
+	}
+}
+
+func TestGraphQL_FailedFieldNullsItsNullableParent(t *testing.T) {
+	f := newFixture()
+	f.settings.err = errors.New("connection refused")
+	status, resp := f.query(t, `query ($id: ID!) {
+		conversation(id: $id) { id settings { language } }
+		other: conversation(id: $id) { title }
+	}`, map[string]any{"id": f.older.ID.Hex()})
+	if status != http.StatusOK {
+		t.Fatalf("got status %d", status)
+	}
+
+	// settings is non-null, so the conversation holding it is null; the other field is unaffected
+	if resp.Data["conversation"] != nil || resp.Data["other"].(map[string]any)["title"] != "Weather" {
+		t.Errorf("data = %v", resp.Data)
+	}
+	if len(resp.Errors) != 1 {
+		t.Fatalf("errors = %v, want one", resp.Errors)
+	}
+	if resp.Errors[0]["message"] != "internal error" {
+		t.Errorf("message = %v, want internal details hidden", resp.Errors[0]["message"])
+	}
+	if path, _ := json.Marshal(resp.Errors[0]["path"]); string(path) != `["conversation","settings"]` {
+		t.Errorf("path = %s", path)
+	}
+}
+
+func TestGraphQL_ArgumentErrorsAreFieldErrors(t *testing.T) {
+	f := newFixture()
+	status, resp := f.query(t, `{ conversations(platform: "web", userId: "user-1", first: 500) { id } settings(platform: "web", userId: "user-1") { language } }`, nil)
+	if status != http.StatusOK {
+		t.Fatalf("got status %d", status)
+	}
+	// conversations is non-null at the root, so data is null as a whole
+	if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0]["message"].(string), "between 1 and 100") {
+		t.Errorf("got %v", resp)
+	}
+}
+
+func TestGraphQL_GetReturnsSchema(t *testing.T) {
+	f := newFixture()
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, graphql.Path, nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("got status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		"type Query {",
+		"first: Int = 20",
+		"): [Conversation!]!",
+		"unreadCount: Int!",
+		"enum Role {",
+		"scalar Time",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("schema lacks %q:\n%s", want, rec.Body.String())
+		}
+	}
+	if rec.Body.String() != f.handler.SDL() {
+		t.Error("GET returned a different schema than SDL()")
+	}
+}
+
+func TestGraphQL_ConversationBudgetPerRequest(t *testing.T) {
+	f := newFixture()
+	var query strings.Builder
+	query.WriteString("{")
+	for i := range 6 {
+		fmt.Fprintf(&query, ` c%d: conversations(platform: "web", userId: "user-1", first: 100) { id }`, i)
+	}
+	query.WriteString(" }")
+
+	// Aliases cannot multiply the limit of one field
+	_, resp := f.query(t, query.String(), nil)
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0]["message"].(string), "more than 500 conversations") {
+		t.Errorf("errors = %v, want the sixth field over the budget", resp.Errors)
+	}
+}
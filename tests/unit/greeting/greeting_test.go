@@ -0,0 +1,63 @@
+package greeting_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/greeting"
+)
+
+func TestGreeter_Greeting(t *testing.T) {
+	greetings, err := greeting.Parse(`{
+		"default": "Hello! How can I help?",
+		"default/coach": "Hi, I am your {{.Persona}}.",
+		"telegram": "Hi from the {{.Platform}} bot!",
+		"telegram/coach": "Ready to train?",
+		"sms": ""
+	}`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	greeter, err := greeting.NewGreeter(greetings)
+	if err != nil {
+		t.Fatalf("NewGreeter() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		platform string
+		persona  string
+		want     string
+	}{
+		{"platform and persona", "telegram", "coach", "Ready to train?"},
+		{"platform", "telegram", "", "Hi from the telegram bot!"},
+		{"platform without the persona", "telegram", "finance", "Hi from the telegram bot!"},
+		{"default persona", "web", "coach", "Hi, I am your coach."},
+		{"default", "web", "", "Hello! How can I help?"},
+		{"turned off for a platform", "sms", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := greeter.Greeting(context.Background(), tt.platform, tt.persona); got != tt.want {
+				t.Errorf("Greeting(%q, %q) = %q, want %q", tt.platform, tt.persona, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewGreeter(t *testing.T) {
+	greeter, err := greeting.NewGreeter(nil)
+	if err != nil || greeter != nil {
+		t.Errorf("NewGreeter(nil) = %v, %v, want nil", greeter, err)
+	}
+	if got := greeter.Greeting(context.Background(), "telegram", ""); got != "" {
+		t.Errorf("nil greeter greeted with %q", got)
+	}
+
+	if _, err := greeting.NewGreeter(map[string]string{"telegram": "Hi {{.Name"}); err == nil {
+		t.Error("NewGreeter() accepted an invalid template")
+	}
+	if _, err := greeting.Parse(`["Hi"]`); err == nil {
+		t.Error("Parse() accepted a list")
+	}
+}
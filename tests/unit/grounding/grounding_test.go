@@ -0,0 +1,95 @@
+package grounding_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/grounding"
+)
+
+func TestPolicy_Enabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		platform string
+		persona  string
+		want     bool
+	}{
+		{"disabled when no platforms configured", nil, "telegram", "", false},
+		{"enabled for configured platform", []string{"telegram"}, "telegram", "", true},
+		{"disabled for other platform", []string{"telegram"}, "web", "", false},
+		{"case insensitive", []string{" Telegram "}, "TELEGRAM", "", true},
+		{"all enables every platform", []string{"all"}, "api", "", true},
+		{"platform covers its personas", []string{"telegram"}, "telegram", "travel", true},
+		{"enabled for configured persona", []string{"web/travel"}, "web", "travel", true},
+		{"disabled for other persona", []string{"web/travel"}, "web", "chef", false},
+		{"persona key needs the persona", []string{"web/travel"}, "web", "", false},
+		{"all enables a persona everywhere", []string{"all/Travel"}, "api", "travel", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := grounding.NewPolicy(tt.keys)
+			if got := policy.Enabled(tt.platform, tt.persona); got != tt.want {
+				t.Errorf("Enabled(%q, %q) = %v, want %v", tt.platform, tt.persona, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nil policy is disabled", func(t *testing.T) {
+		var policy *grounding.Policy
+		if policy.Enabled("telegram", "") {
+			t.Error("expected nil policy to be disabled")
+		}
+	})
+}
+
+func TestRequiredTools(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"weather question", "What's the weather in Barcelona?", []string{grounding.ToolWeather}},
+		{"forecast question", "Will it rain tomorrow in Madrid?", []string{grounding.ToolWeather}},
+		{"date question", "What is the date?", []string{grounding.ToolDate}},
+		{"holiday question", "When is the next public holiday?", []string{grounding.ToolHolidays}},
+		{"multiple topics", "What day is it and what's the temperature?", []string{grounding.ToolWeather, grounding.ToolDate}},
+		{"unrelated question", "Tell me a joke", nil},
+		{"no partial word matches", "Please update my candidate profile", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := grounding.RequiredTools(tt.message)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RequiredTools(%q) = %v, want %v", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	required := []string{grounding.ToolWeather, grounding.ToolDate}
+
+	missing := grounding.Missing(required, map[string]bool{grounding.ToolWeather: true})
+	if !reflect.DeepEqual(missing, []string{grounding.ToolDate}) {
+		t.Errorf("expected only %s to be missing, got %v", grounding.ToolDate, missing)
+	}
+
+	if missing := grounding.Missing(required, map[string]bool{grounding.ToolWeather: true, grounding.ToolDate: true}); len(missing) != 0 {
+		t.Errorf("expected nothing missing, got %v", missing)
+	}
+
+	if missing := grounding.Missing(nil, map[string]bool{}); len(missing) != 0 {
+		t.Errorf("expected nothing missing without requirements, got %v", missing)
+	}
+}
+
+func TestRepromptInstruction(t *testing.T) {
+	instruction := grounding.RepromptInstruction([]string{grounding.ToolWeather, grounding.ToolHolidays})
+	if !strings.Contains(instruction, "get_weather, get_holidays") {
+		t.Errorf("expected instruction to list missing tools, got %q", instruction)
+	}
+}
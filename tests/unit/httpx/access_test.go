@@ -0,0 +1,126 @@
+package httpx_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+)
+
+type stubCountries map[string]string
+
+func (s stubCountries) Country(ip netip.Addr) (string, error) {
+	return s[ip.String()], nil
+}
+
+type blockRecorder struct {
+	reasons []string
+}
+
+func (r *blockRecorder) RecordAccessBlocked(_ context.Context, reason, _ string) {
+	r.reasons = append(r.reasons, reason)
+}
+
+func TestAccessControl_Check(t *testing.T) {
+	countries := stubCountries{"81.2.69.142": "GB", "89.160.20.112": "SE", "175.16.199.1": "CN"}
+
+	tests := []struct {
+		name   string
+		cfg    httpx.AccessConfig
+		ip     string
+		reason string
+	}{
+		{"no rules", httpx.AccessConfig{}, "81.2.69.142", ""},
+		{"denied range", httpx.AccessConfig{DenyCIDRs: []string{"81.2.69.0/24"}}, "81.2.69.142", httpx.BlockIPDenied},
+		{"denied single address", httpx.AccessConfig{DenyCIDRs: []string{"81.2.69.142"}}, "81.2.69.142", httpx.BlockIPDenied},
+		{"outside allowed range", httpx.AccessConfig{AllowCIDRs: []string{"10.0.0.0/8"}}, "81.2.69.142", httpx.BlockIPNotAllowed},
+		{"inside allowed range", httpx.AccessConfig{AllowCIDRs: []string{"10.0.0.0/8"}}, "10.1.2.3", ""},
+		{"deny wins over allow", httpx.AccessConfig{AllowCIDRs: []string{"10.0.0.0/8"}, DenyCIDRs: []string{"10.1.0.0/16"}}, "10.1.2.3", httpx.BlockIPDenied},
+		{"mapped IPv4", httpx.AccessConfig{DenyCIDRs: []string{"81.2.69.0/24"}}, "::ffff:81.2.69.142", httpx.BlockIPDenied},
+		{"denied country", httpx.AccessConfig{DenyCountries: []string{"cn"}}, "175.16.199.1", httpx.BlockCountryDenied},
+		{"allowed country", httpx.AccessConfig{AllowCountries: []string{"GB", "SE"}}, "89.160.20.112", ""},
+		{"country not allowed", httpx.AccessConfig{AllowCountries: []string{"GB"}}, "175.16.199.1", httpx.BlockCountryNotAllowed},
+		{"unknown country not allowed", httpx.AccessConfig{AllowCountries: []string{"GB"}}, "8.8.8.8", httpx.BlockCountryNotAllowed},
+		{"private address skips countries", httpx.AccessConfig{AllowCountries: []string{"GB"}}, "192.168.1.10", ""},
+		{"loopback skips countries", httpx.AccessConfig{AllowCountries: []string{"GB"}}, "127.0.0.1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access, err := httpx.NewAccessControl(tt.cfg, countries, nil)
+			if err != nil {
+				t.Fatalf("NewAccessControl() error = %v", err)
+			}
+			reason, _ := access.Check(context.Background(), netip.MustParseAddr(tt.ip))
+			if reason != tt.reason {
+				t.Errorf("Check(%s) reason = %q, want %q", tt.ip, reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAccessControl_InvalidAddress(t *testing.T) {
+	open, _ := httpx.NewAccessControl(httpx.AccessConfig{DenyCIDRs: []string{"10.0.0.0/8"}}, nil, nil)
+	if reason, _ := open.Check(context.Background(), netip.Addr{}); reason != "" {
+		t.Errorf("invalid address with only deny rules: reason = %q, want allowed", reason)
+	}
+
+	closed, _ := httpx.NewAccessControl(httpx.AccessConfig{AllowCIDRs: []string{"10.0.0.0/8"}}, nil, nil)
+	if reason, _ := closed.Check(context.Background(), netip.Addr{}); reason != httpx.BlockIPNotAllowed {
+		t.Errorf("invalid address with allow rules: reason = %q, want %q", reason, httpx.BlockIPNotAllowed)
+	}
+}
+
+func TestNewAccessControl_Errors(t *testing.T) {
+	if _, err := httpx.NewAccessControl(httpx.AccessConfig{DenyCIDRs: []string{"10.0.0.0/33"}}, nil, nil); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+	if _, err := httpx.NewAccessControl(httpx.AccessConfig{AllowCIDRs: []string{"not-an-ip"}}, nil, nil); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+	if _, err := httpx.NewAccessControl(httpx.AccessConfig{DenyCountries: []string{"CN"}}, nil, nil); err == nil {
+		t.Error("expected an error for country rules without a resolver")
+	}
+}
+
+func TestAccessControl_Middleware(t *testing.T) {
+	recorder := &blockRecorder{}
+	access, err := httpx.NewAccessControl(httpx.AccessConfig{DenyCIDRs: []string{"203.0.113.0/24"}}, nil, recorder)
+	if err != nil {
+		t.Fatalf("NewAccessControl() error = %v", err)
+	}
+	handler := access.Middleware()(okHandler())
+
+	req := httptest.NewRequest(http.MethodPost, "/twirp/chat", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["error"] != "forbidden" || body["reason"] != httpx.BlockIPDenied {
+		t.Errorf("body = %v, want forbidden %s", body, httpx.BlockIPDenied)
+	}
+	if len(recorder.reasons) != 1 || recorder.reasons[0] != httpx.BlockIPDenied {
+		t.Errorf("recorded = %v, want one %s", recorder.reasons, httpx.BlockIPDenied)
+	}
+
+	// The forwarded client address is checked, not the proxy's
+	req = httptest.NewRequest(http.MethodPost, "/twirp/chat", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.4, 203.0.113.7")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("forwarded client status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,154 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestCORS_AllowsOrigin(t *testing.T) {
+	cors := httpx.NewCORS(httpx.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.widgets.io"},
+	})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://evil.com", false},
+		{"https://shop.widgets.io", true},
+		{"http://shop.widgets.io", false},
+		{"https://widgets.io.evil.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := cors.AllowsOrigin(tt.origin); got != tt.want {
+			t.Errorf("AllowsOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cors := httpx.NewCORS(httpx.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAgeSeconds:    600,
+	})
+	handler := cors.Middleware()(okHandler())
+
+	t.Run("allowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/twirp/acai.chat.ChatService/StartConversation", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("expected origin to be echoed, got %q", got)
+		}
+		if rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+			t.Error("expected credentials to be allowed")
+		}
+		// The REST facade updates instructions and settings with PUT
+		if got := rec.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodPut) {
+			t.Errorf("expected PUT to be allowed, got %q", got)
+		}
+		if rec.Header().Get("Access-Control-Max-Age") != "600" {
+			t.Errorf("expected max age 600, got %q", rec.Header().Get("Access-Control-Max-Age"))
+		}
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/twirp/acai.chat.ChatService/StartConversation", nil)
+		req.Header.Set("Origin", "https://evil.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", rec.Code)
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Error("expected no CORS headers for disallowed origin")
+		}
+	})
+}
+
+func TestCORS_WildcardNeverGrantsCredentials(t *testing.T) {
+	cors := httpx.NewCORS(httpx.CORSConfig{
+		AllowedOrigins:   []string{"*", "https://app.example.com"},
+		AllowCredentials: true,
+	})
+	handler := cors.Middleware()(okHandler())
+
+	tests := []struct {
+		origin      string
+		credentials string
+	}{
+		{"https://app.example.com", "true"},
+		{"https://evil.com", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/twirp/acai.chat.ChatService/StartConversation", nil)
+		req.Header.Set("Origin", tt.origin)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.origin {
+			t.Errorf("origin %s: Access-Control-Allow-Origin = %q, want it echoed", tt.origin, got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+			t.Errorf("origin %s: Access-Control-Allow-Credentials = %q, want %q", tt.origin, got, tt.credentials)
+		}
+	}
+}
+
+func TestCORS_OriginMiddleware(t *testing.T) {
+	cors := httpx.NewCORS(httpx.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}})
+	handler := cors.OriginMiddleware()(okHandler())
+
+	tests := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{"non-browser client", "", http.StatusOK},
+		{"same origin", "http://api.local", http.StatusOK},
+		{"allowed origin", "https://app.example.com", http.StatusOK},
+		{"cross-site origin", "https://evil.com", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://api.local/twirp/x", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package httpx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+)
+
+// memoryIdempotency is an in-memory httpx.IdempotencyStore
+type memoryIdempotency struct {
+	mu        sync.Mutex
+	pending   map[string]bool
+	responses map[string]*httpx.StoredResponse
+}
+
+func newMemoryIdempotency() *memoryIdempotency {
+	return &memoryIdempotency{pending: map[string]bool{}, responses: map[string]*httpx.StoredResponse{}}
+}
+
+func (m *memoryIdempotency) Claim(ctx context.Context, key string, ttl time.Duration) (bool, *httpx.StoredResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if resp, ok := m.responses[key]; ok {
+		return false, resp, nil
+	}
+	if m.pending[key] {
+		return false, nil, nil
+	}
+	m.pending[key] = true
+	return true, nil, nil
+}
+
+func (m *memoryIdempotency) Save(ctx context.Context, key string, resp *httpx.StoredResponse, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, key)
+	m.responses[key] = resp
+	return nil
+}
+
+func (m *memoryIdempotency) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, key)
+	return nil
+}
+
+func idempotentRequest(key string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/twirp/acai.chat.ChatService/StartConversation", strings.NewReader(`{"message":"hi"}`))
+	if key != "" {
+		req.Header.Set(httpx.IdempotencyKeyHeader, key)
+	}
+	return req
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	calls := 0
+	handler := httpx.Idempotency(newMemoryIdempotency(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"conversation_id":"c` + strings.Repeat("1", calls) + `"}`))
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("key-1"))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, idempotentRequest("key-1"))
+
+	if calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls)
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected the first response to be replayed, got %q", second.Body.String())
+	}
+	if second.Header().Get(httpx.IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected the replayed response to be marked")
+	}
+
+	// Other keys and requests without one run the handler
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-2"))
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(""))
+	if calls != 3 {
+		t.Errorf("expected other requests to run the handler, ran %d times", calls)
+	}
+}
+
+func TestIdempotency_FailedRequestsCanBeRetried(t *testing.T) {
+	calls := 0
+	handler := httpx.Idempotency(newMemoryIdempotency(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest("key-1"))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, idempotentRequest("key-1"))
+
+	if first.Code != http.StatusServiceUnavailable || second.Code != http.StatusOK || calls != 2 {
+		t.Errorf("expected the retry to run, got %d then %d after %d calls", first.Code, second.Code, calls)
+	}
+}
+
+func TestIdempotency_RequestInProgress(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := httpx.Idempotency(newMemoryIdempotency(), time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte(`{}`))
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1"))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, idempotentRequest("key-1"))
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 while the first request runs, got %d", rec.Code)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), `"aborted"`) {
+		t.Errorf("expected a Twirp aborted error, got %s", body)
+	}
+}
@@ -1,8 +1,12 @@
 package httpx_test
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -225,3 +229,94 @@ func TestRateLimiter_ConcurrentRequests(t *testing.T) {
 	// Should not panic - this tests thread safety
 	t.Log("Concurrent access completed successfully")
 }
+
+// keyCounts records the tracked clients of rate limiters
+type keyCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (k *keyCounts) RecordRateLimiterKeys(ctx context.Context, limiter string, count int64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.counts[limiter] = count
+}
+
+func requestFrom(handler http.Handler, ip string) int {
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = ip
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRateLimiter_ForgetsIdleClients(t *testing.T) {
+	rl := httpx.NewRateLimiter(1, 1, httpx.WithIdleTTL(20*time.Millisecond))
+	handler := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	requestFrom(handler, "10.0.0.1")
+	requestFrom(handler, "10.0.0.2")
+	time.Sleep(30 * time.Millisecond)
+	requestFrom(handler, "10.0.0.3")
+
+	if rl.Len() != 1 {
+		t.Errorf("expected idle clients to be forgotten, tracking %d", rl.Len())
+	}
+}
+
+func TestRateLimiter_MaxKeys(t *testing.T) {
+	recorder := &keyCounts{counts: map[string]int64{}}
+	rl := httpx.NewRateLimiter(1, 1, httpx.WithMaxKeys(2), httpx.WithKeyRecorder("ip", recorder))
+	handler := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	requestFrom(handler, "10.0.0.1")
+	requestFrom(handler, "10.0.0.2")
+	// 10.0.0.1 is seen again, so 10.0.0.2 is the least recently seen when 10.0.0.3 arrives
+	if code := requestFrom(handler, "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the second request to be limited, got %d", code)
+	}
+	requestFrom(handler, "10.0.0.3")
+
+	if rl.Len() != 2 || recorder.counts["ip"] != 2 {
+		t.Errorf("expected 2 tracked clients, got %d (recorded %d)", rl.Len(), recorder.counts["ip"])
+	}
+	if code := requestFrom(handler, "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Errorf("expected the recently seen client to stay limited, got %d", code)
+	}
+}
+
+func TestRateLimiter_StableMemoryUnderChurn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	const maxKeys = 1000
+	rl := httpx.NewRateLimiter(10, 20, httpx.WithMaxKeys(maxKeys))
+	handler := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	churn := func(round int) {
+		for i := 0; i < 50_000; i++ {
+			requestFrom(handler, fmt.Sprintf("%d.%d.%d.%d", round, i>>16, (i>>8)&0xff, i&0xff))
+		}
+	}
+	heap := func() uint64 {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return stats.HeapAlloc
+	}
+
+	churn(1)
+	before := heap()
+	for round := 2; round <= 5; round++ {
+		churn(round)
+	}
+	after := heap()
+
+	if rl.Len() != maxKeys {
+		t.Errorf("expected %d tracked clients, got %d", maxKeys, rl.Len())
+	}
+	// 200k more clients would take tens of megabytes if they were all kept
+	if after > before && after-before > 4<<20 {
+		t.Errorf("expected stable memory, heap grew by %d bytes", after-before)
+	}
+}
@@ -0,0 +1,118 @@
+package httpx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+)
+
+// memoryNonces is an in-memory httpx.NonceStore
+type memoryNonces struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (m *memoryNonces) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[nonce] {
+		return false, nil
+	}
+	m.seen[nonce] = true
+	return true, nil
+}
+
+func signedRequest(t *testing.T, keyID, secret, nonce string, at time.Time) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/twirp/acai.chat.ChatService/StartConversation", strings.NewReader(`{"message":"hi"}`))
+	if err := httpx.SignRequest(req, keyID, secret, nonce, at); err != nil {
+		t.Fatalf("failed to sign request: %v", err)
+	}
+	return req
+}
+
+func TestRequestSigner(t *testing.T) {
+	signer := httpx.NewRequestSigner(httpx.SigningConfig{
+		Keys:     map[string]string{"old": "secret-1", "new": "secret-2"},
+		Required: true,
+		MaxSkew:  time.Minute,
+		Nonces:   &memoryNonces{seen: map[string]bool{}},
+	})
+
+	var body string
+	handler := signer.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name string
+		req  func() *http.Request
+		want int
+	}{
+		{"valid signature with current key", func() *http.Request {
+			return signedRequest(t, "new", "secret-2", "n1", time.Now())
+		}, http.StatusOK},
+		{"valid signature with rotated key", func() *http.Request {
+			return signedRequest(t, "old", "secret-1", "n2", time.Now())
+		}, http.StatusOK},
+		{"replayed nonce", func() *http.Request {
+			return signedRequest(t, "new", "secret-2", "n1", time.Now())
+		}, http.StatusUnauthorized},
+		{"wrong secret", func() *http.Request {
+			return signedRequest(t, "new", "secret-1", "n3", time.Now())
+		}, http.StatusUnauthorized},
+		{"unknown key", func() *http.Request {
+			return signedRequest(t, "other", "secret-2", "n4", time.Now())
+		}, http.StatusUnauthorized},
+		{"stale timestamp", func() *http.Request {
+			return signedRequest(t, "new", "secret-2", "n5", time.Now().Add(-2*time.Minute))
+		}, http.StatusUnauthorized},
+		{"tampered body", func() *http.Request {
+			req := signedRequest(t, "new", "secret-2", "n6", time.Now())
+			req.Body = io.NopCloser(strings.NewReader(`{"message":"bye"}`))
+			return req
+		}, http.StatusUnauthorized},
+		{"unsigned request when required", func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/twirp/x", strings.NewReader(`{}`))
+		}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req())
+
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+
+	// The verified body must still be readable by the handler
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, signedRequest(t, "new", "secret-2", "n7", time.Now()))
+	if body != `{"message":"hi"}` {
+		t.Errorf("expected handler to read original body, got %q", body)
+	}
+}
+
+func TestRequestSigner_OptionalAllowsUnsigned(t *testing.T) {
+	signer := httpx.NewRequestSigner(httpx.SigningConfig{Keys: map[string]string{"k": "s"}})
+	handler := signer.Middleware()(okHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/twirp/x", strings.NewReader(`{}`)))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected unsigned request to pass when signing is optional, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,149 @@
+package httpx_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/twitchtv/twirp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingRecorder keeps the RPC measurements it receives
+type recordingRecorder struct {
+	mu        sync.Mutex
+	requests  []string // "<method> <status>"
+	latencies int
+	tenants   []string
+}
+
+func (r *recordingRecorder) RecordTwirpRequest(ctx context.Context, method, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, method+" "+status)
+	r.tenants = append(r.tenants, tenant.FromContext(ctx))
+}
+
+func (r *recordingRecorder) RecordTwirpLatency(ctx context.Context, method, status string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies++
+}
+
+// hookedChat answers ListConversations, fails DescribeConversation and records the caller it sees
+type hookedChat struct {
+	pb.ChatService
+	caller httpx.Caller
+}
+
+func (c *hookedChat) ListConversations(ctx context.Context, req *pb.ListConversationsRequest) (*pb.ListConversationsResponse, error) {
+	c.caller, _ = httpx.CallerFromContext(ctx)
+	return &pb.ListConversationsResponse{}, nil
+}
+
+func (c *hookedChat) DescribeConversation(ctx context.Context, req *pb.DescribeConversationRequest) (*pb.DescribeConversationResponse, error) {
+	if req.GetConversationId() == "missing" {
+		return nil, twirp.NotFoundError("conversation not found")
+	}
+	return nil, errors.New("database unavailable")
+}
+
+var testTraceID = trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+
+// newHookedServer serves chat behind the tenant resolver and request signer, as the server does, inside a trace
+func newHookedServer(t *testing.T, chat *hookedChat, recorder httpx.TwirpRecorder) pb.ChatService {
+	twirpHandler := pb.NewChatServiceServer(chat,
+		twirp.WithServerHooks(httpx.TwirpHooks(recorder)),
+		twirp.WithServerInterceptors(httpx.TraceErrors()),
+	)
+	signer := httpx.NewRequestSigner(httpx.SigningConfig{Keys: map[string]string{"svc": "secret"}})
+	traced := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: testTraceID, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled,
+		})
+		signer.Middleware()(twirpHandler).ServeHTTP(w, r.WithContext(trace.ContextWithSpanContext(r.Context(), spanContext)))
+	})
+	server := httptest.NewServer(tenant.NewResolver([]string{"acme"}, nil).Middleware()(traced))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: signingTransport{}}
+	return pb.NewChatServiceJSONClient(server.URL, client)
+}
+
+// signingTransport signs requests as the svc key of the acme tenant
+type signingTransport struct{}
+
+func (signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(tenant.Header, "acme")
+	if err := httpx.SignRequest(req, "svc", "secret", "nonce-"+time.Now().Format(time.RFC3339Nano), time.Now()); err != nil {
+		return nil, err
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTwirpHooks_RecordsRequests(t *testing.T) {
+	recorder := &recordingRecorder{}
+	chat := &hookedChat{}
+	client := newHookedServer(t, chat, recorder)
+	ctx := context.Background()
+
+	if _, err := client.ListConversations(ctx, &pb.ListConversationsRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: "missing"})
+	client.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: "broken"})
+
+	want := []string{
+		"ListConversations success",
+		"DescribeConversation not_found",
+		"DescribeConversation internal",
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.requests) != len(want) {
+		t.Fatalf("got requests %v, want %v", recorder.requests, want)
+	}
+	for i := range want {
+		if recorder.requests[i] != want[i] {
+			t.Errorf("request %d: got %q, want %q", i, recorder.requests[i], want[i])
+		}
+		if recorder.tenants[i] != "acme" {
+			t.Errorf("request %d: got tenant %q, want acme", i, recorder.tenants[i])
+		}
+	}
+	if recorder.latencies != len(want) {
+		t.Errorf("got %d latencies, want %d", recorder.latencies, len(want))
+	}
+
+	if chat.caller != (httpx.Caller{TenantID: "acme", SigningKeyID: "svc"}) {
+		t.Errorf("got caller %+v", chat.caller)
+	}
+}
+
+func TestTraceErrors_AddsTraceID(t *testing.T) {
+	client := newHookedServer(t, &hookedChat{}, nil)
+	ctx := context.Background()
+
+	for _, id := range []string{"missing", "broken"} {
+		_, err := client.DescribeConversation(ctx, &pb.DescribeConversationRequest{ConversationId: id})
+		var twerr twirp.Error
+		if !errors.As(err, &twerr) {
+			t.Fatalf("%s: expected a twirp error, got %v", id, err)
+		}
+		if got := twerr.Meta(httpx.TraceIDMeta); got != testTraceID.String() {
+			t.Errorf("%s: got trace ID %q, want %q", id, got, testTraceID.String())
+		}
+	}
+
+	if _, err := client.ListConversations(ctx, &pb.ListConversationsRequest{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package identity_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/identity"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStore keeps links in a map
+type memoryStore struct {
+	links map[identity.Identity]*identity.Link
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{links: map[identity.Identity]*identity.Link{}}
+}
+
+func (s *memoryStore) FindLink(ctx context.Context, id identity.Identity) (*identity.Link, error) {
+	link, ok := s.links[id]
+	if !ok {
+		return nil, identity.ErrNotFound
+	}
+	return link, nil
+}
+
+func (s *memoryStore) ListLinks(ctx context.Context, canonical identity.Identity) ([]*identity.Link, error) {
+	var links []*identity.Link
+	for _, link := range s.links {
+		if link.Canonical == canonical {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (s *memoryStore) SaveLink(ctx context.Context, link *identity.Link) error {
+	s.links[link.Identity] = link
+	return nil
+}
+
+func (s *memoryStore) DeleteLink(ctx context.Context, id identity.Identity) error {
+	if _, ok := s.links[id]; !ok {
+		return identity.ErrNotFound
+	}
+	delete(s.links, id)
+	return nil
+}
+
+var (
+	telegram = identity.Identity{Platform: "telegram", UserID: "123"}
+	web      = identity.Identity{Platform: "webwidget", UserID: "abc"}
+	slack    = identity.Identity{Platform: "slack", UserID: "U1"}
+)
+
+func TestService_LinkCodes(t *testing.T) {
+	ctx := context.Background()
+	svc := identity.NewService(newMemoryStore(), redisx.NewMemoryCache(time.Minute, 0))
+
+	code, err := svc.CreateLinkCode(ctx, telegram)
+	if err != nil {
+		t.Fatalf("CreateLinkCode() error = %v", err)
+	}
+	canonical, err := svc.RedeemLinkCode(ctx, code, web)
+	if err != nil {
+		t.Fatalf("RedeemLinkCode() error = %v", err)
+	}
+	if canonical != telegram {
+		t.Errorf("canonical = %v, want the issuer %v", canonical, telegram)
+	}
+	if got, _ := svc.Resolve(ctx, web); got != telegram {
+		t.Errorf("Resolve(web) = %v, want %v", got, telegram)
+	}
+	if got, _ := svc.Resolve(ctx, telegram); got != telegram {
+		t.Errorf("Resolve(telegram) = %v, want itself", got)
+	}
+
+	if _, err := svc.RedeemLinkCode(ctx, code, slack); !errors.Is(err, identity.ErrInvalidCode) {
+		t.Errorf("second RedeemLinkCode() error = %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestService_Link(t *testing.T) {
+	ctx := context.Background()
+	svc := identity.NewService(newMemoryStore(), nil)
+
+	if _, err := svc.Link(ctx, telegram, web); err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+
+	// Linking through any identity of the user attaches to the same canonical user
+	if canonical, err := svc.Link(ctx, web, slack); err != nil || canonical != telegram {
+		t.Fatalf("Link(web, slack) = %v, %v, want %v", canonical, err, telegram)
+	}
+	canonical, identities, err := svc.Identities(ctx, slack)
+	if err != nil || canonical != telegram || len(identities) != 3 {
+		t.Errorf("Identities() = %v, %v, %v, want telegram and its two links", canonical, identities, err)
+	}
+
+	// Users with linked identities cannot be merged into another user
+	other := identity.Identity{Platform: "telegram", UserID: "999"}
+	if _, err := svc.Link(ctx, other, telegram); !errors.Is(err, identity.ErrAlreadyLinked) {
+		t.Errorf("Link(other, telegram) error = %v, want ErrAlreadyLinked", err)
+	}
+	if _, err := svc.Link(ctx, other, web); !errors.Is(err, identity.ErrAlreadyLinked) {
+		t.Errorf("Link(other, web) error = %v, want ErrAlreadyLinked", err)
+	}
+
+	if err := svc.Unlink(ctx, web); err != nil {
+		t.Fatalf("Unlink() error = %v", err)
+	}
+	if got, _ := svc.Resolve(ctx, web); got != web {
+		t.Errorf("Resolve() after unlinking = %v, want the identity itself", got)
+	}
+}
+
+type conversationsByUser map[identity.Identity][]*model.Conversation
+
+func (c conversationsByUser) ListUserConversations(ctx context.Context, platform, userID string) ([]*model.Conversation, error) {
+	return c[identity.Identity{Platform: platform, UserID: userID}], nil
+}
+
+func TestLinkedConversations(t *testing.T) {
+	ctx := context.Background()
+	svc := identity.NewService(newMemoryStore(), nil)
+	if _, err := svc.Link(ctx, telegram, web); err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+
+	now := time.Now()
+	first := &model.Conversation{ID: primitive.NewObjectID(), CreatedAt: now.Add(-time.Hour)}
+	second := &model.Conversation{ID: primitive.NewObjectID(), CreatedAt: now}
+	lister := identity.LinkedConversations{
+		Identities:    svc,
+		Conversations: conversationsByUser{telegram: {second}, web: {first}},
+	}
+
+	got, err := lister.ListUserConversations(ctx, web.Platform, web.UserID)
+	if err != nil {
+		t.Fatalf("ListUserConversations() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != first || got[1] != second {
+		t.Errorf("ListUserConversations() = %v, want both platforms' conversations oldest first", got)
+	}
+}
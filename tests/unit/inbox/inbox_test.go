@@ -0,0 +1,317 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/delivery"
+	"github.com/8adimka/Go_AI_Assistant/internal/inbox"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/twitchtv/twirp"
+)
+
+// memoryStream is an in-memory inbox.Stream
+type memoryStream struct {
+	mu      sync.Mutex
+	next    int
+	unread  []inbox.Message
+	pending map[string]inbox.Message
+	readAt  map[string]time.Time
+	dead    map[string]string // Reason by message ID
+}
+
+func newMemoryStream() *memoryStream {
+	return &memoryStream{pending: map[string]inbox.Message{}, readAt: map[string]time.Time{}, dead: map[string]string{}}
+}
+
+func (s *memoryStream) Add(ctx context.Context, m *inbox.Message) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	stored := *m
+	stored.ID = strconv.Itoa(s.next)
+	s.unread = append(s.unread, stored)
+	return stored.ID, nil
+}
+
+func (s *memoryStream) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]*inbox.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []*inbox.Message
+	for len(s.unread) > 0 && len(messages) < count {
+		m := s.unread[0]
+		s.unread = s.unread[1:]
+		m.Deliveries = 1
+		s.pending[m.ID] = m
+		s.readAt[m.ID] = time.Now()
+		messages = append(messages, &m)
+	}
+	return messages, nil
+}
+
+func (s *memoryStream) Claim(ctx context.Context, consumer string, minIdle time.Duration, count int) ([]*inbox.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []*inbox.Message
+	for id, m := range s.pending {
+		if len(messages) == count {
+			break
+		}
+		if time.Since(s.readAt[id]) < minIdle {
+			continue
+		}
+		m.Deliveries++
+		s.pending[id] = m
+		s.readAt[id] = time.Now()
+		messages = append(messages, &m)
+	}
+	return messages, nil
+}
+
+func (s *memoryStream) Ack(ctx context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.pending, id)
+	}
+	return nil
+}
+
+func (s *memoryStream) Bury(ctx context.Context, m *inbox.Message, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, m.ID)
+	s.dead[m.ID] = reason
+	return nil
+}
+
+func (s *memoryStream) Stats(ctx context.Context) (inbox.Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return inbox.Stats{
+		Length:  int64(len(s.unread) + len(s.pending)),
+		Pending: int64(len(s.pending)),
+		Dead:    int64(len(s.dead)),
+	}, nil
+}
+
+// fakeProcessor answers messages, failing with the queued errors first
+type fakeProcessor struct {
+	mu       sync.Mutex
+	errs     []error
+	requests []*pb.ContinueConversationRequest
+	tenants  []string
+}
+
+func (p *fakeProcessor) ContinueConversation(ctx context.Context, req *pb.ContinueConversationRequest) (*pb.ContinueConversationResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	p.tenants = append(p.tenants, tenant.FromContext(ctx))
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return nil, err
+	}
+	return &pb.ContinueConversationResponse{Reply: "re: " + req.GetMessage()}, nil
+}
+
+type sent struct{ channel, recipient, text string }
+
+type fakeReplier struct {
+	mu   sync.Mutex
+	sent []sent
+}
+
+func (r *fakeReplier) Supports(channel string) bool { return channel == "telegram" }
+
+func (r *fakeReplier) Send(ctx context.Context, channel, recipient, text string) (*delivery.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, sent{channel, recipient, text})
+	return &delivery.Message{}, nil
+}
+
+// newInbox creates an inbox that claims failed messages again right away
+func newInbox(processor *fakeProcessor) (*inbox.Inbox, *memoryStream, *fakeReplier) {
+	stream := newMemoryStream()
+	replier := &fakeReplier{}
+	cfg := inbox.Config{Consumer: "test", MaxDeliveries: 3, ClaimIdle: time.Nanosecond}
+	return inbox.New(stream, processor, replier, cfg), stream, replier
+}
+
+func TestInbox_AnswersQueuedMessage(t *testing.T) {
+	processor := &fakeProcessor{}
+	ib, stream, replier := newInbox(processor)
+
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	if _, err := ib.Enqueue(ctx, "telegram", "u1", "c1", "hello"); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	n, err := ib.ProcessBatch(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("ProcessBatch() = %d, %v, want 1 message", n, err)
+	}
+
+	req := processor.requests[0]
+	if req.GetMessage() != "hello" || req.GetSessionMetadata().GetChatId() != "c1" || req.GetSessionMetadata().GetUserId() != "u1" {
+		t.Errorf("request = %v, want the queued message in the chat's session", req)
+	}
+	if processor.tenants[0] != "acme" {
+		t.Errorf("tenant = %q, want the tenant that queued the message", processor.tenants[0])
+	}
+	if len(replier.sent) != 1 || replier.sent[0] != (sent{"telegram", "c1", "re: hello"}) {
+		t.Errorf("sent = %v, want the reply to the chat", replier.sent)
+	}
+	if stats, _ := stream.Stats(ctx); stats.Pending != 0 {
+		t.Errorf("pending = %d, want the message acknowledged", stats.Pending)
+	}
+}
+
+func TestInbox_RetriesTransientFailures(t *testing.T) {
+	processor := &fakeProcessor{errs: []error{errors.New("model unavailable")}}
+	ib, stream, replier := newInbox(processor)
+	ctx := context.Background()
+
+	ib.Enqueue(ctx, "telegram", "u1", "c1", "hello")
+	ib.ProcessBatch(ctx)
+	if stats, _ := stream.Stats(ctx); stats.Pending != 1 {
+		t.Fatalf("pending = %d, want the failed message kept", stats.Pending)
+	}
+
+	// The pending message is claimed again and answered
+	ib.ProcessBatch(ctx)
+	if len(replier.sent) != 1 {
+		t.Errorf("sent %d replies, want 1 after the retry", len(replier.sent))
+	}
+	if stats, _ := stream.Stats(ctx); stats.Pending != 0 || stats.Dead != 0 {
+		t.Errorf("stats = %+v, want the message acknowledged", stats)
+	}
+}
+
+func TestInbox_BuriesInvalidMessages(t *testing.T) {
+	processor := &fakeProcessor{errs: []error{twirp.InvalidArgumentError("message", "is too long")}}
+	ib, stream, replier := newInbox(processor)
+	ctx := context.Background()
+
+	m, _ := ib.Enqueue(ctx, "telegram", "u1", "c1", "hello")
+	ib.ProcessBatch(ctx)
+
+	if reason, ok := stream.dead[m.ID]; !ok || !strings.Contains(reason, "too long") {
+		t.Errorf("dead letters = %v, want the invalid message with its error", stream.dead)
+	}
+	if len(replier.sent) != 0 {
+		t.Errorf("sent = %v, want no reply", replier.sent)
+	}
+}
+
+func TestInbox_BuriesAfterMaxDeliveries(t *testing.T) {
+	failure := twirp.InternalError("model unavailable")
+	processor := &fakeProcessor{errs: []error{failure, failure, failure, failure}}
+	ib, stream, _ := newInbox(processor)
+	ctx := context.Background()
+
+	m, _ := ib.Enqueue(ctx, "telegram", "u1", "c1", "hello")
+	for range 4 {
+		ib.ProcessBatch(ctx)
+	}
+
+	if _, ok := stream.dead[m.ID]; !ok {
+		t.Errorf("dead letters = %v, want the message after 3 attempts", stream.dead)
+	}
+	if len(processor.requests) != 3 {
+		t.Errorf("processed %d times, want 3", len(processor.requests))
+	}
+}
+
+func TestInbox_RunAnswersUntilCancelled(t *testing.T) {
+	replier := &fakeReplier{}
+	ib := inbox.New(newMemoryStream(), &fakeProcessor{}, replier,
+		inbox.Config{Consumer: "test", Workers: 2, Block: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for i := range 5 {
+		ib.Enqueue(ctx, "telegram", "u1", "c"+strconv.Itoa(i%2), "hello")
+	}
+	done := make(chan struct{})
+	go func() {
+		ib.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		replier.mu.Lock()
+		n := len(replier.sent)
+		replier.mu.Unlock()
+		if n == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sent %d replies, want 5", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+func TestWebhookHandler_Telegram(t *testing.T) {
+	ib, stream, _ := newInbox(&fakeProcessor{})
+	h := inbox.NewWebhookHandler(ib, "s3cret")
+
+	post := func(secret, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/telegram", strings.NewReader(body))
+		req.Header.Set(inbox.TelegramSecretHeader, secret)
+		rec := httptest.NewRecorder()
+		h.TelegramHandler(rec, req)
+		return rec.Code
+	}
+
+	update := `{"update_id":1,"message":{"text":"hi","chat":{"id":42},"from":{"id":7}}}`
+	if code := post("wrong", update); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: status = %d, want 401", code)
+	}
+	if code := post("s3cret", `{"update_id":2,"message":{"chat":{"id":42}}}`); code != http.StatusOK {
+		t.Errorf("update without text: status = %d, want 200", code)
+	}
+	if code := post("s3cret", update); code != http.StatusOK {
+		t.Errorf("status = %d, want 200", code)
+	}
+
+	if len(stream.unread) != 1 {
+		t.Fatalf("queued %d messages, want only the text message", len(stream.unread))
+	}
+	if m := stream.unread[0]; m.Platform != "telegram" || m.ChatID != "42" || m.UserID != "7" || m.Text != "hi" {
+		t.Errorf("queued %+v, want the Telegram message", m)
+	}
+}
+
+func TestWebhookHandler_Enqueue(t *testing.T) {
+	ib, stream, _ := newInbox(&fakeProcessor{})
+	h := inbox.NewWebhookHandler(ib, "")
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		h.EnqueueHandler(rec, httptest.NewRequest(http.MethodPost, "/webhooks/inbox", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	if code := post(`{"platform":"slack","user_id":"u1","chat_id":"c1"}`); code != http.StatusBadRequest {
+		t.Errorf("missing text: status = %d, want 400", code)
+	}
+	if code := post(`{"platform":"slack","user_id":"u1","chat_id":"c1","text":"hi"}`); code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", code)
+	}
+	if len(stream.unread) != 1 {
+		t.Errorf("queued %d messages, want 1", len(stream.unread))
+	}
+}
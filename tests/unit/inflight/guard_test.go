@@ -0,0 +1,207 @@
+package inflight_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/inflight"
+)
+
+func newGuard(policy inflight.Policy) *inflight.Guard {
+	return inflight.NewGuard(map[string]inflight.Policy{inflight.DefaultPlatform: policy})
+}
+
+// begin starts Begin in the background and returns its result channel
+func begin(g *inflight.Guard, ctx context.Context, message string) <-chan result {
+	out := make(chan result, 1)
+	go func() {
+		t, err := g.Begin(ctx, "web", "conv-1", message)
+		out <- result{t, err}
+	}()
+	return out
+}
+
+type result struct {
+	ticket *inflight.Ticket
+	err    error
+}
+
+func waitFor(t *testing.T, ch <-chan result) result {
+	t.Helper()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(2 * time.Second):
+		t.Fatal("Begin did not return")
+		return result{}
+	}
+}
+
+func assertWaiting(t *testing.T, ch <-chan result) {
+	t.Helper()
+	select {
+	case r := <-ch:
+		t.Fatalf("Begin returned %v, want it to wait", r.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestGuard_Queue(t *testing.T) {
+	g := newGuard(inflight.PolicyQueue)
+	ctx := context.Background()
+
+	first, err := g.Begin(ctx, "web", "conv-1", "first")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	second := begin(g, ctx, "second")
+	assertWaiting(t, second)
+
+	// Other conversations are not held up
+	other, err := g.Begin(ctx, "web", "conv-2", "other")
+	if err != nil {
+		t.Fatalf("Begin() in another conversation error = %v", err)
+	}
+	g.Release(other)
+
+	g.Release(first)
+	r := waitFor(t, second)
+	if r.err != nil {
+		t.Fatalf("queued Begin() error = %v", r.err)
+	}
+	if got := r.ticket.Messages(); len(got) != 1 || got[0] != "second" {
+		t.Errorf("Messages() = %v, want only the queued message", got)
+	}
+	if !g.Commit(r.ticket) {
+		t.Error("Commit() = false, want the queued reply kept")
+	}
+	g.Release(r.ticket)
+	if n := g.InFlight(); n != 0 {
+		t.Errorf("InFlight() = %d, want idle conversations forgotten", n)
+	}
+}
+
+func TestGuard_Merge(t *testing.T) {
+	g := newGuard(inflight.PolicyMerge)
+	ctx := context.Background()
+
+	first, _ := g.Begin(ctx, "web", "conv-1", "first")
+	second := begin(g, ctx, "second")
+
+	// The running generation is cancelled and must not store its reply
+	select {
+	case <-first.Context().Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("first generation was not cancelled")
+	}
+	if cause := context.Cause(first.Context()); !errors.Is(cause, inflight.ErrSuperseded) {
+		t.Errorf("cancellation cause = %v, want ErrSuperseded", cause)
+	}
+	if g.Commit(first) {
+		t.Error("Commit() = true for a superseded ticket")
+	}
+
+	// A third message takes over the waiting second one
+	third := begin(g, ctx, "third")
+	if r := waitFor(t, second); !errors.Is(r.err, inflight.ErrSuperseded) {
+		t.Errorf("waiting Begin() error = %v, want ErrSuperseded", r.err)
+	}
+
+	g.Release(first)
+	r := waitFor(t, third)
+	if r.err != nil {
+		t.Fatalf("Begin() error = %v", r.err)
+	}
+	got := r.ticket.Messages()
+	if len(got) != 3 || got[0] != "first" || got[1] != "second" || got[2] != "third" {
+		t.Errorf("Messages() = %v, want all three messages in order", got)
+	}
+	g.Release(r.ticket)
+}
+
+func TestGuard_MergeWaitsForCommittedReply(t *testing.T) {
+	g := newGuard(inflight.PolicyMerge)
+	ctx := context.Background()
+
+	first, _ := g.Begin(ctx, "web", "conv-1", "first")
+	if !g.Commit(first) {
+		t.Fatal("Commit() = false")
+	}
+	second := begin(g, ctx, "second")
+	assertWaiting(t, second)
+	if first.Context().Err() != nil {
+		t.Error("committed generation was cancelled")
+	}
+
+	g.Release(first)
+	r := waitFor(t, second)
+	if got := r.ticket.Messages(); len(got) != 1 || got[0] != "second" {
+		t.Errorf("Messages() = %v, want only the new message", got)
+	}
+	g.Release(r.ticket)
+}
+
+func TestGuard_Reject(t *testing.T) {
+	g := newGuard(inflight.PolicyReject)
+	ctx := context.Background()
+
+	first, _ := g.Begin(ctx, "web", "conv-1", "first")
+	if _, err := g.Begin(ctx, "web", "conv-1", "second"); !errors.Is(err, inflight.ErrBusy) {
+		t.Errorf("Begin() error = %v, want ErrBusy", err)
+	}
+	g.Release(first)
+	if _, err := g.Begin(ctx, "web", "conv-1", "third"); err != nil {
+		t.Errorf("Begin() after release error = %v", err)
+	}
+}
+
+func TestGuard_PolicyPerPlatform(t *testing.T) {
+	g := inflight.NewGuard(map[string]inflight.Policy{
+		inflight.DefaultPlatform: inflight.PolicyReject,
+		"web":                    inflight.PolicyOff,
+	})
+	ctx := context.Background()
+
+	if _, err := g.Begin(ctx, "web", "conv-1", "first"); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if _, err := g.Begin(ctx, "web", "conv-1", "second"); err != nil {
+		t.Errorf("Begin() with policy off error = %v", err)
+	}
+	if g.Policy("telegram") != inflight.PolicyReject {
+		t.Errorf("Policy(telegram) = %s, want the default", g.Policy("telegram"))
+	}
+}
+
+func TestGuard_CancelWhileWaiting(t *testing.T) {
+	g := newGuard(inflight.PolicyQueue)
+
+	first, _ := g.Begin(context.Background(), "web", "conv-1", "first")
+	ctx, cancel := context.WithCancel(context.Background())
+	second := begin(g, ctx, "second")
+	assertWaiting(t, second)
+	cancel()
+	if r := waitFor(t, second); !errors.Is(r.err, context.Canceled) {
+		t.Errorf("Begin() error = %v, want context.Canceled", r.err)
+	}
+
+	g.Release(first)
+	if n := g.InFlight(); n != 0 {
+		t.Errorf("InFlight() = %d, want the cancelled request forgotten", n)
+	}
+}
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := inflight.ParsePolicies(map[string]string{"telegram": "merge"})
+	if err != nil {
+		t.Fatalf("ParsePolicies() error = %v", err)
+	}
+	if policies["telegram"] != inflight.PolicyMerge || policies[inflight.DefaultPlatform] != inflight.PolicyQueue {
+		t.Errorf("ParsePolicies() = %v, want telegram merge and default queue", policies)
+	}
+	if _, err := inflight.ParsePolicies(map[string]string{"web": "drop"}); err == nil {
+		t.Error("ParsePolicies() accepted an unknown policy")
+	}
+}
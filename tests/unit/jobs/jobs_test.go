@@ -0,0 +1,328 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/jobs"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+// memoryStore is an in-memory jobs.Store
+type memoryStore struct {
+	mu        sync.Mutex
+	scheduled map[string]jobs.Job
+	leased    map[string]time.Time
+	dead      []jobs.Job
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{scheduled: map[string]jobs.Job{}, leased: map[string]time.Time{}}
+}
+
+func (s *memoryStore) Push(ctx context.Context, job *jobs.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scheduled[job.ID] = *job
+	return nil
+}
+
+func (s *memoryStore) Pop(ctx context.Context, now time.Time, lease time.Duration) (*jobs.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []jobs.Job
+	for _, job := range s.scheduled {
+		if _, running := s.leased[job.ID]; !running && !job.RunAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+	s.leased[due[0].ID] = now.Add(lease)
+	return &due[0], nil
+}
+
+func (s *memoryStore) Ack(ctx context.Context, job *jobs.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.scheduled, job.ID)
+	delete(s.leased, job.ID)
+	return nil
+}
+
+func (s *memoryStore) Retry(ctx context.Context, job *jobs.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scheduled[job.ID] = *job
+	delete(s.leased, job.ID)
+	return nil
+}
+
+func (s *memoryStore) Bury(ctx context.Context, job *jobs.Job, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.scheduled, job.ID)
+	delete(s.leased, job.ID)
+	s.dead = append(s.dead, *job)
+	if len(s.dead) > limit {
+		s.dead = s.dead[len(s.dead)-limit:]
+	}
+	return nil
+}
+
+func (s *memoryStore) DeadLetters(ctx context.Context, limit int) ([]*jobs.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var dead []*jobs.Job
+	for i := len(s.dead) - 1; i >= 0 && len(dead) < limit; i-- {
+		job := s.dead[i]
+		dead = append(dead, &job)
+	}
+	return dead, nil
+}
+
+func (s *memoryStore) Requeue(ctx context.Context, id string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, job := range s.dead {
+		if job.ID == id {
+			s.dead = append(s.dead[:i], s.dead[i+1:]...)
+			job.Attempts = 0
+			job.RunAt = now
+			s.scheduled[id] = job
+			return nil
+		}
+	}
+	return jobs.ErrNotFound
+}
+
+func (s *memoryStore) Stats(ctx context.Context) (jobs.Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return jobs.Stats{
+		Scheduled: int64(len(s.scheduled) - len(s.leased)),
+		Running:   int64(len(s.leased)),
+		Dead:      int64(len(s.dead)),
+	}, nil
+}
+
+type recordedJob struct {
+	jobType, result, tenantID string
+}
+
+type recordingRecorder struct {
+	mu      sync.Mutex
+	results []recordedJob
+}
+
+func (r *recordingRecorder) RecordJob(ctx context.Context, queue, jobType, result string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, recordedJob{jobType: jobType, result: result, tenantID: tenant.FromContext(ctx)})
+}
+
+func newQueue(store jobs.Store, recorder jobs.Recorder) *jobs.Queue {
+	return jobs.NewQueue("test", store, jobs.Config{
+		MaxAttempts:  3,
+		BaseBackoff:  time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+		PollInterval: time.Millisecond,
+	}, recorder)
+}
+
+// drain processes due jobs until none is left, waiting out retry delays
+func drain(t *testing.T, q *jobs.Queue) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		processed, err := q.ProcessNext(context.Background())
+		if err != nil {
+			t.Fatalf("ProcessNext() error = %v", err)
+		}
+		if !processed {
+			stats, _ := q.Stats(context.Background())
+			if stats.Scheduled == 0 {
+				return
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+	t.Fatal("jobs were not drained in time")
+}
+
+func TestQueue_RunsJobWithPayloadAndTenant(t *testing.T) {
+	recorder := &recordingRecorder{}
+	q := newQueue(newMemoryStore(), recorder)
+
+	type payload struct {
+		ConversationID string `json:"conversation_id"`
+	}
+	var got payload
+	var gotTenant string
+	q.Handle("summarize", func(ctx context.Context, job *jobs.Job) error {
+		gotTenant = tenant.FromContext(ctx)
+		return job.Decode(&got)
+	})
+
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	if _, err := q.Enqueue(ctx, "summarize", payload{ConversationID: "c1"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	drain(t, q)
+
+	if got.ConversationID != "c1" {
+		t.Errorf("payload = %+v, want conversation c1", got)
+	}
+	if gotTenant != "acme" {
+		t.Errorf("tenant = %q, want acme", gotTenant)
+	}
+	if len(recorder.results) != 1 || recorder.results[0].result != jobs.ResultSucceeded || recorder.results[0].tenantID != "acme" {
+		t.Errorf("recorded %+v, want one succeeded job of acme", recorder.results)
+	}
+}
+
+func TestQueue_DelayedJobWaits(t *testing.T) {
+	q := newQueue(newMemoryStore(), nil)
+	q.Handle("noop", func(ctx context.Context, job *jobs.Job) error { return nil })
+
+	if _, err := q.Enqueue(context.Background(), "noop", nil, jobs.Delay(time.Hour)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	processed, err := q.ProcessNext(context.Background())
+	if err != nil || processed {
+		t.Errorf("ProcessNext() = %v, %v; want a delayed job to wait", processed, err)
+	}
+}
+
+func TestQueue_RetriesThenBuries(t *testing.T) {
+	recorder := &recordingRecorder{}
+	q := newQueue(newMemoryStore(), recorder)
+	calls := 0
+	q.Handle("flaky", func(ctx context.Context, job *jobs.Job) error {
+		calls++
+		return errors.New("upstream unavailable")
+	})
+
+	job, err := q.Enqueue(context.Background(), "flaky", map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	drain(t, q)
+
+	if calls != 3 {
+		t.Errorf("handler called %d times, want 3", calls)
+	}
+	dead, _ := q.DeadLetters(context.Background(), 10)
+	if len(dead) != 1 || dead[0].ID != job.ID {
+		t.Fatalf("dead letters = %+v, want the failed job", dead)
+	}
+	if dead[0].Attempts != 3 || dead[0].LastError != "upstream unavailable" {
+		t.Errorf("dead job = %+v, want 3 attempts and the last error", dead[0])
+	}
+
+	want := []string{jobs.ResultRetried, jobs.ResultRetried, jobs.ResultDead}
+	for i, r := range recorder.results {
+		if r.result != want[i] {
+			t.Errorf("result %d = %s, want %s", i, r.result, want[i])
+		}
+	}
+
+	// A requeued job gets all its attempts again
+	if err := q.Requeue(context.Background(), job.ID); err != nil {
+		t.Fatalf("Requeue() error = %v", err)
+	}
+	drain(t, q)
+	if calls != 6 {
+		t.Errorf("handler called %d times after requeue, want 6", calls)
+	}
+}
+
+func TestQueue_PermanentErrorsAndPanicsAreHandled(t *testing.T) {
+	q := newQueue(newMemoryStore(), nil)
+	calls := 0
+	q.Handle("invalid", func(ctx context.Context, job *jobs.Job) error {
+		calls++
+		var v struct{ N int }
+		return job.Decode(&v)
+	})
+	q.Handle("panics", func(ctx context.Context, job *jobs.Job) error {
+		panic("boom")
+	})
+
+	if _, err := q.Enqueue(context.Background(), "invalid", "not an object"); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if _, err := q.Enqueue(context.Background(), "panics", nil, jobs.MaxAttempts(1)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if _, err := q.Enqueue(context.Background(), "unknown", nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	drain(t, q)
+
+	if calls != 1 {
+		t.Errorf("invalid payload handled %d times, want 1", calls)
+	}
+	stats, _ := q.Stats(context.Background())
+	if stats.Dead != 3 {
+		t.Errorf("dead jobs = %d, want 3", stats.Dead)
+	}
+	if err := q.Requeue(context.Background(), "missing"); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("Requeue(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestQueue_RunStopsWithContext(t *testing.T) {
+	q := jobs.NewQueue("test", newMemoryStore(), jobs.Config{Workers: 2, PollInterval: time.Millisecond}, nil)
+	done := make(chan struct{})
+	q.Handle("noop", func(ctx context.Context, job *jobs.Job) error {
+		close(done)
+		return nil
+	})
+	if _, err := q.Enqueue(context.Background(), "noop", nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job was not run")
+	}
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := jobs.Backoff(time.Second, 30*time.Second, tt.attempts); got != tt.want {
+			t.Errorf("Backoff(attempts=%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package language_test
+
+import (
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/language"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "Could you tell me what the weather is like in Barcelona this week?", "en"},
+		{"spanish", "¿Puedes decirme qué tiempo hace en Barcelona esta semana? Gracias por la ayuda", "es"},
+		{"german", "Kannst du mir sagen, wie das Wetter in Berlin ist? Ich habe die Frage nicht verstanden", "de"},
+		{"french", "Bonjour, est-ce que vous pouvez me dire le temps qu'il fait dans la ville de Paris ?", "fr"},
+		{"russian", "Какая погода будет завтра в Москве?", "ru"},
+		{"ukrainian", "Яка погода буде завтра у Києві?", "uk"},
+		{"japanese", "明日の東京の天気はどうですか？教えてください", "ja"},
+		{"chinese", "明天北京的天气怎么样？请告诉我", "zh"},
+		{"too short", "ok thanks", ""},
+		{"code only", "```go\nfunc main() { fmt.Println(\"the value is\") }\n```", ""},
+		{"no common words", "Barcelona Madrid Valencia Sevilla", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := language.Detect(tt.text); got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect_IgnoresCode(t *testing.T) {
+	text := "Aquí tienes el ejemplo que pediste, con la función para leer el archivo:\n" +
+		"```go\n// Read the file and return the contents of it to the caller\nfunc read() {}\n```"
+	if got := language.Detect(text); got != "es" {
+		t.Errorf("Detect() = %q, want es", got)
+	}
+}
+
+func TestNameAndSame(t *testing.T) {
+	if got := language.Name("pt-BR"); got != "Portuguese" {
+		t.Errorf("Name(pt-BR) = %q, want Portuguese", got)
+	}
+	if got := language.Name("xx"); got != "xx" {
+		t.Errorf("Name(xx) = %q, want the code", got)
+	}
+	if !language.Same("es-MX", "ES") {
+		t.Error("Same(es-MX, ES) = false, want true")
+	}
+	if language.Same("pt", "es") {
+		t.Error("Same(pt, es) = true, want false")
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/8adimka/Go_AI_Assistant/internal/metrics"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
@@ -225,6 +228,11 @@ func TestRecordTwirpRequest(t *testing.T) {
 	// This should not panic
 	metrics.RecordTwirpRequest(ctx, "StartConversation", "success")
 	metrics.RecordTwirpRequest(ctx, "ContinueConversation", "error")
+	metrics.RecordTwirpLatency(ctx, "StartConversation", "success", 120*time.Millisecond)
+	metrics.RecordTwirpLatency(ctx, "DescribeConversation", "not_found", 3*time.Millisecond)
+	metrics.RecordSummaryTokens(ctx, 1200, 90)
+	metrics.RecordSummaryFaithfulness(ctx, 0.8, true)
+	metrics.RecordSummaryFaithfulness(ctx, 0.2, false)
 }
 
 func TestMetricsMiddlewareWithMultipleRequests(t *testing.T) {
@@ -289,3 +297,91 @@ func TestMetricsMiddlewareWithMultipleRequests(t *testing.T) {
 
 	t.Log("Metrics middleware successfully handles multiple different requests")
 }
+
+func TestRecordOpenAIStream(t *testing.T) {
+	ctx := context.Background()
+	reader := metric.NewManualReader()
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+
+	m, err := metrics.NewMetrics(provider.Meter("test"))
+	if err != nil {
+		t.Fatalf("Failed to create metrics: %v", err)
+	}
+
+	m.RecordOpenAIStream(ctx, "summary", "gpt-4o-mini", 400*time.Millisecond, 2*time.Second, 100)
+	// A stream without content records nothing, one without tokens no throughput
+	m.RecordOpenAIStream(ctx, "summary", "gpt-4o-mini", 0, time.Second, 10)
+	m.RecordOpenAIStream(ctx, "summary", "gpt-4o-mini", 300*time.Millisecond, 0, 0)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	histograms := make(map[string]metricdata.HistogramDataPoint[float64])
+	for _, scope := range rm.ScopeMetrics {
+		for _, recorded := range scope.Metrics {
+			if h, ok := recorded.Data.(metricdata.Histogram[float64]); ok && len(h.DataPoints) == 1 {
+				histograms[recorded.Name] = h.DataPoints[0]
+			}
+		}
+	}
+
+	ttft := histograms["openai_time_to_first_token_ms"]
+	if ttft.Count != 2 || ttft.Sum != 700 {
+		t.Errorf("time to first token: count = %d, sum = %v; want 2 and 700", ttft.Count, ttft.Sum)
+	}
+	throughput := histograms["openai_generation_tokens_per_second"]
+	if throughput.Count != 1 || throughput.Sum != 50 {
+		t.Errorf("tokens per second: count = %d, sum = %v; want 1 and 50", throughput.Count, throughput.Sum)
+	}
+}
+
+func TestRecordPromptCache(t *testing.T) {
+	ctx := context.Background()
+	reader := metric.NewManualReader()
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+
+	m, err := metrics.NewMetrics(provider.Meter("test"))
+	if err != nil {
+		t.Fatalf("Failed to create metrics: %v", err)
+	}
+
+	m.RecordPromptCache(ctx, "reply", "gpt-4.1", 3000, 2048)
+	m.RecordPromptCache(ctx, "reply", "gpt-4.1", 3000, 0)
+	// Prompts too short to be cached are not misses
+	m.RecordPromptCache(ctx, "title", "gpt-4.1", 200, 0)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	sums := make(map[string]int64)
+	for _, scope := range rm.ScopeMetrics {
+		for _, recorded := range scope.Metrics {
+			s, ok := recorded.Data.(metricdata.Sum[int64])
+			if !ok {
+				continue
+			}
+			for _, point := range s.DataPoints {
+				for _, key := range []attribute.Key{"result", "cache"} {
+					if value, ok := point.Attributes.Value(key); ok {
+						sums[recorded.Name+"/"+value.AsString()] += point.Value
+					}
+				}
+			}
+		}
+	}
+
+	want := map[string]int64{
+		"openai_prompt_cache_requests_total/hit":        1,
+		"openai_prompt_cache_requests_total/miss":       1,
+		"openai_prompt_cache_requests_total/ineligible": 1,
+		"openai_prompt_cache_tokens_total/cached":       2048,
+		"openai_prompt_cache_tokens_total/uncached":     4152,
+	}
+	for name, value := range want {
+		if sums[name] != value {
+			t.Errorf("%s = %d, want %d", name, sums[name], value)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/admin"
+	"github.com/8adimka/Go_AI_Assistant/internal/migration"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	states map[string]migration.State
+}
+
+func (s *memoryStore) ListStates(ctx context.Context) ([]*migration.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var states []*migration.State
+	for _, state := range s.states {
+		copied := state
+		states = append(states, &copied)
+	}
+	return states, nil
+}
+
+func (s *memoryStore) SaveState(ctx context.Context, state *migration.State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.ID] = *state
+	return nil
+}
+
+// fakeBuilder builds indexes when told to, through the channel of each build
+type fakeBuilder struct {
+	name string
+
+	mu      sync.Mutex
+	built   map[string]bool
+	started []string
+	results chan error
+}
+
+func newBuilder(name string) *fakeBuilder {
+	return &fakeBuilder{name: name, built: map[string]bool{}, results: make(chan error)}
+}
+
+func (b *fakeBuilder) Database() string { return b.name }
+
+func (b *fakeBuilder) Exists(ctx context.Context, collection, name string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.built[collection+"."+name], nil
+}
+
+func (b *fakeBuilder) Build(ctx context.Context, index migration.Index) error {
+	b.mu.Lock()
+	b.started = append(b.started, index.Collection+"."+index.Name())
+	b.mu.Unlock()
+
+	err := <-b.results
+	if err == nil {
+		b.mu.Lock()
+		b.built[index.Collection+"."+index.Name()] = true
+		b.mu.Unlock()
+	}
+	return err
+}
+
+func (b *fakeBuilder) Progress(ctx context.Context, collection, name string) (*migration.Progress, error) {
+	return nil, nil
+}
+
+func (b *fakeBuilder) startedBuilds() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.started...)
+}
+
+type fakeLeader bool
+
+func (l fakeLeader) IsLeader() bool { return bool(l) }
+
+var (
+	settingsIndex = migration.Index{
+		Collection: "user_settings",
+		Keys:       bson.D{{Key: "tenant_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Unique:     true,
+		Residency:  true,
+	}
+	followUpIndex = migration.Index{
+		Collection: "conversations",
+		Keys:       bson.D{{Key: "platform", Value: 1}, {Key: "last_activity", Value: 1}},
+		Residency:  true,
+		Features:   []string{migration.FeatureFollowUps},
+	}
+	usageIndex = migration.Index{
+		Collection: "usage_records",
+		Keys:       bson.D{{Key: "tenant_id", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+)
+
+// waitFor polls until the condition holds, since builds run in the background
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestIndex_Name(t *testing.T) {
+	if got := usageIndex.Name(); got != "tenant_id_1_created_at_-1" {
+		t.Errorf("Name() = %q, want the name MongoDB gives the index", got)
+	}
+}
+
+func TestCoordinator_BuildsOneIndexAtATime(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{states: map[string]migration.State{}}
+	main, eu := newBuilder("acai"), newBuilder("acai_eu")
+	c := migration.NewCoordinator(store, fakeLeader(true), []migration.Index{settingsIndex, followUpIndex, usageIndex},
+		[]migration.Builder{main, eu}, migration.Config{})
+
+	// Indexes without residency are only built in the main database
+	if states := c.States(); len(states) != 5 {
+		t.Fatalf("States() = %d migrations, want 5", len(states))
+	}
+	if c.Ready(migration.FeatureFollowUps) {
+		t.Fatal("Ready() before the build = true, want false")
+	}
+	if !c.Ready(migration.FeatureColdStorage) {
+		t.Error("Ready() of a feature no index lists = false, want true")
+	}
+
+	if err := c.Step(ctx); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	waitFor(t, "the first build", func() bool { return len(main.startedBuilds()) == 1 })
+	if err := c.Step(ctx); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	if started := main.startedBuilds(); len(started) != 1 || started[0] != "user_settings."+settingsIndex.Name() {
+		t.Errorf("builds started while one runs = %v, want only the first", started)
+	}
+	if state := c.State("acai.user_settings." + settingsIndex.Name()); state.Status != migration.StatusBuilding {
+		t.Errorf("state = %+v, want building", state)
+	}
+
+	// Each step after a build finishes starts the next one, until every database has every index
+	for step := 0; step < 5; step++ {
+		for _, b := range []*fakeBuilder{main, eu} {
+			select {
+			case b.results <- nil:
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		waitFor(t, "the build to be recorded", func() bool {
+			for _, s := range c.States() {
+				if s.Status == migration.StatusBuilding {
+					return false
+				}
+			}
+			return true
+		})
+		if err := c.Step(ctx); err != nil {
+			t.Fatalf("Step() error = %v", err)
+		}
+	}
+
+	for _, s := range c.States() {
+		if s.Status != migration.StatusReady || s.Attempts != 1 {
+			t.Errorf("state %s = %+v, want ready after one attempt", s.ID, s)
+		}
+	}
+	if !c.Ready(migration.FeatureFollowUps) {
+		t.Error("Ready() after the builds = false, want true")
+	}
+	if len(store.states) != 5 || store.states["acai_eu.conversations."+followUpIndex.Name()].Status != migration.StatusReady {
+		t.Errorf("stored states = %+v, want every migration ready", store.states)
+	}
+}
+
+func TestCoordinator_OnlyTheLeaderBuilds(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{states: map[string]migration.State{}}
+	main := newBuilder("acai")
+	follower := migration.NewCoordinator(store, fakeLeader(false), []migration.Index{followUpIndex},
+		[]migration.Builder{main}, migration.Config{})
+
+	if err := follower.Step(ctx); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	if started := main.startedBuilds(); len(started) != 0 {
+		t.Fatalf("follower started builds %v, want none", started)
+	}
+
+	// Followers pick up indexes built elsewhere
+	main.built["conversations."+followUpIndex.Name()] = true
+	if err := follower.Step(ctx); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	if !follower.Ready(migration.FeatureFollowUps) {
+		t.Error("Ready() once the index exists = false, want true")
+	}
+}
+
+func TestCoordinator_RetriesFailedBuilds(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{states: map[string]migration.State{}}
+	main := newBuilder("acai")
+	c := migration.NewCoordinator(store, fakeLeader(true), []migration.Index{followUpIndex},
+		[]migration.Builder{main}, migration.Config{MaxAttempts: 2})
+	id := "acai.conversations." + followUpIndex.Name()
+
+	fail := func() {
+		t.Helper()
+		if err := c.Step(ctx); err != nil {
+			t.Fatalf("Step() error = %v", err)
+		}
+		main.results <- errors.New("index build aborted")
+		waitFor(t, "the failure to be recorded", func() bool { return c.State(id).Status == migration.StatusFailed })
+	}
+	fail()
+	fail()
+
+	if err := c.Step(ctx); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	if started := main.startedBuilds(); len(started) != 2 {
+		t.Fatalf("builds started = %d, want 2 before giving up", len(started))
+	}
+	if state := c.State(id); state.Attempts != 2 || state.Error != "index build aborted" {
+		t.Errorf("state = %+v, want two failed attempts", state)
+	}
+
+	if _, err := c.Retry(ctx, "acai.conversations.missing"); !errors.Is(err, migration.ErrUnknownMigration) {
+		t.Errorf("Retry() of an unknown migration = %v, want ErrUnknownMigration", err)
+	}
+	state, err := c.Retry(ctx, id)
+	if err != nil || state.Status != migration.StatusPending || state.Attempts != 0 {
+		t.Fatalf("Retry() = %+v, %v, want a pending migration", state, err)
+	}
+	if err := c.Step(ctx); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	main.results <- nil
+	waitFor(t, "the retried build", func() bool { return c.Ready(migration.FeatureFollowUps) })
+}
+
+func TestAdminHandler_Retry(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{states: map[string]migration.State{}}
+	main := newBuilder("acai")
+	c := migration.NewCoordinator(store, fakeLeader(true), []migration.Index{followUpIndex},
+		[]migration.Builder{main}, migration.Config{MaxAttempts: 1})
+	id := "acai.conversations." + followUpIndex.Name()
+	if err := c.Step(ctx); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	main.results <- errors.New("index build aborted")
+	waitFor(t, "the failure to be recorded", func() bool { return c.State(id).Status == migration.StatusFailed })
+
+	// Mounted like cmd/server
+	tokens := admin.NewTokenIssuer("secret", time.Hour)
+	auth := admin.NewAuthenticator(nil, tokens, "", nil)
+	migrationAdmin := migration.NewAdminHandler(c)
+	router := mux.NewRouter()
+	routes := router.PathPrefix("/admin/migrations").Subrouter()
+	routes.Use(auth.Require(admin.RoleOperator))
+	routes.HandleFunc("", migrationAdmin.ListHandler).Methods(http.MethodGet)
+	routes.HandleFunc("/{id}/retry", migrationAdmin.RetryHandler).Methods(http.MethodPost)
+
+	serve := func(method, path string, roles ...string) int {
+		t.Helper()
+		user, err := admin.NewUser("alice", "correct horse battery", roles)
+		if err != nil {
+			t.Fatalf("NewUser() error = %v", err)
+		}
+		signed, _, err := tokens.Issue(user)
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+signed)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(http.MethodGet, "/admin/migrations", admin.RoleViewer); code != http.StatusOK {
+		t.Errorf("list as viewer: status = %d, want 200", code)
+	}
+	if code := serve(http.MethodPost, "/admin/migrations/"+id+"/retry", admin.RoleViewer); code != http.StatusForbidden {
+		t.Errorf("retry as viewer: status = %d, want 403", code)
+	}
+	if code := serve(http.MethodPost, "/admin/migrations/"+id+"/retry", admin.RoleOperator); code != http.StatusOK {
+		t.Fatalf("retry as operator: status = %d, want 200", code)
+	}
+	if state := c.State(id); state.Status != migration.StatusPending {
+		t.Errorf("state after retry = %+v, want pending", state)
+	}
+}
+
+func TestCoordinator_GatesFeatures(t *testing.T) {
+	main := newBuilder("acai")
+	c := migration.NewCoordinator(&memoryStore{states: map[string]migration.State{}}, fakeLeader(true),
+		[]migration.Index{followUpIndex}, []migration.Builder{main}, migration.Config{})
+
+	ran := false
+	task := c.Gate(migration.FeatureFollowUps, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	handler := c.Require(migration.FeatureFollowUps)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec
+	}
+
+	if err := task(context.Background()); err != nil || ran {
+		t.Errorf("gated task before the build ran = %v, error = %v, want skipped", ran, err)
+	}
+	if rec := serve(); rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("request before the build = %d, want 503 with Retry-After", rec.Code)
+	}
+
+	main.built["conversations."+followUpIndex.Name()] = true
+	if err := c.Step(context.Background()); err != nil {
+		t.Fatalf("Step() error = %v", err)
+	}
+	if err := task(context.Background()); err != nil || !ran {
+		t.Errorf("gated task after the build ran = %v, error = %v, want run", ran, err)
+	}
+	if rec := serve(); rec.Code != http.StatusNoContent {
+		t.Errorf("request after the build = %d, want it served", rec.Code)
+	}
+
+	// Without a coordinator nothing is gated
+	var disabled *migration.Coordinator
+	if !disabled.Ready(migration.FeatureFollowUps) {
+		t.Error("Ready() of a nil coordinator = false, want true")
+	}
+}
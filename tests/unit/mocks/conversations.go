@@ -0,0 +1,238 @@
+package mocks
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/twitchtv/twirp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConversationRepository is an in-memory conversation store
+// It satisfies chat.ConversationRepository and session.Repository; conversations are copied in and out,
+// so tests observe only what was stored
+type ConversationRepository struct {
+	mu            sync.Mutex
+	conversations map[primitive.ObjectID]*model.Conversation
+
+	// Err is returned by every call when set
+	Err error
+}
+
+// NewConversationRepository creates an empty repository
+func NewConversationRepository() *ConversationRepository {
+	return &ConversationRepository{conversations: map[primitive.ObjectID]*model.Conversation{}}
+}
+
+func clone(c *model.Conversation) *model.Conversation {
+	copied := *c
+	copied.Messages = slices.Clone(c.Messages)
+	return &copied
+}
+
+func (r *ConversationRepository) CreateConversation(ctx context.Context, c *model.Conversation) error {
+	return r.UpdateConversation(ctx, c)
+}
+
+func (r *ConversationRepository) DescribeConversation(ctx context.Context, id string) (*model.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, twirp.InvalidArgumentError("id", "invalid conversation ID")
+	}
+	c, ok := r.conversations[oid]
+	if !ok {
+		return nil, twirp.NotFoundError("conversation not found")
+	}
+	return clone(c), nil
+}
+
+func (r *ConversationRepository) ListConversations(ctx context.Context) ([]*model.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	out := make([]*model.Conversation, 0, len(r.conversations))
+	for _, c := range r.conversations {
+		out = append(out, clone(c))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *ConversationRepository) UpdateConversation(ctx context.Context, c *model.Conversation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	r.conversations[c.ID] = clone(c)
+	return nil
+}
+
+// AppendReply stores the conversation's fields and appends the messages to those stored, like model.Repository
+func (r *ConversationRepository) AppendReply(ctx context.Context, c *model.Conversation, messages []*model.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	stored := clone(c)
+	if existing, ok := r.conversations[c.ID]; ok {
+		if len(messages) > 0 && slices.ContainsFunc(existing.Messages, func(m *model.Message) bool { return m.ID == messages[0].ID }) {
+			return nil
+		}
+		stored.Messages = append(slices.Clone(existing.Messages), messages...)
+		stored.Instructions = existing.Instructions
+	}
+	r.conversations[c.ID] = stored
+	return nil
+}
+
+func (r *ConversationRepository) SetMessageReaction(ctx context.Context, conversationID, messageID primitive.ObjectID, reaction model.Reaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	c, ok := r.conversations[conversationID]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	for i, m := range c.Messages {
+		if m.ID == messageID {
+			updated := *m
+			updated.Reactions = slices.DeleteFunc(slices.Clone(m.Reactions), func(existing model.Reaction) bool {
+				return existing.UserID == reaction.UserID
+			})
+			updated.Reactions = append(updated.Reactions, reaction)
+			c.Messages[i] = &updated
+			return nil
+		}
+	}
+	return twirp.NotFoundError("message not found")
+}
+
+func (r *ConversationRepository) AddMessageAttachment(ctx context.Context, conversationID, messageID primitive.ObjectID, attachmentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	c, ok := r.conversations[conversationID]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	for i, m := range c.Messages {
+		if m.ID == messageID {
+			updated := *m
+			updated.AttachmentIDs = append(slices.Clone(m.AttachmentIDs), attachmentID)
+			c.Messages[i] = &updated
+			return nil
+		}
+	}
+	return twirp.NotFoundError("message not found")
+}
+
+func (r *ConversationRepository) SetConversationInstructions(ctx context.Context, id primitive.ObjectID, instructions string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	c, ok := r.conversations[id]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	c.Instructions = instructions
+	return nil
+}
+
+func (r *ConversationRepository) AddConversationMessage(ctx context.Context, id primitive.ObjectID, msg *model.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	c, ok := r.conversations[id]
+	if !ok {
+		return twirp.NotFoundError("conversation not found")
+	}
+	c.Messages = append(c.Messages, msg)
+	c.UpdatedAt = msg.CreatedAt
+	return nil
+}
+
+// FindConversationsByPlatformAndChatID returns the most recent active conversation of the chat, like model.Repository
+func (r *ConversationRepository) FindConversationsByPlatformAndChatID(ctx context.Context, platform, chatID string) ([]*model.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var latest *model.Conversation
+	for _, c := range r.conversations {
+		if c.Platform != platform || c.ChatID != chatID || !c.IsActive {
+			continue
+		}
+		if latest == nil || c.LastActivity.After(latest.LastActivity) {
+			latest = c
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	return []*model.Conversation{clone(latest)}, nil
+}
+
+func (r *ConversationRepository) ArchiveConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	var archived int64
+	for _, id := range ids {
+		if c, ok := r.conversations[id]; ok && c.IsActive {
+			c.IsActive = false
+			archived++
+		}
+	}
+	return archived, nil
+}
+
+// ReassignConversations moves a user's conversations to another user and chat, like model.Repository
+func (r *ConversationRepository) ReassignConversations(ctx context.Context, platform, fromUserID, fromChatID, toUserID, toChatID string) ([]primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	var ids []primitive.ObjectID
+	for id, c := range r.conversations {
+		if c.Platform != platform || c.UserID != fromUserID {
+			continue
+		}
+		c.UserID = toUserID
+		if fromChatID != "" && toChatID != "" && c.ChatID == fromChatID {
+			c.ChatID = toChatID
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Len returns the number of stored conversations
+func (r *ConversationRepository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.conversations)
+}
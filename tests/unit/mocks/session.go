@@ -0,0 +1,109 @@
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConversationCreator stores the conversations a SessionStore starts
+type ConversationCreator interface {
+	CreateConversation(ctx context.Context, c *model.Conversation) error
+}
+
+// SessionStore is an in-memory chat.SessionStore
+// New sessions start a conversation in Conversations; when it is nil only the session is recorded
+type SessionStore struct {
+	Conversations ConversationCreator
+
+	// Err is returned by every call when set
+	Err error
+
+	mu       sync.Mutex
+	sessions map[string]string // platform:chatID → conversation ID
+}
+
+// NewSessionStore creates a session store that starts conversations in conversations
+func NewSessionStore(conversations ConversationCreator) *SessionStore {
+	return &SessionStore{Conversations: conversations, sessions: map[string]string{}}
+}
+
+// SetSession makes the conversation the chat's session
+func (s *SessionStore) SetSession(platform, chatID, conversationID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[platform+":"+chatID] = conversationID
+}
+
+// Session returns the chat's conversation ID, or "" if it has none
+func (s *SessionStore) Session(platform, chatID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[platform+":"+chatID]
+}
+
+func (s *SessionStore) GetOrCreateSession(ctx context.Context, platform, userID, chatID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Err != nil {
+		return "", s.Err
+	}
+	key := platform + ":" + chatID
+	if id, ok := s.sessions[key]; ok {
+		return id, nil
+	}
+
+	// Like session.Manager, the new conversation is empty until the server appends the message
+	now := time.Now()
+	conv := &model.Conversation{
+		ID:           primitive.NewObjectID(),
+		Title:        model.DefaultConversationTitle,
+		Platform:     platform,
+		UserID:       userID,
+		ChatID:       chatID,
+		IsActive:     true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		LastActivity: now,
+		Messages:     []*model.Message{},
+	}
+	if s.Conversations != nil {
+		if err := s.Conversations.CreateConversation(ctx, conv); err != nil {
+			return "", err
+		}
+	}
+	s.sessions[key] = conv.ID.Hex()
+	return conv.ID.Hex(), nil
+}
+
+func (s *SessionStore) ResetSession(ctx context.Context, platform, chatID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Err != nil {
+		return "", s.Err
+	}
+	key := platform + ":" + chatID
+	id := s.sessions[key]
+	delete(s.sessions, key)
+	return id, nil
+}
+
+// ClaimSession moves the anonymous chat's session to the authenticated chat
+// Conversations are not reassigned; it returns the moved session's conversation as the only transferred one
+func (s *SessionStore) ClaimSession(ctx context.Context, platform, anonymousUserID, anonymousChatID, userID, chatID string) ([]string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Err != nil {
+		return nil, "", s.Err
+	}
+	id, ok := s.sessions[platform+":"+anonymousChatID]
+	if !ok {
+		return nil, "", nil
+	}
+	delete(s.sessions, platform+":"+anonymousChatID)
+	s.sessions[platform+":"+chatID] = id
+	return []string{id}, id, nil
+}
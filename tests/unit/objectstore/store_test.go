@@ -0,0 +1,102 @@
+package objectstore_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"billing/usage-daily-2024-05-01.csv", true},
+		{"attachments/abc/def", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../secrets", false},
+		{"a/../../b", false},
+		{"a//b", false},
+		{"a\\b", false},
+	}
+
+	for _, tt := range tests {
+		if got := objectstore.ValidKey(tt.key); got != tt.want {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLocalStore_PutGet(t *testing.T) {
+	store, err := objectstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	prefixed := objectstore.Prefixed(store, "attachments/")
+
+	if err := prefixed.Put(context.Background(), "conv/file.txt", "text/plain", []byte("hello")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	r, err := store.Get(context.Background(), "attachments/conv/file.txt")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer r.Close()
+	data, _ := io.ReadAll(r)
+	if string(data) != "hello" {
+		t.Errorf("Get() = %q, want hello", data)
+	}
+
+	if _, err := store.Get(context.Background(), "attachments/conv/missing.txt"); !errors.Is(err, objectstore.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Put(context.Background(), "../escape", "text/plain", nil); !errors.Is(err, objectstore.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestS3Store_SignsRequests(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer server.Close()
+
+	store, err := objectstore.NewS3Store(objectstore.S3Config{
+		Bucket:          "exports",
+		Region:          "eu-west-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("NewS3Store() error = %v", err)
+	}
+
+	if err := store.Put(context.Background(), "billing/usage-daily-2024-05-01.csv", "text/csv", []byte("a,b\n")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if gotPath != "/exports/billing/usage-daily-2024-05-01.csv" {
+		t.Errorf("unexpected object path %q", gotPath)
+	}
+	if gotBody != "a,b\n" {
+		t.Errorf("unexpected body %q", gotBody)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(gotAuth, "/eu-west-1/s3/aws4_request") ||
+		!strings.Contains(gotAuth, "Signature=") {
+		t.Errorf("unexpected Authorization header %q", gotAuth)
+	}
+}
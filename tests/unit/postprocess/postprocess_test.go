@@ -0,0 +1,250 @@
+package postprocess_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/postprocess"
+)
+
+func TestProfanityFilter(t *testing.T) {
+	f := postprocess.NewProfanityFilter([]string{"darn", "heck"})
+
+	got := f.Apply(context.Background(), "Darn it, what the heck. Darnell is fine.")
+	want := "D*** it, what the h***. Darnell is fine."
+	if got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+
+	empty := postprocess.NewProfanityFilter(nil)
+	if got := empty.Apply(context.Background(), "darn"); got != "darn" {
+		t.Errorf("filter without words changed the reply: %q", got)
+	}
+}
+
+func TestMarkdownSanitizer(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"html", "Hello <script>alert(1)</script><b>world</b>", "Hello alert(1)world"},
+		{"safe link", "See [docs](https://example.com/a)", "See [docs](https://example.com/a)"},
+		{"unsafe link", "Click [here](javascript:alert(1))", "Click here"},
+		{"image", "![chart](https://example.com/c.png)", "[chart](https://example.com/c.png)"},
+		{"unclosed fence", "```go\nfmt.Println()\n", "```go\nfmt.Println()\n```"},
+		{"closed fence", "```\nx\n```", "```\nx\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (postprocess.MarkdownSanitizer{}).Apply(context.Background(), tt.input); got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkRewriter(t *testing.T) {
+	r := postprocess.NewLinkRewriter(map[string]string{"utm_source": "telegram"}, []string{"example.com"})
+
+	got := r.Apply(context.Background(), "Read https://docs.example.com/guide?a=1. Or https://other.org/x")
+	want := "Read https://docs.example.com/guide?a=1&utm_source=telegram. Or https://other.org/x"
+	if got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+
+	// Existing parameters are kept
+	got = r.Apply(context.Background(), "https://example.com/?utm_source=mail")
+	if got != "https://example.com/?utm_source=mail" {
+		t.Errorf("existing parameter overwritten: %q", got)
+	}
+}
+
+func TestLengthLimiter(t *testing.T) {
+	l := postprocess.NewLengthLimiter(40)
+
+	short := "Short reply."
+	if got := l.Apply(context.Background(), short); got != short {
+		t.Errorf("short reply changed: %q", got)
+	}
+
+	long := "The first sentence is here. The second sentence runs past the limit."
+	got := l.Apply(context.Background(), long)
+	if utf8.RuneCountInString(got) > 40 {
+		t.Errorf("reply has %d characters, limit is 40: %q", utf8.RuneCountInString(got), got)
+	}
+	if got != "The first sentence is here. The second…" {
+		t.Errorf("expected a cut at the last word break, got %q", got)
+	}
+
+	unicode := strings.Repeat("привет ", 20)
+	if got := l.Apply(context.Background(), unicode); utf8.RuneCountInString(got) > 40 || !utf8.ValidString(got) {
+		t.Errorf("invalid truncation of multi-byte text: %q", got)
+	}
+}
+
+type upper struct{}
+
+func (upper) Name() string { return "upper" }
+func (upper) Apply(ctx context.Context, reply string) string {
+	return strings.ToUpper(reply)
+}
+
+func TestPipeline(t *testing.T) {
+	registry := postprocess.Registry{
+		"upper":  func(string) postprocess.Filter { return upper{} },
+		"length": func(string) postprocess.Filter { return postprocess.NewLengthLimiter(5) },
+	}
+
+	pipeline, err := registry.Build(map[string][]string{
+		"telegram":                  {"upper", "length"},
+		postprocess.DefaultPlatform: {"upper"},
+	})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if got := pipeline.Process(context.Background(), "telegram", "hello world"); got != "HELL…" {
+		t.Errorf("telegram = %q", got)
+	}
+	if got := pipeline.Process(context.Background(), "web", "hello world"); got != "HELLO WORLD" {
+		t.Errorf("default = %q", got)
+	}
+
+	if _, err := registry.Build(map[string][]string{"web": {"missing"}}); err == nil {
+		t.Error("expected an error for an unknown filter")
+	}
+}
+
+func TestDecorator(t *testing.T) {
+	decorations, err := postprocess.ParseDecorations(`{
+		"default": {"disclaimer": "\n\nAI-generated"},
+		"telegram": {"disclaimer": "\n\n_AI-generated on {{.Platform}}_"},
+		"telegram/finance": {"disclaimer": "\n\nNot financial advice ({{.Persona}})", "strip": ["(?i)\\s*returns are guaranteed\\."]}
+	}`)
+	if err != nil {
+		t.Fatalf("ParseDecorations() error = %v", err)
+	}
+
+	telegram, err := postprocess.NewDecorator("telegram", decorations, 0)
+	if err != nil {
+		t.Fatalf("NewDecorator() error = %v", err)
+	}
+	web, _ := postprocess.NewDecorator("web", decorations, 0)
+	finance := postprocess.WithPersona(context.Background(), "finance")
+
+	tests := []struct {
+		name      string
+		decorator *postprocess.Decorator
+		ctx       context.Context
+		reply     string
+		want      string
+	}{
+		{"platform template", telegram, context.Background(), "Hi", "Hi\n\n_AI-generated on telegram_"},
+		{"default for other platforms", web, context.Background(), "Hi", "Hi\n\nAI-generated"},
+		{"persona wins and strips claims", telegram, finance, "Buy now. Returns are guaranteed.", "Buy now.\n\nNot financial advice (finance)"},
+		{"persona without decoration uses default", web, finance, "Hi", "Hi\n\nAI-generated"},
+		{"already decorated", telegram, context.Background(), "Hi\n\n_AI-generated on telegram_", "Hi\n\n_AI-generated on telegram_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.decorator.Apply(tt.ctx, tt.reply); got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecorator_StreamedReply(t *testing.T) {
+	decorations, _ := postprocess.ParseDecorations(`{"default": {"disclaimer": "\n\nAI-generated"}}`)
+	d, _ := postprocess.NewDecorator("web", decorations, 0)
+	ctx := context.Background()
+
+	// A streaming transport sends the chunks as they come and the suffix after the last one;
+	// the assembled reply then passes through the pipeline without a second disclaimer
+	var streamed strings.Builder
+	for _, chunk := range []string{"The answer ", "is ", "42."} {
+		streamed.WriteString(chunk)
+	}
+	streamed.WriteString(d.Suffix(ctx))
+
+	if got := d.Apply(ctx, streamed.String()); got != "The answer is 42.\n\nAI-generated" {
+		t.Errorf("Apply() = %q", got)
+	}
+}
+
+func TestDecorator_FitsLengthLimit(t *testing.T) {
+	decorations, _ := postprocess.ParseDecorations(`{"telegram": {"disclaimer": "\n\nAI-generated"}}`)
+	d, _ := postprocess.NewDecorator("telegram", decorations, 100)
+
+	// Replies cut into platform-sized chunks keep the disclaimer whole in the last chunk
+	got := d.Apply(context.Background(), strings.Repeat("Long sentence here. ", 20))
+	if n := utf8.RuneCountInString(got); n > 100 {
+		t.Errorf("decorated reply has %d characters, want at most 100", n)
+	}
+	if !strings.HasSuffix(got, "\n\nAI-generated") {
+		t.Errorf("expected the disclaimer to be kept, got %q", got)
+	}
+}
+
+func TestDecorator_InvalidConfig(t *testing.T) {
+	if _, err := postprocess.ParseDecorations(`{"default": "AI-generated"}`); err == nil {
+		t.Error("expected an error for a decoration that is not an object")
+	}
+	if _, err := postprocess.NewDecorator("web", map[string]postprocess.Decoration{"web": {Disclaimer: "{{.Platform"}}, 0); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+	if _, err := postprocess.NewDecorator("web", map[string]postprocess.Decoration{"web": {Strip: []string{"("}}}, 0); err == nil {
+		t.Error("expected an error for an invalid strip pattern")
+	}
+}
+
+type stubTranslator struct {
+	calls    int
+	language string
+	err      error
+}
+
+func (s *stubTranslator) Translate(_ context.Context, text, code string) (string, error) {
+	s.calls++
+	s.language = code
+	if s.err != nil {
+		return "", s.err
+	}
+	return "[" + code + "] " + text, nil
+}
+
+func TestLanguageEnforcer(t *testing.T) {
+	english := "Sure, here is what the weather will be like in Madrid tomorrow: sunny and warm."
+	spanish := "Claro, mañana en Madrid hará sol y calor. ¿Quieres saber algo más de la semana?"
+
+	translator := &stubTranslator{}
+	f := postprocess.NewLanguageEnforcer(translator)
+	ctx := postprocess.WithLanguage(context.Background(), "es-ES")
+
+	if got := f.Apply(ctx, spanish); got != spanish {
+		t.Errorf("reply in the expected language changed: %q", got)
+	}
+	if got := f.Apply(ctx, "OK"); got != "OK" {
+		t.Errorf("undetectable reply changed: %q", got)
+	}
+	if got := f.Apply(context.Background(), english); got != english {
+		t.Errorf("reply without an expected language changed: %q", got)
+	}
+	if translator.calls != 0 {
+		t.Fatalf("translator called %d times, want 0", translator.calls)
+	}
+
+	if got := f.Apply(ctx, english); got != "[es-ES] "+english {
+		t.Errorf("Apply() = %q, want the translation", got)
+	}
+
+	translator.err = errors.New("boom")
+	if got := f.Apply(ctx, english); got != english {
+		t.Errorf("failed translation changed the reply: %q", got)
+	}
+}
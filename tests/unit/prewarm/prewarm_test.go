@@ -0,0 +1,80 @@
+package prewarm_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/prewarm"
+)
+
+type memoryConns struct {
+	mu     sync.Mutex
+	reused []bool
+}
+
+func (r *memoryConns) RecordUpstreamConnection(ctx context.Context, upstream string, reused bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reused = append(r.reused, reused)
+}
+
+func TestWarmer_RequestsReuseTheWarmConnection(t *testing.T) {
+	var opened atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			// The API answers unauthenticated requests with an error, which still leaves the connection open
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	transport := prewarm.NewTransport(4)
+	defer transport.CloseIdleConnections()
+	conns := &memoryConns{}
+	client := &http.Client{Transport: prewarm.Track(transport, "openai", conns)}
+	warmer := prewarm.NewWarmer(transport, server.URL+"/v1/", prewarm.IdleTimeout/2)
+
+	if err := warmer.Warm(context.Background()); err != nil {
+		t.Fatalf("Warm() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/v1/chat/completions")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if n := opened.Load(); n != 1 {
+		t.Errorf("connections opened = %d, want the warm one only", n)
+	}
+	if len(conns.reused) != 2 || !conns.reused[0] || !conns.reused[1] {
+		t.Errorf("recorded connections = %v, want two reused ones", conns.reused)
+	}
+}
+
+func TestWarmer_ReportsUnreachableAPI(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	warmer := prewarm.NewWarmer(prewarm.NewTransport(1), url, prewarm.IdleTimeout/2)
+	if err := warmer.Warm(context.Background()); err == nil {
+		t.Error("Warm() of a closed server = nil, want an error")
+	}
+}
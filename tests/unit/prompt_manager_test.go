@@ -7,6 +7,7 @@ import (
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/assistant"
 	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
 	"github.com/8adimka/Go_AI_Assistant/internal/config"
+	"github.com/openai/openai-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -54,7 +55,7 @@ func TestPromptManager_GetPrompt(t *testing.T) {
 func TestPromptManager_DefaultPrompts(t *testing.T) {
 	// Test that default prompts are properly configured
 	defaultConfigs := model.GetDefaultPromptConfigs()
-	assert.Len(t, defaultConfigs, 3)
+	assert.Len(t, defaultConfigs, 4)
 
 	// Verify each prompt has required fields
 	for _, prompt := range defaultConfigs {
@@ -77,6 +78,7 @@ func TestPromptManager_DefaultPrompts(t *testing.T) {
 	assert.True(t, promptNames[model.PromptNameTitleGeneration])
 	assert.True(t, promptNames[model.PromptNameSystemPrompt])
 	assert.True(t, promptNames[model.PromptNameUserInstruction])
+	assert.True(t, promptNames[model.PromptNameSummarization])
 }
 
 func TestPromptManager_Constants(t *testing.T) {
@@ -84,6 +86,35 @@ func TestPromptManager_Constants(t *testing.T) {
 	assert.Equal(t, "title_generation", model.PromptNameTitleGeneration)
 	assert.Equal(t, "system_prompt", model.PromptNameSystemPrompt)
 	assert.Equal(t, "user_instruction", model.PromptNameUserInstruction)
+	assert.Equal(t, "summarization", model.PromptNameSummarization)
 	assert.Equal(t, "all", model.DefaultPlatform)
 	assert.Equal(t, "all", model.DefaultUserSegment)
 }
+
+func TestPrompt_GenerationParams(t *testing.T) {
+	temperature, penalty, maxTokens := 0.2, 0.5, int64(200)
+	prompt := &assistant.Prompt{Content: "Be brief", Params: &model.GenerationParams{
+		Temperature:     &temperature,
+		PresencePenalty: &penalty,
+		MaxTokens:       &maxTokens,
+	}}
+	require.NoError(t, prompt.Params.Validate())
+
+	params := openai.ChatCompletionNewParams{MaxTokens: openai.Int(30)}
+	prompt.Apply(&params)
+	assert.Equal(t, 0.2, params.Temperature.Value)
+	assert.Equal(t, 0.5, params.PresencePenalty.Value)
+	assert.Equal(t, int64(200), params.MaxTokens.Value)
+	assert.False(t, params.FrequencyPenalty.Valid(), "unset parameters keep the model default")
+
+	// A prompt without parameters leaves the request alone
+	params = openai.ChatCompletionNewParams{MaxTokens: openai.Int(30)}
+	(&assistant.Prompt{Content: "Be brief"}).Apply(&params)
+	assert.Equal(t, int64(30), params.MaxTokens.Value)
+	assert.False(t, params.Temperature.Valid())
+
+	tooHot, negative := 2.5, int64(0)
+	err := (&model.GenerationParams{Temperature: &tooHot, MaxTokens: &negative}).Validate()
+	assert.ErrorContains(t, err, "temperature")
+	assert.ErrorContains(t, err, "max_tokens")
+}
@@ -0,0 +1,218 @@
+package quality_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/quality"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeSampler struct {
+	conversations map[string][]*model.Conversation
+	from, to      time.Time
+}
+
+func (s *fakeSampler) ActiveTenants(_ context.Context, from, to time.Time) ([]string, error) {
+	s.from, s.to = from, to
+	var tenants []string
+	for id := range s.conversations {
+		tenants = append(tenants, id)
+	}
+	return tenants, nil
+}
+
+func (s *fakeSampler) SampleActiveConversations(ctx context.Context, _, _ time.Time, size int) ([]*model.Conversation, error) {
+	conversations := s.conversations[tenant.FromContext(ctx)]
+	if len(conversations) > size {
+		conversations = conversations[:size]
+	}
+	return conversations, nil
+}
+
+// fakeScorer answers with the scores registered for the first user message of a transcript
+type fakeScorer map[string]quality.Scores
+
+func (s fakeScorer) Score(_ context.Context, transcript string) (quality.Scores, error) {
+	for question, scores := range s {
+		if strings.HasPrefix(transcript, "User: "+question) {
+			return scores, nil
+		}
+	}
+	return quality.Scores{}, errors.New("model unavailable")
+}
+
+type memoryStore struct {
+	saved map[string][]*quality.Aggregate
+}
+
+func (s *memoryStore) SaveAggregates(_ context.Context, tenantID string, _ time.Time, aggregates []*quality.Aggregate) error {
+	if s.saved == nil {
+		s.saved = make(map[string][]*quality.Aggregate)
+	}
+	s.saved[tenantID] = aggregates
+	return nil
+}
+
+func (s *memoryStore) Aggregates(_ context.Context, tenantID string, from, to time.Time) ([]*quality.Aggregate, error) {
+	var out []*quality.Aggregate
+	for _, agg := range s.saved[tenantID] {
+		if !agg.Day.Before(from) && agg.Day.Before(to) {
+			out = append(out, agg)
+		}
+	}
+	return out, nil
+}
+
+func conversation(question, persona, version string) *model.Conversation {
+	return &model.Conversation{
+		ID: primitive.NewObjectID(),
+		Messages: []*model.Message{
+			{Role: model.RoleUser, Content: question},
+			{Role: model.RoleAssistant, Content: "answer", Generation: &model.Generation{
+				Variant: model.Variant{Persona: persona, PromptVersion: version, Model: "gpt-4o"},
+			}},
+		},
+	}
+}
+
+func TestService_Run(t *testing.T) {
+	sampler := &fakeSampler{conversations: map[string][]*model.Conversation{
+		"acme": {
+			conversation("a", "support", "v1"),
+			conversation("b", "support", "v1"),
+			conversation("c", "support", "v2"),
+			conversation("invalid", "support", "v2"),
+			conversation("unavailable", "support", "v2"),
+		},
+		"globex": {
+			conversation("a", "", ""),
+			{ID: primitive.NewObjectID(), Messages: []*model.Message{{Role: model.RoleUser, Content: "unanswered"}}},
+		},
+	}}
+	scorer := fakeScorer{
+		"a":       {Helpfulness: 5, Correctness: 4, Tone: 3},
+		"b":       {Helpfulness: 3, Correctness: 4, Tone: 5},
+		"c":       {Helpfulness: 1, Correctness: 1, Tone: 1},
+		"invalid": {Helpfulness: 7, Correctness: 4, Tone: 5},
+	}
+	store := &memoryStore{}
+	service := quality.NewService(sampler, scorer, store, 10)
+
+	result, err := service.Run(context.Background(), time.Date(2024, 5, 2, 2, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if !sampler.from.Equal(day) || !sampler.to.Equal(day.AddDate(0, 0, 1)) {
+		t.Errorf("sampled [%v, %v), want the previous day", sampler.from, sampler.to)
+	}
+	want := quality.Result{Tenants: 2, Sampled: 7, Scored: 4, Failed: 2}
+	if *result != want {
+		t.Errorf("Run() = %+v, want %+v", *result, want)
+	}
+
+	byVersion := make(map[string]*quality.Aggregate)
+	for _, agg := range store.saved["acme"] {
+		byVersion[agg.PromptVersion] = agg
+	}
+	if len(byVersion) != 2 {
+		t.Fatalf("acme aggregates = %d, want one per prompt version", len(store.saved["acme"]))
+	}
+	v1 := byVersion["v1"]
+	if v1.Samples != 2 || v1.Helpfulness != 4 || v1.Correctness != 4 || v1.Tone != 4 || v1.Overall != 4 {
+		t.Errorf("v1 aggregate = %+v, want 2 samples averaging 4", v1)
+	}
+	if v1.Persona != "support" || v1.TenantID != "acme" || !v1.Day.Equal(day) {
+		t.Errorf("v1 aggregate = %+v, want support persona of acme on %v", v1, day)
+	}
+	if v2 := byVersion["v2"]; v2.Samples != 1 || v2.Overall != 1 {
+		t.Errorf("v2 aggregate = %+v, want the failed conversations left out", v2)
+	}
+	if globex := store.saved["globex"]; len(globex) != 1 || globex[0].Samples != 1 {
+		t.Errorf("globex aggregates = %+v, want the unanswered conversation skipped", globex)
+	}
+}
+
+func TestService_Series(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	store := &memoryStore{saved: map[string][]*quality.Aggregate{
+		"acme":   {{TenantID: "acme", Day: day, Samples: 3}},
+		"globex": {{TenantID: "globex", Day: day, Samples: 5}},
+	}}
+	service := quality.NewService(&fakeSampler{}, fakeScorer{}, store, 10)
+
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	points, err := service.Series(ctx, day.Add(6*time.Hour), day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Series() error = %v", err)
+	}
+	if len(points) != 1 || points[0].Samples != 3 {
+		t.Errorf("Series() = %+v, want the day of the context's tenant", points)
+	}
+}
+
+func TestAdminHandler_Series(t *testing.T) {
+	handler := quality.NewAdminHandler(quality.NewService(&fakeSampler{}, fakeScorer{}, &memoryStore{}, 10))
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"", http.StatusOK},
+		{"?from=2024-05-01T00:00:00Z&to=2024-06-01T00:00:00Z", http.StatusOK},
+		{"?from=yesterday", http.StatusBadRequest},
+		{"?from=2024-06-01T00:00:00Z&to=2024-05-01T00:00:00Z", http.StatusBadRequest},
+		{"?from=2020-01-01T00:00:00Z&to=2024-05-01T00:00:00Z", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.SeriesHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/analytics/quality"+tt.query, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%q: status = %d, want %d", tt.query, rec.Code, tt.status)
+		}
+		if tt.status == http.StatusOK && !strings.Contains(rec.Body.String(), `"points":[]`) {
+			t.Errorf("%q: body = %s, want empty points", tt.query, rec.Body.String())
+		}
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	conv := &model.Conversation{Messages: []*model.Message{
+		{Role: model.RoleUser, Content: "Hi"},
+		{Role: model.RoleSystem, Content: "Customer is a VIP"},
+		{Role: model.RoleAssistant, Content: strings.Repeat("x", 1200)},
+	}}
+
+	got := quality.Transcript(conv)
+	if !strings.HasPrefix(got, "User: Hi\n\nAssistant: ") {
+		t.Errorf("Transcript() = %q, want user and assistant messages in order", got)
+	}
+	if strings.Contains(got, "VIP") {
+		t.Error("Transcript() includes operator notes")
+	}
+	if !strings.HasSuffix(got, strings.Repeat("x", 1000)+"…") {
+		t.Error("Transcript() does not cut long messages")
+	}
+
+	if got := quality.Transcript(&model.Conversation{Messages: conv.Messages[:1]}); got != "" {
+		t.Errorf("Transcript() of unanswered conversation = %q, want empty", got)
+	}
+}
+
+func TestScores_Validate(t *testing.T) {
+	if err := (quality.Scores{Helpfulness: 5, Correctness: 1, Tone: 3}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (quality.Scores{Helpfulness: 5, Correctness: 0, Tone: 3}).Validate(); err == nil {
+		t.Error("Validate() accepted a score below the rubric")
+	}
+}
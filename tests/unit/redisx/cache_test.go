@@ -1,8 +1,10 @@
 package redisx_test
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"testing"
 	"time"
 
@@ -222,3 +224,59 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestCache_BatchArguments(t *testing.T) {
+	// Nothing listens on this port: calls that need no round trip must not reach Redis
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1})
+	cache := redisx.NewCache(client, time.Hour)
+	ctx := context.Background()
+
+	found, err := cache.MGet(ctx, nil, nil)
+	if err != nil || len(found) != 0 {
+		t.Errorf("MGet() without keys = %v, %v", found, err)
+	}
+	if _, err := cache.MGet(ctx, []string{"a", "b"}, []interface{}{new(string)}); err == nil {
+		t.Error("expected an error for misaligned keys and destinations")
+	}
+	if err := cache.MSet(ctx, nil); err != nil {
+		t.Errorf("MSet() without values error = %v", err)
+	}
+	if err := cache.Touch(ctx); err != nil {
+		t.Errorf("Touch() without keys error = %v", err)
+	}
+	if err := cache.Touch(ctx, "context:65f1"); err == nil {
+		t.Error("expected Touch() to reach the unreachable Redis")
+	}
+}
+
+type unavailable struct{ reports int }
+
+func (u *unavailable) Available() bool  { return false }
+func (u *unavailable) Report(err error) { u.reports++ }
+
+func TestCache_SkipsUnavailableRedis(t *testing.T) {
+	availability := &unavailable{}
+	redisx.SetAvailability(availability)
+	defer redisx.SetAvailability(nil)
+
+	// Nothing listens on this address: any call reaching Redis would fail
+	cache := redisx.NewCache(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"}), time.Hour)
+	ctx := context.Background()
+
+	var value string
+	if err := cache.Get(ctx, "session:telegram:1", &value); !errors.Is(err, redisx.ErrCacheMiss) {
+		t.Errorf("Get() error = %v, want a miss", err)
+	}
+	if err := cache.Set(ctx, "session:telegram:1", "conversation"); err != nil {
+		t.Errorf("Set() error = %v, want the write dropped", err)
+	}
+	if found, err := cache.MGet(ctx, []string{"a", "b"}, []interface{}{&value, &value}); err != nil || found[0] || found[1] {
+		t.Errorf("MGet() = %v, %v, want misses", found, err)
+	}
+	if err := cache.Delete(ctx, "session:telegram:1"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if availability.reports != 0 {
+		t.Errorf("%d calls reached Redis while it was unavailable", availability.reports)
+	}
+}
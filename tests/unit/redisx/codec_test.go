@@ -0,0 +1,96 @@
+package redisx_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+)
+
+type cachedMessage struct {
+	Role      string
+	Content   string
+	CreatedAt time.Time
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	messages := []cachedMessage{
+		{Role: "user", Content: "What's the weather in Madrid?", CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Role: "assistant", Content: "Sunny, 24°C."},
+	}
+
+	for _, name := range []string{redisx.CodecJSON, redisx.CodecGob, redisx.CodecProto} {
+		t.Run(name, func(t *testing.T) {
+			codec := redisx.MustCodec(name)
+
+			data, err := codec.Marshal(messages)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var got []cachedMessage
+			if err := redisx.Decode(data, &got); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, messages) {
+				t.Errorf("Decode() = %+v, want %+v", got, messages)
+			}
+
+			data, err = codec.Marshal("Weather in Madrid")
+			if err != nil {
+				t.Fatalf("Marshal(string) error = %v", err)
+			}
+			var title string
+			if err := redisx.Decode(data, &title); err != nil || title != "Weather in Madrid" {
+				t.Errorf("Decode(string) = %q, %v", title, err)
+			}
+		})
+	}
+}
+
+func TestCodecs_ReadLegacyJSON(t *testing.T) {
+	// Entries written before the codec was switched are plain JSON
+	legacy := []byte(`[{"Role":"user","Content":"hello"}]`)
+
+	var got []cachedMessage
+	if err := redisx.Decode(legacy, &got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Content != "hello" {
+		t.Errorf("Decode() = %+v", got)
+	}
+}
+
+func TestProtoCodec(t *testing.T) {
+	codec := redisx.ProtoCodec{}
+	msg := &pb.SessionMetadata{Platform: "telegram", ChatId: "42"}
+
+	data, err := codec.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !bytes.HasPrefix(data, codec.Header()) {
+		t.Errorf("protobuf entry %q lacks the codec header", data)
+	}
+
+	var got pb.SessionMetadata
+	if err := redisx.Decode(data, &got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.GetPlatform() != "telegram" || got.GetChatId() != "42" {
+		t.Errorf("Decode() = %v", &got)
+	}
+
+	var wrong string
+	if err := redisx.Decode(data, &wrong); err == nil {
+		t.Error("expected an error decoding a protobuf entry into a string")
+	}
+}
+
+func TestCodecByName_Unknown(t *testing.T) {
+	if _, err := redisx.CodecByName("msgpack"); err == nil {
+		t.Error("expected an error for an unknown codec")
+	}
+}
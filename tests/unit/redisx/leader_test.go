@@ -0,0 +1,39 @@
+package redisx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestElection_StepsDownWithoutRedis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+
+	elected := make(chan struct{}, 1)
+	election := redisx.NewElection(client, "leader:test", "i1", 30*time.Millisecond,
+		redisx.OnElected(func(ctx context.Context) { elected <- struct{}{} }))
+
+	if leads, err := election.Acquire(context.Background()); err == nil || leads {
+		t.Errorf("Acquire() = %v, %v; want an error without Redis", leads, err)
+	}
+	if _, err := election.Leader(context.Background()); err == nil {
+		t.Error("Leader() succeeded without Redis")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	election.Run(ctx)
+
+	if election.IsLeader() {
+		t.Error("IsLeader() = true without Redis")
+	}
+	select {
+	case <-elected:
+		t.Error("OnElected called without Redis")
+	default:
+	}
+}
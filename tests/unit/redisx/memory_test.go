@@ -0,0 +1,150 @@
+package redisx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryCache_SetGet(t *testing.T) {
+	ctx := context.Background()
+	cache := redisx.NewMemoryCache(time.Hour, 10)
+
+	value := []string{"a", "b"}
+	if err := cache.Set(ctx, "k", value); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Stored values are copies, so later changes by the caller are not visible
+	value[0] = "changed"
+
+	var got []string
+	if err := cache.Get(ctx, "k", &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "a" {
+		t.Errorf("got %v, want [a b]", got)
+	}
+
+	if err := cache.Get(ctx, "missing", &got); !errors.Is(err, redisx.ErrCacheMiss) {
+		t.Errorf("got %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := redisx.NewMemoryCache(time.Hour, 2)
+
+	cache.Set(ctx, "a", 1)
+	cache.Set(ctx, "b", 2)
+	var v int
+	cache.Get(ctx, "a", &v) // a is now more recent than b
+	cache.Set(ctx, "c", 3)
+
+	if cache.Len() != 2 {
+		t.Errorf("got %d entries, want 2", cache.Len())
+	}
+	if err := cache.Get(ctx, "b", &v); !errors.Is(err, redisx.ErrCacheMiss) {
+		t.Errorf("expected b to be evicted, got %v", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if err := cache.Get(ctx, key, &v); err != nil {
+			t.Errorf("expected %s to be kept, got %v", key, err)
+		}
+	}
+}
+
+func TestMemoryCache_ExpiresAndTouch(t *testing.T) {
+	ctx := context.Background()
+	cache := redisx.NewMemoryCache(50*time.Millisecond, 10)
+
+	cache.Set(ctx, "touched", 1)
+	cache.Set(ctx, "idle", 2)
+	time.Sleep(30 * time.Millisecond)
+	cache.Touch(ctx, "touched")
+	time.Sleep(30 * time.Millisecond)
+
+	var v int
+	if err := cache.Get(ctx, "touched", &v); err != nil {
+		t.Errorf("expected touched key to be kept, got %v", err)
+	}
+	if err := cache.Get(ctx, "idle", &v); !errors.Is(err, redisx.ErrCacheMiss) {
+		t.Errorf("expected idle key to expire, got %v", err)
+	}
+}
+
+func TestMemoryCache_TenantIsolation(t *testing.T) {
+	cache := redisx.NewMemoryCache(time.Hour, 10)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	cache.Set(acme, "k", "acme value")
+
+	var got string
+	if err := cache.Get(globex, "k", &got); !errors.Is(err, redisx.ErrCacheMiss) {
+		t.Errorf("expected another tenant's key to miss, got %q, %v", got, err)
+	}
+	if err := cache.Get(acme, "k", &got); err != nil || got != "acme value" {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
+
+func TestMemoryCache_MGet(t *testing.T) {
+	ctx := context.Background()
+	cache := redisx.NewMemoryCache(time.Hour, 10)
+	cache.Set(ctx, "a", 1)
+
+	var a, b int
+	found, err := cache.MGet(ctx, []string{"a", "b"}, []interface{}{&a, &b})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found[0] || found[1] || a != 1 {
+		t.Errorf("got found %v and a %d", found, a)
+	}
+}
+
+func TestTieredCache_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	remote := redisx.NewMemoryCache(time.Hour, 10)
+	remote.Set(ctx, "k", "from remote")
+	local := redisx.NewMemoryCache(time.Hour, 10)
+	cache := redisx.NewTieredCache(local, remote)
+
+	var got string
+	if err := cache.Get(ctx, "k", &got); err != nil || got != "from remote" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	// The remote value is kept locally for later reads
+	if err := local.Get(ctx, "k", &got); err != nil {
+		t.Errorf("expected a local copy, got %v", err)
+	}
+
+	cache.Set(ctx, "written", 1)
+	var v int
+	if err := remote.Get(ctx, "written", &v); err != nil || v != 1 {
+		t.Errorf("expected writes to reach the remote store, got %d, %v", v, err)
+	}
+}
+
+func TestTieredCache_RemoteUnavailable(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+	cache := redisx.NewTieredCache(redisx.NewMemoryCache(time.Hour, 10), redisx.NewCache(client, time.Hour))
+
+	if err := cache.Set(ctx, "k", "value"); err != nil {
+		t.Fatalf("expected remote failures to be tolerated on Set, got %v", err)
+	}
+	var got string
+	if err := cache.Get(ctx, "k", &got); err != nil || got != "value" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if err := cache.Get(ctx, "missing", &got); !errors.Is(err, redisx.ErrCacheMiss) {
+		t.Errorf("expected a miss when neither store has the key, got %v", err)
+	}
+}
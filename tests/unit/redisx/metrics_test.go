@@ -0,0 +1,62 @@
+package redisx_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	tests := map[string]string{
+		"title:3f2a":              "title",
+		"weather:current:3f2a":    "weather",
+		"context:65f1":            "context",
+		"t:acme:prompt:a:b:c":     "prompt",
+		"t:acme:session:tg:42":    "session",
+		"health_check":            "health_check",
+		"t:acme":                  "t",
+		"summary:65f1:10:3f2a...": "summary",
+	}
+	for key, want := range tests {
+		if got := redisx.KeyPrefix(key); got != want {
+			t.Errorf("KeyPrefix(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+type recordedRequest struct {
+	cache  string
+	result string
+}
+
+type requestRecorder struct {
+	requests []recordedRequest
+}
+
+func (r *requestRecorder) RecordCacheRequest(ctx context.Context, cache, result string) {
+	r.requests = append(r.requests, recordedRequest{cache, result})
+}
+
+func TestCache_RecordsErrors(t *testing.T) {
+	recorder := &requestRecorder{}
+	redisx.SetRecorder(recorder)
+	defer redisx.SetRecorder(nil)
+
+	// Nothing listens on this port, so the lookup fails
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1, DialTimeout: time.Second})
+	cache := redisx.NewCache(client, time.Hour)
+
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	var dest string
+	if err := cache.Get(ctx, "title:3f2a", &dest); err == nil {
+		t.Fatal("expected an error from an unreachable Redis")
+	}
+
+	if len(recorder.requests) != 1 || recorder.requests[0] != (recordedRequest{"title", redisx.ResultError}) {
+		t.Errorf("recorded %v, want one title error", recorder.requests)
+	}
+}
@@ -0,0 +1,36 @@
+package redisx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRouter_Client(t *testing.T) {
+	eu := redis.NewClient(&redis.Options{Addr: "redis-eu:6379"})
+	router, err := redisx.NewRouter(map[string]*redis.Client{"eu": eu}, map[string]string{"acme": "eu"})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	if client, ok := router.Client(tenant.WithTenant(context.Background(), "acme")); !ok || client != eu {
+		t.Error("Client() did not route acme to its storage profile")
+	}
+	if _, ok := router.Client(tenant.WithTenant(context.Background(), "globex")); ok {
+		t.Error("Client() routed a tenant without a storage profile")
+	}
+
+	var none *redisx.Router
+	if _, ok := none.Client(tenant.WithTenant(context.Background(), "acme")); ok {
+		t.Error("nil router routed a tenant")
+	}
+}
+
+func TestNewRouter_UnknownProfile(t *testing.T) {
+	if _, err := redisx.NewRouter(nil, map[string]string{"acme": "eu"}); err == nil {
+		t.Error("NewRouter() accepted a tenant with an unknown storage profile")
+	}
+}
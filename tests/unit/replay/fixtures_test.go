@@ -0,0 +1,85 @@
+package replay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+func TestFixtures_RecordThenReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(completionJSON))
+	}))
+	defer srv.Close()
+
+	path := replay.ScenarioPath(filepath.Join(t.TempDir(), "llm"), "greeting")
+	recording, err := replay.LoadFixtures(path)
+	if err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+	live := openai.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("sk-secret"),
+		option.WithMiddleware(recording.RecordMiddleware))
+	if _, err := live.Chat.Completions.New(context.Background(), params("Hi")); err != nil {
+		t.Fatalf("live request failed: %v", err)
+	}
+	recording.RecordTool("get_weather", `{"location": "Barcelona", "days": 1}`, "sunny", nil)
+
+	// A fresh process replays the saved file without reaching the server
+	fixtures, err := replay.LoadFixtures(path)
+	if err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+	if len(fixtures.Exchanges) != 1 || len(fixtures.Tools) != 1 {
+		t.Fatalf("saved %d exchanges and %d tools, want 1 of each", len(fixtures.Exchanges), len(fixtures.Tools))
+	}
+	player := openai.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("replay"), option.WithMaxRetries(0),
+		option.WithMiddleware(fixtures.ReplayMiddleware))
+	for range 2 {
+		resp, err := player.Chat.Completions.New(context.Background(), params("Hi"))
+		if err != nil || resp.Choices[0].Message.Content != "Hola!" {
+			t.Fatalf("replayed response = %v, %v, want the recorded reply", resp, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("server was called %d times, want only while recording", calls)
+	}
+
+	if _, err := player.Chat.Completions.New(context.Background(), params("Bye")); !errors.Is(err, replay.ErrNoFixture) {
+		t.Errorf("unrecorded request error = %v, want ErrNoFixture", err)
+	}
+
+	// Tool arguments match regardless of key order and whitespace
+	if execution, ok := fixtures.Tool("get_weather", `{"days":1,"location":"Barcelona"}`); !ok || execution.Output != "sunny" {
+		t.Errorf("Tool() = %+v, %v, want the recorded output", execution, ok)
+	}
+	if _, ok := fixtures.Tool("get_weather", `{"location":"Madrid"}`); ok {
+		t.Error("Tool() found a fixture for other arguments")
+	}
+}
+
+func TestFixtures_TransientErrorsAreNotRecorded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	fixtures, _ := replay.LoadFixtures(filepath.Join(t.TempDir(), "default.json"))
+	live := openai.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("sk-secret"), option.WithMaxRetries(0),
+		option.WithMiddleware(fixtures.RecordMiddleware))
+	if _, err := live.Chat.Completions.New(context.Background(), params("Hi")); err == nil {
+		t.Fatal("expected the upstream error")
+	}
+	if len(fixtures.Exchanges) != 0 {
+		t.Errorf("recorded %d exchanges, want none for a 503", len(fixtures.Exchanges))
+	}
+}
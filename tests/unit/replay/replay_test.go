@@ -0,0 +1,108 @@
+package replay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/replay"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+const completionJSON = `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4.1",
+"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hola!"}}],
+"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`
+
+func params(content string) openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Model:    openai.ChatModelGPT4_1,
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage(content)},
+	}
+}
+
+func TestRecordAndPlay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Error("expected the real request to keep its API key")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(completionJSON))
+	}))
+	defer srv.Close()
+
+	ctx, rec := replay.Start(context.Background(), &replay.Turn{ConversationID: "c1"})
+	live := openai.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("sk-secret"))
+	if _, err := live.Chat.Completions.New(ctx, params("Hi"), option.WithMiddleware(rec.Middleware)); err != nil {
+		t.Fatalf("live request failed: %v", err)
+	}
+	rec.RecordTool("get_weather", `{"location":"Barcelona"}`, "sunny", nil)
+	turn := rec.Finish("Hola!", nil)
+
+	if len(turn.Exchanges) != 1 || turn.Exchanges[0].Status != http.StatusOK {
+		t.Fatalf("expected one recorded exchange, got %+v", turn.Exchanges)
+	}
+	if turn.Exchanges[0].Response == "" || turn.Exchanges[0].Request == "" {
+		t.Fatal("expected request and response bodies to be recorded")
+	}
+	if len(turn.ToolCalls) != 1 || turn.ToolCalls[0].Output != "sunny" {
+		t.Errorf("unexpected tool calls: %+v", turn.ToolCalls)
+	}
+
+	player := replay.NewPlayer(turn.Exchanges)
+	mock := openai.NewClient(option.WithAPIKey("replay"), option.WithMaxRetries(0),
+		option.WithMiddleware(player.Middleware))
+
+	resp, err := mock.Chat.Completions.New(context.Background(), params("Hi"))
+	if err != nil {
+		t.Fatalf("replayed request failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "Hola!" {
+		t.Errorf("expected recorded reply, got %q", resp.Choices[0].Message.Content)
+	}
+	if got := replay.DivergedAt(turn.Exchanges, player.Requests()); got != -1 {
+		t.Errorf("expected identical requests, diverged at %d", got)
+	}
+
+	if _, err := mock.Chat.Completions.New(context.Background(), params("Hi again")); !errors.Is(err, replay.ErrExhausted) {
+		t.Errorf("expected ErrExhausted, got %v", err)
+	}
+	if got := replay.DivergedAt(turn.Exchanges, player.Requests()); got != 1 {
+		t.Errorf("expected divergence at the extra request, got %d", got)
+	}
+}
+
+func TestDivergedAt(t *testing.T) {
+	recorded := []replay.Exchange{
+		{Request: `{"model":"gpt-4.1","messages":[{"role":"user","content":"Hi"}]}`},
+		{Request: `{"model":"gpt-4.1","messages":[{"role":"user","content":"Bye"}]}`},
+	}
+
+	tests := []struct {
+		name     string
+		requests []string
+		want     int
+	}{
+		{"identical up to key order", []string{
+			`{"messages":[{"content":"Hi","role":"user"}],"model":"gpt-4.1"}`,
+			`{"model":"gpt-4.1","messages":[{"role":"user","content":"Bye"}]}`,
+		}, -1},
+		{"changed prompt", []string{
+			`{"model":"gpt-4.1","messages":[{"role":"user","content":"Hi"}]}`,
+			`{"model":"gpt-4.1","messages":[{"role":"user","content":"Goodbye"}]}`,
+		}, 1},
+		{"fewer requests", []string{
+			`{"model":"gpt-4.1","messages":[{"role":"user","content":"Hi"}]}`,
+		}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replay.DivergedAt(recorded, tt.requests); got != tt.want {
+				t.Errorf("DivergedAt() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/httpx"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/rest"
+	"github.com/8adimka/Go_AI_Assistant/internal/session"
+	"github.com/8adimka/Go_AI_Assistant/tests/unit/mocks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// echoAssistant titles conversations with their first message and replies with the last one
+type echoAssistant struct{}
+
+func (echoAssistant) Title(ctx context.Context, conv *model.Conversation) (string, error) {
+	return conv.Messages[0].Content, nil
+}
+
+func (echoAssistant) Reply(ctx context.Context, conv *model.Conversation) (string, error) {
+	return "You said: " + conv.Messages[len(conv.Messages)-1].Content, nil
+}
+
+func newAPI(t *testing.T) (*httptest.Server, *mocks.ConversationRepository) {
+	repo := mocks.NewConversationRepository()
+	sessions := session.NewManager(redisx.NewMemoryCache(time.Hour, 0), time.Hour, repo)
+	server := httptest.NewServer(rest.NewHandler(chat.NewServer(repo, echoAssistant{}, sessions)))
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+// call sends a JSON request and decodes the JSON response into a map
+func call(t *testing.T, server *httptest.Server, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("%s %s: invalid JSON response %q", method, path, data)
+	}
+	return resp.StatusCode, out
+}
+
+func TestREST_ConversationLifecycle(t *testing.T) {
+	server, _ := newAPI(t)
+
+	status, started := call(t, server, http.MethodPost, "/v1/conversations", `{"message":"Hello"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("got status %d, want 201: %v", status, started)
+	}
+	id, _ := started["conversation_id"].(string)
+	if id == "" || started["reply"] != "You said: Hello" || started["title"] != "Hello" {
+		t.Fatalf("unexpected response %v", started)
+	}
+
+	status, continued := call(t, server, http.MethodPost, "/v1/conversations/"+id+"/messages", `{"message":"How are you?"}`)
+	if status != http.StatusOK || continued["reply"] != "You said: How are you?" {
+		t.Fatalf("got %d %v", status, continued)
+	}
+
+	status, described := call(t, server, http.MethodGet, "/v1/conversations/"+id, "")
+	if status != http.StatusOK {
+		t.Fatalf("got status %d: %v", status, described)
+	}
+	conv := described["conversation"].(map[string]any)
+	if messages := conv["messages"].([]any); len(messages) != 4 {
+		t.Errorf("got %d messages, want 4", len(messages))
+	}
+
+	status, listed := call(t, server, http.MethodGet, "/v1/conversations", "")
+	if status != http.StatusOK || len(listed["conversations"].([]any)) != 1 {
+		t.Errorf("got %d %v", status, listed)
+	}
+
+	status, instructions := call(t, server, http.MethodPut, "/v1/conversations/"+id+"/instructions", `{"instructions":"Answer in Spanish"}`)
+	if status != http.StatusOK || instructions["instructions"] != "Answer in Spanish" {
+		t.Errorf("got %d %v", status, instructions)
+	}
+}
+
+func TestREST_Sessions(t *testing.T) {
+	server, repo := newAPI(t)
+	metadata := `"session_metadata":{"platform":"telegram","user_id":"u1","chat_id":"c1"}`
+
+	for _, message := range []string{"Hi", "Again"} {
+		status, resp := call(t, server, http.MethodPost, "/v1/sessions/messages", `{"message":"`+message+`",`+metadata+`}`)
+		if status != http.StatusOK || resp["reply"] != "You said: "+message {
+			t.Fatalf("got %d %v", status, resp)
+		}
+	}
+	if repo.Len() != 1 {
+		t.Errorf("got %d conversations, want both messages in one", repo.Len())
+	}
+
+	status, reset := call(t, server, http.MethodPost, "/v1/sessions/reset", `{`+metadata+`}`)
+	if status != http.StatusOK || reset["archived_conversation_id"] == nil {
+		t.Errorf("got %d %v", status, reset)
+	}
+
+	status, missing := call(t, server, http.MethodPost, "/v1/sessions/messages", `{"message":"Hi"}`)
+	if status != http.StatusBadRequest || missing["code"] != "invalid_argument" {
+		t.Errorf("got %d %v, want invalid_argument", status, missing)
+	}
+}
+
+func TestREST_Errors(t *testing.T) {
+	server, _ := newAPI(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"validation error from the server", http.MethodPost, "/v1/conversations", `{"message":"  "}`, http.StatusBadRequest, "invalid_argument"},
+		{"malformed JSON", http.MethodPost, "/v1/conversations", `{"message":`, http.StatusBadRequest, "malformed"},
+		{"unknown conversation", http.MethodGet, "/v1/conversations/000000000000000000000000", "", http.StatusNotFound, "not_found"},
+		{"unknown route", http.MethodGet, "/v1/unknown", "", http.StatusNotFound, "bad_route"},
+		{"wrong method", http.MethodDelete, "/v1/conversations", "", http.StatusNotFound, "bad_route"},
+		{"disabled feature", http.MethodGet, "/v1/users/telegram/u1/settings", "", http.StatusNotImplemented, "unimplemented"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := call(t, server, tt.method, tt.path, tt.body)
+			if status != tt.wantStatus || resp["code"] != tt.wantCode {
+				t.Errorf("got %d %v, want %d %s", status, resp, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+// rpcRecorder keeps the RPC measurements it receives
+type rpcRecorder struct {
+	mu       sync.Mutex
+	requests []string // "<method> <status>"
+}
+
+func (r *rpcRecorder) RecordTwirpRequest(ctx context.Context, method, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, method+" "+status)
+}
+
+func (r *rpcRecorder) RecordTwirpLatency(ctx context.Context, method, status string, duration time.Duration) {
+}
+
+func TestREST_RunsTwirpHooks(t *testing.T) {
+	repo := mocks.NewConversationRepository()
+	recorder := &rpcRecorder{}
+	handler := rest.NewHandler(chat.NewServer(repo, echoAssistant{}, nil),
+		rest.WithServerHooks(httpx.TwirpHooks(recorder)),
+		rest.WithServerInterceptors(httpx.TraceErrors()))
+	// Requests are traced, as they are behind the OpenTelemetry middleware
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}, TraceFlags: trace.FlagsSampled})
+		handler.ServeHTTP(w, r.WithContext(trace.ContextWithSpanContext(r.Context(), spanContext)))
+	}))
+	t.Cleanup(server.Close)
+
+	if status, _ := call(t, server, http.MethodPost, "/v1/conversations", `{"message":"Hello"}`); status != http.StatusCreated {
+		t.Fatalf("got status %d, want 201", status)
+	}
+	status, failed := call(t, server, http.MethodGet, "/v1/conversations/"+primitive.NewObjectID().Hex(), "")
+	if status != http.StatusNotFound {
+		t.Fatalf("got status %d, want 404", status)
+	}
+	if meta, _ := failed["meta"].(map[string]any); meta[httpx.TraceIDMeta] != traceID.String() {
+		t.Errorf("error meta = %v, want the trace ID", failed["meta"])
+	}
+	call(t, server, http.MethodDelete, "/v1/conversations", "")
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	want := []string{"StartConversation success", "DescribeConversation not_found", "unknown bad_route"}
+	if !slices.Equal(recorder.requests, want) {
+		t.Errorf("recorded requests = %q, want %q", recorder.requests, want)
+	}
+}
@@ -3,10 +3,13 @@ package unit
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/8adimka/Go_AI_Assistant/internal/retry"
+	"github.com/openai/openai-go"
 )
 
 // TestRetryMechanism tests the retry mechanism with different scenarios
@@ -155,3 +158,131 @@ func TestRetryContextCancellation(t *testing.T) {
 	// This is acceptable behavior
 	t.Logf("Context cancellation test completed with %d calls", callCount)
 }
+
+// rateLimitError builds the error OpenAI returns for a 429 response with the given headers
+func rateLimitError(header http.Header) error {
+	return &openai.Error{
+		StatusCode: http.StatusTooManyRequests,
+		Request:    &http.Request{Method: http.MethodPost, URL: &url.URL{Path: "/chat/completions"}},
+		Response:   &http.Response{StatusCode: http.StatusTooManyRequests, Header: header},
+	}
+}
+
+// TestParseRetryAfter tests the Retry-After formats OpenAI sends
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"milliseconds", http.Header{"Retry-After-Ms": {"250"}}, 250 * time.Millisecond, true},
+		{"seconds", http.Header{"Retry-After": {"2"}}, 2 * time.Second, true},
+		{"milliseconds win", http.Header{"Retry-After-Ms": {"100"}, "Retry-After": {"2"}}, 100 * time.Millisecond, true},
+		{"missing", http.Header{}, 0, false},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retry.ParseRetryAfter(tt.header)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ParseRetryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	date := http.Header{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}
+	if got, ok := retry.ParseRetryAfter(date); !ok || got < 59*time.Minute {
+		t.Errorf("ParseRetryAfter(date) = %v, %v, want about an hour", got, ok)
+	}
+}
+
+// TestRetryHonorsServerDelay tests that retries wait for the time the server asked for
+func TestRetryHonorsServerDelay(t *testing.T) {
+	config := retry.RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Millisecond,
+	}
+
+	callCount := 0
+	start := time.Now()
+	result, err := retry.RetryWithResult(context.Background(), config, func() (string, error) {
+		callCount++
+		if callCount == 1 {
+			return "", rateLimitError(http.Header{"Retry-After-Ms": {"50"}})
+		}
+		return "ok", nil
+	})
+
+	if err != nil || result != "ok" {
+		t.Fatalf("RetryWithResult() = %q, %v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Retried after %v, want at least the 50ms the server asked for", elapsed)
+	}
+}
+
+// TestRetryGivesUpOnLongServerDelay tests that a retry the server schedules too late is not waited for
+func TestRetryGivesUpOnLongServerDelay(t *testing.T) {
+	callCount := 0
+	start := time.Now()
+	_, err := retry.RetryWithResult(context.Background(), retry.DefaultConfig(), func() (string, error) {
+		callCount++
+		return "", rateLimitError(http.Header{"Retry-After": {"3600"}})
+	})
+
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	if callCount != 1 || time.Since(start) > time.Second {
+		t.Errorf("Expected to give up at once, made %d calls in %v", callCount, time.Since(start))
+	}
+}
+
+// TestLimiterBlocksWhenLimitExhausted tests that an exhausted rate limit holds requests until it resets
+func TestLimiterBlocksWhenLimitExhausted(t *testing.T) {
+	limiter := retry.NewLimiter()
+	limiter.Observe(http.StatusOK, http.Header{
+		"X-Ratelimit-Remaining-Requests": {"0"},
+		"X-Ratelimit-Reset-Requests":     {"50ms"},
+	})
+
+	start := time.Now()
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Wait() returned after %v, want the limit to reset first", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.Observe(http.StatusTooManyRequests, http.Header{"Retry-After": {"10"}})
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+}
+
+// TestLimiterMiddleware tests that the middleware feeds response headers to the limiter
+func TestLimiterMiddleware(t *testing.T) {
+	limiter := retry.NewLimiter()
+	next := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After-Ms": {"50"}},
+		}, nil
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil)
+	if _, err := limiter.Middleware(req, next); err != nil {
+		t.Fatalf("Middleware() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Wait() succeeded, want the 429 to hold requests")
+	}
+}
@@ -0,0 +1,408 @@
+package rollout_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/audit"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/rollout"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryStore struct {
+	rollouts  map[primitive.ObjectID]*rollout.Rollout
+	active    string
+	versions  map[string]bool
+	activated [][2]string
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		rollouts: make(map[primitive.ObjectID]*rollout.Rollout),
+		active:   "v1",
+		versions: map[string]bool{"v1": true, "v2": true},
+	}
+}
+
+func (s *memoryStore) CreateRollout(ctx context.Context, r *rollout.Rollout) error {
+	if running, _ := s.RunningRollout(ctx); running != nil {
+		return rollout.ErrRolloutRunning
+	}
+	copied := *r
+	s.rollouts[r.ID] = &copied
+	return nil
+}
+
+func (s *memoryStore) GetRollout(ctx context.Context, id string) (*rollout.Rollout, error) {
+	for _, r := range s.rollouts {
+		if r.ID.Hex() == id && r.TenantID == tenant.FromContext(ctx) {
+			copied := *r
+			return &copied, nil
+		}
+	}
+	return nil, rollout.ErrRolloutNotFound
+}
+
+func (s *memoryStore) ListRollouts(context.Context, int64) ([]*rollout.Rollout, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) RunningRollout(ctx context.Context) (*rollout.Rollout, error) {
+	for _, r := range s.rollouts {
+		if r.Status == rollout.StatusRunning && r.TenantID == tenant.FromContext(ctx) {
+			copied := *r
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) PendingRollouts(context.Context) ([]*rollout.Rollout, error) {
+	var pending []*rollout.Rollout
+	for _, r := range s.rollouts {
+		if r.Status == rollout.StatusRunning || (r.Status == rollout.StatusRolledBack && !r.Settled) {
+			copied := *r
+			pending = append(pending, &copied)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memoryStore) UpdateRollout(_ context.Context, r *rollout.Rollout, status string) error {
+	stored, ok := s.rollouts[r.ID]
+	if !ok || stored.Status != status {
+		return rollout.ErrRolloutNotFound
+	}
+	copied := *r
+	s.rollouts[r.ID] = &copied
+	return nil
+}
+
+func (s *memoryStore) ActivePromptVersion(context.Context) (string, error) {
+	return s.active, nil
+}
+
+func (s *memoryStore) PromptVersionExists(_ context.Context, version string) (bool, error) {
+	return s.versions[version], nil
+}
+
+func (s *memoryStore) ActivatePromptVersion(_ context.Context, from, to string) error {
+	s.activated = append(s.activated, [2]string{from, to})
+	s.active = to
+	return nil
+}
+
+type fixedMetrics map[string]*rollout.Metrics
+
+func (m fixedMetrics) VersionMetrics(_ context.Context, version string, _ time.Time) (*rollout.Metrics, error) {
+	if metrics, ok := m[version]; ok {
+		copied := *metrics
+		return &copied, nil
+	}
+	return &rollout.Metrics{}, nil
+}
+
+type repins struct {
+	calls []string
+}
+
+func (p *repins) RepinPromptVersion(ctx context.Context, from, to string, _ time.Time) (int64, error) {
+	p.calls = append(p.calls, fmt.Sprintf("%s:%s->%s", tenant.FromContext(ctx), from, to))
+	return 1, nil
+}
+
+type auditLog struct {
+	entries []*audit.Entry
+}
+
+func (l *auditLog) Record(_ context.Context, entry *audit.Entry) error {
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func (l *auditLog) actions() []string {
+	var actions []string
+	for _, e := range l.entries {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+var defaults = rollout.Config{
+	Stages:       []int{10, 50, 100},
+	StageMinutes: 60,
+	Thresholds:   rollout.Thresholds{MinReplies: 20, MaxErrorRate: 0.1, MaxNegativeRate: 0.3, MaxLatencyIncrease: 0.5},
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	healthy := rollout.Metrics{Replies: 100, Failed: 2, Positive: 20, Negative: 2, AvgLatencyMs: 1200}
+	baseline := rollout.Metrics{Replies: 900, AvgLatencyMs: 1000}
+
+	tests := []struct {
+		name         string
+		stage        int
+		stageStarted time.Time
+		candidate    rollout.Metrics
+		want         rollout.Verdict
+	}{
+		{"too few replies", 0, now.Add(-2 * time.Hour), rollout.Metrics{Replies: 5, Failed: 5}, rollout.VerdictHold},
+		{"errors", 0, now, rollout.Metrics{Replies: 80, Failed: 20}, rollout.VerdictRollback},
+		{"negative feedback", 0, now, rollout.Metrics{Replies: 100, Positive: 6, Negative: 4}, rollout.VerdictRollback},
+		{"slow", 0, now, rollout.Metrics{Replies: 100, AvgLatencyMs: 1600}, rollout.VerdictRollback},
+		{"stage still running", 0, now.Add(-30 * time.Minute), healthy, rollout.VerdictHold},
+		{"stage passed", 0, now.Add(-time.Hour), healthy, rollout.VerdictAdvance},
+		{"last stage passed", 2, now.Add(-time.Hour), healthy, rollout.VerdictPromote},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &rollout.Rollout{
+				Stages:         defaults.Stages,
+				Stage:          tt.stage,
+				StageMinutes:   defaults.StageMinutes,
+				StageStartedAt: tt.stageStarted,
+				Thresholds:     defaults.Thresholds,
+			}
+			verdict, reason := rollout.Evaluate(r, tt.candidate, baseline, now)
+			if verdict != tt.want {
+				t.Errorf("Evaluate() = %s (%s), want %s", verdict, reason, tt.want)
+			}
+			if (verdict == rollout.VerdictRollback) != (reason != "") {
+				t.Errorf("Evaluate() reason = %q for %s", reason, verdict)
+			}
+		})
+	}
+}
+
+func TestRollout_Serves(t *testing.T) {
+	r := &rollout.Rollout{ID: primitive.NewObjectID(), Stages: []int{10, 50, 100}}
+	served := make(map[string]bool)
+	for range 1000 {
+		id := primitive.NewObjectID().Hex()
+		served[id] = r.Serves(id)
+	}
+
+	count := 0
+	for _, ok := range served {
+		if ok {
+			count++
+		}
+	}
+	if count < 50 || count > 150 {
+		t.Errorf("served %d of 1000 conversations at 10%%", count)
+	}
+
+	// Conversations given the candidate keep it as the share grows
+	r.Stage = 1
+	for id, ok := range served {
+		if ok && !r.Serves(id) {
+			t.Fatalf("conversation %s lost the candidate at 50%%", id)
+		}
+	}
+	r.Stage = 2
+	for id := range served {
+		if !r.Serves(id) {
+			t.Fatalf("conversation %s not given the candidate at 100%%", id)
+		}
+	}
+}
+
+func TestParseStages(t *testing.T) {
+	stages, err := rollout.ParseStages([]string{"5", " 25", "100"})
+	if err != nil || fmt.Sprint(stages) != "[5 25 100]" {
+		t.Errorf("ParseStages() = %v, %v", stages, err)
+	}
+	for _, values := range [][]string{nil, {"five"}, {"50", "25", "100"}, {"5", "50"}, {"0", "100"}, {"50", "150"}} {
+		if _, err := rollout.ParseStages(values); err == nil {
+			t.Errorf("ParseStages(%q): expected an error", values)
+		}
+	}
+}
+
+func TestService_Start(t *testing.T) {
+	store := newMemoryStore()
+	log := &auditLog{}
+	service := rollout.NewService(store, fixedMetrics{}, &repins{}, log, defaults)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	r, err := service.Start(ctx, rollout.StartRequest{Candidate: "v2"}, "admin:alice")
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if r.Baseline != "v1" || r.TenantID != "acme" || r.Percent() != 10 || r.Status != rollout.StatusRunning {
+		t.Errorf("started %+v", r)
+	}
+	if len(log.entries) != 1 || log.entries[0].Action != rollout.ActionStarted || log.entries[0].Actor != "admin:alice" {
+		t.Errorf("audit entries = %+v", log.entries)
+	}
+
+	if _, err := service.Start(ctx, rollout.StartRequest{Candidate: "v2"}, ""); !errors.Is(err, rollout.ErrRolloutRunning) {
+		t.Errorf("second Start() error = %v, want ErrRolloutRunning", err)
+	}
+
+	invalid := []rollout.StartRequest{
+		{},
+		{Candidate: "v3"},
+		{Candidate: "v1"},
+		{Candidate: "v2", Stages: []int{50, 10}},
+		{Candidate: "v2", Thresholds: &rollout.Thresholds{MaxErrorRate: -1}},
+	}
+	for _, req := range invalid {
+		other := tenant.WithTenant(context.Background(), "other")
+		if _, err := service.Start(other, req, ""); !errors.Is(err, rollout.ErrInvalidRollout) {
+			t.Errorf("Start(%+v) error = %v, want ErrInvalidRollout", req, err)
+		}
+	}
+}
+
+func TestService_Select(t *testing.T) {
+	store := newMemoryStore()
+	service := rollout.NewService(store, fixedMetrics{}, &repins{}, nil, rollout.Config{
+		Stages: []int{100}, StageMinutes: 60,
+	})
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	if _, ok := service.Select(ctx, "conversation"); ok {
+		t.Error("Select() gave a version without a rollout")
+	}
+	// Without a rollout the lookup is cached, so a new tenant is used to see the started one
+	ctx = tenant.WithTenant(context.Background(), "beta")
+	if _, err := service.Start(ctx, rollout.StartRequest{Candidate: "v2"}, ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if version, ok := service.Select(ctx, "conversation"); !ok || version != "v2" {
+		t.Errorf("Select() = %q, %v, want the candidate at 100%%", version, ok)
+	}
+	if _, ok := service.Select(tenant.WithTenant(context.Background(), "acme"), "conversation"); ok {
+		t.Error("Select() gave another tenant's candidate")
+	}
+}
+
+func TestService_Evaluate(t *testing.T) {
+	healthy := &rollout.Metrics{Replies: 100, Positive: 10, AvgLatencyMs: 1000}
+
+	t.Run("advances and promotes", func(t *testing.T) {
+		store := newMemoryStore()
+		log := &auditLog{}
+		service := rollout.NewService(store, fixedMetrics{"v1": healthy, "v2": healthy}, &repins{}, log, defaults)
+		r, err := service.Start(tenant.WithTenant(context.Background(), "acme"), rollout.StartRequest{Candidate: "v2"}, "")
+		if err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+
+		now := r.CreatedAt
+		for range defaults.Stages {
+			now = now.Add(time.Hour)
+			if _, err := service.Evaluate(context.Background(), now); err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+		}
+		stored := store.rollouts[r.ID]
+		if stored.Status != rollout.StatusPromoted || store.active != "v2" {
+			t.Errorf("status = %s with %s active, want promoted with v2", stored.Status, store.active)
+		}
+		want := []string{rollout.ActionStarted, rollout.ActionAdvanced, rollout.ActionAdvanced, rollout.ActionPromoted}
+		if fmt.Sprint(log.actions()) != fmt.Sprint(want) {
+			t.Errorf("audit actions = %v, want %v", log.actions(), want)
+		}
+	})
+
+	t.Run("rolls back and settles", func(t *testing.T) {
+		store := newMemoryStore()
+		log := &auditLog{}
+		pins := &repins{}
+		failing := &rollout.Metrics{Replies: 50, Failed: 50}
+		service := rollout.NewService(store, fixedMetrics{"v1": healthy, "v2": failing}, pins, log, defaults)
+		r, err := service.Start(tenant.WithTenant(context.Background(), "acme"), rollout.StartRequest{Candidate: "v2"}, "")
+		if err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+
+		result, err := service.Evaluate(context.Background(), r.CreatedAt.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		stored := store.rollouts[r.ID]
+		if result.RolledBack != 1 || stored.Status != rollout.StatusRolledBack || stored.Reason == "" {
+			t.Fatalf("result = %+v, rollout = %+v", result, stored)
+		}
+		if fmt.Sprint(pins.calls) != "[acme:v2->v1]" {
+			t.Errorf("repins = %v", pins.calls)
+		}
+		if last := log.entries[len(log.entries)-1]; last.Action != rollout.ActionRolledBack || last.After != stored.Reason {
+			t.Errorf("last audit entry = %+v", last)
+		}
+
+		// Conversations given the candidate by instances that had not seen the rollback are moved later
+		result, _ = service.Evaluate(context.Background(), r.CreatedAt.Add(time.Minute+10*time.Second))
+		if result.Settled != 0 {
+			t.Errorf("settled before instances looked the rollout up again")
+		}
+		result, _ = service.Evaluate(context.Background(), r.CreatedAt.Add(2*time.Minute))
+		if result.Settled != 1 || !store.rollouts[r.ID].Settled || len(pins.calls) != 2 {
+			t.Errorf("result = %+v, repins = %v", result, pins.calls)
+		}
+	})
+}
+
+func TestService_Rollback(t *testing.T) {
+	store := newMemoryStore()
+	log := &auditLog{}
+	service := rollout.NewService(store, fixedMetrics{}, &repins{}, log, defaults)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	r, err := service.Start(ctx, rollout.StartRequest{Candidate: "v2"}, "")
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if _, err := service.Rollback(tenant.WithTenant(context.Background(), "other"), r.ID.Hex(), "", ""); !errors.Is(err, rollout.ErrRolloutNotFound) {
+		t.Errorf("Rollback() from another tenant error = %v", err)
+	}
+	rolled, err := service.Rollback(ctx, r.ID.Hex(), "", "admin:bob")
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if rolled.Status != rollout.StatusRolledBack || log.entries[len(log.entries)-1].Actor != "admin:bob" {
+		t.Errorf("rolled back %+v", rolled)
+	}
+	if _, err := service.Rollback(ctx, r.ID.Hex(), "", ""); !errors.Is(err, rollout.ErrRolloutNotFound) {
+		t.Errorf("second Rollback() error = %v, want ErrRolloutNotFound", err)
+	}
+}
+
+type variantStore []*model.VariantStats
+
+func (s variantStore) CompareVariants(context.Context, model.ConversationFilter) ([]*model.VariantStats, error) {
+	return s, nil
+}
+
+type failureCounter int64
+
+func (c failureCounter) CountFailedReplies(context.Context, string, time.Time) (int64, error) {
+	return int64(c), nil
+}
+
+func TestReplyMetrics(t *testing.T) {
+	source := rollout.ReplyMetrics{
+		Variants: variantStore{
+			{Variant: model.Variant{PromptVersion: "v2", Model: "gpt-4.1"}, Replies: 30, AvgLatencyMs: 1000, Positive: 3, Negative: 1},
+			{Variant: model.Variant{PromptVersion: "v2", Model: "gpt-4o-mini"}, Replies: 10, AvgLatencyMs: 2000, Negative: 1},
+			{Variant: model.Variant{Model: "gpt-4.1"}, Replies: 50, AvgLatencyMs: 9000}, // Built-in fallback prompt
+		},
+		Failures: failureCounter(4),
+	}
+
+	m, err := source.VersionMetrics(context.Background(), "v2", time.Time{})
+	if err != nil {
+		t.Fatalf("VersionMetrics() error = %v", err)
+	}
+	want := rollout.Metrics{Replies: 40, Failed: 4, Positive: 3, Negative: 2, AvgLatencyMs: 1250}
+	if *m != want {
+		t.Errorf("VersionMetrics() = %+v, want %+v", *m, want)
+	}
+}
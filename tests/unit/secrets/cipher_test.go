@@ -0,0 +1,72 @@
+package secrets_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+)
+
+func newCipher(t *testing.T, fill byte) *secrets.Cipher {
+	t.Helper()
+	c, err := secrets.NewCipher(bytes.Repeat([]byte{fill}, secrets.KeySize))
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	return c
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c := newCipher(t, 1)
+
+	encrypted, err := c.Encrypt("sk-tenant-secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if bytes.Contains([]byte(encrypted), []byte("sk-tenant-secret")) {
+		t.Fatal("ciphertext contains the plaintext")
+	}
+
+	again, _ := c.Encrypt("sk-tenant-secret")
+	if again == encrypted {
+		t.Error("expected a fresh nonce for every encryption")
+	}
+
+	decrypted, err := c.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if decrypted != "sk-tenant-secret" {
+		t.Errorf("Decrypt() = %q, want %q", decrypted, "sk-tenant-secret")
+	}
+}
+
+func TestCipher_RejectsForeignCiphertext(t *testing.T) {
+	encrypted, err := newCipher(t, 1).Encrypt("sk-tenant-secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	for name, input := range map[string]string{
+		"wrong key":  encrypted,
+		"not base64": "%%%",
+		"too short":  base64.StdEncoding.EncodeToString([]byte("abc")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newCipher(t, 2).Decrypt(input); !errors.Is(err, secrets.ErrInvalidCiphertext) {
+				t.Errorf("expected ErrInvalidCiphertext, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewCipherFromBase64(t *testing.T) {
+	if _, err := secrets.NewCipherFromBase64(base64.StdEncoding.EncodeToString(make([]byte, 16))); err == nil {
+		t.Error("expected an error for a 16-byte key")
+	}
+	if _, err := secrets.NewCipherFromBase64(base64.StdEncoding.EncodeToString(make([]byte, 32))); err != nil {
+		t.Errorf("unexpected error for a 32-byte key: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package seed_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/assistant"
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/seed"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryConversations struct {
+	conversations []*model.Conversation
+}
+
+func (m *memoryConversations) CreateConversation(ctx context.Context, c *model.Conversation) error {
+	m.conversations = append(m.conversations, c)
+	return nil
+}
+
+func (m *memoryConversations) FindConversationRefs(ctx context.Context, f model.ConversationFilter, after primitive.ObjectID, limit int) ([]*model.Conversation, error) {
+	var page []*model.Conversation
+	for _, c := range m.conversations {
+		if slices.Contains(c.Tags, f.Tag) && len(page) < limit {
+			page = append(page, c)
+		}
+	}
+	return page, nil
+}
+
+func (m *memoryConversations) DeleteConversations(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	before := len(m.conversations)
+	m.conversations = slices.DeleteFunc(m.conversations, func(c *model.Conversation) bool {
+		return slices.Contains(ids, c.ID)
+	})
+	return int64(before - len(m.conversations)), nil
+}
+
+type memorySettings map[string]*settings.Settings
+
+func (m memorySettings) SaveSettings(ctx context.Context, s *settings.Settings) error {
+	m[s.Platform+":"+s.UserID] = s
+	return nil
+}
+
+type memoryPrompts map[string]*model.PromptConfig
+
+func (m memoryPrompts) UpsertPrompt(ctx context.Context, p *model.PromptConfig) error {
+	m[p.Name+":"+p.UserSegment] = p
+	return nil
+}
+
+func TestSeeder_Seed(t *testing.T) {
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	conversations := &memoryConversations{}
+	users := memorySettings{}
+	prompts := memoryPrompts{}
+	seeder := seed.NewSeeder(conversations, users, prompts, assistant.NewMock(),
+		seed.Config{Users: 6, ConversationsPerUser: 2, Days: 7, Seed: 42})
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	summary, err := seeder.Seed(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Users != 6 || len(users) != 6 {
+		t.Errorf("seeded %d users, stored %d, want 6", summary.Users, len(users))
+	}
+	if summary.Conversations != 12 || len(conversations.conversations) != 12 {
+		t.Errorf("seeded %d conversations, stored %d, want 12", summary.Conversations, len(conversations.conversations))
+	}
+	if summary.Prompts != len(prompts) || prompts[model.PromptNameSystemPrompt+":concise"] == nil {
+		t.Errorf("prompts = %v, want the defaults and a system prompt per persona", prompts)
+	}
+	for _, u := range users {
+		if u.TenantID != "acme" {
+			t.Errorf("user %s has tenant %q, want acme", u.UserID, u.TenantID)
+		}
+		if err := u.Validate(); err != nil {
+			t.Errorf("user %s has invalid settings: %v", u.UserID, err)
+		}
+	}
+
+	messages := 0
+	for _, c := range conversations.conversations {
+		messages += len(c.Messages)
+		if c.Title == "" || c.Title == model.DefaultConversationTitle {
+			t.Errorf("conversation %s has no title", c.ID.Hex())
+		}
+		if !slices.Contains(c.Tags, seed.DemoTag) {
+			t.Errorf("conversation %s is not tagged %s", c.ID.Hex(), seed.DemoTag)
+		}
+		if c.CreatedAt.Before(now.Add(-7*24*time.Hour)) || c.CreatedAt.After(now) {
+			t.Errorf("conversation %s created at %v, want within the last 7 days", c.ID.Hex(), c.CreatedAt)
+		}
+		for i, m := range c.Messages {
+			want := model.RoleUser
+			if i%2 == 1 {
+				want = model.RoleAssistant
+			}
+			if m.Role != want || m.Content == "" {
+				t.Errorf("conversation %s message %d = %s %q, want a %s message", c.ID.Hex(), i, m.Role, m.Content, want)
+			}
+		}
+	}
+	if summary.Messages != messages {
+		t.Errorf("summary counts %d messages, stored %d", summary.Messages, messages)
+	}
+}
+
+func TestSeeder_Reset(t *testing.T) {
+	ctx := context.Background()
+	other := &model.Conversation{ID: primitive.NewObjectID(), Tags: []string{"vip"}}
+	conversations := &memoryConversations{conversations: []*model.Conversation{other}}
+	seeder := seed.NewSeeder(conversations, memorySettings{}, memoryPrompts{}, assistant.NewMock(),
+		seed.Config{Users: 2, ConversationsPerUser: 2})
+
+	if _, err := seeder.Seed(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := seeder.Reset(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 4 || len(conversations.conversations) != 1 || conversations.conversations[0] != other {
+		t.Errorf("Reset() deleted %d, left %d conversations, want only the non-demo conversation", deleted, len(conversations.conversations))
+	}
+}
+
+func TestMockAssistant(t *testing.T) {
+	ctx := context.Background()
+	mock := assistant.NewMock()
+	conv := &model.Conversation{
+		ID:       primitive.NewObjectID(),
+		Messages: []*model.Message{{Role: model.RoleUser, Content: "What's the weather like in Barcelona today?"}},
+	}
+
+	title, err := mock.Title(ctx, conv)
+	if err != nil || title != "What's the Weather Like in" {
+		t.Errorf("Title() = %q, %v", title, err)
+	}
+
+	first, err := mock.Reply(ctx, conv)
+	if err != nil || first == "" {
+		t.Fatalf("Reply() = %q, %v", first, err)
+	}
+	if again, _ := mock.Reply(ctx, conv); again != first {
+		t.Errorf("Reply() = %q then %q, want the same reply", first, again)
+	}
+
+	if _, err := mock.Reply(ctx, &model.Conversation{}); err == nil {
+		t.Error("Reply() of an empty conversation succeeded")
+	}
+}
@@ -0,0 +1,109 @@
+package sentiment_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/sentiment"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type scriptedClassifier struct {
+	results map[string]sentiment.Result
+}
+
+func (c *scriptedClassifier) Classify(ctx context.Context, text string) (sentiment.Result, error) {
+	return c.results[text], nil
+}
+
+// memoryStore keeps a single conversation's moving average
+type memoryStore struct {
+	score  float64
+	labels map[primitive.ObjectID]string
+}
+
+func (s *memoryStore) RecordMessageSentiment(ctx context.Context, conversationID, messageID primitive.ObjectID, label string, score, alpha float64) (float64, float64, error) {
+	before := s.score
+	s.score = model.BlendSentiment(before, len(s.labels), score, alpha)
+	s.labels[messageID] = label
+	return before, s.score, nil
+}
+
+// eventRecorder reports recorded metrics in the order the tracker records them
+type eventRecorder struct {
+	events chan string
+}
+
+func (r *eventRecorder) RecordMessageSentiment(ctx context.Context, platform, sentiment string) {
+	r.events <- sentiment
+}
+
+func (r *eventRecorder) RecordNegativeConversation(ctx context.Context, platform string) {
+	r.events <- "turned negative"
+}
+
+func TestBlendSentiment(t *testing.T) {
+	if got := model.BlendSentiment(0, 0, -0.8, 0.3); got != -0.8 {
+		t.Errorf("first sample = %v, want the sample itself", got)
+	}
+	if got := model.BlendSentiment(0.5, 3, -0.5, 0.3); math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("blended score = %v, want 0.2", got)
+	}
+}
+
+func TestTracker_AlertsOnceWhenConversationTurnsNegative(t *testing.T) {
+	classifier := &scriptedClassifier{results: map[string]sentiment.Result{
+		"thanks":        {Sentiment: model.SentimentPositive, Score: 0.6},
+		"this is wrong": {Sentiment: model.SentimentNegative, Score: -0.9},
+		"useless":       {Sentiment: model.SentimentNegative, Score: -1},
+	}}
+	store := &memoryStore{labels: map[primitive.ObjectID]string{}}
+	recorder := &eventRecorder{events: make(chan string)}
+	tracker := sentiment.NewTracker(classifier, store, recorder, sentiment.Config{SampleRate: 1, Alpha: 0.5})
+
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram"}
+	if tracker.Observe(conv, &model.Message{ID: primitive.NewObjectID(), Role: model.RoleAssistant, Content: "useless"}) {
+		t.Error("Observe() queued an assistant message")
+	}
+	for _, text := range []string{"thanks", "this is wrong", "useless"} {
+		if !tracker.Observe(conv, &model.Message{ID: primitive.NewObjectID(), Role: model.RoleUser, Content: text}) {
+			t.Fatalf("Observe(%q) was not queued", text)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go tracker.Run(ctx)
+
+	// 0.6 -> -0.15 -> -0.575: only the last message crosses the default -0.4 threshold
+	want := []string{model.SentimentPositive, model.SentimentNegative, model.SentimentNegative, "turned negative"}
+	for _, w := range want {
+		select {
+		case got := <-recorder.events:
+			if got != w {
+				t.Fatalf("recorded %q, want %q", got, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %q", w)
+		}
+	}
+	cancel()
+
+	if len(store.labels) != 3 {
+		t.Errorf("stored %d labels, want 3", len(store.labels))
+	}
+	if store.score >= tracker.Threshold() {
+		t.Errorf("score = %v, want below %v", store.score, tracker.Threshold())
+	}
+}
+
+func TestTracker_SampleRateZero(t *testing.T) {
+	tracker := sentiment.NewTracker(&scriptedClassifier{}, nil, nil, sentiment.Config{SampleRate: 0})
+	conv := &model.Conversation{ID: primitive.NewObjectID()}
+	if tracker.Observe(conv, &model.Message{Role: model.RoleUser, Content: "hello"}) {
+		t.Error("Observe() queued a message with a zero sample rate")
+	}
+}
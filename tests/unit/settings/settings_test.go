@@ -0,0 +1,138 @@
+package settings_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+)
+
+func TestSettings_Validate(t *testing.T) {
+	valid := []settings.Settings{
+		{},
+		{Language: "pt-BR", Units: settings.UnitsImperial, Timezone: "America/New_York", Verbosity: settings.VerbosityConcise},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error = %v", s, err)
+		}
+	}
+
+	invalid := []settings.Settings{
+		{Language: "Spanish"},
+		{Units: "kelvin"},
+		{Timezone: "Mars/Olympus"},
+		{Verbosity: "chatty"},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); !errors.Is(err, settings.ErrInvalid) {
+			t.Errorf("Validate(%+v) error = %v, want ErrInvalid", s, err)
+		}
+	}
+}
+
+func TestSettings_Defaults(t *testing.T) {
+	var none *settings.Settings
+	if none.UnitSystem() != settings.UnitsMetric {
+		t.Errorf("default units = %q, want metric", none.UnitSystem())
+	}
+	if none.Location() != time.Local {
+		t.Errorf("default location = %v, want the server's timezone", none.Location())
+	}
+	if none.Instructions() != "" {
+		t.Errorf("default instructions = %q, want none", none.Instructions())
+	}
+
+	s := &settings.Settings{Units: settings.UnitsImperial, Timezone: "Europe/Madrid", Verbosity: settings.VerbosityDetailed}
+	if s.Location().String() != "Europe/Madrid" {
+		t.Errorf("location = %v, want Europe/Madrid", s.Location())
+	}
+	instructions := s.Instructions()
+	for _, want := range []string{"imperial", "Europe/Madrid", "detailed"} {
+		if !strings.Contains(instructions, want) {
+			t.Errorf("instructions %q do not mention %q", instructions, want)
+		}
+	}
+
+	ctx := settings.WithSettings(context.Background(), s)
+	if settings.FromContext(ctx) != s || settings.FromContext(context.Background()) != nil {
+		t.Error("settings are not carried by the context")
+	}
+}
+
+type memoryRepository struct {
+	stored map[string]*settings.Settings
+	reads  int
+}
+
+func (r *memoryRepository) GetSettings(ctx context.Context, platform, userID string) (*settings.Settings, error) {
+	r.reads++
+	s, ok := r.stored[platform+":"+userID]
+	if !ok {
+		return nil, settings.ErrNotFound
+	}
+	return s, nil
+}
+
+func (r *memoryRepository) SaveSettings(ctx context.Context, s *settings.Settings) error {
+	r.stored[s.Platform+":"+s.UserID] = s
+	return nil
+}
+
+type memoryCache struct {
+	values map[string]settings.Settings
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, dest interface{}) error {
+	v, ok := c.values[key]
+	if !ok {
+		return redisx.ErrCacheMiss
+	}
+	*dest.(*settings.Settings) = v
+	return nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value interface{}) error {
+	c.values[key] = *value.(*settings.Settings)
+	return nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryRepository{stored: map[string]*settings.Settings{}}
+	svc := settings.NewService(repo, &memoryCache{values: map[string]settings.Settings{}})
+
+	got, err := svc.Get(ctx, "telegram", "u1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Units != "" || got.UserID != "u1" {
+		t.Errorf("Get() = %+v, want empty settings for the user", got)
+	}
+
+	if _, err := svc.Set(ctx, &settings.Settings{Platform: "telegram", UserID: "u1", Units: "kelvin"}); !errors.Is(err, settings.ErrInvalid) {
+		t.Fatalf("Set() with invalid units error = %v, want ErrInvalid", err)
+	}
+
+	saved, err := svc.Set(ctx, &settings.Settings{Platform: "telegram", UserID: "u1", Units: settings.UnitsImperial})
+	if err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if saved.UpdatedAt.IsZero() {
+		t.Error("Set() did not stamp UpdatedAt")
+	}
+
+	// The cache is refreshed on write, so reads do not reach the repository
+	reads := repo.reads
+	got, err = svc.Get(ctx, "telegram", "u1")
+	if err != nil || got.Units != settings.UnitsImperial {
+		t.Errorf("Get() after Set() = %+v, %v; want imperial units", got, err)
+	}
+	if repo.reads != reads {
+		t.Error("Get() after Set() read the repository instead of the cache")
+	}
+}
@@ -0,0 +1,44 @@
+package smalltalk_test
+
+import (
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/smalltalk"
+)
+
+func TestClassifier_Simple(t *testing.T) {
+	tests := []struct {
+		name          string
+		message       string
+		previousReply string
+		want          bool
+	}{
+		{"thanks", "Thanks!", "It is 18°C and sunny in Barcelona.", true},
+		{"longer thanks", "Thank you so much, that's very helpful", "", true},
+		{"greeting", "hey", "", true},
+		{"goodbye", "ok bye, have a good day", "", true},
+		{"other language", "Спасибо", "", true},
+		{"emoji", "👍", "", true},
+		{"question", "ok?", "", false},
+		{"request", "thanks, and the weather in Paris", "", false},
+		{"answer to a question", "yes", "Shall I check the forecast for tomorrow?", false},
+		{"too long", "thanks thanks thanks thanks thanks thanks thanks thanks", "", false},
+		{"number", "42", "", false},
+		{"empty", "  ", "", false},
+	}
+
+	classifier := smalltalk.NewClassifier(40)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.Simple(tt.message, tt.previousReply); got != tt.want {
+				t.Errorf("Simple(%q, %q) = %v, want %v", tt.message, tt.previousReply, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nil classifier sends tools", func(t *testing.T) {
+		if smalltalk.NewClassifier(0).Simple("thanks", "") {
+			t.Error("expected a disabled classifier to treat small talk as needing tools")
+		}
+	})
+}
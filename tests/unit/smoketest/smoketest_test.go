@@ -0,0 +1,160 @@
+package smoketest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/doctor"
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/smoketest"
+	"github.com/twitchtv/twirp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeChat is a deployment that stores a single conversation
+type fakeChat struct {
+	pb.ChatService
+
+	mu       sync.Mutex
+	conv     *pb.Conversation
+	reply    string
+	apiKeys  []string
+	polls    int
+	failList bool
+}
+
+func (f *fakeChat) add(role pb.Conversation_Role, content string) {
+	f.conv.Messages = append(f.conv.Messages, &pb.Conversation_Message{
+		Id: primitive.NewObjectID().Hex(), Role: role, Content: content,
+	})
+}
+
+func (f *fakeChat) StartConversation(ctx context.Context, req *pb.StartConversationRequest) (*pb.StartConversationResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conv = &pb.Conversation{Id: primitive.NewObjectID().Hex(), Title: "Weather in Barcelona"}
+	f.add(pb.Conversation_USER, req.GetMessage())
+	f.add(pb.Conversation_ASSISTANT, f.reply)
+	return &pb.StartConversationResponse{ConversationId: f.conv.Id, Title: f.conv.Title, Reply: f.reply}, nil
+}
+
+func (f *fakeChat) ContinueConversation(ctx context.Context, req *pb.ContinueConversationRequest) (*pb.ContinueConversationResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.add(pb.Conversation_USER, req.GetMessage())
+	f.add(pb.Conversation_ASSISTANT, f.reply)
+	return &pb.ContinueConversationResponse{Reply: f.reply}, nil
+}
+
+func (f *fakeChat) ListConversations(ctx context.Context, req *pb.ListConversationsRequest) (*pb.ListConversationsResponse, error) {
+	if f.failList {
+		return nil, twirp.InternalError("database unavailable")
+	}
+	return &pb.ListConversationsResponse{Conversations: []*pb.Conversation{{Id: primitive.NewObjectID().Hex()}, {Id: f.conv.Id}}}, nil
+}
+
+func (f *fakeChat) DescribeConversation(ctx context.Context, req *pb.DescribeConversationRequest) (*pb.DescribeConversationResponse, error) {
+	return &pb.DescribeConversationResponse{Conversation: f.conv}, nil
+}
+
+func (f *fakeChat) RequestDataExport(ctx context.Context, req *pb.RequestDataExportRequest) (*pb.RequestDataExportResponse, error) {
+	return &pb.RequestDataExportResponse{Export: &pb.DataExport{Id: "export-1", Status: "pending"}}, nil
+}
+
+func (f *fakeChat) GetDataExport(ctx context.Context, req *pb.GetDataExportRequest) (*pb.GetDataExportResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls++
+	if f.polls < 2 {
+		return &pb.GetDataExportResponse{Export: &pb.DataExport{Id: "export-1", Status: "pending"}}, nil
+	}
+	return &pb.GetDataExportResponse{Export: &pb.DataExport{Id: "export-1", Status: "ready", DownloadUrl: "/takeout/export-1?sig=abc"}}, nil
+}
+
+func newDeployment(t *testing.T, chat *fakeChat) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(pb.ChatServicePathPrefix, pb.NewChatServiceServer(chat))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy","checks":{"mongodb":"ok"}}`))
+	})
+	mux.HandleFunc("/takeout/export-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chat.mu.Lock()
+		chat.apiKeys = append(chat.apiKeys, r.Header.Get("X-API-Key"))
+		chat.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func run(server *httptest.Server) []doctor.Result {
+	checks := smoketest.Checks(smoketest.Config{
+		BaseURL:      server.URL,
+		APIKey:       "secret",
+		PollInterval: time.Millisecond,
+	})
+	return doctor.Run(context.Background(), checks, 5*time.Second)
+}
+
+func TestChecks_HealthyDeployment(t *testing.T) {
+	chat := &fakeChat{reply: "It's 21°C and sunny."}
+	results := run(newDeployment(t, chat))
+
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+		if r.Status != doctor.StatusOK {
+			t.Errorf("%s: %s (%s)", r.Name, r.Status, r.Detail)
+		}
+	}
+	if got := strings.Join(names, ","); got != "health,start,continue #1,continue #2,list,describe,export" {
+		t.Errorf("steps = %s", got)
+	}
+	for _, key := range chat.apiKeys {
+		if key != "secret" {
+			t.Fatalf("request sent with API key %q", key)
+		}
+	}
+}
+
+func TestChecks_Failures(t *testing.T) {
+	chat := &fakeChat{reply: "Sorry, I cannot check that right now.", failList: true}
+	results := run(newDeployment(t, chat))
+
+	statuses := make(map[string]string)
+	for _, r := range results {
+		statuses[r.Name] = r.Status
+	}
+	if statuses["start"] != doctor.StatusWarn {
+		t.Errorf("start = %s, want a warning for a reply without figures", statuses["start"])
+	}
+	if statuses["list"] != doctor.StatusFail {
+		t.Errorf("list = %s, want fail", statuses["list"])
+	}
+	if !doctor.Failed(results) {
+		t.Error("Failed() = false")
+	}
+}
+
+func TestChecks_SkipsWithoutConversation(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	results := run(server)
+	for _, r := range results[2:] {
+		if r.Status != doctor.StatusSkip {
+			t.Errorf("%s = %s, want skip after start failed", r.Name, r.Status)
+		}
+	}
+	if results[0].Status != doctor.StatusFail || results[1].Status != doctor.StatusFail {
+		t.Errorf("health = %s, start = %s, want fail", results[0].Status, results[1].Status)
+	}
+}
@@ -0,0 +1,141 @@
+package stats_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/stats"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+type window struct {
+	granularity string
+	from, to    time.Time
+}
+
+type memoryStore struct {
+	latest       time.Time
+	buckets      []*stats.Bucket
+	materialized []window
+}
+
+func (s *memoryStore) Materialize(_ context.Context, granularity string, from, to time.Time) error {
+	s.materialized = append(s.materialized, window{granularity, from, to})
+	return nil
+}
+
+func (s *memoryStore) LatestBucket(context.Context, string) (time.Time, error) {
+	return s.latest, nil
+}
+
+func (s *memoryStore) Buckets(_ context.Context, granularity, tenantID string, from, to time.Time) ([]*stats.Bucket, error) {
+	var out []*stats.Bucket
+	for _, b := range s.buckets {
+		if b.Granularity == granularity && b.TenantID == tenantID && !b.Start.Before(from) && b.Start.Before(to) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func TestService_Refresh(t *testing.T) {
+	now := time.Date(2024, 5, 10, 14, 25, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		latest   time.Time
+		wantFrom time.Time
+	}{
+		{"first run backfills", time.Time{}, time.Date(2024, 5, 3, 14, 0, 0, 0, time.UTC)},
+		{"recomputes the latest buckets", time.Date(2024, 5, 10, 13, 0, 0, 0, time.UTC), time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)},
+		{"long outage is capped", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 3, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{latest: tt.latest}
+			if err := stats.NewService(store, 7*24*time.Hour).Refresh(context.Background(), now); err != nil {
+				t.Fatalf("Refresh() error = %v", err)
+			}
+			if len(store.materialized) != 2 {
+				t.Fatalf("materialized %d windows, want hourly and daily", len(store.materialized))
+			}
+			hourly, daily := store.materialized[0], store.materialized[1]
+			if hourly.granularity != stats.Hourly || !hourly.from.Equal(tt.wantFrom) || !hourly.to.Equal(now) {
+				t.Errorf("hourly window = %+v, want from %s", hourly, tt.wantFrom)
+			}
+			wantDay := time.Date(tt.wantFrom.Year(), tt.wantFrom.Month(), tt.wantFrom.Day(), 0, 0, 0, 0, time.UTC)
+			if daily.granularity != stats.Daily || !daily.from.Equal(wantDay) {
+				t.Errorf("daily window = %+v, want from %s", daily, wantDay)
+			}
+		})
+	}
+}
+
+func TestService_Series(t *testing.T) {
+	start := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	store := &memoryStore{buckets: []*stats.Bucket{
+		{Granularity: stats.Hourly, TenantID: "acme", Start: start.Add(time.Hour), Requests: 5, Errors: 1, ActiveUsers: 2},
+		{Granularity: stats.Hourly, TenantID: "other", Start: start.Add(2 * time.Hour), Requests: 9},
+		{Granularity: stats.Daily, TenantID: "acme", Start: start, Requests: 5},
+	}}
+	ctx := tenant.WithTenant(context.Background(), "acme")
+
+	series, err := stats.NewService(store, 0).Series(ctx, stats.Hourly, start.Add(30*time.Minute), start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Series() error = %v", err)
+	}
+	if !series.From.Equal(start) || len(series.Points) != 3 {
+		t.Fatalf("series from %s with %d points, want from %s with 3", series.From, len(series.Points), start)
+	}
+	for i, want := range []int64{0, 5, 0} {
+		if !series.Points[i].Start.Equal(start.Add(time.Duration(i) * time.Hour)) {
+			t.Errorf("point %d starts at %s", i, series.Points[i].Start)
+		}
+		if series.Points[i].Requests != want {
+			t.Errorf("point %d requests = %d, want %d", i, series.Points[i].Requests, want)
+		}
+	}
+
+	if _, err := stats.NewService(store, 0).Series(ctx, "minute", start, start.Add(time.Hour)); err != stats.ErrInvalidGranularity {
+		t.Errorf("Series(minute) error = %v, want ErrInvalidGranularity", err)
+	}
+}
+
+func TestAdminHandler_Series(t *testing.T) {
+	handler := stats.NewAdminHandler(stats.NewService(&memoryStore{}, 0))
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantPoints int
+	}{
+		{"", http.StatusOK, 25}, // The last 24 hours, the current one included
+		{"?granularity=day&from=2024-05-01T00:00:00Z&to=2024-05-08T00:00:00Z", http.StatusOK, 7},
+		{"?granularity=minute", http.StatusBadRequest, 0},
+		{"?from=yesterday", http.StatusBadRequest, 0},
+		{"?from=2024-05-08T00:00:00Z&to=2024-05-01T00:00:00Z", http.StatusBadRequest, 0},
+		{"?granularity=hour&from=2024-01-01T00:00:00Z&to=2024-05-01T00:00:00Z", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.SeriesHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/analytics/usage"+tt.query, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%q: status = %d, want %d (%s)", tt.query, rec.Code, tt.wantStatus, rec.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var series stats.Series
+		if err := json.NewDecoder(rec.Body).Decode(&series); err != nil {
+			t.Fatalf("%q: decode error = %v", tt.query, err)
+		}
+		if len(series.Points) != tt.wantPoints {
+			t.Errorf("%q: %d points, want %d", tt.query, len(series.Points), tt.wantPoints)
+		}
+	}
+}
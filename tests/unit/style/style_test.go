@@ -0,0 +1,65 @@
+package style_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/pb"
+	"github.com/8adimka/Go_AI_Assistant/internal/style"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []*style.Style{
+		nil,
+		{},
+		{MaxLength: 160, Format: style.FormatBullets, ReadingLevel: style.ReadingLevelSimple},
+		{Format: style.FormatProse, ReadingLevel: style.ReadingLevelExpert},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) error = %v", s, err)
+		}
+	}
+
+	invalid := []*style.Style{
+		{MaxLength: 10},
+		{MaxLength: -1},
+		{MaxLength: style.MaxMaxLength + 1},
+		{Format: "table"},
+		{ReadingLevel: "child"},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); !errors.Is(err, style.ErrInvalid) {
+			t.Errorf("Validate(%+v) error = %v, want ErrInvalid", s, err)
+		}
+	}
+}
+
+func TestInstructions(t *testing.T) {
+	var none *style.Style
+	if got := none.Instructions(); got != "" {
+		t.Errorf("nil style instructions = %q, want none", got)
+	}
+	if got := (&style.Style{ReadingLevel: style.ReadingLevelStandard}).Instructions(); got != "" {
+		t.Errorf("default style instructions = %q, want none", got)
+	}
+
+	got := style.FromProto(&pb.ReplyStyle{MaxLength: 160, Format: "bullets", ReadingLevel: "simple"}).Instructions()
+	for _, want := range []string{"160 characters", "bullet points", "simple words"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Instructions() = %q, want it to mention %q", got, want)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if style.FromContext(context.Background()) != nil {
+		t.Error("FromContext() of an empty context is not nil")
+	}
+	s := &style.Style{Format: style.FormatProse}
+	if got := style.FromContext(style.WithStyle(context.Background(), s)); got != s {
+		t.Errorf("FromContext() = %+v, want %+v", got, s)
+	}
+}
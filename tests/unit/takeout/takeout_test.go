@@ -0,0 +1,233 @@
+package takeout_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/objectstore"
+	"github.com/8adimka/Go_AI_Assistant/internal/takeout"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryRepository struct {
+	mu      sync.Mutex
+	exports map[string]*takeout.Export
+	updated chan *takeout.Export
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{exports: map[string]*takeout.Export{}, updated: make(chan *takeout.Export, 10)}
+}
+
+func (r *memoryRepository) CreateExport(ctx context.Context, e *takeout.Export) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *e
+	r.exports[e.ID.Hex()] = &copied
+	return nil
+}
+
+func (r *memoryRepository) UpdateExport(ctx context.Context, e *takeout.Export) error {
+	r.mu.Lock()
+	copied := *e
+	r.exports[e.ID.Hex()] = &copied
+	r.mu.Unlock()
+	r.updated <- &copied
+	return nil
+}
+
+func (r *memoryRepository) GetExport(ctx context.Context, id string) (*takeout.Export, error) {
+	e, err := r.GetExportByID(ctx, id)
+	if err != nil || e.TenantID != tenant.FromContext(ctx) {
+		return nil, takeout.ErrNotFound
+	}
+	return e, nil
+}
+
+func (r *memoryRepository) GetExportByID(ctx context.Context, id string) (*takeout.Export, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.exports[id]
+	if !ok {
+		return nil, takeout.ErrNotFound
+	}
+	copied := *e
+	return &copied, nil
+}
+
+func (r *memoryRepository) PendingExport(ctx context.Context, platform, userID string, since time.Time) (*takeout.Export, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.exports {
+		if e.Platform == platform && e.UserID == userID && e.Status == takeout.StatusPending && e.CreatedAt.After(since) {
+			copied := *e
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+type stubConversations struct {
+	conversations []*model.Conversation
+}
+
+func (s *stubConversations) ListUserConversations(ctx context.Context, platform, userID string) ([]*model.Conversation, error) {
+	return s.conversations, nil
+}
+
+type recordingNotifier struct {
+	links chan string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, e *takeout.Export, downloadURL string) (bool, error) {
+	n.links <- downloadURL
+	return true, nil
+}
+
+func TestSigner(t *testing.T) {
+	signer, err := takeout.NewSigner("https://chat.example.com/", "secret")
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	link, err := url.Parse(signer.URL("abc", expires))
+	if err != nil {
+		t.Fatalf("invalid link: %v", err)
+	}
+	if link.Host != "chat.example.com" || link.Path != "/takeout/abc" {
+		t.Fatalf("unexpected link %s", link)
+	}
+
+	query := link.Query()
+	if err := signer.Verify("abc", query.Get("expires"), query.Get("signature"), time.Now()); err != nil {
+		t.Errorf("valid link rejected: %v", err)
+	}
+	if err := signer.Verify("abd", query.Get("expires"), query.Get("signature"), time.Now()); !errors.Is(err, takeout.ErrInvalidLink) {
+		t.Errorf("link for another export accepted: %v", err)
+	}
+	if err := signer.Verify("abc", query.Get("expires"), query.Get("signature"), expires.Add(time.Second)); !errors.Is(err, takeout.ErrInvalidLink) {
+		t.Errorf("expired link accepted: %v", err)
+	}
+
+	other, _ := takeout.NewSigner("https://chat.example.com", "other")
+	if err := other.Verify("abc", query.Get("expires"), query.Get("signature"), time.Now()); !errors.Is(err, takeout.ErrInvalidLink) {
+		t.Errorf("link signed with another key accepted: %v", err)
+	}
+
+	if _, err := takeout.NewSigner("https://chat.example.com", ""); err == nil {
+		t.Error("expected an error without a signing key")
+	}
+}
+
+func TestService_Export(t *testing.T) {
+	store, err := objectstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	signer, _ := takeout.NewSigner("http://localhost", "secret")
+	repo := newMemoryRepository()
+	notifier := &recordingNotifier{links: make(chan string, 1)}
+	conversations := &stubConversations{conversations: []*model.Conversation{{
+		ID:       primitive.NewObjectID(),
+		Title:    "Weather",
+		Platform: "telegram",
+		UserID:   "42",
+		Messages: []*model.Message{
+			{Role: model.RoleUser, Content: "Is it sunny?"},
+			{Role: model.RoleAssistant, Content: "Yes"},
+		},
+	}}}
+
+	svc := takeout.NewService(repo, store, signer, notifier, takeout.Config{LinkTTL: time.Hour},
+		takeout.ConversationSource{Conversations: conversations})
+
+	ctx, cancel := context.WithCancel(tenant.WithTenant(context.Background(), "acme"))
+	defer cancel()
+	go svc.Run(ctx)
+
+	export, err := svc.Request(ctx, "telegram", "42", "100")
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if export.Status != takeout.StatusPending || export.TenantID != "acme" {
+		t.Fatalf("unexpected export %+v", export)
+	}
+
+	// A second request while the first is pending returns the same export
+	again, err := svc.Request(ctx, "telegram", "42", "100")
+	if err != nil || again.ID != export.ID {
+		t.Fatalf("expected the pending export, got %v, %v", again, err)
+	}
+
+	var link string
+	select {
+	case link = <-notifier.links:
+	case <-time.After(5 * time.Second):
+		t.Fatal("export was not generated")
+	}
+
+	got, downloadURL, err := svc.Get(ctx, export.ID.Hex(), "telegram", "42")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != takeout.StatusReady || downloadURL == "" {
+		t.Fatalf("expected a ready export with a link, got %+v %q", got, downloadURL)
+	}
+	if _, _, err := svc.Get(ctx, export.ID.Hex(), "telegram", "43"); !errors.Is(err, takeout.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+	if _, _, err := svc.Get(tenant.WithTenant(context.Background(), "other"), export.ID.Hex(), "telegram", "42"); !errors.Is(err, takeout.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another tenant, got %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/takeout/{export_id}", svc.DownloadHandler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(link, "http://localhost"), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("download status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("invalid archive: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	if _, ok := files["manifest.json"]; !ok {
+		t.Error("archive has no manifest")
+	}
+	var exported []map[string]any
+	if err := json.Unmarshal(files["conversations.json"], &exported); err != nil {
+		t.Fatalf("invalid conversations.json: %v", err)
+	}
+	if len(exported) != 1 || exported[0]["title"] != "Weather" || len(exported[0]["messages"].([]any)) != 2 {
+		t.Errorf("unexpected conversations %v", exported)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/takeout/"+export.ID.Hex()+"?expires=9999999999&signature=bad", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("forged link status = %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package tenant_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/secrets"
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+type memoryCredentialRepository struct {
+	stored map[string]*tenant.EncryptedCredentials
+	reads  int
+}
+
+func (r *memoryCredentialRepository) GetCredentials(ctx context.Context, tenantID string) (*tenant.EncryptedCredentials, error) {
+	r.reads++
+	return r.stored[tenantID], nil
+}
+
+func (r *memoryCredentialRepository) SaveCredentials(ctx context.Context, creds *tenant.EncryptedCredentials) error {
+	r.stored[creds.TenantID] = creds
+	return nil
+}
+
+func (r *memoryCredentialRepository) DeleteCredentials(ctx context.Context, tenantID string) error {
+	delete(r.stored, tenantID)
+	return nil
+}
+
+func newKeyManager(t *testing.T) (*tenant.KeyManager, *memoryCredentialRepository) {
+	t.Helper()
+	cipher, err := secrets.NewCipher(bytes.Repeat([]byte{7}, secrets.KeySize))
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	repo := &memoryCredentialRepository{stored: make(map[string]*tenant.EncryptedCredentials)}
+	return tenant.NewKeyManager(repo, cipher, time.Minute), repo
+}
+
+func TestKeyManager_StoresKeysEncrypted(t *testing.T) {
+	keys, repo := newKeyManager(t)
+	ctx := context.Background()
+
+	if err := keys.Set(ctx, "acme", &tenant.Credentials{OpenAIAPIKey: "sk-acme"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	stored := repo.stored["acme"]
+	if stored == nil || stored.OpenAIAPIKey == "" || stored.OpenAIAPIKey == "sk-acme" {
+		t.Fatalf("expected the OpenAI key to be stored encrypted, got %+v", stored)
+	}
+	if stored.WeatherAPIKey != "" {
+		t.Errorf("expected no weather key, got %q", stored.WeatherAPIKey)
+	}
+
+	creds, err := keys.Get(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if creds.OpenAIAPIKey != "sk-acme" {
+		t.Errorf("Get() OpenAI key = %q, want sk-acme", creds.OpenAIAPIKey)
+	}
+}
+
+func TestKeyManager_CachesAndInvalidates(t *testing.T) {
+	keys, repo := newKeyManager(t)
+	ctx := context.Background()
+
+	if creds, _ := keys.Get(ctx, "acme"); creds != nil {
+		t.Fatalf("expected no credentials, got %+v", creds)
+	}
+	keys.Get(ctx, "acme")
+	if repo.reads != 1 {
+		t.Errorf("expected missing credentials to be cached, got %d reads", repo.reads)
+	}
+
+	keys.Set(ctx, "acme", &tenant.Credentials{OpenAIAPIKey: "sk-acme"})
+	if creds, _ := keys.Get(ctx, "acme"); creds == nil || creds.OpenAIAPIKey != "sk-acme" {
+		t.Errorf("expected updated credentials after Set, got %+v", creds)
+	}
+
+	keys.Delete(ctx, "acme")
+	if creds, _ := keys.Get(ctx, "acme"); creds != nil {
+		t.Errorf("expected no credentials after Delete, got %+v", creds)
+	}
+}
+
+func TestKeyManager_Attach(t *testing.T) {
+	keys, _ := newKeyManager(t)
+	keys.Set(context.Background(), "acme", &tenant.Credentials{WeatherAPIKey: "w-acme"})
+
+	ctx := keys.Attach(tenant.WithTenant(context.Background(), "acme"))
+	creds := tenant.CredentialsFromContext(ctx)
+	if creds == nil || creds.WeatherAPIKey != "w-acme" {
+		t.Errorf("expected acme credentials in context, got %+v", creds)
+	}
+
+	ctx = keys.Attach(tenant.WithTenant(context.Background(), "globex"))
+	if creds := tenant.CredentialsFromContext(ctx); creds != nil {
+		t.Errorf("expected platform keys for a tenant without credentials, got %+v", creds)
+	}
+
+	ctx = keys.Attach(context.Background())
+	if creds := tenant.CredentialsFromContext(ctx); creds != nil {
+		t.Errorf("expected platform keys for the default tenant, got %+v", creds)
+	}
+}
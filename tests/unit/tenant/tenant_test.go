@@ -0,0 +1,103 @@
+package tenant_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+)
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := tenant.FromContext(ctx); got != tenant.DefaultID {
+		t.Errorf("expected default tenant, got %q", got)
+	}
+
+	ctx = tenant.WithTenant(ctx, "acme")
+	if got := tenant.FromContext(ctx); got != "acme" {
+		t.Errorf("expected acme, got %q", got)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		tenant string
+		want   string
+	}{
+		{"default tenant keeps legacy keys", tenant.DefaultID, "session:telegram:1"},
+		{"other tenants are prefixed", "acme", "t:acme:session:telegram:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tenant.WithTenant(context.Background(), tt.tenant)
+			if got := tenant.Key(ctx, "session:telegram:1"); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Middleware(t *testing.T) {
+	resolver := tenant.NewResolver([]string{"globex"}, map[string]string{"acme": "acme-secret"})
+
+	var seen string
+	handler := resolver.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = tenant.FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		header     string
+		key        string
+		wantStatus int
+		wantTenant string
+	}{
+		{"no header uses default tenant", "", "", http.StatusOK, tenant.DefaultID},
+		{"tenant with its key", "acme", "acme-secret", http.StatusOK, "acme"},
+		{"tenant without its key", "acme", "", http.StatusForbidden, ""},
+		{"tenant with a wrong key", "acme", "globex-secret", http.StatusForbidden, ""},
+		{"trusted tenant", "globex", "", http.StatusOK, "globex"},
+		{"unknown tenant", "initech", "", http.StatusForbidden, ""},
+		{"malformed tenant", "../acme", "", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = ""
+			req := httptest.NewRequest(http.MethodPost, "/twirp/x", nil)
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+			if tt.key != "" {
+				req.Header.Set(tenant.KeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if seen != tt.wantTenant {
+				t.Errorf("expected tenant %q, got %q", tt.wantTenant, seen)
+			}
+		})
+	}
+}
+
+func TestResolver_DefaultOnly(t *testing.T) {
+	resolver := tenant.NewResolver(nil, nil)
+
+	// The tenant header is chosen by the caller; without keys or trusted tenants it cannot name one
+	if resolver.Allowed("any-tenant_1", "") {
+		t.Error("expected other tenants to be refused without access keys or trusted tenants")
+	}
+	if !resolver.Allowed(tenant.DefaultID, "") {
+		t.Error("expected the default tenant to be allowed")
+	}
+}
@@ -0,0 +1,160 @@
+package tlsx_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tlsx"
+)
+
+// testPKI holds a CA plus server and client certificates written to a temp dir
+type testPKI struct {
+	caFile, serverCert, serverKey, clientCert, clientKey string
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("failed to issue %s certificate: %v", name, err)
+		}
+		keyDER, _ := x509.MarshalECPrivateKey(key)
+		certFile := writePEM(t, dir, name+".crt", "CERTIFICATE", der)
+		keyFile := writePEM(t, dir, name+".key", "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+
+	pki := testPKI{caFile: writePEM(t, dir, "ca.crt", "CERTIFICATE", caDER)}
+	pki.serverCert, pki.serverKey = issue("server", 2, x509.ExtKeyUsageServerAuth)
+	pki.clientCert, pki.clientKey = issue("client", 3, x509.ExtKeyUsageClientAuth)
+	return pki
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	serverTLS, err := tlsx.NewServerTLS(tlsx.ServerConfig{
+		CertFile:     pki.serverCert,
+		KeyFile:      pki.serverKey,
+		ClientCAFile: pki.caFile,
+	})
+	if err != nil {
+		t.Fatalf("failed to build server TLS: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = serverTLS
+	srv.StartTLS()
+	defer srv.Close()
+
+	t.Run("client with certificate is accepted", func(t *testing.T) {
+		client, err := tlsx.NewHTTPClient(tlsx.ClientConfig{
+			CertFile: pki.clientCert,
+			KeyFile:  pki.clientKey,
+			CAFile:   pki.caFile,
+		}, 5*time.Second)
+		if err != nil {
+			t.Fatalf("failed to build client: %v", err)
+		}
+
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("expected mTLS request to succeed, got %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("client without certificate is rejected", func(t *testing.T) {
+		client, err := tlsx.NewHTTPClient(tlsx.ClientConfig{CAFile: pki.caFile}, 5*time.Second)
+		if err != nil {
+			t.Fatalf("failed to build client: %v", err)
+		}
+
+		if resp, err := client.Get(srv.URL); err == nil {
+			resp.Body.Close()
+			t.Fatal("expected handshake failure without client certificate")
+		}
+	})
+}
+
+func TestNewServerTLS_Errors(t *testing.T) {
+	pki := newTestPKI(t)
+
+	if _, err := tlsx.NewServerTLS(tlsx.ServerConfig{CertFile: "missing.crt", KeyFile: "missing.key"}); err == nil {
+		t.Error("expected error for missing certificate files")
+	}
+
+	if _, err := tlsx.NewServerTLS(tlsx.ServerConfig{
+		CertFile:     pki.serverCert,
+		KeyFile:      pki.serverKey,
+		ClientCAFile: pki.caFile,
+		ClientAuth:   "sometimes",
+	}); err == nil {
+		t.Error("expected error for unknown client auth mode")
+	}
+}
+
+func TestNewHTTPClient_PlainWhenNotConfigured(t *testing.T) {
+	client, err := tlsx.NewHTTPClient(tlsx.ClientConfig{}, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.Transport != nil {
+		t.Error("expected default transport when outbound TLS is not configured")
+	}
+}
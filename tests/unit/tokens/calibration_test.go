@@ -0,0 +1,50 @@
+package tokens_test
+
+import (
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tokens"
+)
+
+func TestCalibrator_WarmsUpBeforeAdjusting(t *testing.T) {
+	c := tokens.NewCalibrator(0.5, 2)
+
+	c.Observe(100, 150)
+	if got := c.Adjust(100); got != 100 {
+		t.Errorf("Adjust() during warmup = %d, want the estimate unchanged", got)
+	}
+
+	c.Observe(100, 150)
+	if got := c.Adjust(100); got != 150 {
+		t.Errorf("Adjust() = %d, want 150 after consistent underestimates", got)
+	}
+}
+
+func TestCalibrator_FollowsDrift(t *testing.T) {
+	c := tokens.NewCalibrator(0.5, 0)
+
+	c.Observe(100, 200)
+	c.Observe(100, 100)
+	if got := c.Factor(); got != 1.5 {
+		t.Errorf("Factor() = %v, want the weighted average 1.5", got)
+	}
+	if got := c.Adjust(99); got != 149 {
+		t.Errorf("Adjust(99) = %d, want it rounded up to 149", got)
+	}
+}
+
+func TestCalibrator_IgnoresUselessObservations(t *testing.T) {
+	c := tokens.NewCalibrator(0.5, 0)
+
+	c.Observe(0, 100)
+	c.Observe(100, 0)
+	if c.Samples() != 0 {
+		t.Errorf("Samples() = %d, want empty counts ignored", c.Samples())
+	}
+
+	// One wildly wrong response cannot push the factor beyond its bounds
+	c.Observe(1, 1000)
+	if got := c.Factor(); got != 3 {
+		t.Errorf("Factor() = %v, want it clamped to 3", got)
+	}
+}
@@ -0,0 +1,54 @@
+package tools_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/pinfact"
+)
+
+type memoryPinner struct {
+	facts map[string][]string
+}
+
+func (p *memoryPinner) PinFact(ctx context.Context, conversationID, fact string) error {
+	p.facts[conversationID] = append(p.facts[conversationID], fact)
+	return nil
+}
+
+func TestPinFactTool_Execute(t *testing.T) {
+	pinner := &memoryPinner{facts: map[string][]string{}}
+	tool := pinfact.New(pinner)
+	ctx := pinfact.WithConversation(context.Background(), "conv-1")
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"fact": "  Order number is\n48213 "})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result != "Pinned: Order number is 48213" {
+		t.Errorf("result = %q", result)
+	}
+	if got := pinner.facts["conv-1"]; len(got) != 1 || got[0] != "Order number is 48213" {
+		t.Errorf("pinned facts = %v", got)
+	}
+
+	invalid := []struct {
+		name string
+		ctx  context.Context
+		args map[string]interface{}
+	}{
+		{"missing fact", ctx, map[string]interface{}{}},
+		{"blank fact", ctx, map[string]interface{}{"fact": "   "}},
+		{"long fact", ctx, map[string]interface{}{"fact": strings.Repeat("x", pinfact.MaxFactChars+1)}},
+		{"no conversation", context.Background(), map[string]interface{}{"fact": "Name is Ana"}},
+	}
+	for _, tt := range invalid {
+		if _, err := tool.Execute(tt.ctx, tt.args); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if len(pinner.facts["conv-1"]) != 1 {
+		t.Errorf("invalid calls pinned facts: %v", pinner.facts)
+	}
+}
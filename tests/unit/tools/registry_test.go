@@ -0,0 +1,75 @@
+package tools_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/datetime"
+	"github.com/8adimka/Go_AI_Assistant/internal/tools/registry"
+	"github.com/8adimka/Go_AI_Assistant/internal/weather"
+)
+
+func TestToolRegistry_Describe(t *testing.T) {
+	tools := registry.NewToolRegistry()
+	tools.Register(weather.New(weather.NewFallbackWeatherService(weather.NewWeatherAPIClient("key", nil),
+		weather.NewMockWeatherProvider(), nil)))
+	tools.Register(datetime.New())
+
+	tools.RecordCall("get_weather", nil)
+	tools.RecordCall("get_weather", nil)
+	tools.RecordCall("get_weather", nil)
+	tools.RecordCall("get_weather", errors.New("upstream timeout"))
+
+	infos := tools.Describe()
+	if len(infos) != 2 || infos[0].Name != "get_today_date" || infos[1].Name != "get_weather" {
+		t.Fatalf("Describe() = %+v, want both tools sorted by name", infos)
+	}
+	date, forecast := infos[0], infos[1]
+	if date.RateLimit != nil || date.Recent != (registry.Stats{}) {
+		t.Errorf("date/time tool = %+v, want no limit and no calls", date)
+	}
+	if forecast.Description == "" || forecast.Parameters["type"] != "object" {
+		t.Errorf("weather tool = %+v, want its description and schema", forecast)
+	}
+	if forecast.RateLimit == nil || forecast.RateLimit.PerMinute != 1 || forecast.RateLimit.Burst != 10 {
+		t.Errorf("rate limit = %+v, want the WeatherAPI limit", forecast.RateLimit)
+	}
+	if want := (registry.Stats{Calls: 4, Errors: 1, ErrorRate: 0.25}); forecast.Recent != want {
+		t.Errorf("recent = %+v, want %+v", forecast.Recent, want)
+	}
+}
+
+func TestAdminHandler_List(t *testing.T) {
+	tools := registry.NewToolRegistry()
+	tools.Register(datetime.New())
+	tools.RecordCall("get_today_date", nil)
+
+	rec := httptest.NewRecorder()
+	registry.NewAdminHandler(tools).ListHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/tools", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	var body struct {
+		Tools []struct {
+			Name       string         `json:"name"`
+			Parameters map[string]any `json:"parameters"`
+			RateLimit  any            `json:"rate_limit"`
+			Recent     registry.Stats `json:"recent"`
+		} `json:"tools"`
+		StatsWindowSeconds int `json:"stats_window_seconds"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %s: %v", rec.Body, err)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].Name != "get_today_date" || body.Tools[0].Parameters == nil ||
+		body.Tools[0].RateLimit != nil || body.Tools[0].Recent.Calls != 1 {
+		t.Errorf("tools = %+v", body.Tools)
+	}
+	if body.StatsWindowSeconds != int(registry.StatsWindow.Seconds()) {
+		t.Errorf("stats_window_seconds = %d", body.StatsWindowSeconds)
+	}
+}
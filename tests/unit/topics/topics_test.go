@@ -0,0 +1,107 @@
+package topics_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/chat/model"
+	"github.com/8adimka/Go_AI_Assistant/internal/topics"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestKeywordClassifier(t *testing.T) {
+	classifier := topics.NewKeywordClassifier(nil)
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Will it RAIN in Paris tomorrow?", []string{topics.TopicWeather, topics.TopicScheduling}},
+		{"Hello! How are you?", []string{topics.TopicSmalltalk}},
+		{"The app is not working, I need help", []string{topics.TopicSupport}},
+		{"Book a meeting for next week", []string{topics.TopicScheduling}},
+		{"Explain quantum computing", nil},
+		// Keywords match whole words only
+		{"Which hill is the highest?", nil},
+	}
+	for _, tt := range tests {
+		got, err := classifier.Classify(context.Background(), tt.text)
+		if err != nil {
+			t.Fatalf("Classify(%q) error = %v", tt.text, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Classify(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+// memoryStore keeps the topics of conversations
+type memoryStore struct {
+	topics map[primitive.ObjectID][]string
+}
+
+func (s *memoryStore) AddConversationTopics(ctx context.Context, id primitive.ObjectID, found []string) ([]string, error) {
+	var added []string
+	for _, topic := range found {
+		if !slices.Contains(s.topics[id], topic) {
+			s.topics[id] = append(s.topics[id], topic)
+			added = append(added, topic)
+		}
+	}
+	return added, nil
+}
+
+type topicRecorder struct {
+	events chan string
+}
+
+func (r *topicRecorder) RecordConversationTopic(ctx context.Context, platform, topic string) {
+	r.events <- topic
+}
+
+func TestTagger_CountsConversationsOncePerTopic(t *testing.T) {
+	store := &memoryStore{topics: map[primitive.ObjectID][]string{}}
+	recorder := &topicRecorder{events: make(chan string)}
+	tagger := topics.NewTagger(topics.NewKeywordClassifier(nil), store, recorder, topics.Config{SampleRate: 1})
+
+	conv := &model.Conversation{ID: primitive.NewObjectID(), Platform: "telegram"}
+	if tagger.Observe(conv, &model.Message{Role: model.RoleAssistant, Content: "The forecast says rain"}) {
+		t.Error("Observe() queued an assistant message")
+	}
+	for _, text := range []string{"What's the weather?", "Any rain? Thanks!", "Remind me tomorrow"} {
+		if !tagger.Observe(conv, &model.Message{Role: model.RoleUser, Content: text}) {
+			t.Fatalf("Observe(%q) was not queued", text)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go tagger.Run(ctx)
+
+	want := []string{topics.TopicWeather, topics.TopicSmalltalk, topics.TopicScheduling}
+	for _, w := range want {
+		select {
+		case got := <-recorder.events:
+			if got != w {
+				t.Fatalf("recorded %q, want %q", got, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %q", w)
+		}
+	}
+	cancel()
+
+	if got := store.topics[conv.ID]; !slices.Equal(got, want) {
+		t.Errorf("conversation topics = %v, want %v", got, want)
+	}
+}
+
+func TestTagger_SampleRateZero(t *testing.T) {
+	tagger := topics.NewTagger(topics.NewKeywordClassifier(nil), nil, nil, topics.Config{SampleRate: 0})
+	conv := &model.Conversation{ID: primitive.NewObjectID()}
+	if tagger.Observe(conv, &model.Message{Role: model.RoleUser, Content: "hello"}) {
+		t.Error("Observe() queued a message with a zero sample rate")
+	}
+}
@@ -0,0 +1,290 @@
+package vectorstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/tenant"
+	"github.com/8adimka/Go_AI_Assistant/internal/vectorstore"
+)
+
+func randomRecords(rng *rand.Rand, n, dims int) []vectorstore.Record {
+	records := make([]vectorstore.Record, n)
+	for i := range records {
+		vector := make([]float32, dims)
+		for j := range vector {
+			vector[j] = float32(rng.NormFloat64())
+		}
+		records[i] = vectorstore.Record{
+			ID:       fmt.Sprintf("rec-%d", i),
+			Vector:   vector,
+			Metadata: map[string]string{"parity": []string{"even", "odd"}[i%2]},
+		}
+	}
+	return records
+}
+
+func cosine(a, b []float32) float64 {
+	var ab, aa, bb float64
+	for i := range a {
+		ab += float64(a[i]) * float64(b[i])
+		aa += float64(a[i]) * float64(a[i])
+		bb += float64(b[i]) * float64(b[i])
+	}
+	return ab / math.Sqrt(aa*bb)
+}
+
+// bruteForce returns the IDs of the limit records closest to query
+func bruteForce(records []vectorstore.Record, query []float32, limit int) []string {
+	sorted := slices.Clone(records)
+	slices.SortFunc(sorted, func(a, b vectorstore.Record) int {
+		if cosine(query, a.Vector) > cosine(query, b.Vector) {
+			return -1
+		}
+		return 1
+	})
+	ids := make([]string, 0, limit)
+	for _, rec := range sorted[:limit] {
+		ids = append(ids, rec.ID)
+	}
+	return ids
+}
+
+func TestHNSW_RecallMatchesBruteForce(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewPCG(7, 7))
+	records := randomRecords(rng, 2000, 16)
+	store := vectorstore.NewHNSW(16, 0, 0, 0)
+	if err := store.Upsert(ctx, records); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	const queries, limit = 50, 10
+	hits := 0
+	for range queries {
+		query := randomRecords(rng, 1, 16)[0].Vector
+		found, err := store.Search(ctx, query, limit, nil)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		want := bruteForce(records, query, limit)
+		for _, m := range found {
+			if slices.Contains(want, m.ID) {
+				hits++
+			}
+		}
+		if len(found) > 0 && math.Abs(found[0].Score-cosine(query, found[0].Vector)) > 1e-4 {
+			t.Errorf("score = %f, want the cosine similarity %f", found[0].Score, cosine(query, found[0].Vector))
+		}
+	}
+	if recall := float64(hits) / (queries * limit); recall < 0.9 {
+		t.Errorf("recall = %.2f, want at least 0.9", recall)
+	}
+}
+
+func TestHNSW_FiltersTenantsAndDeletes(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 1))
+	records := randomRecords(rng, 300, 8)
+	store := vectorstore.NewHNSW(8, 0, 0, 0)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	if err := store.Upsert(acme, records); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	query := records[3].Vector
+	found, err := store.Search(acme, query, 5, map[string]string{"parity": "odd"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(found) != 5 || found[0].ID != "rec-3" {
+		t.Fatalf("Search() = %v, want rec-3 first", found)
+	}
+	for _, m := range found {
+		if m.Metadata["parity"] != "odd" || m.TenantID != "acme" {
+			t.Errorf("match %s has parity %s in tenant %s", m.ID, m.Metadata["parity"], m.TenantID)
+		}
+	}
+
+	// Another tenant sees nothing
+	if found, _ := store.Search(context.Background(), query, 5, nil); len(found) != 0 {
+		t.Errorf("default tenant found %d records, want none", len(found))
+	}
+
+	if err := store.Delete(acme, []string{"rec-3", "unknown"}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	found, _ = store.Search(acme, query, 5, nil)
+	for _, m := range found {
+		if m.ID == "rec-3" {
+			t.Error("deleted record is still found")
+		}
+	}
+	if store.Len() != 299 {
+		t.Errorf("Len() = %d, want 299", store.Len())
+	}
+
+	// Upserting an existing ID replaces the record
+	moved := vectorstore.Record{ID: "rec-4", Vector: query, Text: "moved"}
+	if err := store.Upsert(acme, []vectorstore.Record{moved}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	found, _ = store.Search(acme, query, 1, nil)
+	if len(found) != 1 || found[0].ID != "rec-4" || found[0].Text != "moved" || store.Len() != 299 {
+		t.Errorf("Search() = %v with %d records, want the replaced rec-4", found, store.Len())
+	}
+
+	if err := store.Upsert(acme, []vectorstore.Record{{ID: "short", Vector: []float32{1}}}); !errors.Is(err, vectorstore.ErrDimensions) {
+		t.Errorf("Upsert() error = %v, want ErrDimensions", err)
+	}
+}
+
+func TestHNSW_SnapshotAndMigrate(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 3))
+	store := vectorstore.NewHNSW(4, 0, 0, 0)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	store.Upsert(context.Background(), randomRecords(rng, 30, 4))
+	store.Upsert(acme, randomRecords(rng, 20, 4))
+
+	path := filepath.Join(t.TempDir(), "vectors.gob")
+	if err := store.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded := vectorstore.NewHNSW(4, 0, 0, 0)
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Len() != 50 {
+		t.Fatalf("loaded %d records, want 50", loaded.Len())
+	}
+
+	copied := vectorstore.NewHNSW(4, 0, 0, 0)
+	n, err := vectorstore.Migrate(context.Background(), loaded, copied, 7)
+	if err != nil || n != 50 {
+		t.Fatalf("Migrate() = %d, %v, want 50 records", n, err)
+	}
+	found, err := copied.Search(acme, randomRecords(rng, 1, 4)[0].Vector, 100, nil)
+	if err != nil || len(found) != 20 {
+		t.Errorf("acme has %d records after migrating, want 20", len(found))
+	}
+}
+
+// fakeQdrant serves the subset of the Qdrant REST API the store uses
+type fakeQdrant struct {
+	mu     sync.Mutex
+	exists bool
+	points map[string]map[string]any
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("api-key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	reply := func(result any) { json.NewEncoder(w).Encode(map[string]any{"result": result, "status": "ok"}) }
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /collections/vectors":
+		if !f.exists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":{"error":"Collection not found"}}`))
+			return
+		}
+		reply(map[string]any{})
+	case "PUT /collections/vectors":
+		f.exists = true
+		reply(true)
+	case "PUT /collections/vectors/points":
+		for _, p := range body["points"].([]any) {
+			point := p.(map[string]any)
+			f.points[point["id"].(string)] = point
+		}
+		reply(map[string]any{"status": "completed"})
+	case "POST /collections/vectors/points/delete":
+		for _, id := range body["points"].([]any) {
+			delete(f.points, id.(string))
+		}
+		reply(map[string]any{"status": "completed"})
+	case "POST /collections/vectors/points/search":
+		tenantID := body["filter"].(map[string]any)["must"].([]any)[0].(map[string]any)["match"].(map[string]any)["value"]
+		var results []map[string]any
+		for _, point := range f.points {
+			if point["payload"].(map[string]any)["tenant_id"] == tenantID {
+				results = append(results, map[string]any{"id": point["id"], "payload": point["payload"], "vector": point["vector"], "score": 1.0})
+			}
+		}
+		reply(results)
+	case "POST /collections/vectors/points/scroll":
+		var points []map[string]any
+		for _, point := range f.points {
+			points = append(points, point)
+		}
+		reply(map[string]any{"points": points, "next_page_offset": nil})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestQdrant(t *testing.T) {
+	fake := &fakeQdrant{points: make(map[string]map[string]any)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := vectorstore.NewQdrant(server.URL, "secret", "vectors", 2, nil)
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	if err := store.EnsureCollection(ctx); err != nil || !fake.exists {
+		t.Fatalf("EnsureCollection() error = %v, created = %v", err, fake.exists)
+	}
+
+	records := []vectorstore.Record{
+		{ID: "a", Vector: []float32{1, 0}, Text: "alpha", Metadata: map[string]string{"kind": "fact"}},
+		{ID: "b", Vector: []float32{0, 1}},
+	}
+	if err := store.Upsert(ctx, records); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if err := store.Upsert(context.Background(), records[:1]); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if len(fake.points) != 3 {
+		t.Fatalf("stored %d points, want the same ID kept apart per tenant", len(fake.points))
+	}
+
+	found, err := store.Search(ctx, []float32{1, 0}, 5, nil)
+	if err != nil || len(found) != 2 {
+		t.Fatalf("Search() = %v, %v, want the two acme records", found, err)
+	}
+	for _, m := range found {
+		if m.TenantID != "acme" || (m.ID == "a" && m.Text != "alpha") {
+			t.Errorf("match = %+v, want acme records with their payload", m)
+		}
+	}
+
+	if err := store.Delete(ctx, []string{"a"}); err != nil || len(fake.points) != 2 {
+		t.Errorf("Delete() error = %v, %d points left, want 2", err, len(fake.points))
+	}
+
+	scanned := 0
+	if err := store.Scan(ctx, 10, func(batch []vectorstore.Record) error { scanned += len(batch); return nil }); err != nil || scanned != 2 {
+		t.Errorf("Scan() = %d records, %v, want 2", scanned, err)
+	}
+
+	unauthorized := vectorstore.NewQdrant(server.URL, "wrong", "vectors", 2, nil)
+	if _, err := unauthorized.Search(ctx, []float32{1, 0}, 5, nil); err == nil {
+		t.Error("Search() with a wrong API key succeeded")
+	}
+}
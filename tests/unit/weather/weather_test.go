@@ -0,0 +1,88 @@
+package weather_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/8adimka/Go_AI_Assistant/internal/degradation"
+	"github.com/8adimka/Go_AI_Assistant/internal/redisx"
+	"github.com/8adimka/Go_AI_Assistant/internal/settings"
+	"github.com/8adimka/Go_AI_Assistant/internal/weather"
+)
+
+func TestFormatWeatherIn(t *testing.T) {
+	data := &weather.WeatherData{Location: "Boston", Condition: "Sunny", Temperature: 20, FeelsLike: 25, WindSpeed: 10, Visibility: 10, Pressure: 1013}
+
+	metric := weather.FormatWeatherIn(data, settings.UnitsMetric)
+	if !strings.Contains(metric, "20.0°C") || !strings.Contains(metric, "10.0 km/h") {
+		t.Errorf("metric format = %q", metric)
+	}
+
+	imperial := weather.FormatWeatherIn(data, settings.UnitsImperial)
+	for _, want := range []string{"68.0°F", "77.0°F", "6.2 mph", "29.91 inHg", "6.2 mi"} {
+		if !strings.Contains(imperial, want) {
+			t.Errorf("imperial format %q does not contain %q", imperial, want)
+		}
+	}
+}
+
+type failingProvider struct {
+	err   error
+	calls int
+}
+
+func (p *failingProvider) GetCurrent(ctx context.Context, location string) (*weather.WeatherData, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &weather.WeatherData{Location: location, Temperature: 12, Condition: "Rain"}, nil
+}
+
+func (p *failingProvider) GetForecast(ctx context.Context, location string, days int) (*weather.ForecastData, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func TestFallbackWeatherService_StaleWhileDegraded(t *testing.T) {
+	ctx := context.Background()
+	health := degradation.NewManager(degradation.Config{MaxFailures: 1, Cooldown: time.Minute}).Health(degradation.Weather)
+	primary := &failingProvider{}
+	service := weather.NewFallbackWeatherService(primary, weather.NewMockWeatherProvider(),
+		redisx.NewMemoryCache(time.Hour, 100), weather.WithHealth(health))
+
+	if data, err := service.GetCurrentWithFallback(ctx, "Oslo"); err != nil || data.Stale {
+		t.Fatalf("GetCurrentWithFallback() = %+v, %v, want live data", data, err)
+	}
+
+	// Unknown locations are not outages of the weather API
+	primary.err = weather.ErrInvalidLocation
+	service.GetCurrentWithFallback(ctx, "Nowhere")
+	if health.Degraded() {
+		t.Fatal("an invalid location degraded the weather API")
+	}
+
+	primary.err = errors.New("retryable HTTP error: 503 Service Unavailable")
+	data, err := service.GetCurrentWithFallback(ctx, "Oslo")
+	if err != nil || !data.Stale || data.Temperature != 12 {
+		t.Fatalf("GetCurrentWithFallback() = %+v, %v, want the last known data flagged as stale", data, err)
+	}
+	if !strings.Contains(weather.FormatWeatherIn(data, settings.UnitsMetric), "last known report") {
+		t.Error("stale weather is not flagged in the tool output")
+	}
+
+	// While the circuit is open the API is not called at all
+	calls := primary.calls
+	data, _ = service.GetCurrentWithFallback(ctx, "Oslo")
+	if primary.calls != calls || !data.Stale {
+		t.Errorf("degraded weather API called %d more times, stale = %v", primary.calls-calls, data.Stale)
+	}
+
+	// Without last known data, mock data is served
+	if data, err := service.GetCurrentWithFallback(ctx, "Lima"); err != nil || data.Stale || data.Location != "Lima" {
+		t.Errorf("GetCurrentWithFallback() = %+v, %v, want mock data", data, err)
+	}
+}